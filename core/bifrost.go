@@ -6,6 +6,7 @@ package bifrost
 import (
 	"context"
 	"fmt"
+	"maps"
 	"math/rand"
 	"slices"
 	"strings"
@@ -26,6 +27,7 @@ type ChannelMessage struct {
 	Response       chan *schemas.BifrostResponse
 	ResponseStream chan chan *schemas.BifrostStream
 	Err            chan schemas.BifrostError
+	PluginCount    int // Number of plugins whose PreHooks ran, whose PostHooks run on the response
 }
 
 // Bifrost manages providers and maintains specified open channels for concurrent processing.
@@ -33,7 +35,8 @@ type ChannelMessage struct {
 type Bifrost struct {
 	ctx                 context.Context
 	account             schemas.Account                  // account interface
-	plugins             atomic.Pointer[[]schemas.Plugin] // list of plugins
+	plugins             atomic.Pointer[[]schemas.Plugin] // list of plugins, in the order of their priority
+	pluginPolicies      map[string]schemas.PluginPolicy  // policies of plugins by name, overriding the ones they declare
 	requestQueues       sync.Map                         // provider request queues (thread-safe)
 	waitGroups          sync.Map                         // wait groups for each provider (thread-safe)
 	providerMutexes     sync.Map                         // mutexes for each provider to prevent concurrent updates (thread-safe)
@@ -47,12 +50,15 @@ type Bifrost struct {
 	mcpManager          *MCPManager                      // MCP integration manager (nil if MCP not configured)
 	dropExcessRequests  atomic.Bool                      // If true, in cases where the queue is full, requests will not wait for the queue to be empty and will be dropped instead.
 	keySelector         schemas.KeySelector              // Custom key selector function
+	raceSelector        schemas.RaceSelector             // Picks the provider raced against the primary one (nil when requests are never raced)
+	pluginState         schemas.PluginState              // State shared by plugins, set in the context of every request
 }
 
 // PluginPipeline encapsulates the execution of plugin PreHooks and PostHooks, tracks how many plugins ran, and manages short-circuiting and error aggregation.
 type PluginPipeline struct {
-	plugins []schemas.Plugin
-	logger  schemas.Logger
+	plugins  []schemas.Plugin
+	policies map[string]schemas.PluginPolicy
+	logger   schemas.Logger
 
 	// Number of PreHooks that were executed (used to determine which PostHooks to run in reverse order)
 	executedPreHooks int
@@ -88,13 +94,25 @@ func Init(ctx context.Context, config schemas.BifrostConfig) (*Bifrost, error) {
 		requestQueues: sync.Map{},
 		waitGroups:    sync.Map{},
 		keySelector:   config.KeySelector,
+		raceSelector:  config.RaceSelector,
+		pluginState:   config.PluginState,
 	}
-	bifrost.plugins.Store(&config.Plugins)
+	for name, policy := range config.PluginPolicies {
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", name, err)
+		}
+	}
+	bifrost.pluginPolicies = config.PluginPolicies
+	plugins := sortPlugins(config.Plugins, config.PluginPolicies)
+	bifrost.plugins.Store(&plugins)
 	bifrost.dropExcessRequests.Store(config.DropExcessRequests)
 
 	if bifrost.keySelector == nil {
 		bifrost.keySelector = WeightedRandomKeySelector
 	}
+	if bifrost.pluginState == nil {
+		bifrost.pluginState = NewInMemoryPluginState()
+	}
 
 	// Initialize object pools
 	bifrost.channelMessagePool = sync.Pool{
@@ -404,6 +422,36 @@ func (bifrost *Bifrost) EmbeddingRequest(ctx context.Context, req *schemas.Bifro
 	return bifrost.handleRequest(ctx, bifrostReq)
 }
 
+// RerankRequest sends a rerank request to the specified provider, ordering the documents by their relevance
+// to the query.
+func (bifrost *Bifrost) RerankRequest(ctx context.Context, req *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	if req == nil {
+		return nil, &schemas.BifrostError{
+			IsBifrostError: false,
+			Error: &schemas.ErrorField{
+				Message: "rerank request is nil",
+			},
+		}
+	}
+	if req.Query == "" || len(req.Documents) == 0 {
+		return nil, &schemas.BifrostError{
+			IsBifrostError: false,
+			Error: &schemas.ErrorField{
+				Message: "query and documents not provided for rerank request",
+			},
+		}
+	}
+
+	bifrostReq := bifrost.getBifrostRequest()
+	bifrostReq.Provider = req.Provider
+	bifrostReq.Model = req.Model
+	bifrostReq.Fallbacks = req.Fallbacks
+	bifrostReq.RequestType = schemas.RerankRequest
+	bifrostReq.RerankRequest = req
+
+	return bifrost.handleRequest(ctx, bifrostReq)
+}
+
 // SpeechRequest sends a speech request to the specified provider.
 func (bifrost *Bifrost) SpeechRequest(ctx context.Context, req *schemas.BifrostSpeechRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	if req == nil {
@@ -588,6 +636,7 @@ func (bifrost *Bifrost) ReloadPlugin(plugin schemas.Plugin) error {
 			bifrost.logger.Debug("adding new plugin %s", plugin.GetName())
 			newPlugins = append(newPlugins, plugin)
 		}
+		newPlugins = sortPlugins(newPlugins, bifrost.pluginPolicies)
 		// Atomic compare-and-swap
 		if bifrost.plugins.CompareAndSwap(oldPlugins, &newPlugins) {
 			// Cleanup the old plugin
@@ -747,6 +796,27 @@ func (bifrost *Bifrost) UpdateDropExcessRequests(value bool) {
 	bifrost.logger.Info("drop_excess_requests updated to: %v", value)
 }
 
+// PluginState returns the state shared by the plugins of the instance, for their work outside of requests.
+func (bifrost *Bifrost) PluginState() schemas.PluginState {
+	return bifrost.pluginState
+}
+
+// GetUpstreamConnectionStats returns the connections each provider opened to its API, for connection reuse metrics.
+func (bifrost *Bifrost) GetUpstreamConnectionStats() map[schemas.ModelProvider]schemas.UpstreamConnectionStats {
+	return providers.UpstreamConnectionStats()
+}
+
+// GetQueueStats returns the requests waiting in the queue of each provider, for autoscaling and queue metrics.
+func (bifrost *Bifrost) GetQueueStats() map[schemas.ModelProvider]schemas.ProviderQueueStats {
+	stats := make(map[schemas.ModelProvider]schemas.ProviderQueueStats)
+	bifrost.requestQueues.Range(func(key, value interface{}) bool {
+		queue := value.(chan *ChannelMessage)
+		stats[key.(schemas.ModelProvider)] = schemas.ProviderQueueStats{Queued: len(queue), Capacity: cap(queue)}
+		return true
+	})
+	return stats
+}
+
 // getProviderMutex gets or creates a mutex for the given provider
 func (bifrost *Bifrost) getProviderMutex(providerKey schemas.ModelProvider) *sync.RWMutex {
 	mutexValue, _ := bifrost.providerMutexes.LoadOrStore(providerKey, &sync.RWMutex{})
@@ -1009,6 +1079,14 @@ func (bifrost *Bifrost) createBaseProvider(providerKey schemas.ModelProvider, co
 		return providers.NewGeminiProvider(config, bifrost.logger), nil
 	case schemas.OpenRouter:
 		return providers.NewOpenRouterProvider(config, bifrost.logger), nil
+	case schemas.Voyage:
+		return providers.NewVoyageProvider(config, bifrost.logger)
+	case schemas.Jina:
+		return providers.NewJinaProvider(config, bifrost.logger)
+	case schemas.Mock:
+		return providers.NewMockProvider(config, bifrost.logger)
+	case schemas.Declarative:
+		return providers.NewDeclarativeProvider(config, bifrost.logger)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", targetProviderKey)
 	}
@@ -1164,6 +1242,13 @@ func (bifrost *Bifrost) prepareFallbackRequest(req *schemas.BifrostRequest, fall
 		fallbackReq.EmbeddingRequest = &tmp
 	}
 
+	if req.RerankRequest != nil {
+		tmp := *req.RerankRequest
+		tmp.Provider = fallback.Provider
+		tmp.Model = fallback.Model
+		fallbackReq.RerankRequest = &tmp
+	}
+
 	if req.SpeechRequest != nil {
 		tmp := *req.SpeechRequest
 		tmp.Provider = fallback.Provider
@@ -1220,11 +1305,18 @@ func (bifrost *Bifrost) handleRequest(ctx context.Context, req *schemas.BifrostR
 	if ctx == nil {
 		ctx = bifrost.ctx
 	}
+	ctx = context.WithValue(ctx, schemas.BifrostContextKeyPluginState, bifrost.pluginState)
 
 	bifrost.logger.Debug(fmt.Sprintf("Primary provider %s with model %s and %d fallbacks", req.Provider, req.Model, len(req.Fallbacks)))
 
-	// Try the primary provider first
-	primaryResult, primaryErr := bifrost.tryRequest(req, ctx)
+	// Try the primary provider first, racing or hedging it with another provider when one is selected
+	var primaryResult *schemas.BifrostResponse
+	var primaryErr *schemas.BifrostError
+	if run := bifrost.selectRaceChallenger(&ctx, req); run != nil {
+		primaryResult, primaryErr = bifrost.raceRequest(ctx, req, run)
+	} else {
+		primaryResult, primaryErr = bifrost.tryRequest(req, ctx)
+	}
 
 	if primaryErr != nil {
 		bifrost.logger.Debug(fmt.Sprintf("Primary provider %s with model %s returned error: %v", req.Provider, req.Model, primaryErr))
@@ -1249,6 +1341,7 @@ func (bifrost *Bifrost) handleRequest(ctx context.Context, req *schemas.BifrostR
 			bifrost.logger.Debug(fmt.Sprintf("Fallback provider %s with model %s is nil", fallback.Provider, fallback.Model))
 			continue
 		}
+		recordFallback(ctx, fallback)
 
 		// Try the fallback provider
 		result, fallbackErr := bifrost.tryRequest(fallbackReq, ctx)
@@ -1287,9 +1380,19 @@ func (bifrost *Bifrost) handleStreamRequest(ctx context.Context, req *schemas.Bi
 	if ctx == nil {
 		ctx = bifrost.ctx
 	}
+	ctx = context.WithValue(ctx, schemas.BifrostContextKeyPluginState, bifrost.pluginState)
 
-	// Try the primary provider first
-	primaryResult, primaryErr := bifrost.tryStreamRequest(req, ctx)
+	// Try the primary provider first, racing or hedging it with another provider when one is selected
+	var primaryResult chan *schemas.BifrostStream
+	var primaryErr *schemas.BifrostError
+	if run := bifrost.selectRaceChallenger(&ctx, req); run != nil {
+		primaryResult, primaryErr = bifrost.raceStreamRequest(ctx, req, run)
+	} else {
+		primaryResult, primaryErr = bifrost.tryStreamRequest(req, ctx)
+	}
+	if primaryErr == nil {
+		return bifrost.spliceStreamFallbacks(ctx, *req, primaryResult, 0), nil
+	}
 
 	// Check if we should proceed with fallbacks
 	shouldTryFallbacks := bifrost.shouldTryFallbacks(req, primaryErr)
@@ -1298,19 +1401,20 @@ func (bifrost *Bifrost) handleStreamRequest(ctx context.Context, req *schemas.Bi
 	}
 
 	// Try fallbacks in order
-	for _, fallback := range req.Fallbacks {
+	for i, fallback := range req.Fallbacks {
 		ctx = context.WithValue(ctx, schemas.BifrostContextKeyFallbackRequestID, uuid.New().String())
 
 		fallbackReq := bifrost.prepareFallbackRequest(req, fallback)
 		if fallbackReq == nil {
 			continue
 		}
+		recordFallback(ctx, fallback)
 
 		// Try the fallback provider
 		result, fallbackErr := bifrost.tryStreamRequest(fallbackReq, ctx)
 		if fallbackErr == nil {
 			bifrost.logger.Debug(fmt.Sprintf("Successfully used fallback provider %s with model %s", fallback.Provider, fallback.Model))
-			return result, nil
+			return bifrost.spliceStreamFallbacks(ctx, *req, result, i+1), nil
 		}
 
 		// Check if we should continue with more fallbacks
@@ -1322,6 +1426,438 @@ func (bifrost *Bifrost) handleStreamRequest(ctx context.Context, req *schemas.Bi
 	return nil, primaryErr
 }
 
+// streamResumeHint tells clients how to continue an interrupted stream.
+const streamResumeHint = "The stream failed after partial output was sent. Re-send the request with partial_content appended as an assistant message to continue, or retry it from the start."
+
+// spliceStreamFallbacks forwards stream to the returned channel and keeps it going across providers.
+// When the stream fails before any content was emitted, the fallbacks from index next on are tried
+// and the first one that starts streaming is spliced into the same channel, so the client never sees
+// the failure. When it fails after content was emitted, or a chat or text completion stream ends
+// without a final chunk, the client gets an error carrying a StreamInterruption with a resume hint
+// instead of a silently truncated stream.
+// With BifrostContextKeyBufferStream set, the chunks of each provider are held back until its stream ends, so that
+// a failure at any point, truncation included, discards them and falls back, and the client only gets the chunks
+// of one provider.
+// req is a copy, as the pooled request is released once handleStreamRequest returns.
+func (bifrost *Bifrost) spliceStreamFallbacks(ctx context.Context, req schemas.BifrostRequest, stream chan *schemas.BifrostStream, next int) chan *schemas.BifrostStream {
+	outputStream := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+
+	go func() {
+		defer close(outputStream)
+
+		interruption := &schemas.StreamInterruption{ResumeHint: streamResumeHint}
+		var partialContent strings.Builder
+		completed := false
+		buffered, _ := ctx.Value(schemas.BifrostContextKeyBufferStream).(bool)
+		var pending []*schemas.BifrostStream
+		truncatable := req.RequestType == schemas.ChatCompletionStreamRequest || req.RequestType == schemas.TextCompletionStreamRequest
+
+		for {
+			var streamErr *schemas.BifrostError
+			for msg := range stream {
+				if msg == nil {
+					continue
+				}
+				if msg.BifrostError != nil {
+					streamErr = msg.BifrostError
+					break
+				}
+				if msg.BifrostResponse != nil {
+					if streamChunkHasContent(msg.BifrostResponse, &partialContent) {
+						interruption.ChunksEmitted++
+					}
+					if streamChunkIsFinal(msg.BifrostResponse) {
+						completed = true
+					}
+				}
+				if buffered {
+					pending = append(pending, msg)
+					continue
+				}
+				outputStream <- msg
+			}
+
+			if streamErr == nil && buffered && truncatable && !completed && interruption.ChunksEmitted > 0 && ctx.Err() == nil {
+				streamErr = bifrost.streamTruncatedError(&req)
+			}
+			if streamErr == nil {
+				for _, msg := range pending {
+					outputStream <- msg
+				}
+				break
+			}
+			// Let the failed provider finish its stream without blocking it
+			go drainStream(stream)
+			if buffered {
+				// Nothing was sent to the client, so the failed stream is discarded as if it never started
+				pending = nil
+				interruption.ChunksEmitted = 0
+				partialContent.Reset()
+				completed = false
+			}
+
+			if interruption.ChunksEmitted == 0 && ctx.Err() == nil && bifrost.shouldTryFallbacks(&req, streamErr) {
+				var fallbackStream chan *schemas.BifrostStream
+				fallbackStream, next = bifrost.startStreamFallback(ctx, &req, next)
+				if fallbackStream != nil {
+					stream = fallbackStream
+					continue
+				}
+			}
+
+			if interruption.ChunksEmitted > 0 {
+				interruption.PartialContent = partialContent.String()
+				streamErr.ExtraFields.StreamInterruption = interruption
+			}
+			outputStream <- &schemas.BifrostStream{BifrostError: streamErr}
+			return
+		}
+
+		if truncatable && !completed && interruption.ChunksEmitted > 0 && ctx.Err() == nil {
+			interruption.PartialContent = partialContent.String()
+			bifrostErr := bifrost.streamTruncatedError(&req)
+			bifrostErr.ExtraFields.StreamInterruption = interruption
+			outputStream <- &schemas.BifrostStream{BifrostError: bifrostErr}
+		}
+	}()
+
+	return outputStream
+}
+
+// streamTruncatedError logs and returns the error of a chat or text completion stream of req ending without a
+// final chunk.
+func (bifrost *Bifrost) streamTruncatedError(req *schemas.BifrostRequest) *schemas.BifrostError {
+	bifrost.logger.Warn(fmt.Sprintf("stream from provider %s ended without a final chunk", req.Provider))
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		Error: &schemas.ErrorField{
+			Message: "stream ended unexpectedly before completion",
+		},
+		ExtraFields: schemas.BifrostErrorExtraFields{
+			Provider:       req.Provider,
+			ModelRequested: req.Model,
+			RequestType:    req.RequestType,
+		},
+	}
+}
+
+// startStreamFallback tries the fallbacks from index next on, returning the first stream that starts
+// and the index of the fallback after it. It returns a nil stream when no fallback could start.
+func (bifrost *Bifrost) startStreamFallback(ctx context.Context, req *schemas.BifrostRequest, next int) (chan *schemas.BifrostStream, int) {
+	for i := next; i < len(req.Fallbacks); i++ {
+		fallback := req.Fallbacks[i]
+		fallbackCtx := context.WithValue(ctx, schemas.BifrostContextKeyFallbackRequestID, uuid.New().String())
+
+		fallbackReq := bifrost.prepareFallbackRequest(req, fallback)
+		if fallbackReq == nil {
+			continue
+		}
+		recordFallback(ctx, fallback)
+
+		result, fallbackErr := bifrost.tryStreamRequest(fallbackReq, fallbackCtx)
+		if fallbackErr == nil {
+			bifrost.logger.Debug(fmt.Sprintf("Stream failed before any content, spliced in fallback provider %s with model %s", fallback.Provider, fallback.Model))
+			return result, i + 1
+		}
+
+		if !bifrost.shouldContinueWithFallbacks(fallback, fallbackErr) {
+			break
+		}
+	}
+	return nil, len(req.Fallbacks)
+}
+
+// streamChunkHasContent reports whether a stream chunk carries output for the client, appending its text to partialContent.
+// Chunks of streams other than chat and text completions always count as content.
+func streamChunkHasContent(resp *schemas.BifrostResponse, partialContent *strings.Builder) bool {
+	if len(resp.Choices) == 0 {
+		return resp.Speech != nil || resp.Transcribe != nil || resp.ResponsesResponse != nil
+	}
+	hasContent := false
+	for _, choice := range resp.Choices {
+		if choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil && *choice.Text != "" {
+			partialContent.WriteString(*choice.Text)
+			hasContent = true
+		}
+		if choice.BifrostStreamResponseChoice == nil || choice.Delta == nil {
+			continue
+		}
+		delta := choice.Delta
+		if delta.Content != nil && *delta.Content != "" {
+			partialContent.WriteString(*delta.Content)
+			hasContent = true
+		}
+		if (delta.Thought != nil && *delta.Thought != "") || (delta.Refusal != nil && *delta.Refusal != "") || len(delta.ToolCalls) > 0 {
+			hasContent = true
+		}
+	}
+	return hasContent
+}
+
+// streamChunkIsFinal reports whether a chat or text completion chunk ends the stream, which is the case of the chunk
+// carrying the finish reason: providers send it at the end of the stream when the upstream stream finished. Usage
+// alone does not end a stream, as usage-only chunks may come before the last content chunk.
+func streamChunkIsFinal(resp *schemas.BifrostResponse) bool {
+	for _, choice := range resp.Choices {
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// drainStream discards the remaining messages of a stream until it is closed
+func drainStream(stream chan *schemas.BifrostStream) {
+	for range stream {
+	}
+}
+
+// raceRun is the challenger of a raced request and how it is sent.
+type raceRun struct {
+	challengerReq *schemas.BifrostRequest
+	delay         time.Duration                            // Time the primary attempt has to answer before the challenger is sent
+	onOutcome     func(challengerSent, challengerWon bool) // Optional
+}
+
+// selectRaceChallenger returns how req is raced, or nil when req is not raced.
+func (bifrost *Bifrost) selectRaceChallenger(ctx *context.Context, req *schemas.BifrostRequest) *raceRun {
+	if bifrost.raceSelector == nil {
+		return nil
+	}
+	challenger := bifrost.raceSelector(ctx, req)
+	if challenger == nil || (challenger.Provider == req.Provider && challenger.Model == req.Model) {
+		return nil
+	}
+	challengerReq := bifrost.prepareFallbackRequest(req, schemas.Fallback{Provider: challenger.Provider, Model: challenger.Model})
+	if challengerReq == nil {
+		return nil
+	}
+	return &raceRun{challengerReq: challengerReq, delay: challenger.Delay, onOutcome: challenger.OnOutcome}
+}
+
+// report tells the selector of the race how it was decided
+func (run *raceRun) report(challengerSent, challengerWon bool) {
+	if run.onOutcome != nil {
+		run.onOutcome(challengerSent, challengerWon)
+	}
+}
+
+// isolateRaceRequest gives a raced request its own parameters, as both attempts of a race go through
+// the plugins and the MCP tool injection at the same time.
+func isolateRaceRequest(req *schemas.BifrostRequest) *schemas.BifrostRequest {
+	isolated := *req
+	if req.ChatRequest != nil && req.ChatRequest.Params != nil {
+		chatReq := *req.ChatRequest
+		params := *chatReq.Params
+		params.Tools = slices.Clip(params.Tools)
+		chatReq.Params = &params
+		isolated.ChatRequest = &chatReq
+	}
+	if req.ResponsesRequest != nil && req.ResponsesRequest.Params != nil {
+		responsesReq := *req.ResponsesRequest
+		params := *responsesReq.Params
+		params.Tools = slices.Clip(params.Tools)
+		responsesReq.Params = &params
+		isolated.ResponsesRequest = &responsesReq
+	}
+	return &isolated
+}
+
+// raceAttempt is the outcome of one attempt of a race. The stream of a successful streaming attempt
+// has already delivered first.
+type raceAttempt struct {
+	primary  bool
+	response *schemas.BifrostResponse
+	stream   chan *schemas.BifrostStream
+	first    *schemas.BifrostStream
+	err      *schemas.BifrostError
+	cancel   context.CancelFunc
+}
+
+// raceContexts returns the cancellable contexts of the primary and challenger attempts of a race.
+// The challenger gets its own fallback request ID, so plugins log it apart from the primary attempt.
+func raceContexts(ctx context.Context) (primaryCtx context.Context, cancelPrimary context.CancelFunc, challengerCtx context.Context, cancelChallenger context.CancelFunc) {
+	primaryCtx, cancelPrimary = context.WithCancel(ctx)
+	challengerCtx, cancelChallenger = context.WithCancel(context.WithValue(ctx, schemas.BifrostContextKeyFallbackRequestID, uuid.New().String()))
+	return primaryCtx, cancelPrimary, challengerCtx, cancelChallenger
+}
+
+// awaitHedgeDelay waits for the delay of a hedged race. It returns the primary attempt when it ended
+// within the delay, in which case the challenger is not sent.
+func awaitHedgeDelay(run *raceRun, attempts chan raceAttempt) (raceAttempt, bool) {
+	if run.delay <= 0 {
+		return raceAttempt{}, false
+	}
+	timer := time.NewTimer(run.delay)
+	defer timer.Stop()
+	select {
+	case attempt := <-attempts:
+		return attempt, true
+	case <-timer.C:
+		return raceAttempt{}, false
+	}
+}
+
+// raceRequest sends req to its primary provider and the challenger of run at the same time, or once the
+// hedge delay of run passed without a response, returns the first successful response and cancels the
+// other attempt. When both attempts fail, the error of the primary provider is returned so the regular
+// fallbacks take over.
+func (bifrost *Bifrost) raceRequest(ctx context.Context, req *schemas.BifrostRequest, run *raceRun) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	primaryCtx, cancelPrimary, challengerCtx, cancelChallenger := raceContexts(ctx)
+	defer cancelPrimary()
+	defer cancelChallenger()
+
+	// Attempts work on their own copies, as req goes back to the pool once the race is decided
+	attempts := make(chan raceAttempt, 2)
+	primaryReq, challengerReq := isolateRaceRequest(req), isolateRaceRequest(run.challengerReq)
+	go func() {
+		response, err := bifrost.tryRequest(primaryReq, primaryCtx)
+		attempts <- raceAttempt{primary: true, response: response, err: err}
+	}()
+	if attempt, done := awaitHedgeDelay(run, attempts); done {
+		run.report(false, false)
+		return attempt.response, attempt.err
+	}
+	go func() {
+		response, err := bifrost.tryRequest(challengerReq, challengerCtx)
+		attempts <- raceAttempt{response: response, err: err}
+	}()
+
+	var primaryErr *schemas.BifrostError
+	for range 2 {
+		attempt := <-attempts
+		if attempt.err == nil {
+			// The deferred cancellations stop the losing attempt
+			bifrost.logger.Debug(fmt.Sprintf("Race won by %s", raceWinner(attempt.primary, req, challengerReq)))
+			run.report(true, !attempt.primary)
+			return attempt.response, nil
+		}
+		if attempt.primary {
+			// Errors that do not allow fallbacks, like policy rejections, end the race
+			if !allowsRaceChallenger(attempt.err) {
+				run.report(true, false)
+				return nil, attempt.err
+			}
+			primaryErr = attempt.err
+		} else {
+			bifrost.logger.Warn(fmt.Sprintf("Raced provider %s failed: %s", challengerReq.Provider, attempt.err.Error.Message))
+		}
+	}
+	run.report(true, false)
+	return nil, primaryErr
+}
+
+// raceStreamRequest starts streaming req from its primary provider and from the challenger of run at the
+// same time, or once the hedge delay of run passed without a first chunk, streams from the attempt
+// delivering the first chunk and cancels the other one. When both attempts fail before their first
+// chunk, the error of the primary provider is returned.
+func (bifrost *Bifrost) raceStreamRequest(ctx context.Context, req *schemas.BifrostRequest, run *raceRun) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	primaryCtx, cancelPrimary, challengerCtx, cancelChallenger := raceContexts(ctx)
+	challengerReq := run.challengerReq
+
+	attempts := make(chan raceAttempt, 2)
+	start := func(attemptReq *schemas.BifrostRequest, attemptCtx context.Context, cancel context.CancelFunc, primary bool) {
+		attempt := raceAttempt{primary: primary, cancel: cancel}
+		attempt.stream, attempt.err = bifrost.tryStreamRequest(attemptReq, attemptCtx)
+		if attempt.err == nil {
+			first, ok := <-attempt.stream
+			switch {
+			case !ok:
+				attempt.err = newBifrostErrorFromMsg("stream ended before its first chunk")
+			case first != nil && first.BifrostError != nil:
+				attempt.err = first.BifrostError
+				go drainStream(attempt.stream)
+			default:
+				attempt.first = first
+			}
+		}
+		attempts <- attempt
+	}
+	go start(isolateRaceRequest(req), primaryCtx, cancelPrimary, true)
+	if attempt, done := awaitHedgeDelay(run, attempts); done {
+		cancelChallenger()
+		run.report(false, false)
+		if attempt.err != nil {
+			attempt.cancel()
+			return nil, attempt.err
+		}
+		return forwardRaceStream(attempt), nil
+	}
+	go start(isolateRaceRequest(challengerReq), challengerCtx, cancelChallenger, false)
+
+	var primaryErr *schemas.BifrostError
+	for i := range 2 {
+		attempt := <-attempts
+		if attempt.err != nil {
+			attempt.cancel()
+			if attempt.primary {
+				// Errors that do not allow fallbacks, like policy rejections, end the race
+				if i == 0 && !allowsRaceChallenger(attempt.err) {
+					cancelChallenger()
+					go func() {
+						if loser := <-attempts; loser.err == nil {
+							drainStream(loser.stream)
+						}
+					}()
+					run.report(true, false)
+					return nil, attempt.err
+				}
+				primaryErr = attempt.err
+			} else {
+				bifrost.logger.Warn(fmt.Sprintf("Raced provider %s failed: %s", challengerReq.Provider, attempt.err.Error.Message))
+			}
+			continue
+		}
+
+		bifrost.logger.Debug(fmt.Sprintf("Race won by %s", raceWinner(attempt.primary, req, challengerReq)))
+		run.report(true, !attempt.primary)
+		if i == 0 {
+			// Cancel the losing attempt and let it finish its stream without blocking it
+			if attempt.primary {
+				cancelChallenger()
+			} else {
+				cancelPrimary()
+			}
+			go func() {
+				if loser := <-attempts; loser.err == nil {
+					drainStream(loser.stream)
+				}
+			}()
+		}
+		return forwardRaceStream(attempt), nil
+	}
+	run.report(true, false)
+	return nil, primaryErr
+}
+
+// forwardRaceStream returns the stream of the attempt that won a race, starting with its first chunk.
+// The context of the attempt is cancelled once its stream ends.
+func forwardRaceStream(attempt raceAttempt) chan *schemas.BifrostStream {
+	outputStream := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	go func() {
+		defer close(outputStream)
+		defer attempt.cancel()
+		outputStream <- attempt.first
+		for msg := range attempt.stream {
+			outputStream <- msg
+		}
+	}()
+	return outputStream
+}
+
+// allowsRaceChallenger reports whether the challenger of a race may still answer after the primary attempt failed with err
+func allowsRaceChallenger(err *schemas.BifrostError) bool {
+	return err.AllowFallbacks == nil || *err.AllowFallbacks
+}
+
+// raceWinner describes the provider and model of the attempt that won a race
+func raceWinner(primary bool, req *schemas.BifrostRequest, challengerReq *schemas.BifrostRequest) string {
+	if primary {
+		return fmt.Sprintf("primary provider %s with model %s", req.Provider, req.Model)
+	}
+	return fmt.Sprintf("challenger %s with model %s", challengerReq.Provider, challengerReq.Model)
+}
+
 // tryRequest is a generic function that handles common request processing logic
 // It consolidates queue setup, plugin pipeline execution, enqueue logic, and response handling
 func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Context) (*schemas.BifrostResponse, *schemas.BifrostError) {
@@ -1332,6 +1868,7 @@ func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Cont
 
 	// Add MCP tools to request if MCP is configured and requested
 	if req.RequestType != schemas.EmbeddingRequest &&
+		req.RequestType != schemas.RerankRequest &&
 		req.RequestType != schemas.SpeechRequest &&
 		req.RequestType != schemas.TranscriptionRequest &&
 		bifrost.mcpManager != nil {
@@ -1366,6 +1903,7 @@ func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Cont
 
 	msg := bifrost.getChannelMessage(*preReq)
 	msg.Context = ctx
+	msg.PluginCount = preCount
 	select {
 	case queue <- msg:
 		// Message was sent successfully
@@ -1389,10 +1927,9 @@ func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Cont
 
 	var result *schemas.BifrostResponse
 	var resp *schemas.BifrostResponse
-	pluginCount := len(*bifrost.plugins.Load())
 	select {
 	case result = <-msg.Response:
-		resp, bifrostErr := pipeline.RunPostHooks(&msg.Context, result, nil, pluginCount)
+		resp, bifrostErr := pipeline.RunPostHooks(&msg.Context, result, nil, preCount)
 		if bifrostErr != nil {
 			bifrost.releaseChannelMessage(msg)
 			return nil, bifrostErr
@@ -1401,7 +1938,7 @@ func (bifrost *Bifrost) tryRequest(req *schemas.BifrostRequest, ctx context.Cont
 		return resp, nil
 	case bifrostErrVal := <-msg.Err:
 		bifrostErrPtr := &bifrostErrVal
-		resp, bifrostErrPtr = pipeline.RunPostHooks(&msg.Context, nil, bifrostErrPtr, pluginCount)
+		resp, bifrostErrPtr = pipeline.RunPostHooks(&msg.Context, nil, bifrostErrPtr, preCount)
 		bifrost.releaseChannelMessage(msg)
 		if bifrostErrPtr != nil {
 			return nil, bifrostErrPtr
@@ -1481,6 +2018,7 @@ func (bifrost *Bifrost) tryStreamRequest(req *schemas.BifrostRequest, ctx contex
 
 	msg := bifrost.getChannelMessage(*preReq)
 	msg.Context = ctx
+	msg.PluginCount = preCount
 
 	select {
 	case queue <- msg:
@@ -1512,7 +2050,7 @@ func (bifrost *Bifrost) tryStreamRequest(req *schemas.BifrostRequest, ctx contex
 		// Marking final chunk
 		ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
 		// On error we will complete post-hooks
-		recoveredResp, recoveredErr := pipeline.RunPostHooks(&ctx, nil, &bifrostErrVal, len(*bifrost.plugins.Load()))
+		recoveredResp, recoveredErr := pipeline.RunPostHooks(&ctx, nil, &bifrostErrVal, preCount)
 		bifrost.releaseChannelMessage(msg)
 		if recoveredErr != nil {
 			return nil, recoveredErr
@@ -1564,6 +2102,26 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, config *schemas
 			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeySelectedKey, key.ID)
 		}
 
+		// Forward the inbound headers allowed by the passthrough policy of the provider
+		if inbound, ok := req.Context.Value(schemas.BifrostContextKeyRequestHeaders).(map[string]string); ok {
+			if forwarded := config.NetworkConfig.PassthroughHeaders.Select(inbound); forwarded != nil {
+				req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyForwardedHeaders, forwarded)
+				bifrost.logger.Debug("forwarding headers %v to provider %s", forwarded.Redacted(), provider.GetProviderKey())
+			}
+		}
+
+		// Shape the parameters of the request with the defaults and overrides of the provider
+		if changes := config.RequestShaping.Apply(&req.BifrostRequest); len(changes) > 0 {
+			recordRequestShaping(req, changes)
+			bifrost.logger.Debug("shaped %d parameters of request for provider %s", len(changes), provider.GetProviderKey())
+		}
+
+		// Forward the extra parameters allowed by the passthrough policy of the provider, once shaped
+		if forwarded := config.NetworkConfig.PassthroughParams.Select(req.BifrostRequest.GetExtraParams()); forwarded != nil {
+			req.Context = context.WithValue(req.Context, schemas.BifrostContextKeyForwardedParams, forwarded)
+			bifrost.logger.Debug("forwarding parameters %v to provider %s", slices.Sorted(maps.Keys(forwarded)), provider.GetProviderKey())
+		}
+
 		// Track attempts
 		var attempts int
 
@@ -1572,9 +2130,13 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, config *schemas
 		if IsStreamRequestType(req.RequestType) {
 			pipeline := bifrost.getPluginPipeline()
 			defer bifrost.releasePluginPipeline(pipeline)
+			// The message is released once the stream is handed over, so its plugin count is read up front
+			pluginCount := req.PluginCount
 
 			postHookRunner = func(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError) {
-				resp, bifrostErr := pipeline.RunPostHooks(ctx, result, err, len(*bifrost.plugins.Load()))
+				// Normalize chunks ahead of the plugins, so that they see the same shape whatever the provider
+				schemas.NormalizeResponse(baseProvider, result)
+				resp, bifrostErr := pipeline.RunPostHooks(ctx, result, err, pluginCount)
 				if bifrostErr != nil {
 					return nil, bifrostErr
 				}
@@ -1596,13 +2158,19 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, config *schemas
 			bifrost.logger.Debug("attempting request for provider %s", provider.GetProviderKey())
 
 			// Attempt the request
+			started := time.Now()
 			if IsStreamRequestType(req.RequestType) {
 				stream, bifrostError = handleProviderStreamRequest(provider, req, key, postHookRunner)
+				if providers.IsUnsupportedOperationError(bifrostError) && config.SyntheticStreaming != nil && config.SyntheticStreaming.Enabled {
+					stream, bifrostError = bifrost.synthesizeProviderStream(provider, req, key, postHookRunner, config.SyntheticStreaming, bifrostError)
+				}
+				recordProviderAttempt(req, key, attempts, nil, bifrostError, time.Since(started))
 				if bifrostError != nil && !bifrostError.IsBifrostError {
 					break // Don't retry client errors
 				}
 			} else {
 				result, bifrostError = handleProviderRequest(provider, req, key)
+				recordProviderAttempt(req, key, attempts, result, bifrostError, time.Since(started))
 				if bifrostError != nil {
 					break // Don't retry client errors
 				}
@@ -1625,9 +2193,10 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, config *schemas
 				bifrost.logger.Warn("request failed after %d %s", attempts, map[bool]string{true: "retries", false: "retry"}[attempts > 1])
 			}
 			bifrostError.ExtraFields = schemas.BifrostErrorExtraFields{
-				Provider:       provider.GetProviderKey(),
-				ModelRequested: req.Model,
-				RequestType:    req.RequestType,
+				Provider:          provider.GetProviderKey(),
+				ModelRequested:    req.Model,
+				RequestType:       req.RequestType,
+				RetryAfterSeconds: bifrostError.ExtraFields.RetryAfterSeconds,
 			}
 
 			// Send error with context awareness to prevent deadlock
@@ -1655,6 +2224,7 @@ func (bifrost *Bifrost) requestWorker(provider schemas.Provider, config *schemas
 					bifrost.logger.Warn("Timeout while sending stream response, client may have disconnected")
 				}
 			} else {
+				schemas.NormalizeResponse(baseProvider, result)
 				result.ExtraFields.RequestType = req.RequestType
 				result.ExtraFields.Provider = provider.GetProviderKey()
 				result.ExtraFields.ModelRequested = req.Model
@@ -1688,6 +2258,8 @@ func handleProviderRequest(provider schemas.Provider, req *ChannelMessage, key s
 		return provider.Responses(req.Context, key, req.BifrostRequest.ResponsesRequest)
 	case schemas.EmbeddingRequest:
 		return provider.Embedding(req.Context, key, req.BifrostRequest.EmbeddingRequest)
+	case schemas.RerankRequest:
+		return provider.Rerank(req.Context, key, req.BifrostRequest.RerankRequest)
 	case schemas.SpeechRequest:
 		return provider.Speech(req.Context, key, req.BifrostRequest.SpeechRequest)
 	case schemas.TranscriptionRequest:
@@ -1725,22 +2297,68 @@ func handleProviderStreamRequest(provider schemas.Provider, req *ChannelMessage,
 	}
 }
 
+// synthesizeProviderStream serves a chat or text completion stream the provider does not support by requesting the
+// completion without streaming and streaming it back. Other requests fail with unsupportedErr.
+func (bifrost *Bifrost) synthesizeProviderStream(provider schemas.Provider, req *ChannelMessage, key schemas.Key, postHookRunner schemas.PostHookRunner, config *schemas.SyntheticStreamingConfig, unsupportedErr *schemas.BifrostError) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	var result *schemas.BifrostResponse
+	var bifrostErr *schemas.BifrostError
+	switch req.RequestType {
+	case schemas.TextCompletionStreamRequest:
+		result, bifrostErr = provider.TextCompletion(req.Context, key, req.BifrostRequest.TextCompletionRequest)
+	case schemas.ChatCompletionStreamRequest:
+		result, bifrostErr = provider.ChatCompletion(req.Context, key, req.BifrostRequest.ChatRequest)
+	default:
+		return nil, unsupportedErr
+	}
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	bifrost.logger.Debug("synthesizing %s of model %s for provider %s", req.RequestType, req.Model, provider.GetProviderKey())
+	result.ExtraFields.Provider = provider.GetProviderKey()
+	result.ExtraFields.ModelRequested = req.Model
+	return providers.SynthesizeStream(req.Context, postHookRunner, result, req.RequestType, config, bifrost.logger), nil
+}
+
 // PLUGIN MANAGEMENT
 
 // RunPreHooks executes PreHooks in order, tracks how many ran, and returns the final request, any short-circuit decision, and the count.
+// A short-circuit allowing the request stops the PreHooks without being returned, and errors of plugins failing closed
+// short-circuit the request with a plugin error.
 func (p *PluginPipeline) RunPreHooks(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, int) {
 	var shortCircuit *schemas.PluginShortCircuit
 	var err error
+	trace, _ := (*ctx).Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace)
 	for i, plugin := range p.plugins {
 		p.logger.Debug("running pre-hook for plugin %s", plugin.GetName())
+		var before preHookSnapshot
+		if trace != nil {
+			before = snapshotPreHook(req)
+		}
+		started := time.Now()
 		req, shortCircuit, err = plugin.PreHook(ctx, req)
 		if err != nil {
 			p.preHookErrors = append(p.preHookErrors, err)
 			p.logger.Warn("error in PreHook for plugin %s: %v", plugin.GetName(), err)
+			if resolvePluginPolicy(plugin, p.policies).FailurePolicy == schemas.PluginFailClosed {
+				shortCircuit = &schemas.PluginShortCircuit{Error: newPluginError(plugin, "PreHook", err)}
+			}
+		}
+		if trace != nil {
+			trace.AddStep(preHookTraceStep(plugin.GetName(), before, req, shortCircuit, err, time.Since(started)))
 		}
 		p.executedPreHooks = i + 1
 		if shortCircuit != nil {
-			return req, shortCircuit, p.executedPreHooks // short-circuit: only plugins up to and including i ran
+			if shortCircuit.Response != nil || shortCircuit.Stream != nil || shortCircuit.Error != nil {
+				return req, shortCircuit, p.executedPreHooks // short-circuit: only plugins up to and including i ran
+			}
+			if shortCircuit.Allow {
+				p.logger.Debug("plugin %s allowed the request, skipping the remaining pre-hooks", plugin.GetName())
+				break
+			}
+			// An empty short-circuit is a no-op, so a mistaken one cannot skip the PreHooks of the plugins after it
+			p.logger.Warn("plugin %s returned an empty short-circuit from PreHook, ignoring it", plugin.GetName())
+			shortCircuit = nil
 		}
 	}
 	return req, nil, p.executedPreHooks
@@ -1759,13 +2377,21 @@ func (p *PluginPipeline) RunPostHooks(ctx *context.Context, resp *schemas.Bifros
 		runFrom = len(p.plugins)
 	}
 	var err error
+	trace, _ := (*ctx).Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace)
 	for i := runFrom - 1; i >= 0; i-- {
 		plugin := p.plugins[i]
 		p.logger.Debug("running post-hook for plugin %s", plugin.GetName())
+		failed, started := bifrostErr != nil, time.Now()
 		resp, bifrostErr, err = plugin.PostHook(ctx, resp, bifrostErr)
 		if err != nil {
 			p.postHookErrors = append(p.postHookErrors, err)
 			p.logger.Warn("error in PostHook for plugin %s: %v", plugin.GetName(), err)
+			if resolvePluginPolicy(plugin, p.policies).FailurePolicy == schemas.PluginFailClosed {
+				resp, bifrostErr = nil, newPluginError(plugin, "PostHook", err)
+			}
+		}
+		if trace != nil {
+			trace.AddStep(postHookTraceStep(plugin.GetName(), failed, resp, bifrostErr, err, time.Since(started)))
 		}
 		// If a plugin recovers from an error (sets bifrostErr to nil and sets resp), allow that
 		// If a plugin invalidates a response (sets resp to nil and sets bifrostErr), allow that
@@ -1793,6 +2419,7 @@ func (p *PluginPipeline) resetPluginPipeline() {
 func (bifrost *Bifrost) getPluginPipeline() *PluginPipeline {
 	pipeline := bifrost.pluginPipelinePool.Get().(*PluginPipeline)
 	pipeline.plugins = *bifrost.plugins.Load()
+	pipeline.policies = bifrost.pluginPolicies
 	pipeline.logger = bifrost.logger
 	pipeline.resetPluginPipeline()
 	return pipeline
@@ -1814,6 +2441,7 @@ func resetBifrostRequest(req *schemas.BifrostRequest) {
 	req.ChatRequest = nil
 	req.ResponsesRequest = nil
 	req.EmbeddingRequest = nil
+	req.RerankRequest = nil
 	req.SpeechRequest = nil
 	req.TranscriptionRequest = nil
 }
@@ -1892,6 +2520,7 @@ func (bifrost *Bifrost) releaseChannelMessage(msg *ChannelMessage) {
 	msg.Response = nil
 	msg.ResponseStream = nil
 	msg.Err = nil
+	msg.PluginCount = 0
 	bifrost.channelMessagePool.Put(msg)
 }
 
@@ -0,0 +1,262 @@
+package bifrost
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// streamTestAccount configures the mock provider only
+type streamTestAccount struct{}
+
+func (streamTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{schemas.Mock}, nil
+}
+
+func (streamTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	return nil, nil
+}
+
+func (streamTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	if providerKey != schemas.Mock {
+		return nil, fmt.Errorf("provider %s is not configured", providerKey)
+	}
+	return &schemas.ProviderConfig{ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize}, nil
+}
+
+// streamTestStep is a message of a scripted stream: a content chunk, a usage-only chunk, the final chunk with
+// its finish reason, or an error
+type streamTestStep struct {
+	content string
+	usage   bool
+	finish  bool
+	err     bool
+}
+
+// streamTestPlugin answers stream requests with the scripted stream of their model and records the models attempted
+type streamTestPlugin struct {
+	scripts map[string][]streamTestStep
+
+	mu        sync.Mutex
+	attempted []string
+}
+
+func (p *streamTestPlugin) GetName() string {
+	return "stream-test"
+}
+
+func (p *streamTestPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+func (p *streamTestPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	p.mu.Lock()
+	p.attempted = append(p.attempted, req.Model)
+	p.mu.Unlock()
+
+	stream := make(chan *schemas.BifrostStream, len(p.scripts[req.Model]))
+	for _, step := range p.scripts[req.Model] {
+		switch {
+		case step.err:
+			stream <- &schemas.BifrostStream{BifrostError: &schemas.BifrostError{Error: &schemas.ErrorField{Message: req.Model + " failed"}}}
+		case step.usage:
+			stream <- &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{Usage: &schemas.LLMUsage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2}}}
+		default:
+			choice := schemas.BifrostChatResponseChoice{BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{}}}
+			if step.content != "" {
+				choice.Delta.Content = Ptr(step.content)
+			}
+			if step.finish {
+				choice.FinishReason = Ptr("stop")
+			}
+			stream <- &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{choice}}}
+		}
+	}
+	close(stream)
+	return req, &schemas.PluginShortCircuit{Stream: stream}, nil
+}
+
+func (p *streamTestPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+func (p *streamTestPlugin) Cleanup() error {
+	return nil
+}
+
+// streamTestResult is what a client receives from a stream
+type streamTestResult struct {
+	content string
+	err     *schemas.BifrostError
+}
+
+// runTestStream streams a chat completion of model with fallbacks through plugin and collects what the client receives
+func runTestStream(t *testing.T, plugin *streamTestPlugin, ctx context.Context, model string, fallbacks ...string) streamTestResult {
+	t.Helper()
+	client, err := Init(context.Background(), schemas.BifrostConfig{
+		Account: streamTestAccount{},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+
+	req := &schemas.BifrostChatRequest{Provider: schemas.Mock, Model: model, Input: []schemas.ChatMessage{{
+		Role:    schemas.ChatMessageRoleUser,
+		Content: &schemas.ChatMessageContent{ContentStr: Ptr("Hi")},
+	}}}
+	for _, fallback := range fallbacks {
+		req.Fallbacks = append(req.Fallbacks, schemas.Fallback{Provider: schemas.Mock, Model: fallback})
+	}
+	stream, bifrostErr := client.ChatCompletionStreamRequest(ctx, req)
+	if bifrostErr != nil {
+		return streamTestResult{err: bifrostErr}
+	}
+	var result streamTestResult
+	var content strings.Builder
+	for msg := range stream {
+		if msg.BifrostError != nil {
+			result.err = msg.BifrostError
+			continue
+		}
+		for _, choice := range msg.BifrostResponse.Choices {
+			if choice.BifrostStreamResponseChoice != nil && choice.Delta.Content != nil {
+				content.WriteString(*choice.Delta.Content)
+			}
+		}
+	}
+	result.content = content.String()
+	return result
+}
+
+// TestStreamFallbacks tests that streams fall back to the next provider when they fail before their first token, and
+// report an interruption with the partial content when they fail or are truncated after it, unless they are buffered
+func TestStreamFallbacks(t *testing.T) {
+	scripts := map[string][]streamTestStep{
+		"ok":         {{content: "Hello"}, {content: " world"}, {finish: true}},
+		"early":      {{err: true}},
+		"mid":        {{content: "Hel"}, {err: true}},
+		"truncated":  {{content: "Hel"}},
+		"usage-only": {{content: "Hel"}, {usage: true}, {content: "lo"}},
+		"usage-last": {{content: "Hello"}, {usage: true}, {finish: true}},
+	}
+	buffered := context.WithValue(context.Background(), schemas.BifrostContextKeyBufferStream, true)
+
+	for name, tc := range map[string]struct {
+		ctx              context.Context
+		model            string
+		fallbacks        []string
+		wantContent      string
+		wantAttempted    []string
+		wantErr          bool
+		wantInterruption string // Partial content of the interruption reported to the client
+	}{
+		"completed":                       {model: "ok", wantContent: "Hello world", wantAttempted: []string{"ok"}},
+		"failover before the first token": {model: "early", fallbacks: []string{"ok"}, wantContent: "Hello world", wantAttempted: []string{"early", "ok"}},
+		"interrupted mid-stream":          {model: "mid", fallbacks: []string{"ok"}, wantContent: "Hel", wantAttempted: []string{"mid"}, wantErr: true, wantInterruption: "Hel"},
+		"truncated":                       {model: "truncated", fallbacks: []string{"ok"}, wantContent: "Hel", wantAttempted: []string{"truncated"}, wantErr: true, wantInterruption: "Hel"},
+		"usage before the end":            {model: "usage-only", wantContent: "Hello", wantAttempted: []string{"usage-only"}, wantErr: true, wantInterruption: "Hello"},
+		"usage with the final chunk":      {model: "usage-last", wantContent: "Hello", wantAttempted: []string{"usage-last"}},
+		"buffered interruption":           {ctx: buffered, model: "mid", fallbacks: []string{"ok"}, wantContent: "Hello world", wantAttempted: []string{"mid", "ok"}},
+		"buffered truncation":             {ctx: buffered, model: "truncated", fallbacks: []string{"ok"}, wantContent: "Hello world", wantAttempted: []string{"truncated", "ok"}},
+		"buffered without fallbacks":      {ctx: buffered, model: "usage-only", wantErr: true, wantAttempted: []string{"usage-only"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := tc.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			plugin := &streamTestPlugin{scripts: scripts}
+			result := runTestStream(t, plugin, ctx, tc.model, tc.fallbacks...)
+
+			if result.content != tc.wantContent {
+				t.Errorf("content = %q, want %q", result.content, tc.wantContent)
+			}
+			if !slices.Equal(plugin.attempted, tc.wantAttempted) {
+				t.Errorf("attempted models = %v, want %v", plugin.attempted, tc.wantAttempted)
+			}
+			if (result.err != nil) != tc.wantErr {
+				t.Fatalf("error = %+v, want an error: %v", result.err, tc.wantErr)
+			}
+			if result.err == nil {
+				return
+			}
+			interruption := result.err.ExtraFields.StreamInterruption
+			switch {
+			case tc.wantInterruption == "" && interruption != nil:
+				t.Errorf("unexpected interruption %+v", interruption)
+			case tc.wantInterruption != "" && (interruption == nil || interruption.PartialContent != tc.wantInterruption || interruption.ResumeHint == ""):
+				t.Errorf("interruption = %+v, want partial content %q with a resume hint", interruption, tc.wantInterruption)
+			}
+		})
+	}
+}
+
+// shortCircuitTestPlugin returns its short-circuit from PreHook and records that it ran
+type shortCircuitTestPlugin struct {
+	name         string
+	shortCircuit *schemas.PluginShortCircuit
+	ran          *[]string
+}
+
+func (p *shortCircuitTestPlugin) GetName() string {
+	return p.name
+}
+
+func (p *shortCircuitTestPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+func (p *shortCircuitTestPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	*p.ran = append(*p.ran, p.name)
+	return req, p.shortCircuit, nil
+}
+
+func (p *shortCircuitTestPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+func (p *shortCircuitTestPlugin) Cleanup() error {
+	return nil
+}
+
+// TestRunPreHooks_ShortCircuits tests that only a short-circuit allowing the request skips the remaining PreHooks,
+// and that an empty one is ignored
+func TestRunPreHooks_ShortCircuits(t *testing.T) {
+	for name, tc := range map[string]struct {
+		shortCircuit *schemas.PluginShortCircuit
+		wantRan      []string
+		wantReturned bool
+	}{
+		"none":  {wantRan: []string{"first", "auth"}},
+		"allow": {shortCircuit: &schemas.PluginShortCircuit{Allow: true}, wantRan: []string{"first"}},
+		"empty": {shortCircuit: &schemas.PluginShortCircuit{}, wantRan: []string{"first", "auth"}},
+		"error": {shortCircuit: &schemas.PluginShortCircuit{Error: &schemas.BifrostError{}}, wantRan: []string{"first"}, wantReturned: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var ran []string
+			pipeline := &PluginPipeline{
+				plugins: []schemas.Plugin{
+					&shortCircuitTestPlugin{name: "first", shortCircuit: tc.shortCircuit, ran: &ran},
+					&shortCircuitTestPlugin{name: "auth", ran: &ran},
+				},
+				logger: NewDefaultLogger(schemas.LogLevelError),
+			}
+			ctx := context.Background()
+			_, shortCircuit, executed := pipeline.RunPreHooks(&ctx, &schemas.BifrostRequest{})
+			if !slices.Equal(ran, tc.wantRan) || executed != len(tc.wantRan) {
+				t.Errorf("ran %v (%d executed), want %v", ran, executed, tc.wantRan)
+			}
+			if (shortCircuit != nil) != tc.wantReturned {
+				t.Errorf("returned short-circuit %+v, want one: %v", shortCircuit, tc.wantReturned)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@
 package bifrost
 
 import (
+	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	schemas "github.com/maximhq/bifrost/core/schemas"
@@ -17,6 +20,10 @@ import (
 type DefaultLogger struct {
 	stderrLogger zerolog.Logger
 	stdoutLogger zerolog.Logger
+
+	fields  []any          // Key-value pairs attached to every message
+	sampleN uint32         // Write one of every sampleN messages (0 or 1 writes all)
+	counter *atomic.Uint32 // Shared message counter used for sampling
 }
 
 // toZerologLevel converts a Bifrost log level to a Zerolog level.
@@ -51,25 +58,37 @@ func NewDefaultLogger(level schemas.LogLevel) *DefaultLogger {
 // Debug logs a debug level message to stdout.
 // Messages are only output if the logger's level is set to LogLevelDebug.
 func (logger *DefaultLogger) Debug(msg string, args ...any) {
-	logger.stdoutLogger.Debug().Msgf(msg, args...)
+	if !logger.sampled() {
+		return
+	}
+	logger.stdoutLogger.Debug().Fields(logger.fields).Msgf(msg, args...)
 }
 
 // Info logs an info level message to stdout.
 // Messages are output if the logger's level is LogLevelDebug or LogLevelInfo.
 func (logger *DefaultLogger) Info(msg string, args ...any) {
-	logger.stdoutLogger.Info().Msgf(msg, args...)
+	if !logger.sampled() {
+		return
+	}
+	logger.stdoutLogger.Info().Fields(logger.fields).Msgf(msg, args...)
 }
 
 // Warn logs a warning level message to stdout.
 // Messages are output if the logger's level is LogLevelDebug, LogLevelInfo, or LogLevelWarn.
 func (logger *DefaultLogger) Warn(msg string, args ...any) {
-	logger.stdoutLogger.Warn().Msgf(msg, args...)
+	if !logger.sampled() {
+		return
+	}
+	logger.stdoutLogger.Warn().Fields(logger.fields).Msgf(msg, args...)
 }
 
 // Error logs an error level message to stderr.
 // Error messages are always output regardless of the logger's level.
 func (logger *DefaultLogger) Error(msg string, args ...any) {
-	logger.stderrLogger.Error().Msgf(msg, args...)
+	if !logger.sampled() {
+		return
+	}
+	logger.stderrLogger.Error().Fields(logger.fields).Msgf(msg, args...)
 }
 
 // Fatal logs a fatal-level message to stderr.
@@ -85,9 +104,9 @@ func (logger *DefaultLogger) Fatal(msg string, args ...any) {
 		}
 	}
 	if errToPass != nil {
-		logger.stderrLogger.Fatal().Msgf(msg, errToPass)
+		logger.stderrLogger.Fatal().Fields(logger.fields).Msgf(msg, errToPass)
 	} else {
-		logger.stderrLogger.Fatal().Msgf(msg, args...)
+		logger.stderrLogger.Fatal().Fields(logger.fields).Msgf(msg, args...)
 	}
 }
 
@@ -102,7 +121,7 @@ func (logger *DefaultLogger) SetLevel(level schemas.LogLevel) {
 // If the output type is unknown, it defaults to JSON
 func (logger *DefaultLogger) SetOutputType(outputType schemas.LoggerOutputType) {
 	switch outputType {
-	case schemas.LoggerOutputTypePretty:
+	case schemas.LoggerOutputTypePretty, schemas.LoggerOutputTypeConsole:
 		logger.stdoutLogger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout}).With().Timestamp().Logger()
 		logger.stderrLogger = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
 	case schemas.LoggerOutputTypeJSON:
@@ -115,3 +134,115 @@ func (logger *DefaultLogger) SetOutputType(outputType schemas.LoggerOutputType)
 		logger.stdoutLogger = zerolog.New(os.Stdout).With().Timestamp().Logger()
 	}
 }
+
+// With returns a child logger that attaches the given key-value pairs to every message.
+// The child shares the level and output of its parent.
+func (logger *DefaultLogger) With(keyvals ...any) schemas.Logger {
+	child := *logger
+	child.fields = append(append(make([]any, 0, len(logger.fields)+len(keyvals)), logger.fields...), keyvals...)
+	return &child
+}
+
+// Sample returns a child logger that only writes one of every n messages.
+func (logger *DefaultLogger) Sample(n uint32) schemas.Logger {
+	child := *logger
+	child.sampleN = n
+	child.counter = &atomic.Uint32{}
+	return &child
+}
+
+// sampled reports whether the current message should be written.
+func (logger *DefaultLogger) sampled() bool {
+	if logger.sampleN <= 1 || logger.counter == nil {
+		return true
+	}
+	return (logger.counter.Add(1)-1)%logger.sampleN == 0
+}
+
+// WithFields returns a logger that attaches the given key-value pairs to every message.
+// Loggers that do not implement schemas.StructuredLogger get the fields appended to the message text.
+func WithFields(logger schemas.Logger, keyvals ...any) schemas.Logger {
+	if structured, ok := logger.(schemas.StructuredLogger); ok {
+		return structured.With(keyvals...)
+	}
+	return &fieldsLogger{Logger: logger, suffix: formatFields(keyvals)}
+}
+
+// Sampled returns a logger that only writes one of every n messages, for use on hot paths.
+// Loggers that do not implement schemas.StructuredLogger are sampled by a wrapper.
+func Sampled(logger schemas.Logger, n uint32) schemas.Logger {
+	if structured, ok := logger.(schemas.StructuredLogger); ok {
+		return structured.Sample(n)
+	}
+	return &sampledLogger{Logger: logger, n: n}
+}
+
+// formatFields renders key-value pairs as " key=value" text.
+func formatFields(keyvals []any) string {
+	var sb strings.Builder
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&sb, " %v=%v", keyvals[i], keyvals[i+1])
+	}
+	return sb.String()
+}
+
+// fieldsLogger adds key-value pairs to the messages of a plain schemas.Logger.
+type fieldsLogger struct {
+	schemas.Logger
+	suffix string
+}
+
+func (l *fieldsLogger) Debug(msg string, args ...any) {
+	l.Logger.Debug(msg+"%s", append(args, l.suffix)...)
+}
+
+func (l *fieldsLogger) Info(msg string, args ...any) {
+	l.Logger.Info(msg+"%s", append(args, l.suffix)...)
+}
+
+func (l *fieldsLogger) Warn(msg string, args ...any) {
+	l.Logger.Warn(msg+"%s", append(args, l.suffix)...)
+}
+
+func (l *fieldsLogger) Error(msg string, args ...any) {
+	l.Logger.Error(msg+"%s", append(args, l.suffix)...)
+}
+
+func (l *fieldsLogger) Fatal(msg string, args ...any) {
+	l.Logger.Fatal(msg+"%s", append(args, l.suffix)...)
+}
+
+// sampledLogger drops all but one of every n messages of a plain schemas.Logger.
+type sampledLogger struct {
+	schemas.Logger
+	n       uint32
+	counter atomic.Uint32
+}
+
+func (l *sampledLogger) sampled() bool {
+	return l.n <= 1 || (l.counter.Add(1)-1)%l.n == 0
+}
+
+func (l *sampledLogger) Debug(msg string, args ...any) {
+	if l.sampled() {
+		l.Logger.Debug(msg, args...)
+	}
+}
+
+func (l *sampledLogger) Info(msg string, args ...any) {
+	if l.sampled() {
+		l.Logger.Info(msg, args...)
+	}
+}
+
+func (l *sampledLogger) Warn(msg string, args ...any) {
+	if l.sampled() {
+		l.Logger.Warn(msg, args...)
+	}
+}
+
+func (l *sampledLogger) Error(msg string, args ...any) {
+	if l.sampled() {
+		l.Logger.Error(msg, args...)
+	}
+}
@@ -0,0 +1,52 @@
+package bifrost
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// pluginErrorType is the error type of requests failed by plugins that fail closed.
+const pluginErrorType = "plugin_error"
+
+// resolvePluginPolicy returns the policy of a plugin: its override when there is one, else the one it declares.
+func resolvePluginPolicy(plugin schemas.Plugin, overrides map[string]schemas.PluginPolicy) schemas.PluginPolicy {
+	if policy, ok := overrides[plugin.GetName()]; ok {
+		return policy
+	}
+	if declared, ok := plugin.(schemas.PolicyPlugin); ok {
+		return declared.PluginPolicy()
+	}
+	return schemas.PluginPolicy{}
+}
+
+// sortPlugins returns the plugins in the order of their priority, highest first, keeping the registration order of
+// plugins of the same priority.
+func sortPlugins(plugins []schemas.Plugin, overrides map[string]schemas.PluginPolicy) []schemas.Plugin {
+	sorted := slices.Clone(plugins)
+	slices.SortStableFunc(sorted, func(a, b schemas.Plugin) int {
+		return cmp.Compare(resolvePluginPolicy(b, overrides).Priority, resolvePluginPolicy(a, overrides).Priority)
+	})
+	return sorted
+}
+
+// newPluginError is the error of a request failed by a hook of a plugin that fails closed. Fallbacks are not tried,
+// since they run the same plugins.
+func newPluginError(plugin schemas.Plugin, hook string, err error) *schemas.BifrostError {
+	statusCode := 500
+	errorType := pluginErrorType
+	allowFallbacks := false
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		AllowFallbacks: &allowFallbacks,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Message: fmt.Sprintf("%s of plugin %s failed: %v", hook, plugin.GetName(), err),
+			Error:   err,
+		},
+	}
+}
@@ -0,0 +1,160 @@
+package bifrost
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// pluginStateSweepInterval is how often writes to the in-memory plugin state remove expired values.
+const pluginStateSweepInterval = time.Minute
+
+// pluginStateEntry is a value of the in-memory plugin state, with its expiry (zero for none).
+type pluginStateEntry struct {
+	value     any
+	expiresAt time.Time
+}
+
+func (e *pluginStateEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// InMemoryPluginState is the default PluginState of Bifrost instances, held in the memory of the process.
+type InMemoryPluginState struct {
+	mu        sync.Mutex
+	entries   map[string]map[string]*pluginStateEntry // By namespace, then key
+	lastSweep time.Time
+}
+
+// NewInMemoryPluginState creates an empty in-memory plugin state.
+func NewInMemoryPluginState() *InMemoryPluginState {
+	return &InMemoryPluginState{entries: make(map[string]map[string]*pluginStateEntry), lastSweep: time.Now()}
+}
+
+// load returns the live entry of a key. Callers must hold the lock.
+func (s *InMemoryPluginState) load(namespace, key string, now time.Time) *pluginStateEntry {
+	entry, ok := s.entries[namespace][key]
+	if !ok {
+		return nil
+	}
+	if entry.expired(now) {
+		s.remove(namespace, key)
+		return nil
+	}
+	return entry
+}
+
+// store sets the entry of a key, and sweeps expired entries when due. Callers must hold the lock.
+func (s *InMemoryPluginState) store(namespace, key string, value any, ttl time.Duration, now time.Time) {
+	if now.Sub(s.lastSweep) >= pluginStateSweepInterval {
+		s.sweep(now)
+	}
+	entries, ok := s.entries[namespace]
+	if !ok {
+		entries = make(map[string]*pluginStateEntry)
+		s.entries[namespace] = entries
+	}
+	entry := &pluginStateEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+	entries[key] = entry
+}
+
+// remove deletes a key, and its namespace once empty. Callers must hold the lock.
+func (s *InMemoryPluginState) remove(namespace, key string) {
+	delete(s.entries[namespace], key)
+	if len(s.entries[namespace]) == 0 {
+		delete(s.entries, namespace)
+	}
+}
+
+// sweep removes the expired entries. Callers must hold the lock.
+func (s *InMemoryPluginState) sweep(now time.Time) {
+	for namespace, entries := range s.entries {
+		for key, entry := range entries {
+			if entry.expired(now) {
+				s.remove(namespace, key)
+			}
+		}
+	}
+	s.lastSweep = now
+}
+
+// Get returns the value of a key, and whether it is set.
+func (s *InMemoryPluginState) Get(namespace, key string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry := s.load(namespace, key, time.Now()); entry != nil {
+		return entry.value, true
+	}
+	return nil, false
+}
+
+// Set sets the value of a key, replacing the current one and its TTL.
+func (s *InMemoryPluginState) Set(namespace, key string, value any, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store(namespace, key, value, ttl, time.Now())
+}
+
+// SetIfAbsent sets the value of a key when it is not set, returning the value it holds afterwards and whether it is
+// the one given.
+func (s *InMemoryPluginState) SetIfAbsent(namespace, key string, value any, ttl time.Duration) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if entry := s.load(namespace, key, now); entry != nil {
+		return entry.value, false
+	}
+	s.store(namespace, key, value, ttl, now)
+	return value, true
+}
+
+// CompareAndSwap replaces the value of a key with new when it is old, keeping its TTL.
+func (s *InMemoryPluginState) CompareAndSwap(namespace, key string, old, new any) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.load(namespace, key, time.Now())
+	if entry == nil || entry.value != old {
+		return false
+	}
+	entry.value = new
+	return true
+}
+
+// Increment adds delta to the int64 counter of a key, creating it with the TTL when missing.
+func (s *InMemoryPluginState) Increment(namespace, key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	entry := s.load(namespace, key, now)
+	if entry == nil {
+		s.store(namespace, key, delta, ttl, now)
+		return delta, nil
+	}
+	counter, ok := entry.value.(int64)
+	if !ok {
+		return 0, schemas.ErrPluginStateNotCounter
+	}
+	entry.value = counter + delta
+	return counter + delta, nil
+}
+
+// Delete removes a key.
+func (s *InMemoryPluginState) Delete(namespace, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(namespace, key)
+}
+
+// GetPluginState returns the plugin state Bifrost set in the context of a request, or nil outside of requests.
+func GetPluginState(ctx context.Context) schemas.PluginState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(schemas.BifrostContextKeyPluginState).(schemas.PluginState)
+	return state
+}
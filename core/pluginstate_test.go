@@ -0,0 +1,121 @@
+package bifrost
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// TestInMemoryPluginState tests the operations of the in-memory plugin state, namespaces keeping keys apart and
+// expired values not being returned
+func TestInMemoryPluginState(t *testing.T) {
+	var state schemas.PluginState = NewInMemoryPluginState()
+
+	state.Set("first", "key", "a", 0)
+	if value, ok := state.Get("first", "key"); !ok || value != "a" {
+		t.Errorf("Get = %v, %v, want a", value, ok)
+	}
+	if _, ok := state.Get("second", "key"); ok {
+		t.Error("expected namespaces to keep keys apart")
+	}
+
+	if value, set := state.SetIfAbsent("first", "key", "b", 0); set || value != "a" {
+		t.Errorf("SetIfAbsent on a set key = %v, %v, want a, false", value, set)
+	}
+	if value, set := state.SetIfAbsent("second", "key", "b", 0); !set || value != "b" {
+		t.Errorf("SetIfAbsent on a missing key = %v, %v, want b, true", value, set)
+	}
+
+	if state.CompareAndSwap("first", "key", "b", "c") {
+		t.Error("expected CompareAndSwap to fail on another value")
+	}
+	if !state.CompareAndSwap("first", "key", "a", "c") {
+		t.Error("expected CompareAndSwap to succeed on the current value")
+	}
+	if state.CompareAndSwap("first", "missing", nil, "c") {
+		t.Error("expected CompareAndSwap to fail on a missing key")
+	}
+
+	if _, err := state.Increment("first", "key", 1, 0); !errors.Is(err, schemas.ErrPluginStateNotCounter) {
+		t.Errorf("Increment of a string = %v, want ErrPluginStateNotCounter", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := state.Increment("first", "counter", 2, 0); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if value, _ := state.Get("first", "counter"); value != int64(200) {
+		t.Errorf("counter = %v, want 200", value)
+	}
+
+	state.Delete("first", "key")
+	if _, ok := state.Get("first", "key"); ok {
+		t.Error("expected Delete to remove the key")
+	}
+
+	state.Set("first", "short", "a", 10*time.Millisecond)
+	if _, err := state.Increment("first", "window", 1, 10*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := state.Get("first", "short"); ok {
+		t.Error("expected the value to expire")
+	}
+	if _, set := state.SetIfAbsent("first", "short", "b", 0); !set {
+		t.Error("expected SetIfAbsent to replace the expired value")
+	}
+	if counter, _ := state.Increment("first", "window", 1, time.Minute); counter != 1 {
+		t.Errorf("counter = %d after its window expired, want 1", counter)
+	}
+}
+
+// TestInMemoryPluginState_Sweep tests that writes remove the expired values of every namespace once due
+func TestInMemoryPluginState_Sweep(t *testing.T) {
+	state := NewInMemoryPluginState()
+	state.Set("first", "key", "a", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	state.mu.Lock()
+	state.lastSweep = time.Now().Add(-pluginStateSweepInterval)
+	state.mu.Unlock()
+	state.Set("second", "key", "b", 0)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if _, ok := state.entries["first"]; ok {
+		t.Error("expected the sweep to remove the expired value and its namespace")
+	}
+}
+
+// TestBifrost_PluginState tests that Bifrost keeps the plugin state it is given, and creates an in-memory one
+// otherwise
+func TestBifrost_PluginState(t *testing.T) {
+	state := NewInMemoryPluginState()
+	client, err := Init(context.Background(), schemas.BifrostConfig{Account: streamTestAccount{}, PluginState: state, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+	if client.PluginState() != schemas.PluginState(state) {
+		t.Error("expected the given plugin state to be kept")
+	}
+
+	client, err = Init(context.Background(), schemas.BifrostConfig{Account: streamTestAccount{}, Logger: NewDefaultLogger(schemas.LogLevelError)})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+	if _, ok := client.PluginState().(*InMemoryPluginState); !ok {
+		t.Errorf("expected an in-memory plugin state, got %T", client.PluginState())
+	}
+}
@@ -99,6 +99,9 @@ func NewAnthropicProvider(config *schemas.ProviderConfig, logger schemas.Logger)
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(getProviderName(schemas.Anthropic, config.CustomProviderConfig), config, client, streamClient, logger)
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.anthropic.com"
@@ -323,6 +326,11 @@ func (provider *AnthropicProvider) Embedding(ctx context.Context, key schemas.Ke
 	return nil, newUnsupportedOperationError("embedding", "anthropic")
 }
 
+// Rerank is not supported by the Anthropic provider.
+func (provider *AnthropicProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "anthropic")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to the Anthropic API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Returns a channel containing BifrostResponse objects representing the stream or an error if the request fails.
@@ -406,9 +414,11 @@ func handleAnthropicStreaming(
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, extraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Make the request
-	resp, err := httpClient.Do(req)
+	resp, err := doHTTPRequest(httpClient, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -430,7 +440,7 @@ func handleAnthropicStreaming(
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, newProviderAPIError(fmt.Sprintf("HTTP error from %s: %d", providerType, resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, providerType, nil, nil)
+		return nil, setRetryAfter(newProviderAPIError(fmt.Sprintf("HTTP error from %s: %d", providerType, resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, providerType, nil, nil), resp.Header.Get)
 	}
 
 	// Create response channel
@@ -484,11 +494,7 @@ func handleAnthropicStreaming(
 			}
 
 			if event.Usage != nil {
-				usage = &schemas.LLMUsage{
-					PromptTokens:     event.Usage.InputTokens,
-					CompletionTokens: event.Usage.OutputTokens,
-					TotalTokens:      event.Usage.InputTokens + event.Usage.OutputTokens,
-				}
+				usage = event.Usage.ToBifrostUsage()
 			}
 			if event.Delta != nil && event.Delta.StopReason != nil {
 				mappedReason := anthropic.MapAnthropicFinishReasonToBifrost(*event.Delta.StopReason)
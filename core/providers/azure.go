@@ -46,6 +46,9 @@ func NewAzureProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*A
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.Azure, config, client, streamClient, logger)
+
 	return &AzureProvider{
 		logger:              logger,
 		client:              client,
@@ -324,6 +327,11 @@ func (provider *AzureProvider) Embedding(ctx context.Context, key schemas.Key, r
 	return response, nil
 }
 
+// Rerank is not supported by the Azure provider.
+func (provider *AzureProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "azure")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to Azure's OpenAI API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Uses Azure-specific URL construction with deployments and supports both api-key and Bearer token authentication.
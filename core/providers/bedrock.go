@@ -66,6 +66,9 @@ func NewBedrockProvider(config *schemas.ProviderConfig, logger schemas.Logger) (
 
 	client := &http.Client{Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds)}
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(getProviderName(schemas.Bedrock, config.CustomProviderConfig), config, nil, client, logger)
+
 	// Pre-warm response pools
 	for range config.ConcurrencyAndBufferSize.Concurrency {
 		bedrockChatResponsePool.Put(&bedrock.BedrockConverseResponse{})
@@ -131,6 +134,8 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// If Value is set, use API Key authentication - else use IAM role authentication
 	if key.Value != "" {
@@ -144,7 +149,7 @@ func (provider *BedrockProvider) completeRequest(ctx context.Context, requestBod
 
 	// Execute the request and measure latency
 	startTime := time.Now()
-	resp, err := provider.client.Do(req)
+	resp, err := doHTTPRequest(provider.client, req)
 	latency := time.Since(startTime)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
@@ -558,6 +563,11 @@ func (provider *BedrockProvider) Embedding(ctx context.Context, key schemas.Key,
 	return bifrostResponse, nil
 }
 
+// Rerank is not supported by the Bedrock provider.
+func (provider *BedrockProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "bedrock")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to Bedrock's API.
 // It formats the request, sends it to Bedrock, and processes the streaming response.
 // Returns a channel for streaming BifrostResponse objects or an error if the request fails.
@@ -599,6 +609,8 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// If Value is set, use API Key authentication - else use IAM role authentication
 	if key.Value != "" {
@@ -611,7 +623,7 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 	}
 
 	// Make the request
-	resp, respErr := provider.client.Do(req)
+	resp, respErr := doHTTPRequest(provider.client, req)
 	if respErr != nil {
 		if errors.Is(respErr, fasthttp.ErrTimeout) || errors.Is(respErr, context.Canceled) || errors.Is(respErr, context.DeadlineExceeded) {
 			return nil, newBifrostOperationError(schemas.ErrProviderRequestTimedOut, respErr, provider.GetProviderKey())
@@ -623,7 +635,7 @@ func (provider *BedrockProvider) ChatCompletionStream(ctx context.Context, postH
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, newProviderAPIError(fmt.Sprintf("HTTP error from %s: %d", providerName, resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, providerName, nil, nil)
+		return nil, setRetryAfter(newProviderAPIError(fmt.Sprintf("HTTP error from %s: %d", providerName, resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, providerName, nil, nil), resp.Header.Get)
 	}
 
 	// Create response channel
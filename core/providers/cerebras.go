@@ -41,6 +41,9 @@ func NewCerebrasProvider(config *schemas.ProviderConfig, logger schemas.Logger)
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.Cerebras, config, client, streamClient, logger)
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.cerebras.ai"
@@ -129,6 +132,11 @@ func (provider *CerebrasProvider) Embedding(ctx context.Context, key schemas.Key
 	return nil, newUnsupportedOperationError("embedding", "cerebras")
 }
 
+// Rerank is not supported by the Cerebras provider.
+func (provider *CerebrasProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "cerebras")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to the Cerebras API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Uses Cerebras's OpenAI-compatible streaming format.
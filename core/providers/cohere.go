@@ -69,6 +69,9 @@ func NewCohereProvider(config *schemas.ProviderConfig, logger schemas.Logger) *C
 		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
 	}
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(getProviderName(schemas.Cohere, config.CustomProviderConfig), config, client, streamClient, logger)
+
 	// Pre-warm response pools
 	for i := 0; i < config.ConcurrencyAndBufferSize.Concurrency; i++ {
 		cohereResponsePool.Put(&cohere.CohereChatResponse{})
@@ -342,6 +345,38 @@ func (provider *CohereProvider) Embedding(ctx context.Context, key schemas.Key,
 	return bifrostResponse, nil
 }
 
+// Rerank orders documents by their relevance to a query using the Cohere v2 rerank API.
+// Requests with more documents than a single call accepts are reranked in batches.
+func (provider *CohereProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	// Check if rerank is allowed
+	if err := checkOperationAllowed(schemas.Cohere, provider.customProviderConfig, schemas.RerankRequest); err != nil {
+		return nil, err
+	}
+
+	providerName := provider.GetProviderKey()
+
+	return rerankInBatches(request, func(documents []string, topN *int) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		var cohereResp cohere.CohereRerankResponse
+		rawResponse, latency, bifrostErr := handleRerankRequest(
+			ctx,
+			provider.client,
+			provider.networkConfig.BaseURL+"/v2/rerank",
+			key,
+			provider.networkConfig.ExtraHeaders,
+			providerName,
+			cohere.ToCohereRerankRequest(request, documents, topN),
+			&cohereResp,
+			func(errorResp *cohere.CohereError) string { return errorResp.Message },
+			provider.sendBackRawResponse,
+			provider.logger,
+		)
+		if bifrostErr != nil {
+			return nil, bifrostErr
+		}
+		return finishRerankResponse(cohereResp.ToBifrostResponse(), request, providerName, latency, rawResponse, provider.sendBackRawResponse), nil
+	})
+}
+
 // ChatCompletionStream performs a streaming chat completion request to the Cohere API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Returns a channel containing BifrostResponse objects representing the stream or an error if the request fails.
@@ -391,9 +426,11 @@ func (provider *CohereProvider) ChatCompletionStream(ctx context.Context, postHo
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Make the request
-	resp, err := provider.streamClient.Do(req)
+	resp, err := doHTTPRequest(provider.streamClient, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -421,7 +458,7 @@ func (provider *CohereProvider) ChatCompletionStream(ctx context.Context, postHo
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
-		return nil, newProviderAPIError(fmt.Sprintf("HTTP error from %s: %d", providerName, resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, providerName, nil, nil)
+		return nil, setRetryAfter(newProviderAPIError(fmt.Sprintf("HTTP error from %s: %d", providerName, resp.StatusCode), fmt.Errorf("%s", string(body)), resp.StatusCode, providerName, nil, nil), resp.Header.Get)
 	}
 
 	// Create response channel
@@ -0,0 +1,754 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the declarative provider implementation.
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bytedance/sonic"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/providers/openai"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultDeclarativeAuthHeader   = "Authorization"
+	defaultDeclarativeAuthTemplate = "Bearer {{.Key}}"
+	defaultDeclarativeStreamDone   = "[DONE]"
+)
+
+// DeclarativeProvider implements the Provider interface for custom providers defined by a ProviderSpec. Requests
+// are built from the OpenAI format of Bifrost requests and responses read with the path expressions of the spec.
+type DeclarativeProvider struct {
+	logger               schemas.Logger                // Logger for provider operations
+	client               *fasthttp.Client              // HTTP client for API requests
+	streamClient         *http.Client                  // HTTP client for streaming requests
+	networkConfig        schemas.NetworkConfig         // Network configuration including extra headers
+	sendBackRawResponse  bool                          // Whether to include raw response in BifrostResponse
+	customProviderConfig *schemas.CustomProviderConfig // Custom provider config, carrying the spec
+	headers              map[string]string             // Static headers of the spec
+	authHeader           string
+	auth                 *template.Template
+	operations           map[schemas.RequestType]*declarativeOperation // By non-streaming request type
+}
+
+// declarativeOperation is an operation of the spec with its templates and expressions parsed.
+type declarativeOperation struct {
+	method        string
+	path          *template.Template
+	request       []declarativeField
+	response      declarativeResponse
+	stream        *schemas.ProviderSpecStream // nil when the operation is not streamed
+	streamRequest []declarativeField          // Request with the fields added for streaming
+	chunk         declarativeResponse
+}
+
+// declarativeField is a field of request bodies, set to the value of expr or, without expr, to value.
+type declarativeField struct {
+	path  []string
+	expr  *schemas.SpecExpression
+	value any
+}
+
+// declarativeResponse holds the parsed expressions of a response mapping, nil when not mapped.
+type declarativeResponse struct {
+	id, content, finishReason, promptTokens, completionTokens, embeddings, error *schemas.SpecExpression
+}
+
+// declarativeTemplateData is passed to the auth and path templates.
+type declarativeTemplateData struct {
+	Key   string
+	Model string
+}
+
+// NewDeclarativeProvider creates a new declarative provider instance from the spec of its custom provider config.
+// It fails when the spec is missing or invalid, or when network_config.base_url is not set.
+func NewDeclarativeProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*DeclarativeProvider, error) {
+	config.CheckAndSetDefaults()
+
+	if config.CustomProviderConfig == nil || config.CustomProviderConfig.Spec == nil {
+		return nil, fmt.Errorf("declarative providers require custom_provider_config.spec")
+	}
+	spec := config.CustomProviderConfig.Spec
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	if config.NetworkConfig.BaseURL == "" {
+		return nil, fmt.Errorf("declarative providers require network_config.base_url")
+	}
+	config.NetworkConfig.BaseURL = strings.TrimRight(config.NetworkConfig.BaseURL, "/")
+
+	client := &fasthttp.Client{
+		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
+	}
+	streamClient := &http.Client{
+		Timeout: time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+	}
+
+	// Configure proxy if provided
+	client = configureProxy(client, config.ProxyConfig, logger)
+
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(getProviderName(schemas.Declarative, config.CustomProviderConfig), config, client, streamClient, logger)
+
+	authHeader := spec.AuthHeader
+	if authHeader == "" {
+		authHeader = defaultDeclarativeAuthHeader
+	}
+	authTemplate := spec.AuthTemplate
+	if authTemplate == "" {
+		authTemplate = defaultDeclarativeAuthTemplate
+	}
+
+	// Templates and expressions were checked by Validate
+	operations := make(map[schemas.RequestType]*declarativeOperation)
+	for requestType, operation := range map[schemas.RequestType]*schemas.ProviderSpecOperation{
+		schemas.ChatCompletionRequest: spec.ChatCompletion,
+		schemas.TextCompletionRequest: spec.TextCompletion,
+		schemas.EmbeddingRequest:      spec.Embedding,
+	} {
+		if operation != nil {
+			operations[requestType] = newDeclarativeOperation(operation)
+		}
+	}
+
+	return &DeclarativeProvider{
+		logger:               logger,
+		client:               client,
+		streamClient:         streamClient,
+		networkConfig:        config.NetworkConfig,
+		sendBackRawResponse:  config.SendBackRawResponse,
+		customProviderConfig: config.CustomProviderConfig,
+		headers:              spec.Headers,
+		authHeader:           authHeader,
+		auth:                 template.Must(template.New("auth").Parse(authTemplate)),
+		operations:           operations,
+	}, nil
+}
+
+// newDeclarativeOperation parses the templates and expressions of a validated operation.
+func newDeclarativeOperation(operation *schemas.ProviderSpecOperation) *declarativeOperation {
+	method := operation.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	op := &declarativeOperation{
+		method:   strings.ToUpper(method),
+		path:     template.Must(template.New("path").Parse(operation.Path)),
+		request:  newDeclarativeFields(operation.Request),
+		response: newDeclarativeResponse(operation.Response),
+	}
+	if operation.Stream != nil {
+		op.stream = operation.Stream
+		op.streamRequest = append(newDeclarativeFields(operation.Request), newDeclarativeFields(operation.Stream.Request)...)
+		op.chunk = newDeclarativeResponse(operation.Stream.Chunk)
+	}
+	return op
+}
+
+// newDeclarativeFields parses a request mapping, sorted by field so bodies are built in a stable order.
+func newDeclarativeFields(request map[string]any) []declarativeField {
+	fields := make([]declarativeField, 0, len(request))
+	for field, value := range request {
+		f := declarativeField{path: strings.Split(field, "."), value: value}
+		if text, ok := value.(string); ok && strings.HasPrefix(text, ".") {
+			f.expr, _ = schemas.ParseSpecExpression(text)
+		}
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return strings.Join(fields[i].path, ".") < strings.Join(fields[j].path, ".")
+	})
+	return fields
+}
+
+// newDeclarativeResponse parses a response mapping.
+func newDeclarativeResponse(response schemas.ProviderSpecResponse) declarativeResponse {
+	parse := func(expr string) *schemas.SpecExpression {
+		if expr == "" {
+			return nil
+		}
+		parsed, _ := schemas.ParseSpecExpression(expr)
+		return parsed
+	}
+	return declarativeResponse{
+		id:               parse(response.ID),
+		content:          parse(response.Content),
+		finishReason:     parse(response.FinishReason),
+		promptTokens:     parse(response.PromptTokens),
+		completionTokens: parse(response.CompletionTokens),
+		embeddings:       parse(response.Embeddings),
+		error:            parse(response.Error),
+	}
+}
+
+// GetProviderKey returns the name of the custom provider.
+func (provider *DeclarativeProvider) GetProviderKey() schemas.ModelProvider {
+	return getProviderName(schemas.Declarative, provider.customProviderConfig)
+}
+
+// TextCompletion sends a text completion request as defined by the text_completion operation of the spec.
+func (provider *DeclarativeProvider) TextCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	operation, bifrostErr := provider.operation(schemas.TextCompletionRequest, false)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	response, bifrostErr := provider.complete(ctx, key, operation, schemas.TextCompletionRequest, request.Model, openai.ToOpenAITextCompletionRequest(request))
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	response.ToTextCompletionResponse()
+	response.ExtraFields.RequestType = schemas.TextCompletionRequest
+	return response, nil
+}
+
+// TextCompletionStream streams a text completion as defined by the stream of the text_completion operation.
+func (provider *DeclarativeProvider) TextCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	operation, bifrostErr := provider.operation(schemas.TextCompletionRequest, true)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	return provider.stream(ctx, postHookRunner, key, operation, schemas.TextCompletionStreamRequest, request.Model, openai.ToOpenAITextCompletionRequest(request))
+}
+
+// ChatCompletion sends a chat completion request as defined by the chat_completion operation of the spec.
+func (provider *DeclarativeProvider) ChatCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostChatRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	operation, bifrostErr := provider.operation(schemas.ChatCompletionRequest, false)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	return provider.complete(ctx, key, operation, schemas.ChatCompletionRequest, request.Model, openai.ToOpenAIChatRequest(request))
+}
+
+// ChatCompletionStream streams a chat completion as defined by the stream of the chat_completion operation.
+func (provider *DeclarativeProvider) ChatCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostChatRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	operation, bifrostErr := provider.operation(schemas.ChatCompletionRequest, true)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	return provider.stream(ctx, postHookRunner, key, operation, schemas.ChatCompletionStreamRequest, request.Model, openai.ToOpenAIChatRequest(request))
+}
+
+// Responses answers a Responses API request through ChatCompletion.
+func (provider *DeclarativeProvider) Responses(ctx context.Context, key schemas.Key, request *schemas.BifrostResponsesRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	if err := checkOperationAllowed(schemas.Declarative, provider.customProviderConfig, schemas.ResponsesRequest); err != nil {
+		return nil, err
+	}
+	response, err := provider.ChatCompletion(ctx, key, request.ToChatRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	response.ToResponsesOnly()
+	response.ExtraFields.RequestType = schemas.ResponsesRequest
+	response.ExtraFields.Provider = provider.GetProviderKey()
+	response.ExtraFields.ModelRequested = request.Model
+
+	return response, nil
+}
+
+// ResponsesStream is not supported by declarative providers.
+func (provider *DeclarativeProvider) ResponsesStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostResponsesRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("responses stream", string(provider.GetProviderKey()))
+}
+
+// Embedding sends an embedding request as defined by the embedding operation of the spec.
+func (provider *DeclarativeProvider) Embedding(ctx context.Context, key schemas.Key, request *schemas.BifrostEmbeddingRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	operation, bifrostErr := provider.operation(schemas.EmbeddingRequest, false)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	body, rawResponse, latency, bifrostErr := provider.call(ctx, key, operation, operation.request, schemas.EmbeddingRequest, request.Model, openai.ToOpenAIEmbeddingRequest(request))
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	vectors := declarativeVectors(operation.response.embeddings.Evaluate(body))
+	if vectors == nil {
+		return nil, newBifrostOperationError("no embeddings found at "+provider.customProviderConfig.Spec.Embedding.Response.Embeddings, nil, provider.GetProviderKey())
+	}
+	data := make([]schemas.BifrostEmbedding, len(vectors))
+	for i, vector := range vectors {
+		data[i] = schemas.BifrostEmbedding{
+			Index:     i,
+			Object:    "embedding",
+			Embedding: schemas.BifrostEmbeddingResponse{EmbeddingArray: vector},
+		}
+	}
+	response := &schemas.BifrostResponse{
+		ID:     declarativeString(evaluateSpec(operation.response.id, body)),
+		Object: "list",
+		Model:  request.Model,
+		Data:   data,
+		Usage:  declarativeUsage(operation.response, body),
+		ExtraFields: schemas.BifrostResponseExtraFields{
+			RequestType:    schemas.EmbeddingRequest,
+			Provider:       provider.GetProviderKey(),
+			ModelRequested: request.Model,
+			Latency:        latency.Milliseconds(),
+		},
+	}
+	if provider.sendBackRawResponse {
+		response.ExtraFields.RawResponse = rawResponse
+	}
+	return response, nil
+}
+
+// Rerank is not supported by declarative providers.
+func (provider *DeclarativeProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", string(provider.GetProviderKey()))
+}
+
+// Speech is not supported by declarative providers.
+func (provider *DeclarativeProvider) Speech(ctx context.Context, key schemas.Key, request *schemas.BifrostSpeechRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech", string(provider.GetProviderKey()))
+}
+
+// SpeechStream is not supported by declarative providers.
+func (provider *DeclarativeProvider) SpeechStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostSpeechRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech stream", string(provider.GetProviderKey()))
+}
+
+// Transcription is not supported by declarative providers.
+func (provider *DeclarativeProvider) Transcription(ctx context.Context, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription", string(provider.GetProviderKey()))
+}
+
+// TranscriptionStream is not supported by declarative providers.
+func (provider *DeclarativeProvider) TranscriptionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription stream", string(provider.GetProviderKey()))
+}
+
+// operation returns the operation of the spec serving a request type, failing when the spec does not define it,
+// does not stream it or when allowed_requests excludes it.
+func (provider *DeclarativeProvider) operation(requestType schemas.RequestType, stream bool) (*declarativeOperation, *schemas.BifrostError) {
+	gated := requestType
+	if stream {
+		gated = requestType + "_stream"
+	}
+	if err := checkOperationAllowed(schemas.Declarative, provider.customProviderConfig, gated); err != nil {
+		return nil, err
+	}
+	operation := provider.operations[requestType]
+	if operation == nil || (stream && operation.stream == nil) {
+		return nil, newUnsupportedOperationError(strings.ReplaceAll(string(gated), "_", " "), string(provider.GetProviderKey()))
+	}
+	return operation, nil
+}
+
+// complete sends a completion request and maps its response to a chat completion.
+func (provider *DeclarativeProvider) complete(ctx context.Context, key schemas.Key, operation *declarativeOperation, requestType schemas.RequestType, model string, openAIRequest any) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	body, rawResponse, latency, bifrostErr := provider.call(ctx, key, operation, operation.request, requestType, model, openAIRequest)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	content := declarativeString(operation.response.content.Evaluate(body))
+	choice := schemas.BifrostChatResponseChoice{
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+			Message: &schemas.ChatMessage{
+				Role:    schemas.ChatMessageRoleAssistant,
+				Content: &schemas.ChatMessageContent{ContentStr: &content},
+			},
+		},
+	}
+	if finishReason := declarativeString(evaluateSpec(operation.response.finishReason, body)); finishReason != "" {
+		choice.FinishReason = &finishReason
+	}
+	response := &schemas.BifrostResponse{
+		ID:      declarativeString(evaluateSpec(operation.response.id, body)),
+		Object:  "chat.completion",
+		Model:   model,
+		Created: int(time.Now().Unix()),
+		Choices: []schemas.BifrostChatResponseChoice{choice},
+		Usage:   declarativeUsage(operation.response, body),
+		ExtraFields: schemas.BifrostResponseExtraFields{
+			RequestType:    requestType,
+			Provider:       provider.GetProviderKey(),
+			ModelRequested: model,
+			Latency:        latency.Milliseconds(),
+		},
+	}
+	if provider.sendBackRawResponse {
+		response.ExtraFields.RawResponse = rawResponse
+	}
+	return response, nil
+}
+
+// call sends the request of an operation and returns its decoded response body, the raw response when
+// sendBackRawResponse is set, and the latency. Non-2xx responses are returned as errors.
+func (provider *DeclarativeProvider) call(ctx context.Context, key schemas.Key, operation *declarativeOperation, fields []declarativeField, requestType schemas.RequestType, model string, openAIRequest any) (any, any, time.Duration, *schemas.BifrostError) {
+	providerName := provider.GetProviderKey()
+	url, jsonBody, bifrostErr := provider.prepare(key, operation, fields, model, openAIRequest)
+	if bifrostErr != nil {
+		return nil, nil, 0, bifrostErr
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	setExtraHeaders(req, provider.networkConfig.ExtraHeaders, nil)
+	req.SetRequestURI(url)
+	req.Header.SetMethod(operation.method)
+	req.Header.SetContentType("application/json")
+	for name, value := range provider.headers {
+		req.Header.Set(name, value)
+	}
+	if auth, ok := provider.authorization(key, model); ok {
+		req.Header.Set(provider.authHeader, auth)
+	}
+	if operation.method != http.MethodGet {
+		req.SetBody(jsonBody)
+	}
+
+	latency, bifrostErr := makeRequestWithContext(ctx, provider.client, req, resp)
+	if bifrostErr != nil {
+		return nil, nil, latency, bifrostErr
+	}
+
+	var body any
+	decodeErr := sonic.Unmarshal(resp.Body(), &body)
+	if resp.StatusCode() < 200 || resp.StatusCode() > 299 {
+		provider.logger.Debug(fmt.Sprintf("error from %s provider: %s", providerName, string(resp.Body())))
+		bifrostErr := newProviderAPIError(declarativeErrorMessage(operation.response, body, resp.Body()), nil, resp.StatusCode(), providerName, nil, nil)
+		bifrostErr.ExtraFields = schemas.BifrostErrorExtraFields{Provider: providerName, ModelRequested: model, RequestType: requestType}
+		return nil, nil, latency, setRetryAfter(bifrostErr, func(name string) string { return string(resp.Header.Peek(name)) })
+	}
+	if decodeErr != nil {
+		return nil, nil, latency, newBifrostOperationError(schemas.ErrProviderDecodeStructured, decodeErr, providerName)
+	}
+
+	var rawResponse any
+	if provider.sendBackRawResponse {
+		rawResponse = body
+	}
+	return body, rawResponse, latency, nil
+}
+
+// prepare renders the URL of an operation and builds its request body from the OpenAI format of the request.
+func (provider *DeclarativeProvider) prepare(key schemas.Key, operation *declarativeOperation, fields []declarativeField, model string, openAIRequest any) (string, []byte, *schemas.BifrostError) {
+	providerName := provider.GetProviderKey()
+
+	var path strings.Builder
+	if err := operation.path.Execute(&path, declarativeTemplateData{Model: model}); err != nil {
+		return "", nil, newConfigurationError(fmt.Sprintf("failed to render the path of provider %s: %v", providerName, err), providerName)
+	}
+
+	encoded, err := sonic.Marshal(openAIRequest)
+	if err != nil {
+		return "", nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, providerName)
+	}
+	var document any
+	if err := sonic.Unmarshal(encoded, &document); err != nil {
+		return "", nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, providerName)
+	}
+
+	body := make(map[string]any)
+	for _, field := range fields {
+		value := field.value
+		if field.expr != nil {
+			value = field.expr.Evaluate(document)
+		}
+		if value == nil {
+			continue
+		}
+		setDeclarativeField(body, field.path, value)
+	}
+	jsonBody, err := sonic.Marshal(body)
+	if err != nil {
+		return "", nil, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, providerName)
+	}
+	return provider.networkConfig.BaseURL + path.String(), jsonBody, nil
+}
+
+// authorization renders the auth header of a key, false for keys without a value.
+func (provider *DeclarativeProvider) authorization(key schemas.Key, model string) (string, bool) {
+	if key.Value == "" {
+		return "", false
+	}
+	var auth strings.Builder
+	if err := provider.auth.Execute(&auth, declarativeTemplateData{Key: key.Value, Model: model}); err != nil {
+		provider.logger.Warn(fmt.Sprintf("failed to render the auth header of provider %s: %v", provider.GetProviderKey(), err))
+		return "", false
+	}
+	return auth.String(), true
+}
+
+// stream sends the streaming request of an operation and streams its chunks, read as SSE data lines or JSON lines.
+func (provider *DeclarativeProvider) stream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, operation *declarativeOperation, requestType schemas.RequestType, model string, openAIRequest any) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	providerName := provider.GetProviderKey()
+	url, jsonBody, bifrostErr := provider.prepare(key, operation, operation.streamRequest, model, openAIRequest)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	req, err := http.NewRequestWithContext(ctx, operation.method, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, newBifrostOperationError(schemas.ErrProviderRequest, err, providerName)
+	}
+	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cache-Control", "no-cache")
+	if operation.stream.Format == schemas.ProviderSpecStreamSSE {
+		req.Header.Set("Accept", "text/event-stream")
+	} else {
+		req.Header.Set("Accept", "application/x-ndjson")
+	}
+	for name, value := range provider.headers {
+		req.Header.Set(name, value)
+	}
+	if auth, ok := provider.authorization(key, model); ok {
+		req.Header.Set(provider.authHeader, auth)
+	}
+
+	resp, err := doHTTPRequest(provider.streamClient, req)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, &schemas.BifrostError{
+				IsBifrostError: false,
+				Error: &schemas.ErrorField{
+					Type:    schemas.Ptr(schemas.RequestCancelled),
+					Message: schemas.ErrRequestCancelled,
+					Error:   err,
+				},
+			}
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, newBifrostOperationError(schemas.ErrProviderRequestTimedOut, err, providerName)
+		}
+		return nil, newBifrostOperationError(schemas.ErrProviderRequest, err, providerName)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		var body any
+		_ = sonic.Unmarshal(raw, &body)
+		bifrostErr := newProviderAPIError(declarativeErrorMessage(operation.response, body, raw), nil, resp.StatusCode, providerName, nil, nil)
+		bifrostErr.ExtraFields = schemas.BifrostErrorExtraFields{Provider: providerName, ModelRequested: model, RequestType: requestType}
+		return nil, setRetryAfter(bifrostErr, resp.Header.Get)
+	}
+
+	done := operation.stream.Done
+	if done == "" {
+		done = defaultDeclarativeStreamDone
+	}
+	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	go func() {
+		defer close(responseChan)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		chunkIndex := -1
+		usage := &schemas.LLMUsage{}
+		var messageID string
+		var finishReason *string
+
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if operation.stream.Format == schemas.ProviderSpecStreamSSE {
+				// Only data lines carry chunks; event names, ids and comments are skipped
+				data, ok := strings.CutPrefix(line, "data:")
+				if !ok {
+					continue
+				}
+				line = strings.TrimSpace(data)
+				if line == done {
+					break
+				}
+			}
+			if line == "" {
+				continue
+			}
+
+			var chunk any
+			if err := sonic.Unmarshal([]byte(line), &chunk); err != nil {
+				provider.logger.Warn(fmt.Sprintf("Failed to parse stream data as JSON: %v", err))
+				continue
+			}
+			if message := declarativeString(evaluateSpec(operation.chunk.error, chunk)); message != "" {
+				ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+				bifrostErr := newBifrostOperationError(message, nil, providerName)
+				bifrostErr.IsBifrostError = false
+				bifrostErr.ExtraFields = schemas.BifrostErrorExtraFields{Provider: providerName, ModelRequested: model, RequestType: requestType}
+				processAndSendBifrostError(ctx, postHookRunner, bifrostErr, responseChan, provider.logger)
+				return
+			}
+
+			if id := declarativeString(evaluateSpec(operation.chunk.id, chunk)); id != "" && messageID == "" {
+				messageID = id
+			}
+			if reason := declarativeString(evaluateSpec(operation.chunk.finishReason, chunk)); reason != "" {
+				finishReason = &reason
+			}
+			if chunkUsage := declarativeUsage(operation.chunk, chunk); chunkUsage != nil {
+				// Usage is cumulative, or only sent with the last chunk
+				usage.PromptTokens = max(usage.PromptTokens, chunkUsage.PromptTokens)
+				usage.CompletionTokens = max(usage.CompletionTokens, chunkUsage.CompletionTokens)
+				usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+			}
+
+			content := declarativeString(operation.chunk.content.Evaluate(chunk))
+			if content == "" {
+				continue
+			}
+			chunkIndex++
+			response := createBifrostChatCompletionChunkResponse(messageID, nil, nil, chunkIndex-1, requestType, providerName, model)
+			response.Model = model
+			response.Choices[0].BifrostStreamResponseChoice.Delta.Content = &content
+			if chunkIndex == 0 {
+				response.Choices[0].BifrostStreamResponseChoice.Delta.Role = schemas.Ptr(string(schemas.ChatMessageRoleAssistant))
+			}
+			if requestType == schemas.TextCompletionStreamRequest {
+				response.ToTextCompletionResponse()
+				response.ExtraFields.RequestType = requestType
+			}
+			processAndSendResponse(ctx, postHookRunner, response, responseChan, provider.logger)
+		}
+
+		if err := scanner.Err(); err != nil {
+			provider.logger.Warn(fmt.Sprintf("Error reading stream: %v", err))
+			processAndSendError(ctx, postHookRunner, err, responseChan, requestType, providerName, model, provider.logger)
+			return
+		}
+		if usage.TotalTokens == 0 {
+			usage = nil
+		}
+		var response *schemas.BifrostResponse
+		if requestType == schemas.TextCompletionStreamRequest {
+			response = createBifrostCompletionChunkResponse(messageID, usage, finishReason, chunkIndex, requestType, providerName, model)
+		} else {
+			response = createBifrostChatCompletionChunkResponse(messageID, usage, finishReason, chunkIndex, requestType, providerName, model)
+		}
+		response.Model = model
+		handleStreamEndWithSuccess(ctx, response, postHookRunner, responseChan, provider.logger)
+	}()
+
+	return responseChan, nil
+}
+
+// setDeclarativeField sets the field at path in body, creating the objects along the path.
+func setDeclarativeField(body map[string]any, path []string, value any) {
+	for _, name := range path[:len(path)-1] {
+		next, ok := body[name].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			body[name] = next
+		}
+		body = next
+	}
+	body[path[len(path)-1]] = value
+}
+
+// evaluateSpec evaluates an optional expression.
+func evaluateSpec(expr *schemas.SpecExpression, document any) any {
+	if expr == nil {
+		return nil
+	}
+	return expr.Evaluate(document)
+}
+
+// declarativeString converts a selected value to text: lists, such as the texts of content blocks, are
+// concatenated and numbers formatted.
+func declarativeString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		var text strings.Builder
+		for _, element := range v {
+			text.WriteString(declarativeString(element))
+		}
+		return text.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, _ := sonic.MarshalString(v)
+		return encoded
+	}
+}
+
+// declarativeInt converts a selected number, or numeric string, to an int.
+func declarativeInt(value any) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
+
+// declarativeUsage returns the token usage of a response, nil when the spec maps none.
+func declarativeUsage(response declarativeResponse, body any) *schemas.LLMUsage {
+	if response.promptTokens == nil && response.completionTokens == nil {
+		return nil
+	}
+	usage := &schemas.LLMUsage{
+		PromptTokens:     declarativeInt(evaluateSpec(response.promptTokens, body)),
+		CompletionTokens: declarativeInt(evaluateSpec(response.completionTokens, body)),
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return usage
+}
+
+// declarativeVectors converts a selected list of vectors, or a single vector, to embeddings.
+func declarativeVectors(value any) [][]float32 {
+	list, ok := value.([]any)
+	if !ok || len(list) == 0 {
+		return nil
+	}
+	if _, single := list[0].(float64); single {
+		list = []any{list}
+	}
+	vectors := make([][]float32, 0, len(list))
+	for _, element := range list {
+		values, ok := element.([]any)
+		if !ok {
+			return nil
+		}
+		vector := make([]float32, len(values))
+		for i, v := range values {
+			f, ok := v.(float64)
+			if !ok {
+				return nil
+			}
+			vector[i] = float32(f)
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors
+}
+
+// declarativeErrorMessage returns the message of an error response, its raw body when the spec maps no message.
+func declarativeErrorMessage(response declarativeResponse, body any, raw []byte) string {
+	if message := declarativeString(evaluateSpec(response.error, body)); message != "" {
+		return message
+	}
+	if len(raw) == 0 {
+		return "empty error response"
+	}
+	return string(raw)
+}
@@ -49,6 +49,9 @@ func NewGeminiProvider(config *schemas.ProviderConfig, logger schemas.Logger) *G
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(getProviderName(schemas.Gemini, config.CustomProviderConfig), config, client, streamClient, logger)
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://generativelanguage.googleapis.com/v1beta"
@@ -228,6 +231,11 @@ func (provider *GeminiProvider) Embedding(ctx context.Context, key schemas.Key,
 	)
 }
 
+// Rerank is not supported by the Gemini provider.
+func (provider *GeminiProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "gemini")
+}
+
 func (provider *GeminiProvider) Speech(ctx context.Context, key schemas.Key, request *schemas.BifrostSpeechRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	// Check if speech is allowed for this provider
 	if err := checkOperationAllowed(schemas.Gemini, provider.customProviderConfig, schemas.SpeechRequest); err != nil {
@@ -317,6 +325,8 @@ func (provider *GeminiProvider) SpeechStream(ctx context.Context, postHookRunner
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Set headers for streaming
 	req.Header.Set("Content-Type", "application/json")
@@ -325,7 +335,7 @@ func (provider *GeminiProvider) SpeechStream(ctx context.Context, postHookRunner
 	req.Header.Set("Cache-Control", "no-cache")
 
 	// Make the request
-	resp, err := provider.streamClient.Do(req)
+	resp, err := doHTTPRequest(provider.streamClient, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -586,6 +596,8 @@ func (provider *GeminiProvider) TranscriptionStream(ctx context.Context, postHoo
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Set headers for streaming
 	req.Header.Set("Content-Type", "application/json")
@@ -594,7 +606,7 @@ func (provider *GeminiProvider) TranscriptionStream(ctx context.Context, postHoo
 	req.Header.Set("Cache-Control", "no-cache")
 
 	// Make the request
-	resp, err := provider.streamClient.Do(req)
+	resp, err := doHTTPRequest(provider.streamClient, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
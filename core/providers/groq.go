@@ -46,6 +46,9 @@ func NewGroqProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*Gr
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.Groq, config, client, streamClient, logger)
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.groq.com/openai"
@@ -170,6 +173,11 @@ func (provider *GroqProvider) Embedding(ctx context.Context, key schemas.Key, re
 	return nil, newUnsupportedOperationError("embedding", "groq")
 }
 
+// Rerank is not supported by the Groq provider.
+func (provider *GroqProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "groq")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to the Groq API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Uses Groq's OpenAI-compatible streaming format.
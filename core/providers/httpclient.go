@@ -0,0 +1,245 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the upstream HTTP client tuning and connection metrics shared by the providers.
+package providers
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+// connectionCounters counts the upstream connections of one provider across its clients and instances.
+type connectionCounters struct {
+	opened         atomic.Int64
+	closed         atomic.Int64
+	tlsCacheHits   atomic.Int64
+	tlsCacheMisses atomic.Int64
+}
+
+// upstreamConnections maps providers to their *connectionCounters.
+var upstreamConnections sync.Map
+
+func countersFor(provider schemas.ModelProvider) *connectionCounters {
+	counters, _ := upstreamConnections.LoadOrStore(provider, &connectionCounters{})
+	return counters.(*connectionCounters)
+}
+
+// UpstreamConnectionStats returns the connection counters of every provider that opened a client.
+// Comparing the connections opened with the requests sent gives the connection reuse rate.
+func UpstreamConnectionStats() map[schemas.ModelProvider]schemas.UpstreamConnectionStats {
+	stats := make(map[schemas.ModelProvider]schemas.UpstreamConnectionStats)
+	upstreamConnections.Range(func(key, value any) bool {
+		counters := value.(*connectionCounters)
+		opened, closed := counters.opened.Load(), counters.closed.Load()
+		stats[key.(schemas.ModelProvider)] = schemas.UpstreamConnectionStats{
+			Opened:                opened,
+			Closed:                closed,
+			Open:                  opened - closed,
+			TLSSessionCacheHits:   counters.tlsCacheHits.Load(),
+			TLSSessionCacheMisses: counters.tlsCacheMisses.Load(),
+		}
+		return true
+	})
+	return stats
+}
+
+// configureHTTPClients applies the provider's HTTP client, proxy and egress settings to its clients and counts
+// their connections. Either client may be nil. It runs after configureProxy, so proxied dials are counted too.
+func configureHTTPClients(provider schemas.ModelProvider, config *schemas.ProviderConfig, client *fasthttp.Client, streamClient *http.Client, logger schemas.Logger) {
+	counters := countersFor(provider)
+	settings := config.NetworkConfig.HTTPClient
+	if settings == nil {
+		settings = &schemas.HTTPClientConfig{}
+	}
+
+	var tlsConfig *tls.Config
+	rootCAs, err := settings.RootCAs()
+	if err != nil {
+		logger.Warn("ignoring the CA bundle of provider %s: %v", provider, err)
+	}
+	certificates, err := settings.ClientCertificates()
+	if err != nil {
+		logger.Warn("ignoring the client certificate of provider %s: %v", provider, err)
+	}
+	if rootCAs != nil || certificates != nil || settings.TLSSessionCacheSize > 0 {
+		tlsConfig = &tls.Config{RootCAs: rootCAs, Certificates: certificates}
+		if settings.TLSSessionCacheSize > 0 {
+			tlsConfig.ClientSessionCache = &countingSessionCache{
+				ClientSessionCache: tls.NewLRUClientSessionCache(settings.TLSSessionCacheSize),
+				counters:           counters,
+			}
+		}
+	}
+	idleTimeout := time.Duration(settings.IdleConnTimeoutInSeconds) * time.Second
+	lifetime := time.Duration(settings.MaxConnLifetimeInSeconds) * time.Second
+
+	if client != nil {
+		if settings.MaxConnsPerHost > 0 {
+			client.MaxConnsPerHost = settings.MaxConnsPerHost
+		}
+		client.MaxIdleConnDuration = idleTimeout
+		client.MaxConnDuration = lifetime
+		if settings.DisableKeepAlives {
+			// fasthttp sends "Connection: close" once a connection outlives MaxConnDuration
+			client.MaxConnDuration = time.Nanosecond
+		}
+		if tlsConfig != nil {
+			client.TLSConfig = tlsConfig.Clone()
+		}
+		// Proxied dials still receive the upstream address, so the egress check applies to them too
+		if dial := client.Dial; dial != nil {
+			client.Dial = func(addr string) (net.Conn, error) {
+				if err := checkEgress(config.AllowedEgressHosts, addr); err != nil {
+					return nil, err
+				}
+				return counters.track(dial(addr))
+			}
+		} else {
+			client.DialTimeout = func(addr string, timeout time.Duration) (net.Conn, error) {
+				if err := checkEgress(config.AllowedEgressHosts, addr); err != nil {
+					return nil, err
+				}
+				// Same as the fasthttp default dialer, which has no deadline without a request timeout
+				if timeout > 0 {
+					return counters.track(fasthttp.DialTimeout(addr, timeout))
+				}
+				return counters.track(fasthttp.Dial(addr))
+			}
+		}
+	}
+
+	if streamClient != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if settings.MaxConnsPerHost > 0 {
+			transport.MaxConnsPerHost = settings.MaxConnsPerHost
+		}
+		if settings.MaxIdleConns > 0 {
+			transport.MaxIdleConns = settings.MaxIdleConns
+			transport.MaxIdleConnsPerHost = settings.MaxIdleConns
+		}
+		if idleTimeout > 0 {
+			transport.IdleConnTimeout = idleTimeout
+		}
+		transport.DisableKeepAlives = settings.DisableKeepAlives
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig.Clone()
+		}
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return counters.track(dialer.DialContext(ctx, network, addr))
+		}
+		configureStreamProxy(transport, config.ProxyConfig, logger)
+		streamClient.Transport = transport
+		if len(config.AllowedEgressHosts) > 0 {
+			// Dials go to the proxy when one is set, so requests are checked instead
+			streamClient.Transport = &egressRoundTripper{next: transport, allowed: config.AllowedEgressHosts}
+		}
+	}
+}
+
+// configureStreamProxy applies the proxy settings to the net/http transport of streaming requests.
+// Without proxy settings the transport keeps reading the proxy from the environment.
+func configureStreamProxy(transport *http.Transport, proxyConfig *schemas.ProxyConfig, logger schemas.Logger) {
+	if proxyConfig == nil {
+		return
+	}
+	switch proxyConfig.Type {
+	case schemas.NoProxy:
+		transport.Proxy = nil
+	case schemas.HTTPProxy, schemas.Socks5Proxy:
+		rawURL := proxyConfig.URL
+		if !strings.Contains(rawURL, "://") {
+			scheme := "http"
+			if proxyConfig.Type == schemas.Socks5Proxy {
+				scheme = "socks5"
+			}
+			rawURL = scheme + "://" + rawURL
+		}
+		proxyURL, err := url.Parse(rawURL)
+		if err != nil || proxyURL.Host == "" {
+			logger.Warn("Invalid proxy configuration: invalid %s proxy URL", proxyConfig.Type)
+			return
+		}
+		if proxyConfig.Username != "" && proxyConfig.Password != "" {
+			proxyURL.User = url.UserPassword(proxyConfig.Username, proxyConfig.Password)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// checkEgress returns an error when the host of addr is not in the egress allowlist.
+func checkEgress(allowed []string, addr string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if !schemas.EgressHostAllowed(allowed, host) {
+		return fmt.Errorf("egress to %s is not allowed", host)
+	}
+	return nil
+}
+
+// egressRoundTripper rejects requests to hosts outside the egress allowlist.
+type egressRoundTripper struct {
+	next    http.RoundTripper
+	allowed []string
+}
+
+func (rt *egressRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !schemas.EgressHostAllowed(rt.allowed, req.URL.Hostname()) {
+		return nil, fmt.Errorf("egress to %s is not allowed", req.URL.Hostname())
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// track counts a newly dialed connection and its close.
+func (c *connectionCounters) track(conn net.Conn, err error) (net.Conn, error) {
+	if err != nil {
+		return nil, err
+	}
+	c.opened.Add(1)
+	return &countedConn{Conn: conn, counters: c}, nil
+}
+
+// countedConn counts its close once.
+type countedConn struct {
+	net.Conn
+	counters *connectionCounters
+	closed   atomic.Bool
+}
+
+func (c *countedConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.counters.closed.Add(1)
+	}
+	return c.Conn.Close()
+}
+
+// countingSessionCache counts TLS session cache lookups; a hit resumes the previous session and skips a full handshake.
+type countingSessionCache struct {
+	tls.ClientSessionCache
+	counters *connectionCounters
+}
+
+func (c *countingSessionCache) Get(sessionKey string) (*tls.ClientSessionState, bool) {
+	session, ok := c.ClientSessionCache.Get(sessionKey)
+	if ok {
+		c.counters.tlsCacheHits.Add(1)
+	} else {
+		c.counters.tlsCacheMisses.Add(1)
+	}
+	return session, ok
+}
@@ -0,0 +1,150 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the Jina AI provider implementation.
+package providers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/providers/jina"
+	"github.com/valyala/fasthttp"
+)
+
+// JinaProvider implements the Provider interface for Jina AI's API, which serves embeddings and reranking.
+type JinaProvider struct {
+	logger              schemas.Logger        // Logger for provider operations
+	client              *fasthttp.Client      // HTTP client for API requests
+	networkConfig       schemas.NetworkConfig // Network configuration including extra headers
+	sendBackRawResponse bool                  // Whether to include raw response in BifrostResponse
+}
+
+// NewJinaProvider creates a new Jina AI provider instance.
+// It initializes the HTTP client with the provided configuration.
+// The client is configured with timeouts, concurrency limits, and optional proxy settings.
+func NewJinaProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*JinaProvider, error) {
+	config.CheckAndSetDefaults()
+
+	client := &fasthttp.Client{
+		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
+	}
+
+	// Configure proxy if provided
+	client = configureProxy(client, config.ProxyConfig, logger)
+
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.Jina, config, client, nil, logger)
+
+	// Set default BaseURL if not provided
+	if config.NetworkConfig.BaseURL == "" {
+		config.NetworkConfig.BaseURL = "https://api.jina.ai"
+	}
+	config.NetworkConfig.BaseURL = strings.TrimRight(config.NetworkConfig.BaseURL, "/")
+
+	return &JinaProvider{
+		logger:              logger,
+		client:              client,
+		networkConfig:       config.NetworkConfig,
+		sendBackRawResponse: config.SendBackRawResponse,
+	}, nil
+}
+
+// GetProviderKey returns the provider identifier for Jina AI.
+func (provider *JinaProvider) GetProviderKey() schemas.ModelProvider {
+	return schemas.Jina
+}
+
+// TextCompletion is not supported by the Jina AI provider.
+func (provider *JinaProvider) TextCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("text completion", "jina")
+}
+
+// TextCompletionStream is not supported by the Jina AI provider.
+func (provider *JinaProvider) TextCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("text completion stream", "jina")
+}
+
+// ChatCompletion is not supported by the Jina AI provider.
+func (provider *JinaProvider) ChatCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostChatRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("chat completion", "jina")
+}
+
+// ChatCompletionStream is not supported by the Jina AI provider.
+func (provider *JinaProvider) ChatCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostChatRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("chat completion stream", "jina")
+}
+
+// Responses is not supported by the Jina AI provider.
+func (provider *JinaProvider) Responses(ctx context.Context, key schemas.Key, request *schemas.BifrostResponsesRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("responses", "jina")
+}
+
+// ResponsesStream is not supported by the Jina AI provider.
+func (provider *JinaProvider) ResponsesStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostResponsesRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("responses stream", "jina")
+}
+
+// Embedding generates embeddings for the given input text(s) using the OpenAI-compatible Jina AI embeddings API.
+func (provider *JinaProvider) Embedding(ctx context.Context, key schemas.Key, request *schemas.BifrostEmbeddingRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return handleOpenAIEmbeddingRequest(
+		ctx,
+		provider.client,
+		provider.networkConfig.BaseURL+"/v1/embeddings",
+		request,
+		key,
+		provider.networkConfig.ExtraHeaders,
+		provider.GetProviderKey(),
+		provider.sendBackRawResponse,
+		provider.logger,
+	)
+}
+
+// Rerank orders documents by their relevance to a query using the Jina AI rerank API.
+// Requests with more documents than a single call accepts are reranked in batches.
+func (provider *JinaProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	providerName := provider.GetProviderKey()
+
+	return rerankInBatches(request, func(documents []string, topN *int) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		var jinaResp jina.JinaRerankResponse
+		rawResponse, latency, bifrostErr := handleRerankRequest(
+			ctx,
+			provider.client,
+			provider.networkConfig.BaseURL+"/v1/rerank",
+			key,
+			provider.networkConfig.ExtraHeaders,
+			providerName,
+			jina.ToJinaRerankRequest(request, documents, topN),
+			&jinaResp,
+			func(errorResp *jina.JinaError) string { return errorResp.Detail },
+			provider.sendBackRawResponse,
+			provider.logger,
+		)
+		if bifrostErr != nil {
+			return nil, bifrostErr
+		}
+		return finishRerankResponse(jinaResp.ToBifrostResponse(), request, providerName, latency, rawResponse, provider.sendBackRawResponse), nil
+	})
+}
+
+// Speech is not supported by the Jina AI provider.
+func (provider *JinaProvider) Speech(ctx context.Context, key schemas.Key, request *schemas.BifrostSpeechRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech", "jina")
+}
+
+// SpeechStream is not supported by the Jina AI provider.
+func (provider *JinaProvider) SpeechStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostSpeechRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech stream", "jina")
+}
+
+// Transcription is not supported by the Jina AI provider.
+func (provider *JinaProvider) Transcription(ctx context.Context, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription", "jina")
+}
+
+// TranscriptionStream is not supported by the Jina AI provider.
+func (provider *JinaProvider) TranscriptionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription stream", "jina")
+}
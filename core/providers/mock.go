@@ -0,0 +1,495 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the mock provider implementation.
+package providers
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+)
+
+const (
+	// defaultMockResponse echoes the prompt back
+	defaultMockResponse = "This is a mock response from {{.Model}}. You said: {{.Prompt}}"
+	// defaultMockEmbeddingDimensions matches common embedding models
+	defaultMockEmbeddingDimensions = 1536
+	defaultMockErrorMessage        = "mock provider error"
+)
+
+// MockProvider implements the Provider interface without calling any API. Completions are rendered from
+// templates and embeddings derived from the input, so identical requests get identical responses.
+type MockProvider struct {
+	logger         schemas.Logger                // Logger for provider operations
+	config         schemas.MockProviderConfig    // Responses, latency and error injection
+	response       *template.Template            // Default completion template
+	modelResponses map[string]*template.Template // Completion templates per model
+}
+
+// mockTemplateData is passed to the completion templates.
+type mockTemplateData struct {
+	Model       string
+	Prompt      string
+	RequestType schemas.RequestType
+}
+
+// NewMockProvider creates a new mock provider instance, parsing the completion templates of config.MockConfig.
+func NewMockProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*MockProvider, error) {
+	config.CheckAndSetDefaults()
+
+	var mockConfig schemas.MockProviderConfig
+	if config.MockConfig != nil {
+		mockConfig = *config.MockConfig
+	}
+	if err := mockConfig.Validate(); err != nil {
+		return nil, err
+	}
+
+	text := mockConfig.Response
+	if text == "" {
+		text = defaultMockResponse
+	}
+	// Templates were checked by Validate
+	response := template.Must(template.New("response").Parse(text))
+	modelResponses := make(map[string]*template.Template, len(mockConfig.ModelResponses))
+	for model, text := range mockConfig.ModelResponses {
+		modelResponses[model] = template.Must(template.New(model).Parse(text))
+	}
+
+	return &MockProvider{
+		logger:         logger,
+		config:         mockConfig,
+		response:       response,
+		modelResponses: modelResponses,
+	}, nil
+}
+
+// GetProviderKey returns the provider identifier for the mock provider.
+func (provider *MockProvider) GetProviderKey() schemas.ModelProvider {
+	return schemas.Mock
+}
+
+// TextCompletion renders a text completion for the prompt.
+func (provider *MockProvider) TextCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	prompt := mockTextPrompt(request.Input)
+	text, finishReason, usage, bifrostErr := provider.complete(ctx, schemas.TextCompletionRequest, request.Model, prompt, mockMaxTokens(request.Params))
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	return &schemas.BifrostResponse{
+		ID:      "cmpl-mock-" + uuid.NewString(),
+		Object:  "text_completion",
+		Model:   request.Model,
+		Created: int(time.Now().Unix()),
+		Usage:   usage,
+		Choices: []schemas.BifrostChatResponseChoice{{
+			FinishReason:                        &finishReason,
+			BifrostTextCompletionResponseChoice: &schemas.BifrostTextCompletionResponseChoice{Text: &text},
+		}},
+		ExtraFields: schemas.BifrostResponseExtraFields{
+			RequestType:    schemas.TextCompletionRequest,
+			Provider:       provider.GetProviderKey(),
+			ModelRequested: request.Model,
+		},
+	}, nil
+}
+
+// TextCompletionStream streams a text completion for the prompt one word at a time.
+func (provider *MockProvider) TextCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	prompt := mockTextPrompt(request.Input)
+	return provider.stream(ctx, postHookRunner, schemas.TextCompletionStreamRequest, request.Model, prompt, mockMaxTokens(request.Params))
+}
+
+// ChatCompletion renders an assistant message answering the last user message.
+func (provider *MockProvider) ChatCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostChatRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	var maxTokens *int
+	if request.Params != nil {
+		maxTokens = request.Params.MaxCompletionTokens
+	}
+	text, finishReason, usage, bifrostErr := provider.complete(ctx, schemas.ChatCompletionRequest, request.Model, mockChatPrompt(request.Input), maxTokens)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	return &schemas.BifrostResponse{
+		ID:      "chatcmpl-mock-" + uuid.NewString(),
+		Object:  "chat.completion",
+		Model:   request.Model,
+		Created: int(time.Now().Unix()),
+		Usage:   usage,
+		Choices: []schemas.BifrostChatResponseChoice{{
+			FinishReason: &finishReason,
+			BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+				Message: &schemas.ChatMessage{
+					Role:    schemas.ChatMessageRoleAssistant,
+					Content: &schemas.ChatMessageContent{ContentStr: &text},
+				},
+			},
+		}},
+		ExtraFields: schemas.BifrostResponseExtraFields{
+			RequestType:    schemas.ChatCompletionRequest,
+			Provider:       provider.GetProviderKey(),
+			ModelRequested: request.Model,
+		},
+	}, nil
+}
+
+// ChatCompletionStream streams an assistant message one word at a time, paced by TokenDelayMs.
+func (provider *MockProvider) ChatCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostChatRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	var maxTokens *int
+	if request.Params != nil {
+		maxTokens = request.Params.MaxCompletionTokens
+	}
+	return provider.stream(ctx, postHookRunner, schemas.ChatCompletionStreamRequest, request.Model, mockChatPrompt(request.Input), maxTokens)
+}
+
+// Responses answers a Responses API request through ChatCompletion.
+func (provider *MockProvider) Responses(ctx context.Context, key schemas.Key, request *schemas.BifrostResponsesRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	response, err := provider.ChatCompletion(ctx, key, request.ToChatRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	response.ToResponsesOnly()
+	response.ExtraFields.RequestType = schemas.ResponsesRequest
+	response.ExtraFields.Provider = provider.GetProviderKey()
+	response.ExtraFields.ModelRequested = request.Model
+
+	return response, nil
+}
+
+// ResponsesStream is not supported by the mock provider.
+func (provider *MockProvider) ResponsesStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostResponsesRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("responses stream", "mock")
+}
+
+// Embedding returns unit vectors seeded by a hash of each input, so identical inputs get identical embeddings.
+func (provider *MockProvider) Embedding(ctx context.Context, key schemas.Key, request *schemas.BifrostEmbeddingRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	if bifrostErr := provider.simulate(ctx, schemas.EmbeddingRequest, request.Model); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	var texts []string
+	if request.Input != nil {
+		if request.Input.Text != nil {
+			texts = []string{*request.Input.Text}
+		} else {
+			texts = request.Input.Texts
+		}
+	}
+	if len(texts) == 0 {
+		return nil, newBifrostOperationError("embedding input texts are empty", nil, provider.GetProviderKey())
+	}
+	dimensions := provider.config.EmbeddingDimensions
+	if request.Params != nil && request.Params.Dimensions != nil && *request.Params.Dimensions > 0 {
+		dimensions = *request.Params.Dimensions
+	}
+	if dimensions == 0 {
+		dimensions = defaultMockEmbeddingDimensions
+	}
+
+	data := make([]schemas.BifrostEmbedding, len(texts))
+	promptTokens := 0
+	for i, text := range texts {
+		data[i] = schemas.BifrostEmbedding{
+			Index:     i,
+			Object:    "embedding",
+			Embedding: schemas.BifrostEmbeddingResponse{EmbeddingArray: mockEmbedding(text, dimensions)},
+		}
+		promptTokens += mockTokenCount(text)
+	}
+	return &schemas.BifrostResponse{
+		Object: "list",
+		Model:  request.Model,
+		Data:   data,
+		Usage:  &schemas.LLMUsage{PromptTokens: promptTokens, TotalTokens: promptTokens},
+		ExtraFields: schemas.BifrostResponseExtraFields{
+			RequestType:    schemas.EmbeddingRequest,
+			Provider:       provider.GetProviderKey(),
+			ModelRequested: request.Model,
+		},
+	}, nil
+}
+
+// Rerank scores each document by the share of the words of the query it contains.
+func (provider *MockProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	if bifrostErr := provider.simulate(ctx, schemas.RerankRequest, request.Model); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	queryWords := strings.Fields(strings.ToLower(request.Query))
+	return rerankInBatches(request, func(documents []string, topN *int) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		results := make([]schemas.BifrostRerankResult, len(documents))
+		promptTokens := 0
+		for i, document := range documents {
+			words := make(map[string]bool)
+			for _, word := range strings.Fields(strings.ToLower(document)) {
+				words[word] = true
+			}
+			matched := 0
+			for _, word := range queryWords {
+				if words[word] {
+					matched++
+				}
+			}
+			results[i] = schemas.BifrostRerankResult{Index: i}
+			if len(queryWords) > 0 {
+				results[i].RelevanceScore = float64(matched) / float64(len(queryWords))
+			}
+			promptTokens += mockTokenCount(request.Query) + mockTokenCount(document)
+		}
+		sort.SliceStable(results, func(i, j int) bool { return results[i].RelevanceScore > results[j].RelevanceScore })
+		if topN != nil && *topN >= 0 && *topN < len(results) {
+			results = results[:*topN]
+		}
+		return &schemas.BifrostResponse{
+			Object:  "rerank",
+			Model:   request.Model,
+			Results: results,
+			Usage:   &schemas.LLMUsage{PromptTokens: promptTokens, TotalTokens: promptTokens},
+			ExtraFields: schemas.BifrostResponseExtraFields{
+				RequestType:    schemas.RerankRequest,
+				Provider:       provider.GetProviderKey(),
+				ModelRequested: request.Model,
+			},
+		}, nil
+	})
+}
+
+// Speech is not supported by the mock provider.
+func (provider *MockProvider) Speech(ctx context.Context, key schemas.Key, request *schemas.BifrostSpeechRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech", "mock")
+}
+
+// SpeechStream is not supported by the mock provider.
+func (provider *MockProvider) SpeechStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostSpeechRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech stream", "mock")
+}
+
+// Transcription is not supported by the mock provider.
+func (provider *MockProvider) Transcription(ctx context.Context, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription", "mock")
+}
+
+// TranscriptionStream is not supported by the mock provider.
+func (provider *MockProvider) TranscriptionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription stream", "mock")
+}
+
+// complete waits for the simulated latency and renders a completion truncated to maxTokens words.
+func (provider *MockProvider) complete(ctx context.Context, requestType schemas.RequestType, model, prompt string, maxTokens *int) (string, string, *schemas.LLMUsage, *schemas.BifrostError) {
+	if bifrostErr := provider.simulate(ctx, requestType, model); bifrostErr != nil {
+		return "", "", nil, bifrostErr
+	}
+	words, finishReason, bifrostErr := provider.render(requestType, model, prompt, maxTokens)
+	if bifrostErr != nil {
+		return "", "", nil, bifrostErr
+	}
+	return strings.Join(words, ""), finishReason, mockUsage(prompt, len(words)), nil
+}
+
+// stream waits for the simulated latency and streams the rendered completion one word per chunk.
+func (provider *MockProvider) stream(ctx context.Context, postHookRunner schemas.PostHookRunner, requestType schemas.RequestType, model, prompt string, maxTokens *int) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	if bifrostErr := provider.simulate(ctx, requestType, model); bifrostErr != nil {
+		return nil, bifrostErr
+	}
+	words, finishReason, bifrostErr := provider.render(requestType, model, prompt, maxTokens)
+	if bifrostErr != nil {
+		return nil, bifrostErr
+	}
+
+	providerName := provider.GetProviderKey()
+	id := "chatcmpl-mock-" + uuid.NewString()
+	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	go func() {
+		defer close(responseChan)
+
+		tokenDelay := time.Duration(provider.config.TokenDelayMs) * time.Millisecond
+		for i, word := range words {
+			if i > 0 && tokenDelay > 0 {
+				if err := mockSleep(ctx, tokenDelay); err != nil {
+					return
+				}
+			}
+			content := word
+			response := createBifrostChatCompletionChunkResponse(id, nil, nil, i-1, requestType, providerName, model)
+			response.Model = model
+			response.Choices[0].BifrostStreamResponseChoice.Delta.Content = &content
+			if i == 0 {
+				response.Choices[0].BifrostStreamResponseChoice.Delta.Role = schemas.Ptr(string(schemas.ChatMessageRoleAssistant))
+			}
+			if requestType == schemas.TextCompletionStreamRequest {
+				response.ToTextCompletionResponse()
+				response.ExtraFields.RequestType = requestType
+			}
+			processAndSendResponse(ctx, postHookRunner, response, responseChan, provider.logger)
+		}
+
+		var response *schemas.BifrostResponse
+		if requestType == schemas.TextCompletionStreamRequest {
+			response = createBifrostCompletionChunkResponse(id, mockUsage(prompt, len(words)), &finishReason, len(words)-1, requestType, providerName, model)
+		} else {
+			response = createBifrostChatCompletionChunkResponse(id, mockUsage(prompt, len(words)), &finishReason, len(words)-1, requestType, providerName, model)
+		}
+		response.Model = model
+		handleStreamEndWithSuccess(ctx, response, postHookRunner, responseChan, provider.logger)
+	}()
+
+	return responseChan, nil
+}
+
+// simulate waits for the configured latency plus jitter and injects errors at the configured rate.
+func (provider *MockProvider) simulate(ctx context.Context, requestType schemas.RequestType, model string) *schemas.BifrostError {
+	delay := time.Duration(provider.config.LatencyMs) * time.Millisecond
+	if provider.config.JitterMs > 0 {
+		delay += time.Duration(rand.IntN(provider.config.JitterMs+1)) * time.Millisecond
+	}
+	if delay > 0 {
+		if err := mockSleep(ctx, delay); err != nil {
+			return &schemas.BifrostError{
+				IsBifrostError: false,
+				Error: &schemas.ErrorField{
+					Type:    schemas.Ptr(schemas.RequestCancelled),
+					Message: schemas.ErrRequestCancelled,
+					Error:   err,
+				},
+			}
+		}
+	}
+
+	if provider.config.ErrorRate > 0 && rand.Float64() < provider.config.ErrorRate {
+		statusCode := provider.config.ErrorStatusCode
+		if statusCode == 0 {
+			statusCode = 500
+		}
+		message := provider.config.ErrorMessage
+		if message == "" {
+			message = defaultMockErrorMessage
+		}
+		bifrostErr := newProviderAPIError(message, nil, statusCode, provider.GetProviderKey(), nil, nil)
+		bifrostErr.ExtraFields.RequestType = requestType
+		bifrostErr.ExtraFields.ModelRequested = model
+		return bifrostErr
+	}
+	return nil
+}
+
+// render executes the completion template of model and splits the completion into words keeping their
+// trailing whitespace, so that joining them restores the completion.
+func (provider *MockProvider) render(requestType schemas.RequestType, model, prompt string, maxTokens *int) ([]string, string, *schemas.BifrostError) {
+	tmpl, ok := provider.modelResponses[model]
+	if !ok {
+		tmpl = provider.response
+	}
+	var text strings.Builder
+	if err := tmpl.Execute(&text, mockTemplateData{Model: model, Prompt: prompt, RequestType: requestType}); err != nil {
+		return nil, "", newBifrostOperationError("failed to render mock response", err, provider.GetProviderKey())
+	}
+
+	words := strings.SplitAfter(text.String(), " ")
+	if len(words) > 1 && words[len(words)-1] == "" {
+		words = words[:len(words)-1]
+	}
+	finishReason := "stop"
+	if maxTokens != nil && *maxTokens > 0 && len(words) > *maxTokens {
+		words = words[:*maxTokens]
+		finishReason = "length"
+	}
+	return words, finishReason, nil
+}
+
+// mockSleep waits for d unless ctx is done first.
+func mockSleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// mockTextPrompt returns the prompt of a text completion request.
+func mockTextPrompt(input *schemas.TextCompletionInput) string {
+	if input == nil {
+		return ""
+	}
+	if input.PromptStr != nil {
+		return *input.PromptStr
+	}
+	return strings.Join(input.PromptArray, "\n")
+}
+
+// mockChatPrompt returns the text of the last user message.
+func mockChatPrompt(messages []schemas.ChatMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		message := messages[i]
+		if message.Role != schemas.ChatMessageRoleUser || message.Content == nil {
+			continue
+		}
+		if message.Content.ContentStr != nil {
+			return *message.Content.ContentStr
+		}
+		var parts []string
+		for _, block := range message.Content.ContentBlocks {
+			if block.Type == schemas.ChatContentBlockTypeText && block.Text != nil {
+				parts = append(parts, *block.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// mockMaxTokens returns the token limit of a text completion request.
+func mockMaxTokens(params *schemas.TextCompletionParameters) *int {
+	if params == nil {
+		return nil
+	}
+	return params.MaxTokens
+}
+
+// mockTokenCount approximates the token count of text by its word count.
+func mockTokenCount(text string) int {
+	return len(strings.Fields(text))
+}
+
+// mockUsage reports the word counts of the prompt and the completion as token usage.
+func mockUsage(prompt string, completionTokens int) *schemas.LLMUsage {
+	promptTokens := mockTokenCount(prompt)
+	return &schemas.LLMUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// mockEmbedding derives a unit vector of the given dimensions from a hash of text.
+func mockEmbedding(text string, dimensions int) []float32 {
+	hash := fnv.New64a()
+	hash.Write([]byte(text))
+	seed := hash.Sum64()
+	rng := rand.New(rand.NewPCG(seed, seed>>1))
+
+	vector := make([]float32, dimensions)
+	var norm float64
+	for i := range vector {
+		value := rng.NormFloat64()
+		vector[i] = float32(value)
+		norm += value * value
+	}
+	norm = math.Sqrt(norm)
+	if norm > 0 {
+		for i := range vector {
+			vector[i] = float32(float64(vector[i]) / norm)
+		}
+	}
+	return vector
+}
@@ -47,6 +47,9 @@ func NewOllamaProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.Ollama, config, client, streamClient, logger)
+
 	config.NetworkConfig.BaseURL = strings.TrimRight(config.NetworkConfig.BaseURL, "/")
 
 	// BaseURL is required for Ollama
@@ -142,6 +145,11 @@ func (provider *OllamaProvider) Embedding(ctx context.Context, key schemas.Key,
 	)
 }
 
+// Rerank is not supported by the Ollama provider.
+func (provider *OllamaProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "ollama")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to the Ollama API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Uses Ollama's OpenAI-compatible streaming format.
@@ -56,6 +56,9 @@ func NewOpenAIProvider(config *schemas.ProviderConfig, logger schemas.Logger) *O
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(getProviderName(schemas.OpenAI, config.CustomProviderConfig), config, client, streamClient, logger)
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.openai.com"
@@ -248,6 +251,8 @@ func handleOpenAITextCompletionStreaming(
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, extraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Set headers
 	for key, value := range headers {
@@ -255,7 +260,7 @@ func handleOpenAITextCompletionStreaming(
 	}
 
 	// Make the request
-	resp, err := client.Do(req)
+	resp, err := doHTTPRequest(client, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -362,6 +367,12 @@ func handleOpenAITextCompletionStreaming(
 				if response.Usage.TotalTokens > usage.TotalTokens {
 					usage.TotalTokens = response.Usage.TotalTokens
 				}
+				if response.Usage.PromptTokensDetails != nil {
+					usage.PromptTokensDetails = response.Usage.PromptTokensDetails
+				}
+				if response.Usage.CompletionTokensDetails != nil {
+					usage.CompletionTokensDetails = response.Usage.CompletionTokensDetails
+				}
 				calculatedTotal := usage.PromptTokens + usage.CompletionTokens
 				if calculatedTotal > usage.TotalTokens {
 					usage.TotalTokens = calculatedTotal
@@ -643,6 +654,11 @@ func (provider *OpenAIProvider) Embedding(ctx context.Context, key schemas.Key,
 	)
 }
 
+// Rerank is not supported by the OpenAI provider.
+func (provider *OpenAIProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "openai")
+}
+
 // handleOpenAIEmbeddingRequest handles embedding requests for OpenAI-compatible APIs.
 // This shared function reduces code duplication between providers that use the same embedding request format.
 func handleOpenAIEmbeddingRequest(
@@ -799,6 +815,8 @@ func handleOpenAIStreaming(
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, extraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Set headers
 	for key, value := range headers {
@@ -806,7 +824,7 @@ func handleOpenAIStreaming(
 	}
 
 	// Make the request
-	resp, err := client.Do(req)
+	resp, err := doHTTPRequest(client, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -913,6 +931,12 @@ func handleOpenAIStreaming(
 				if response.Usage.TotalTokens > usage.TotalTokens {
 					usage.TotalTokens = response.Usage.TotalTokens
 				}
+				if response.Usage.PromptTokensDetails != nil {
+					usage.PromptTokensDetails = response.Usage.PromptTokensDetails
+				}
+				if response.Usage.CompletionTokensDetails != nil {
+					usage.CompletionTokensDetails = response.Usage.CompletionTokensDetails
+				}
 				calculatedTotal := usage.PromptTokens + usage.CompletionTokens
 				if calculatedTotal > usage.TotalTokens {
 					usage.TotalTokens = calculatedTotal
@@ -1086,6 +1110,8 @@ func (provider *OpenAIProvider) SpeechStream(ctx context.Context, postHookRunner
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Set headers
 	for key, value := range headers {
@@ -1093,7 +1119,7 @@ func (provider *OpenAIProvider) SpeechStream(ctx context.Context, postHookRunner
 	}
 
 	// Make the request
-	resp, err := provider.streamClient.Do(req)
+	resp, err := doHTTPRequest(provider.streamClient, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -1356,6 +1382,8 @@ func (provider *OpenAIProvider) TranscriptionStream(ctx context.Context, postHoo
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	// Set headers
 	for key, value := range headers {
@@ -1363,7 +1391,7 @@ func (provider *OpenAIProvider) TranscriptionStream(ctx context.Context, postHoo
 	}
 
 	// Make the request
-	resp, err := provider.streamClient.Do(req)
+	resp, err := doHTTPRequest(provider.streamClient, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -1568,21 +1596,21 @@ func parseStreamOpenAIError(resp *http.Response) *schemas.BifrostError {
 	resp.Body.Close()
 
 	if err := sonic.Unmarshal(body, &errorResp); err != nil {
-		return &schemas.BifrostError{
+		return setRetryAfter(&schemas.BifrostError{
 			IsBifrostError: true,
 			StatusCode:     &statusCode,
 			Error: &schemas.ErrorField{
 				Message: schemas.ErrProviderResponseUnmarshal,
 				Error:   err,
 			},
-		}
+		}, resp.Header.Get)
 	}
 
-	bifrostErr := &schemas.BifrostError{
+	bifrostErr := setRetryAfter(&schemas.BifrostError{
 		IsBifrostError: false,
 		StatusCode:     &statusCode,
 		Error:          &schemas.ErrorField{},
-	}
+	}, resp.Header.Get)
 
 	if errorResp.EventID != nil {
 		bifrostErr.EventID = errorResp.EventID
@@ -41,6 +41,9 @@ func NewOpenRouterProvider(config *schemas.ProviderConfig, logger schemas.Logger
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.OpenRouter, config, client, streamClient, logger)
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://openrouter.ai/api"
@@ -145,6 +148,11 @@ func (provider *OpenRouterProvider) Embedding(ctx context.Context, key schemas.K
 	return nil, newUnsupportedOperationError("embedding", "openrouter")
 }
 
+// Rerank is not supported by the OpenRouter provider.
+func (provider *OpenRouterProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "openrouter")
+}
+
 func (provider *OpenRouterProvider) Speech(ctx context.Context, key schemas.Key, request *schemas.BifrostSpeechRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
 	return nil, newUnsupportedOperationError("speech", "openrouter")
 }
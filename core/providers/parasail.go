@@ -46,6 +46,9 @@ func NewParasailProvider(config *schemas.ProviderConfig, logger schemas.Logger)
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.Parasail, config, client, streamClient, logger)
+
 	// Set default BaseURL if not provided
 	if config.NetworkConfig.BaseURL == "" {
 		config.NetworkConfig.BaseURL = "https://api.parasail.io"
@@ -112,6 +115,11 @@ func (provider *ParasailProvider) Embedding(ctx context.Context, key schemas.Key
 	return nil, newUnsupportedOperationError("embedding", "parasail")
 }
 
+// Rerank is not supported by the Parasail provider.
+func (provider *ParasailProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "parasail")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to the Parasail API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Uses Parasail's OpenAI-compatible streaming format.
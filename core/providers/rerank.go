@@ -0,0 +1,173 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the rerank request handling shared by the providers supporting reranking.
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bytedance/sonic"
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+// rerankBatchSize is the largest number of documents sent to a provider in one rerank call. The documents of
+// larger requests are reranked in batches whose results are merged.
+const rerankBatchSize = 1000
+
+// rerankBatch reranks documents, returning the best topN of them (all of them when topN is nil) with indexes
+// relative to documents.
+type rerankBatch func(documents []string, topN *int) (*schemas.BifrostResponse, *schemas.BifrostError)
+
+// rerankInBatches reranks the documents of request in batches of at most rerankBatchSize documents, merging the
+// results of the batches by relevance score and adding up their usage. topN is set on each call to rerank: the
+// top_n of the request when there is a single batch, and nil otherwise so every document is scored. The text of
+// the documents is attached to the results when return_documents is set.
+func rerankInBatches(request *schemas.BifrostRerankRequest, rerank rerankBatch) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	var topN *int
+	if request.Params != nil {
+		topN = request.Params.TopN
+	}
+
+	var response *schemas.BifrostResponse
+	if len(request.Documents) <= rerankBatchSize {
+		resp, bifrostErr := rerank(request.Documents, topN)
+		if bifrostErr != nil {
+			return nil, bifrostErr
+		}
+		response = resp
+	} else {
+		for offset := 0; offset < len(request.Documents); offset += rerankBatchSize {
+			resp, bifrostErr := rerank(request.Documents[offset:min(offset+rerankBatchSize, len(request.Documents))], nil)
+			if bifrostErr != nil {
+				return nil, bifrostErr
+			}
+			for i := range resp.Results {
+				resp.Results[i].Index += offset
+			}
+			if response == nil {
+				response = resp
+				continue
+			}
+			mergeRerankResponse(response, resp)
+		}
+		sort.SliceStable(response.Results, func(i, j int) bool {
+			return response.Results[i].RelevanceScore > response.Results[j].RelevanceScore
+		})
+		if topN != nil && *topN >= 0 && *topN < len(response.Results) {
+			response.Results = response.Results[:*topN]
+		}
+	}
+
+	if request.Params != nil && request.Params.ReturnDocuments != nil && *request.Params.ReturnDocuments {
+		for i, result := range response.Results {
+			if result.Index >= 0 && result.Index < len(request.Documents) {
+				response.Results[i].Document = &schemas.RerankDocument{Text: request.Documents[result.Index]}
+			}
+		}
+	}
+	return response, nil
+}
+
+// mergeRerankResponse adds the results, usage and latency of the rerank response of a batch to response
+func mergeRerankResponse(response, batch *schemas.BifrostResponse) {
+	response.Results = append(response.Results, batch.Results...)
+	response.ExtraFields.Latency += batch.ExtraFields.Latency
+	if batch.Usage != nil {
+		if response.Usage == nil {
+			response.Usage = &schemas.LLMUsage{}
+		}
+		response.Usage.PromptTokens += batch.Usage.PromptTokens
+		response.Usage.TotalTokens += batch.Usage.TotalTokens
+	}
+	if batch.ExtraFields.BilledUsage != nil && batch.ExtraFields.BilledUsage.SearchUnits != nil {
+		if response.ExtraFields.BilledUsage == nil {
+			response.ExtraFields.BilledUsage = &schemas.BilledLLMUsage{}
+		}
+		searchUnits := *batch.ExtraFields.BilledUsage.SearchUnits
+		if response.ExtraFields.BilledUsage.SearchUnits != nil {
+			searchUnits += *response.ExtraFields.BilledUsage.SearchUnits
+		}
+		response.ExtraFields.BilledUsage.SearchUnits = &searchUnits
+	}
+}
+
+// handleRerankRequest posts reqBody to the rerank endpoint at url and decodes a successful response into
+// response. Error responses are decoded into an E, whose message is returned by errorMessage.
+// It returns the raw response when sendBackRawResponse is set, and the latency of the call.
+func handleRerankRequest[T any, E any](
+	ctx context.Context,
+	client *fasthttp.Client,
+	url string,
+	key schemas.Key,
+	extraHeaders map[string]string,
+	providerName schemas.ModelProvider,
+	reqBody any,
+	response *T,
+	errorMessage func(*E) string,
+	sendBackRawResponse bool,
+	logger schemas.Logger,
+) (interface{}, int64, *schemas.BifrostError) {
+	jsonBody, err := sonic.Marshal(reqBody)
+	if err != nil {
+		return nil, 0, newBifrostOperationError(schemas.ErrProviderJSONMarshaling, err, providerName)
+	}
+
+	// Create request
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	// Set any extra headers from network config
+	setExtraHeaders(req, extraHeaders, nil)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod("POST")
+	req.Header.SetContentType("application/json")
+	req.Header.Set("Authorization", "Bearer "+key.Value)
+
+	req.SetBody(jsonBody)
+
+	// Make request
+	latency, bifrostErr := makeRequestWithContext(ctx, client, req, resp)
+	if bifrostErr != nil {
+		return nil, 0, bifrostErr
+	}
+
+	// Handle error response
+	if resp.StatusCode() != fasthttp.StatusOK {
+		logger.Debug(fmt.Sprintf("error from %s provider: %s", providerName, string(resp.Body())))
+
+		var errorResp E
+		bifrostErr := handleProviderAPIError(resp, &errorResp)
+		if bifrostErr.Error.Message == "" {
+			bifrostErr.Error.Message = errorMessage(&errorResp)
+		}
+		return nil, 0, bifrostErr
+	}
+
+	rawResponse, bifrostErr := handleProviderResponse(resp.Body(), response, sendBackRawResponse)
+	if bifrostErr != nil {
+		return nil, 0, bifrostErr
+	}
+	return rawResponse, latency.Milliseconds(), nil
+}
+
+// finishRerankResponse sets the extra fields of the rerank response of a provider
+func finishRerankResponse(response *schemas.BifrostResponse, request *schemas.BifrostRerankRequest, providerName schemas.ModelProvider, latency int64, rawResponse interface{}, sendBackRawResponse bool) *schemas.BifrostResponse {
+	if response.Model == "" {
+		response.Model = request.Model
+	}
+	response.ExtraFields.Provider = providerName
+	response.ExtraFields.ModelRequested = request.Model
+	response.ExtraFields.RequestType = schemas.RerankRequest
+	response.ExtraFields.Latency = latency
+
+	// Only include RawResponse if sendBackRawResponse is enabled
+	if sendBackRawResponse {
+		response.ExtraFields.RawResponse = rawResponse
+	}
+	return response
+}
@@ -47,6 +47,9 @@ func NewSGLProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*SGL
 	// Configure proxy if provided
 	client = configureProxy(client, config.ProxyConfig, logger)
 
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.SGL, config, client, streamClient, logger)
+
 	config.NetworkConfig.BaseURL = strings.TrimRight(config.NetworkConfig.BaseURL, "/")
 
 	// BaseURL is required for SGLang
@@ -144,6 +147,11 @@ func (provider *SGLProvider) Embedding(ctx context.Context, key schemas.Key, req
 	)
 }
 
+// Rerank is not supported by the SGL provider.
+func (provider *SGLProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "sgl")
+}
+
 // ChatCompletionStream performs a streaming chat completion request to the SGL API.
 // It supports real-time streaming of responses using Server-Sent Events (SSE).
 // Uses SGL's OpenAI-compatible streaming format.
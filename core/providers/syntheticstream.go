@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// IsUnsupportedOperationError reports whether bifrostErr is the error of an operation the provider does not support.
+func IsUnsupportedOperationError(bifrostErr *schemas.BifrostError) bool {
+	return bifrostErr != nil && bifrostErr.Error != nil && bifrostErr.Error.Type != nil && *bifrostErr.Error.Type == schemas.UnsupportedOperation
+}
+
+// SynthesizeStream streams a chat or text completion response as the provider would have, following requestType:
+// the content of each choice is sent in chunks of config.ChunkWords words, config.IntervalMs apart, its refusal and
+// tool calls in one chunk each, and the finish reasons and usage in the final chunk. Chunks go through
+// postHookRunner like the chunks of real streams.
+func SynthesizeStream(ctx context.Context, postHookRunner schemas.PostHookRunner, response *schemas.BifrostResponse, requestType schemas.RequestType, config *schemas.SyntheticStreamingConfig, logger schemas.Logger) chan *schemas.BifrostStream {
+	chunkWords := 1
+	var interval time.Duration
+	if config != nil {
+		if config.ChunkWords > 0 {
+			chunkWords = config.ChunkWords
+		}
+		interval = time.Duration(config.IntervalMs) * time.Millisecond
+	}
+	isText := requestType == schemas.TextCompletionStreamRequest
+	providerName := response.ExtraFields.Provider
+
+	responseChan := make(chan *schemas.BifrostStream, schemas.DefaultStreamBufferSize)
+	go func() {
+		defer close(responseChan)
+
+		chunkIndex := -1
+		newChunk := func(index int) *schemas.BifrostResponse {
+			var chunk *schemas.BifrostResponse
+			if isText {
+				chunk = createBifrostCompletionChunkResponse(response.ID, nil, nil, chunkIndex, requestType, providerName, response.ExtraFields.ModelRequested)
+			} else {
+				chunk = createBifrostChatCompletionChunkResponse(response.ID, nil, nil, chunkIndex, requestType, providerName, response.ExtraFields.ModelRequested)
+			}
+			chunkIndex++
+			chunk.Model = response.Model
+			chunk.Created = response.Created
+			chunk.SystemFingerprint = response.SystemFingerprint
+			chunk.Choices[0].Index = index
+			return chunk
+		}
+		send := func(chunk *schemas.BifrostResponse) bool {
+			if chunkIndex > 0 && interval > 0 {
+				timer := time.NewTimer(interval)
+				defer timer.Stop()
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			processAndSendResponse(ctx, postHookRunner, chunk, responseChan, logger)
+			return true
+		}
+
+		for _, choice := range response.Choices {
+			var content string
+			var message *schemas.ChatMessage
+			switch {
+			case choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil:
+				content = *choice.Text
+			case choice.BifrostNonStreamResponseChoice != nil && choice.Message != nil:
+				message = choice.Message
+				if message.Content != nil && message.Content.ContentStr != nil {
+					content = *message.Content.ContentStr
+				} else if message.Content != nil {
+					var text strings.Builder
+					for _, block := range message.Content.ContentBlocks {
+						if block.Text != nil {
+							text.WriteString(*block.Text)
+						}
+					}
+					content = text.String()
+				}
+			}
+
+			words := strings.SplitAfter(content, " ")
+			if len(words) > 1 && words[len(words)-1] == "" {
+				words = words[:len(words)-1]
+			}
+			for start := 0; start < len(words); start += chunkWords {
+				text := strings.Join(words[start:min(start+chunkWords, len(words))], "")
+				if text == "" && start > 0 {
+					continue
+				}
+				chunk := newChunk(choice.Index)
+				if isText {
+					chunk.Choices[0].Text = &text
+				} else {
+					chunk.Choices[0].Delta.Content = &text
+					if start == 0 {
+						chunk.Choices[0].Delta.Role = schemas.Ptr(string(schemas.ChatMessageRoleAssistant))
+					}
+				}
+				if !send(chunk) {
+					return
+				}
+			}
+
+			if message == nil || message.ChatAssistantMessage == nil {
+				continue
+			}
+			if message.Refusal != nil {
+				chunk := newChunk(choice.Index)
+				chunk.Choices[0].Delta.Refusal = message.Refusal
+				if !send(chunk) {
+					return
+				}
+			}
+			if len(message.ToolCalls) > 0 {
+				chunk := newChunk(choice.Index)
+				chunk.Choices[0].Delta.ToolCalls = message.ToolCalls
+				if !send(chunk) {
+					return
+				}
+			}
+		}
+
+		// The final chunk carries the finish reason of every choice and the usage
+		final := newChunk(0)
+		final.Usage = response.Usage
+		final.Choices = final.Choices[:0]
+		for _, choice := range response.Choices {
+			finalChoice := schemas.BifrostChatResponseChoice{Index: choice.Index, FinishReason: choice.FinishReason}
+			if isText {
+				finalChoice.BifrostTextCompletionResponseChoice = &schemas.BifrostTextCompletionResponseChoice{}
+			} else {
+				finalChoice.BifrostStreamResponseChoice = &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{}}
+			}
+			final.Choices = append(final.Choices, finalChoice)
+		}
+		handleStreamEndWithSuccess(ctx, final, postHookRunner, responseChan, logger)
+	}()
+
+	return responseChan
+}
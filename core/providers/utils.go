@@ -3,13 +3,17 @@
 package providers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/textproto"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +33,8 @@ func makeRequestWithContext(ctx context.Context, client *fasthttp.Client, req *f
 	startTime := time.Now()
 	errChan := make(chan error, 1)
 
+	setForwardedHeaders(ctx, req)
+	setForwardedParams(ctx, req)
 	go func() {
 		// client.Do is a blocking call.
 		// It will send an error (or nil for success) to errChan when it completes.
@@ -53,6 +59,7 @@ func makeRequestWithContext(ctx context.Context, client *fasthttp.Client, req *f
 		// The fasthttp.Do call completed.
 		// Calculate latency for both successful and failed requests
 		latency := time.Since(startTime)
+		recordUpstream(ctx, req, resp, latency, err)
 		if err != nil {
 			if errors.Is(err, context.Canceled) {
 				return latency, &schemas.BifrostError{
@@ -82,6 +89,155 @@ func makeRequestWithContext(ctx context.Context, client *fasthttp.Client, req *f
 	}
 }
 
+// upstreamSecretHeaders are the request headers carrying provider credentials.
+var upstreamSecretHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key", "Api-Key", "X-Goog-Api-Key", "Cookie", "X-Amz-Security-Token"}
+
+// upstreamSecretParams are the query parameters carrying provider credentials.
+var upstreamSecretParams = []string{"key", "api-key", "api_key", "access_token", "token"}
+
+// upstreamRecording reports whether the exchanges with providers of a request are captured, by an UpstreamRecorder
+// or a PipelineTrace in ctx.
+func upstreamRecording(ctx context.Context) bool {
+	recorder, _ := ctx.Value(schemas.BifrostContextKeyUpstreamRecorder).(schemas.UpstreamRecorder)
+	trace, _ := ctx.Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace)
+	return recorder != nil || trace != nil
+}
+
+// recordUpstream hands the exchange with a provider to the UpstreamRecorder and the PipelineTrace in ctx, if any,
+// with credentials redacted. Bodies are copied since the request and response are released by the caller.
+func recordUpstream(ctx context.Context, req *fasthttp.Request, resp *fasthttp.Response, latency time.Duration, err error) {
+	if !upstreamRecording(ctx) {
+		return
+	}
+
+	uri := &fasthttp.URI{}
+	req.URI().CopyTo(uri)
+	for _, param := range upstreamSecretParams {
+		if uri.QueryArgs().Has(param) {
+			uri.QueryArgs().Set(param, "[REDACTED]")
+		}
+	}
+	exchange := &schemas.UpstreamExchange{
+		Method:         string(req.Header.Method()),
+		URL:            uri.String(),
+		RequestHeaders: make(map[string]string),
+		RequestBody:    append([]byte(nil), req.Body()...),
+		Latency:        latency,
+	}
+	req.Header.All()(func(key, value []byte) bool {
+		exchange.RequestHeaders[string(key)] = string(value)
+		return true
+	})
+	if err != nil {
+		exchange.Error = err.Error()
+	} else {
+		exchange.StatusCode = resp.StatusCode()
+		exchange.ResponseHeaders = make(map[string]string)
+		resp.Header.All()(func(key, value []byte) bool {
+			exchange.ResponseHeaders[string(key)] = string(value)
+			return true
+		})
+		exchange.ResponseBody = append([]byte(nil), resp.Body()...)
+	}
+	dispatchUpstream(ctx, exchange)
+}
+
+// doHTTPRequest sends a request with a net/http client, used for streams and by the providers not using fasthttp,
+// and records the exchange like makeRequestWithContext. Response bodies are recorded as the caller reads them, and
+// the exchange once the caller closes the body, with the latency of the whole response.
+func doHTTPRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !upstreamRecording(ctx) {
+		return client.Do(req)
+	}
+
+	uri := *req.URL
+	query := uri.Query()
+	for _, param := range upstreamSecretParams {
+		if query.Has(param) {
+			query.Set(param, "[REDACTED]")
+		}
+	}
+	uri.RawQuery = query.Encode()
+	exchange := &schemas.UpstreamExchange{
+		Method:         req.Method,
+		URL:            uri.String(),
+		RequestHeaders: make(map[string]string, len(req.Header)),
+	}
+	for name := range req.Header {
+		exchange.RequestHeaders[name] = req.Header.Get(name)
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			exchange.RequestBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	startTime := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		exchange.Latency = time.Since(startTime)
+		exchange.Error = err.Error()
+		dispatchUpstream(ctx, exchange)
+		return resp, err
+	}
+	exchange.StatusCode = resp.StatusCode
+	exchange.ResponseHeaders = make(map[string]string, len(resp.Header))
+	for name := range resp.Header {
+		exchange.ResponseHeaders[name] = resp.Header.Get(name)
+	}
+	resp.Body = &recordedBody{ReadCloser: resp.Body, finish: func(body []byte) {
+		exchange.ResponseBody = body
+		exchange.Latency = time.Since(startTime)
+		dispatchUpstream(ctx, exchange)
+	}}
+	return resp, nil
+}
+
+// recordedBody keeps a copy of the response body read through it and hands it to finish once closed.
+type recordedBody struct {
+	io.ReadCloser
+	body   bytes.Buffer
+	finish func(body []byte)
+	once   sync.Once
+}
+
+// Read reads from the response body, keeping a copy of what was read
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.body.Write(p[:n])
+	return n, err
+}
+
+// Close closes the response body and records the exchange
+func (b *recordedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.finish(b.body.Bytes()) })
+	return err
+}
+
+// dispatchUpstream redacts the credentials in the headers of an exchange and hands it to the UpstreamRecorder and
+// the PipelineTrace in ctx, if any.
+func dispatchUpstream(ctx context.Context, exchange *schemas.UpstreamExchange) {
+	forwarded, _ := ctx.Value(schemas.BifrostContextKeyForwardedHeaders).(*schemas.ForwardedHeaders)
+	for name := range exchange.RequestHeaders {
+		if forwarded.IsSensitive(name) {
+			exchange.RequestHeaders[name] = "[REDACTED]"
+		}
+		for _, secret := range upstreamSecretHeaders {
+			if strings.EqualFold(name, secret) {
+				exchange.RequestHeaders[name] = "[REDACTED]"
+			}
+		}
+	}
+	if recorder, _ := ctx.Value(schemas.BifrostContextKeyUpstreamRecorder).(schemas.UpstreamRecorder); recorder != nil {
+		recorder.RecordUpstream(ctx, exchange)
+	}
+	trace, _ := ctx.Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace)
+	trace.RecordUpstream(exchange)
+}
+
 // configureProxy sets up a proxy for the fasthttp client based on the provided configuration.
 // It supports HTTP, SOCKS5, and environment-based proxy configurations.
 // Returns the configured client or the original client if proxy configuration is invalid.
@@ -192,28 +348,154 @@ func setExtraHeadersHTTP(req *http.Request, extraHeaders map[string]string, skip
 	}
 }
 
+// setForwardedHeaders sets the inbound headers forwarded to the provider, from ctx, on the fasthttp request.
+// Headers already set on the request, such as credentials and extra headers, are not overwritten.
+func setForwardedHeaders(ctx context.Context, req *fasthttp.Request) {
+	forwarded, ok := ctx.Value(schemas.BifrostContextKeyForwardedHeaders).(*schemas.ForwardedHeaders)
+	if !ok || forwarded == nil {
+		return
+	}
+	for name, value := range forwarded.Headers {
+		canonicalKey := textproto.CanonicalMIMEHeaderKey(name)
+		if len(req.Header.Peek(canonicalKey)) == 0 {
+			req.Header.Set(canonicalKey, value)
+		}
+	}
+}
+
+// setForwardedHeadersHTTP sets the inbound headers forwarded to the provider, from the request context, on the
+// standard HTTP request. Headers already set on the request, such as credentials and extra headers, are not overwritten.
+func setForwardedHeadersHTTP(req *http.Request) {
+	forwarded, ok := req.Context().Value(schemas.BifrostContextKeyForwardedHeaders).(*schemas.ForwardedHeaders)
+	if !ok || forwarded == nil {
+		return
+	}
+	for name, value := range forwarded.Headers {
+		canonicalKey := textproto.CanonicalMIMEHeaderKey(name)
+		if req.Header.Get(canonicalKey) == "" {
+			req.Header.Set(canonicalKey, value)
+		}
+	}
+}
+
+// setForwardedParams adds the extra parameters forwarded to the provider, from ctx, to the JSON body of the fasthttp
+// request. Parameters already in the body are not overwritten.
+func setForwardedParams(ctx context.Context, req *fasthttp.Request) {
+	forwarded, ok := ctx.Value(schemas.BifrostContextKeyForwardedParams).(map[string]any)
+	if !ok || len(forwarded) == 0 {
+		return
+	}
+	if body, ok := mergeForwardedParams(req.Body(), forwarded); ok {
+		req.SetBodyRaw(body)
+	}
+}
+
+// setForwardedParamsHTTP adds the extra parameters forwarded to the provider, from the request context, to the
+// JSON body of the standard HTTP request. Parameters already in the body are not overwritten.
+func setForwardedParamsHTTP(req *http.Request) {
+	forwarded, ok := req.Context().Value(schemas.BifrostContextKeyForwardedParams).(map[string]any)
+	if !ok || len(forwarded) == 0 || req.GetBody == nil {
+		return
+	}
+	reader, err := req.GetBody()
+	if err != nil {
+		return
+	}
+	original, err := io.ReadAll(reader)
+	if err != nil {
+		return
+	}
+	if body, ok := mergeForwardedParams(original, forwarded); ok {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+}
+
+// mergeForwardedParams returns body, a JSON object, with the forwarded parameters it does not have added, and
+// whether it added any. Bodies that are not JSON objects, e.g. multipart forms, are left as they are.
+func mergeForwardedParams(body []byte, forwarded map[string]any) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, false
+	}
+	var fields map[string]json.RawMessage
+	if err := sonic.Unmarshal(trimmed, &fields); err != nil {
+		return nil, false
+	}
+	added := false
+	for name, value := range forwarded {
+		if _, ok := fields[name]; ok {
+			continue
+		}
+		encoded, err := sonic.Marshal(value)
+		if err != nil {
+			continue
+		}
+		fields[name] = encoded
+		added = true
+	}
+	if !added {
+		return nil, false
+	}
+	merged, err := sonic.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+	return merged, true
+}
+
 // handleProviderAPIError processes error responses from provider APIs.
 // It attempts to unmarshal the error response and returns a BifrostError
 // with the appropriate status code and error information.
 func handleProviderAPIError(resp *fasthttp.Response, errorResp any) *schemas.BifrostError {
 	statusCode := resp.StatusCode()
 
+	header := func(name string) string { return string(resp.Header.Peek(name)) }
+
 	if err := sonic.Unmarshal(resp.Body(), &errorResp); err != nil {
-		return &schemas.BifrostError{
+		return setRetryAfter(&schemas.BifrostError{
 			IsBifrostError: true,
 			StatusCode:     &statusCode,
 			Error: &schemas.ErrorField{
 				Message: schemas.ErrProviderResponseUnmarshal,
 				Error:   err,
 			},
-		}
+		}, header)
 	}
 
-	return &schemas.BifrostError{
+	return setRetryAfter(&schemas.BifrostError{
 		IsBifrostError: false,
 		StatusCode:     &statusCode,
 		Error:          &schemas.ErrorField{},
+	}, header)
+}
+
+// parseRetryAfter returns the delay a provider asked for before the next request, from its retry-after-ms header
+// (OpenAI, Azure) or its Retry-After header in seconds or as an HTTP date, 0 when it sent none.
+func parseRetryAfter(header func(name string) string, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(strings.TrimSpace(header("retry-after-ms")), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := strings.TrimSpace(header("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(max(seconds, 0) * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// setRetryAfter records on a provider error the delay the provider asked for in its response headers.
+func setRetryAfter(bifrostErr *schemas.BifrostError, header func(name string) string) *schemas.BifrostError {
+	if delay := parseRetryAfter(header, time.Now()); delay > 0 {
+		bifrostErr.ExtraFields.RetryAfterSeconds = delay.Seconds()
 	}
+	return bifrostErr
 }
 
 // handleProviderResponse handles common response parsing logic for provider responses.
@@ -272,6 +554,7 @@ func newUnsupportedOperationError(operation string, providerName string) *schema
 	return &schemas.BifrostError{
 		IsBifrostError: false,
 		Error: &schemas.ErrorField{
+			Type:    schemas.Ptr(schemas.UnsupportedOperation),
 			Message: fmt.Sprintf("%s is not supported by %s provider", operation, providerName),
 		},
 		ExtraFields: schemas.BifrostErrorExtraFields{
@@ -60,6 +60,7 @@ type VertexProvider struct {
 	logger              schemas.Logger        // Logger for provider operations
 	networkConfig       schemas.NetworkConfig // Network configuration including extra headers
 	sendBackRawResponse bool                  // Whether to include raw response in BifrostResponse
+	allowedEgressHosts  []string              // Hosts requests may go to, empty allows every host
 }
 
 // NewVertexProvider creates a new Vertex provider instance.
@@ -79,6 +80,7 @@ func NewVertexProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*
 		logger:              logger,
 		networkConfig:       config.NetworkConfig,
 		sendBackRawResponse: config.SendBackRawResponse,
+		allowedEgressHosts:  config.AllowedEgressHosts,
 	}, nil
 }
 
@@ -127,6 +129,23 @@ func getAuthClient(key schemas.Key) (*http.Client, error) {
 	return actual.(*http.Client), nil
 }
 
+// authClient returns the authenticated client of a key, restricted to the egress allowlist.
+// Token requests of the client are not restricted.
+func (provider *VertexProvider) authClient(key schemas.Key) (*http.Client, error) {
+	client, err := getAuthClient(key)
+	if err != nil || len(provider.allowedEgressHosts) == 0 {
+		return client, err
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &http.Client{
+		Transport: &egressRoundTripper{next: next, allowed: provider.allowedEgressHosts},
+		Timeout:   client.Timeout,
+	}, nil
+}
+
 // GetProviderKey returns the provider identifier for Vertex.
 func (provider *VertexProvider) GetProviderKey() schemas.ModelProvider {
 	return schemas.Vertex
@@ -236,10 +255,12 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, key schemas.
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client, err := getAuthClient(key)
+	client, err := provider.authClient(key)
 	if err != nil {
 		// Remove client from pool if auth client creation fails
 		removeVertexClient(key.VertexKeyConfig.AuthCredentials)
@@ -247,7 +268,7 @@ func (provider *VertexProvider) ChatCompletion(ctx context.Context, key schemas.
 	}
 
 	// Make request
-	resp, err := client.Do(req)
+	resp, err := doHTTPRequest(client, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -388,6 +409,11 @@ func (provider *VertexProvider) Embedding(ctx context.Context, key schemas.Key,
 	return provider.handleVertexEmbedding(ctx, request.Model, key, reqBody, request.Params)
 }
 
+// Rerank is not supported by the Vertex provider.
+func (provider *VertexProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("rerank", "vertex")
+}
+
 // handleVertexEmbedding handles embedding requests using Vertex's native embedding API
 // This is used for all Vertex AI embedding models as they all use the same response format
 func (provider *VertexProvider) handleVertexEmbedding(ctx context.Context, model string, key schemas.Key, vertexReq *vertex.VertexEmbeddingRequest, params *schemas.EmbeddingParameters) (*schemas.BifrostResponse, *schemas.BifrostError) {
@@ -422,10 +448,12 @@ func (provider *VertexProvider) handleVertexEmbedding(ctx context.Context, model
 
 	// Set any extra headers from network config
 	setExtraHeadersHTTP(req, provider.networkConfig.ExtraHeaders, nil)
+	setForwardedHeadersHTTP(req)
+	setForwardedParamsHTTP(req)
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client, err := getAuthClient(key)
+	client, err := provider.authClient(key)
 	if err != nil {
 		// Remove client from pool if auth client creation fails
 		removeVertexClient(key.VertexKeyConfig.AuthCredentials)
@@ -433,7 +461,7 @@ func (provider *VertexProvider) handleVertexEmbedding(ctx context.Context, model
 	}
 
 	// Make request
-	resp, err := client.Do(req)
+	resp, err := doHTTPRequest(client, req)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			return nil, &schemas.BifrostError{
@@ -531,7 +559,7 @@ func (provider *VertexProvider) ChatCompletionStream(ctx context.Context, postHo
 		return nil, newConfigurationError("region is not set in key config", schemas.Vertex)
 	}
 
-	client, err := getAuthClient(key)
+	client, err := provider.authClient(key)
 	if err != nil {
 		// Remove client from pool if auth client creation fails
 		removeVertexClient(key.VertexKeyConfig.AuthCredentials)
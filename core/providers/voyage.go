@@ -0,0 +1,150 @@
+// Package providers implements various LLM providers and their utility functions.
+// This file contains the Voyage AI provider implementation.
+package providers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/providers/voyage"
+	"github.com/valyala/fasthttp"
+)
+
+// VoyageProvider implements the Provider interface for Voyage AI's API, which serves embeddings and reranking.
+type VoyageProvider struct {
+	logger              schemas.Logger        // Logger for provider operations
+	client              *fasthttp.Client      // HTTP client for API requests
+	networkConfig       schemas.NetworkConfig // Network configuration including extra headers
+	sendBackRawResponse bool                  // Whether to include raw response in BifrostResponse
+}
+
+// NewVoyageProvider creates a new Voyage AI provider instance.
+// It initializes the HTTP client with the provided configuration.
+// The client is configured with timeouts, concurrency limits, and optional proxy settings.
+func NewVoyageProvider(config *schemas.ProviderConfig, logger schemas.Logger) (*VoyageProvider, error) {
+	config.CheckAndSetDefaults()
+
+	client := &fasthttp.Client{
+		ReadTimeout:     time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		WriteTimeout:    time.Second * time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds),
+		MaxConnsPerHost: config.ConcurrencyAndBufferSize.Concurrency,
+	}
+
+	// Configure proxy if provided
+	client = configureProxy(client, config.ProxyConfig, logger)
+
+	// Apply the HTTP client settings and count upstream connections
+	configureHTTPClients(schemas.Voyage, config, client, nil, logger)
+
+	// Set default BaseURL if not provided
+	if config.NetworkConfig.BaseURL == "" {
+		config.NetworkConfig.BaseURL = "https://api.voyageai.com"
+	}
+	config.NetworkConfig.BaseURL = strings.TrimRight(config.NetworkConfig.BaseURL, "/")
+
+	return &VoyageProvider{
+		logger:              logger,
+		client:              client,
+		networkConfig:       config.NetworkConfig,
+		sendBackRawResponse: config.SendBackRawResponse,
+	}, nil
+}
+
+// GetProviderKey returns the provider identifier for Voyage AI.
+func (provider *VoyageProvider) GetProviderKey() schemas.ModelProvider {
+	return schemas.Voyage
+}
+
+// TextCompletion is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) TextCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("text completion", "voyage")
+}
+
+// TextCompletionStream is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) TextCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTextCompletionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("text completion stream", "voyage")
+}
+
+// ChatCompletion is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) ChatCompletion(ctx context.Context, key schemas.Key, request *schemas.BifrostChatRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("chat completion", "voyage")
+}
+
+// ChatCompletionStream is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) ChatCompletionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostChatRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("chat completion stream", "voyage")
+}
+
+// Responses is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) Responses(ctx context.Context, key schemas.Key, request *schemas.BifrostResponsesRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("responses", "voyage")
+}
+
+// ResponsesStream is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) ResponsesStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostResponsesRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("responses stream", "voyage")
+}
+
+// Embedding generates embeddings for the given input text(s) using the OpenAI-compatible Voyage AI embeddings API.
+func (provider *VoyageProvider) Embedding(ctx context.Context, key schemas.Key, request *schemas.BifrostEmbeddingRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return handleOpenAIEmbeddingRequest(
+		ctx,
+		provider.client,
+		provider.networkConfig.BaseURL+"/v1/embeddings",
+		request,
+		key,
+		provider.networkConfig.ExtraHeaders,
+		provider.GetProviderKey(),
+		provider.sendBackRawResponse,
+		provider.logger,
+	)
+}
+
+// Rerank orders documents by their relevance to a query using the Voyage AI rerank API.
+// Requests with more documents than a single call accepts are reranked in batches.
+func (provider *VoyageProvider) Rerank(ctx context.Context, key schemas.Key, request *schemas.BifrostRerankRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	providerName := provider.GetProviderKey()
+
+	return rerankInBatches(request, func(documents []string, topN *int) (*schemas.BifrostResponse, *schemas.BifrostError) {
+		var voyageResp voyage.VoyageRerankResponse
+		rawResponse, latency, bifrostErr := handleRerankRequest(
+			ctx,
+			provider.client,
+			provider.networkConfig.BaseURL+"/v1/rerank",
+			key,
+			provider.networkConfig.ExtraHeaders,
+			providerName,
+			voyage.ToVoyageRerankRequest(request, documents, topN),
+			&voyageResp,
+			func(errorResp *voyage.VoyageError) string { return errorResp.Detail },
+			provider.sendBackRawResponse,
+			provider.logger,
+		)
+		if bifrostErr != nil {
+			return nil, bifrostErr
+		}
+		return finishRerankResponse(voyageResp.ToBifrostResponse(), request, providerName, latency, rawResponse, provider.sendBackRawResponse), nil
+	})
+}
+
+// Speech is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) Speech(ctx context.Context, key schemas.Key, request *schemas.BifrostSpeechRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech", "voyage")
+}
+
+// SpeechStream is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) SpeechStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostSpeechRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("speech stream", "voyage")
+}
+
+// Transcription is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) Transcription(ctx context.Context, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (*schemas.BifrostResponse, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription", "voyage")
+}
+
+// TranscriptionStream is not supported by the Voyage AI provider.
+func (provider *VoyageProvider) TranscriptionStream(ctx context.Context, postHookRunner schemas.PostHookRunner, key schemas.Key, request *schemas.BifrostTranscriptionRequest) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+	return nil, newUnsupportedOperationError("transcription stream", "voyage")
+}
@@ -4,6 +4,7 @@ package schemas
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/bytedance/sonic"
 )
@@ -14,12 +15,29 @@ const (
 
 type KeySelector func(ctx *context.Context, keys []Key, providerKey ModelProvider, model string) (Key, error)
 
+// RaceSelector picks the challenger raced against the primary provider of a request, or returns nil to send the
+// request to the primary provider alone. It must not modify the request.
+type RaceSelector func(ctx *context.Context, req *BifrostRequest) *RaceChallenger
+
+// RaceChallenger is the provider and model a request is raced against. The first successful response, or the
+// first stream to deliver a chunk, is returned and the other attempt is cancelled.
+type RaceChallenger struct {
+	Provider ModelProvider
+	Model    string
+	// Delay hedges the request: the challenger is only sent when the primary provider has not answered, or
+	// streamed a first chunk, within Delay. Both providers are sent the request at once when it is zero.
+	Delay time.Duration
+	// OnOutcome, when set, is called once the race is decided with whether the challenger was sent and won.
+	OnOutcome func(challengerSent, challengerWon bool)
+}
+
 // BifrostRequest is the request struct for all bifrost requests.
 // only ONE of the following fields should be set:
 // - TextCompletionRequest
 // - ChatRequest
 // - ResponsesRequest
 // - EmbeddingRequest
+// - RerankRequest
 // - SpeechRequest
 // - TranscriptionRequest
 type BifrostRequest struct {
@@ -32,10 +50,32 @@ type BifrostRequest struct {
 	ChatRequest           *BifrostChatRequest
 	ResponsesRequest      *BifrostResponsesRequest
 	EmbeddingRequest      *BifrostEmbeddingRequest
+	RerankRequest         *BifrostRerankRequest
 	SpeechRequest         *BifrostSpeechRequest
 	TranscriptionRequest  *BifrostTranscriptionRequest
 }
 
+// GetExtraParams returns the extra parameters of the request, those Bifrost does not know, or nil when it has none.
+func (br *BifrostRequest) GetExtraParams() map[string]any {
+	switch {
+	case br.TextCompletionRequest != nil && br.TextCompletionRequest.Params != nil:
+		return br.TextCompletionRequest.Params.ExtraParams
+	case br.ChatRequest != nil && br.ChatRequest.Params != nil:
+		return br.ChatRequest.Params.ExtraParams
+	case br.ResponsesRequest != nil && br.ResponsesRequest.Params != nil:
+		return br.ResponsesRequest.Params.ExtraParams
+	case br.EmbeddingRequest != nil && br.EmbeddingRequest.Params != nil:
+		return br.EmbeddingRequest.Params.ExtraParams
+	case br.RerankRequest != nil && br.RerankRequest.Params != nil:
+		return br.RerankRequest.Params.ExtraParams
+	case br.SpeechRequest != nil && br.SpeechRequest.Params != nil:
+		return br.SpeechRequest.Params.ExtraParams
+	case br.TranscriptionRequest != nil && br.TranscriptionRequest.Params != nil:
+		return br.TranscriptionRequest.Params.ExtraParams
+	}
+	return nil
+}
+
 // BifrostConfig represents the configuration for initializing a Bifrost instance.
 // It contains the necessary components for setting up the system including account details,
 // plugins, logging, and initial pool size.
@@ -43,10 +83,13 @@ type BifrostConfig struct {
 	Account            Account
 	Plugins            []Plugin
 	Logger             Logger
-	InitialPoolSize    int         // Initial pool size for sync pools in Bifrost. Higher values will reduce memory allocations but will increase memory usage.
-	DropExcessRequests bool        // If true, in cases where the queue is full, requests will not wait for the queue to be empty and will be dropped instead.
-	MCPConfig          *MCPConfig  // MCP (Model Context Protocol) configuration for tool integration
-	KeySelector        KeySelector // Custom key selector function
+	InitialPoolSize    int                     // Initial pool size for sync pools in Bifrost. Higher values will reduce memory allocations but will increase memory usage.
+	DropExcessRequests bool                    // If true, in cases where the queue is full, requests will not wait for the queue to be empty and will be dropped instead.
+	MCPConfig          *MCPConfig              // MCP (Model Context Protocol) configuration for tool integration
+	KeySelector        KeySelector             // Custom key selector function
+	RaceSelector       RaceSelector            // Picks the provider raced against, or hedging, the primary one (requests are never raced when nil)
+	PluginState        PluginState             // State shared by plugins, in memory when nil
+	PluginPolicies     map[string]PluginPolicy // Priority and failure policy of plugins by name, overriding the ones they declare
 }
 
 // ModelProvider represents the different AI model providers supported by Bifrost.
@@ -67,6 +110,11 @@ const (
 	Cerebras   ModelProvider = "cerebras"
 	Gemini     ModelProvider = "gemini"
 	OpenRouter ModelProvider = "openrouter"
+	Voyage     ModelProvider = "voyage"
+	Jina       ModelProvider = "jina"
+	Mock       ModelProvider = "mock"
+	// Declarative is the base provider of custom providers defined by a ProviderSpec
+	Declarative ModelProvider = "declarative"
 )
 
 // SupportedBaseProviders is the list of base providers allowed for custom providers.
@@ -74,6 +122,7 @@ var SupportedBaseProviders = []ModelProvider{
 	Anthropic,
 	Bedrock,
 	Cohere,
+	Declarative,
 	Gemini,
 	OpenAI,
 }
@@ -94,6 +143,9 @@ var StandardProviders = []ModelProvider{
 	SGL,
 	Vertex,
 	OpenRouter,
+	Voyage,
+	Jina,
+	Mock,
 }
 
 // RequestType represents the type of request being made to a provider.
@@ -107,6 +159,7 @@ const (
 	ResponsesRequest            RequestType = "responses"
 	ResponsesStreamRequest      RequestType = "responses_stream"
 	EmbeddingRequest            RequestType = "embedding"
+	RerankRequest               RequestType = "rerank"
 	SpeechRequest               RequestType = "speech"
 	SpeechStreamRequest         RequestType = "speech_stream"
 	TranscriptionRequest        RequestType = "transcription"
@@ -124,8 +177,23 @@ const (
 	BifrostContextKeyDirectKey          BifrostContextKey = "bifrost-direct-key"
 	BifrostContextKeySelectedKey        BifrostContextKey = "bifrost-key-selected" // To store the selected key ID (set by bifrost)
 	BifrostContextKeyStreamEndIndicator BifrostContextKey = "bifrost-stream-end-indicator"
+	BifrostContextKeyUpstreamRecorder   BifrostContextKey = "bifrost-upstream-recorder" // UpstreamRecorder receiving the HTTP exchanges with providers
+	BifrostContextKeyBillingCustomer    BifrostContextKey = "x-bifrost-customer"        // End customer the request is billed to (string)
+	BifrostContextKeyRetentionClass     BifrostContextKey = "bifrost-retention-class"   // Retention class of the request content (string)
+	BifrostContextKeyLanguage           BifrostContextKey = "bifrost-language"          // Language of the prompt, ISO 639-1 code detected or sent by the client (string)
+	BifrostContextKeyLabels             BifrostContextKey = "bifrost-labels"            // Labels attributing the request, e.g. to a feature or environment (map[string]string)
+	BifrostContextKeyRequestHeaders     BifrostContextKey = "bifrost-request-headers"   // Headers of the inbound request, by lowercase name, for the providers' passthrough policies (map[string]string)
+	BifrostContextKeyForwardedHeaders   BifrostContextKey = "bifrost-forwarded-headers" // Inbound headers forwarded to the provider serving the request, set by Bifrost (*ForwardedHeaders)
+	BifrostContextKeyForwardedParams    BifrostContextKey = "bifrost-forwarded-params"  // Extra body parameters forwarded to the provider serving the request, set by Bifrost (map[string]any)
+	BifrostContextKeyPipelineTrace      BifrostContextKey = "bifrost-pipeline-trace"    // PipelineTrace recording the plugin hooks, provider attempts and upstream exchanges of the request
+	BifrostContextKeyBufferStream       BifrostContextKey = "bifrost-buffer-stream"     // Hold back stream chunks until the stream ends, falling back on errors at any point (bool)
+	BifrostContextKeyPluginState        BifrostContextKey = "bifrost-plugin-state"      // PluginState shared by the plugins of the instance, set by Bifrost
 )
 
+// RetentionClassZeroDataRetention marks requests whose prompts and responses must not be persisted by Bifrost or
+// stored by providers that support opting out.
+const RetentionClassZeroDataRetention = "zero_data_retention"
+
 // NOTE: for custom plugin implementation dealing with streaming short circuit,
 // make sure to mark BifrostContextKeyStreamEndIndicator as true at the end of the stream.
 
@@ -211,6 +279,15 @@ type BifrostEmbeddingRequest struct {
 	Fallbacks []Fallback           `json:"fallbacks,omitempty"`
 }
 
+type BifrostRerankRequest struct {
+	Provider  ModelProvider     `json:"provider"`
+	Model     string            `json:"model"`
+	Query     string            `json:"query"`
+	Documents []string          `json:"documents"`
+	Params    *RerankParameters `json:"params,omitempty"`
+	Fallbacks []Fallback        `json:"fallbacks,omitempty"`
+}
+
 type BifrostSpeechRequest struct {
 	Provider  ModelProvider     `json:"provider"`
 	Model     string            `json:"model"`
@@ -241,6 +318,7 @@ type BifrostResponse struct {
 	Object            string                      `json:"object,omitempty"` // text.completion, chat.completion, embedding, speech, transcribe
 	Choices           []BifrostChatResponseChoice `json:"choices,omitempty"`
 	Data              []BifrostEmbedding          `json:"data,omitempty"`       // Maps to "data" field in provider responses (e.g., OpenAI embedding format)
+	Results           []BifrostRerankResult       `json:"results,omitempty"`    // Documents of rerank requests, most relevant first (Cohere and Jina rerank format)
 	Speech            *BifrostSpeech              `json:"speech,omitempty"`     // Maps to "speech" field in provider responses (e.g., OpenAI speech format)
 	Transcribe        *BifrostTranscribe          `json:"transcribe,omitempty"` // Maps to "transcribe" field in provider responses (e.g., OpenAI transcription format)
 	Model             string                      `json:"model,omitempty"`
@@ -294,9 +372,11 @@ func (r *BifrostResponse) ToTextCompletionResponse() {
 
 // LLMUsage represents token usage information
 type LLMUsage struct {
-	PromptTokens     int `json:"prompt_tokens,omitempty"`
-	CompletionTokens int `json:"completion_tokens,omitempty"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens            int                      `json:"prompt_tokens,omitempty"`
+	CompletionTokens        int                      `json:"completion_tokens,omitempty"`
+	TotalTokens             int                      `json:"total_tokens"`
+	PromptTokensDetails     *TokenDetails            `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *CompletionTokensDetails `json:"completion_tokens_details,omitempty"` // Reasoning tokens are counted in the completion tokens
 
 	*ResponsesExtendedResponseUsage
 }
@@ -316,8 +396,9 @@ type AudioTokenDetails struct {
 // TokenDetails provides detailed information about token usage.
 // It is not provided by all model providers.
 type TokenDetails struct {
-	CachedTokens int `json:"cached_tokens,omitempty"`
-	AudioTokens  int `json:"audio_tokens,omitempty"`
+	CachedTokens        int `json:"cached_tokens,omitempty"`         // Prompt tokens read from the provider's prompt cache
+	CacheCreationTokens int `json:"cache_creation_tokens,omitempty"` // Prompt tokens written to the provider's prompt cache
+	AudioTokens         int `json:"audio_tokens,omitempty"`
 }
 
 // CompletionTokensDetails provides detailed information about completion token usage.
@@ -501,9 +582,21 @@ type BifrostResponseExtraFields struct {
 	ModelRequested string             `json:"model_requested"`
 	Latency        int64              `json:"latency,omitempty"` // in milliseconds
 	BilledUsage    *BilledLLMUsage    `json:"billed_usage,omitempty"`
-	ChunkIndex     int                `json:"chunk_index"` // used for streaming responses to identify the chunk index, will be 0 for non-streaming responses
+	UsageEstimated bool               `json:"usage_estimated,omitempty"` // usage was (partly) estimated because the provider did not report it
+	ChunkIndex     int                `json:"chunk_index"`               // used for streaming responses to identify the chunk index, will be 0 for non-streaming responses
 	RawResponse    interface{}        `json:"raw_response,omitempty"`
 	CacheDebug     *BifrostCacheDebug `json:"cache_debug,omitempty"`
+	Sources        []BifrostSource    `json:"sources,omitempty"` // chunks retrieved server-side and injected into the prompt
+}
+
+// BifrostSource attributes a chunk retrieved from a vector store and added to the prompt as context.
+type BifrostSource struct {
+	ID       string                 `json:"id"`
+	Store    string                 `json:"store"`
+	Source   string                 `json:"source,omitempty"`
+	Score    *float64               `json:"score,omitempty"`
+	Text     string                 `json:"text,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // BifrostCacheDebug represents debug information about the cache.
@@ -524,7 +617,8 @@ type BifrostCacheDebug struct {
 }
 
 const (
-	RequestCancelled = "request_cancelled"
+	RequestCancelled     = "request_cancelled"
+	UnsupportedOperation = "unsupported_operation" // Type of the errors of operations a provider does not support
 )
 
 // BifrostStream represents a stream of responses from the Bifrost system.
@@ -567,7 +661,17 @@ type ErrorField struct {
 }
 
 type BifrostErrorExtraFields struct {
-	Provider       ModelProvider `json:"provider"`
-	ModelRequested string        `json:"model_requested"`
-	RequestType    RequestType   `json:"request_type"`
+	Provider           ModelProvider       `json:"provider"`
+	ModelRequested     string              `json:"model_requested"`
+	RequestType        RequestType         `json:"request_type"`
+	StreamInterruption *StreamInterruption `json:"stream_interruption,omitempty"` // Set when a stream fails after content was emitted
+	RetryAfterSeconds  float64             `json:"retry_after_seconds,omitempty"` // Delay the provider asked for before the next request, from its Retry-After header
+}
+
+// StreamInterruption describes a stream that failed after part of the response was sent to the client.
+// Fallbacks are not tried at that point, so the client decides whether to resume or start over.
+type StreamInterruption struct {
+	ChunksEmitted  int    `json:"chunks_emitted"`            // Content chunks sent before the failure
+	PartialContent string `json:"partial_content,omitempty"` // Text streamed before the failure
+	ResumeHint     string `json:"resume_hint"`
 }
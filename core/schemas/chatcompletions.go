@@ -59,6 +59,8 @@ type ChatTool struct {
 	Type     ChatToolType      `json:"type"`
 	Function *ChatToolFunction `json:"function,omitempty"` // Function definition
 	Custom   *ChatToolCustom   `json:"custom,omitempty"`   // Custom tool definition
+
+	CacheControl *CacheControl `json:"cache_control,omitempty"` // Prompt caching breakpoint (Anthropic)
 }
 
 // ChatToolFunction represents a function definition.
@@ -284,6 +286,17 @@ type ChatContentBlock struct {
 	ImageURLStruct *ChatInputImage      `json:"image_url,omitempty"`
 	InputAudio     *ChatInputAudio      `json:"input_audio,omitempty"`
 	File           *ChatInputFile       `json:"file,omitempty"`
+	CacheControl   *CacheControl        `json:"cache_control,omitempty"` // Prompt caching breakpoint (Anthropic)
+}
+
+// CacheControlTypeEphemeral is the only cache control type providers support
+const CacheControlTypeEphemeral = "ephemeral"
+
+// CacheControl marks the end of a cacheable prompt prefix, as in Anthropic's cache_control. Providers caching
+// prompts automatically (e.g. OpenAI) ignore it.
+type CacheControl struct {
+	Type string  `json:"type"`          // "ephemeral"
+	TTL  *string `json:"ttl,omitempty"` // "5m" or "1h"
 }
 
 // ChatInputImage represents image data in a message.
@@ -20,6 +20,8 @@ type LoggerOutputType string
 const (
 	LoggerOutputTypeJSON   LoggerOutputType = "json"
 	LoggerOutputTypePretty LoggerOutputType = "pretty"
+	// LoggerOutputTypeConsole is an alias of LoggerOutputTypePretty.
+	LoggerOutputTypeConsole LoggerOutputType = "console"
 )
 
 // Logger defines the interface for logging operations in the Bifrost system.
@@ -53,3 +55,17 @@ type Logger interface {
 	// SetOutputType sets the output type for the logger.
 	SetOutputType(outputType LoggerOutputType)
 }
+
+// StructuredLogger is implemented by loggers that support key-value fields and sampling.
+// Use bifrost.WithFields and bifrost.Sampled to get these capabilities from any Logger.
+type StructuredLogger interface {
+	Logger
+
+	// With returns a child logger that attaches the given key-value pairs to every message.
+	// Keys must be strings; a trailing key without a value is ignored.
+	With(keyvals ...any) Logger
+
+	// Sample returns a child logger that only writes one of every n messages.
+	// It is meant for hot paths such as per-chunk streaming errors. Fatal messages are never dropped.
+	Sample(n uint32) Logger
+}
@@ -814,6 +814,12 @@ func (br *BifrostResponse) ToResponsesOnly() {
 				InputTokens:  br.Usage.PromptTokens,
 				OutputTokens: br.Usage.CompletionTokens,
 			}
+			if details := br.Usage.CompletionTokensDetails; details != nil {
+				br.Usage.OutputTokensDetails = &ResponsesResponseOutputTokens{ReasoningTokens: details.ReasoningTokens}
+			}
+			if details := br.Usage.PromptTokensDetails; details != nil {
+				br.Usage.InputTokensDetails = &ResponsesResponseInputTokens{CachedTokens: details.CachedTokens}
+			}
 
 			if br.Usage.TotalTokens == 0 {
 				br.Usage.TotalTokens = br.Usage.PromptTokens + br.Usage.CompletionTokens
@@ -873,6 +879,12 @@ func (br *BifrostResponse) ToChatOnly() {
 		// Map Responses usage to Chat usage
 		br.Usage.PromptTokens = br.Usage.ResponsesExtendedResponseUsage.InputTokens
 		br.Usage.CompletionTokens = br.Usage.ResponsesExtendedResponseUsage.OutputTokens
+		if details := br.Usage.OutputTokensDetails; details != nil && details.ReasoningTokens > 0 {
+			br.Usage.CompletionTokensDetails = &CompletionTokensDetails{ReasoningTokens: details.ReasoningTokens}
+		}
+		if details := br.Usage.InputTokensDetails; details != nil && details.CachedTokens > 0 {
+			br.Usage.PromptTokensDetails = &TokenDetails{CachedTokens: details.CachedTokens}
+		}
 		if br.Usage.TotalTokens == 0 {
 			br.Usage.TotalTokens = br.Usage.PromptTokens + br.Usage.CompletionTokens
 		}
@@ -0,0 +1,204 @@
+package schemas
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// OpenAI finish reasons, the ones every normalized response carries
+const (
+	FinishReasonStop          = "stop"
+	FinishReasonLength        = "length"
+	FinishReasonToolCalls     = "tool_calls"
+	FinishReasonContentFilter = "content_filter"
+)
+
+// finishReasonAdapters map the finish reasons of each provider to OpenAI ones. Keys are lowercase.
+var finishReasonAdapters = map[ModelProvider]map[string]string{
+	Anthropic: anthropicFinishReasons,
+	Bedrock: mergeFinishReasons(anthropicFinishReasons, map[string]string{
+		"guardrail_intervened": FinishReasonContentFilter,
+		"content_filtered":     FinishReasonContentFilter,
+	}),
+	Gemini: geminiFinishReasons,
+	// Vertex serves both Gemini and Anthropic models
+	Vertex: mergeFinishReasons(geminiFinishReasons, anthropicFinishReasons),
+	Cohere: {
+		"complete":      FinishReasonStop,
+		"stop_sequence": FinishReasonStop,
+		"max_tokens":    FinishReasonLength,
+		"tool_call":     FinishReasonToolCalls,
+	},
+	Mistral: {
+		"model_length": FinishReasonLength,
+	},
+}
+
+var anthropicFinishReasons = map[string]string{
+	"end_turn":                      FinishReasonStop,
+	"stop_sequence":                 FinishReasonStop,
+	"pause_turn":                    FinishReasonStop,
+	"max_tokens":                    FinishReasonLength,
+	"model_context_window_exceeded": FinishReasonLength,
+	"tool_use":                      FinishReasonToolCalls,
+	"refusal":                       FinishReasonContentFilter,
+}
+
+var geminiFinishReasons = map[string]string{
+	"stop":                      FinishReasonStop,
+	"finish_reason_unspecified": FinishReasonStop,
+	"other":                     FinishReasonStop,
+	"language":                  FinishReasonStop,
+	"malformed_function_call":   FinishReasonStop,
+	"max_tokens":                FinishReasonLength,
+	"unexpected_tool_call":      FinishReasonToolCalls,
+	"safety":                    FinishReasonContentFilter,
+	"recitation":                FinishReasonContentFilter,
+	"blocklist":                 FinishReasonContentFilter,
+	"prohibited_content":        FinishReasonContentFilter,
+	"spii":                      FinishReasonContentFilter,
+	"image_safety":              FinishReasonContentFilter,
+}
+
+// commonFinishReasons catch provider reasons surfacing through OpenAI-compatible providers (e.g. OpenRouter or
+// Ollama serving Anthropic or Gemini models)
+var commonFinishReasons = mergeFinishReasons(anthropicFinishReasons, map[string]string{
+	"eos":          FinishReasonStop,
+	"complete":     FinishReasonStop,
+	"model_length": FinishReasonLength,
+	"tool_call":    FinishReasonToolCalls,
+	"safety":       FinishReasonContentFilter,
+})
+
+// mergeFinishReasons returns the reasons of all maps, later maps winning
+func mergeFinishReasons(maps ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, m := range maps {
+		for reason, mapped := range m {
+			merged[reason] = mapped
+		}
+	}
+	return merged
+}
+
+// NormalizeFinishReason maps a finish reason of provider to the OpenAI one. Reasons without a known mapping are
+// returned lowercased, so that new upstream values stay visible rather than being misreported.
+func NormalizeFinishReason(provider ModelProvider, reason string) string {
+	lower := strings.ToLower(reason)
+	switch lower {
+	case FinishReasonStop, FinishReasonLength, FinishReasonToolCalls, FinishReasonContentFilter, "function_call":
+		return lower
+	}
+	if mapped, ok := finishReasonAdapters[provider][lower]; ok {
+		return mapped
+	}
+	if mapped, ok := commonFinishReasons[lower]; ok {
+		return mapped
+	}
+	return lower
+}
+
+// NormalizeResponse adapts a chat or text completion response (or stream chunk) of provider to the OpenAI shape:
+//   - finish reasons are mapped to stop, length, tool_calls or content_filter, and a stop with tool calls to tool_calls
+//   - usage gets prompt, completion and total tokens even when the provider only reports some of them, and reasoning
+//     and cached tokens in completion_tokens_details and prompt_tokens_details
+//   - assistant messages get their role, and complete tool calls get an ID, the function type and JSON arguments
+//
+// Tool call IDs are only added to complete messages: the deltas of a stream carry the ID in their first chunk only.
+func NormalizeResponse(provider ModelProvider, resp *BifrostResponse) {
+	if resp == nil {
+		return
+	}
+	for i := range resp.Choices {
+		choice := &resp.Choices[i]
+		var toolCalls []ChatAssistantMessageToolCall
+		if choice.BifrostNonStreamResponseChoice != nil && choice.Message != nil {
+			if choice.Message.Role == "" {
+				choice.Message.Role = ChatMessageRoleAssistant
+			}
+			if choice.Message.ChatAssistantMessage != nil {
+				toolCalls = choice.Message.ToolCalls
+				normalizeToolCalls(resp.ID, choice.Index, toolCalls)
+			}
+		}
+		if choice.BifrostStreamResponseChoice != nil && choice.Delta != nil {
+			toolCalls = choice.Delta.ToolCalls
+			for j := range toolCalls {
+				if toolCalls[j].ID != nil && toolCalls[j].Type == nil {
+					toolCalls[j].Type = Ptr("function")
+				}
+			}
+		}
+		if choice.FinishReason != nil {
+			if *choice.FinishReason == "" {
+				choice.FinishReason = nil
+				continue
+			}
+			reason := NormalizeFinishReason(provider, *choice.FinishReason)
+			if reason == FinishReasonStop && len(toolCalls) > 0 {
+				reason = FinishReasonToolCalls
+			}
+			choice.FinishReason = &reason
+		}
+	}
+	if resp.Object == "" && len(resp.Choices) > 0 {
+		switch {
+		case resp.Choices[0].BifrostTextCompletionResponseChoice != nil:
+			resp.Object = "text_completion"
+		case resp.Choices[0].BifrostStreamResponseChoice != nil:
+			resp.Object = "chat.completion.chunk"
+		case resp.Choices[0].BifrostNonStreamResponseChoice != nil:
+			resp.Object = "chat.completion"
+		}
+	}
+	if usage := resp.Usage; usage != nil {
+		if extended := usage.ResponsesExtendedResponseUsage; extended != nil {
+			if usage.PromptTokens == 0 {
+				usage.PromptTokens = extended.InputTokens
+			}
+			if usage.CompletionTokens == 0 {
+				usage.CompletionTokens = extended.OutputTokens
+			}
+			if details := extended.OutputTokensDetails; details != nil && details.ReasoningTokens > 0 && usage.CompletionTokensDetails == nil {
+				usage.CompletionTokensDetails = &CompletionTokensDetails{ReasoningTokens: details.ReasoningTokens}
+			}
+			if details := extended.InputTokensDetails; details != nil && details.CachedTokens > 0 && usage.PromptTokensDetails == nil {
+				usage.PromptTokensDetails = &TokenDetails{CachedTokens: details.CachedTokens}
+			}
+		}
+		if usage.TotalTokens == 0 {
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
+	}
+}
+
+// normalizeToolCalls completes the tool calls of a message. Missing IDs are derived from the response ID, so that
+// they are stable for a response and unique across the responses of a conversation.
+func normalizeToolCalls(responseID string, choiceIndex int, toolCalls []ChatAssistantMessageToolCall) {
+	for i := range toolCalls {
+		toolCall := &toolCalls[i]
+		if toolCall.ID == nil || *toolCall.ID == "" {
+			toolCall.ID = Ptr(toolCallID(responseID, choiceIndex, i))
+		}
+		if toolCall.Type == nil || *toolCall.Type == "" {
+			toolCall.Type = Ptr("function")
+		}
+		if strings.TrimSpace(toolCall.Function.Arguments) == "" {
+			toolCall.Function.Arguments = "{}"
+		}
+	}
+}
+
+// toolCallID returns an OpenAI-style tool call ID, random when the response has no ID
+func toolCallID(responseID string, choiceIndex, toolIndex int) string {
+	if responseID == "" {
+		random := make([]byte, 12)
+		rand.Read(random)
+		return "call_" + hex.EncodeToString(random)
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d/%d", responseID, choiceIndex, toolIndex)))
+	return "call_" + hex.EncodeToString(sum[:12])
+}
@@ -1,21 +1,58 @@
 // Package schemas defines the core schemas and types used by the Bifrost system.
 package schemas
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 // PluginShortCircuit represents a plugin's decision to short-circuit the normal flow.
-// It can contain either a response (success short-circuit), a stream (streaming short-circuit), or an error (error short-circuit).
+// It can contain either a response (success short-circuit), a stream (streaming short-circuit), or an error (error short-circuit),
+// or allow the request, skipping the PreHooks of the plugins after it. A short-circuit setting none of them is ignored.
 type PluginShortCircuit struct {
 	Response *BifrostResponse    // If set, short-circuit with this response (skips provider call)
 	Stream   chan *BifrostStream // If set, short-circuit with this stream (skips provider call)
 	Error    *BifrostError       // If set, short-circuit with this error (can set AllowFallbacks field)
+	Allow    bool                // If set without the others, send the request to the provider without running the remaining PreHooks
+}
+
+// PluginFailurePolicy decides what happens to a request when a hook of a plugin returns an error.
+type PluginFailurePolicy string
+
+const (
+	// PluginFailOpen logs the error and continues as if the hook had not failed (default).
+	PluginFailOpen PluginFailurePolicy = "fail_open"
+	// PluginFailClosed fails the request with a plugin_error wrapping the error of the hook.
+	PluginFailClosed PluginFailurePolicy = "fail_closed"
+)
+
+// PluginPolicy is how the plugin pipeline runs a plugin.
+type PluginPolicy struct {
+	Priority      int                 `json:"priority,omitempty"`       // Plugins of higher priority run their PreHooks first and their PostHooks last; ties keep the registration order
+	FailurePolicy PluginFailurePolicy `json:"failure_policy,omitempty"` // fail_open (default) or fail_closed
+}
+
+// Validate checks the failure policy of a plugin policy.
+func (p PluginPolicy) Validate() error {
+	switch p.FailurePolicy {
+	case "", PluginFailOpen, PluginFailClosed:
+		return nil
+	default:
+		return fmt.Errorf("unknown plugin failure policy %q", p.FailurePolicy)
+	}
+}
+
+// PolicyPlugin is implemented by plugins declaring their own policy, which BifrostConfig.PluginPolicies overrides.
+type PolicyPlugin interface {
+	Plugin
+	PluginPolicy() PluginPolicy
 }
 
 // Plugin defines the interface for Bifrost plugins.
 // Plugins can intercept and modify requests and responses at different stages
 // of the processing pipeline.
 // User can provide multiple plugins in the BifrostConfig.
-// PreHooks are executed in the order they are registered.
+// PreHooks are executed in the order of the priority of plugins, then in the order they are registered.
 // PostHooks are executed in the reverse order of PreHooks.
 //
 // Execution order:
@@ -27,11 +64,12 @@ type PluginShortCircuit struct {
 // Common use cases: rate limiting, caching, logging, monitoring, request transformation, governance.
 //
 // Plugin error handling:
-// - No Plugin errors are returned to the caller; they are logged as warnings by the Bifrost instance.
+// - Plugin errors are logged as warnings by the Bifrost instance and not returned to the caller, unless the plugin fails closed (see PluginPolicy).
 // - PreHook and PostHook can both modify the request/response and the error. Plugins can recover from errors (set error to nil and provide a response), or invalidate a response (set response to nil and provide an error).
 // - PostHook is always called with both the current response and error, and should handle either being nil.
 // - Only truly empty errors (no message, no error, no status code, no type) are treated as recoveries by the pipeline.
 // - If a PreHook returns a PluginShortCircuit, the provider call may be skipped and only the PostHook methods of plugins that had their PreHook executed are called in reverse order.
+// - A PluginShortCircuit allowing the request skips the remaining PreHooks and their PostHooks, and sends the request to the provider.
 // - The plugin pipeline ensures symmetry: for every PreHook executed, the corresponding PostHook will be called in reverse order.
 //
 // IMPORTANT: When returning BifrostError from PreHook or PostHook:
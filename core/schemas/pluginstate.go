@@ -0,0 +1,38 @@
+package schemas
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPluginStateNotCounter is returned by PluginState.Increment when the key holds a value that is not a counter.
+var ErrPluginStateNotCounter = errors.New("plugin state value is not an int64 counter")
+
+// PluginState is a key-value store shared by the plugins of a Bifrost instance, so that plugins can coordinate,
+// e.g. a rate limiter reading the counters of governance, without each inventing its own storage. Keys live in
+// namespaces, by convention the name of the plugin owning them. A TTL of 0 keeps a value until it is deleted; expired
+// values are never returned. Implementations must be safe for concurrent use.
+//
+// Bifrost sets the state of the instance in the context of every request under BifrostContextKeyPluginState.
+type PluginState interface {
+	// Get returns the value of a key, and whether it is set.
+	Get(namespace, key string) (any, bool)
+
+	// Set sets the value of a key, replacing the current one and its TTL.
+	Set(namespace, key string, value any, ttl time.Duration)
+
+	// SetIfAbsent sets the value of a key when it is not set. It returns the value the key holds afterwards, and
+	// whether it is the one given.
+	SetIfAbsent(namespace, key string, value any, ttl time.Duration) (any, bool)
+
+	// CompareAndSwap replaces the value of a key with new when it is old, keeping its TTL, and reports whether it
+	// did. Values must be comparable.
+	CompareAndSwap(namespace, key string, old, new any) bool
+
+	// Increment adds delta to the int64 counter of a key and returns the result. Missing keys start at 0 with the
+	// given TTL, which is not extended by later increments, so counters of fixed windows reset when they expire.
+	Increment(namespace, key string, delta int64, ttl time.Duration) (int64, error)
+
+	// Delete removes a key.
+	Delete(namespace, key string)
+}
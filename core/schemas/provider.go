@@ -3,8 +3,16 @@ package schemas
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
 	"time"
+	"unicode"
 )
 
 const (
@@ -33,12 +41,285 @@ const (
 // ExtraHeaders is automatically copied during provider initialization to prevent data races.
 type NetworkConfig struct {
 	// BaseURL is supported for OpenAI, Anthropic, Cohere, Mistral, and Ollama providers (required for Ollama)
-	BaseURL                        string            `json:"base_url,omitempty"`                 // Base URL for the provider (optional)
-	ExtraHeaders                   map[string]string `json:"extra_headers,omitempty"`            // Additional headers to include in requests (optional)
-	DefaultRequestTimeoutInSeconds int               `json:"default_request_timeout_in_seconds"` // Default timeout for requests
-	MaxRetries                     int               `json:"max_retries"`                        // Maximum number of retries
-	RetryBackoffInitial            time.Duration     `json:"retry_backoff_initial"`              // Initial backoff duration
-	RetryBackoffMax                time.Duration     `json:"retry_backoff_max"`                  // Maximum backoff duration
+	BaseURL                        string                 `json:"base_url,omitempty"`                 // Base URL for the provider (optional)
+	ExtraHeaders                   map[string]string      `json:"extra_headers,omitempty"`            // Additional headers to include in requests (optional)
+	DefaultRequestTimeoutInSeconds int                    `json:"default_request_timeout_in_seconds"` // Default timeout for requests
+	MaxRetries                     int                    `json:"max_retries"`                        // Maximum number of retries
+	RetryBackoffInitial            time.Duration          `json:"retry_backoff_initial"`              // Initial backoff duration
+	RetryBackoffMax                time.Duration          `json:"retry_backoff_max"`                  // Maximum backoff duration
+	HTTPClient                     *HTTPClientConfig      `json:"http_client,omitempty"`              // Upstream connection tuning (optional)
+	PassthroughHeaders             *ForwardingPolicy      `json:"passthrough_headers,omitempty"`      // Inbound request headers forwarded to the provider (optional, none by default)
+	PassthroughParams              *ParamForwardingPolicy `json:"passthrough_params,omitempty"`       // Extra request body parameters forwarded to the provider (optional, none by default)
+}
+
+// HTTPClientConfig tunes the HTTP clients a provider uses to reach its API. Zero values keep the defaults.
+// Bifrost sends regular requests through a fasthttp client and streams through a net/http client; both
+// share these settings.
+type HTTPClientConfig struct {
+	MaxConnsPerHost          int    `json:"max_conns_per_host,omitempty"`           // Connections per upstream host (defaults to the provider concurrency)
+	MaxIdleConns             int    `json:"max_idle_conns,omitempty"`               // Idle connections kept for reuse by the streaming client (defaults to 100)
+	IdleConnTimeoutInSeconds int    `json:"idle_conn_timeout_in_seconds,omitempty"` // How long idle keep-alive connections are kept (defaults to 10s, 90s for streams)
+	MaxConnLifetimeInSeconds int    `json:"max_conn_lifetime_in_seconds,omitempty"` // Non-streaming connections are closed after this long, so DNS changes are picked up
+	DisableKeepAlives        bool   `json:"disable_keep_alives,omitempty"`          // Open a new connection for every request
+	TLSSessionCacheSize      int    `json:"tls_session_cache_size,omitempty"`       // TLS sessions cached for resumption (0 disables resumption)
+	CABundle                 string `json:"ca_bundle,omitempty"`                    // PEM certificates, or the path of a PEM file, trusted in addition to the system roots
+	ClientCertificate        string `json:"client_certificate,omitempty"`           // PEM certificate chain presented for mTLS, or the path of a PEM file
+	ClientKey                string `json:"client_key,omitempty"`                   // PEM private key of the client certificate, or the path of a PEM file
+}
+
+// Validate checks that the limits are not negative and that the CA bundle and client certificate can be loaded.
+func (hcc *HTTPClientConfig) Validate() error {
+	switch {
+	case hcc.MaxConnsPerHost < 0:
+		return fmt.Errorf("max_conns_per_host must not be negative")
+	case hcc.MaxIdleConns < 0:
+		return fmt.Errorf("max_idle_conns must not be negative")
+	case hcc.IdleConnTimeoutInSeconds < 0:
+		return fmt.Errorf("idle_conn_timeout_in_seconds must not be negative")
+	case hcc.MaxConnLifetimeInSeconds < 0:
+		return fmt.Errorf("max_conn_lifetime_in_seconds must not be negative")
+	case hcc.TLSSessionCacheSize < 0:
+		return fmt.Errorf("tls_session_cache_size must not be negative")
+	}
+	if _, err := hcc.RootCAs(); err != nil {
+		return err
+	}
+	_, err := hcc.ClientCertificates()
+	return err
+}
+
+// nonForwardableHeaders are the inbound headers never forwarded to providers, whatever the passthrough policy:
+// credentials, hop-by-hop and body framing headers. Headers prefixed with x-bf- control Bifrost itself and are
+// not forwarded either.
+var nonForwardableHeaders = []string{
+	"authorization", "proxy-authorization", "x-api-key", "api-key", "x-goog-api-key", "cookie",
+	"host", "connection", "keep-alive", "transfer-encoding", "te", "trailer", "upgrade", "content-length",
+	"content-type", "content-encoding", "accept-encoding",
+}
+
+// ForwardingPolicy is the policy selecting the headers of inbound requests forwarded to a provider. Headers
+// are denied by default: only the allowed ones are forwarded, and credentials and Bifrost's own x-bf- headers
+// never are. Forwarded headers never replace the headers Bifrost sets itself, extra headers included.
+type ForwardingPolicy struct {
+	Allow     []string `json:"allow"`               // Header names, case-insensitive; names ending in * match by prefix, e.g. "x-trace-*"
+	Sensitive []string `json:"sensitive,omitempty"` // Forwarded headers whose values are redacted in logs and recordings, same syntax as allow
+}
+
+// Validate checks that the patterns are header names and that the allowed ones do not name headers that are
+// never forwarded.
+func (fp *ForwardingPolicy) Validate() error {
+	for _, patterns := range [][]string{fp.Allow, fp.Sensitive} {
+		for _, pattern := range patterns {
+			name := strings.TrimSuffix(pattern, "*")
+			if name == "" && pattern != "*" {
+				return fmt.Errorf("header pattern cannot be empty")
+			}
+			for _, r := range name {
+				if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("!#$%&'+-.^_`|~", r)) {
+					return fmt.Errorf("header pattern %q is not a header name", pattern)
+				}
+			}
+		}
+	}
+	for _, pattern := range fp.Allow {
+		if name := strings.ToLower(pattern); slices.Contains(nonForwardableHeaders, name) || strings.HasPrefix(name, "x-bf-") {
+			return fmt.Errorf("header %s is never forwarded", pattern)
+		}
+	}
+	return nil
+}
+
+// Select returns the headers of an inbound request, by lowercase name, that the policy forwards, or nil when
+// none is. It is safe to call on a nil policy, which forwards nothing.
+func (fp *ForwardingPolicy) Select(headers map[string]string) *ForwardedHeaders {
+	if fp == nil || len(fp.Allow) == 0 {
+		return nil
+	}
+	var forwarded *ForwardedHeaders
+	for name, value := range headers {
+		name = strings.ToLower(name)
+		if slices.Contains(nonForwardableHeaders, name) || strings.HasPrefix(name, "x-bf-") || !matchesHeaderPattern(fp.Allow, name) {
+			continue
+		}
+		if forwarded == nil {
+			forwarded = &ForwardedHeaders{Headers: make(map[string]string)}
+		}
+		forwarded.Headers[name] = value
+		if matchesHeaderPattern(fp.Sensitive, name) {
+			forwarded.Sensitive = append(forwarded.Sensitive, name)
+		}
+	}
+	return forwarded
+}
+
+// matchesHeaderPattern reports whether a lowercase header name matches one of the patterns.
+func matchesHeaderPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ForwardedHeaders are the inbound request headers forwarded to the provider serving a request. Bifrost sets
+// them in the request context under BifrostContextKeyForwardedHeaders.
+type ForwardedHeaders struct {
+	Headers   map[string]string // By lowercase name
+	Sensitive []string          // Lowercase names of the headers whose values are redacted in logs and recordings
+}
+
+// IsSensitive reports whether the value of a forwarded header is redacted in logs and recordings.
+func (fh *ForwardedHeaders) IsSensitive(name string) bool {
+	return fh != nil && slices.Contains(fh.Sensitive, strings.ToLower(name))
+}
+
+// Redacted returns the forwarded headers with the values of the sensitive ones replaced, for logging.
+func (fh *ForwardedHeaders) Redacted() map[string]string {
+	redacted := make(map[string]string, len(fh.Headers))
+	for name, value := range fh.Headers {
+		if fh.IsSensitive(name) {
+			value = "[REDACTED]"
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// nonForwardableParams are the request body parameters never forwarded as extra parameters: Bifrost sets them
+// itself from the request it serves.
+var nonForwardableParams = []string{"model", "messages", "input", "prompt", "stream", "fallbacks"}
+
+// ParamForwardingPolicy is the policy selecting the extra parameters of requests, those Bifrost does not know,
+// forwarded as-is in the body sent to a provider. Vendor-specific parameters are denied by default: only the
+// allowed ones are forwarded, and they never replace the parameters Bifrost sets itself. Clients send them at the
+// top level of the request body, or in an extra_body object as OpenAI SDKs do.
+type ParamForwardingPolicy struct {
+	Allow []string `json:"allow"` // Parameter names, case-sensitive; names ending in * match by prefix, e.g. "vendor_*"
+}
+
+// Validate checks that the patterns are parameter names and do not name parameters that are never forwarded.
+func (pp *ParamForwardingPolicy) Validate() error {
+	for _, pattern := range pp.Allow {
+		name := strings.TrimSuffix(pattern, "*")
+		if name == "" && pattern != "*" {
+			return fmt.Errorf("parameter pattern cannot be empty")
+		}
+		if strings.ContainsAny(name, "*\"\\") || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+			return fmt.Errorf("parameter pattern %q is not a parameter name", pattern)
+		}
+		if slices.Contains(nonForwardableParams, name) {
+			return fmt.Errorf("parameter %s is never forwarded", pattern)
+		}
+	}
+	return nil
+}
+
+// Select returns the extra parameters the policy forwards, or nil when none is. It is safe to call on a nil
+// policy, which forwards nothing.
+func (pp *ParamForwardingPolicy) Select(extraParams map[string]any) map[string]any {
+	if pp == nil || len(pp.Allow) == 0 {
+		return nil
+	}
+	var forwarded map[string]any
+	for name, value := range extraParams {
+		if slices.Contains(nonForwardableParams, name) || !matchesParamPattern(pp.Allow, name) {
+			continue
+		}
+		if forwarded == nil {
+			forwarded = make(map[string]any)
+		}
+		forwarded[name] = value
+	}
+	return forwarded
+}
+
+// matchesParamPattern reports whether a parameter name matches one of the patterns.
+func matchesParamPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UpstreamConnectionStats counts the connections a provider opened to its API.
+type UpstreamConnectionStats struct {
+	Opened                int64 `json:"opened"`
+	Closed                int64 `json:"closed"`
+	Open                  int64 `json:"open"`
+	TLSSessionCacheHits   int64 `json:"tls_session_cache_hits"`   // Handshakes resuming a cached session
+	TLSSessionCacheMisses int64 `json:"tls_session_cache_misses"` // Full handshakes with a session cache configured
+}
+
+// ProviderQueueStats describes the requests waiting for a worker of a provider.
+type ProviderQueueStats struct {
+	Queued   int `json:"queued"`   // Requests waiting in the queue
+	Capacity int `json:"capacity"` // Buffer size of the queue
+}
+
+// RootCAs returns the system roots with the CA bundle added, or nil when no bundle is configured.
+func (hcc *HTTPClientConfig) RootCAs() (*x509.CertPool, error) {
+	if hcc.CABundle == "" {
+		return nil, nil
+	}
+	pem, err := readPEM(hcc.CABundle, "ca_bundle")
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca_bundle contains no PEM certificates")
+	}
+	return pool, nil
+}
+
+// ClientCertificates returns the mTLS client certificate, or nil when none is configured.
+func (hcc *HTTPClientConfig) ClientCertificates() ([]tls.Certificate, error) {
+	if hcc.ClientCertificate == "" && hcc.ClientKey == "" {
+		return nil, nil
+	}
+	if hcc.ClientCertificate == "" || hcc.ClientKey == "" {
+		return nil, fmt.Errorf("client_certificate and client_key must be set together")
+	}
+	certPEM, err := readPEM(hcc.ClientCertificate, "client_certificate")
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := readPEM(hcc.ClientKey, "client_key")
+	if err != nil {
+		return nil, err
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return []tls.Certificate{certificate}, nil
+}
+
+// readPEM returns a PEM value given inline or as the path of a file.
+func readPEM(value string, field string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", field, err)
+	}
+	return data, nil
 }
 
 // DefaultNetworkConfig is the default network configuration for provider connections.
@@ -83,6 +364,41 @@ type ProxyConfig struct {
 	Password string    `json:"password"` // Password for proxy authentication
 }
 
+// Validate checks the proxy type and that HTTP and SOCKS5 proxies have a URL.
+func (pc *ProxyConfig) Validate() error {
+	switch pc.Type {
+	case NoProxy, EnvProxy:
+		return nil
+	case HTTPProxy, Socks5Proxy:
+		if pc.URL == "" {
+			return fmt.Errorf("%s proxy requires a url", pc.Type)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported proxy type %q", pc.Type)
+	}
+}
+
+// EgressHostAllowed reports whether host matches an entry of allowed, exactly or through a "*." wildcard
+// matching any subdomain. Every host is allowed when the list is empty.
+func EgressHostAllowed(allowed []string, host string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
 // AllowedRequests controls which operations are permitted.
 // A nil *AllowedRequests means "all operations allowed."
 // A non-nil value only allows fields set to true; omitted or false fields are disallowed.
@@ -92,6 +408,7 @@ type AllowedRequests struct {
 	ChatCompletion       bool `json:"chat_completion"`
 	ChatCompletionStream bool `json:"chat_completion_stream"`
 	Embedding            bool `json:"embedding"`
+	Rerank               bool `json:"rerank"`
 	Speech               bool `json:"speech"`
 	SpeechStream         bool `json:"speech_stream"`
 	Transcription        bool `json:"transcription"`
@@ -115,6 +432,8 @@ func (ar *AllowedRequests) IsOperationAllowed(operation RequestType) bool {
 		return ar.ChatCompletionStream
 	case EmbeddingRequest:
 		return ar.Embedding
+	case RerankRequest:
+		return ar.Rerank
 	case SpeechRequest:
 		return ar.Speech
 	case SpeechStreamRequest:
@@ -132,6 +451,7 @@ type CustomProviderConfig struct {
 	CustomProviderKey string           `json:"-"`                  // Custom provider key, internally set by Bifrost
 	BaseProviderType  ModelProvider    `json:"base_provider_type"` // Base provider type
 	AllowedRequests   *AllowedRequests `json:"allowed_requests,omitempty"`
+	Spec              *ProviderSpec    `json:"spec,omitempty"` // API of the provider, required by the declarative base provider only
 }
 
 // IsOperationAllowed checks if a specific operation is allowed for this custom provider
@@ -142,6 +462,69 @@ func (cpc *CustomProviderConfig) IsOperationAllowed(operation RequestType) bool
 	return cpc.AllowedRequests.IsOperationAllowed(operation)
 }
 
+// MockProviderConfig configures the mock provider, which answers requests locally without calling any API.
+// Completions only depend on the request, while latency, jitter, token pacing and injected errors simulate
+// a real provider for load tests and demos.
+type MockProviderConfig struct {
+	// Response is a text/template rendering completions. It receives .Model, .Prompt (the last user message or
+	// the prompt) and .RequestType. By default the prompt is echoed back.
+	Response string `json:"response,omitempty"`
+	// ModelResponses overrides Response for specific models
+	ModelResponses map[string]string `json:"model_responses,omitempty"`
+	LatencyMs      int               `json:"latency_ms,omitempty"`     // Delay before responding, or before the first streamed chunk
+	JitterMs       int               `json:"jitter_ms,omitempty"`      // Random extra delay of up to JitterMs
+	TokenDelayMs   int               `json:"token_delay_ms,omitempty"` // Delay between streamed chunks, one chunk per word
+	// ErrorRate is the fraction of requests failing with ErrorStatusCode (500 by default) and ErrorMessage
+	ErrorRate           float64 `json:"error_rate,omitempty"`
+	ErrorStatusCode     int     `json:"error_status_code,omitempty"`
+	ErrorMessage        string  `json:"error_message,omitempty"`
+	EmbeddingDimensions int     `json:"embedding_dimensions,omitempty"` // Size of the deterministic embeddings, 1536 by default
+}
+
+// Validate checks the delays, the error rate and the response templates.
+func (mpc *MockProviderConfig) Validate() error {
+	if mpc.LatencyMs < 0 || mpc.JitterMs < 0 || mpc.TokenDelayMs < 0 {
+		return fmt.Errorf("mock provider delays must not be negative")
+	}
+	if mpc.ErrorRate < 0 || mpc.ErrorRate > 1 {
+		return fmt.Errorf("mock provider error_rate must be between 0 and 1")
+	}
+	if mpc.ErrorStatusCode != 0 && (mpc.ErrorStatusCode < 400 || mpc.ErrorStatusCode > 599) {
+		return fmt.Errorf("mock provider error_status_code must be between 400 and 599")
+	}
+	if mpc.EmbeddingDimensions < 0 {
+		return fmt.Errorf("mock provider embedding_dimensions must not be negative")
+	}
+	if _, err := template.New("response").Parse(mpc.Response); err != nil {
+		return fmt.Errorf("invalid mock provider response template: %w", err)
+	}
+	for model, text := range mpc.ModelResponses {
+		if _, err := template.New(model).Parse(text); err != nil {
+			return fmt.Errorf("invalid mock provider response template for model %s: %w", model, err)
+		}
+	}
+	return nil
+}
+
+// SyntheticStreamingConfig lets a provider serve the chat and text completion streams it does not support: the
+// completion is requested without streaming and sent back in chunks of a few words, paced like a real stream.
+type SyntheticStreamingConfig struct {
+	Enabled    bool `json:"enabled"`
+	ChunkWords int  `json:"chunk_words,omitempty"` // Words per chunk, 1 by default
+	IntervalMs int  `json:"interval_ms,omitempty"` // Delay between chunks, none by default
+}
+
+// Validate checks the chunk size and the pacing.
+func (ssc *SyntheticStreamingConfig) Validate() error {
+	if ssc.ChunkWords < 0 {
+		return fmt.Errorf("synthetic_streaming: chunk_words must not be negative")
+	}
+	if ssc.IntervalMs < 0 {
+		return fmt.Errorf("synthetic_streaming: interval_ms must not be negative")
+	}
+	return nil
+}
+
 // ProviderConfig represents the complete configuration for a provider.
 // An array of ProviderConfig needs to be provided in GetConfigForProvider
 // in your account interface implementation.
@@ -153,6 +536,13 @@ type ProviderConfig struct {
 	ProxyConfig          *ProxyConfig          `json:"proxy_config,omitempty"` // Proxy configuration
 	SendBackRawResponse  bool                  `json:"send_back_raw_response"` // Send raw response back in the bifrost response (default: false)
 	CustomProviderConfig *CustomProviderConfig `json:"custom_provider_config,omitempty"`
+	MockConfig           *MockProviderConfig   `json:"mock_config,omitempty"`     // Responses of the mock provider
+	RequestShaping       *RequestShapingConfig `json:"request_shaping,omitempty"` // Parameter defaults and overrides of the requests
+	// SyntheticStreaming streams the completions of the provider when it cannot stream them itself
+	SyntheticStreaming *SyntheticStreamingConfig `json:"synthetic_streaming,omitempty"`
+	// AllowedEgressHosts restricts the hosts the provider connects to; "*.example.com" matches subdomains.
+	// Requests to other hosts fail before connecting. Empty allows every host.
+	AllowedEgressHosts []string `json:"allowed_egress_hosts,omitempty"`
 }
 
 func (config *ProviderConfig) CheckAndSetDefaults() {
@@ -208,6 +598,8 @@ type Provider interface {
 	ResponsesStream(ctx context.Context, postHookRunner PostHookRunner, key Key, request *BifrostResponsesRequest) (chan *BifrostStream, *BifrostError)
 	// Embedding performs an embedding request
 	Embedding(ctx context.Context, key Key, request *BifrostEmbeddingRequest) (*BifrostResponse, *BifrostError)
+	// Rerank performs a rerank request, ordering documents by their relevance to a query
+	Rerank(ctx context.Context, key Key, request *BifrostRerankRequest) (*BifrostResponse, *BifrostError)
 	// Speech performs a text to speech request
 	Speech(ctx context.Context, key Key, request *BifrostSpeechRequest) (*BifrostResponse, *BifrostError)
 	// SpeechStream performs a text to speech stream request
@@ -217,3 +609,25 @@ type Provider interface {
 	// TranscriptionStream performs a transcription stream request
 	TranscriptionStream(ctx context.Context, postHookRunner PostHookRunner, key Key, request *BifrostTranscriptionRequest) (chan *BifrostStream, *BifrostError)
 }
+
+// UpstreamExchange is an HTTP request sent to a provider and the response it returned, with credentials redacted.
+type UpstreamExchange struct {
+	Method          string            `json:"method"`
+	URL             string            `json:"url"`
+	RequestHeaders  map[string]string `json:"request_headers"`
+	RequestBody     []byte            `json:"request_body,omitempty"`
+	StatusCode      int               `json:"status_code,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ResponseBody    []byte            `json:"response_body,omitempty"`
+	Latency         time.Duration     `json:"latency"`
+	Error           string            `json:"error,omitempty"` // Transport error when no response was received
+}
+
+// UpstreamRecorder receives the HTTP exchanges with providers of a request. Set it in the request context under
+// BifrostContextKeyUpstreamRecorder to capture them, streams and the providers using net/http included.
+// RecordUpstream is called on the provider worker goroutine once the exchange completes, or for streamed responses
+// on the stream goroutine once the whole body was read; requests cancelled before the provider answers are not
+// recorded.
+type UpstreamRecorder interface {
+	RecordUpstream(ctx context.Context, exchange *UpstreamExchange)
+}
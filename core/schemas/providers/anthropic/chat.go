@@ -34,8 +34,9 @@ func (mr *AnthropicMessageRequest) ToBifrostRequest() *schemas.BifrostChatReques
 			for _, block := range mr.System.ContentBlocks {
 				if block.Text != nil { // System messages will only have text content
 					contentBlocks = append(contentBlocks, schemas.ChatContentBlock{
-						Type: schemas.ChatContentBlockTypeText,
-						Text: block.Text,
+						Type:         schemas.ChatContentBlockTypeText,
+						Text:         block.Text,
+						CacheControl: block.CacheControl,
 					})
 				}
 			}
@@ -88,8 +89,9 @@ func (mr *AnthropicMessageRequest) ToBifrostRequest() *schemas.BifrostChatReques
 							for _, block := range toolResult.Content.ContentBlocks {
 								if block.Text != nil {
 									contentBlocks = append(contentBlocks, schemas.ChatContentBlock{
-										Type: schemas.ChatContentBlockTypeText,
-										Text: block.Text,
+										Type:         schemas.ChatContentBlockTypeText,
+										Text:         block.Text,
+										CacheControl: block.CacheControl,
 									})
 								} else if block.Source != nil {
 									contentBlocks = append(contentBlocks, block.ToBifrostContentImageBlock())
@@ -124,8 +126,9 @@ func (mr *AnthropicMessageRequest) ToBifrostRequest() *schemas.BifrostChatReques
 					case AnthropicContentBlockTypeText:
 						if content.Text != nil {
 							contentBlocks = append(contentBlocks, schemas.ChatContentBlock{
-								Type: schemas.ChatContentBlockTypeText,
-								Text: content.Text,
+								Type:         schemas.ChatContentBlockTypeText,
+								Text:         content.Text,
+								CacheControl: content.CacheControl,
 							})
 						}
 					case AnthropicContentBlockTypeImage:
@@ -169,7 +172,7 @@ func (mr *AnthropicMessageRequest) ToBifrostRequest() *schemas.BifrostChatReques
 	bifrostReq.Input = messages
 
 	// Convert parameters
-	if mr.MaxTokens > 0 || mr.Temperature != nil || mr.TopP != nil || mr.TopK != nil || mr.StopSequences != nil {
+	if mr.MaxTokens > 0 || mr.Temperature != nil || mr.TopP != nil || mr.TopK != nil || mr.StopSequences != nil || mr.Thinking != nil {
 		params := &schemas.ChatParameters{
 			ExtraParams: make(map[string]interface{}),
 		}
@@ -189,6 +192,15 @@ func (mr *AnthropicMessageRequest) ToBifrostRequest() *schemas.BifrostChatReques
 		if mr.StopSequences != nil {
 			params.Stop = mr.StopSequences
 		}
+		if mr.Thinking != nil {
+			switch {
+			case mr.Thinking.Type == "enabled" && mr.Thinking.BudgetTokens != nil:
+				params.ReasoningEffort = schemas.Ptr(schemas.ReasoningEffortForBudget(*mr.Thinking.BudgetTokens))
+				params.ExtraParams["thinking_budget"] = *mr.Thinking.BudgetTokens
+			case mr.Thinking.Type == "disabled":
+				params.ReasoningEffort = schemas.Ptr(schemas.ReasoningEffortMinimal)
+			}
+		}
 
 		bifrostReq.Params = params
 	}
@@ -214,6 +226,7 @@ func (mr *AnthropicMessageRequest) ToBifrostRequest() *schemas.BifrostChatReques
 					Description: schemas.Ptr(tool.Description),
 					Parameters:  &params,
 				},
+				CacheControl: tool.CacheControl,
 			})
 		}
 		if bifrostReq.Params == nil {
@@ -350,11 +363,7 @@ func (response *AnthropicMessageResponse) ToBifrostResponse() *schemas.BifrostRe
 
 	// Convert usage information
 	if response.Usage != nil {
-		bifrostResponse.Usage = &schemas.LLMUsage{
-			PromptTokens:     response.Usage.InputTokens,
-			CompletionTokens: response.Usage.OutputTokens,
-			TotalTokens:      response.Usage.InputTokens + response.Usage.OutputTokens,
-		}
+		bifrostResponse.Usage = response.Usage.ToBifrostUsage()
 	}
 
 	return bifrostResponse
@@ -386,6 +395,7 @@ func ToAnthropicChatCompletionRequest(bifrostReq *schemas.BifrostChatRequest) *A
 		if ok {
 			anthropicReq.TopK = topK
 		}
+		applyReasoningEffort(anthropicReq, bifrostReq.Params.ReasoningEffort, bifrostReq.Params.ExtraParams)
 
 		// Convert tools
 		if bifrostReq.Params.Tools != nil {
@@ -395,7 +405,8 @@ func ToAnthropicChatCompletionRequest(bifrostReq *schemas.BifrostChatRequest) *A
 					continue
 				}
 				anthropicTool := AnthropicTool{
-					Name: tool.Function.Name,
+					Name:         tool.Function.Name,
+					CacheControl: tool.CacheControl,
 				}
 				if tool.Function.Description != nil {
 					anthropicTool.Description = *tool.Function.Description
@@ -465,8 +476,9 @@ func ToAnthropicChatCompletionRequest(bifrostReq *schemas.BifrostChatRequest) *A
 					for _, block := range msg.Content.ContentBlocks {
 						if block.Text != nil {
 							blocks = append(blocks, AnthropicContentBlock{
-								Type: "text",
-								Text: block.Text,
+								Type:         "text",
+								Text:         block.Text,
+								CacheControl: block.CacheControl,
 							})
 						}
 					}
@@ -499,8 +511,9 @@ func ToAnthropicChatCompletionRequest(bifrostReq *schemas.BifrostChatRequest) *A
 							for _, block := range toolMsg.Content.ContentBlocks {
 								if block.Text != nil {
 									blocks = append(blocks, AnthropicContentBlock{
-										Type: "text",
-										Text: block.Text,
+										Type:         "text",
+										Text:         block.Text,
+										CacheControl: block.CacheControl,
 									})
 								} else if block.ImageURLStruct != nil {
 									blocks = append(blocks, ConvertToAnthropicImageBlock(block))
@@ -544,8 +557,9 @@ func ToAnthropicChatCompletionRequest(bifrostReq *schemas.BifrostChatRequest) *A
 					for _, block := range msg.Content.ContentBlocks {
 						if block.Text != nil {
 							content = append(content, AnthropicContentBlock{
-								Type: "text",
-								Text: block.Text,
+								Type:         "text",
+								Text:         block.Text,
+								CacheControl: block.CacheControl,
 							})
 						} else if block.ImageURLStruct != nil {
 							content = append(content, ConvertToAnthropicImageBlock(block))
@@ -576,8 +590,8 @@ func ToAnthropicChatCompletionRequest(bifrostReq *schemas.BifrostChatRequest) *A
 			}
 
 			// Set content
-			if len(content) == 1 && content[0].Type == "text" {
-				// Single text content can be string
+			if len(content) == 1 && content[0].Type == "text" && content[0].CacheControl == nil {
+				// Single text content can be string, unless it carries a cache breakpoint
 				anthropicMsg.Content = AnthropicContent{ContentStr: content[0].Text}
 			} else if len(content) > 0 {
 				// Multiple content blocks
@@ -610,10 +624,7 @@ func ToAnthropicChatCompletionResponse(bifrostResp *schemas.BifrostResponse) *An
 
 	// Convert usage information
 	if bifrostResp.Usage != nil {
-		anthropicResp.Usage = &AnthropicUsage{
-			InputTokens:  bifrostResp.Usage.PromptTokens,
-			OutputTokens: bifrostResp.Usage.CompletionTokens,
-		}
+		anthropicResp.Usage = ToAnthropicUsage(bifrostResp.Usage)
 	}
 
 	// Convert choices to content
@@ -772,10 +783,7 @@ func ToAnthropicChatCompletionStreamResponse(bifrostResp *schemas.BifrostRespons
 		if streamResp.Type == "" {
 			streamResp.Type = "message_delta"
 		}
-		streamResp.Usage = &AnthropicUsage{
-			InputTokens:  bifrostResp.Usage.PromptTokens,
-			OutputTokens: bifrostResp.Usage.CompletionTokens,
-		}
+		streamResp.Usage = ToAnthropicUsage(bifrostResp.Usage)
 	}
 
 	// Set common fields
@@ -844,3 +852,28 @@ func ToAnthropicChatCompletionError(bifrostErr *schemas.BifrostError) *Anthropic
 		Error: errorStruct,
 	}
 }
+
+// applyReasoningEffort enables extended thinking with the budget standing for a reasoning effort. Anthropic requires
+// budgets of at least 1024 tokens, below max_tokens, and rejects sampling parameters while thinking, so thinking is
+// left disabled when max_tokens leaves no room for it. The exact budget of Anthropic clients, kept in the
+// thinking_budget extra param, wins over the one of the effort.
+func applyReasoningEffort(anthropicReq *AnthropicMessageRequest, effort *string, extraParams map[string]interface{}) {
+	if effort == nil {
+		return
+	}
+	budget, ok := schemas.ReasoningBudget(*effort)
+	if !ok || budget == 0 {
+		return
+	}
+	if exact, ok := schemas.SafeExtractIntPointer(extraParams["thinking_budget"]); ok && exact != nil {
+		budget = *exact
+	}
+	budget = min(budget, anthropicReq.MaxTokens-1)
+	if budget < anthropicMinThinkingBudget {
+		return
+	}
+	anthropicReq.Thinking = &AnthropicThinking{Type: "enabled", BudgetTokens: schemas.Ptr(budget)}
+	anthropicReq.Temperature = nil
+	anthropicReq.TopP = nil
+	anthropicReq.TopK = nil
+}
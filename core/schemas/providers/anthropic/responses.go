@@ -156,6 +156,9 @@ func ToAnthropicResponsesRequest(bifrostReq *schemas.BifrostResponsesRequest) *A
 				}
 			}
 		}
+		if anthropicReq.Thinking == nil && bifrostReq.Params.Reasoning != nil {
+			applyReasoningEffort(anthropicReq, bifrostReq.Params.Reasoning.Effort, bifrostReq.Params.ExtraParams)
+		}
 
 		// Convert tools
 		if bifrostReq.Params.Tools != nil {
@@ -213,10 +216,12 @@ func (response *AnthropicMessageResponse) ToResponsesBifrostResponse() *schemas.
 
 	// Convert usage information
 	if response.Usage != nil {
+		usage := response.Usage.ToBifrostUsage()
 		bifrostResp.Usage = &schemas.LLMUsage{
-			TotalTokens: response.Usage.InputTokens + response.Usage.OutputTokens,
+			TotalTokens:         usage.TotalTokens,
+			PromptTokensDetails: usage.PromptTokensDetails,
 			ResponsesExtendedResponseUsage: &schemas.ResponsesExtendedResponseUsage{
-				InputTokens:  response.Usage.InputTokens,
+				InputTokens:  usage.PromptTokens,
 				OutputTokens: response.Usage.OutputTokens,
 			},
 		}
@@ -250,27 +255,25 @@ func ToAnthropicResponsesResponse(bifrostResp *schemas.BifrostResponse) *Anthrop
 
 	// Convert usage information
 	if bifrostResp.Usage != nil {
-		anthropicResp.Usage = &AnthropicUsage{
-			InputTokens:  bifrostResp.Usage.PromptTokens,
-			OutputTokens: bifrostResp.Usage.CompletionTokens,
-		}
+		anthropicResp.Usage = ToAnthropicUsage(bifrostResp.Usage)
 
 		responsesUsage := bifrostResp.Usage.ResponsesExtendedResponseUsage
 
-		if responsesUsage != nil && responsesUsage.InputTokens > 0 {
-			anthropicResp.Usage.InputTokens = responsesUsage.InputTokens
-		}
-
-		if responsesUsage != nil && responsesUsage.OutputTokens > 0 {
-			anthropicResp.Usage.OutputTokens = responsesUsage.OutputTokens
-		}
-
 		// Handle cached tokens if present
 		if responsesUsage != nil &&
 			responsesUsage.InputTokensDetails != nil &&
 			responsesUsage.InputTokensDetails.CachedTokens > 0 {
 			anthropicResp.Usage.CacheReadInputTokens = responsesUsage.InputTokensDetails.CachedTokens
 		}
+
+		// Responses input tokens include the cached ones, Anthropic input tokens do not
+		if responsesUsage != nil && responsesUsage.InputTokens > 0 {
+			anthropicResp.Usage.InputTokens = max(0, responsesUsage.InputTokens-anthropicResp.Usage.CacheReadInputTokens-anthropicResp.Usage.CacheCreationInputTokens)
+		}
+
+		if responsesUsage != nil && responsesUsage.OutputTokens > 0 {
+			anthropicResp.Usage.OutputTokens = responsesUsage.OutputTokens
+		}
 	}
 
 	// Convert output messages to Anthropic content blocks
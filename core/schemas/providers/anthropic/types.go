@@ -12,6 +12,9 @@ const (
 	AnthropicDefaultMaxTokens = 4096
 )
 
+// anthropicMinThinkingBudget is the smallest budget_tokens Anthropic accepts for extended thinking
+const anthropicMinThinkingBudget = 1024
+
 // ==================== REQUEST TYPES ====================
 
 // AnthropicTextRequest represents an Anthropic text completion request
@@ -135,6 +138,8 @@ type AnthropicContentBlock struct {
 	Input     any                       `json:"input,omitempty"`       // For tool_use content
 	Content   *AnthropicContent         `json:"content,omitempty"`     // For tool_result content
 	Source    *AnthropicImageSource     `json:"source,omitempty"`      // For image content
+
+	CacheControl *schemas.CacheControl `json:"cache_control,omitempty"` // Prompt caching breakpoint
 }
 
 // AnthropicImageSource represents image source in Anthropic format
@@ -169,6 +174,8 @@ type AnthropicTool struct {
 	Type        *AnthropicToolType              `json:"type,omitempty"`
 	Description string                          `json:"description"`
 	InputSchema *schemas.ToolFunctionParameters `json:"input_schema,omitempty"`
+
+	CacheControl *schemas.CacheControl `json:"cache_control,omitempty"` // Prompt caching breakpoint
 }
 
 // AnthropicToolChoice represents tool choice in Anthropic format
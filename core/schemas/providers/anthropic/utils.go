@@ -47,8 +47,9 @@ func MapBifrostFinishReasonToAnthropic(bifrostReason string) string {
 // Uses the same pattern as the original buildAnthropicImageSourceMap function
 func ConvertToAnthropicImageBlock(block schemas.ChatContentBlock) AnthropicContentBlock {
 	imageBlock := AnthropicContentBlock{
-		Type:   "image",
-		Source: &AnthropicImageSource{},
+		Type:         "image",
+		Source:       &AnthropicImageSource{},
+		CacheControl: block.CacheControl,
 	}
 
 	if block.ImageURLStruct == nil {
@@ -105,6 +106,7 @@ func (block AnthropicContentBlock) ToBifrostContentImageBlock() schemas.ChatCont
 		ImageURLStruct: &schemas.ChatInputImage{
 			URL: getImageURLFromBlock(block),
 		},
+		CacheControl: block.CacheControl,
 	}
 }
 
@@ -129,3 +131,35 @@ func getImageURLFromBlock(block AnthropicContentBlock) string {
 
 	return ""
 }
+
+// ToBifrostUsage converts Anthropic usage to Bifrost usage. Anthropic input tokens exclude the tokens read from
+// and written to the prompt cache, while Bifrost prompt tokens include them, as OpenAI's do.
+func (usage *AnthropicUsage) ToBifrostUsage() *schemas.LLMUsage {
+	promptTokens := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+	bifrostUsage := &schemas.LLMUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      promptTokens + usage.OutputTokens,
+	}
+	if usage.CacheReadInputTokens > 0 || usage.CacheCreationInputTokens > 0 {
+		bifrostUsage.PromptTokensDetails = &schemas.TokenDetails{
+			CachedTokens:        usage.CacheReadInputTokens,
+			CacheCreationTokens: usage.CacheCreationInputTokens,
+		}
+	}
+	return bifrostUsage
+}
+
+// ToAnthropicUsage converts Bifrost usage to Anthropic usage, taking the cached prompt tokens out of the input tokens
+func ToAnthropicUsage(usage *schemas.LLMUsage) *AnthropicUsage {
+	anthropicUsage := &AnthropicUsage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+	}
+	if details := usage.PromptTokensDetails; details != nil {
+		anthropicUsage.CacheReadInputTokens = details.CachedTokens
+		anthropicUsage.CacheCreationInputTokens = details.CacheCreationTokens
+		anthropicUsage.InputTokens = max(0, usage.PromptTokens-details.CachedTokens-details.CacheCreationTokens)
+	}
+	return anthropicUsage
+}
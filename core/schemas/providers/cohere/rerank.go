@@ -0,0 +1,68 @@
+package cohere
+
+import "github.com/maximhq/bifrost/core/schemas"
+
+// CohereRerankRequest represents a Cohere v2 rerank API request
+type CohereRerankRequest struct {
+	Model           string   `json:"model"`                        // Rerank model to use
+	Query           string   `json:"query"`                        // Query the documents are ranked against
+	Documents       []string `json:"documents"`                    // Documents to rank
+	TopN            *int     `json:"top_n,omitempty"`              // Number of best documents to return
+	MaxTokensPerDoc *int     `json:"max_tokens_per_doc,omitempty"` // Documents are truncated to this many tokens
+}
+
+// CohereRerankResponse represents a Cohere v2 rerank API response
+type CohereRerankResponse struct {
+	ID      string               `json:"id"`             // Response ID
+	Results []CohereRerankResult `json:"results"`        // Documents ranked by relevance
+	Meta    *CohereEmbeddingMeta `json:"meta,omitempty"` // Response metadata, with the billed search units
+}
+
+// CohereRerankResult represents the relevance of a document
+type CohereRerankResult struct {
+	Index          int     `json:"index"`           // Position of the document in the request
+	RelevanceScore float64 `json:"relevance_score"` // Relevance of the document to the query
+}
+
+// ToCohereRerankRequest converts a Bifrost rerank request to Cohere format, for the given documents and top_n
+func ToCohereRerankRequest(bifrostReq *schemas.BifrostRerankRequest, documents []string, topN *int) *CohereRerankRequest {
+	if bifrostReq == nil {
+		return nil
+	}
+	cohereReq := &CohereRerankRequest{
+		Model:     bifrostReq.Model,
+		Query:     bifrostReq.Query,
+		Documents: documents,
+		TopN:      topN,
+	}
+	if bifrostReq.Params != nil {
+		cohereReq.MaxTokensPerDoc = bifrostReq.Params.MaxTokensPerDoc
+	}
+	return cohereReq
+}
+
+// ToBifrostResponse converts a Cohere rerank response to Bifrost format
+func (cohereResp *CohereRerankResponse) ToBifrostResponse() *schemas.BifrostResponse {
+	if cohereResp == nil {
+		return nil
+	}
+
+	bifrostResponse := &schemas.BifrostResponse{
+		ID:      cohereResp.ID,
+		Object:  "rerank",
+		Results: make([]schemas.BifrostRerankResult, 0, len(cohereResp.Results)),
+	}
+	for _, result := range cohereResp.Results {
+		bifrostResponse.Results = append(bifrostResponse.Results, schemas.BifrostRerankResult{
+			Index:          result.Index,
+			RelevanceScore: result.RelevanceScore,
+		})
+	}
+
+	// Search units are what Cohere bills reranking by
+	if cohereResp.Meta != nil && cohereResp.Meta.BilledUnits != nil && cohereResp.Meta.BilledUnits.SearchUnits != nil {
+		bifrostResponse.ExtraFields.BilledUsage = &schemas.BilledLLMUsage{SearchUnits: cohereResp.Meta.BilledUnits.SearchUnits}
+	}
+
+	return bifrostResponse
+}
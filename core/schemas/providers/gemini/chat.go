@@ -558,7 +558,7 @@ func ToGeminiGenerationResponse(bifrostResp *schemas.BifrostResponse) interface{
 			}
 
 			if choice.FinishReason != nil {
-				candidate.FinishReason = FinishReason(*choice.FinishReason)
+				candidate.FinishReason = FinishReason(schemas.MapFinishReasonToProvider(*choice.FinishReason, schemas.Gemini))
 			}
 
 			// Convert message content to Gemini parts
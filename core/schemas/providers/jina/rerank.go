@@ -0,0 +1,75 @@
+package jina
+
+import "github.com/maximhq/bifrost/core/schemas"
+
+// JinaRerankRequest represents a Jina AI rerank API request
+type JinaRerankRequest struct {
+	Model           string   `json:"model"`            // Rerank model to use
+	Query           string   `json:"query"`            // Query the documents are ranked against
+	Documents       []string `json:"documents"`        // Documents to rank
+	TopN            *int     `json:"top_n,omitempty"`  // Number of best documents to return
+	ReturnDocuments bool     `json:"return_documents"` // Always false, documents are attached by Bifrost
+}
+
+// JinaRerankResponse represents a Jina AI rerank API response
+type JinaRerankResponse struct {
+	Model   string             `json:"model"`
+	Results []JinaRerankResult `json:"results"` // Documents ranked by relevance
+	Usage   *JinaUsage         `json:"usage,omitempty"`
+}
+
+// JinaRerankResult represents the relevance of a document
+type JinaRerankResult struct {
+	Index          int     `json:"index"`           // Position of the document in the request
+	RelevanceScore float64 `json:"relevance_score"` // Relevance of the document to the query
+}
+
+// JinaUsage represents the tokens billed for a request
+type JinaUsage struct {
+	TotalTokens int `json:"total_tokens"`
+}
+
+// JinaError represents an error response from the Jina AI API
+type JinaError struct {
+	Detail string `json:"detail"`
+}
+
+// ToJinaRerankRequest converts a Bifrost rerank request to Jina AI format, for the given documents and top_n
+func ToJinaRerankRequest(bifrostReq *schemas.BifrostRerankRequest, documents []string, topN *int) *JinaRerankRequest {
+	if bifrostReq == nil {
+		return nil
+	}
+	return &JinaRerankRequest{
+		Model:     bifrostReq.Model,
+		Query:     bifrostReq.Query,
+		Documents: documents,
+		TopN:      topN,
+	}
+}
+
+// ToBifrostResponse converts a Jina AI rerank response to Bifrost format
+func (jinaResp *JinaRerankResponse) ToBifrostResponse() *schemas.BifrostResponse {
+	if jinaResp == nil {
+		return nil
+	}
+
+	bifrostResponse := &schemas.BifrostResponse{
+		Object:  "rerank",
+		Model:   jinaResp.Model,
+		Results: make([]schemas.BifrostRerankResult, 0, len(jinaResp.Results)),
+	}
+	for _, result := range jinaResp.Results {
+		bifrostResponse.Results = append(bifrostResponse.Results, schemas.BifrostRerankResult{
+			Index:          result.Index,
+			RelevanceScore: result.RelevanceScore,
+		})
+	}
+	if jinaResp.Usage != nil {
+		bifrostResponse.Usage = &schemas.LLMUsage{
+			PromptTokens: jinaResp.Usage.TotalTokens,
+			TotalTokens:  jinaResp.Usage.TotalTokens,
+		}
+	}
+
+	return bifrostResponse
+}
@@ -1,11 +1,20 @@
 package openai
 
-import "github.com/maximhq/bifrost/core/schemas"
+import (
+	"slices"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
 
 // ToBifrostRequest converts an OpenAI chat request to Bifrost format
 func (r *OpenAIChatRequest) ToBifrostRequest() *schemas.BifrostChatRequest {
 	provider, model := schemas.ParseModelString(r.Model, schemas.OpenAI)
 
+	// max_tokens is accepted as an alias of max_completion_tokens, the latter winning when both are set
+	if r.MaxCompletionTokens == nil && r.MaxTokens != nil {
+		r.MaxCompletionTokens = r.MaxTokens
+	}
+
 	bifrostReq := &schemas.BifrostChatRequest{
 		Provider: provider,
 		Model:    model,
@@ -29,7 +38,73 @@ func ToOpenAIChatRequest(bifrostReq *schemas.BifrostChatRequest) *OpenAIChatRequ
 
 	if bifrostReq.Params != nil {
 		openaiReq.ChatParameters = *bifrostReq.Params
+		translateReasoningParams(bifrostReq.Provider, openaiReq)
+	}
+	// OpenRouter forwards cache breakpoints to the providers supporting them, other OpenAI-compatible APIs cache
+	// prompts automatically and may reject the unknown field
+	if bifrostReq.Provider != schemas.OpenRouter {
+		stripCacheControl(openaiReq)
 	}
 
 	return openaiReq
 }
+
+// stripCacheControl removes the cache_control breakpoints of a request's messages and tools. Messages, content
+// blocks and tools are shared with the Bifrost request, so they are copied before being modified.
+func stripCacheControl(req *OpenAIChatRequest) {
+	var messages []schemas.ChatMessage
+	for i, message := range req.Messages {
+		if message.Content == nil || !slices.ContainsFunc(message.Content.ContentBlocks, hasCacheControl) {
+			continue
+		}
+		if messages == nil {
+			messages = slices.Clone(req.Messages)
+		}
+		content := *message.Content
+		content.ContentBlocks = slices.Clone(content.ContentBlocks)
+		for j := range content.ContentBlocks {
+			content.ContentBlocks[j].CacheControl = nil
+		}
+		messages[i].Content = &content
+	}
+	if messages != nil {
+		req.Messages = messages
+	}
+	if slices.ContainsFunc(req.Tools, func(tool schemas.ChatTool) bool { return tool.CacheControl != nil }) {
+		req.Tools = slices.Clone(req.Tools)
+		for i := range req.Tools {
+			req.Tools[i].CacheControl = nil
+		}
+	}
+}
+
+// hasCacheControl reports whether a content block is a cache breakpoint
+func hasCacheControl(block schemas.ChatContentBlock) bool {
+	return block.CacheControl != nil
+}
+
+// translateReasoningParams adapts the parameters of a request to its model, so that clients can switch between
+// reasoning and chat models without parameter errors:
+//   - reasoning models (o-series, gpt-5) get max_completion_tokens and no sampling parameters, which they reject
+//   - other models get max_tokens, the only token limit many OpenAI-compatible APIs understand
+//   - OpenAI chat models (gpt-4o, gpt-4.1, ...) get no reasoning_effort, which they reject; other models keep it, as
+//     OpenAI-compatible APIs serving their own reasoning models (e.g. Gemini, Groq) accept it
+func translateReasoningParams(provider schemas.ModelProvider, req *OpenAIChatRequest) {
+	if schemas.IsOpenAIReasoningModel(req.Model) {
+		req.Temperature = nil
+		req.TopP = nil
+		req.PresencePenalty = nil
+		req.FrequencyPenalty = nil
+		req.LogitBias = nil
+		req.LogProbs = nil
+		req.TopLogProbs = nil
+		return
+	}
+	if req.MaxCompletionTokens != nil {
+		req.MaxTokens = req.MaxCompletionTokens
+		req.MaxCompletionTokens = nil
+	}
+	if (provider == schemas.OpenAI || provider == schemas.Azure) && schemas.IsOpenAIChatModel(req.Model) {
+		req.ReasoningEffort = nil
+	}
+}
@@ -37,7 +37,8 @@ type OpenAIChatRequest struct {
 	Messages []schemas.ChatMessage `json:"messages"`
 
 	schemas.ChatParameters
-	Stream *bool `json:"stream,omitempty"`
+	MaxTokens *int  `json:"max_tokens,omitempty"` // Legacy alias of max_completion_tokens, still the only one many compatible APIs accept
+	Stream    *bool `json:"stream,omitempty"`
 }
 
 // IsStreamingRequested implements the StreamingRequest interface
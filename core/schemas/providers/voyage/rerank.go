@@ -0,0 +1,82 @@
+package voyage
+
+import "github.com/maximhq/bifrost/core/schemas"
+
+// VoyageRerankRequest represents a Voyage AI rerank API request
+type VoyageRerankRequest struct {
+	Model      string   `json:"model"`                // Rerank model to use
+	Query      string   `json:"query"`                // Query the documents are ranked against
+	Documents  []string `json:"documents"`            // Documents to rank
+	TopK       *int     `json:"top_k,omitempty"`      // Number of best documents to return
+	Truncation *bool    `json:"truncation,omitempty"` // Whether inputs over the context length are truncated instead of rejected
+}
+
+// VoyageRerankResponse represents a Voyage AI rerank API response
+type VoyageRerankResponse struct {
+	Object string               `json:"object"`
+	Data   []VoyageRerankResult `json:"data"` // Documents ranked by relevance
+	Model  string               `json:"model"`
+	Usage  *VoyageUsage         `json:"usage,omitempty"`
+}
+
+// VoyageRerankResult represents the relevance of a document
+type VoyageRerankResult struct {
+	Index          int     `json:"index"`           // Position of the document in the request
+	RelevanceScore float64 `json:"relevance_score"` // Relevance of the document to the query
+}
+
+// VoyageUsage represents the tokens billed for a request
+type VoyageUsage struct {
+	TotalTokens int `json:"total_tokens"`
+}
+
+// VoyageError represents an error response from the Voyage AI API
+type VoyageError struct {
+	Detail string `json:"detail"`
+}
+
+// ToVoyageRerankRequest converts a Bifrost rerank request to Voyage AI format, for the given documents and top_n
+func ToVoyageRerankRequest(bifrostReq *schemas.BifrostRerankRequest, documents []string, topN *int) *VoyageRerankRequest {
+	if bifrostReq == nil {
+		return nil
+	}
+	voyageReq := &VoyageRerankRequest{
+		Model:     bifrostReq.Model,
+		Query:     bifrostReq.Query,
+		Documents: documents,
+		TopK:      topN,
+	}
+	if bifrostReq.Params != nil && bifrostReq.Params.ExtraParams != nil {
+		if truncation, ok := schemas.SafeExtractBoolPointer(bifrostReq.Params.ExtraParams["truncation"]); ok {
+			voyageReq.Truncation = truncation
+		}
+	}
+	return voyageReq
+}
+
+// ToBifrostResponse converts a Voyage AI rerank response to Bifrost format
+func (voyageResp *VoyageRerankResponse) ToBifrostResponse() *schemas.BifrostResponse {
+	if voyageResp == nil {
+		return nil
+	}
+
+	bifrostResponse := &schemas.BifrostResponse{
+		Object:  "rerank",
+		Model:   voyageResp.Model,
+		Results: make([]schemas.BifrostRerankResult, 0, len(voyageResp.Data)),
+	}
+	for _, result := range voyageResp.Data {
+		bifrostResponse.Results = append(bifrostResponse.Results, schemas.BifrostRerankResult{
+			Index:          result.Index,
+			RelevanceScore: result.RelevanceScore,
+		})
+	}
+	if voyageResp.Usage != nil {
+		bifrostResponse.Usage = &schemas.LLMUsage{
+			PromptTokens: voyageResp.Usage.TotalTokens,
+			TotalTokens:  voyageResp.Usage.TotalTokens,
+		}
+	}
+
+	return bifrostResponse
+}
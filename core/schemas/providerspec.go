@@ -0,0 +1,299 @@
+package schemas
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Stream formats of declarative providers
+const (
+	ProviderSpecStreamSSE    = "sse"    // Server-sent events, one JSON document per data line
+	ProviderSpecStreamNDJSON = "ndjson" // One JSON document per line
+)
+
+// ProviderSpec defines the API of a declarative provider: the requests sent for each operation and how their
+// responses are read. Requests are built from the OpenAI format of the Bifrost request and responses mapped back
+// with JQ-like path expressions, so OpenAI-compatible and bespoke inference APIs need no Go code.
+//
+// Paths are relative to network_config.base_url. Operations without a definition are not supported.
+type ProviderSpec struct {
+	// AuthHeader carries the key value, Authorization by default. No header is sent for keys without a value.
+	AuthHeader string `json:"auth_header,omitempty"`
+	// AuthTemplate is a text/template rendering the auth header from .Key and .Model, "Bearer {{.Key}}" by default
+	AuthTemplate   string                 `json:"auth_template,omitempty"`
+	Headers        map[string]string      `json:"headers,omitempty"` // Static headers sent with every request
+	ChatCompletion *ProviderSpecOperation `json:"chat_completion,omitempty"`
+	TextCompletion *ProviderSpecOperation `json:"text_completion,omitempty"`
+	Embedding      *ProviderSpecOperation `json:"embedding,omitempty"`
+}
+
+// ProviderSpecOperation is the request and response of an operation of a declarative provider.
+type ProviderSpecOperation struct {
+	Path   string `json:"path"`             // text/template rendering the path from .Model, e.g. /v1/models/{{.Model}}:generate
+	Method string `json:"method,omitempty"` // POST by default
+	// Request maps the fields of the request body, by dot separated path, to expressions evaluated against the
+	// OpenAI format of the request, e.g. {"inputs": ".messages[-1].content", "parameters.max_new_tokens": ".max_tokens"}.
+	// Values other than strings starting with "." are sent as they are. Expressions yielding null are left out.
+	Request map[string]any `json:"request"`
+	// Response maps the fields of the response body
+	Response ProviderSpecResponse `json:"response"`
+	// Stream streams the operation. Operations without it only serve non-streaming requests.
+	Stream *ProviderSpecStream `json:"stream,omitempty"`
+}
+
+// ProviderSpecResponse maps response bodies to Bifrost responses with path expressions. Lists of strings, such as
+// the texts of content blocks, are concatenated.
+type ProviderSpecResponse struct {
+	ID               string `json:"id,omitempty"`
+	Content          string `json:"content,omitempty"` // Completion text, or the delta of a streamed chunk
+	FinishReason     string `json:"finish_reason,omitempty"`
+	PromptTokens     string `json:"prompt_tokens,omitempty"`
+	CompletionTokens string `json:"completion_tokens,omitempty"`
+	Embeddings       string `json:"embeddings,omitempty"` // A list of vectors, or a single vector
+	Error            string `json:"error,omitempty"`      // Error message of non-2xx responses and stream chunks
+}
+
+// ProviderSpecStream is the streaming variant of an operation.
+type ProviderSpecStream struct {
+	Format string `json:"format"` // sse or ndjson
+	// Request adds fields to the request body of streaming requests, e.g. {"stream": true}, as Request does
+	Request map[string]any `json:"request,omitempty"`
+	// Done is the data ending SSE streams, [DONE] by default. Streams also end with the response body.
+	Done  string               `json:"done,omitempty"`
+	Chunk ProviderSpecResponse `json:"chunk"` // Mapping of each chunk
+}
+
+// Validate checks the templates, the expressions and that every operation maps the responses it serves.
+func (ps *ProviderSpec) Validate() error {
+	if ps.ChatCompletion == nil && ps.TextCompletion == nil && ps.Embedding == nil {
+		return fmt.Errorf("provider spec defines no operation")
+	}
+	if _, err := template.New("auth").Parse(ps.AuthTemplate); err != nil {
+		return fmt.Errorf("invalid provider spec auth_template: %w", err)
+	}
+	for name, operation := range map[string]*ProviderSpecOperation{
+		"chat_completion": ps.ChatCompletion,
+		"text_completion": ps.TextCompletion,
+		"embedding":       ps.Embedding,
+	} {
+		if operation == nil {
+			continue
+		}
+		if err := operation.validate(name == "embedding"); err != nil {
+			return fmt.Errorf("provider spec %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks an operation; embedding operations map embeddings rather than content.
+func (op *ProviderSpecOperation) validate(embedding bool) error {
+	if !strings.HasPrefix(op.Path, "/") {
+		return fmt.Errorf("path must start with /")
+	}
+	if _, err := template.New("path").Parse(op.Path); err != nil {
+		return fmt.Errorf("invalid path template: %w", err)
+	}
+	if err := validateSpecRequest(op.Request); err != nil {
+		return err
+	}
+	if err := op.Response.validate(); err != nil {
+		return fmt.Errorf("response: %w", err)
+	}
+	if embedding && op.Response.Embeddings == "" {
+		return fmt.Errorf("response.embeddings is required")
+	}
+	if !embedding && op.Response.Content == "" {
+		return fmt.Errorf("response.content is required")
+	}
+	if op.Stream == nil {
+		return nil
+	}
+	if embedding {
+		return fmt.Errorf("embeddings cannot be streamed")
+	}
+	if op.Stream.Format != ProviderSpecStreamSSE && op.Stream.Format != ProviderSpecStreamNDJSON {
+		return fmt.Errorf("stream.format must be %s or %s", ProviderSpecStreamSSE, ProviderSpecStreamNDJSON)
+	}
+	if err := validateSpecRequest(op.Stream.Request); err != nil {
+		return fmt.Errorf("stream: %w", err)
+	}
+	if op.Stream.Chunk.Content == "" {
+		return fmt.Errorf("stream.chunk.content is required")
+	}
+	if err := op.Stream.Chunk.validate(); err != nil {
+		return fmt.Errorf("stream.chunk: %w", err)
+	}
+	return nil
+}
+
+// validateSpecRequest checks the field paths and expressions of a request mapping.
+func validateSpecRequest(request map[string]any) error {
+	for field, value := range request {
+		if field == "" || strings.HasPrefix(field, ".") || strings.HasSuffix(field, ".") || strings.Contains(field, "..") {
+			return fmt.Errorf("invalid request field %q", field)
+		}
+		if expr, ok := value.(string); ok && strings.HasPrefix(expr, ".") {
+			if _, err := ParseSpecExpression(expr); err != nil {
+				return fmt.Errorf("request field %s: %w", field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the expressions of a response mapping.
+func (r *ProviderSpecResponse) validate() error {
+	for name, expr := range map[string]string{
+		"id":                r.ID,
+		"content":           r.Content,
+		"finish_reason":     r.FinishReason,
+		"prompt_tokens":     r.PromptTokens,
+		"completion_tokens": r.CompletionTokens,
+		"embeddings":        r.Embeddings,
+		"error":             r.Error,
+	} {
+		if expr == "" {
+			continue
+		}
+		if _, err := ParseSpecExpression(expr); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SpecExpression is a JQ-like path selecting a value of a JSON document decoded into maps and slices:
+// .choices[0].message.content selects fields and indices, negative indices count from the end, .data[].embedding
+// collects a field of every element of a list, and ".text // .output" falls back to the next path when a path
+// yields null. A lone "." selects the whole document.
+type SpecExpression struct {
+	alternatives [][]specStep
+}
+
+// specStep selects a field, an index or, with each, every element of a list.
+type specStep struct {
+	field string
+	index int
+	kind  specStepKind
+}
+
+type specStepKind int
+
+const (
+	specStepField specStepKind = iota
+	specStepIndex
+	specStepEach
+)
+
+// ParseSpecExpression parses a path expression.
+func ParseSpecExpression(expr string) (*SpecExpression, error) {
+	parsed := &SpecExpression{}
+	for _, alternative := range strings.Split(expr, "//") {
+		steps, err := parseSpecPath(strings.TrimSpace(alternative))
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %w", expr, err)
+		}
+		parsed.alternatives = append(parsed.alternatives, steps)
+	}
+	return parsed, nil
+}
+
+// parseSpecPath parses a path such as .a.b[0][].c into its steps.
+func parseSpecPath(path string) ([]specStep, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("paths start with .")
+	}
+	var steps []specStep
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			i++
+			start := i
+			for i < len(path) && isSpecFieldChar(path[i]) {
+				i++
+			}
+			if i > start {
+				steps = append(steps, specStep{kind: specStepField, field: path[start:i]})
+			} else if i < len(path) && path[i] != '[' {
+				return nil, fmt.Errorf("unexpected %q at %d", path[i], i)
+			}
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ at %d", i)
+			}
+			inside := path[i+1 : i+end]
+			if inside == "" {
+				steps = append(steps, specStep{kind: specStepEach})
+			} else {
+				index, err := strconv.Atoi(inside)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q", inside)
+				}
+				steps = append(steps, specStep{kind: specStepIndex, index: index})
+			}
+			i += end + 1
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", path[i], i)
+		}
+	}
+	return steps, nil
+}
+
+// isSpecFieldChar reports whether c may appear in the field names of paths.
+func isSpecFieldChar(c byte) bool {
+	return c == '_' || c == '-' || c == '$' || c == '@' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// Evaluate returns the value selected in document, nil when no alternative selects a value.
+func (e *SpecExpression) Evaluate(document any) any {
+	for _, steps := range e.alternatives {
+		if value := evaluateSpecSteps(document, steps); value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// evaluateSpecSteps applies steps to value; missing fields and indices out of range yield nil.
+func evaluateSpecSteps(value any, steps []specStep) any {
+	for i, step := range steps {
+		switch step.kind {
+		case specStepField:
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil
+			}
+			value = object[step.field]
+		case specStepIndex:
+			list, ok := value.([]any)
+			if !ok {
+				return nil
+			}
+			index := step.index
+			if index < 0 {
+				index += len(list)
+			}
+			if index < 0 || index >= len(list) {
+				return nil
+			}
+			value = list[index]
+		case specStepEach:
+			list, ok := value.([]any)
+			if !ok {
+				return nil
+			}
+			collected := make([]any, 0, len(list))
+			for _, element := range list {
+				if selected := evaluateSpecSteps(element, steps[i+1:]); selected != nil {
+					collected = append(collected, selected)
+				}
+			}
+			return collected
+		}
+	}
+	return value
+}
@@ -0,0 +1,65 @@
+package schemas
+
+import "strings"
+
+// Values of reasoning_effort
+const (
+	ReasoningEffortMinimal = "minimal"
+	ReasoningEffortLow     = "low"
+	ReasoningEffortMedium  = "medium"
+	ReasoningEffortHigh    = "high"
+)
+
+// reasoningBudgets are the thinking budgets, in tokens, standing for each reasoning effort with providers that
+// configure reasoning by budget (e.g. Anthropic). Minimal effort disables thinking.
+var reasoningBudgets = map[string]int{
+	ReasoningEffortMinimal: 0,
+	ReasoningEffortLow:     1024,
+	ReasoningEffortMedium:  4096,
+	ReasoningEffortHigh:    16384,
+}
+
+// ReasoningBudget returns the thinking budget in tokens of a reasoning effort, false for unknown efforts
+func ReasoningBudget(effort string) (int, bool) {
+	budget, ok := reasoningBudgets[strings.ToLower(effort)]
+	return budget, ok
+}
+
+// ReasoningEffortForBudget returns the reasoning effort closest to a thinking budget in tokens
+func ReasoningEffortForBudget(budget int) string {
+	switch {
+	case budget <= 0:
+		return ReasoningEffortMinimal
+	case budget < 2048:
+		return ReasoningEffortLow
+	case budget < 8192:
+		return ReasoningEffortMedium
+	default:
+		return ReasoningEffortHigh
+	}
+}
+
+// IsOpenAIReasoningModel reports whether model belongs to an OpenAI reasoning family (o1, o3, o4 and gpt-5, but not
+// the gpt-5 chat models), whatever the provider serving it. Reasoning models take max_completion_tokens and
+// reasoning_effort, and reject sampling parameters such as temperature.
+func IsOpenAIReasoningModel(model string) bool {
+	model = strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	for _, family := range []string{"o1", "o3", "o4"} {
+		if model == family || strings.HasPrefix(model, family+"-") {
+			return true
+		}
+	}
+	return strings.HasPrefix(model, "gpt-5") && !strings.Contains(model, "-chat")
+}
+
+// IsOpenAIChatModel reports whether model belongs to an OpenAI family without reasoning (gpt-3.5, gpt-4 and its
+// variants, chatgpt and the gpt-5 chat models), which reject reasoning_effort
+func IsOpenAIChatModel(model string) bool {
+	model = strings.ToLower(model[strings.LastIndex(model, "/")+1:])
+	for _, family := range []string{"gpt-3.5", "gpt-4", "chatgpt-"} {
+		if strings.HasPrefix(model, family) {
+			return true
+		}
+	}
+	return strings.HasPrefix(model, "gpt-5") && strings.Contains(model, "-chat")
+}
@@ -0,0 +1,320 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sources of request shaping changes, recorded as the rule of their trace changes.
+const (
+	RequestShapingProviderDefaults  = "provider.defaults"
+	RequestShapingProviderOverrides = "provider.overrides"
+)
+
+// requestShapingTokenLimits are the names of the token limit of the text, chat and responses parameters. Shaping
+// one of them shapes whichever the request type has, so max_tokens also limits chat and responses requests.
+var requestShapingTokenLimits = []string{"max_tokens", "max_completion_tokens", "max_output_tokens"}
+
+// RequestShapingConfig sets the parameters of the text, chat, responses and embedding requests a provider serves,
+// fallback requests included. Parameters are named as in the OpenAI API (temperature, top_p, max_tokens, ...);
+// names the request type has no field for, such as safe_mode, are set in ExtraParams, which the providers with
+// provider-specific parameters forward.
+//
+// Defaults only set the parameters a request leaves unset, while overrides replace what the request sends and a
+// null override removes the parameter. From lowest to highest precedence: provider defaults, model defaults, the
+// request, provider overrides, model overrides.
+type RequestShapingConfig struct {
+	Defaults  map[string]any `json:"defaults,omitempty"`
+	Overrides map[string]any `json:"overrides,omitempty"`
+	// Models shapes the requests of specific models, keyed by model name. Keys ending in * match by prefix, and
+	// only the exact key of a model, or else the longest key matching it, applies.
+	Models map[string]RequestShapingRule `json:"models,omitempty"`
+}
+
+// RequestShapingRule holds the defaults and overrides of the requests of a model.
+type RequestShapingRule struct {
+	Defaults  map[string]any `json:"defaults,omitempty"`
+	Overrides map[string]any `json:"overrides,omitempty"`
+}
+
+// requestShapingValue is a default or override resolved for a request, with the rule it comes from.
+type requestShapingValue struct {
+	value any
+	rule  string
+}
+
+// IsZero reports whether the config shapes nothing.
+func (rsc *RequestShapingConfig) IsZero() bool {
+	return rsc == nil || (len(rsc.Defaults) == 0 && len(rsc.Overrides) == 0 && len(rsc.Models) == 0)
+}
+
+// Validate checks the model keys and that the values decode into the parameters they are named after.
+func (rsc *RequestShapingConfig) Validate() error {
+	if err := validateRequestShapingValues("defaults", rsc.Defaults, false); err != nil {
+		return err
+	}
+	if err := validateRequestShapingValues("overrides", rsc.Overrides, true); err != nil {
+		return err
+	}
+	for model, rule := range rsc.Models {
+		if model == "" || strings.Contains(strings.TrimSuffix(model, "*"), "*") {
+			return fmt.Errorf("request_shaping: invalid model %q, only a trailing * is allowed", model)
+		}
+		if err := validateRequestShapingValues("models["+model+"].defaults", rule.Defaults, false); err != nil {
+			return err
+		}
+		if err := validateRequestShapingValues("models["+model+"].overrides", rule.Overrides, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRequestShapingValues checks the values of defaults or overrides; only overrides may be null.
+func validateRequestShapingValues(name string, values map[string]any, nullable bool) error {
+	for param, value := range values {
+		if param == "" {
+			return fmt.Errorf("request_shaping: %s: parameter name is required", name)
+		}
+		if value == nil {
+			if !nullable {
+				return fmt.Errorf("request_shaping: %s.%s: defaults cannot be null", name, param)
+			}
+			continue
+		}
+		for _, params := range []any{ChatParameters{}, TextCompletionParameters{}, ResponsesParameters{}, EmbeddingParameters{}} {
+			field, ok := requestShapingFields(reflect.TypeOf(params))[param]
+			if !ok {
+				continue
+			}
+			if _, err := decodeRequestShapingValue(value, field.Type); err != nil {
+				return fmt.Errorf("request_shaping: %s.%s: %w", name, param, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply shapes the parameters of req for its model and returns the changes made, nil when nothing changed.
+// The parameters are copied before they are changed, so the requests they are shared with are left untouched.
+func (rsc *RequestShapingConfig) Apply(req *BifrostRequest) []TraceChange {
+	if rsc == nil {
+		return nil
+	}
+	defaults, overrides := rsc.resolve(req.Model)
+	if len(defaults) == 0 && len(overrides) == 0 {
+		return nil
+	}
+
+	switch {
+	case req.ChatRequest != nil:
+		shaped := *req.ChatRequest
+		params := ChatParameters{}
+		if shaped.Params != nil {
+			params = *shaped.Params
+		}
+		if changes := shapeParams(reflect.ValueOf(&params).Elem(), "ChatRequest.Params.", defaults, overrides); len(changes) > 0 {
+			shaped.Params = &params
+			req.ChatRequest = &shaped
+			return changes
+		}
+	case req.TextCompletionRequest != nil:
+		shaped := *req.TextCompletionRequest
+		params := TextCompletionParameters{}
+		if shaped.Params != nil {
+			params = *shaped.Params
+		}
+		if changes := shapeParams(reflect.ValueOf(&params).Elem(), "TextCompletionRequest.Params.", defaults, overrides); len(changes) > 0 {
+			shaped.Params = &params
+			req.TextCompletionRequest = &shaped
+			return changes
+		}
+	case req.ResponsesRequest != nil:
+		shaped := *req.ResponsesRequest
+		params := ResponsesParameters{}
+		if shaped.Params != nil {
+			params = *shaped.Params
+		}
+		if changes := shapeParams(reflect.ValueOf(&params).Elem(), "ResponsesRequest.Params.", defaults, overrides); len(changes) > 0 {
+			shaped.Params = &params
+			req.ResponsesRequest = &shaped
+			return changes
+		}
+	case req.EmbeddingRequest != nil:
+		shaped := *req.EmbeddingRequest
+		params := EmbeddingParameters{}
+		if shaped.Params != nil {
+			params = *shaped.Params
+		}
+		if changes := shapeParams(reflect.ValueOf(&params).Elem(), "EmbeddingRequest.Params.", defaults, overrides); len(changes) > 0 {
+			shaped.Params = &params
+			req.EmbeddingRequest = &shaped
+			return changes
+		}
+	}
+	return nil
+}
+
+// resolve merges the provider and model rules applying to model, model values taking precedence.
+func (rsc *RequestShapingConfig) resolve(model string) (defaults, overrides map[string]requestShapingValue) {
+	defaults = make(map[string]requestShapingValue, len(rsc.Defaults))
+	overrides = make(map[string]requestShapingValue, len(rsc.Overrides))
+	for param, value := range rsc.Defaults {
+		defaults[param] = requestShapingValue{value: value, rule: RequestShapingProviderDefaults}
+	}
+	for param, value := range rsc.Overrides {
+		overrides[param] = requestShapingValue{value: value, rule: RequestShapingProviderOverrides}
+	}
+
+	matched := ""
+	if _, ok := rsc.Models[model]; ok {
+		matched = model
+	} else {
+		for pattern := range rsc.Models {
+			prefix, wildcard := strings.CutSuffix(pattern, "*")
+			if wildcard && strings.HasPrefix(model, prefix) && len(pattern) > len(matched) {
+				matched = pattern
+			}
+		}
+	}
+	if matched == "" {
+		return defaults, overrides
+	}
+	rule := rsc.Models[matched]
+	for param, value := range rule.Defaults {
+		defaults[param] = requestShapingValue{value: value, rule: "models[" + matched + "].defaults"}
+	}
+	for param, value := range rule.Overrides {
+		overrides[param] = requestShapingValue{value: value, rule: "models[" + matched + "].overrides"}
+	}
+	return defaults, overrides
+}
+
+// shapeParams applies the defaults and then the overrides to params, a parameters struct with an ExtraParams map,
+// in parameter name order. prefix is the path of params in the changes returned.
+func shapeParams(params reflect.Value, prefix string, defaults, overrides map[string]requestShapingValue) []TraceChange {
+	fields := requestShapingFields(params.Type())
+	extra := params.FieldByName("ExtraParams")
+	// The extra parameters are shared with the request, so they are cloned on the first change
+	cloned := false
+
+	var changes []TraceChange
+	apply := func(param string, shaping requestShapingValue, onlyUnset bool) {
+		name := param
+		if _, ok := fields[name]; !ok && isRequestShapingTokenLimit(name) {
+			for _, limit := range requestShapingTokenLimits {
+				if _, ok := fields[limit]; ok {
+					name = limit
+				}
+			}
+		}
+
+		var old any
+		field, known := fields[name]
+		if known {
+			if value := params.FieldByIndex(field.Index); !value.IsZero() {
+				old = SnapshotJSON(value.Interface())
+			}
+		} else if extra.Len() > 0 {
+			if value, ok := extra.Interface().(map[string]any)[name]; ok {
+				old = SnapshotJSON(value)
+			}
+		}
+		if (onlyUnset && old != nil) || reflect.DeepEqual(old, SnapshotJSON(shaping.value)) {
+			return
+		}
+
+		if known {
+			value := reflect.Zero(field.Type)
+			if shaping.value != nil {
+				decoded, err := decodeRequestShapingValue(shaping.value, field.Type)
+				if err != nil {
+					return
+				}
+				value = decoded
+			}
+			params.FieldByIndex(field.Index).Set(value)
+		} else {
+			if !cloned {
+				cloned = true
+				extraParams := maps.Clone(extra.Interface().(map[string]any))
+				if extraParams == nil {
+					extraParams = map[string]any{}
+				}
+				extra.Set(reflect.ValueOf(extraParams))
+			}
+			if shaping.value == nil {
+				delete(extra.Interface().(map[string]any), name)
+			} else {
+				extra.Interface().(map[string]any)[name] = shaping.value
+			}
+		}
+		changes = append(changes, TraceChange{Path: prefix + name, Old: old, New: SnapshotJSON(shaping.value), Rule: shaping.rule})
+	}
+
+	for _, param := range sortedRequestShapingParams(defaults) {
+		apply(param, defaults[param], true)
+	}
+	for _, param := range sortedRequestShapingParams(overrides) {
+		apply(param, overrides[param], false)
+	}
+	return changes
+}
+
+// decodeRequestShapingValue converts a value decoded from JSON into a value of type t.
+func decodeRequestShapingValue(value any, t reflect.Type) (reflect.Value, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	decoded := reflect.New(t)
+	if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid value %s", data)
+	}
+	return decoded.Elem(), nil
+}
+
+// requestShapingFieldsCache caches requestShapingFields by parameters type.
+var requestShapingFieldsCache sync.Map
+
+// requestShapingFields returns the fields of a parameters struct by JSON name.
+func requestShapingFields(t reflect.Type) map[string]reflect.StructField {
+	if cached, ok := requestShapingFieldsCache.Load(t); ok {
+		return cached.(map[string]reflect.StructField)
+	}
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = field
+		}
+	}
+	requestShapingFieldsCache.Store(t, fields)
+	return fields
+}
+
+// isRequestShapingTokenLimit reports whether param names a token limit.
+func isRequestShapingTokenLimit(param string) bool {
+	for _, limit := range requestShapingTokenLimits {
+		if param == limit {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedRequestShapingParams returns the parameter names of values in order.
+func sortedRequestShapingParams(values map[string]requestShapingValue) []string {
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	return params
+}
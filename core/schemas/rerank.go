@@ -0,0 +1,47 @@
+package schemas
+
+import (
+	"fmt"
+
+	"github.com/bytedance/sonic"
+)
+
+// RerankDocument is a document ranked by a rerank request. Clients send documents as plain strings or as
+// objects with a text field (Cohere v1, Jina), and results return them as objects (Cohere, Jina).
+type RerankDocument struct {
+	Text string `json:"text"`
+}
+
+// UnmarshalJSON accepts a string or an object with a text field.
+func (d *RerankDocument) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := sonic.Unmarshal(data, &text); err == nil {
+		d.Text = text
+		return nil
+	}
+	var object struct {
+		Text *string `json:"text"`
+	}
+	if err := sonic.Unmarshal(data, &object); err == nil && object.Text != nil {
+		d.Text = *object.Text
+		return nil
+	}
+	return fmt.Errorf("rerank document must be a string or an object with a text field")
+}
+
+type RerankParameters struct {
+	TopN            *int  `json:"top_n,omitempty"`              // Number of best documents to return, all of them when not set
+	ReturnDocuments *bool `json:"return_documents,omitempty"`   // Whether results include the text of their document
+	MaxTokensPerDoc *int  `json:"max_tokens_per_doc,omitempty"` // Documents are truncated to this many tokens (Cohere)
+
+	// Dynamic parameters that can be provider-specific, they are directly
+	// added to the request as is.
+	ExtraParams map[string]interface{} `json:"-"`
+}
+
+// BifrostRerankResult is the relevance of a document to the query of a rerank request.
+type BifrostRerankResult struct {
+	Index          int             `json:"index"` // Position of the document in the request
+	RelevanceScore float64         `json:"relevance_score"`
+	Document       *RerankDocument `json:"document,omitempty"` // Set when return_documents is true
+}
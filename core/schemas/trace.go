@@ -0,0 +1,197 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	PipelineTraceMaxSteps       = 512  // Steps of a trace, as post-hooks run once per chunk of a stream
+	PipelineTraceMaxChanges     = 100  // Changes of a step
+	PipelineTraceMaxValueLength = 1024 // Bytes of a changed value, longer values are cut
+)
+
+// PipelineTraceStage is the stage of the pipeline a trace step records.
+type PipelineTraceStage string
+
+const (
+	PipelineTraceStagePreHook  PipelineTraceStage = "pre_hook"
+	PipelineTraceStageProvider PipelineTraceStage = "provider"
+	PipelineTraceStageFallback PipelineTraceStage = "fallback"
+	PipelineTraceStageShaping  PipelineTraceStage = "request_shaping" // Parameters set by the request shaping of the provider
+	PipelineTraceStagePostHook PipelineTraceStage = "post_hook"
+)
+
+// Decisions of plugins recorded in trace steps.
+const (
+	PipelineTraceDecisionShortCircuit = "short_circuit" // The pre-hook answered the request, with a response, an error or a stream
+	PipelineTraceDecisionRerouted     = "rerouted"      // The pre-hook changed the provider or the model of the request
+	PipelineTraceDecisionRecovered    = "recovered"     // The post-hook replaced an error with a response
+	PipelineTraceDecisionFailed       = "failed"        // The post-hook replaced a response with an error
+)
+
+// PipelineTraceStep is a plugin hook or a provider attempt of a request.
+type PipelineTraceStep struct {
+	Stage    PipelineTraceStage `json:"stage"`
+	Plugin   string             `json:"plugin,omitempty"`
+	Provider ModelProvider      `json:"provider,omitempty"` // Provider and model of the request after the step
+	Model    string             `json:"model,omitempty"`
+	KeyID    string             `json:"key_id,omitempty"`  // Key of the provider attempt
+	Attempt  int                `json:"attempt,omitempty"` // Provider attempt, from 1, retries included
+	Decision string             `json:"decision,omitempty"`
+	Error    string             `json:"error,omitempty"`   // Error returned by the hook, or of the provider attempt
+	Changes  []TraceChange      `json:"changes,omitempty"` // Changes of the pre-hook or of request shaping to the request
+	Usage    *LLMUsage          `json:"usage,omitempty"`   // Token usage of the provider attempt
+	Latency  time.Duration      `json:"latency"`
+}
+
+// TraceChange is a value changed by a step, at a path of its JSON form such as ChatRequest.Params.temperature.
+// Old is absent for added values and New for removed ones.
+type TraceChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+	Rule string `json:"rule,omitempty"` // Rule behind the change, such as the request shaping default or override
+}
+
+// PipelineTrace records the plugin hooks, provider attempts and HTTP exchanges with providers of a request. Set it
+// in the request context under BifrostContextKeyPipelineTrace to capture them. Steps and exchanges are appended
+// from the caller and the provider worker goroutines; read them with Snapshot.
+type PipelineTrace struct {
+	mu        sync.Mutex
+	steps     []PipelineTraceStep
+	exchanges []UpstreamExchange
+	truncated bool
+}
+
+// PipelineTraceSnapshot is a copy of the steps and exchanges recorded by a PipelineTrace.
+type PipelineTraceSnapshot struct {
+	Steps     []PipelineTraceStep `json:"steps"`
+	Upstream  []UpstreamExchange  `json:"upstream"`
+	Truncated bool                `json:"truncated,omitempty"` // Steps beyond PipelineTraceMaxSteps were dropped
+}
+
+// AddStep appends a step to the trace. It is a no-op on a nil trace.
+func (t *PipelineTrace) AddStep(step PipelineTraceStep) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.steps) >= PipelineTraceMaxSteps {
+		t.truncated = true
+		return
+	}
+	t.steps = append(t.steps, step)
+}
+
+// RecordUpstream appends an HTTP exchange with a provider to the trace.
+func (t *PipelineTrace) RecordUpstream(exchange *UpstreamExchange) {
+	if t == nil || exchange == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exchanges = append(t.exchanges, *exchange)
+}
+
+// Snapshot returns a copy of the steps and exchanges recorded so far.
+func (t *PipelineTrace) Snapshot() PipelineTraceSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return PipelineTraceSnapshot{
+		Steps:     append([]PipelineTraceStep{}, t.steps...),
+		Upstream:  append([]UpstreamExchange{}, t.exchanges...),
+		Truncated: t.truncated,
+	}
+}
+
+// SnapshotJSON returns the JSON form of v decoded into maps, slices and scalars, to diff it after it is modified
+// in place. It returns nil when v cannot be marshalled.
+func SnapshotJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var snapshot any
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// SnapshotJSONBytes decodes a JSON document into maps, slices and scalars to diff it. It returns nil when data
+// is not JSON.
+func SnapshotJSONBytes(data []byte) any {
+	var snapshot any
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// DiffJSON returns the changes between two JSON snapshots, sorted by path, at most PipelineTraceMaxChanges.
+// Objects are compared key by key; arrays of different lengths and values of different types are changed as a
+// whole.
+func DiffJSON(before, after any) []TraceChange {
+	var changes []TraceChange
+	diffJSON("", before, after, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	if len(changes) > PipelineTraceMaxChanges {
+		changes = changes[:PipelineTraceMaxChanges]
+	}
+	return changes
+}
+
+func diffJSON(path string, before, after any, changes *[]TraceChange) {
+	switch b := before.(type) {
+	case map[string]any:
+		if a, ok := after.(map[string]any); ok {
+			for key, value := range b {
+				diffJSON(joinTracePath(path, key), value, a[key], changes)
+			}
+			for key, value := range a {
+				if _, ok := b[key]; !ok {
+					diffJSON(joinTracePath(path, key), nil, value, changes)
+				}
+			}
+			return
+		}
+	case []any:
+		if a, ok := after.([]any); ok && len(a) == len(b) {
+			for i := range b {
+				diffJSON(joinTracePath(path, fmt.Sprint(i)), b[i], a[i], changes)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, TraceChange{Path: path, Old: truncateTraceValue(before), New: truncateTraceValue(after)})
+	}
+}
+
+func joinTracePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// truncateTraceValue cuts long strings, and replaces objects and arrays whose JSON form is too long with it cut.
+func truncateTraceValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		if len(v) > PipelineTraceMaxValueLength {
+			return v[:PipelineTraceMaxValueLength] + "…"
+		}
+	case map[string]any, []any:
+		if data, err := json.Marshal(v); err == nil && len(data) > PipelineTraceMaxValueLength {
+			return string(data[:PipelineTraceMaxValueLength]) + "…"
+		}
+	}
+	return value
+}
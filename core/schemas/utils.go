@@ -38,6 +38,8 @@ func MapFinishReasonToProvider(finishReason string, targetProvider ModelProvider
 	switch targetProvider {
 	case Anthropic:
 		return mapFinishReasonToAnthropic(finishReason)
+	case Gemini, Vertex:
+		return mapFinishReasonToGemini(finishReason)
 	default:
 		// For OpenAI, Azure, and other providers, pass through as-is
 		return finishReason
@@ -53,12 +55,29 @@ func mapFinishReasonToAnthropic(finishReason string) string {
 		return "max_tokens"
 	case "tool_calls":
 		return "tool_use"
+	case "content_filter":
+		return "refusal"
 	default:
 		// Pass through other reasons like "pause_turn", "refusal", "stop_sequence", etc.
 		return finishReason
 	}
 }
 
+// mapFinishReasonToGemini maps OpenAI finish reasons to Gemini format
+func mapFinishReasonToGemini(finishReason string) string {
+	switch finishReason {
+	case "stop", "tool_calls":
+		return "STOP"
+	case "length":
+		return "MAX_TOKENS"
+	case "content_filter":
+		return "SAFETY"
+	default:
+		// Pass through reasons that are already in Gemini format
+		return strings.ToUpper(finishReason)
+	}
+}
+
 //* IMAGE UTILS *//
 
 // dataURIRegex is a precompiled regex for matching data URI format patterns.
@@ -16,13 +16,14 @@ func Ptr[T any](v T) *T {
 // providerRequiresKey returns true if the given provider requires an API key for authentication.
 // Some providers like Ollama and SGL are keyless and don't require API keys.
 func providerRequiresKey(providerKey schemas.ModelProvider) bool {
-	return providerKey != schemas.Ollama && providerKey != schemas.SGL
+	return providerKey != schemas.Ollama && providerKey != schemas.SGL && providerKey != schemas.Mock
 }
 
 // canProviderKeyValueBeEmpty returns true if the given provider allows the API key to be empty.
 // Some providers like Vertex and Bedrock have their credentials in additional key configs..
+// Declarative providers send no auth header for keys without a value.
 func canProviderKeyValueBeEmpty(providerKey schemas.ModelProvider) bool {
-	return providerKey == schemas.Vertex || providerKey == schemas.Bedrock
+	return providerKey == schemas.Vertex || providerKey == schemas.Bedrock || providerKey == schemas.Declarative
 }
 
 // calculateBackoff implements exponential backoff with jitter for retry attempts.
@@ -149,3 +150,106 @@ func GetRequestFields(result *schemas.BifrostResponse, err *schemas.BifrostError
 
 	return err.ExtraFields.RequestType, err.ExtraFields.Provider, err.ExtraFields.ModelRequested
 }
+
+// preHookSnapshot is a request before a pre-hook, which may modify it in place.
+type preHookSnapshot struct {
+	provider schemas.ModelProvider
+	model    string
+	request  any // JSON snapshot
+}
+
+// snapshotPreHook returns the snapshot of a request before a pre-hook.
+func snapshotPreHook(req *schemas.BifrostRequest) preHookSnapshot {
+	if req == nil {
+		return preHookSnapshot{}
+	}
+	return preHookSnapshot{provider: req.Provider, model: req.Model, request: schemas.SnapshotJSON(req)}
+}
+
+// preHookTraceStep returns the trace step of a pre-hook, given the request before and after it.
+func preHookTraceStep(plugin string, before preHookSnapshot, after *schemas.BifrostRequest, shortCircuit *schemas.PluginShortCircuit, err error, latency time.Duration) schemas.PipelineTraceStep {
+	step := schemas.PipelineTraceStep{Stage: schemas.PipelineTraceStagePreHook, Plugin: plugin, Latency: latency}
+	if after != nil {
+		step.Provider, step.Model = after.Provider, after.Model
+		step.Changes = schemas.DiffJSON(before.request, schemas.SnapshotJSON(after))
+	}
+	switch {
+	case shortCircuit != nil:
+		step.Decision = schemas.PipelineTraceDecisionShortCircuit
+		if shortCircuit.Error != nil {
+			step.Error = bifrostErrorMessage(shortCircuit.Error)
+		}
+	case after != nil && before.request != nil && (before.provider != after.Provider || before.model != after.Model):
+		step.Decision = schemas.PipelineTraceDecisionRerouted
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return step
+}
+
+// postHookTraceStep returns the trace step of a post-hook, given whether it was handed an error and what it returned.
+func postHookTraceStep(plugin string, failed bool, resp *schemas.BifrostResponse, bifrostErr *schemas.BifrostError, err error, latency time.Duration) schemas.PipelineTraceStep {
+	step := schemas.PipelineTraceStep{Stage: schemas.PipelineTraceStagePostHook, Plugin: plugin, Latency: latency}
+	switch {
+	case failed && bifrostErr == nil && resp != nil:
+		step.Decision = schemas.PipelineTraceDecisionRecovered
+	case !failed && bifrostErr != nil:
+		step.Decision = schemas.PipelineTraceDecisionFailed
+		step.Error = bifrostErrorMessage(bifrostErr)
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return step
+}
+
+// recordProviderAttempt adds a provider attempt of a request to its PipelineTrace, if any. result is nil for
+// streams, whose usage is only known once they end.
+func recordProviderAttempt(req *ChannelMessage, key schemas.Key, attempt int, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError, latency time.Duration) {
+	trace, _ := req.Context.Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace)
+	if trace == nil {
+		return
+	}
+	step := schemas.PipelineTraceStep{
+		Stage:    schemas.PipelineTraceStageProvider,
+		Provider: req.Provider,
+		Model:    req.Model,
+		KeyID:    key.ID,
+		Attempt:  attempt + 1,
+		Latency:  latency,
+	}
+	if bifrostErr != nil {
+		step.Error = bifrostErrorMessage(bifrostErr)
+	} else if result != nil && result.Usage != nil {
+		usage := *result.Usage
+		step.Usage = &usage
+	}
+	trace.AddStep(step)
+}
+
+// recordRequestShaping adds the parameters set by the request shaping of a provider to the PipelineTrace of the
+// request, if any.
+func recordRequestShaping(req *ChannelMessage, changes []schemas.TraceChange) {
+	if trace, _ := req.Context.Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace); trace != nil {
+		trace.AddStep(schemas.PipelineTraceStep{Stage: schemas.PipelineTraceStageShaping, Provider: req.Provider, Model: req.Model, Changes: changes})
+	}
+}
+
+// recordFallback adds the switch of a request to a fallback to its PipelineTrace, if any.
+func recordFallback(ctx context.Context, fallback schemas.Fallback) {
+	if trace, _ := ctx.Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace); trace != nil {
+		trace.AddStep(schemas.PipelineTraceStep{Stage: schemas.PipelineTraceStageFallback, Provider: fallback.Provider, Model: fallback.Model})
+	}
+}
+
+// bifrostErrorMessage returns the message of an error, or of the error it wraps.
+func bifrostErrorMessage(bifrostErr *schemas.BifrostError) string {
+	if bifrostErr.Error == nil {
+		return "unknown error"
+	}
+	if bifrostErr.Error.Message == "" && bifrostErr.Error.Error != nil {
+		return bifrostErr.Error.Error.Error()
+	}
+	return bifrostErr.Error.Message
+}
@@ -0,0 +1,534 @@
+// Package assistants stores the assistants, threads and runs of the Assistants API compatibility layer.
+// The messages of a thread live in the sessions store under the thread ID; threads only keep their metadata
+// and the IDs and timestamps of their messages.
+package assistants
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when an assistant, thread or run does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Run statuses, as named by the Assistants API
+const (
+	StatusQueued         = "queued"
+	StatusInProgress     = "in_progress"
+	StatusRequiresAction = "requires_action"
+	StatusCancelling     = "cancelling"
+	StatusCancelled      = "cancelled"
+	StatusFailed         = "failed"
+	StatusCompleted      = "completed"
+	StatusExpired        = "expired"
+)
+
+// finishedStatuses are the final run statuses
+var finishedStatuses = []string{StatusCancelled, StatusFailed, StatusCompleted, StatusExpired}
+
+// IsFinished reports whether a run status is final.
+func IsFinished(status string) bool {
+	return slices.Contains(finishedStatuses, status)
+}
+
+// Assistant is a model with instructions and tools that runs on threads. Assistants, threads, their messages
+// and runs are only shown to their Owner, the hash of the credential of their creator, or to anyone when it is empty.
+type Assistant struct {
+	ID           string    `gorm:"type:varchar(255);primaryKey" json:"id"`
+	Name         string    `gorm:"type:varchar(255)" json:"name,omitempty"`
+	Description  string    `gorm:"type:text" json:"description,omitempty"`
+	Model        string    `gorm:"type:varchar(255)" json:"model"` // In provider/model form
+	Instructions string    `gorm:"type:text" json:"instructions,omitempty"`
+	ToolsJSON    string    `gorm:"type:text" json:"-"` // JSON serialized []schemas.ChatTool
+	MetadataJSON string    `gorm:"type:text" json:"-"` // JSON serialized map[string]string
+	Owner        string    `gorm:"type:varchar(64);index" json:"-"`
+	CreatedAt    time.Time `gorm:"index;not null" json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Tools    []schemas.ChatTool `gorm:"-" json:"tools"`
+	Metadata map[string]string  `gorm:"-" json:"metadata,omitempty"`
+}
+
+// TableName sets the table name for assistants
+func (Assistant) TableName() string { return "assistants" }
+
+// BeforeSave serializes the tools and metadata of an assistant
+func (a *Assistant) BeforeSave(tx *gorm.DB) (err error) {
+	if a.ToolsJSON, err = marshalJSON(a.Tools); err != nil {
+		return err
+	}
+	a.MetadataJSON, err = marshalJSON(a.Metadata)
+	return err
+}
+
+// AfterFind deserializes the tools and metadata of an assistant
+func (a *Assistant) AfterFind(tx *gorm.DB) error {
+	if err := unmarshalJSON(a.ToolsJSON, &a.Tools); err != nil {
+		return err
+	}
+	return unmarshalJSON(a.MetadataJSON, &a.Metadata)
+}
+
+// MessageInfo describes a message of a thread, at the same position in the thread session.
+type MessageInfo struct {
+	ID          string            `json:"id"`
+	CreatedAt   int64             `json:"created_at"` // Unix seconds
+	AssistantID string            `json:"assistant_id,omitempty"`
+	RunID       string            `json:"run_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Owner       string            `json:"owner,omitempty"`
+}
+
+// Thread is a conversation assistants run on.
+type Thread struct {
+	ID           string    `gorm:"type:varchar(255);primaryKey" json:"id"`
+	MetadataJSON string    `gorm:"type:text" json:"-"` // JSON serialized map[string]string
+	MessagesJSON string    `gorm:"type:text" json:"-"` // JSON serialized []MessageInfo
+	Owner        string    `gorm:"type:varchar(64);index" json:"-"`
+	CreatedAt    time.Time `gorm:"index;not null" json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Metadata map[string]string `gorm:"-" json:"metadata,omitempty"`
+	Messages []MessageInfo     `gorm:"-" json:"messages,omitempty"`
+}
+
+// TableName sets the table name for threads
+func (Thread) TableName() string { return "assistant_threads" }
+
+// BeforeSave serializes the metadata and messages of a thread
+func (t *Thread) BeforeSave(tx *gorm.DB) (err error) {
+	if t.MetadataJSON, err = marshalJSON(t.Metadata); err != nil {
+		return err
+	}
+	t.MessagesJSON, err = marshalJSON(t.Messages)
+	return err
+}
+
+// AfterFind deserializes the metadata and messages of a thread
+func (t *Thread) AfterFind(tx *gorm.DB) error {
+	if err := unmarshalJSON(t.MetadataJSON, &t.Metadata); err != nil {
+		return err
+	}
+	return unmarshalJSON(t.MessagesJSON, &t.Messages)
+}
+
+// Run is the execution of an assistant on a thread. The messages it produces, tool calls and outputs
+// included, are kept in Steps; only its final reply is added to the thread.
+type Run struct {
+	ID               string     `gorm:"type:varchar(255);primaryKey" json:"id"`
+	ThreadID         string     `gorm:"type:varchar(255);index" json:"thread_id"`
+	AssistantID      string     `gorm:"type:varchar(255)" json:"assistant_id"`
+	Model            string     `gorm:"type:varchar(255)" json:"model"`
+	Instructions     string     `gorm:"type:text" json:"instructions,omitempty"`
+	Status           string     `gorm:"type:varchar(32);index" json:"status"`
+	ToolsJSON        string     `gorm:"type:text" json:"-"` // JSON serialized []schemas.ChatTool
+	StepsJSON        string     `gorm:"type:text" json:"-"` // JSON serialized []schemas.ChatMessage
+	PendingJSON      string     `gorm:"type:text" json:"-"` // JSON serialized []schemas.ChatAssistantMessageToolCall
+	MetadataJSON     string     `gorm:"type:text" json:"-"` // JSON serialized map[string]string
+	LastError        string     `gorm:"type:text" json:"last_error,omitempty"`
+	Owner            string     `gorm:"type:varchar(64);index" json:"-"`
+	PromptTokens     int        `json:"prompt_tokens"`
+	CompletionTokens int        `json:"completion_tokens"`
+	TotalTokens      int        `json:"total_tokens"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
+	FailedAt         *time.Time `json:"failed_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"index;not null" json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Tools            []schemas.ChatTool                     `gorm:"-" json:"tools"`
+	Steps            []schemas.ChatMessage                  `gorm:"-" json:"steps,omitempty"`
+	PendingToolCalls []schemas.ChatAssistantMessageToolCall `gorm:"-" json:"pending_tool_calls,omitempty"` // Function calls waiting for their outputs
+	Metadata         map[string]string                      `gorm:"-" json:"metadata,omitempty"`
+}
+
+// TableName sets the table name for runs
+func (Run) TableName() string { return "assistant_runs" }
+
+// BeforeSave serializes the tools, steps, pending tool calls and metadata of a run
+func (r *Run) BeforeSave(tx *gorm.DB) (err error) {
+	if r.ToolsJSON, err = marshalJSON(r.Tools); err != nil {
+		return err
+	}
+	if r.StepsJSON, err = marshalJSON(r.Steps); err != nil {
+		return err
+	}
+	if r.PendingJSON, err = marshalJSON(r.PendingToolCalls); err != nil {
+		return err
+	}
+	r.MetadataJSON, err = marshalJSON(r.Metadata)
+	return err
+}
+
+// AfterFind deserializes the tools, steps, pending tool calls and metadata of a run
+func (r *Run) AfterFind(tx *gorm.DB) error {
+	if err := unmarshalJSON(r.ToolsJSON, &r.Tools); err != nil {
+		return err
+	}
+	if err := unmarshalJSON(r.StepsJSON, &r.Steps); err != nil {
+		return err
+	}
+	if err := unmarshalJSON(r.PendingJSON, &r.PendingToolCalls); err != nil {
+		return err
+	}
+	return unmarshalJSON(r.MetadataJSON, &r.Metadata)
+}
+
+// marshalJSON serializes value, storing nothing for empty values
+func marshalJSON(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	if string(data) == "null" {
+		return "", nil
+	}
+	return string(data), nil
+}
+
+// unmarshalJSON deserializes data into target, leaving it unset when data is empty
+func unmarshalJSON(data string, target any) error {
+	if data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), target)
+}
+
+// Store persists assistants, threads and runs.
+type Store interface {
+	// SaveAssistant creates or replaces an assistant.
+	SaveAssistant(ctx context.Context, assistant *Assistant) error
+	// GetAssistant returns an assistant, or ErrNotFound.
+	GetAssistant(ctx context.Context, id string) (*Assistant, error)
+	// DeleteAssistant removes an assistant, or returns ErrNotFound.
+	DeleteAssistant(ctx context.Context, id string) error
+	// ListAssistants returns all assistants, newest first.
+	ListAssistants(ctx context.Context) ([]Assistant, error)
+
+	// SaveThread creates or replaces a thread.
+	SaveThread(ctx context.Context, thread *Thread) error
+	// GetThread returns a thread, or ErrNotFound.
+	GetThread(ctx context.Context, id string) (*Thread, error)
+	// DeleteThread removes a thread and its runs, or returns ErrNotFound.
+	DeleteThread(ctx context.Context, id string) error
+
+	// SaveRun creates or replaces a run.
+	SaveRun(ctx context.Context, run *Run) error
+	// GetRun returns a run of a thread, or ErrNotFound.
+	GetRun(ctx context.Context, threadID, id string) (*Run, error)
+	// ListRuns returns the runs of a thread, newest first.
+	ListRuns(ctx context.Context, threadID string) ([]Run, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores assistants, threads and runs in a database.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the tables if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate assistants tables: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the assistants, threads and runs tables, recording the migration in db so later schema changes
+// are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addassistantstables",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Assistant{}) {
+				if err := migrator.CreateTable(&Assistant{}); err != nil {
+					return err
+				}
+			}
+			if !migrator.HasTable(&Thread{}) {
+				if err := migrator.CreateTable(&Thread{}); err != nil {
+					return err
+				}
+			}
+			if !migrator.HasTable(&Run{}) {
+				if err := migrator.CreateTable(&Run{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// SaveAssistant creates or replaces an assistant.
+func (s *RDBStore) SaveAssistant(ctx context.Context, assistant *Assistant) error {
+	return s.db.WithContext(ctx).Save(assistant).Error
+}
+
+// GetAssistant returns an assistant.
+func (s *RDBStore) GetAssistant(ctx context.Context, id string) (*Assistant, error) {
+	var assistant Assistant
+	if err := s.first(ctx, &assistant, "id = ?", id); err != nil {
+		return nil, err
+	}
+	return &assistant, nil
+}
+
+// DeleteAssistant removes an assistant.
+func (s *RDBStore) DeleteAssistant(ctx context.Context, id string) error {
+	return s.delete(ctx, &Assistant{}, "id = ?", id)
+}
+
+// ListAssistants returns all assistants, newest first.
+func (s *RDBStore) ListAssistants(ctx context.Context) ([]Assistant, error) {
+	assistants := []Assistant{}
+	err := s.db.WithContext(ctx).Order("created_at DESC").Find(&assistants).Error
+	return assistants, err
+}
+
+// SaveThread creates or replaces a thread.
+func (s *RDBStore) SaveThread(ctx context.Context, thread *Thread) error {
+	return s.db.WithContext(ctx).Save(thread).Error
+}
+
+// GetThread returns a thread.
+func (s *RDBStore) GetThread(ctx context.Context, id string) (*Thread, error) {
+	var thread Thread
+	if err := s.first(ctx, &thread, "id = ?", id); err != nil {
+		return nil, err
+	}
+	return &thread, nil
+}
+
+// DeleteThread removes a thread and its runs.
+func (s *RDBStore) DeleteThread(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("thread_id = ?", id).Delete(&Run{}).Error; err != nil {
+			return err
+		}
+		return (&RDBStore{db: tx}).delete(ctx, &Thread{}, "id = ?", id)
+	})
+}
+
+// SaveRun creates or replaces a run.
+func (s *RDBStore) SaveRun(ctx context.Context, run *Run) error {
+	return s.db.WithContext(ctx).Save(run).Error
+}
+
+// GetRun returns a run of a thread.
+func (s *RDBStore) GetRun(ctx context.Context, threadID, id string) (*Run, error) {
+	var run Run
+	if err := s.first(ctx, &run, "id = ? AND thread_id = ?", id, threadID); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns returns the runs of a thread, newest first.
+func (s *RDBStore) ListRuns(ctx context.Context, threadID string) ([]Run, error) {
+	runs := []Run{}
+	err := s.db.WithContext(ctx).Where("thread_id = ?", threadID).Order("created_at DESC").Find(&runs).Error
+	return runs, err
+}
+
+// first loads the first row matching the condition into dest, mapping missing rows to ErrNotFound
+func (s *RDBStore) first(ctx context.Context, dest any, query string, args ...any) error {
+	if err := s.db.WithContext(ctx).Where(query, args...).First(dest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// delete removes the rows matching the condition, returning ErrNotFound when there are none
+func (s *RDBStore) delete(ctx context.Context, model any, query string, args ...any) error {
+	result := s.db.WithContext(ctx).Where(query, args...).Delete(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// InMemoryStore keeps assistants, threads and runs in memory. They are lost on restart.
+type InMemoryStore struct {
+	mu         sync.RWMutex
+	assistants map[string]Assistant
+	threads    map[string]Thread
+	runs       map[string]Run
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		assistants: make(map[string]Assistant),
+		threads:    make(map[string]Thread),
+		runs:       make(map[string]Run),
+	}
+}
+
+// touch fills in the timestamps like the database store does
+func touch(createdAt, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	*updatedAt = now
+}
+
+// SaveAssistant stores a copy of an assistant.
+func (s *InMemoryStore) SaveAssistant(ctx context.Context, assistant *Assistant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&assistant.CreatedAt, &assistant.UpdatedAt)
+	stored := *assistant
+	stored.Tools = slices.Clone(assistant.Tools)
+	s.assistants[assistant.ID] = stored
+	return nil
+}
+
+// GetAssistant returns a copy of an assistant.
+func (s *InMemoryStore) GetAssistant(ctx context.Context, id string) (*Assistant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	assistant, ok := s.assistants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	assistant.Tools = slices.Clone(assistant.Tools)
+	return &assistant, nil
+}
+
+// DeleteAssistant removes an assistant.
+func (s *InMemoryStore) DeleteAssistant(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.assistants[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.assistants, id)
+	return nil
+}
+
+// ListAssistants returns all assistants, newest first.
+func (s *InMemoryStore) ListAssistants(ctx context.Context) ([]Assistant, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	assistants := make([]Assistant, 0, len(s.assistants))
+	for _, assistant := range s.assistants {
+		assistants = append(assistants, assistant)
+	}
+	sort.Slice(assistants, func(i, j int) bool { return assistants[i].CreatedAt.After(assistants[j].CreatedAt) })
+	return assistants, nil
+}
+
+// SaveThread stores a copy of a thread.
+func (s *InMemoryStore) SaveThread(ctx context.Context, thread *Thread) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&thread.CreatedAt, &thread.UpdatedAt)
+	stored := *thread
+	stored.Messages = slices.Clone(thread.Messages)
+	s.threads[thread.ID] = stored
+	return nil
+}
+
+// GetThread returns a copy of a thread.
+func (s *InMemoryStore) GetThread(ctx context.Context, id string) (*Thread, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	thread, ok := s.threads[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	thread.Messages = slices.Clone(thread.Messages)
+	return &thread, nil
+}
+
+// DeleteThread removes a thread and its runs.
+func (s *InMemoryStore) DeleteThread(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.threads[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.threads, id)
+	for runID, run := range s.runs {
+		if run.ThreadID == id {
+			delete(s.runs, runID)
+		}
+	}
+	return nil
+}
+
+// SaveRun stores a copy of a run.
+func (s *InMemoryStore) SaveRun(ctx context.Context, run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	touch(&run.CreatedAt, &run.UpdatedAt)
+	s.runs[run.ID] = cloneRun(*run)
+	return nil
+}
+
+// GetRun returns a copy of a run of a thread.
+func (s *InMemoryStore) GetRun(ctx context.Context, threadID, id string) (*Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	run, ok := s.runs[id]
+	if !ok || run.ThreadID != threadID {
+		return nil, ErrNotFound
+	}
+	run = cloneRun(run)
+	return &run, nil
+}
+
+// ListRuns returns the runs of a thread, newest first.
+func (s *InMemoryStore) ListRuns(ctx context.Context, threadID string) ([]Run, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	runs := []Run{}
+	for _, run := range s.runs {
+		if run.ThreadID == threadID {
+			runs = append(runs, cloneRun(run))
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedAt.After(runs[j].CreatedAt) })
+	return runs, nil
+}
+
+// cloneRun copies the slices of a run, so stored runs are not changed through returned ones
+func cloneRun(run Run) Run {
+	run.Tools = slices.Clone(run.Tools)
+	run.Steps = slices.Clone(run.Steps)
+	run.PendingToolCalls = slices.Clone(run.PendingToolCalls)
+	return run
+}
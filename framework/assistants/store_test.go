@@ -0,0 +1,85 @@
+package assistants
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestStore verifies that assistants, threads and runs round-trip with their serialized fields, and that
+// deleting a thread deletes its runs, in both stores.
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "assistants.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			tools := []schemas.ChatTool{{Type: schemas.ChatToolTypeFunction, Function: &schemas.ChatToolFunction{Name: "get_weather"}}}
+			for _, assistant := range []*Assistant{
+				{ID: "asst_1", Model: "openai/gpt-4o-mini", Tools: tools, Metadata: map[string]string{"team": "support"}, CreatedAt: now.Add(-time.Hour)},
+				{ID: "asst_2", Model: "anthropic/claude-3-5-haiku", CreatedAt: now},
+			} {
+				if err := store.SaveAssistant(ctx, assistant); err != nil {
+					t.Fatalf("SaveAssistant() error = %v", err)
+				}
+			}
+			assistant, err := store.GetAssistant(ctx, "asst_1")
+			if err != nil || len(assistant.Tools) != 1 || assistant.Tools[0].Function.Name != "get_weather" || assistant.Metadata["team"] != "support" {
+				t.Fatalf("GetAssistant() = %+v, %v", assistant, err)
+			}
+			list, err := store.ListAssistants(ctx)
+			if err != nil || len(list) != 2 || list[0].ID != "asst_2" {
+				t.Fatalf("ListAssistants() = %+v, %v, want newest first", list, err)
+			}
+
+			thread := &Thread{ID: "thread_1", Messages: []MessageInfo{{ID: "msg_1", CreatedAt: now.Unix()}}}
+			if err := store.SaveThread(ctx, thread); err != nil {
+				t.Fatalf("SaveThread() error = %v", err)
+			}
+			for _, run := range []*Run{
+				{ID: "run_1", ThreadID: "thread_1", Status: StatusCompleted, CreatedAt: now.Add(-time.Minute)},
+				{ID: "run_2", ThreadID: "thread_1", Status: StatusRequiresAction, Tools: tools, CreatedAt: now,
+					PendingToolCalls: []schemas.ChatAssistantMessageToolCall{{ID: schemas.Ptr("call_1")}}},
+			} {
+				if err := store.SaveRun(ctx, run); err != nil {
+					t.Fatalf("SaveRun() error = %v", err)
+				}
+			}
+			run, err := store.GetRun(ctx, "thread_1", "run_2")
+			if err != nil || len(run.PendingToolCalls) != 1 || *run.PendingToolCalls[0].ID != "call_1" || len(run.Tools) != 1 {
+				t.Fatalf("GetRun() = %+v, %v", run, err)
+			}
+			if _, err := store.GetRun(ctx, "thread_2", "run_2"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("GetRun() of another thread error = %v, want ErrNotFound", err)
+			}
+			runs, err := store.ListRuns(ctx, "thread_1")
+			if err != nil || len(runs) != 2 || runs[0].ID != "run_2" {
+				t.Fatalf("ListRuns() = %+v, %v, want newest first", runs, err)
+			}
+
+			if err := store.DeleteThread(ctx, "thread_1"); err != nil {
+				t.Fatalf("DeleteThread() error = %v", err)
+			}
+			if runs, _ := store.ListRuns(ctx, "thread_1"); len(runs) != 0 {
+				t.Errorf("expected the runs of the deleted thread to be deleted, got %d", len(runs))
+			}
+			if err := store.DeleteAssistant(ctx, "asst_missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("DeleteAssistant() of an unknown assistant error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
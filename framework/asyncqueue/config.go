@@ -0,0 +1,101 @@
+// Package asyncqueue persists inference requests sent in async mode, so they are executed in the background
+// once their provider is available and their results can be polled or delivered to a webhook.
+package asyncqueue
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	DefaultWorkers                 = 4
+	DefaultMaxAttempts             = 10
+	DefaultRetryIntervalSeconds    = 30
+	DefaultMaxRetryIntervalSeconds = 600
+	DefaultMaxAgeHours             = 24
+	DefaultRetentionHours          = 72
+)
+
+// Config enables async mode. Requests carrying the X-Bifrost-Async header are queued and answered with
+// their job ID; failed attempts are retried with exponential backoff until the provider recovers.
+type Config struct {
+	Enabled                 bool `json:"enabled"`
+	Workers                 int  `json:"workers,omitempty"`                    // Jobs executed concurrently (default: 4)
+	MaxAttempts             int  `json:"max_attempts,omitempty"`               // Attempts before a job fails (default: 10)
+	RetryIntervalSeconds    int  `json:"retry_interval_seconds,omitempty"`     // Wait after the first failed attempt, doubled after each one (default: 30)
+	MaxRetryIntervalSeconds int  `json:"max_retry_interval_seconds,omitempty"` // Longest wait between attempts (default: 600)
+	MaxAgeHours             int  `json:"max_age_hours,omitempty"`              // Jobs not completed within this time expire (default: 24)
+	RetentionHours          int  `json:"retention_hours,omitempty"`            // Finished jobs and their results are deleted after this time (default: 72)
+
+	// Webhook receives the result of every job
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	// RequestWebhooks lets requests name the webhook receiving their result in the X-Bifrost-Async-Webhook header
+	RequestWebhooks bool `json:"request_webhooks,omitempty"`
+	// AllowedWebhookHosts restricts the hosts of request webhooks, a "*." prefix matches subdomains (default: any host)
+	AllowedWebhookHosts []string `json:"allowed_webhook_hosts,omitempty"`
+	// AllowPrivateWebhooks lets request webhooks point to loopback, private and link-local addresses
+	AllowPrivateWebhooks bool `json:"allow_private_webhooks,omitempty"`
+}
+
+// WebhookConfig is the endpoint results are posted to.
+type WebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // e.g. authorization; values may reference env.VAR_NAME
+	// Secret signs the deliveries, also those to request webhooks, in the X-Bifrost-Signature header; may reference env.VAR_NAME
+	Secret string `json:"secret,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *Config) Validate() error {
+	if c.Workers < 0 || c.MaxAttempts < 0 || c.RetryIntervalSeconds < 0 || c.MaxRetryIntervalSeconds < 0 ||
+		c.MaxAgeHours < 0 || c.RetentionHours < 0 {
+		return fmt.Errorf("async: workers, attempts, intervals and hours must not be negative")
+	}
+	if c.Webhook != nil {
+		if c.Webhook.URL == "" {
+			return fmt.Errorf("async: webhook url is required")
+		}
+		if err := ValidateWebhookURL(c.Webhook.URL); err != nil {
+			return fmt.Errorf("async: webhook: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValidateWebhookURL checks that a webhook is an absolute http or https URL.
+func ValidateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("webhook url must be an absolute http or https url")
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of the configuration with unset values defaulted.
+func (c Config) WithDefaults() Config {
+	if c.Workers == 0 {
+		c.Workers = DefaultWorkers
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.RetryIntervalSeconds == 0 {
+		c.RetryIntervalSeconds = DefaultRetryIntervalSeconds
+	}
+	if c.MaxRetryIntervalSeconds == 0 {
+		c.MaxRetryIntervalSeconds = DefaultMaxRetryIntervalSeconds
+	}
+	if c.MaxRetryIntervalSeconds < c.RetryIntervalSeconds {
+		c.MaxRetryIntervalSeconds = c.RetryIntervalSeconds
+	}
+	if c.MaxAgeHours == 0 {
+		c.MaxAgeHours = DefaultMaxAgeHours
+	}
+	if c.RetentionHours == 0 {
+		c.RetentionHours = DefaultRetentionHours
+	}
+	return c
+}
@@ -0,0 +1,281 @@
+package asyncqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a job does not exist.
+var ErrNotFound = errors.New("async job not found")
+
+// Status is the state of a job.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"    // Waiting for its next attempt
+	StatusRunning   Status = "running"   // Being executed
+	StatusSucceeded Status = "succeeded" // Answered with a 2xx status
+	StatusFailed    Status = "failed"    // Rejected by the gateway or the provider, or out of attempts
+	StatusExpired   Status = "expired"   // Not completed within the maximum age
+)
+
+// Finished reports whether a job reached a final state.
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusExpired
+}
+
+// Job is an inference request executed in the background, and its result once finished.
+type Job struct {
+	ID     string `gorm:"type:varchar(36);primaryKey" json:"id"`
+	Status Status `gorm:"type:varchar(16);index:idx_async_jobs_due,priority:1" json:"status"`
+
+	// Request replayed by every attempt
+	Method             string `gorm:"type:varchar(16)" json:"-"`
+	Path               string `gorm:"type:text" json:"-"` // Including the query string
+	RequestHeadersJSON string `gorm:"type:text" json:"-"` // JSON serialized RequestHeaders
+	RequestBody        string `gorm:"type:text" json:"-"`
+	RequestCredentials string `gorm:"type:text" json:"-"` // Credential headers, encrypted by the gateway and cleared once finished
+	ClientIP           string `gorm:"type:varchar(64)" json:"-"`
+	Provider           string `gorm:"type:varchar(50);index" json:"provider,omitempty"` // Parsed from the model, when it names one
+	Model              string `gorm:"type:varchar(255)" json:"model,omitempty"`
+	WebhookURL         string `gorm:"type:text" json:"-"`
+	Owner              string `gorm:"type:varchar(64);index" json:"-"` // Hash of the virtual key or authorization that queued the job
+
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `gorm:"index:idx_async_jobs_due,priority:2" json:"next_attempt_at"`
+
+	// Result of the last attempt
+	StatusCode          int    `json:"status_code,omitempty"`
+	ResponseContentType string `gorm:"type:varchar(255)" json:"-"`
+	ResponseBody        string `gorm:"type:text" json:"-"`
+	Error               string `gorm:"type:text;column:error_message" json:"error,omitempty"`
+	WebhookStatus       string `gorm:"type:varchar(16)" json:"webhook_status,omitempty"` // delivered or failed
+
+	CreatedAt   time.Time  `gorm:"index;not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"index" json:"updated_at"`
+	CompletedAt *time.Time `gorm:"index" json:"completed_at,omitempty"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	RequestHeaders map[string]string `gorm:"-" json:"-"`
+}
+
+// TableName sets the table name for async jobs
+func (Job) TableName() string { return "async_jobs" }
+
+// BeforeSave serializes the headers of a job
+func (j *Job) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(j.RequestHeaders)
+	if err != nil {
+		return err
+	}
+	j.RequestHeadersJSON = string(data)
+	return nil
+}
+
+// AfterFind deserializes the headers of a job
+func (j *Job) AfterFind(tx *gorm.DB) error {
+	if j.RequestHeadersJSON != "" {
+		return json.Unmarshal([]byte(j.RequestHeadersJSON), &j.RequestHeaders)
+	}
+	return nil
+}
+
+// Store persists jobs. Claims are atomic, so several gateway replicas can share a database store.
+type Store interface {
+	// Save creates or updates a job.
+	Save(ctx context.Context, job *Job) error
+	// Get returns a job, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Job, error)
+	// Claim marks up to limit queued jobs due at now as running, oldest first, and returns them.
+	Claim(ctx context.Context, now time.Time, limit int) ([]Job, error)
+	// Requeue returns running jobs last updated before staleBefore to the queue, e.g. after a crash, and
+	// returns how many there were.
+	Requeue(ctx context.Context, staleBefore time.Time) (int64, error)
+	// Purge deletes the jobs finished before the given time and returns how many there were.
+	Purge(ctx context.Context, finishedBefore time.Time) (int64, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores jobs in a database, usually the logs store.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the jobs table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate async jobs table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the async jobs table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addasyncjobstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Job{}) {
+				if err := migrator.CreateTable(&Job{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Save creates or updates a job.
+func (s *RDBStore) Save(ctx context.Context, job *Job) error {
+	return s.db.WithContext(ctx).Save(job).Error
+}
+
+// Get returns a job.
+func (s *RDBStore) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := s.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Claim marks due jobs as running. A job claimed by another replica in the meantime is skipped.
+func (s *RDBStore) Claim(ctx context.Context, now time.Time, limit int) ([]Job, error) {
+	var due []Job
+	if err := s.db.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", StatusQueued, now).
+		Order("next_attempt_at ASC").Limit(limit).Find(&due).Error; err != nil {
+		return nil, err
+	}
+	claimed := due[:0]
+	for _, job := range due {
+		result := s.db.WithContext(ctx).Model(&Job{}).Where("id = ? AND status = ?", job.ID, StatusQueued).
+			Updates(map[string]any{"status": StatusRunning, "updated_at": now})
+		if result.Error != nil {
+			return claimed, result.Error
+		}
+		if result.RowsAffected == 1 {
+			job.Status, job.UpdatedAt = StatusRunning, now
+			claimed = append(claimed, job)
+		}
+	}
+	return claimed, nil
+}
+
+// Requeue returns stale running jobs to the queue.
+func (s *RDBStore) Requeue(ctx context.Context, staleBefore time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Model(&Job{}).Where("status = ? AND updated_at < ?", StatusRunning, staleBefore).
+		Updates(map[string]any{"status": StatusQueued, "updated_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// Purge deletes old finished jobs.
+func (s *RDBStore) Purge(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("completed_at IS NOT NULL AND completed_at < ?", finishedBefore).Delete(&Job{})
+	return result.RowsAffected, result.Error
+}
+
+// InMemoryStore keeps jobs in memory. Queued jobs and results are lost on restart.
+type InMemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{jobs: make(map[string]Job)}
+}
+
+// Save creates or updates a job.
+func (s *InMemoryStore) Save(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	job.UpdatedAt = now
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+// Get returns a job.
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
+
+// Claim marks due jobs as running.
+func (s *InMemoryStore) Claim(ctx context.Context, now time.Time, limit int) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []Job
+	for _, job := range s.jobs {
+		if job.Status == StatusQueued && !job.NextAttemptAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	for i := range due {
+		due[i].Status, due[i].UpdatedAt = StatusRunning, now
+		s.jobs[due[i].ID] = due[i]
+	}
+	return due, nil
+}
+
+// Requeue returns stale running jobs to the queue.
+func (s *InMemoryStore) Requeue(ctx context.Context, staleBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var requeued int64
+	for id, job := range s.jobs {
+		if job.Status == StatusRunning && job.UpdatedAt.Before(staleBefore) {
+			job.Status, job.UpdatedAt = StatusQueued, time.Now()
+			s.jobs[id] = job
+			requeued++
+		}
+	}
+	return requeued, nil
+}
+
+// Purge deletes old finished jobs.
+func (s *InMemoryStore) Purge(ctx context.Context, finishedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var purged int64
+	for id, job := range s.jobs {
+		if job.CompletedAt != nil && job.CompletedAt.Before(finishedBefore) {
+			delete(s.jobs, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,76 @@
+package asyncqueue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestStore verifies that due jobs are claimed once, oldest first, and that stale and finished jobs are
+// requeued and purged, in both stores.
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "async.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			for _, job := range []*Job{
+				{ID: "later", Status: StatusQueued, NextAttemptAt: now.Add(time.Minute)},
+				{ID: "second", Status: StatusQueued, NextAttemptAt: now.Add(-time.Second)},
+				{ID: "first", Status: StatusQueued, NextAttemptAt: now.Add(-time.Minute), RequestHeaders: map[string]string{"x-bf-vk": "vk"}},
+			} {
+				if err := store.Save(ctx, job); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			claimed, err := store.Claim(ctx, now, 10)
+			if err != nil {
+				t.Fatalf("Claim() error = %v", err)
+			}
+			if len(claimed) != 2 || claimed[0].ID != "first" || claimed[1].ID != "second" || claimed[0].Status != StatusRunning {
+				t.Fatalf("Claim() = %+v, want the two due jobs, oldest first, running", claimed)
+			}
+			if claimed[0].RequestHeaders["x-bf-vk"] != "vk" {
+				t.Errorf("claimed headers = %v, want the saved ones", claimed[0].RequestHeaders)
+			}
+			if again, _ := store.Claim(ctx, now, 10); len(again) != 0 {
+				t.Errorf("second Claim() = %+v, want no job", again)
+			}
+
+			// A running job not updated for a while is returned to the queue
+			if requeued, err := store.Requeue(ctx, time.Now().Add(time.Hour)); err != nil || requeued != 2 {
+				t.Fatalf("Requeue() = %d, %v, want 2", requeued, err)
+			}
+			job, err := store.Get(ctx, "first")
+			if err != nil || job.Status != StatusQueued {
+				t.Fatalf("Get() = %+v, %v, want a queued job", job, err)
+			}
+
+			completedAt := now.Add(-time.Hour)
+			job.Status, job.CompletedAt = StatusSucceeded, &completedAt
+			if err := store.Save(ctx, job); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if purged, err := store.Purge(ctx, now); err != nil || purged != 1 {
+				t.Fatalf("Purge() = %d, %v, want 1", purged, err)
+			}
+			if _, err := store.Get(ctx, "first"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a purged job error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
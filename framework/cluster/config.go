@@ -0,0 +1,106 @@
+// Package cluster provides leader election for Bifrost deployments running multiple replicas.
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ElectorType represents the backend used for leader election.
+type ElectorType string
+
+const (
+	ElectorTypeLocal    ElectorType = "local"
+	ElectorTypePostgres ElectorType = "postgres"
+	ElectorTypeRedis    ElectorType = "redis"
+)
+
+const (
+	// DefaultLeaseTTLSeconds is how long a leader keeps leadership without renewing it.
+	DefaultLeaseTTLSeconds = 15
+	// DefaultRenewIntervalSeconds is how often leadership is renewed or re-contested.
+	DefaultRenewIntervalSeconds = 5
+)
+
+// Config represents the configuration for cluster coordination.
+type Config struct {
+	Enabled              bool        `json:"enabled"`
+	Type                 ElectorType `json:"type"`
+	NodeID               string      `json:"node_id,omitempty"`                // Unique identifier of this replica (default: hostname)
+	LeaseTTLSeconds      int         `json:"lease_ttl_seconds,omitempty"`      // Leadership lease TTL (default: 15s)
+	RenewIntervalSeconds int         `json:"renew_interval_seconds,omitempty"` // Renew/contest interval (default: 5s)
+	Config               any         `json:"config,omitempty"`
+}
+
+// PostgresConfig represents the configuration for Postgres advisory lock based election.
+// The election reuses the config store connection, so the config store must be a Postgres store.
+type PostgresConfig struct {
+	LockID int64 `json:"lock_id,omitempty"` // Advisory lock key shared by all replicas
+}
+
+// RedisConfig represents the configuration for Redis lease based election.
+type RedisConfig struct {
+	Addr     string `json:"addr"`               // Redis server address (host:port) - REQUIRED
+	Username string `json:"username,omitempty"` // Username for Redis AUTH (optional)
+	Password string `json:"password,omitempty"` // Password for Redis AUTH (optional)
+	DB       int    `json:"db,omitempty"`       // Redis database number (default: 0)
+	Key      string `json:"key,omitempty"`      // Lease key shared by all replicas (default: bifrost:cluster:leader)
+	// Prefix of the keys of the single-use values shared by all replicas (default: bifrost:cluster:nonce:)
+	NoncePrefix string `json:"nonce_prefix,omitempty"`
+}
+
+// UnmarshalJSON unmarshals the config from JSON.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	// First, unmarshal into a temporary struct to get the basic fields
+	type TempConfig struct {
+		Enabled              bool            `json:"enabled"`
+		Type                 ElectorType     `json:"type"`
+		NodeID               string          `json:"node_id,omitempty"`
+		LeaseTTLSeconds      int             `json:"lease_ttl_seconds,omitempty"`
+		RenewIntervalSeconds int             `json:"renew_interval_seconds,omitempty"`
+		Config               json.RawMessage `json:"config,omitempty"` // Keep as raw JSON
+	}
+
+	var temp TempConfig
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return fmt.Errorf("failed to unmarshal cluster config: %w", err)
+	}
+
+	// Set basic fields
+	c.Enabled = temp.Enabled
+	c.Type = temp.Type
+	c.NodeID = temp.NodeID
+	c.LeaseTTLSeconds = temp.LeaseTTLSeconds
+	c.RenewIntervalSeconds = temp.RenewIntervalSeconds
+
+	if !temp.Enabled {
+		c.Config = nil
+		return nil
+	}
+
+	// Parse the config field based on type
+	switch temp.Type {
+	case ElectorTypeLocal, "":
+		c.Config = nil
+	case ElectorTypePostgres:
+		var postgresConfig PostgresConfig
+		if len(temp.Config) > 0 {
+			if err := json.Unmarshal(temp.Config, &postgresConfig); err != nil {
+				return fmt.Errorf("failed to unmarshal postgres cluster config: %w", err)
+			}
+		}
+		c.Config = &postgresConfig
+	case ElectorTypeRedis:
+		if len(temp.Config) == 0 {
+			return fmt.Errorf("missing redis cluster config payload")
+		}
+		var redisConfig RedisConfig
+		if err := json.Unmarshal(temp.Config, &redisConfig); err != nil {
+			return fmt.Errorf("failed to unmarshal redis cluster config: %w", err)
+		}
+		c.Config = &redisConfig
+	default:
+		return fmt.Errorf("unknown cluster elector type: %s", temp.Type)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"gorm.io/gorm"
+)
+
+// LeaderChecker is the minimal interface background workers use to decide whether they should run.
+type LeaderChecker interface {
+	// IsLeader reports whether this replica currently holds leadership.
+	IsLeader() bool
+}
+
+// LeaderElector coordinates leadership between replicas.
+// Exactly one replica is leader at a time; when the leader stops renewing its lease
+// another replica takes over automatically.
+type LeaderElector interface {
+	LeaderChecker
+
+	// Start begins contesting leadership in the background.
+	Start(ctx context.Context) error
+
+	// Status returns the current view of the cluster from this replica.
+	Status(ctx context.Context) (*Status, error)
+
+	// Close releases leadership (if held) and stops the background loop.
+	Close(ctx context.Context) error
+}
+
+// Status describes the leadership state as seen by one replica.
+type Status struct {
+	Type           ElectorType `json:"type"`
+	NodeID         string      `json:"node_id"`
+	IsLeader       bool        `json:"is_leader"`
+	LeaderID       string      `json:"leader_id,omitempty"`
+	LeaderSince    *time.Time  `json:"leader_since,omitempty"`
+	LeaseExpiresAt *time.Time  `json:"lease_expires_at,omitempty"`
+}
+
+// NonceStore records single-use values, such as request signature nonces and token IDs, in state shared by all
+// replicas, so a value accepted by one replica is rejected by the others until it expires. The Postgres and Redis
+// electors implement it.
+type NonceStore interface {
+	// Remember records nonce until expiry, reporting false when it is already recorded and not yet expired.
+	Remember(ctx context.Context, nonce string, expiry time.Time) (bool, error)
+}
+
+// NewLeaderElector creates a new leader elector based on the configuration.
+// db is the config store connection and is only used by the Postgres elector.
+// A nil or disabled config yields a local elector which always considers itself leader.
+func NewLeaderElector(ctx context.Context, config *Config, db *gorm.DB, logger schemas.Logger) (LeaderElector, error) {
+	if config == nil || !config.Enabled {
+		return newLocalElector(resolveNodeID("")), nil
+	}
+	nodeID := resolveNodeID(config.NodeID)
+	leaseTTL := time.Duration(config.LeaseTTLSeconds) * time.Second
+	if leaseTTL <= 0 {
+		leaseTTL = DefaultLeaseTTLSeconds * time.Second
+	}
+	renewInterval := time.Duration(config.RenewIntervalSeconds) * time.Second
+	if renewInterval <= 0 {
+		renewInterval = DefaultRenewIntervalSeconds * time.Second
+	}
+	if renewInterval >= leaseTTL {
+		return nil, fmt.Errorf("renew interval (%s) must be shorter than lease ttl (%s)", renewInterval, leaseTTL)
+	}
+	switch config.Type {
+	case ElectorTypeLocal, "":
+		return newLocalElector(nodeID), nil
+	case ElectorTypePostgres:
+		postgresConfig, ok := config.Config.(*PostgresConfig)
+		if !ok || postgresConfig == nil {
+			postgresConfig = &PostgresConfig{}
+		}
+		return newPostgresElector(ctx, postgresConfig, db, nodeID, leaseTTL, renewInterval, logger)
+	case ElectorTypeRedis:
+		if redisConfig, ok := config.Config.(*RedisConfig); ok {
+			return newRedisElector(ctx, redisConfig, nodeID, leaseTTL, renewInterval, logger)
+		}
+		return nil, fmt.Errorf("invalid redis cluster config: %T", config.Config)
+	}
+	return nil, fmt.Errorf("unsupported cluster elector type: %s", config.Type)
+}
+
+// resolveNodeID returns the configured node id, falling back to the hostname and then a random id.
+func resolveNodeID(nodeID string) string {
+	if nodeID != "" {
+		return nodeID
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return uuid.NewString()
+}
+
+// localElector is used for single replica deployments; it is always the leader.
+type localElector struct {
+	nodeID    string
+	startedAt time.Time
+}
+
+func newLocalElector(nodeID string) *localElector {
+	return &localElector{nodeID: nodeID, startedAt: time.Now()}
+}
+
+func (e *localElector) Start(ctx context.Context) error { return nil }
+
+func (e *localElector) IsLeader() bool { return true }
+
+func (e *localElector) Status(ctx context.Context) (*Status, error) {
+	return &Status{
+		Type:        ElectorTypeLocal,
+		NodeID:      e.nodeID,
+		IsLeader:    true,
+		LeaderID:    e.nodeID,
+		LeaderSince: &e.startedAt,
+	}, nil
+}
+
+func (e *localElector) Close(ctx context.Context) error { return nil }
+
+// campaign holds the state shared by lease based electors: the leadership flag and the renew loop.
+type campaign struct {
+	nodeID        string
+	leaseTTL      time.Duration
+	renewInterval time.Duration
+	logger        schemas.Logger
+
+	isLeader    atomic.Bool
+	mu          sync.RWMutex
+	leaderSince *time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// IsLeader reports whether this replica currently holds leadership.
+func (c *campaign) IsLeader() bool {
+	return c.isLeader.Load()
+}
+
+// setLeader records a leadership transition and logs it.
+func (c *campaign) setLeader(leader bool) {
+	if c.isLeader.Swap(leader) == leader {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if leader {
+		now := time.Now()
+		c.leaderSince = &now
+		c.logger.Info("cluster: node %s acquired leadership", c.nodeID)
+	} else {
+		c.leaderSince = nil
+		c.logger.Warn("cluster: node %s lost leadership", c.nodeID)
+	}
+}
+
+// getLeaderSince returns the time this replica became leader, or nil.
+func (c *campaign) getLeaderSince() *time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.leaderSince
+}
+
+// run invokes tick immediately and then every renew interval until the context is cancelled.
+// tick returns whether this replica holds leadership after the attempt.
+func (c *campaign) run(ctx context.Context, tick func(ctx context.Context) (bool, error)) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.renewInterval)
+		defer ticker.Stop()
+		for {
+			leader, err := tick(ctx)
+			if err != nil && ctx.Err() == nil {
+				c.logger.Warn("cluster: leadership renewal failed for node %s: %v", c.nodeID, err)
+			}
+			c.setLeader(leader)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stop cancels the renew loop and waits for it to exit.
+func (c *campaign) stop() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+	c.setLeader(false)
+}
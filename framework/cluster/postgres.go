@@ -0,0 +1,208 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultAdvisoryLockID is the advisory lock key used when none is configured ("bifr" in ASCII).
+const DefaultAdvisoryLockID int64 = 0x62696672
+
+// TableClusterLeader records the current leader so that every replica can report it.
+// Postgres advisory locks do not expose their holder, so the leader writes this row on every renewal.
+type TableClusterLeader struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	NodeID      string    `gorm:"type:varchar(255);not null" json:"node_id"`
+	LeaderSince time.Time `gorm:"not null" json:"leader_since"`
+	RenewedAt   time.Time `gorm:"index;not null" json:"renewed_at"`
+}
+
+// TableName sets the table name for the cluster leader record
+func (TableClusterLeader) TableName() string { return "cluster_leader" }
+
+// TableClusterNonce records a single-use value accepted by one of the replicas until it expires.
+// The leader deletes expired nonces on every renewal.
+type TableClusterNonce struct {
+	Nonce     string    `gorm:"type:varchar(255);primaryKey" json:"nonce"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+}
+
+// TableName sets the table name for the cluster nonces
+func (TableClusterNonce) TableName() string { return "cluster_nonces" }
+
+// postgresElector holds a session level advisory lock on a dedicated connection.
+// Leadership is lost as soon as that connection dies, which lets another replica take over.
+type postgresElector struct {
+	campaign
+	db     *gorm.DB
+	sqlDB  *sql.DB
+	lockID int64
+
+	connMu sync.Mutex
+	conn   *sql.Conn
+}
+
+// newPostgresElector creates a new Postgres advisory lock elector on top of the config store connection.
+func newPostgresElector(ctx context.Context, config *PostgresConfig, db *gorm.DB, nodeID string, leaseTTL, renewInterval time.Duration, logger schemas.Logger) (*postgresElector, error) {
+	if db == nil {
+		return nil, fmt.Errorf("postgres leader election requires a config store")
+	}
+	if db.Dialector.Name() != "postgres" {
+		return nil, fmt.Errorf("postgres leader election requires a postgres config store, got %s", db.Dialector.Name())
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql connection: %w", err)
+	}
+	if err := db.WithContext(ctx).AutoMigrate(&TableClusterLeader{}, &TableClusterNonce{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate cluster tables: %w", err)
+	}
+	lockID := config.LockID
+	if lockID == 0 {
+		lockID = DefaultAdvisoryLockID
+	}
+	return &postgresElector{
+		campaign: campaign{
+			nodeID:        nodeID,
+			leaseTTL:      leaseTTL,
+			renewInterval: renewInterval,
+			logger:        logger,
+		},
+		db:     db,
+		sqlDB:  sqlDB,
+		lockID: lockID,
+	}, nil
+}
+
+// Start begins contesting the advisory lock in the background.
+func (e *postgresElector) Start(ctx context.Context) error {
+	e.run(ctx, e.tick)
+	return nil
+}
+
+// tick acquires or verifies the advisory lock and refreshes the leader record.
+func (e *postgresElector) tick(ctx context.Context) (bool, error) {
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+
+	if e.conn == nil {
+		conn, err := e.sqlDB.Conn(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to open lock connection: %w", err)
+		}
+		e.conn = conn
+	}
+
+	if e.IsLeader() {
+		// The lock is tied to the session; if the session is alive we still hold it
+		if err := e.conn.PingContext(ctx); err != nil {
+			e.resetConnLocked()
+			return false, fmt.Errorf("lock connection lost: %w", err)
+		}
+	} else {
+		var acquired bool
+		if err := e.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.lockID).Scan(&acquired); err != nil {
+			e.resetConnLocked()
+			return false, fmt.Errorf("failed to try advisory lock: %w", err)
+		}
+		if !acquired {
+			return false, nil
+		}
+	}
+
+	now := time.Now()
+	leaderSince := now
+	if since := e.getLeaderSince(); since != nil {
+		leaderSince = *since
+	}
+	record := TableClusterLeader{ID: 1, NodeID: e.nodeID, LeaderSince: leaderSince, RenewedAt: now}
+	if err := e.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"node_id", "leader_since", "renewed_at"}),
+	}).Create(&record).Error; err != nil {
+		// Holding the lock is what makes us leader; a failed record write only affects reporting
+		e.logger.Warn("cluster: failed to record leader %s: %v", e.nodeID, err)
+	}
+	if err := e.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&TableClusterNonce{}).Error; err != nil {
+		e.logger.Warn("cluster: failed to delete expired nonces: %v", err)
+	}
+	return true, nil
+}
+
+// resetConnLocked closes the lock connection so the next tick opens a fresh one.
+func (e *postgresElector) resetConnLocked() {
+	if e.conn != nil {
+		_ = e.conn.Close()
+		e.conn = nil
+	}
+}
+
+// Remember records nonce until expiry, reporting false when it is already recorded and not yet expired.
+// An expired record the leader has not deleted yet is taken over.
+func (e *postgresElector) Remember(ctx context.Context, nonce string, expiry time.Time) (bool, error) {
+	result := e.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "nonce"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Lt{Column: clause.Column{Table: TableClusterNonce{}.TableName(), Name: "expires_at"}, Value: time.Now()}}},
+	}).Create(&TableClusterNonce{Nonce: nonce, ExpiresAt: expiry})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record nonce: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Status returns the leader as recorded in the cluster leader table.
+func (e *postgresElector) Status(ctx context.Context) (*Status, error) {
+	status := &Status{
+		Type:     ElectorTypePostgres,
+		NodeID:   e.nodeID,
+		IsLeader: e.IsLeader(),
+	}
+	var record TableClusterLeader
+	if err := e.db.WithContext(ctx).First(&record, 1).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status, nil
+		}
+		return nil, err
+	}
+	expiresAt := record.RenewedAt.Add(e.leaseTTL)
+	if time.Now().After(expiresAt) {
+		// Stale record, the previous leader stopped renewing and nobody took over yet
+		return status, nil
+	}
+	status.LeaderID = record.NodeID
+	status.LeaderSince = &record.LeaderSince
+	status.LeaseExpiresAt = &expiresAt
+	return status, nil
+}
+
+// Close releases the advisory lock and removes the leader record if this replica owns it.
+func (e *postgresElector) Close(ctx context.Context) error {
+	wasLeader := e.IsLeader()
+	e.stop()
+
+	e.connMu.Lock()
+	defer e.connMu.Unlock()
+	if e.conn == nil {
+		return nil
+	}
+	var err error
+	if wasLeader {
+		if _, unlockErr := e.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", e.lockID); unlockErr != nil {
+			err = fmt.Errorf("failed to release advisory lock: %w", unlockErr)
+		}
+		if delErr := e.db.WithContext(ctx).Where("id = ? AND node_id = ?", 1, e.nodeID).Delete(&TableClusterLeader{}).Error; delErr != nil && err == nil {
+			err = fmt.Errorf("failed to clear leader record: %w", delErr)
+		}
+	}
+	e.resetConnLocked()
+	return err
+}
@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestPostgresElectorRemember verifies that a nonce is accepted once until it expires, on SQLite, which shares the
+// upsert syntax of Postgres
+func TestPostgresElectorRemember(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "cluster.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&TableClusterNonce{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	elector := &postgresElector{db: db}
+
+	remember := func(nonce string, expiry time.Time) bool {
+		t.Helper()
+		recorded, err := elector.Remember(ctx, nonce, expiry)
+		if err != nil {
+			t.Fatalf("Remember(%q) error = %v", nonce, err)
+		}
+		return recorded
+	}
+	if !remember("a", time.Now().Add(time.Minute)) {
+		t.Error("expected a new nonce to be recorded")
+	}
+	if remember("a", time.Now().Add(time.Minute)) {
+		t.Error("expected a recorded nonce to be rejected")
+	}
+	if !remember("b", time.Now().Add(time.Minute)) {
+		t.Error("expected another nonce to be recorded")
+	}
+	if err := db.Model(&TableClusterNonce{}).Where("nonce = ?", "a").Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("failed to expire nonce: %v", err)
+	}
+	if !remember("a", time.Now().Add(time.Minute)) {
+		t.Error("expected an expired nonce to be recorded again")
+	}
+	if remember("a", time.Now().Add(time.Minute)) {
+		t.Error("expected the nonce recorded again to be rejected")
+	}
+}
@@ -0,0 +1,248 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisLeaderKey is the lease key used when none is configured.
+const DefaultRedisLeaderKey = "bifrost:cluster:leader"
+
+// DefaultRedisNoncePrefix is the prefix of nonce keys used when none is configured.
+const DefaultRedisNoncePrefix = "bifrost:cluster:nonce:"
+
+// renewScript extends the lease only if it is still owned by the caller.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lease only if it is still owned by the caller.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// reclaimScript replaces the lease only if it still holds the given value.
+var reclaimScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3]) and 1
+end
+return 0
+`)
+
+// leaseClient is the key operations the Redis elector relies on, so the election can be exercised without a server.
+type leaseClient interface {
+	// setNX sets key to value with ttl when it is missing, reporting whether it did.
+	setNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// get returns the value of key, and whether it is set.
+	get(ctx context.Context, key string) (string, bool, error)
+	// ttl returns the time left before key expires, 0 when it is missing or does not expire.
+	ttl(ctx context.Context, key string) (time.Duration, error)
+	// compareAndExpire sets the ttl of key when it holds value, reporting whether it did.
+	compareAndExpire(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// compareAndSet replaces key with value and ttl when it holds old, reporting whether it did.
+	compareAndSet(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error)
+	// compareAndDelete deletes key when it holds value.
+	compareAndDelete(ctx context.Context, key, value string) error
+	// close closes the connection.
+	close() error
+}
+
+// redisLeaseClient implements leaseClient with a Redis client and the lease scripts.
+type redisLeaseClient struct {
+	client *redis.Client
+}
+
+func (c *redisLeaseClient) setNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (c *redisLeaseClient) get(ctx context.Context, key string) (string, bool, error) {
+	value, err := c.client.Get(ctx, key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+func (c *redisLeaseClient) ttl(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client.PTTL(ctx, key).Result()
+	if err != nil || ttl < 0 {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+func (c *redisLeaseClient) compareAndExpire(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	expired, err := renewScript.Run(ctx, c.client, []string{key}, value, ttl.Milliseconds()).Int64()
+	return expired == 1, err
+}
+
+func (c *redisLeaseClient) compareAndSet(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error) {
+	set, err := reclaimScript.Run(ctx, c.client, []string{key}, old, value, ttl.Milliseconds()).Int64()
+	return set == 1, err
+}
+
+func (c *redisLeaseClient) compareAndDelete(ctx context.Context, key, value string) error {
+	return releaseScript.Run(ctx, c.client, []string{key}, value).Err()
+}
+
+func (c *redisLeaseClient) close() error {
+	return c.client.Close()
+}
+
+// redisElector holds a lease key with a TTL; the owner renews it and others try to claim it when it expires.
+// The lease value is "<node_id>|<leader_since_unix_ms>" so every replica can report who leads and since when.
+type redisElector struct {
+	campaign
+	client      leaseClient
+	key         string
+	value       string
+	noncePrefix string
+}
+
+// newRedisElector creates a new Redis lease elector.
+func newRedisElector(ctx context.Context, config *RedisConfig, nodeID string, leaseTTL, renewInterval time.Duration, logger schemas.Logger) (*redisElector, error) {
+	if config.Addr == "" {
+		return nil, fmt.Errorf("redis addr is required for leader election")
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Addr,
+		Username: config.Username,
+		Password: config.Password,
+		DB:       config.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	key := config.Key
+	if key == "" {
+		key = DefaultRedisLeaderKey
+	}
+	noncePrefix := config.NoncePrefix
+	if noncePrefix == "" {
+		noncePrefix = DefaultRedisNoncePrefix
+	}
+	return &redisElector{
+		campaign: campaign{
+			nodeID:        nodeID,
+			leaseTTL:      leaseTTL,
+			renewInterval: renewInterval,
+			logger:        logger,
+		},
+		client:      &redisLeaseClient{client: client},
+		key:         key,
+		noncePrefix: noncePrefix,
+	}, nil
+}
+
+// Start begins contesting the lease in the background.
+func (e *redisElector) Start(ctx context.Context) error {
+	e.run(ctx, e.tick)
+	return nil
+}
+
+// tick renews the lease when leader, otherwise tries to claim it. A lease still holding our node ID, left by a
+// renewal that failed transiently, is reclaimed rather than waited out.
+func (e *redisElector) tick(ctx context.Context) (bool, error) {
+	if e.IsLeader() {
+		renewed, err := e.client.compareAndExpire(ctx, e.key, e.value, e.leaseTTL)
+		if err != nil {
+			return false, fmt.Errorf("failed to renew lease: %w", err)
+		}
+		return renewed, nil
+	}
+	value := fmt.Sprintf("%s|%d", e.nodeID, time.Now().UnixMilli())
+	acquired, err := e.client.setNX(ctx, e.key, value, e.leaseTTL)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim lease: %w", err)
+	}
+	if !acquired {
+		current, ok, err := e.client.get(ctx, e.key)
+		if err != nil {
+			return false, fmt.Errorf("failed to read lease: %w", err)
+		}
+		if leaderID, _, _ := strings.Cut(current, "|"); !ok || leaderID != e.nodeID {
+			return false, nil
+		}
+		if acquired, err = e.client.compareAndSet(ctx, e.key, current, value, e.leaseTTL); err != nil {
+			return false, fmt.Errorf("failed to reclaim lease: %w", err)
+		}
+	}
+	if acquired {
+		e.value = value
+	}
+	return acquired, nil
+}
+
+// Remember records nonce with a key expiring at expiry, reporting false when the key already exists.
+func (e *redisElector) Remember(ctx context.Context, nonce string, expiry time.Time) (bool, error) {
+	ttl := time.Until(expiry)
+	if ttl <= 0 {
+		return true, nil
+	}
+	recorded, err := e.client.setNX(ctx, e.noncePrefix+nonce, "1", ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to record nonce: %w", err)
+	}
+	return recorded, nil
+}
+
+// Status returns the current lease holder.
+func (e *redisElector) Status(ctx context.Context) (*Status, error) {
+	status := &Status{
+		Type:     ElectorTypeRedis,
+		NodeID:   e.nodeID,
+		IsLeader: e.IsLeader(),
+	}
+	value, ok, err := e.client.get(ctx, e.key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return status, nil
+	}
+	leaderID, since, _ := strings.Cut(value, "|")
+	status.LeaderID = leaderID
+	if ms, err := strconv.ParseInt(since, 10, 64); err == nil {
+		leaderSince := time.UnixMilli(ms)
+		status.LeaderSince = &leaderSince
+	}
+	if ttl, err := e.client.ttl(ctx, e.key); err == nil && ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		status.LeaseExpiresAt = &expiresAt
+	}
+	return status, nil
+}
+
+// Close releases the lease if held and closes the redis client.
+func (e *redisElector) Close(ctx context.Context) error {
+	wasLeader := e.IsLeader()
+	e.stop()
+	var err error
+	if wasLeader && e.value != "" {
+		if releaseErr := e.client.compareAndDelete(ctx, e.key, e.value); releaseErr != nil {
+			err = fmt.Errorf("failed to release lease: %w", releaseErr)
+		}
+	}
+	if closeErr := e.client.close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,243 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// fakeLease is a value of fakeLeaseClient with its expiry.
+type fakeLease struct {
+	value     string
+	expiresAt time.Time
+}
+
+// fakeLeaseClient is an in-memory leaseClient shared by the electors of a test, with a clock the test advances and
+// errors it injects.
+type fakeLeaseClient struct {
+	mu     sync.Mutex
+	now    time.Time
+	leases map[string]fakeLease
+	err    error // Returned by the next call, then cleared
+}
+
+func newFakeLeaseClient() *fakeLeaseClient {
+	return &fakeLeaseClient{now: time.Now(), leases: make(map[string]fakeLease)}
+}
+
+// advance moves the clock forward, expiring leases.
+func (c *fakeLeaseClient) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fail makes the next call return err.
+func (c *fakeLeaseClient) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+// load returns the live lease of key, and the injected error. Callers must hold the lock.
+func (c *fakeLeaseClient) load(key string) (fakeLease, bool, error) {
+	if err := c.err; err != nil {
+		c.err = nil
+		return fakeLease{}, false, err
+	}
+	lease, ok := c.leases[key]
+	if ok && !c.now.Before(lease.expiresAt) {
+		delete(c.leases, key)
+		return fakeLease{}, false, nil
+	}
+	return lease, ok, nil
+}
+
+func (c *fakeLeaseClient) setNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok, err := c.load(key); err != nil || ok {
+		return false, err
+	}
+	c.leases[key] = fakeLease{value: value, expiresAt: c.now.Add(ttl)}
+	return true, nil
+}
+
+func (c *fakeLeaseClient) get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lease, ok, err := c.load(key)
+	return lease.value, ok, err
+}
+
+func (c *fakeLeaseClient) ttl(ctx context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lease, ok, err := c.load(key)
+	if !ok {
+		return 0, err
+	}
+	return lease.expiresAt.Sub(c.now), nil
+}
+
+func (c *fakeLeaseClient) compareAndExpire(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lease, ok, err := c.load(key)
+	if err != nil || !ok || lease.value != value {
+		return false, err
+	}
+	c.leases[key] = fakeLease{value: value, expiresAt: c.now.Add(ttl)}
+	return true, nil
+}
+
+func (c *fakeLeaseClient) compareAndSet(ctx context.Context, key, old, value string, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lease, ok, err := c.load(key)
+	if err != nil || !ok || lease.value != old {
+		return false, err
+	}
+	c.leases[key] = fakeLease{value: value, expiresAt: c.now.Add(ttl)}
+	return true, nil
+}
+
+func (c *fakeLeaseClient) compareAndDelete(ctx context.Context, key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lease, ok, err := c.load(key)
+	if err == nil && ok && lease.value == value {
+		delete(c.leases, key)
+	}
+	return err
+}
+
+func (c *fakeLeaseClient) close() error { return nil }
+
+// newTestRedisElector creates a Redis elector for nodeID on the fake client.
+func newTestRedisElector(client *fakeLeaseClient, nodeID string) *redisElector {
+	return &redisElector{
+		campaign: campaign{
+			nodeID:        nodeID,
+			leaseTTL:      10 * time.Second,
+			renewInterval: 3 * time.Second,
+			logger:        bifrost.NewDefaultLogger(schemas.LogLevelError),
+		},
+		client:      client,
+		key:         DefaultRedisLeaderKey,
+		noncePrefix: DefaultRedisNoncePrefix,
+	}
+}
+
+// tick runs one election round of elector and records its outcome, as the renew loop does.
+func tick(t *testing.T, elector *redisElector) (bool, error) {
+	t.Helper()
+	leader, err := elector.tick(context.Background())
+	elector.setLeader(leader)
+	return leader, err
+}
+
+// TestRedisElector_Election verifies that one node acquires the lease and renews it while the other waits, and that
+// the other takes over once the leader stops renewing and its lease expires
+func TestRedisElector_Election(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeLeaseClient()
+	first, second := newTestRedisElector(client, "first"), newTestRedisElector(client, "second")
+
+	if leader, err := tick(t, first); err != nil || !leader {
+		t.Fatalf("first tick = %v, %v, want the lease acquired", leader, err)
+	}
+	if leader, err := tick(t, second); err != nil || leader {
+		t.Fatalf("second tick = %v, %v, want the lease held by the first node", leader, err)
+	}
+	status, err := second.Status(ctx)
+	if err != nil || status.LeaderID != "first" || status.IsLeader || status.LeaderSince == nil || status.LeaseExpiresAt == nil {
+		t.Fatalf("unexpected status %+v, %v", status, err)
+	}
+
+	// Renewals keep the lease past its first TTL
+	for i := 0; i < 5; i++ {
+		client.advance(3 * time.Second)
+		if leader, err := tick(t, first); err != nil || !leader {
+			t.Fatalf("renewal %d = %v, %v, want the lease kept", i, leader, err)
+		}
+		if leader, _ := tick(t, second); leader {
+			t.Fatalf("expected the second node to wait while the lease is renewed")
+		}
+	}
+
+	// The first node stops renewing: the second takes over once the lease expires, and the first loses it
+	client.advance(11 * time.Second)
+	if leader, err := tick(t, second); err != nil || !leader {
+		t.Fatalf("failover tick = %v, %v, want the expired lease acquired", leader, err)
+	}
+	if leader, err := tick(t, first); err != nil || leader {
+		t.Fatalf("tick of the old leader = %v, %v, want the lease lost", leader, err)
+	}
+	if status, err := first.Status(ctx); err != nil || status.LeaderID != "second" || status.IsLeader {
+		t.Fatalf("unexpected status %+v, %v", status, err)
+	}
+
+	// Closing releases the lease for the next node
+	if err := second.Close(ctx); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+	if leader, err := tick(t, first); err != nil || !leader {
+		t.Fatalf("tick after release = %v, %v, want the lease acquired", leader, err)
+	}
+}
+
+// TestRedisElector_Reclaim verifies that a leader stepping down after a failed renewal reclaims its own live lease on
+// the next tick, while another node cannot
+func TestRedisElector_Reclaim(t *testing.T) {
+	client := newFakeLeaseClient()
+	first, second := newTestRedisElector(client, "first"), newTestRedisElector(client, "second")
+	if leader, _ := tick(t, first); !leader {
+		t.Fatal("expected the first node to acquire the lease")
+	}
+
+	client.advance(3 * time.Second)
+	client.fail(errors.New("connection reset"))
+	if leader, err := tick(t, first); err == nil || leader {
+		t.Fatalf("failed renewal = %v, %v, want an error and leadership lost", leader, err)
+	}
+	if leader, _ := tick(t, second); leader {
+		t.Fatal("expected the lease to stay with the first node")
+	}
+	if leader, err := tick(t, first); err != nil || !leader {
+		t.Fatalf("tick after the failed renewal = %v, %v, want the lease reclaimed", leader, err)
+	}
+
+	// The reclaimed lease is renewed with its new value
+	client.advance(8 * time.Second)
+	if leader, err := tick(t, first); err != nil || !leader {
+		t.Fatalf("renewal of the reclaimed lease = %v, %v, want it kept", leader, err)
+	}
+	client.advance(8 * time.Second)
+	if leader, _ := tick(t, second); leader {
+		t.Fatal("expected the renewed lease to stay with the first node")
+	}
+}
+
+// TestRedisElectorRemember verifies that a nonce is accepted once until it expires
+func TestRedisElectorRemember(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeLeaseClient()
+	elector := newTestRedisElector(client, "first")
+	expiry := time.Now().Add(time.Minute)
+	if recorded, err := elector.Remember(ctx, "a", expiry); err != nil || !recorded {
+		t.Fatalf("Remember = %v, %v, want a new nonce recorded", recorded, err)
+	}
+	if recorded, err := elector.Remember(ctx, "a", expiry); err != nil || recorded {
+		t.Fatalf("Remember = %v, %v, want a recorded nonce rejected", recorded, err)
+	}
+	client.advance(2 * time.Minute)
+	if recorded, err := elector.Remember(ctx, "a", time.Now().Add(time.Minute)); err != nil || !recorded {
+		t.Fatalf("Remember = %v, %v, want an expired nonce recorded again", recorded, err)
+	}
+}
@@ -0,0 +1,193 @@
+package configstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reset schedules of budgets. Any other schedule is a cron expression.
+const (
+	BudgetScheduleRolling       = "rolling"        // Every reset_duration since the last reset (default)
+	BudgetScheduleCalendarMonth = "calendar_month" // At midnight UTC on the first day of every month
+)
+
+// ValidateBudgetReset checks the reset schedule of a budget and, for rolling budgets, its reset duration.
+func ValidateBudgetReset(schedule, duration string) error {
+	switch schedule {
+	case "", BudgetScheduleRolling:
+		if _, err := ParseDuration(duration); err != nil {
+			return fmt.Errorf("invalid reset duration format: %s", duration)
+		}
+	case BudgetScheduleCalendarMonth:
+	default:
+		if _, err := parseCronSchedule(schedule); err != nil {
+			return fmt.Errorf("invalid reset schedule %q: %w", schedule, err)
+		}
+	}
+	return nil
+}
+
+// NextReset returns when the current period of the budget ends.
+func (b *TableBudget) NextReset() (time.Time, error) {
+	switch b.ResetSchedule {
+	case "", BudgetScheduleRolling:
+		duration, err := ParseDuration(b.ResetDuration)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid reset duration %s: %w", b.ResetDuration, err)
+		}
+		return b.LastReset.Add(duration), nil
+	case BudgetScheduleCalendarMonth:
+		last := b.LastReset.UTC()
+		return time.Date(last.Year(), last.Month()+1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	schedule, err := parseCronSchedule(b.ResetSchedule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid reset schedule %s: %w", b.ResetSchedule, err)
+	}
+	next, ok := schedule.next(b.LastReset)
+	if !ok {
+		return time.Time{}, fmt.Errorf("reset schedule %s never fires", b.ResetSchedule)
+	}
+	return next, nil
+}
+
+// ResetDue reports whether the current period of the budget has ended. Budgets with an invalid
+// schedule never reset.
+func (b *TableBudget) ResetDue(now time.Time) bool {
+	next, err := b.NextReset()
+	return err == nil && !now.Before(next)
+}
+
+// EffectiveLimit is the spending limit of the current period: the max limit plus the quota rolled over.
+func (b *TableBudget) EffectiveLimit() float64 {
+	return b.MaxLimit + b.CarriedOver
+}
+
+// Reset starts a new period at now. With rollover, the unused quota of the ending period is carried
+// over, up to the rollover cap (the max limit by default).
+func (b *TableBudget) Reset(now time.Time) {
+	carried := 0.0
+	if b.Rollover {
+		limit := b.MaxLimit
+		if b.RolloverCap != nil {
+			limit = *b.RolloverCap
+		}
+		carried = max(0, min(b.EffectiveLimit()-b.CurrentUsage, limit))
+	}
+	b.CarriedOver = carried
+	b.CurrentUsage = 0
+	b.LastReset = now
+	b.AlertThreshold = 0
+}
+
+// cronSchedule is a parsed 5-field cron expression (minute hour day-of-month month day-of-week), in UTC.
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays uint64 // Bit sets of the allowed values
+	anyDay, anyWeekday                     bool   // Whether the day fields are unrestricted
+}
+
+// parseCronSchedule parses an expression of numbers, ranges (1-5), steps (*/15, 1-10/2) and lists.
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields (minute hour day month weekday), got %d", len(fields))
+	}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("field %d (%s): %w", i+1, field, err)
+		}
+		sets[i] = set
+	}
+	// Sunday is both 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &cronSchedule{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField parses a comma separated field into a bit set of values within [low, high].
+func parseCronField(field string, low, high int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+		start, end := low, high
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				end = high
+			}
+		}
+		if start < low || end > high || start > end {
+			return 0, fmt.Errorf("values must be within %d-%d", low, high)
+		}
+		for value := start; value <= end; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+// next returns the first time strictly after t matching the schedule, searching up to five years ahead.
+func (c *cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case c.hours&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case c.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// dayMatches applies the cron rule that a day matches either day field when both are restricted.
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
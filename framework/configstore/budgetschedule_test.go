@@ -0,0 +1,77 @@
+package configstore
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBudgetNextReset verifies the end of budget periods for rolling, calendar month and cron schedules,
+// and that invalid schedules are rejected.
+func TestBudgetNextReset(t *testing.T) {
+	lastReset := time.Date(2025, 1, 31, 15, 4, 0, 0, time.UTC) // A Friday
+	tests := []struct {
+		schedule string
+		duration string
+		want     time.Time
+	}{
+		{"", "1d", lastReset.Add(24 * time.Hour)},
+		{BudgetScheduleRolling, "30d", lastReset.Add(30 * 24 * time.Hour)},
+		{BudgetScheduleCalendarMonth, "", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", "", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1", "", time.Date(2025, 2, 3, 9, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", "", time.Date(2025, 1, 31, 15, 15, 0, 0, time.UTC)},
+		{"0 0 15 * 7", "", time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)}, // Day 15 or Sunday
+		{"0 12 29 2 *", "", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		budget := TableBudget{ResetSchedule: tt.schedule, ResetDuration: tt.duration, LastReset: lastReset}
+		if err := ValidateBudgetReset(tt.schedule, tt.duration); err != nil {
+			t.Fatalf("%q: expected a valid schedule, got %v", tt.schedule, err)
+		}
+		got, err := budget.NextReset()
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("%q: expected next reset %v, got %v (%v)", tt.schedule, tt.want, got, err)
+		}
+		if budget.ResetDue(tt.want.Add(-time.Second)) || !budget.ResetDue(tt.want) {
+			t.Errorf("%q: expected the reset to be due exactly at %v", tt.schedule, tt.want)
+		}
+	}
+
+	for _, schedule := range []string{"0 0 * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "weekly"} {
+		if err := ValidateBudgetReset(schedule, ""); err == nil {
+			t.Errorf("expected schedule %q to be rejected", schedule)
+		}
+	}
+	if err := ValidateBudgetReset("", "monthly"); err == nil {
+		t.Error("expected an invalid rolling duration to be rejected")
+	}
+}
+
+// TestBudgetResetRollover verifies that unused quota is carried over on reset, up to the rollover cap.
+func TestBudgetResetRollover(t *testing.T) {
+	now := time.Now()
+	budget := TableBudget{MaxLimit: 100, CurrentUsage: 30, AlertThreshold: 80}
+	budget.Reset(now)
+	if budget.CarriedOver != 0 || budget.CurrentUsage != 0 || budget.AlertThreshold != 0 || !budget.LastReset.Equal(now) {
+		t.Errorf("expected a plain reset without rollover, got %+v", budget)
+	}
+
+	budget = TableBudget{MaxLimit: 100, CurrentUsage: 30, Rollover: true}
+	budget.Reset(now)
+	if budget.CarriedOver != 70 || budget.EffectiveLimit() != 170 {
+		t.Errorf("expected 70 carried over, got %v", budget.CarriedOver)
+	}
+	// The carried quota can roll over again, up to the cap
+	rolloverCap := 50.0
+	budget.RolloverCap = &rolloverCap
+	budget.CurrentUsage = 10
+	budget.Reset(now)
+	if budget.CarriedOver != 50 {
+		t.Errorf("expected the rollover to be capped at 50, got %v", budget.CarriedOver)
+	}
+	budget.CurrentUsage = 200
+	budget.Reset(now)
+	if budget.CarriedOver != 0 {
+		t.Errorf("expected nothing carried over after overspending, got %v", budget.CarriedOver)
+	}
+}
@@ -47,6 +47,9 @@ type ProviderConfig struct {
 	ProxyConfig              *schemas.ProxyConfig              `json:"proxy_config,omitempty"`                // Proxy configuration
 	SendBackRawResponse      bool                              `json:"send_back_raw_response"`                // Include raw response in BifrostResponse
 	CustomProviderConfig     *schemas.CustomProviderConfig     `json:"custom_provider_config,omitempty"`      // Custom provider configuration
+	MockConfig               *schemas.MockProviderConfig       `json:"mock_config,omitempty"`                 // Mock provider responses
+	RequestShaping           *schemas.RequestShapingConfig     `json:"request_shaping,omitempty"`             // Parameter defaults and overrides of the requests
+	SyntheticStreaming       *schemas.SyntheticStreamingConfig `json:"synthetic_streaming,omitempty"`         // Synthesized streams of the completions the provider cannot stream
 }
 
 // ConfigMap maps provider names to their configurations.
@@ -55,6 +58,7 @@ type ConfigMap map[schemas.ModelProvider]ProviderConfig
 type GovernanceConfig struct {
 	VirtualKeys []TableVirtualKey `json:"virtual_keys"`
 	Teams       []TableTeam       `json:"teams"`
+	Projects    []TableProject    `json:"projects,omitempty"`
 	Customers   []TableCustomer   `json:"customers"`
 	Budgets     []TableBudget     `json:"budgets"`
 	RateLimits  []TableRateLimit  `json:"rate_limits"`
@@ -37,6 +37,39 @@ func triggerMigrations(ctx context.Context, db *gorm.DB) error {
 	if err := migrationTeamsTableUpdates(ctx, db); err != nil {
 		return err
 	}
+	if err := migrationAddMockConfigJSONColumn(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddBudgetScheduleColumns(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddProjectsAndRateLimitHierarchy(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddCacheCreationInputTokenCostColumn(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddInputCostPerQueryColumn(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddVirtualKeyParameterGuardrailsColumn(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddRequestShapingJSONColumn(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddSyntheticStreamingJSONColumn(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddGovernanceShadowColumns(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddUISessionsTable(ctx, db); err != nil {
+		return err
+	}
+	if err := migrationAddDeviceLoginTables(ctx, db); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -430,4 +463,294 @@ func migrationTeamsTableUpdates(ctx context.Context, db *gorm.DB) error {
 		return fmt.Errorf("error while running db migration: %s", err.Error())
 	}
 	return nil
-}
\ No newline at end of file
+}
+// migrationAddMockConfigJSONColumn adds the mock_config_json column to the provider table
+func migrationAddMockConfigJSONColumn(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addmockconfigjsoncolumn",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasColumn(&TableProvider{}, "mock_config_json") {
+				if err := migrator.AddColumn(&TableProvider{}, "mock_config_json"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddBudgetScheduleColumns adds the reset schedule, rollover and alert columns to the budget table
+func migrationAddBudgetScheduleColumns(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addbudgetschedulecolumns",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			for _, column := range []string{"reset_schedule", "rollover", "rollover_cap", "carried_over", "alert_threshold"} {
+				if !migrator.HasColumn(&TableBudget{}, column) {
+					if err := migrator.AddColumn(&TableBudget{}, column); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddProjectsAndRateLimitHierarchy adds the projects table, the project of virtual keys and the
+// rate limits of teams and customers
+func migrationAddProjectsAndRateLimitHierarchy(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addprojectsandratelimithierarchy",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&TableProject{}) {
+				if err := migrator.CreateTable(&TableProject{}); err != nil {
+					return err
+				}
+			}
+			if !migrator.HasColumn(&TableVirtualKey{}, "project_id") {
+				if err := migrator.AddColumn(&TableVirtualKey{}, "project_id"); err != nil {
+					return err
+				}
+			}
+			if !migrator.HasColumn(&TableTeam{}, "rate_limit_id") {
+				if err := migrator.AddColumn(&TableTeam{}, "rate_limit_id"); err != nil {
+					return err
+				}
+			}
+			if !migrator.HasColumn(&TableCustomer{}, "rate_limit_id") {
+				if err := migrator.AddColumn(&TableCustomer{}, "rate_limit_id"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if err := migrator.DropTable(&TableProject{}); err != nil {
+				return err
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddCacheCreationInputTokenCostColumn adds the price of the prompt tokens written to provider caches
+func migrationAddCacheCreationInputTokenCostColumn(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addcachecreationinputtokencostcolumn",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasColumn(&TableModelPricing{}, "cache_creation_input_token_cost") {
+				if err := migrator.AddColumn(&TableModelPricing{}, "cache_creation_input_token_cost"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddInputCostPerQueryColumn adds the price of the queries of rerank models
+func migrationAddInputCostPerQueryColumn(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addinputcostperquerycolumn",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasColumn(&TableModelPricing{}, "input_cost_per_query") {
+				if err := migrator.AddColumn(&TableModelPricing{}, "input_cost_per_query"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddVirtualKeyParameterGuardrailsColumn adds the parameter guardrails of virtual keys
+func migrationAddVirtualKeyParameterGuardrailsColumn(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addvirtualkeyparameterguardrailscolumn",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasColumn(&TableVirtualKey{}, "parameter_guardrails") {
+				if err := migrator.AddColumn(&TableVirtualKey{}, "parameter_guardrails"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+
+// migrationAddRequestShapingJSONColumn adds the request_shaping_json column to the provider table
+func migrationAddRequestShapingJSONColumn(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addrequestshapingjsoncolumn",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasColumn(&TableProvider{}, "request_shaping_json") {
+				if err := migrator.AddColumn(&TableProvider{}, "request_shaping_json"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddSyntheticStreamingJSONColumn adds the synthetic_streaming_json column to the provider table
+func migrationAddSyntheticStreamingJSONColumn(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addsyntheticstreamingjsoncolumn",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasColumn(&TableProvider{}, "synthetic_streaming_json") {
+				if err := migrator.AddColumn(&TableProvider{}, "synthetic_streaming_json"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddGovernanceShadowColumns adds the shadow column to the budget and rate limit tables
+func migrationAddGovernanceShadowColumns(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addgovernanceshadowcolumns",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasColumn(&TableBudget{}, "shadow") {
+				if err := migrator.AddColumn(&TableBudget{}, "shadow"); err != nil {
+					return err
+				}
+			}
+			if !migrator.HasColumn(&TableRateLimit{}, "shadow") {
+				if err := migrator.AddColumn(&TableRateLimit{}, "shadow"); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddUISessionsTable adds the table of the dashboard sessions
+func migrationAddUISessionsTable(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "adduisessionstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&TableUISession{}) {
+				if err := migrator.CreateTable(&TableUISession{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
+
+// migrationAddDeviceLoginTables adds the tables of the CLI device authorizations and tokens
+func migrationAddDeviceLoginTables(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "adddevicelogintables",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&TableDeviceAuthorization{}) {
+				if err := migrator.CreateTable(&TableDeviceAuthorization{}); err != nil {
+					return err
+				}
+			}
+			if !migrator.HasTable(&TableDeviceToken{}) {
+				if err := migrator.CreateTable(&TableDeviceToken{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	err := m.Migrate()
+	if err != nil {
+		return fmt.Errorf("error while running db migration: %s", err.Error())
+	}
+	return nil
+}
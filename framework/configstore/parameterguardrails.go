@@ -0,0 +1,59 @@
+package configstore
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Enforcement modes of parameter guardrails.
+const (
+	GuardrailModeHard   = "hard"   // Requests exceeding a limit are rejected (default)
+	GuardrailModeSoft   = "soft"   // Parameters exceeding a limit are clamped, tools removed and the response format replaced
+	GuardrailModeShadow = "shadow" // Requests the hard mode would reject are only recorded, and sent unchanged
+)
+
+// Response formats parameter guardrails can force.
+var guardrailResponseFormats = []string{"text", "json_object"}
+
+// ParameterGuardrails limit the inference parameters of the requests of a virtual key. Requests without a
+// max_tokens are capped at MaxTokens and requests without a response format get ResponseFormat, in the hard and soft
+// modes.
+type ParameterGuardrails struct {
+	Mode           string   `json:"mode,omitempty"`            // "hard" (default), "soft" or "shadow"
+	MaxTemperature *float64 `json:"max_temperature,omitempty"` // Highest temperature allowed
+	MaxTokens      *int     `json:"max_tokens,omitempty"`      // Highest max_tokens, max_completion_tokens or max_output_tokens allowed
+	DisallowTools  bool     `json:"disallow_tools,omitempty"`  // Forbid tools and tool_choice
+	ResponseFormat string   `json:"response_format,omitempty"` // Response format type every request must use: "text" or "json_object"
+}
+
+// Validate checks the mode and the limits.
+func (g *ParameterGuardrails) Validate() error {
+	if g.Mode != "" && g.Mode != GuardrailModeHard && g.Mode != GuardrailModeSoft && g.Mode != GuardrailModeShadow {
+		return fmt.Errorf("parameter_guardrails: mode must be %q, %q or %q", GuardrailModeHard, GuardrailModeSoft, GuardrailModeShadow)
+	}
+	if g.MaxTemperature != nil && *g.MaxTemperature < 0 {
+		return fmt.Errorf("parameter_guardrails: max_temperature cannot be negative")
+	}
+	if g.MaxTokens != nil && *g.MaxTokens <= 0 {
+		return fmt.Errorf("parameter_guardrails: max_tokens must be positive")
+	}
+	if g.ResponseFormat != "" && !slices.Contains(guardrailResponseFormats, g.ResponseFormat) {
+		return fmt.Errorf("parameter_guardrails: response_format must be one of %v", guardrailResponseFormats)
+	}
+	return nil
+}
+
+// IsZero reports whether the guardrails limit nothing.
+func (g *ParameterGuardrails) IsZero() bool {
+	return g == nil || (g.MaxTemperature == nil && g.MaxTokens == nil && !g.DisallowTools && g.ResponseFormat == "")
+}
+
+// IsSoft reports whether violations are clamped rather than rejected.
+func (g *ParameterGuardrails) IsSoft() bool {
+	return g.Mode == GuardrailModeSoft
+}
+
+// IsShadow reports whether violations are only recorded.
+func (g *ParameterGuardrails) IsShadow() bool {
+	return g != nil && g.Mode == GuardrailModeShadow
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/maximhq/bifrost/core/schemas"
 	"github.com/maximhq/bifrost/framework/configstore/migrator"
@@ -47,6 +48,15 @@ func (s *RDBConfigStore) DB() *gorm.DB {
 	return s.db
 }
 
+// Ping checks that the database is reachable.
+func (s *RDBConfigStore) Ping(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // GetClientConfig retrieves the client configuration from the database.
 func (s *RDBConfigStore) GetClientConfig(ctx context.Context) (*ClientConfig, error) {
 	var dbConfig TableClientConfig
@@ -89,6 +99,9 @@ func (s *RDBConfigStore) UpdateProvidersConfig(ctx context.Context, providers ma
 				ProxyConfig:              providerConfig.ProxyConfig,
 				SendBackRawResponse:      providerConfig.SendBackRawResponse,
 				CustomProviderConfig:     providerConfig.CustomProviderConfig,
+				MockConfig:               providerConfig.MockConfig,
+				RequestShaping:           providerConfig.RequestShaping,
+				SyntheticStreaming:       providerConfig.SyntheticStreaming,
 			}
 
 			// Create provider first
@@ -189,6 +202,9 @@ func (s *RDBConfigStore) UpdateProvider(ctx context.Context, provider schemas.Mo
 		dbProvider.ProxyConfig = configCopy.ProxyConfig
 		dbProvider.SendBackRawResponse = configCopy.SendBackRawResponse
 		dbProvider.CustomProviderConfig = configCopy.CustomProviderConfig
+		dbProvider.MockConfig = configCopy.MockConfig
+		dbProvider.RequestShaping = configCopy.RequestShaping
+		dbProvider.SyntheticStreaming = configCopy.SyntheticStreaming
 
 		// Save the updated provider
 		if err := tx.WithContext(ctx).Save(&dbProvider).Error; err != nil {
@@ -298,6 +314,9 @@ func (s *RDBConfigStore) AddProvider(ctx context.Context, provider schemas.Model
 			ProxyConfig:              configCopy.ProxyConfig,
 			SendBackRawResponse:      configCopy.SendBackRawResponse,
 			CustomProviderConfig:     configCopy.CustomProviderConfig,
+			MockConfig:               configCopy.MockConfig,
+			RequestShaping:           configCopy.RequestShaping,
+			SyntheticStreaming:       configCopy.SyntheticStreaming,
 		}
 
 		// Create the provider
@@ -474,6 +493,9 @@ func (s *RDBConfigStore) GetProvidersConfig(ctx context.Context) (map[schemas.Mo
 			ProxyConfig:              dbProvider.ProxyConfig,
 			SendBackRawResponse:      dbProvider.SendBackRawResponse,
 			CustomProviderConfig:     dbProvider.CustomProviderConfig,
+			MockConfig:               dbProvider.MockConfig,
+			RequestShaping:           dbProvider.RequestShaping,
+			SyntheticStreaming:       dbProvider.SyntheticStreaming,
 		}
 		processedProviders[provider] = providerConfig
 	}
@@ -822,7 +844,8 @@ func (s *RDBConfigStore) GetVirtualKeys(ctx context.Context) ([]TableVirtualKey,
 	var virtualKeys []TableVirtualKey
 
 	// Preload all relationships for complete information
-	if err := s.db.WithContext(ctx).Preload("Team").
+	if err := s.db.WithContext(ctx).Preload("Project").
+		Preload("Team").
 		Preload("Customer").
 		Preload("Budget").
 		Preload("RateLimit").
@@ -839,7 +862,8 @@ func (s *RDBConfigStore) GetVirtualKeys(ctx context.Context) ([]TableVirtualKey,
 // GetVirtualKey retrieves a virtual key from the database.
 func (s *RDBConfigStore) GetVirtualKey(ctx context.Context, id string) (*TableVirtualKey, error) {
 	var virtualKey TableVirtualKey
-	if err := s.db.WithContext(ctx).Preload("Team").
+	if err := s.db.WithContext(ctx).Preload("Project").
+		Preload("Team").
 		Preload("Customer").
 		Preload("Budget").
 		Preload("RateLimit").
@@ -978,7 +1002,8 @@ func (s *RDBConfigStore) DeleteVirtualKeyProviderConfig(ctx context.Context, id
 // GetVirtualKeyByValue retrieves a virtual key by its value
 func (s *RDBConfigStore) GetVirtualKeyByValue(ctx context.Context, value string) (*TableVirtualKey, error) {
 	var virtualKey TableVirtualKey
-	if err := s.db.WithContext(ctx).Preload("Team").
+	if err := s.db.WithContext(ctx).Preload("Project").
+		Preload("Team").
 		Preload("Customer").
 		Preload("Budget").
 		Preload("RateLimit").
@@ -994,7 +1019,7 @@ func (s *RDBConfigStore) GetVirtualKeyByValue(ctx context.Context, value string)
 // GetTeams retrieves all teams from the database.
 func (s *RDBConfigStore) GetTeams(ctx context.Context, customerID string) ([]TableTeam, error) {
 	// Preload relationships for complete information
-	query := s.db.WithContext(ctx).Preload("Customer").Preload("Budget")
+	query := s.db.WithContext(ctx).Preload("Customer").Preload("Budget").Preload("RateLimit")
 
 	// Optional filtering by customer
 	if customerID != "" {
@@ -1011,7 +1036,7 @@ func (s *RDBConfigStore) GetTeams(ctx context.Context, customerID string) ([]Tab
 // GetTeam retrieves a specific team from the database.
 func (s *RDBConfigStore) GetTeam(ctx context.Context, id string) (*TableTeam, error) {
 	var team TableTeam
-	if err := s.db.WithContext(ctx).Preload("Customer").Preload("Budget").First(&team, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Customer").Preload("Budget").Preload("RateLimit").Preload("Projects").First(&team, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &team, nil
@@ -1044,10 +1069,63 @@ func (s *RDBConfigStore) DeleteTeam(ctx context.Context, id string) error {
 	return s.db.WithContext(ctx).Delete(&TableTeam{}, "id = ?", id).Error
 }
 
+// GetProjects retrieves all projects from the database.
+func (s *RDBConfigStore) GetProjects(ctx context.Context, teamID string) ([]TableProject, error) {
+	// Preload relationships for complete information
+	query := s.db.WithContext(ctx).Preload("Team").Preload("Budget").Preload("RateLimit")
+
+	// Optional filtering by team
+	if teamID != "" {
+		query = query.Where("team_id = ?", teamID)
+	}
+
+	var projects []TableProject
+	if err := query.Find(&projects).Error; err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetProject retrieves a specific project from the database.
+func (s *RDBConfigStore) GetProject(ctx context.Context, id string) (*TableProject, error) {
+	var project TableProject
+	if err := s.db.WithContext(ctx).Preload("Team").Preload("Budget").Preload("RateLimit").First(&project, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// CreateProject creates a new project in the database.
+func (s *RDBConfigStore) CreateProject(ctx context.Context, project *TableProject, tx ...*gorm.DB) error {
+	var txDB *gorm.DB
+	if len(tx) > 0 {
+		txDB = tx[0]
+	} else {
+		txDB = s.db
+	}
+	return txDB.WithContext(ctx).Create(project).Error
+}
+
+// UpdateProject updates an existing project in the database.
+func (s *RDBConfigStore) UpdateProject(ctx context.Context, project *TableProject, tx ...*gorm.DB) error {
+	var txDB *gorm.DB
+	if len(tx) > 0 {
+		txDB = tx[0]
+	} else {
+		txDB = s.db
+	}
+	return txDB.WithContext(ctx).Save(project).Error
+}
+
+// DeleteProject deletes a project from the database.
+func (s *RDBConfigStore) DeleteProject(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Delete(&TableProject{}, "id = ?", id).Error
+}
+
 // GetCustomers retrieves all customers from the database.
 func (s *RDBConfigStore) GetCustomers(ctx context.Context) ([]TableCustomer, error) {
 	var customers []TableCustomer
-	if err := s.db.WithContext(ctx).Preload("Teams").Preload("Budget").Find(&customers).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Teams").Preload("Budget").Preload("RateLimit").Find(&customers).Error; err != nil {
 		return nil, err
 	}
 	return customers, nil
@@ -1056,7 +1134,7 @@ func (s *RDBConfigStore) GetCustomers(ctx context.Context) ([]TableCustomer, err
 // GetCustomer retrieves a specific customer from the database.
 func (s *RDBConfigStore) GetCustomer(ctx context.Context, id string) (*TableCustomer, error) {
 	var customer TableCustomer
-	if err := s.db.WithContext(ctx).Preload("Teams").Preload("Budget").First(&customer, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Teams").Preload("Budget").Preload("RateLimit").First(&customer, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &customer, nil
@@ -1209,6 +1287,7 @@ func (s *RDBConfigStore) UpdateBudget(ctx context.Context, budget *TableBudget,
 func (s *RDBConfigStore) GetGovernanceConfig(ctx context.Context) (*GovernanceConfig, error) {
 	var virtualKeys []TableVirtualKey
 	var teams []TableTeam
+	var projects []TableProject
 	var customers []TableCustomer
 	var budgets []TableBudget
 	var rateLimits []TableRateLimit
@@ -1219,6 +1298,9 @@ func (s *RDBConfigStore) GetGovernanceConfig(ctx context.Context) (*GovernanceCo
 	if err := s.db.WithContext(ctx).Find(&teams).Error; err != nil {
 		return nil, err
 	}
+	if err := s.db.WithContext(ctx).Find(&projects).Error; err != nil {
+		return nil, err
+	}
 	if err := s.db.WithContext(ctx).Find(&customers).Error; err != nil {
 		return nil, err
 	}
@@ -1229,13 +1311,14 @@ func (s *RDBConfigStore) GetGovernanceConfig(ctx context.Context) (*GovernanceCo
 		return nil, err
 	}
 
-	if len(virtualKeys) == 0 && len(teams) == 0 && len(customers) == 0 && len(budgets) == 0 && len(rateLimits) == 0 {
+	if len(virtualKeys) == 0 && len(teams) == 0 && len(projects) == 0 && len(customers) == 0 && len(budgets) == 0 && len(rateLimits) == 0 {
 		return nil, nil
 	}
 
 	return &GovernanceConfig{
 		VirtualKeys: virtualKeys,
 		Teams:       teams,
+		Projects:    projects,
 		Customers:   customers,
 		Budgets:     budgets,
 		RateLimits:  rateLimits,
@@ -1289,6 +1372,144 @@ func (s *RDBConfigStore) removeDuplicateKeysAndNullKeys(ctx context.Context) err
 	return nil
 }
 
+// CreateUISession creates a dashboard session in the database.
+func (s *RDBConfigStore) CreateUISession(ctx context.Context, session *TableUISession) error {
+	return s.db.WithContext(ctx).Create(session).Error
+}
+
+// GetUISession retrieves a dashboard session by ID from the database.
+func (s *RDBConfigStore) GetUISession(ctx context.Context, id string) (*TableUISession, error) {
+	var session TableUISession
+	if err := s.db.WithContext(ctx).First(&session, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetUISessionByToken retrieves a dashboard session by its internal token from the database.
+func (s *RDBConfigStore) GetUISessionByToken(ctx context.Context, token string) (*TableUISession, error) {
+	var session TableUISession
+	if err := s.db.WithContext(ctx).First(&session, "token = ?", token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteUISession deletes a dashboard session from the database.
+func (s *RDBConfigStore) DeleteUISession(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Delete(&TableUISession{}, "id = ?", id).Error
+}
+
+// DeleteUISessions deletes every dashboard session from the database.
+func (s *RDBConfigStore) DeleteUISessions(ctx context.Context) error {
+	return s.db.WithContext(ctx).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&TableUISession{}).Error
+}
+
+// DeleteExpiredUISessions deletes the dashboard sessions expired at now from the database.
+func (s *RDBConfigStore) DeleteExpiredUISessions(ctx context.Context, now time.Time) error {
+	return s.db.WithContext(ctx).Delete(&TableUISession{}, "expires_at <= ?", now).Error
+}
+
+// CreateDeviceAuthorization creates a device authorization in the database.
+func (s *RDBConfigStore) CreateDeviceAuthorization(ctx context.Context, authorization *TableDeviceAuthorization) error {
+	return s.db.WithContext(ctx).Create(authorization).Error
+}
+
+// GetDeviceAuthorization retrieves a device authorization by the hash of its device code from the database.
+func (s *RDBConfigStore) GetDeviceAuthorization(ctx context.Context, deviceCodeHash string) (*TableDeviceAuthorization, error) {
+	return s.firstDeviceAuthorization(ctx, "device_code_hash = ?", deviceCodeHash)
+}
+
+// GetDeviceAuthorizationByUserCode retrieves a device authorization by its user code from the database.
+func (s *RDBConfigStore) GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (*TableDeviceAuthorization, error) {
+	return s.firstDeviceAuthorization(ctx, "user_code = ?", userCode)
+}
+
+// firstDeviceAuthorization retrieves the device authorization matching a condition from the database.
+func (s *RDBConfigStore) firstDeviceAuthorization(ctx context.Context, query string, arg any) (*TableDeviceAuthorization, error) {
+	var authorization TableDeviceAuthorization
+	if err := s.db.WithContext(ctx).Where(query, arg).First(&authorization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &authorization, nil
+}
+
+// UpdateDeviceAuthorization updates a device authorization in the database, provided it is still in status. It
+// reports whether the authorization was updated, so that concurrent decisions on a code cannot both succeed.
+func (s *RDBConfigStore) UpdateDeviceAuthorization(ctx context.Context, authorization *TableDeviceAuthorization, status string) (bool, error) {
+	result := s.db.WithContext(ctx).Model(authorization).Where("status = ?", status).Select("*").Omit("created_at").Updates(authorization)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteDeviceAuthorization deletes a device authorization from the database. It reports whether the authorization
+// was deleted, so that a device code is only spent once.
+func (s *RDBConfigStore) DeleteDeviceAuthorization(ctx context.Context, deviceCodeHash string) (bool, error) {
+	result := s.db.WithContext(ctx).Delete(&TableDeviceAuthorization{}, "device_code_hash = ?", deviceCodeHash)
+	return result.RowsAffected > 0, result.Error
+}
+
+// CreateDeviceToken creates a device token in the database.
+func (s *RDBConfigStore) CreateDeviceToken(ctx context.Context, token *TableDeviceToken) error {
+	return s.db.WithContext(ctx).Create(token).Error
+}
+
+// GetDeviceToken retrieves a device token by the hash of its access token from the database.
+func (s *RDBConfigStore) GetDeviceToken(ctx context.Context, accessTokenHash string) (*TableDeviceToken, error) {
+	return s.firstDeviceToken(ctx, "access_token_hash = ?", accessTokenHash)
+}
+
+// GetDeviceTokenByRefreshToken retrieves a device token by the hash of its refresh token from the database.
+func (s *RDBConfigStore) GetDeviceTokenByRefreshToken(ctx context.Context, refreshTokenHash string) (*TableDeviceToken, error) {
+	return s.firstDeviceToken(ctx, "refresh_token_hash = ?", refreshTokenHash)
+}
+
+// firstDeviceToken retrieves the device token matching a condition from the database.
+func (s *RDBConfigStore) firstDeviceToken(ctx context.Context, query string, arg any) (*TableDeviceToken, error) {
+	var token TableDeviceToken
+	if err := s.db.WithContext(ctx).Where(query, arg).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteDeviceToken deletes a device token from the database. It reports whether the token was deleted, so that a
+// refresh token is only spent once.
+func (s *RDBConfigStore) DeleteDeviceToken(ctx context.Context, accessTokenHash string) (bool, error) {
+	result := s.db.WithContext(ctx).Delete(&TableDeviceToken{}, "access_token_hash = ?", accessTokenHash)
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteDeviceLogins deletes every device authorization and token from the database.
+func (s *RDBConfigStore) DeleteDeviceLogins(ctx context.Context) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&TableDeviceAuthorization{}).Error; err != nil {
+			return err
+		}
+		return tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&TableDeviceToken{}).Error
+	})
+}
+
+// DeleteExpiredDeviceLogins deletes the device authorizations and the device tokens whose refresh token expired at
+// now from the database.
+func (s *RDBConfigStore) DeleteExpiredDeviceLogins(ctx context.Context, now time.Time) error {
+	if err := s.db.WithContext(ctx).Delete(&TableDeviceAuthorization{}, "expires_at <= ?", now).Error; err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Delete(&TableDeviceToken{}, "refresh_expires_at <= ?", now).Error
+}
+
 // RunMigration runs a migration.
 func (s *RDBConfigStore) RunMigration(ctx context.Context, migration *migrator.Migration) error {
 	if migration == nil {
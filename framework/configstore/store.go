@@ -4,6 +4,7 @@ package configstore
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/maximhq/bifrost/core/schemas"
 	"github.com/maximhq/bifrost/framework/configstore/migrator"
@@ -74,6 +75,13 @@ type ConfigStore interface {
 	UpdateTeam(ctx context.Context, team *TableTeam, tx ...*gorm.DB) error
 	DeleteTeam(ctx context.Context, id string) error
 
+	// Project CRUD
+	GetProjects(ctx context.Context, teamID string) ([]TableProject, error)
+	GetProject(ctx context.Context, id string) (*TableProject, error)
+	CreateProject(ctx context.Context, project *TableProject, tx ...*gorm.DB) error
+	UpdateProject(ctx context.Context, project *TableProject, tx ...*gorm.DB) error
+	DeleteProject(ctx context.Context, id string) error
+
 	// Customer CRUD
 	GetCustomers(ctx context.Context) ([]TableCustomer, error)
 	GetCustomer(ctx context.Context, id string) (*TableCustomer, error)
@@ -104,6 +112,27 @@ type ConfigStore interface {
 	// Key management
 	GetKeysByIDs(ctx context.Context, ids []string) ([]TableKey, error)
 
+	// UI session CRUD
+	CreateUISession(ctx context.Context, session *TableUISession) error
+	GetUISession(ctx context.Context, id string) (*TableUISession, error)
+	GetUISessionByToken(ctx context.Context, token string) (*TableUISession, error)
+	DeleteUISession(ctx context.Context, id string) error
+	DeleteUISessions(ctx context.Context) error
+	DeleteExpiredUISessions(ctx context.Context, now time.Time) error
+
+	// Device login CRUD
+	CreateDeviceAuthorization(ctx context.Context, authorization *TableDeviceAuthorization) error
+	GetDeviceAuthorization(ctx context.Context, deviceCodeHash string) (*TableDeviceAuthorization, error)
+	GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (*TableDeviceAuthorization, error)
+	UpdateDeviceAuthorization(ctx context.Context, authorization *TableDeviceAuthorization, status string) (bool, error)
+	DeleteDeviceAuthorization(ctx context.Context, deviceCodeHash string) (bool, error)
+	CreateDeviceToken(ctx context.Context, token *TableDeviceToken) error
+	GetDeviceToken(ctx context.Context, accessTokenHash string) (*TableDeviceToken, error)
+	GetDeviceTokenByRefreshToken(ctx context.Context, refreshTokenHash string) (*TableDeviceToken, error)
+	DeleteDeviceToken(ctx context.Context, accessTokenHash string) (bool, error)
+	DeleteDeviceLogins(ctx context.Context) error
+	DeleteExpiredDeviceLogins(ctx context.Context, now time.Time) error
+
 	// Generic transaction manager
 	ExecuteTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error
 
@@ -113,6 +142,9 @@ type ConfigStore interface {
 	// Migration manager
 	RunMigration(ctx context.Context, migration *migrator.Migration) error
 
+	// Ping checks that the underlying database is reachable.
+	Ping(ctx context.Context) error
+
 	// Cleanup
 	Close(ctx context.Context) error
 }
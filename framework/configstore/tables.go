@@ -27,6 +27,9 @@ type TableProvider struct {
 	ConcurrencyBufferJSON    string    `gorm:"type:text" json:"-"`                                // JSON serialized schemas.ConcurrencyAndBufferSize
 	ProxyConfigJSON          string    `gorm:"type:text" json:"-"`                                // JSON serialized schemas.ProxyConfig
 	CustomProviderConfigJSON string    `gorm:"type:text" json:"-"`                                // JSON serialized schemas.CustomProviderConfig
+	MockConfigJSON           string    `gorm:"type:text" json:"-"`                                // JSON serialized schemas.MockProviderConfig
+	RequestShapingJSON       string    `gorm:"type:text" json:"-"`                                // JSON serialized schemas.RequestShapingConfig
+	SyntheticStreamingJSON   string    `gorm:"type:text" json:"-"`                                // JSON serialized schemas.SyntheticStreamingConfig
 	SendBackRawResponse      bool      `json:"send_back_raw_response"`
 	CreatedAt                time.Time `gorm:"index;not null" json:"created_at"`
 	UpdatedAt                time.Time `gorm:"index;not null" json:"updated_at"`
@@ -42,6 +45,15 @@ type TableProvider struct {
 	// Custom provider fields
 	CustomProviderConfig *schemas.CustomProviderConfig `gorm:"-" json:"custom_provider_config,omitempty"`
 
+	// Mock provider fields
+	MockConfig *schemas.MockProviderConfig `gorm:"-" json:"mock_config,omitempty"`
+
+	// Request shaping fields
+	RequestShaping *schemas.RequestShapingConfig `gorm:"-" json:"request_shaping,omitempty"`
+
+	// Synthetic streaming fields
+	SyntheticStreaming *schemas.SyntheticStreamingConfig `gorm:"-" json:"synthetic_streaming,omitempty"`
+
 	// Foreign keys
 	Models []TableModel `gorm:"foreignKey:ProviderID;constraint:OnDelete:CASCADE" json:"models"`
 }
@@ -237,6 +249,36 @@ func (p *TableProvider) BeforeSave(tx *gorm.DB) error {
 		p.CustomProviderConfigJSON = string(data)
 	}
 
+	if p.MockConfig != nil {
+		data, err := json.Marshal(p.MockConfig)
+		if err != nil {
+			return err
+		}
+		p.MockConfigJSON = string(data)
+	} else {
+		p.MockConfigJSON = ""
+	}
+
+	if p.RequestShaping != nil {
+		data, err := json.Marshal(p.RequestShaping)
+		if err != nil {
+			return err
+		}
+		p.RequestShapingJSON = string(data)
+	} else {
+		p.RequestShapingJSON = ""
+	}
+
+	if p.SyntheticStreaming != nil {
+		data, err := json.Marshal(p.SyntheticStreaming)
+		if err != nil {
+			return err
+		}
+		p.SyntheticStreamingJSON = string(data)
+	} else {
+		p.SyntheticStreamingJSON = ""
+	}
+
 	return nil
 }
 
@@ -429,6 +471,30 @@ func (p *TableProvider) AfterFind(tx *gorm.DB) error {
 		p.CustomProviderConfig = &customConfig
 	}
 
+	if p.MockConfigJSON != "" {
+		var mockConfig schemas.MockProviderConfig
+		if err := json.Unmarshal([]byte(p.MockConfigJSON), &mockConfig); err != nil {
+			return err
+		}
+		p.MockConfig = &mockConfig
+	}
+
+	if p.RequestShapingJSON != "" {
+		var requestShaping schemas.RequestShapingConfig
+		if err := json.Unmarshal([]byte(p.RequestShapingJSON), &requestShaping); err != nil {
+			return err
+		}
+		p.RequestShaping = &requestShaping
+	}
+
+	if p.SyntheticStreamingJSON != "" {
+		var syntheticStreaming schemas.SyntheticStreamingConfig
+		if err := json.Unmarshal([]byte(p.SyntheticStreamingJSON), &syntheticStreaming); err != nil {
+			return err
+		}
+		p.SyntheticStreaming = &syntheticStreaming
+	}
+
 	return nil
 }
 
@@ -570,6 +636,124 @@ type TableConfig struct {
 	Value string `gorm:"type:text" json:"value"`
 }
 
+// TableUISession represents a dashboard session started by a login. Sessions live in the database so that every
+// replica accepts them and they survive restarts.
+type TableUISession struct {
+	ID         string    `gorm:"primaryKey;type:varchar(64)" json:"id"`
+	Token      string    `gorm:"type:varchar(128);uniqueIndex;not null" json:"-"`
+	Tenant     string    `gorm:"type:varchar(255)" json:"tenant"`
+	ScopesJSON string    `gorm:"type:text" json:"-"` // JSON serialized []string
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+	ExpiresAt  time.Time `gorm:"index;not null" json:"expires_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Scopes []string `gorm:"-" json:"scopes,omitempty"`
+}
+
+// TableName sets the table name for the dashboard sessions
+func (TableUISession) TableName() string { return "config_ui_sessions" }
+
+// BeforeSave hook for TableUISession to serialize the scopes
+func (s *TableUISession) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(s.Scopes)
+	if err != nil {
+		return err
+	}
+	s.ScopesJSON = string(data)
+	return nil
+}
+
+// AfterFind hook for TableUISession to deserialize the scopes
+func (s *TableUISession) AfterFind(tx *gorm.DB) error {
+	s.Scopes = nil
+	if s.ScopesJSON != "" {
+		if err := json.Unmarshal([]byte(s.ScopesJSON), &s.Scopes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TableDeviceAuthorization represents a CLI device login waiting for a user to confirm its code. The device code is
+// only stored hashed.
+type TableDeviceAuthorization struct {
+	DeviceCodeHash string        `gorm:"primaryKey;type:varchar(64)" json:"-"`
+	UserCode       string        `gorm:"type:varchar(16);uniqueIndex;not null" json:"user_code"`
+	ClientName     string        `gorm:"type:varchar(255)" json:"client_name"`
+	ScopesJSON     string        `gorm:"type:text" json:"-"` // JSON serialized []string
+	Status         string        `gorm:"type:varchar(16);not null" json:"status"`
+	Interval       time.Duration `gorm:"not null" json:"interval"`
+	LastPolledAt   *time.Time    `json:"last_polled_at,omitempty"`
+	CreatedAt      time.Time     `gorm:"not null" json:"created_at"`
+	ExpiresAt      time.Time     `gorm:"index;not null" json:"expires_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Scopes []string `gorm:"-" json:"scopes,omitempty"`
+}
+
+// TableDeviceToken represents the access and refresh tokens of a confirmed CLI device login. Both tokens are only
+// stored hashed.
+type TableDeviceToken struct {
+	AccessTokenHash  string    `gorm:"primaryKey;type:varchar(64)" json:"-"`
+	RefreshTokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ClientName       string    `gorm:"type:varchar(255)" json:"client_name"`
+	ScopesJSON       string    `gorm:"type:text" json:"-"` // JSON serialized []string
+	CreatedAt        time.Time `gorm:"not null" json:"created_at"`
+	ExpiresAt        time.Time `gorm:"not null" json:"expires_at"`
+	RefreshExpiresAt time.Time `gorm:"index;not null" json:"refresh_expires_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Scopes []string `gorm:"-" json:"scopes,omitempty"`
+}
+
+// TableName sets the table name for the device authorizations
+func (TableDeviceAuthorization) TableName() string { return "config_device_authorizations" }
+
+// TableName sets the table name for the device tokens
+func (TableDeviceToken) TableName() string { return "config_device_tokens" }
+
+// BeforeSave hook for TableDeviceAuthorization to serialize the scopes
+func (a *TableDeviceAuthorization) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(a.Scopes)
+	if err != nil {
+		return err
+	}
+	a.ScopesJSON = string(data)
+	return nil
+}
+
+// AfterFind hook for TableDeviceAuthorization to deserialize the scopes
+func (a *TableDeviceAuthorization) AfterFind(tx *gorm.DB) error {
+	a.Scopes = nil
+	if a.ScopesJSON != "" {
+		if err := json.Unmarshal([]byte(a.ScopesJSON), &a.Scopes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BeforeSave hook for TableDeviceToken to serialize the scopes
+func (t *TableDeviceToken) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(t.Scopes)
+	if err != nil {
+		return err
+	}
+	t.ScopesJSON = string(data)
+	return nil
+}
+
+// AfterFind hook for TableDeviceToken to deserialize the scopes
+func (t *TableDeviceToken) AfterFind(tx *gorm.DB) error {
+	t.Scopes = nil
+	if t.ScopesJSON != "" {
+		if err := json.Unmarshal([]byte(t.ScopesJSON), &t.Scopes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GOVERNANCE TABLES
 
 // TableBudget defines spending limits with configurable reset periods
@@ -580,6 +764,13 @@ type TableBudget struct {
 	LastReset     time.Time `gorm:"index" json:"last_reset"`                         // Last time budget was reset
 	CurrentUsage  float64   `gorm:"default:0" json:"current_usage"`                  // Current usage in dollars
 
+	ResetSchedule  string   `gorm:"type:varchar(100)" json:"reset_schedule,omitempty"` // rolling (default, every reset_duration), calendar_month or a cron expression in UTC
+	Rollover       bool     `gorm:"default:false" json:"rollover,omitempty"`           // Carry the unused quota of a period over to the next one
+	RolloverCap    *float64 `json:"rollover_cap,omitempty"`                            // Maximum quota carried over in dollars (defaults to max_limit)
+	CarriedOver    float64  `gorm:"default:0" json:"carried_over"`                     // Quota carried over into the current period
+	AlertThreshold int      `gorm:"default:0" json:"alert_threshold"`                  // Highest consumption alert (percent) sent in the current period
+	Shadow         bool     `gorm:"default:false" json:"shadow,omitempty"`             // Only record the requests the budget would block, without blocking them
+
 	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
 	UpdatedAt time.Time `gorm:"index;not null" json:"updated_at"`
 }
@@ -600,18 +791,23 @@ type TableRateLimit struct {
 	RequestCurrentUsage  int64     `gorm:"default:0" json:"request_current_usage"`                   // Current request usage
 	RequestLastReset     time.Time `gorm:"index" json:"request_last_reset"`                          // Last time request counter was reset
 
+	Shadow bool `gorm:"default:false" json:"shadow,omitempty"` // Only record the requests the rate limit would block, without blocking them
+
 	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
 	UpdatedAt time.Time `gorm:"index;not null" json:"updated_at"`
 }
 
-// TableCustomer represents a customer entity with budget
+// TableCustomer represents a customer entity with budget and rate limit, the organization at the top of
+// the governance hierarchy (customer → team → project → virtual key)
 type TableCustomer struct {
-	ID       string  `gorm:"primaryKey;type:varchar(255)" json:"id"`
-	Name     string  `gorm:"type:varchar(255);not null" json:"name"`
-	BudgetID *string `gorm:"type:varchar(255);index" json:"budget_id,omitempty"`
+	ID          string  `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	Name        string  `gorm:"type:varchar(255);not null" json:"name"`
+	BudgetID    *string `gorm:"type:varchar(255);index" json:"budget_id,omitempty"`
+	RateLimitID *string `gorm:"type:varchar(255);index" json:"rate_limit_id,omitempty"`
 
 	// Relationships
 	Budget      *TableBudget      `gorm:"foreignKey:BudgetID" json:"budget,omitempty"`
+	RateLimit   *TableRateLimit   `gorm:"foreignKey:RateLimitID" json:"rate_limit,omitempty"`
 	Teams       []TableTeam       `gorm:"foreignKey:CustomerID" json:"teams"`
 	VirtualKeys []TableVirtualKey `gorm:"foreignKey:CustomerID" json:"virtual_keys"`
 
@@ -619,16 +815,19 @@ type TableCustomer struct {
 	UpdatedAt time.Time `gorm:"index;not null" json:"updated_at"`
 }
 
-// TableTeam represents a team entity with budget and customer association
+// TableTeam represents a team entity with budget, rate limit and customer association
 type TableTeam struct {
-	ID         string  `gorm:"primaryKey;type:varchar(255)" json:"id"`
-	Name       string  `gorm:"type:varchar(255);not null" json:"name"`
-	CustomerID *string `gorm:"type:varchar(255);index" json:"customer_id,omitempty"` // A team can belong to a customer
-	BudgetID   *string `gorm:"type:varchar(255);index" json:"budget_id,omitempty"`
+	ID          string  `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	Name        string  `gorm:"type:varchar(255);not null" json:"name"`
+	CustomerID  *string `gorm:"type:varchar(255);index" json:"customer_id,omitempty"` // A team can belong to a customer
+	BudgetID    *string `gorm:"type:varchar(255);index" json:"budget_id,omitempty"`
+	RateLimitID *string `gorm:"type:varchar(255);index" json:"rate_limit_id,omitempty"`
 
 	// Relationships
 	Customer    *TableCustomer    `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
 	Budget      *TableBudget      `gorm:"foreignKey:BudgetID" json:"budget,omitempty"`
+	RateLimit   *TableRateLimit   `gorm:"foreignKey:RateLimitID" json:"rate_limit,omitempty"`
+	Projects    []TableProject    `gorm:"foreignKey:TeamID" json:"projects,omitempty"`
 	VirtualKeys []TableVirtualKey `gorm:"foreignKey:TeamID" json:"virtual_keys"`
 
 	Profile *string `gorm:"type:text" json:"-"`
@@ -698,7 +897,25 @@ func (t *TableTeam) AfterFind(tx *gorm.DB) error {
 }
 
 
-// TableVirtualKey represents a virtual key with budget, rate limits, and team/customer association
+// TableProject represents a project of a team, grouping virtual keys under a budget and rate limit
+type TableProject struct {
+	ID          string  `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	Name        string  `gorm:"type:varchar(255);not null" json:"name"`
+	TeamID      *string `gorm:"type:varchar(255);index" json:"team_id,omitempty"` // A project can belong to a team
+	BudgetID    *string `gorm:"type:varchar(255);index" json:"budget_id,omitempty"`
+	RateLimitID *string `gorm:"type:varchar(255);index" json:"rate_limit_id,omitempty"`
+
+	// Relationships
+	Team        *TableTeam        `gorm:"foreignKey:TeamID" json:"team,omitempty"`
+	Budget      *TableBudget      `gorm:"foreignKey:BudgetID" json:"budget,omitempty"`
+	RateLimit   *TableRateLimit   `gorm:"foreignKey:RateLimitID" json:"rate_limit,omitempty"`
+	VirtualKeys []TableVirtualKey `gorm:"foreignKey:ProjectID" json:"virtual_keys"`
+
+	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
+	UpdatedAt time.Time `gorm:"index;not null" json:"updated_at"`
+}
+
+// TableVirtualKey represents a virtual key with budget, rate limits, and project/team/customer association
 type TableVirtualKey struct {
 	ID              string                          `gorm:"primaryKey;type:varchar(255)" json:"id"`
 	Name            string                          `gorm:"uniqueIndex:idx_virtual_key_name;type:varchar(255);not null" json:"name"`
@@ -707,7 +924,11 @@ type TableVirtualKey struct {
 	IsActive        bool                            `gorm:"default:true" json:"is_active"`
 	ProviderConfigs []TableVirtualKeyProviderConfig `gorm:"foreignKey:VirtualKeyID;constraint:OnDelete:CASCADE" json:"provider_configs"` // Empty means all providers allowed
 
-	// Foreign key relationships (mutually exclusive: either TeamID or CustomerID, not both)
+	// Limits on the inference parameters of the requests of the key (optional)
+	ParameterGuardrails *ParameterGuardrails `gorm:"type:text;serializer:json" json:"parameter_guardrails,omitempty"`
+
+	// Foreign key relationships (mutually exclusive: one of ProjectID, TeamID or CustomerID)
+	ProjectID   *string    `gorm:"type:varchar(255);index" json:"project_id,omitempty"`
 	TeamID      *string    `gorm:"type:varchar(255);index" json:"team_id,omitempty"`
 	CustomerID  *string    `gorm:"type:varchar(255);index" json:"customer_id,omitempty"`
 	BudgetID    *string    `gorm:"type:varchar(255);index" json:"budget_id,omitempty"`
@@ -715,6 +936,7 @@ type TableVirtualKey struct {
 	Keys        []TableKey `gorm:"many2many:governance_virtual_key_keys;constraint:OnDelete:CASCADE" json:"keys"`
 
 	// Relationships
+	Project   *TableProject   `gorm:"foreignKey:ProjectID" json:"project,omitempty"`
 	Team      *TableTeam      `gorm:"foreignKey:TeamID" json:"team,omitempty"`
 	Customer  *TableCustomer  `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
 	Budget    *TableBudget    `gorm:"foreignKey:BudgetID" json:"budget,omitempty"`
@@ -761,9 +983,13 @@ type TableModelPricing struct {
 	OutputCostPerCharacterAbove128kTokens     *float64 `gorm:"default:null" json:"output_cost_per_character_above_128k_tokens,omitempty"`
 
 	// Cache and batch pricing
-	CacheReadInputTokenCost   *float64 `gorm:"default:null" json:"cache_read_input_token_cost,omitempty"`
-	InputCostPerTokenBatches  *float64 `gorm:"default:null" json:"input_cost_per_token_batches,omitempty"`
-	OutputCostPerTokenBatches *float64 `gorm:"default:null" json:"output_cost_per_token_batches,omitempty"`
+	CacheReadInputTokenCost     *float64 `gorm:"default:null" json:"cache_read_input_token_cost,omitempty"`
+	CacheCreationInputTokenCost *float64 `gorm:"default:null" json:"cache_creation_input_token_cost,omitempty"`
+	InputCostPerTokenBatches    *float64 `gorm:"default:null" json:"input_cost_per_token_batches,omitempty"`
+	OutputCostPerTokenBatches   *float64 `gorm:"default:null" json:"output_cost_per_token_batches,omitempty"`
+
+	// Rerank pricing
+	InputCostPerQuery *float64 `gorm:"default:null" json:"input_cost_per_query,omitempty"`
 }
 
 // Table names
@@ -771,6 +997,7 @@ func (TableBudget) TableName() string     { return "governance_budgets" }
 func (TableRateLimit) TableName() string  { return "governance_rate_limits" }
 func (TableCustomer) TableName() string   { return "governance_customers" }
 func (TableTeam) TableName() string       { return "governance_teams" }
+func (TableProject) TableName() string    { return "governance_projects" }
 func (TableVirtualKey) TableName() string { return "governance_virtual_keys" }
 func (TableVirtualKeyProviderConfig) TableName() string {
 	return "governance_virtual_key_provider_configs"
@@ -786,14 +1013,21 @@ func (vk *TableVirtualKey) BeforeSave(tx *gorm.DB) error {
 	if vk.TeamID != nil && vk.CustomerID != nil {
 		return fmt.Errorf("virtual key cannot belong to both team and customer")
 	}
+	// A VK in a project belongs to the team and customer of the project
+	if vk.ProjectID != nil && (vk.TeamID != nil || vk.CustomerID != nil) {
+		return fmt.Errorf("virtual key in a project cannot also belong to a team or customer")
+	}
 	return nil
 }
 
 // BeforeSave hook for Budget to validate reset duration format and max limit
 func (b *TableBudget) BeforeSave(tx *gorm.DB) error {
-	// Validate that ResetDuration is in correct format (e.g., "30s", "5m", "1h", "1d", "1w", "1M", "1Y")
-	if _, err := ParseDuration(b.ResetDuration); err != nil {
-		return fmt.Errorf("invalid reset duration format: %s", b.ResetDuration)
+	// Validate the reset schedule, and that ResetDuration is in correct format (e.g., "30s", "5m", "1h", "1d", "1w", "1M", "1Y") for rolling budgets
+	if err := ValidateBudgetReset(b.ResetSchedule, b.ResetDuration); err != nil {
+		return err
+	}
+	if b.RolloverCap != nil && *b.RolloverCap < 0 {
+		return fmt.Errorf("budget rollover_cap cannot be negative: %.2f", *b.RolloverCap)
 	}
 
 	// Validate that MaxLimit is not negative (budgets should be positive)
@@ -876,6 +1110,9 @@ func (vk *TableVirtualKey) AfterAutoMigrate(tx *gorm.DB) error {
 			IF NEW.team_id IS NOT NULL AND NEW.customer_id IS NOT NULL THEN
 				RAISE EXCEPTION 'Virtual key cannot belong to both team and customer';
 			END IF;
+			IF NEW.project_id IS NOT NULL AND (NEW.team_id IS NOT NULL OR NEW.customer_id IS NOT NULL) THEN
+				RAISE EXCEPTION 'Virtual key in a project cannot also belong to a team or customer';
+			END IF;
 			RETURN NEW;
 		END;
 		$$ LANGUAGE plpgsql;
@@ -17,13 +17,13 @@ func processEnvValue(value string, logger schemas.Logger) (string, error) {
 	}
 	envKey := strings.TrimSpace(strings.TrimPrefix(v, "env."))
 	if envKey == "" {
-		logger.Warn(fmt.Sprintf("Environment variable name missing in value: %s", value))
+		logger.Warn("Environment variable name missing in value: %s", value)
 		return "", fmt.Errorf("environment variable name missing in %q", value)
 	}
 	if envValue, ok := os.LookupEnv(envKey); ok {
 		return envValue, nil
 	}
-	logger.Warn(fmt.Sprintf("Environment variable not found: %s", envKey))
+	logger.Warn("Environment variable not found: %s", envKey)
 	return "", fmt.Errorf("environment variable %s not found", envKey)
 }
 
@@ -0,0 +1,138 @@
+// Package evaluation scores model outputs after the response has been sent, with built-in evaluators (regular
+// expression checks, an LLM judge and embedding similarity to reference answers) and evaluators provided by
+// plugins, and aggregates the scores into quality metrics per model.
+package evaluation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultWorkers        = 4
+	DefaultQueueSize      = 1000
+	DefaultTimeoutSeconds = 30
+	DefaultPassThreshold  = 0.5
+)
+
+// EvaluatorType selects a built-in evaluator.
+type EvaluatorType string
+
+const (
+	EvaluatorRegex      EvaluatorType = "regex"                // Checks the output against regular expressions
+	EvaluatorLLMJudge   EvaluatorType = "llm_judge"            // Asks a judge model to grade the output against criteria
+	EvaluatorSimilarity EvaluatorType = "embedding_similarity" // Compares the output to reference answers by embedding similarity
+)
+
+// EvaluatorConfig configures a built-in evaluator.
+type EvaluatorConfig struct {
+	Name   string        `json:"name"`
+	Type   EvaluatorType `json:"type"`
+	Models []string      `json:"models,omitempty"` // Models evaluated, as provider/model or model, a trailing * matches a prefix; empty evaluates all
+	// PassThreshold is the minimum score of a passing output (default: 0.5)
+	PassThreshold *float64 `json:"pass_threshold,omitempty"`
+
+	// regex: the output must match every pattern and none of the forbidden patterns
+	Patterns  []string `json:"patterns,omitempty"`
+	Forbidden []string `json:"forbidden,omitempty"`
+
+	// llm_judge: the judge model, in provider/model form, and what it grades
+	JudgeModel string `json:"judge_model,omitempty"`
+	Criteria   string `json:"criteria,omitempty"`
+
+	// embedding_similarity: the embedding model, in provider/model form, and the reference answers used when
+	// the request does not carry its own in the x-bf-eval-reference header
+	EmbeddingModel string   `json:"embedding_model,omitempty"`
+	References     []string `json:"references,omitempty"`
+}
+
+// Config represents the configuration of response evaluation.
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// SampleRate is the fraction of successful responses evaluated, between 0 and 1 (default: 1)
+	SampleRate     *float64          `json:"sample_rate,omitempty"`
+	Workers        int               `json:"workers,omitempty"`         // Concurrent evaluations (default: 4)
+	QueueSize      int               `json:"queue_size,omitempty"`      // Pending evaluations, further responses are not evaluated (default: 1000)
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"` // Timeout of the evaluators of one response (default: 30)
+	Evaluators     []EvaluatorConfig `json:"evaluators,omitempty"`
+}
+
+// Validate checks the sampling settings and the evaluators.
+func (c *Config) Validate() error {
+	if c.SampleRate != nil && (*c.SampleRate < 0 || *c.SampleRate > 1) {
+		return fmt.Errorf("evaluation sample_rate must be between 0 and 1")
+	}
+	if c.Workers < 0 || c.QueueSize < 0 || c.TimeoutSeconds < 0 {
+		return fmt.Errorf("evaluation workers, queue_size and timeout_seconds cannot be negative")
+	}
+	names := make(map[string]struct{}, len(c.Evaluators))
+	for i, evaluator := range c.Evaluators {
+		if evaluator.Name == "" {
+			return fmt.Errorf("evaluator %d: name is required", i)
+		}
+		if _, ok := names[evaluator.Name]; ok {
+			return fmt.Errorf("evaluator %s: duplicate name", evaluator.Name)
+		}
+		names[evaluator.Name] = struct{}{}
+		if evaluator.PassThreshold != nil && (*evaluator.PassThreshold < 0 || *evaluator.PassThreshold > 1) {
+			return fmt.Errorf("evaluator %s: pass_threshold must be between 0 and 1", evaluator.Name)
+		}
+		switch evaluator.Type {
+		case EvaluatorRegex:
+			if len(evaluator.Patterns) == 0 && len(evaluator.Forbidden) == 0 {
+				return fmt.Errorf("evaluator %s: patterns or forbidden are required", evaluator.Name)
+			}
+			for _, pattern := range append(append([]string{}, evaluator.Patterns...), evaluator.Forbidden...) {
+				if _, err := regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("evaluator %s: pattern %q: %w", evaluator.Name, pattern, err)
+				}
+			}
+		case EvaluatorLLMJudge:
+			if !strings.Contains(evaluator.JudgeModel, "/") || evaluator.Criteria == "" {
+				return fmt.Errorf("evaluator %s: judge_model in provider/model form and criteria are required", evaluator.Name)
+			}
+		case EvaluatorSimilarity:
+			if !strings.Contains(evaluator.EmbeddingModel, "/") {
+				return fmt.Errorf("evaluator %s: embedding_model in provider/model form is required", evaluator.Name)
+			}
+		default:
+			return fmt.Errorf("evaluator %s: unknown type %q", evaluator.Name, evaluator.Type)
+		}
+	}
+	return nil
+}
+
+// sampleRate returns the fraction of responses evaluated.
+func (c *Config) sampleRate() float64 {
+	if c.SampleRate == nil {
+		return 1
+	}
+	return *c.SampleRate
+}
+
+// passThreshold returns the minimum score of a passing output.
+func (c *EvaluatorConfig) passThreshold() float64 {
+	if c.PassThreshold == nil {
+		return DefaultPassThreshold
+	}
+	return *c.PassThreshold
+}
+
+// matchesModel reports whether patterns select the model of provider. Empty patterns select every model.
+func matchesModel(patterns []string, provider, model string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	qualified := provider + "/" + model
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(qualified, prefix) || strings.HasPrefix(model, prefix) {
+				return true
+			}
+		} else if pattern == qualified || pattern == model {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// lengthEvaluator is a plugin-provided evaluator scoring short answers higher.
+type lengthEvaluator struct{}
+
+func (lengthEvaluator) GetName() string { return "brevity" }
+
+func (lengthEvaluator) Evaluate(ctx context.Context, sample *Sample) (float64, string, error) {
+	if len(sample.Output) > 20 {
+		return 0, "too long", nil
+	}
+	return 1, "", nil
+}
+
+// TestRunner_Evaluate verifies that the built-in and registered evaluators score samples of the models they
+// apply to, that failures are recorded and skipped evaluations are not.
+func TestRunner_Evaluate(t *testing.T) {
+	threshold := 0.9
+	config := &Config{
+		Enabled: true,
+		Evaluators: []EvaluatorConfig{
+			{Name: "cites", Type: EvaluatorRegex, Patterns: []string{`\[\d+\]`}, Forbidden: []string{`(?i)as an ai`}},
+			{Name: "judge", Type: EvaluatorLLMJudge, JudgeModel: "openai/gpt-4o", Criteria: "Factual accuracy", Models: []string{"openai/*"}},
+			{Name: "similar", Type: EvaluatorSimilarity, EmbeddingModel: "openai/text-embedding-3-small", PassThreshold: &threshold},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	backends := Backends{
+		Complete: func(ctx context.Context, model, system, prompt string) (string, error) {
+			if !strings.Contains(system, "Factual accuracy") || !strings.Contains(prompt, "Answer:\nParis [1]") {
+				return "", errors.New("unexpected judge prompt")
+			}
+			return "Grade: 8\nCorrect and cited.", nil
+		},
+		Embed: func(ctx context.Context, model string, texts []string) ([][]float64, error) {
+			embeddings := make([][]float64, len(texts))
+			for i, text := range texts {
+				if strings.Contains(text, "Paris") {
+					embeddings[i] = []float64{1, 0}
+				} else {
+					embeddings[i] = []float64{0, 1}
+				}
+			}
+			return embeddings, nil
+		},
+	}
+	store := NewInMemoryStore()
+	runner, err := NewRunner(config, store, backends, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	runner.Register(lengthEvaluator{})
+	ctx := context.Background()
+
+	scores := runner.Evaluate(ctx, &Sample{RequestID: "r1", Provider: "openai", Model: "gpt-4o", Input: "Capital of France?", Output: "Paris [1]", Reference: "It is Paris"})
+	got := map[string]Score{}
+	for _, score := range scores {
+		got[score.Evaluator] = score
+	}
+	if len(got) != 4 {
+		t.Fatalf("Evaluate() = %+v, want 4 scores", scores)
+	}
+	if got["cites"].Score != 1 || got["judge"].Score != 0.8 || got["judge"].Reason != "Correct and cited." || got["similar"].Score != 1 || got["brevity"].Score != 1 {
+		t.Errorf("Evaluate() = %+v", got)
+	}
+
+	// The judge only scores openai models, the similarity check is skipped without a reference
+	scores = runner.Evaluate(ctx, &Sample{RequestID: "r2", Provider: "anthropic", Model: "claude-3-5-sonnet", Output: "As an AI, I cannot say which city it is."})
+	if len(scores) != 2 || scores[0].Evaluator != "cites" || scores[0].Score != 0 || scores[0].Passed || !strings.Contains(scores[0].Reason, "forbidden") {
+		t.Errorf("Evaluate() = %+v", scores)
+	}
+
+	// Failed evaluations are recorded with their error
+	runner.Evaluate(ctx, &Sample{RequestID: "r3", Provider: "openai", Model: "gpt-4o", Output: "Lyon"})
+	list, total, _ := store.List(ctx, Filter{RequestID: "r3", Evaluator: "judge"}, 10, 0)
+	if total != 1 || list[0].Error == "" {
+		t.Errorf("List() = %+v, want the failed judge evaluation", list)
+	}
+}
+
+// TestRunner_Submit verifies that submitted samples are evaluated in the background and that samples
+// are dropped once the queue is full.
+func TestRunner_Submit(t *testing.T) {
+	store := NewInMemoryStore()
+	runner, err := NewRunner(&Config{Enabled: true, QueueSize: 1}, store, Backends{}, nil)
+	if err != nil {
+		t.Fatalf("NewRunner() error = %v", err)
+	}
+	runner.Register(lengthEvaluator{})
+	if !runner.Submit(&Sample{RequestID: "r1", Output: "ok"}) || runner.Submit(&Sample{RequestID: "r2", Output: "ok"}) {
+		t.Fatalf("expected the second sample to be dropped")
+	}
+	if runner.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", runner.Dropped())
+	}
+	runner.Start()
+	runner.Stop(context.Background())
+	if _, total, _ := store.List(context.Background(), Filter{}, 10, 0); total != 1 {
+		t.Errorf("expected the queued sample to be evaluated before stopping, got %d scores", total)
+	}
+	if runner.Submit(&Sample{RequestID: "r3"}) {
+		t.Errorf("expected a stopped runner to refuse samples")
+	}
+}
+
+// TestStores_Summarize verifies that the database and in-memory stores aggregate scores the same way.
+func TestStores_Summarize(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "evaluation.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			for i, score := range []Score{
+				{ID: "1", RequestID: "r1", Evaluator: "judge", Provider: "openai", Model: "gpt-4o", Score: 0.9, Passed: true},
+				{ID: "2", RequestID: "r2", Evaluator: "judge", Provider: "openai", Model: "gpt-4o", Score: 0.3},
+				{ID: "3", RequestID: "r3", Evaluator: "judge", Provider: "openai", Model: "gpt-4o", Error: "timeout"},
+				{ID: "4", RequestID: "r4", Evaluator: "judge", Provider: "anthropic", Model: "claude", Score: 1, Passed: true},
+			} {
+				if err := store.Save(ctx, &score); err != nil {
+					t.Fatalf("Save(%d) error = %v", i, err)
+				}
+			}
+			summaries, err := store.Summarize(ctx, Filter{Evaluator: "judge"})
+			if err != nil {
+				t.Fatalf("Summarize() error = %v", err)
+			}
+			if len(summaries) != 2 {
+				t.Fatalf("Summarize() = %+v, want 2 models", summaries)
+			}
+			openai := summaries[1]
+			if openai.Model != "gpt-4o" || openai.Count != 2 || openai.Errors != 1 || openai.PassRate != 0.5 ||
+				openai.MinScore != 0.3 || openai.MaxScore != 0.9 || openai.AvgScore < 0.599 || openai.AvgScore > 0.601 {
+				t.Errorf("Summarize() = %+v", openai)
+			}
+			if scores, total, _ := store.List(ctx, Filter{Model: "claude"}, 10, 0); total != 1 || scores[0].RequestID != "r4" {
+				t.Errorf("List() = %+v", scores)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrSkipped is returned by evaluators that do not apply to a sample, e.g. a similarity check without a
+// reference answer. Skipped evaluations are not recorded.
+var ErrSkipped = errors.New("evaluation skipped")
+
+// Sample is a completed request handed to the evaluators.
+type Sample struct {
+	RequestID string
+	Provider  string
+	Model     string
+	Input     string // Latest prompt
+	Output    string // Completion text
+	Reference string // Expected answer, from the x-bf-eval-reference header
+}
+
+// Evaluator scores the output of a sample between 0 (worst) and 1 (best), with an optional explanation.
+// Plugins implementing Evaluator are registered with the runner and score every sampled response.
+type Evaluator interface {
+	GetName() string
+	Evaluate(ctx context.Context, sample *Sample) (score float64, reason string, err error)
+}
+
+// Completer sends a system and user prompt to a model, in provider/model form, and returns its reply.
+type Completer func(ctx context.Context, model, system, prompt string) (string, error)
+
+// Embedder returns the embeddings of texts computed by a model, in provider/model form.
+type Embedder func(ctx context.Context, model string, texts []string) ([][]float64, error)
+
+// Backends are the model calls of the built-in evaluators. They are resolved lazily, so they may be set
+// before the gateway client exists.
+type Backends struct {
+	Complete Completer
+	Embed    Embedder
+}
+
+// newEvaluator creates the built-in evaluator selected by config.
+func newEvaluator(config EvaluatorConfig, backends Backends) (Evaluator, error) {
+	switch config.Type {
+	case EvaluatorRegex:
+		evaluator := &regexEvaluator{name: config.Name}
+		for _, pattern := range config.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("evaluator %s: %w", config.Name, err)
+			}
+			evaluator.patterns = append(evaluator.patterns, re)
+		}
+		for _, pattern := range config.Forbidden {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("evaluator %s: %w", config.Name, err)
+			}
+			evaluator.forbidden = append(evaluator.forbidden, re)
+		}
+		return evaluator, nil
+	case EvaluatorLLMJudge:
+		if backends.Complete == nil {
+			return nil, fmt.Errorf("evaluator %s: no completion backend", config.Name)
+		}
+		return &judgeEvaluator{name: config.Name, model: config.JudgeModel, criteria: config.Criteria, complete: backends.Complete}, nil
+	case EvaluatorSimilarity:
+		if backends.Embed == nil {
+			return nil, fmt.Errorf("evaluator %s: no embedding backend", config.Name)
+		}
+		return &similarityEvaluator{name: config.Name, model: config.EmbeddingModel, references: config.References, embed: backends.Embed}, nil
+	}
+	return nil, fmt.Errorf("evaluator %s: unknown type %q", config.Name, config.Type)
+}
+
+// regexEvaluator scores the fraction of its checks the output passes.
+type regexEvaluator struct {
+	name      string
+	patterns  []*regexp.Regexp // Must match
+	forbidden []*regexp.Regexp // Must not match
+}
+
+func (e *regexEvaluator) GetName() string { return e.name }
+
+// Evaluate returns the fraction of patterns matched and forbidden patterns avoided, listing the failed checks.
+func (e *regexEvaluator) Evaluate(ctx context.Context, sample *Sample) (float64, string, error) {
+	var failed []string
+	for _, re := range e.patterns {
+		if !re.MatchString(sample.Output) {
+			failed = append(failed, "missing "+re.String())
+		}
+	}
+	for _, re := range e.forbidden {
+		if re.MatchString(sample.Output) {
+			failed = append(failed, "forbidden "+re.String())
+		}
+	}
+	checks := len(e.patterns) + len(e.forbidden)
+	return float64(checks-len(failed)) / float64(checks), strings.Join(failed, "; "), nil
+}
+
+// judgePrompt instructs the judge model to grade an answer on a fixed scale.
+const judgePrompt = "You grade the answers of an AI assistant. Grade the answer to the question against these criteria:\n%s\n\n" +
+	"Reply with a grade from 0 (fails the criteria) to 10 (fully meets them) on the first line, followed by a one-sentence justification."
+
+// judgeScore finds the grade on the first line of the judge's reply.
+var judgeScore = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// judgeEvaluator asks a model to grade the output against criteria.
+type judgeEvaluator struct {
+	name     string
+	model    string
+	criteria string
+	complete Completer
+}
+
+func (e *judgeEvaluator) GetName() string { return e.name }
+
+// Evaluate returns the grade of the judge, scaled to [0, 1], and its justification.
+func (e *judgeEvaluator) Evaluate(ctx context.Context, sample *Sample) (float64, string, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Question:\n" + sample.Input + "\n\nAnswer:\n" + sample.Output)
+	if sample.Reference != "" {
+		prompt.WriteString("\n\nReference answer:\n" + sample.Reference)
+	}
+	reply, err := e.complete(ctx, e.model, fmt.Sprintf(judgePrompt, e.criteria), prompt.String())
+	if err != nil {
+		return 0, "", err
+	}
+	first, rest, _ := strings.Cut(strings.TrimSpace(reply), "\n")
+	grade, err := strconv.ParseFloat(judgeScore.FindString(first), 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("judge reply has no grade: %q", first)
+	}
+	return math.Min(math.Max(grade/10, 0), 1), strings.TrimSpace(rest), nil
+}
+
+// similarityEvaluator scores the cosine similarity of the output to the closest reference answer.
+type similarityEvaluator struct {
+	name       string
+	model      string
+	references []string
+	embed      Embedder
+}
+
+func (e *similarityEvaluator) GetName() string { return e.name }
+
+// Evaluate embeds the output with the reference answers and returns the highest similarity. The reference
+// of the request replaces the configured ones.
+func (e *similarityEvaluator) Evaluate(ctx context.Context, sample *Sample) (float64, string, error) {
+	references := e.references
+	if sample.Reference != "" {
+		references = []string{sample.Reference}
+	}
+	if len(references) == 0 || sample.Output == "" {
+		return 0, "", ErrSkipped
+	}
+	embeddings, err := e.embed(ctx, e.model, append([]string{sample.Output}, references...))
+	if err != nil {
+		return 0, "", err
+	}
+	if len(embeddings) != len(references)+1 {
+		return 0, "", fmt.Errorf("expected %d embeddings, got %d", len(references)+1, len(embeddings))
+	}
+	best, closest := 0.0, 0
+	for i, reference := range embeddings[1:] {
+		if similarity := cosineSimilarity(embeddings[0], reference); similarity > best {
+			best, closest = similarity, i
+		}
+	}
+	return best, fmt.Sprintf("closest to reference %d", closest+1), nil
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, clamped to [0, 1].
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return math.Max(dot/(math.Sqrt(normA)*math.Sqrt(normB)), 0)
+}
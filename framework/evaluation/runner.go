@@ -0,0 +1,181 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// registeredEvaluator is an evaluator and the models it scores.
+type registeredEvaluator struct {
+	evaluator     Evaluator
+	models        []string
+	passThreshold float64
+}
+
+// Runner evaluates sampled responses in the background with a fixed pool of workers and records the scores.
+// Responses submitted while the queue is full are not evaluated, so evaluation never slows down requests.
+type Runner struct {
+	config *Config
+	store  Store
+	logger schemas.Logger
+
+	mu         sync.RWMutex
+	evaluators []registeredEvaluator
+	stopped    bool
+
+	queue   chan *Sample
+	wg      sync.WaitGroup
+	dropped atomic.Int64
+}
+
+// NewRunner creates a runner with the built-in evaluators of a validated config. Call Start to begin
+// evaluating submitted responses.
+func NewRunner(config *Config, store Store, backends Backends, logger schemas.Logger) (*Runner, error) {
+	queueSize := config.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	r := &Runner{config: config, store: store, logger: logger, queue: make(chan *Sample, queueSize)}
+	for _, evaluatorConfig := range config.Evaluators {
+		evaluator, err := newEvaluator(evaluatorConfig, backends)
+		if err != nil {
+			return nil, err
+		}
+		r.evaluators = append(r.evaluators, registeredEvaluator{evaluator: evaluator, models: evaluatorConfig.Models, passThreshold: evaluatorConfig.passThreshold()})
+	}
+	return r, nil
+}
+
+// Register adds an evaluator provided by a plugin, scoring the responses of every model, and replaces a
+// registered evaluator of the same name.
+func (r *Runner) Register(evaluator Evaluator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Copy on write, since running evaluations iterate over the current slice without the lock
+	evaluators := make([]registeredEvaluator, 0, len(r.evaluators)+1)
+	for _, registered := range r.evaluators {
+		if registered.evaluator.GetName() != evaluator.GetName() {
+			evaluators = append(evaluators, registered)
+		}
+	}
+	r.evaluators = append(evaluators, registeredEvaluator{evaluator: evaluator, passThreshold: DefaultPassThreshold})
+}
+
+// Start launches the workers.
+func (r *Runner) Start() {
+	workers := r.config.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for sample := range r.queue {
+				r.Evaluate(context.Background(), sample)
+			}
+		}()
+	}
+}
+
+// Sampled decides whether a response is evaluated.
+func (r *Runner) Sampled() bool {
+	rate := r.config.sampleRate()
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// Submit queues a response for evaluation. It returns false when the queue is full or the runner is stopped.
+func (r *Runner) Submit(sample *Sample) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.stopped {
+		return false
+	}
+	select {
+	case r.queue <- sample:
+		return true
+	default:
+		r.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped returns the number of responses not evaluated because the queue was full.
+func (r *Runner) Dropped() int64 {
+	return r.dropped.Load()
+}
+
+// Evaluate runs the evaluators applying to the model of a sample and records their scores. Evaluators that
+// fail are recorded with their error, evaluators that skip the sample are not recorded.
+func (r *Runner) Evaluate(ctx context.Context, sample *Sample) []Score {
+	timeout := time.Duration(r.config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultTimeoutSeconds * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r.mu.RLock()
+	evaluators := r.evaluators
+	r.mu.RUnlock()
+
+	var scores []Score
+	for _, registered := range evaluators {
+		if !matchesModel(registered.models, sample.Provider, sample.Model) {
+			continue
+		}
+		value, reason, err := registered.evaluator.Evaluate(ctx, sample)
+		if errors.Is(err, ErrSkipped) {
+			continue
+		}
+		score := Score{
+			ID:        uuid.NewString(),
+			RequestID: sample.RequestID,
+			Evaluator: registered.evaluator.GetName(),
+			Provider:  sample.Provider,
+			Model:     sample.Model,
+			CreatedAt: time.Now(),
+		}
+		if err != nil {
+			score.Error = err.Error()
+		} else {
+			score.Score = value
+			score.Passed = value >= registered.passThreshold
+			score.Reason = reason
+		}
+		if err := r.store.Save(context.Background(), &score); err != nil && r.logger != nil {
+			r.logger.Warn("failed to record evaluation score of %s for request %s: %v", score.Evaluator, score.RequestID, err)
+		}
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+// Stop stops accepting responses and waits until the queued ones are evaluated or ctx is done.
+func (r *Runner) Stop(ctx context.Context) {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	r.stopped = true
+	close(r.queue)
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
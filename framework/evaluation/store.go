@@ -0,0 +1,277 @@
+package evaluation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// maxInMemoryScores bounds the in-memory store, which drops its oldest scores first.
+const maxInMemoryScores = 10000
+
+// Score is the recorded outcome of one evaluator on one response. RequestID matches the request log entry.
+type Score struct {
+	ID        string    `gorm:"type:varchar(36);primaryKey" json:"id"`
+	RequestID string    `gorm:"type:varchar(255);index" json:"request_id"`
+	Evaluator string    `gorm:"type:varchar(255);index" json:"evaluator"`
+	Provider  string    `gorm:"type:varchar(50);index" json:"provider"`
+	Model     string    `gorm:"type:varchar(255);index" json:"model"`
+	Score     float64   `json:"score"`
+	Passed    bool      `json:"passed"`
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"`
+	Error     string    `gorm:"type:text;column:error_message" json:"error,omitempty"` // Set when the evaluator failed, the score is then not meaningful
+	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
+}
+
+// TableName sets the table name for evaluation scores
+func (Score) TableName() string { return "evaluation_scores" }
+
+// Summary aggregates the scores of an evaluator for a model. Failed evaluations are only counted in Errors.
+type Summary struct {
+	Provider  string  `json:"provider"`
+	Model     string  `json:"model"`
+	Evaluator string  `json:"evaluator"`
+	Count     int64   `json:"count"`
+	Errors    int64   `json:"errors"`
+	AvgScore  float64 `json:"avg_score"`
+	MinScore  float64 `json:"min_score"`
+	MaxScore  float64 `json:"max_score"`
+	PassRate  float64 `json:"pass_rate"`
+}
+
+// Filter selects scores. Empty fields match all scores.
+type Filter struct {
+	RequestID string
+	Evaluator string
+	Provider  string
+	Model     string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// matches reports whether a score is selected by the filter.
+func (f Filter) matches(score *Score) bool {
+	return (f.RequestID == "" || score.RequestID == f.RequestID) &&
+		(f.Evaluator == "" || score.Evaluator == f.Evaluator) &&
+		(f.Provider == "" || score.Provider == f.Provider) &&
+		(f.Model == "" || score.Model == f.Model) &&
+		(f.StartTime == nil || !score.CreatedAt.Before(*f.StartTime)) &&
+		(f.EndTime == nil || !score.CreatedAt.After(*f.EndTime))
+}
+
+// Store persists evaluation scores.
+type Store interface {
+	// Save records a score.
+	Save(ctx context.Context, score *Score) error
+	// List returns a page of the scores matching filter, newest first, and the number of matching scores.
+	List(ctx context.Context, filter Filter, limit, offset int) ([]Score, int64, error)
+	// Summarize aggregates the scores matching filter per provider, model and evaluator.
+	Summarize(ctx context.Context, filter Filter) ([]Summary, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores evaluation scores in a database, usually the logs store next to the request logs.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the evaluation scores table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate evaluation scores table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the evaluation scores table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addevaluationscorestable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Score{}) {
+				if err := migrator.CreateTable(&Score{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Save records a score.
+func (s *RDBStore) Save(ctx context.Context, score *Score) error {
+	return s.db.WithContext(ctx).Save(score).Error
+}
+
+// filtered applies filter to a query of the scores table.
+func (s *RDBStore) filtered(ctx context.Context, filter Filter) *gorm.DB {
+	query := s.db.WithContext(ctx).Model(&Score{})
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Evaluator != "" {
+		query = query.Where("evaluator = ?", filter.Evaluator)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.Model != "" {
+		query = query.Where("model = ?", filter.Model)
+	}
+	if filter.StartTime != nil {
+		query = query.Where("created_at >= ?", *filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		query = query.Where("created_at <= ?", *filter.EndTime)
+	}
+	return query
+}
+
+// List returns a page of the scores matching filter.
+func (s *RDBStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Score, int64, error) {
+	query := s.filtered(ctx, filter)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var scores []Score
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&scores).Error; err != nil {
+		return nil, 0, err
+	}
+	return scores, total, nil
+}
+
+// Summarize aggregates the scores matching filter in the database.
+func (s *RDBStore) Summarize(ctx context.Context, filter Filter) ([]Summary, error) {
+	summaries := []Summary{}
+	err := s.filtered(ctx, filter).
+		Select(`provider, model, evaluator,
+			COUNT(CASE WHEN error_message = '' THEN 1 END) AS count,
+			COUNT(CASE WHEN error_message <> '' THEN 1 END) AS errors,
+			COALESCE(AVG(CASE WHEN error_message = '' THEN score END), 0) AS avg_score,
+			COALESCE(MIN(CASE WHEN error_message = '' THEN score END), 0) AS min_score,
+			COALESCE(MAX(CASE WHEN error_message = '' THEN score END), 0) AS max_score,
+			COALESCE(AVG(CASE WHEN error_message = '' THEN (CASE WHEN passed THEN 1.0 ELSE 0.0 END) END), 0) AS pass_rate`).
+		Group("provider, model, evaluator").
+		Order("provider, model, evaluator").
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+// InMemoryStore keeps the latest evaluation scores in memory. Scores are lost on restart.
+type InMemoryStore struct {
+	mu     sync.RWMutex
+	scores []Score // Oldest first
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+// Save records a score.
+func (s *InMemoryStore) Save(ctx context.Context, score *Score) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if score.CreatedAt.IsZero() {
+		score.CreatedAt = time.Now()
+	}
+	s.scores = append(s.scores, *score)
+	if len(s.scores) > maxInMemoryScores {
+		s.scores = s.scores[len(s.scores)-maxInMemoryScores:]
+	}
+	return nil
+}
+
+// List returns a page of the scores matching filter.
+func (s *InMemoryStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Score, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var matching []Score
+	for i := len(s.scores) - 1; i >= 0; i-- {
+		if filter.matches(&s.scores[i]) {
+			matching = append(matching, s.scores[i])
+		}
+	}
+	total := int64(len(matching))
+	if offset >= len(matching) {
+		return []Score{}, total, nil
+	}
+	matching = matching[offset:]
+	if limit > 0 && limit < len(matching) {
+		matching = matching[:limit]
+	}
+	return matching, total, nil
+}
+
+// Summarize aggregates the scores matching filter.
+func (s *InMemoryStore) Summarize(ctx context.Context, filter Filter) ([]Summary, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	type groupKey struct{ provider, model, evaluator string }
+	groups := make(map[groupKey]*Summary)
+	passed := make(map[groupKey]int64)
+	for i := range s.scores {
+		score := &s.scores[i]
+		if !filter.matches(score) {
+			continue
+		}
+		key := groupKey{score.Provider, score.Model, score.Evaluator}
+		summary, ok := groups[key]
+		if !ok {
+			summary = &Summary{Provider: score.Provider, Model: score.Model, Evaluator: score.Evaluator}
+			groups[key] = summary
+		}
+		if score.Error != "" {
+			summary.Errors++
+			continue
+		}
+		if summary.Count == 0 || score.Score < summary.MinScore {
+			summary.MinScore = score.Score
+		}
+		if summary.Count == 0 || score.Score > summary.MaxScore {
+			summary.MaxScore = score.Score
+		}
+		summary.AvgScore += score.Score
+		summary.Count++
+		if score.Passed {
+			passed[key]++
+		}
+	}
+
+	summaries := make([]Summary, 0, len(groups))
+	for key, summary := range groups {
+		if summary.Count > 0 {
+			summary.AvgScore /= float64(summary.Count)
+			summary.PassRate = float64(passed[key]) / float64(summary.Count)
+		}
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.Evaluator < b.Evaluator
+	})
+	return summaries, nil
+}
@@ -0,0 +1,229 @@
+// Package finetuning tracks the fine-tuning jobs created through the gateway, so their status can be followed
+// and their access restricted to the virtual key that created them.
+package finetuning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a job is not tracked.
+var ErrNotFound = errors.New("fine-tuning job not found")
+
+// Job statuses reported by providers
+const (
+	StatusValidatingFiles = "validating_files"
+	StatusQueued          = "queued"
+	StatusRunning         = "running"
+	StatusSucceeded       = "succeeded"
+	StatusFailed          = "failed"
+	StatusCancelled       = "cancelled"
+)
+
+// finishedStatuses are the final job statuses
+var finishedStatuses = []string{StatusSucceeded, StatusFailed, StatusCancelled}
+
+// IsFinished reports whether a job status is final.
+func IsFinished(status string) bool {
+	return slices.Contains(finishedStatuses, status)
+}
+
+// Job is a fine-tuning job created through the gateway, as last reported by its provider.
+type Job struct {
+	ID              string     `gorm:"type:varchar(255);primaryKey" json:"id"` // ID assigned by the provider
+	Provider        string     `gorm:"type:varchar(50);index" json:"provider"`
+	KeyID           string     `gorm:"type:varchar(255)" json:"key_id,omitempty"` // Provider key the job was created with
+	VirtualKey      string     `gorm:"type:varchar(255);index" json:"virtual_key,omitempty"`
+	Model           string     `gorm:"type:varchar(255)" json:"model"`
+	FineTunedModel  string     `gorm:"type:varchar(255)" json:"fine_tuned_model,omitempty"`
+	Status          string     `gorm:"type:varchar(32);index" json:"status"`
+	TrainingFile    string     `gorm:"type:varchar(255)" json:"training_file"`
+	ValidationFile  string     `gorm:"type:varchar(255)" json:"validation_file,omitempty"`
+	TrainedTokens   int64      `json:"trained_tokens,omitempty"`
+	LastEvent       string     `gorm:"type:text" json:"last_event,omitempty"` // Latest progress message, e.g. the training step and loss
+	Error           string     `gorm:"type:text;column:error_message" json:"error,omitempty"`
+	EstimatedFinish *time.Time `json:"estimated_finish,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `gorm:"index;not null" json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for fine-tuning jobs
+func (Job) TableName() string { return "fine_tuning_jobs" }
+
+// Filter selects jobs. Empty fields match all jobs.
+type Filter struct {
+	Provider   string
+	VirtualKey string
+	Status     string
+	Active     bool // Only jobs not finished yet
+}
+
+// matches reports whether a job is selected by the filter.
+func (f Filter) matches(j *Job) bool {
+	return (f.Provider == "" || j.Provider == f.Provider) &&
+		(f.VirtualKey == "" || j.VirtualKey == f.VirtualKey) &&
+		(f.Status == "" || j.Status == f.Status) &&
+		(!f.Active || !IsFinished(j.Status))
+}
+
+// Store persists tracked jobs.
+type Store interface {
+	// Save creates or updates a job.
+	Save(ctx context.Context, job *Job) error
+	// Get returns a job, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Job, error)
+	// List returns a page of the jobs matching filter, newest first, and the number of matching jobs.
+	List(ctx context.Context, filter Filter, limit, offset int) ([]Job, int64, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores jobs in a database.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the jobs table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate fine-tuning jobs table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the fine-tuning jobs table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addfinetuningjobstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Job{}) {
+				if err := migrator.CreateTable(&Job{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Save creates or updates a job.
+func (s *RDBStore) Save(ctx context.Context, job *Job) error {
+	return s.db.WithContext(ctx).Save(job).Error
+}
+
+// Get returns a job.
+func (s *RDBStore) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := s.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// List returns a page of the jobs matching filter.
+func (s *RDBStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Job, int64, error) {
+	query := s.db.WithContext(ctx).Model(&Job{})
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.VirtualKey != "" {
+		query = query.Where("virtual_key = ?", filter.VirtualKey)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Active {
+		query = query.Where("status NOT IN ?", finishedStatuses)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	jobs := []Job{}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Order("created_at DESC").Offset(offset).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+// InMemoryStore keeps jobs in memory. Jobs are lost on restart.
+type InMemoryStore struct {
+	mu   sync.RWMutex
+	jobs map[string]Job
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{jobs: make(map[string]Job)}
+}
+
+// Save creates or updates a job.
+func (s *InMemoryStore) Save(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	job.UpdatedAt = now
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+// Get returns a job.
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
+
+// List returns a page of the jobs matching filter.
+func (s *InMemoryStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Job, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matching := []Job{}
+	for _, job := range s.jobs {
+		if filter.matches(&job) {
+			matching = append(matching, job)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].CreatedAt.After(matching[j].CreatedAt) })
+	total := int64(len(matching))
+	if offset >= len(matching) {
+		return []Job{}, total, nil
+	}
+	matching = matching[offset:]
+	if limit > 0 && limit < len(matching) {
+		matching = matching[:limit]
+	}
+	return matching, total, nil
+}
@@ -0,0 +1,61 @@
+package finetuning
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestStore verifies that jobs are updated in place and listed newest first by filter, in both stores.
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "finetuning.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			for _, job := range []*Job{
+				{ID: "ftjob-1", Provider: "openai", VirtualKey: "vk-a", Status: StatusSucceeded, CreatedAt: now.Add(-2 * time.Hour)},
+				{ID: "ftjob-2", Provider: "openai", VirtualKey: "vk-a", Status: StatusQueued, CreatedAt: now.Add(-time.Hour)},
+				{ID: "ftjob-3", Provider: "azure", VirtualKey: "vk-b", Status: StatusRunning, CreatedAt: now},
+			} {
+				if err := store.Save(ctx, job); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			job, err := store.Get(ctx, "ftjob-2")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			job.Status, job.LastEvent = StatusRunning, "Step 10/100: training loss=1.20"
+			if err := store.Save(ctx, job); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			active, total, err := store.List(ctx, Filter{Active: true}, 10, 0)
+			if err != nil || total != 2 || len(active) != 2 || active[0].ID != "ftjob-3" || active[1].LastEvent == "" {
+				t.Fatalf("List(active) = %+v, %d, %v, want the two running jobs, newest first", active, total, err)
+			}
+			page, total, err := store.List(ctx, Filter{VirtualKey: "vk-a"}, 1, 1)
+			if err != nil || total != 2 || len(page) != 1 || page[0].ID != "ftjob-1" {
+				t.Fatalf("List(vk-a) = %+v, %d, %v, want the older job of vk-a", page, total, err)
+			}
+			if _, err := store.Get(ctx, "ftjob-unknown"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of an unknown job error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
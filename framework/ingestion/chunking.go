@@ -0,0 +1,187 @@
+package ingestion
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunking strategies
+const (
+	ChunkingStrategyFixed     = "fixed"     // Windows of Size characters, cut at whitespace
+	ChunkingStrategySentence  = "sentence"  // Whole sentences packed up to Size characters
+	ChunkingStrategyParagraph = "paragraph" // Whole paragraphs packed up to Size characters, long ones split by sentence
+)
+
+const (
+	DefaultChunkSize    = 1000
+	DefaultChunkOverlap = 100
+)
+
+// ChunkingConfig selects how documents are split into chunks. Sizes are in characters; Overlap is how much of
+// the end of a chunk is repeated at the start of the next one.
+type ChunkingConfig struct {
+	Strategy string `json:"strategy,omitempty"` // fixed, sentence or paragraph (default: paragraph)
+	Size     int    `json:"size,omitempty"`     // Maximum chunk length (default: 1000)
+	Overlap  *int   `json:"overlap,omitempty"`  // Default: 100, at most a quarter of the size
+}
+
+// WithDefaults returns the configuration with unset fields taken from defaults, then from the built-in defaults.
+func (c ChunkingConfig) WithDefaults(defaults ChunkingConfig) ChunkingConfig {
+	if c.Strategy == "" {
+		c.Strategy = defaults.Strategy
+	}
+	if c.Strategy == "" {
+		c.Strategy = ChunkingStrategyParagraph
+	}
+	if c.Size == 0 {
+		c.Size = defaults.Size
+	}
+	if c.Size == 0 {
+		c.Size = DefaultChunkSize
+	}
+	if c.Overlap == nil {
+		c.Overlap = defaults.Overlap
+	}
+	if c.Overlap == nil {
+		overlap := min(DefaultChunkOverlap, c.Size/4)
+		c.Overlap = &overlap
+	}
+	return c
+}
+
+// Validate checks the configuration.
+func (c ChunkingConfig) Validate() error {
+	switch c.Strategy {
+	case "", ChunkingStrategyFixed, ChunkingStrategySentence, ChunkingStrategyParagraph:
+	default:
+		return fmt.Errorf("unknown chunking strategy %q: use fixed, sentence or paragraph", c.Strategy)
+	}
+	if c.Size < 0 || (c.Overlap != nil && *c.Overlap < 0) {
+		return fmt.Errorf("chunk size and overlap must not be negative")
+	}
+	if c.Size > 0 && c.Overlap != nil && *c.Overlap >= c.Size {
+		return fmt.Errorf("chunk overlap must be smaller than the chunk size")
+	}
+	return nil
+}
+
+var (
+	paragraphBreak = regexp.MustCompile(`\n\s*\n`)
+	sentenceEnd    = regexp.MustCompile(`[.!?。！？]+["')\]]*\s+|\n`)
+)
+
+// Split splits text into chunks according to config, using the built-in defaults for unset fields.
+func Split(text string, config ChunkingConfig) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	config = config.WithDefaults(ChunkingConfig{})
+	overlap := *config.Overlap
+	switch config.Strategy {
+	case ChunkingStrategyFixed:
+		return splitFixed(text, config.Size, overlap)
+	case ChunkingStrategySentence:
+		return pack(splitSentences(text, config.Size), " ", config.Size, overlap)
+	default:
+		var units []string
+		for _, paragraph := range paragraphBreak.Split(text, -1) {
+			paragraph = strings.TrimSpace(paragraph)
+			if paragraph == "" {
+				continue
+			}
+			if utf8.RuneCountInString(paragraph) > config.Size {
+				units = append(units, pack(splitSentences(paragraph, config.Size), " ", config.Size, 0)...)
+				continue
+			}
+			units = append(units, paragraph)
+		}
+		return pack(units, "\n\n", config.Size, overlap)
+	}
+}
+
+// splitSentences splits text into sentences, splitting the ones longer than size into fixed windows.
+func splitSentences(text string, size int) []string {
+	var sentences []string
+	start := 0
+	add := func(sentence string) {
+		sentence = strings.TrimSpace(sentence)
+		if sentence == "" {
+			return
+		}
+		if utf8.RuneCountInString(sentence) > size {
+			sentences = append(sentences, splitFixed(sentence, size, 0)...)
+			return
+		}
+		sentences = append(sentences, sentence)
+	}
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		add(text[start:loc[1]])
+		start = loc[1]
+	}
+	add(text[start:])
+	return sentences
+}
+
+// splitFixed splits text into windows of at most size characters, overlapping by overlap characters. Windows
+// end at the last whitespace of their second half when there is one, so words are not cut.
+func splitFixed(text string, size, overlap int) []string {
+	runes := []rune(text)
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			for i := end; i > start+size/2; i-- {
+				if unicode.IsSpace(runes[i-1]) {
+					end = i
+					break
+				}
+			}
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		start = max(end-overlap, start+1)
+	}
+	return chunks
+}
+
+// pack joins units with sep into chunks of at most size characters. Each chunk starts with the trailing units
+// of the previous one that fit within overlap characters.
+func pack(units []string, sep string, size, overlap int) []string {
+	var chunks, current []string
+	length := func(units []string) int {
+		total := 0
+		for i, unit := range units {
+			if i > 0 {
+				total += len(sep)
+			}
+			total += utf8.RuneCountInString(unit)
+		}
+		return total
+	}
+	for _, unit := range units {
+		if len(current) > 0 && length(append(current, unit)) > size {
+			chunks = append(chunks, strings.Join(current, sep))
+			carry := 0
+			for carry < len(current)-1 && length(current[len(current)-carry-1:]) <= overlap {
+				carry++
+			}
+			current = append([]string(nil), current[len(current)-carry:]...)
+			if len(current) > 0 && length(append(current, unit)) > size {
+				current = nil // The carried over units leave no room for the next one
+			}
+		}
+		current = append(current, unit)
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, sep))
+	}
+	return chunks
+}
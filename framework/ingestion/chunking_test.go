@@ -0,0 +1,66 @@
+package ingestion
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func intPtr(value int) *int {
+	return &value
+}
+
+// TestSplit verifies that each strategy keeps chunks within the size, cuts at the expected boundaries and
+// repeats the overlap
+func TestSplit(t *testing.T) {
+	text := "Leave policy.\n\nEmployees get 25 days of paid leave. Unused days carry over. Requests go to the manager.\n\n" +
+		"Sick leave is separate."
+
+	paragraphs := Split(text, ChunkingConfig{Strategy: ChunkingStrategyParagraph, Size: 200, Overlap: intPtr(0)})
+	if len(paragraphs) != 1 || paragraphs[0] != strings.TrimSpace(text) {
+		t.Errorf("paragraph chunks = %q, want the whole text", paragraphs)
+	}
+	paragraphs = Split(text, ChunkingConfig{Strategy: ChunkingStrategyParagraph, Size: 60, Overlap: intPtr(0)})
+	want := []string{"Leave policy.", "Employees get 25 days of paid leave. Unused days carry over.", "Requests go to the manager.\n\nSick leave is separate."}
+	if strings.Join(paragraphs, "|") != strings.Join(want, "|") {
+		t.Errorf("paragraph chunks = %q, want %q", paragraphs, want)
+	}
+
+	sentences := Split(text, ChunkingConfig{Strategy: ChunkingStrategySentence, Size: 70, Overlap: intPtr(40)})
+	want = []string{
+		"Leave policy. Employees get 25 days of paid leave.",
+		"Employees get 25 days of paid leave. Unused days carry over.",
+		"Unused days carry over. Requests go to the manager.",
+		"Requests go to the manager. Sick leave is separate.",
+	}
+	if strings.Join(sentences, "|") != strings.Join(want, "|") {
+		t.Errorf("sentence chunks = %q, want %q", sentences, want)
+	}
+
+	fixed := Split(strings.Repeat("word ", 100), ChunkingConfig{Strategy: ChunkingStrategyFixed, Size: 50, Overlap: intPtr(10)})
+	for i, chunk := range fixed {
+		if utf8.RuneCountInString(chunk) > 50 || strings.Contains(chunk, "wor ") || strings.HasPrefix(chunk, "ord") {
+			t.Errorf("fixed chunk %d = %q cuts a word or is too long", i, chunk)
+		}
+	}
+	if len(fixed) < 10 {
+		t.Errorf("fixed chunks = %d, want overlapping windows over 500 characters", len(fixed))
+	}
+
+	if chunks := Split("  \n ", ChunkingConfig{}); len(chunks) != 0 {
+		t.Errorf("blank text chunks = %q", chunks)
+	}
+}
+
+// TestChunkingConfig verifies the defaults and the validation of chunking configurations
+func TestChunkingConfig(t *testing.T) {
+	config := ChunkingConfig{Size: 200}.WithDefaults(ChunkingConfig{Strategy: ChunkingStrategySentence})
+	if config.Strategy != ChunkingStrategySentence || config.Size != 200 || *config.Overlap != 50 {
+		t.Errorf("WithDefaults() = %+v, overlap %d", config, *config.Overlap)
+	}
+	for _, invalid := range []ChunkingConfig{{Strategy: "semantic"}, {Size: -1}, {Size: 100, Overlap: intPtr(100)}} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", invalid)
+		}
+	}
+}
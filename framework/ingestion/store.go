@@ -0,0 +1,255 @@
+// Package ingestion splits the documents ingested into retrieval stores into chunks and keeps track of them
+// while their chunks are embedded in the background.
+package ingestion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a document does not exist.
+var ErrNotFound = errors.New("document not found")
+
+// Document statuses
+const (
+	StatusQueued     = "queued"     // Waiting for a worker
+	StatusProcessing = "processing" // Chunks are being embedded
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// Document is a text ingested into a retrieval store. Its chunks are stored in the vector store under the IDs
+// returned by ChunkID, with the document metadata.
+type Document struct {
+	ID             string     `gorm:"type:varchar(255);primaryKey" json:"id"`
+	Store          string     `gorm:"type:varchar(255);index" json:"store"` // Retrieval store name
+	Name           string     `gorm:"type:varchar(1024)" json:"name"`       // Reported as the source of its chunks
+	Content        string     `gorm:"type:text" json:"-"`                   // Kept for reindexing
+	MetadataJSON   string     `gorm:"type:text" json:"-"`                   // JSON serialized map[string]interface{}
+	ChunkingJSON   string     `gorm:"type:text" json:"-"`                   // JSON serialized ChunkingConfig
+	EmbeddingModel string     `gorm:"type:varchar(255)" json:"embedding_model"`
+	Status         string     `gorm:"type:varchar(32);index" json:"status"`
+	ChunkCount     int        `json:"chunk_count"`     // Chunks of the current indexing
+	EmbeddedChunks int        `json:"embedded_chunks"` // Chunks of the current indexing embedded and stored so far
+	StoredChunks   int        `json:"-"`               // Chunk IDs possibly present in the vector store, from 0
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"index;not null" json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Metadata map[string]interface{} `gorm:"-" json:"metadata,omitempty"`
+	Chunking ChunkingConfig         `gorm:"-" json:"chunking"`
+}
+
+// TableName sets the table name for documents
+func (Document) TableName() string { return "retrieval_documents" }
+
+// BeforeSave serializes the metadata and chunking of a document
+func (d *Document) BeforeSave(tx *gorm.DB) (err error) {
+	if d.MetadataJSON, err = marshalJSON(d.Metadata); err != nil {
+		return err
+	}
+	d.ChunkingJSON, err = marshalJSON(d.Chunking)
+	return err
+}
+
+// AfterFind deserializes the metadata and chunking of a document
+func (d *Document) AfterFind(tx *gorm.DB) error {
+	if err := unmarshalJSON(d.MetadataJSON, &d.Metadata); err != nil {
+		return err
+	}
+	return unmarshalJSON(d.ChunkingJSON, &d.Chunking)
+}
+
+// IsFinished reports whether the document is not waiting for or undergoing indexing.
+func (d *Document) IsFinished() bool {
+	return d.Status == StatusCompleted || d.Status == StatusFailed
+}
+
+// ChunkID returns the vector store ID of the chunk of a document at index.
+func ChunkID(documentID string, index int) string {
+	return fmt.Sprintf("%s#%d", documentID, index)
+}
+
+// marshalJSON serializes value, storing nothing for empty values
+func marshalJSON(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	if string(data) == "null" {
+		return "", nil
+	}
+	return string(data), nil
+}
+
+// unmarshalJSON deserializes data into target, leaving it unset when data is empty
+func unmarshalJSON(data string, target any) error {
+	if data == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(data), target)
+}
+
+// Store persists documents.
+type Store interface {
+	// Save creates or replaces a document.
+	Save(ctx context.Context, document *Document) error
+	// Get returns a document, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Document, error)
+	// Delete removes a document, or returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+	// List returns the documents of a retrieval store, or of all stores when store is empty, newest first.
+	List(ctx context.Context, store string) ([]Document, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores documents in a database.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the documents table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate retrieval documents table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the retrieval documents table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addretrievaldocumentstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Document{}) {
+				if err := migrator.CreateTable(&Document{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Save creates or replaces a document.
+func (s *RDBStore) Save(ctx context.Context, document *Document) error {
+	return s.db.WithContext(ctx).Save(document).Error
+}
+
+// Get returns a document.
+func (s *RDBStore) Get(ctx context.Context, id string) (*Document, error) {
+	var document Document
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&document).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &document, nil
+}
+
+// Delete removes a document.
+func (s *RDBStore) Delete(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&Document{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns the documents of a retrieval store, newest first. Their content is not loaded.
+func (s *RDBStore) List(ctx context.Context, store string) ([]Document, error) {
+	documents := []Document{}
+	query := s.db.WithContext(ctx).Omit("content").Order("created_at DESC")
+	if store != "" {
+		query = query.Where("store = ?", store)
+	}
+	err := query.Find(&documents).Error
+	return documents, err
+}
+
+// InMemoryStore keeps documents in memory. They are lost on restart.
+type InMemoryStore struct {
+	mu        sync.RWMutex
+	documents map[string]Document
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{documents: make(map[string]Document)}
+}
+
+// Save stores a copy of a document.
+func (s *InMemoryStore) Save(ctx context.Context, document *Document) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if document.CreatedAt.IsZero() {
+		document.CreatedAt = now
+	}
+	document.UpdatedAt = now
+	s.documents[document.ID] = *document
+	return nil
+}
+
+// Get returns a copy of a document.
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	document, ok := s.documents[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &document, nil
+}
+
+// Delete removes a document.
+func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.documents[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.documents, id)
+	return nil
+}
+
+// List returns the documents of a retrieval store, newest first, without their content.
+func (s *InMemoryStore) List(ctx context.Context, store string) ([]Document, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	documents := make([]Document, 0, len(s.documents))
+	for _, document := range s.documents {
+		if store != "" && document.Store != store {
+			continue
+		}
+		document.Content = ""
+		documents = append(documents, document)
+	}
+	sort.Slice(documents, func(i, j int) bool { return documents[i].CreatedAt.After(documents[j].CreatedAt) })
+	return documents, nil
+}
@@ -0,0 +1,64 @@
+package ingestion
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestStore verifies that documents round-trip with their metadata and chunking, and are listed per store
+// without their content, in both stores.
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "documents.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			for _, document := range []*Document{
+				{ID: "doc_1", Store: "handbook", Name: "leave.md", Content: "Employees get 25 days.", Status: StatusCompleted,
+					Metadata: map[string]interface{}{"department": "hr"}, Chunking: ChunkingConfig{Strategy: ChunkingStrategySentence, Size: 500}, CreatedAt: now.Add(-time.Hour)},
+				{ID: "doc_2", Store: "handbook", Name: "travel.md", Content: "Book trains.", Status: StatusQueued, CreatedAt: now},
+				{ID: "doc_3", Store: "wiki", Name: "setup.md", Content: "Install Go.", Status: StatusQueued, CreatedAt: now},
+			} {
+				if err := store.Save(ctx, document); err != nil {
+					t.Fatalf("Save() error = %v", err)
+				}
+			}
+
+			document, err := store.Get(ctx, "doc_1")
+			if err != nil || document.Content != "Employees get 25 days." || document.Metadata["department"] != "hr" || document.Chunking.Size != 500 {
+				t.Fatalf("Get() = %+v, %v", document, err)
+			}
+			list, err := store.List(ctx, "handbook")
+			if err != nil || len(list) != 2 || list[0].ID != "doc_2" || list[1].Content != "" {
+				t.Fatalf("List() = %+v, %v, want the handbook documents newest first without content", list, err)
+			}
+			if all, _ := store.List(ctx, ""); len(all) != 3 {
+				t.Errorf("List() of all stores = %d documents, want 3", len(all))
+			}
+
+			if err := store.Delete(ctx, "doc_1"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Get(ctx, "doc_1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() of a deleted document error = %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, "doc_1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete() of a deleted document error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
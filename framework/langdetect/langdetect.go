@@ -0,0 +1,225 @@
+// Package langdetect detects the natural language of prompts. Detection is lightweight and dependency free:
+// text written in a script used by a single language is attributed by script, and Latin script text is
+// scored against the most frequent words of each supported language.
+package langdetect
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxRunes bounds the text examined, so detection costs the same for short and very long prompts
+const maxRunes = 2000
+
+// Result is the detected language of a text.
+type Result struct {
+	Language   string  `json:"language"`   // ISO 639-1 code, empty when the language could not be determined
+	Confidence float64 `json:"confidence"` // In [0, 1]
+}
+
+// Languages lists the languages Detect can report.
+var Languages = []string{"ar", "de", "el", "en", "es", "fa", "fr", "he", "hi", "it", "ja", "ko", "nl", "pt", "ru", "th", "uk", "zh"}
+
+// script is a group of letters counted together
+type script int
+
+const (
+	scriptLatin script = iota
+	scriptKana
+	scriptHan
+	scriptHangul
+	scriptCyrillic
+	scriptArabic
+	scriptHebrew
+	scriptGreek
+	scriptThai
+	scriptDevanagari
+	scriptCount
+)
+
+// scriptWeights scale the letter counts of scripts, since one CJK character carries about as much text as a
+// few Latin letters
+var scriptWeights = [scriptCount]float64{scriptKana: 3, scriptHan: 3, scriptHangul: 3}
+
+// scriptLanguages are the languages of scripts used by a single supported language
+var scriptLanguages = map[script]string{
+	scriptHangul:     "ko",
+	scriptHebrew:     "he",
+	scriptGreek:      "el",
+	scriptThai:       "th",
+	scriptDevanagari: "hi",
+}
+
+// stopwords are the most frequent words of the supported Latin script languages
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "that", "it", "for", "you", "with", "what", "how", "this", "be", "have", "not", "on", "can", "please", "my", "i", "me", "do", "an", "at", "your", "from"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "un", "una", "es", "por", "para", "con", "no", "se", "del", "al", "lo", "como", "qué", "cómo", "mi", "está", "pero", "puedes", "hola", "muy", "sobre", "más"},
+	"fr": {"le", "la", "les", "de", "des", "du", "et", "est", "un", "une", "que", "qui", "pour", "pas", "dans", "en", "sur", "je", "vous", "il", "ce", "avec", "mon", "comment", "quel", "quelle", "être", "sont", "au", "aux"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "ich", "sie", "zu", "mit", "den", "von", "auf", "für", "wie", "was", "es", "dem", "bitte", "mein", "kann", "sind", "auch", "über", "wir", "einen", "bei", "oder"},
+	"it": {"il", "lo", "la", "gli", "le", "di", "che", "e", "è", "un", "una", "per", "non", "con", "sono", "come", "mi", "del", "della", "questo", "cosa", "perché", "io", "ho", "anche", "nel", "alla", "ciao", "puoi", "dei"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "é", "um", "uma", "para", "com", "não", "do", "da", "em", "no", "na", "por", "como", "eu", "você", "meu", "isso", "mais", "olá", "pode", "sobre", "dos", "das"},
+	"nl": {"de", "het", "een", "en", "is", "van", "ik", "niet", "dat", "die", "op", "te", "je", "met", "voor", "zijn", "wat", "hoe", "er", "maar", "ook", "kan", "mijn", "naar", "dit", "hallo", "wij", "jij", "zou", "wel"},
+}
+
+// markers are letters specific to one supported Latin script language, each worth a stopword
+var markers = map[rune]string{
+	'ñ': "es", '¿': "es", '¡': "es",
+	'ß': "de", 'ä': "de", 'ö': "de", 'ü': "de",
+	'ç': "fr", 'œ': "fr", 'ê': "fr", 'î': "fr", 'û': "fr", 'ë': "fr",
+	'ã': "pt", 'õ': "pt",
+	'ì': "it", 'ò': "it",
+}
+
+// stopwordLanguages maps each stopword to the languages it belongs to
+var stopwordLanguages = func() map[string][]string {
+	languages := make(map[string][]string)
+	for language, words := range stopwords {
+		for _, word := range words {
+			if !slices.Contains(languages[word], language) {
+				languages[word] = append(languages[word], language)
+			}
+		}
+	}
+	return languages
+}()
+
+// Detect returns the language of text. The language is empty when text has no letters or, for Latin script
+// text, none of the frequent words of a supported language.
+func Detect(text string) Result {
+	var counts [scriptCount]int
+	var cyrillicUkrainian, arabicPersian int
+	var latin strings.Builder
+	n := 0
+	for _, r := range text {
+		if n == maxRunes {
+			break
+		}
+		n++
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			counts[scriptKana]++
+		case unicode.Is(unicode.Han, r):
+			counts[scriptHan]++
+		case unicode.Is(unicode.Hangul, r):
+			counts[scriptHangul]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts[scriptCyrillic]++
+			if strings.ContainsRune("іїєґІЇЄҐ", r) {
+				cyrillicUkrainian++
+			}
+		case unicode.Is(unicode.Arabic, r) && unicode.IsLetter(r):
+			counts[scriptArabic]++
+			if strings.ContainsRune("پچژگک", r) {
+				arabicPersian++
+			}
+		case unicode.Is(unicode.Hebrew, r) && unicode.IsLetter(r):
+			counts[scriptHebrew]++
+		case unicode.Is(unicode.Greek, r):
+			counts[scriptGreek]++
+		case unicode.Is(unicode.Thai, r):
+			counts[scriptThai]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts[scriptDevanagari]++
+		case unicode.Is(unicode.Latin, r):
+			counts[scriptLatin]++
+			latin.WriteRune(unicode.ToLower(r))
+			continue
+		case r == '¿' || r == '¡':
+			latin.WriteRune(r)
+			continue
+		}
+		latin.WriteByte(' ')
+	}
+
+	// Japanese is written with kanji and kana together, so both count for it when kana are present
+	if counts[scriptKana] > 0 {
+		counts[scriptKana] += counts[scriptHan]
+		counts[scriptHan] = 0
+	}
+	dominant, total, best := scriptLatin, 0.0, 0.0
+	for s := script(0); s < scriptCount; s++ {
+		weighted := float64(counts[s])
+		if scriptWeights[s] > 0 {
+			weighted *= scriptWeights[s]
+		}
+		total += weighted
+		if weighted > best {
+			dominant, best = s, weighted
+		}
+	}
+	if total == 0 {
+		return Result{}
+	}
+	share := best / total
+
+	switch dominant {
+	case scriptLatin:
+		language, confidence := detectLatin(latin.String())
+		return Result{Language: language, Confidence: confidence * share}
+	case scriptKana:
+		return Result{Language: "ja", Confidence: share}
+	case scriptHan:
+		return Result{Language: "zh", Confidence: share}
+	case scriptCyrillic:
+		if cyrillicUkrainian > 0 {
+			return Result{Language: "uk", Confidence: share}
+		}
+		return Result{Language: "ru", Confidence: share}
+	case scriptArabic:
+		if arabicPersian > 0 {
+			return Result{Language: "fa", Confidence: share}
+		}
+		return Result{Language: "ar", Confidence: share}
+	default:
+		return Result{Language: scriptLanguages[dominant], Confidence: share}
+	}
+}
+
+// detectLatin scores lowercase Latin script text against the stopwords and markers of each language.
+// The confidence is the share of the best language in all the scores.
+func detectLatin(text string) (string, float64) {
+	scores := make(map[string]float64)
+	for _, r := range text {
+		if language, ok := markers[r]; ok {
+			scores[language]++
+		}
+	}
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		languages := stopwordLanguages[word]
+		for _, language := range languages {
+			// A word shared by several languages tells less about each of them
+			scores[language] += 1 / float64(len(languages))
+		}
+	}
+
+	best, bestScore, total := "", 0.0, 0.0
+	for _, language := range Languages {
+		score := scores[language]
+		total += score
+		if score > bestScore {
+			best, bestScore = language, score
+		}
+	}
+	if best == "" {
+		return "", 0
+	}
+	return best, bestScore / total
+}
+
+// Normalize returns the lowercase primary language subtag of a language tag or locale, e.g. "ja" for "ja-JP",
+// "pt_BR" or "ja;q=0.9". Tags whose primary subtag is not two or three letters return an empty string.
+func Normalize(tag string) string {
+	tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+	primary, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	primary = strings.ToLower(strings.TrimSpace(primary))
+	if len(primary) < 2 || len(primary) > 3 {
+		return ""
+	}
+	for _, r := range primary {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	return primary
+}
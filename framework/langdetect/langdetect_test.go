@@ -0,0 +1,61 @@
+package langdetect
+
+import "testing"
+
+// TestDetect tests that prompts in the supported languages are attributed to their language
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"What is the capital of France? Please answer in one word.", "en"},
+		{"¿Cuál es la capital de Francia? Responde con una sola palabra.", "es"},
+		{"Quelle est la capitale de la France ? Réponds en un seul mot.", "fr"},
+		{"Was ist die Hauptstadt von Frankreich? Bitte antworte mit einem Wort.", "de"},
+		{"Qual è la capitale della Francia? Rispondi con una sola parola, per favore.", "it"},
+		{"Qual é a capital da França? Você pode responder com uma palavra?", "pt"},
+		{"Wat is de hoofdstad van Frankrijk? Kan je het in een woord zeggen?", "nl"},
+		{"フランスの首都はどこですか？一言で答えてください。", "ja"},
+		{"法国的首都是哪里？请用一个词回答。", "zh"},
+		{"프랑스의 수도는 어디입니까? 한 단어로 대답하세요.", "ko"},
+		{"Какая столица Франции? Ответь одним словом.", "ru"},
+		{"Яка столиця Франції? Відповідай одним словом.", "uk"},
+		{"ما هي عاصمة فرنسا؟ أجب بكلمة واحدة.", "ar"},
+		{"پایتخت فرانسه کجاست؟ با یک کلمه جواب بده.", "fa"},
+		{"Ποια είναι η πρωτεύουσα της Γαλλίας;", "el"},
+		{"फ्रांस की राजधानी क्या है?", "hi"},
+		{"Translate to English: これは私の猫です", "ja"},
+		{"12345 + 67890 = ?", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := Detect(tt.text)
+		if got.Language != tt.want {
+			t.Errorf("Detect(%q) = %+v, want %q", tt.text, got, tt.want)
+		}
+		if got.Language != "" && (got.Confidence <= 0 || got.Confidence > 1) {
+			t.Errorf("Detect(%q) confidence = %v, want it in (0, 1]", tt.text, got.Confidence)
+		}
+	}
+}
+
+// TestNormalize tests that locales and language tags are reduced to their primary language subtag
+func TestNormalize(t *testing.T) {
+	for tag, want := range map[string]string{
+		"ja":          "ja",
+		"ja-JP":       "ja",
+		"pt_BR":       "pt",
+		" EN-us ":     "en",
+		"fr;q=0.9":    "fr",
+		"x":           "",
+		"chinese":     "",
+		"12":          "",
+		"":            "",
+		"zh-Hant-TW":  "zh",
+		"yue-Hant-HK": "yue",
+	} {
+		if got := Normalize(tag); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
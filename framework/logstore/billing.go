@@ -0,0 +1,72 @@
+package logstore
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// BillingFilters selects the requests covered by a billing report.
+// Only successful requests attributed to a customer are billed.
+type BillingFilters struct {
+	Customers []string   // Customers to report (all attributed customers when empty)
+	StartTime *time.Time // Inclusive
+	EndTime   *time.Time // Exclusive
+	ByModel   bool       // Break each customer's usage down by provider and model
+	ByLabels  bool       // Break each customer's usage down by the labels of the requests
+}
+
+// BillingLine is the usage and cost of a customer, or of one provider and model or set of labels of a customer.
+type BillingLine struct {
+	Customer         string            `json:"customer"`
+	Provider         string            `json:"provider,omitempty"`
+	Model            string            `json:"model,omitempty"`
+	Labels           map[string]string `gorm:"-" json:"labels,omitempty"`
+	Requests         int64             `json:"requests"`
+	PromptTokens     int64             `json:"prompt_tokens"`
+	CompletionTokens int64             `json:"completion_tokens"`
+	TotalTokens      int64             `json:"total_tokens"`
+	Cost             float64           `json:"cost"` // In dollars
+}
+
+// billingRow is a billing line as read from the store, with its labels serialized.
+type billingRow struct {
+	BillingLine
+	LabelsJSON string `json:"labels_json"`
+}
+
+// billingGroupColumns returns the columns billing lines are grouped and ordered by.
+func billingGroupColumns(filters BillingFilters) string {
+	columns := []string{"customer"}
+	if filters.ByModel {
+		columns = append(columns, "provider", "model")
+	}
+	if filters.ByLabels {
+		columns = append(columns, "labels")
+	}
+	return strings.Join(columns, ", ")
+}
+
+// billingSelectColumns returns the group columns of billing lines as selected, with the labels renamed to be read
+// into billingRow.LabelsJSON.
+func billingSelectColumns(filters BillingFilters) string {
+	columns := billingGroupColumns(filters)
+	if filters.ByLabels {
+		columns = strings.TrimSuffix(columns, "labels") + "labels AS labels_json"
+	}
+	return columns
+}
+
+// billingLines returns the billing lines of rows, with their labels deserialized.
+func billingLines(rows []billingRow) []BillingLine {
+	lines := make([]BillingLine, len(rows))
+	for i, row := range rows {
+		lines[i] = row.BillingLine
+		if row.LabelsJSON != "" {
+			if err := json.Unmarshal([]byte(row.LabelsJSON), &lines[i].Labels); err != nil {
+				lines[i].Labels = nil
+			}
+		}
+	}
+	return lines
+}
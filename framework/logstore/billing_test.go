@@ -0,0 +1,98 @@
+package logstore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRDBBillingReport tests that successful attributed requests are aggregated per customer and model
+func TestRDBBillingReport(t *testing.T) {
+	ctx := context.Background()
+	store, err := newSqliteLogStore(ctx, &SQLiteConfig{Path: filepath.Join(t.TempDir(), "logs.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to create sqlite log store: %v", err)
+	}
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cost := func(value float64) *float64 { return &value }
+	for _, entry := range []*Log{
+		{ID: "1", Customer: "acme", Provider: "openai", Model: "gpt-4o", Status: "success", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, Cost: cost(0.5), LabelsParsed: map[string]string{"feature": "chat"}},
+		{ID: "2", Customer: "acme", Provider: "openai", Model: "gpt-4o-mini", Status: "success", PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30, Cost: cost(0.25), LabelsParsed: map[string]string{"feature": "search_1"}},
+		{ID: "3", Customer: "globex", Provider: "anthropic", Model: "claude", Status: "success", TotalTokens: 7, Cost: cost(1)},
+		{ID: "4", Customer: "acme", Provider: "openai", Model: "gpt-4o", Status: "error", TotalTokens: 100, Cost: cost(9)},
+		{ID: "5", Provider: "openai", Model: "gpt-4o", Status: "success", TotalTokens: 100, Cost: cost(9)},
+		{ID: "6", Customer: "acme", Provider: "openai", Model: "gpt-4o", Status: "success", TotalTokens: 100, Cost: cost(9), Timestamp: day.AddDate(0, 0, 1)},
+	} {
+		if entry.Timestamp.IsZero() {
+			entry.Timestamp = day
+		}
+		entry.CreatedAt = entry.Timestamp
+		if err := store.Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create log %s: %v", entry.ID, err)
+		}
+	}
+	start, end := day.Add(-time.Hour), day.Add(time.Hour)
+
+	lines, err := store.BillingReport(ctx, BillingFilters{StartTime: &start, EndTime: &end})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 customers, got %+v", lines)
+	}
+	if lines[0].Customer != "acme" || lines[0].Requests != 2 || lines[0].TotalTokens != 45 || lines[0].Cost != 0.75 || lines[0].Model != "" {
+		t.Errorf("unexpected acme line: %+v", lines[0])
+	}
+	if lines[1].Customer != "globex" || lines[1].Requests != 1 || lines[1].Cost != 1 {
+		t.Errorf("unexpected globex line: %+v", lines[1])
+	}
+
+	lines, err = store.BillingReport(ctx, BillingFilters{Customers: []string{"acme"}, StartTime: &start, EndTime: &end, ByModel: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2 || lines[0].Model != "gpt-4o" || lines[0].PromptTokens != 10 || lines[1].Model != "gpt-4o-mini" || lines[1].Provider != "openai" {
+		t.Errorf("unexpected per model lines: %+v", lines)
+	}
+
+	lines, err = store.BillingReport(ctx, BillingFilters{StartTime: &start, EndTime: &end, ByLabels: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 3 || lines[0].Labels["feature"] != "chat" || lines[0].Cost != 0.5 || lines[1].Labels["feature"] != "search_1" || lines[2].Labels != nil {
+		t.Errorf("unexpected per labels lines: %+v", lines)
+	}
+
+	// Underscores in label values match literally
+	for value, want := range map[string]int64{"chat": 1, "search_1": 1, "search%": 0, "searchx1": 0} {
+		result, err := store.SearchLogs(ctx, SearchFilters{Labels: map[string]string{"feature": value}}, PaginationOptions{Limit: 10})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Stats.TotalRequests != want {
+			t.Errorf("logs labeled feature=%s: got %d, want %d", value, result.Stats.TotalRequests, want)
+		}
+	}
+}
+
+// TestBuildClickHouseBillingQuery tests that billing filters and grouping are translated
+func TestBuildClickHouseBillingQuery(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := buildClickHouseBillingQuery("`default`.`bifrost_logs`", BillingFilters{Customers: []string{"o'neil"}, StartTime: &start, ByModel: true, ByLabels: true})
+	for _, expected := range []string{
+		"FROM `default`.`bifrost_logs` FINAL WHERE status = 'success' AND customer != ''",
+		`customer IN ('o\'neil')`,
+		"timestamp >= toDateTime64('2025-01-01 00:00:00.000', 3, 'UTC')",
+		"SELECT customer, provider, model, labels AS labels_json, count() AS requests",
+		"GROUP BY customer, provider, model, labels ORDER BY customer, provider, model, labels",
+	} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("expected %q in %q", expected, query)
+		}
+	}
+	if strings.Contains(query, "timestamp <") {
+		t.Errorf("unexpected end time condition in %q", query)
+	}
+}
@@ -0,0 +1,1128 @@
+package logstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+const (
+	DefaultClickHouseTable         = "bifrost_logs"
+	DefaultClickHouseBatchSize     = 1000
+	DefaultClickHouseFlushInterval = time.Second
+	DefaultClickHouseQueueSize     = 10000
+	DefaultClickHouseMaxRetries    = 3
+	DefaultClickHousePendingTTL    = 10 * time.Minute
+
+	clickHouseTimeLayout = "2006-01-02 15:04:05.000"
+)
+
+// ClickHouseConfig represents the configuration for the ClickHouse log store.
+// Logs are written through the ClickHouse HTTP interface, so no native driver is required.
+type ClickHouseConfig struct {
+	URL      string `json:"url"`                // HTTP endpoint, e.g. http://localhost:8123 - REQUIRED
+	Database string `json:"database,omitempty"` // Database name (default: default)
+	Table    string `json:"table,omitempty"`    // Table name (default: bifrost_logs)
+	Username string `json:"username,omitempty"` // Username (optional)
+	Password string `json:"password,omitempty"` // Password (optional)
+
+	RetentionDays   int  `json:"retention_days,omitempty"`    // Rows older than this are dropped by a table TTL (0 keeps them forever)
+	BatchSize       int  `json:"batch_size,omitempty"`        // Max rows per insert (default: 1000)
+	FlushIntervalMs int  `json:"flush_interval_ms,omitempty"` // Max time a row waits before being inserted (default: 1000)
+	QueueSize       int  `json:"queue_size,omitempty"`        // Completed rows buffered in memory (default: 10000)
+	MaxRetries      int  `json:"max_retries,omitempty"`       // Insert attempts per batch before it is dropped (default: 3)
+	DropOnFull      bool `json:"drop_on_full,omitempty"`      // Drop rows instead of blocking callers when the queue is full
+	TimeoutSeconds  int  `json:"timeout_seconds,omitempty"`   // HTTP request timeout (default: 30)
+}
+
+// ClickHouseLogStore is a log store for high volume deployments.
+//
+// ClickHouse is append-only in practice, so a log entry is kept in memory while the request is in flight
+// and only written once it reaches a terminal status. Completed rows are inserted in batches by a background
+// worker; when the queue is full callers either block (backpressure) or the row is dropped, depending on DropOnFull.
+// Late updates to an already written row insert a new version which the ReplacingMergeTree engine collapses.
+// In-flight ("processing") entries are local to the replica and are not returned by SearchLogs.
+type ClickHouseLogStore struct {
+	client   *http.Client
+	endpoint string
+	config   *ClickHouseConfig
+	table    string
+	logger   schemas.Logger
+
+	pendingMu sync.Mutex
+	pending   map[string]*Log
+
+	queue         chan *Log
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	dropped       atomic.Int64
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newClickHouseLogStore creates a new ClickHouse log store and ensures the logs table exists.
+func newClickHouseLogStore(ctx context.Context, config *ClickHouseConfig, logger schemas.Logger) (*ClickHouseLogStore, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("clickhouse url is required")
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, fmt.Errorf("invalid clickhouse url: %w", err)
+	}
+	database := config.Database
+	if database == "" {
+		database = "default"
+	}
+	table := config.Table
+	if table == "" {
+		table = DefaultClickHouseTable
+	}
+	timeout := 30 * time.Second
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	s := &ClickHouseLogStore{
+		client:        &http.Client{Timeout: timeout},
+		endpoint:      strings.TrimRight(config.URL, "/"),
+		config:        config,
+		table:         quoteIdentifier(database) + "." + quoteIdentifier(table),
+		logger:        logger,
+		pending:       make(map[string]*Log),
+		batchSize:     DefaultClickHouseBatchSize,
+		flushInterval: DefaultClickHouseFlushInterval,
+		maxRetries:    DefaultClickHouseMaxRetries,
+		done:          make(chan struct{}),
+	}
+	if config.BatchSize > 0 {
+		s.batchSize = config.BatchSize
+	}
+	if config.FlushIntervalMs > 0 {
+		s.flushInterval = time.Duration(config.FlushIntervalMs) * time.Millisecond
+	}
+	if config.MaxRetries > 0 {
+		s.maxRetries = config.MaxRetries
+	}
+	queueSize := DefaultClickHouseQueueSize
+	if config.QueueSize > 0 {
+		queueSize = config.QueueSize
+	}
+	s.queue = make(chan *Log, queueSize)
+
+	if err := s.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to clickhouse: %w", err)
+	}
+	if err := s.migrate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create clickhouse logs table: %w", err)
+	}
+
+	s.wg.Add(1)
+	go s.batchWorker()
+	return s, nil
+}
+
+// migrate creates the logs table if it does not exist and adds missing columns to an existing one.
+func (s *ClickHouseLogStore) migrate(ctx context.Context) error {
+	ttl := ""
+	if s.config.RetentionDays > 0 {
+		ttl = fmt.Sprintf(" TTL toDateTime(created_at) + INTERVAL %d DAY", s.config.RetentionDays)
+	}
+	ddl := `CREATE TABLE IF NOT EXISTS ` + s.table + ` (
+	id String,
+	parent_request_id Nullable(String),
+	timestamp DateTime64(3, 'UTC'),
+	object_type LowCardinality(String),
+	provider LowCardinality(String),
+	model LowCardinality(String),
+	input_history String,
+	output_message String,
+	embedding_output String,
+	params String,
+	tools String,
+	tool_calls String,
+	speech_input String,
+	transcription_input String,
+	speech_output String,
+	transcription_output String,
+	cache_debug String,
+	latency Nullable(Float64),
+	time_to_first_token Nullable(Float64),
+	tokens_per_second Nullable(Float64),
+	token_usage String,
+	cost Nullable(Float64),
+	status LowCardinality(String),
+	error_details String,
+	stream Bool,
+	content_summary String,
+	raw_response String,
+	prompt_tokens Int64,
+	completion_tokens Int64,
+	total_tokens Int64,
+	customer String,
+	retention_class LowCardinality(String),
+	key_id String,
+	cached_tokens Int64,
+	cache_creation_tokens Int64,
+	cache_savings Nullable(Float64),
+	language LowCardinality(String),
+	labels String,
+	created_at DateTime64(3, 'UTC'),
+	version UInt64
+) ENGINE = ReplacingMergeTree(version)
+PARTITION BY toYYYYMM(created_at)
+ORDER BY (created_at, id)` + ttl
+	if _, err := s.exec(ctx, ddl, nil); err != nil {
+		return err
+	}
+	// Columns added after the table was first released
+	_, err := s.exec(ctx, `ALTER TABLE `+s.table+`
+	ADD COLUMN IF NOT EXISTS time_to_first_token Nullable(Float64) AFTER latency,
+	ADD COLUMN IF NOT EXISTS tokens_per_second Nullable(Float64) AFTER time_to_first_token,
+	ADD COLUMN IF NOT EXISTS customer String AFTER total_tokens,
+	ADD COLUMN IF NOT EXISTS retention_class LowCardinality(String) AFTER customer,
+	ADD COLUMN IF NOT EXISTS key_id String AFTER retention_class,
+	ADD COLUMN IF NOT EXISTS cached_tokens Int64 AFTER key_id,
+	ADD COLUMN IF NOT EXISTS cache_creation_tokens Int64 AFTER cached_tokens,
+	ADD COLUMN IF NOT EXISTS cache_savings Nullable(Float64) AFTER cache_creation_tokens,
+	ADD COLUMN IF NOT EXISTS language LowCardinality(String) AFTER cache_savings,
+	ADD COLUMN IF NOT EXISTS labels String AFTER language`, nil)
+	return err
+}
+
+// Create keeps the entry in memory until it reaches a terminal status.
+func (s *ClickHouseLogStore) Create(ctx context.Context, entry *Log) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	if err := entry.SerializeFields(); err != nil {
+		return err
+	}
+	if isTerminalStatus(entry.Status) {
+		return s.enqueue(ctx, entry)
+	}
+	s.pendingMu.Lock()
+	s.pending[entry.ID] = entry
+	s.pendingMu.Unlock()
+	return nil
+}
+
+// Update applies column updates to an in-flight entry, or writes a new version of an already stored one.
+func (s *ClickHouseLogStore) Update(ctx context.Context, id string, entry any) error {
+	updates, ok := entry.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("clickhouse log store only supports map updates, got %T", entry)
+	}
+	s.pendingMu.Lock()
+	log, found := s.pending[id]
+	if found {
+		if err := applyLogUpdates(log, updates); err != nil {
+			s.pendingMu.Unlock()
+			return err
+		}
+		if !isTerminalStatus(log.Status) {
+			s.pendingMu.Unlock()
+			return nil
+		}
+		delete(s.pending, id)
+	}
+	s.pendingMu.Unlock()
+	if found {
+		return s.enqueue(ctx, log)
+	}
+
+	// Not in flight on this replica, update the stored row
+	log, err := s.FindFirst(ctx, map[string]interface{}{"id": id})
+	if err != nil {
+		return err
+	}
+	if err := applyLogUpdates(log, updates); err != nil {
+		return err
+	}
+	return s.enqueue(ctx, log)
+}
+
+// enqueue hands a completed entry to the batch worker.
+func (s *ClickHouseLogStore) enqueue(ctx context.Context, entry *Log) error {
+	select {
+	case s.queue <- entry:
+		return nil
+	default:
+	}
+	if s.config.DropOnFull {
+		if s.dropped.Add(1)%1000 == 1 {
+			s.logger.Warn("clickhouse log queue is full, dropped %d log entries so far", s.dropped.Load())
+		}
+		return nil
+	}
+	select {
+	case s.queue <- entry:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.done:
+		return fmt.Errorf("clickhouse log store is closed")
+	}
+}
+
+// batchWorker inserts queued entries when a batch fills up or the flush interval elapses.
+func (s *ClickHouseLogStore) batchWorker() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Log, 0, s.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		s.insertBatch(batch)
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case entry := <-s.queue:
+			batch = append(batch, entry)
+			if len(batch) >= s.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			s.evictStalePending(time.Now().Add(-DefaultClickHousePendingTTL))
+		case <-s.done:
+			// Drain whatever is still queued before exiting
+			for {
+				select {
+				case entry := <-s.queue:
+					batch = append(batch, entry)
+					if len(batch) >= s.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// insertBatch writes a batch using JSONEachRow, retrying with backoff before giving up.
+func (s *ClickHouseLogStore) insertBatch(batch []*Log) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	version := uint64(time.Now().UnixNano())
+	for _, entry := range batch {
+		if err := encoder.Encode(newClickHouseRow(entry, version)); err != nil {
+			s.logger.Error("failed to encode log %s for clickhouse: %v", entry.ID, err)
+		}
+	}
+	query := "INSERT INTO " + s.table + " FORMAT JSONEachRow"
+	backoff := 200 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), s.client.Timeout)
+		_, err := s.exec(ctx, query, bytes.NewReader(body.Bytes()))
+		cancel()
+		if err == nil {
+			return
+		}
+		if attempt >= s.maxRetries {
+			s.logger.Error("failed to insert %d logs into clickhouse after %d attempts: %v", len(batch), attempt, err)
+			return
+		}
+		s.logger.Warn("failed to insert logs into clickhouse (attempt %d): %v", attempt, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// evictStalePending drops in-flight entries that never completed.
+func (s *ClickHouseLogStore) evictStalePending(before time.Time) {
+	s.pendingMu.Lock()
+	defer s.pendingMu.Unlock()
+	for id, entry := range s.pending {
+		if entry.CreatedAt.Before(before) {
+			delete(s.pending, id)
+		}
+	}
+}
+
+// Flush drops in-flight entries created before the given time.
+func (s *ClickHouseLogStore) Flush(ctx context.Context, since time.Time) error {
+	s.evictStalePending(since)
+	return nil
+}
+
+// FindFirst returns the first entry matching the query, looking at in-flight entries first for id lookups.
+func (s *ClickHouseLogStore) FindFirst(ctx context.Context, query any, fields ...string) (*Log, error) {
+	if conditions, ok := query.(map[string]interface{}); ok {
+		if id, ok := conditions["id"].(string); ok && len(conditions) == 1 {
+			s.pendingMu.Lock()
+			entry, found := s.pending[id]
+			if found {
+				copied := *entry
+				s.pendingMu.Unlock()
+				return &copied, nil
+			}
+			s.pendingMu.Unlock()
+		}
+	}
+	logs, err := s.find(ctx, query, 1, fields...)
+	if err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, ErrNotFound
+	}
+	return logs[0], nil
+}
+
+// FindAll returns all stored entries matching the query.
+func (s *ClickHouseLogStore) FindAll(ctx context.Context, query any, fields ...string) ([]*Log, error) {
+	return s.find(ctx, query, 0, fields...)
+}
+
+// find runs a SELECT for the query, which is either a column/value map or a raw WHERE clause.
+func (s *ClickHouseLogStore) find(ctx context.Context, query any, limit int, fields ...string) ([]*Log, error) {
+	where, err := clickHouseWhere(query)
+	if err != nil {
+		return nil, err
+	}
+	columns := "*"
+	if len(fields) > 0 {
+		quoted := make([]string, len(fields))
+		for i, field := range fields {
+			quoted[i] = quoteIdentifier(field)
+		}
+		columns = strings.Join(quoted, ", ")
+	}
+	sql := "SELECT " + columns + " FROM " + s.table + " FINAL"
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	sql += " ORDER BY created_at DESC"
+	if limit > 0 {
+		sql += " LIMIT " + strconv.Itoa(limit)
+	}
+	return s.selectLogs(ctx, sql)
+}
+
+// SearchLogs searches stored logs; it mirrors the filters, stats, and sorting of the relational store.
+func (s *ClickHouseLogStore) SearchLogs(ctx context.Context, filters SearchFilters, pagination PaginationOptions) (*SearchResult, error) {
+	where := buildClickHouseFilters(filters)
+	from := " FROM " + s.table + " FINAL"
+	if where != "" {
+		from += " WHERE " + where
+	}
+
+	var stats struct {
+		Total      int64    `json:"total"`
+		Completed  int64    `json:"completed"`
+		Succeeded  int64    `json:"succeeded"`
+		AvgLatency *float64 `json:"avg_latency"`
+		Tokens     int64    `json:"tokens"`
+		Cost       *float64 `json:"cost"`
+	}
+	statsSQL := "SELECT count() AS total, countIf(status IN ('success', 'error')) AS completed, countIf(status = 'success') AS succeeded, " +
+		"avgIf(latency, status IN ('success', 'error')) AS avg_latency, sumIf(total_tokens, status IN ('success', 'error')) AS tokens, " +
+		"sumIf(cost, status IN ('success', 'error')) AS cost" + from + " FORMAT JSONEachRow"
+	out, err := s.exec(ctx, statsSQL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) > 0 {
+		if err := json.Unmarshal(bytes.TrimSpace(out), &stats); err != nil {
+			return nil, fmt.Errorf("failed to decode clickhouse stats: %w", err)
+		}
+	}
+	result := &SearchResult{Logs: []Log{}, Pagination: pagination}
+	result.Stats.TotalRequests = stats.Total
+	if stats.Completed > 0 {
+		result.Stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Completed) * 100
+		if stats.AvgLatency != nil {
+			result.Stats.AverageLatency = *stats.AvgLatency
+		}
+		result.Stats.TotalTokens = stats.Tokens
+		if stats.Cost != nil {
+			result.Stats.TotalCost = *stats.Cost
+		}
+	}
+	if stats.Total == 0 {
+		return result, nil
+	}
+
+	direction := "DESC"
+	if pagination.Order == "asc" {
+		direction = "ASC"
+	}
+	orderColumn := "timestamp"
+	switch pagination.SortBy {
+	case "latency":
+		orderColumn = "latency"
+	case "tokens":
+		orderColumn = "total_tokens"
+	case "cost":
+		orderColumn = "cost"
+	}
+	sql := "SELECT *" + from + " ORDER BY " + orderColumn + " " + direction
+	if pagination.Limit > 0 {
+		sql += " LIMIT " + strconv.Itoa(pagination.Limit)
+	}
+	if pagination.Offset > 0 {
+		sql += " OFFSET " + strconv.Itoa(pagination.Offset)
+	}
+	logs, err := s.selectLogs(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		result.Logs = append(result.Logs, *log)
+	}
+	return result, nil
+}
+
+// BillingReport aggregates the usage and cost of successful requests per customer.
+func (s *ClickHouseLogStore) BillingReport(ctx context.Context, filters BillingFilters) ([]BillingLine, error) {
+	out, err := s.exec(ctx, buildClickHouseBillingQuery(s.table, filters)+" FORMAT JSONEachRow", nil)
+	if err != nil {
+		return nil, err
+	}
+	rows := []billingRow{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var row billingRow
+		if err := decoder.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode clickhouse billing line: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	return billingLines(rows), nil
+}
+
+// PromptCacheReport aggregates the prompt caching of successful requests per provider, key and model.
+func (s *ClickHouseLogStore) PromptCacheReport(ctx context.Context, filters PromptCacheFilters) ([]PromptCacheLine, error) {
+	out, err := s.exec(ctx, buildClickHousePromptCacheQuery(s.table, filters)+" FORMAT JSONEachRow", nil)
+	if err != nil {
+		return nil, err
+	}
+	lines := []PromptCacheLine{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var line PromptCacheLine
+		if err := decoder.Decode(&line); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode clickhouse prompt cache line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	setTokenHitRates(lines)
+	return lines, nil
+}
+
+// LatencyMatrix computes the p50, p95 and p99 latency of successful requests per provider, model and hour.
+func (s *ClickHouseLogStore) LatencyMatrix(ctx context.Context, filters LatencyMatrixFilters) ([]LatencyCell, error) {
+	out, err := s.exec(ctx, buildClickHouseLatencyMatrixQuery(s.table, filters)+" FORMAT JSONEachRow", nil)
+	if err != nil {
+		return nil, err
+	}
+	cells := []LatencyCell{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var row struct {
+			Provider  string    `json:"provider"`
+			Model     string    `json:"model"`
+			Hour      int64     `json:"hour"` // Unix seconds
+			Requests  int64     `json:"requests"`
+			Quantiles []float64 `json:"quantiles"`
+		}
+		if err := decoder.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode clickhouse latency cell: %w", err)
+		}
+		if len(row.Quantiles) != 3 {
+			return nil, fmt.Errorf("unexpected clickhouse latency quantiles: %v", row.Quantiles)
+		}
+		cells = append(cells, LatencyCell{
+			Provider: row.Provider,
+			Model:    row.Model,
+			Hour:     time.Unix(row.Hour, 0).UTC(),
+			Requests: row.Requests,
+			P50:      row.Quantiles[0],
+			P95:      row.Quantiles[1],
+			P99:      row.Quantiles[2],
+		})
+	}
+	return cells, nil
+}
+
+// selectLogs runs a SELECT and decodes its JSONEachRow output into log entries.
+func (s *ClickHouseLogStore) selectLogs(ctx context.Context, sql string) ([]*Log, error) {
+	out, err := s.exec(ctx, sql+" FORMAT JSONEachRow", nil)
+	if err != nil {
+		return nil, err
+	}
+	logs := []*Log{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var row clickHouseRow
+		if err := decoder.Decode(&row); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to decode clickhouse row: %w", err)
+		}
+		log, err := row.toLog()
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+// exec sends a query over the HTTP interface; body, when set, is the insert payload.
+func (s *ClickHouseLogStore) exec(ctx context.Context, query string, body io.Reader) ([]byte, error) {
+	// 64 bit integers are quoted in JSON output by default, which would not decode into Go integers
+	params := url.Values{}
+	params.Set("output_format_json_quote_64bit_integers", "0")
+	if body == nil {
+		body = strings.NewReader(query)
+	} else {
+		params.Set("query", query)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/?"+params.Encode(), body)
+	if err != nil {
+		return nil, err
+	}
+	if s.config.Username != "" {
+		req.Header.Set("X-ClickHouse-User", s.config.Username)
+	}
+	if s.config.Password != "" {
+		req.Header.Set("X-ClickHouse-Key", s.config.Password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("clickhouse returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// Ping checks that ClickHouse is reachable.
+func (s *ClickHouseLogStore) Ping(ctx context.Context) error {
+	_, err := s.exec(ctx, "SELECT 1", nil)
+	return err
+}
+
+// Close stops the batch worker after inserting everything still queued.
+func (s *ClickHouseLogStore) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isTerminalStatus reports whether a log entry will not be updated anymore.
+func isTerminalStatus(status string) bool {
+	return status == "success" || status == "error"
+}
+
+// applyLogUpdates applies a column/value update map, as produced by the logging plugin, to an entry.
+func applyLogUpdates(log *Log, updates map[string]interface{}) error {
+	for column, value := range updates {
+		var err error
+		switch column {
+		case "status":
+			log.Status, err = asString(value)
+		case "model":
+			log.Model, err = asString(value)
+		case "provider":
+			log.Provider, err = asString(value)
+		case "object_type":
+			log.Object, err = asString(value)
+		case "input_history":
+			log.InputHistory, err = asString(value)
+		case "output_message":
+			log.OutputMessage, err = asString(value)
+		case "embedding_output":
+			log.EmbeddingOutput, err = asString(value)
+		case "params":
+			log.Params, err = asString(value)
+		case "tools":
+			log.Tools, err = asString(value)
+		case "tool_calls":
+			log.ToolCalls, err = asString(value)
+		case "speech_input":
+			log.SpeechInput, err = asString(value)
+		case "transcription_input":
+			log.TranscriptionInput, err = asString(value)
+		case "speech_output":
+			log.SpeechOutput, err = asString(value)
+		case "transcription_output":
+			log.TranscriptionOutput, err = asString(value)
+		case "cache_debug":
+			log.CacheDebug, err = asString(value)
+		case "token_usage":
+			log.TokenUsage, err = asString(value)
+		case "error_details":
+			log.ErrorDetails, err = asString(value)
+		case "content_summary":
+			log.ContentSummary, err = asString(value)
+		case "raw_response":
+			log.RawResponse, err = asString(value)
+		case "latency":
+			var latency float64
+			latency, err = asFloat(value)
+			log.Latency = &latency
+		case "time_to_first_token":
+			var ttft float64
+			ttft, err = asFloat(value)
+			log.TimeToFirstToken = &ttft
+		case "tokens_per_second":
+			var tps float64
+			tps, err = asFloat(value)
+			log.TokensPerSecond = &tps
+		case "cost":
+			var cost float64
+			cost, err = asFloat(value)
+			log.Cost = &cost
+		case "prompt_tokens":
+			log.PromptTokens, err = asInt(value)
+		case "completion_tokens":
+			log.CompletionTokens, err = asInt(value)
+		case "total_tokens":
+			log.TotalTokens, err = asInt(value)
+		case "cached_tokens":
+			log.CachedTokens, err = asInt(value)
+		case "cache_creation_tokens":
+			log.CacheCreationTokens, err = asInt(value)
+		case "cache_savings":
+			var savings float64
+			savings, err = asFloat(value)
+			log.CacheSavings = &savings
+		case "key_id":
+			log.KeyID, err = asString(value)
+		case "stream":
+			stream, ok := value.(bool)
+			if !ok {
+				err = fmt.Errorf("expected bool")
+			}
+			log.Stream = stream
+		case "timestamp":
+			timestamp, ok := value.(time.Time)
+			if !ok {
+				err = fmt.Errorf("expected time.Time")
+			}
+			log.Timestamp = timestamp
+		default:
+			return fmt.Errorf("unsupported log column: %s", column)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for log column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+func asString(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case *string:
+		if v == nil {
+			return "", nil
+		}
+		return *v, nil
+	}
+	return "", fmt.Errorf("expected string, got %T", value)
+}
+
+func asFloat(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("expected number, got %T", value)
+}
+
+func asInt(value any) (int, error) {
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	}
+	return 0, fmt.Errorf("expected integer, got %T", value)
+}
+
+// clickHouseRow is the JSONEachRow representation of a log entry.
+type clickHouseRow struct {
+	ID                  string   `json:"id"`
+	ParentRequestID     *string  `json:"parent_request_id"`
+	Timestamp           string   `json:"timestamp"`
+	Object              string   `json:"object_type"`
+	Provider            string   `json:"provider"`
+	Model               string   `json:"model"`
+	InputHistory        string   `json:"input_history"`
+	OutputMessage       string   `json:"output_message"`
+	EmbeddingOutput     string   `json:"embedding_output"`
+	Params              string   `json:"params"`
+	Tools               string   `json:"tools"`
+	ToolCalls           string   `json:"tool_calls"`
+	SpeechInput         string   `json:"speech_input"`
+	TranscriptionInput  string   `json:"transcription_input"`
+	SpeechOutput        string   `json:"speech_output"`
+	TranscriptionOutput string   `json:"transcription_output"`
+	CacheDebug          string   `json:"cache_debug"`
+	Latency             *float64 `json:"latency"`
+	TimeToFirstToken    *float64 `json:"time_to_first_token"`
+	TokensPerSecond     *float64 `json:"tokens_per_second"`
+	TokenUsage          string   `json:"token_usage"`
+	Cost                *float64 `json:"cost"`
+	Status              string   `json:"status"`
+	ErrorDetails        string   `json:"error_details"`
+	Stream              bool     `json:"stream"`
+	ContentSummary      string   `json:"content_summary"`
+	RawResponse         string   `json:"raw_response"`
+	PromptTokens        int      `json:"prompt_tokens"`
+	CompletionTokens    int      `json:"completion_tokens"`
+	TotalTokens         int      `json:"total_tokens"`
+	Customer            string   `json:"customer"`
+	RetentionClass      string   `json:"retention_class"`
+	KeyID               string   `json:"key_id"`
+	CachedTokens        int      `json:"cached_tokens"`
+	CacheCreationTokens int      `json:"cache_creation_tokens"`
+	CacheSavings        *float64 `json:"cache_savings"`
+	Language            string   `json:"language"`
+	Labels              string   `json:"labels"`
+	CreatedAt           string   `json:"created_at"`
+	Version             uint64   `json:"version"`
+}
+
+func newClickHouseRow(l *Log, version uint64) *clickHouseRow {
+	return &clickHouseRow{
+		ID:                  l.ID,
+		ParentRequestID:     l.ParentRequestID,
+		Timestamp:           l.Timestamp.UTC().Format(clickHouseTimeLayout),
+		Object:              l.Object,
+		Provider:            l.Provider,
+		Model:               l.Model,
+		InputHistory:        l.InputHistory,
+		OutputMessage:       l.OutputMessage,
+		EmbeddingOutput:     l.EmbeddingOutput,
+		Params:              l.Params,
+		Tools:               l.Tools,
+		ToolCalls:           l.ToolCalls,
+		SpeechInput:         l.SpeechInput,
+		TranscriptionInput:  l.TranscriptionInput,
+		SpeechOutput:        l.SpeechOutput,
+		TranscriptionOutput: l.TranscriptionOutput,
+		CacheDebug:          l.CacheDebug,
+		Latency:             l.Latency,
+		TimeToFirstToken:    l.TimeToFirstToken,
+		TokensPerSecond:     l.TokensPerSecond,
+		TokenUsage:          l.TokenUsage,
+		Cost:                l.Cost,
+		Status:              l.Status,
+		ErrorDetails:        l.ErrorDetails,
+		Stream:              l.Stream,
+		ContentSummary:      l.ContentSummary,
+		RawResponse:         l.RawResponse,
+		PromptTokens:        l.PromptTokens,
+		CompletionTokens:    l.CompletionTokens,
+		TotalTokens:         l.TotalTokens,
+		Customer:            l.Customer,
+		RetentionClass:      l.RetentionClass,
+		KeyID:               l.KeyID,
+		CachedTokens:        l.CachedTokens,
+		CacheCreationTokens: l.CacheCreationTokens,
+		CacheSavings:        l.CacheSavings,
+		Language:            l.Language,
+		Labels:              l.Labels,
+		CreatedAt:           l.CreatedAt.UTC().Format(clickHouseTimeLayout),
+		Version:             version,
+	}
+}
+
+func (r *clickHouseRow) toLog() (*Log, error) {
+	l := &Log{
+		ID:                  r.ID,
+		ParentRequestID:     r.ParentRequestID,
+		Object:              r.Object,
+		Provider:            r.Provider,
+		Model:               r.Model,
+		InputHistory:        r.InputHistory,
+		OutputMessage:       r.OutputMessage,
+		EmbeddingOutput:     r.EmbeddingOutput,
+		Params:              r.Params,
+		Tools:               r.Tools,
+		ToolCalls:           r.ToolCalls,
+		SpeechInput:         r.SpeechInput,
+		TranscriptionInput:  r.TranscriptionInput,
+		SpeechOutput:        r.SpeechOutput,
+		TranscriptionOutput: r.TranscriptionOutput,
+		CacheDebug:          r.CacheDebug,
+		Latency:             r.Latency,
+		TimeToFirstToken:    r.TimeToFirstToken,
+		TokensPerSecond:     r.TokensPerSecond,
+		TokenUsage:          r.TokenUsage,
+		Cost:                r.Cost,
+		Status:              r.Status,
+		ErrorDetails:        r.ErrorDetails,
+		Stream:              r.Stream,
+		ContentSummary:      r.ContentSummary,
+		RawResponse:         r.RawResponse,
+		PromptTokens:        r.PromptTokens,
+		CompletionTokens:    r.CompletionTokens,
+		TotalTokens:         r.TotalTokens,
+		Customer:            r.Customer,
+		RetentionClass:      r.RetentionClass,
+		KeyID:               r.KeyID,
+		CachedTokens:        r.CachedTokens,
+		CacheCreationTokens: r.CacheCreationTokens,
+		CacheSavings:        r.CacheSavings,
+		Language:            r.Language,
+		Labels:              r.Labels,
+	}
+	var err error
+	if r.Timestamp != "" {
+		if l.Timestamp, err = time.ParseInLocation(clickHouseTimeLayout, r.Timestamp, time.UTC); err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", r.Timestamp, err)
+		}
+	}
+	if r.CreatedAt != "" {
+		if l.CreatedAt, err = time.ParseInLocation(clickHouseTimeLayout, r.CreatedAt, time.UTC); err != nil {
+			return nil, fmt.Errorf("invalid created_at %q: %w", r.CreatedAt, err)
+		}
+	}
+	if err := l.DeserializeFields(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// buildClickHouseFilters converts search filters into a WHERE clause.
+func buildClickHouseFilters(filters SearchFilters) string {
+	var conditions []string
+	if len(filters.Providers) > 0 {
+		conditions = append(conditions, "provider IN "+quoteStringList(filters.Providers))
+	}
+	if len(filters.Models) > 0 {
+		conditions = append(conditions, "model IN "+quoteStringList(filters.Models))
+	}
+	if len(filters.Status) > 0 {
+		conditions = append(conditions, "status IN "+quoteStringList(filters.Status))
+	}
+	if len(filters.Objects) > 0 {
+		conditions = append(conditions, "object_type IN "+quoteStringList(filters.Objects))
+	}
+	if filters.StartTime != nil {
+		conditions = append(conditions, "timestamp >= "+quoteTime(*filters.StartTime))
+	}
+	if filters.EndTime != nil {
+		conditions = append(conditions, "timestamp <= "+quoteTime(*filters.EndTime))
+	}
+	if filters.MinLatency != nil {
+		conditions = append(conditions, "latency >= "+strconv.FormatFloat(*filters.MinLatency, 'f', -1, 64))
+	}
+	if filters.MaxLatency != nil {
+		conditions = append(conditions, "latency <= "+strconv.FormatFloat(*filters.MaxLatency, 'f', -1, 64))
+	}
+	if filters.MinTokens != nil {
+		conditions = append(conditions, "total_tokens >= "+strconv.Itoa(*filters.MinTokens))
+	}
+	if filters.MaxTokens != nil {
+		conditions = append(conditions, "total_tokens <= "+strconv.Itoa(*filters.MaxTokens))
+	}
+	if filters.MinCost != nil {
+		conditions = append(conditions, "cost >= "+strconv.FormatFloat(*filters.MinCost, 'f', -1, 64))
+	}
+	if filters.MaxCost != nil {
+		conditions = append(conditions, "cost <= "+strconv.FormatFloat(*filters.MaxCost, 'f', -1, 64))
+	}
+	if filters.ContentSearch != "" {
+		conditions = append(conditions, "positionCaseInsensitive(content_summary, "+quoteString(filters.ContentSearch)+") > 0")
+	}
+	if len(filters.Customers) > 0 {
+		conditions = append(conditions, "customer IN "+quoteStringList(filters.Customers))
+	}
+	if len(filters.Languages) > 0 {
+		conditions = append(conditions, "language IN "+quoteStringList(filters.Languages))
+	}
+	labelNames := make([]string, 0, len(filters.Labels))
+	for name := range filters.Labels {
+		labelNames = append(labelNames, name)
+	}
+	sort.Strings(labelNames)
+	for _, name := range labelNames {
+		conditions = append(conditions, "JSONExtractString(labels, "+quoteString(name)+") = "+quoteString(filters.Labels[name]))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+// buildClickHouseBillingQuery builds the aggregation query of a billing report.
+func buildClickHouseBillingQuery(table string, filters BillingFilters) string {
+	conditions := []string{"status = 'success'", "customer != ''"}
+	if len(filters.Customers) > 0 {
+		conditions = append(conditions, "customer IN "+quoteStringList(filters.Customers))
+	}
+	if filters.StartTime != nil {
+		conditions = append(conditions, "timestamp >= "+quoteTime(*filters.StartTime))
+	}
+	if filters.EndTime != nil {
+		conditions = append(conditions, "timestamp < "+quoteTime(*filters.EndTime))
+	}
+	group := billingGroupColumns(filters)
+	return "SELECT " + billingSelectColumns(filters) + ", count() AS requests, sum(prompt_tokens) AS prompt_tokens, sum(completion_tokens) AS completion_tokens, " +
+		"sum(total_tokens) AS total_tokens, ifNull(sum(cost), 0) AS cost FROM " + table + " FINAL WHERE " + strings.Join(conditions, " AND ") +
+		" GROUP BY " + group + " ORDER BY " + group
+}
+
+// buildClickHousePromptCacheQuery builds the aggregation query of a prompt cache report.
+func buildClickHousePromptCacheQuery(table string, filters PromptCacheFilters) string {
+	conditions := []string{"status = 'success'", "prompt_tokens > 0"}
+	if len(filters.Providers) > 0 {
+		conditions = append(conditions, "provider IN "+quoteStringList(filters.Providers))
+	}
+	if filters.StartTime != nil {
+		conditions = append(conditions, "timestamp >= "+quoteTime(*filters.StartTime))
+	}
+	if filters.EndTime != nil {
+		conditions = append(conditions, "timestamp < "+quoteTime(*filters.EndTime))
+	}
+	group := promptCacheGroupColumns(filters)
+	return "SELECT " + group + ", count() AS requests, countIf(cached_tokens > 0) AS cache_hit_requests, sum(prompt_tokens) AS prompt_tokens, " +
+		"sum(cached_tokens) AS cached_tokens, sum(cache_creation_tokens) AS cache_creation_tokens, ifNull(sum(cache_savings), 0) AS cache_savings FROM " +
+		table + " FINAL WHERE " + strings.Join(conditions, " AND ") + " GROUP BY " + group + " ORDER BY " + group
+}
+
+// buildClickHouseLatencyMatrixQuery builds the aggregation query of a latency matrix with exact percentiles.
+func buildClickHouseLatencyMatrixQuery(table string, filters LatencyMatrixFilters) string {
+	conditions := []string{"status = 'success'", "latency IS NOT NULL"}
+	if len(filters.Providers) > 0 {
+		conditions = append(conditions, "provider IN "+quoteStringList(filters.Providers))
+	}
+	if len(filters.Models) > 0 {
+		conditions = append(conditions, "model IN "+quoteStringList(filters.Models))
+	}
+	if filters.StartTime != nil {
+		conditions = append(conditions, "timestamp >= "+quoteTime(*filters.StartTime))
+	}
+	if filters.EndTime != nil {
+		conditions = append(conditions, "timestamp < "+quoteTime(*filters.EndTime))
+	}
+	return "SELECT provider, model, toUnixTimestamp(toStartOfHour(timestamp)) AS hour, count() AS requests, " +
+		"quantilesExact(0.5, 0.95, 0.99)(assumeNotNull(latency)) AS quantiles FROM " + table + " FINAL WHERE " +
+		strings.Join(conditions, " AND ") + " GROUP BY provider, model, hour ORDER BY provider, model, hour"
+}
+
+// clickHouseWhere converts a FindFirst/FindAll query into a WHERE clause.
+// Maps are matched column by column; strings are trusted raw conditions written by Bifrost itself.
+func clickHouseWhere(query any) (string, error) {
+	switch q := query.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return q, nil
+	case map[string]interface{}:
+		columns := make([]string, 0, len(q))
+		for column := range q {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		conditions := make([]string, 0, len(q))
+		for _, column := range columns {
+			literal, err := clickHouseLiteral(q[column])
+			if err != nil {
+				return "", fmt.Errorf("invalid value for %s: %w", column, err)
+			}
+			conditions = append(conditions, quoteIdentifier(column)+" = "+literal)
+		}
+		return strings.Join(conditions, " AND "), nil
+	}
+	return "", fmt.Errorf("unsupported clickhouse query type: %T", query)
+}
+
+func clickHouseLiteral(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return quoteString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return quoteTime(v), nil
+	}
+	return "", fmt.Errorf("unsupported type %T", value)
+}
+
+// quoteString returns a ClickHouse string literal.
+func quoteString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+func quoteStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteString(value)
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+func quoteTime(value time.Time) string {
+	return "toDateTime64(" + quoteString(value.UTC().Format(clickHouseTimeLayout)) + ", 3, 'UTC')"
+}
+
+// quoteIdentifier returns a backtick quoted ClickHouse identifier.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
@@ -0,0 +1,76 @@
+package logstore
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestClickHouseWhere_Map tests that map queries become escaped equality conditions in a stable order
+func TestClickHouseWhere_Map(t *testing.T) {
+	where, err := clickHouseWhere(map[string]interface{}{"status": "success", "id": "it's"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "`id` = 'it\\'s' AND `status` = 'success'"
+	if where != expected {
+		t.Errorf("expected %q, got %q", expected, where)
+	}
+}
+
+// TestClickHouseWhere_UnsupportedType tests that unknown query types are rejected
+func TestClickHouseWhere_UnsupportedType(t *testing.T) {
+	if _, err := clickHouseWhere(42); err == nil {
+		t.Error("expected error for unsupported query type")
+	}
+}
+
+// TestBuildClickHouseFilters tests that search filters are translated and string values are quoted
+func TestBuildClickHouseFilters(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	minTokens := 10
+	where := buildClickHouseFilters(SearchFilters{
+		Providers:     []string{"openai", "anthropic"},
+		StartTime:     &start,
+		MinTokens:     &minTokens,
+		ContentSearch: `50% o'clock`,
+		Labels:        map[string]string{"env": "prod"},
+	})
+	for _, expected := range []string{
+		"provider IN ('openai', 'anthropic')",
+		"timestamp >= toDateTime64('2025-01-02 03:04:05.000', 3, 'UTC')",
+		"total_tokens >= 10",
+		`positionCaseInsensitive(content_summary, '50% o\'clock') > 0`,
+		"JSONExtractString(labels, 'env') = 'prod'",
+	} {
+		if !strings.Contains(where, expected) {
+			t.Errorf("expected filter %q in %q", expected, where)
+		}
+	}
+}
+
+// TestApplyLogUpdates tests that plugin update maps are applied to in-flight entries
+func TestApplyLogUpdates(t *testing.T) {
+	entry := &Log{ID: "req-1", Status: "processing"}
+	err := applyLogUpdates(entry, map[string]interface{}{
+		"status":       "success",
+		"latency":      12.5,
+		"total_tokens": 42,
+		"stream":       true,
+
+		"time_to_first_token": float64(180),
+		"tokens_per_second":   55.5,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entry.Status != "success" || entry.Latency == nil || *entry.Latency != 12.5 || entry.TotalTokens != 42 || !entry.Stream {
+		t.Errorf("updates not applied: %+v", entry)
+	}
+	if entry.TimeToFirstToken == nil || *entry.TimeToFirstToken != 180 || entry.TokensPerSecond == nil || *entry.TokensPerSecond != 55.5 {
+		t.Errorf("stream timings not applied: %+v", entry)
+	}
+	if err := applyLogUpdates(entry, map[string]interface{}{"unknown": 1}); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
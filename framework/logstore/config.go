@@ -53,6 +53,15 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("failed to unmarshal postgres config: %w", err)
 		}
 		c.Config = &postgresConfig
+	case LogStoreTypeClickHouse:
+		if len(temp.Config) == 0 {
+			return fmt.Errorf("missing clickhouse config payload")
+		}
+		var clickHouseConfig ClickHouseConfig
+		if err := json.Unmarshal(temp.Config, &clickHouseConfig); err != nil {
+			return fmt.Errorf("failed to unmarshal clickhouse config: %w", err)
+		}
+		c.Config = &clickHouseConfig
 	default:
 		return fmt.Errorf("unknown log store type: %s", temp.Type)
 	}
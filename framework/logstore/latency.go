@@ -0,0 +1,84 @@
+package logstore
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// LatencyMatrixFilters selects the requests covered by a latency matrix.
+// Only successful requests with a latency are counted.
+type LatencyMatrixFilters struct {
+	Providers []string   // Providers to report (all when empty)
+	Models    []string   // Models to report (all when empty)
+	StartTime *time.Time // Inclusive
+	EndTime   *time.Time // Exclusive
+}
+
+// LatencyCell is the latency of the requests to a model of a provider during an hour.
+type LatencyCell struct {
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Hour     time.Time `json:"hour"` // Start of the hour, UTC
+	Requests int64     `json:"requests"`
+	P50      float64   `json:"p50"` // In milliseconds
+	P95      float64   `json:"p95"`
+	P99      float64   `json:"p99"`
+}
+
+// latencyCellKey identifies the cell of a request in a latency matrix.
+type latencyCellKey struct {
+	provider string
+	model    string
+	hour     time.Time
+}
+
+// latencyMatrix groups request latencies into cells and computes their percentiles.
+type latencyMatrix map[latencyCellKey][]float64
+
+// add counts the latency of a request.
+func (m latencyMatrix) add(provider, model string, timestamp time.Time, latency float64) {
+	key := latencyCellKey{provider: provider, model: model, hour: timestamp.UTC().Truncate(time.Hour)}
+	m[key] = append(m[key], latency)
+}
+
+// cells returns the cells ordered by provider, model and hour.
+func (m latencyMatrix) cells() []LatencyCell {
+	cells := make([]LatencyCell, 0, len(m))
+	for key, latencies := range m {
+		sort.Float64s(latencies)
+		cells = append(cells, LatencyCell{
+			Provider: key.provider,
+			Model:    key.model,
+			Hour:     key.hour,
+			Requests: int64(len(latencies)),
+			P50:      latencyPercentile(latencies, 0.50),
+			P95:      latencyPercentile(latencies, 0.95),
+			P99:      latencyPercentile(latencies, 0.99),
+		})
+	}
+	sortLatencyCells(cells)
+	return cells
+}
+
+// latencyPercentile returns the nearest-rank percentile q of sorted latencies.
+func latencyPercentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// sortLatencyCells orders cells by provider, model and hour.
+func sortLatencyCells(cells []LatencyCell) {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Provider != cells[j].Provider {
+			return cells[i].Provider < cells[j].Provider
+		}
+		if cells[i].Model != cells[j].Model {
+			return cells[i].Model < cells[j].Model
+		}
+		return cells[i].Hour.Before(cells[j].Hour)
+	})
+}
@@ -0,0 +1,58 @@
+package logstore
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestRDBLatencyMatrix tests that the latencies of successful requests are grouped per provider, model and hour
+// with nearest-rank percentiles
+func TestRDBLatencyMatrix(t *testing.T) {
+	ctx := context.Background()
+	store, err := newSqliteLogStore(ctx, &SQLiteConfig{Path: filepath.Join(t.TempDir(), "logs.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to create sqlite log store: %v", err)
+	}
+	hour := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	latency := func(value float64) *float64 { return &value }
+	entries := []*Log{
+		{ID: "slow", Provider: "openai", Model: "gpt-4o", Status: "success", Timestamp: hour.Add(59 * time.Minute), Latency: latency(900)},
+		{ID: "error", Provider: "openai", Model: "gpt-4o", Status: "error", Timestamp: hour, Latency: latency(5000)},
+		{ID: "no-latency", Provider: "openai", Model: "gpt-4o", Status: "success", Timestamp: hour},
+		{ID: "next-hour", Provider: "openai", Model: "gpt-4o", Status: "success", Timestamp: hour.Add(time.Hour), Latency: latency(300)},
+		{ID: "anthropic", Provider: "anthropic", Model: "claude", Status: "success", Timestamp: hour, Latency: latency(250)},
+	}
+	for i := 1; i <= 99; i++ {
+		entries = append(entries, &Log{ID: "fast-" + strconv.Itoa(i), Provider: "openai", Model: "gpt-4o", Status: "success",
+			Timestamp: hour.Add(time.Duration(i) * time.Second), Latency: latency(float64(i))})
+	}
+	for _, entry := range entries {
+		entry.CreatedAt = entry.Timestamp
+		if err := store.Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create log %s: %v", entry.ID, err)
+		}
+	}
+
+	cells, err := store.LatencyMatrix(ctx, LatencyMatrixFilters{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cells) != 3 || cells[0].Provider != "anthropic" || cells[1].Model != "gpt-4o" || !cells[1].Hour.Equal(hour) || !cells[2].Hour.Equal(hour.Add(time.Hour)) {
+		t.Fatalf("unexpected cells: %+v", cells)
+	}
+	if cell := cells[1]; cell.Requests != 100 || cell.P50 != 50 || cell.P95 != 95 || cell.P99 != 99 {
+		t.Errorf("unexpected gpt-4o cell at %s: %+v", hour, cell)
+	}
+
+	end := hour.Add(time.Hour)
+	cells, err = store.LatencyMatrix(ctx, LatencyMatrixFilters{Providers: []string{"openai"}, EndTime: &end})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cells) != 1 || cells[0].Provider != "openai" || cells[0].Requests != 100 {
+		t.Errorf("unexpected filtered cells: %+v", cells)
+	}
+}
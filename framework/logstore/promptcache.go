@@ -0,0 +1,51 @@
+package logstore
+
+import (
+	"strings"
+	"time"
+)
+
+// PromptCacheFilters selects the requests covered by a prompt cache report.
+// Only successful requests with prompt tokens are counted.
+type PromptCacheFilters struct {
+	Providers []string   // Providers to report (all when empty)
+	StartTime *time.Time // Inclusive
+	EndTime   *time.Time // Exclusive
+	ByKey     bool       // Break each provider's usage down by provider key
+	ByModel   bool       // Break each provider's usage down by model
+}
+
+// PromptCacheLine is the prompt caching of a provider, or of one key and/or model of a provider.
+type PromptCacheLine struct {
+	Provider            string  `json:"provider"`
+	KeyID               string  `json:"key_id,omitempty"`
+	Model               string  `json:"model,omitempty"`
+	Requests            int64   `json:"requests"`
+	CacheHitRequests    int64   `json:"cache_hit_requests"` // Requests with prompt tokens read from the cache
+	PromptTokens        int64   `json:"prompt_tokens"`
+	CachedTokens        int64   `json:"cached_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheSavings        float64 `json:"cache_savings"`  // In dollars
+	TokenHitRate        float64 `json:"token_hit_rate"` // Share of the prompt tokens read from the cache
+}
+
+// promptCacheGroupColumns returns the columns prompt cache lines are grouped and ordered by.
+func promptCacheGroupColumns(filters PromptCacheFilters) string {
+	columns := []string{"provider"}
+	if filters.ByKey {
+		columns = append(columns, "key_id")
+	}
+	if filters.ByModel {
+		columns = append(columns, "model")
+	}
+	return strings.Join(columns, ", ")
+}
+
+// setTokenHitRates computes the token hit rate of each line.
+func setTokenHitRates(lines []PromptCacheLine) {
+	for i := range lines {
+		if lines[i].PromptTokens > 0 {
+			lines[i].TokenHitRate = float64(lines[i].CachedTokens) / float64(lines[i].PromptTokens)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package logstore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// TestRDBPromptCacheReport tests that the cached tokens of successful requests are aggregated per key and model
+func TestRDBPromptCacheReport(t *testing.T) {
+	ctx := context.Background()
+	store, err := newSqliteLogStore(ctx, &SQLiteConfig{Path: filepath.Join(t.TempDir(), "logs.db")}, nil)
+	if err != nil {
+		t.Fatalf("failed to create sqlite log store: %v", err)
+	}
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	savings := func(value float64) *float64 { return &value }
+	usage := func(prompt, cached, created int) *schemas.LLMUsage {
+		return &schemas.LLMUsage{PromptTokens: prompt, TotalTokens: prompt, PromptTokensDetails: &schemas.TokenDetails{CachedTokens: cached, CacheCreationTokens: created}}
+	}
+	for _, entry := range []*Log{
+		{ID: "1", KeyID: "key-a", Provider: "anthropic", Model: "claude", Status: "success", TokenUsageParsed: usage(1000, 0, 800), CacheSavings: savings(-0.5)},
+		{ID: "2", KeyID: "key-a", Provider: "anthropic", Model: "claude", Status: "success", TokenUsageParsed: usage(1000, 800, 0), CacheSavings: savings(2)},
+		{ID: "3", KeyID: "key-b", Provider: "anthropic", Model: "claude", Status: "success", TokenUsageParsed: &schemas.LLMUsage{PromptTokens: 500, TotalTokens: 500}},
+		{ID: "4", KeyID: "key-c", Provider: "openai", Model: "gpt-4o", Status: "success", TokenUsageParsed: usage(2000, 1024, 0), CacheSavings: savings(1)},
+		{ID: "5", KeyID: "key-a", Provider: "anthropic", Model: "claude", Status: "error", TokenUsageParsed: usage(1000, 1000, 0)},
+	} {
+		entry.Timestamp = day
+		entry.CreatedAt = day
+		if err := store.Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create log %s: %v", entry.ID, err)
+		}
+	}
+
+	lines, err := store.PromptCacheReport(ctx, PromptCacheFilters{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 providers, got %+v", lines)
+	}
+	anthropic := lines[0]
+	if anthropic.Provider != "anthropic" || anthropic.Requests != 3 || anthropic.CacheHitRequests != 1 || anthropic.PromptTokens != 2500 ||
+		anthropic.CachedTokens != 800 || anthropic.CacheCreationTokens != 800 || anthropic.CacheSavings != 1.5 || anthropic.TokenHitRate != 0.32 {
+		t.Errorf("unexpected anthropic line: %+v", anthropic)
+	}
+
+	start := day.Add(-time.Hour)
+	lines, err = store.PromptCacheReport(ctx, PromptCacheFilters{Providers: []string{"anthropic"}, StartTime: &start, ByKey: true, ByModel: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 2 || lines[0].KeyID != "key-a" || lines[0].Model != "claude" || lines[0].TokenHitRate != 0.4 || lines[1].KeyID != "key-b" || lines[1].TokenHitRate != 0 {
+		t.Errorf("unexpected per key lines: %+v", lines)
+	}
+}
+
+// TestBuildClickHousePromptCacheQuery tests that prompt cache filters and grouping are translated
+func TestBuildClickHousePromptCacheQuery(t *testing.T) {
+	end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := buildClickHousePromptCacheQuery("`default`.`bifrost_logs`", PromptCacheFilters{Providers: []string{"anthropic"}, EndTime: &end, ByKey: true})
+	for _, expected := range []string{
+		"countIf(cached_tokens > 0) AS cache_hit_requests",
+		"FROM `default`.`bifrost_logs` FINAL WHERE status = 'success' AND prompt_tokens > 0",
+		"provider IN ('anthropic')",
+		"timestamp < toDateTime64('2025-01-01 00:00:00.000', 3, 'UTC')",
+		"GROUP BY provider, key_id ORDER BY provider, key_id",
+	} {
+		if !strings.Contains(query, expected) {
+			t.Errorf("expected %q in %q", expected, query)
+		}
+	}
+}
@@ -3,8 +3,10 @@ package logstore
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/maximhq/bifrost/core/schemas"
@@ -17,6 +19,11 @@ type RDBLogStore struct {
 	logger schemas.Logger
 }
 
+// DB returns the underlying database connection.
+func (s *RDBLogStore) DB() *gorm.DB {
+	return s.db
+}
+
 // Create inserts a new log entry into the database.
 func (s *RDBLogStore) Create(ctx context.Context, entry *Log) error {
 	return s.db.WithContext(ctx).Create(entry).Error
@@ -78,6 +85,15 @@ func (s *RDBLogStore) SearchLogs(ctx context.Context, filters SearchFilters, pag
 	if filters.ContentSearch != "" {
 		baseQuery = baseQuery.Where("content_summary LIKE ?", "%"+filters.ContentSearch+"%")
 	}
+	if len(filters.Customers) > 0 {
+		baseQuery = baseQuery.Where("customer IN ?", filters.Customers)
+	}
+	if len(filters.Languages) > 0 {
+		baseQuery = baseQuery.Where("language IN ?", filters.Languages)
+	}
+	for name, value := range filters.Labels {
+		baseQuery = baseQuery.Where(`labels LIKE ? ESCAPE '\'`, labelPattern(name, value))
+	}
 
 	// Get total count
 	var totalCount int64
@@ -182,6 +198,91 @@ func (s *RDBLogStore) SearchLogs(ctx context.Context, filters SearchFilters, pag
 	}, nil
 }
 
+// BillingReport aggregates the usage and cost of successful requests per customer.
+func (s *RDBLogStore) BillingReport(ctx context.Context, filters BillingFilters) ([]BillingLine, error) {
+	query := s.db.WithContext(ctx).Model(&Log{}).Where("status = ? AND customer <> ''", "success")
+	if len(filters.Customers) > 0 {
+		query = query.Where("customer IN ?", filters.Customers)
+	}
+	if filters.StartTime != nil {
+		query = query.Where("timestamp >= ?", *filters.StartTime)
+	}
+	if filters.EndTime != nil {
+		query = query.Where("timestamp < ?", *filters.EndTime)
+	}
+	group := billingGroupColumns(filters)
+	rows := []billingRow{}
+	err := query.Select(billingSelectColumns(filters) + ", COUNT(*) AS requests, COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+		"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, COALESCE(SUM(total_tokens), 0) AS total_tokens, " +
+		"COALESCE(SUM(cost), 0) AS cost").Group(group).Order(group).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return billingLines(rows), nil
+}
+
+// PromptCacheReport aggregates the prompt caching of successful requests per provider, key and model.
+func (s *RDBLogStore) PromptCacheReport(ctx context.Context, filters PromptCacheFilters) ([]PromptCacheLine, error) {
+	query := s.db.WithContext(ctx).Model(&Log{}).Where("status = ? AND prompt_tokens > 0", "success")
+	if len(filters.Providers) > 0 {
+		query = query.Where("provider IN ?", filters.Providers)
+	}
+	if filters.StartTime != nil {
+		query = query.Where("timestamp >= ?", *filters.StartTime)
+	}
+	if filters.EndTime != nil {
+		query = query.Where("timestamp < ?", *filters.EndTime)
+	}
+	group := promptCacheGroupColumns(filters)
+	lines := []PromptCacheLine{}
+	err := query.Select(group + ", COUNT(*) AS requests, COALESCE(SUM(CASE WHEN cached_tokens > 0 THEN 1 ELSE 0 END), 0) AS cache_hit_requests, " +
+		"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, COALESCE(SUM(cached_tokens), 0) AS cached_tokens, " +
+		"COALESCE(SUM(cache_creation_tokens), 0) AS cache_creation_tokens, COALESCE(SUM(cache_savings), 0) AS cache_savings").
+		Group(group).Order(group).Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	setTokenHitRates(lines)
+	return lines, nil
+}
+
+// LatencyMatrix computes the p50, p95 and p99 latency of successful requests per provider, model and hour.
+// Percentiles are not portable across SQL dialects, so latencies are streamed and grouped in memory.
+func (s *RDBLogStore) LatencyMatrix(ctx context.Context, filters LatencyMatrixFilters) ([]LatencyCell, error) {
+	query := s.db.WithContext(ctx).Model(&Log{}).Where("status = ? AND latency IS NOT NULL", "success")
+	if len(filters.Providers) > 0 {
+		query = query.Where("provider IN ?", filters.Providers)
+	}
+	if len(filters.Models) > 0 {
+		query = query.Where("model IN ?", filters.Models)
+	}
+	if filters.StartTime != nil {
+		query = query.Where("timestamp >= ?", *filters.StartTime)
+	}
+	if filters.EndTime != nil {
+		query = query.Where("timestamp < ?", *filters.EndTime)
+	}
+	rows, err := query.Select("provider, model, timestamp, latency").Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	matrix := latencyMatrix{}
+	for rows.Next() {
+		var provider, model string
+		var timestamp time.Time
+		var latency float64
+		if err := rows.Scan(&provider, &model, &timestamp, &latency); err != nil {
+			return nil, err
+		}
+		matrix.add(provider, model, timestamp, latency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return matrix.cells(), nil
+}
+
 // FindFirst gets a log entry from the database.
 func (s *RDBLogStore) FindFirst(ctx context.Context, query any, fields ...string) (*Log, error) {
 	var log Log
@@ -215,6 +316,15 @@ func (s *RDBLogStore) FindAll(ctx context.Context, query any, fields ...string)
 	return logs, nil
 }
 
+// Ping checks that the database is reachable.
+func (s *RDBLogStore) Ping(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 // Close closes the log store.
 func (s *RDBLogStore) Close(ctx context.Context) error {
 	sqlDB, err := s.db.WithContext(ctx).DB()
@@ -223,3 +333,12 @@ func (s *RDBLogStore) Close(ctx context.Context) error {
 	}
 	return sqlDB.Close()
 }
+
+// labelPattern returns the LIKE pattern matching the serialized labels carrying a label with a value, escaped with
+// a backslash.
+func labelPattern(name, value string) string {
+	encodedName, _ := json.Marshal(name)
+	encodedValue, _ := json.Marshal(value)
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(string(encodedName) + ":" + string(encodedValue))
+	return "%" + escaped + "%"
+}
@@ -15,6 +15,7 @@ type LogStoreType string
 const (
 	LogStoreTypeSQLite LogStoreType = "sqlite"
 	LogStoreTypePostgres LogStoreType = "postgres"
+	LogStoreTypeClickHouse LogStoreType = "clickhouse"
 )
 
 // LogStore is the interface for the log store.
@@ -23,8 +24,12 @@ type LogStore interface {
 	FindFirst(ctx context.Context, query any, fields ...string) (*Log, error)
 	FindAll(ctx context.Context, query any, fields ...string) ([]*Log, error)
 	SearchLogs(ctx context.Context, filters SearchFilters, pagination PaginationOptions) (*SearchResult, error)
+	BillingReport(ctx context.Context, filters BillingFilters) ([]BillingLine, error)
+	PromptCacheReport(ctx context.Context, filters PromptCacheFilters) ([]PromptCacheLine, error)
+	LatencyMatrix(ctx context.Context, filters LatencyMatrixFilters) ([]LatencyCell, error)
 	Update(ctx context.Context, id string, entry any) error
 	Flush(ctx context.Context, since time.Time) error	
+	Ping(ctx context.Context) error
 	Close(ctx context.Context) error
 }
 
@@ -41,6 +46,11 @@ func NewLogStore(ctx context.Context,config *Config, logger schemas.Logger) (Log
 			return newPostgresLogStore(ctx, postgresConfig, logger)
 		}
 		return nil, fmt.Errorf("invalid postgres config: %T", config.Config)
+	case LogStoreTypeClickHouse:
+		if clickHouseConfig, ok := config.Config.(*ClickHouseConfig); ok {
+			return newClickHouseLogStore(ctx, clickHouseConfig, logger)
+		}
+		return nil, fmt.Errorf("invalid clickhouse config: %T", config.Config)
 	default:
 		return nil, fmt.Errorf("unsupported log store type: %s", config.Type)
 	}
@@ -27,19 +27,22 @@ const (
 
 // SearchFilters represents the available filters for log searches
 type SearchFilters struct {
-	Providers     []string   `json:"providers,omitempty"`
-	Models        []string   `json:"models,omitempty"`
-	Status        []string   `json:"status,omitempty"`
-	Objects       []string   `json:"objects,omitempty"` // For filtering by request type (chat.completion, text.completion, embedding)
-	StartTime     *time.Time `json:"start_time,omitempty"`
-	EndTime       *time.Time `json:"end_time,omitempty"`
-	MinLatency    *float64   `json:"min_latency,omitempty"`
-	MaxLatency    *float64   `json:"max_latency,omitempty"`
-	MinTokens     *int       `json:"min_tokens,omitempty"`
-	MaxTokens     *int       `json:"max_tokens,omitempty"`
-	MinCost       *float64   `json:"min_cost,omitempty"`
-	MaxCost       *float64   `json:"max_cost,omitempty"`
-	ContentSearch string     `json:"content_search,omitempty"`
+	Providers     []string          `json:"providers,omitempty"`
+	Models        []string          `json:"models,omitempty"`
+	Status        []string          `json:"status,omitempty"`
+	Objects       []string          `json:"objects,omitempty"` // For filtering by request type (chat.completion, text.completion, embedding)
+	StartTime     *time.Time        `json:"start_time,omitempty"`
+	EndTime       *time.Time        `json:"end_time,omitempty"`
+	MinLatency    *float64          `json:"min_latency,omitempty"`
+	MaxLatency    *float64          `json:"max_latency,omitempty"`
+	MinTokens     *int              `json:"min_tokens,omitempty"`
+	MaxTokens     *int              `json:"max_tokens,omitempty"`
+	MinCost       *float64          `json:"min_cost,omitempty"`
+	MaxCost       *float64          `json:"max_cost,omitempty"`
+	ContentSearch string            `json:"content_search,omitempty"`
+	Customers     []string          `json:"customers,omitempty"` // Billing customers of the requests
+	Languages     []string          `json:"languages,omitempty"` // Languages of the prompts, ISO 639-1 codes
+	Labels        map[string]string `json:"labels,omitempty"`    // Labels the requests carry, all of them with these values
 }
 
 // PaginationOptions represents pagination parameters
@@ -86,6 +89,8 @@ type Log struct {
 	TranscriptionOutput string    `gorm:"type:text" json:"-"` // JSON serialized *schemas.BifrostTranscribe
 	CacheDebug          string    `gorm:"type:text" json:"-"` // JSON serialized *schemas.BifrostCacheDebug
 	Latency             *float64  `json:"latency,omitempty"`
+	TimeToFirstToken    *float64  `json:"time_to_first_token,omitempty"`                 // Streaming only, in milliseconds
+	TokensPerSecond     *float64  `json:"tokens_per_second,omitempty"`                   // Streaming only, completion tokens per second
 	TokenUsage          string    `gorm:"type:text" json:"-"`                            // JSON serialized *schemas.LLMUsage
 	Cost                *float64  `gorm:"index" json:"cost,omitempty"`                   // Cost in dollars (total cost of the request - includes cache lookup cost)
 	Status              string    `gorm:"type:varchar(50);index;not null" json:"status"` // "processing", "success", or "error"
@@ -99,6 +104,26 @@ type Log struct {
 	CompletionTokens int `gorm:"default:0" json:"-"`
 	TotalTokens      int `gorm:"default:0" json:"-"`
 
+	// End customer the request is billed to, from the x-bifrost-customer header or the virtual key's customer
+	Customer string `gorm:"type:varchar(255);index" json:"customer,omitempty"`
+
+	// Provider key that served the request
+	KeyID string `gorm:"type:varchar(255);index" json:"key_id,omitempty"`
+
+	// Denormalized prompt caching fields of the provider, for the cache hit rate analytics
+	CachedTokens        int      `gorm:"default:0" json:"-"`      // Prompt tokens read from the provider's prompt cache
+	CacheCreationTokens int      `gorm:"default:0" json:"-"`      // Prompt tokens written to the provider's prompt cache
+	CacheSavings        *float64 `json:"cache_savings,omitempty"` // Dollars saved by the prompt cache, negative when writes cost more than reads saved
+
+	// Retention class of the request (e.g. zero_data_retention); content columns of such requests are left empty
+	RetentionClass string `gorm:"type:varchar(50)" json:"retention_class,omitempty"`
+
+	// Language of the prompt, detected or sent by the client, as an ISO 639-1 code
+	Language string `gorm:"type:varchar(16);index" json:"language,omitempty"`
+
+	// Labels attributing the request, e.g. to a feature or environment, from the X-Bifrost-Labels header or metadata
+	Labels string `gorm:"type:text" json:"-"` // JSON serialized map[string]string
+
 	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
 
 	// Virtual fields for JSON output - these will be populated when needed
@@ -115,6 +140,7 @@ type Log struct {
 	SpeechOutputParsed        *schemas.BifrostSpeech                 `gorm:"-" json:"speech_output,omitempty"`
 	TranscriptionOutputParsed *schemas.BifrostTranscribe             `gorm:"-" json:"transcription_output,omitempty"`
 	CacheDebugParsed          *schemas.BifrostCacheDebug             `gorm:"-" json:"cache_debug,omitempty"`
+	LabelsParsed              map[string]string                      `gorm:"-" json:"labels,omitempty"`
 }
 
 // TableName sets the table name for GORM
@@ -232,6 +258,10 @@ func (l *Log) SerializeFields() error {
 		l.PromptTokens = l.TokenUsageParsed.PromptTokens
 		l.CompletionTokens = l.TokenUsageParsed.CompletionTokens
 		l.TotalTokens = l.TokenUsageParsed.TotalTokens
+		if details := l.TokenUsageParsed.PromptTokensDetails; details != nil {
+			l.CachedTokens = details.CachedTokens
+			l.CacheCreationTokens = details.CacheCreationTokens
+		}
 	}
 
 	if l.ErrorDetailsParsed != nil {
@@ -251,6 +281,14 @@ func (l *Log) SerializeFields() error {
 		}
 	}
 
+	if l.LabelsParsed != nil {
+		if data, err := json.Marshal(l.LabelsParsed); err != nil {
+			return err
+		} else {
+			l.Labels = string(data)
+		}
+	}
+
 	// Build content summary for search
 	l.ContentSummary = l.BuildContentSummary()
 
@@ -356,6 +394,13 @@ func (l *Log) DeserializeFields() error {
 		}
 	}
 
+	if l.Labels != "" {
+		if err := json.Unmarshal([]byte(l.Labels), &l.LabelsParsed); err != nil {
+			// Log error but don't fail the operation - initialize as nil
+			l.LabelsParsed = nil
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,169 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// azureMaxTextLength is the longest text Azure AI Content Safety analyzes in one call.
+const azureMaxTextLength = 10000
+
+// Classifier scores text per moderation category. Scores range from 0 (safe) to 1 (certainly harmful).
+type Classifier interface {
+	Classify(ctx context.Context, text string) (map[string]float64, error)
+}
+
+// newClassifier creates the classifier selected by config.
+func newClassifier(config *Config) (Classifier, error) {
+	timeout := time.Duration(config.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultTimeoutSeconds * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch config.Classifier {
+	case ClassifierOpenAI:
+		baseURL := config.OpenAI.BaseURL
+		if baseURL == "" {
+			baseURL = DefaultOpenAIBaseURL
+		}
+		model := config.OpenAI.Model
+		if model == "" {
+			model = DefaultOpenAIModel
+		}
+		return &openAIClassifier{client: client, url: strings.TrimSuffix(baseURL, "/") + "/v1/moderations", apiKey: config.OpenAI.APIKey, model: model}, nil
+	case ClassifierAzure:
+		version := config.Azure.APIVersion
+		if version == "" {
+			version = DefaultAzureVersion
+		}
+		url := strings.TrimSuffix(config.Azure.Endpoint, "/") + "/contentsafety/text:analyze?api-version=" + version
+		return &azureClassifier{client: client, url: url, apiKey: config.Azure.APIKey}, nil
+	case ClassifierKeywords:
+		classifier := &keywordClassifier{patterns: make(map[string][]*regexp.Regexp, len(config.Keywords))}
+		for category, patterns := range config.Keywords {
+			for _, pattern := range patterns {
+				re, err := regexp.Compile("(?i)" + pattern)
+				if err != nil {
+					return nil, fmt.Errorf("invalid keyword %q of %s: %w", pattern, category, err)
+				}
+				classifier.patterns[category] = append(classifier.patterns[category], re)
+			}
+		}
+		return classifier, nil
+	}
+	return nil, fmt.Errorf("unknown moderation classifier %q", config.Classifier)
+}
+
+// openAIClassifier calls the OpenAI moderation API.
+type openAIClassifier struct {
+	client *http.Client
+	url    string
+	apiKey string
+	model  string
+}
+
+// Classify returns the category scores of the OpenAI moderation API.
+func (c *openAIClassifier) Classify(ctx context.Context, text string) (map[string]float64, error) {
+	var response struct {
+		Results []struct {
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + c.apiKey}
+	if err := postJSON(ctx, c.client, c.url, headers, map[string]any{"model": c.model, "input": text}, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Results) == 0 {
+		return nil, fmt.Errorf("moderation API returned no results")
+	}
+	return response.Results[0].CategoryScores, nil
+}
+
+// azureClassifier calls Azure AI Content Safety text analysis.
+type azureClassifier struct {
+	client *http.Client
+	url    string
+	apiKey string
+}
+
+// Classify returns the Azure severities (0, 2, 4 or 6) as scores (0, 0.33, 0.67 or 1), keyed by lower case category.
+func (c *azureClassifier) Classify(ctx context.Context, text string) (map[string]float64, error) {
+	if runes := []rune(text); len(runes) > azureMaxTextLength {
+		text = string(runes[:azureMaxTextLength])
+	}
+	var response struct {
+		CategoriesAnalysis []struct {
+			Category string `json:"category"`
+			Severity int    `json:"severity"`
+		} `json:"categoriesAnalysis"`
+	}
+	headers := map[string]string{"Ocp-Apim-Subscription-Key": c.apiKey}
+	if err := postJSON(ctx, c.client, c.url, headers, map[string]any{"text": text}, &response); err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(response.CategoriesAnalysis))
+	for _, analysis := range response.CategoriesAnalysis {
+		scores[strings.ToLower(analysis.Category)] = min(float64(analysis.Severity)/6, 1)
+	}
+	return scores, nil
+}
+
+// keywordClassifier scores a category 1 when one of its patterns matches, and 0 otherwise.
+type keywordClassifier struct {
+	patterns map[string][]*regexp.Regexp
+}
+
+// Classify matches text against the keyword patterns.
+func (c *keywordClassifier) Classify(ctx context.Context, text string) (map[string]float64, error) {
+	scores := make(map[string]float64, len(c.patterns))
+	for category, patterns := range c.patterns {
+		scores[category] = 0
+		for _, re := range patterns {
+			if re.MatchString(text) {
+				scores[category] = 1
+				break
+			}
+		}
+	}
+	return scores, nil
+}
+
+// postJSON posts body as JSON and decodes the JSON response into out.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body any, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read moderation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("moderation request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Package moderation screens prompts and completions with a content classifier (the OpenAI moderation API,
+// Azure AI Content Safety or local keyword rules) and decides per policy whether to block, flag or only log them.
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultThreshold      = 0.5
+	DefaultTimeoutSeconds = 10
+	DefaultOpenAIBaseURL  = "https://api.openai.com"
+	DefaultOpenAIModel    = "omni-moderation-latest"
+	DefaultAzureVersion   = "2023-10-01"
+)
+
+// ClassifierType selects the content classifier.
+type ClassifierType string
+
+const (
+	ClassifierOpenAI   ClassifierType = "openai"
+	ClassifierAzure    ClassifierType = "azure"
+	ClassifierKeywords ClassifierType = "keywords"
+)
+
+// Action is what happens to content that crosses a policy threshold.
+type Action string
+
+const (
+	ActionBlock Action = "block" // Reject the request, or replace the completion with an error
+	ActionFlag  Action = "flag"  // Let it through, queue it for review and report it in the x-bf-moderation-flagged response header
+	ActionLog   Action = "log"   // Let it through and only record the outcome
+	ActionHold  Action = "hold"  // Hold the request until a reviewer approves or denies it
+)
+
+// Stage is the part of a request that was moderated.
+type Stage string
+
+const (
+	StageInput  Stage = "input"
+	StageOutput Stage = "output"
+)
+
+// OpenAIConfig configures the OpenAI moderation API classifier.
+type OpenAIConfig struct {
+	APIKey  string `json:"api_key"`
+	BaseURL string `json:"base_url,omitempty"` // Default: https://api.openai.com
+	Model   string `json:"model,omitempty"`    // Default: omni-moderation-latest
+}
+
+// AzureConfig configures the Azure AI Content Safety classifier. Severities are mapped to scores in [0, 1].
+type AzureConfig struct {
+	Endpoint   string `json:"endpoint"` // e.g. https://<resource>.cognitiveservices.azure.com
+	APIKey     string `json:"api_key"`
+	APIVersion string `json:"api_version,omitempty"` // Default: 2023-10-01
+}
+
+// Policy decides the action for the requests it matches. The first matching policy applies.
+type Policy struct {
+	Name        string             `json:"name"`
+	VirtualKeys []string           `json:"virtual_keys,omitempty"` // Virtual key IDs or names, a trailing * matches a prefix; empty matches all
+	Action      Action             `json:"action"`
+	Categories  []string           `json:"categories,omitempty"` // Categories considered, empty considers all
+	Thresholds  map[string]float64 `json:"thresholds,omitempty"` // Minimum score per category, others use the default threshold
+}
+
+// Config represents the configuration of the moderation stage.
+type Config struct {
+	Enabled          bool                `json:"enabled"`
+	Classifier       ClassifierType      `json:"classifier"`
+	OpenAI           *OpenAIConfig       `json:"openai,omitempty"`
+	Azure            *AzureConfig        `json:"azure,omitempty"`
+	Keywords         map[string][]string `json:"keywords,omitempty"`     // Regular expressions per category, matched case-insensitively
+	CheckInput       *bool               `json:"check_input,omitempty"`  // Moderate prompts (default: true)
+	CheckOutput      bool                `json:"check_output,omitempty"` // Moderate completions (default: false)
+	FailOpen         *bool               `json:"fail_open,omitempty"`    // Let content through when the classifier fails (default: true)
+	TimeoutSeconds   int                 `json:"timeout_seconds,omitempty"`
+	DefaultThreshold float64             `json:"default_threshold,omitempty"` // Default: 0.5
+	DefaultAction    Action              `json:"default_action,omitempty"`    // Action when no policy matches (default: flag)
+	Policies         []Policy            `json:"policies,omitempty"`
+	// HoldTimeoutSeconds is how long a held request waits for a review. Requests still pending afterwards
+	// are rejected and can be resubmitted once approved (default: 0, rejected immediately).
+	HoldTimeoutSeconds int `json:"hold_timeout_seconds,omitempty"`
+}
+
+// Validate checks the classifier settings and policies.
+func (c *Config) Validate() error {
+	switch c.Classifier {
+	case ClassifierOpenAI:
+		if c.OpenAI == nil || c.OpenAI.APIKey == "" {
+			return fmt.Errorf("moderation openai.api_key is required")
+		}
+	case ClassifierAzure:
+		if c.Azure == nil || c.Azure.Endpoint == "" || c.Azure.APIKey == "" {
+			return fmt.Errorf("moderation azure.endpoint and azure.api_key are required")
+		}
+	case ClassifierKeywords:
+		if len(c.Keywords) == 0 {
+			return fmt.Errorf("moderation keywords are required")
+		}
+		for category, patterns := range c.Keywords {
+			for _, pattern := range patterns {
+				if _, err := regexp.Compile(pattern); err != nil {
+					return fmt.Errorf("moderation keyword %q of %s: %w", pattern, category, err)
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unknown moderation classifier %q", c.Classifier)
+	}
+	if c.HoldTimeoutSeconds < 0 {
+		return fmt.Errorf("moderation hold_timeout_seconds cannot be negative")
+	}
+	if c.DefaultAction != "" && !validAction(c.DefaultAction) {
+		return fmt.Errorf("unknown moderation default_action %q", c.DefaultAction)
+	}
+	names := make(map[string]struct{}, len(c.Policies))
+	for i, policy := range c.Policies {
+		if policy.Name == "" {
+			return fmt.Errorf("moderation policy %d: name is required", i)
+		}
+		if _, ok := names[policy.Name]; ok {
+			return fmt.Errorf("moderation policy %s: duplicate name", policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+		if !validAction(policy.Action) {
+			return fmt.Errorf("moderation policy %s: unknown action %q", policy.Name, policy.Action)
+		}
+		for category, threshold := range policy.Thresholds {
+			if threshold < 0 || threshold > 1 {
+				return fmt.Errorf("moderation policy %s: threshold of %s must be between 0 and 1", policy.Name, category)
+			}
+		}
+	}
+	return nil
+}
+
+// ChecksInput reports whether prompts are moderated.
+func (c *Config) ChecksInput() bool {
+	return c.CheckInput == nil || *c.CheckInput
+}
+
+// failOpen reports whether content passes when the classifier fails.
+func (c *Config) failOpen() bool {
+	return c.FailOpen == nil || *c.FailOpen
+}
+
+// policyFor returns the first policy matching one of the virtual keys, or the default policy.
+func (c *Config) policyFor(virtualKeys []string) Policy {
+	for _, policy := range c.Policies {
+		if len(policy.VirtualKeys) == 0 {
+			return policy
+		}
+		for _, virtualKey := range virtualKeys {
+			if matchesPattern(policy.VirtualKeys, virtualKey) {
+				return policy
+			}
+		}
+	}
+	action := c.DefaultAction
+	if action == "" {
+		action = ActionFlag
+	}
+	return Policy{Name: "default", Action: action}
+}
+
+// threshold returns the minimum score at which a category is flagged under policy.
+func (c *Config) threshold(policy Policy, category string) float64 {
+	if threshold, ok := policy.Thresholds[category]; ok {
+		return threshold
+	}
+	if c.DefaultThreshold > 0 {
+		return c.DefaultThreshold
+	}
+	return DefaultThreshold
+}
+
+func validAction(action Action) bool {
+	return action == ActionBlock || action == ActionFlag || action == ActionLog || action == ActionHold
+}
+
+// matchesPattern reports whether value equals one of the patterns, or starts with a pattern ending in *.
+func matchesPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		} else if pattern == value {
+			return true
+		}
+	}
+	return false
+}
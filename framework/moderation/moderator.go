@@ -0,0 +1,82 @@
+package moderation
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Decision is the outcome of moderating content under a policy.
+type Decision struct {
+	Policy  string             `json:"policy"`
+	Action  Action             `json:"action"`
+	Scores  map[string]float64 `json:"scores"`
+	Flagged []string           `json:"flagged"` // Categories at or above their threshold, sorted
+}
+
+// Moderator classifies content and applies the moderation policies.
+type Moderator struct {
+	config     *Config
+	classifier Classifier
+}
+
+// New creates a moderator from a validated config.
+func New(config *Config) (*Moderator, error) {
+	classifier, err := newClassifier(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Moderator{config: config, classifier: classifier}, nil
+}
+
+// NewWithClassifier creates a moderator using a custom classifier, e.g. a local model.
+func NewWithClassifier(config *Config, classifier Classifier) *Moderator {
+	return &Moderator{config: config, classifier: classifier}
+}
+
+// ChecksInput reports whether prompts are moderated.
+func (m *Moderator) ChecksInput() bool {
+	return m.config.ChecksInput()
+}
+
+// ChecksOutput reports whether completions are moderated.
+func (m *Moderator) ChecksOutput() bool {
+	return m.config.CheckOutput
+}
+
+// HoldTimeout returns how long a held request waits for a review.
+func (m *Moderator) HoldTimeout() time.Duration {
+	return time.Duration(m.config.HoldTimeoutSeconds) * time.Second
+}
+
+// Moderate classifies text under the policy matching the virtual keys (IDs and names) of the caller.
+// It returns nil when no category crosses its threshold. Classifier errors are returned unless the
+// moderator fails open, in which case the text passes.
+func (m *Moderator) Moderate(ctx context.Context, virtualKeys []string, text string) (*Decision, error) {
+	if text == "" {
+		return nil, nil
+	}
+	scores, err := m.classifier.Classify(ctx, text)
+	if err != nil {
+		if m.config.failOpen() {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	policy := m.config.policyFor(virtualKeys)
+	var flagged []string
+	for category, score := range scores {
+		if len(policy.Categories) > 0 && !matchesPattern(policy.Categories, category) {
+			continue
+		}
+		if score >= m.config.threshold(policy, category) {
+			flagged = append(flagged, category)
+		}
+	}
+	if len(flagged) == 0 {
+		return nil, nil
+	}
+	sort.Strings(flagged)
+	return &Decision{Policy: policy.Name, Action: policy.Action, Scores: scores, Flagged: flagged}, nil
+}
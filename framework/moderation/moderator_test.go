@@ -0,0 +1,136 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestModerate_PolicyPerVirtualKey verifies that the first matching policy decides the action and thresholds.
+func TestModerate_PolicyPerVirtualKey(t *testing.T) {
+	config := &Config{
+		Enabled:    true,
+		Classifier: ClassifierKeywords,
+		Keywords:   map[string][]string{"violence": {`\bkill\b`}, "self-harm": {`hurt myself`}},
+		Policies: []Policy{
+			{Name: "kids", VirtualKeys: []string{"kids-*"}, Action: ActionBlock},
+			{Name: "research", VirtualKeys: []string{"research"}, Action: ActionLog, Categories: []string{"self-harm"}},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	m, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx := context.Background()
+
+	decision, err := m.Moderate(ctx, []string{"vk-1", "kids-app"}, "How do I KILL a process?")
+	if err != nil || decision == nil {
+		t.Fatalf("Moderate() = %v, %v, want a decision", decision, err)
+	}
+	if decision.Policy != "kids" || decision.Action != ActionBlock || len(decision.Flagged) != 1 || decision.Flagged[0] != "violence" {
+		t.Errorf("Moderate() = %+v", decision)
+	}
+
+	if decision, _ := m.Moderate(ctx, []string{"research"}, "How do I kill a process?"); decision != nil {
+		t.Errorf("Moderate() flagged a category outside the policy categories: %+v", decision)
+	}
+	if decision, _ := m.Moderate(ctx, []string{"other"}, "How do I kill a process?"); decision == nil || decision.Action != ActionFlag {
+		t.Errorf("Moderate() = %+v, want the default flag action", decision)
+	}
+	if decision, _ := m.Moderate(ctx, nil, "hello"); decision != nil {
+		t.Errorf("Moderate() flagged harmless text: %+v", decision)
+	}
+}
+
+// TestOpenAIClassifier verifies the moderation API request and the fail-open behaviour.
+func TestOpenAIClassifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/moderations" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		score := 0.01
+		if body.Input == "bad" {
+			score = 0.9
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"results": []any{map[string]any{"category_scores": map[string]float64{"harassment": score, "hate": 0.2}}},
+		})
+	}))
+	defer server.Close()
+
+	failOpen := false
+	config := &Config{Enabled: true, Classifier: ClassifierOpenAI, OpenAI: &OpenAIConfig{APIKey: "sk-test", BaseURL: server.URL}, FailOpen: &failOpen}
+	m, err := New(config)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	decision, err := m.Moderate(context.Background(), nil, "bad")
+	if err != nil || decision == nil || decision.Flagged[0] != "harassment" || decision.Scores["hate"] != 0.2 {
+		t.Fatalf("Moderate() = %+v, %v", decision, err)
+	}
+
+	config.OpenAI.APIKey = "sk-wrong"
+	m, _ = New(config)
+	if _, err := m.Moderate(context.Background(), nil, "bad"); err == nil {
+		t.Error("Moderate() expected the classifier error when failing closed")
+	}
+}
+
+// TestReviews verifies that decisions release waiting held requests and approved content may be resubmitted.
+func TestReviews(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	reviews := NewReviews(store)
+	text := "Where can I buy a crossbow?"
+	event := &Event{ID: "held", Stage: StageInput, Action: ActionHold, ReviewStatus: ReviewPending, ContentHash: ContentHash(text)}
+	if err := store.Save(ctx, event); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if status := reviews.Wait(ctx, "held", 0); status != ReviewPending {
+		t.Errorf("Wait() without timeout = %s, want pending", status)
+	}
+	if reviews.Released(ctx, "held", text) {
+		t.Error("Released() = true before approval")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		if _, err := reviews.Review(ctx, "held", ReviewApproved, "alice", "fine for archery club"); err != nil {
+			t.Errorf("Review() error = %v", err)
+		}
+	}()
+	start := time.Now()
+	if status := reviews.Wait(ctx, "held", 5*time.Second); status != ReviewApproved {
+		t.Errorf("Wait() = %s, want approved", status)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wait() took %v, want release on review", elapsed)
+	}
+
+	if !reviews.Released(ctx, "held", text) {
+		t.Error("Released() = false for approved content")
+	}
+	if reviews.Released(ctx, "held", "Where can I buy a rifle?") {
+		t.Error("Released() = true for different content")
+	}
+	reviewed, _ := store.Get(ctx, "held")
+	if reviewed.Reviewer != "alice" || reviewed.ReviewNote != "fine for archery club" || reviewed.ReviewedAt == nil {
+		t.Errorf("unexpected reviewed event %+v", reviewed)
+	}
+	if _, err := reviews.Review(ctx, "held", "maybe", "", ""); err == nil {
+		t.Error("Review() accepted an unknown status")
+	}
+}
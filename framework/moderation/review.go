@@ -0,0 +1,143 @@
+package moderation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// reviewPollInterval is how often a held request rereads its event, picking up decisions made on other
+// nodes sharing the config store.
+const reviewPollInterval = time.Second
+
+// ReviewStatus is the state of a moderation event in the review queue.
+type ReviewStatus string
+
+const (
+	ReviewPending  ReviewStatus = "pending"  // Waiting for a reviewer
+	ReviewApproved ReviewStatus = "approved" // Released: held requests proceed or may be resubmitted
+	ReviewDenied   ReviewStatus = "denied"   // Held requests are rejected
+)
+
+// ContentHash returns the hash identifying moderated content, so that an approved event can release
+// the resubmission of the same content.
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Reviews records reviewer decisions on moderation events and releases the requests held for them.
+type Reviews struct {
+	store Store
+
+	mu      sync.Mutex
+	waiters map[string][]chan struct{} // Held requests per event ID, notified on decisions made by this node
+}
+
+// NewReviews creates the review queue of the events in store.
+func NewReviews(store Store) *Reviews {
+	return &Reviews{store: store, waiters: make(map[string][]chan struct{})}
+}
+
+// Review records a decision on an event. An empty status only updates the reviewer and the note.
+func (r *Reviews) Review(ctx context.Context, id string, status ReviewStatus, reviewer, note string) (*Event, error) {
+	switch status {
+	case "", ReviewApproved, ReviewDenied:
+	default:
+		return nil, fmt.Errorf("review status must be %s or %s", ReviewApproved, ReviewDenied)
+	}
+	event, err := r.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status != "" {
+		event.ReviewStatus = status
+	}
+	event.Reviewer = reviewer
+	event.ReviewNote = note
+	now := time.Now()
+	event.ReviewedAt = &now
+	if err := r.store.Save(ctx, event); err != nil {
+		return nil, err
+	}
+
+	if status != "" {
+		r.mu.Lock()
+		for _, waiter := range r.waiters[id] {
+			close(waiter)
+		}
+		delete(r.waiters, id)
+		r.mu.Unlock()
+	}
+	return event, nil
+}
+
+// Wait blocks until the event is approved or denied, timeout elapses or ctx is done, and returns
+// the review status at that point.
+func (r *Reviews) Wait(ctx context.Context, id string, timeout time.Duration) ReviewStatus {
+	if timeout <= 0 {
+		return ReviewPending
+	}
+	waiter := make(chan struct{})
+	r.mu.Lock()
+	r.waiters[id] = append(r.waiters[id], waiter)
+	r.mu.Unlock()
+	defer r.removeWaiter(id, waiter)
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(reviewPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-waiter:
+			return r.status(id)
+		case <-ticker.C:
+			if status := r.status(id); status != ReviewPending {
+				return status
+			}
+		case <-deadline.C:
+			return r.status(id)
+		case <-ctx.Done():
+			return ReviewPending
+		}
+	}
+}
+
+// Released reports whether id names an approved input event for the same content, letting a held
+// request be resubmitted once approved.
+func (r *Reviews) Released(ctx context.Context, id, text string) bool {
+	event, err := r.store.Get(ctx, id)
+	if err != nil {
+		return false
+	}
+	return event.Stage == StageInput && event.ReviewStatus == ReviewApproved && event.ContentHash == ContentHash(text)
+}
+
+// status rereads the review status of an event.
+func (r *Reviews) status(id string) ReviewStatus {
+	event, err := r.store.Get(context.Background(), id)
+	if err != nil {
+		return ReviewPending
+	}
+	return event.ReviewStatus
+}
+
+// removeWaiter unregisters a held request that stopped waiting.
+func (r *Reviews) removeWaiter(id string, waiter chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	waiters := r.waiters[id]
+	for i := range waiters {
+		if waiters[i] == waiter {
+			r.waiters[id] = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(r.waiters[id]) == 0 {
+		delete(r.waiters, id)
+	}
+}
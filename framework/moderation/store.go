@@ -0,0 +1,249 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a moderation event does not exist.
+var ErrNotFound = errors.New("moderation event not found")
+
+// maxInMemoryEvents bounds the in-memory store, which drops its oldest events first.
+const maxInMemoryEvents = 10000
+
+// Event is the recorded outcome of moderated content that crossed a policy threshold.
+type Event struct {
+	ID          string    `gorm:"type:varchar(36);primaryKey" json:"id"`
+	RequestID   string    `gorm:"type:varchar(255);index" json:"request_id"`
+	Stage       Stage     `gorm:"type:varchar(16);index" json:"stage"`
+	Action      Action    `gorm:"type:varchar(16);index" json:"action"`
+	Policy      string    `gorm:"type:varchar(255)" json:"policy"`
+	VirtualKey  string    `gorm:"type:varchar(255);index" json:"virtual_key,omitempty"`
+	Provider    string    `gorm:"type:varchar(50)" json:"provider,omitempty"`
+	Model       string    `gorm:"type:varchar(255)" json:"model,omitempty"`
+	Content     string    `gorm:"type:text" json:"content"` // Moderated text, redacted when redaction is enabled
+	ScoresJSON  string    `gorm:"type:text" json:"-"`       // JSON serialized Scores
+	FlaggedJSON string    `gorm:"type:text" json:"-"`       // JSON serialized Flagged
+	CreatedAt   time.Time `gorm:"index;not null" json:"created_at"`
+
+	// Review of flagged and held content
+	ReviewStatus ReviewStatus `gorm:"type:varchar(16);index" json:"review_status,omitempty"`
+	Reviewer     string       `gorm:"type:varchar(255)" json:"reviewer,omitempty"`
+	ReviewNote   string       `gorm:"type:text" json:"review_note,omitempty"`
+	ReviewedAt   *time.Time   `json:"reviewed_at,omitempty"`
+	ContentHash  string       `gorm:"type:varchar(64)" json:"-"` // SHA-256 of the unredacted content, matching resubmitted held requests
+
+	// Virtual fields for runtime use (not stored in DB)
+	Scores  map[string]float64 `gorm:"-" json:"scores"`
+	Flagged []string           `gorm:"-" json:"flagged"`
+}
+
+// TableName sets the table name for moderation events
+func (Event) TableName() string { return "moderation_events" }
+
+// BeforeSave serializes the scores and flagged categories of an event
+func (e *Event) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(e.Scores)
+	if err != nil {
+		return err
+	}
+	e.ScoresJSON = string(data)
+	data, err = json.Marshal(e.Flagged)
+	if err != nil {
+		return err
+	}
+	e.FlaggedJSON = string(data)
+	return nil
+}
+
+// AfterFind deserializes the scores and flagged categories of an event
+func (e *Event) AfterFind(tx *gorm.DB) error {
+	if e.ScoresJSON != "" {
+		if err := json.Unmarshal([]byte(e.ScoresJSON), &e.Scores); err != nil {
+			return err
+		}
+	}
+	if e.FlaggedJSON != "" {
+		if err := json.Unmarshal([]byte(e.FlaggedJSON), &e.Flagged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter selects moderation events. Empty fields match all events.
+type Filter struct {
+	Stage        Stage
+	Action       Action
+	VirtualKey   string
+	ReviewStatus ReviewStatus
+}
+
+// Store persists moderation events.
+type Store interface {
+	// Save records an event.
+	Save(ctx context.Context, event *Event) error
+	// Get returns an event, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Event, error)
+	// List returns a page of the events matching filter, newest first, and the number of matching events.
+	List(ctx context.Context, filter Filter, limit, offset int) ([]Event, int64, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores moderation events in the config store database.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the moderation events table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate moderation events table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the moderation events table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addmoderationeventstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Event{}) {
+				if err := migrator.CreateTable(&Event{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Save records an event.
+func (s *RDBStore) Save(ctx context.Context, event *Event) error {
+	return s.db.WithContext(ctx).Save(event).Error
+}
+
+// Get returns an event.
+func (s *RDBStore) Get(ctx context.Context, id string) (*Event, error) {
+	var event Event
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&event).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// List returns a page of the events matching filter.
+func (s *RDBStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Event, int64, error) {
+	query := s.db.WithContext(ctx).Model(&Event{})
+	if filter.Stage != "" {
+		query = query.Where("stage = ?", filter.Stage)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.VirtualKey != "" {
+		query = query.Where("virtual_key = ?", filter.VirtualKey)
+	}
+	if filter.ReviewStatus != "" {
+		query = query.Where("review_status = ?", filter.ReviewStatus)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []Event
+	if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// InMemoryStore keeps the latest moderation events in memory. Events are lost on restart.
+type InMemoryStore struct {
+	mu     sync.RWMutex
+	events []Event // Oldest first
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+// Save records an event, replacing an event with the same ID.
+func (s *InMemoryStore) Save(ctx context.Context, event *Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	for i := range s.events {
+		if s.events[i].ID == event.ID {
+			s.events[i] = *event
+			return nil
+		}
+	}
+	s.events = append(s.events, *event)
+	if len(s.events) > maxInMemoryEvents {
+		s.events = s.events[len(s.events)-maxInMemoryEvents:]
+	}
+	return nil
+}
+
+// Get returns an event.
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := range s.events {
+		if s.events[i].ID == id {
+			event := s.events[i]
+			return &event, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List returns a page of the events matching filter.
+func (s *InMemoryStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Event, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var matching []Event
+	for i := len(s.events) - 1; i >= 0; i-- {
+		event := s.events[i]
+		if (filter.Stage == "" || event.Stage == filter.Stage) &&
+			(filter.Action == "" || event.Action == filter.Action) &&
+			(filter.VirtualKey == "" || event.VirtualKey == filter.VirtualKey) &&
+			(filter.ReviewStatus == "" || event.ReviewStatus == filter.ReviewStatus) {
+			matching = append(matching, event)
+		}
+	}
+	total := int64(len(matching))
+	if offset >= len(matching) {
+		return []Event{}, total, nil
+	}
+	matching = matching[offset:]
+	if limit > 0 && limit < len(matching) {
+		matching = matching[:limit]
+	}
+	return matching, total, nil
+}
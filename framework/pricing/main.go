@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/cluster"
 	"github.com/maximhq/bifrost/framework/configstore"
 )
 
@@ -30,11 +31,12 @@ type PricingManager struct {
 	modelPool map[schemas.ModelProvider][]string
 
 	// Background sync worker
-	syncTicker *time.Ticker
-	done       chan struct{}
-	wg         sync.WaitGroup
-	syncCtx    context.Context
-	syncCancel context.CancelFunc
+	leaderChecker cluster.LeaderChecker // When set, only the cluster leader syncs pricing data
+	syncTicker    *time.Ticker
+	done          chan struct{}
+	wg            sync.WaitGroup
+	syncCtx       context.Context
+	syncCancel    context.CancelFunc
 }
 
 // PricingData represents the structure of the pricing.json file
@@ -67,9 +69,13 @@ type PricingEntry struct {
 	OutputCostPerCharacterAbove128kTokens     *float64 `json:"output_cost_per_character_above_128k_tokens,omitempty"`
 
 	// Cache and batch pricing
-	CacheReadInputTokenCost   *float64 `json:"cache_read_input_token_cost,omitempty"`
-	InputCostPerTokenBatches  *float64 `json:"input_cost_per_token_batches,omitempty"`
-	OutputCostPerTokenBatches *float64 `json:"output_cost_per_token_batches,omitempty"`
+	CacheReadInputTokenCost     *float64 `json:"cache_read_input_token_cost,omitempty"`
+	CacheCreationInputTokenCost *float64 `json:"cache_creation_input_token_cost,omitempty"`
+	InputCostPerTokenBatches    *float64 `json:"input_cost_per_token_batches,omitempty"`
+	OutputCostPerTokenBatches   *float64 `json:"output_cost_per_token_batches,omitempty"`
+
+	// Rerank pricing
+	InputCostPerQuery *float64 `json:"input_cost_per_query,omitempty"`
 }
 
 // Init initializes the pricing manager
@@ -126,6 +132,10 @@ func (pm *PricingManager) CalculateCost(result *schemas.BifrostResponse) float64
 	isCacheRead := false
 	isBatch := false
 
+	if result.ExtraFields.RequestType == schemas.RerankRequest {
+		return pm.calculateRerankCost(result)
+	}
+
 	// Check main usage field
 	if result.Usage != nil {
 		usage = result.Usage
@@ -213,6 +223,21 @@ func (pm *PricingManager) CalculateCostWithCacheDebug(result *schemas.BifrostRes
 	return pm.CalculateCost(result)
 }
 
+// SetLeaderChecker restricts the background pricing sync to the cluster leader.
+// Replicas that are not leader reload their cache from the database instead.
+func (pm *PricingManager) SetLeaderChecker(leaderChecker cluster.LeaderChecker) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.leaderChecker = leaderChecker
+}
+
+// isLeader reports whether this replica should run cluster-wide background work.
+func (pm *PricingManager) isLeader() bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	return pm.leaderChecker == nil || pm.leaderChecker.IsLeader()
+}
+
 func (pm *PricingManager) Cleanup() error {
 	if pm.syncCancel != nil {
 		pm.syncCancel()
@@ -376,8 +401,12 @@ func (pm *PricingManager) CalculateCostFromUsage(provider string, model string,
 		// Output tokens always use regular pricing for cache reads
 		outputCost = float64(completionTokens) * pricing.OutputCostPerToken
 	} else {
-		// Use regular pricing
-		inputCost = float64(promptTokens) * pricing.InputCostPerToken
+		// Use regular pricing, except for the prompt tokens read from or written to the provider's prompt cache
+		cachedTokens, cacheCreationTokens := promptCacheTokens(usage)
+		uncachedTokens := max(0, promptTokens-cachedTokens-cacheCreationTokens)
+		inputCost = float64(uncachedTokens)*pricing.InputCostPerToken +
+			float64(cachedTokens)*getSafeFloat64(pricing.CacheReadInputTokenCost, pricing.InputCostPerToken) +
+			float64(cacheCreationTokens)*getSafeFloat64(pricing.CacheCreationInputTokenCost, pricing.InputCostPerToken)
 		outputCost = float64(completionTokens) * pricing.OutputCostPerToken
 	}
 
@@ -386,6 +415,50 @@ func (pm *PricingManager) CalculateCostFromUsage(provider string, model string,
 	return totalCost
 }
 
+// calculateRerankCost calculates the cost in dollars of a rerank response. Models priced per query are billed
+// for the search units reported by the provider, or for a single query when it reports none, and token-priced
+// models for the tokens of the query and documents.
+func (pm *PricingManager) calculateRerankCost(result *schemas.BifrostResponse) float64 {
+	pricing, exists := pm.getPricing(result.ExtraFields.ModelRequested, string(result.ExtraFields.Provider), schemas.RerankRequest)
+	if !exists {
+		pm.logger.Debug("pricing not found for model %s and provider %s of request type %s, skipping cost calculation", result.ExtraFields.ModelRequested, result.ExtraFields.Provider, normalizeRequestType(schemas.RerankRequest))
+		return 0.0
+	}
+
+	cost := 0.0
+	if pricing.InputCostPerQuery != nil {
+		queries := 1.0
+		if result.ExtraFields.BilledUsage != nil && result.ExtraFields.BilledUsage.SearchUnits != nil {
+			queries = *result.ExtraFields.BilledUsage.SearchUnits
+		}
+		cost += queries * *pricing.InputCostPerQuery
+	}
+	if result.Usage != nil {
+		cost += float64(result.Usage.PromptTokens) * pricing.InputCostPerToken
+	}
+	return cost
+}
+
+// CalculatePromptCacheSavings returns the dollars saved by the provider's prompt cache on a response: what the
+// cached prompt tokens would have cost at the regular input price minus what they cost, less the premium paid for
+// the tokens written to the cache. It is negative when cache writes cost more than cache reads saved.
+func (pm *PricingManager) CalculatePromptCacheSavings(result *schemas.BifrostResponse) float64 {
+	if result == nil || result.Usage == nil {
+		return 0.0
+	}
+	cachedTokens, cacheCreationTokens := promptCacheTokens(result.Usage)
+	if cachedTokens == 0 && cacheCreationTokens == 0 {
+		return 0.0
+	}
+	pricing, exists := pm.getPricing(result.ExtraFields.ModelRequested, string(result.ExtraFields.Provider), result.ExtraFields.RequestType)
+	if !exists {
+		return 0.0
+	}
+	readSavings := float64(cachedTokens) * (pricing.InputCostPerToken - getSafeFloat64(pricing.CacheReadInputTokenCost, pricing.InputCostPerToken))
+	writePremium := float64(cacheCreationTokens) * (getSafeFloat64(pricing.CacheCreationInputTokenCost, pricing.InputCostPerToken) - pricing.InputCostPerToken)
+	return readSavings - writePremium
+}
+
 // populateModelPool populates the model pool with all available models per provider (thread-safe)
 func (pm *PricingManager) populateModelPool() {
 	// Acquire write lock for the entire rebuild operation
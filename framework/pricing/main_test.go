@@ -0,0 +1,95 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// newTestPricingManager returns a pricing manager with the prices of one chat model
+func newTestPricingManager(pricing configstore.TableModelPricing) *PricingManager {
+	pricing.Model, pricing.Provider, pricing.Mode = "claude", "anthropic", "chat"
+	return &PricingManager{pricingData: map[string]configstore.TableModelPricing{
+		makeKey("claude", "anthropic", "chat"): pricing,
+	}}
+}
+
+// TestPromptCachePricing tests that cached and cache creation tokens are priced apart, and the savings they bring
+func TestPromptCachePricing(t *testing.T) {
+	price := func(value float64) *float64 { return &value }
+	pm := newTestPricingManager(configstore.TableModelPricing{
+		InputCostPerToken:           3e-6,
+		OutputCostPerToken:          15e-6,
+		CacheReadInputTokenCost:     price(0.3e-6),
+		CacheCreationInputTokenCost: price(3.75e-6),
+	})
+	response := func(usage *schemas.LLMUsage) *schemas.BifrostResponse {
+		return &schemas.BifrostResponse{Usage: usage, ExtraFields: schemas.BifrostResponseExtraFields{
+			Provider: schemas.Anthropic, ModelRequested: "claude", RequestType: schemas.ChatCompletionRequest,
+		}}
+	}
+	tests := []struct {
+		name    string
+		usage   *schemas.LLMUsage
+		cost    float64
+		savings float64
+	}{
+		{"no caching", &schemas.LLMUsage{PromptTokens: 1000, CompletionTokens: 100}, 1000*3e-6 + 100*15e-6, 0},
+		{"cache write", &schemas.LLMUsage{PromptTokens: 1000, CompletionTokens: 100, PromptTokensDetails: &schemas.TokenDetails{CacheCreationTokens: 800}},
+			200*3e-6 + 800*3.75e-6 + 100*15e-6, -800 * 0.75e-6},
+		{"cache read", &schemas.LLMUsage{PromptTokens: 1000, CompletionTokens: 100, PromptTokensDetails: &schemas.TokenDetails{CachedTokens: 800}},
+			200*3e-6 + 800*0.3e-6 + 100*15e-6, 800 * 2.7e-6},
+		{"responses shape", &schemas.LLMUsage{ResponsesExtendedResponseUsage: &schemas.ResponsesExtendedResponseUsage{
+			InputTokens: 1000, InputTokensDetails: &schemas.ResponsesResponseInputTokens{CachedTokens: 800}, OutputTokens: 100,
+		}}, 200*3e-6 + 800*0.3e-6 + 100*15e-6, 800 * 2.7e-6},
+	}
+	for _, tt := range tests {
+		if cost := pm.CalculateCost(response(tt.usage)); math.Abs(cost-tt.cost) > 1e-12 {
+			t.Errorf("%s: expected a cost of %g, got %g", tt.name, tt.cost, cost)
+		}
+		if savings := pm.CalculatePromptCacheSavings(response(tt.usage)); math.Abs(savings-tt.savings) > 1e-12 {
+			t.Errorf("%s: expected savings of %g, got %g", tt.name, tt.savings, savings)
+		}
+	}
+
+	// Cached tokens are billed at the regular price when the model has no cache prices
+	pm = newTestPricingManager(configstore.TableModelPricing{InputCostPerToken: 3e-6, OutputCostPerToken: 15e-6})
+	cached := response(&schemas.LLMUsage{PromptTokens: 1000, PromptTokensDetails: &schemas.TokenDetails{CachedTokens: 800}})
+	if cost, savings := pm.CalculateCost(cached), pm.CalculatePromptCacheSavings(cached); math.Abs(cost-1000*3e-6) > 1e-12 || savings != 0 {
+		t.Errorf("expected the regular price and no savings, got %g and %g", cost, savings)
+	}
+}
+
+// TestRerankPricing tests that rerank responses are billed per search unit or per token
+func TestRerankPricing(t *testing.T) {
+	price := func(value float64) *float64 { return &value }
+	pm := &PricingManager{pricingData: map[string]configstore.TableModelPricing{
+		makeKey("rerank-v3.5", "cohere", "rerank"): {Model: "rerank-v3.5", Provider: "cohere", Mode: "rerank", InputCostPerQuery: price(0.002)},
+		makeKey("rerank-m0", "jina", "rerank"):     {Model: "rerank-m0", Provider: "jina", Mode: "rerank", InputCostPerToken: 2e-8},
+	}}
+	response := func(provider schemas.ModelProvider, model string, usage *schemas.LLMUsage, searchUnits *float64) *schemas.BifrostResponse {
+		resp := &schemas.BifrostResponse{Usage: usage, ExtraFields: schemas.BifrostResponseExtraFields{
+			Provider: provider, ModelRequested: model, RequestType: schemas.RerankRequest,
+		}}
+		if searchUnits != nil {
+			resp.ExtraFields.BilledUsage = &schemas.BilledLLMUsage{SearchUnits: searchUnits}
+		}
+		return resp
+	}
+	tests := []struct {
+		name     string
+		response *schemas.BifrostResponse
+		cost     float64
+	}{
+		{"search units", response(schemas.Cohere, "rerank-v3.5", nil, price(3)), 3 * 0.002},
+		{"no search units", response(schemas.Cohere, "rerank-v3.5", nil, nil), 0.002},
+		{"tokens", response(schemas.Jina, "rerank-m0", &schemas.LLMUsage{PromptTokens: 5000, TotalTokens: 5000}, nil), 5000 * 2e-8},
+	}
+	for _, tt := range tests {
+		if cost := pm.CalculateCost(tt.response); math.Abs(cost-tt.cost) > 1e-12 {
+			t.Errorf("%s: expected a cost of %g, got %g", tt.name, tt.cost, cost)
+		}
+	}
+}
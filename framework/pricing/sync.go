@@ -231,6 +231,13 @@ func (pm *PricingManager) syncWorker(ctx context.Context) {
 	for {
 		select {
 		case <-pm.syncTicker.C:
+			// Only the cluster leader syncs; other replicas pick up the leader's data from the database
+			if !pm.isLeader() {
+				if err := pm.loadPricingFromDatabase(ctx); err != nil {
+					pm.logger.Error("background pricing reload failed: %v", err)
+				}
+				continue
+			}
 			// Check and sync pricing data - this handles the sync internally
 			if err := pm.checkAndSyncPricing(ctx); err != nil {
 				pm.logger.Error("background pricing sync failed: %v", err)
@@ -40,6 +40,8 @@ func isCacheReadRequest(req *schemas.BifrostRequest, headers map[string]string)
 func normalizeProvider(p string) string {
 	if strings.Contains(p, "vertex_ai") || p == "google-vertex" {
 		return string(schemas.Vertex)
+	} else if p == "jina_ai" {
+		return string(schemas.Jina)
 	} else {
 		return p
 	}
@@ -58,6 +60,8 @@ func normalizeRequestType(reqType schemas.RequestType) string {
 		baseType = "responses"
 	case schemas.EmbeddingRequest:
 		baseType = "embedding"
+	case schemas.RerankRequest:
+		baseType = "rerank"
 	case schemas.SpeechRequest, schemas.SpeechStreamRequest:
 		baseType = "audio_speech"
 	case schemas.TranscriptionRequest, schemas.TranscriptionStreamRequest:
@@ -111,9 +115,13 @@ func convertPricingDataToTableModelPricing(modelKey string, entry PricingEntry)
 		OutputCostPerCharacterAbove128kTokens:     entry.OutputCostPerCharacterAbove128kTokens,
 
 		// Cache and batch pricing
-		CacheReadInputTokenCost:   entry.CacheReadInputTokenCost,
-		InputCostPerTokenBatches:  entry.InputCostPerTokenBatches,
-		OutputCostPerTokenBatches: entry.OutputCostPerTokenBatches,
+		CacheReadInputTokenCost:     entry.CacheReadInputTokenCost,
+		CacheCreationInputTokenCost: entry.CacheCreationInputTokenCost,
+		InputCostPerTokenBatches:    entry.InputCostPerTokenBatches,
+		OutputCostPerTokenBatches:   entry.OutputCostPerTokenBatches,
+
+		// Rerank pricing
+		InputCostPerQuery: entry.InputCostPerQuery,
 	}
 
 	return pricing
@@ -126,3 +134,17 @@ func getSafeFloat64(ptr *float64, fallback float64) float64 {
 	}
 	return fallback
 }
+
+// promptCacheTokens returns the prompt tokens of usage read from and written to the provider's prompt cache
+func promptCacheTokens(usage *schemas.LLMUsage) (cachedTokens int, cacheCreationTokens int) {
+	if usage == nil {
+		return 0, 0
+	}
+	if details := usage.PromptTokensDetails; details != nil {
+		return details.CachedTokens, details.CacheCreationTokens
+	}
+	if usage.ResponsesExtendedResponseUsage != nil && usage.InputTokensDetails != nil {
+		return usage.InputTokensDetails.CachedTokens, 0
+	}
+	return 0, 0
+}
@@ -0,0 +1,47 @@
+// Package recording captures the HTTP exchanges between the gateway and providers for debugging,
+// so they can be inspected and replayed against the same or another provider.
+package recording
+
+import (
+	"fmt"
+	"slices"
+)
+
+// DefaultMaxBodyBytes bounds the request and response bodies kept by a recording.
+const DefaultMaxBodyBytes = 1 << 20
+
+// Config enables recording. Requests are recorded when they send the x-bf-record header, use one of
+// VirtualKeys or are served by one of the provider Keys.
+type Config struct {
+	Enabled      bool     `json:"enabled"`
+	VirtualKeys  []string `json:"virtual_keys,omitempty"`   // Virtual key values whose requests are always recorded
+	Keys         []string `json:"keys,omitempty"`           // Provider key IDs whose requests are always recorded
+	MaxBodyBytes int      `json:"max_body_bytes,omitempty"` // Longer bodies are truncated, defaults to 1 MiB
+}
+
+// Validate checks the configuration.
+func (c *Config) Validate() error {
+	if c.MaxBodyBytes < 0 {
+		return fmt.Errorf("recording: max_body_bytes must not be negative")
+	}
+	return nil
+}
+
+// maxBodyBytes returns the body size limit.
+func (c *Config) maxBodyBytes() int {
+	if c.MaxBodyBytes > 0 {
+		return c.MaxBodyBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+// ShouldRecord reports whether a request is recorded, given whether it asked to be through the header,
+// its virtual key and the ID of the provider key serving it.
+func (c *Config) ShouldRecord(requested bool, virtualKey, keyID string) bool {
+	if c == nil || !c.Enabled {
+		return false
+	}
+	return requested ||
+		(virtualKey != "" && slices.Contains(c.VirtualKeys, virtualKey)) ||
+		(keyID != "" && slices.Contains(c.Keys, keyID))
+}
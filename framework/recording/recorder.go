@@ -0,0 +1,76 @@
+package recording
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// Recorder saves the upstream exchanges of one request attempt that Config selects for recording.
+// It implements schemas.UpstreamRecorder; set it in the request context under
+// schemas.BifrostContextKeyUpstreamRecorder.
+type Recorder struct {
+	config *Config
+	store  Store
+	logger schemas.Logger
+
+	// Requested is set when the client asked for the request to be recorded
+	Requested   bool
+	RequestID   string
+	RequestType schemas.RequestType
+	Provider    schemas.ModelProvider
+	Model       string
+	VirtualKey  string
+	// Request is the gateway request serialized as JSON, re-issued by replays
+	Request string
+}
+
+// NewRecorder creates a recorder saving to store the exchanges selected by config.
+func NewRecorder(config *Config, store Store, logger schemas.Logger) *Recorder {
+	return &Recorder{config: config, store: store, logger: logger}
+}
+
+// RecordUpstream saves an exchange when the request or the provider key serving it is selected for recording.
+// The exchange is saved synchronously so a recording is available as soon as the response is.
+func (r *Recorder) RecordUpstream(ctx context.Context, exchange *schemas.UpstreamExchange) {
+	keyID, _ := ctx.Value(schemas.BifrostContextKeySelectedKey).(string)
+	if !r.config.ShouldRecord(r.Requested, r.VirtualKey, keyID) {
+		return
+	}
+	limit := r.config.maxBodyBytes()
+	requestBody, requestTruncated := truncate(exchange.RequestBody, limit)
+	responseBody, responseTruncated := truncate(exchange.ResponseBody, limit)
+	recording := &Recording{
+		ID:              uuid.NewString(),
+		RequestID:       r.RequestID,
+		RequestType:     string(r.RequestType),
+		Provider:        string(r.Provider),
+		Model:           r.Model,
+		KeyID:           keyID,
+		VirtualKey:      r.VirtualKey,
+		Request:         r.Request,
+		Method:          exchange.Method,
+		URL:             exchange.URL,
+		RequestHeaders:  exchange.RequestHeaders,
+		RequestBody:     requestBody,
+		StatusCode:      exchange.StatusCode,
+		ResponseHeaders: exchange.ResponseHeaders,
+		ResponseBody:    responseBody,
+		Truncated:       requestTruncated || responseTruncated,
+		LatencyMs:       exchange.Latency.Milliseconds(),
+		Error:           exchange.Error,
+	}
+	// The request context may already be cancelled once the response is in
+	if err := r.store.Save(context.WithoutCancel(ctx), recording); err != nil {
+		r.logger.Warn("failed to save recording of request %s: %v", r.RequestID, err)
+	}
+}
+
+// truncate cuts body to limit bytes.
+func truncate(body []byte, limit int) (string, bool) {
+	if len(body) > limit {
+		return string(body[:limit]), true
+	}
+	return string(body), false
+}
@@ -0,0 +1,83 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestRecorder verifies that only selected exchanges are saved, with truncated bodies, in both stores.
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "recordings.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	config := &Config{Enabled: true, Keys: []string{"key-debug"}, MaxBodyBytes: 8}
+	exchange := &schemas.UpstreamExchange{
+		Method:         "POST",
+		URL:            "https://api.openai.com/v1/chat/completions",
+		RequestHeaders: map[string]string{"Authorization": "[REDACTED]"},
+		RequestBody:    []byte(`{"model":"gpt-4o"}`),
+		StatusCode:     200,
+		ResponseBody:   []byte(`{"id":"1"}`),
+		Latency:        120 * time.Millisecond,
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			recorder := NewRecorder(config, store, bifrost.NewDefaultLogger(schemas.LogLevelError))
+			recorder.RequestID = "req-" + name
+			recorder.Provider = schemas.OpenAI
+			recorder.Model = "gpt-4o"
+			recorder.Request = `{"model":"gpt-4o"}`
+
+			// Neither requested nor served by a recorded key
+			recorder.RecordUpstream(context.WithValue(ctx, schemas.BifrostContextKeySelectedKey, "key-prod"), exchange)
+			// Served by a recorded key
+			recorder.RecordUpstream(context.WithValue(ctx, schemas.BifrostContextKeySelectedKey, "key-debug"), exchange)
+
+			recordings, total, err := store.List(ctx, Filter{RequestID: recorder.RequestID}, 10, 0)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if total != 1 || len(recordings) != 1 || recordings[0].ResponseBody != "" {
+				t.Fatalf("List() = %+v, %d, want one recording without bodies", recordings, total)
+			}
+			recording, err := store.Get(ctx, recordings[0].ID)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if recording.KeyID != "key-debug" || recording.RequestBody != `{"model"` || !recording.Truncated ||
+				recording.RequestHeaders["Authorization"] != "[REDACTED]" || recording.LatencyMs != 120 || recording.Request == "" {
+				t.Errorf("Get() = %+v", recording)
+			}
+
+			if err := store.Delete(ctx, recording.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Get(ctx, recording.ID); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+
+	if (&Config{}).ShouldRecord(true, "", "") {
+		t.Error("expected nothing to be recorded while recording is disabled")
+	}
+	if !(&Config{Enabled: true, VirtualKeys: []string{"vk-1"}}).ShouldRecord(false, "vk-1", "") {
+		t.Error("expected requests of a recorded virtual key to be recorded")
+	}
+}
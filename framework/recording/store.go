@@ -0,0 +1,279 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a recording does not exist.
+var ErrNotFound = errors.New("recording not found")
+
+// maxInMemoryRecordings bounds the in-memory store, which drops its oldest recordings first.
+const maxInMemoryRecordings = 1000
+
+// Recording is one HTTP exchange with a provider. A request retried or falling back to other providers has
+// one recording per attempt, all sharing its RequestID, which matches the request log entry.
+type Recording struct {
+	ID          string `gorm:"type:varchar(36);primaryKey" json:"id"`
+	RequestID   string `gorm:"type:varchar(255);index" json:"request_id"`
+	RequestType string `gorm:"type:varchar(32)" json:"request_type"`
+	Provider    string `gorm:"type:varchar(50);index" json:"provider"`
+	Model       string `gorm:"type:varchar(255);index" json:"model"`
+	KeyID       string `gorm:"type:varchar(255)" json:"key_id,omitempty"`
+	VirtualKey  string `gorm:"type:varchar(255)" json:"virtual_key,omitempty"`
+	// Request is the gateway request, serialized as JSON, that replays re-issue
+	Request string `gorm:"type:text" json:"request"`
+
+	// Upstream exchange, with credentials redacted
+	Method              string `gorm:"type:varchar(16)" json:"method"`
+	URL                 string `gorm:"type:text" json:"url"`
+	RequestHeadersJSON  string `gorm:"type:text" json:"-"` // JSON serialized RequestHeaders
+	RequestBody         string `gorm:"type:text" json:"request_body,omitempty"`
+	StatusCode          int    `json:"status_code,omitempty"`
+	ResponseHeadersJSON string `gorm:"type:text" json:"-"` // JSON serialized ResponseHeaders
+	ResponseBody        string `gorm:"type:text" json:"response_body,omitempty"`
+	Truncated           bool   `json:"truncated,omitempty"` // Set when a body exceeded the size limit
+	LatencyMs           int64  `json:"latency_ms"`
+	Error               string `gorm:"type:text;column:error_message" json:"error,omitempty"`
+
+	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	RequestHeaders  map[string]string `gorm:"-" json:"request_headers"`
+	ResponseHeaders map[string]string `gorm:"-" json:"response_headers,omitempty"`
+}
+
+// TableName sets the table name for recordings
+func (Recording) TableName() string { return "upstream_recordings" }
+
+// BeforeSave serializes the headers of a recording
+func (r *Recording) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(r.RequestHeaders)
+	if err != nil {
+		return err
+	}
+	r.RequestHeadersJSON = string(data)
+	data, err = json.Marshal(r.ResponseHeaders)
+	if err != nil {
+		return err
+	}
+	r.ResponseHeadersJSON = string(data)
+	return nil
+}
+
+// AfterFind deserializes the headers of a recording
+func (r *Recording) AfterFind(tx *gorm.DB) error {
+	if r.RequestHeadersJSON != "" {
+		if err := json.Unmarshal([]byte(r.RequestHeadersJSON), &r.RequestHeaders); err != nil {
+			return err
+		}
+	}
+	if r.ResponseHeadersJSON != "" {
+		if err := json.Unmarshal([]byte(r.ResponseHeadersJSON), &r.ResponseHeaders); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter selects recordings. Empty fields match all recordings.
+type Filter struct {
+	RequestID string
+	Provider  string
+	Model     string
+}
+
+// matches reports whether a recording is selected by the filter.
+func (f Filter) matches(r *Recording) bool {
+	return (f.RequestID == "" || r.RequestID == f.RequestID) &&
+		(f.Provider == "" || r.Provider == f.Provider) &&
+		(f.Model == "" || r.Model == f.Model)
+}
+
+// Store persists recordings.
+type Store interface {
+	// Save records an exchange.
+	Save(ctx context.Context, recording *Recording) error
+	// Get returns a recording, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Recording, error)
+	// List returns a page of the recordings matching filter, newest first, and the number of matching recordings.
+	// Bodies are omitted.
+	List(ctx context.Context, filter Filter, limit, offset int) ([]Recording, int64, error)
+	// Delete removes a recording, or returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores recordings in a database, usually the logs store next to the request logs.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the recordings table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate recordings table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the recordings table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addupstreamrecordingstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Recording{}) {
+				if err := migrator.CreateTable(&Recording{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Save records an exchange.
+func (s *RDBStore) Save(ctx context.Context, recording *Recording) error {
+	return s.db.WithContext(ctx).Save(recording).Error
+}
+
+// Get returns a recording.
+func (s *RDBStore) Get(ctx context.Context, id string) (*Recording, error) {
+	var recording Recording
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&recording).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &recording, nil
+}
+
+// List returns a page of the recordings matching filter.
+func (s *RDBStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Recording, int64, error) {
+	query := s.db.WithContext(ctx).Model(&Recording{})
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.Model != "" {
+		query = query.Where("model = ?", filter.Model)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var recordings []Recording
+	err := query.Omit("request", "request_body", "response_body").
+		Order("created_at DESC").Limit(limit).Offset(offset).Find(&recordings).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return recordings, total, nil
+}
+
+// Delete removes a recording.
+func (s *RDBStore) Delete(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&Recording{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// InMemoryStore keeps the latest recordings in memory. Recordings are lost on restart.
+type InMemoryStore struct {
+	mu         sync.RWMutex
+	recordings []Recording // Oldest first
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{}
+}
+
+// Save records an exchange.
+func (s *InMemoryStore) Save(ctx context.Context, recording *Recording) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if recording.CreatedAt.IsZero() {
+		recording.CreatedAt = time.Now()
+	}
+	s.recordings = append(s.recordings, *recording)
+	if len(s.recordings) > maxInMemoryRecordings {
+		s.recordings = s.recordings[len(s.recordings)-maxInMemoryRecordings:]
+	}
+	return nil
+}
+
+// Get returns a recording.
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Recording, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := range s.recordings {
+		if s.recordings[i].ID == id {
+			recording := s.recordings[i]
+			return &recording, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List returns a page of the recordings matching filter.
+func (s *InMemoryStore) List(ctx context.Context, filter Filter, limit, offset int) ([]Recording, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var matching []Recording
+	for i := len(s.recordings) - 1; i >= 0; i-- {
+		if filter.matches(&s.recordings[i]) {
+			recording := s.recordings[i]
+			recording.Request, recording.RequestBody, recording.ResponseBody = "", "", ""
+			matching = append(matching, recording)
+		}
+	}
+	total := int64(len(matching))
+	if offset >= len(matching) {
+		return []Recording{}, total, nil
+	}
+	matching = matching[offset:]
+	if limit > 0 && limit < len(matching) {
+		matching = matching[:limit]
+	}
+	return matching, total, nil
+}
+
+// Delete removes a recording.
+func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.recordings {
+		if s.recordings[i].ID == id {
+			s.recordings = append(s.recordings[:i], s.recordings[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
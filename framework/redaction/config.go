@@ -0,0 +1,51 @@
+// Package redaction provides PII scrubbing for prompt and response content before it leaves
+// the request path, i.e. before it is written to log stores, event streams or trace attributes.
+package redaction
+
+// Detector is a built-in PII detector.
+type Detector string
+
+const (
+	DetectorEmail      Detector = "email"
+	DetectorAPIKey     Detector = "api_key"
+	DetectorCreditCard Detector = "credit_card"
+)
+
+// DefaultReplacement is the text that replaces redacted content when no replacement is configured.
+const DefaultReplacement = "[REDACTED]"
+
+// Pattern is a custom regular expression whose matches are redacted.
+type Pattern struct {
+	Name        string `json:"name,omitempty"`
+	Regex       string `json:"regex"`
+	Replacement string `json:"replacement,omitempty"` // Overrides the policy replacement for this pattern
+}
+
+// Policy describes what gets redacted.
+//
+// Field paths are dot separated and are resolved against the JSON form of the content,
+// rooted at the log column name (input_history, output_message, params, tools, tool_calls,
+// error_details, raw_response, ...) or, for traces, at the attribute key. A "*" segment
+// matches any object key or array index, e.g. "input_history.*.content" or "params.user".
+// The whole value at a matching path is replaced, whatever its type.
+type Policy struct {
+	Detectors   []Detector `json:"detectors,omitempty"`
+	Patterns    []Pattern  `json:"patterns,omitempty"`
+	FieldPaths  []string   `json:"field_paths,omitempty"`
+	Replacement string     `json:"replacement,omitempty"` // Default: [REDACTED]
+}
+
+// TenantPolicy overrides the default policy for a single tenant.
+// A tenant policy replaces the default policy entirely rather than being merged into it.
+type TenantPolicy struct {
+	Disabled bool `json:"disabled,omitempty"` // Skip redaction for this tenant
+	Policy
+}
+
+// Config represents the redaction configuration.
+// Tenants are keyed by virtual key (the x-bf-vk header value).
+type Config struct {
+	Enabled bool `json:"enabled"`
+	Policy
+	Tenants map[string]*TenantPolicy `json:"tenants,omitempty"`
+}
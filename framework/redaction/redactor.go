@@ -0,0 +1,279 @@
+package redaction
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+var (
+	emailRegex = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// apiKeyRegex covers the common provider key formats (OpenAI/Anthropic, AWS, Google, GitHub, Slack) and bearer tokens.
+	apiKeyRegex = regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}|\bAKIA[0-9A-Z]{16}\b|\bAIza[0-9A-Za-z_-]{35}|\bgh[pousr]_[A-Za-z0-9]{36,}|\bxox[abprs]-[A-Za-z0-9-]{10,}|(?i:\bbearer\s+)[A-Za-z0-9._~+/-]{20,}=*`)
+	// creditCardRegex finds 13-19 digit runs with optional space/dash separators; matches are Luhn checked.
+	creditCardRegex = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// rule is a compiled redaction rule.
+type rule struct {
+	re          *regexp.Regexp
+	replacement string
+	validate    func(match string) bool // Optional check to cut false positives
+}
+
+// compiledPolicy is a Policy ready to be applied.
+type compiledPolicy struct {
+	disabled    bool
+	rules       []rule
+	fieldPaths  [][]string
+	replacement string
+}
+
+// Redactor applies redaction policies to content.
+// A nil *Redactor is valid and leaves all content untouched.
+type Redactor struct {
+	defaultPolicy *compiledPolicy
+	tenants       map[string]*compiledPolicy
+}
+
+// New compiles the redaction config. It returns nil when redaction is not enabled.
+func New(config *Config) (*Redactor, error) {
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+	defaultPolicy, err := compilePolicy(&config.Policy)
+	if err != nil {
+		return nil, err
+	}
+	r := &Redactor{
+		defaultPolicy: defaultPolicy,
+		tenants:       make(map[string]*compiledPolicy, len(config.Tenants)),
+	}
+	for tenant, tenantPolicy := range config.Tenants {
+		if tenantPolicy == nil {
+			continue
+		}
+		policy, err := compilePolicy(&tenantPolicy.Policy)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		policy.disabled = tenantPolicy.Disabled
+		r.tenants[tenant] = policy
+	}
+	return r, nil
+}
+
+// compilePolicy validates a policy and compiles its detectors and patterns.
+func compilePolicy(policy *Policy) (*compiledPolicy, error) {
+	compiled := &compiledPolicy{replacement: policy.Replacement}
+	if compiled.replacement == "" {
+		compiled.replacement = DefaultReplacement
+	}
+	for _, detector := range policy.Detectors {
+		switch detector {
+		case DetectorEmail:
+			compiled.rules = append(compiled.rules, rule{re: emailRegex, replacement: compiled.replacement})
+		case DetectorAPIKey:
+			compiled.rules = append(compiled.rules, rule{re: apiKeyRegex, replacement: compiled.replacement})
+		case DetectorCreditCard:
+			compiled.rules = append(compiled.rules, rule{re: creditCardRegex, replacement: compiled.replacement, validate: luhnValid})
+		default:
+			return nil, fmt.Errorf("unknown redaction detector: %s", detector)
+		}
+	}
+	for _, pattern := range policy.Patterns {
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern.Name, err)
+		}
+		replacement := pattern.Replacement
+		if replacement == "" {
+			replacement = compiled.replacement
+		}
+		compiled.rules = append(compiled.rules, rule{re: re, replacement: replacement})
+	}
+	for _, path := range policy.FieldPaths {
+		if path == "" {
+			continue
+		}
+		compiled.fieldPaths = append(compiled.fieldPaths, strings.Split(path, "."))
+	}
+	return compiled, nil
+}
+
+// TenantFromContext returns the tenant a request belongs to, which is its virtual key.
+func TenantFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	return tenant
+}
+
+// policyFor returns the policy for a tenant, or nil when nothing should be redacted.
+func (r *Redactor) policyFor(tenant string) *compiledPolicy {
+	if r == nil {
+		return nil
+	}
+	policy := r.defaultPolicy
+	if tenant != "" {
+		if tenantPolicy, ok := r.tenants[tenant]; ok {
+			policy = tenantPolicy
+		}
+	}
+	if policy.disabled {
+		return nil
+	}
+	return policy
+}
+
+// RedactString applies the detectors and patterns of the tenant's policy to s.
+func (r *Redactor) RedactString(tenant string, s string) string {
+	policy := r.policyFor(tenant)
+	if policy == nil {
+		return s
+	}
+	return policy.redactString(s)
+}
+
+// RedactField redacts the value stored under a top level field (a log column or trace attribute key).
+// The whole value is replaced when a field path matches the key, otherwise its string content is scrubbed.
+func (r *Redactor) RedactField(tenant string, key string, value string) string {
+	policy := r.policyFor(tenant)
+	if policy == nil {
+		return value
+	}
+	if policy.matchesPath([]string{key}) {
+		return policy.replacement
+	}
+	return policy.redactString(value)
+}
+
+// RedactJSON redacts a JSON document rooted at the given field name.
+// Documents that cannot be parsed are scrubbed as plain text.
+func (r *Redactor) RedactJSON(tenant string, root string, data []byte) []byte {
+	policy := r.policyFor(tenant)
+	if policy == nil || len(data) == 0 {
+		return data
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return []byte(policy.redactString(string(data)))
+	}
+	redacted, err := json.Marshal(policy.walk([]string{root}, value))
+	if err != nil {
+		return []byte(policy.redactString(string(data)))
+	}
+	return redacted
+}
+
+// RedactValue redacts a decoded JSON value (maps, slices and scalars as produced by encoding/json)
+// rooted at the given field name. Maps and slices are redacted in place.
+func (r *Redactor) RedactValue(tenant string, root string, value any) any {
+	policy := r.policyFor(tenant)
+	if policy == nil {
+		return value
+	}
+	return policy.walk([]string{root}, value)
+}
+
+// RedactValueAt redacts a decoded JSON value located at a dot separated path, such as a value changed by a
+// traced request step. Maps and slices are redacted in place.
+func (r *Redactor) RedactValueAt(tenant string, path string, value any) any {
+	policy := r.policyFor(tenant)
+	if policy == nil {
+		return value
+	}
+	return policy.walk(strings.Split(path, "."), value)
+}
+
+// redactString applies every rule to s.
+func (p *compiledPolicy) redactString(s string) string {
+	for _, rule := range p.rules {
+		if rule.validate == nil {
+			s = rule.re.ReplaceAllLiteralString(s, rule.replacement)
+			continue
+		}
+		validate := rule.validate
+		replacement := rule.replacement
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			if validate(match) {
+				return replacement
+			}
+			return match
+		})
+	}
+	return s
+}
+
+// walk redacts a decoded JSON value located at path.
+func (p *compiledPolicy) walk(path []string, value any) any {
+	if p.matchesPath(path) {
+		return p.replacement
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			v[key] = p.walk(append(path, key), child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = p.walk(append(path, strconv.Itoa(i)), child)
+		}
+		return v
+	case string:
+		return p.redactString(v)
+	default:
+		return v
+	}
+}
+
+// matchesPath reports whether path matches one of the configured field paths.
+func (p *compiledPolicy) matchesPath(path []string) bool {
+	for _, fieldPath := range p.fieldPaths {
+		if len(fieldPath) != len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range fieldPath {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,123 @@
+package redaction
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRedactStringDetectors verifies the built-in detectors and that the credit card detector is Luhn checked.
+func TestRedactStringDetectors(t *testing.T) {
+	r, err := New(&Config{
+		Enabled: true,
+		Policy: Policy{
+			Detectors: []Detector{DetectorEmail, DetectorAPIKey, DetectorCreditCard},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	input := "mail jane.doe@example.com, key sk-abcdefghijklmnopqrstuvwx, card 4111 1111 1111 1111, order 1234567890123"
+	got := r.RedactString("", input)
+	for _, leaked := range []string{"jane.doe@example.com", "sk-abcdefghijklmnopqrstuvwx", "4111 1111 1111 1111"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("RedactString() leaked %q: %s", leaked, got)
+		}
+	}
+	if !strings.Contains(got, "1234567890123") {
+		t.Errorf("RedactString() redacted a number that fails the Luhn check: %s", got)
+	}
+}
+
+// TestRedactJSONFieldPaths verifies field path matching with wildcards and custom patterns.
+func TestRedactJSONFieldPaths(t *testing.T) {
+	r, err := New(&Config{
+		Enabled: true,
+		Policy: Policy{
+			Patterns:   []Pattern{{Name: "ssn", Regex: `\d{3}-\d{2}-\d{4}`, Replacement: "[SSN]"}},
+			FieldPaths: []string{"input_history.*.name", "params.user"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	got := string(r.RedactJSON("", "input_history", []byte(`[{"role":"user","name":"jane","content":"ssn 123-45-6789"}]`)))
+	want := `[{"content":"ssn [SSN]","name":"[REDACTED]","role":"user"}]`
+	if got != want {
+		t.Errorf("RedactJSON() = %s, want %s", got, want)
+	}
+
+	got = string(r.RedactJSON("", "params", []byte(`{"user":{"id":42},"temperature":0.5}`)))
+	want = `{"temperature":0.5,"user":"[REDACTED]"}`
+	if got != want {
+		t.Errorf("RedactJSON() = %s, want %s", got, want)
+	}
+}
+
+// TestRedactValueAt verifies that values located at a dot separated path are matched against the field paths.
+func TestRedactValueAt(t *testing.T) {
+	r, err := New(&Config{
+		Enabled: true,
+		Policy: Policy{
+			Detectors:  []Detector{DetectorEmail},
+			FieldPaths: []string{"ChatRequest.Params.user", "messages.*.name"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if got := r.RedactValueAt("", "ChatRequest.Params.user", "user-42"); got != DefaultReplacement {
+		t.Errorf("RedactValueAt() = %v, want the field path redacted", got)
+	}
+	message := map[string]any{"name": "jane", "content": "mail jane@example.com"}
+	got := r.RedactValueAt("", "messages.0", message).(map[string]any)
+	if got["name"] != DefaultReplacement || got["content"] != "mail "+DefaultReplacement {
+		t.Errorf("RedactValueAt() = %v, want the nested field path and the email redacted", got)
+	}
+	if got := r.RedactValueAt("", "model", "gpt-4o"); got != "gpt-4o" {
+		t.Errorf("RedactValueAt() = %v, want values outside the field paths kept", got)
+	}
+}
+
+// TestTenantOverrides verifies that tenant policies replace the default policy.
+func TestTenantOverrides(t *testing.T) {
+	r, err := New(&Config{
+		Enabled: true,
+		Policy:  Policy{Detectors: []Detector{DetectorEmail}},
+		Tenants: map[string]*TenantPolicy{
+			"vk-internal": {Disabled: true},
+			"vk-strict":   {Policy: Policy{Detectors: []Detector{DetectorEmail}, Replacement: "***"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	const input = "contact a@b.io"
+	if got := r.RedactString("vk-other", input); got != "contact [REDACTED]" {
+		t.Errorf("default policy: got %q", got)
+	}
+	if got := r.RedactString("vk-internal", input); got != input {
+		t.Errorf("disabled tenant: got %q", got)
+	}
+	if got := r.RedactString("vk-strict", input); got != "contact ***" {
+		t.Errorf("tenant replacement: got %q", got)
+	}
+
+	var nilRedactor *Redactor
+	if got := nilRedactor.RedactString("", input); got != input {
+		t.Errorf("nil redactor: got %q", got)
+	}
+}
+
+// TestNewRejectsInvalidConfig verifies that unknown detectors and bad patterns are reported.
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	if _, err := New(&Config{Enabled: true, Policy: Policy{Detectors: []Detector{"phone"}}}); err == nil {
+		t.Error("expected error for unknown detector")
+	}
+	if _, err := New(&Config{Enabled: true, Policy: Policy{Patterns: []Pattern{{Regex: "("}}}}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,110 @@
+// Package serviceaccounts manages machine-to-machine tokens for the management API. Each service account
+// holds scopes limiting what its token may do, so that automation does not need the admin secret.
+package serviceaccounts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TokenPrefix starts every service account token, telling them apart from the admin secret.
+const TokenPrefix = "bf-sa-"
+
+// Scope is a permission granted to a service account.
+type Scope string
+
+const (
+	ScopeConfigRead  Scope = "config:read"  // Read the configuration through GET requests
+	ScopeConfigWrite Scope = "config:write" // Change the configuration, except provider and virtual keys
+	ScopeLogsRead    Scope = "logs:read"    // Read request logs
+	ScopeKeysWrite   Scope = "keys:write"   // Create, change and delete provider and virtual keys
+	ScopeAdmin       Scope = "admin"        // Everything the admin secret allows, including managing service accounts
+)
+
+// Scopes lists the valid scopes.
+var Scopes = []Scope{ScopeConfigRead, ScopeConfigWrite, ScopeLogsRead, ScopeKeysWrite, ScopeAdmin}
+
+// ValidScope reports whether scope is a known scope.
+func ValidScope(scope Scope) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Account is a service account. Only the hash of its token is stored; the token itself is shown once
+// when the account is created or its token rotated.
+type Account struct {
+	ID          string     `gorm:"type:varchar(36);primaryKey" json:"id"`
+	Name        string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"name"`
+	Description string     `gorm:"type:text" json:"description,omitempty"`
+	ScopesJSON  string     `gorm:"type:text" json:"-"` // JSON serialized Scopes
+	TokenHash   string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	TokenHint   string     `gorm:"type:varchar(16)" json:"token_hint"` // Last characters of the token, to recognize it
+	Disabled    bool       `json:"disabled"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"index;not null" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"not null" json:"updated_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Scopes []Scope `gorm:"-" json:"scopes"`
+}
+
+// TableName sets the table name for service accounts
+func (Account) TableName() string { return "service_accounts" }
+
+// BeforeSave serializes the scopes of an account
+func (a *Account) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(a.Scopes)
+	if err != nil {
+		return err
+	}
+	a.ScopesJSON = string(data)
+	return nil
+}
+
+// AfterFind deserializes the scopes of an account
+func (a *Account) AfterFind(tx *gorm.DB) error {
+	if a.ScopesJSON == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(a.ScopesJSON), &a.Scopes)
+}
+
+// Allows reports whether the account holds scope. The admin scope allows everything.
+func (a *Account) Allows(scope Scope) bool {
+	for _, s := range a.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the token of the account is accepted at now.
+func (a *Account) Active(now time.Time) bool {
+	return !a.Disabled && (a.ExpiresAt == nil || now.Before(*a.ExpiresAt))
+}
+
+// HashToken returns the stored hash of a token.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken generates a random token.
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return TokenPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,228 @@
+package serviceaccounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// cacheTTL bounds how long an authenticated token is trusted without rereading its account, so changes
+	// made on other nodes sharing the config store apply within that delay.
+	cacheTTL = 10 * time.Second
+	// lastUsedInterval is how often the last use of an account is written to the store.
+	lastUsedInterval = time.Minute
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid service account token")
+	ErrInactive      = errors.New("service account is disabled or expired")
+	ErrDuplicateName = errors.New("a service account with this name already exists")
+)
+
+// Changes are the editable settings of a service account. Nil fields are left unchanged.
+type Changes struct {
+	Description *string    `json:"description,omitempty"`
+	Scopes      []Scope    `json:"scopes,omitempty"`
+	Disabled    *bool      `json:"disabled,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// cachedAccount is an authenticated account and when it was read from the store.
+type cachedAccount struct {
+	account  *Account
+	loadedAt time.Time
+}
+
+// Manager mints, rotates and authenticates service account tokens.
+type Manager struct {
+	store Store
+
+	mu    sync.Mutex
+	cache map[string]cachedAccount // By token hash
+}
+
+// NewManager creates a manager of the accounts in store.
+func NewManager(store Store) *Manager {
+	return &Manager{store: store, cache: make(map[string]cachedAccount)}
+}
+
+// validateScopes checks that scopes are known and not empty.
+func validateScopes(scopes []Scope) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !ValidScope(scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// Create mints a service account and returns it with its token, which is not retrievable afterwards.
+func (m *Manager) Create(ctx context.Context, name, description string, scopes []Scope, expiresAt *time.Time) (*Account, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("name is required")
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+	accounts, err := m.store.List(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, account := range accounts {
+		if account.Name == name {
+			return nil, "", ErrDuplicateName
+		}
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	account := &Account{
+		ID:          uuid.NewString(),
+		Name:        name,
+		Description: description,
+		Scopes:      scopes,
+		TokenHash:   HashToken(token),
+		TokenHint:   token[len(token)-4:],
+		ExpiresAt:   expiresAt,
+	}
+	if err := m.store.Save(ctx, account); err != nil {
+		return nil, "", err
+	}
+	return account, token, nil
+}
+
+// Update applies changes to an account.
+func (m *Manager) Update(ctx context.Context, id string, changes Changes) (*Account, error) {
+	account, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if changes.Scopes != nil {
+		if err := validateScopes(changes.Scopes); err != nil {
+			return nil, err
+		}
+		account.Scopes = changes.Scopes
+	}
+	if changes.Description != nil {
+		account.Description = *changes.Description
+	}
+	if changes.Disabled != nil {
+		account.Disabled = *changes.Disabled
+	}
+	if changes.ExpiresAt != nil {
+		account.ExpiresAt = changes.ExpiresAt
+	}
+	if err := m.store.Save(ctx, account); err != nil {
+		return nil, err
+	}
+	m.forget(account.TokenHash)
+	return account, nil
+}
+
+// Rotate replaces the token of an account, invalidating the previous one, and returns the new token.
+func (m *Manager) Rotate(ctx context.Context, id string) (*Account, string, error) {
+	account, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	previous := account.TokenHash
+	account.TokenHash = HashToken(token)
+	account.TokenHint = token[len(token)-4:]
+	if err := m.store.Save(ctx, account); err != nil {
+		return nil, "", err
+	}
+	m.forget(previous)
+	return account, token, nil
+}
+
+// Delete removes an account, revoking its token.
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	account, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := m.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	m.forget(account.TokenHash)
+	return nil
+}
+
+// Get returns an account.
+func (m *Manager) Get(ctx context.Context, id string) (*Account, error) {
+	return m.store.Get(ctx, id)
+}
+
+// List returns all accounts.
+func (m *Manager) List(ctx context.Context) ([]Account, error) {
+	return m.store.List(ctx)
+}
+
+// Authenticate returns the active account of a token, or ErrInvalidToken or ErrInactive.
+func (m *Manager) Authenticate(ctx context.Context, token string) (*Account, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+	hash := HashToken(token)
+	now := time.Now()
+
+	m.mu.Lock()
+	cached, ok := m.cache[hash]
+	m.mu.Unlock()
+	if !ok || now.Sub(cached.loadedAt) > cacheTTL {
+		account, err := m.store.GetByTokenHash(ctx, hash)
+		if errors.Is(err, ErrNotFound) {
+			m.forget(hash)
+			return nil, ErrInvalidToken
+		}
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedAccount{account: account, loadedAt: now}
+		m.mu.Lock()
+		m.cache[hash] = cached
+		m.mu.Unlock()
+	}
+
+	account := cached.account
+	if !account.Active(now) {
+		return nil, ErrInactive
+	}
+	m.touch(ctx, account, now)
+	return account, nil
+}
+
+// touch records the last use of an account, at most once per lastUsedInterval.
+func (m *Manager) touch(ctx context.Context, account *Account, now time.Time) {
+	m.mu.Lock()
+	if account.LastUsedAt != nil && now.Sub(*account.LastUsedAt) < lastUsedInterval {
+		m.mu.Unlock()
+		return
+	}
+	account.LastUsedAt = &now
+	m.mu.Unlock()
+
+	m.store.SetLastUsed(ctx, account.ID, now)
+}
+
+// forget drops a token from the cache.
+func (m *Manager) forget(hash string) {
+	m.mu.Lock()
+	delete(m.cache, hash)
+	m.mu.Unlock()
+}
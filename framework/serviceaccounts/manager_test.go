@@ -0,0 +1,93 @@
+package serviceaccounts
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestManager verifies minting, authenticating, disabling, rotating and deleting tokens with both stores.
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "accounts.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			m := NewManager(store)
+			if _, _, err := m.Create(ctx, "ci", "", []Scope{"deploy"}, nil); err == nil {
+				t.Fatal("expected an unknown scope to be rejected")
+			}
+			account, token, err := m.Create(ctx, "ci", "GitHub Actions", []Scope{ScopeConfigRead, ScopeLogsRead}, nil)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if !strings.HasPrefix(token, TokenPrefix) || account.TokenHint != token[len(token)-4:] || account.TokenHash == token {
+				t.Errorf("Create() = %+v, %q", account, token)
+			}
+			if _, _, err := m.Create(ctx, "ci", "", []Scope{ScopeAdmin}, nil); !errors.Is(err, ErrDuplicateName) {
+				t.Errorf("Create() error = %v, want ErrDuplicateName", err)
+			}
+
+			authenticated, err := m.Authenticate(ctx, token)
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if !authenticated.Allows(ScopeLogsRead) || authenticated.Allows(ScopeKeysWrite) {
+				t.Errorf("unexpected scopes %v", authenticated.Scopes)
+			}
+			if stored, _ := m.Get(ctx, account.ID); stored.LastUsedAt == nil {
+				t.Errorf("expected the last use to be recorded")
+			}
+			if _, err := m.Authenticate(ctx, TokenPrefix+"unknown"); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Authenticate() error = %v, want ErrInvalidToken", err)
+			}
+
+			disabled := true
+			if _, err := m.Update(ctx, account.ID, Changes{Disabled: &disabled}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if _, err := m.Authenticate(ctx, token); !errors.Is(err, ErrInactive) {
+				t.Errorf("Authenticate() error = %v, want ErrInactive", err)
+			}
+			disabled = false
+			expired := time.Now().Add(-time.Minute)
+			m.Update(ctx, account.ID, Changes{Disabled: &disabled, ExpiresAt: &expired})
+			if _, err := m.Authenticate(ctx, token); !errors.Is(err, ErrInactive) {
+				t.Errorf("Authenticate() error = %v, want ErrInactive for an expired account", err)
+			}
+			future := time.Now().Add(time.Hour)
+			m.Update(ctx, account.ID, Changes{ExpiresAt: &future, Scopes: []Scope{ScopeAdmin}})
+
+			_, rotated, err := m.Rotate(ctx, account.ID)
+			if err != nil {
+				t.Fatalf("Rotate() error = %v", err)
+			}
+			if _, err := m.Authenticate(ctx, token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Authenticate() error = %v, want the previous token to be revoked", err)
+			}
+			if authenticated, err := m.Authenticate(ctx, rotated); err != nil || !authenticated.Allows(ScopeKeysWrite) {
+				t.Errorf("Authenticate() = %v, %v, want an admin account", authenticated, err)
+			}
+
+			if err := m.Delete(ctx, account.ID); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := m.Authenticate(ctx, rotated); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Authenticate() error = %v, want the deleted account's token to be revoked", err)
+			}
+		})
+	}
+}
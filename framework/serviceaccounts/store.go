@@ -0,0 +1,212 @@
+package serviceaccounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a service account does not exist.
+var ErrNotFound = errors.New("service account not found")
+
+// Store persists service accounts.
+type Store interface {
+	// Get returns an account by ID, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Account, error)
+	// GetByTokenHash returns the account of a token hash, or ErrNotFound.
+	GetByTokenHash(ctx context.Context, hash string) (*Account, error)
+	// Save creates or replaces an account.
+	Save(ctx context.Context, account *Account) error
+	// SetLastUsed records the last use of an account without touching its other fields.
+	SetLastUsed(ctx context.Context, id string, at time.Time) error
+	// Delete removes an account, or returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+	// List returns all accounts sorted by name.
+	List(ctx context.Context) ([]Account, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores service accounts in the config store database.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the service accounts table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate service accounts table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the service accounts table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addserviceaccountstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Account{}) {
+				if err := migrator.CreateTable(&Account{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Get returns an account by ID.
+func (s *RDBStore) Get(ctx context.Context, id string) (*Account, error) {
+	return s.first(ctx, "id = ?", id)
+}
+
+// GetByTokenHash returns the account of a token hash.
+func (s *RDBStore) GetByTokenHash(ctx context.Context, hash string) (*Account, error) {
+	return s.first(ctx, "token_hash = ?", hash)
+}
+
+// first returns the account matching a condition.
+func (s *RDBStore) first(ctx context.Context, query string, arg any) (*Account, error) {
+	var account Account
+	if err := s.db.WithContext(ctx).Where(query, arg).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Save creates or replaces an account.
+func (s *RDBStore) Save(ctx context.Context, account *Account) error {
+	return s.db.WithContext(ctx).Save(account).Error
+}
+
+// SetLastUsed records the last use of an account.
+func (s *RDBStore) SetLastUsed(ctx context.Context, id string, at time.Time) error {
+	return s.db.WithContext(ctx).Model(&Account{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+}
+
+// Delete removes an account.
+func (s *RDBStore) Delete(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&Account{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns all accounts sorted by name.
+func (s *RDBStore) List(ctx context.Context) ([]Account, error) {
+	var accounts []Account
+	err := s.db.WithContext(ctx).Order("name").Find(&accounts).Error
+	return accounts, err
+}
+
+// InMemoryStore keeps service accounts in memory. Accounts are lost on restart and are not shared between replicas.
+type InMemoryStore struct {
+	mu       sync.RWMutex
+	accounts map[string]Account
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{accounts: make(map[string]Account)}
+}
+
+// Get returns a copy of an account by ID.
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	account, ok := s.accounts[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	account.Scopes = append([]Scope(nil), account.Scopes...)
+	return &account, nil
+}
+
+// GetByTokenHash returns a copy of the account of a token hash.
+func (s *InMemoryStore) GetByTokenHash(ctx context.Context, hash string) (*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, account := range s.accounts {
+		if account.TokenHash == hash {
+			account.Scopes = append([]Scope(nil), account.Scopes...)
+			return &account, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Save stores a copy of an account, filling in its timestamps like the database store does.
+func (s *InMemoryStore) Save(ctx context.Context, account *Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if account.CreatedAt.IsZero() {
+		account.CreatedAt = now
+	}
+	account.UpdatedAt = now
+	stored := *account
+	stored.Scopes = append([]Scope(nil), account.Scopes...)
+	s.accounts[account.ID] = stored
+	return nil
+}
+
+// SetLastUsed records the last use of an account.
+func (s *InMemoryStore) SetLastUsed(ctx context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account, ok := s.accounts[id]
+	if !ok {
+		return ErrNotFound
+	}
+	account.LastUsedAt = &at
+	s.accounts[id] = account
+	return nil
+}
+
+// Delete removes an account.
+func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.accounts, id)
+	return nil
+}
+
+// List returns all accounts sorted by name.
+func (s *InMemoryStore) List(ctx context.Context) ([]Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	accounts := make([]Account, 0, len(s.accounts))
+	for _, account := range s.accounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts, nil
+}
@@ -0,0 +1,23 @@
+package sessions
+
+// DefaultMaxTokens is the default token window of a session.
+const DefaultMaxTokens = 8000
+
+// Config configures server-side sessions.
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// MaxTokens bounds the estimated tokens of the stored summary and messages (default: 8000).
+	// The oldest messages are dropped once a session grows past it.
+	MaxTokens int `json:"max_tokens,omitempty"`
+	// SummarizeModel, in provider/model form, summarizes dropped messages into the session summary.
+	// Without it, dropped messages are discarded.
+	SummarizeModel string `json:"summarize_model,omitempty"`
+}
+
+// GetMaxTokens returns the token window, applying the default.
+func (c *Config) GetMaxTokens() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	return DefaultMaxTokens
+}
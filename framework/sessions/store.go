@@ -0,0 +1,219 @@
+// Package sessions stores server-side conversation histories, so that stateless clients can hold
+// multi-turn chats by sending only a session ID and their new messages.
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore/migrator"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a session does not exist.
+var ErrNotFound = errors.New("session not found")
+
+// MaxIDLength is the maximum length of a session ID.
+const MaxIDLength = 255
+
+// Session is a stored conversation. Messages older than the token window are dropped from
+// Messages and, when summarization is enabled, folded into Summary. Sessions created by inference requests
+// belong to the caller that created them (Owner) and are only continued by it; sessions without an owner are
+// continued by anyone knowing their ID.
+type Session struct {
+	ID           string    `gorm:"type:varchar(255);primaryKey" json:"id"`
+	Owner        string    `gorm:"type:varchar(64);index" json:"-"` // Hash of the virtual key or authorization that created the session
+	Summary      string    `gorm:"type:text" json:"summary,omitempty"`
+	MessagesJSON string    `gorm:"type:text" json:"-"` // JSON serialized []schemas.ChatMessage
+	MessageCount int       `json:"message_count"`
+	TokenCount   int       `json:"token_count"` // Estimated tokens of the summary and messages
+	CreatedAt    time.Time `gorm:"index;not null" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"index;not null" json:"updated_at"`
+
+	// Virtual fields for runtime use (not stored in DB)
+	Messages []schemas.ChatMessage `gorm:"-" json:"messages,omitempty"`
+}
+
+// TableName sets the table name for sessions
+func (Session) TableName() string { return "sessions" }
+
+// BeforeSave serializes the messages of a session
+func (s *Session) BeforeSave(tx *gorm.DB) error {
+	data, err := json.Marshal(s.Messages)
+	if err != nil {
+		return err
+	}
+	s.MessagesJSON = string(data)
+	s.MessageCount = len(s.Messages)
+	return nil
+}
+
+// AfterFind deserializes the messages of a session
+func (s *Session) AfterFind(tx *gorm.DB) error {
+	if s.MessagesJSON == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(s.MessagesJSON), &s.Messages)
+}
+
+// Store persists sessions.
+type Store interface {
+	// Get returns a session with its messages, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Session, error)
+	// Save creates or replaces a session.
+	Save(ctx context.Context, session *Session) error
+	// Delete removes a session, or returns ErrNotFound.
+	Delete(ctx context.Context, id string) error
+	// List returns all sessions without their messages, most recently updated first.
+	List(ctx context.Context) ([]Session, error)
+}
+
+// NewStore returns a store backed by db, or an in-memory store when db is nil.
+func NewStore(ctx context.Context, db *gorm.DB) (Store, error) {
+	if db == nil {
+		return NewInMemoryStore(), nil
+	}
+	return NewRDBStore(ctx, db)
+}
+
+// RDBStore stores sessions in the config store database.
+type RDBStore struct {
+	db *gorm.DB
+}
+
+// NewRDBStore creates the sessions table if needed and returns a store backed by db.
+func NewRDBStore(ctx context.Context, db *gorm.DB) (*RDBStore, error) {
+	if err := migrate(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to migrate sessions table: %w", err)
+	}
+	return &RDBStore{db: db}, nil
+}
+
+// migrate creates the sessions table, recording the migration in db so later schema changes are versioned.
+func migrate(ctx context.Context, db *gorm.DB) error {
+	m := migrator.New(db, migrator.DefaultOptions, []*migrator.Migration{{
+		ID: "addsessionstable",
+		Migrate: func(tx *gorm.DB) error {
+			tx = tx.WithContext(ctx)
+			migrator := tx.Migrator()
+
+			if !migrator.HasTable(&Session{}) {
+				if err := migrator.CreateTable(&Session{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+	return m.Migrate()
+}
+
+// Get returns a session with its messages.
+func (s *RDBStore) Get(ctx context.Context, id string) (*Session, error) {
+	var session Session
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Save creates or replaces a session.
+func (s *RDBStore) Save(ctx context.Context, session *Session) error {
+	return s.db.WithContext(ctx).Save(session).Error
+}
+
+// Delete removes a session.
+func (s *RDBStore) Delete(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Where("id = ?", id).Delete(&Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns all sessions without their messages.
+func (s *RDBStore) List(ctx context.Context) ([]Session, error) {
+	var sessions []Session
+	err := s.db.WithContext(ctx).
+		Omit("messages_json").
+		Order("updated_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// InMemoryStore keeps sessions in memory. Sessions are lost on restart and are not shared between replicas.
+type InMemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+// NewInMemoryStore creates an empty in-memory store.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{sessions: make(map[string]Session)}
+}
+
+// Get returns a copy of a session.
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	session.Messages = append([]schemas.ChatMessage(nil), session.Messages...)
+	return &session, nil
+}
+
+// Save stores a copy of a session, filling in its timestamps like the database store does.
+func (s *InMemoryStore) Save(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+	session.MessageCount = len(session.Messages)
+	stored := *session
+	stored.Messages = append([]schemas.ChatMessage(nil), session.Messages...)
+	s.sessions[session.ID] = stored
+	return nil
+}
+
+// Delete removes a session.
+func (s *InMemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+// List returns all sessions without their messages.
+func (s *InMemoryStore) List(ctx context.Context) ([]Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		session.Messages = nil
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
@@ -0,0 +1,97 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func textMessage(role schemas.ChatMessageRole, text string) schemas.ChatMessage {
+	return schemas.ChatMessage{Role: role, Content: &schemas.ChatMessageContent{ContentStr: &text}}
+}
+
+// TestTrim verifies that the oldest messages are dropped first, along with orphaned tool results,
+// and that the latest message is kept even when it alone exceeds the window.
+func TestTrim(t *testing.T) {
+	long := strings.Repeat("a", 400) // ~100 tokens
+	messages := []schemas.ChatMessage{
+		textMessage(schemas.ChatMessageRoleUser, long),
+		textMessage(schemas.ChatMessageRoleAssistant, long),
+		textMessage(schemas.ChatMessageRoleTool, long),
+		textMessage(schemas.ChatMessageRoleUser, "hi"),
+	}
+
+	kept, dropped := Trim("", messages, 120)
+	if len(dropped) != 3 || len(kept) != 1 {
+		t.Fatalf("Trim() kept %d and dropped %d messages, want 1 and 3", len(kept), len(dropped))
+	}
+
+	kept, dropped = Trim("", messages, 10_000)
+	if len(dropped) != 0 || len(kept) != 4 {
+		t.Errorf("Trim() dropped %d messages under the window", len(dropped))
+	}
+
+	kept, _ = Trim(long, messages[3:], 1)
+	if len(kept) != 1 {
+		t.Errorf("Trim() dropped the latest message")
+	}
+}
+
+// TestStores verifies that the database and in-memory stores behave the same.
+func TestStores(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "sessions.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	rdb, err := NewRDBStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewRDBStore() error = %v", err)
+	}
+
+	for name, store := range map[string]Store{"rdb": rdb, "memory": NewInMemoryStore()} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.Get(ctx, "s1"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get() error = %v, want ErrNotFound", err)
+			}
+			session := &Session{ID: "s1", Messages: []schemas.ChatMessage{textMessage(schemas.ChatMessageRoleUser, "hi")}}
+			if err := store.Save(ctx, session); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			session.Messages = append(session.Messages, textMessage(schemas.ChatMessageRoleAssistant, "hello"))
+			session.Summary = "greetings"
+			if err := store.Save(ctx, session); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			got, err := store.Get(ctx, "s1")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if len(got.Messages) != 2 || *got.Messages[1].Content.ContentStr != "hello" || got.Summary != "greetings" {
+				t.Errorf("Get() = %+v", got)
+			}
+
+			list, err := store.List(ctx)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(list) != 1 || list[0].MessageCount != 2 || list[0].Messages != nil {
+				t.Errorf("List() = %+v", list)
+			}
+
+			if err := store.Delete(ctx, "s1"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := store.Delete(ctx, "s1"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete() error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package sessions
+
+import (
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/tokenizer"
+)
+
+// Trim drops the oldest messages until the estimated tokens of the summary and messages fit in maxTokens.
+// The latest message is always kept. Tool results whose tool call was dropped are dropped with it,
+// since providers reject them. It returns the kept and the dropped messages.
+func Trim(summary string, messages []schemas.ChatMessage, maxTokens int) ([]schemas.ChatMessage, []schemas.ChatMessage) {
+	tokens := EstimateTokens(summary, messages)
+	start := 0
+	for start < len(messages)-1 && tokens > maxTokens {
+		tokens -= tokenizer.EstimateMessage(messages[start])
+		start++
+	}
+	for start < len(messages)-1 && messages[start].Role == schemas.ChatMessageRoleTool {
+		start++
+	}
+	return messages[start:], messages[:start]
+}
+
+// EstimateTokens returns the estimated tokens of the summary and messages of a session.
+func EstimateTokens(summary string, messages []schemas.ChatMessage) int {
+	return tokenizer.EstimateText(summary) + tokenizer.EstimateMessages(messages)
+}
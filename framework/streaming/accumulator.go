@@ -39,6 +39,7 @@ func (a *Accumulator) putChatStreamChunk(chunk *ChatStreamChunk) {
 	chunk.Timestamp = time.Time{}
 	chunk.Delta = nil
 	chunk.Cost = nil
+	chunk.CacheSavings = nil
 	chunk.SemanticCacheDebug = nil
 	chunk.ErrorDetails = nil
 	chunk.FinishReason = nil
@@ -111,6 +112,9 @@ func (a *Accumulator) addChatStreamChunk(requestID string, chunk *ChatStreamChun
 	if accumulator.StartTimestamp.IsZero() {
 		accumulator.StartTimestamp = chunk.Timestamp
 	}
+	if accumulator.FirstChunkTimestamp.IsZero() {
+		accumulator.FirstChunkTimestamp = chunk.Timestamp
+	}
 	// Store object type once (from first chunk)
 	if accumulator.Object == "" && object != "" {
 		accumulator.Object = object
@@ -135,6 +139,9 @@ func (a *Accumulator) addTranscriptionStreamChunk(requestID string, chunk *Trans
 	if accumulator.StartTimestamp.IsZero() {
 		accumulator.StartTimestamp = chunk.Timestamp
 	}
+	if accumulator.FirstChunkTimestamp.IsZero() {
+		accumulator.FirstChunkTimestamp = chunk.Timestamp
+	}
 	// Store object type once (from first chunk)
 	if accumulator.Object == "" && object != "" {
 		accumulator.Object = object
@@ -159,6 +166,9 @@ func (a *Accumulator) addAudioStreamChunk(requestID string, chunk *AudioStreamCh
 	if accumulator.StartTimestamp.IsZero() {
 		accumulator.StartTimestamp = chunk.Timestamp
 	}
+	if accumulator.FirstChunkTimestamp.IsZero() {
+		accumulator.FirstChunkTimestamp = chunk.Timestamp
+	}
 	// Store object type once (from first chunk)
 	if accumulator.Object == "" && object != "" {
 		accumulator.Object = object
@@ -174,6 +184,19 @@ func (a *Accumulator) addAudioStreamChunk(requestID string, chunk *AudioStreamCh
 	return nil
 }
 
+// setStreamTimings sets the time to first token and the output throughput of a finished stream.
+// The caller must hold the accumulator lock and have set the token usage.
+func (sc *StreamAccumulator) setStreamTimings(data *AccumulatedData) {
+	if sc.StartTimestamp.IsZero() || sc.FirstChunkTimestamp.IsZero() {
+		return
+	}
+	data.TimeToFirstToken = sc.FirstChunkTimestamp.Sub(sc.StartTimestamp).Milliseconds()
+	generation := sc.FinalTimestamp.Sub(sc.FirstChunkTimestamp)
+	if data.TokenUsage != nil && data.TokenUsage.CompletionTokens > 0 && generation > 0 {
+		data.TokensPerSecond = float64(data.TokenUsage.CompletionTokens) / generation.Seconds()
+	}
+}
+
 // cleanupStreamAccumulator removes the stream accumulator for a request
 func (a *Accumulator) cleanupStreamAccumulator(requestID string) {
 	if accumulator, exists := a.streamAccumulators.Load(requestID); exists {
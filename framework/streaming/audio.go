@@ -97,6 +97,7 @@ func (a *Accumulator) processAccumulatedAudioStreamingChunks(requestID string, b
 			data.CacheDebug = lastChunk.SemanticCacheDebug
 		}
 	}
+	accumulator.setStreamTimings(data)
 	// Update object field from accumulator (stored once for the entire stream)
 	if accumulator.Object != "" {
 		data.Object = accumulator.Object
@@ -113,8 +113,10 @@ func (a *Accumulator) processAccumulatedChatStreamingChunks(requestID string, re
 		if lastChunk.Cost != nil {
 			data.Cost = lastChunk.Cost
 		}
+		data.CacheSavings = lastChunk.CacheSavings
 		data.FinishReason = lastChunk.FinishReason
 	}
+	accumulator.setStreamTimings(data)
 	// Update object field from accumulator (stored once for the entire stream)
 	if accumulator.Object != "" {
 		data.Object = accumulator.Object
@@ -174,6 +176,9 @@ func (a *Accumulator) processChatStreamingResponse(ctx *context.Context, result
 			if a.pricingManager != nil {
 				cost := a.pricingManager.CalculateCostWithCacheDebug(result)
 				chunk.Cost = bifrost.Ptr(cost)
+				if savings := a.pricingManager.CalculatePromptCacheSavings(result); savings != 0 {
+					chunk.CacheSavings = bifrost.Ptr(savings)
+				}
 			}
 			chunk.SemanticCacheDebug = result.ExtraFields.CacheDebug
 		}
@@ -29,7 +29,9 @@ type AccumulatedData struct {
 	Model               string
 	Status              string
 	Stream              bool
-	Latency             int64 // in milliseconds
+	Latency             int64   // in milliseconds
+	TimeToFirstToken    int64   // in milliseconds, from the request start to the first chunk
+	TokensPerSecond     float64 // completion tokens per second between the first and the final chunk
 	StartTimestamp      time.Time
 	EndTimestamp        time.Time
 	OutputMessage       *schemas.ChatMessage
@@ -38,6 +40,7 @@ type AccumulatedData struct {
 	TokenUsage          *schemas.LLMUsage
 	CacheDebug          *schemas.BifrostCacheDebug
 	Cost                *float64
+	CacheSavings        *float64 // Dollars saved by the provider's prompt cache
 	Object              string
 	AudioOutput         *schemas.BifrostSpeech
 	TranscriptionOutput *schemas.BifrostTranscribe
@@ -75,6 +78,7 @@ type ChatStreamChunk struct {
 	TokenUsage         *schemas.LLMUsage           // Token usage if available
 	SemanticCacheDebug *schemas.BifrostCacheDebug  // Semantic cache debug if available
 	Cost               *float64                    // Cost in dollars from pricing plugin
+	CacheSavings       *float64                    // Dollars saved by the provider's prompt cache
 	ErrorDetails       *schemas.BifrostError       // Error if any
 }
 
@@ -86,6 +90,7 @@ type StreamAccumulator struct {
 	TranscriptionStreamChunks []*TranscriptionStreamChunk
 	AudioStreamChunks         []*AudioStreamChunk
 	IsComplete                bool
+	FirstChunkTimestamp       time.Time
 	FinalTimestamp            time.Time
 	Object                    string // Store object type once for the entire stream
 	mu                        sync.Mutex
@@ -0,0 +1,78 @@
+// Package tokenizer estimates token counts of prompts without calling a provider.
+//
+// Estimates follow the common rule of thumb of about four characters per token for English text,
+// plus the per-message framing overhead of chat formats. They are meant for budgeting (context
+// windows, history trimming), not for billing, and may be off by a few percent for any given model.
+package tokenizer
+
+import (
+	"unicode/utf8"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+const (
+	// charsPerToken is the average number of characters in a token.
+	charsPerToken = 4
+	// messageOverhead is the number of tokens spent on the role and delimiters of every chat message.
+	messageOverhead = 4
+	// replyOverhead is the number of tokens priming the assistant reply.
+	replyOverhead = 3
+	// imageTokens is the flat estimate used for image, audio and file content blocks.
+	imageTokens = 85
+)
+
+// EstimateText returns the estimated number of tokens in text.
+func EstimateText(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateMessage returns the estimated number of tokens of a single chat message, including its framing.
+func EstimateMessage(message schemas.ChatMessage) int {
+	tokens := messageOverhead
+	if message.Name != nil {
+		tokens += EstimateText(*message.Name)
+	}
+	if message.Content != nil {
+		if message.Content.ContentStr != nil {
+			tokens += EstimateText(*message.Content.ContentStr)
+		}
+		for _, block := range message.Content.ContentBlocks {
+			switch {
+			case block.Text != nil:
+				tokens += EstimateText(*block.Text)
+			case block.Refusal != nil:
+				tokens += EstimateText(*block.Refusal)
+			default:
+				tokens += imageTokens
+			}
+		}
+	}
+	if message.ChatAssistantMessage != nil {
+		if message.ChatAssistantMessage.Refusal != nil {
+			tokens += EstimateText(*message.ChatAssistantMessage.Refusal)
+		}
+		for _, toolCall := range message.ChatAssistantMessage.ToolCalls {
+			if toolCall.Function.Name != nil {
+				tokens += EstimateText(*toolCall.Function.Name)
+			}
+			tokens += EstimateText(toolCall.Function.Arguments)
+		}
+	}
+	return tokens
+}
+
+// EstimateMessages returns the estimated number of prompt tokens of a chat conversation.
+func EstimateMessages(messages []schemas.ChatMessage) int {
+	if len(messages) == 0 {
+		return 0
+	}
+	tokens := replyOverhead
+	for _, message := range messages {
+		tokens += EstimateMessage(message)
+	}
+	return tokens
+}
@@ -0,0 +1,42 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// TestEstimateMessages verifies that text is counted at about four characters per token plus message framing.
+func TestEstimateMessages(t *testing.T) {
+	text := "abcdefgh" // 2 tokens
+	messages := []schemas.ChatMessage{
+		{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: &text}},
+		{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentBlocks: []schemas.ChatContentBlock{
+			{Type: schemas.ChatContentBlockTypeText, Text: &text},
+			{Type: schemas.ChatContentBlockTypeImage, ImageURLStruct: &schemas.ChatInputImage{URL: "https://example.com/cat.png"}},
+		}}},
+	}
+	want := replyOverhead + (messageOverhead + 2) + (messageOverhead + 2 + imageTokens)
+	if got := EstimateMessages(messages); got != want {
+		t.Errorf("EstimateMessages() = %d, want %d", got, want)
+	}
+	if got := EstimateText("héllo"); got != 2 {
+		t.Errorf("EstimateText() = %d, want 2 (counted in characters, not bytes)", got)
+	}
+}
+
+// TestContextWindow verifies longest prefix matching and provider prefix handling.
+func TestContextWindow(t *testing.T) {
+	cases := map[string]int{
+		"gpt-4":                  8192,
+		"gpt-4o-mini-2024-07-18": 128000,
+		"openai/gpt-4.1-nano":    1047576,
+		"anthropic.claude-3-5-sonnet-20240620-v1": 200000,
+		"some-private-model":                      0,
+	}
+	for model, want := range cases {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
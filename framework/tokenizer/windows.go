@@ -0,0 +1,58 @@
+package tokenizer
+
+import "strings"
+
+// contextWindows holds the context window, in tokens, of well-known model families, keyed by model name prefix.
+// The longest matching prefix wins, so specific models can override their family.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo":     16385,
+	"gpt-4":             8192,
+	"gpt-4-32k":         32768,
+	"gpt-4-turbo":       128000,
+	"gpt-4o":            128000,
+	"gpt-4.1":           1047576,
+	"gpt-4.5":           128000,
+	"gpt-5":             400000,
+	"o1":                200000,
+	"o1-mini":           128000,
+	"o3":                200000,
+	"o4-mini":           200000,
+	"claude-2":          100000,
+	"claude-3":          200000,
+	"claude-opus-4":     200000,
+	"claude-sonnet-4":   200000,
+	"gemini-1.0-pro":    32760,
+	"gemini-1.5-flash":  1048576,
+	"gemini-1.5-pro":    2097152,
+	"gemini-2.0":        1048576,
+	"gemini-2.5":        1048576,
+	"mistral-large":     128000,
+	"mistral-small":     32000,
+	"open-mistral-nemo": 128000,
+	"codestral":         256000,
+	"command-r":         128000,
+	"llama3-":           8192,
+	"llama-3.1":         128000,
+	"llama-3.2":         128000,
+	"llama-3.3":         128000,
+}
+
+// ContextWindow returns the context window of a model in tokens, or 0 when it is not known.
+// Provider prefixes (e.g. "openai/", "anthropic.") and dated suffixes are handled by prefix matching.
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	if i := strings.Index(model, "."); i >= 0 && strings.HasPrefix(model[i+1:], "claude") {
+		model = model[i+1:] // Bedrock model IDs such as anthropic.claude-3-5-sonnet
+	}
+
+	window, matched := 0, 0
+	for prefix, size := range contextWindows {
+		if len(prefix) > matched && strings.HasPrefix(model, prefix) {
+			window, matched = size, len(prefix)
+		}
+	}
+	return window
+}
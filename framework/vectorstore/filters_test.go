@@ -0,0 +1,84 @@
+package vectorstore
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var filterTestQueries = []Query{
+	{Field: "source", Operator: QueryOperatorEqual, Value: "handbook.pdf"},
+	{Field: "page", Operator: QueryOperatorGreaterThanOrEqual, Value: 3},
+	{Field: "tags", Operator: QueryOperatorContainsAny, Value: []interface{}{"hr", "policy"}},
+	{Field: "deleted_at", Operator: QueryOperatorIsNull},
+}
+
+func TestBuildPgVectorFilter(t *testing.T) {
+	conditions, args, err := buildPgVectorFilter(filterTestQueries)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"metadata->>'source' = ?",
+		"(metadata->>'page')::numeric >= ?",
+		"jsonb_exists_any(metadata->'tags', ?::text[])",
+		"(metadata->'deleted_at' IS NULL OR metadata->'deleted_at' = 'null'::jsonb)",
+	}, conditions)
+	assert.Equal(t, []interface{}{"handbook.pdf", 3, `{"hr","policy"}`}, args)
+
+	conditions, _, err = buildPgVectorFilter([]Query{{Field: "it's", Operator: QueryOperatorEqual, Value: "x"}})
+	require.NoError(t, err)
+	assert.Equal(t, "metadata->>'it''s' = ?", conditions[0], "quotes in field names must be escaped")
+
+	_, err = pgVectorTable(`docs"; DROP TABLE users; --`)
+	assert.Error(t, err)
+}
+
+func TestBuildQdrantFilter(t *testing.T) {
+	filter, err := buildQdrantFilter(filterTestQueries)
+	require.NoError(t, err)
+	data, err := json.Marshal(filter)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"must":[
+		{"key":"source","match":{"value":"handbook.pdf"}},
+		{"key":"page","range":{"gte":3}},
+		{"key":"tags","match":{"any":["hr","policy"]}},
+		{"is_null":{"key":"deleted_at"}}
+	]}`, string(data))
+
+	filter, err = buildQdrantFilter(nil)
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	assert.Equal(t, "0f0a7e5c-7c1e-4b0b-9a62-3f1c0a0e6b8d", qdrantPointID("0f0a7e5c-7c1e-4b0b-9a62-3f1c0a0e6b8d"))
+	assert.Equal(t, qdrantPointID("doc-1#0"), qdrantPointID("doc-1#0"), "IDs that are not UUIDs must map to a stable UUID")
+}
+
+func TestBuildPineconeFilter(t *testing.T) {
+	filter, err := buildPineconeFilter(filterTestQueries)
+	require.NoError(t, err)
+	data, err := json.Marshal(filter)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"$and":[
+		{"source":{"$eq":"handbook.pdf"}},
+		{"page":{"$gte":3}},
+		{"tags":{"$in":["hr","policy"]}},
+		{"deleted_at":{"$exists":false}}
+	]}`, string(data))
+
+	_, err = buildPineconeFilter([]Query{{Field: "title", Operator: QueryOperatorLike, Value: "%policy%"}})
+	assert.True(t, errors.Is(err, ErrNotSupported))
+}
+
+func TestConfigUnmarshal_RemoteStores(t *testing.T) {
+	var config Config
+	require.NoError(t, json.Unmarshal([]byte(`{"enabled":true,"type":"pgvector","config":{"dsn":"postgres://localhost/vectors"}}`), &config))
+	assert.Equal(t, PgVectorConfig{DSN: "postgres://localhost/vectors"}, config.Config)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"enabled":true,"type":"qdrant","config":{"url":"http://localhost:6333"}}`), &config))
+	assert.Equal(t, QdrantConfig{URL: "http://localhost:6333"}, config.Config)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"enabled":true,"type":"pinecone","config":{"index_host":"docs.svc.pinecone.io","api_key":"key"}}`), &config))
+	assert.Equal(t, PineconeConfig{IndexHost: "docs.svc.pinecone.io", APIKey: "key"}, config.Config)
+}
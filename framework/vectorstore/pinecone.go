@@ -0,0 +1,310 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// pineconeAPIVersion is the version of the Pinecone data plane API used by the store.
+const pineconeAPIVersion = "2024-07"
+
+// PineconeConfig represents the configuration for the Pinecone vector store. Each namespace is a namespace of the
+// index, which must use the cosine metric.
+type PineconeConfig struct {
+	IndexHost string `json:"index_host"` // Host of the index, e.g. my-index-abc123.svc.us-east-1.pinecone.io - REQUIRED
+	APIKey    string `json:"api_key"`    // REQUIRED
+
+	// Request timeout (optional)
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// PineconeStore represents the Pinecone vector store.
+type PineconeStore struct {
+	client *http.Client
+	config *PineconeConfig
+	logger schemas.Logger
+}
+
+// pineconeVector is a vector returned by the fetch and query endpoints.
+type pineconeVector struct {
+	ID       string                 `json:"id"`
+	Score    *float64               `json:"score,omitempty"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+func (v pineconeVector) toSearchResult(selectFields []string) SearchResult {
+	properties := v.Metadata
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return SearchResult{ID: v.ID, Score: v.Score, Properties: selectProperties(properties, selectFields)}
+}
+
+// do sends a request to the index and decodes the response into out.
+func (s *PineconeStore) do(ctx context.Context, method, path string, body any, out any) error {
+	ctx, cancel := withTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	host := s.config.IndexHost
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	headers := map[string]string{
+		"Api-Key":                s.config.APIKey,
+		"X-Pinecone-API-Version": pineconeAPIVersion,
+	}
+	return doJSONRequest(ctx, s.client, method, strings.TrimRight(host, "/")+path, headers, body, out)
+}
+
+// CreateNamespace is a no-op: Pinecone creates namespaces on first write, and the dimension is fixed by the index.
+func (s *PineconeStore) CreateNamespace(ctx context.Context, namespace string, dimension int, properties map[string]VectorStoreProperties) error {
+	return nil
+}
+
+// DeleteNamespace deletes every vector of a namespace.
+func (s *PineconeStore) DeleteNamespace(ctx context.Context, namespace string) error {
+	err := s.do(ctx, http.MethodPost, "/vectors/delete", map[string]any{"deleteAll": true, "namespace": namespace}, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// GetChunk returns the vector with the given ID.
+func (s *PineconeStore) GetChunk(ctx context.Context, namespace string, id string) (SearchResult, error) {
+	results, err := s.GetChunks(ctx, namespace, []string{id})
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if len(results) == 0 {
+		return SearchResult{}, ErrNotFound
+	}
+	return results[0], nil
+}
+
+// GetChunks returns the vectors with the given IDs, skipping the missing ones.
+func (s *PineconeStore) GetChunks(ctx context.Context, namespace string, ids []string) ([]SearchResult, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	params := url.Values{"namespace": {namespace}}
+	for _, id := range ids {
+		params.Add("ids", id)
+	}
+	var response struct {
+		Vectors map[string]pineconeVector `json:"vectors"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/vectors/fetch?"+params.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(response.Vectors))
+	for _, id := range ids {
+		if vector, ok := response.Vectors[id]; ok {
+			results = append(results, vector.toSearchResult(nil))
+		}
+	}
+	return results, nil
+}
+
+// GetAll lists the vectors of a namespace, continuing from cursor. Pinecone can only list IDs without filtering,
+// so queries are not supported.
+func (s *PineconeStore) GetAll(ctx context.Context, namespace string, queries []Query, selectFields []string, cursor *string, limit int64) ([]SearchResult, *string, error) {
+	if len(queries) > 0 {
+		return nil, nil, fmt.Errorf("%w: pinecone cannot filter listed vectors", ErrNotSupported)
+	}
+	params := url.Values{"namespace": {namespace}}
+	if limit > 0 {
+		params.Set("limit", fmt.Sprint(min(limit, 100)))
+	}
+	if cursor != nil && *cursor != "" {
+		params.Set("paginationToken", *cursor)
+	}
+	var page struct {
+		Vectors []struct {
+			ID string `json:"id"`
+		} `json:"vectors"`
+		Pagination *struct {
+			Next string `json:"next"`
+		} `json:"pagination"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/vectors/list?"+params.Encode(), nil, &page); err != nil {
+		return nil, nil, err
+	}
+	ids := make([]string, len(page.Vectors))
+	for i, vector := range page.Vectors {
+		ids[i] = vector.ID
+	}
+	results, err := s.GetChunks(ctx, namespace, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range results {
+		results[i].Properties = selectProperties(results[i].Properties, selectFields)
+	}
+	var nextCursor *string
+	if page.Pagination != nil && page.Pagination.Next != "" {
+		nextCursor = &page.Pagination.Next
+	}
+	return results, nextCursor, nil
+}
+
+// GetNearest returns the vectors matching queries closest to vector. With the cosine metric the score is the
+// cosine similarity; vectors less similar than threshold are left out.
+func (s *PineconeStore) GetNearest(ctx context.Context, namespace string, vector []float32, queries []Query, selectFields []string, threshold float64, limit int64) ([]SearchResult, error) {
+	filter, err := buildPineconeFilter(queries)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	body := map[string]any{
+		"namespace":       namespace,
+		"vector":          vector,
+		"topK":            limit,
+		"includeMetadata": true,
+	}
+	if filter != nil {
+		body["filter"] = filter
+	}
+	var response struct {
+		Matches []pineconeVector `json:"matches"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/query", body, &response); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(response.Matches))
+	for _, match := range response.Matches {
+		if match.Score != nil && *match.Score < threshold {
+			continue
+		}
+		results = append(results, match.toSearchResult(selectFields))
+	}
+	return results, nil
+}
+
+// Add stores a vector, replacing the vector with the same ID.
+func (s *PineconeStore) Add(ctx context.Context, namespace string, id string, embedding []float32, metadata map[string]interface{}) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("id is required")
+	}
+	if len(embedding) == 0 {
+		return fmt.Errorf("pinecone vectors require an embedding")
+	}
+	vector := map[string]any{"id": id, "values": embedding}
+	if len(metadata) > 0 {
+		vector["metadata"] = metadata
+	}
+	return s.do(ctx, http.MethodPost, "/vectors/upsert", map[string]any{
+		"vectors":   []map[string]any{vector},
+		"namespace": namespace,
+	}, nil)
+}
+
+// Delete removes the vector with the given ID.
+func (s *PineconeStore) Delete(ctx context.Context, namespace string, id string) error {
+	return s.do(ctx, http.MethodPost, "/vectors/delete", map[string]any{"ids": []string{id}, "namespace": namespace}, nil)
+}
+
+// DeleteAll removes the vectors matching queries. Pinecone does not report which vectors a filtered delete
+// removed, so only deletes without queries, which list the vectors first, return per-ID results.
+func (s *PineconeStore) DeleteAll(ctx context.Context, namespace string, queries []Query) ([]DeleteResult, error) {
+	if len(queries) > 0 {
+		filter, err := buildPineconeFilter(queries)
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.do(ctx, http.MethodPost, "/vectors/delete", map[string]any{"filter": filter, "namespace": namespace}, nil)
+	}
+	var results []DeleteResult
+	var cursor *string
+	for {
+		page, next, err := s.GetAll(ctx, namespace, nil, []string{}, cursor, 100)
+		if err != nil {
+			return results, err
+		}
+		if len(page) > 0 {
+			ids := make([]string, len(page))
+			for i, result := range page {
+				ids[i] = result.ID
+			}
+			status, message := DeleteStatusSuccess, ""
+			if err := s.do(ctx, http.MethodPost, "/vectors/delete", map[string]any{"ids": ids, "namespace": namespace}, nil); err != nil {
+				status, message = DeleteStatusError, err.Error()
+			}
+			for _, id := range ids {
+				results = append(results, DeleteResult{ID: id, Status: status, Error: message})
+			}
+		}
+		if next == nil {
+			return results, nil
+		}
+		cursor = next
+	}
+}
+
+// Close releases idle connections.
+func (s *PineconeStore) Close(ctx context.Context, namespace string) error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// buildPineconeFilter converts queries to a Pinecone metadata filter, or nil when there are none.
+func buildPineconeFilter(queries []Query) (map[string]any, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+	conditions := make([]map[string]any, 0, len(queries))
+	for _, query := range queries {
+		switch query.Operator {
+		case QueryOperatorEqual, QueryOperatorNotEqual, QueryOperatorGreaterThan, QueryOperatorLessThan, QueryOperatorGreaterThanOrEqual, QueryOperatorLessThanOrEqual:
+			op := map[QueryOperator]string{
+				QueryOperatorEqual:              "$eq",
+				QueryOperatorNotEqual:           "$ne",
+				QueryOperatorGreaterThan:        "$gt",
+				QueryOperatorLessThan:           "$lt",
+				QueryOperatorGreaterThanOrEqual: "$gte",
+				QueryOperatorLessThanOrEqual:    "$lte",
+			}[query.Operator]
+			conditions = append(conditions, map[string]any{query.Field: map[string]any{op: query.Value}})
+		case QueryOperatorContainsAny:
+			conditions = append(conditions, map[string]any{query.Field: map[string]any{"$in": toValueList(query.Value)}})
+		case QueryOperatorContainsAll:
+			for _, value := range toValueList(query.Value) {
+				conditions = append(conditions, map[string]any{query.Field: map[string]any{"$in": []interface{}{value}}})
+			}
+		case QueryOperatorIsNull:
+			conditions = append(conditions, map[string]any{query.Field: map[string]any{"$exists": false}})
+		case QueryOperatorIsNotNull:
+			conditions = append(conditions, map[string]any{query.Field: map[string]any{"$exists": true}})
+		case QueryOperatorLike:
+			return nil, fmt.Errorf("%w: pinecone does not support the Like operator", ErrNotSupported)
+		default:
+			return nil, fmt.Errorf("unsupported query operator %s", query.Operator)
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+	return map[string]any{"$and": conditions}, nil
+}
+
+// newPineconeStore creates a new Pinecone vector store.
+func newPineconeStore(ctx context.Context, config *PineconeConfig, logger schemas.Logger) (*PineconeStore, error) {
+	if config.IndexHost == "" || config.APIKey == "" {
+		return nil, fmt.Errorf("pinecone index_host and api_key are required")
+	}
+	store := &PineconeStore{client: &http.Client{}, config: config, logger: logger}
+	var stats any
+	if err := store.do(ctx, http.MethodPost, "/describe_index_stats", map[string]any{}, &stats); err != nil {
+		return nil, fmt.Errorf("failed to connect to pinecone: %w", err)
+	}
+	return store, nil
+}
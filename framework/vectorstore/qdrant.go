@@ -0,0 +1,336 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// qdrantIDField is the payload field keeping the original ID of a point, since Qdrant only accepts UUIDs and
+// unsigned integers as point IDs.
+const qdrantIDField = "_bifrost_id"
+
+// QdrantConfig represents the configuration for the Qdrant vector store. Each namespace is a collection using
+// cosine distance.
+type QdrantConfig struct {
+	URL    string `json:"url"`               // e.g. http://localhost:6333 - REQUIRED
+	APIKey string `json:"api_key,omitempty"` // API key for authentication (optional)
+
+	// Request timeout (optional)
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// QdrantStore represents the Qdrant vector store.
+type QdrantStore struct {
+	client *http.Client
+	config *QdrantConfig
+	logger schemas.Logger
+}
+
+// qdrantPoint is a point returned by the points, scroll and search endpoints.
+type qdrantPoint struct {
+	ID      any                    `json:"id"`
+	Score   *float64               `json:"score,omitempty"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// toSearchResult converts a point to a search result, restoring its original ID.
+func (p qdrantPoint) toSearchResult(selectFields []string) SearchResult {
+	id := fmt.Sprint(p.ID)
+	if original, ok := p.Payload[qdrantIDField].(string); ok {
+		id = original
+	}
+	delete(p.Payload, qdrantIDField)
+	return SearchResult{ID: id, Score: p.Score, Properties: selectProperties(p.Payload, selectFields)}
+}
+
+// qdrantPointID maps an ID to a Qdrant point ID, hashing IDs that are not UUIDs into one.
+func qdrantPointID(id string) string {
+	if parsed, err := uuid.Parse(id); err == nil {
+		return parsed.String()
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(id)).String()
+}
+
+// do sends a request to the Qdrant API and decodes the result field of the response into out.
+func (s *QdrantStore) do(ctx context.Context, method, path string, body any, out any) error {
+	ctx, cancel := withTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	headers := map[string]string{}
+	if s.config.APIKey != "" {
+		headers["api-key"] = s.config.APIKey
+	}
+	var response struct {
+		Result any `json:"result"`
+	}
+	if out != nil {
+		response.Result = out
+	}
+	return doJSONRequest(ctx, s.client, method, strings.TrimRight(s.config.URL, "/")+path, headers, body, &response)
+}
+
+// collectionPath returns the API path of a collection.
+func collectionPath(namespace string) string {
+	return "/collections/" + url.PathEscape(namespace)
+}
+
+// CreateNamespace creates a collection using cosine distance. Payload fields need no schema in Qdrant, so
+// properties are not used.
+func (s *QdrantStore) CreateNamespace(ctx context.Context, namespace string, dimension int, properties map[string]VectorStoreProperties) error {
+	var existing any
+	if err := s.do(ctx, http.MethodGet, collectionPath(namespace), nil, &existing); err == nil {
+		return nil
+	}
+	return s.do(ctx, http.MethodPut, collectionPath(namespace), map[string]any{
+		"vectors": map[string]any{"size": dimension, "distance": "Cosine"},
+	}, nil)
+}
+
+// DeleteNamespace deletes a collection.
+func (s *QdrantStore) DeleteNamespace(ctx context.Context, namespace string) error {
+	return s.do(ctx, http.MethodDelete, collectionPath(namespace), nil, nil)
+}
+
+// GetChunk returns the point with the given ID.
+func (s *QdrantStore) GetChunk(ctx context.Context, namespace string, id string) (SearchResult, error) {
+	results, err := s.GetChunks(ctx, namespace, []string{id})
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if len(results) == 0 {
+		return SearchResult{}, ErrNotFound
+	}
+	return results[0], nil
+}
+
+// GetChunks returns the points with the given IDs, skipping the missing ones.
+func (s *QdrantStore) GetChunks(ctx context.Context, namespace string, ids []string) ([]SearchResult, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	pointIDs := make([]string, len(ids))
+	for i, id := range ids {
+		pointIDs[i] = qdrantPointID(id)
+	}
+	var points []qdrantPoint
+	if err := s.do(ctx, http.MethodPost, collectionPath(namespace)+"/points", map[string]any{
+		"ids":          pointIDs,
+		"with_payload": true,
+	}, &points); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(points))
+	for _, point := range points {
+		results = append(results, point.toSearchResult(nil))
+	}
+	return results, nil
+}
+
+// GetAll returns the points matching queries, continuing from cursor. The returned cursor is nil once there are
+// no more points.
+func (s *QdrantStore) GetAll(ctx context.Context, namespace string, queries []Query, selectFields []string, cursor *string, limit int64) ([]SearchResult, *string, error) {
+	filter, err := buildQdrantFilter(queries)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := map[string]any{"with_payload": true}
+	if filter != nil {
+		body["filter"] = filter
+	}
+	if limit > 0 {
+		body["limit"] = limit
+	}
+	if cursor != nil && *cursor != "" {
+		body["offset"] = *cursor
+	}
+	var page struct {
+		Points         []qdrantPoint `json:"points"`
+		NextPageOffset any           `json:"next_page_offset"`
+	}
+	if err := s.do(ctx, http.MethodPost, collectionPath(namespace)+"/points/scroll", body, &page); err != nil {
+		return nil, nil, err
+	}
+	results := make([]SearchResult, 0, len(page.Points))
+	for _, point := range page.Points {
+		results = append(results, point.toSearchResult(selectFields))
+	}
+	var nextCursor *string
+	if page.NextPageOffset != nil {
+		next := fmt.Sprint(page.NextPageOffset)
+		nextCursor = &next
+	}
+	return results, nextCursor, nil
+}
+
+// GetNearest returns the points matching queries closest to vector, with their cosine similarity as score.
+// Points less similar than threshold are left out.
+func (s *QdrantStore) GetNearest(ctx context.Context, namespace string, vector []float32, queries []Query, selectFields []string, threshold float64, limit int64) ([]SearchResult, error) {
+	filter, err := buildQdrantFilter(queries)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	body := map[string]any{
+		"vector":          vector,
+		"limit":           limit,
+		"with_payload":    true,
+		"score_threshold": threshold,
+	}
+	if filter != nil {
+		body["filter"] = filter
+	}
+	var points []qdrantPoint
+	if err := s.do(ctx, http.MethodPost, collectionPath(namespace)+"/points/search", body, &points); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(points))
+	for _, point := range points {
+		results = append(results, point.toSearchResult(selectFields))
+	}
+	return results, nil
+}
+
+// Add stores a point, replacing the point with the same ID.
+func (s *QdrantStore) Add(ctx context.Context, namespace string, id string, embedding []float32, metadata map[string]interface{}) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("id is required")
+	}
+	if len(embedding) == 0 {
+		return fmt.Errorf("qdrant points require an embedding")
+	}
+	payload := make(map[string]interface{}, len(metadata)+1)
+	for key, value := range metadata {
+		payload[key] = value
+	}
+	payload[qdrantIDField] = id
+	return s.do(ctx, http.MethodPut, collectionPath(namespace)+"/points?wait=true", map[string]any{
+		"points": []map[string]any{{"id": qdrantPointID(id), "vector": embedding, "payload": payload}},
+	}, nil)
+}
+
+// Delete removes the point with the given ID.
+func (s *QdrantStore) Delete(ctx context.Context, namespace string, id string) error {
+	return s.do(ctx, http.MethodPost, collectionPath(namespace)+"/points/delete?wait=true", map[string]any{
+		"points": []string{qdrantPointID(id)},
+	}, nil)
+}
+
+// DeleteAll removes the points matching queries. The matching points are listed first so the result reports
+// their IDs.
+func (s *QdrantStore) DeleteAll(ctx context.Context, namespace string, queries []Query) ([]DeleteResult, error) {
+	var results []DeleteResult
+	var cursor *string
+	for {
+		page, next, err := s.GetAll(ctx, namespace, queries, []string{}, cursor, 256)
+		if err != nil {
+			return results, err
+		}
+		if len(page) > 0 {
+			pointIDs := make([]string, len(page))
+			for i, result := range page {
+				pointIDs[i] = qdrantPointID(result.ID)
+			}
+			status, message := DeleteStatusSuccess, ""
+			if err := s.do(ctx, http.MethodPost, collectionPath(namespace)+"/points/delete?wait=true", map[string]any{"points": pointIDs}, nil); err != nil {
+				status, message = DeleteStatusError, err.Error()
+			}
+			for _, result := range page {
+				results = append(results, DeleteResult{ID: result.ID, Status: status, Error: message})
+			}
+		}
+		if next == nil {
+			return results, nil
+		}
+		cursor = next
+	}
+}
+
+// Close releases idle connections.
+func (s *QdrantStore) Close(ctx context.Context, namespace string) error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// buildQdrantFilter converts queries to a Qdrant filter, or nil when there are none.
+func buildQdrantFilter(queries []Query) (map[string]any, error) {
+	if len(queries) == 0 {
+		return nil, nil
+	}
+	var must, mustNot []map[string]any
+	for _, query := range queries {
+		switch query.Operator {
+		case QueryOperatorEqual:
+			must = append(must, map[string]any{"key": query.Field, "match": map[string]any{"value": query.Value}})
+		case QueryOperatorNotEqual:
+			mustNot = append(mustNot, map[string]any{"key": query.Field, "match": map[string]any{"value": query.Value}})
+		case QueryOperatorGreaterThan, QueryOperatorLessThan, QueryOperatorGreaterThanOrEqual, QueryOperatorLessThanOrEqual:
+			bound := map[QueryOperator]string{
+				QueryOperatorGreaterThan:        "gt",
+				QueryOperatorLessThan:           "lt",
+				QueryOperatorGreaterThanOrEqual: "gte",
+				QueryOperatorLessThanOrEqual:    "lte",
+			}[query.Operator]
+			must = append(must, map[string]any{"key": query.Field, "range": map[string]any{bound: query.Value}})
+		case QueryOperatorLike:
+			must = append(must, map[string]any{"key": query.Field, "match": map[string]any{"text": strings.Trim(fmt.Sprint(query.Value), "%*")}})
+		case QueryOperatorContainsAny:
+			must = append(must, map[string]any{"key": query.Field, "match": map[string]any{"any": toValueList(query.Value)}})
+		case QueryOperatorContainsAll:
+			for _, value := range toValueList(query.Value) {
+				must = append(must, map[string]any{"key": query.Field, "match": map[string]any{"value": value}})
+			}
+		case QueryOperatorIsNull:
+			must = append(must, map[string]any{"is_null": map[string]any{"key": query.Field}})
+		case QueryOperatorIsNotNull:
+			mustNot = append(mustNot, map[string]any{"is_null": map[string]any{"key": query.Field}})
+		default:
+			return nil, fmt.Errorf("unsupported query operator %s", query.Operator)
+		}
+	}
+	filter := map[string]any{}
+	if len(must) > 0 {
+		filter["must"] = must
+	}
+	if len(mustNot) > 0 {
+		filter["must_not"] = mustNot
+	}
+	return filter, nil
+}
+
+// toValueList returns value as a list, wrapping single values.
+func toValueList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = item
+		}
+		return values
+	default:
+		return []interface{}{v}
+	}
+}
+
+// newQdrantStore creates a new Qdrant vector store.
+func newQdrantStore(ctx context.Context, config *QdrantConfig, logger schemas.Logger) (*QdrantStore, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("qdrant url is required")
+	}
+	store := &QdrantStore{client: &http.Client{}, config: config, logger: logger}
+	var collections any
+	if err := store.do(ctx, http.MethodGet, "/collections", nil, &collections); err != nil {
+		return nil, fmt.Errorf("failed to connect to qdrant: %w", err)
+	}
+	return store, nil
+}
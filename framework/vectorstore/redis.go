@@ -177,7 +177,7 @@ func (s *RedisStore) GetChunks(ctx context.Context, namespace string, ids []stri
 	for i, cmd := range cmds {
 		if cmd.Err() != nil {
 			// Log error but continue with other results
-			s.logger.Debug(fmt.Sprintf("failed to get chunk %s: %v", ids[i], cmd.Err()))
+			s.logger.Debug("failed to get chunk %s: %v", ids[i], cmd.Err())
 			continue
 		}
 
@@ -14,6 +14,9 @@ type VectorStoreType string
 const (
 	VectorStoreTypeWeaviate VectorStoreType = "weaviate"
 	VectorStoreTypeRedis    VectorStoreType = "redis"
+	VectorStoreTypePgVector VectorStoreType = "pgvector"
+	VectorStoreTypeQdrant   VectorStoreType = "qdrant"
+	VectorStoreTypePinecone VectorStoreType = "pinecone"
 )
 
 // Query represents a query to the vector store.
@@ -127,6 +130,24 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 			return fmt.Errorf("failed to unmarshal redis config: %w", err)
 		}
 		c.Config = redisConfig
+	case VectorStoreTypePgVector:
+		var pgVectorConfig PgVectorConfig
+		if err := json.Unmarshal(temp.Config, &pgVectorConfig); err != nil {
+			return fmt.Errorf("failed to unmarshal pgvector config: %w", err)
+		}
+		c.Config = pgVectorConfig
+	case VectorStoreTypeQdrant:
+		var qdrantConfig QdrantConfig
+		if err := json.Unmarshal(temp.Config, &qdrantConfig); err != nil {
+			return fmt.Errorf("failed to unmarshal qdrant config: %w", err)
+		}
+		c.Config = qdrantConfig
+	case VectorStoreTypePinecone:
+		var pineconeConfig PineconeConfig
+		if err := json.Unmarshal(temp.Config, &pineconeConfig); err != nil {
+			return fmt.Errorf("failed to unmarshal pinecone config: %w", err)
+		}
+		c.Config = pineconeConfig
 	default:
 		return fmt.Errorf("unknown vector store type: %s", temp.Type)
 	}
@@ -163,6 +184,33 @@ func NewVectorStore(ctx context.Context, config *Config, logger schemas.Logger)
 			return nil, fmt.Errorf("invalid redis config")
 		}
 		return newRedisStore(ctx, redisConfig, logger)
+	case VectorStoreTypePgVector:
+		if config.Config == nil {
+			return nil, fmt.Errorf("pgvector config is required")
+		}
+		pgVectorConfig, ok := config.Config.(PgVectorConfig)
+		if !ok {
+			return nil, fmt.Errorf("invalid pgvector config")
+		}
+		return newPgVectorStore(ctx, &pgVectorConfig, logger)
+	case VectorStoreTypeQdrant:
+		if config.Config == nil {
+			return nil, fmt.Errorf("qdrant config is required")
+		}
+		qdrantConfig, ok := config.Config.(QdrantConfig)
+		if !ok {
+			return nil, fmt.Errorf("invalid qdrant config")
+		}
+		return newQdrantStore(ctx, &qdrantConfig, logger)
+	case VectorStoreTypePinecone:
+		if config.Config == nil {
+			return nil, fmt.Errorf("pinecone config is required")
+		}
+		pineconeConfig, ok := config.Config.(PineconeConfig)
+		if !ok {
+			return nil, fmt.Errorf("invalid pinecone config")
+		}
+		return newPineconeStore(ctx, &pineconeConfig, logger)
 	}
 	return nil, fmt.Errorf("invalid vector store type: %s", config.Type)
 }
@@ -1,7 +1,13 @@
 package vectorstore
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 	"time"
 )
 
@@ -13,3 +19,60 @@ func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, c
 	// No-op cancel to simplify call sites.
 	return ctx, func() {}
 }
+
+// doJSONRequest sends body as JSON to url with headers and decodes the response into out when it is not nil.
+// Responses with a status of 400 or above are returned as errors, 404 as ErrNotFound.
+func doJSONRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, strings.TrimSpace(string(data)))
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s %s returned %d: %s", method, url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// selectProperties keeps only selectFields of properties, or all of them when selectFields is empty.
+func selectProperties(properties map[string]interface{}, selectFields []string) map[string]interface{} {
+	if len(selectFields) == 0 {
+		return properties
+	}
+	selected := make(map[string]interface{}, len(selectFields))
+	for _, field := range selectFields {
+		if value, ok := properties[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
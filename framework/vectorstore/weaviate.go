@@ -179,13 +179,13 @@ func (s *WeaviateStore) GetAll(ctx context.Context, className string, queries []
 	objsRaw, exists := data[className]
 	if !exists {
 		// No results for this class - this is normal, not an error
-		s.logger.Debug(fmt.Sprintf("No results found for class '%s', available classes: %+v", className, data))
+		s.logger.Debug("No results found for class '%s', available classes: %+v", className, data)
 		return nil, nil, nil
 	}
 
 	objs, ok := objsRaw.([]interface{})
 	if !ok {
-		s.logger.Debug(fmt.Sprintf("Class '%s' exists but data is not an array: %+v", className, objsRaw))
+		s.logger.Debug("Class '%s' exists but data is not an array: %+v", className, objsRaw)
 		return nil, nil, nil
 	}
 
@@ -274,13 +274,13 @@ func (s *WeaviateStore) GetNearest(
 	objsRaw, exists := data[className]
 	if !exists {
 		// No results for this class - this is normal, not an error
-		s.logger.Debug(fmt.Sprintf("No results found for class '%s', available classes: %+v", className, data))
+		s.logger.Debug("No results found for class '%s', available classes: %+v", className, data)
 		return nil, nil
 	}
 
 	objs, ok := objsRaw.([]interface{})
 	if !ok {
-		s.logger.Debug(fmt.Sprintf("Class '%s' exists but data is not an array: %+v", className, objsRaw))
+		s.logger.Debug("Class '%s' exists but data is not an array: %+v", className, objsRaw)
 		return nil, nil
 	}
 
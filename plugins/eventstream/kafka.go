@@ -0,0 +1,58 @@
+package eventstream
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// kafkaPublisher writes events with acks from all in-sync replicas.
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// newKafkaPublisher creates a synchronous Kafka writer; the topic is set per message.
+func newKafkaPublisher(config *KafkaConfig) (*kafkaPublisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("at least one kafka broker is required")
+	}
+	transport := &kafka.Transport{}
+	if config.Username != "" {
+		transport.SASL = plain.Mechanism{Username: config.Username, Password: config.Password}
+	}
+	if config.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(config.Brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           10 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+			Transport:              transport,
+		},
+	}, nil
+}
+
+// Publish writes the batch and waits for the broker acknowledgement.
+func (k *kafkaPublisher) Publish(ctx context.Context, messages []*Message) error {
+	kafkaMessages := make([]kafka.Message, len(messages))
+	for i, message := range messages {
+		kafkaMessages[i] = kafka.Message{
+			Topic: message.Topic,
+			Key:   []byte(message.Key),
+			Value: message.Value,
+		}
+	}
+	return k.writer.WriteMessages(ctx, kafkaMessages...)
+}
+
+// Close flushes and closes the writer.
+func (k *kafkaPublisher) Close() error {
+	return k.writer.Close()
+}
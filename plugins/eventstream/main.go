@@ -0,0 +1,414 @@
+// Package eventstream publishes request telemetry and governance events to Kafka or NATS
+// so that downstream data pipelines can consume them.
+package eventstream
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/pricing"
+	"github.com/maximhq/bifrost/framework/redaction"
+)
+
+const PluginName = "eventstream"
+
+const (
+	DefaultTopic           = "bifrost.requests"
+	DefaultGovernanceTopic = "bifrost.governance"
+	DefaultBufferSize      = 10000
+	DefaultBatchSize       = 100
+	DefaultFlushInterval   = 500 * time.Millisecond
+
+	maxRetryBackoff = 30 * time.Second
+	pendingTTL      = 30 * time.Minute
+)
+
+// governanceErrorTypes are the error types the governance plugin uses when it rejects a request.
+var governanceErrorTypes = map[string]bool{
+	"virtual_key_required":  true,
+	"virtual_key_not_found": true,
+	"virtual_key_blocked":   true,
+	"rate_limited":          true,
+	"budget_exceeded":       true,
+	"token_limited":         true,
+	"request_limited":       true,
+	"model_blocked":         true,
+	"provider_blocked":      true,
+}
+
+// Publisher sends a batch of events to the broker and returns once the broker has acknowledged them.
+type Publisher interface {
+	Publish(ctx context.Context, messages []*Message) error
+	Close() error
+}
+
+// Message is an encoded event ready to be published.
+type Message struct {
+	ID    string // Event id, used by brokers that deduplicate retried publishes
+	Topic string
+	Key   string
+	Value []byte
+}
+
+// pendingRequest is what PreHook remembers about a request until PostHook publishes it.
+type pendingRequest struct {
+	startedAt time.Time
+	input     []schemas.ChatMessage
+}
+
+// EventStreamPlugin publishes an event for every sampled request once it completes.
+//
+// Events are buffered in memory and published in batches by a background worker, which retries failed batches
+// until the broker acknowledges them (at-least-once). Inference is never blocked: when the buffer is full new
+// events are dropped and counted.
+type EventStreamPlugin struct {
+	config          *Config
+	topic           string
+	governanceTopic string
+	sampleRate      float64
+	publisher       Publisher
+	pricingManager  *pricing.PricingManager
+	redactor        *redaction.Redactor // Scrubs event content before it is published
+	logger          schemas.Logger
+
+	pending sync.Map // request id -> *pendingRequest
+
+	queue         chan *Message
+	batchSize     int
+	flushInterval time.Duration
+	dropped       atomic.Int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Init creates the event stream plugin and connects to the configured broker.
+func Init(ctx context.Context, config *Config, logger schemas.Logger, pricingManager *pricing.PricingManager) (*EventStreamPlugin, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	var publisher Publisher
+	var err error
+	switch config.Broker {
+	case BrokerTypeKafka:
+		if config.Kafka == nil {
+			return nil, fmt.Errorf("kafka config is required")
+		}
+		publisher, err = newKafkaPublisher(config.Kafka)
+	case BrokerTypeNATS:
+		if config.NATS == nil {
+			return nil, fmt.Errorf("nats config is required")
+		}
+		publisher, err = newNATSPublisher(config.NATS)
+	default:
+		return nil, fmt.Errorf("unsupported broker: %q", config.Broker)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newPlugin(ctx, config, publisher, logger, pricingManager)
+}
+
+// newPlugin wires the plugin around an already connected publisher.
+func newPlugin(ctx context.Context, config *Config, publisher Publisher, logger schemas.Logger, pricingManager *pricing.PricingManager) (*EventStreamPlugin, error) {
+	sampleRate := 1.0
+	if config.SampleRate != nil {
+		sampleRate = *config.SampleRate
+		if sampleRate < 0 || sampleRate > 1 {
+			return nil, fmt.Errorf("sample_rate must be between 0 and 1, got %v", sampleRate)
+		}
+	}
+	p := &EventStreamPlugin{
+		config:          config,
+		topic:           config.Topic,
+		governanceTopic: config.GovernanceTopic,
+		sampleRate:      sampleRate,
+		publisher:       publisher,
+		pricingManager:  pricingManager,
+		logger:          logger,
+		batchSize:       DefaultBatchSize,
+		flushInterval:   DefaultFlushInterval,
+	}
+	if p.topic == "" {
+		p.topic = DefaultTopic
+	}
+	if p.governanceTopic == "" {
+		p.governanceTopic = DefaultGovernanceTopic
+	}
+	if config.BatchSize > 0 {
+		p.batchSize = config.BatchSize
+	}
+	if config.FlushIntervalMs > 0 {
+		p.flushInterval = time.Duration(config.FlushIntervalMs) * time.Millisecond
+	}
+	bufferSize := DefaultBufferSize
+	if config.BufferSize > 0 {
+		bufferSize = config.BufferSize
+	}
+	p.queue = make(chan *Message, bufferSize)
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.wg.Add(1)
+	go p.publishWorker()
+	return p, nil
+}
+
+// GetName returns the name of the plugin.
+func (p *EventStreamPlugin) GetName() string {
+	return PluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *EventStreamPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook records the request start time (and input, if content is included).
+func (p *EventStreamPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	requestID, ok := (*ctx).Value(schemas.BifrostContextKeyRequestID).(string)
+	if !ok || requestID == "" {
+		return req, nil, nil
+	}
+	pending := &pendingRequest{startedAt: time.Now()}
+	if p.includeContent(*ctx) && req.ChatRequest != nil {
+		pending.input = req.ChatRequest.Input
+	}
+	p.pending.Store(requestID, pending)
+	return req, nil, nil
+}
+
+// PostHook publishes the event for a finished request. Streams are published once, on their final chunk.
+func (p *EventStreamPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	requestID, ok := (*ctx).Value(schemas.BifrostContextKeyRequestID).(string)
+	if !ok || requestID == "" {
+		return result, bifrostErr, nil
+	}
+	requestType, provider, model := bifrost.GetRequestFields(result, bifrostErr)
+	isStream := bifrost.IsStreamRequestType(requestType)
+	if isStream && bifrostErr == nil && !bifrost.IsFinalChunk(ctx) {
+		return result, bifrostErr, nil
+	}
+	value, found := p.pending.LoadAndDelete(requestID)
+
+	event := &Event{
+		SchemaVersion: SchemaVersion,
+		ID:            uuid.NewString(),
+		Type:          EventTypeRequestCompleted,
+		Timestamp:     time.Now().UTC(),
+		RequestID:     requestID,
+		Provider:      string(provider),
+		Model:         model,
+		RequestType:   string(requestType),
+		Stream:        isStream,
+	}
+	topic := p.topic
+	if bifrostErr != nil {
+		event.Type = EventTypeRequestFailed
+		event.Error = &EventError{}
+		if bifrostErr.Type != nil {
+			event.Error.Type = *bifrostErr.Type
+		}
+		if bifrostErr.StatusCode != nil {
+			event.Error.StatusCode = *bifrostErr.StatusCode
+		}
+		if bifrostErr.Error != nil {
+			event.Error.Message = bifrostErr.Error.Message
+			if event.Error.Type == "" && bifrostErr.Error.Type != nil {
+				event.Error.Type = *bifrostErr.Error.Type
+			}
+		}
+		if governanceErrorTypes[event.Error.Type] {
+			event.Type = EventTypeGovernanceRejected
+			topic = p.governanceTopic
+		}
+	}
+	if event.Type != EventTypeGovernanceRejected && !p.sampled(requestID) {
+		return result, bifrostErr, nil
+	}
+	if found {
+		pending := value.(*pendingRequest)
+		latency := float64(time.Since(pending.startedAt).Microseconds()) / 1000
+		event.LatencyMs = &latency
+		event.Input = pending.input
+	}
+	if result != nil {
+		event.Usage = result.Usage
+		if p.pricingManager != nil {
+			cost := p.pricingManager.CalculateCostWithCacheDebug(result)
+			event.Cost = &cost
+		}
+		if p.includeContent(*ctx) && !isStream && len(result.Choices) > 0 && result.Choices[0].BifrostNonStreamResponseChoice != nil {
+			event.Output = result.Choices[0].Message
+		}
+	}
+	if p.config.IncludeVirtualKey {
+		if virtualKey, ok := (*ctx).Value(schemas.BifrostContextKeyVirtualKeyHeader).(string); ok {
+			event.VirtualKey = virtualKey
+		}
+	}
+	p.enqueue(topic, event, redaction.TenantFromContext(*ctx))
+	return result, bifrostErr, nil
+}
+
+// includeContent reports whether the events of a request carry its input and output. The content of zero data
+// retention requests is never published.
+func (p *EventStreamPlugin) includeContent(ctx context.Context) bool {
+	retentionClass, _ := ctx.Value(schemas.BifrostContextKeyRetentionClass).(string)
+	return p.config.IncludeContent && retentionClass != schemas.RetentionClassZeroDataRetention
+}
+
+// sampled decides whether a request event is published. The decision only depends on the request id.
+func (p *EventStreamPlugin) sampled(requestID string) bool {
+	if p.sampleRate >= 1 {
+		return true
+	}
+	if p.sampleRate <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(requestID))
+	return float64(h.Sum32()%10000) < p.sampleRate*10000
+}
+
+// enqueue encodes the event and hands it to the publish worker without blocking.
+func (p *EventStreamPlugin) enqueue(topic string, event *Event, tenant string) {
+	data, err := sonic.Marshal(event)
+	if err != nil {
+		p.logger.Error("eventstream: failed to encode event for request %s: %v", event.RequestID, err)
+		return
+	}
+	if p.redactor != nil && (event.Input != nil || event.Output != nil || event.Error != nil) {
+		if data, err = p.redact(tenant, data); err != nil {
+			p.logger.Error("eventstream: failed to redact event for request %s, dropping it: %v", event.RequestID, err)
+			return
+		}
+	}
+	select {
+	case p.queue <- &Message{ID: event.ID, Topic: topic, Key: event.RequestID, Value: data}:
+	default:
+		if dropped := p.dropped.Add(1); dropped%1000 == 1 {
+			p.logger.Warn("eventstream: buffer is full, dropped %d events so far", dropped)
+		}
+	}
+}
+
+// SetRedactor sets the redaction policies applied to event content before it is published.
+func (p *EventStreamPlugin) SetRedactor(redactor *redaction.Redactor) {
+	p.redactor = redactor
+}
+
+// redact scrubs the input, output and error message of an encoded event.
+// Input and output are rooted like the log columns they mirror, so field paths are shared with the log store.
+func (p *EventStreamPlugin) redact(tenant string, data []byte) ([]byte, error) {
+	var event map[string]any
+	if err := sonic.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	if input, ok := event["input"]; ok {
+		event["input"] = p.redactor.RedactValue(tenant, "input_history", input)
+	}
+	if output, ok := event["output"]; ok {
+		event["output"] = p.redactor.RedactValue(tenant, "output_message", output)
+	}
+	if eventError, ok := event["error"].(map[string]any); ok {
+		if message, ok := eventError["message"].(string); ok {
+			eventError["message"] = p.redactor.RedactString(tenant, message)
+		}
+	}
+	return sonic.Marshal(event)
+}
+
+// publishWorker publishes batches and retries each batch until it is acknowledged or the plugin is closed.
+func (p *EventStreamPlugin) publishWorker() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Message, 0, p.batchSize)
+	for {
+		select {
+		case message := <-p.queue:
+			batch = append(batch, message)
+			if len(batch) >= p.batchSize {
+				p.publishWithRetry(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				p.publishWithRetry(batch)
+				batch = batch[:0]
+			}
+			p.evictStalePending()
+		case <-p.ctx.Done():
+			// Best effort drain of what is already buffered
+			for {
+				select {
+				case message := <-p.queue:
+					batch = append(batch, message)
+					continue
+				default:
+				}
+				break
+			}
+			if len(batch) > 0 {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := p.publisher.Publish(ctx, batch); err != nil {
+					p.logger.Error("eventstream: failed to publish %d events on shutdown: %v", len(batch), err)
+				}
+				cancel()
+			}
+			return
+		}
+	}
+}
+
+// publishWithRetry publishes a batch, backing off exponentially between failed attempts.
+func (p *EventStreamPlugin) publishWithRetry(batch []*Message) {
+	backoff := 100 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := p.publisher.Publish(p.ctx, batch)
+		if err == nil {
+			return
+		}
+		if p.ctx.Err() != nil {
+			p.logger.Error("eventstream: dropping %d events, plugin is shutting down: %v", len(batch), err)
+			return
+		}
+		p.logger.Warn("eventstream: failed to publish %d events (attempt %d): %v", len(batch), attempt, err)
+		select {
+		case <-time.After(backoff):
+		case <-p.ctx.Done():
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// evictStalePending forgets requests whose PostHook never ran.
+func (p *EventStreamPlugin) evictStalePending() {
+	cutoff := time.Now().Add(-pendingTTL)
+	p.pending.Range(func(key, value any) bool {
+		if value.(*pendingRequest).startedAt.Before(cutoff) {
+			p.pending.Delete(key)
+		}
+		return true
+	})
+}
+
+// Cleanup flushes buffered events and closes the broker connection.
+func (p *EventStreamPlugin) Cleanup() error {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+	if p.publisher != nil {
+		return p.publisher.Close()
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package eventstream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsPublisher publishes events on NATS subjects, optionally through JetStream.
+type natsPublisher struct {
+	conn      *nats.Conn
+	jetStream nats.JetStreamContext
+}
+
+// newNATSPublisher connects to NATS. With JetStream enabled the subjects must be bound to a stream.
+func newNATSPublisher(config *NATSConfig) (*natsPublisher, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("nats url is required")
+	}
+	options := []nats.Option{nats.Name("bifrost-eventstream")}
+	if config.Username != "" {
+		options = append(options, nats.UserInfo(config.Username, config.Password))
+	}
+	if config.Token != "" {
+		options = append(options, nats.Token(config.Token))
+	}
+	conn, err := nats.Connect(config.URL, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	publisher := &natsPublisher{conn: conn}
+	if config.JetStream {
+		publisher.jetStream, err = conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+		}
+	}
+	return publisher, nil
+}
+
+// Publish sends the batch. JetStream publishes wait for the stream ack; core NATS publishes wait for a server flush.
+func (n *natsPublisher) Publish(ctx context.Context, messages []*Message) error {
+	if n.jetStream != nil {
+		futures := make([]nats.PubAckFuture, 0, len(messages))
+		for _, message := range messages {
+			// The message id lets JetStream drop duplicates of a retried batch
+			future, err := n.jetStream.PublishMsgAsync(&nats.Msg{
+				Subject: message.Topic,
+				Data:    message.Value,
+				Header:  nats.Header{nats.MsgIdHdr: []string{message.ID}},
+			})
+			if err != nil {
+				return err
+			}
+			futures = append(futures, future)
+		}
+		for _, future := range futures {
+			select {
+			case <-future.Ok():
+			case err := <-future.Err():
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+	for _, message := range messages {
+		if err := n.conn.Publish(message.Topic, message.Value); err != nil {
+			return err
+		}
+	}
+	return n.conn.FlushWithContext(ctx)
+}
+
+// Close drains pending publishes and closes the connection.
+func (n *natsPublisher) Close() error {
+	return n.conn.Drain()
+}
@@ -0,0 +1,220 @@
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/redaction"
+)
+
+// fakePublisher records published messages and can fail the first N publishes.
+type fakePublisher struct {
+	mu       sync.Mutex
+	failures int
+	messages []*Message
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, messages []*Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return fmt.Errorf("broker unavailable")
+	}
+	f.messages = append(f.messages, messages...)
+	return nil
+}
+
+func (f *fakePublisher) Close() error { return nil }
+
+func (f *fakePublisher) published() []*Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Message(nil), f.messages...)
+}
+
+func newTestPlugin(t *testing.T, config *Config, publisher *fakePublisher) *EventStreamPlugin {
+	t.Helper()
+	config.FlushIntervalMs = 10
+	plugin, err := newPlugin(context.Background(), config, publisher, bifrost.NewDefaultLogger(schemas.LogLevelError), nil)
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	return plugin
+}
+
+func runRequest(plugin *EventStreamPlugin, requestID string, resp *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) {
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, requestID)
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o-mini", RequestType: schemas.ChatCompletionRequest}
+	plugin.PreHook(&ctx, req)
+	plugin.PostHook(&ctx, resp, bifrostErr)
+}
+
+// TestPostHook_PublishesAfterRetry tests that a failed publish is retried until the broker accepts it
+func TestPostHook_PublishesAfterRetry(t *testing.T) {
+	publisher := &fakePublisher{failures: 1}
+	plugin := newTestPlugin(t, &Config{}, publisher)
+
+	runRequest(plugin, "req-1", &schemas.BifrostResponse{
+		Model:       "gpt-4o-mini",
+		Usage:       &schemas.LLMUsage{PromptTokens: 3, CompletionTokens: 4, TotalTokens: 7},
+		ExtraFields: schemas.BifrostResponseExtraFields{Provider: schemas.OpenAI, RequestType: schemas.ChatCompletionRequest},
+	}, nil)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(publisher.published()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := plugin.Cleanup(); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	messages := publisher.published()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Topic != DefaultTopic || messages[0].Key != "req-1" {
+		t.Errorf("unexpected topic/key: %s/%s", messages[0].Topic, messages[0].Key)
+	}
+	var event Event
+	if err := json.Unmarshal(messages[0].Value, &event); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if event.Type != EventTypeRequestCompleted || event.SchemaVersion != SchemaVersion || event.Usage == nil || event.Usage.TotalTokens != 7 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.LatencyMs == nil {
+		t.Error("expected latency to be set")
+	}
+}
+
+// TestPostHook_GovernanceRejectionIgnoresSampling tests that governance events use their own topic and are never sampled out
+func TestPostHook_GovernanceRejectionIgnoresSampling(t *testing.T) {
+	zero := 0.0
+	publisher := &fakePublisher{}
+	plugin := newTestPlugin(t, &Config{SampleRate: &zero}, publisher)
+
+	runRequest(plugin, "req-ok", &schemas.BifrostResponse{}, nil)
+	runRequest(plugin, "req-blocked", nil, &schemas.BifrostError{
+		Type:       bifrost.Ptr("budget_exceeded"),
+		StatusCode: bifrost.Ptr(402),
+		Error:      &schemas.ErrorField{Message: "budget exceeded"},
+	})
+	if err := plugin.Cleanup(); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+
+	messages := publisher.published()
+	if len(messages) != 1 {
+		t.Fatalf("expected only the governance event, got %d messages", len(messages))
+	}
+	if messages[0].Topic != DefaultGovernanceTopic {
+		t.Errorf("expected governance topic, got %s", messages[0].Topic)
+	}
+	var event Event
+	if err := json.Unmarshal(messages[0].Value, &event); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if event.Type != EventTypeGovernanceRejected || event.Error == nil || event.Error.StatusCode != 402 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+// TestSampled_IsDeterministic tests that the sampling decision only depends on the request id
+func TestSampled_IsDeterministic(t *testing.T) {
+	rate := 0.5
+	plugin := newTestPlugin(t, &Config{SampleRate: &rate}, &fakePublisher{})
+	defer plugin.Cleanup()
+
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		requestID := fmt.Sprintf("req-%d", i)
+		first := plugin.sampled(requestID)
+		if first != plugin.sampled(requestID) {
+			t.Fatalf("sampling decision changed for %s", requestID)
+		}
+		if first {
+			sampled++
+		}
+	}
+	if sampled < 400 || sampled > 600 {
+		t.Errorf("expected roughly half of the requests to be sampled, got %d/1000", sampled)
+	}
+}
+
+// TestPostHook_RedactsErrorMessage tests that configured redaction is applied before an event is published
+func TestPostHook_RedactsErrorMessage(t *testing.T) {
+	publisher := &fakePublisher{}
+	plugin := newTestPlugin(t, &Config{}, publisher)
+	redactor, err := redaction.New(&redaction.Config{Enabled: true, Policy: redaction.Policy{Detectors: []redaction.Detector{redaction.DetectorEmail}}})
+	if err != nil {
+		t.Fatalf("failed to create redactor: %v", err)
+	}
+	plugin.SetRedactor(redactor)
+
+	runRequest(plugin, "req-redact", nil, &schemas.BifrostError{
+		Error:       &schemas.ErrorField{Message: "user jane@example.com is not allowed"},
+		ExtraFields: schemas.BifrostErrorExtraFields{Provider: schemas.OpenAI, RequestType: schemas.ChatCompletionRequest},
+	})
+	if err := plugin.Cleanup(); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	messages := publisher.published()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	var event Event
+	if err := json.Unmarshal(messages[0].Value, &event); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if event.Error == nil || event.Error.Message != "user [REDACTED] is not allowed" {
+		t.Errorf("expected redacted error message, got %+v", event.Error)
+	}
+}
+
+// TestPostHook_ZeroDataRetentionDropsContent tests that the input and output of zero data retention requests are
+// left out of their events even when content is included
+func TestPostHook_ZeroDataRetentionDropsContent(t *testing.T) {
+	publisher := &fakePublisher{}
+	plugin := newTestPlugin(t, &Config{IncludeContent: true}, publisher)
+
+	content := "hello"
+	message := schemas.ChatMessage{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: &content}}
+	response := &schemas.BifrostResponse{
+		Choices: []schemas.BifrostChatResponseChoice{{
+			BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &message},
+		}},
+		ExtraFields: schemas.BifrostResponseExtraFields{Provider: schemas.OpenAI, RequestType: schemas.ChatCompletionRequest},
+	}
+	for _, retentionClass := range []string{"", schemas.RetentionClassZeroDataRetention} {
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "req-"+retentionClass)
+		ctx = context.WithValue(ctx, schemas.BifrostContextKeyRetentionClass, retentionClass)
+		req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o-mini", RequestType: schemas.ChatCompletionRequest,
+			ChatRequest: &schemas.BifrostChatRequest{Input: []schemas.ChatMessage{message}}}
+		plugin.PreHook(&ctx, req)
+		plugin.PostHook(&ctx, response, nil)
+	}
+	if err := plugin.Cleanup(); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+
+	messages := publisher.published()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	for _, published := range messages {
+		var event Event
+		if err := json.Unmarshal(published.Value, &event); err != nil {
+			t.Fatalf("failed to decode event: %v", err)
+		}
+		zeroDataRetention := event.RequestID == "req-"+schemas.RetentionClassZeroDataRetention
+		if hasContent := event.Input != nil && event.Output != nil; hasContent == zeroDataRetention {
+			t.Errorf("event %s: input = %v, output = %v, want content only without zero data retention", event.RequestID, event.Input, event.Output)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package eventstream
+
+import (
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// SchemaVersion is bumped whenever a field of Event changes meaning or is removed.
+// Adding optional fields does not bump it.
+const SchemaVersion = 1
+
+// EventType identifies what an event describes.
+type EventType string
+
+const (
+	// EventTypeRequestCompleted is published when a request finishes successfully.
+	EventTypeRequestCompleted EventType = "request.completed"
+	// EventTypeRequestFailed is published when a request fails at the provider or inside Bifrost.
+	EventTypeRequestFailed EventType = "request.failed"
+	// EventTypeGovernanceRejected is published when governance blocks a request (budgets, rate limits, access rules).
+	EventTypeGovernanceRejected EventType = "governance.rejected"
+)
+
+// Event is the message published for every sampled request and every governance rejection.
+//
+// Messages are JSON encoded. On Kafka the message key is the request id, so all events of a request
+// land on the same partition. Delivery is at-least-once: consumers should deduplicate on ID.
+//
+//	{
+//	  "schema_version": 1,
+//	  "id": "0b6f...",                       // unique per event
+//	  "type": "request.completed",
+//	  "timestamp": "2025-01-01T00:00:00Z",   // when the request finished
+//	  "request_id": "7d1c...",
+//	  "provider": "openai",
+//	  "model": "gpt-4o-mini",
+//	  "request_type": "chat_completion",
+//	  "stream": false,
+//	  "latency_ms": 812.4,
+//	  "usage": {"prompt_tokens": 12, "completion_tokens": 40, "total_tokens": 52},
+//	  "cost": 0.000031,
+//	  "virtual_key": "sk-bf-...",           // only when include_virtual_key is enabled
+//	  "error": {"type": "rate_limited", "status_code": 429, "message": "..."},
+//	  "input": [...], "output": {...}        // only when include_content is enabled
+//	}
+type Event struct {
+	SchemaVersion int                   `json:"schema_version"`
+	ID            string                `json:"id"`
+	Type          EventType             `json:"type"`
+	Timestamp     time.Time             `json:"timestamp"`
+	RequestID     string                `json:"request_id"`
+	Provider      string                `json:"provider,omitempty"`
+	Model         string                `json:"model,omitempty"`
+	RequestType   string                `json:"request_type,omitempty"`
+	Stream        bool                  `json:"stream"`
+	LatencyMs     *float64              `json:"latency_ms,omitempty"`
+	Usage         *schemas.LLMUsage     `json:"usage,omitempty"`
+	Cost          *float64              `json:"cost,omitempty"`
+	VirtualKey    string                `json:"virtual_key,omitempty"`
+	Error         *EventError           `json:"error,omitempty"`
+	Input         []schemas.ChatMessage `json:"input,omitempty"`
+	Output        *schemas.ChatMessage  `json:"output,omitempty"`
+}
+
+// EventError describes why a request failed or was rejected.
+type EventError struct {
+	Type       string `json:"type,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// BrokerType selects the event bus.
+type BrokerType string
+
+const (
+	BrokerTypeKafka BrokerType = "kafka"
+	BrokerTypeNATS  BrokerType = "nats"
+)
+
+// Config is the configuration for the event stream plugin.
+type Config struct {
+	Broker BrokerType   `json:"broker"`
+	Kafka  *KafkaConfig `json:"kafka,omitempty"`
+	NATS   *NATSConfig  `json:"nats,omitempty"`
+
+	// Topic (Kafka) or subject (NATS) for request events. Default: bifrost.requests
+	Topic string `json:"topic,omitempty"`
+	// Topic or subject for governance events. Default: bifrost.governance
+	GovernanceTopic string `json:"governance_topic,omitempty"`
+
+	// SampleRate is the fraction of request events published, between 0 and 1. Default: 1.
+	// Sampling is keyed on the request id, so every replica makes the same decision. Governance events are never sampled.
+	SampleRate *float64 `json:"sample_rate,omitempty"`
+	// IncludeContent adds request input and response output to events.
+	IncludeContent bool `json:"include_content,omitempty"`
+	// IncludeVirtualKey adds the virtual key used for the request to events.
+	IncludeVirtualKey bool `json:"include_virtual_key,omitempty"`
+
+	BufferSize      int `json:"buffer_size,omitempty"`       // Events held in memory while the broker is slow (default: 10000)
+	BatchSize       int `json:"batch_size,omitempty"`        // Events published per broker call (default: 100)
+	FlushIntervalMs int `json:"flush_interval_ms,omitempty"` // Max time an event waits before being published (default: 500)
+}
+
+// KafkaConfig is the Kafka producer configuration.
+type KafkaConfig struct {
+	Brokers  []string `json:"brokers"`            // host:port list - REQUIRED
+	Username string   `json:"username,omitempty"` // SASL/PLAIN username (optional)
+	Password string   `json:"password,omitempty"` // SASL/PLAIN password (optional)
+	TLS      bool     `json:"tls,omitempty"`      // Connect using TLS
+}
+
+// NATSConfig is the NATS publisher configuration.
+type NATSConfig struct {
+	URL       string `json:"url"`                 // e.g. nats://localhost:4222 - REQUIRED
+	Username  string `json:"username,omitempty"`  // (optional)
+	Password  string `json:"password,omitempty"`  // (optional)
+	Token     string `json:"token,omitempty"`     // (optional)
+	JetStream bool   `json:"jetstream,omitempty"` // Publish through JetStream and wait for acks; required for at-least-once delivery
+}
@@ -0,0 +1,149 @@
+// Package governance provides budget consumption alerts delivered to a webhook
+package governance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// DefaultBudgetAlertThresholds are the consumption percentages alerted by default.
+var DefaultBudgetAlertThresholds = []int{80, 90, 100}
+
+// BudgetAlertEventType is the type of budget alert payloads.
+const BudgetAlertEventType = "budget.threshold"
+
+// BudgetAlertsConfig sends a webhook when the consumption of a budget crosses a threshold, once per
+// threshold and budget period.
+type BudgetAlertsConfig struct {
+	WebhookURL string            `json:"webhook_url"`
+	Thresholds []int             `json:"thresholds,omitempty"` // Percentages of the budget limit (default 80, 90, 100)
+	Headers    map[string]string `json:"headers,omitempty"`    // Extra headers of webhook requests, e.g. authorization
+}
+
+// BudgetAlert is the payload posted to the webhook.
+type BudgetAlert struct {
+	Type         string    `json:"type"`
+	BudgetID     string    `json:"budget_id"`
+	Threshold    int       `json:"threshold"`     // Percentage crossed
+	CurrentUsage float64   `json:"current_usage"` // Dollars spent in the current period
+	Limit        float64   `json:"limit"`         // Limit of the current period, including quota rolled over
+	NextReset    time.Time `json:"next_reset,omitempty"`
+	VirtualKeyID string    `json:"virtual_key_id"` // Virtual key of the request that crossed the threshold
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// budgetAlerter detects threshold crossings and delivers alerts in the background.
+type budgetAlerter struct {
+	url        string
+	headers    map[string]string
+	thresholds []int // Ascending
+	client     *http.Client
+	logger     schemas.Logger
+}
+
+// newBudgetAlerter creates an alerter, or returns nil when alerts are not configured.
+func newBudgetAlerter(config *BudgetAlertsConfig, logger schemas.Logger) (*budgetAlerter, error) {
+	if config == nil || config.WebhookURL == "" {
+		return nil, nil
+	}
+	thresholds := slices.Clone(config.Thresholds)
+	if len(thresholds) == 0 {
+		thresholds = slices.Clone(DefaultBudgetAlertThresholds)
+	}
+	for _, threshold := range thresholds {
+		if threshold <= 0 {
+			return nil, fmt.Errorf("budget alert thresholds must be positive, got %d", threshold)
+		}
+	}
+	slices.Sort(thresholds)
+	return &budgetAlerter{
+		url:        config.WebhookURL,
+		headers:    config.Headers,
+		thresholds: slices.Compact(thresholds),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+	}, nil
+}
+
+// check records the highest threshold the usage of budget newly crossed in its period, returning the
+// alert to send. Nil alerters never alert.
+func (a *budgetAlerter) check(budget *configstore.TableBudget, virtualKeyID string) *BudgetAlert {
+	if a == nil {
+		return nil
+	}
+	limit := budget.EffectiveLimit()
+	if limit <= 0 {
+		return nil
+	}
+	consumed := budget.CurrentUsage / limit * 100
+	crossed := 0
+	for _, threshold := range a.thresholds {
+		if consumed >= float64(threshold) && threshold > budget.AlertThreshold {
+			crossed = threshold
+		}
+	}
+	if crossed == 0 {
+		return nil
+	}
+	budget.AlertThreshold = crossed
+	alert := &BudgetAlert{
+		Type:         BudgetAlertEventType,
+		BudgetID:     budget.ID,
+		Threshold:    crossed,
+		CurrentUsage: budget.CurrentUsage,
+		Limit:        limit,
+		VirtualKeyID: virtualKeyID,
+		Timestamp:    time.Now().UTC(),
+	}
+	if next, err := budget.NextReset(); err == nil {
+		alert.NextReset = next.UTC()
+	}
+	return alert
+}
+
+// send delivers alerts in the background. Failed deliveries are logged and not retried.
+func (a *budgetAlerter) send(alerts []*BudgetAlert) {
+	if a == nil || len(alerts) == 0 {
+		return
+	}
+	go func() {
+		for _, alert := range alerts {
+			if err := a.post(alert); err != nil {
+				a.logger.Warn("failed to deliver alert of budget %s at %d%%: %v", alert.BudgetID, alert.Threshold, err)
+			}
+		}
+	}()
+}
+
+// post sends one alert to the webhook.
+func (a *budgetAlerter) post(alert *BudgetAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range a.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
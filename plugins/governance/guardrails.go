@@ -0,0 +1,141 @@
+// Package governance provides the enforcement of the parameter guardrails of virtual keys
+package governance
+
+import (
+	"fmt"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// applyParameterGuardrails enforces the parameter guardrails of a virtual key on the text, chat and responses
+// requests. With hard and shadow guardrails, it returns an error describing the first violated limit; with soft ones,
+// the parameters are clamped instead. Requests without a max tokens or response format get the limit of the guardrails.
+func applyParameterGuardrails(guardrails *configstore.ParameterGuardrails, req *schemas.BifrostRequest) error {
+	if guardrails.IsZero() {
+		return nil
+	}
+	switch {
+	case req.ChatRequest != nil:
+		if req.ChatRequest.Params == nil {
+			req.ChatRequest.Params = &schemas.ChatParameters{}
+		}
+		params := req.ChatRequest.Params
+		if err := guardTemperature(guardrails, params.Temperature); err != nil {
+			return err
+		}
+		if err := guardMaxTokens(guardrails, "max_completion_tokens", &params.MaxCompletionTokens); err != nil {
+			return err
+		}
+		if guardrails.DisallowTools && (len(params.Tools) > 0 || params.ToolChoice != nil) {
+			if !guardrails.IsSoft() {
+				return fmt.Errorf("tools are not allowed for this virtual key")
+			}
+			params.Tools, params.ToolChoice, params.ParallelToolCalls = nil, nil, nil
+		}
+		if guardrails.ResponseFormat != "" {
+			requested := ""
+			if params.ResponseFormat != nil {
+				if format, ok := (*params.ResponseFormat).(map[string]any); ok {
+					requested, _ = format["type"].(string)
+				}
+			}
+			if requested != guardrails.ResponseFormat {
+				if requested != "" && !guardrails.IsSoft() {
+					return fmt.Errorf("response_format %q is not allowed for this virtual key, which requires %q", requested, guardrails.ResponseFormat)
+				}
+				var format any = map[string]any{"type": guardrails.ResponseFormat}
+				params.ResponseFormat = &format
+			}
+		}
+	case req.ResponsesRequest != nil:
+		if req.ResponsesRequest.Params == nil {
+			req.ResponsesRequest.Params = &schemas.ResponsesParameters{}
+		}
+		params := req.ResponsesRequest.Params
+		if err := guardTemperature(guardrails, params.Temperature); err != nil {
+			return err
+		}
+		if err := guardMaxTokens(guardrails, "max_output_tokens", &params.MaxOutputTokens); err != nil {
+			return err
+		}
+		if guardrails.DisallowTools && (len(params.Tools) > 0 || params.ToolChoice != nil) {
+			if !guardrails.IsSoft() {
+				return fmt.Errorf("tools are not allowed for this virtual key")
+			}
+			params.Tools, params.ToolChoice, params.ParallelToolCalls = nil, nil, nil
+		}
+		if guardrails.ResponseFormat != "" {
+			requested := ""
+			if params.Text != nil && params.Text.Format != nil {
+				requested = params.Text.Format.Type
+			}
+			if requested != guardrails.ResponseFormat {
+				if requested != "" && !guardrails.IsSoft() {
+					return fmt.Errorf("text.format %q is not allowed for this virtual key, which requires %q", requested, guardrails.ResponseFormat)
+				}
+				if params.Text == nil {
+					params.Text = &schemas.ResponsesTextConfig{}
+				}
+				params.Text.Format = &schemas.ResponsesTextConfigFormat{Type: guardrails.ResponseFormat}
+			}
+		}
+	case req.TextCompletionRequest != nil:
+		if req.TextCompletionRequest.Params == nil {
+			req.TextCompletionRequest.Params = &schemas.TextCompletionParameters{}
+		}
+		params := req.TextCompletionRequest.Params
+		if err := guardTemperature(guardrails, params.Temperature); err != nil {
+			return err
+		}
+		if err := guardMaxTokens(guardrails, "max_tokens", &params.MaxTokens); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// guardTemperature rejects or clamps a temperature above the maximum of the guardrails.
+func guardTemperature(guardrails *configstore.ParameterGuardrails, temperature *float64) error {
+	if guardrails.MaxTemperature == nil || temperature == nil || *temperature <= *guardrails.MaxTemperature {
+		return nil
+	}
+	if !guardrails.IsSoft() {
+		return fmt.Errorf("temperature %g exceeds the maximum of %g allowed for this virtual key", *temperature, *guardrails.MaxTemperature)
+	}
+	*temperature = *guardrails.MaxTemperature
+	return nil
+}
+
+// guardMaxTokens rejects or clamps a max tokens parameter above the maximum of the guardrails, and sets the maximum
+// on requests without one.
+func guardMaxTokens(guardrails *configstore.ParameterGuardrails, name string, maxTokens **int) error {
+	if guardrails.MaxTokens == nil {
+		return nil
+	}
+	if *maxTokens == nil {
+		limit := *guardrails.MaxTokens
+		*maxTokens = &limit
+		return nil
+	}
+	if **maxTokens <= *guardrails.MaxTokens {
+		return nil
+	}
+	if !guardrails.IsSoft() {
+		return fmt.Errorf("%s %d exceeds the maximum of %d allowed for this virtual key", name, **maxTokens, *guardrails.MaxTokens)
+	}
+	**maxTokens = *guardrails.MaxTokens
+	return nil
+}
+
+// guardrailsViolation is the violation of the parameter guardrails of a virtual key, from the error of
+// applyParameterGuardrails.
+func guardrailsViolation(vk *configstore.TableVirtualKey, shadow bool, err error) RuleViolation {
+	return RuleViolation{
+		Kind:   RuleKindParameterGuardrails,
+		ID:     vk.ID,
+		Level:  "VK",
+		Shadow: shadow,
+		Reason: err.Error(),
+	}
+}
@@ -29,7 +29,9 @@ const (
 
 // Config is the configuration for the governance plugin
 type Config struct {
-	IsVkMandatory *bool `json:"is_vk_mandatory"`
+	IsVkMandatory     *bool                    `json:"is_vk_mandatory"`
+	BudgetAlerts      *BudgetAlertsConfig      `json:"budget_alerts,omitempty"`       // Webhook warnings when budgets cross consumption thresholds
+	StreamTokenLimits *StreamTokenLimitsConfig `json:"stream_token_limits,omitempty"` // Token rate limits enforced while streams generate
 }
 
 type InMemoryStore interface {
@@ -46,6 +48,9 @@ type GovernancePlugin struct {
 	resolver *BudgetResolver  // Pure decision engine for hierarchical governance
 	tracker  *UsageTracker    // Business logic owner (updates, resets, persistence)
 
+	streamLimiter *streamLimiter  // Enforces token limits mid-stream, nil when not configured
+	shadow        *shadowRecorder // Requests blocked by rules and the ones shadow rules would have blocked
+
 	// Dependencies
 	configStore    configstore.ConfigStore
 	pricingManager *pricing.PricingManager
@@ -116,6 +121,15 @@ func Init(
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize governance store: %w", err)
 	}
+	var limiter *streamLimiter
+	if config != nil {
+		if governanceStore.alerter, err = newBudgetAlerter(config.BudgetAlerts, logger); err != nil {
+			return nil, err
+		}
+		if limiter, err = newStreamLimiter(config.StreamTokenLimits, governanceStore, logger); err != nil {
+			return nil, err
+		}
+	}
 	// Initialize components in dependency order with fixed, optimal settings
 	// Resolver (pure decision engine for hierarchical governance, depends only on store)
 	resolver := NewBudgetResolver(governanceStore, logger)
@@ -137,6 +151,8 @@ func Init(
 		store:          governanceStore,
 		resolver:       resolver,
 		tracker:        tracker,
+		streamLimiter:  limiter,
+		shadow:         newShadowRecorder(),
 		configStore:    store,
 		pricingManager: pricingManager,
 		logger:         logger,
@@ -298,6 +314,7 @@ func (p *GovernancePlugin) PreHook(ctx *context.Context, req *schemas.BifrostReq
 	headers := extractHeadersFromContext(*ctx)
 	virtualKey := getStringFromContext(*ctx, schemas.BifrostContextKeyVirtualKeyHeader)
 	requestID := getStringFromContext(*ctx, schemas.BifrostContextKeyRequestID)
+	labels, _ := (*ctx).Value(schemas.BifrostContextKeyLabels).(map[string]string)
 
 	if virtualKey == "" {
 		if p.isVkMandatory != nil && *p.isVkMandatory {
@@ -326,12 +343,14 @@ func (p *GovernancePlugin) PreHook(ctx *context.Context, req *schemas.BifrostReq
 		Model:      model,
 		Headers:    headers,
 		RequestID:  requestID,
+		Labels:     labels,
 	}
 
 	// Use resolver to make governance decision (pure decision engine)
 	result := p.resolver.EvaluateRequest(ctx, evaluationRequest)
 
 	if result.Decision != DecisionAllow {
+		p.shadow.record(result.Violation, result.ShadowViolations, labels)
 		if ctx != nil {
 			if _, ok := (*ctx).Value(governanceRejectedContextKey).(bool); !ok {
 				*ctx = context.WithValue(*ctx, governanceRejectedContextKey, true)
@@ -342,6 +361,28 @@ func (p *GovernancePlugin) PreHook(ctx *context.Context, req *schemas.BifrostReq
 	// Handle decision
 	switch result.Decision {
 	case DecisionAllow:
+		guardrails := result.VirtualKey.ParameterGuardrails
+		if guardrails.IsShadow() {
+			// Shadow guardrails are evaluated as hard ones on a copy, leaving the request unchanged
+			if err := applyParameterGuardrails(guardrails, shadowCopy(req)); err != nil {
+				result.ShadowViolations = append(result.ShadowViolations, guardrailsViolation(result.VirtualKey, true, err))
+			}
+		} else if err := applyParameterGuardrails(guardrails, req); err != nil {
+			violation := guardrailsViolation(result.VirtualKey, false, err)
+			p.shadow.record(&violation, result.ShadowViolations, labels)
+			*ctx = context.WithValue(*ctx, governanceRejectedContextKey, true)
+			return req, &schemas.PluginShortCircuit{
+				Error: &schemas.BifrostError{
+					Type:       bifrost.Ptr(string(DecisionParameterGuardrailViolated)),
+					StatusCode: bifrost.Ptr(400),
+					Error: &schemas.ErrorField{
+						Message: err.Error(),
+					},
+				},
+			}, nil
+		}
+		p.shadow.record(nil, result.ShadowViolations, labels)
+		p.streamLimiter.track(ctx, req)
 		return req, nil, nil
 
 	case DecisionVirtualKeyNotFound, DecisionVirtualKeyBlocked, DecisionModelBlocked, DecisionProviderBlocked:
@@ -432,7 +473,16 @@ func (p *GovernancePlugin) PostHook(ctx *context.Context, result *schemas.Bifros
 		customerID = &customerIDValue
 	}
 
-	go p.postHookWorker(result, provider, model, requestType, virtualKey, requestID, teamID, customerID, isCacheRead, isBatch, bifrost.IsFinalChunk(ctx))
+	// Charge the output of tracked streams as it arrives, ending the ones past their token limits
+	var chargedTokens int64
+	if p.streamLimiter != nil && err == nil {
+		if limitErr := p.streamLimiter.chargeChunk(*ctx, virtualKey, result); limitErr != nil {
+			return result, limitErr, nil
+		}
+		chargedTokens = p.streamLimiter.charged(*ctx)
+	}
+
+	go p.postHookWorker(result, provider, model, requestType, virtualKey, requestID, teamID, customerID, isCacheRead, isBatch, bifrost.IsFinalChunk(ctx), chargedTokens)
 
 	return result, err, nil
 }
@@ -449,7 +499,7 @@ func (p *GovernancePlugin) Cleanup() error {
 	return nil
 }
 
-func (p *GovernancePlugin) postHookWorker(result *schemas.BifrostResponse, provider schemas.ModelProvider, model string, requestType schemas.RequestType, virtualKey, requestID string, teamID, customerID *string, isCacheRead, isBatch bool, isFinalChunk bool) {
+func (p *GovernancePlugin) postHookWorker(result *schemas.BifrostResponse, provider schemas.ModelProvider, model string, requestType schemas.RequestType, virtualKey, requestID string, teamID, customerID *string, isCacheRead, isBatch bool, isFinalChunk bool, chargedTokens int64) {
 	// Determine if request was successful
 	success := (result != nil)
 
@@ -469,6 +519,10 @@ func (p *GovernancePlugin) postHookWorker(result *schemas.BifrostResponse, provi
 			tokensUsed = int64(*result.Transcribe.Usage.TotalTokens)
 		}
 	}
+	// The reported usage of a stream replaces the estimates its chunks were charged
+	if hasUsageData {
+		tokensUsed -= chargedTokens
+	}
 
 	cost := 0.0
 	if !isStreaming || (isStreaming && isFinalChunk) {
@@ -501,3 +555,13 @@ func (p *GovernancePlugin) postHookWorker(result *schemas.BifrostResponse, provi
 func (p *GovernancePlugin) GetGovernanceStore() *GovernanceStore {
 	return p.store
 }
+
+// ShadowReport returns the requests governance rules blocked and the ones rules in shadow mode would have blocked
+func (p *GovernancePlugin) ShadowReport() ShadowReport {
+	return p.shadow.report()
+}
+
+// ResetShadowReport starts a new shadow report, e.g. after tuning rules
+func (p *GovernancePlugin) ResetShadowReport() {
+	p.shadow.reset()
+}
@@ -0,0 +1,97 @@
+package governance
+
+import (
+	"context"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// QuotaWindow is what is left of the tightest limit of one kind (requests, tokens or dollars) across the
+// hierarchy of a virtual key.
+type QuotaWindow struct {
+	Limit     float64       // Limit of the current window
+	Remaining float64       // Quota left in the current window, never negative
+	Reset     time.Duration // Time until the window resets
+	Level     string        // Level of the hierarchy the limit is set on: VK, Project, Team or Customer
+}
+
+// Quota is what is left of the request, token and budget limits of a virtual key, nil for the kinds without
+// limits. Limits in shadow mode are left out, since they never block requests.
+type Quota struct {
+	Requests *QuotaWindow
+	Tokens   *QuotaWindow
+	Budget   *QuotaWindow
+}
+
+// QuotaOfVirtualKey returns the quota left to a virtual key at now: for each kind, the limit of its hierarchy with
+// the least quota left. Windows past their reset are reported as reset (lock-free).
+func (gs *GovernanceStore) QuotaOfVirtualKey(vk *configstore.TableVirtualKey, now time.Time) Quota {
+	var quota Quota
+	rateLimits, levels := gs.CollectRateLimitsFromHierarchy(vk)
+	for i, rateLimit := range rateLimits {
+		if rateLimit.Shadow {
+			continue
+		}
+		if rateLimit.RequestMaxLimit != nil && rateLimit.RequestResetDuration != nil {
+			quota.Requests = tighterWindow(quota.Requests, rateLimitWindow(*rateLimit.RequestMaxLimit, rateLimit.RequestCurrentUsage, *rateLimit.RequestResetDuration, rateLimit.RequestLastReset, levels[i], now))
+		}
+		if rateLimit.TokenMaxLimit != nil && rateLimit.TokenResetDuration != nil {
+			quota.Tokens = tighterWindow(quota.Tokens, rateLimitWindow(*rateLimit.TokenMaxLimit, rateLimit.TokenCurrentUsage, *rateLimit.TokenResetDuration, rateLimit.TokenLastReset, levels[i], now))
+		}
+	}
+
+	budgets, budgetLevels := gs.collectBudgetsFromHierarchy(context.Background(), vk)
+	for i, budget := range budgets {
+		if budget.Shadow {
+			continue
+		}
+		current := *budget
+		if current.ResetDue(now) {
+			current.Reset(now)
+		}
+		next, err := current.NextReset()
+		if err != nil {
+			continue
+		}
+		quota.Budget = tighterWindow(quota.Budget, &QuotaWindow{
+			Limit:     current.EffectiveLimit(),
+			Remaining: max(current.EffectiveLimit()-current.CurrentUsage, 0),
+			Reset:     max(next.Sub(now), 0),
+			Level:     budgetLevels[i],
+		})
+	}
+	return quota
+}
+
+// rateLimitWindow returns the window of a request or token limit, nil when its reset duration is invalid.
+func rateLimitWindow(limit, usage int64, resetDuration string, lastReset time.Time, level string, now time.Time) *QuotaWindow {
+	duration, err := configstore.ParseDuration(resetDuration)
+	if err != nil {
+		return nil
+	}
+	reset := lastReset.Add(duration).Sub(now)
+	if reset <= 0 {
+		// Expired but not reset yet, the next request starts a new window
+		usage, reset = 0, duration
+	}
+	return &QuotaWindow{
+		Limit:     float64(limit),
+		Remaining: float64(max(limit-usage, 0)),
+		Reset:     reset,
+		Level:     level,
+	}
+}
+
+// tighterWindow returns the window with the least quota left, the one resetting last on ties.
+func tighterWindow(current, candidate *QuotaWindow) *QuotaWindow {
+	switch {
+	case candidate == nil:
+		return current
+	case current == nil,
+		candidate.Remaining < current.Remaining,
+		candidate.Remaining == current.Remaining && candidate.Reset > current.Reset:
+		return candidate
+	}
+	return current
+}
@@ -24,6 +24,8 @@ const (
 	DecisionRequestLimited     Decision = "request_limited"
 	DecisionModelBlocked       Decision = "model_blocked"
 	DecisionProviderBlocked    Decision = "provider_blocked"
+
+	DecisionParameterGuardrailViolated Decision = "parameter_guardrail_violated" // Rejected by a hard parameter guardrail of the virtual key
 )
 
 // EvaluationRequest contains the context for evaluating a request
@@ -33,6 +35,7 @@ type EvaluationRequest struct {
 	Model      string                `json:"model"`
 	Headers    map[string]string     `json:"headers"`
 	RequestID  string                `json:"request_id"`
+	Labels     map[string]string     `json:"labels,omitempty"` // Labels attributing the request
 }
 
 // EvaluationResult contains the complete result of governance evaluation
@@ -43,6 +46,9 @@ type EvaluationResult struct {
 	RateLimitInfo *configstore.TableRateLimit  `json:"rate_limit_info,omitempty"`
 	BudgetInfo    []*configstore.TableBudget   `json:"budget_info,omitempty"` // All budgets in hierarchy
 	UsageInfo     *UsageInfo                   `json:"usage_info,omitempty"`
+
+	Violation        *RuleViolation  `json:"violation,omitempty"`         // Budget or rate limit that blocked the request
+	ShadowViolations []RuleViolation `json:"shadow_violations,omitempty"` // Rules in shadow mode that would have blocked it
 }
 
 // UsageInfo represents current usage levels for rate limits and budgets
@@ -111,13 +117,18 @@ func (r *BudgetResolver) EvaluateRequest(ctx *context.Context, evaluationRequest
 		}
 	}
 
-	// 4. Check rate limits (VK level only)
-	if rateLimitResult := r.checkRateLimits(vk); rateLimitResult != nil {
+	// 4. Check rate limits hierarchy (VK → Project → Team → Customer)
+	rateLimitResult, shadowViolations := r.checkRateLimits(vk)
+	if rateLimitResult != nil {
+		rateLimitResult.ShadowViolations = shadowViolations
 		return rateLimitResult
 	}
 
-	// 5. Check budget hierarchy (VK → Team → Customer)
-	if budgetResult := r.checkBudgetHierarchy(*ctx, vk); budgetResult != nil {
+	// 5. Check budget hierarchy (VK → Project → Team → Customer)
+	budgetResult, budgetShadowViolations := r.checkBudgetHierarchy(*ctx, vk)
+	shadowViolations = append(shadowViolations, budgetShadowViolations...)
+	if budgetResult != nil {
+		budgetResult.ShadowViolations = shadowViolations
 		return budgetResult
 	}
 
@@ -134,9 +145,10 @@ func (r *BudgetResolver) EvaluateRequest(ctx *context.Context, evaluationRequest
 
 	// All checks passed
 	return &EvaluationResult{
-		Decision:   DecisionAllow,
-		Reason:     "Request allowed by governance policy",
-		VirtualKey: vk,
+		Decision:         DecisionAllow,
+		Reason:           "Request allowed by governance policy",
+		VirtualKey:       vk,
+		ShadowViolations: shadowViolations,
 	}
 }
 
@@ -175,15 +187,28 @@ func (r *BudgetResolver) isProviderAllowed(vk *configstore.TableVirtualKey, prov
 	return false
 }
 
-// checkRateLimits checks the VK's rate limits using flexible approach
-func (r *BudgetResolver) checkRateLimits(vk *configstore.TableVirtualKey) *EvaluationResult {
-	// No rate limits defined
-	if vk.RateLimit == nil {
-		return nil
+// checkRateLimits checks the rate limits of every level of the VK's hierarchy using flexible approach. Exceeded rate
+// limits in shadow mode are returned instead of blocking the request.
+func (r *BudgetResolver) checkRateLimits(vk *configstore.TableVirtualKey) (*EvaluationResult, []RuleViolation) {
+	var shadowViolations []RuleViolation
+	rateLimits, levelNames := r.store.CollectRateLimitsFromHierarchy(vk)
+	for i, rateLimit := range rateLimits {
+		result := r.checkRateLimit(vk, rateLimit, levelNames[i])
+		if result == nil {
+			continue
+		}
+		if rateLimit.Shadow {
+			shadowViolations = append(shadowViolations, *result.Violation)
+			continue
+		}
+		return result, shadowViolations
 	}
 
-	rateLimit := vk.RateLimit
+	return nil, shadowViolations // No rate limit violations
+}
 
+// checkRateLimit checks one rate limit of the VK's hierarchy, set at the named level
+func (r *BudgetResolver) checkRateLimit(vk *configstore.TableVirtualKey, rateLimit *configstore.TableRateLimit, level string) *EvaluationResult {
 	// Check if any rate limits are exceeded
 	var violations []string
 
@@ -218,29 +243,45 @@ func (r *BudgetResolver) checkRateLimits(vk *configstore.TableVirtualKey) *Evalu
 			}
 		}
 
+		reason := fmt.Sprintf("%s rate limits exceeded: %v", level, violations)
 		return &EvaluationResult{
 			Decision:      decision,
-			Reason:        fmt.Sprintf("Rate limits exceeded: %v", violations),
+			Reason:        reason,
 			VirtualKey:    vk,
 			RateLimitInfo: rateLimit,
+			Violation: &RuleViolation{
+				Kind:   RuleKindRateLimit,
+				ID:     rateLimit.ID,
+				Level:  level,
+				Shadow: rateLimit.Shadow,
+				Reason: reason,
+			},
 		}
 	}
 
-	return nil // No rate limit violations
+	return nil
 }
 
-// checkBudgetHierarchy checks the budget hierarchy atomically (VK → Team → Customer)
-func (r *BudgetResolver) checkBudgetHierarchy(ctx context.Context, vk *configstore.TableVirtualKey) *EvaluationResult {
+// checkBudgetHierarchy checks the budget hierarchy atomically (VK → Project → Team → Customer). Exceeded budgets in
+// shadow mode are returned instead of blocking the request.
+func (r *BudgetResolver) checkBudgetHierarchy(ctx context.Context, vk *configstore.TableVirtualKey) (*EvaluationResult, []RuleViolation) {
 	// Use atomic budget checking to prevent race conditions
-	if err := r.store.CheckBudget(ctx, vk); err != nil {
-		r.logger.Debug(fmt.Sprintf("Atomic budget check failed for VK %s: %s", vk.ID, err.Error()))
+	violations, err := r.store.CheckBudget(ctx, vk)
+	if err != nil {
+		r.logger.Debug("Atomic budget check failed for VK %s: %s", vk.ID, err.Error())
 
-		return &EvaluationResult{
+		result := &EvaluationResult{
 			Decision:   DecisionBudgetExceeded,
 			Reason:     fmt.Sprintf("Budget check failed: %s", err.Error()),
 			VirtualKey: vk,
 		}
+		if len(violations) > 0 {
+			// The last violation is the budget failing the check
+			result.Violation = &violations[len(violations)-1]
+			violations = violations[:len(violations)-1]
+		}
+		return result, violations
 	}
 
-	return nil // No budget violations
+	return nil, violations // No budget violations besides the shadow ones
 }
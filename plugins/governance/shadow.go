@@ -0,0 +1,166 @@
+// Package governance provides the shadow mode of governance rules and its report
+package governance
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// RuleKind is the kind of a governance rule
+type RuleKind string
+
+const (
+	RuleKindBudget              RuleKind = "budget"
+	RuleKindRateLimit           RuleKind = "rate_limit"
+	RuleKindParameterGuardrails RuleKind = "parameter_guardrails"
+)
+
+// RuleViolation is a budget, rate limit or parameter guardrails a request violated
+type RuleViolation struct {
+	Kind   RuleKind `json:"kind"`
+	ID     string   `json:"id"`    // ID of the budget or rate limit, or of the virtual key of the guardrails
+	Level  string   `json:"level"` // Level of the hierarchy the rule is set at
+	Shadow bool     `json:"shadow"`
+	Reason string   `json:"reason"`
+}
+
+// ShadowReport compares the requests governance rules blocked with the ones rules in shadow mode would have blocked,
+// so that policies can be tuned before they are enforced
+type ShadowReport struct {
+	Since         time.Time         `json:"since"`          // Start of the report, at startup or its last reset
+	Evaluated     int64             `json:"evaluated"`      // Requests with a virtual key evaluated by governance
+	Blocked       int64             `json:"blocked"`        // Requests blocked by enforced rules
+	ShadowBlocked int64             `json:"shadow_blocked"` // Requests let through that shadow rules would have blocked
+	Rules         []RuleShadowStats `json:"rules"`          // By kind, level and ID
+}
+
+// RuleShadowStats are the requests one rule blocked, or would have blocked in shadow mode
+type RuleShadowStats struct {
+	Kind          RuleKind          `json:"kind"`
+	ID            string            `json:"id"`
+	Level         string            `json:"level"`
+	Shadow        bool              `json:"shadow"`
+	Blocked       int64             `json:"blocked"`
+	LastReason    string            `json:"last_reason"`
+	LastLabels    map[string]string `json:"last_labels,omitempty"` // Labels of the last request blocked
+	LastBlockedAt time.Time         `json:"last_blocked_at"`
+}
+
+// shadowRuleKey identifies a rule in the shadow report
+type shadowRuleKey struct {
+	kind   RuleKind
+	id     string
+	shadow bool
+}
+
+// shadowRecorder accumulates the shadow report of the governance plugin
+type shadowRecorder struct {
+	mu            sync.Mutex
+	since         time.Time
+	evaluated     int64
+	blocked       int64
+	shadowBlocked int64
+	rules         map[shadowRuleKey]*RuleShadowStats
+}
+
+func newShadowRecorder() *shadowRecorder {
+	return &shadowRecorder{since: time.Now(), rules: make(map[shadowRuleKey]*RuleShadowStats)}
+}
+
+// record adds the outcome of the evaluation of a request: the enforced rule that blocked it, if any, and the shadow
+// rules it violated, with the labels of the request.
+func (r *shadowRecorder) record(enforced *RuleViolation, shadow []RuleViolation, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.evaluated++
+	if enforced != nil {
+		r.blocked++
+		r.add(*enforced, labels, now)
+	} else if len(shadow) > 0 {
+		r.shadowBlocked++
+	}
+	for _, violation := range shadow {
+		r.add(violation, labels, now)
+	}
+}
+
+// add counts a violation of a rule. Callers must hold the lock.
+func (r *shadowRecorder) add(violation RuleViolation, labels map[string]string, now time.Time) {
+	key := shadowRuleKey{kind: violation.Kind, id: violation.ID, shadow: violation.Shadow}
+	stats, ok := r.rules[key]
+	if !ok {
+		stats = &RuleShadowStats{Kind: violation.Kind, ID: violation.ID, Level: violation.Level, Shadow: violation.Shadow}
+		r.rules[key] = stats
+	}
+	stats.Blocked++
+	stats.LastReason = violation.Reason
+	stats.LastLabels = labels
+	stats.LastBlockedAt = now
+}
+
+// report returns a snapshot of the report.
+func (r *shadowRecorder) report() ShadowReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := ShadowReport{
+		Since:         r.since,
+		Evaluated:     r.evaluated,
+		Blocked:       r.blocked,
+		ShadowBlocked: r.shadowBlocked,
+		Rules:         make([]RuleShadowStats, 0, len(r.rules)),
+	}
+	for _, stats := range r.rules {
+		report.Rules = append(report.Rules, *stats)
+	}
+	slices.SortFunc(report.Rules, func(a, b RuleShadowStats) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Level, b.Level), cmp.Compare(a.ID, b.ID))
+	})
+	return report
+}
+
+// reset empties the report.
+func (r *shadowRecorder) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.since = time.Now()
+	r.evaluated, r.blocked, r.shadowBlocked = 0, 0, 0
+	r.rules = make(map[shadowRuleKey]*RuleShadowStats)
+}
+
+// shadowCopy returns a copy of a request that parameter guardrails can be applied to without changing the request.
+func shadowCopy(req *schemas.BifrostRequest) *schemas.BifrostRequest {
+	clone := *req
+	switch {
+	case req.ChatRequest != nil:
+		chat := *req.ChatRequest
+		if chat.Params != nil {
+			params := *chat.Params
+			chat.Params = &params
+		}
+		clone.ChatRequest = &chat
+	case req.ResponsesRequest != nil:
+		responses := *req.ResponsesRequest
+		if responses.Params != nil {
+			params := *responses.Params
+			if params.Text != nil {
+				text := *params.Text
+				params.Text = &text
+			}
+			responses.Params = &params
+		}
+		clone.ResponsesRequest = &responses
+	case req.TextCompletionRequest != nil:
+		text := *req.TextCompletionRequest
+		if text.Params != nil {
+			params := *text.Params
+			text.Params = &params
+		}
+		clone.TextCompletionRequest = &text
+	}
+	return &clone
+}
@@ -3,6 +3,7 @@ package governance
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -18,12 +19,19 @@ type GovernanceStore struct {
 	// Core data maps using sync.Map for lock-free reads
 	virtualKeys sync.Map // string -> *VirtualKey (VK value -> VirtualKey with preloaded relationships)
 	teams       sync.Map // string -> *Team (Team ID -> Team)
+	projects    sync.Map // string -> *Project (Project ID -> Project)
 	customers   sync.Map // string -> *Customer (Customer ID -> Customer)
 	budgets     sync.Map // string -> *Budget (Budget ID -> Budget)
 
+	// Counters of the rate limits are updated in place, under the lock of their rate limit
+	rateLimitLocks sync.Map // string -> *sync.Mutex (Rate limit ID -> lock)
+
 	// Config store for refresh operations
 	configStore configstore.ConfigStore
 
+	// Budget consumption alerts (nil when alerts are off)
+	alerter *budgetAlerter
+
 	// Logger
 	logger schemas.Logger
 }
@@ -80,36 +88,45 @@ func (gs *GovernanceStore) GetAllBudgets() map[string]*configstore.TableBudget {
 	return result
 }
 
-// CheckBudget performs budget checking using in-memory store data (lock-free for high performance)
-func (gs *GovernanceStore) CheckBudget(ctx context.Context, vk *configstore.TableVirtualKey) error {
+// CheckBudget performs budget checking using in-memory store data (lock-free for high performance). It returns the
+// exceeded budgets of the hierarchy up to the first one not in shadow mode, which fails the check.
+func (gs *GovernanceStore) CheckBudget(ctx context.Context, vk *configstore.TableVirtualKey) ([]RuleViolation, error) {
 	if vk == nil {
-		return fmt.Errorf("virtual key cannot be nil")
+		return nil, fmt.Errorf("virtual key cannot be nil")
 	}
 
 	// Use helper to collect budgets and their names (lock-free)
 	budgetsToCheck, budgetNames := gs.collectBudgetsFromHierarchy(ctx, vk)
 
 	// Check each budget in hierarchy order using in-memory data
+	now := time.Now()
+	var violations []RuleViolation
 	for i, budget := range budgetsToCheck {
 		// Check if budget needs reset (in-memory check)
-		if budget.ResetDuration != "" {
-			if duration, err := configstore.ParseDuration(budget.ResetDuration); err == nil {
-				if time.Since(budget.LastReset).Round(time.Millisecond) >= duration {
-					// Budget expired but hasn't been reset yet - treat as reset
-					// Note: actual reset will happen in post-hook via AtomicBudgetUpdate
-					continue // Skip budget check for expired budgets
-				}
-			}
+		if budget.ResetDue(now) {
+			// Budget expired but hasn't been reset yet - treat as reset
+			// Note: actual reset will happen in post-hook via AtomicBudgetUpdate
+			continue // Skip budget check for expired budgets
 		}
 
-		// Check if current usage exceeds budget limit
-		if budget.CurrentUsage > budget.MaxLimit {
-			return fmt.Errorf("%s budget exceeded: %.4f > %.4f dollars",
-				budgetNames[i], budget.CurrentUsage, budget.MaxLimit)
+		// Check if current usage exceeds budget limit, including the quota rolled over
+		if budget.CurrentUsage > budget.EffectiveLimit() {
+			reason := fmt.Sprintf("%s budget exceeded: %.4f > %.4f dollars",
+				budgetNames[i], budget.CurrentUsage, budget.EffectiveLimit())
+			violations = append(violations, RuleViolation{
+				Kind:   RuleKindBudget,
+				ID:     budget.ID,
+				Level:  budgetNames[i],
+				Shadow: budget.Shadow,
+				Reason: reason,
+			})
+			if !budget.Shadow {
+				return violations, errors.New(reason)
+			}
 		}
 	}
 
-	return nil
+	return violations, nil
 }
 
 // UpdateBudget performs atomic budget updates across the hierarchy (both in memory and in database)
@@ -120,23 +137,33 @@ func (gs *GovernanceStore) UpdateBudget(ctx context.Context, vk *configstore.Tab
 
 	// Collect budget IDs using fast in-memory lookup instead of DB queries
 	budgetIDs := gs.collectBudgetIDsFromMemory(ctx, vk)
+	var alerts []*BudgetAlert
 
 	if gs.configStore == nil {
+		now := time.Now()
 		for _, budgetID := range budgetIDs {
 			// Update in-memory cache for next read (lock-free)
 			if cachedBudgetValue, exists := gs.budgets.Load(budgetID); exists && cachedBudgetValue != nil {
 				if cachedBudget, ok := cachedBudgetValue.(*configstore.TableBudget); ok && cachedBudget != nil {
 					clone := *cachedBudget
+					// Reset expired budgets before charging them, as resetBudgetIfNeeded does for persisted ones
+					if clone.ResetDue(now) {
+						clone.Reset(now)
+					}
 					clone.CurrentUsage += cost
+					if alert := gs.alerter.check(&clone, vk.ID); alert != nil {
+						alerts = append(alerts, alert)
+					}
 					gs.budgets.Store(budgetID, &clone)
 				}
 			}
 		}
 
+		gs.alerter.send(alerts)
 		return nil
 	}
 
-	return gs.configStore.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+	err := gs.configStore.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
 		// budgetIDs already collected from in-memory data - no need to duplicate
 
 		// Update each budget atomically
@@ -151,8 +178,11 @@ func (gs *GovernanceStore) UpdateBudget(ctx context.Context, vk *configstore.Tab
 				return fmt.Errorf("failed to reset budget: %w", err)
 			}
 
-			// Update usage
+			// Update usage, recording crossed alert thresholds with it so every replica alerts once
 			budget.CurrentUsage += cost
+			if alert := gs.alerter.check(&budget, vk.ID); alert != nil {
+				alerts = append(alerts, alert)
+			}
 			if err := gs.configStore.UpdateBudget(ctx, &budget, tx); err != nil {
 				return fmt.Errorf("failed to save budget %s: %w", budgetID, err)
 			}
@@ -163,6 +193,8 @@ func (gs *GovernanceStore) UpdateBudget(ctx context.Context, vk *configstore.Tab
 					clone := *cachedBudget
 					clone.CurrentUsage += cost
 					clone.LastReset = budget.LastReset
+					clone.CarriedOver = budget.CarriedOver
+					clone.AlertThreshold = budget.AlertThreshold
 					gs.budgets.Store(budgetID, &clone)
 				}
 			}
@@ -170,9 +202,14 @@ func (gs *GovernanceStore) UpdateBudget(ctx context.Context, vk *configstore.Tab
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	gs.alerter.send(alerts)
+	return nil
 }
 
-// UpdateRateLimitUsage updates rate limit counters (lock-free)
+// UpdateRateLimitUsage updates the rate limit counters of every level of the hierarchy of a virtual key (lock-free)
 func (gs *GovernanceStore) UpdateRateLimitUsage(ctx context.Context, vkValue string, tokensUsed int64, shouldUpdateTokens bool, shouldUpdateRequests bool) error {
 	if vkValue == "" {
 		return fmt.Errorf("virtual key value cannot be empty")
@@ -187,50 +224,41 @@ func (gs *GovernanceStore) UpdateRateLimitUsage(ctx context.Context, vkValue str
 	if !ok || vk == nil {
 		return fmt.Errorf("invalid virtual key type for: %s", vkValue)
 	}
-	if vk.RateLimit == nil {
-		return nil // No rate limit configured, nothing to update
-	}
 
-	rateLimit := vk.RateLimit
 	now := time.Now()
-	updated := false
-
-	// Check and reset token counter if needed
-	if rateLimit.TokenResetDuration != nil {
-		if duration, err := configstore.ParseDuration(*rateLimit.TokenResetDuration); err == nil {
-			if now.Sub(rateLimit.TokenLastReset) >= duration {
-				rateLimit.TokenCurrentUsage = 0
-				rateLimit.TokenLastReset = now
-				updated = true
-			}
+	var updatedRateLimits []*configstore.TableRateLimit
+	for _, level := range gs.collectHierarchy(vk) {
+		rateLimit := level.rateLimit
+		if rateLimit == nil {
+			continue // No rate limit configured at this level
 		}
-	}
 
-	// Check and reset request counter if needed
-	if rateLimit.RequestResetDuration != nil {
-		if duration, err := configstore.ParseDuration(*rateLimit.RequestResetDuration); err == nil {
-			if now.Sub(rateLimit.RequestLastReset) >= duration {
-				rateLimit.RequestCurrentUsage = 0
-				rateLimit.RequestLastReset = now
-				updated = true
-			}
+		unlock := gs.lockRateLimit(rateLimit)
+		// Check and reset counters if needed
+		updated := gs.checkAndResetSingleRateLimit(ctx, rateLimit, now)
+
+		// Update usage counters based on flags. Negative usage gives back tokens charged from estimates.
+		if shouldUpdateTokens && tokensUsed != 0 {
+			rateLimit.TokenCurrentUsage = max(rateLimit.TokenCurrentUsage+tokensUsed, 0)
+			updated = true
 		}
-	}
 
-	// Update usage counters based on flags
-	if shouldUpdateTokens && tokensUsed > 0 {
-		rateLimit.TokenCurrentUsage += tokensUsed
-		updated = true
-	}
+		if shouldUpdateRequests {
+			rateLimit.RequestCurrentUsage += 1
+			updated = true
+		}
 
-	if shouldUpdateRequests {
-		rateLimit.RequestCurrentUsage += 1
-		updated = true
+		if updated {
+			// A copy is persisted, as the counters keep changing
+			snapshot := *rateLimit
+			updatedRateLimits = append(updatedRateLimits, &snapshot)
+		}
+		unlock()
 	}
 
 	// Save to database only if something changed
-	if updated && gs.configStore != nil {
-		if err := gs.configStore.UpdateRateLimit(ctx, rateLimit); err != nil {
+	if len(updatedRateLimits) > 0 && gs.configStore != nil {
+		if err := gs.configStore.UpdateRateLimits(ctx, updatedRateLimits); err != nil {
 			return fmt.Errorf("failed to update rate limit usage: %w", err)
 		}
 	}
@@ -238,7 +266,40 @@ func (gs *GovernanceStore) UpdateRateLimitUsage(ctx context.Context, vkValue str
 	return nil
 }
 
-// checkAndResetSingleRateLimit checks and resets a single rate limit's counters if expired
+// AddStreamedTokens charges tokens streamed so far to the in-memory rate limits of the hierarchy of a virtual key,
+// leaving their persistence to the usage update at the end of the stream. It returns a copy of the first token
+// limit not in shadow mode the usage is over with the name of its level, or nil when there is none.
+func (gs *GovernanceStore) AddStreamedTokens(vk *configstore.TableVirtualKey, tokens int64) (*configstore.TableRateLimit, string) {
+	now := time.Now()
+	var exceeded *configstore.TableRateLimit
+	var exceededLevel string
+	for _, level := range gs.collectHierarchy(vk) {
+		rateLimit := level.rateLimit
+		if rateLimit == nil {
+			continue
+		}
+		unlock := gs.lockRateLimit(rateLimit)
+		gs.checkAndResetSingleRateLimit(context.Background(), rateLimit, now)
+		rateLimit.TokenCurrentUsage = max(rateLimit.TokenCurrentUsage+tokens, 0)
+		if exceeded == nil && !rateLimit.Shadow && rateLimit.TokenMaxLimit != nil && rateLimit.TokenCurrentUsage > *rateLimit.TokenMaxLimit {
+			snapshot := *rateLimit
+			exceeded, exceededLevel = &snapshot, level.name
+		}
+		unlock()
+	}
+	return exceeded, exceededLevel
+}
+
+// lockRateLimit locks the counters of a rate limit and returns the function unlocking them
+func (gs *GovernanceStore) lockRateLimit(rateLimit *configstore.TableRateLimit) func() {
+	lock, _ := gs.rateLimitLocks.LoadOrStore(rateLimit.ID, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// checkAndResetSingleRateLimit checks and resets a single rate limit's counters if expired. The caller holds the
+// lock of the rate limit.
 func (gs *GovernanceStore) checkAndResetSingleRateLimit(ctx context.Context, rateLimit *configstore.TableRateLimit, now time.Time) bool {
 	updated := false
 
@@ -272,18 +333,41 @@ func (gs *GovernanceStore) ResetExpiredRateLimits(ctx context.Context) error {
 	now := time.Now()
 	var resetRateLimits []*configstore.TableRateLimit
 
-	gs.virtualKeys.Range(func(key, value interface{}) bool {
-		// Type-safe conversion
-		vk, ok := value.(*configstore.TableVirtualKey)
-		if !ok || vk == nil || vk.RateLimit == nil {
-			return true // continue
+	// Rate limits can be set at every level of the hierarchy (VK, Project, Team, Customer)
+	checkRateLimit := func(rateLimit *configstore.TableRateLimit) {
+		if rateLimit == nil {
+			return
 		}
-
-		rateLimit := vk.RateLimit
-
+		unlock := gs.lockRateLimit(rateLimit)
+		defer unlock()
 		// Use helper method to check and reset rate limit
 		if gs.checkAndResetSingleRateLimit(ctx, rateLimit, now) {
-			resetRateLimits = append(resetRateLimits, rateLimit)
+			snapshot := *rateLimit
+			resetRateLimits = append(resetRateLimits, &snapshot)
+		}
+	}
+	gs.virtualKeys.Range(func(key, value interface{}) bool {
+		// Type-safe conversion
+		if vk, ok := value.(*configstore.TableVirtualKey); ok && vk != nil {
+			checkRateLimit(vk.RateLimit)
+		}
+		return true // continue
+	})
+	gs.projects.Range(func(key, value interface{}) bool {
+		if project, ok := value.(*configstore.TableProject); ok && project != nil {
+			checkRateLimit(project.RateLimit)
+		}
+		return true // continue
+	})
+	gs.teams.Range(func(key, value interface{}) bool {
+		if team, ok := value.(*configstore.TableTeam); ok && team != nil {
+			checkRateLimit(team.RateLimit)
+		}
+		return true // continue
+	})
+	gs.customers.Range(func(key, value interface{}) bool {
+		if customer, ok := value.(*configstore.TableCustomer); ok && customer != nil {
+			checkRateLimit(customer.RateLimit)
 		}
 		return true // continue
 	})
@@ -310,20 +394,18 @@ func (gs *GovernanceStore) ResetExpiredBudgets(ctx context.Context) error {
 			return true // continue
 		}
 
-		duration, err := configstore.ParseDuration(budget.ResetDuration)
-		if err != nil {
-			gs.logger.Error("invalid budget reset duration %s: %w", budget.ResetDuration, err)
+		if _, err := budget.NextReset(); err != nil {
+			gs.logger.Error("invalid budget reset schedule: %v", err)
 			return true // continue
 		}
 
-		if now.Sub(budget.LastReset) >= duration {
+		if budget.ResetDue(now) {
 			oldUsage := budget.CurrentUsage
-			budget.CurrentUsage = 0
-			budget.LastReset = now
+			budget.Reset(now)
 			resetBudgets = append(resetBudgets, budget)
 
-			gs.logger.Debug(fmt.Sprintf("Reset budget %s (was %.2f, reset to 0)",
-				budget.ID, oldUsage))
+			gs.logger.Debug("Reset budget %s (was %.2f, reset to 0, %.2f carried over)",
+				budget.ID, oldUsage, budget.CarriedOver)
 		}
 		return true // continue
 	})
@@ -354,6 +436,12 @@ func (gs *GovernanceStore) loadFromDatabase(ctx context.Context) error {
 		return fmt.Errorf("failed to load teams: %w", err)
 	}
 
+	// Load projects with their budgets
+	projects, err := gs.configStore.GetProjects(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed to load projects: %w", err)
+	}
+
 	// Load virtual keys with all relationships
 	virtualKeys, err := gs.configStore.GetVirtualKeys(ctx)
 	if err != nil {
@@ -367,7 +455,7 @@ func (gs *GovernanceStore) loadFromDatabase(ctx context.Context) error {
 	}
 
 	// Rebuild in-memory structures (lock-free)
-	gs.rebuildInMemoryStructures(ctx, customers, teams, virtualKeys, budgets)
+	gs.rebuildInMemoryStructures(ctx, customers, teams, projects, virtualKeys, budgets)
 
 	return nil
 }
@@ -384,6 +472,9 @@ func (gs *GovernanceStore) loadFromConfigMemory(ctx context.Context, config *con
 	// Load teams with their budgets
 	teams := config.Teams
 
+	// Load projects with their budgets
+	projects := config.Projects
+
 	// Load budgets
 	budgets := config.Budgets
 
@@ -393,10 +484,35 @@ func (gs *GovernanceStore) loadFromConfigMemory(ctx context.Context, config *con
 	// Load rate limits
 	rateLimits := config.RateLimits
 
+	// Populate the rate limits of projects, teams and customers
+	findRateLimit := func(id *string) *configstore.TableRateLimit {
+		for i := range rateLimits {
+			if id != nil && rateLimits[i].ID == *id {
+				return &rateLimits[i]
+			}
+		}
+		return nil
+	}
+	for i := range projects {
+		projects[i].RateLimit = findRateLimit(projects[i].RateLimitID)
+	}
+	for i := range teams {
+		teams[i].RateLimit = findRateLimit(teams[i].RateLimitID)
+	}
+	for i := range customers {
+		customers[i].RateLimit = findRateLimit(customers[i].RateLimitID)
+	}
+
 	// Populate virtual keys with their relationships
 	for i := range virtualKeys {
 		vk := &virtualKeys[i]
 
+		for i := range projects {
+			if vk.ProjectID != nil && projects[i].ID == *vk.ProjectID {
+				vk.Project = &projects[i]
+			}
+		}
+
 		for i := range teams {
 			if vk.TeamID != nil && teams[i].ID == *vk.TeamID {
 				vk.Team = &teams[i]
@@ -425,16 +541,17 @@ func (gs *GovernanceStore) loadFromConfigMemory(ctx context.Context, config *con
 	}
 
 	// Rebuild in-memory structures (lock-free)
-	gs.rebuildInMemoryStructures(ctx, customers, teams, virtualKeys, budgets)
+	gs.rebuildInMemoryStructures(ctx, customers, teams, projects, virtualKeys, budgets)
 
 	return nil
 }
 
 // rebuildInMemoryStructures rebuilds all in-memory data structures (lock-free)
-func (gs *GovernanceStore) rebuildInMemoryStructures(ctx context.Context, customers []configstore.TableCustomer, teams []configstore.TableTeam, virtualKeys []configstore.TableVirtualKey, budgets []configstore.TableBudget) {
+func (gs *GovernanceStore) rebuildInMemoryStructures(ctx context.Context, customers []configstore.TableCustomer, teams []configstore.TableTeam, projects []configstore.TableProject, virtualKeys []configstore.TableVirtualKey, budgets []configstore.TableBudget) {
 	// Clear existing data by creating new sync.Maps
 	gs.virtualKeys = sync.Map{}
 	gs.teams = sync.Map{}
+	gs.projects = sync.Map{}
 	gs.customers = sync.Map{}
 	gs.budgets = sync.Map{}
 
@@ -450,6 +567,12 @@ func (gs *GovernanceStore) rebuildInMemoryStructures(ctx context.Context, custom
 		gs.teams.Store(team.ID, team)
 	}
 
+	// Build projects map
+	for i := range projects {
+		project := &projects[i]
+		gs.projects.Store(project.ID, project)
+	}
+
 	// Build budgets map
 	for i := range budgets {
 		budget := &budgets[i]
@@ -465,67 +588,69 @@ func (gs *GovernanceStore) rebuildInMemoryStructures(ctx context.Context, custom
 
 // UTILITY FUNCTIONS
 
-// collectBudgetsFromHierarchy collects budgets and their metadata from the hierarchy (VK → Team → Customer)
-func (gs *GovernanceStore) collectBudgetsFromHierarchy(ctx context.Context, vk *configstore.TableVirtualKey) ([]*configstore.TableBudget, []string) {
+// hierarchyLevel is one level of the governance hierarchy of a virtual key
+type hierarchyLevel struct {
+	name      string // VK, Project, Team or Customer
+	id        string
+	budgetID  *string
+	rateLimit *configstore.TableRateLimit
+}
+
+// collectHierarchy collects the levels of the hierarchy of a virtual key, from the key up (VK → Project → Team → Customer).
+// Limits at every level apply to the combined usage below it, so a level without its own limits inherits those of its
+// ancestors and a level with its own is held to the tightest of them.
+func (gs *GovernanceStore) collectHierarchy(vk *configstore.TableVirtualKey) []hierarchyLevel {
 	if vk == nil {
-		return nil, nil
+		return nil
 	}
 
-	var budgets []*configstore.TableBudget
-	var budgetNames []string
+	levels := []hierarchyLevel{{name: "VK", id: vk.ID, budgetID: vk.BudgetID, rateLimit: vk.RateLimit}}
+	teamID, customerID := vk.TeamID, vk.CustomerID
 
-	// Collect all budgets in hierarchy order using lock-free sync.Map access (VK → Team → Customer)
-	if vk.BudgetID != nil {
-		if budgetValue, exists := gs.budgets.Load(*vk.BudgetID); exists && budgetValue != nil {
-			if budget, ok := budgetValue.(*configstore.TableBudget); ok && budget != nil {
-				budgets = append(budgets, budget)
-				budgetNames = append(budgetNames, "VK")
+	// Lock-free sync.Map access for the levels above the VK
+	if vk.ProjectID != nil {
+		if projectValue, exists := gs.projects.Load(*vk.ProjectID); exists && projectValue != nil {
+			if project, ok := projectValue.(*configstore.TableProject); ok && project != nil {
+				levels = append(levels, hierarchyLevel{name: "Project", id: project.ID, budgetID: project.BudgetID, rateLimit: project.RateLimit})
+				teamID = project.TeamID
 			}
 		}
 	}
 
-	if vk.TeamID != nil {
-		if teamValue, exists := gs.teams.Load(*vk.TeamID); exists && teamValue != nil {
+	if teamID != nil {
+		if teamValue, exists := gs.teams.Load(*teamID); exists && teamValue != nil {
 			if team, ok := teamValue.(*configstore.TableTeam); ok && team != nil {
-				if team.BudgetID != nil {
-					if budgetValue, exists := gs.budgets.Load(*team.BudgetID); exists && budgetValue != nil {
-						if budget, ok := budgetValue.(*configstore.TableBudget); ok && budget != nil {
-							budgets = append(budgets, budget)
-							budgetNames = append(budgetNames, "Team")
-						}
-					}
-				}
-
+				levels = append(levels, hierarchyLevel{name: "Team", id: team.ID, budgetID: team.BudgetID, rateLimit: team.RateLimit})
 				// Check if team belongs to a customer
-				if team.CustomerID != nil {
-					if customerValue, exists := gs.customers.Load(*team.CustomerID); exists && customerValue != nil {
-						if customer, ok := customerValue.(*configstore.TableCustomer); ok && customer != nil {
-							if customer.BudgetID != nil {
-								if budgetValue, exists := gs.budgets.Load(*customer.BudgetID); exists && budgetValue != nil {
-									if budget, ok := budgetValue.(*configstore.TableBudget); ok && budget != nil {
-										budgets = append(budgets, budget)
-										budgetNames = append(budgetNames, "Customer")
-									}
-								}
-							}
-						}
-					}
-				}
+				customerID = team.CustomerID
 			}
 		}
 	}
 
-	if vk.CustomerID != nil {
-		if customerValue, exists := gs.customers.Load(*vk.CustomerID); exists && customerValue != nil {
+	if customerID != nil {
+		if customerValue, exists := gs.customers.Load(*customerID); exists && customerValue != nil {
 			if customer, ok := customerValue.(*configstore.TableCustomer); ok && customer != nil {
-				if customer.BudgetID != nil {
-					if budgetValue, exists := gs.budgets.Load(*customer.BudgetID); exists && budgetValue != nil {
-						if budget, ok := budgetValue.(*configstore.TableBudget); ok && budget != nil {
-							budgets = append(budgets, budget)
-							budgetNames = append(budgetNames, "Customer")
-						}
-					}
-				}
+				levels = append(levels, hierarchyLevel{name: "Customer", id: customer.ID, budgetID: customer.BudgetID, rateLimit: customer.RateLimit})
+			}
+		}
+	}
+
+	return levels
+}
+
+// collectBudgetsFromHierarchy collects budgets and their metadata from the hierarchy (VK → Project → Team → Customer)
+func (gs *GovernanceStore) collectBudgetsFromHierarchy(ctx context.Context, vk *configstore.TableVirtualKey) ([]*configstore.TableBudget, []string) {
+	var budgets []*configstore.TableBudget
+	var budgetNames []string
+
+	for _, level := range gs.collectHierarchy(vk) {
+		if level.budgetID == nil {
+			continue
+		}
+		if budgetValue, exists := gs.budgets.Load(*level.budgetID); exists && budgetValue != nil {
+			if budget, ok := budgetValue.(*configstore.TableBudget); ok && budget != nil {
+				budgets = append(budgets, budget)
+				budgetNames = append(budgetNames, level.name)
 			}
 		}
 	}
@@ -533,6 +658,34 @@ func (gs *GovernanceStore) collectBudgetsFromHierarchy(ctx context.Context, vk *
 	return budgets, budgetNames
 }
 
+// CollectRateLimitsFromHierarchy collects copies of the rate limits of the hierarchy of a virtual key, with
+// consistent counters, and the names of their levels (VK → Project → Team → Customer)
+func (gs *GovernanceStore) CollectRateLimitsFromHierarchy(vk *configstore.TableVirtualKey) ([]*configstore.TableRateLimit, []string) {
+	var rateLimits []*configstore.TableRateLimit
+	var levelNames []string
+
+	for _, level := range gs.collectHierarchy(vk) {
+		if level.rateLimit != nil {
+			unlock := gs.lockRateLimit(level.rateLimit)
+			snapshot := *level.rateLimit
+			unlock()
+			rateLimits = append(rateLimits, &snapshot)
+			levelNames = append(levelNames, level.name)
+		}
+	}
+
+	return rateLimits, levelNames
+}
+
+// CustomerIDOfVirtualKey returns the ID of the customer at the top of the hierarchy of a virtual key (lock-free)
+func (gs *GovernanceStore) CustomerIDOfVirtualKey(vk *configstore.TableVirtualKey) (string, bool) {
+	levels := gs.collectHierarchy(vk)
+	if len(levels) == 0 || levels[len(levels)-1].name != "Customer" {
+		return "", false
+	}
+	return levels[len(levels)-1].id, true
+}
+
 // collectBudgetIDsFromMemory collects budget IDs from in-memory store data (lock-free)
 func (gs *GovernanceStore) collectBudgetIDsFromMemory(ctx context.Context, vk *configstore.TableVirtualKey) []string {
 	budgets, _ := gs.collectBudgetsFromHierarchy(ctx, vk)
@@ -547,15 +700,13 @@ func (gs *GovernanceStore) collectBudgetIDsFromMemory(ctx context.Context, vk *c
 
 // resetBudgetIfNeeded checks and resets budget within a transaction
 func (gs *GovernanceStore) resetBudgetIfNeeded(ctx context.Context, tx *gorm.DB, budget *configstore.TableBudget) error {
-	duration, err := configstore.ParseDuration(budget.ResetDuration)
-	if err != nil {
-		return fmt.Errorf("invalid reset duration %s: %w", budget.ResetDuration, err)
+	if _, err := budget.NextReset(); err != nil {
+		return err
 	}
 
 	now := time.Now()
-	if now.Sub(budget.LastReset) >= duration {
-		budget.CurrentUsage = 0
-		budget.LastReset = now
+	if budget.ResetDue(now) {
+		budget.Reset(now)
 
 		if gs.configStore != nil {
 			// Save reset to database
@@ -632,6 +783,30 @@ func (gs *GovernanceStore) DeleteTeamInMemory(teamID string) {
 	gs.teams.Delete(teamID)
 }
 
+// CreateProjectInMemory adds a new project to the in-memory store (lock-free)
+func (gs *GovernanceStore) CreateProjectInMemory(project *configstore.TableProject) {
+	if project == nil {
+		return // Nothing to create
+	}
+	gs.projects.Store(project.ID, project)
+}
+
+// UpdateProjectInMemory updates an existing project in the in-memory store (lock-free)
+func (gs *GovernanceStore) UpdateProjectInMemory(project *configstore.TableProject) {
+	if project == nil {
+		return // Nothing to update
+	}
+	gs.projects.Store(project.ID, project)
+}
+
+// DeleteProjectInMemory removes a project from the in-memory store (lock-free)
+func (gs *GovernanceStore) DeleteProjectInMemory(projectID string) {
+	if projectID == "" {
+		return // Nothing to delete
+	}
+	gs.projects.Delete(projectID)
+}
+
 // CreateCustomerInMemory adds a new customer to the in-memory store (lock-free)
 func (gs *GovernanceStore) CreateCustomerInMemory(customer *configstore.TableCustomer) {
 	if customer == nil {
@@ -0,0 +1,295 @@
+package governance
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// hierarchyLevels are the levels of the test hierarchy, from the key up
+var hierarchyLevels = []string{"VK", "Project", "Team", "Customer"}
+
+// newHierarchyTestStore creates a store holding a customer, a team of it, a project of the team and virtual keys
+// attached at every level, each level with a budget of $10 and a rate limit of 100 tokens and 10 requests per hour.
+// The budget and rate limit of a level are named after it, e.g. "budget-Team" and "rate-limit-Team". change edits
+// the config before it is loaded.
+func newHierarchyTestStore(t *testing.T, change func(config *configstore.GovernanceConfig)) *GovernanceStore {
+	t.Helper()
+	now := time.Now()
+	config := &configstore.GovernanceConfig{
+		Customers: []configstore.TableCustomer{{ID: "customer", Name: "Customer", BudgetID: bifrost.Ptr("budget-Customer"), RateLimitID: bifrost.Ptr("rate-limit-Customer")}},
+		Teams:     []configstore.TableTeam{{ID: "team", Name: "Team", CustomerID: bifrost.Ptr("customer"), BudgetID: bifrost.Ptr("budget-Team"), RateLimitID: bifrost.Ptr("rate-limit-Team")}},
+		Projects:  []configstore.TableProject{{ID: "project", Name: "Project", TeamID: bifrost.Ptr("team"), BudgetID: bifrost.Ptr("budget-Project"), RateLimitID: bifrost.Ptr("rate-limit-Project")}},
+		VirtualKeys: []configstore.TableVirtualKey{
+			{ID: "vk-project", Value: "vk-project", IsActive: true, ProjectID: bifrost.Ptr("project"), BudgetID: bifrost.Ptr("budget-VK"), RateLimitID: bifrost.Ptr("rate-limit-VK")},
+			{ID: "vk-team", Value: "vk-team", IsActive: true, TeamID: bifrost.Ptr("team")},
+			{ID: "vk-customer", Value: "vk-customer", IsActive: true, CustomerID: bifrost.Ptr("customer")},
+			{ID: "vk-standalone", Value: "vk-standalone", IsActive: true},
+			{ID: "vk-missing-project", Value: "vk-missing-project", IsActive: true, ProjectID: bifrost.Ptr("missing"), TeamID: bifrost.Ptr("team")},
+			{ID: "vk-project-and-customer", Value: "vk-project-and-customer", IsActive: true, ProjectID: bifrost.Ptr("project"), CustomerID: bifrost.Ptr("other")},
+		},
+	}
+	for _, level := range hierarchyLevels {
+		config.Budgets = append(config.Budgets, configstore.TableBudget{ID: "budget-" + level, MaxLimit: 10, ResetDuration: "1h", LastReset: now})
+		config.RateLimits = append(config.RateLimits, configstore.TableRateLimit{
+			ID:                   "rate-limit-" + level,
+			TokenMaxLimit:        bifrost.Ptr(int64(100)),
+			TokenResetDuration:   bifrost.Ptr("1h"),
+			TokenLastReset:       now,
+			RequestMaxLimit:      bifrost.Ptr(int64(10)),
+			RequestResetDuration: bifrost.Ptr("1h"),
+			RequestLastReset:     now,
+		})
+	}
+	if change != nil {
+		change(config)
+	}
+	store, err := NewGovernanceStore(context.Background(), bifrost.NewDefaultLogger(schemas.LogLevelError), nil, config)
+	if err != nil {
+		t.Fatalf("failed to create governance store: %v", err)
+	}
+	return store
+}
+
+// budgetOf returns the budget of a level of the test config
+func budgetOf(config *configstore.GovernanceConfig, level string) *configstore.TableBudget {
+	i := slices.IndexFunc(config.Budgets, func(budget configstore.TableBudget) bool { return budget.ID == "budget-"+level })
+	return &config.Budgets[i]
+}
+
+// rateLimitOf returns the rate limit of a level of the test config
+func rateLimitOf(config *configstore.GovernanceConfig, level string) *configstore.TableRateLimit {
+	i := slices.IndexFunc(config.RateLimits, func(rateLimit configstore.TableRateLimit) bool { return rateLimit.ID == "rate-limit-"+level })
+	return &config.RateLimits[i]
+}
+
+// TestCollectHierarchy tests that the hierarchy of a virtual key is resolved from the key up, through its project,
+// the team of the project or of the key, and the customer of the team or of the key
+func TestCollectHierarchy(t *testing.T) {
+	store := newHierarchyTestStore(t, nil)
+
+	for vkValue, want := range map[string][]string{
+		"vk-project":              {"VK/vk-project", "Project/project", "Team/team", "Customer/customer"},
+		"vk-team":                 {"VK/vk-team", "Team/team", "Customer/customer"},
+		"vk-customer":             {"VK/vk-customer", "Customer/customer"},
+		"vk-standalone":           {"VK/vk-standalone"},
+		"vk-missing-project":      {"VK/vk-missing-project", "Team/team", "Customer/customer"},
+		"vk-project-and-customer": {"VK/vk-project-and-customer", "Project/project", "Team/team", "Customer/customer"},
+	} {
+		vk, ok := store.GetVirtualKey(vkValue)
+		if !ok {
+			t.Fatalf("virtual key %s not loaded", vkValue)
+		}
+		var got []string
+		for _, level := range store.collectHierarchy(vk) {
+			got = append(got, level.name+"/"+level.id)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: hierarchy = %v, want %v", vkValue, got, want)
+		}
+	}
+}
+
+// TestEvaluateRequest_CheckOrder tests that rate limits are checked before budgets, each from the key up, that
+// levels without limits of their own are held to those of their ancestors and that shadow rules do not block
+func TestEvaluateRequest_CheckOrder(t *testing.T) {
+	for name, tc := range map[string]struct {
+		vk            string
+		change        func(config *configstore.GovernanceConfig)
+		wantDecision  Decision
+		wantViolation string   // Kind/Level of the rule blocking the request
+		wantShadow    []string // Kind/Level of the shadow rules that would have blocked it
+	}{
+		"within every limit": {
+			vk:           "vk-project",
+			wantDecision: DecisionAllow,
+		},
+		"rate limit checked before budget": {
+			vk: "vk-project",
+			change: func(config *configstore.GovernanceConfig) {
+				budgetOf(config, "VK").CurrentUsage = 11
+				rateLimitOf(config, "Customer").RequestCurrentUsage = 10
+			},
+			wantDecision:  DecisionRequestLimited,
+			wantViolation: "rate_limit/Customer",
+		},
+		"lowest exceeded rate limit reported": {
+			vk: "vk-project",
+			change: func(config *configstore.GovernanceConfig) {
+				rateLimitOf(config, "Customer").TokenCurrentUsage = 100
+				rateLimitOf(config, "Project").TokenCurrentUsage = 100
+			},
+			wantDecision:  DecisionTokenLimited,
+			wantViolation: "rate_limit/Project",
+		},
+		"rate limit inherited from the team": {
+			vk: "vk-team",
+			change: func(config *configstore.GovernanceConfig) {
+				rateLimitOf(config, "Team").TokenCurrentUsage = 100
+				rateLimitOf(config, "Team").RequestCurrentUsage = 10
+			},
+			wantDecision:  DecisionRateLimited,
+			wantViolation: "rate_limit/Team",
+		},
+		"budget inherited from the customer": {
+			vk: "vk-customer",
+			change: func(config *configstore.GovernanceConfig) {
+				budgetOf(config, "Customer").CurrentUsage = 10.5
+			},
+			wantDecision:  DecisionBudgetExceeded,
+			wantViolation: "budget/Customer",
+		},
+		"limits of other branches ignored": {
+			vk: "vk-standalone",
+			change: func(config *configstore.GovernanceConfig) {
+				budgetOf(config, "Team").CurrentUsage = 11
+				rateLimitOf(config, "Team").RequestCurrentUsage = 10
+			},
+			wantDecision: DecisionAllow,
+		},
+		"shadow rate limit falls through to the budgets": {
+			vk: "vk-project",
+			change: func(config *configstore.GovernanceConfig) {
+				rateLimitOf(config, "Team").Shadow = true
+				rateLimitOf(config, "Team").RequestCurrentUsage = 10
+				budgetOf(config, "Project").CurrentUsage = 11
+			},
+			wantDecision:  DecisionBudgetExceeded,
+			wantViolation: "budget/Project",
+			wantShadow:    []string{"rate_limit/Team"},
+		},
+		"shadow budget below a blocking one": {
+			vk: "vk-project",
+			change: func(config *configstore.GovernanceConfig) {
+				budgetOf(config, "VK").Shadow = true
+				budgetOf(config, "VK").CurrentUsage = 11
+				budgetOf(config, "Team").CurrentUsage = 11
+			},
+			wantDecision:  DecisionBudgetExceeded,
+			wantViolation: "budget/Team",
+			wantShadow:    []string{"budget/VK"},
+		},
+		"budget due for reset not enforced": {
+			vk: "vk-project",
+			change: func(config *configstore.GovernanceConfig) {
+				budgetOf(config, "Team").CurrentUsage = 11
+				budgetOf(config, "Team").LastReset = time.Now().Add(-2 * time.Hour)
+			},
+			wantDecision: DecisionAllow,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := newHierarchyTestStore(t, tc.change)
+			resolver := NewBudgetResolver(store, bifrost.NewDefaultLogger(schemas.LogLevelError))
+			ctx := context.Background()
+			result := resolver.EvaluateRequest(&ctx, &EvaluationRequest{VirtualKey: tc.vk, Provider: schemas.OpenAI, Model: "gpt-4o-mini"})
+
+			if result.Decision != tc.wantDecision {
+				t.Errorf("decision = %s (%s), want %s", result.Decision, result.Reason, tc.wantDecision)
+			}
+			var violation string
+			if result.Violation != nil {
+				violation = string(result.Violation.Kind) + "/" + result.Violation.Level
+			}
+			if violation != tc.wantViolation {
+				t.Errorf("violation = %q, want %q", violation, tc.wantViolation)
+			}
+			var shadow []string
+			for _, v := range result.ShadowViolations {
+				shadow = append(shadow, string(v.Kind)+"/"+v.Level)
+			}
+			if !slices.Equal(shadow, tc.wantShadow) {
+				t.Errorf("shadow violations = %v, want %v", shadow, tc.wantShadow)
+			}
+		})
+	}
+}
+
+// TestResetPropagation tests that usage is charged to every level of the hierarchy of a virtual key and that
+// expired budgets and rate limits are reset at whatever level they are set, leaving the other levels alone
+func TestResetPropagation(t *testing.T) {
+	for _, expired := range hierarchyLevels {
+		t.Run(expired, func(t *testing.T) {
+			store := newHierarchyTestStore(t, func(config *configstore.GovernanceConfig) {
+				for _, level := range hierarchyLevels {
+					budgetOf(config, level).CurrentUsage = 1
+					rateLimitOf(config, level).TokenCurrentUsage = 5
+					rateLimitOf(config, level).RequestCurrentUsage = 1
+				}
+				past := time.Now().Add(-2 * time.Hour)
+				budgetOf(config, expired).LastReset = past
+				rateLimitOf(config, expired).TokenLastReset = past
+				rateLimitOf(config, expired).RequestLastReset = past
+			})
+			vk, _ := store.GetVirtualKey("vk-project")
+			ctx := context.Background()
+
+			// Charge usage twice: the expired level is reset by the first charge, so it loses its earlier usage only
+			for range 2 {
+				if err := store.UpdateRateLimitUsage(ctx, "vk-project", 30, true, true); err != nil {
+					t.Fatalf("failed to update rate limit usage: %v", err)
+				}
+				if err := store.UpdateBudget(ctx, vk, 2); err != nil {
+					t.Fatalf("failed to update budget: %v", err)
+				}
+			}
+			rateLimits, levels := store.CollectRateLimitsFromHierarchy(vk)
+			if !slices.Equal(levels, hierarchyLevels) {
+				t.Fatalf("rate limit levels = %v, want %v", levels, hierarchyLevels)
+			}
+			budgets := store.GetAllBudgets()
+			for i, rateLimit := range rateLimits {
+				wantTokens, wantRequests, wantSpend := int64(65), int64(3), 5.0
+				if levels[i] == expired {
+					wantTokens, wantRequests, wantSpend = 60, 2, 4
+				}
+				if rateLimit.TokenCurrentUsage != wantTokens || rateLimit.RequestCurrentUsage != wantRequests {
+					t.Errorf("%s rate limit usage = %d tokens, %d requests, want %d tokens, %d requests", levels[i], rateLimit.TokenCurrentUsage, rateLimit.RequestCurrentUsage, wantTokens, wantRequests)
+				}
+				if got := budgets["budget-"+levels[i]].CurrentUsage; got != wantSpend {
+					t.Errorf("%s budget usage = %.2f, want %.2f", levels[i], got, wantSpend)
+				}
+			}
+
+			// Expire the level again: the background resets only clear it
+			for _, budget := range budgets {
+				if budget.ID == "budget-"+expired {
+					budget.LastReset = time.Now().Add(-2 * time.Hour)
+				}
+			}
+			for _, level := range store.collectHierarchy(vk) {
+				if level.name == expired {
+					unlock := store.lockRateLimit(level.rateLimit)
+					level.rateLimit.TokenLastReset = time.Now().Add(-2 * time.Hour)
+					level.rateLimit.RequestLastReset = time.Now().Add(-2 * time.Hour)
+					unlock()
+				}
+			}
+			if err := store.ResetExpiredRateLimits(ctx); err != nil {
+				t.Fatalf("failed to reset rate limits: %v", err)
+			}
+			if err := store.ResetExpiredBudgets(ctx); err != nil {
+				t.Fatalf("failed to reset budgets: %v", err)
+			}
+			rateLimits, levels = store.CollectRateLimitsFromHierarchy(vk)
+			budgets = store.GetAllBudgets()
+			for i, rateLimit := range rateLimits {
+				wantTokens, wantRequests, wantSpend := int64(65), int64(3), 5.0
+				if levels[i] == expired {
+					wantTokens, wantRequests, wantSpend = 0, 0, 0
+				}
+				if rateLimit.TokenCurrentUsage != wantTokens || rateLimit.RequestCurrentUsage != wantRequests {
+					t.Errorf("after reset, %s rate limit usage = %d tokens, %d requests, want %d tokens, %d requests", levels[i], rateLimit.TokenCurrentUsage, rateLimit.RequestCurrentUsage, wantTokens, wantRequests)
+				}
+				if got := budgets["budget-"+levels[i]].CurrentUsage; got != wantSpend {
+					t.Errorf("after reset, %s budget usage = %.2f, want %.2f", levels[i], got, wantSpend)
+				}
+			}
+		})
+	}
+}
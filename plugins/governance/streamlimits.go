@@ -0,0 +1,196 @@
+// Package governance provides the enforcement of token rate limits while streams generate
+package governance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/tokenizer"
+)
+
+// DefaultStreamLimitMaxPause is how long a paused stream waits for its token limit to reset by default.
+const DefaultStreamLimitMaxPause = time.Minute
+
+const streamTokensContextKey contextKey = "bf-governance-stream-tokens"
+
+// StreamLimitAction decides what happens to a stream whose output goes past a token limit.
+type StreamLimitAction string
+
+const (
+	// StreamLimitActionTerminate ends the stream with a token_limited error.
+	StreamLimitActionTerminate StreamLimitAction = "terminate"
+	// StreamLimitActionPause holds the stream until the limit resets, terminating it if that takes longer than the
+	// maximum pause.
+	StreamLimitActionPause StreamLimitAction = "pause"
+)
+
+// StreamTokenLimitsConfig enforces token rate limits while chat and text completion streams generate: the output
+// tokens of each chunk are estimated and charged to the rate limits of the virtual key's hierarchy as it arrives,
+// and the estimate is replaced by the usage the provider reports at the end of the stream. Without it, streams are
+// only checked at their start and charged at their end, so a long generation can run far past a limit.
+type StreamTokenLimitsConfig struct {
+	Action     StreamLimitAction `json:"action,omitempty"`       // terminate (default) or pause
+	MaxPauseMs int               `json:"max_pause_ms,omitempty"` // Longest pause before a stream is terminated (default: 60000)
+}
+
+// streamLimiter charges and enforces the output tokens of streams.
+type streamLimiter struct {
+	action   StreamLimitAction
+	maxPause time.Duration
+	store    *GovernanceStore
+	logger   schemas.Logger
+}
+
+// streamTokens are the output tokens of a stream charged from estimates so far
+type streamTokens struct {
+	charged int64
+}
+
+// newStreamLimiter creates a stream limiter, or returns nil when stream token limits are not configured.
+func newStreamLimiter(config *StreamTokenLimitsConfig, store *GovernanceStore, logger schemas.Logger) (*streamLimiter, error) {
+	if config == nil {
+		return nil, nil
+	}
+	limiter := &streamLimiter{action: config.Action, maxPause: DefaultStreamLimitMaxPause, store: store, logger: logger}
+	switch config.Action {
+	case "":
+		limiter.action = StreamLimitActionTerminate
+	case StreamLimitActionTerminate, StreamLimitActionPause:
+	default:
+		return nil, fmt.Errorf("unknown stream token limit action %q", config.Action)
+	}
+	if config.MaxPauseMs < 0 {
+		return nil, fmt.Errorf("stream token limit max_pause_ms cannot be negative")
+	}
+	if config.MaxPauseMs > 0 {
+		limiter.maxPause = time.Duration(config.MaxPauseMs) * time.Millisecond
+	}
+	return limiter, nil
+}
+
+// track starts charging the chunks of a chat or text completion stream. Nil limiters track nothing.
+func (l *streamLimiter) track(ctx *context.Context, req *schemas.BifrostRequest) {
+	if l == nil || (req.RequestType != schemas.ChatCompletionStreamRequest && req.RequestType != schemas.TextCompletionStreamRequest) {
+		return
+	}
+	*ctx = context.WithValue(*ctx, streamTokensContextKey, &streamTokens{})
+}
+
+// charged returns the tokens a tracked stream was charged from estimates, which its reported usage replaces.
+func (l *streamLimiter) charged(ctx context.Context) int64 {
+	if tokens, ok := ctx.Value(streamTokensContextKey).(*streamTokens); ok {
+		return tokens.charged
+	}
+	return 0
+}
+
+// chargeChunk charges the estimated output tokens of a chunk of a tracked stream, and enforces the token limits it
+// goes past. It returns the error ending the stream when the chunk is over a limit that does not reset in time.
+func (l *streamLimiter) chargeChunk(ctx context.Context, virtualKey string, result *schemas.BifrostResponse) *schemas.BifrostError {
+	tokens, ok := ctx.Value(streamTokensContextKey).(*streamTokens)
+	if !ok || result == nil || hasUsageData(result) || bifrost.IsFinalChunk(&ctx) {
+		return nil
+	}
+	vk, exists := l.store.GetVirtualKey(virtualKey)
+	if !exists {
+		return nil
+	}
+	estimate := int64(estimateChunkTokens(result))
+	if estimate == 0 {
+		return nil
+	}
+	tokens.charged += estimate
+	exceeded, level := l.store.AddStreamedTokens(vk, estimate)
+	if exceeded == nil {
+		return nil
+	}
+
+	if l.action == StreamLimitActionPause {
+		deadline := time.Now().Add(l.maxPause)
+		for exceeded != nil {
+			resetDuration := tokenResetDuration(exceeded)
+			if resetDuration == 0 {
+				break // The limit never resets
+			}
+			wait := max(time.Until(exceeded.TokenLastReset.Add(resetDuration)), time.Millisecond)
+			if time.Now().Add(wait).After(deadline) {
+				break
+			}
+			// The chunk is held back while paused, and charged to the window it is let through in
+			l.store.AddStreamedTokens(vk, -estimate)
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				tokens.charged -= estimate
+				return nil
+			}
+			exceeded, level = l.store.AddStreamedTokens(vk, estimate)
+		}
+		if exceeded == nil {
+			return nil
+		}
+	}
+
+	l.logger.Debug("terminating stream of VK %s past its %s token limit", vk.ID, level)
+	duration := "unknown"
+	if exceeded.TokenResetDuration != nil {
+		duration = *exceeded.TokenResetDuration
+	}
+	return &schemas.BifrostError{
+		Type:           bifrost.Ptr(string(DecisionTokenLimited)),
+		StatusCode:     bifrost.Ptr(429),
+		AllowFallbacks: bifrost.Ptr(false),
+		Error: &schemas.ErrorField{
+			Type:    bifrost.Ptr(string(DecisionTokenLimited)),
+			Message: fmt.Sprintf("%s rate limits exceeded while streaming: token limit exceeded (%d/%d, resets every %s)", level, exceeded.TokenCurrentUsage, *exceeded.TokenMaxLimit, duration),
+		},
+	}
+}
+
+// tokenResetDuration returns the token reset duration of a rate limit, 0 when it has none.
+func tokenResetDuration(rateLimit *configstore.TableRateLimit) time.Duration {
+	if rateLimit.TokenResetDuration == nil {
+		return 0
+	}
+	duration, err := configstore.ParseDuration(*rateLimit.TokenResetDuration)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// estimateChunkTokens estimates the output tokens of a chat or text completion chunk.
+func estimateChunkTokens(result *schemas.BifrostResponse) int {
+	tokens := 0
+	for _, choice := range result.Choices {
+		if choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil {
+			tokens += tokenizer.EstimateText(*choice.Text)
+		}
+		if choice.BifrostStreamResponseChoice == nil || choice.Delta == nil {
+			continue
+		}
+		delta := choice.Delta
+		if delta.Content != nil {
+			tokens += tokenizer.EstimateText(*delta.Content)
+		}
+		if delta.Thought != nil {
+			tokens += tokenizer.EstimateText(*delta.Thought)
+		}
+		if delta.Refusal != nil {
+			tokens += tokenizer.EstimateText(*delta.Refusal)
+		}
+		for _, toolCall := range delta.ToolCalls {
+			if toolCall.Function.Name != nil {
+				tokens += tokenizer.EstimateText(*toolCall.Function.Name)
+			}
+			tokens += tokenizer.EstimateText(toolCall.Function.Arguments)
+		}
+	}
+	return tokens
+}
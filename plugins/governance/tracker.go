@@ -67,13 +67,13 @@ func (t *UsageTracker) UpdateUsage(ctx context.Context, update *UsageUpdate) {
 	// Get virtual key
 	vk, exists := t.store.GetVirtualKey(update.VirtualKey)
 	if !exists {
-		t.logger.Debug(fmt.Sprintf("Virtual key not found: %s", update.VirtualKey))
+		t.logger.Debug("Virtual key not found: %s", update.VirtualKey)
 		return
 	}
 
 	// Only process successful requests for usage tracking
 	if !update.Success {
-		t.logger.Debug(fmt.Sprintf("Request was not successful, skipping usage update for VK: %s", vk.ID))
+		t.logger.Debug("Request was not successful, skipping usage update for VK: %s", vk.ID)
 		return
 	}
 
@@ -82,20 +82,18 @@ func (t *UsageTracker) UpdateUsage(ctx context.Context, update *UsageUpdate) {
 	shouldUpdateRequests := !update.IsStreaming || (update.IsStreaming && update.IsFinalChunk)
 	shouldUpdateBudget := !update.IsStreaming || (update.IsStreaming && update.HasUsageData)
 
-	// Update VK rate limit usage if applicable
-	if vk.RateLimit != nil {
-		if err := t.store.UpdateRateLimitUsage(ctx, update.VirtualKey, update.TokensUsed, shouldUpdateTokens, shouldUpdateRequests); err != nil {
-			t.logger.Error("failed to update rate limit usage for VK %s: %v", vk.ID, err)
-		}
+	// Update rate limit usage in hierarchy (VK → Project → Team → Customer) where limits are set
+	if err := t.store.UpdateRateLimitUsage(ctx, update.VirtualKey, update.TokensUsed, shouldUpdateTokens, shouldUpdateRequests); err != nil {
+		t.logger.Error("failed to update rate limit usage for VK %s: %v", vk.ID, err)
 	}
 
-	// Update budget usage in hierarchy (VK → Team → Customer) only if we have usage data
+	// Update budget usage in hierarchy (VK → Project → Team → Customer) only if we have usage data
 	if shouldUpdateBudget && update.Cost > 0 {
 		t.updateBudgetHierarchy(ctx, vk, update)
 	}
 }
 
-// updateBudgetHierarchy updates budget usage atomically in the VK → Team → Customer hierarchy
+// updateBudgetHierarchy updates budget usage atomically in the VK → Project → Team → Customer hierarchy
 func (t *UsageTracker) updateBudgetHierarchy(ctx context.Context, vk *configstore.TableVirtualKey, update *UsageUpdate) {
 	// Use atomic budget update to prevent race conditions and ensure consistency
 	if err := t.store.UpdateBudget(ctx, vk, update.Cost); err != nil {
@@ -162,7 +160,7 @@ func (t *UsageTracker) PerformStartupResets(ctx context.Context) error {
 	if err != nil {
 		errs = append(errs, fmt.Sprintf("failed to load virtual keys for reset: %s", err.Error()))
 	} else {
-		t.logger.Debug(fmt.Sprintf("startup reset: checking %d virtual keys (active + inactive) for expired rate limits", len(allVKs)))
+		t.logger.Debug("startup reset: checking %d virtual keys (active + inactive) for expired rate limits", len(allVKs))
 	}
 
 	for i := range allVKs {
@@ -210,6 +208,11 @@ func (t *UsageTracker) PerformStartupResets(ctx context.Context) error {
 		}
 	}
 
+	// Rate limits of projects, teams and customers are reset in memory and persisted by this function
+	if err := t.store.ResetExpiredRateLimits(ctx); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to reset expired rate limits: %s", err.Error()))
+	}
+
 	// DB reset is also handled by this function
 	if err := t.store.ResetExpiredBudgets(ctx); err != nil {
 		errs = append(errs, fmt.Sprintf("failed to reset expired budgets: %s", err.Error()))
@@ -0,0 +1,144 @@
+// Package governance provides aggregate usage views over the governance hierarchy
+package governance
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// Levels of the governance hierarchy (customer → team → project → virtual key)
+const (
+	UsageLevelCustomer   = "customer"
+	UsageLevelTeam       = "team"
+	UsageLevelProject    = "project"
+	UsageLevelVirtualKey = "virtual_key"
+)
+
+// LevelUsage is the usage of one entity of the governance hierarchy, aggregated over everything below it
+type LevelUsage struct {
+	Level       string                      `json:"level"`
+	ID          string                      `json:"id"`
+	Name        string                      `json:"name"`
+	ParentLevel string                      `json:"parent_level,omitempty"`
+	ParentID    string                      `json:"parent_id,omitempty"`
+	Spend       float64                     `json:"spend"`        // Dollars spent in the current budget period: the entity's own budget usage, or the sum of its children without one
+	VirtualKeys int                         `json:"virtual_keys"` // Virtual keys at or below the entity
+	Budget      *configstore.TableBudget    `json:"budget,omitempty"`
+	RateLimit   *configstore.TableRateLimit `json:"rate_limit,omitempty"`
+}
+
+// usageNode is an entity of the hierarchy while aggregating usage
+type usageNode struct {
+	usage    LevelUsage
+	children []*usageNode
+	done     bool
+}
+
+// UsageByLevel returns the aggregate usage of every entity of a level of the hierarchy, or of all levels when
+// level is empty, sorted by spend (lock-free)
+func (gs *GovernanceStore) UsageByLevel(level string) ([]LevelUsage, error) {
+	switch level {
+	case "", UsageLevelCustomer, UsageLevelTeam, UsageLevelProject, UsageLevelVirtualKey:
+	default:
+		return nil, fmt.Errorf("invalid level %q, expected customer, team, project or virtual_key", level)
+	}
+
+	nodes := make(map[string]*usageNode) // level/ID -> node
+	var ordered []*usageNode
+	add := func(usage LevelUsage, budgetID *string) {
+		if budgetID != nil {
+			if budgetValue, exists := gs.budgets.Load(*budgetID); exists && budgetValue != nil {
+				if budget, ok := budgetValue.(*configstore.TableBudget); ok && budget != nil {
+					usage.Budget = budget
+				}
+			}
+		}
+		node := &usageNode{usage: usage}
+		nodes[usage.Level+"/"+usage.ID] = node
+		ordered = append(ordered, node)
+	}
+
+	gs.customers.Range(func(key, value interface{}) bool {
+		if customer, ok := value.(*configstore.TableCustomer); ok && customer != nil {
+			add(LevelUsage{Level: UsageLevelCustomer, ID: customer.ID, Name: customer.Name, RateLimit: customer.RateLimit}, customer.BudgetID)
+		}
+		return true // continue
+	})
+	gs.teams.Range(func(key, value interface{}) bool {
+		if team, ok := value.(*configstore.TableTeam); ok && team != nil {
+			usage := LevelUsage{Level: UsageLevelTeam, ID: team.ID, Name: team.Name, RateLimit: team.RateLimit}
+			if team.CustomerID != nil {
+				usage.ParentLevel, usage.ParentID = UsageLevelCustomer, *team.CustomerID
+			}
+			add(usage, team.BudgetID)
+		}
+		return true // continue
+	})
+	gs.projects.Range(func(key, value interface{}) bool {
+		if project, ok := value.(*configstore.TableProject); ok && project != nil {
+			usage := LevelUsage{Level: UsageLevelProject, ID: project.ID, Name: project.Name, RateLimit: project.RateLimit}
+			if project.TeamID != nil {
+				usage.ParentLevel, usage.ParentID = UsageLevelTeam, *project.TeamID
+			}
+			add(usage, project.BudgetID)
+		}
+		return true // continue
+	})
+	gs.virtualKeys.Range(func(key, value interface{}) bool {
+		if vk, ok := value.(*configstore.TableVirtualKey); ok && vk != nil {
+			usage := LevelUsage{Level: UsageLevelVirtualKey, ID: vk.ID, Name: vk.Name, VirtualKeys: 1, RateLimit: vk.RateLimit}
+			switch {
+			case vk.ProjectID != nil:
+				usage.ParentLevel, usage.ParentID = UsageLevelProject, *vk.ProjectID
+			case vk.TeamID != nil:
+				usage.ParentLevel, usage.ParentID = UsageLevelTeam, *vk.TeamID
+			case vk.CustomerID != nil:
+				usage.ParentLevel, usage.ParentID = UsageLevelCustomer, *vk.CustomerID
+			}
+			add(usage, vk.BudgetID)
+		}
+		return true // continue
+	})
+
+	// Link children to their parents; entities whose parent is gone are roots
+	for _, node := range ordered {
+		if parent, exists := nodes[node.usage.ParentLevel+"/"+node.usage.ParentID]; exists {
+			parent.children = append(parent.children, node)
+		}
+	}
+
+	var result []LevelUsage
+	for _, node := range ordered {
+		aggregateUsage(node)
+		if level == "" || node.usage.Level == level {
+			result = append(result, node.usage)
+		}
+	}
+	slices.SortStableFunc(result, func(a, b LevelUsage) int {
+		return cmp.Or(cmp.Compare(b.Spend, a.Spend), cmp.Compare(a.Level, b.Level), cmp.Compare(a.Name, b.Name))
+	})
+	return result, nil
+}
+
+// aggregateUsage computes the spend and virtual key count of a node from its children
+func aggregateUsage(node *usageNode) {
+	if node.done {
+		return
+	}
+	node.done = true
+	var childSpend float64
+	for _, child := range node.children {
+		aggregateUsage(child)
+		childSpend += child.usage.Spend
+		node.usage.VirtualKeys += child.usage.VirtualKeys
+	}
+	if node.usage.Budget != nil {
+		// The budget of a level is charged for all usage below it
+		node.usage.Spend = node.usage.Budget.CurrentUsage
+	} else {
+		node.usage.Spend = childSpend
+	}
+}
@@ -14,8 +14,10 @@ import (
 
 	bifrost "github.com/maximhq/bifrost/core"
 	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/cluster"
 	"github.com/maximhq/bifrost/framework/logstore"
 	"github.com/maximhq/bifrost/framework/pricing"
+	"github.com/maximhq/bifrost/framework/redaction"
 	"github.com/maximhq/bifrost/framework/streaming"
 )
 
@@ -46,6 +48,7 @@ type UpdateLogData struct {
 	Status              string
 	TokenUsage          *schemas.LLMUsage
 	Cost                *float64 // Cost in dollars from pricing plugin
+	CacheSavings        *float64 // Dollars saved by the provider's prompt cache, from pricing plugin
 	OutputMessage       *schemas.ChatMessage
 	EmbeddingOutput     []schemas.BifrostEmbedding
 	ToolCalls           []schemas.ChatAssistantMessageToolCall
@@ -62,6 +65,9 @@ type LogMessage struct {
 	Operation          LogOperation
 	RequestID          string                             // Unique ID for the request
 	ParentRequestID    string                             // Unique ID for the parent request
+	Tenant             string                             // Redaction tenant (virtual key) of the request
+	RetentionClass     string                             // Retention class of the request, content is not stored for zero data retention
+	KeyID              string                             // Provider key that served the request
 	Timestamp          time.Time                          // Of the preHook/postHook call
 	InitialData        *InitialLogData                    // For create operations
 	SemanticCacheDebug *schemas.BifrostCacheDebug         // For semantic cache operations
@@ -79,6 +85,10 @@ type InitialLogData struct {
 	SpeechInput        *schemas.SpeechInput
 	TranscriptionInput *schemas.TranscriptionInput
 	Tools              []schemas.ChatTool
+	Customer           string            // End customer the request is billed to
+	RetentionClass     string            // Retention class of the request
+	Language           string            // Language of the prompt, ISO 639-1 code
+	Labels             map[string]string // Labels attributing the request
 }
 
 // LogCallback is a function that gets called when a new log entry is created
@@ -94,6 +104,8 @@ type LoggerPlugin struct {
 	wg              sync.WaitGroup
 	logger          schemas.Logger
 	logCallback     LogCallback
+	leaderChecker   cluster.LeaderChecker // When set, only the cluster leader flushes stale logs
+	redactor        *redaction.Redactor   // When set, content is scrubbed before it is stored
 	droppedRequests atomic.Int64
 	cleanupTicker   *time.Ticker           // Ticker for cleaning up old processing logs
 	logMsgPool      sync.Pool              // Pool for reusing LogMessage structs
@@ -182,6 +194,9 @@ func (p *LoggerPlugin) cleanupWorker() {
 	for {
 		select {
 		case <-p.cleanupTicker.C:
+			if !p.isLeader() {
+				continue
+			}
 			p.cleanupOldProcessingLogs()
 		case <-p.done:
 			return
@@ -206,6 +221,34 @@ func (p *LoggerPlugin) SetLogCallback(callback LogCallback) {
 	p.logCallback = callback
 }
 
+// SetLeaderChecker restricts the stale log cleanup to the cluster leader.
+func (p *LoggerPlugin) SetLeaderChecker(leaderChecker cluster.LeaderChecker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.leaderChecker = leaderChecker
+}
+
+// SetRedactor sets the redaction policies applied to request and response content before it is stored.
+func (p *LoggerPlugin) SetRedactor(redactor *redaction.Redactor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.redactor = redactor
+}
+
+// getRedactor returns the configured redactor, nil when redaction is off.
+func (p *LoggerPlugin) getRedactor() *redaction.Redactor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.redactor
+}
+
+// isLeader reports whether this replica should run cluster-wide background work.
+func (p *LoggerPlugin) isLeader() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.leaderChecker == nil || p.leaderChecker.IsLeader()
+}
+
 // GetName returns the name of the plugin
 func (p *LoggerPlugin) GetName() string {
 	return PluginName
@@ -247,6 +290,13 @@ func (p *LoggerPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest
 		Object:       objectType,
 		InputHistory: inputHistory,
 	}
+	if customer, ok := (*ctx).Value(schemas.BifrostContextKeyBillingCustomer).(string); ok {
+		initialData.Customer = customer
+	}
+	retentionClass, _ := (*ctx).Value(schemas.BifrostContextKeyRetentionClass).(string)
+	initialData.RetentionClass = retentionClass
+	initialData.Language, _ = (*ctx).Value(schemas.BifrostContextKeyLanguage).(string)
+	initialData.Labels, _ = (*ctx).Value(schemas.BifrostContextKeyLabels).(map[string]string)
 
 	switch req.RequestType {
 	case schemas.TextCompletionRequest, schemas.TextCompletionStreamRequest:
@@ -268,6 +318,8 @@ func (p *LoggerPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest
 		}
 	case schemas.EmbeddingRequest:
 		initialData.Params = req.EmbeddingRequest.Params
+	case schemas.RerankRequest:
+		initialData.Params = req.RerankRequest.Params
 	case schemas.SpeechRequest, schemas.SpeechStreamRequest:
 		initialData.Params = req.SpeechRequest.Params
 		initialData.SpeechInput = req.SpeechRequest.Input
@@ -275,6 +327,9 @@ func (p *LoggerPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest
 		initialData.Params = req.TranscriptionRequest.Params
 		initialData.TranscriptionInput = req.TranscriptionRequest.Input
 	}
+	if retentionClass == schemas.RetentionClassZeroDataRetention {
+		dropInitialContent(initialData)
+	}
 	*ctx = context.WithValue(*ctx, CreatedTimestampKey, createdTimestamp)
 	// Queue the log creation message (non-blocking) - Using sync.Pool
 	logMsg := p.getLogMessage()
@@ -291,9 +346,11 @@ func (p *LoggerPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest
 
 	logMsg.Timestamp = createdTimestamp
 	logMsg.InitialData = initialData
+	logMsg.Tenant = redaction.TenantFromContext(*ctx)
 
 	go func(logMsg *LogMessage) {
 		defer p.putLogMessage(logMsg) // Return to pool when done
+		p.redactInitialData(logMsg.Tenant, logMsg.InitialData)
 		if err := p.insertInitialLogEntry(p.ctx, logMsg.RequestID, logMsg.ParentRequestID, logMsg.Timestamp, logMsg.InitialData); err != nil {
 			p.logger.Error("failed to insert initial log entry for request %s: %v", logMsg.RequestID, err)
 		} else {
@@ -310,6 +367,10 @@ func (p *LoggerPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest
 					InputHistoryParsed: logMsg.InitialData.InputHistory,
 					ParamsParsed:       logMsg.InitialData.Params,
 					ToolsParsed:        logMsg.InitialData.Tools,
+					Customer:           logMsg.InitialData.Customer,
+					RetentionClass:     logMsg.InitialData.RetentionClass,
+					Language:           logMsg.InitialData.Language,
+					LabelsParsed:       logMsg.InitialData.Labels,
 					Status:             "processing",
 					Stream:             false, // Initially false, will be updated if streaming
 					CreatedAt:          logMsg.Timestamp,
@@ -351,6 +412,9 @@ func (p *LoggerPlugin) PostHook(ctx *context.Context, result *schemas.BifrostRes
 	logMsg := p.getLogMessage()
 	logMsg.RequestID = requestID
 	logMsg.Timestamp = time.Now()
+	logMsg.Tenant = redaction.TenantFromContext(*ctx)
+	logMsg.RetentionClass, _ = (*ctx).Value(schemas.BifrostContextKeyRetentionClass).(string)
+	logMsg.KeyID, _ = (*ctx).Value(schemas.BifrostContextKeySelectedKey).(string)
 	// If response is nil, and there is an error, we update log with error
 	if result == nil && bifrostErr != nil {
 		// If request type is streaming, then we trigger cleanup as well
@@ -363,7 +427,7 @@ func (p *LoggerPlugin) PostHook(ctx *context.Context, result *schemas.BifrostRes
 			ErrorDetails: bifrostErr,
 		}
 		processingErr := retryOnNotFound(p.ctx, func() error {
-			return p.updateLogEntry(p.ctx, logMsg.RequestID, logMsg.Timestamp, logMsg.SemanticCacheDebug, logMsg.UpdateData)
+			return p.updateLogEntry(p.ctx, logMsg.RequestID, logMsg.Tenant, logMsg.RetentionClass, logMsg.KeyID, logMsg.Timestamp, logMsg.SemanticCacheDebug, logMsg.UpdateData)
 		})
 		if processingErr != nil {
 			p.logger.Error("failed to process log update for request %s: %v", logMsg.RequestID, processingErr)
@@ -395,7 +459,7 @@ func (p *LoggerPlugin) PostHook(ctx *context.Context, result *schemas.BifrostRes
 			go func() {
 				defer p.putLogMessage(logMsg) // Return to pool when done
 				processingErr := retryOnNotFound(p.ctx, func() error {
-					return p.updateStreamingLogEntry(p.ctx, logMsg.RequestID, logMsg.Timestamp, logMsg.SemanticCacheDebug, logMsg.StreamResponse, streamResponse.Type == streaming.StreamResponseTypeFinal)
+					return p.updateStreamingLogEntry(p.ctx, logMsg.RequestID, logMsg.Tenant, logMsg.RetentionClass, logMsg.KeyID, logMsg.Timestamp, logMsg.SemanticCacheDebug, logMsg.StreamResponse, streamResponse.Type == streaming.StreamResponseTypeFinal)
 				})
 				if processingErr != nil {
 					p.logger.Error("failed to process stream update for request %s: %v", logMsg.RequestID, processingErr)
@@ -525,10 +589,13 @@ func (p *LoggerPlugin) PostHook(ctx *context.Context, result *schemas.BifrostRes
 			if logMsg.UpdateData != nil && p.pricingManager != nil {
 				cost := p.pricingManager.CalculateCostWithCacheDebug(result)
 				logMsg.UpdateData.Cost = &cost
+				if savings := p.pricingManager.CalculatePromptCacheSavings(result); savings != 0 {
+					logMsg.UpdateData.CacheSavings = &savings
+				}
 			}
 			// Here we pass plugin level context for background processing to avoid context cancellation
 			processingErr := retryOnNotFound(p.ctx, func() error {
-				return p.updateLogEntry(p.ctx, logMsg.RequestID, logMsg.Timestamp, logMsg.SemanticCacheDebug, logMsg.UpdateData)
+				return p.updateLogEntry(p.ctx, logMsg.RequestID, logMsg.Tenant, logMsg.RetentionClass, logMsg.KeyID, logMsg.Timestamp, logMsg.SemanticCacheDebug, logMsg.UpdateData)
 			})
 			if processingErr != nil {
 				p.logger.Error("failed to process log update for request %s: %v", logMsg.RequestID, processingErr)
@@ -580,6 +647,8 @@ func (p *LoggerPlugin) determineObjectType(requestType schemas.RequestType) stri
 		return "response.completion.chunk"
 	case schemas.EmbeddingRequest:
 		return "list"
+	case schemas.RerankRequest:
+		return "rerank"
 	case schemas.SpeechRequest:
 		return "audio.speech"
 	case schemas.SpeechStreamRequest:
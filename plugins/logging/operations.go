@@ -15,14 +15,18 @@ import (
 // insertInitialLogEntry creates a new log entry in the database using GORM
 func (p *LoggerPlugin) insertInitialLogEntry(ctx context.Context, requestID string, parentRequestID string, timestamp time.Time, data *InitialLogData) error {
 	entry := &logstore.Log{
-		ID:        requestID,
-		Timestamp: timestamp,
-		Object:    data.Object,
-		Provider:  data.Provider,
-		Model:     data.Model,
-		Status:    "processing",
-		Stream:    false,
-		CreatedAt: timestamp,
+		ID:             requestID,
+		Timestamp:      timestamp,
+		Object:         data.Object,
+		Provider:       data.Provider,
+		Model:          data.Model,
+		Customer:       data.Customer,
+		RetentionClass: data.RetentionClass,
+		Language:       data.Language,
+		LabelsParsed:   data.Labels,
+		Status:         "processing",
+		Stream:         false,
+		CreatedAt:      timestamp,
 		// Set parsed fields for serialization
 		InputHistoryParsed:       data.InputHistory,
 		ParamsParsed:             data.Params,
@@ -39,7 +43,7 @@ func (p *LoggerPlugin) insertInitialLogEntry(ctx context.Context, requestID stri
 }
 
 // updateLogEntry updates an existing log entry using GORM
-func (p *LoggerPlugin) updateLogEntry(ctx context.Context, requestID string, timestamp time.Time, cacheDebug *schemas.BifrostCacheDebug, data *UpdateLogData) error {
+func (p *LoggerPlugin) updateLogEntry(ctx context.Context, requestID string, tenant string, retentionClass string, keyID string, timestamp time.Time, cacheDebug *schemas.BifrostCacheDebug, data *UpdateLogData) error {
 	updates := make(map[string]interface{})
 	if !timestamp.IsZero() {
 		// Try to get original timestamp from context first for latency calculation
@@ -50,6 +54,9 @@ func (p *LoggerPlugin) updateLogEntry(ctx context.Context, requestID string, tim
 		updates["latency"] = latency
 	}
 	updates["status"] = data.Status
+	if keyID != "" {
+		updates["key_id"] = keyID
+	}
 	if data.Model != "" {
 		updates["model"] = data.Model
 	}
@@ -113,6 +120,8 @@ func (p *LoggerPlugin) updateLogEntry(ctx context.Context, requestID string, tim
 			updates["prompt_tokens"] = data.TokenUsage.PromptTokens
 			updates["completion_tokens"] = data.TokenUsage.CompletionTokens
 			updates["total_tokens"] = data.TokenUsage.TotalTokens
+			updates["cached_tokens"] = tempEntry.CachedTokens
+			updates["cache_creation_tokens"] = tempEntry.CacheCreationTokens
 		}
 	}
 
@@ -120,6 +129,9 @@ func (p *LoggerPlugin) updateLogEntry(ctx context.Context, requestID string, tim
 	if data.Cost != nil {
 		updates["cost"] = *data.Cost
 	}
+	if data.CacheSavings != nil {
+		updates["cache_savings"] = *data.CacheSavings
+	}
 
 	// Handle cache debug
 	if cacheDebug != nil {
@@ -149,11 +161,12 @@ func (p *LoggerPlugin) updateLogEntry(ctx context.Context, requestID string, tim
 		}
 	}
 
+	p.redactUpdates(tenant, retentionClass, updates)
 	return p.store.Update(ctx, requestID, updates)
 }
 
 // updateStreamingLogEntry handles streaming updates using GORM
-func (p *LoggerPlugin) updateStreamingLogEntry(ctx context.Context, requestID string, timestamp time.Time, cacheDebug *schemas.BifrostCacheDebug, streamResponse *streaming.ProcessedStreamResponse, isFinalChunk bool) error {
+func (p *LoggerPlugin) updateStreamingLogEntry(ctx context.Context, requestID string, tenant string, retentionClass string, keyID string, timestamp time.Time, cacheDebug *schemas.BifrostCacheDebug, streamResponse *streaming.ProcessedStreamResponse, isFinalChunk bool) error {
 	p.logger.Debug("[logging] updating streaming log entry %s", requestID)
 	updates := make(map[string]interface{})
 	// Handle error case first
@@ -164,11 +177,11 @@ func (p *LoggerPlugin) updateStreamingLogEntry(ctx context.Context, requestID st
 			tempEntry := &logstore.Log{}
 			tempEntry.ErrorDetailsParsed = streamResponse.Data.ErrorDetails
 			if err := tempEntry.SerializeFields(); err == nil {
-				return p.store.Update(ctx, requestID, map[string]interface{}{
-					"status":        "error",
-					"error_details": tempEntry.ErrorDetails,
-					"timestamp":     timestamp,
-				})
+				updates["status"] = "error"
+				updates["error_details"] = tempEntry.ErrorDetails
+				updates["timestamp"] = timestamp
+				p.redactUpdates(tenant, retentionClass, updates)
+				return p.store.Update(ctx, requestID, updates)
 			}
 			return err
 		}
@@ -178,22 +191,31 @@ func (p *LoggerPlugin) updateStreamingLogEntry(ctx context.Context, requestID st
 		if err := tempEntry.SerializeFields(); err != nil {
 			return fmt.Errorf("failed to serialize error details: %w", err)
 		}
-		return p.store.Update(ctx, requestID, map[string]interface{}{
-			"status":        "error",
-			"latency":       latency,
-			"timestamp":     timestamp,
-			"error_details": tempEntry.ErrorDetails,
-		})
+		updates["status"] = "error"
+		updates["latency"] = latency
+		updates["timestamp"] = timestamp
+		updates["error_details"] = tempEntry.ErrorDetails
+		p.redactUpdates(tenant, retentionClass, updates)
+		return p.store.Update(ctx, requestID, updates)
 	}
 
 	// Always mark as streaming and update timestamp
 	updates["stream"] = true
 	updates["timestamp"] = timestamp
+	if keyID != "" {
+		updates["key_id"] = keyID
+	}
 
 	// Calculate latency when stream finishes
 	tempEntry := &logstore.Log{}
 
 	updates["latency"] = streamResponse.Data.Latency
+	if isFinalChunk && streamResponse.Data.TimeToFirstToken > 0 {
+		updates["time_to_first_token"] = float64(streamResponse.Data.TimeToFirstToken)
+	}
+	if isFinalChunk && streamResponse.Data.TokensPerSecond > 0 {
+		updates["tokens_per_second"] = streamResponse.Data.TokensPerSecond
+	}
 
 	// Update model if provided
 	if streamResponse.Data.Model != "" {
@@ -213,6 +235,8 @@ func (p *LoggerPlugin) updateStreamingLogEntry(ctx context.Context, requestID st
 			updates["prompt_tokens"] = streamResponse.Data.TokenUsage.PromptTokens
 			updates["completion_tokens"] = streamResponse.Data.TokenUsage.CompletionTokens
 			updates["total_tokens"] = streamResponse.Data.TokenUsage.TotalTokens
+			updates["cached_tokens"] = tempEntry.CachedTokens
+			updates["cache_creation_tokens"] = tempEntry.CacheCreationTokens
 		}
 	}
 
@@ -220,6 +244,9 @@ func (p *LoggerPlugin) updateStreamingLogEntry(ctx context.Context, requestID st
 	if streamResponse.Data.Cost != nil {
 		updates["cost"] = *streamResponse.Data.Cost
 	}
+	if streamResponse.Data.CacheSavings != nil {
+		updates["cache_savings"] = *streamResponse.Data.CacheSavings
+	}
 	// Handle finish reason - if present, mark as complete
 	if isFinalChunk {
 		updates["status"] = "success"
@@ -272,6 +299,7 @@ func (p *LoggerPlugin) updateStreamingLogEntry(ctx context.Context, requestID st
 	}
 	// Only perform update if there's something to update
 	if len(updates) > 0 {
+		p.redactUpdates(tenant, retentionClass, updates)
 		return p.store.Update(ctx, requestID, updates)
 	}
 	return nil
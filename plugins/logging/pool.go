@@ -14,6 +14,9 @@ func (p *LoggerPlugin) putLogMessage(msg *LogMessage) {
 	// Reset the message fields to avoid memory leaks
 	msg.Operation = ""
 	msg.RequestID = ""
+	msg.Tenant = ""
+	msg.RetentionClass = ""
+	msg.KeyID = ""
 	msg.Timestamp = time.Time{}
 	msg.InitialData = nil
 
@@ -43,5 +46,6 @@ func (p *LoggerPlugin) putUpdateLogData(data *UpdateLogData) {
 	data.TranscriptionOutput = nil
 	data.EmbeddingOutput = nil	
 	data.Cost = nil	
+	data.CacheSavings = nil
 	p.updateDataPool.Put(data)
 }
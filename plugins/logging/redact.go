@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/framework/redaction"
+)
+
+// redactedColumns are the serialized log columns that can carry prompt or response content.
+// Embedding vectors and speech audio are left out as they hold no text.
+var redactedColumns = []string{"output_message", "tool_calls", "transcription_output", "error_details", "raw_response"}
+
+// retainedContentColumns are the log columns not written for zero data retention requests. Error details are kept,
+// as they describe the failure rather than the exchange.
+var retainedContentColumns = []string{"output_message", "content_summary", "tool_calls", "embedding_output", "speech_output", "transcription_output", "raw_response"}
+
+// dropInitialContent clears the request content of a new log entry, keeping its metadata.
+func dropInitialContent(data *InitialLogData) {
+	data.InputHistory = nil
+	data.Params = nil
+	data.Tools = nil
+	data.SpeechInput = nil
+	data.TranscriptionInput = nil
+}
+
+// redactInitialData scrubs the request content of a new log entry in place.
+func (p *LoggerPlugin) redactInitialData(tenant string, data *InitialLogData) {
+	redactor := p.getRedactor()
+	if redactor == nil {
+		return
+	}
+	data.InputHistory = redactValue(redactor, tenant, "input_history", data.InputHistory)
+	data.Params = redactValue(redactor, tenant, "params", data.Params)
+	data.Tools = redactValue(redactor, tenant, "tools", data.Tools)
+	data.SpeechInput = redactValue(redactor, tenant, "speech_input", data.SpeechInput)
+}
+
+// redactUpdates scrubs the serialized content columns of a log update in place. The content of zero data
+// retention requests is dropped instead.
+func (p *LoggerPlugin) redactUpdates(tenant string, retentionClass string, updates map[string]interface{}) {
+	if retentionClass == schemas.RetentionClassZeroDataRetention {
+		for _, column := range retainedContentColumns {
+			delete(updates, column)
+		}
+		return
+	}
+	redactor := p.getRedactor()
+	if redactor == nil {
+		return
+	}
+	for _, column := range redactedColumns {
+		if value, ok := updates[column].(string); ok && value != "" {
+			updates[column] = string(redactor.RedactJSON(tenant, column, []byte(value)))
+		}
+	}
+	// The content summary is rebuilt from the redacted output so that search never sees the original text
+	if outputMessage, ok := updates["output_message"].(string); ok && outputMessage != "" {
+		entry := &logstore.Log{OutputMessage: outputMessage}
+		if err := entry.DeserializeFields(); err == nil {
+			updates["content_summary"] = entry.BuildContentSummary()
+		}
+	}
+}
+
+// redactValue scrubs a value through its JSON form. If the redacted document no longer fits the
+// original type (e.g. a field path replaced a number), the zero value is returned so that
+// unredacted content is never stored.
+func redactValue[T any](redactor *redaction.Redactor, tenant string, root string, value T) T {
+	var redacted T
+	data, err := sonic.Marshal(value)
+	if err != nil {
+		return redacted
+	}
+	if string(data) == "null" {
+		return value
+	}
+	if err := sonic.Unmarshal(redactor.RedactJSON(tenant, root, data), &redacted); err != nil {
+		return redacted
+	}
+	return redacted
+}
@@ -208,6 +208,11 @@ func (plugin *Plugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest)
 		return req, nil, nil
 	}
 
+	// Zero data retention requests are not logged, as their generations would hold their content
+	if ctx != nil && isZeroDataRetention(*ctx) {
+		return req, nil, nil
+	}
+
 	// Check if context already has traceID and generationID
 	if ctx != nil {
 		if existingGenerationID, ok := (*ctx).Value(GenerationIDKey).(string); ok && existingGenerationID != "" {
@@ -462,7 +467,7 @@ func (plugin *Plugin) PostHook(ctxRef *context.Context, res *schemas.BifrostResp
 				Type:    bifrostErr.Error.Type,
 			}
 			logger.SetGenerationError(generationID, &genErr)
-		} else if res != nil {
+		} else if res != nil && !isZeroDataRetention(ctx) {
 			logger.AddResultToGeneration(generationID, res)
 		}
 		logger.EndGeneration(generationID)
@@ -476,6 +481,12 @@ func (plugin *Plugin) PostHook(ctxRef *context.Context, res *schemas.BifrostResp
 	return res, bifrostErr, nil
 }
 
+// isZeroDataRetention reports whether the content of a request must not be retained
+func isZeroDataRetention(ctx context.Context) bool {
+	retentionClass, _ := ctx.Value(schemas.BifrostContextKeyRetentionClass).(string)
+	return retentionClass == schemas.RetentionClassZeroDataRetention
+}
+
 func (plugin *Plugin) Cleanup() error {
 	// Flush all loggers
 	plugin.loggerMutex.RLock()
@@ -10,6 +10,7 @@ import (
 	bifrost "github.com/maximhq/bifrost/core"
 	"github.com/maximhq/bifrost/core/schemas"
 	"github.com/maximhq/bifrost/framework/pricing"
+	"github.com/maximhq/bifrost/framework/redaction"
 	"github.com/maximhq/bifrost/framework/streaming"
 )
 
@@ -69,6 +70,7 @@ type OtelPlugin struct {
 
 	pricingManager *pricing.PricingManager
 	accumulator    *streaming.Accumulator // Accumulator for streaming chunks
+	redactor       *redaction.Redactor    // Scrubs span attributes before export
 }
 
 // Init function for the OTEL plugin
@@ -203,12 +205,16 @@ func (p *OtelPlugin) PostHook(ctx *context.Context, resp *schemas.BifrostRespons
 			}
 			if streamResponse != nil && streamResponse.Type == streaming.StreamResponseTypeFinal {
 				defer p.ongoingSpans.Delete(traceID)
-				p.client.Emit(p.ctx, []*ResourceSpan{completeResourceSpan(span, time.Now(), streamResponse.ToBifrostResponse(), bifrostErr, p.pricingManager)})
+				completedSpan := completeResourceSpan(span, time.Now(), streamResponse.ToBifrostResponse(), bifrostErr, p.pricingManager)
+				p.scrubResourceSpan(*ctx, completedSpan)
+				p.client.Emit(p.ctx, []*ResourceSpan{completedSpan})
 			}
 			return resp, bifrostErr, nil
 		}
 		defer p.ongoingSpans.Delete(traceID)
-		p.client.Emit(p.ctx, []*ResourceSpan{completeResourceSpan(span, time.Now(), resp, bifrostErr, p.pricingManager)})
+		completedSpan := completeResourceSpan(span, time.Now(), resp, bifrostErr, p.pricingManager)
+		p.scrubResourceSpan(*ctx, completedSpan)
+		p.client.Emit(p.ctx, []*ResourceSpan{completedSpan})
 	}
 	return resp, bifrostErr, nil
 }
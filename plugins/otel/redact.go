@@ -0,0 +1,93 @@
+package otel
+
+import (
+	"context"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/redaction"
+)
+
+// contentAttributes are the span attributes holding prompt or response content.
+var contentAttributes = map[string]bool{
+	"gen_ai.input.embedding":            true,
+	"gen_ai.input.messages":             true,
+	"gen_ai.input.speech":               true,
+	"gen_ai.input.text":                 true,
+	"gen_ai.request.instructions":       true,
+	"gen_ai.request.prompt":             true,
+	"gen_ai.request.suffix":             true,
+	"gen_ai.chat.output_messages":       true,
+	"gen_ai.text.output_messages":       true,
+	"gen_ai.transcribe.output_messages": true,
+	"gen_ai.responses.output_messages":  true,
+}
+
+// SetRedactor sets the redaction policies applied to span attributes before they are exported.
+func (p *OtelPlugin) SetRedactor(redactor *redaction.Redactor) {
+	p.redactor = redactor
+}
+
+// scrubResourceSpan removes the content attributes of the spans of zero data retention requests, and redacts
+// the attributes of the others.
+func (p *OtelPlugin) scrubResourceSpan(ctx context.Context, resourceSpan *ResourceSpan) {
+	if retentionClass, _ := ctx.Value(schemas.BifrostContextKeyRetentionClass).(string); retentionClass == schemas.RetentionClassZeroDataRetention {
+		dropContentAttributes(resourceSpan)
+	}
+	p.redactResourceSpan(redaction.TenantFromContext(ctx), resourceSpan)
+}
+
+// dropContentAttributes removes the content attributes of every span in place.
+func dropContentAttributes(resourceSpan *ResourceSpan) {
+	if resourceSpan == nil {
+		return
+	}
+	for _, scopeSpan := range resourceSpan.ScopeSpans {
+		for _, span := range scopeSpan.Spans {
+			kept := span.Attributes[:0]
+			for _, attribute := range span.Attributes {
+				if !contentAttributes[attribute.Key] {
+					kept = append(kept, attribute)
+				}
+			}
+			span.Attributes = kept
+		}
+	}
+}
+
+// redactResourceSpan scrubs the string attributes of every span in place.
+// Field paths match top level attribute keys, e.g. "gen_ai.request.user".
+func (p *OtelPlugin) redactResourceSpan(tenant string, resourceSpan *ResourceSpan) {
+	if p.redactor == nil || resourceSpan == nil {
+		return
+	}
+	for _, scopeSpan := range resourceSpan.ScopeSpans {
+		for _, span := range scopeSpan.Spans {
+			for _, attribute := range span.Attributes {
+				if value, ok := attribute.Value.GetValue().(*StringValue); ok {
+					value.StringValue = p.redactor.RedactField(tenant, attribute.Key, value.StringValue)
+					continue
+				}
+				p.redactAnyValue(tenant, attribute.Value)
+			}
+		}
+	}
+}
+
+// redactAnyValue scrubs the strings nested in array and key-value list attributes.
+func (p *OtelPlugin) redactAnyValue(tenant string, value *AnyValue) {
+	if value == nil {
+		return
+	}
+	switch v := value.GetValue().(type) {
+	case *StringValue:
+		v.StringValue = p.redactor.RedactString(tenant, v.StringValue)
+	case *ArrayValue:
+		for _, child := range v.ArrayValue.GetValues() {
+			p.redactAnyValue(tenant, child)
+		}
+	case *ListValue:
+		for _, child := range v.KvlistValue.GetValues() {
+			p.redactAnyValue(tenant, child.Value)
+		}
+	}
+}
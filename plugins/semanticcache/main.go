@@ -590,11 +590,11 @@ func (plugin *Plugin) PostHook(ctx *context.Context, res *schemas.BifrostRespons
 
 		if bifrost.IsStreamRequestType(requestType) {
 			if err := plugin.addStreamingResponse(cacheCtx, requestID, res, bifrostErr, embeddingToStore, unifiedMetadata, cacheTTL, isFinalChunk); err != nil {
-				plugin.logger.Warn(fmt.Sprintf("%s Failed to cache streaming response: %v", PluginLoggerPrefix, err))
+				plugin.logger.Warn("%s Failed to cache streaming response: %v", PluginLoggerPrefix, err)
 			}
 		} else {
 			if err := plugin.addSingleResponse(cacheCtx, requestID, res, embeddingToStore, unifiedMetadata, cacheTTL); err != nil {
-				plugin.logger.Warn(fmt.Sprintf("%s Failed to cache single response: %v", PluginLoggerPrefix, err))
+				plugin.logger.Warn("%s Failed to cache single response: %v", PluginLoggerPrefix, err)
 			}
 		}
 	}()
@@ -650,10 +650,10 @@ func (plugin *Plugin) Cleanup() error {
 
 	for _, result := range results {
 		if result.Status == vectorstore.DeleteStatusError {
-			plugin.logger.Warn(fmt.Sprintf("%s Failed to delete cache entry: %s", PluginLoggerPrefix, result.Error))
+			plugin.logger.Warn("%s Failed to delete cache entry: %s", PluginLoggerPrefix, result.Error)
 		}
 	}
-	plugin.logger.Info(fmt.Sprintf("%s Cleanup completed - deleted all cache entries", PluginLoggerPrefix))
+	plugin.logger.Info("%s Cleanup completed - deleted all cache entries", PluginLoggerPrefix)
 
 	if err := plugin.store.DeleteNamespace(ctx, plugin.config.VectorStoreNamespace); err != nil {
 		return fmt.Errorf("failed to delete namespace: %w", err)
@@ -691,17 +691,17 @@ func (plugin *Plugin) ClearCacheForKey(cacheKey string) error {
 	defer cancel()
 	results, err := plugin.store.DeleteAll(ctx, plugin.config.VectorStoreNamespace, queries)
 	if err != nil {
-		plugin.logger.Warn(fmt.Sprintf("%s Failed to delete cache entries for key '%s': %v", PluginLoggerPrefix, cacheKey, err))
+		plugin.logger.Warn("%s Failed to delete cache entries for key '%s': %v", PluginLoggerPrefix, cacheKey, err)
 		return err
 	}
 
 	for _, result := range results {
 		if result.Status == vectorstore.DeleteStatusError {
-			plugin.logger.Warn(fmt.Sprintf("%s Failed to delete cache entry for key %s: %s", PluginLoggerPrefix, result.ID, result.Error))
+			plugin.logger.Warn("%s Failed to delete cache entry for key %s: %s", PluginLoggerPrefix, result.ID, result.Error)
 		}
 	}
 
-	plugin.logger.Debug(fmt.Sprintf("%s Deleted all cache entries for key %s", PluginLoggerPrefix, cacheKey))
+	plugin.logger.Debug("%s Deleted all cache entries for key %s", PluginLoggerPrefix, cacheKey)
 
 	return nil
 }
@@ -719,11 +719,11 @@ func (plugin *Plugin) ClearCacheForRequestID(requestID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), CacheSetTimeout)
 	defer cancel()
 	if err := plugin.store.Delete(ctx, plugin.config.VectorStoreNamespace, requestID); err != nil {
-		plugin.logger.Warn(fmt.Sprintf("%s Failed to delete cache entry: %v", PluginLoggerPrefix, err))
+		plugin.logger.Warn("%s Failed to delete cache entry: %v", PluginLoggerPrefix, err)
 		return err
 	}
 
-	plugin.logger.Debug(fmt.Sprintf("%s Deleted cache entry for key %s", PluginLoggerPrefix, requestID))
+	plugin.logger.Debug("%s Deleted cache entry for key %s", PluginLoggerPrefix, requestID)
 
 	return nil
 }
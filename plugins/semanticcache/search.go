@@ -47,7 +47,7 @@ func (plugin *Plugin) performDirectSearch(ctx *context.Context, req *schemas.Bif
 		filters = append(filters, vectorstore.Query{Field: "model", Operator: vectorstore.QueryOperatorEqual, Value: req.Model})
 	}
 
-	plugin.logger.Debug(fmt.Sprintf("%s Searching for direct hash match with %d filters", PluginLoggerPrefix, len(filters)))
+	plugin.logger.Debug("%s Searching for direct hash match with %d filters", PluginLoggerPrefix, len(filters))
 
 	// Make a full copy so we don't mutate the original backing array
 	selectFields := append([]string(nil), SelectFields...)
@@ -74,7 +74,7 @@ func (plugin *Plugin) performDirectSearch(ctx *context.Context, req *schemas.Bif
 
 	// Found a matching entry - extract the response
 	result := results[0]
-	plugin.logger.Debug(fmt.Sprintf("%s Found direct hash match with ID: %s", PluginLoggerPrefix, result.ID))
+	plugin.logger.Debug("%s Found direct hash match with ID: %s", PluginLoggerPrefix, result.ID)
 
 	// Build response from cached result
 	return plugin.buildResponseFromResult(ctx, req, result, CacheTypeDirect, 1.0, 0)
@@ -125,7 +125,7 @@ func (plugin *Plugin) performSemanticSearch(ctx *context.Context, req *schemas.B
 		strictFilters = append(strictFilters, vectorstore.Query{Field: "model", Operator: vectorstore.QueryOperatorEqual, Value: req.Model})
 	}
 
-	plugin.logger.Debug(fmt.Sprintf("%s Performing semantic search with %d metadata filters", PluginLoggerPrefix, len(strictFilters)))
+	plugin.logger.Debug("%s Performing semantic search with %d metadata filters", PluginLoggerPrefix, len(strictFilters))
 
 	// Make a full copy so we don't mutate the original backing array
 	selectFields := append([]string(nil), SelectFields...)
@@ -148,7 +148,7 @@ func (plugin *Plugin) performSemanticSearch(ctx *context.Context, req *schemas.B
 
 	// Found a semantically similar entry
 	result := results[0]
-	plugin.logger.Debug(fmt.Sprintf("%s Found semantic match with ID: %s, Score: %f", PluginLoggerPrefix, result.ID, *result.Score))
+	plugin.logger.Debug("%s Found semantic match with ID: %s, Score: %f", PluginLoggerPrefix, result.ID, *result.Score)
 
 	// Build response from cached result
 	return plugin.buildResponseFromResult(ctx, req, result, CacheTypeSemantic, cacheThreshold, inputTokens)
@@ -194,7 +194,7 @@ func (plugin *Plugin) buildResponseFromResult(ctx *context.Context, req *schemas
 					defer cancel()
 					err := plugin.store.Delete(deleteCtx, plugin.config.VectorStoreNamespace, result.ID)
 					if err != nil {
-						plugin.logger.Warn(fmt.Sprintf("%s Failed to delete expired entry %s: %v", PluginLoggerPrefix, result.ID, err))
+						plugin.logger.Warn("%s Failed to delete expired entry %s: %v", PluginLoggerPrefix, result.ID, err)
 					}
 				}()
 				// Return nil to indicate cache miss
@@ -302,14 +302,14 @@ func (plugin *Plugin) buildStreamingResponseFromResult(ctx *context.Context, req
 		for i, chunkData := range streamArray {
 			chunkStr, ok := chunkData.(string)
 			if !ok {
-				plugin.logger.Warn(fmt.Sprintf("%s Stream chunk %d is not a string, skipping", PluginLoggerPrefix, i))
+				plugin.logger.Warn("%s Stream chunk %d is not a string, skipping", PluginLoggerPrefix, i)
 				continue
 			}
 
 			// Unmarshal the chunk as BifrostResponse
 			var cachedResponse schemas.BifrostResponse
 			if err := json.Unmarshal([]byte(chunkStr), &cachedResponse); err != nil {
-				plugin.logger.Warn(fmt.Sprintf("%s Failed to unmarshal stream chunk %d, skipping: %v", PluginLoggerPrefix, i, err))
+				plugin.logger.Warn("%s Failed to unmarshal stream chunk %d, skipping: %v", PluginLoggerPrefix, i, err)
 				continue
 			}
 
@@ -56,7 +56,7 @@ func (plugin *Plugin) addStreamChunk(requestID string, chunk *StreamChunk, isFin
 		accumulator.FinalTimestamp = chunk.Timestamp
 	}
 
-	plugin.logger.Debug(fmt.Sprintf("%s Added chunk to stream accumulator for request %s", PluginLoggerPrefix, requestID))
+	plugin.logger.Debug("%s Added chunk to stream accumulator for request %s", PluginLoggerPrefix, requestID)
 
 	return nil
 }
@@ -78,12 +78,12 @@ func (plugin *Plugin) processAccumulatedStream(ctx context.Context, requestID st
 
 	// STEP 1: Check if any chunk in the entire stream had an error
 	if accumulator.HasError {
-		plugin.logger.Debug(fmt.Sprintf("%s Stream for request %s had errors, dropping entire operation (not caching)", PluginLoggerPrefix, requestID))
+		plugin.logger.Debug("%s Stream for request %s had errors, dropping entire operation (not caching)", PluginLoggerPrefix, requestID)
 		return nil
 	}
 
 	// STEP 2: All chunks are clean, now sort and build ordered stream for caching
-	plugin.logger.Debug(fmt.Sprintf("%s Stream for request %s completed successfully, processing %d chunks for caching", PluginLoggerPrefix, requestID, len(accumulator.Chunks)))
+	plugin.logger.Debug("%s Stream for request %s completed successfully, processing %d chunks for caching", PluginLoggerPrefix, requestID, len(accumulator.Chunks))
 
 	// Sort chunks by their ChunkIndex to ensure proper order (stable + nil-safe)
 	sort.SliceStable(accumulator.Chunks, func(i, j int) bool {
@@ -99,7 +99,7 @@ func (plugin *Plugin) processAccumulatedStream(ctx context.Context, requestID st
 		if chunk.Response != nil {
 			chunkData, err := json.Marshal(chunk.Response)
 			if err != nil {
-				plugin.logger.Warn(fmt.Sprintf("%s Failed to marshal stream chunk %d: %v", PluginLoggerPrefix, i, err))
+				plugin.logger.Warn("%s Failed to marshal stream chunk %d: %v", PluginLoggerPrefix, i, err)
 				continue
 			}
 			streamResponses = append(streamResponses, string(chunkData))
@@ -108,7 +108,7 @@ func (plugin *Plugin) processAccumulatedStream(ctx context.Context, requestID st
 
 	// STEP 3: Validate we have valid chunks to cache
 	if len(streamResponses) == 0 {
-		plugin.logger.Warn(fmt.Sprintf("%s Stream for request %s has no valid response chunks, skipping cache storage", PluginLoggerPrefix, requestID))
+		plugin.logger.Warn("%s Stream for request %s has no valid response chunks, skipping cache storage", PluginLoggerPrefix, requestID)
 		return nil
 	}
 
@@ -124,7 +124,7 @@ func (plugin *Plugin) processAccumulatedStream(ctx context.Context, requestID st
 		return fmt.Errorf("failed to store complete streaming cache entry: %w", err)
 	}
 
-	plugin.logger.Debug(fmt.Sprintf("%s Successfully cached complete stream with %d ordered chunks, ID: %s", PluginLoggerPrefix, len(streamResponses), requestID))
+	plugin.logger.Debug("%s Successfully cached complete stream with %d ordered chunks, ID: %s", PluginLoggerPrefix, len(streamResponses), requestID)
 	return nil
 }
 
@@ -149,7 +149,7 @@ func (plugin *Plugin) cleanupOldStreamAccumulators() {
 			firstChunkTime := accumulator.Chunks[0].Timestamp
 			if firstChunkTime.Before(fiveMinutesAgo) {
 				toDelete = append(toDelete, requestID)
-				plugin.logger.Debug(fmt.Sprintf("%s Cleaned up old stream accumulator for request %s", PluginLoggerPrefix, requestID))
+				plugin.logger.Debug("%s Cleaned up old stream accumulator for request %s", PluginLoggerPrefix, requestID)
 			}
 		}
 		accumulator.mu.Unlock()
@@ -163,6 +163,6 @@ func (plugin *Plugin) cleanupOldStreamAccumulators() {
 	}
 
 	if cleanedCount > 0 {
-		plugin.logger.Debug(fmt.Sprintf("%s Cleaned up %d old stream accumulators", PluginLoggerPrefix, cleanedCount))
+		plugin.logger.Debug("%s Cleaned up %d old stream accumulators", PluginLoggerPrefix, cleanedCount)
 	}
 }
@@ -376,7 +376,7 @@ func (plugin *Plugin) addSingleResponse(ctx context.Context, responseID string,
 		return fmt.Errorf("failed to store unified cache entry: %w", err)
 	}
 
-	plugin.logger.Debug(fmt.Sprintf("%s Successfully cached single response with ID: %s", PluginLoggerPrefix, responseID))
+	plugin.logger.Debug("%s Successfully cached single response with ID: %s", PluginLoggerPrefix, responseID)
 	return nil
 }
 
@@ -427,7 +427,7 @@ func (plugin *Plugin) addStreamingResponse(ctx context.Context, responseID strin
 	// Note: processAccumulatedStream will check for errors and skip caching if any errors occurred
 	if isFinalChunk && !alreadyComplete {
 		if processErr := plugin.processAccumulatedStream(ctx, responseID); processErr != nil {
-			plugin.logger.Warn(fmt.Sprintf("%s Failed to process accumulated stream for request %s: %v", PluginLoggerPrefix, responseID, processErr))
+			plugin.logger.Warn("%s Failed to process accumulated stream for request %s: %v", PluginLoggerPrefix, responseID, processErr)
 		}
 	}
 
@@ -632,7 +632,7 @@ func (plugin *Plugin) extractChatParametersToMetadata(params *schemas.ChatParame
 	}
 	if len(params.Tools) > 0 {
 		if toolsJSON, err := json.Marshal(params.Tools); err != nil {
-			plugin.logger.Warn(fmt.Sprintf("%s Failed to marshal tools for metadata: %v", PluginLoggerPrefix, err))
+			plugin.logger.Warn("%s Failed to marshal tools for metadata: %v", PluginLoggerPrefix, err)
 		} else {
 			toolHash := xxhash.Sum64(toolsJSON)
 			metadata["tools_hash"] = fmt.Sprintf("%x", toolHash)
@@ -718,7 +718,7 @@ func (plugin *Plugin) extractResponsesParametersToMetadata(params *schemas.Respo
 	}
 	if len(params.Tools) > 0 {
 		if toolsJSON, err := json.Marshal(params.Tools); err != nil {
-			plugin.logger.Warn(fmt.Sprintf("%s Failed to marshal tools for metadata: %v", PluginLoggerPrefix, err))
+			plugin.logger.Warn("%s Failed to marshal tools for metadata: %v", PluginLoggerPrefix, err)
 		} else {
 			toolHash := xxhash.Sum64(toolsJSON)
 			metadata["tools_hash"] = fmt.Sprintf("%x", toolHash)
@@ -0,0 +1,61 @@
+// Package telemetry provides Prometheus metrics collection and monitoring functionality
+// for the Bifrost HTTP service. This file contains the guard keeping the number of label values bounded.
+package telemetry
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMaxLabelValues is the number of distinct values a label keeps before new ones are reported as OverflowLabelValue.
+const DefaultMaxLabelValues = 500
+
+// OverflowLabelValue replaces the values of a label beyond its limit.
+const OverflowLabelValue = "__other__"
+
+// unguardedLabels have a small, fixed set of values
+var unguardedLabels = map[string]bool{"method": true, "status": true, "provider": true, "cache_type": true, "key_tier": true}
+
+// labelGuard caps the distinct values of each label, so that unbounded inputs (paths, error messages, model names
+// or custom x-bf-prom-* headers) cannot exhaust the memory of Bifrost or of Prometheus.
+type labelGuard struct {
+	mu       sync.Mutex
+	limit    int
+	seen     map[string]map[string]struct{}
+	overflow *prometheus.CounterVec
+}
+
+// newLabelGuard creates a guard keeping up to limit values per label
+func newLabelGuard(limit int, overflow *prometheus.CounterVec) *labelGuard {
+	if limit <= 0 {
+		limit = DefaultMaxLabelValues
+	}
+	return &labelGuard{limit: limit, seen: map[string]map[string]struct{}{}, overflow: overflow}
+}
+
+// value returns the value to record for a label: the value itself while the label is under its limit or once it
+// has been seen, OverflowLabelValue otherwise.
+func (g *labelGuard) value(label, value string) string {
+	if g == nil || value == "" || unguardedLabels[label] {
+		return value
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	values, ok := g.seen[label]
+	if !ok {
+		values = map[string]struct{}{}
+		g.seen[label] = values
+	}
+	if _, ok := values[value]; ok {
+		return value
+	}
+	if len(values) >= g.limit {
+		if g.overflow != nil {
+			g.overflow.WithLabelValues(label).Inc()
+		}
+		return OverflowLabelValue
+	}
+	values[value] = struct{}{}
+	return value
+}
@@ -0,0 +1,52 @@
+// Package telemetry provides Prometheus metrics collection and monitoring functionality
+// for the Bifrost HTTP service. This file contains the catalog of the metrics it exports.
+package telemetry
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// MetricDescription documents an exported metric.
+type MetricDescription struct {
+	Name      string    `json:"name"`
+	Type      string    `json:"type"` // counter, gauge, histogram, summary or untyped
+	Help      string    `json:"help"`
+	Labels    []string  `json:"labels"`
+	Buckets   []float64 `json:"buckets,omitempty"`
+	Exemplars bool      `json:"exemplars,omitempty"` // Observations carry a trace_id exemplar
+}
+
+var (
+	catalogMu sync.Mutex
+	catalog   []MetricDescription
+)
+
+// Catalog returns the metrics registered by this package.
+func Catalog() []MetricDescription {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	return slices.Clone(catalog)
+}
+
+// describe adds a metric to the catalog
+func describe(description MetricDescription) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	catalog = append(catalog, description)
+}
+
+// newCounterVec registers a counter and adds it to the catalog
+func newCounterVec(opts prometheus.CounterOpts, labels []string) *prometheus.CounterVec {
+	describe(MetricDescription{Name: opts.Name, Type: "counter", Help: opts.Help, Labels: labels})
+	return promauto.NewCounterVec(opts, labels)
+}
+
+// newHistogramVec registers a histogram and adds it to the catalog; exemplars tells whether it records trace IDs
+func newHistogramVec(opts prometheus.HistogramOpts, labels []string, exemplars bool) *prometheus.HistogramVec {
+	describe(MetricDescription{Name: opts.Name, Type: "histogram", Help: opts.Help, Labels: labels, Buckets: opts.Buckets, Exemplars: exemplars})
+	return promauto.NewHistogramVec(opts, labels)
+}
@@ -6,6 +6,9 @@ package telemetry
 import (
 	"context"
 	"log"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	bifrost "github.com/maximhq/bifrost/core"
@@ -22,14 +25,30 @@ const (
 type ContextKey string
 
 const (
-	startTimeKey ContextKey = "bf-prom-start-time"
+	startTimeKey   ContextKey = "bf-prom-start-time"
+	streamTimesKey ContextKey = "bf-prom-stream-times"
 )
 
+// streamTimes records when the first chunk of a streaming response arrived.
+// It is stored as a pointer in the context so every chunk's PostHook sees the same value.
+type streamTimes struct {
+	once       sync.Once
+	firstChunk time.Time
+}
+
+// markChunk records the arrival time of the first chunk.
+func (s *streamTimes) markChunk() {
+	s.once.Do(func() {
+		s.firstChunk = time.Now()
+	})
+}
+
 // PrometheusPlugin implements the schemas.Plugin interface for Prometheus metrics.
 // It tracks metrics for upstream provider requests, including:
 //   - Total number of requests
 //   - Request latency
 //   - Error counts
+//   - Time to first token, duration and output throughput of streaming responses
 type PrometheusPlugin struct {
 	pricingManager *pricing.PricingManager
 
@@ -42,6 +61,10 @@ type PrometheusPlugin struct {
 	OutputTokensTotal     *prometheus.CounterVec
 	CacheHitsTotal        *prometheus.CounterVec
 	CostTotal             *prometheus.CounterVec
+
+	StreamFirstTokenLatency *prometheus.HistogramVec
+	StreamDuration          *prometheus.HistogramVec
+	StreamTokensPerSecond   *prometheus.HistogramVec
 }
 
 // Init creates a new PrometheusPlugin with initialized metrics.
@@ -60,6 +83,10 @@ func Init(pricingManager *pricing.PricingManager, logger schemas.Logger) (*Prome
 		OutputTokensTotal:     bifrostOutputTokensTotal,
 		CacheHitsTotal:        bifrostCacheHitsTotal,
 		CostTotal:             bifrostCostTotal,
+
+		StreamFirstTokenLatency: bifrostStreamFirstTokenLatencySeconds,
+		StreamDuration:          bifrostStreamDurationSeconds,
+		StreamTokensPerSecond:   bifrostStreamOutputTokensPerSecond,
 	}, nil
 }
 
@@ -77,6 +104,9 @@ func (p *PrometheusPlugin) TransportInterceptor(url string, headers map[string]s
 // This time is used later in PostHook to calculate request duration.
 func (p *PrometheusPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
 	*ctx = context.WithValue(*ctx, startTimeKey, time.Now())
+	if bifrost.IsStreamRequestType(req.RequestType) {
+		*ctx = context.WithValue(*ctx, streamTimesKey, &streamTimes{})
+	}
 
 	return req, nil, nil
 }
@@ -95,7 +125,11 @@ func (p *PrometheusPlugin) PostHook(ctx *context.Context, result *schemas.Bifros
 	}
 
 	// For streaming requests, only record metrics on the final chunk
+	times, _ := (*ctx).Value(streamTimesKey).(*streamTimes)
 	if bifrost.IsStreamRequestType(requestType) {
+		if times != nil {
+			times.markChunk()
+		}
 		streamEndIndicatorValue := (*ctx).Value(schemas.BifrostContextKeyStreamEndIndicator)
 		if streamEndIndicatorValue == nil {
 			// No stream end indicator - this is an intermediate chunk, skip metrics
@@ -110,6 +144,22 @@ func (p *PrometheusPlugin) PostHook(ctx *context.Context, result *schemas.Bifros
 		// This is the final chunk - continue with metrics recording
 	}
 
+	// Read the context ahead of the goroutine recording the metrics
+	labelValues := map[string]string{
+		"provider": string(provider),
+		"model":    model,
+		"method":   string(requestType),
+		"key_tier": keyTier(*ctx),
+	}
+	for _, key := range customLabels {
+		if value := (*ctx).Value(ContextKey(key)); value != nil {
+			if strValue, ok := value.(string); ok {
+				labelValues[key] = strValue
+			}
+		}
+	}
+	exemplar := traceExemplar(*ctx)
+
 	// Calculate cost and record metrics in a separate goroutine to avoid blocking the main thread
 	go func() {
 		cost := 0.0
@@ -117,28 +167,18 @@ func (p *PrometheusPlugin) PostHook(ctx *context.Context, result *schemas.Bifros
 			cost = p.pricingManager.CalculateCostWithCacheDebug(result)
 		}
 
-		labelValues := map[string]string{
-			"provider": string(provider),
-			"model":    model,
-			"method":   string(requestType),
-		}
-
-		// Get all prometheus labels from context
-		for _, key := range customLabels {
-			if value := (*ctx).Value(ContextKey(key)); value != nil {
-				if strValue, ok := value.(string); ok {
-					labelValues[key] = strValue
-				}
-			}
-		}
-
-		// Get label values in the correct order (cache_type will be handled separately for cache hits)
-		promLabelValues := getPrometheusLabelValues(append([]string{"provider", "model", "method"}, customLabels...), labelValues)
+		// Get label values in the correct order (cache_type and reason are inserted after the default labels)
+		promLabelValues := getPrometheusLabelValues(slices.Concat(bifrostDefaultLabels, customLabels), labelValues)
+		defaultLabelValues, customLabelValues := promLabelValues[:len(bifrostDefaultLabels)], promLabelValues[len(bifrostDefaultLabels):]
 
 		duration := time.Since(startTime).Seconds()
-		p.UpstreamLatency.WithLabelValues(promLabelValues...).Observe(duration)
+		observeWithExemplar(p.UpstreamLatency.WithLabelValues(promLabelValues...), duration, exemplar)
 		p.UpstreamRequestsTotal.WithLabelValues(promLabelValues...).Inc()
 
+		if times != nil && bifrostErr == nil {
+			p.recordStreamTimes(promLabelValues, startTime, times, result, exemplar)
+		}
+
 		// Record cost using the dedicated cost counter
 		if cost > 0 {
 			p.CostTotal.WithLabelValues(promLabelValues...).Add(cost)
@@ -146,19 +186,11 @@ func (p *PrometheusPlugin) PostHook(ctx *context.Context, result *schemas.Bifros
 
 		// Record error and success counts
 		if bifrostErr != nil {
-			// Pre-allocate slice for error labels: [provider, model, method, reason, ...customLabels]
-			errorPromLabelValues := make([]string, 4+len(customLabels))
-
-			// Set standard labels
-			errorPromLabelValues[0] = promLabelValues[0]       // provider
-			errorPromLabelValues[1] = promLabelValues[1]       // model
-			errorPromLabelValues[2] = promLabelValues[2]       // method
-			errorPromLabelValues[3] = bifrostErr.Error.Message // reason
-
-			// Copy custom labels from promLabelValues (they start at index 3 in promLabelValues)
-			copy(errorPromLabelValues[4:], promLabelValues[3:])
-
-			p.ErrorRequestsTotal.WithLabelValues(errorPromLabelValues...).Inc()
+			reason := ""
+			if bifrostErr.Error != nil {
+				reason = guard.value("reason", bifrostErr.Error.Message)
+			}
+			p.ErrorRequestsTotal.WithLabelValues(slices.Concat(defaultLabelValues, []string{reason}, customLabelValues)...).Inc()
 		} else {
 			p.SuccessRequestsTotal.WithLabelValues(promLabelValues...).Inc()
 		}
@@ -176,12 +208,7 @@ func (p *PrometheusPlugin) PostHook(ctx *context.Context, result *schemas.Bifros
 				if result.ExtraFields.CacheDebug.HitType != nil {
 					cacheType = *result.ExtraFields.CacheDebug.HitType
 				}
-
-				// Add cache_type to label values
-				cacheHitLabelValues := append(promLabelValues[:3], cacheType)             // provider, model, method, cache_type
-				cacheHitLabelValues = append(cacheHitLabelValues, promLabelValues[3:]...) // then custom labels
-
-				p.CacheHitsTotal.WithLabelValues(cacheHitLabelValues...).Inc()
+				p.CacheHitsTotal.WithLabelValues(slices.Concat(defaultLabelValues, []string{cacheType}, customLabelValues)...).Inc()
 			}
 		}
 	}()
@@ -189,6 +216,60 @@ func (p *PrometheusPlugin) PostHook(ctx *context.Context, result *schemas.Bifros
 	return result, bifrostErr, nil
 }
 
+// recordStreamTimes records time to first token, stream duration and output throughput for a finished stream.
+func (p *PrometheusPlugin) recordStreamTimes(labelValues []string, startTime time.Time, times *streamTimes, result *schemas.BifrostResponse, exemplar prometheus.Labels) {
+	end := time.Now()
+	observeWithExemplar(p.StreamDuration.WithLabelValues(labelValues...), end.Sub(startTime).Seconds(), exemplar)
+	observeWithExemplar(p.StreamFirstTokenLatency.WithLabelValues(labelValues...), times.firstChunk.Sub(startTime).Seconds(), exemplar)
+	generation := end.Sub(times.firstChunk).Seconds()
+	if result != nil && result.Usage != nil && result.Usage.CompletionTokens > 0 && generation > 0 {
+		p.StreamTokensPerSecond.WithLabelValues(labelValues...).Observe(float64(result.Usage.CompletionTokens) / generation)
+	}
+}
+
+// keyTier returns the tier of the provider key selected for the request
+func keyTier(ctx context.Context) string {
+	keyID, _ := ctx.Value(schemas.BifrostContextKeySelectedKey).(string)
+	if tier, ok := keyTiers[keyID]; ok && keyID != "" {
+		return tier
+	}
+	return DefaultKeyTier
+}
+
+// traceExemplar returns the trace_id exemplar of a request: its request ID as the 32 hex digit trace ID the otel
+// plugin exports, so latency buckets link to traces in Grafana. It is nil when the request has no ID.
+func traceExemplar(ctx context.Context) prometheus.Labels {
+	requestID, _ := ctx.Value(schemas.BifrostContextKeyRequestID).(string)
+	traceID := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' || r >= 'a' && r <= 'f' {
+			return r
+		}
+		if r >= 'A' && r <= 'F' {
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, requestID)
+	if traceID == "" {
+		return nil
+	}
+	if len(traceID)%2 != 0 {
+		traceID = "0" + traceID
+	}
+	if len(traceID) < 32 {
+		traceID = strings.Repeat("0", 32-len(traceID)) + traceID
+	}
+	return prometheus.Labels{"trace_id": traceID[:32]}
+}
+
+// observeWithExemplar records an observation, with the exemplar when there is one
+func observeWithExemplar(observer prometheus.Observer, value float64, exemplar prometheus.Labels) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		exemplarObserver.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	observer.Observe(value)
+}
+
 func (p *PrometheusPlugin) Cleanup() error {
 	return nil
 }
@@ -6,12 +6,12 @@ package telemetry
 import (
 	"log"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/valyala/fasthttp"
 )
 
@@ -49,126 +49,201 @@ var (
 	// bifrostCostTotal tracks the total cost in USD for requests to upstream providers
 	bifrostCostTotal *prometheus.CounterVec
 
+	// bifrostStreamFirstTokenLatencySeconds tracks the time from the request to the first streamed chunk.
+	bifrostStreamFirstTokenLatencySeconds *prometheus.HistogramVec
+
+	// bifrostStreamDurationSeconds tracks the time from the request to the final streamed chunk.
+	bifrostStreamDurationSeconds *prometheus.HistogramVec
+
+	// bifrostStreamOutputTokensPerSecond tracks the output token throughput of streaming responses.
+	bifrostStreamOutputTokensPerSecond *prometheus.HistogramVec
+
+	// bifrostMetricLabelOverflowTotal counts the label values replaced because a label reached its limit.
+	bifrostMetricLabelOverflowTotal *prometheus.CounterVec
+
 	// customLabels stores the expected label names in order
 	customLabels  []string
 	isInitialized bool
+
+	// keyTiers maps provider key IDs to the tier reported in the key_tier label
+	keyTiers map[string]string
+	// guard bounds the number of values of each label
+	guard *labelGuard
 )
 
-func InitPrometheusMetrics(labels []string) {
+// httpDefaultLabels are the labels of HTTP metrics, ahead of the custom labels
+var httpDefaultLabels = []string{"path", "method", "status"}
+
+// bifrostDefaultLabels are the labels of upstream metrics, ahead of the custom labels
+var bifrostDefaultLabels = []string{"provider", "model", "method", "key_tier"}
+
+// DefaultKeyTier is the key_tier of keys without a configured tier.
+const DefaultKeyTier = "default"
+
+// Config configures the labels of the metrics.
+type Config struct {
+	// KeyTiers maps provider key IDs to the tier reported in the key_tier label (e.g. "primary", "batch"); other keys
+	// are reported as "default".
+	KeyTiers map[string]string `json:"key_tiers,omitempty"`
+	// MaxLabelValues caps the distinct values of each label with open-ended values (path, model, reason, custom
+	// labels); further values are reported as "__other__" (default: 500).
+	MaxLabelValues int `json:"max_label_values,omitempty"`
+}
+
+// InitPrometheusMetrics registers the metrics, with the custom labels set through x-bf-prom-* headers. config may
+// be nil.
+func InitPrometheusMetrics(labels []string, config *Config) {
 	if isInitialized {
 		return
 	}
 
 	customLabels = labels
+	if config == nil {
+		config = &Config{}
+	}
+	keyTiers = config.KeyTiers
 
-	httpDefaultLabels := []string{"path", "method", "status"}
-	bifrostDefaultLabels := []string{"provider", "model", "method"}
+	bifrostMetricLabelOverflowTotal = newCounterVec(
+		prometheus.CounterOpts{
+			Name: "bifrost_metric_label_overflow_total",
+			Help: "Label values reported as __other__ because the label reached its limit of distinct values.",
+		},
+		[]string{"label"},
+	)
+	guard = newLabelGuard(config.MaxLabelValues, bifrostMetricLabelOverflowTotal)
 
 	// Upstream LLM latency buckets - extended range for AI model inference times
 	upstreamLatencyBuckets := []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 15, 30, 45, 60, 90} // in seconds
 
-	httpRequestsTotal = promauto.NewCounterVec(
+	httpRequestsTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "http_requests_total",
 			Help: "Total number of HTTP requests.",
 		},
-		append(httpDefaultLabels, labels...),
+		slices.Concat(httpDefaultLabels, labels),
 	)
 
 	// httpRequestDuration tracks the duration of HTTP requests
-	httpRequestDuration = promauto.NewHistogramVec(
+	httpRequestDuration = newHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "http_request_duration_seconds",
 			Help:    "Duration of HTTP requests.",
 			Buckets: prometheus.DefBuckets,
 		},
-		append(httpDefaultLabels, labels...),
+		slices.Concat(httpDefaultLabels, labels), false,
 	)
 
 	// httpRequestSizeBytes tracks the size of incoming HTTP requests
-	httpRequestSizeBytes = promauto.NewHistogramVec(
+	httpRequestSizeBytes = newHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "http_request_size_bytes",
 			Help:    "Size of HTTP requests.",
 			Buckets: prometheus.ExponentialBuckets(100, 10, 8), // 100B to 1GB
 		},
-		append(httpDefaultLabels, labels...),
+		slices.Concat(httpDefaultLabels, labels), false,
 	)
 
 	// httpResponseSizeBytes tracks the size of outgoing HTTP responses
-	httpResponseSizeBytes = promauto.NewHistogramVec(
+	httpResponseSizeBytes = newHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "http_response_size_bytes",
 			Help:    "Size of HTTP responses.",
 			Buckets: prometheus.ExponentialBuckets(100, 10, 8), // 100B to 1GB
 		},
-		append(httpDefaultLabels, labels...),
+		slices.Concat(httpDefaultLabels, labels), false,
 	)
 
 	// Bifrost Upstream Metrics (Defined globally, used by PrometheusPlugin)
-	bifrostUpstreamRequestsTotal = promauto.NewCounterVec(
+	bifrostUpstreamRequestsTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "bifrost_upstream_requests_total",
 			Help: "Total number of requests forwarded to upstream providers by Bifrost.",
 		},
-		append(bifrostDefaultLabels, labels...),
+		slices.Concat(bifrostDefaultLabels, labels),
 	)
 
-	bifrostUpstreamLatencySeconds = promauto.NewHistogramVec(
+	bifrostUpstreamLatencySeconds = newHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "bifrost_upstream_latency_seconds",
 			Help:    "Latency of requests forwarded to upstream providers by Bifrost.",
 			Buckets: upstreamLatencyBuckets, // Extended range for AI model inference times
 		},
-		append(bifrostDefaultLabels, labels...),
+		slices.Concat(bifrostDefaultLabels, labels), true,
 	)
 
-	bifrostSuccessRequestsTotal = promauto.NewCounterVec(
+	bifrostSuccessRequestsTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "bifrost_success_requests_total",
 			Help: "Total number of successful requests forwarded to upstream providers by Bifrost.",
 		},
-		append(bifrostDefaultLabels, labels...),
+		slices.Concat(bifrostDefaultLabels, labels),
 	)
 
-	bifrostErrorRequestsTotal = promauto.NewCounterVec(
+	bifrostErrorRequestsTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "bifrost_error_requests_total",
 			Help: "Total number of error requests forwarded to upstream providers by Bifrost.",
 		},
-		append(append(bifrostDefaultLabels, "reason"), labels...),
+		slices.Concat(bifrostDefaultLabels, []string{"reason"}, labels),
 	)
 
-	bifrostInputTokensTotal = promauto.NewCounterVec(
+	bifrostInputTokensTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "bifrost_input_tokens_total",
 			Help: "Total number of input tokens forwarded to upstream providers by Bifrost.",
 		},
-		append(bifrostDefaultLabels, labels...),
+		slices.Concat(bifrostDefaultLabels, labels),
 	)
 
-	bifrostOutputTokensTotal = promauto.NewCounterVec(
+	bifrostOutputTokensTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "bifrost_output_tokens_total",
 			Help: "Total number of output tokens forwarded to upstream providers by Bifrost.",
 		},
-		append(bifrostDefaultLabels, labels...),
+		slices.Concat(bifrostDefaultLabels, labels),
 	)
 
-	bifrostCacheHitsTotal = promauto.NewCounterVec(
+	bifrostCacheHitsTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "bifrost_cache_hits_total",
 			Help: "Total number of cache hits forwarded to upstream providers by Bifrost, separated by cache type (direct/semantic).",
 		},
-		append(append(bifrostDefaultLabels, "cache_type"), labels...),
+		slices.Concat(bifrostDefaultLabels, []string{"cache_type"}, labels),
 	)
 
-	bifrostCostTotal = promauto.NewCounterVec(
+	bifrostCostTotal = newCounterVec(
 		prometheus.CounterOpts{
 			Name: "bifrost_cost_total",
 			Help: "Total cost in USD for requests to upstream providers.",
 		},
-		append(bifrostDefaultLabels, labels...),
+		slices.Concat(bifrostDefaultLabels, labels),
+	)
+
+	bifrostStreamFirstTokenLatencySeconds = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bifrost_stream_first_token_latency_seconds",
+			Help:    "Time from the request to the first streamed chunk (time to first token).",
+			Buckets: upstreamLatencyBuckets,
+		},
+		slices.Concat(bifrostDefaultLabels, labels), true,
+	)
+
+	bifrostStreamDurationSeconds = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bifrost_stream_duration_seconds",
+			Help:    "Time from the request to the final streamed chunk.",
+			Buckets: upstreamLatencyBuckets,
+		},
+		slices.Concat(bifrostDefaultLabels, labels), true,
+	)
+
+	bifrostStreamOutputTokensPerSecond = newHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bifrost_stream_output_tokens_per_second",
+			Help:    "Output tokens per second of streaming responses, measured between the first and the final chunk.",
+			Buckets: []float64{1, 5, 10, 25, 50, 75, 100, 150, 200, 300, 500, 1000},
+		},
+		slices.Concat(bifrostDefaultLabels, labels), false,
 	)
 
 	isInitialized = true
@@ -176,11 +251,12 @@ func InitPrometheusMetrics(labels []string) {
 
 // getPrometheusLabelValues takes an array of expected label keys and a map of header values,
 // and returns an array of values in the same order as the keys, using empty string for missing values.
+// Values of labels over their limit of distinct values are replaced with OverflowLabelValue.
 func getPrometheusLabelValues(expectedLabels []string, headerValues map[string]string) []string {
 	values := make([]string, len(expectedLabels))
 	for i, label := range expectedLabels {
 		if value, exists := headerValues[label]; exists {
-			values[i] = value
+			values[i] = guard.value(label, value)
 		} else {
 			values[i] = "" // Default empty value for missing labels
 		}
@@ -248,7 +324,7 @@ func PrometheusMiddleware(handler fasthttp.RequestHandler) fasthttp.RequestHandl
 		promKeyValues["status"] = status
 
 		// Get label values in the correct order
-		promLabelValues := getPrometheusLabelValues(append([]string{"path", "method", "status"}, customLabels...), promKeyValues)
+		promLabelValues := getPrometheusLabelValues(slices.Concat(httpDefaultLabels, customLabels), promKeyValues)
 
 		// Record all metrics with prometheus labels
 		httpRequestsTotal.WithLabelValues(promLabelValues...).Inc()
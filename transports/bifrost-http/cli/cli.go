@@ -0,0 +1,198 @@
+// Package cli implements the administration subcommands of the bifrost-http binary.
+// Subcommands talk to the management API of a running Bifrost instance, so headless
+// administration can be scripted:
+//
+//	bifrost-http keys list [-provider openai]
+//	bifrost-http keys create -provider openai -value env.OPENAI_API_KEY [-models gpt-4o,gpt-4o-mini] [-weight 1]
+//	bifrost-http keys delete -provider openai -id <key-id>
+//	bifrost-http config get
+//	bifrost-http config validate [-file ./data/config.json]
+//	bifrost-http logs tail [-n 20] [-follow] [-interval 2s]
+//	bifrost-http password rotate [-new <password>]
+//
+// The API address defaults to the -host/-port flags of the binary and can be overridden with
+// BIFROST_URL or -url. When admin auth is enabled, the admin password is read from
+// BIFROST_ADMIN_PASSWORD or -token.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Options are the defaults shared by all subcommands.
+type Options struct {
+	BaseURL string    // Management API address, e.g. http://localhost:8080
+	Token   string    // Admin password, sent as a bearer token
+	Stdout  io.Writer // Command output
+}
+
+// command is a subcommand handler. args are the arguments after the subcommand name.
+type command func(ctx context.Context, c *client, args []string) error
+
+// commands maps "group action" to its handler.
+var commands = map[string]command{
+	"keys list":       keysList,
+	"keys create":     keysCreate,
+	"keys delete":     keysDelete,
+	"config get":      configGet,
+	"config validate": configValidate,
+	"logs tail":       logsTail,
+	"password rotate": passwordRotate,
+}
+
+// IsCommand reports whether name is a CLI command group.
+func IsCommand(name string) bool {
+	for key := range commands {
+		if strings.HasPrefix(key, name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes a subcommand. args start with the command group, e.g. ["keys", "list", "-provider", "openai"].
+func Run(ctx context.Context, args []string, options Options) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: %s", usage())
+	}
+	run, ok := commands[args[0]+" "+args[1]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, usage: %s", strings.Join(args[:2], " "), usage())
+	}
+	if env := os.Getenv("BIFROST_URL"); env != "" {
+		options.BaseURL = env
+	}
+	if options.Token == "" {
+		options.Token = os.Getenv("BIFROST_ADMIN_PASSWORD")
+	}
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	c := &client{
+		baseURL: strings.TrimRight(options.BaseURL, "/"),
+		token:   options.Token,
+		stdout:  options.Stdout,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	return run(ctx, c, args[2:])
+}
+
+// usage lists the available commands.
+func usage() string {
+	return "keys list|create|delete, config get|validate, logs tail, password rotate"
+}
+
+// newFlagSet creates the flag set of a subcommand with the shared -url and -token flags.
+func newFlagSet(name string, c *client) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.StringVar(&c.baseURL, "url", c.baseURL, "Bifrost management API address (env: BIFROST_URL)")
+	flags.StringVar(&c.token, "token", c.token, "Admin password (env: BIFROST_ADMIN_PASSWORD)")
+	return flags
+}
+
+// client is a minimal management API client.
+type client struct {
+	baseURL string
+	token   string
+	stdout  io.Writer
+	http    *http.Client
+}
+
+// apiErrorBody is the error body returned by the management API.
+type apiErrorBody struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// StatusError is returned for management API responses with an error status.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %s (%d)", e.Method, e.Path, e.Message, e.StatusCode)
+}
+
+// isNotFound reports whether err is a 404 response.
+func isNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a request and decodes the JSON response into out (skipped when out is nil).
+func (c *client) do(ctx context.Context, method string, path string, query url.Values, body any, out any) error {
+	endpoint := strings.TrimRight(c.baseURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		statusErr := &StatusError{Method: method, Path: path, StatusCode: resp.StatusCode, Message: resp.Status}
+		var body apiErrorBody
+		if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+			statusErr.Message = body.Error.Message
+		}
+		return statusErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s %s: invalid response: %w", method, path, err)
+	}
+	return nil
+}
+
+// printJSON writes v as indented JSON.
+func (c *client) printJSON(v any) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// errUsage is returned when a subcommand is missing a required flag.
+var errUsage = errors.New("missing required flag")
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestKeysCreateAppendsToExistingProvider verifies that the new key is added to the provider's keys.
+func TestKeysCreateAppendsToExistingProvider(t *testing.T) {
+	var updated map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("unexpected authorization header %q", got)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/providers/openai":
+			io.WriteString(w, `{"name":"openai","keys":[{"id":"primary","value":"sk-****abcd","weight":1}]}`)
+		case "PUT /api/providers/openai":
+			if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+				t.Errorf("invalid body: %v", err)
+			}
+			io.WriteString(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	args := []string{"keys", "create", "-provider", "openai", "-value", "env.OPENAI_API_KEY_2", "-id", "secondary"}
+	if err := Run(context.Background(), args, Options{BaseURL: server.URL, Token: "secret", Stdout: &out}); err != nil {
+		t.Fatalf("keys create failed: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "secondary" {
+		t.Errorf("expected the key ID to be printed, got %q", out.String())
+	}
+	keys, _ := updated["keys"].([]any)
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys in the update, got %v", updated["keys"])
+	}
+	if key := keys[1].(map[string]any); key["id"] != "secondary" || key["value"] != "env.OPENAI_API_KEY_2" {
+		t.Errorf("unexpected new key %v", key)
+	}
+}
+
+// TestKeysCreateAddsMissingProvider verifies that a provider that does not exist is created with the key.
+func TestKeysCreateAddsMissingProvider(t *testing.T) {
+	var created map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/providers/anthropic":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"is_bifrost_error":false,"error":{"message":"Provider not found"}}`)
+		case "POST /api/providers":
+			json.NewDecoder(r.Body).Decode(&created)
+			io.WriteString(w, `{}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	args := []string{"keys", "create", "-provider", "anthropic", "-value", "env.ANTHROPIC_API_KEY"}
+	if err := Run(context.Background(), args, Options{BaseURL: server.URL, Stdout: io.Discard}); err != nil {
+		t.Fatalf("keys create failed: %v", err)
+	}
+	if created["provider"] != "anthropic" {
+		t.Errorf("expected provider to be created, got %v", created)
+	}
+}
+
+// TestRunSurfacesAPIErrors verifies that API error messages are returned to the caller.
+func TestRunSurfacesAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"is_bifrost_error":false,"error":{"message":"Unauthorized"}}`)
+	}))
+	defer server.Close()
+
+	err := Run(context.Background(), []string{"config", "get"}, Options{BaseURL: server.URL, Stdout: io.Discard})
+	if err == nil || err.Error() != "GET /api/config: Unauthorized (401)" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := Run(context.Background(), []string{"keys", "rename"}, Options{BaseURL: server.URL}); err == nil {
+		t.Error("expected an unknown command to fail")
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// configGet implements `config get`.
+func configGet(ctx context.Context, c *client, args []string) error {
+	flags := newFlagSet("config get", c)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var config map[string]any
+	if err := c.do(ctx, http.MethodGet, "/api/config", nil, nil, &config); err != nil {
+		return err
+	}
+	return c.printJSON(config)
+}
+
+// configValidate implements `config validate`. It checks a config.json file locally,
+// without contacting the server.
+func configValidate(ctx context.Context, c *client, args []string) error {
+	flags := newFlagSet("config validate", c)
+	file := flags.String("file", "config.json", "Path to the config file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config lib.ConfigData
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("%s: invalid config: %w", *file, err)
+	}
+
+	if problems := validateConfigData(&config); len(problems) > 0 {
+		return fmt.Errorf("%s: invalid config:\n  %s", *file, strings.Join(problems, "\n  "))
+	}
+	fmt.Fprintf(c.stdout, "%s: config is valid\n", *file)
+	return nil
+}
+
+// validateConfigData applies the checks the management API performs on provider updates,
+// and reports env.VAR references that are not set in the current environment.
+func validateConfigData(config *lib.ConfigData) []string {
+	var problems []string
+	providers := make([]string, 0, len(config.Providers))
+	for name := range config.Providers {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	for _, name := range providers {
+		provider := config.Providers[name]
+		if err := lib.ValidateCustomProvider(provider, schemas.ModelProvider(name)); err != nil {
+			problems = append(problems, fmt.Sprintf("providers.%s: %v", name, err))
+		}
+		if err := lib.ValidateMockProvider(provider, schemas.ModelProvider(name)); err != nil {
+			problems = append(problems, fmt.Sprintf("providers.%s: %v", name, err))
+		}
+		if err := lib.ValidateRequestShaping(provider); err != nil {
+			problems = append(problems, fmt.Sprintf("providers.%s: %v", name, err))
+		}
+		if err := lib.ValidateSyntheticStreaming(provider); err != nil {
+			problems = append(problems, fmt.Sprintf("providers.%s: %v", name, err))
+		}
+		if err := lib.ValidateNetworkConfig(provider); err != nil {
+			problems = append(problems, fmt.Sprintf("providers.%s: %v", name, err))
+		}
+		if cb := provider.ConcurrencyAndBufferSize; cb != nil {
+			switch {
+			case cb.Concurrency <= 0:
+				problems = append(problems, fmt.Sprintf("providers.%s: concurrency must be greater than 0", name))
+			case cb.BufferSize <= 0:
+				problems = append(problems, fmt.Sprintf("providers.%s: buffer size must be greater than 0", name))
+			case cb.Concurrency > cb.BufferSize:
+				problems = append(problems, fmt.Sprintf("providers.%s: concurrency must be less than or equal to buffer size", name))
+			}
+		}
+		for i, key := range provider.Keys {
+			if err := checkEnvReference(key.Value); err != nil {
+				problems = append(problems, fmt.Sprintf("providers.%s.keys[%d]: %v", name, i, err))
+			}
+		}
+	}
+	if config.Egress != nil {
+		if err := config.Egress.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if config.TLS != nil {
+		if err := config.TLS.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if config.RequestSigning != nil {
+		if err := config.RequestSigning.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, key := range config.RequestSigning.Keys {
+			if err := checkEnvReference(key.Secret); err != nil {
+				problems = append(problems, fmt.Sprintf("request_signing.keys[%s]: %v", key.ID, err))
+			}
+		}
+	}
+	if config.JWTAuth != nil {
+		if err := config.JWTAuth.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// errEnvNotSet is reported for env.VAR references to unset variables.
+var errEnvNotSet = errors.New("environment variable is not set")
+
+// checkEnvReference checks that an env.VAR reference resolves.
+func checkEnvReference(value string) error {
+	name, ok := strings.CutPrefix(value, "env.")
+	if !ok {
+		return nil
+	}
+	if _, set := os.LookupEnv(name); !set {
+		return fmt.Errorf("%w: %s", errEnvNotSet, name)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// keysList implements `keys list`.
+func keysList(ctx context.Context, c *client, args []string) error {
+	flags := newFlagSet("keys list", c)
+	provider := flags.String("provider", "", "Only list keys of this provider")
+	asJSON := flags.Bool("json", false, "Print JSON instead of a table")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if *provider != "" {
+		query.Set("provider", *provider)
+	}
+	var keys []struct {
+		KeyID    string   `json:"key_id"`
+		Provider string   `json:"provider"`
+		Models   []string `json:"models"`
+		Weight   float64  `json:"weight"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/keys", query, nil, &keys); err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.printJSON(keys)
+	}
+
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROVIDER\tKEY ID\tWEIGHT\tMODELS")
+	for _, key := range keys {
+		models := strings.Join(key.Models, ",")
+		if models == "" {
+			models = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%g\t%s\n", key.Provider, key.KeyID, key.Weight, models)
+	}
+	return w.Flush()
+}
+
+// keysCreate implements `keys create`. The provider is created when it does not exist yet.
+func keysCreate(ctx context.Context, c *client, args []string) error {
+	flags := newFlagSet("keys create", c)
+	provider := flags.String("provider", "", "Provider to add the key to (required)")
+	value := flags.String("value", "", "Key value, or env.VAR to read it from the server environment (required)")
+	id := flags.String("id", "", "Key ID (default: a new UUID)")
+	models := flags.String("models", "", "Comma separated models the key may serve (default: all)")
+	weight := flags.Float64("weight", 1, "Load balancing weight")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *provider == "" || *value == "" {
+		return fmt.Errorf("%w: -provider and -value are required", errUsage)
+	}
+	if *id == "" {
+		*id = uuid.NewString()
+	}
+	key := schemas.Key{
+		ID:     *id,
+		Value:  *value,
+		Models: splitList(*models),
+		Weight: *weight,
+	}
+
+	path := "/api/providers/" + url.PathEscape(*provider)
+	var config map[string]any
+	err := c.do(ctx, http.MethodGet, path, nil, nil, &config)
+	switch {
+	case err == nil:
+		// Existing keys are sent back redacted, the server keeps their values
+		keys, _ := config["keys"].([]any)
+		config["keys"] = append(keys, key)
+		if err := c.do(ctx, http.MethodPut, path, nil, config, nil); err != nil {
+			return err
+		}
+	case isNotFound(err):
+		body := map[string]any{
+			"provider": *provider,
+			"keys":     []schemas.Key{key},
+		}
+		if err := c.do(ctx, http.MethodPost, "/api/providers", nil, body, nil); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, *id)
+	return nil
+}
+
+// keysDelete implements `keys delete`.
+func keysDelete(ctx context.Context, c *client, args []string) error {
+	flags := newFlagSet("keys delete", c)
+	provider := flags.String("provider", "", "Provider of the key (required)")
+	id := flags.String("id", "", "Key ID (required)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *provider == "" || *id == "" {
+		return fmt.Errorf("%w: -provider and -id are required", errUsage)
+	}
+
+	path := "/api/providers/" + url.PathEscape(*provider)
+	var config map[string]any
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &config); err != nil {
+		return err
+	}
+	keys, _ := config["keys"].([]any)
+	remaining := make([]any, 0, len(keys))
+	for _, key := range keys {
+		if fields, ok := key.(map[string]any); ok && fields["id"] == *id {
+			continue
+		}
+		remaining = append(remaining, key)
+	}
+	if len(remaining) == len(keys) {
+		return fmt.Errorf("key %s not found on provider %s", *id, *provider)
+	}
+	config["keys"] = remaining
+	if err := c.do(ctx, http.MethodPut, path, nil, config, nil); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "deleted key %s\n", *id)
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// logEntry is the subset of a log entry printed by `logs tail`.
+type logEntry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Status    string    `json:"status"`
+	Latency   *float64  `json:"latency,omitempty"`
+	Stream    bool      `json:"stream"`
+}
+
+// logsTail implements `logs tail`. With -follow it polls for new entries until interrupted.
+func logsTail(ctx context.Context, c *client, args []string) error {
+	flags := newFlagSet("logs tail", c)
+	limit := flags.Int("n", 20, "Number of recent entries to print")
+	follow := flags.Bool("follow", false, "Keep polling for new entries")
+	interval := flags.Duration("interval", 2*time.Second, "Polling interval with -follow")
+	asJSON := flags.Bool("json", false, "Print one JSON object per line")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *limit <= 0 {
+		return fmt.Errorf("%w: -n must be greater than 0", errUsage)
+	}
+
+	print := func(entry logEntry) error {
+		if *asJSON {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(c.stdout, string(data))
+			return err
+		}
+		latency := "-"
+		if entry.Latency != nil {
+			latency = strconv.FormatFloat(*entry.Latency, 'f', 0, 64) + "ms"
+		}
+		_, err := fmt.Fprintf(c.stdout, "%s  %s  %-8s  %s/%s  %s\n",
+			entry.Timestamp.Local().Format(time.RFC3339), entry.ID, entry.Status, entry.Provider, entry.Model, latency)
+		return err
+	}
+
+	seen := make(map[string]struct{})
+	var since time.Time
+	for {
+		entries, err := fetchLogs(ctx, c, *limit, since)
+		if err != nil {
+			return err
+		}
+		// The API returns the newest entries first, print them oldest first
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			if _, ok := seen[entry.ID]; ok {
+				continue
+			}
+			seen[entry.ID] = struct{}{}
+			if entry.Timestamp.After(since) {
+				since = entry.Timestamp
+			}
+			if err := print(entry); err != nil {
+				return err
+			}
+		}
+		if !*follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// fetchLogs returns up to limit entries newer than since (all entries when since is zero), newest first.
+func fetchLogs(ctx context.Context, c *client, limit int, since time.Time) ([]logEntry, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("sort_by", "timestamp")
+	query.Set("order", "desc")
+	if !since.IsZero() {
+		// start_time has second precision, entries already printed are skipped by ID
+		query.Set("start_time", since.UTC().Truncate(time.Second).Format(time.RFC3339))
+	}
+	var result struct {
+		Logs []logEntry `json:"logs"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/logs", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Logs, nil
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// passwordRotate implements `password rotate`. A random password is generated when -new is not set.
+func passwordRotate(ctx context.Context, c *client, args []string) error {
+	flags := newFlagSet("password rotate", c)
+	password := flags.String("new", "", "New admin password (default: a random 64 character password)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *password == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("failed to generate password: %w", err)
+		}
+		*password = hex.EncodeToString(buf)
+	}
+
+	body := map[string]string{"password": *password}
+	if err := c.do(ctx, http.MethodPut, "/api/admin/password", nil, body, nil); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, *password)
+	fmt.Fprintln(os.Stderr, "Admin password rotated. Update BIFROST_ADMIN_PASSWORD before the next restart.")
+	return nil
+}
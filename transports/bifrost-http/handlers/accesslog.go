@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// accessLogPluginName is the name of the internal plugin that reports provider, model and key to the access log.
+const accessLogPluginName = "access-log"
+
+// accessLogEntry is a single access log line.
+type accessLogEntry struct {
+	Time       time.Time         `json:"time"`
+	RemoteAddr string            `json:"remote_addr"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Protocol   string            `json:"protocol"`
+	Status     int               `json:"status"`
+	Bytes      int               `json:"bytes"` // -1 for streamed responses
+	LatencyMs  float64           `json:"latency_ms"`
+	Referer    string            `json:"referer,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	KeyID      string            `json:"key_id,omitempty"`
+	Provider   string            `json:"provider,omitempty"`
+	Model      string            `json:"model,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// AccessLogMiddleware writes one line per HTTP request in the configured format.
+// Excluded paths are skipped, other requests are sampled per route; server errors (5xx) are always logged.
+// For streamed responses latency covers the time to the first byte and bytes is -1.
+func AccessLogMiddleware(config *lib.AccessLogConfig, w io.Writer) lib.BifrostHTTPMiddleware {
+	var mu sync.Mutex
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			path := string(ctx.Path())
+			if config.IsExcluded(path) {
+				next(ctx)
+				return
+			}
+			requestInfo := &lib.RequestInfo{}
+			ctx.SetUserValue(lib.RequestInfoContextKey, requestInfo)
+			start := time.Now()
+			next(ctx)
+			status := ctx.Response.StatusCode()
+			if status < fasthttp.StatusInternalServerError {
+				if rate := config.SampleRateFor(path); rate < 1 && rand.Float64() >= rate {
+					return
+				}
+			}
+			entry := &accessLogEntry{
+				Time:       start,
+				RemoteAddr: ctx.RemoteIP().String(),
+				Method:     string(ctx.Method()),
+				Path:       path,
+				Protocol:   string(ctx.Request.Header.Protocol()),
+				Status:     status,
+				Bytes:      len(ctx.Response.Body()),
+				LatencyMs:  float64(time.Since(start).Microseconds()) / 1000,
+				Referer:    string(ctx.Request.Header.Referer()),
+				UserAgent:  string(ctx.Request.Header.UserAgent()),
+			}
+			if ctx.Response.IsBodyStream() {
+				entry.Bytes = -1
+			}
+			entry.Provider, entry.Model, entry.KeyID = requestInfo.Get()
+			entry.Labels, _ = ctx.UserValue(schemas.BifrostContextKeyLabels).(map[string]string)
+			line, err := formatAccessLogEntry(config.Format, entry)
+			if err != nil {
+				logger.Warn("failed to format access log entry: %v", err)
+				return
+			}
+			mu.Lock()
+			_, err = w.Write(line)
+			mu.Unlock()
+			if err != nil {
+				logger.Warn("failed to write access log entry: %v", err)
+			}
+		}
+	}
+}
+
+// formatAccessLogEntry renders an entry as a newline terminated line.
+// The combined format appends latency, key, provider, model and labels as key=value pairs after the standard fields,
+// the labels quoted in the name=value format of the X-Bifrost-Labels header.
+func formatAccessLogEntry(format lib.AccessLogFormat, entry *accessLogEntry) ([]byte, error) {
+	if format == lib.AccessLogFormatCombined {
+		bytes := "-"
+		if entry.Bytes >= 0 {
+			bytes = strconv.Itoa(entry.Bytes)
+		}
+		labels := "-"
+		if len(entry.Labels) > 0 {
+			pairs := make([]string, 0, len(entry.Labels))
+			for name, value := range entry.Labels {
+				pairs = append(pairs, name+"="+value)
+			}
+			slices.Sort(pairs)
+			labels = strconv.Quote(strings.Join(pairs, ","))
+		}
+		line := fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s %q %q rt=%.3f key_id=%s provider=%s model=%s labels=%s\n",
+			entry.RemoteAddr,
+			entry.Time.Format("02/Jan/2006:15:04:05 -0700"),
+			entry.Method, entry.Path, entry.Protocol,
+			entry.Status, bytes,
+			orDash(entry.Referer), orDash(entry.UserAgent),
+			entry.LatencyMs/1000,
+			orDash(entry.KeyID), orDash(entry.Provider), orDash(entry.Model), labels,
+		)
+		return []byte(line), nil
+	}
+	line, err := sonic.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// orDash returns "-" for empty values, as Apache does.
+func orDash(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "-"
+	}
+	return value
+}
+
+// accessLogPlugin copies the routed provider, model and selected key of a request into its lib.RequestInfo.
+type accessLogPlugin struct{}
+
+// GetName returns the name of the plugin
+func (p *accessLogPlugin) GetName() string {
+	return accessLogPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *accessLogPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook records the provider and model the request is routed to
+func (p *accessLogPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if requestInfo, ok := (*ctx).Value(lib.RequestInfoContextKey).(*lib.RequestInfo); ok {
+		requestInfo.SetRoute(string(req.Provider), req.Model)
+	}
+	return req, nil, nil
+}
+
+// PostHook records the key that served the request (fallbacks overwrite the primary attempt)
+func (p *accessLogPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	requestInfo, ok := (*ctx).Value(lib.RequestInfoContextKey).(*lib.RequestInfo)
+	if !ok {
+		return result, bifrostErr, nil
+	}
+	if keyID, ok := (*ctx).Value(schemas.BifrostContextKeySelectedKey).(string); ok {
+		requestInfo.SetKeyID(keyID)
+	}
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *accessLogPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// runAccessLogged runs a request for path through the access log middleware
+func runAccessLogged(config *lib.AccessLogConfig, path string, status int) string {
+	var out bytes.Buffer
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.SetRequestURI(path)
+	handler := AccessLogMiddleware(config, &out)(func(ctx *fasthttp.RequestCtx) {
+		if requestInfo, ok := ctx.UserValue(lib.RequestInfoContextKey).(*lib.RequestInfo); ok {
+			requestInfo.SetRoute("openai", "gpt-4o-mini")
+			requestInfo.SetKeyID("key-1")
+		}
+		ctx.SetStatusCode(status)
+		ctx.SetBodyString("hello")
+	})
+	handler(ctx)
+	return out.String()
+}
+
+// TestAccessLogMiddleware_JSON tests that JSON lines carry the request, response and routing fields
+func TestAccessLogMiddleware_JSON(t *testing.T) {
+	line := runAccessLogged(&lib.AccessLogConfig{Enabled: true}, "/v1/chat/completions", fasthttp.StatusOK)
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", line, err)
+	}
+	expected := map[string]any{
+		"method":   "POST",
+		"path":     "/v1/chat/completions",
+		"status":   float64(200),
+		"bytes":    float64(5),
+		"provider": "openai",
+		"model":    "gpt-4o-mini",
+		"key_id":   "key-1",
+	}
+	for field, want := range expected {
+		if entry[field] != want {
+			t.Errorf("Expected %s=%v, got %v", field, want, entry[field])
+		}
+	}
+}
+
+// TestAccessLogMiddleware_Combined tests the Apache combined format
+func TestAccessLogMiddleware_Combined(t *testing.T) {
+	line := runAccessLogged(&lib.AccessLogConfig{Enabled: true, Format: lib.AccessLogFormatCombined}, "/v1/chat/completions", fasthttp.StatusOK)
+
+	if !strings.Contains(line, `"POST /v1/chat/completions HTTP/1.1" 200 5 "-" "-"`) {
+		t.Errorf("Expected combined log fields, got %q", line)
+	}
+	if !strings.Contains(line, "key_id=key-1 provider=openai model=gpt-4o-mini") {
+		t.Errorf("Expected routing fields, got %q", line)
+	}
+}
+
+// TestAccessLogMiddleware_ExcludeAndSample tests excluded paths, route sampling and that server errors bypass sampling
+func TestAccessLogMiddleware_ExcludeAndSample(t *testing.T) {
+	config := &lib.AccessLogConfig{
+		Enabled: true,
+		Routes:  []lib.AccessLogRoute{{PathPrefix: "/api/", SampleRate: 0}},
+	}
+	if line := runAccessLogged(config, "/health", fasthttp.StatusOK); line != "" {
+		t.Errorf("Expected /health to be excluded by default, got %q", line)
+	}
+	if line := runAccessLogged(config, "/api/providers", fasthttp.StatusOK); line != "" {
+		t.Errorf("Expected /api/providers to be sampled out, got %q", line)
+	}
+	if line := runAccessLogged(config, "/api/providers", fasthttp.StatusInternalServerError); line == "" {
+		t.Error("Expected server errors to always be logged")
+	}
+	if line := runAccessLogged(config, "/v1/models", fasthttp.StatusOK); line == "" {
+		t.Error("Expected routes without an override to use the default sample rate")
+	}
+}
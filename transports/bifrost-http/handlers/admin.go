@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// minAdminPasswordLength is the minimum length of a rotated admin password.
+const minAdminPasswordLength = 12
+
+// AdminHandler manages the admin credentials.
+type AdminHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// RotatePasswordRequest is the body of PUT /api/admin/password.
+type RotatePasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// NewAdminHandler creates a new admin handler.
+func NewAdminHandler(store *lib.Config, logger schemas.Logger) *AdminHandler {
+	return &AdminHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the admin routes.
+func (h *AdminHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.PUT("/api/admin/password", lib.ChainMiddlewares(h.rotatePassword, middlewares...))
+}
+
+// rotatePassword handles PUT /api/admin/password - Rotate the admin password
+// The request must be authenticated with the current password (enforced by AdminAuthMiddleware).
+func (h *AdminHandler) rotatePassword(ctx *fasthttp.RequestCtx) {
+	if strings.TrimSpace(h.store.GetAdminSecret()) == "" {
+		SendError(ctx, fasthttp.StatusConflict, "admin auth is not enabled, set BIFROST_ADMIN_PASSWORD to enable it", h.logger)
+		return
+	}
+
+	var req RotatePasswordRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if len(strings.TrimSpace(req.Password)) < minAdminPasswordLength {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("password must be at least %d characters", minAdminPasswordLength), h.logger)
+		return
+	}
+
+	h.store.SetAdminSecret(ctx, req.Password)
+	h.logger.Info("admin password rotated")
+
+	SendJSON(ctx, map[string]any{
+		"status":  "success",
+		"message": "Admin password rotated. Update BIFROST_ADMIN_PASSWORD to keep it across restarts.",
+	}, h.logger)
+}
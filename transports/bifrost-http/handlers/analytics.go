@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultLatencyMatrixDays = 7
+	maxLatencyMatrixDays     = 90
+)
+
+// LatencyMatrixResponse is the JSON body of GET /api/analytics/latency-matrix.
+type LatencyMatrixResponse struct {
+	StartTime time.Time              `json:"start_time"`
+	EndTime   time.Time              `json:"end_time"` // Exclusive, the end of the current hour
+	Days      int                    `json:"days"`
+	Cells     []logstore.LatencyCell `json:"cells"` // Ordered by provider, model and hour; hours without requests are left out
+}
+
+// AnalyticsHandler serves aggregates of the logs.
+type AnalyticsHandler struct {
+	store  logstore.LogStore
+	logger schemas.Logger
+}
+
+// NewAnalyticsHandler creates a new analytics handler.
+func NewAnalyticsHandler(store logstore.LogStore, logger schemas.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the analytics routes.
+func (h *AnalyticsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/analytics/latency-matrix", lib.ChainMiddlewares(h.getLatencyMatrix, middlewares...))
+}
+
+// getLatencyMatrix handles GET /api/analytics/latency-matrix - p50, p95 and p99 latency of successful requests per
+// provider, model and hour over the last days (days, providers, models)
+func (h *AnalyticsHandler) getLatencyMatrix(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	days := defaultLatencyMatrixDays
+	if value := string(args.Peek("days")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLatencyMatrixDays {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("invalid days %q, expected 1 to %d", value, maxLatencyMatrixDays), h.logger)
+			return
+		}
+		days = parsed
+	}
+	end := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	start := end.AddDate(0, 0, -days)
+
+	cells, err := h.store.LatencyMatrix(ctx, logstore.LatencyMatrixFilters{
+		Providers: parseCommaSeparated(string(args.Peek("providers"))),
+		Models:    parseCommaSeparated(string(args.Peek("models"))),
+		StartTime: &start,
+		EndTime:   &end,
+	})
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to compute latency matrix: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, LatencyMatrixResponse{StartTime: start, EndTime: end, Days: days, Cells: cells}, h.logger)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/valyala/fasthttp"
+)
+
+// TestAnalytics_LatencyMatrix tests that the latency matrix covers the requested days up to the current hour and
+// is filtered by provider
+func TestAnalytics_LatencyMatrix(t *testing.T) {
+	store, err := logstore.NewLogStore(context.Background(), &logstore.Config{
+		Type:   logstore.LogStoreTypeSQLite,
+		Config: &logstore.SQLiteConfig{Path: filepath.Join(t.TempDir(), "logs.db")},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create log store: %v", err)
+	}
+	now := time.Now().UTC()
+	for _, entry := range []*logstore.Log{
+		{ID: "1", Provider: "openai", Model: "gpt-4o", Status: "success", Timestamp: now, Latency: bifrost.Ptr(120.0)},
+		{ID: "2", Provider: "anthropic", Model: "claude", Status: "success", Timestamp: now.Add(-time.Hour), Latency: bifrost.Ptr(300.0)},
+		{ID: "3", Provider: "openai", Model: "gpt-4o", Status: "success", Timestamp: now.AddDate(0, 0, -3), Latency: bifrost.Ptr(80.0)},
+	} {
+		entry.CreatedAt = entry.Timestamp
+		if err := store.Create(context.Background(), entry); err != nil {
+			t.Fatalf("failed to create log: %v", err)
+		}
+	}
+	h := NewAnalyticsHandler(store, logger)
+
+	get := func(query string) (*LatencyMatrixResponse, int) {
+		ctx := billingRequestCtx("/api/analytics/latency-matrix?" + query)
+		h.getLatencyMatrix(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			return nil, ctx.Response.StatusCode()
+		}
+		var response LatencyMatrixResponse
+		if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return &response, fasthttp.StatusOK
+	}
+
+	response, status := get("days=2")
+	if status != fasthttp.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if !response.EndTime.After(now) || response.EndTime.Sub(response.StartTime) != 48*time.Hour || len(response.Cells) != 2 {
+		t.Fatalf("unexpected matrix %+v, want the two requests of the last two days", response)
+	}
+	if cell := response.Cells[1]; cell.Provider != "openai" || !cell.Hour.Equal(now.Truncate(time.Hour)) || cell.Requests != 1 || cell.P99 != 120 {
+		t.Errorf("unexpected openai cell %+v", cell)
+	}
+
+	response, _ = get("providers=openai")
+	if response == nil || response.Days != defaultLatencyMatrixDays || len(response.Cells) != 2 || response.Cells[0].P50 != 80 {
+		t.Errorf("unexpected openai matrix %+v, want both openai requests of the last week", response)
+	}
+	for _, query := range []string{"days=0", "days=91", "days=week"} {
+		if _, status := get(query); status != fasthttp.StatusBadRequest {
+			t.Errorf("status = %d for %s, want 400", status, query)
+		}
+	}
+}
@@ -0,0 +1,591 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// DesiredState is the document accepted by POST /api/apply.
+// Providers and budgets listed in the document are created or updated to match it. The keys of a
+// listed provider are exactly the keys in the document. Providers missing from the document are
+// only removed when Prune is set; budgets are never removed since they are owned by virtual keys,
+// teams and customers.
+type DesiredState struct {
+	Providers map[schemas.ModelProvider]DesiredProvider `json:"providers,omitempty"`
+	Budgets   []DesiredBudget                           `json:"budgets,omitempty"`
+	Prune     bool                                      `json:"prune,omitempty"` // Remove providers missing from the document
+}
+
+// DesiredProvider is the desired configuration of a provider.
+// Key IDs are required so that keys can be matched across applies; key values may be env.VAR references.
+type DesiredProvider struct {
+	Keys                     []schemas.Key                     `json:"keys"`
+	NetworkConfig            *schemas.NetworkConfig            `json:"network_config,omitempty"`
+	ConcurrencyAndBufferSize *schemas.ConcurrencyAndBufferSize `json:"concurrency_and_buffer_size,omitempty"`
+	ProxyConfig              *schemas.ProxyConfig              `json:"proxy_config,omitempty"`
+	SendBackRawResponse      bool                              `json:"send_back_raw_response,omitempty"`
+	CustomProviderConfig     *schemas.CustomProviderConfig     `json:"custom_provider_config,omitempty"`
+	MockConfig               *schemas.MockProviderConfig       `json:"mock_config,omitempty"`
+	RequestShaping           *schemas.RequestShapingConfig     `json:"request_shaping,omitempty"`
+	SyntheticStreaming       *schemas.SyntheticStreamingConfig `json:"synthetic_streaming,omitempty"`
+}
+
+// DesiredBudget is the desired configuration of a governance budget.
+type DesiredBudget struct {
+	ID            string   `json:"id"`
+	MaxLimit      float64  `json:"max_limit"`
+	ResetDuration string   `json:"reset_duration,omitempty"`
+	ResetSchedule string   `json:"reset_schedule,omitempty"`
+	Rollover      bool     `json:"rollover,omitempty"`
+	RolloverCap   *float64 `json:"rollover_cap,omitempty"`
+}
+
+// ApplyAction is the action a change performs.
+type ApplyAction string
+
+const (
+	ApplyActionCreate ApplyAction = "create"
+	ApplyActionUpdate ApplyAction = "update"
+	ApplyActionDelete ApplyAction = "delete"
+)
+
+// ApplyChange is one entry of an apply plan.
+type ApplyChange struct {
+	Resource string      `json:"resource"` // provider, key or budget
+	Name     string      `json:"name"`     // provider name, provider/key_id or budget ID
+	Action   ApplyAction `json:"action"`
+	Fields   []string    `json:"fields,omitempty"` // Changed fields of updates
+}
+
+// ApplyResponse is the response of POST /api/apply. An empty plan means the configuration already matches.
+type ApplyResponse struct {
+	DryRun  bool          `json:"dry_run"`
+	Changes []ApplyChange `json:"changes"`
+}
+
+// ApplyHandler serves the declarative provisioning endpoint.
+type ApplyHandler struct {
+	store           *lib.Config
+	client          *bifrost.Bifrost
+	governanceStore *governance.GovernanceStore // nil when governance is disabled
+	logger          schemas.Logger
+	mu              sync.Mutex // Serializes plan and apply
+}
+
+// NewApplyHandler creates a new apply handler. governanceStore may be nil.
+func NewApplyHandler(store *lib.Config, client *bifrost.Bifrost, governanceStore *governance.GovernanceStore, logger schemas.Logger) *ApplyHandler {
+	return &ApplyHandler{
+		store:           store,
+		client:          client,
+		governanceStore: governanceStore,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes registers the apply route.
+func (h *ApplyHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST("/api/apply", lib.ChainMiddlewares(h.apply, middlewares...))
+}
+
+// providerPlan is the planned change of one provider.
+type providerPlan struct {
+	name     schemas.ModelProvider
+	action   ApplyAction
+	desired  DesiredProvider
+	existing *configstore.ProviderConfig // nil on create
+}
+
+// budgetPlan is the planned change of one budget.
+type budgetPlan struct {
+	action   ApplyAction
+	desired  DesiredBudget
+	existing *configstore.TableBudget // nil on create
+}
+
+// applyPlan is the plan computed from a desired state.
+type applyPlan struct {
+	changes   []ApplyChange
+	providers []providerPlan
+	budgets   []budgetPlan
+}
+
+// apply handles POST /api/apply - Converge the configuration to a desired state (?dry_run=true returns the plan only)
+func (h *ApplyHandler) apply(ctx *fasthttp.RequestCtx) {
+	var desired DesiredState
+	decoder := json.NewDecoder(bytes.NewReader(ctx.PostBody()))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&desired); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid desired state: %v", err), h.logger)
+		return
+	}
+	if err := validateDesiredState(&desired); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	if len(desired.Budgets) > 0 && h.store.ConfigStore == nil {
+		SendError(ctx, fasthttp.StatusBadRequest, "Budgets require a config store", h.logger)
+		return
+	}
+	dryRun := string(ctx.QueryArgs().Peek("dry_run")) == "true"
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	plan, err := h.plan(ctx, &desired)
+	if err != nil {
+		h.logger.Error("failed to compute apply plan: %v", err)
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to compute plan: %v", err), h.logger)
+		return
+	}
+
+	if !dryRun {
+		if err := h.execute(ctx, plan); err != nil {
+			// Changes before the failing one stay applied; re-applying the document converges
+			h.logger.Error("failed to apply desired state: %v", err)
+			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to apply: %v", err), h.logger)
+			return
+		}
+		h.logger.Info("applied desired state with %d changes", len(plan.changes))
+	}
+
+	SendJSON(ctx, ApplyResponse{
+		DryRun:  dryRun,
+		Changes: plan.changes,
+	}, h.logger)
+}
+
+// validateDesiredState checks a desired state before planning.
+func validateDesiredState(desired *DesiredState) error {
+	for name, provider := range desired.Providers {
+		if name == "" {
+			return fmt.Errorf("provider name cannot be empty")
+		}
+		if err := lib.ValidateCustomProvider(configstore.ProviderConfig{CustomProviderConfig: provider.CustomProviderConfig, NetworkConfig: provider.NetworkConfig}, name); err != nil {
+			return fmt.Errorf("provider %s: %w", name, err)
+		}
+		if err := lib.ValidateMockProvider(configstore.ProviderConfig{MockConfig: provider.MockConfig}, name); err != nil {
+			return fmt.Errorf("provider %s: %w", name, err)
+		}
+		if err := lib.ValidateRequestShaping(configstore.ProviderConfig{RequestShaping: provider.RequestShaping}); err != nil {
+			return fmt.Errorf("provider %s: %w", name, err)
+		}
+		if err := lib.ValidateSyntheticStreaming(configstore.ProviderConfig{SyntheticStreaming: provider.SyntheticStreaming}); err != nil {
+			return fmt.Errorf("provider %s: %w", name, err)
+		}
+		if err := lib.ValidateNetworkConfig(configstore.ProviderConfig{NetworkConfig: provider.NetworkConfig, ProxyConfig: provider.ProxyConfig}); err != nil {
+			return fmt.Errorf("provider %s: %w", name, err)
+		}
+		if cb := provider.ConcurrencyAndBufferSize; cb != nil {
+			if cb.Concurrency == 0 || cb.BufferSize == 0 {
+				return fmt.Errorf("provider %s: concurrency and buffer size must be greater than 0", name)
+			}
+			if cb.Concurrency > cb.BufferSize {
+				return fmt.Errorf("provider %s: concurrency must be less than or equal to buffer size", name)
+			}
+		}
+		seen := make(map[string]bool, len(provider.Keys))
+		for _, key := range provider.Keys {
+			if key.ID == "" {
+				return fmt.Errorf("provider %s: every key needs an id", name)
+			}
+			if lib.IsRedacted(key.Value) && !strings.HasPrefix(key.Value, "env.") {
+				return fmt.Errorf("provider %s: key %s has a redacted value", name, key.ID)
+			}
+			if seen[key.ID] {
+				return fmt.Errorf("provider %s: duplicate key id %s", name, key.ID)
+			}
+			seen[key.ID] = true
+		}
+	}
+	seen := make(map[string]bool, len(desired.Budgets))
+	for _, budget := range desired.Budgets {
+		if budget.ID == "" {
+			return fmt.Errorf("every budget needs an id")
+		}
+		if seen[budget.ID] {
+			return fmt.Errorf("duplicate budget id %s", budget.ID)
+		}
+		seen[budget.ID] = true
+		if budget.MaxLimit < 0 {
+			return fmt.Errorf("budget %s: max_limit cannot be negative", budget.ID)
+		}
+		if err := configstore.ValidateBudgetReset(budget.ResetSchedule, budget.ResetDuration); err != nil {
+			return fmt.Errorf("budget %s: %w", budget.ID, err)
+		}
+		if budget.RolloverCap != nil && *budget.RolloverCap < 0 {
+			return fmt.Errorf("budget %s: rollover_cap cannot be negative", budget.ID)
+		}
+	}
+	return nil
+}
+
+// plan diffs the desired state against the current configuration.
+func (h *ApplyHandler) plan(ctx context.Context, desired *DesiredState) (*applyPlan, error) {
+	plan := &applyPlan{}
+
+	names := make([]schemas.ModelProvider, 0, len(desired.Providers))
+	for name := range desired.Providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		want := desired.Providers[name]
+		existing, err := h.store.GetProviderConfigRaw(name)
+		if err != nil && !errors.Is(err, lib.ErrNotFound) {
+			return nil, err
+		}
+		if existing == nil {
+			plan.changes = append(plan.changes, ApplyChange{Resource: "provider", Name: string(name), Action: ApplyActionCreate})
+			for _, key := range want.Keys {
+				plan.changes = append(plan.changes, ApplyChange{Resource: "key", Name: string(name) + "/" + key.ID, Action: ApplyActionCreate})
+			}
+			plan.providers = append(plan.providers, providerPlan{name: name, action: ApplyActionCreate, desired: want})
+			continue
+		}
+
+		redacted, err := h.store.GetProviderConfigRedacted(name)
+		if err != nil {
+			return nil, err
+		}
+		fields := diffProviderFields(want, existing)
+		keyChanges := diffProviderKeys(name, want.Keys, existing.Keys, redacted.Keys)
+		if len(fields) == 0 && len(keyChanges) == 0 {
+			continue
+		}
+		if len(keyChanges) > 0 {
+			fields = append(fields, "keys")
+		}
+		plan.changes = append(plan.changes, ApplyChange{Resource: "provider", Name: string(name), Action: ApplyActionUpdate, Fields: fields})
+		plan.changes = append(plan.changes, keyChanges...)
+		plan.providers = append(plan.providers, providerPlan{name: name, action: ApplyActionUpdate, desired: want, existing: existing})
+	}
+
+	if desired.Prune {
+		current, err := h.store.GetAllProviders()
+		if err != nil {
+			return nil, err
+		}
+		slices.Sort(current)
+		for _, name := range current {
+			if _, ok := desired.Providers[name]; ok {
+				continue
+			}
+			plan.changes = append(plan.changes, ApplyChange{Resource: "provider", Name: string(name), Action: ApplyActionDelete})
+			plan.providers = append(plan.providers, providerPlan{name: name, action: ApplyActionDelete})
+		}
+	}
+
+	for _, want := range desired.Budgets {
+		existing, err := h.store.ConfigStore.GetBudget(ctx, want.ID)
+		if err != nil && !errors.Is(err, configstore.ErrNotFound) {
+			return nil, err
+		}
+		if existing == nil {
+			plan.changes = append(plan.changes, ApplyChange{Resource: "budget", Name: want.ID, Action: ApplyActionCreate})
+			plan.budgets = append(plan.budgets, budgetPlan{action: ApplyActionCreate, desired: want})
+			continue
+		}
+		var fields []string
+		if existing.MaxLimit != want.MaxLimit {
+			fields = append(fields, "max_limit")
+		}
+		if existing.ResetDuration != want.ResetDuration {
+			fields = append(fields, "reset_duration")
+		}
+		if existing.ResetSchedule != want.ResetSchedule {
+			fields = append(fields, "reset_schedule")
+		}
+		if existing.Rollover != want.Rollover {
+			fields = append(fields, "rollover")
+		}
+		if !reflect.DeepEqual(existing.RolloverCap, want.RolloverCap) {
+			fields = append(fields, "rollover_cap")
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		plan.changes = append(plan.changes, ApplyChange{Resource: "budget", Name: want.ID, Action: ApplyActionUpdate, Fields: fields})
+		plan.budgets = append(plan.budgets, budgetPlan{action: ApplyActionUpdate, desired: want, existing: existing})
+	}
+
+	if plan.changes == nil {
+		plan.changes = []ApplyChange{}
+	}
+	return plan, nil
+}
+
+// execute applies a plan in order: providers first, then budgets.
+func (h *ApplyHandler) execute(ctx context.Context, plan *applyPlan) error {
+	for _, p := range plan.providers {
+		switch p.action {
+		case ApplyActionCreate:
+			if err := h.store.AddProvider(ctx, p.name, desiredProviderConfig(p.desired)); err != nil {
+				return fmt.Errorf("provider %s: %w", p.name, err)
+			}
+		case ApplyActionUpdate:
+			config := desiredProviderConfig(p.desired)
+			// Env references are re-registered for the new keys
+			h.store.CleanupEnvKeysForKeys(p.name, p.existing.Keys)
+			if err := h.store.UpdateProviderConfig(ctx, p.name, config); err != nil {
+				return fmt.Errorf("provider %s: %w", p.name, err)
+			}
+			if !reflect.DeepEqual(concurrencyOrDefault(config.ConcurrencyAndBufferSize), concurrencyOrDefault(p.existing.ConcurrencyAndBufferSize)) ||
+				!reflect.DeepEqual(config.MockConfig, p.existing.MockConfig) ||
+				!reflect.DeepEqual(config.RequestShaping, p.existing.RequestShaping) ||
+				!reflect.DeepEqual(config.SyntheticStreaming, p.existing.SyntheticStreaming) ||
+				!reflect.DeepEqual(providerSpec(config.CustomProviderConfig), providerSpec(p.existing.CustomProviderConfig)) ||
+				!reflect.DeepEqual(httpClientConfig(config.NetworkConfig), httpClientConfig(p.existing.NetworkConfig)) ||
+				!reflect.DeepEqual(config.ProxyConfig, p.existing.ProxyConfig) {
+				if err := h.client.UpdateProviderConcurrency(p.name); err != nil {
+					// The store update succeeded, so only log the concurrency update failure
+					bifrost.WithFields(h.logger, "provider", p.name).Warn("failed to update provider concurrency: %v", err)
+				}
+			}
+		case ApplyActionDelete:
+			if err := h.store.RemoveProvider(ctx, p.name); err != nil {
+				return fmt.Errorf("provider %s: %w", p.name, err)
+			}
+		}
+	}
+
+	for _, b := range plan.budgets {
+		switch b.action {
+		case ApplyActionCreate:
+			budget := &configstore.TableBudget{
+				ID:            b.desired.ID,
+				MaxLimit:      b.desired.MaxLimit,
+				ResetDuration: b.desired.ResetDuration,
+				ResetSchedule: b.desired.ResetSchedule,
+				Rollover:      b.desired.Rollover,
+				RolloverCap:   b.desired.RolloverCap,
+				LastReset:     time.Now(),
+			}
+			if err := h.store.ConfigStore.CreateBudget(ctx, budget); err != nil {
+				return fmt.Errorf("budget %s: %w", b.desired.ID, err)
+			}
+			if h.governanceStore != nil {
+				h.governanceStore.CreateBudgetInMemory(budget)
+			}
+		case ApplyActionUpdate:
+			budget := *b.existing
+			budget.MaxLimit = b.desired.MaxLimit
+			budget.ResetDuration = b.desired.ResetDuration
+			budget.ResetSchedule = b.desired.ResetSchedule
+			budget.Rollover = b.desired.Rollover
+			budget.RolloverCap = b.desired.RolloverCap
+			if err := h.store.ConfigStore.UpdateBudget(ctx, &budget); err != nil {
+				return fmt.Errorf("budget %s: %w", b.desired.ID, err)
+			}
+			if h.governanceStore != nil {
+				if err := h.governanceStore.UpdateBudgetInMemory(&budget); err != nil {
+					return fmt.Errorf("budget %s: %w", b.desired.ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// desiredProviderConfig converts a desired provider to a provider config.
+// Keys are copied since the store resolves env references in place.
+func desiredProviderConfig(desired DesiredProvider) configstore.ProviderConfig {
+	return configstore.ProviderConfig{
+		Keys:                     slices.Clone(desired.Keys),
+		NetworkConfig:            desired.NetworkConfig,
+		ConcurrencyAndBufferSize: desired.ConcurrencyAndBufferSize,
+		ProxyConfig:              desired.ProxyConfig,
+		SendBackRawResponse:      desired.SendBackRawResponse,
+		CustomProviderConfig:     desired.CustomProviderConfig,
+		MockConfig:               desired.MockConfig,
+		RequestShaping:           desired.RequestShaping,
+		SyntheticStreaming:       desired.SyntheticStreaming,
+	}
+}
+
+// diffProviderFields returns the names of the provider level fields that differ.
+func diffProviderFields(desired DesiredProvider, existing *configstore.ProviderConfig) []string {
+	var fields []string
+	if !reflect.DeepEqual(networkConfigOrDefault(desired.NetworkConfig), networkConfigOrDefault(existing.NetworkConfig)) {
+		fields = append(fields, "network_config")
+	}
+	if !reflect.DeepEqual(concurrencyOrDefault(desired.ConcurrencyAndBufferSize), concurrencyOrDefault(existing.ConcurrencyAndBufferSize)) {
+		fields = append(fields, "concurrency_and_buffer_size")
+	}
+	if !reflect.DeepEqual(desired.ProxyConfig, existing.ProxyConfig) {
+		fields = append(fields, "proxy_config")
+	}
+	if desired.SendBackRawResponse != existing.SendBackRawResponse {
+		fields = append(fields, "send_back_raw_response")
+	}
+	if !reflect.DeepEqual(desired.CustomProviderConfig, existing.CustomProviderConfig) {
+		fields = append(fields, "custom_provider_config")
+	}
+	if !reflect.DeepEqual(desired.MockConfig, existing.MockConfig) {
+		fields = append(fields, "mock_config")
+	}
+	if !reflect.DeepEqual(desired.RequestShaping, existing.RequestShaping) {
+		fields = append(fields, "request_shaping")
+	}
+	if !reflect.DeepEqual(desired.SyntheticStreaming, existing.SyntheticStreaming) {
+		fields = append(fields, "synthetic_streaming")
+	}
+	return fields
+}
+
+// diffProviderKeys returns the key changes of a provider, sorted by key ID.
+// Existing keys are compared in their declared form, with env.VAR references in place of resolved values.
+func diffProviderKeys(provider schemas.ModelProvider, desired []schemas.Key, existingRaw []schemas.Key, existingRedacted []schemas.Key) []ApplyChange {
+	redactedByID := make(map[string]schemas.Key, len(existingRedacted))
+	for _, key := range existingRedacted {
+		redactedByID[key.ID] = key
+	}
+	existingByID := make(map[string]schemas.Key, len(existingRaw))
+	for _, key := range existingRaw {
+		existingByID[key.ID] = declaredKey(key, redactedByID[key.ID])
+	}
+
+	var changes []ApplyChange
+	desiredIDs := make(map[string]bool, len(desired))
+	for _, key := range desired {
+		desiredIDs[key.ID] = true
+		name := string(provider) + "/" + key.ID
+		existing, ok := existingByID[key.ID]
+		if !ok {
+			changes = append(changes, ApplyChange{Resource: "key", Name: name, Action: ApplyActionCreate})
+			continue
+		}
+		if fields := diffKeyFields(key, existing); len(fields) > 0 {
+			changes = append(changes, ApplyChange{Resource: "key", Name: name, Action: ApplyActionUpdate, Fields: fields})
+		}
+	}
+	for _, key := range existingRaw {
+		if !desiredIDs[key.ID] {
+			changes = append(changes, ApplyChange{Resource: "key", Name: string(provider) + "/" + key.ID, Action: ApplyActionDelete})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// diffKeyFields returns the names of the key fields that differ.
+func diffKeyFields(desired schemas.Key, existing schemas.Key) []string {
+	var fields []string
+	if desired.Value != existing.Value {
+		fields = append(fields, "value")
+	}
+	if !(len(desired.Models) == 0 && len(existing.Models) == 0) && !slices.Equal(desired.Models, existing.Models) {
+		fields = append(fields, "models")
+	}
+	if desired.Weight != existing.Weight {
+		fields = append(fields, "weight")
+	}
+	if !reflect.DeepEqual(desired.OpenAIKeyConfig, existing.OpenAIKeyConfig) {
+		fields = append(fields, "openai_key_config")
+	}
+	if !reflect.DeepEqual(desired.AzureKeyConfig, existing.AzureKeyConfig) {
+		fields = append(fields, "azure_key_config")
+	}
+	if !reflect.DeepEqual(desired.VertexKeyConfig, existing.VertexKeyConfig) {
+		fields = append(fields, "vertex_key_config")
+	}
+	if !reflect.DeepEqual(desired.BedrockKeyConfig, existing.BedrockKeyConfig) {
+		fields = append(fields, "bedrock_key_config")
+	}
+	return fields
+}
+
+// declaredKey returns a raw key with env.VAR references restored from its redacted form.
+func declaredKey(raw schemas.Key, redacted schemas.Key) schemas.Key {
+	key := raw
+	key.Value = envReferenceOr(redacted.Value, raw.Value)
+	if raw.AzureKeyConfig != nil && redacted.AzureKeyConfig != nil {
+		azure := *raw.AzureKeyConfig
+		azure.Endpoint = envReferenceOr(redacted.AzureKeyConfig.Endpoint, azure.Endpoint)
+		azure.APIVersion = envReferencePtrOr(redacted.AzureKeyConfig.APIVersion, azure.APIVersion)
+		key.AzureKeyConfig = &azure
+	}
+	if raw.VertexKeyConfig != nil && redacted.VertexKeyConfig != nil {
+		vertex := *raw.VertexKeyConfig
+		vertex.ProjectID = envReferenceOr(redacted.VertexKeyConfig.ProjectID, vertex.ProjectID)
+		vertex.Region = envReferenceOr(redacted.VertexKeyConfig.Region, vertex.Region)
+		vertex.AuthCredentials = envReferenceOr(redacted.VertexKeyConfig.AuthCredentials, vertex.AuthCredentials)
+		key.VertexKeyConfig = &vertex
+	}
+	if raw.BedrockKeyConfig != nil && redacted.BedrockKeyConfig != nil {
+		bedrock := *raw.BedrockKeyConfig
+		bedrock.AccessKey = envReferenceOr(redacted.BedrockKeyConfig.AccessKey, bedrock.AccessKey)
+		bedrock.SecretKey = envReferenceOr(redacted.BedrockKeyConfig.SecretKey, bedrock.SecretKey)
+		bedrock.SessionToken = envReferencePtrOr(redacted.BedrockKeyConfig.SessionToken, bedrock.SessionToken)
+		bedrock.Region = envReferencePtrOr(redacted.BedrockKeyConfig.Region, bedrock.Region)
+		bedrock.ARN = envReferencePtrOr(redacted.BedrockKeyConfig.ARN, bedrock.ARN)
+		key.BedrockKeyConfig = &bedrock
+	}
+	return key
+}
+
+// envReferenceOr returns redacted when it is an env.VAR reference, raw otherwise.
+func envReferenceOr(redacted string, raw string) string {
+	if strings.HasPrefix(redacted, "env.") {
+		return redacted
+	}
+	return raw
+}
+
+// envReferencePtrOr is envReferenceOr for optional fields.
+func envReferencePtrOr(redacted *string, raw *string) *string {
+	if redacted != nil && strings.HasPrefix(*redacted, "env.") {
+		return redacted
+	}
+	return raw
+}
+
+// networkConfigOrDefault returns the effective network config.
+func networkConfigOrDefault(config *schemas.NetworkConfig) *schemas.NetworkConfig {
+	if config == nil {
+		return &schemas.DefaultNetworkConfig
+	}
+	return config
+}
+
+// httpClientConfig returns the HTTP client settings of a network config, or nil.
+func httpClientConfig(config *schemas.NetworkConfig) *schemas.HTTPClientConfig {
+	if config == nil {
+		return nil
+	}
+	return config.HTTPClient
+}
+
+// providerSpec returns the spec of a declarative custom provider, or nil.
+func providerSpec(config *schemas.CustomProviderConfig) *schemas.ProviderSpec {
+	if config == nil {
+		return nil
+	}
+	return config.Spec
+}
+
+// concurrencyOrDefault returns the effective concurrency and buffer size.
+func concurrencyOrDefault(config *schemas.ConcurrencyAndBufferSize) *schemas.ConcurrencyAndBufferSize {
+	if config == nil {
+		return &schemas.DefaultConcurrencyAndBufferSize
+	}
+	return config
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// newApplyTestHandler creates an apply handler over an in-memory config with one OpenAI key sourced from env
+func newApplyTestHandler() *ApplyHandler {
+	store := &lib.Config{
+		Providers: map[schemas.ModelProvider]configstore.ProviderConfig{
+			schemas.OpenAI: {
+				Keys: []schemas.Key{{ID: "primary", Value: "sk-resolved", Weight: 1}},
+			},
+			schemas.Anthropic: {
+				Keys: []schemas.Key{{ID: "claude", Value: "sk-ant-literal", Weight: 1}},
+			},
+		},
+		EnvKeys: map[string][]configstore.EnvKeyInfo{
+			"OPENAI_API_KEY": {{
+				EnvVar:     "OPENAI_API_KEY",
+				Provider:   schemas.OpenAI,
+				KeyType:    "api_key",
+				ConfigPath: "providers.openai.keys[primary]",
+				KeyID:      "primary",
+			}},
+		},
+	}
+	return NewApplyHandler(store, nil, nil, bifrost.NewDefaultLogger(schemas.LogLevelError))
+}
+
+// TestApplyPlan_Idempotent tests that a document matching the current configuration plans no changes
+func TestApplyPlan_Idempotent(t *testing.T) {
+	h := newApplyTestHandler()
+	desired := &DesiredState{
+		Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI:    {Keys: []schemas.Key{{ID: "primary", Value: "env.OPENAI_API_KEY", Weight: 1}}},
+			schemas.Anthropic: {Keys: []schemas.Key{{ID: "claude", Value: "sk-ant-literal", Weight: 1}}},
+		},
+		Prune: true,
+	}
+
+	plan, err := h.plan(context.Background(), desired)
+	if err != nil {
+		t.Fatalf("Expected plan to succeed, got %v", err)
+	}
+	if len(plan.changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", plan.changes)
+	}
+}
+
+// TestApplyPlan_Diff tests creates, updates and pruning
+func TestApplyPlan_Diff(t *testing.T) {
+	h := newApplyTestHandler()
+	desired := &DesiredState{
+		Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {Keys: []schemas.Key{
+				{ID: "primary", Value: "env.OPENAI_API_KEY", Weight: 2},
+				{ID: "secondary", Value: "env.OPENAI_API_KEY_2", Weight: 1},
+			}},
+			schemas.Groq: {Keys: []schemas.Key{{ID: "groq", Value: "env.GROQ_API_KEY", Weight: 1}}},
+		},
+		Prune: true,
+	}
+
+	plan, err := h.plan(context.Background(), desired)
+	if err != nil {
+		t.Fatalf("Expected plan to succeed, got %v", err)
+	}
+	expected := []ApplyChange{
+		{Resource: "provider", Name: "groq", Action: ApplyActionCreate},
+		{Resource: "key", Name: "groq/groq", Action: ApplyActionCreate},
+		{Resource: "provider", Name: "openai", Action: ApplyActionUpdate, Fields: []string{"keys"}},
+		{Resource: "key", Name: "openai/primary", Action: ApplyActionUpdate, Fields: []string{"weight"}},
+		{Resource: "key", Name: "openai/secondary", Action: ApplyActionCreate},
+		{Resource: "provider", Name: "anthropic", Action: ApplyActionDelete},
+	}
+	if len(plan.changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), plan.changes)
+	}
+	for i, change := range plan.changes {
+		want := expected[i]
+		if change.Resource != want.Resource || change.Name != want.Name || change.Action != want.Action || len(change.Fields) != len(want.Fields) {
+			t.Errorf("Change %d: expected %+v, got %+v", i, want, change)
+			continue
+		}
+		for j := range want.Fields {
+			if change.Fields[j] != want.Fields[j] {
+				t.Errorf("Change %d: expected fields %v, got %v", i, want.Fields, change.Fields)
+			}
+		}
+	}
+}
+
+// TestValidateDesiredState tests that documents that cannot be applied idempotently are rejected
+func TestValidateDesiredState(t *testing.T) {
+	invalid := map[string]*DesiredState{
+		"missing key id": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {Keys: []schemas.Key{{Value: "sk-test"}}},
+		}},
+		"redacted key": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {Keys: []schemas.Key{{ID: "a", Value: "sk-t************************abcd"}}},
+		}},
+		"invalid budget duration": {Budgets: []DesiredBudget{{ID: "b", MaxLimit: 10, ResetDuration: "soon"}}},
+		"invalid budget schedule": {Budgets: []DesiredBudget{{ID: "b", MaxLimit: 10, ResetSchedule: "0 0 32 * *"}}},
+		"mock config on another provider": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {MockConfig: &schemas.MockProviderConfig{Response: "hi"}},
+		}},
+		"invalid mock template": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.Mock: {MockConfig: &schemas.MockProviderConfig{Response: "{{.Prompt"}},
+		}},
+		"invalid request shaping": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {RequestShaping: &schemas.RequestShapingConfig{Defaults: map[string]any{"temperature": "hot"}}},
+		}},
+		"negative synthetic streaming interval": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.Cohere: {SyntheticStreaming: &schemas.SyntheticStreamingConfig{Enabled: true, IntervalMs: -1}},
+		}},
+		"declarative provider without spec": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			"acme": {
+				CustomProviderConfig: &schemas.CustomProviderConfig{BaseProviderType: schemas.Declarative},
+				NetworkConfig:        &schemas.NetworkConfig{BaseURL: "https://acme.example"},
+			},
+		}},
+		"invalid declarative spec expression": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			"acme": {
+				CustomProviderConfig: &schemas.CustomProviderConfig{BaseProviderType: schemas.Declarative, Spec: &schemas.ProviderSpec{
+					ChatCompletion: &schemas.ProviderSpecOperation{Path: "/generate", Response: schemas.ProviderSpecResponse{Content: ".choices[0"}},
+				}},
+				NetworkConfig: &schemas.NetworkConfig{BaseURL: "https://acme.example"},
+			},
+		}},
+		"declarative provider without base url": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			"acme": {CustomProviderConfig: &schemas.CustomProviderConfig{BaseProviderType: schemas.Declarative, Spec: &schemas.ProviderSpec{
+				ChatCompletion: &schemas.ProviderSpecOperation{Path: "/generate", Response: schemas.ProviderSpecResponse{Content: ".text"}},
+			}}},
+		}},
+		"spec on another base provider": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			"acme": {CustomProviderConfig: &schemas.CustomProviderConfig{BaseProviderType: schemas.OpenAI, Spec: &schemas.ProviderSpec{}}},
+		}},
+		"invalid mock error rate": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.Mock: {MockConfig: &schemas.MockProviderConfig{ErrorRate: 2}},
+		}},
+		"negative idle connections": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {NetworkConfig: &schemas.NetworkConfig{HTTPClient: &schemas.HTTPClientConfig{MaxIdleConns: -1}}},
+		}},
+		"proxy without url": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {ProxyConfig: &schemas.ProxyConfig{Type: schemas.Socks5Proxy}},
+		}},
+		"invalid ca bundle": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {NetworkConfig: &schemas.NetworkConfig{HTTPClient: &schemas.HTTPClientConfig{CABundle: "-----BEGIN CERTIFICATE-----\nnot a certificate"}}},
+		}},
+		"client certificate without key": {Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.OpenAI: {NetworkConfig: &schemas.NetworkConfig{HTTPClient: &schemas.HTTPClientConfig{ClientCertificate: "/etc/bifrost/client.pem"}}},
+		}},
+	}
+	for name, desired := range invalid {
+		if err := validateDesiredState(desired); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+
+	valid := &DesiredState{
+		Providers: map[schemas.ModelProvider]DesiredProvider{
+			schemas.Mock:   {MockConfig: &schemas.MockProviderConfig{Response: "Echo: {{.Prompt}}", LatencyMs: 200, ErrorRate: 0.1}},
+			schemas.OpenAI: {NetworkConfig: &schemas.NetworkConfig{HTTPClient: &schemas.HTTPClientConfig{MaxIdleConns: 200, TLSSessionCacheSize: 64}}},
+		},
+		Budgets: []DesiredBudget{{ID: "b", MaxLimit: 10, ResetDuration: "1M"}},
+	}
+	if err := validateDesiredState(valid); err != nil {
+		t.Errorf("Expected valid document to pass, got %v", err)
+	}
+}
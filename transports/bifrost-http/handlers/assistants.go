@@ -0,0 +1,885 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/assistants"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// maxAssistantsListLimit is the largest page of the Assistants API lists
+const maxAssistantsListLimit = 100
+
+// AssistantsHandler serves the OpenAI Assistants API on top of the gateway.
+type AssistantsHandler struct {
+	assistants *lib.Assistants
+	logger     schemas.Logger
+}
+
+// AssistantRequest is the body of POST /v1/assistants and POST /v1/assistants/{assistant_id}.
+type AssistantRequest struct {
+	Model        *string             `json:"model"` // In provider/model form, OpenAI when no provider is given
+	Name         *string             `json:"name"`
+	Description  *string             `json:"description"`
+	Instructions *string             `json:"instructions"`
+	Tools        *[]schemas.ChatTool `json:"tools"`
+	Metadata     map[string]string   `json:"metadata"`
+}
+
+// AssistantObject is an assistant in the Assistants API format.
+type AssistantObject struct {
+	ID           string             `json:"id"`
+	Object       string             `json:"object"`
+	CreatedAt    int64              `json:"created_at"`
+	Name         string             `json:"name,omitempty"`
+	Description  string             `json:"description,omitempty"`
+	Model        string             `json:"model"`
+	Instructions string             `json:"instructions,omitempty"`
+	Tools        []schemas.ChatTool `json:"tools"`
+	Metadata     map[string]string  `json:"metadata"`
+}
+
+// ThreadMessageRequest is a message added to a thread. Its content is a string or a list of text and image_url parts.
+type ThreadMessageRequest struct {
+	Role     schemas.ChatMessageRole     `json:"role"`
+	Content  *schemas.ChatMessageContent `json:"content"`
+	Metadata map[string]string           `json:"metadata"`
+}
+
+// ThreadRequest is the body of POST /v1/threads and POST /v1/threads/{thread_id}.
+type ThreadRequest struct {
+	Messages []ThreadMessageRequest `json:"messages"`
+	Metadata map[string]string      `json:"metadata"`
+}
+
+// ThreadObject is a thread in the Assistants API format.
+type ThreadObject struct {
+	ID        string            `json:"id"`
+	Object    string            `json:"object"`
+	CreatedAt int64             `json:"created_at"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+// MessageContentPart is a part of the content of a message in the Assistants API format.
+type MessageContentPart struct {
+	Type     string                  `json:"type"`
+	Text     *MessageText            `json:"text,omitempty"`
+	ImageURL *schemas.ChatInputImage `json:"image_url,omitempty"`
+}
+
+// MessageText is the text of a message content part.
+type MessageText struct {
+	Value       string `json:"value"`
+	Annotations []any  `json:"annotations"`
+}
+
+// MessageObject is a thread message in the Assistants API format.
+type MessageObject struct {
+	ID          string               `json:"id"`
+	Object      string               `json:"object"`
+	CreatedAt   int64                `json:"created_at"`
+	ThreadID    string               `json:"thread_id"`
+	Role        string               `json:"role"`
+	Content     []MessageContentPart `json:"content"`
+	AssistantID *string              `json:"assistant_id"`
+	RunID       *string              `json:"run_id"`
+	Attachments []any                `json:"attachments"`
+	Metadata    map[string]string    `json:"metadata"`
+}
+
+// RunRequest is the body of POST /v1/threads/{thread_id}/runs. Thread is only used by POST /v1/threads/runs.
+type RunRequest struct {
+	AssistantID            string                 `json:"assistant_id"`
+	Model                  *string                `json:"model"`
+	Instructions           *string                `json:"instructions"`
+	AdditionalInstructions string                 `json:"additional_instructions"`
+	AdditionalMessages     []ThreadMessageRequest `json:"additional_messages"`
+	Tools                  *[]schemas.ChatTool    `json:"tools"`
+	Metadata               map[string]string      `json:"metadata"`
+	Stream                 bool                   `json:"stream"`
+	Thread                 *ThreadRequest         `json:"thread"`
+}
+
+// SubmitToolOutputsRequest is the body of POST /v1/threads/{thread_id}/runs/{run_id}/submit_tool_outputs.
+type SubmitToolOutputsRequest struct {
+	ToolOutputs []lib.ToolOutput `json:"tool_outputs"`
+	Stream      bool             `json:"stream"`
+}
+
+// RunObject is a run in the Assistants API format.
+type RunObject struct {
+	ID             string             `json:"id"`
+	Object         string             `json:"object"`
+	CreatedAt      int64              `json:"created_at"`
+	ThreadID       string             `json:"thread_id"`
+	AssistantID    string             `json:"assistant_id"`
+	Status         string             `json:"status"`
+	RequiredAction *RunRequiredAction `json:"required_action"`
+	LastError      *RunError          `json:"last_error"`
+	Model          string             `json:"model"`
+	Instructions   string             `json:"instructions"`
+	Tools          []schemas.ChatTool `json:"tools"`
+	StartedAt      *int64             `json:"started_at"`
+	CompletedAt    *int64             `json:"completed_at"`
+	CancelledAt    *int64             `json:"cancelled_at"`
+	FailedAt       *int64             `json:"failed_at"`
+	Usage          *RunUsage          `json:"usage"`
+	Metadata       map[string]string  `json:"metadata"`
+}
+
+// RunRequiredAction lists the function calls whose outputs the client must submit.
+type RunRequiredAction struct {
+	Type              string `json:"type"`
+	SubmitToolOutputs struct {
+		ToolCalls []schemas.ChatAssistantMessageToolCall `json:"tool_calls"`
+	} `json:"submit_tool_outputs"`
+}
+
+// RunError is the error that stopped a run.
+type RunError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RunUsage is the token usage of the model calls of a finished run.
+type RunUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// DeletedObject answers the deletion of an assistant or thread.
+type DeletedObject struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Deleted bool   `json:"deleted"`
+}
+
+// NewAssistantsHandler creates a new Assistants API handler; assistants is nil when the layer is off.
+func NewAssistantsHandler(assistants *lib.Assistants, logger schemas.Logger) *AssistantsHandler {
+	return &AssistantsHandler{
+		assistants: assistants,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers the Assistants API routes.
+func (h *AssistantsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST("/v1/assistants", lib.ChainMiddlewares(h.createAssistant, middlewares...))
+	r.GET("/v1/assistants", lib.ChainMiddlewares(h.listAssistants, middlewares...))
+	r.GET("/v1/assistants/{assistant_id}", lib.ChainMiddlewares(h.getAssistant, middlewares...))
+	r.POST("/v1/assistants/{assistant_id}", lib.ChainMiddlewares(h.updateAssistant, middlewares...))
+	r.DELETE("/v1/assistants/{assistant_id}", lib.ChainMiddlewares(h.deleteAssistant, middlewares...))
+	r.POST("/v1/threads", lib.ChainMiddlewares(h.createThread, middlewares...))
+	r.POST("/v1/threads/runs", lib.ChainMiddlewares(h.createThreadAndRun, middlewares...))
+	r.GET("/v1/threads/{thread_id}", lib.ChainMiddlewares(h.getThread, middlewares...))
+	r.POST("/v1/threads/{thread_id}", lib.ChainMiddlewares(h.updateThread, middlewares...))
+	r.DELETE("/v1/threads/{thread_id}", lib.ChainMiddlewares(h.deleteThread, middlewares...))
+	r.POST("/v1/threads/{thread_id}/messages", lib.ChainMiddlewares(h.createMessage, middlewares...))
+	r.GET("/v1/threads/{thread_id}/messages", lib.ChainMiddlewares(h.listMessages, middlewares...))
+	r.GET("/v1/threads/{thread_id}/messages/{message_id}", lib.ChainMiddlewares(h.getMessage, middlewares...))
+	r.POST("/v1/threads/{thread_id}/runs", lib.ChainMiddlewares(h.createRun, middlewares...))
+	r.GET("/v1/threads/{thread_id}/runs", lib.ChainMiddlewares(h.listRuns, middlewares...))
+	r.GET("/v1/threads/{thread_id}/runs/{run_id}", lib.ChainMiddlewares(h.getRun, middlewares...))
+	r.POST("/v1/threads/{thread_id}/runs/{run_id}/submit_tool_outputs", lib.ChainMiddlewares(h.submitToolOutputs, middlewares...))
+	r.POST("/v1/threads/{thread_id}/runs/{run_id}/cancel", lib.ChainMiddlewares(h.cancelRun, middlewares...))
+}
+
+// enabled answers 404 when the Assistants API layer is off
+func (h *AssistantsHandler) enabled(ctx *fasthttp.RequestCtx) bool {
+	if h.assistants == nil {
+		SendError(ctx, fasthttp.StatusNotFound, "the assistants API is not enabled", h.logger)
+		return false
+	}
+	return true
+}
+
+// decode parses the JSON body into req, answering 400 when it is invalid
+func (h *AssistantsHandler) decode(ctx *fasthttp.RequestCtx, req any) bool {
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, req); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+			return false
+		}
+	}
+	return true
+}
+
+// sendFailure answers with the status of rejected requests, 404 for unknown objects and 500 otherwise
+func (h *AssistantsHandler) sendFailure(ctx *fasthttp.RequestCtx, what string, err error) {
+	if assistantsErr, ok := lib.IsAssistantsError(err); ok {
+		SendError(ctx, assistantsErr.StatusCode, assistantsErr.Message, h.logger)
+		return
+	}
+	if errors.Is(err, assistants.ErrNotFound) {
+		SendError(ctx, fasthttp.StatusNotFound, what+" not found", h.logger)
+		return
+	}
+	SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to process %s: %v", what, err), h.logger)
+}
+
+// validateTools rejects the tool types the gateway cannot run. MCP tools are offered to every run without being declared.
+func validateTools(tools []schemas.ChatTool) error {
+	for _, tool := range tools {
+		if tool.Type != schemas.ChatToolTypeFunction || tool.Function == nil || tool.Function.Name == "" {
+			return fmt.Errorf("unsupported tool %q: only function tools are supported, MCP tools configured in Bifrost are available to every run", tool.Type)
+		}
+	}
+	return nil
+}
+
+// createAssistant handles POST /v1/assistants - Create an assistant
+func (h *AssistantsHandler) createAssistant(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	var req AssistantRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	if req.Model == nil || *req.Model == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "model is required", h.logger)
+		return
+	}
+	assistant := &assistants.Assistant{ID: lib.NewAssistantsID("asst"), Owner: lib.RequestOwner(ctx)}
+	if !h.applyAssistantRequest(ctx, assistant, &req) {
+		return
+	}
+	if err := h.assistants.Store().SaveAssistant(ctx, assistant); err != nil {
+		h.sendFailure(ctx, "assistant", err)
+		return
+	}
+	SendJSON(ctx, toAssistantObject(assistant), h.logger)
+}
+
+// applyAssistantRequest sets the fields present in req, answering 400 when they are invalid
+func (h *AssistantsHandler) applyAssistantRequest(ctx *fasthttp.RequestCtx, assistant *assistants.Assistant, req *AssistantRequest) bool {
+	if req.Tools != nil {
+		if err := validateTools(*req.Tools); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return false
+		}
+		assistant.Tools = *req.Tools
+	}
+	if req.Model != nil {
+		assistant.Model = *req.Model
+	}
+	if req.Name != nil {
+		assistant.Name = *req.Name
+	}
+	if req.Description != nil {
+		assistant.Description = *req.Description
+	}
+	if req.Instructions != nil {
+		assistant.Instructions = *req.Instructions
+	}
+	if req.Metadata != nil {
+		assistant.Metadata = req.Metadata
+	}
+	return true
+}
+
+// listAssistants handles GET /v1/assistants - List assistants (limit, order, after, before)
+func (h *AssistantsHandler) listAssistants(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	list, err := h.assistants.Store().ListAssistants(ctx)
+	if err != nil {
+		h.sendFailure(ctx, "assistants", err)
+		return
+	}
+	objects := make([]AssistantObject, 0, len(list))
+	for i := range list {
+		if ownedBy(ctx, list[i].Owner) {
+			objects = append(objects, toAssistantObject(&list[i]))
+		}
+	}
+	sendCursorList(ctx, objects, func(object AssistantObject) string { return object.ID }, h.logger)
+}
+
+// loadAssistant loads an assistant of the caller, sending an error response when it cannot. The assistants of
+// other callers are reported as missing, so their IDs cannot be probed.
+func (h *AssistantsHandler) loadAssistant(ctx *fasthttp.RequestCtx, id string) (*assistants.Assistant, bool) {
+	assistant, err := h.assistants.Store().GetAssistant(ctx, id)
+	if err == nil && !ownedBy(ctx, assistant.Owner) {
+		err = assistants.ErrNotFound
+	}
+	if err != nil {
+		h.sendFailure(ctx, "assistant", err)
+		return nil, false
+	}
+	return assistant, true
+}
+
+// getAssistant handles GET /v1/assistants/{assistant_id} - Get an assistant
+func (h *AssistantsHandler) getAssistant(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	assistant, ok := h.loadAssistant(ctx, ctx.UserValue("assistant_id").(string))
+	if !ok {
+		return
+	}
+	SendJSON(ctx, toAssistantObject(assistant), h.logger)
+}
+
+// updateAssistant handles POST /v1/assistants/{assistant_id} - Modify the fields of an assistant present in the body
+func (h *AssistantsHandler) updateAssistant(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	var req AssistantRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	if req.Model != nil && *req.Model == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "model cannot be empty", h.logger)
+		return
+	}
+	assistant, ok := h.loadAssistant(ctx, ctx.UserValue("assistant_id").(string))
+	if !ok {
+		return
+	}
+	if !h.applyAssistantRequest(ctx, assistant, &req) {
+		return
+	}
+	if err := h.assistants.Store().SaveAssistant(ctx, assistant); err != nil {
+		h.sendFailure(ctx, "assistant", err)
+		return
+	}
+	SendJSON(ctx, toAssistantObject(assistant), h.logger)
+}
+
+// deleteAssistant handles DELETE /v1/assistants/{assistant_id} - Delete an assistant
+func (h *AssistantsHandler) deleteAssistant(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	id := ctx.UserValue("assistant_id").(string)
+	if _, ok := h.loadAssistant(ctx, id); !ok {
+		return
+	}
+	if err := h.assistants.Store().DeleteAssistant(ctx, id); err != nil {
+		h.sendFailure(ctx, "assistant", err)
+		return
+	}
+	SendJSON(ctx, DeletedObject{ID: id, Object: "assistant.deleted", Deleted: true}, h.logger)
+}
+
+// threadMessages converts the messages of a request, answering 400 when they are invalid
+func (h *AssistantsHandler) threadMessages(ctx *fasthttp.RequestCtx, requests []ThreadMessageRequest) ([]lib.ThreadMessage, bool) {
+	messages := make([]lib.ThreadMessage, 0, len(requests))
+	for _, req := range requests {
+		if req.Role != schemas.ChatMessageRoleUser && req.Role != schemas.ChatMessageRoleAssistant {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("invalid message role %q: must be user or assistant", req.Role), h.logger)
+			return nil, false
+		}
+		if req.Content == nil || (req.Content.ContentStr == nil && len(req.Content.ContentBlocks) == 0) {
+			SendError(ctx, fasthttp.StatusBadRequest, "message content is required", h.logger)
+			return nil, false
+		}
+		messages = append(messages, lib.ThreadMessage{
+			MessageInfo: assistants.MessageInfo{ID: lib.NewAssistantsID("msg"), CreatedAt: time.Now().Unix(), Metadata: req.Metadata, Owner: lib.RequestOwner(ctx)},
+			Message:     schemas.ChatMessage{Role: req.Role, Content: req.Content},
+		})
+	}
+	return messages, true
+}
+
+// createThread handles POST /v1/threads - Create a thread, optionally with messages
+func (h *AssistantsHandler) createThread(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	var req ThreadRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	messages, ok := h.threadMessages(ctx, req.Messages)
+	if !ok {
+		return
+	}
+	thread, err := h.assistants.CreateThread(ctx, lib.RequestOwner(ctx), req.Metadata, messages)
+	if err != nil {
+		h.sendFailure(ctx, "thread", err)
+		return
+	}
+	SendJSON(ctx, toThreadObject(thread), h.logger)
+}
+
+// loadThread loads the thread of the caller named in the path, sending an error response when it cannot
+func (h *AssistantsHandler) loadThread(ctx *fasthttp.RequestCtx) (*assistants.Thread, bool) {
+	if !h.enabled(ctx) {
+		return nil, false
+	}
+	thread, err := h.assistants.Store().GetThread(ctx, ctx.UserValue("thread_id").(string))
+	if err == nil && !ownedBy(ctx, thread.Owner) {
+		err = assistants.ErrNotFound
+	}
+	if err != nil {
+		h.sendFailure(ctx, "thread", err)
+		return nil, false
+	}
+	return thread, true
+}
+
+// getThread handles GET /v1/threads/{thread_id} - Get a thread
+func (h *AssistantsHandler) getThread(ctx *fasthttp.RequestCtx) {
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	SendJSON(ctx, toThreadObject(thread), h.logger)
+}
+
+// updateThread handles POST /v1/threads/{thread_id} - Replace the metadata of a thread
+func (h *AssistantsHandler) updateThread(ctx *fasthttp.RequestCtx) {
+	var req ThreadRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	thread.Metadata = req.Metadata
+	if err := h.assistants.Store().SaveThread(ctx, thread); err != nil {
+		h.sendFailure(ctx, "thread", err)
+		return
+	}
+	SendJSON(ctx, toThreadObject(thread), h.logger)
+}
+
+// deleteThread handles DELETE /v1/threads/{thread_id} - Delete a thread with its messages and runs
+func (h *AssistantsHandler) deleteThread(ctx *fasthttp.RequestCtx) {
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	if err := h.assistants.DeleteThread(ctx, thread.ID); err != nil {
+		h.sendFailure(ctx, "thread", err)
+		return
+	}
+	SendJSON(ctx, DeletedObject{ID: thread.ID, Object: "thread.deleted", Deleted: true}, h.logger)
+}
+
+// createMessage handles POST /v1/threads/{thread_id}/messages - Add a message to a thread
+func (h *AssistantsHandler) createMessage(ctx *fasthttp.RequestCtx) {
+	var req ThreadMessageRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	messages, ok := h.threadMessages(ctx, []ThreadMessageRequest{req})
+	if !ok {
+		return
+	}
+	if err := h.assistants.AddMessage(ctx, thread.ID, messages[0]); err != nil {
+		h.sendFailure(ctx, "thread", err)
+		return
+	}
+	SendJSON(ctx, toMessageObject(thread.ID, messages[0]), h.logger)
+}
+
+// listMessages handles GET /v1/threads/{thread_id}/messages - List the messages of a thread (limit, order, after, before, run_id)
+func (h *AssistantsHandler) listMessages(ctx *fasthttp.RequestCtx) {
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	messages, err := h.assistants.Messages(ctx, thread)
+	if err != nil {
+		h.sendFailure(ctx, "thread messages", err)
+		return
+	}
+	runID := string(ctx.QueryArgs().Peek("run_id"))
+	objects := make([]MessageObject, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		if (runID == "" || messages[i].RunID == runID) && ownedBy(ctx, messages[i].Owner) {
+			objects = append(objects, toMessageObject(thread.ID, messages[i]))
+		}
+	}
+	sendCursorList(ctx, objects, func(object MessageObject) string { return object.ID }, h.logger)
+}
+
+// getMessage handles GET /v1/threads/{thread_id}/messages/{message_id} - Get a message of a thread
+func (h *AssistantsHandler) getMessage(ctx *fasthttp.RequestCtx) {
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	messages, err := h.assistants.Messages(ctx, thread)
+	if err != nil {
+		h.sendFailure(ctx, "thread messages", err)
+		return
+	}
+	id := ctx.UserValue("message_id").(string)
+	for _, message := range messages {
+		if message.ID == id && ownedBy(ctx, message.Owner) {
+			SendJSON(ctx, toMessageObject(thread.ID, message), h.logger)
+			return
+		}
+	}
+	SendError(ctx, fasthttp.StatusNotFound, "message not found", h.logger)
+}
+
+// createRun handles POST /v1/threads/{thread_id}/runs - Run an assistant on a thread in the background
+func (h *AssistantsHandler) createRun(ctx *fasthttp.RequestCtx) {
+	var req RunRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	h.startRun(ctx, thread.ID, &req)
+}
+
+// createThreadAndRun handles POST /v1/threads/runs - Create a thread and run an assistant on it
+func (h *AssistantsHandler) createThreadAndRun(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	var req RunRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	if req.Thread == nil {
+		req.Thread = &ThreadRequest{}
+	}
+	messages, ok := h.threadMessages(ctx, req.Thread.Messages)
+	if !ok || !h.checkRunRequest(ctx, &req) {
+		return
+	}
+	thread, err := h.assistants.CreateThread(ctx, lib.RequestOwner(ctx), req.Thread.Metadata, messages)
+	if err != nil {
+		h.sendFailure(ctx, "thread", err)
+		return
+	}
+	h.startRun(ctx, thread.ID, &req)
+}
+
+// checkRunRequest rejects the run options the gateway cannot honor
+func (h *AssistantsHandler) checkRunRequest(ctx *fasthttp.RequestCtx, req *RunRequest) bool {
+	if req.Stream {
+		SendError(ctx, fasthttp.StatusBadRequest, "streaming runs are not supported, poll the run instead", h.logger)
+		return false
+	}
+	if req.AssistantID == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "assistant_id is required", h.logger)
+		return false
+	}
+	if req.Tools != nil {
+		if err := validateTools(*req.Tools); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return false
+		}
+	}
+	return true
+}
+
+// startRun runs the assistant of req on a thread, with the model, instructions and tools of the assistant
+// unless req overrides them
+func (h *AssistantsHandler) startRun(ctx *fasthttp.RequestCtx, threadID string, req *RunRequest) {
+	if !h.checkRunRequest(ctx, req) {
+		return
+	}
+	assistant, ok := h.loadAssistant(ctx, req.AssistantID)
+	if !ok {
+		return
+	}
+	additional, ok := h.threadMessages(ctx, req.AdditionalMessages)
+	if !ok {
+		return
+	}
+	for _, message := range additional {
+		if err := h.assistants.AddMessage(ctx, threadID, message); err != nil {
+			h.sendFailure(ctx, "thread", err)
+			return
+		}
+	}
+
+	run := &assistants.Run{
+		ID:           lib.NewAssistantsID("run"),
+		ThreadID:     threadID,
+		AssistantID:  assistant.ID,
+		Model:        assistant.Model,
+		Instructions: assistant.Instructions,
+		Tools:        assistant.Tools,
+		Metadata:     req.Metadata,
+		Owner:        lib.RequestOwner(ctx),
+	}
+	if req.Model != nil && *req.Model != "" {
+		run.Model = *req.Model
+	}
+	if req.Instructions != nil {
+		run.Instructions = *req.Instructions
+	}
+	if req.AdditionalInstructions != "" {
+		if run.Instructions != "" {
+			run.Instructions += "\n\n"
+		}
+		run.Instructions += req.AdditionalInstructions
+	}
+	if req.Tools != nil {
+		run.Tools = *req.Tools
+	}
+	// The run is executed with the headers of this request, so governance applies to its virtual key
+	bifrostCtx := lib.ConvertToBifrostContext(ctx, false)
+	if err := h.assistants.StartRun(*bifrostCtx, run); err != nil {
+		h.sendFailure(ctx, "run", err)
+		return
+	}
+	SendJSON(ctx, toRunObject(run), h.logger)
+}
+
+// loadRun loads the run of the caller named in the path, sending an error response when it cannot
+func (h *AssistantsHandler) loadRun(ctx *fasthttp.RequestCtx) (*assistants.Run, bool) {
+	if !h.enabled(ctx) {
+		return nil, false
+	}
+	run, err := h.assistants.Store().GetRun(ctx, ctx.UserValue("thread_id").(string), ctx.UserValue("run_id").(string))
+	if err == nil && !ownedBy(ctx, run.Owner) {
+		err = assistants.ErrNotFound
+	}
+	if err != nil {
+		h.sendFailure(ctx, "run", err)
+		return nil, false
+	}
+	return run, true
+}
+
+// listRuns handles GET /v1/threads/{thread_id}/runs - List the runs of a thread (limit, order, after, before)
+func (h *AssistantsHandler) listRuns(ctx *fasthttp.RequestCtx) {
+	thread, ok := h.loadThread(ctx)
+	if !ok {
+		return
+	}
+	runs, err := h.assistants.Store().ListRuns(ctx, thread.ID)
+	if err != nil {
+		h.sendFailure(ctx, "runs", err)
+		return
+	}
+	objects := make([]RunObject, 0, len(runs))
+	for i := range runs {
+		if ownedBy(ctx, runs[i].Owner) {
+			objects = append(objects, toRunObject(&runs[i]))
+		}
+	}
+	sendCursorList(ctx, objects, func(object RunObject) string { return object.ID }, h.logger)
+}
+
+// getRun handles GET /v1/threads/{thread_id}/runs/{run_id} - Get a run, polled until it completes or requires action
+func (h *AssistantsHandler) getRun(ctx *fasthttp.RequestCtx) {
+	run, ok := h.loadRun(ctx)
+	if !ok {
+		return
+	}
+	SendJSON(ctx, toRunObject(run), h.logger)
+}
+
+// submitToolOutputs handles POST /v1/threads/{thread_id}/runs/{run_id}/submit_tool_outputs - Resume a run with the outputs of its function calls
+func (h *AssistantsHandler) submitToolOutputs(ctx *fasthttp.RequestCtx) {
+	if _, ok := h.loadRun(ctx); !ok {
+		return
+	}
+	var req SubmitToolOutputsRequest
+	if !h.decode(ctx, &req) {
+		return
+	}
+	if req.Stream {
+		SendError(ctx, fasthttp.StatusBadRequest, "streaming runs are not supported, poll the run instead", h.logger)
+		return
+	}
+	bifrostCtx := lib.ConvertToBifrostContext(ctx, false)
+	run, err := h.assistants.SubmitToolOutputs(*bifrostCtx, ctx.UserValue("thread_id").(string), ctx.UserValue("run_id").(string), req.ToolOutputs)
+	if err != nil {
+		h.sendFailure(ctx, "run", err)
+		return
+	}
+	SendJSON(ctx, toRunObject(run), h.logger)
+}
+
+// cancelRun handles POST /v1/threads/{thread_id}/runs/{run_id}/cancel - Cancel an active run
+func (h *AssistantsHandler) cancelRun(ctx *fasthttp.RequestCtx) {
+	if _, ok := h.loadRun(ctx); !ok {
+		return
+	}
+	run, err := h.assistants.CancelRun(ctx, ctx.UserValue("thread_id").(string), ctx.UserValue("run_id").(string))
+	if err != nil {
+		h.sendFailure(ctx, "run", err)
+		return
+	}
+	SendJSON(ctx, toRunObject(run), h.logger)
+}
+
+// sendCursorList sends a page of items, given newest first, in the list format of the Assistants API. The page
+// is selected by the limit (default 20), order (desc or asc), after and before query parameters.
+func sendCursorList[T any](ctx *fasthttp.RequestCtx, items []T, id func(T) string, logger schemas.Logger) {
+	args := ctx.QueryArgs()
+	limit := 20
+	if value := string(args.Peek("limit")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i <= 0 || i > maxAssistantsListLimit {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxAssistantsListLimit), logger)
+			return
+		}
+		limit = i
+	}
+	switch string(args.Peek("order")) {
+	case "", "desc":
+	case "asc":
+		items = slices.Clone(items)
+		slices.Reverse(items)
+	default:
+		SendError(ctx, fasthttp.StatusBadRequest, "order must be asc or desc", logger)
+		return
+	}
+	if after := string(args.Peek("after")); after != "" {
+		if i := slices.IndexFunc(items, func(item T) bool { return id(item) == after }); i >= 0 {
+			items = items[i+1:]
+		}
+	}
+	if before := string(args.Peek("before")); before != "" {
+		if i := slices.IndexFunc(items, func(item T) bool { return id(item) == before }); i >= 0 {
+			items = items[:i]
+		}
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	list := map[string]any{"object": "list", "data": items, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(items) > 0 {
+		list["first_id"], list["last_id"] = id(items[0]), id(items[len(items)-1])
+	}
+	SendJSON(ctx, list, logger)
+}
+
+// toAssistantObject converts an assistant to the Assistants API format
+func toAssistantObject(assistant *assistants.Assistant) AssistantObject {
+	object := AssistantObject{
+		ID:           assistant.ID,
+		Object:       "assistant",
+		CreatedAt:    assistant.CreatedAt.Unix(),
+		Name:         assistant.Name,
+		Description:  assistant.Description,
+		Model:        assistant.Model,
+		Instructions: assistant.Instructions,
+		Tools:        assistant.Tools,
+		Metadata:     assistant.Metadata,
+	}
+	if object.Tools == nil {
+		object.Tools = []schemas.ChatTool{}
+	}
+	if object.Metadata == nil {
+		object.Metadata = map[string]string{}
+	}
+	return object
+}
+
+// toThreadObject converts a thread to the Assistants API format
+func toThreadObject(thread *assistants.Thread) ThreadObject {
+	object := ThreadObject{ID: thread.ID, Object: "thread", CreatedAt: thread.CreatedAt.Unix(), Metadata: thread.Metadata}
+	if object.Metadata == nil {
+		object.Metadata = map[string]string{}
+	}
+	return object
+}
+
+// toMessageObject converts a thread message to the Assistants API format
+func toMessageObject(threadID string, message lib.ThreadMessage) MessageObject {
+	object := MessageObject{
+		ID:          message.ID,
+		Object:      "thread.message",
+		CreatedAt:   message.CreatedAt,
+		ThreadID:    threadID,
+		Role:        string(message.Message.Role),
+		Content:     []MessageContentPart{},
+		Attachments: []any{},
+		Metadata:    message.Metadata,
+	}
+	if message.AssistantID != "" {
+		object.AssistantID = &message.AssistantID
+	}
+	if message.RunID != "" {
+		object.RunID = &message.RunID
+	}
+	if object.Metadata == nil {
+		object.Metadata = map[string]string{}
+	}
+	text := func(value string) MessageContentPart {
+		return MessageContentPart{Type: "text", Text: &MessageText{Value: value, Annotations: []any{}}}
+	}
+	if content := message.Message.Content; content != nil {
+		if content.ContentStr != nil {
+			object.Content = append(object.Content, text(*content.ContentStr))
+		}
+		for _, block := range content.ContentBlocks {
+			switch {
+			case block.Text != nil:
+				object.Content = append(object.Content, text(*block.Text))
+			case block.ImageURLStruct != nil:
+				object.Content = append(object.Content, MessageContentPart{Type: "image_url", ImageURL: block.ImageURLStruct})
+			}
+		}
+	}
+	return object
+}
+
+// toRunObject converts a run to the Assistants API format
+func toRunObject(run *assistants.Run) RunObject {
+	unix := func(t *time.Time) *int64 {
+		if t == nil {
+			return nil
+		}
+		seconds := t.Unix()
+		return &seconds
+	}
+	object := RunObject{
+		ID:           run.ID,
+		Object:       "thread.run",
+		CreatedAt:    run.CreatedAt.Unix(),
+		ThreadID:     run.ThreadID,
+		AssistantID:  run.AssistantID,
+		Status:       run.Status,
+		Model:        run.Model,
+		Instructions: run.Instructions,
+		Tools:        run.Tools,
+		StartedAt:    unix(run.StartedAt),
+		CompletedAt:  unix(run.CompletedAt),
+		CancelledAt:  unix(run.CancelledAt),
+		FailedAt:     unix(run.FailedAt),
+		Metadata:     run.Metadata,
+	}
+	if object.Tools == nil {
+		object.Tools = []schemas.ChatTool{}
+	}
+	if object.Metadata == nil {
+		object.Metadata = map[string]string{}
+	}
+	if run.Status == assistants.StatusRequiresAction {
+		object.RequiredAction = &RunRequiredAction{Type: "submit_tool_outputs"}
+		object.RequiredAction.SubmitToolOutputs.ToolCalls = run.PendingToolCalls
+	}
+	if run.LastError != "" {
+		object.LastError = &RunError{Code: "server_error", Message: run.LastError}
+	}
+	if assistants.IsFinished(run.Status) {
+		object.Usage = &RunUsage{PromptTokens: run.PromptTokens, CompletionTokens: run.CompletionTokens, TotalTokens: run.TotalTokens}
+	}
+	return object
+}
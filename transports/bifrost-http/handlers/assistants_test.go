@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/assistants"
+	"github.com/maximhq/bifrost/framework/sessions"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// assistantsTestPlugin plays the model: it calls get_weather on the first turn and answers with the tool output
+// once it is submitted. Replies are held until release is closed, so tests can act while a run is in progress.
+type assistantsTestPlugin struct {
+	release chan struct{}
+	inputs  chan []schemas.ChatMessage // Input of every model call
+}
+
+func (p *assistantsTestPlugin) GetName() string {
+	return "assistants-test"
+}
+
+func (p *assistantsTestPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+func (p *assistantsTestPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	<-p.release
+	input := req.ChatRequest.Input
+	p.inputs <- input
+	message := &schemas.ChatMessage{Role: schemas.ChatMessageRoleAssistant}
+	if last := input[len(input)-1]; last.Role == schemas.ChatMessageRoleTool {
+		message.Content = &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("It is " + *last.Content.ContentStr + " in Paris.")}
+	} else {
+		message.ChatAssistantMessage = &schemas.ChatAssistantMessage{ToolCalls: []schemas.ChatAssistantMessageToolCall{{
+			Type:     bifrost.Ptr("function"),
+			ID:       bifrost.Ptr("call_1"),
+			Function: schemas.ChatAssistantMessageToolCallFunction{Name: bifrost.Ptr("get_weather"), Arguments: `{"city":"Paris"}`},
+		}}}
+	}
+	return req, &schemas.PluginShortCircuit{Response: &schemas.BifrostResponse{
+		Choices: []schemas.BifrostChatResponseChoice{{BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: message}}},
+		Usage:   &schemas.LLMUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}}, nil
+}
+
+func (p *assistantsTestPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+func (p *assistantsTestPlugin) Cleanup() error {
+	return nil
+}
+
+// newAssistantsTestHandler creates an Assistants API handler whose runs call the mock provider through plugin
+func newAssistantsTestHandler(t *testing.T, plugin *assistantsTestPlugin) *AssistantsHandler {
+	t.Helper()
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: raceTestAccount{},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+	gateway := &lib.Config{}
+	gateway.SetBifrostClient(client)
+	config := lib.AssistantsConfig{Enabled: true, MaxSteps: lib.DefaultAssistantsMaxSteps, RunTimeoutSeconds: 10}
+	return NewAssistantsHandler(lib.NewAssistants(config, assistants.NewInMemoryStore(), sessions.NewInMemoryStore(), gateway), bifrost.NewDefaultLogger(schemas.LogLevelError))
+}
+
+// assistantsRequest calls handler with the route parameters and body, and decodes the response into out
+func assistantsRequest(t *testing.T, handler fasthttp.RequestHandler, params map[string]string, body string, out any) int {
+	t.Helper()
+	ctx := &fasthttp.RequestCtx{}
+	for name, value := range params {
+		ctx.SetUserValue(name, value)
+	}
+	ctx.Request.SetBodyString(body)
+	handler(ctx)
+	if out != nil && ctx.Response.StatusCode() == fasthttp.StatusOK {
+		if err := json.Unmarshal(ctx.Response.Body(), out); err != nil {
+			t.Fatalf("failed to decode response %s: %v", ctx.Response.Body(), err)
+		}
+	}
+	return ctx.Response.StatusCode()
+}
+
+// waitForRunStatus polls the run until it reaches status
+func waitForRunStatus(t *testing.T, h *AssistantsHandler, threadID, runID, status string) RunObject {
+	t.Helper()
+	var run RunObject
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		assistantsRequest(t, h.getRun, map[string]string{"thread_id": threadID, "run_id": runID}, "", &run)
+		if run.Status == status {
+			return run
+		}
+	}
+	t.Fatalf("run status = %s, want %s (last error %+v)", run.Status, status, run.LastError)
+	return run
+}
+
+// TestAssistants_RunWithFunctionCall tests that a run stops for the outputs of the function calls of the model,
+// resumes once they are submitted and appends the final reply to the thread, refusing new messages meanwhile
+func TestAssistants_RunWithFunctionCall(t *testing.T) {
+	plugin := &assistantsTestPlugin{release: make(chan struct{}), inputs: make(chan []schemas.ChatMessage, 8)}
+	h := newAssistantsTestHandler(t, plugin)
+
+	var assistant AssistantObject
+	if status := assistantsRequest(t, h.createAssistant, nil, `{"model":"mock/weather","instructions":"You answer weather questions.",
+		"tools":[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object"}}}]}`, &assistant); status != fasthttp.StatusOK {
+		t.Fatalf("create assistant status = %d", status)
+	}
+	var thread ThreadObject
+	assistantsRequest(t, h.createThread, nil, `{"messages":[{"role":"user","content":"What is the weather in Paris?"}]}`, &thread)
+	threadParams := map[string]string{"thread_id": thread.ID}
+
+	var run RunObject
+	if status := assistantsRequest(t, h.createRun, threadParams, `{"assistant_id":"`+assistant.ID+`"}`, &run); status != fasthttp.StatusOK || run.Status != assistants.StatusQueued {
+		t.Fatalf("create run status = %d, run = %+v", status, run)
+	}
+	if status := assistantsRequest(t, h.createMessage, threadParams, `{"role":"user","content":"Hello?"}`, nil); status != fasthttp.StatusBadRequest {
+		t.Errorf("adding a message during a run status = %d, want 400", status)
+	}
+	close(plugin.release)
+
+	run = waitForRunStatus(t, h, thread.ID, run.ID, assistants.StatusRequiresAction)
+	if input := <-plugin.inputs; len(input) != 2 || input[0].Role != schemas.ChatMessageRoleSystem {
+		t.Errorf("expected the instructions and the user message to be sent, got %+v", input)
+	}
+	calls := run.RequiredAction.SubmitToolOutputs.ToolCalls
+	if len(calls) != 1 || *calls[0].Function.Name != "get_weather" {
+		t.Fatalf("required action = %+v", run.RequiredAction)
+	}
+
+	runParams := map[string]string{"thread_id": thread.ID, "run_id": run.ID}
+	if status := assistantsRequest(t, h.submitToolOutputs, runParams, `{"tool_outputs":[{"tool_call_id":"call_unknown","output":"sunny"}]}`, nil); status != fasthttp.StatusBadRequest {
+		t.Errorf("submitting the output of an unknown call status = %d, want 400", status)
+	}
+	assistantsRequest(t, h.submitToolOutputs, runParams, `{"tool_outputs":[{"tool_call_id":"call_1","output":"sunny"}]}`, nil)
+	run = waitForRunStatus(t, h, thread.ID, run.ID, assistants.StatusCompleted)
+	if run.Usage == nil || run.Usage.TotalTokens != 30 || run.CompletedAt == nil {
+		t.Errorf("completed run = %+v, want the usage of both model calls", run)
+	}
+
+	var messages struct {
+		Data    []MessageObject `json:"data"`
+		FirstID string          `json:"first_id"`
+	}
+	assistantsRequest(t, h.listMessages, threadParams, "", &messages)
+	if len(messages.Data) != 2 || messages.FirstID != messages.Data[0].ID {
+		t.Fatalf("messages = %+v, want the question and the reply", messages)
+	}
+	reply := messages.Data[0]
+	if reply.Role != "assistant" || reply.Content[0].Text.Value != "It is sunny in Paris." || reply.RunID == nil || *reply.RunID != run.ID {
+		t.Errorf("reply = %+v", reply)
+	}
+}
+
+// TestAssistants_Rejections tests the requests the compatibility layer refuses
+func TestAssistants_Rejections(t *testing.T) {
+	plugin := &assistantsTestPlugin{release: make(chan struct{}), inputs: make(chan []schemas.ChatMessage, 8)}
+	h := newAssistantsTestHandler(t, plugin)
+
+	for name, body := range map[string]string{
+		"no model":           `{"name":"helper"}`,
+		"code interpreter":   `{"model":"mock/weather","tools":[{"type":"code_interpreter"}]}`,
+		"invalid body":       `{"model":`,
+		"function sans name": `{"model":"mock/weather","tools":[{"type":"function","function":{}}]}`,
+	} {
+		if status := assistantsRequest(t, h.createAssistant, nil, body, nil); status != fasthttp.StatusBadRequest {
+			t.Errorf("%s: create assistant status = %d, want 400", name, status)
+		}
+	}
+
+	var thread ThreadObject
+	assistantsRequest(t, h.createThread, nil, `{}`, &thread)
+	threadParams := map[string]string{"thread_id": thread.ID}
+	if status := assistantsRequest(t, h.createMessage, threadParams, `{"role":"system","content":"Be terse."}`, nil); status != fasthttp.StatusBadRequest {
+		t.Errorf("system message status = %d, want 400", status)
+	}
+	if status := assistantsRequest(t, h.createRun, threadParams, `{"assistant_id":"asst_missing"}`, nil); status != fasthttp.StatusNotFound {
+		t.Errorf("run of an unknown assistant status = %d, want 404", status)
+	}
+	if status := assistantsRequest(t, h.createRun, threadParams, `{"assistant_id":"asst_missing","stream":true}`, nil); status != fasthttp.StatusBadRequest {
+		t.Errorf("streaming run status = %d, want 400", status)
+	}
+	if status := assistantsRequest(t, h.getThread, map[string]string{"thread_id": "thread_missing"}, "", nil); status != fasthttp.StatusNotFound {
+		t.Errorf("unknown thread status = %d, want 404", status)
+	}
+
+	disabled := NewAssistantsHandler(nil, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	ctx := &fasthttp.RequestCtx{}
+	disabled.listAssistants(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusNotFound || !strings.Contains(string(ctx.Response.Body()), "not enabled") {
+		t.Errorf("disabled assistants API status = %d, body = %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+}
+
+// TestAssistants_OwnerScoping tests that assistants, threads, messages and runs are only shown to the virtual key
+// that created them, and are reported as missing to other callers
+func TestAssistants_OwnerScoping(t *testing.T) {
+	plugin := &assistantsTestPlugin{release: make(chan struct{}), inputs: make(chan []schemas.ChatMessage, 8)}
+	h := newAssistantsTestHandler(t, plugin)
+	defer close(plugin.release)
+
+	as := func(vk string, handler fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.Request.Header.Set("x-bf-vk", vk)
+			handler(ctx)
+		}
+	}
+	var assistant AssistantObject
+	if status := assistantsRequest(t, as("sk-bf-alice", h.createAssistant), nil, `{"model":"mock/weather"}`, &assistant); status != fasthttp.StatusOK {
+		t.Fatalf("create assistant status = %d", status)
+	}
+	var thread ThreadObject
+	assistantsRequest(t, as("sk-bf-alice", h.createThread), nil, `{"messages":[{"role":"user","content":"Hi"}]}`, &thread)
+	var run RunObject
+	threadParams := map[string]string{"thread_id": thread.ID}
+	if status := assistantsRequest(t, as("sk-bf-alice", h.createRun), threadParams, `{"assistant_id":"`+assistant.ID+`"}`, &run); status != fasthttp.StatusOK {
+		t.Fatalf("create run status = %d", status)
+	}
+	assistantParams := map[string]string{"assistant_id": assistant.ID}
+	runParams := map[string]string{"thread_id": thread.ID, "run_id": run.ID}
+
+	var list struct {
+		Data []AssistantObject `json:"data"`
+	}
+	assistantsRequest(t, as("sk-bf-bob", h.listAssistants), nil, "", &list)
+	if len(list.Data) != 0 {
+		t.Errorf("assistants listed to another virtual key = %+v, want none", list.Data)
+	}
+	for name, request := range map[string]func() int{
+		"get assistant": func() int { return assistantsRequest(t, as("sk-bf-bob", h.getAssistant), assistantParams, "", nil) },
+		"update assistant": func() int {
+			return assistantsRequest(t, as("sk-bf-bob", h.updateAssistant), assistantParams, `{"name":"x"}`, nil)
+		},
+		"delete assistant": func() int { return assistantsRequest(t, as("sk-bf-bob", h.deleteAssistant), assistantParams, "", nil) },
+		"get thread":       func() int { return assistantsRequest(t, as("sk-bf-bob", h.getThread), threadParams, "", nil) },
+		"delete thread":    func() int { return assistantsRequest(t, as("sk-bf-bob", h.deleteThread), threadParams, "", nil) },
+		"list messages":    func() int { return assistantsRequest(t, as("sk-bf-bob", h.listMessages), threadParams, "", nil) },
+		"list runs":        func() int { return assistantsRequest(t, as("sk-bf-bob", h.listRuns), threadParams, "", nil) },
+		"get run":          func() int { return assistantsRequest(t, as("sk-bf-bob", h.getRun), runParams, "", nil) },
+		"cancel run":       func() int { return assistantsRequest(t, as("sk-bf-bob", h.cancelRun), runParams, "", nil) },
+		"submit outputs": func() int {
+			return assistantsRequest(t, as("sk-bf-bob", h.submitToolOutputs), runParams, `{"tool_outputs":[]}`, nil)
+		},
+		"run on a new thread": func() int {
+			return assistantsRequest(t, as("sk-bf-bob", h.createThreadAndRun), nil, `{"assistant_id":"`+assistant.ID+`"}`, nil)
+		},
+	} {
+		if status := request(); status != fasthttp.StatusNotFound {
+			t.Errorf("%s of another virtual key: status = %d, want 404", name, status)
+		}
+	}
+
+	var messages struct {
+		Data []MessageObject `json:"data"`
+	}
+	assistantsRequest(t, as("sk-bf-alice", h.listMessages), threadParams, "", &messages)
+	if len(messages.Data) != 1 {
+		t.Errorf("messages listed to their owner = %+v, want 1", messages.Data)
+	}
+	if status := assistantsRequest(t, as("sk-bf-alice", h.cancelRun), runParams, "", nil); status != fasthttp.StatusOK {
+		t.Errorf("cancel run of its owner status = %d, want 200", status)
+	}
+	if status := assistantsRequest(t, as("sk-bf-alice", h.getAssistant), assistantParams, "", nil); status != fasthttp.StatusOK {
+		t.Errorf("get assistant of its owner status = %d, want 200", status)
+	}
+}
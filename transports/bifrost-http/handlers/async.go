@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/asyncqueue"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// asyncPathPrefix is the prefix of the async job routes, which are never queued themselves
+const asyncPathPrefix = "/v1/async/"
+
+// asyncSkippedHeaders are not persisted with queued requests, as they describe the connection or async mode itself
+var asyncSkippedHeaders = map[string]bool{
+	"connection":           true,
+	"content-length":       true,
+	"cookie":               true,
+	"host":                 true,
+	"transfer-encoding":    true,
+	lib.AsyncHeader:        true,
+	lib.AsyncWebhookHeader: true,
+}
+
+// asyncCredentialHeaders are persisted encrypted with queued requests, and deleted once they finish
+var asyncCredentialHeaders = map[string]bool{
+	"api-key":             true,
+	"authorization":       true,
+	"proxy-authorization": true,
+	"x-api-key":           true,
+	"x-bf-vk":             true,
+	"x-goog-api-key":      true,
+}
+
+// AsyncMiddleware queues the inference requests carrying the X-Bifrost-Async: true header and answers them with
+// 202 Accepted and the job to poll. It runs after authentication, so the queued requests are replayed below it.
+// Requests under zero data retention are rejected, since their body and response would be stored until purged;
+// retention may be nil when zero data retention is only requested with the header.
+func AsyncMiddleware(config *lib.Config, retention *zeroDataRetentionPlugin, logger schemas.Logger) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			queue := config.AsyncQueue
+			path := string(ctx.Path())
+			if !strings.EqualFold(string(ctx.Request.Header.Peek(lib.AsyncHeader)), "true") || !ctx.IsPost() ||
+				!isInferencePath(path) || strings.HasPrefix(path, asyncPathPrefix) {
+				next(ctx)
+				return
+			}
+			if queue == nil {
+				SendError(ctx, fasthttp.StatusBadRequest, "async mode is not enabled", logger)
+				return
+			}
+			if isZeroDataRetention(ctx, retention) {
+				SendError(ctx, fasthttp.StatusBadRequest, "zero data retention requests cannot be queued, as async mode stores them", logger)
+				return
+			}
+
+			var body struct {
+				Model  string `json:"model"`
+				Stream bool   `json:"stream"`
+			}
+			_ = json.Unmarshal(ctx.Request.Body(), &body)
+			if body.Stream || strings.Contains(path, ":streamGenerateContent") {
+				SendError(ctx, fasthttp.StatusBadRequest, "async requests cannot stream", logger)
+				return
+			}
+			webhookURL := string(ctx.Request.Header.Peek(lib.AsyncWebhookHeader))
+			if webhookURL != "" {
+				if err := queue.CheckRequestWebhook(webhookURL); err != nil {
+					SendError(ctx, fasthttp.StatusBadRequest, "invalid async webhook: "+err.Error(), logger)
+					return
+				}
+			}
+
+			job := &asyncqueue.Job{
+				Method:         string(ctx.Method()),
+				Path:           string(ctx.RequestURI()),
+				RequestBody:    string(ctx.Request.Body()),
+				RequestHeaders: make(map[string]string),
+				ClientIP:       ctx.RemoteIP().String(),
+				WebhookURL:     webhookURL,
+				Owner:          lib.RequestOwner(ctx),
+			}
+			provider, model := schemas.ParseModelString(body.Model, "")
+			job.Provider, job.Model = string(provider), model
+			credentials := make(map[string]string)
+			ctx.Request.Header.All()(func(key, value []byte) bool {
+				switch name := strings.ToLower(string(key)); {
+				case asyncCredentialHeaders[name]:
+					credentials[name] = string(value)
+				case !asyncSkippedHeaders[name]:
+					job.RequestHeaders[name] = string(value)
+				}
+				return true
+			})
+			if len(credentials) > 0 {
+				sealed, err := queue.SealCredentials(credentials)
+				if err != nil {
+					SendError(ctx, fasthttp.StatusInternalServerError, "failed to queue async request: "+err.Error(), logger)
+					return
+				}
+				job.RequestCredentials = sealed
+			}
+			if err := queue.Enqueue(ctx, job); err != nil {
+				SendError(ctx, fasthttp.StatusInternalServerError, "failed to queue async request: "+err.Error(), logger)
+				return
+			}
+			ctx.Response.Header.Set(fasthttp.HeaderLocation, asyncPathPrefix+job.ID)
+			ctx.SetStatusCode(fasthttp.StatusAccepted)
+			SendJSON(ctx, lib.NewAsyncJobResponse(job), logger)
+		}
+	}
+}
+
+// isZeroDataRetention reports whether a request falls under zero data retention, which is otherwise only known
+// to the plugins once the request is executed
+func isZeroDataRetention(ctx *fasthttp.RequestCtx, retention *zeroDataRetentionPlugin) bool {
+	bifrostCtx := lib.ConvertToBifrostContext(ctx, false)
+	if retention != nil {
+		return retention.applies(*bifrostCtx)
+	}
+	optedIn, _ := (*bifrostCtx).Value(lib.ZeroDataRetentionHeaderContextKey).(bool)
+	return optedIn
+}
+
+// asyncExecutor replays queued requests of queue through pipeline, the handler below AsyncMiddleware.
+func asyncExecutor(queue *lib.AsyncQueue, pipeline fasthttp.RequestHandler) lib.AsyncExecutor {
+	return func(_ context.Context, job *asyncqueue.Job) lib.AsyncResult {
+		var req fasthttp.Request
+		req.Header.SetMethod(job.Method)
+		req.SetRequestURI(job.Path)
+		for name, value := range job.RequestHeaders {
+			req.Header.Set(name, value)
+		}
+		if job.RequestCredentials != "" {
+			credentials, err := queue.OpenCredentials(job.RequestCredentials)
+			if err != nil {
+				// Encrypted with another key, e.g. by a replica that does not share the config store
+				return lib.AsyncResult{
+					StatusCode:  fasthttp.StatusUnauthorized,
+					ContentType: "application/json",
+					Body:        []byte(`{"error":{"message":"the credentials of the request could not be decrypted"}}`),
+				}
+			}
+			for name, value := range credentials {
+				req.Header.Set(name, value)
+			}
+		}
+		req.SetBodyString(job.RequestBody)
+		var remoteAddr net.Addr
+		if ip := net.ParseIP(job.ClientIP); ip != nil {
+			remoteAddr = &net.TCPAddr{IP: ip}
+		}
+
+		var requestCtx fasthttp.RequestCtx
+		requestCtx.Init(&req, remoteAddr, nil)
+		pipeline(&requestCtx)
+		return lib.AsyncResult{
+			StatusCode:  requestCtx.Response.StatusCode(),
+			ContentType: string(requestCtx.Response.Header.ContentType()),
+			Body:        append([]byte(nil), requestCtx.Response.Body()...),
+		}
+	}
+}
+
+// AsyncHandler serves the state and results of async requests.
+type AsyncHandler struct {
+	queue  *lib.AsyncQueue
+	logger schemas.Logger
+}
+
+// NewAsyncHandler creates a new async handler; queue is nil when async mode is off.
+func NewAsyncHandler(queue *lib.AsyncQueue, logger schemas.Logger) *AsyncHandler {
+	return &AsyncHandler{
+		queue:  queue,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the async routes.
+func (h *AsyncHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET(asyncPathPrefix+"{id}", lib.ChainMiddlewares(h.getAsyncJob, middlewares...))
+}
+
+// getAsyncJob handles GET /v1/async/{id} - Get the state of an async request, and its response once finished
+func (h *AsyncHandler) getAsyncJob(ctx *fasthttp.RequestCtx) {
+	if h.queue == nil {
+		SendError(ctx, fasthttp.StatusNotFound, "async mode is not enabled", h.logger)
+		return
+	}
+	id, _ := ctx.UserValue("id").(string)
+	job, err := h.queue.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, asyncqueue.ErrNotFound) {
+			SendError(ctx, fasthttp.StatusNotFound, "async job not found", h.logger)
+			return
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, "failed to get async job: "+err.Error(), h.logger)
+		return
+	}
+	// Jobs of other callers are reported as missing, so their IDs cannot be probed
+	if !ownedBy(ctx, job.Owner) {
+		SendError(ctx, fasthttp.StatusNotFound, "async job not found", h.logger)
+		return
+	}
+	SendJSON(ctx, lib.NewAsyncJobResponse(job), h.logger)
+}
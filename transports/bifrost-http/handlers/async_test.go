@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/asyncqueue"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// asyncRequest builds a chat completion request asking for async mode
+func asyncRequest(body string, headers map[string]string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/v1/chat/completions")
+	ctx.Request.Header.Set(lib.AsyncHeader, "true")
+	for name, value := range headers {
+		ctx.Request.Header.Set(name, value)
+	}
+	ctx.Request.SetBodyString(body)
+	return ctx
+}
+
+// TestAsync_QueuedRequestRunsOnceProviderRecovers tests that an async request is answered with its job, waits
+// while its provider is unhealthy, is retried after a transient failure and that its result can be polled and
+// is posted, signed, to the webhook
+func TestAsync_QueuedRequestRunsOnceProviderRecovers(t *testing.T) {
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	deliveries := make(chan []byte, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, signature, _ := strings.Cut(strings.TrimPrefix(r.Header.Get(lib.AsyncSignatureHeader), "t="), ",sig=")
+		mac := hmac.New(sha256.New, []byte("webhook-secret"))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		if signature != hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("invalid webhook signature %q", r.Header.Get(lib.AsyncSignatureHeader))
+		}
+		deliveries <- body
+	}))
+	defer webhook.Close()
+
+	health := lib.NewProviderHealthTracker(lib.ProviderHealthConfig{Enabled: true, FailureThreshold: 1})
+	health.Record(schemas.OpenAI, "gpt-4o-mini", lib.ProviderHealthSample{Timestamp: time.Now(), Error: "unreachable"})
+	queue := lib.NewAsyncQueue(asyncqueue.Config{
+		Enabled:              true,
+		RetryIntervalSeconds: 1,
+		Webhook:              &asyncqueue.WebhookConfig{URL: webhook.URL, Secret: "webhook-secret"},
+	}, asyncqueue.NewInMemoryStore(), health)
+
+	var calls atomic.Int32
+	pipeline := func(ctx *fasthttp.RequestCtx) {
+		if ctx.Request.Header.Peek(lib.AsyncHeader) != nil || string(ctx.Request.Header.Peek("x-bf-vk")) != "vk-1" {
+			t.Errorf("unexpected replayed headers %s", ctx.Request.Header.String())
+		}
+		if calls.Add(1) == 1 {
+			SendError(ctx, fasthttp.StatusServiceUnavailable, "overloaded", logger)
+			return
+		}
+		SendJSON(ctx, map[string]string{"id": "chatcmpl-1"}, logger)
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue.Start(runCtx, asyncExecutor(queue, pipeline))
+
+	ctx := asyncRequest(`{"model":"openai/gpt-4o-mini","messages":[{"role":"user","content":"hi"}]}`, map[string]string{"x-bf-vk": "vk-1"})
+	AsyncMiddleware(&lib.Config{AsyncQueue: queue}, nil, logger)(pipeline)(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var queued lib.AsyncJobResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &queued); err != nil || queued.Status != asyncqueue.StatusQueued || queued.Provider != "openai" {
+		t.Fatalf("unexpected queued job %s", ctx.Response.Body())
+	}
+	if location := string(ctx.Response.Header.Peek(fasthttp.HeaderLocation)); location != "/v1/async/"+queued.ID {
+		t.Errorf("expected the job location, got %q", location)
+	}
+
+	stored, err := queue.Get(context.Background(), queued.ID)
+	if err != nil || stored.RequestHeaders["x-bf-vk"] != "" || stored.RequestCredentials == "" || strings.Contains(stored.RequestCredentials, "vk-1") {
+		t.Fatalf("expected the virtual key to be stored encrypted, got headers %v and credentials %q", stored.RequestHeaders, stored.RequestCredentials)
+	}
+
+	handler := NewAsyncHandler(queue, logger)
+	// Other callers cannot see the job
+	otherCtx := &fasthttp.RequestCtx{}
+	otherCtx.Request.Header.Set("x-bf-vk", "vk-2")
+	otherCtx.SetUserValue("id", queued.ID)
+	handler.getAsyncJob(otherCtx)
+	if otherCtx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Fatalf("expected 404 for the job of another virtual key, got %d", otherCtx.Response.StatusCode())
+	}
+	poll := func() lib.AsyncJobResponse {
+		pollCtx := &fasthttp.RequestCtx{}
+		pollCtx.Request.Header.Set("x-bf-vk", "vk-1")
+		pollCtx.SetUserValue("id", queued.ID)
+		handler.getAsyncJob(pollCtx)
+		var job lib.AsyncJobResponse
+		if err := json.Unmarshal(pollCtx.Response.Body(), &job); err != nil {
+			t.Fatalf("invalid poll response %s", pollCtx.Response.Body())
+		}
+		return job
+	}
+
+	// The provider is unhealthy: the job waits without spending attempts
+	time.Sleep(200 * time.Millisecond)
+	if job := poll(); calls.Load() != 0 || job.Status != asyncqueue.StatusQueued || job.Attempts != 0 || job.NextAttemptAt == nil {
+		t.Fatalf("expected the job to wait for the provider, got %+v after %d calls", job, calls.Load())
+	}
+
+	health.Record(schemas.OpenAI, "gpt-4o-mini", lib.ProviderHealthSample{Timestamp: time.Now(), Success: true})
+	select {
+	case body := <-deliveries:
+		var delivered lib.AsyncJobResponse
+		if err := json.Unmarshal(body, &delivered); err != nil || delivered.ID != queued.ID || delivered.Status != asyncqueue.StatusSucceeded {
+			t.Fatalf("unexpected webhook delivery %s", body)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("no webhook delivery, job %+v", poll())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	job := poll()
+	for job.WebhookStatus == "" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		job = poll()
+	}
+	if job.Status != asyncqueue.StatusSucceeded || job.Attempts != 2 || job.StatusCode != 200 || job.CompletedAt == nil || job.WebhookStatus != "delivered" {
+		t.Errorf("unexpected finished job %+v", job)
+	}
+	if string(job.Response) != `{"id":"chatcmpl-1"}` {
+		t.Errorf("expected the response of the last attempt, got %s", job.Response)
+	}
+	if stored, err := queue.Get(context.Background(), queued.ID); err != nil || stored.RequestCredentials != "" {
+		t.Errorf("expected the credentials to be deleted once the job finished")
+	}
+}
+
+// TestAsyncMiddleware_Rejections tests which requests are passed through, queued or rejected
+func TestAsyncMiddleware_Rejections(t *testing.T) {
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	var passed int
+	pipeline := func(ctx *fasthttp.RequestCtx) { passed++ }
+	chat := `{"model":"openai/gpt-4o-mini","messages":[]}`
+	newQueue := func(config asyncqueue.Config) *lib.Config {
+		config.Enabled = true
+		return &lib.Config{AsyncQueue: lib.NewAsyncQueue(config, asyncqueue.NewInMemoryStore(), nil)}
+	}
+
+	// Requests without the header pass through
+	ctx := asyncRequest(chat, nil)
+	ctx.Request.Header.Del(lib.AsyncHeader)
+	AsyncMiddleware(newQueue(asyncqueue.Config{}), nil, logger)(pipeline)(ctx)
+	if passed != 1 {
+		t.Fatalf("expected the synchronous request to pass through")
+	}
+
+	tests := []struct {
+		name    string
+		config  *lib.Config
+		body    string
+		webhook string
+		headers map[string]string
+		status  int
+	}{
+		{name: "async mode off", config: &lib.Config{}, body: chat, status: fasthttp.StatusBadRequest},
+		{name: "streaming", config: newQueue(asyncqueue.Config{}), body: `{"model":"openai/gpt-4o-mini","stream":true}`, status: fasthttp.StatusBadRequest},
+		{name: "request webhooks off", config: newQueue(asyncqueue.Config{}), body: chat, webhook: "https://hooks.example.com/bifrost", status: fasthttp.StatusBadRequest},
+		{name: "private webhook", config: newQueue(asyncqueue.Config{RequestWebhooks: true}), body: chat, webhook: "http://127.0.0.1:8080/hook", status: fasthttp.StatusBadRequest},
+		{name: "webhook host not allowed", config: newQueue(asyncqueue.Config{RequestWebhooks: true, AllowedWebhookHosts: []string{"*.example.com"}}), body: chat, webhook: "https://hooks.example.org/bifrost", status: fasthttp.StatusBadRequest},
+		{name: "zero data retention", config: newQueue(asyncqueue.Config{}), body: chat, headers: map[string]string{"x-bf-zero-data-retention": "true"}, status: fasthttp.StatusBadRequest},
+		{name: "allowed webhook", config: newQueue(asyncqueue.Config{RequestWebhooks: true, AllowedWebhookHosts: []string{"*.example.com"}}), body: chat, webhook: "https://hooks.example.com/bifrost", status: fasthttp.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{lib.AsyncWebhookHeader: tt.webhook}
+			for name, value := range tt.headers {
+				headers[name] = value
+			}
+			ctx := asyncRequest(tt.body, headers)
+			AsyncMiddleware(tt.config, nil, logger)(pipeline)(ctx)
+			if ctx.Response.StatusCode() != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, ctx.Response.StatusCode(), ctx.Response.Body())
+			}
+		})
+	}
+	if passed != 1 {
+		t.Errorf("expected async requests never to reach the pipeline, %d did", passed)
+	}
+
+	// Unknown jobs are not found
+	pollCtx := &fasthttp.RequestCtx{}
+	pollCtx.SetUserValue("id", "missing")
+	NewAsyncHandler(newQueue(asyncqueue.Config{}).AsyncQueue, logger).getAsyncJob(pollCtx)
+	if pollCtx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("expected 404 for an unknown job, got %d", pollCtx.Response.StatusCode())
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// Authentication methods reported by GET /api/auth/me.
+const (
+	AuthMethodNone           = "none"
+	AuthMethodCookie         = "cookie"
+	AuthMethodBearer         = "bearer"
+	AuthMethodServiceAccount = "service_account"
+	AuthMethodTenant         = "tenant"
+	AuthMethodDevice         = "device"
+)
+
+// AuthHandler signs admins in and out of the dashboard.
+type AuthHandler struct {
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// LoginRequest is the body of POST /api/auth/login.
+type LoginRequest struct {
+	Password string                  `json:"password"`
+	Next     string                  `json:"next,omitempty"`
+	Scopes   []serviceaccounts.Scope `json:"scopes,omitempty"` // Limits the session to these scopes
+}
+
+// AuthSession describes the caller's admin session.
+type AuthSession struct {
+	AuthEnabled    bool                    `json:"auth_enabled"`
+	Authenticated  bool                    `json:"authenticated"`
+	Method         string                  `json:"method"`
+	ServiceAccount string                  `json:"service_account,omitempty"`
+	Tenant         string                  `json:"tenant,omitempty"`
+	Scopes         []serviceaccounts.Scope `json:"scopes,omitempty"`
+	Redirect       string                  `json:"redirect,omitempty"`
+	Branding       *lib.TenantBranding     `json:"branding,omitempty"`
+	ExpiresAt      *time.Time              `json:"expires_at,omitempty"` // End of a login session
+}
+
+// NewAuthHandler creates a new auth handler.
+func NewAuthHandler(config *lib.Config, logger schemas.Logger) *AuthHandler {
+	return &AuthHandler{
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the auth routes. They are public, see isPublicPath.
+func (h *AuthHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST("/api/auth/login", lib.ChainMiddlewares(h.login, middlewares...))
+	r.POST("/api/auth/logout", lib.ChainMiddlewares(h.logout, middlewares...))
+	r.GET("/api/auth/me", lib.ChainMiddlewares(h.me, middlewares...))
+}
+
+// login handles POST /api/auth/login - Check the admin password and start a cookie session
+// The cookie carries an opaque session ID, optionally limited to the requested scopes. On a tenant domain with a
+// password, the tenant password starts a session limited to the tenant's scopes.
+func (h *AuthHandler) login(ctx *fasthttp.RequestCtx) {
+	adminSecret := h.config.GetAdminSecret()
+	if strings.TrimSpace(adminSecret) == "" {
+		SendError(ctx, fasthttp.StatusConflict, "admin auth is not enabled, set BIFROST_ADMIN_PASSWORD to enable it", h.logger)
+		return
+	}
+	var req LoginRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Password == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "password is required", h.logger)
+		return
+	}
+	if tenant := requestTenant(ctx); tenant != nil && tenant.LoginPassword() != "" &&
+		subtle.ConstantTimeCompare([]byte(req.Password), []byte(tenant.LoginPassword())) == 1 {
+		scopes := tenant.SessionScopes()
+		if len(req.Scopes) > 0 {
+			for _, scope := range req.Scopes {
+				if !tenant.Allows(scope) {
+					SendError(ctx, fasthttp.StatusForbidden, fmt.Sprintf("tenant sessions lack the %s scope", scope), h.logger)
+					return
+				}
+			}
+			scopes = req.Scopes
+		}
+		session, ok := h.startSession(ctx, tenant.Name, scopes)
+		if !ok {
+			return
+		}
+		branding := tenant.Branding()
+		SendJSON(ctx, AuthSession{
+			AuthEnabled:   true,
+			Authenticated: true,
+			Method:        AuthMethodTenant,
+			Tenant:        tenant.Name,
+			Scopes:        session.Scopes,
+			Redirect:      safeRedirect(req.Next),
+			Branding:      &branding,
+			ExpiresAt:     &session.ExpiresAt,
+		}, h.logger)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Password), []byte(adminSecret)) != 1 {
+		SendError(ctx, fasthttp.StatusUnauthorized, "invalid password", h.logger)
+		return
+	}
+	session, ok := h.startSession(ctx, "", req.Scopes)
+	if !ok {
+		return
+	}
+	SendJSON(ctx, AuthSession{
+		AuthEnabled:   true,
+		Authenticated: true,
+		Method:        AuthMethodCookie,
+		Scopes:        session.Scopes,
+		Redirect:      safeRedirect(req.Next),
+		ExpiresAt:     &session.ExpiresAt,
+	}, h.logger)
+}
+
+// startSession starts a dashboard session and sets its cookie. It returns false after sending the error response.
+func (h *AuthHandler) startSession(ctx *fasthttp.RequestCtx, tenant string, scopes []serviceaccounts.Scope) (*lib.UISession, bool) {
+	session, err := h.config.CreateUISession(ctx, tenant, scopes)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return nil, false
+	}
+	setAdminCookie(ctx, h.config, session.ID)
+	return session, true
+}
+
+// logout handles POST /api/auth/logout - End the cookie session, or the device login of a bearer device token
+func (h *AuthHandler) logout(ctx *fasthttp.RequestCtx) {
+	if token, ok := bearerToken(ctx); ok && strings.HasPrefix(token, lib.DeviceTokenPrefix) {
+		h.config.RevokeDeviceToken(ctx, token)
+	}
+	if c := ctx.Request.Header.Cookie(adminCookieName(h.config)); len(c) > 0 {
+		h.config.DeleteUISession(ctx, string(c))
+	}
+	clearAdminCookie(ctx, h.config)
+	enabled := strings.TrimSpace(h.config.GetAdminSecret()) != ""
+	SendJSON(ctx, AuthSession{
+		AuthEnabled:   enabled,
+		Authenticated: !enabled,
+		Method:        AuthMethodNone,
+		Redirect:      "/login",
+	}, h.logger)
+}
+
+// me handles GET /api/auth/me - Get the caller's admin session
+// It always succeeds so the dashboard can tell whether it needs to show the login page.
+func (h *AuthHandler) me(ctx *fasthttp.RequestCtx) {
+	SendJSON(ctx, authSession(ctx, h.config), h.logger)
+}
+
+// authSession resolves how a request is authenticated, mirroring the checks of AdminAuthMiddleware. On a tenant
+// domain, the session carries the tenant's branding.
+func authSession(ctx *fasthttp.RequestCtx, config *lib.Config) AuthSession {
+	tenant := requestTenant(ctx)
+	var branding *lib.TenantBranding
+	if tenant != nil {
+		b := tenant.Branding()
+		branding = &b
+	}
+	adminSecret := config.GetAdminSecret()
+	if strings.TrimSpace(adminSecret) == "" {
+		return AuthSession{Authenticated: true, Method: AuthMethodNone, Branding: branding}
+	}
+	session := AuthSession{AuthEnabled: true, Method: AuthMethodNone, Branding: branding}
+	if token, ok := bearerToken(ctx); ok {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminSecret)) == 1 {
+			session.Authenticated = true
+			session.Method = AuthMethodBearer
+			return session
+		}
+		if strings.HasPrefix(token, lib.DeviceTokenPrefix) {
+			if deviceToken := config.GetDeviceToken(ctx, token); deviceToken != nil {
+				session.Authenticated = true
+				session.Method = AuthMethodDevice
+				session.Scopes = deviceToken.Scopes
+				session.ExpiresAt = &deviceToken.ExpiresAt
+			}
+			return session
+		}
+		if strings.HasPrefix(token, serviceaccounts.TokenPrefix) && config.ServiceAccounts != nil {
+			if account, err := config.ServiceAccounts.Authenticate(ctx, token); err == nil {
+				session.Authenticated = true
+				session.Method = AuthMethodServiceAccount
+				session.ServiceAccount = account.Name
+				session.Scopes = account.Scopes
+			}
+			return session
+		}
+	}
+	if uiSession := requestUISession(ctx, config); uiSession != nil {
+		session.Authenticated = true
+		session.Method = AuthMethodCookie
+		if uiSession.Tenant != "" {
+			session.Method = AuthMethodTenant
+			session.Tenant = uiSession.Tenant
+		}
+		session.Scopes = uiSession.Scopes
+		session.ExpiresAt = &uiSession.ExpiresAt
+		return session
+	}
+	return session
+}
+
+// bearerToken returns the token of an Authorization: Bearer header.
+func bearerToken(ctx *fasthttp.RequestCtx) (string, bool) {
+	auth := strings.TrimSpace(string(ctx.Request.Header.Peek("Authorization")))
+	if !strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(auth[len("Bearer "):]), true
+}
+
+// requestUISession returns the dashboard session of a request: the session of the internal token on requests
+// dispatched by the UI proxy, else the session of the cookie. Sessions of a tenant login are only valid on the
+// tenant's domain. It returns nil when the request has no active session.
+func requestUISession(ctx *fasthttp.RequestCtx, config *lib.Config) *lib.UISession {
+	var session *lib.UISession
+	if token, ok := bearerToken(ctx); ok && strings.HasPrefix(token, lib.UISessionTokenPrefix) {
+		if proxied, _ := ctx.UserValue(lib.UIProxyContextKey).(bool); proxied {
+			session = config.GetUISessionByToken(ctx, token)
+		}
+	} else if c := ctx.Request.Header.Cookie(adminCookieName(config)); len(c) > 0 {
+		session = config.GetUISession(ctx, string(c))
+	}
+	if session != nil && session.Tenant != "" {
+		if tenant := requestTenant(ctx); tenant == nil || tenant.Name != session.Tenant {
+			return nil
+		}
+	}
+	return session
+}
+
+// authorizeUISession checks that a dashboard session holds the scopes of the request. Scoped sessions cannot call
+// the inference endpoints, which take virtual keys. Requests to the UI proxy are checked once dispatched. It returns
+// false after sending the error response.
+func authorizeUISession(ctx *fasthttp.RequestCtx, session *lib.UISession, logger schemas.Logger) bool {
+	method, path := string(ctx.Method()), string(ctx.Path())
+	if len(session.Scopes) == 0 || strings.HasPrefix(path, uiProxyPrefix) {
+		return true
+	}
+	if strings.HasPrefix(path, "/v1/") {
+		SendError(ctx, fasthttp.StatusForbidden, "scoped sessions cannot call the inference endpoints", logger)
+		return false
+	}
+	for _, scope := range serviceAccountScopes(method, path) {
+		if !session.Allows(scope) {
+			SendError(ctx, fasthttp.StatusForbidden, fmt.Sprintf("session lacks the %s scope", scope), logger)
+			return false
+		}
+	}
+	return true
+}
+
+// adminCookieName returns the name of the admin session cookie.
+func adminCookieName(config *lib.Config) string {
+	if config != nil && strings.TrimSpace(config.AdminCookieName) != "" {
+		return config.AdminCookieName
+	}
+	return "bf_admin"
+}
+
+// setAdminCookie sets the cookie of a dashboard session. The cookie is HttpOnly and lasts for the browser session.
+func setAdminCookie(ctx *fasthttp.RequestCtx, config *lib.Config, sessionID string) {
+	var c fasthttp.Cookie
+	c.SetKey(adminCookieName(config))
+	c.SetValue(sessionID)
+	c.SetPath("/")
+	c.SetHTTPOnly(true)
+	c.SetSameSite(fasthttp.CookieSameSiteLaxMode)
+	ctx.Response.Header.SetCookie(&c)
+}
+
+// clearAdminCookie expires the admin session cookie.
+func clearAdminCookie(ctx *fasthttp.RequestCtx, config *lib.Config) {
+	var c fasthttp.Cookie
+	c.SetKey(adminCookieName(config))
+	c.SetValue("")
+	c.SetPath("/")
+	c.SetExpire(time.Unix(0, 0))
+	c.SetMaxAge(-1)
+	ctx.Response.Header.SetCookie(&c)
+}
+
+// safeRedirect returns next when it is a local path, so the login page cannot be used as an open redirect.
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.Contains(next, "://") || strings.Contains(next, `\`) {
+		return "/"
+	}
+	return next
+}
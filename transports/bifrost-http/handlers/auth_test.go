@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestAuthHandler_LoginSession tests that signing in sets a session cookie accepted by the admin auth middleware,
+// that /api/auth/me reports it and that redirects stay on the dashboard
+func TestAuthHandler_LoginSession(t *testing.T) {
+	config := &lib.Config{AdminSecret: "admin-secret", AdminCookieName: "bf_admin"}
+	handler := NewAuthHandler(config, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetBody([]byte(`{"password":"wrong"}`))
+	handler.login(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Fatalf("expected a wrong password to be rejected, got %d", ctx.Response.StatusCode())
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetBody([]byte(`{"password":"admin-secret","next":"//evil.example/logs"}`))
+	handler.login(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected the login to succeed, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var session AuthSession
+	if err := json.Unmarshal(ctx.Response.Body(), &session); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !session.Authenticated || session.Method != AuthMethodCookie || session.Redirect != "/" {
+		t.Errorf("unexpected session %+v", session)
+	}
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+	cookie.SetKey("bf_admin")
+	if !ctx.Response.Header.Cookie(cookie) || len(cookie.Value()) == 0 || string(cookie.Value()) == "admin-secret" || !cookie.HTTPOnly() {
+		t.Fatalf("expected an HttpOnly session cookie not carrying the secret, got %q", cookie.String())
+	}
+	sessionID := string(cookie.Value())
+
+	// The cookie authenticates dashboard requests and /api/auth/me
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("/api/config")
+	ctx.Request.Header.SetCookie("bf_admin", sessionID)
+	AdminAuthMiddleware(config, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	})(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("expected the session cookie to be accepted, got %d", ctx.Response.StatusCode())
+	}
+	ctx.Response.Reset()
+	handler.me(ctx)
+	if err := json.Unmarshal(ctx.Response.Body(), &session); err != nil || !session.Authenticated || session.Method != AuthMethodCookie {
+		t.Errorf("expected /api/auth/me to report the cookie session, got %s", ctx.Response.Body())
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	handler.me(ctx)
+	if err := json.Unmarshal(ctx.Response.Body(), &session); err != nil || session.Authenticated || !session.AuthEnabled {
+		t.Errorf("expected /api/auth/me to report a missing session, got %s", ctx.Response.Body())
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetCookie("bf_admin", sessionID)
+	handler.logout(ctx)
+	cookie.SetKey("bf_admin")
+	if !ctx.Response.Header.Cookie(cookie) || len(cookie.Value()) != 0 {
+		t.Errorf("expected the logout to clear the session cookie, got %q", cookie.String())
+	}
+	if config.GetUISession(context.Background(), sessionID) != nil {
+		t.Errorf("expected the logout to end the session")
+	}
+}
+
+// TestAuthHandler_SharedSessions tests that a session started on one replica is accepted by another replica sharing
+// the config store, survives a restart, and ends everywhere on logout
+func TestAuthHandler_SharedSessions(t *testing.T) {
+	ctx := context.Background()
+	testLogger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	lib.SetLogger(testLogger)
+	storeConfig := &configstore.Config{
+		Enabled: true,
+		Type:    configstore.ConfigStoreTypeSQLite,
+		Config:  &configstore.SQLiteConfig{Path: filepath.Join(t.TempDir(), "config.db")},
+	}
+	newReplica := func() *lib.Config {
+		t.Helper()
+		store, err := configstore.NewConfigStore(ctx, storeConfig, testLogger)
+		if err != nil {
+			t.Fatalf("failed to create config store: %v", err)
+		}
+		t.Cleanup(func() { store.Close(ctx) })
+		return &lib.Config{AdminSecret: "admin-secret", AdminCookieName: "bf_admin", ConfigStore: store}
+	}
+	authorized := func(config *lib.Config, sessionID string) bool {
+		t.Helper()
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Init(&fasthttp.Request{}, nil, nil)
+		ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+		ctx.Request.SetRequestURI("/api/config")
+		ctx.Request.Header.SetCookie("bf_admin", sessionID)
+		AdminAuthMiddleware(config, testLogger)(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusOK)
+		})(ctx)
+		return ctx.Response.StatusCode() == fasthttp.StatusOK
+	}
+	first, second := newReplica(), newReplica()
+
+	login := &fasthttp.RequestCtx{}
+	login.Init(&fasthttp.Request{}, nil, nil)
+	login.Request.SetBody([]byte(`{"password":"admin-secret"}`))
+	NewAuthHandler(first, testLogger).login(login)
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+	cookie.SetKey("bf_admin")
+	if !login.Response.Header.Cookie(cookie) || len(cookie.Value()) == 0 {
+		t.Fatalf("expected a session cookie, got %d: %s", login.Response.StatusCode(), login.Response.Body())
+	}
+	sessionID := string(cookie.Value())
+
+	if !authorized(second, sessionID) {
+		t.Error("expected the session to be accepted by another replica")
+	}
+	if !authorized(newReplica(), sessionID) {
+		t.Error("expected the session to survive a restart")
+	}
+
+	logout := &fasthttp.RequestCtx{}
+	logout.Init(&fasthttp.Request{}, nil, nil)
+	logout.Request.Header.SetCookie("bf_admin", sessionID)
+	NewAuthHandler(second, testLogger).logout(logout)
+	if authorized(first, sessionID) {
+		t.Error("expected the logout on another replica to end the session")
+	}
+}
+
+// TestAdminAuthMiddleware_LoginPage tests that the login page and its endpoints are reachable without a session
+// while the rest of the dashboard redirects to it
+func TestAdminAuthMiddleware_LoginPage(t *testing.T) {
+	config := &lib.Config{AdminSecret: "admin-secret", AdminCookieName: "bf_admin"}
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{fasthttp.MethodGet, "/login/", fasthttp.StatusOK},
+		{fasthttp.MethodGet, "/_next/static/chunks/app.js", fasthttp.StatusOK},
+		{fasthttp.MethodPost, "/api/auth/login", fasthttp.StatusOK},
+		{fasthttp.MethodGet, "/api/auth/me", fasthttp.StatusOK},
+		{fasthttp.MethodGet, "/api/config", fasthttp.StatusUnauthorized},
+		{fasthttp.MethodGet, "/logs", fasthttp.StatusFound},
+	}
+	for _, tt := range tests {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(tt.method)
+		ctx.Request.SetRequestURI(tt.path)
+		AdminAuthMiddleware(config, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusOK)
+		})(ctx)
+		if got := ctx.Response.StatusCode(); got != tt.want {
+			t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, got, tt.want)
+		}
+		if tt.want == fasthttp.StatusFound {
+			if location := string(ctx.Response.Header.Peek("Location")); !strings.HasPrefix(location, "/login?next=") {
+				t.Errorf("%s %s: expected a redirect to the login page, got %q", tt.method, tt.path, location)
+			}
+		}
+	}
+}
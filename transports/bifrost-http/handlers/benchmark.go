@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+const (
+	defaultBenchmarkModel       = "benchmark"
+	defaultBenchmarkPrompt      = "Hello, how are you?"
+	defaultBenchmarkRequests    = 100
+	maxBenchmarkRequests        = 100000
+	defaultBenchmarkConcurrency = 10
+	maxBenchmarkConcurrency     = 1000
+	defaultBenchmarkTimeout     = 30 * time.Second
+	maxBenchmarkErrors          = 10 // Distinct error messages reported
+)
+
+// BenchmarkEndpoint is the inference endpoint a benchmark sends requests to.
+type BenchmarkEndpoint string
+
+const (
+	BenchmarkEndpointChat       BenchmarkEndpoint = "chat"
+	BenchmarkEndpointText       BenchmarkEndpoint = "text"
+	BenchmarkEndpointResponses  BenchmarkEndpoint = "responses"
+	BenchmarkEndpointEmbeddings BenchmarkEndpoint = "embeddings"
+)
+
+// BenchmarkRequest is the body of POST /api/benchmark/run.
+type BenchmarkRequest struct {
+	Endpoint       BenchmarkEndpoint `json:"endpoint,omitempty"`        // chat (default), text, responses or embeddings
+	Model          string            `json:"model,omitempty"`           // Mock provider model, defaults to "benchmark"
+	Prompt         string            `json:"prompt,omitempty"`          // User message, prompt or embedding input
+	Stream         bool              `json:"stream,omitempty"`          // Stream chat and text completions
+	Requests       int               `json:"requests,omitempty"`        // Total requests, 100 by default
+	Concurrency    int               `json:"concurrency,omitempty"`     // Requests in flight, 10 by default
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"` // Timeout of each request, 30 by default
+	Headers        map[string]string `json:"headers,omitempty"`         // Sent with every request, e.g. a virtual key
+}
+
+// BenchmarkLatency is a latency distribution in milliseconds.
+type BenchmarkLatency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+// BenchmarkResult reports the throughput and latencies of a benchmark.
+type BenchmarkResult struct {
+	Requests        int               `json:"requests"`
+	Succeeded       int               `json:"succeeded"`
+	Failed          int               `json:"failed"`
+	StatusCodes     map[int]int       `json:"status_codes"`
+	DurationMs      float64           `json:"duration_ms"`
+	Throughput      float64           `json:"requests_per_second"`
+	Latency         BenchmarkLatency  `json:"latency"`                      // Until the response is fully read
+	TimeToFirstByte *BenchmarkLatency `json:"time_to_first_byte,omitempty"` // Streaming benchmarks only
+	Errors          []string          `json:"errors,omitempty"`             // First distinct errors
+}
+
+// BenchmarkHandler runs synthetic traffic through the complete server handler, with every middleware and plugin,
+// against the mock provider.
+type BenchmarkHandler struct {
+	config   *lib.Config
+	pipeline fasthttp.RequestHandler
+	running  atomic.Bool
+	logger   schemas.Logger
+}
+
+// NewBenchmarkHandler creates a new benchmark handler sending requests to pipeline.
+func NewBenchmarkHandler(config *lib.Config, pipeline fasthttp.RequestHandler, logger schemas.Logger) *BenchmarkHandler {
+	return &BenchmarkHandler{
+		config:   config,
+		pipeline: pipeline,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the benchmark routes.
+func (h *BenchmarkHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST("/api/benchmark/run", lib.ChainMiddlewares(h.run, middlewares...))
+}
+
+// run handles POST /api/benchmark/run - Send synthetic requests to the mock provider and report throughput and latencies
+// Requests go through an in-memory connection to the server handler, so HTTP parsing, middlewares, plugins and
+// the provider queue are all measured. One benchmark runs at a time.
+func (h *BenchmarkHandler) run(ctx *fasthttp.RequestCtx) {
+	var req BenchmarkRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+			return
+		}
+	}
+	if err := req.normalize(); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	if _, err := h.config.GetProviderConfigRaw(schemas.Mock); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Benchmarks run against the %s provider, add it first", schemas.Mock), h.logger)
+		return
+	}
+	if !h.running.CompareAndSwap(false, true) {
+		SendError(ctx, fasthttp.StatusConflict, "A benchmark is already running", h.logger)
+		return
+	}
+	defer h.running.Store(false)
+
+	result, err := runBenchmark(h.pipeline, req)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to run benchmark: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, result, h.logger)
+}
+
+// normalize validates the request and applies defaults.
+func (r *BenchmarkRequest) normalize() error {
+	if r.Endpoint == "" {
+		r.Endpoint = BenchmarkEndpointChat
+	}
+	if r.Model == "" {
+		r.Model = defaultBenchmarkModel
+	}
+	if r.Prompt == "" {
+		r.Prompt = defaultBenchmarkPrompt
+	}
+	if r.Requests == 0 {
+		r.Requests = defaultBenchmarkRequests
+	}
+	if r.Concurrency == 0 {
+		r.Concurrency = defaultBenchmarkConcurrency
+	}
+	switch {
+	case r.Requests < 0 || r.Requests > maxBenchmarkRequests:
+		return fmt.Errorf("requests must be between 1 and %d", maxBenchmarkRequests)
+	case r.Concurrency < 0 || r.Concurrency > maxBenchmarkConcurrency:
+		return fmt.Errorf("concurrency must be between 1 and %d", maxBenchmarkConcurrency)
+	case r.TimeoutSeconds < 0:
+		return errors.New("timeout_seconds must not be negative")
+	case r.Stream && (r.Endpoint == BenchmarkEndpointResponses || r.Endpoint == BenchmarkEndpointEmbeddings):
+		return fmt.Errorf("%s requests cannot be streamed", r.Endpoint)
+	}
+	r.Concurrency = min(r.Concurrency, r.Requests)
+	return nil
+}
+
+// benchmarkBody returns the path and body of the benchmark requests.
+func benchmarkBody(req BenchmarkRequest) (string, []byte, error) {
+	model := string(schemas.Mock) + "/" + req.Model
+	var path string
+	var body map[string]any
+	switch req.Endpoint {
+	case BenchmarkEndpointChat:
+		path = "/v1/chat/completions"
+		body = map[string]any{"model": model, "messages": []map[string]string{{"role": "user", "content": req.Prompt}}}
+	case BenchmarkEndpointText:
+		path = "/v1/completions"
+		body = map[string]any{"model": model, "prompt": req.Prompt}
+	case BenchmarkEndpointResponses:
+		path = "/v1/responses"
+		body = map[string]any{"model": model, "input": req.Prompt}
+	case BenchmarkEndpointEmbeddings:
+		path = "/v1/embeddings"
+		body = map[string]any{"model": model, "input": req.Prompt}
+	default:
+		return "", nil, fmt.Errorf("unsupported endpoint %q", req.Endpoint)
+	}
+	if req.Stream {
+		body["stream"] = true
+	}
+	data, err := json.Marshal(body)
+	return path, data, err
+}
+
+// benchmarkSample is the outcome of one benchmark request.
+type benchmarkSample struct {
+	statusCode int
+	latency    time.Duration
+	firstByte  time.Duration
+	err        string
+}
+
+// runBenchmark serves pipeline on an in-memory listener and sends the benchmark requests to it.
+func runBenchmark(pipeline fasthttp.RequestHandler, req BenchmarkRequest) (*BenchmarkResult, error) {
+	path, body, err := benchmarkBody(req)
+	if err != nil {
+		return nil, err
+	}
+	timeout := defaultBenchmarkTimeout
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+
+	listener := fasthttputil.NewInmemoryListener()
+	server := &fasthttp.Server{Handler: pipeline}
+	go server.Serve(listener)
+	defer server.Shutdown()
+	client := &fasthttp.Client{
+		Dial:               func(string) (net.Conn, error) { return listener.Dial() },
+		MaxConnsPerHost:    req.Concurrency,
+		StreamResponseBody: req.Stream,
+	}
+
+	samples := make([]benchmarkSample, req.Requests)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range req.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= req.Requests {
+					return
+				}
+				samples[i] = sendBenchmarkRequest(client, path, body, req, timeout)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	return summarizeBenchmark(samples, elapsed, req.Stream), nil
+}
+
+// sendBenchmarkRequest sends one request and reads the whole response.
+func sendBenchmarkRequest(client *fasthttp.Client, path string, body []byte, req BenchmarkRequest, timeout time.Duration) benchmarkSample {
+	request := fasthttp.AcquireRequest()
+	response := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(request)
+	defer fasthttp.ReleaseResponse(response)
+
+	request.SetRequestURI("http://bifrost.benchmark" + path)
+	request.Header.SetMethod(fasthttp.MethodPost)
+	request.Header.SetContentType("application/json")
+	for name, value := range req.Headers {
+		request.Header.Set(name, value)
+	}
+	request.SetBodyRaw(body)
+	request.SetTimeout(timeout)
+
+	start := time.Now()
+	if err := client.Do(request, response); err != nil {
+		return benchmarkSample{latency: time.Since(start), err: err.Error()}
+	}
+	sample := benchmarkSample{statusCode: response.StatusCode()}
+	if stream := response.BodyStream(); stream != nil {
+		reader := &firstByteReader{Reader: stream}
+		_, err := io.Copy(io.Discard, reader)
+		sample.firstByte = reader.firstByte.Sub(start)
+		if err != nil {
+			sample.err = err.Error()
+		}
+	}
+	sample.latency = time.Since(start)
+	if sample.err == "" && sample.statusCode >= fasthttp.StatusBadRequest {
+		var bifrostErr schemas.BifrostError
+		if err := json.Unmarshal(response.Body(), &bifrostErr); err == nil && bifrostErr.Error != nil {
+			sample.err = bifrostErr.Error.Message
+		} else {
+			sample.err = fmt.Sprintf("status %d", sample.statusCode)
+		}
+	}
+	return sample
+}
+
+// firstByteReader records when the first bytes of a stream are read.
+type firstByteReader struct {
+	io.Reader
+	firstByte time.Time
+}
+
+func (r *firstByteReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 && r.firstByte.IsZero() {
+		r.firstByte = time.Now()
+	}
+	return n, err
+}
+
+// summarizeBenchmark computes the result of a benchmark from its samples.
+func summarizeBenchmark(samples []benchmarkSample, elapsed time.Duration, stream bool) *BenchmarkResult {
+	result := &BenchmarkResult{
+		Requests:    len(samples),
+		StatusCodes: make(map[int]int),
+		DurationMs:  float64(elapsed.Microseconds()) / 1000,
+	}
+	if elapsed > 0 {
+		result.Throughput = float64(len(samples)) / elapsed.Seconds()
+	}
+	latencies := make([]time.Duration, 0, len(samples))
+	firstBytes := make([]time.Duration, 0, len(samples))
+	for _, sample := range samples {
+		if sample.statusCode != 0 {
+			result.StatusCodes[sample.statusCode]++
+		}
+		if sample.err != "" {
+			result.Failed++
+			if len(result.Errors) < maxBenchmarkErrors && !slices.Contains(result.Errors, sample.err) {
+				result.Errors = append(result.Errors, sample.err)
+			}
+			continue
+		}
+		result.Succeeded++
+		latencies = append(latencies, sample.latency)
+		if sample.firstByte > 0 {
+			firstBytes = append(firstBytes, sample.firstByte)
+		}
+	}
+	result.Latency = latencyDistribution(latencies)
+	if stream {
+		distribution := latencyDistribution(firstBytes)
+		result.TimeToFirstByte = &distribution
+	}
+	return result
+}
+
+// latencyDistribution returns the distribution of durations in milliseconds, using nearest-rank percentiles.
+func latencyDistribution(durations []time.Duration) BenchmarkLatency {
+	if len(durations) == 0 {
+		return BenchmarkLatency{}
+	}
+	slices.Sort(durations)
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p*float64(len(durations)))) - 1
+		return milliseconds(durations[max(rank, 0)])
+	}
+	return BenchmarkLatency{
+		Min:  milliseconds(durations[0]),
+		Mean: milliseconds(total / time.Duration(len(durations))),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P95:  percentile(0.95),
+		P99:  percentile(0.99),
+		Max:  milliseconds(durations[len(durations)-1]),
+	}
+}
+
+// milliseconds converts d to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
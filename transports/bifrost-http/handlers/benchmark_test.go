@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestBenchmarkHandler_Run tests that benchmarks send the requested traffic through the pipeline with the
+// configured headers and report status codes, failures and latencies
+func TestBenchmarkHandler_Run(t *testing.T) {
+	config := &lib.Config{Providers: map[schemas.ModelProvider]configstore.ProviderConfig{schemas.Mock: {}}}
+	pipeline := func(ctx *fasthttp.RequestCtx) {
+		var body struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+		if err := json.Unmarshal(ctx.PostBody(), &body); err != nil || body.Model != "mock/benchmark" || string(ctx.Path()) != "/v1/chat/completions" {
+			ctx.SetStatusCode(fasthttp.StatusBadRequest)
+			return
+		}
+		if string(ctx.Request.Header.Peek("x-bf-vk")) != "vk-1" {
+			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+			ctx.SetBodyString(`{"error":{"message":"virtual key required"}}`)
+			return
+		}
+		if body.Stream {
+			ctx.SetContentType("text/event-stream")
+			ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+				w.WriteString("data: {}\n\n")
+				w.Flush()
+				w.WriteString("data: [DONE]\n\n")
+			})
+			return
+		}
+		ctx.SetBodyString(`{"choices":[]}`)
+	}
+	handler := NewBenchmarkHandler(config, pipeline, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	run := func(body string) (int, BenchmarkResult) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBodyString(body)
+		handler.run(ctx)
+		var result BenchmarkResult
+		if ctx.Response.StatusCode() == fasthttp.StatusOK {
+			if err := json.Unmarshal(ctx.Response.Body(), &result); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+		}
+		return ctx.Response.StatusCode(), result
+	}
+
+	status, result := run(`{"requests":20,"concurrency":4,"headers":{"x-bf-vk":"vk-1"}}`)
+	if status != fasthttp.StatusOK || result.Requests != 20 || result.Succeeded != 20 || result.StatusCodes[200] != 20 {
+		t.Fatalf("expected every request to succeed, got %d %+v", status, result)
+	}
+	if result.Latency.Max < result.Latency.P50 || result.Throughput <= 0 || result.TimeToFirstByte != nil {
+		t.Errorf("unexpected latencies %+v", result)
+	}
+
+	status, result = run(`{"requests":10,"stream":true,"headers":{"x-bf-vk":"vk-1"}}`)
+	if status != fasthttp.StatusOK || result.Succeeded != 10 || result.TimeToFirstByte == nil || result.TimeToFirstByte.Max > result.Latency.Max {
+		t.Fatalf("expected streamed requests to report the time to first byte, got %d %+v", status, result)
+	}
+
+	status, result = run(`{"requests":5}`)
+	if status != fasthttp.StatusOK || result.Failed != 5 || result.StatusCodes[401] != 5 || len(result.Errors) != 1 || result.Errors[0] != "virtual key required" {
+		t.Errorf("expected failures to be reported once, got %d %+v", status, result)
+	}
+
+	if status, _ := run(`{"endpoint":"embeddings","stream":true}`); status != fasthttp.StatusBadRequest {
+		t.Errorf("expected streamed embeddings to be rejected, got %d", status)
+	}
+	if status, _ := run(`{"requests":1000000}`); status != fasthttp.StatusBadRequest {
+		t.Errorf("expected too many requests to be rejected, got %d", status)
+	}
+
+	handler.config = &lib.Config{}
+	ctx := &fasthttp.RequestCtx{}
+	handler.run(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest || !strings.Contains(string(ctx.Response.Body()), "mock") {
+		t.Errorf("expected benchmarks to require the mock provider, got %d %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+}
@@ -0,0 +1,427 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const billingPluginName = "bifrost-billing"
+
+// billingDateLayout is the layout of date-only report bounds
+const billingDateLayout = "2006-01-02"
+
+// BillingHandler serves the usage and cost of requests aggregated per end customer.
+type BillingHandler struct {
+	store    logstore.LogStore
+	metering *lib.StripeMeteringExporter // nil when Stripe metering is off
+	logger   schemas.Logger
+}
+
+// BillingTotals is the usage and cost of all the lines of a billing report.
+type BillingTotals struct {
+	Requests         int64   `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// BillingReportResponse is the JSON body of GET /api/billing/customers.
+type BillingReportResponse struct {
+	StartTime time.Time              `json:"start_time"`
+	EndTime   time.Time              `json:"end_time"` // Exclusive
+	GroupBy   string                 `json:"group_by"` // "customer", or "model" and/or "labels" comma-separated
+	Lines     []logstore.BillingLine `json:"lines"`
+	Totals    BillingTotals          `json:"totals"`
+}
+
+// PromptCacheTotals is the prompt caching of all the lines of a prompt cache report.
+type PromptCacheTotals struct {
+	Requests            int64   `json:"requests"`
+	CacheHitRequests    int64   `json:"cache_hit_requests"`
+	PromptTokens        int64   `json:"prompt_tokens"`
+	CachedTokens        int64   `json:"cached_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	CacheSavings        float64 `json:"cache_savings"`
+	TokenHitRate        float64 `json:"token_hit_rate"`
+}
+
+// PromptCacheReportResponse is the JSON body of GET /api/billing/prompt-cache.
+type PromptCacheReportResponse struct {
+	StartTime time.Time                  `json:"start_time"`
+	EndTime   time.Time                  `json:"end_time"` // Exclusive
+	GroupBy   []string                   `json:"group_by"` // "provider", then "key" and/or "model"
+	Lines     []logstore.PromptCacheLine `json:"lines"`
+	Totals    PromptCacheTotals          `json:"totals"`
+}
+
+// NewBillingHandler creates a new billing handler.
+func NewBillingHandler(store logstore.LogStore, metering *lib.StripeMeteringExporter, logger schemas.Logger) *BillingHandler {
+	return &BillingHandler{
+		store:    store,
+		metering: metering,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the billing routes.
+func (h *BillingHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/billing/customers", lib.ChainMiddlewares(h.getCustomerReport, middlewares...))
+	r.GET("/api/billing/prompt-cache", lib.ChainMiddlewares(h.getPromptCacheReport, middlewares...))
+	if h.metering != nil {
+		r.GET("/api/billing/stripe/exports", lib.ChainMiddlewares(h.getStripeExports, middlewares...))
+		r.POST("/api/billing/stripe/export", lib.ChainMiddlewares(h.exportToStripe, middlewares...))
+		r.GET("/api/billing/stripe/reconciliation", lib.ChainMiddlewares(h.getStripeReconciliation, middlewares...))
+	}
+}
+
+// getCustomerReport handles GET /api/billing/customers - Usage and cost per customer over a date range,
+// as JSON or as a CSV download (format=csv)
+func (h *BillingHandler) getCustomerReport(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	start, end, err := parseBillingRange(string(args.Peek("start")), string(args.Peek("end")), time.Now().UTC())
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	filters := logstore.BillingFilters{
+		Customers: parseCommaSeparated(string(args.Peek("customers"))),
+		StartTime: &start,
+		EndTime:   &end,
+	}
+	for _, group := range parseCommaSeparated(string(args.Peek("group_by"))) {
+		switch group {
+		case "customer":
+		case "model":
+			filters.ByModel = true
+		case "labels":
+			filters.ByLabels = true
+		default:
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("invalid group_by %q, expected customer, or model and/or labels", group), h.logger)
+			return
+		}
+	}
+	groupBy := "customer"
+	switch {
+	case filters.ByModel && filters.ByLabels:
+		groupBy = "model,labels"
+	case filters.ByModel:
+		groupBy = "model"
+	case filters.ByLabels:
+		groupBy = "labels"
+	}
+	format := string(args.Peek("format"))
+	if format != "" && format != "json" && format != "csv" {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("invalid format %q, expected json or csv", format), h.logger)
+		return
+	}
+
+	lines, err := h.store.BillingReport(ctx, filters)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to build billing report: %v", err), h.logger)
+		return
+	}
+
+	if format == "csv" {
+		body, err := writeBillingCSV(lines, filters.ByModel, filters.ByLabels)
+		if err != nil {
+			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to write billing report: %v", err), h.logger)
+			return
+		}
+		ctx.SetContentType("text/csv; charset=utf-8")
+		ctx.Response.Header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="billing-%s-%s.csv"`,
+			start.Format(billingDateLayout), end.Add(-time.Nanosecond).Format(billingDateLayout)))
+		ctx.SetBody(body)
+		return
+	}
+
+	response := BillingReportResponse{StartTime: start, EndTime: end, GroupBy: groupBy, Lines: lines}
+	for _, line := range lines {
+		response.Totals.Requests += line.Requests
+		response.Totals.PromptTokens += line.PromptTokens
+		response.Totals.CompletionTokens += line.CompletionTokens
+		response.Totals.TotalTokens += line.TotalTokens
+		response.Totals.Cost += line.Cost
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// getPromptCacheReport handles GET /api/billing/prompt-cache - Cache hit rate and savings of the providers' prompt
+// caches over a date range, per provider and optionally per key and model (group_by=key,model)
+func (h *BillingHandler) getPromptCacheReport(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	start, end, err := parseBillingRange(string(args.Peek("start")), string(args.Peek("end")), time.Now().UTC())
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	filters := logstore.PromptCacheFilters{
+		Providers: parseCommaSeparated(string(args.Peek("providers"))),
+		StartTime: &start,
+		EndTime:   &end,
+	}
+	groupBy := []string{"provider"}
+	for _, group := range parseCommaSeparated(string(args.Peek("group_by"))) {
+		switch group {
+		case "provider":
+		case "key":
+			filters.ByKey = true
+		case "model":
+			filters.ByModel = true
+		default:
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("invalid group_by %q, expected key and/or model", group), h.logger)
+			return
+		}
+	}
+	if filters.ByKey {
+		groupBy = append(groupBy, "key")
+	}
+	if filters.ByModel {
+		groupBy = append(groupBy, "model")
+	}
+
+	lines, err := h.store.PromptCacheReport(ctx, filters)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to build prompt cache report: %v", err), h.logger)
+		return
+	}
+	response := PromptCacheReportResponse{StartTime: start, EndTime: end, GroupBy: groupBy, Lines: lines}
+	for _, line := range lines {
+		response.Totals.Requests += line.Requests
+		response.Totals.CacheHitRequests += line.CacheHitRequests
+		response.Totals.PromptTokens += line.PromptTokens
+		response.Totals.CachedTokens += line.CachedTokens
+		response.Totals.CacheCreationTokens += line.CacheCreationTokens
+		response.Totals.CacheSavings += line.CacheSavings
+	}
+	if response.Totals.PromptTokens > 0 {
+		response.Totals.TokenHitRate = float64(response.Totals.CachedTokens) / float64(response.Totals.PromptTokens)
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// StripeExportsResponse is the JSON body of the Stripe export endpoints.
+type StripeExportsResponse struct {
+	Exports []lib.StripeExportRecord `json:"exports"`
+	Count   int                      `json:"count"`
+	DryRun  bool                     `json:"dry_run"`
+}
+
+// StripeReconciliationResponse is the JSON body of GET /api/billing/stripe/reconciliation.
+type StripeReconciliationResponse struct {
+	StartTime time.Time                      `json:"start_time"`
+	EndTime   time.Time                      `json:"end_time"` // Exclusive
+	Lines     []lib.StripeReconciliationLine `json:"lines"`
+}
+
+// getStripeExports handles GET /api/billing/stripe/exports - Most recent meter events exported to Stripe
+func (h *BillingHandler) getStripeExports(ctx *fasthttp.RequestCtx) {
+	limit := 100
+	if limitStr := string(ctx.QueryArgs().Peek("limit")); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 {
+			SendError(ctx, fasthttp.StatusBadRequest, "limit must be a positive integer", h.logger)
+			return
+		}
+		limit = n
+	}
+	records := h.metering.Records(limit)
+	SendJSON(ctx, StripeExportsResponse{Exports: records, Count: len(records), DryRun: h.metering.DryRun()}, h.logger)
+}
+
+// exportToStripe handles POST /api/billing/stripe/export - Exports the usage of a range right away, e.g. to
+// backfill a window that failed for longer than the scheduler retries it. dry_run=true only computes the events.
+func (h *BillingHandler) exportToStripe(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	if len(args.Peek("start")) == 0 || len(args.Peek("end")) == 0 {
+		SendError(ctx, fasthttp.StatusBadRequest, "start and end are required", h.logger)
+		return
+	}
+	start, end, err := parseBillingRange(string(args.Peek("start")), string(args.Peek("end")), time.Now().UTC())
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	dryRun := string(args.Peek("dry_run")) == "true"
+	records, err := h.metering.Export(ctx, start, end, dryRun)
+	if records == nil && err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to export usage: %v", err), h.logger)
+		return
+	}
+	if err != nil {
+		// Some events failed: report every outcome so the caller can retry
+		ctx.SetStatusCode(fasthttp.StatusBadGateway)
+	}
+	SendJSON(ctx, StripeExportsResponse{Exports: records, Count: len(records), DryRun: dryRun || h.metering.DryRun()}, h.logger)
+}
+
+// getStripeReconciliation handles GET /api/billing/stripe/reconciliation - Usage in the logs vs usage exported
+// to Stripe per customer and meter
+func (h *BillingHandler) getStripeReconciliation(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	start, end, err := parseBillingRange(string(args.Peek("start")), string(args.Peek("end")), time.Now().UTC())
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	lines, err := h.metering.Reconcile(ctx, start, end)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to reconcile usage: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, StripeReconciliationResponse{StartTime: start, EndTime: end, Lines: lines}, h.logger)
+}
+
+// parseBillingRange parses the bounds of a billing report. Bounds are RFC 3339 timestamps or dates; a date end
+// includes the whole day. The range defaults to the current calendar month (UTC) up to now.
+func parseBillingRange(startParam, endParam string, now time.Time) (time.Time, time.Time, error) {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := now
+	if startParam != "" {
+		t, _, err := parseBillingTime(startParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start: %v", err)
+		}
+		start = t
+	}
+	if endParam != "" {
+		t, dateOnly, err := parseBillingTime(endParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end: %v", err)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		end = t
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end must be after start")
+	}
+	return start, end, nil
+}
+
+// parseBillingTime parses an RFC 3339 timestamp or a YYYY-MM-DD date (UTC midnight)
+func parseBillingTime(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(billingDateLayout, value); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected YYYY-MM-DD or RFC 3339, got %q", value)
+	}
+	return t.UTC(), false, nil
+}
+
+// writeBillingCSV renders billing lines as CSV with a header row. Labels are written in the name=value format of
+// the X-Bifrost-Labels header.
+func writeBillingCSV(lines []logstore.BillingLine, byModel bool, byLabels bool) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	header := []string{"customer"}
+	if byModel {
+		header = append(header, "provider", "model")
+	}
+	if byLabels {
+		header = append(header, "labels")
+	}
+	header = append(header, "requests", "prompt_tokens", "completion_tokens", "total_tokens", "cost")
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		record := []string{line.Customer}
+		if byModel {
+			record = append(record, line.Provider, line.Model)
+		}
+		if byLabels {
+			pairs := make([]string, 0, len(line.Labels))
+			for name, value := range line.Labels {
+				pairs = append(pairs, name+"="+value)
+			}
+			slices.Sort(pairs)
+			record = append(record, strings.Join(pairs, ","))
+		}
+		record = append(record,
+			strconv.FormatInt(line.Requests, 10),
+			strconv.FormatInt(line.PromptTokens, 10),
+			strconv.FormatInt(line.CompletionTokens, 10),
+			strconv.FormatInt(line.TotalTokens, 10),
+			strconv.FormatFloat(line.Cost, 'f', 6, 64),
+		)
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// billingPlugin attributes requests without an x-bifrost-customer header to the authenticated customer
+// (x-bf-customer) or to the customer of their virtual key. It runs before the logging plugin, which stores
+// the customer with the request.
+type billingPlugin struct {
+	// governanceStore resolves the customer of a virtual key (nil when governance is off)
+	governanceStore *governance.GovernanceStore
+}
+
+// GetName returns the name of the plugin
+func (p *billingPlugin) GetName() string {
+	return billingPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *billingPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook sets the billing customer of the request when the caller did not
+func (p *billingPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if customer, ok := (*ctx).Value(schemas.BifrostContextKeyBillingCustomer).(string); ok && customer != "" {
+		return req, nil, nil
+	}
+	if customer := authenticatedCustomer(*ctx, p.governanceStore); customer != "" {
+		*ctx = context.WithValue(*ctx, schemas.BifrostContextKeyBillingCustomer, customer)
+	}
+	return req, nil, nil
+}
+
+// authenticatedCustomer returns the authenticated customer of a request (x-bf-customer), or the customer of its
+// virtual key when governanceStore is set
+func authenticatedCustomer(ctx context.Context, governanceStore *governance.GovernanceStore) string {
+	if customer, ok := ctx.Value(governance.ContextKey("x-bf-customer")).(string); ok && customer != "" {
+		return customer
+	}
+	vkValue, _ := ctx.Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	if vkValue == "" || governanceStore == nil {
+		return ""
+	}
+	vk, ok := governanceStore.GetVirtualKey(vkValue)
+	if !ok {
+		return ""
+	}
+	customerID, _ := governanceStore.CustomerIDOfVirtualKey(vk)
+	return customerID
+}
+
+// PostHook is not used for this plugin
+func (p *billingPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *billingPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/valyala/fasthttp"
+)
+
+// billingRequestCtx returns a request context for a GET of uri, usable as a context.Context
+func billingRequestCtx(uri string) *fasthttp.RequestCtx {
+	var req fasthttp.Request
+	req.SetRequestURI(uri)
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Init(&req, nil, nil)
+	return ctx
+}
+
+// TestParseBillingRange tests date and timestamp bounds, the inclusive date end and the month-to-date default
+func TestParseBillingRange(t *testing.T) {
+	now := time.Date(2025, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	start, end, err := parseBillingRange("", "", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !start.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(now) {
+		t.Errorf("expected month to date, got %v - %v", start, end)
+	}
+
+	start, end, err = parseBillingRange("2025-02-01", "2025-02-28", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !start.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the whole of February, got %v - %v", start, end)
+	}
+
+	_, end, err = parseBillingRange("2025-02-01", "2025-02-10T12:00:00+02:00", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !end.Equal(time.Date(2025, 2, 10, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected timestamp end in UTC, got %v", end)
+	}
+
+	for _, bounds := range [][2]string{{"yesterday", ""}, {"", "2025-13-01"}, {"2025-02-10", "2025-02-01"}} {
+		if _, _, err := parseBillingRange(bounds[0], bounds[1], now); err == nil {
+			t.Errorf("expected error for %v", bounds)
+		}
+	}
+}
+
+// newBillingTestStore returns a SQLite logs store with the requests of two customers on 2025-02-03
+func newBillingTestStore(t *testing.T) logstore.LogStore {
+	t.Helper()
+	store, err := logstore.NewLogStore(context.Background(), &logstore.Config{
+		Type:   logstore.LogStoreTypeSQLite,
+		Config: &logstore.SQLiteConfig{Path: filepath.Join(t.TempDir(), "logs.db")},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create log store: %v", err)
+	}
+	day := time.Date(2025, 2, 3, 12, 0, 0, 0, time.UTC)
+	for _, entry := range []*logstore.Log{
+		{ID: "1", Customer: "acme, inc", Provider: "openai", Model: "gpt-4o", Status: "success", TotalTokens: 30, Cost: bifrost.Ptr(0.5), LabelsParsed: map[string]string{"feature": "chat", "env": "prod"}},
+		{ID: "2", Customer: "acme, inc", Provider: "openai", Model: "gpt-4o", Status: "success", TotalTokens: 10, Cost: bifrost.Ptr(0.25), LabelsParsed: map[string]string{"feature": "search"}},
+		{ID: "3", Customer: "globex", Provider: "anthropic", Model: "claude", Status: "success", TotalTokens: 5, Cost: bifrost.Ptr(1.0)},
+	} {
+		entry.Timestamp, entry.CreatedAt = day, day
+		if err := store.Create(context.Background(), entry); err != nil {
+			t.Fatalf("failed to create log: %v", err)
+		}
+	}
+	return store
+}
+
+// TestBillingHandler_Report tests the JSON and CSV reports over logs attributed to customers
+func TestBillingHandler_Report(t *testing.T) {
+	store := newBillingTestStore(t)
+	handler := NewBillingHandler(store, nil, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	ctx := billingRequestCtx("/api/billing/customers?start=2025-02-01&end=2025-02-28")
+	handler.getCustomerReport(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var report BillingReportResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if len(report.Lines) != 2 || report.Lines[0].Customer != "acme, inc" || report.Lines[0].Requests != 2 || report.Lines[0].Cost != 0.75 {
+		t.Errorf("unexpected lines: %+v", report.Lines)
+	}
+	if report.Totals.Requests != 3 || report.Totals.TotalTokens != 45 || report.Totals.Cost != 1.75 {
+		t.Errorf("unexpected totals: %+v", report.Totals)
+	}
+
+	ctx = billingRequestCtx("/api/billing/customers?start=2025-02-01&end=2025-02-28&group_by=model&customers=globex&format=csv")
+	handler.getCustomerReport(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	if disposition := string(ctx.Response.Header.Peek("Content-Disposition")); disposition != `attachment; filename="billing-2025-02-01-2025-02-28.csv"` {
+		t.Errorf("unexpected content disposition %q", disposition)
+	}
+	expected := "customer,provider,model,requests,prompt_tokens,completion_tokens,total_tokens,cost\nglobex,anthropic,claude,1,0,0,5,1.000000\n"
+	if body := string(ctx.Response.Body()); body != expected {
+		t.Errorf("expected CSV %q, got %q", expected, body)
+	}
+
+	ctx = billingRequestCtx("/api/billing/customers?start=2025-02-01&end=2025-02-28&group_by=labels&format=csv")
+	handler.getCustomerReport(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	expected = "customer,labels,requests,prompt_tokens,completion_tokens,total_tokens,cost\n" +
+		"\"acme, inc\",\"env=prod,feature=chat\",1,0,0,30,0.500000\n\"acme, inc\",feature=search,1,0,0,10,0.250000\nglobex,,1,0,0,5,1.000000\n"
+	if body := string(ctx.Response.Body()); body != expected {
+		t.Errorf("expected CSV %q, got %q", expected, body)
+	}
+
+	ctx = billingRequestCtx("/api/billing/customers?group_by=team")
+	handler.getCustomerReport(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid group_by, got %d", ctx.Response.StatusCode())
+	}
+}
+
+// TestWriteBillingCSV_Escapes tests that customer names are quoted when needed
+func TestWriteBillingCSV_Escapes(t *testing.T) {
+	body, err := writeBillingCSV([]logstore.BillingLine{{Customer: `acme, "inc"`, Requests: 1, Cost: 0.1}}, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(body), `"acme, ""inc""",1,0,0,0,0.100000`) {
+		t.Errorf("unexpected CSV %q", body)
+	}
+}
+
+// TestBillingPlugin_Attribution tests that the caller's customer header wins over the authenticated customer
+func TestBillingPlugin_Attribution(t *testing.T) {
+	plugin := &billingPlugin{}
+
+	ctx := context.WithValue(context.Background(), governance.ContextKey("x-bf-customer"), "cust-1")
+	if _, _, err := plugin.PreHook(&ctx, &schemas.BifrostRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if customer, _ := ctx.Value(schemas.BifrostContextKeyBillingCustomer).(string); customer != "cust-1" {
+		t.Errorf("expected the authenticated customer, got %q", customer)
+	}
+
+	ctx = context.WithValue(context.Background(), governance.ContextKey("x-bf-customer"), "cust-1")
+	ctx = context.WithValue(ctx, schemas.BifrostContextKeyBillingCustomer, "end-customer")
+	if _, _, err := plugin.PreHook(&ctx, &schemas.BifrostRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if customer, _ := ctx.Value(schemas.BifrostContextKeyBillingCustomer).(string); customer != "end-customer" {
+		t.Errorf("expected the x-bifrost-customer header to win, got %q", customer)
+	}
+
+	ctx = context.Background()
+	if _, _, err := plugin.PreHook(&ctx, &schemas.BifrostRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ctx.Value(schemas.BifrostContextKeyBillingCustomer) != nil {
+		t.Error("expected unattributed requests to stay unattributed")
+	}
+}
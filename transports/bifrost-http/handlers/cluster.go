@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// ClusterHandler exposes the leader election state of this replica.
+type ClusterHandler struct {
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// NewClusterHandler creates a new cluster handler instance.
+func NewClusterHandler(config *lib.Config, logger schemas.Logger) *ClusterHandler {
+	return &ClusterHandler{
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the cluster-related routes.
+func (h *ClusterHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/cluster/status", lib.ChainMiddlewares(h.getStatus, middlewares...))
+}
+
+// getStatus handles GET /api/cluster/status - Get the leader election status
+func (h *ClusterHandler) getStatus(ctx *fasthttp.RequestCtx) {
+	if h.config.LeaderElector == nil {
+		SendError(ctx, fasthttp.StatusServiceUnavailable, "Leader election is not initialized", h.logger)
+		return
+	}
+	status, err := h.config.LeaderElector.Status(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get cluster status: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, status, h.logger)
+}
@@ -43,6 +43,7 @@ func NewConfigHandler(client *bifrost.Bifrost, logger schemas.Logger, store *lib
 func (h *ConfigHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
 	r.GET("/api/config", lib.ChainMiddlewares(h.getConfig, middlewares...))
 	r.PUT("/api/config", lib.ChainMiddlewares(h.updateConfig, middlewares...))
+	r.POST("/api/config/validate", lib.ChainMiddlewares(h.validateConfig, middlewares...))
 	r.GET("/api/version", lib.ChainMiddlewares(h.getVersion, middlewares...))
 }
 
@@ -126,13 +127,13 @@ func (h *ConfigHandler) updateConfig(ctx *fasthttp.RequestCtx) {
 	h.store.ClientConfig = updatedConfig
 
 	if err := h.store.ConfigStore.UpdateClientConfig(ctx, &updatedConfig); err != nil {
-		h.logger.Warn(fmt.Sprintf("failed to save configuration: %v", err))
+		h.logger.Warn("failed to save configuration: %v", err)
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("failed to save configuration: %v", err), h.logger)
 		return
 	}
 
 	if err := h.configManager.ReloadClientConfigFromConfigStore(); err != nil {
-		h.logger.Warn(fmt.Sprintf("failed to reload client config from config store: %v", err))
+		h.logger.Warn("failed to reload client config from config store: %v", err)
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("failed to reload client config from config store: %v", err), h.logger)
 		return
 	}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/eventstream"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/plugins/logging"
+	"github.com/maximhq/bifrost/plugins/maxim"
+	"github.com/maximhq/bifrost/plugins/otel"
+	"github.com/maximhq/bifrost/plugins/semanticcache"
+	"github.com/maximhq/bifrost/plugins/telemetry"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// validateConfig handles POST /api/config/validate - Validate a candidate config.json document without applying it
+// The report is returned with 200 when the config is valid and 422 otherwise. connectivity=false skips the
+// provider connectivity dry-runs and timeout (e.g. 2s) bounds each of them.
+func (h *ConfigHandler) validateConfig(ctx *fasthttp.RequestCtx) {
+	opts := lib.ConfigValidationOptions{
+		Connectivity:   string(ctx.QueryArgs().Peek("connectivity")) != "false",
+		ValidatePlugin: dryRunPlugin,
+	}
+	if timeout := string(ctx.QueryArgs().Peek("timeout")); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("invalid timeout %q", timeout), h.logger)
+			return
+		}
+		opts.Timeout = d
+	}
+	// The dry-runs are bounded by their timeout rather than by the request context
+	report := lib.ValidateConfigDocument(context.Background(), ctx.PostBody(), opts)
+	if !report.Valid {
+		ctx.SetStatusCode(fasthttp.StatusUnprocessableEntity)
+	}
+	SendJSON(ctx, report, h.logger)
+}
+
+// dryRunPlugin checks a plugin config the way LoadPlugin initializes it, without connecting the plugin to its
+// backend.
+func dryRunPlugin(_ context.Context, plugin *schemas.PluginConfig, candidate *lib.ConfigData) error {
+	switch plugin.Name {
+	case telemetry.PluginName, logging.PluginName, governance.PluginName:
+		// Built-in plugins configured through the client config
+		return nil
+	case maxim.PluginName:
+		config, err := MarshalPluginConfig[maxim.Config](plugin.Config)
+		if err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		if config.APIKey == "" {
+			return fmt.Errorf("api_key is required")
+		}
+	case semanticcache.PluginName:
+		config, err := MarshalPluginConfig[semanticcache.Config](plugin.Config)
+		if err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		if candidate.VectorStoreConfig == nil || !candidate.VectorStoreConfig.Enabled {
+			return fmt.Errorf("an enabled vector_store is required")
+		}
+		if config.Dimension <= 0 {
+			return fmt.Errorf("dimension must be positive")
+		}
+	case otel.PluginName:
+		config, err := (&otel.OtelPlugin{}).ValidateConfig(plugin.Config)
+		if err != nil {
+			return err
+		}
+		if config.Protocol != otel.ProtocolHTTP && config.Protocol != otel.ProtocolGRPC {
+			return fmt.Errorf("protocol must be %q or %q", otel.ProtocolHTTP, otel.ProtocolGRPC)
+		}
+	case eventstream.PluginName:
+		config, err := MarshalPluginConfig[eventstream.Config](plugin.Config)
+		if err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		switch config.Broker {
+		case eventstream.BrokerTypeKafka:
+			if config.Kafka == nil || len(config.Kafka.Brokers) == 0 {
+				return fmt.Errorf("kafka.brokers is required")
+			}
+		case eventstream.BrokerTypeNATS:
+			if config.NATS == nil || config.NATS.URL == "" {
+				return fmt.Errorf("nats.url is required")
+			}
+		default:
+			return fmt.Errorf("unsupported broker: %q", config.Broker)
+		}
+	default:
+		return fmt.Errorf("plugin %s not found", plugin.Name)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// validateConfigDocument posts a candidate config to POST /api/config/validate and returns the status and report.
+func validateConfigDocument(t *testing.T, query, body string) (int, lib.ConfigValidationReport) {
+	t.Helper()
+	h := NewConfigHandler(nil, bifrost.NewDefaultLogger(schemas.LogLevelError), &lib.Config{}, nil)
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/api/config/validate?" + query)
+	ctx.Request.SetBodyString(body)
+	h.validateConfig(ctx)
+	var report lib.ConfigValidationReport
+	if err := json.Unmarshal(ctx.Response.Body(), &report); err != nil {
+		t.Fatalf("invalid report: %s", ctx.Response.Body())
+	}
+	return ctx.Response.StatusCode(), report
+}
+
+// findCheck returns the check of a stage and path.
+func findCheck(report lib.ConfigValidationReport, stage, path string) *lib.ConfigValidationCheck {
+	for i, check := range report.Checks {
+		if check.Stage == stage && check.Path == path {
+			return &report.Checks[i]
+		}
+	}
+	return nil
+}
+
+// TestValidateConfig tests that every stage reports the problems of a candidate config with their location
+func TestValidateConfig(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	body := fmt.Sprintf(`{
+		"$schema": "https://www.getbifrost.ai/schema",
+		"providers": {
+			"openai": {"keys": [{"value": "env.BIFROST_TEST_MISSING_KEY", "models": [], "weight": 1}], "network_config": {"base_url": "http://%[1]s"}},
+			"anthropic": {"keys": [], "network_config": {"base_url": "http://%[1]s"}},
+			"reachable": {"keys": [{"value": "sk-1", "models": [], "weight": 1}], "network_config": {"base_url": "http://%[1]s"}, "custom_provider_config": {"base_provider_type": "openai"}},
+			"unreachable": {"keys": [{"value": "sk-1", "models": [], "weight": 1}], "network_config": {"base_url": "http://%[2]s"}, "custom_provider_config": {"base_provider_type": "openai"}},
+			"unknown": {"keys": []}
+		},
+		"routing_overrides": {"enabled": true, "policies": [{"virtual_keys": ["sk-bf-app"], "allow_provider": true}]},
+		"public_routes": [],
+		"plugins": [
+			{"name": "maxim", "enabled": true, "config": {"log_repo_id": "repo"}},
+			{"name": "otel", "enabled": true, "config": {"collector_url": "localhost:4317", "trace_type": "genai_extension", "protocol": "grpc"}},
+			{"name": "custom", "enabled": true},
+			{"name": "semantic_cache", "enabled": false}
+		],
+		"observability": {}
+	}`, listener.Addr().String(), closedAddr)
+
+	status, report := validateConfigDocument(t, "timeout=2s", body)
+	if status != fasthttp.StatusUnprocessableEntity || report.Valid {
+		t.Fatalf("status = %d, valid = %v, want 422 and invalid", status, report.Valid)
+	}
+
+	tests := []struct {
+		stage  string
+		path   string
+		status string
+	}{
+		{lib.ConfigStageSchema, "observability", lib.ConfigCheckWarning},
+		{lib.ConfigStageEnvironment, "providers.openai.keys[0].value", lib.ConfigCheckError},
+		{lib.ConfigStageSections, "routing_overrides", lib.ConfigCheckError},
+		{lib.ConfigStageSections, "public_routes", lib.ConfigCheckOK},
+		{lib.ConfigStageProviders, "providers.anthropic", lib.ConfigCheckWarning},
+		{lib.ConfigStageProviders, "providers.reachable", lib.ConfigCheckOK},
+		{lib.ConfigStageProviders, "providers.unknown", lib.ConfigCheckError},
+		{lib.ConfigStageConnectivity, "providers.reachable", lib.ConfigCheckOK},
+		{lib.ConfigStageConnectivity, "providers.unreachable", lib.ConfigCheckError},
+		{lib.ConfigStagePlugins, "plugins[0]", lib.ConfigCheckError},
+		{lib.ConfigStagePlugins, "plugins[1]", lib.ConfigCheckOK},
+		{lib.ConfigStagePlugins, "plugins[2]", lib.ConfigCheckError},
+		{lib.ConfigStagePlugins, "plugins[3]", lib.ConfigCheckSkipped},
+	}
+	for _, tt := range tests {
+		check := findCheck(report, tt.stage, tt.path)
+		if check == nil {
+			t.Errorf("%s %s: missing check", tt.stage, tt.path)
+			continue
+		}
+		if check.Status != tt.status {
+			t.Errorf("%s %s: status = %s (%s), want %s", tt.stage, tt.path, check.Status, check.Message, tt.status)
+		}
+	}
+	if report.Errors != 6 || report.Warnings != 2 {
+		t.Errorf("errors = %d, warnings = %d, want 6 and 2", report.Errors, report.Warnings)
+	}
+}
+
+// TestValidateConfig_Valid tests that a valid config passes, and that connectivity dry-runs can be skipped
+func TestValidateConfig_Valid(t *testing.T) {
+	t.Setenv("BIFROST_TEST_OPENAI_KEY", "sk-test")
+	status, report := validateConfigDocument(t, "connectivity=false", `{
+		"providers": {"openai": {"keys": [{"value": "env.BIFROST_TEST_OPENAI_KEY", "models": [], "weight": 1}]}},
+		"tenant_domains": [{"host": "ai.acme.com", "name": "Acme AI"}]
+	}`)
+	if status != fasthttp.StatusOK || !report.Valid || report.Errors != 0 || report.Warnings != 0 {
+		t.Fatalf("status = %d, report = %+v, want a valid config", status, report)
+	}
+	for _, check := range report.Checks {
+		if check.Stage == lib.ConfigStageConnectivity {
+			t.Errorf("unexpected connectivity check %+v", check)
+		}
+	}
+}
+
+// TestValidateConfig_Schema tests that malformed documents fail the schema stage only
+func TestValidateConfig_Schema(t *testing.T) {
+	for _, body := range []string{
+		`{"providers": `,
+		`[]`,
+		`{"providers": {"openai": {"keys": "sk-1"}}}`,
+	} {
+		status, report := validateConfigDocument(t, "", body)
+		if status != fasthttp.StatusUnprocessableEntity || len(report.Checks) != 1 || report.Checks[0].Stage != lib.ConfigStageSchema {
+			t.Errorf("%s: status = %d, report = %+v, want a single schema error", body, status, report)
+		}
+	}
+}
@@ -0,0 +1,485 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// ConfigVersionSummary describes a configuration version and its changes from the preceding version.
+type ConfigVersionSummary struct {
+	ID        int           `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Source    string        `json:"source"`
+	Changes   []ApplyChange `json:"changes"` // Everything is created for the oldest version kept
+}
+
+// ConfigVersionResponse is the response of GET /api/config/versions/{id}, with the provider keys redacted.
+type ConfigVersionResponse struct {
+	ConfigVersionSummary
+	Snapshot lib.ConfigSnapshot `json:"snapshot"`
+}
+
+// ConfigRollbackResponse is the response of POST /api/config/versions/{id}/rollback.
+type ConfigRollbackResponse struct {
+	DryRun  bool          `json:"dry_run"`
+	Changes []ApplyChange `json:"changes"`           // Changes from the current configuration to the version
+	Version int           `json:"version,omitempty"` // The version recorded by the rollback
+}
+
+// ConfigVersionsHandler serves the configuration version history and rolls back to prior versions.
+type ConfigVersionsHandler struct {
+	store         *lib.Config
+	client        *bifrost.Bifrost
+	apply         *ApplyHandler // Converges the providers, and serializes rollbacks with applies
+	pluginsLoader PluginsLoader
+	configManager ConfigManager
+	logger        schemas.Logger
+}
+
+// NewConfigVersionsHandler creates a new config versions handler.
+func NewConfigVersionsHandler(store *lib.Config, client *bifrost.Bifrost, apply *ApplyHandler, pluginsLoader PluginsLoader, configManager ConfigManager, logger schemas.Logger) *ConfigVersionsHandler {
+	return &ConfigVersionsHandler{
+		store:         store,
+		client:        client,
+		apply:         apply,
+		pluginsLoader: pluginsLoader,
+		configManager: configManager,
+		logger:        logger,
+	}
+}
+
+// RegisterRoutes registers the config versions routes.
+func (h *ConfigVersionsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/config/versions", lib.ChainMiddlewares(h.listVersions, middlewares...))
+	r.GET("/api/config/versions/{id}", lib.ChainMiddlewares(h.getVersion, middlewares...))
+	r.POST("/api/config/versions/{id}/rollback", lib.ChainMiddlewares(h.rollback, middlewares...))
+}
+
+// RecordVersion is a middleware recording a configuration version after every successful change made by the
+// routes it wraps. Requests that leave the configuration as it was record nothing.
+func (h *ConfigVersionsHandler) RecordVersion(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		next(ctx)
+		if ctx.IsGet() || ctx.IsHead() || ctx.Response.StatusCode() >= fasthttp.StatusBadRequest {
+			return
+		}
+		h.record(ctx, string(ctx.Method())+" "+string(ctx.Path()))
+	}
+}
+
+// record snapshots the configuration and records it as a new version. Failures are logged since the change
+// itself was applied.
+func (h *ConfigVersionsHandler) record(ctx context.Context, source string) *lib.ConfigVersion {
+	snapshot, err := snapshotConfig(ctx, h.store)
+	if err != nil {
+		h.logger.Warn("failed to snapshot config: %v", err)
+		return nil
+	}
+	version, err := h.store.RecordConfigVersion(ctx, source, snapshot)
+	if err != nil {
+		h.logger.Warn("failed to record config version: %v", err)
+		return nil
+	}
+	return version
+}
+
+// listVersions handles GET /api/config/versions - List the configuration versions with their changes, newest first
+func (h *ConfigVersionsHandler) listVersions(ctx *fasthttp.RequestCtx) {
+	versions := h.store.GetConfigVersions()
+	summaries := make([]ConfigVersionSummary, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		var previous *lib.ConfigVersion
+		if i > 0 {
+			previous = &versions[i-1]
+		}
+		summaries = append(summaries, versionSummary(&versions[i], previous))
+	}
+	SendJSON(ctx, summaries, h.logger)
+}
+
+// getVersion handles GET /api/config/versions/{id} - Get a configuration version with its redacted snapshot
+func (h *ConfigVersionsHandler) getVersion(ctx *fasthttp.RequestCtx) {
+	version, previous, ok := h.lookupVersion(ctx)
+	if !ok {
+		return
+	}
+	SendJSON(ctx, ConfigVersionResponse{
+		ConfigVersionSummary: versionSummary(version, previous),
+		Snapshot:             redactSnapshot(version.Snapshot),
+	}, h.logger)
+}
+
+// rollback handles POST /api/config/versions/{id}/rollback - Restore a configuration version (?dry_run=true returns
+// the changes only). Affected providers and plugins are re-initialized, and the rollback is recorded as a new version.
+func (h *ConfigVersionsHandler) rollback(ctx *fasthttp.RequestCtx) {
+	version, _, ok := h.lookupVersion(ctx)
+	if !ok {
+		return
+	}
+	dryRun := string(ctx.QueryArgs().Peek("dry_run")) == "true"
+
+	h.apply.mu.Lock()
+	defer h.apply.mu.Unlock()
+
+	current, err := snapshotConfig(ctx, h.store)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to snapshot config: %v", err), h.logger)
+		return
+	}
+	response := ConfigRollbackResponse{
+		DryRun:  dryRun,
+		Changes: diffSnapshots(current, version.Snapshot),
+	}
+	if dryRun || len(response.Changes) == 0 {
+		SendJSON(ctx, response, h.logger)
+		return
+	}
+
+	if err := h.restore(ctx, current, version.Snapshot); err != nil {
+		// Changes before the failing one stay applied; rolling back again converges
+		h.logger.Error("failed to roll back to config version %d: %v", version.ID, err)
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to roll back: %v", err), h.logger)
+		return
+	}
+	if recorded := h.record(ctx, fmt.Sprintf("rollback to %d", version.ID)); recorded != nil {
+		response.Version = recorded.ID
+	}
+	h.logger.Info("rolled back to config version %d with %d changes", version.ID, len(response.Changes))
+	SendJSON(ctx, response, h.logger)
+}
+
+// lookupVersion returns the version named by the id path parameter, sending the error response when there is none.
+func (h *ConfigVersionsHandler) lookupVersion(ctx *fasthttp.RequestCtx) (*lib.ConfigVersion, *lib.ConfigVersion, bool) {
+	idValue, _ := ctx.UserValue("id").(string)
+	id, err := strconv.Atoi(idValue)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid version id %q", idValue), h.logger)
+		return nil, nil, false
+	}
+	version, previous, err := h.store.GetConfigVersion(id)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Config version %d not found", id), h.logger)
+		return nil, nil, false
+	}
+	return version, previous, true
+}
+
+// restore converges the configuration to a snapshot: providers first, then the client config, then plugins.
+func (h *ConfigVersionsHandler) restore(ctx context.Context, current lib.ConfigSnapshot, target lib.ConfigSnapshot) error {
+	desired := &DesiredState{
+		Providers: make(map[schemas.ModelProvider]DesiredProvider, len(target.Providers)),
+		Prune:     true,
+	}
+	for name, config := range target.Providers {
+		desired.Providers[name] = desiredProvider(config)
+	}
+	plan, err := h.apply.plan(ctx, desired)
+	if err != nil {
+		return err
+	}
+	if err := h.apply.execute(ctx, plan); err != nil {
+		return err
+	}
+
+	if len(diffClientConfig(current.Client, target.Client)) > 0 {
+		if err := h.restoreClientConfig(ctx, current.Client, target.Client); err != nil {
+			return fmt.Errorf("client config: %w", err)
+		}
+	}
+
+	if h.store.ConfigStore == nil {
+		return nil
+	}
+	return h.restorePlugins(ctx, current.Plugins, target.Plugins)
+}
+
+// restoreClientConfig saves the client config and reloads the Bifrost client with it.
+func (h *ConfigVersionsHandler) restoreClientConfig(ctx context.Context, current configstore.ClientConfig, target configstore.ClientConfig) error {
+	h.store.ClientConfig = target
+	if h.store.ConfigStore == nil {
+		if h.client != nil && current.DropExcessRequests != target.DropExcessRequests {
+			h.client.UpdateDropExcessRequests(target.DropExcessRequests)
+		}
+		return nil
+	}
+	if err := h.store.ConfigStore.UpdateClientConfig(ctx, &target); err != nil {
+		return err
+	}
+	return h.configManager.ReloadClientConfigFromConfigStore()
+}
+
+// restorePlugins saves the plugins of a snapshot and reloads, or stops, the plugins that changed.
+// Plugins failing to load are logged since their config is saved, as when they are updated through the plugins API.
+func (h *ConfigVersionsHandler) restorePlugins(ctx context.Context, current []schemas.PluginConfig, target []schemas.PluginConfig) error {
+	for _, change := range diffPlugins(current, target) {
+		if change.Action == ApplyActionDelete {
+			if err := h.store.ConfigStore.DeletePlugin(ctx, change.Name); err != nil {
+				return fmt.Errorf("plugin %s: %w", change.Name, err)
+			}
+			if err := h.pluginsLoader.RemovePlugin(ctx, change.Name); err != nil {
+				bifrost.WithFields(h.logger, "plugin", change.Name).Warn("failed to stop plugin: %v", err)
+			}
+			continue
+		}
+		i := slices.IndexFunc(target, func(plugin schemas.PluginConfig) bool { return plugin.Name == change.Name })
+		plugin := target[i]
+		if err := h.store.ConfigStore.UpdatePlugin(ctx, &configstore.TablePlugin{
+			Name:    plugin.Name,
+			Enabled: plugin.Enabled,
+			Config:  plugin.Config,
+		}); err != nil {
+			return fmt.Errorf("plugin %s: %w", plugin.Name, err)
+		}
+		if plugin.Enabled {
+			if err := h.pluginsLoader.ReloadPlugin(ctx, plugin.Name, plugin.Config); err != nil {
+				bifrost.WithFields(h.logger, "plugin", plugin.Name).Warn("failed to load plugin: %v", err)
+			}
+		} else if change.Action == ApplyActionUpdate {
+			if err := h.pluginsLoader.RemovePlugin(ctx, plugin.Name); err != nil {
+				bifrost.WithFields(h.logger, "plugin", plugin.Name).Warn("failed to stop plugin: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotConfig captures the current configuration, with the provider keys in their declared form.
+func snapshotConfig(ctx context.Context, store *lib.Config) (lib.ConfigSnapshot, error) {
+	snapshot := lib.ConfigSnapshot{
+		Client:    store.ClientConfig,
+		Providers: make(map[schemas.ModelProvider]configstore.ProviderConfig),
+	}
+	names, err := store.GetAllProviders()
+	if err != nil {
+		return snapshot, err
+	}
+	for _, name := range names {
+		raw, err := store.GetProviderConfigRaw(name)
+		if errors.Is(err, lib.ErrNotFound) {
+			continue // Removed concurrently
+		}
+		if err != nil {
+			return snapshot, err
+		}
+		redacted, err := store.GetProviderConfigRedacted(name)
+		if err != nil {
+			return snapshot, err
+		}
+		redactedByID := make(map[string]schemas.Key, len(redacted.Keys))
+		for _, key := range redacted.Keys {
+			redactedByID[key.ID] = key
+		}
+		config := *raw
+		config.Keys = make([]schemas.Key, len(raw.Keys))
+		for i, key := range raw.Keys {
+			config.Keys[i] = declaredKey(key, redactedByID[key.ID])
+		}
+		snapshot.Providers[name] = config
+	}
+
+	if store.ConfigStore != nil {
+		plugins, err := store.ConfigStore.GetPlugins(ctx)
+		if err != nil {
+			return snapshot, err
+		}
+		for _, plugin := range plugins {
+			snapshot.Plugins = append(snapshot.Plugins, schemas.PluginConfig{Name: plugin.Name, Enabled: plugin.Enabled, Config: plugin.Config})
+		}
+		slices.SortFunc(snapshot.Plugins, func(a, b schemas.PluginConfig) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	return snapshot, nil
+}
+
+// versionSummary describes a version with its changes from the preceding one.
+func versionSummary(version *lib.ConfigVersion, previous *lib.ConfigVersion) ConfigVersionSummary {
+	var from lib.ConfigSnapshot
+	if previous != nil {
+		from = previous.Snapshot
+	}
+	return ConfigVersionSummary{
+		ID:        version.ID,
+		CreatedAt: version.CreatedAt,
+		Source:    version.Source,
+		Changes:   diffSnapshots(from, version.Snapshot),
+	}
+}
+
+// diffSnapshots returns the changes turning one snapshot into another: client config, providers and keys, then
+// plugins.
+func diffSnapshots(from lib.ConfigSnapshot, to lib.ConfigSnapshot) []ApplyChange {
+	changes := []ApplyChange{}
+	if fields := diffClientConfig(from.Client, to.Client); len(fields) > 0 {
+		changes = append(changes, ApplyChange{Resource: "client", Name: "client", Action: ApplyActionUpdate, Fields: fields})
+	}
+
+	names := make([]schemas.ModelProvider, 0, len(from.Providers)+len(to.Providers))
+	for name := range from.Providers {
+		names = append(names, name)
+	}
+	for name := range to.Providers {
+		if _, ok := from.Providers[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		existing, existed := from.Providers[name]
+		want, wanted := to.Providers[name]
+		switch {
+		case !wanted:
+			changes = append(changes, ApplyChange{Resource: "provider", Name: string(name), Action: ApplyActionDelete})
+		case !existed:
+			changes = append(changes, ApplyChange{Resource: "provider", Name: string(name), Action: ApplyActionCreate})
+			for _, key := range want.Keys {
+				changes = append(changes, ApplyChange{Resource: "key", Name: string(name) + "/" + key.ID, Action: ApplyActionCreate})
+			}
+		default:
+			fields := diffProviderFields(desiredProvider(want), &existing)
+			// Both snapshots hold declared keys
+			keyChanges := diffProviderKeys(name, want.Keys, existing.Keys, existing.Keys)
+			if len(keyChanges) > 0 {
+				fields = append(fields, "keys")
+			}
+			if len(fields) > 0 {
+				changes = append(changes, ApplyChange{Resource: "provider", Name: string(name), Action: ApplyActionUpdate, Fields: fields})
+				changes = append(changes, keyChanges...)
+			}
+		}
+	}
+
+	return append(changes, diffPlugins(from.Plugins, to.Plugins)...)
+}
+
+// diffClientConfig returns the JSON names of the client config fields that differ.
+func diffClientConfig(from configstore.ClientConfig, to configstore.ClientConfig) []string {
+	var fields []string
+	a, b := reflect.ValueOf(from), reflect.ValueOf(to)
+	for i := 0; i < a.NumField(); i++ {
+		if reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			continue
+		}
+		name, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("json"), ",")
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// diffPlugins returns the plugin changes, sorted by name. Plugin configs are compared in their JSON form.
+func diffPlugins(from []schemas.PluginConfig, to []schemas.PluginConfig) []ApplyChange {
+	var changes []ApplyChange
+	for _, want := range to {
+		i := slices.IndexFunc(from, func(plugin schemas.PluginConfig) bool { return plugin.Name == want.Name })
+		if i < 0 {
+			changes = append(changes, ApplyChange{Resource: "plugin", Name: want.Name, Action: ApplyActionCreate})
+			continue
+		}
+		var fields []string
+		if from[i].Enabled != want.Enabled {
+			fields = append(fields, "enabled")
+		}
+		if !sameJSON(from[i].Config, want.Config) {
+			fields = append(fields, "config")
+		}
+		if len(fields) > 0 {
+			changes = append(changes, ApplyChange{Resource: "plugin", Name: want.Name, Action: ApplyActionUpdate, Fields: fields})
+		}
+	}
+	for _, existing := range from {
+		if !slices.ContainsFunc(to, func(plugin schemas.PluginConfig) bool { return plugin.Name == existing.Name }) {
+			changes = append(changes, ApplyChange{Resource: "plugin", Name: existing.Name, Action: ApplyActionDelete})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b ApplyChange) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return changes
+}
+
+// sameJSON reports whether two values have the same JSON encoding.
+func sameJSON(a any, b any) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// desiredProvider converts a snapshotted provider config to a desired provider.
+func desiredProvider(config configstore.ProviderConfig) DesiredProvider {
+	return DesiredProvider{
+		Keys:                     config.Keys,
+		NetworkConfig:            config.NetworkConfig,
+		ConcurrencyAndBufferSize: config.ConcurrencyAndBufferSize,
+		ProxyConfig:              config.ProxyConfig,
+		SendBackRawResponse:      config.SendBackRawResponse,
+		CustomProviderConfig:     config.CustomProviderConfig,
+		MockConfig:               config.MockConfig,
+		RequestShaping:           config.RequestShaping,
+		SyntheticStreaming:       config.SyntheticStreaming,
+	}
+}
+
+// redactSnapshot returns a copy of a snapshot with the secrets of providers redacted. env.VAR references are
+// kept as they are.
+func redactSnapshot(snapshot lib.ConfigSnapshot) lib.ConfigSnapshot {
+	redact := func(value string) string {
+		if value == "" || strings.HasPrefix(value, "env.") {
+			return value
+		}
+		return lib.RedactKey(value)
+	}
+	redactPtr := func(value *string) *string {
+		if value == nil {
+			return nil
+		}
+		redacted := redact(*value)
+		return &redacted
+	}
+
+	redacted := snapshot
+	redacted.Providers = make(map[schemas.ModelProvider]configstore.ProviderConfig, len(snapshot.Providers))
+	for name, config := range snapshot.Providers {
+		keys := make([]schemas.Key, len(config.Keys))
+		for i, key := range config.Keys {
+			key.Value = redact(key.Value)
+			if key.VertexKeyConfig != nil {
+				vertex := *key.VertexKeyConfig
+				vertex.AuthCredentials = redact(vertex.AuthCredentials)
+				key.VertexKeyConfig = &vertex
+			}
+			if key.BedrockKeyConfig != nil {
+				bedrock := *key.BedrockKeyConfig
+				bedrock.AccessKey = redact(bedrock.AccessKey)
+				bedrock.SecretKey = redact(bedrock.SecretKey)
+				bedrock.SessionToken = redactPtr(bedrock.SessionToken)
+				key.BedrockKeyConfig = &bedrock
+			}
+			keys[i] = key
+		}
+		config.Keys = keys
+		// An inline client key of the upstream HTTP client is a secret, file paths are kept as-is
+		if config.NetworkConfig != nil && config.NetworkConfig.HTTPClient != nil && strings.Contains(config.NetworkConfig.HTTPClient.ClientKey, "-----BEGIN") {
+			networkConfig := *config.NetworkConfig
+			httpClient := *networkConfig.HTTPClient
+			httpClient.ClientKey = lib.RedactKey(httpClient.ClientKey)
+			networkConfig.HTTPClient = &httpClient
+			config.NetworkConfig = &networkConfig
+		}
+		redacted.Providers[name] = config
+	}
+	return redacted
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// configVersionsRequest runs a request through a config versions handler route and decodes its response into v.
+func configVersionsRequest(t *testing.T, handler fasthttp.RequestHandler, method, path, id, body string, v any) int {
+	t.Helper()
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(path)
+	ctx.Request.SetBodyString(body)
+	if id != "" {
+		ctx.SetUserValue("id", id)
+	}
+	handler(ctx)
+	if v != nil && ctx.Response.StatusCode() == fasthttp.StatusOK {
+		if err := json.Unmarshal(ctx.Response.Body(), v); err != nil {
+			t.Fatalf("invalid response: %s", ctx.Response.Body())
+		}
+	}
+	return ctx.Response.StatusCode()
+}
+
+// TestConfigVersions tests that applied changes are recorded as versions with their diffs, and that rolling back
+// restores a prior version and is recorded in turn
+func TestConfigVersions(t *testing.T) {
+	lib.SetLogger(bifrost.NewDefaultLogger(schemas.LogLevelError))
+	t.Setenv("OPENAI_API_KEY", "sk-resolved")
+	apply := newApplyTestHandler()
+	h := NewConfigVersionsHandler(apply.store, nil, apply, nil, nil, apply.logger)
+	h.record(context.Background(), "startup")
+
+	applyBody := `{"providers": {
+		"openai": {"keys": [{"id": "primary", "value": "env.OPENAI_API_KEY", "models": [], "weight": 2}]},
+		"anthropic": {"keys": [{"id": "claude", "value": "sk-ant-literal", "models": [], "weight": 1}]}
+	}}`
+	for range 2 {
+		if status := configVersionsRequest(t, h.RecordVersion(apply.apply), fasthttp.MethodPost, "/api/apply", "", applyBody, nil); status != fasthttp.StatusOK {
+			t.Fatalf("apply failed with %d", status)
+		}
+	}
+
+	var versions []ConfigVersionSummary
+	configVersionsRequest(t, h.listVersions, fasthttp.MethodGet, "/api/config/versions", "", "", &versions)
+	if len(versions) != 2 || versions[0].ID != 2 || versions[0].Source != "POST /api/apply" || versions[1].Source != "startup" {
+		t.Fatalf("unexpected versions %+v, want the apply once after the startup version", versions)
+	}
+	wantChanges := []ApplyChange{
+		{Resource: "provider", Name: "openai", Action: ApplyActionUpdate, Fields: []string{"keys"}},
+		{Resource: "key", Name: "openai/primary", Action: ApplyActionUpdate, Fields: []string{"weight"}},
+	}
+	if !reflect.DeepEqual(versions[0].Changes, wantChanges) {
+		t.Errorf("changes = %+v, want %+v", versions[0].Changes, wantChanges)
+	}
+
+	var version ConfigVersionResponse
+	configVersionsRequest(t, h.getVersion, fasthttp.MethodGet, "/api/config/versions/1", "1", "", &version)
+	if len(version.Changes) != 4 {
+		t.Errorf("expected the startup version to create both providers and their keys, got %+v", version.Changes)
+	}
+	if key := version.Snapshot.Providers[schemas.OpenAI].Keys[0]; key.Value != "env.OPENAI_API_KEY" || key.Weight != 1 {
+		t.Errorf("openai key = %+v, want the env reference with the startup weight", key)
+	}
+	if value := version.Snapshot.Providers[schemas.Anthropic].Keys[0].Value; value == "sk-ant-literal" || !lib.IsRedacted(value) {
+		t.Errorf("anthropic key = %q, want it redacted", value)
+	}
+
+	var rollback ConfigRollbackResponse
+	configVersionsRequest(t, h.rollback, fasthttp.MethodPost, "/api/config/versions/1/rollback?dry_run=true", "1", "", &rollback)
+	if !rollback.DryRun || len(rollback.Changes) != 2 || rollback.Version != 0 {
+		t.Fatalf("unexpected dry run %+v", rollback)
+	}
+	if config, _ := apply.store.GetProviderConfigRaw(schemas.OpenAI); config.Keys[0].Weight != 2 {
+		t.Fatalf("expected the dry run to leave the config unchanged")
+	}
+
+	configVersionsRequest(t, h.rollback, fasthttp.MethodPost, "/api/config/versions/1/rollback", "1", "", &rollback)
+	if rollback.DryRun || len(rollback.Changes) != 2 || rollback.Version != 3 {
+		t.Fatalf("unexpected rollback %+v", rollback)
+	}
+	config, _ := apply.store.GetProviderConfigRaw(schemas.OpenAI)
+	if config.Keys[0].Weight != 1 || config.Keys[0].Value != "sk-resolved" {
+		t.Errorf("openai key = %+v, want the startup weight with its env value resolved", config.Keys[0])
+	}
+	if latest, _, _ := apply.store.GetConfigVersion(3); latest == nil || latest.Source != "rollback to 1" {
+		t.Errorf("expected the rollback to be recorded, got %+v", latest)
+	}
+
+	if status := configVersionsRequest(t, h.rollback, fasthttp.MethodPost, "/api/config/versions/9/rollback", "9", "", nil); status != fasthttp.StatusNotFound {
+		t.Errorf("status = %d, want 404 for an unknown version", status)
+	}
+	if status := configVersionsRequest(t, h.getVersion, fasthttp.MethodGet, "/api/config/versions/latest", "latest", "", nil); status != fasthttp.StatusBadRequest {
+		t.Errorf("status = %d, want 400 for an invalid id", status)
+	}
+}
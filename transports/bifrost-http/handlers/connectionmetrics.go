@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/telemetry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// upstreamConnectionCollector exports the connections providers opened to their APIs. Together with
+// bifrost_upstream_requests_total it gives the connection reuse rate of each provider.
+type upstreamConnectionCollector struct {
+	stats          func() map[schemas.ModelProvider]schemas.UpstreamConnectionStats
+	opened         *prometheus.Desc
+	closed         *prometheus.Desc
+	open           *prometheus.Desc
+	tlsCacheHits   *prometheus.Desc
+	tlsCacheMisses *prometheus.Desc
+}
+
+// newUpstreamConnectionCollector creates a collector reading the connection counters from stats.
+func newUpstreamConnectionCollector(stats func() map[schemas.ModelProvider]schemas.UpstreamConnectionStats) *upstreamConnectionCollector {
+	labels := []string{"provider"}
+	return &upstreamConnectionCollector{
+		stats:          stats,
+		opened:         prometheus.NewDesc("bifrost_upstream_connections_opened_total", "Connections opened to provider APIs.", labels, nil),
+		closed:         prometheus.NewDesc("bifrost_upstream_connections_closed_total", "Connections to provider APIs that were closed.", labels, nil),
+		open:           prometheus.NewDesc("bifrost_upstream_connections_open", "Connections to provider APIs currently open.", labels, nil),
+		tlsCacheHits:   prometheus.NewDesc("bifrost_upstream_tls_session_cache_hits_total", "TLS handshakes resuming a cached session.", labels, nil),
+		tlsCacheMisses: prometheus.NewDesc("bifrost_upstream_tls_session_cache_misses_total", "Full TLS handshakes of providers with a session cache.", labels, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *upstreamConnectionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.opened
+	ch <- c.closed
+	ch <- c.open
+	ch <- c.tlsCacheHits
+	ch <- c.tlsCacheMisses
+}
+
+// Collect implements prometheus.Collector.
+func (c *upstreamConnectionCollector) Collect(ch chan<- prometheus.Metric) {
+	for provider, stats := range c.stats() {
+		ch <- prometheus.MustNewConstMetric(c.opened, prometheus.CounterValue, float64(stats.Opened), string(provider))
+		ch <- prometheus.MustNewConstMetric(c.closed, prometheus.CounterValue, float64(stats.Closed), string(provider))
+		ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.Open), string(provider))
+		ch <- prometheus.MustNewConstMetric(c.tlsCacheHits, prometheus.CounterValue, float64(stats.TLSSessionCacheHits), string(provider))
+		ch <- prometheus.MustNewConstMetric(c.tlsCacheMisses, prometheus.CounterValue, float64(stats.TLSSessionCacheMisses), string(provider))
+	}
+}
+
+// upstreamConnectionCatalog documents the metrics of upstreamConnectionCollector for the metrics catalog.
+func upstreamConnectionCatalog() []telemetry.MetricDescription {
+	labels := []string{"provider"}
+	return []telemetry.MetricDescription{
+		{Name: "bifrost_upstream_connections_opened_total", Type: "counter", Help: "Connections opened to provider APIs.", Labels: labels},
+		{Name: "bifrost_upstream_connections_closed_total", Type: "counter", Help: "Connections to provider APIs that were closed.", Labels: labels},
+		{Name: "bifrost_upstream_connections_open", Type: "gauge", Help: "Connections to provider APIs currently open.", Labels: labels},
+		{Name: "bifrost_upstream_tls_session_cache_hits_total", Type: "counter", Help: "TLS handshakes resuming a cached session.", Labels: labels},
+		{Name: "bifrost_upstream_tls_session_cache_misses_total", Type: "counter", Help: "Full TLS handshakes of providers with a session cache.", Labels: labels},
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestUpstreamConnectionCollector tests that the connection counters of each provider are exported
+func TestUpstreamConnectionCollector(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(newUpstreamConnectionCollector(func() map[schemas.ModelProvider]schemas.UpstreamConnectionStats {
+		return map[schemas.ModelProvider]schemas.UpstreamConnectionStats{
+			schemas.OpenAI: {Opened: 3, Closed: 1, Open: 2, TLSSessionCacheHits: 2, TLSSessionCacheMisses: 1},
+		}
+	}))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		metric := family.GetMetric()[0]
+		if label := metric.GetLabel()[0]; label.GetName() != "provider" || label.GetValue() != "openai" {
+			t.Errorf("expected %s to be labeled with the provider, got %v", family.GetName(), metric.GetLabel())
+		}
+		values[family.GetName()] = metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+	}
+
+	expected := map[string]float64{
+		"bifrost_upstream_connections_opened_total":       3,
+		"bifrost_upstream_connections_closed_total":       1,
+		"bifrost_upstream_connections_open":               2,
+		"bifrost_upstream_tls_session_cache_hits_total":   2,
+		"bifrost_upstream_tls_session_cache_misses_total": 1,
+	}
+	for name, value := range expected {
+		if values[name] != value {
+			t.Errorf("expected %s to be %v, got %v", name, value, values[name])
+		}
+	}
+}
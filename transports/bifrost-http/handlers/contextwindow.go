@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/tokenizer"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const contextWindowPluginName = "bifrost-context-window"
+
+// contextWindowPlugin applies the context window strategy to chat requests whose messages exceed the window
+// of the model they are sent to. It runs after sessions and system prompt policies, so the budget covers the
+// full prompt, and before logging, so request logs show what the provider received. Fallback attempts are
+// checked against the window of their own model.
+type contextWindowPlugin struct {
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// GetName returns the name of the plugin
+func (p *contextWindowPlugin) GetName() string {
+	return contextWindowPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *contextWindowPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook truncates, summarizes or rejects chat requests over the context window and reports it in the response headers
+func (p *contextWindowPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	config := p.config.ContextWindow
+	if req.ChatRequest == nil || config == nil {
+		return req, nil, nil
+	}
+	budget := config.PromptBudget(req.Model, req.ChatRequest.Params)
+	if budget == 0 {
+		return req, nil, nil
+	}
+	input := req.ChatRequest.Input
+	tokens := tokenizer.EstimateMessages(input)
+	if tokens <= budget {
+		return req, nil, nil
+	}
+
+	strategy := config.GetStrategy()
+	if strategy == lib.ContextWindowStrategyError {
+		return req, &schemas.PluginShortCircuit{Error: contextLengthExceededError(req.Model, tokens, budget)}, nil
+	}
+
+	kept, dropped := lib.FitChatMessages(input, budget)
+	if len(dropped) == 0 {
+		return req, nil, nil
+	}
+	droppedCount := len(dropped)
+	if strategy == lib.ContextWindowStrategySummarize {
+		summarized, err := p.summarize(*ctx, config, kept, dropped, budget)
+		if err != nil {
+			p.logger.Warn("failed to summarize messages over the context window of %s, dropping them instead: %v", req.Model, err)
+			strategy = lib.ContextWindowStrategyDropOldest
+		} else {
+			droppedCount = len(input) - (len(summarized) - 1) // The summary message is not a client message
+			kept = summarized
+		}
+	}
+
+	if responseHeaders, ok := (*ctx).Value(lib.ResponseHeadersContextKey).(*lib.ResponseHeaders); ok {
+		responseHeaders.Set(lib.ContextTruncationHeader, string(strategy))
+		responseHeaders.Set(lib.ContextDroppedMessagesHeader, strconv.Itoa(droppedCount))
+		responseHeaders.Set(lib.ContextTokensHeader, strconv.Itoa(tokenizer.EstimateMessages(kept)))
+	}
+
+	truncated := *req
+	chatReq := *req.ChatRequest
+	chatReq.Input = kept
+	truncated.ChatRequest = &chatReq
+	return &truncated, nil, nil
+}
+
+// summarize replaces the dropped messages with a summary message placed after the leading system messages.
+// Messages that still do not fit next to the summary are dropped.
+func (p *contextWindowPlugin) summarize(ctx context.Context, config *lib.ContextWindowConfig, kept, dropped []schemas.ChatMessage, budget int) ([]schemas.ChatMessage, error) {
+	summary, err := p.config.SummarizeMessages(ctx, config.SummarizeModel, "", dropped)
+	if err != nil {
+		return nil, err
+	}
+	leading := lib.LeadingSystemMessages(kept)
+	summarized := make([]schemas.ChatMessage, 0, len(kept)+1)
+	summarized = append(summarized, kept[:leading]...)
+	summarized = append(summarized, lib.SummaryMessage(summary))
+	summarized = append(summarized, kept[leading:]...)
+	summarized, _ = lib.FitChatMessages(summarized, budget)
+	return summarized, nil
+}
+
+// contextLengthExceededError is returned by the error strategy. Fallbacks are allowed, since a fallback
+// model may have a larger window.
+func contextLengthExceededError(model string, tokens, budget int) *schemas.BifrostError {
+	statusCode := fasthttp.StatusBadRequest
+	errorType := "context_length_exceeded"
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Code:    &errorType,
+			Message: fmt.Sprintf("the messages use about %d tokens, more than the %d tokens available in the context window of %s", tokens, budget, model),
+		},
+	}
+}
+
+// PostHook is not used for this plugin
+func (p *contextWindowPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *contextWindowPlugin) Cleanup() error {
+	return nil
+}
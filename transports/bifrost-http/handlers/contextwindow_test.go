@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// contextWindowRequest returns a chat request to a model with a 1200 token window, whose 5 turns of ~300 tokens each overflow it
+func contextWindowRequest() *schemas.BifrostRequest {
+	messages := []schemas.ChatMessage{sessionMessage(schemas.ChatMessageRoleSystem, "You are terse.")}
+	for i := 0; i < 5; i++ {
+		role := schemas.ChatMessageRoleUser
+		if i%2 == 1 {
+			role = schemas.ChatMessageRoleAssistant
+		}
+		messages = append(messages, sessionMessage(role, strings.Repeat("word ", 240)))
+	}
+	return &schemas.BifrostRequest{
+		Provider:    schemas.OpenAI,
+		Model:       "tiny-chat",
+		RequestType: schemas.ChatCompletionRequest,
+		ChatRequest: &schemas.BifrostChatRequest{Provider: schemas.OpenAI, Model: "tiny-chat", Input: messages},
+	}
+}
+
+// TestContextWindowPlugin_DropsOldestMessages tests that the oldest messages after the system prompt are dropped to fit the
+// window, that the truncation is reported in the response headers and that summarization failures fall back to dropping
+func TestContextWindowPlugin_DropsOldestMessages(t *testing.T) {
+	for _, strategy := range []lib.ContextWindowStrategy{lib.ContextWindowStrategyDropOldest, lib.ContextWindowStrategySummarize} {
+		t.Run(string(strategy), func(t *testing.T) {
+			config := &lib.Config{ContextWindow: &lib.ContextWindowConfig{
+				Enabled:             true,
+				Strategy:            strategy,
+				SummarizeModel:      "openai/gpt-4o-mini", // No client in tests, so summarization fails
+				ReserveOutputTokens: 100,
+				Models:              map[string]int{"tiny-*": 1200},
+			}}
+			plugin := &contextWindowPlugin{config: config, logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+			responseHeaders := &lib.ResponseHeaders{}
+			ctx := context.WithValue(context.Background(), lib.ResponseHeadersContextKey, responseHeaders)
+
+			req := contextWindowRequest()
+			truncated, shortCircuit, err := plugin.PreHook(&ctx, req)
+			if err != nil || shortCircuit != nil {
+				t.Fatalf("unexpected error %v or short circuit %+v", err, shortCircuit)
+			}
+
+			input := truncated.ChatRequest.Input
+			if len(input) != 4 || input[0].Role != schemas.ChatMessageRoleSystem {
+				t.Fatalf("expected the system prompt and the 3 latest messages, got %d messages", len(input))
+			}
+			if input[3].Content != req.ChatRequest.Input[5].Content {
+				t.Error("expected the latest message to be kept")
+			}
+			if len(req.ChatRequest.Input) != 6 {
+				t.Error("expected the original request to be untouched")
+			}
+			if got := responseHeaders.Get(lib.ContextTruncationHeader); got != string(lib.ContextWindowStrategyDropOldest) {
+				t.Errorf("unexpected %s header %q", lib.ContextTruncationHeader, got)
+			}
+			if got := responseHeaders.Get(lib.ContextDroppedMessagesHeader); got != "2" {
+				t.Errorf("unexpected %s header %q", lib.ContextDroppedMessagesHeader, got)
+			}
+		})
+	}
+}
+
+// TestContextWindowPlugin_ErrorStrategy tests that the error strategy rejects oversized requests and leaves others alone
+func TestContextWindowPlugin_ErrorStrategy(t *testing.T) {
+	config := &lib.Config{ContextWindow: &lib.ContextWindowConfig{
+		Enabled:             true,
+		Strategy:            lib.ContextWindowStrategyError,
+		ReserveOutputTokens: 100,
+		Models:              map[string]int{"tiny-chat": 1200},
+	}}
+	plugin := &contextWindowPlugin{config: config}
+	ctx := context.Background()
+
+	_, shortCircuit, _ := plugin.PreHook(&ctx, contextWindowRequest())
+	if shortCircuit == nil || shortCircuit.Error == nil {
+		t.Fatal("expected the request to be rejected")
+	}
+	if *shortCircuit.Error.StatusCode != 400 || *shortCircuit.Error.Error.Type != "context_length_exceeded" {
+		t.Errorf("unexpected error %+v", shortCircuit.Error.Error)
+	}
+
+	small := contextWindowRequest()
+	small.ChatRequest.Input = small.ChatRequest.Input[:2]
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, small); shortCircuit != nil {
+		t.Errorf("expected a request within the window to pass, got %+v", shortCircuit.Error.Error)
+	}
+
+	unknown := contextWindowRequest()
+	unknown.Model = "private-model"
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, unknown); shortCircuit != nil {
+		t.Error("expected a model with an unknown window to pass")
+	}
+}
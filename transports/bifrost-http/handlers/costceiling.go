@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/tokenizer"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const costCeilingPluginName = "bifrost-cost-ceiling"
+
+// costEstimateTokens is the number of completion tokens the price of a completion token is taken from, so that
+// prices below a millionth of a dollar are not lost to rounding.
+const costEstimateTokens = 1_000_000
+
+// costCeilingPlugin rejects or clamps chat and text completions and responses requests whose worst-case cost, from
+// their estimated prompt tokens and max tokens, exceeds their cost ceiling, and rejects embedding requests whose
+// input costs more. Requests without max tokens are bounded by the context window of their model, and clamped
+// whatever the mode when it is not known. Requests to models without pricing are rejected unless the config allows
+// them. It runs after the plugins changing prompts, so the estimate covers the full prompt, and fallback attempts are
+// checked with the pricing of their own model.
+type costCeilingPlugin struct {
+	config  *lib.Config
+	pricing usagePricer
+	logger  schemas.Logger
+}
+
+// usagePricer prices the usage of a request, implemented by the pricing manager
+type usagePricer interface {
+	CalculateCostFromUsage(provider string, model string, usage *schemas.LLMUsage, requestType schemas.RequestType, isCacheRead bool, isBatch bool, audioSeconds *int, audioTokenDetails *schemas.AudioTokenDetails) float64
+}
+
+// GetName returns the name of the plugin
+func (p *costCeilingPlugin) GetName() string {
+	return costCeilingPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *costCeilingPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook estimates the worst-case cost of completions with a cost ceiling, rejects or clamps the ones over it and
+// reports the estimate in the response headers
+func (p *costCeilingPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	requestMaxCost, _ := (*ctx).Value(lib.MaxCostContextKey).(float64)
+	virtualKey, _ := (*ctx).Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	maxCost := p.config.CostCeiling.MaxCost(requestMaxCost, virtualKey)
+	if maxCost == 0 {
+		return req, nil, nil
+	}
+	promptTokens, ok := estimatePromptTokens(req)
+	if !ok {
+		return req, nil, nil
+	}
+
+	var maxTokens *int
+	var requestType schemas.RequestType
+	switch {
+	case req.ChatRequest != nil:
+		requestType = schemas.ChatCompletionRequest
+		if req.ChatRequest.Params != nil {
+			maxTokens = req.ChatRequest.Params.MaxCompletionTokens
+		}
+	case req.TextCompletionRequest != nil:
+		requestType = schemas.TextCompletionRequest
+		if req.TextCompletionRequest.Params != nil {
+			maxTokens = req.TextCompletionRequest.Params.MaxTokens
+		}
+	case req.ResponsesRequest != nil:
+		requestType = schemas.ResponsesRequest
+		if req.ResponsesRequest.Params != nil {
+			maxTokens = req.ResponsesRequest.Params.MaxOutputTokens
+		}
+	default:
+		// Embeddings generate no tokens, so their cost is the one of their input
+		requestType = schemas.EmbeddingRequest
+		maxTokens = new(int)
+	}
+	promptCost := p.pricing.CalculateCostFromUsage(string(req.Provider), req.Model, &schemas.LLMUsage{
+		PromptTokens: promptTokens,
+		TotalTokens:  promptTokens,
+	}, requestType, false, false, nil, nil)
+	var tokenCost float64
+	if requestType != schemas.EmbeddingRequest {
+		tokenCost = p.pricing.CalculateCostFromUsage(string(req.Provider), req.Model, &schemas.LLMUsage{
+			CompletionTokens: costEstimateTokens,
+			TotalTokens:      costEstimateTokens,
+		}, requestType, false, false, nil, nil) / costEstimateTokens
+	}
+	if promptCost == 0 && tokenCost == 0 {
+		if p.config.CostCeiling.AllowsUnpriced() {
+			p.logger.Debug("no pricing for %s/%s, not enforcing its cost ceiling", req.Provider, req.Model)
+			return req, nil, nil
+		}
+		return req, &schemas.PluginShortCircuit{Error: costUnknownError(req.Model, maxCost)}, nil
+	}
+
+	responseHeaders, _ := (*ctx).Value(lib.ResponseHeadersContextKey).(*lib.ResponseHeaders)
+	setHeaders := func(estimate float64) {
+		if responseHeaders != nil {
+			responseHeaders.Set(lib.MaxCostHeader, formatCost(maxCost))
+			responseHeaders.Set(lib.CostEstimateHeader, formatCost(estimate))
+		}
+	}
+
+	// Without max tokens, the completion is bounded by what the context window leaves, when it is known
+	completionTokens := -1
+	if maxTokens != nil {
+		completionTokens = *maxTokens
+	} else if window := tokenizer.ContextWindow(req.Model); window > promptTokens {
+		completionTokens = window - promptTokens
+	}
+	if completionTokens >= 0 || tokenCost == 0 {
+		estimate := promptCost + tokenCost*float64(max(completionTokens, 0))
+		setHeaders(estimate)
+		if estimate <= maxCost {
+			return req, nil, nil
+		}
+		if p.config.CostCeiling.GetMode() == lib.CostCeilingModeReject || tokenCost == 0 {
+			return req, &schemas.PluginShortCircuit{Error: costLimitExceededError(req.Model, estimate, maxCost)}, nil
+		}
+	}
+
+	// Clamping, which is also the only way to bound requests of unknown worst case
+	affordable := int((maxCost - promptCost) / tokenCost)
+	if affordable < 1 {
+		setHeaders(promptCost)
+		return req, &schemas.PluginShortCircuit{Error: costLimitExceededError(req.Model, promptCost, maxCost)}, nil
+	}
+	setHeaders(promptCost + tokenCost*float64(affordable))
+	if responseHeaders != nil {
+		responseHeaders.Set(lib.CostClampedTokensHeader, strconv.Itoa(affordable))
+	}
+	clamped := *req
+	if req.ChatRequest != nil {
+		chatReq := *req.ChatRequest
+		params := schemas.ChatParameters{}
+		if chatReq.Params != nil {
+			params = *chatReq.Params
+		}
+		params.MaxCompletionTokens = &affordable
+		chatReq.Params = &params
+		clamped.ChatRequest = &chatReq
+	} else if req.ResponsesRequest != nil {
+		responsesReq := *req.ResponsesRequest
+		params := schemas.ResponsesParameters{}
+		if responsesReq.Params != nil {
+			params = *responsesReq.Params
+		}
+		params.MaxOutputTokens = &affordable
+		responsesReq.Params = &params
+		clamped.ResponsesRequest = &responsesReq
+	} else {
+		textReq := *req.TextCompletionRequest
+		params := schemas.TextCompletionParameters{}
+		if textReq.Params != nil {
+			params = *textReq.Params
+		}
+		params.MaxTokens = &affordable
+		textReq.Params = &params
+		clamped.TextCompletionRequest = &textReq
+	}
+	return &clamped, nil, nil
+}
+
+// formatCost formats an amount in USD for the response headers, rounded to a billionth of a dollar
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(math.Round(cost*1e9)/1e9, 'f', -1, 64)
+}
+
+// costLimitExceededError is returned for requests over their cost ceiling. Fallbacks are allowed, since a fallback
+// model may be cheaper.
+func costLimitExceededError(model string, estimate, maxCost float64) *schemas.BifrostError {
+	statusCode := fasthttp.StatusBadRequest
+	errorType := "cost_limit_exceeded"
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Code:    &errorType,
+			Message: fmt.Sprintf("the request to %s may cost up to $%s, more than its max_cost of $%s", model, formatCost(estimate), formatCost(maxCost)),
+		},
+	}
+}
+
+// costUnknownError is returned for requests with a cost ceiling to a model without pricing, unless the config allows
+// them. Fallbacks are allowed, since a fallback model may be priced.
+func costUnknownError(model string, maxCost float64) *schemas.BifrostError {
+	statusCode := fasthttp.StatusBadRequest
+	errorType := "cost_unknown"
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Code:    &errorType,
+			Message: fmt.Sprintf("%s has no pricing, so its cost cannot be checked against the max_cost of $%s", model, formatCost(maxCost)),
+		},
+	}
+}
+
+// PostHook is not used for this plugin
+func (p *costCeilingPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *costCeilingPlugin) Cleanup() error {
+	return nil
+}
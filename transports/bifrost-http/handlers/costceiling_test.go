@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// costCeilingTestPricer prices gpt-4o at $1 per million prompt tokens and $4 per million completion tokens,
+// text-embedding-3-small at $1 per million input tokens, and no other model
+type costCeilingTestPricer struct{}
+
+func (costCeilingTestPricer) CalculateCostFromUsage(provider string, model string, usage *schemas.LLMUsage, requestType schemas.RequestType, isCacheRead bool, isBatch bool, audioSeconds *int, audioTokenDetails *schemas.AudioTokenDetails) float64 {
+	switch model {
+	case "gpt-4o":
+		return float64(usage.PromptTokens)*1e-6 + float64(usage.CompletionTokens)*4e-6
+	case "text-embedding-3-small":
+		return float64(usage.PromptTokens) * 1e-6
+	}
+	return 0
+}
+
+func costCeilingRequest(model string, maxTokens *int) *schemas.BifrostRequest {
+	return &schemas.BifrostRequest{
+		Provider:    schemas.OpenAI,
+		Model:       model,
+		RequestType: schemas.ChatCompletionRequest,
+		ChatRequest: &schemas.BifrostChatRequest{
+			Provider: schemas.OpenAI,
+			Model:    model,
+			Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("What is the capital of France?")}}},
+			Params:   &schemas.ChatParameters{MaxCompletionTokens: maxTokens},
+		},
+	}
+}
+
+// TestCostCeiling tests that completions over their cost ceiling, set by the request or by its virtual key, are
+// rejected or clamped following the mode, that requests without max tokens are bounded by the context window, and
+// that the estimate is reported in the response headers
+func TestCostCeiling(t *testing.T) {
+	promptTokens, _ := estimatePromptTokens(costCeilingRequest("gpt-4o", nil))
+	promptCost := float64(promptTokens) * 1e-6
+	affordable := int((0.01 - promptCost) / 4e-6)
+
+	tests := []struct {
+		name          string
+		mode          lib.CostCeilingMode
+		unpriced      lib.CostCeilingUnpricedPolicy
+		model         string
+		maxTokens     *int
+		maxCost       float64 // Ceiling of the request, 0 for none
+		virtualKey    string
+		wantRejected  bool
+		wantMaxTokens *int // Max tokens sent to the provider
+	}{
+		{name: "under the ceiling", model: "gpt-4o", maxTokens: bifrost.Ptr(1000), maxCost: 0.01, wantMaxTokens: bifrost.Ptr(1000)},
+		{name: "over the ceiling", model: "gpt-4o", maxTokens: bifrost.Ptr(5000), maxCost: 0.01, wantRejected: true},
+		{name: "clamped", mode: lib.CostCeilingModeClamp, model: "gpt-4o", maxTokens: bifrost.Ptr(5000), maxCost: 0.01, wantMaxTokens: &affordable},
+		{name: "virtual key ceiling", model: "gpt-4o", maxTokens: bifrost.Ptr(1000), virtualKey: "vk-capped", wantRejected: true},
+		{name: "request ceiling over the virtual key one", model: "gpt-4o", maxTokens: bifrost.Ptr(1000), maxCost: 0.01, virtualKey: "vk-capped", wantMaxTokens: bifrost.Ptr(1000)},
+		{name: "context window over the ceiling", model: "gpt-4o", maxCost: 0.01, wantRejected: true},
+		{name: "context window clamped", mode: lib.CostCeilingModeClamp, model: "gpt-4o", maxCost: 0.01, wantMaxTokens: &affordable},
+		{name: "context window under the ceiling", model: "gpt-4o", maxCost: 1},
+		{name: "no ceiling", model: "gpt-4o", maxTokens: bifrost.Ptr(5000), virtualKey: "vk-other", wantMaxTokens: bifrost.Ptr(5000)},
+		{name: "unpriced model allowed", unpriced: lib.CostCeilingUnpricedAllow, model: "private-model", maxTokens: bifrost.Ptr(5000), maxCost: 0.01, wantMaxTokens: bifrost.Ptr(5000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &lib.Config{CostCeiling: &lib.CostCeilingConfig{Mode: tt.mode, Unpriced: tt.unpriced, VirtualKeys: map[string]float64{"vk-capped": 0.001}}}
+			plugin := &costCeilingPlugin{config: config, pricing: costCeilingTestPricer{}, logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+			responseHeaders := &lib.ResponseHeaders{}
+			ctx := context.WithValue(context.Background(), lib.ResponseHeadersContextKey, responseHeaders)
+			if tt.maxCost > 0 {
+				ctx = context.WithValue(ctx, lib.MaxCostContextKey, tt.maxCost)
+			}
+			if tt.virtualKey != "" {
+				ctx = context.WithValue(ctx, schemas.BifrostContextKeyVirtualKeyHeader, tt.virtualKey)
+			}
+
+			req := costCeilingRequest(tt.model, tt.maxTokens)
+			result, shortCircuit, err := plugin.PreHook(&ctx, req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantRejected {
+				if shortCircuit == nil || shortCircuit.Error == nil || *shortCircuit.Error.Error.Type != "cost_limit_exceeded" {
+					t.Fatalf("expected a cost_limit_exceeded error, got %+v", shortCircuit)
+				}
+				if responseHeaders.Get(lib.CostEstimateHeader) == "" {
+					t.Errorf("expected the estimate in the response headers")
+				}
+				return
+			}
+			if shortCircuit != nil {
+				t.Fatalf("expected the request to go through, got %+v", shortCircuit.Error)
+			}
+
+			maxTokens := result.ChatRequest.Params.MaxCompletionTokens
+			if (maxTokens == nil) != (tt.wantMaxTokens == nil) || (maxTokens != nil && *maxTokens != *tt.wantMaxTokens) {
+				t.Errorf("max tokens = %v, want %v", maxTokens, tt.wantMaxTokens)
+			}
+			clamped := responseHeaders.Get(lib.CostClampedTokensHeader)
+			if wantClamped := tt.mode == lib.CostCeilingModeClamp; (clamped == strconv.Itoa(affordable)) != wantClamped {
+				t.Errorf("clamped max tokens header = %q", clamped)
+			}
+			if req.ChatRequest.Params.MaxCompletionTokens != tt.maxTokens {
+				t.Errorf("expected the request of the caller to be left untouched")
+			}
+			if tt.model == "gpt-4o" && tt.virtualKey != "vk-other" {
+				if estimate, _ := strconv.ParseFloat(responseHeaders.Get(lib.CostEstimateHeader), 64); estimate <= 0 || estimate > tt.maxCost {
+					t.Errorf("estimate header = %q, want the worst-case cost within the ceiling", responseHeaders.Get(lib.CostEstimateHeader))
+				}
+			}
+		})
+	}
+}
+
+// TestCostCeiling_OtherRequests tests that responses requests are rejected or clamped like chat completions, that
+// embedding requests over their ceiling are rejected whatever the mode, and that requests to unpriced models fail
+// closed by default
+func TestCostCeiling_OtherRequests(t *testing.T) {
+	responsesRequest := func(model string, maxTokens *int) *schemas.BifrostRequest {
+		return &schemas.BifrostRequest{
+			Provider:    schemas.OpenAI,
+			Model:       model,
+			RequestType: schemas.ResponsesRequest,
+			ResponsesRequest: &schemas.BifrostResponsesRequest{
+				Provider: schemas.OpenAI,
+				Model:    model,
+				Input: []schemas.ResponsesMessage{{
+					Role:    schemas.Ptr(schemas.ResponsesInputMessageRoleUser),
+					Content: &schemas.ResponsesMessageContent{ContentStr: bifrost.Ptr("What is the capital of France?")},
+				}},
+				Params: &schemas.ResponsesParameters{MaxOutputTokens: maxTokens, Instructions: bifrost.Ptr("Answer in one word.")},
+			},
+		}
+	}
+	embeddingRequest := func(text string) *schemas.BifrostRequest {
+		return &schemas.BifrostRequest{
+			Provider:    schemas.OpenAI,
+			Model:       "text-embedding-3-small",
+			RequestType: schemas.EmbeddingRequest,
+			EmbeddingRequest: &schemas.BifrostEmbeddingRequest{
+				Provider: schemas.OpenAI,
+				Model:    "text-embedding-3-small",
+				Input:    &schemas.EmbeddingInput{Text: bifrost.Ptr(text)},
+			},
+		}
+	}
+	promptTokens, ok := estimatePromptTokens(responsesRequest("gpt-4o", nil))
+	if !ok || promptTokens == 0 {
+		t.Fatalf("expected the prompt tokens of responses requests to be estimated, got %d", promptTokens)
+	}
+	affordable := int((0.01 - float64(promptTokens)*1e-6) / 4e-6)
+	longText := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 2000)
+
+	tests := []struct {
+		name          string
+		mode          lib.CostCeilingMode
+		unpriced      lib.CostCeilingUnpricedPolicy
+		req           *schemas.BifrostRequest
+		maxCost       float64
+		wantError     string // Type of the error the request is rejected with, empty when it goes through
+		wantMaxTokens *int   // Max output tokens sent to the provider, for responses requests
+	}{
+		{name: "responses under the ceiling", req: responsesRequest("gpt-4o", bifrost.Ptr(1000)), maxCost: 0.01, wantMaxTokens: bifrost.Ptr(1000)},
+		{name: "responses over the ceiling", req: responsesRequest("gpt-4o", bifrost.Ptr(5000)), maxCost: 0.01, wantError: "cost_limit_exceeded"},
+		{name: "responses clamped", mode: lib.CostCeilingModeClamp, req: responsesRequest("gpt-4o", bifrost.Ptr(5000)), maxCost: 0.01, wantMaxTokens: &affordable},
+		{name: "embedding under the ceiling", req: embeddingRequest("hello"), maxCost: 0.01},
+		{name: "embedding over the ceiling", req: embeddingRequest(longText), maxCost: 0.001, wantError: "cost_limit_exceeded"},
+		{name: "embedding over the ceiling in clamp mode", mode: lib.CostCeilingModeClamp, req: embeddingRequest(longText), maxCost: 0.001, wantError: "cost_limit_exceeded"},
+		{name: "unpriced model rejected", req: responsesRequest("private-model", bifrost.Ptr(1000)), maxCost: 0.01, wantError: "cost_unknown"},
+		{name: "unpriced model allowed", unpriced: lib.CostCeilingUnpricedAllow, req: responsesRequest("private-model", bifrost.Ptr(1000)), maxCost: 0.01, wantMaxTokens: bifrost.Ptr(1000)},
+		{name: "unpriced model without a ceiling", req: responsesRequest("private-model", bifrost.Ptr(1000)), wantMaxTokens: bifrost.Ptr(1000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &lib.Config{CostCeiling: &lib.CostCeilingConfig{Mode: tt.mode, Unpriced: tt.unpriced}}
+			plugin := &costCeilingPlugin{config: config, pricing: costCeilingTestPricer{}, logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+			ctx := context.WithValue(context.Background(), lib.ResponseHeadersContextKey, &lib.ResponseHeaders{})
+			if tt.maxCost > 0 {
+				ctx = context.WithValue(ctx, lib.MaxCostContextKey, tt.maxCost)
+			}
+			result, shortCircuit, err := plugin.PreHook(&ctx, tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantError != "" {
+				if shortCircuit == nil || shortCircuit.Error == nil || *shortCircuit.Error.Error.Type != tt.wantError {
+					t.Fatalf("expected a %s error, got %+v", tt.wantError, shortCircuit)
+				}
+				return
+			}
+			if shortCircuit != nil {
+				t.Fatalf("expected the request to go through, got %+v", shortCircuit.Error)
+			}
+			if result.ResponsesRequest != nil {
+				maxTokens := result.ResponsesRequest.Params.MaxOutputTokens
+				if (maxTokens == nil) != (tt.wantMaxTokens == nil) || (maxTokens != nil && *maxTokens != *tt.wantMaxTokens) {
+					t.Errorf("max output tokens = %v, want %v", maxTokens, tt.wantMaxTokens)
+				}
+			}
+		})
+	}
+}
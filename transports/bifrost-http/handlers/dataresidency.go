@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const dataResidencyPluginName = "bifrost-data-residency"
+
+// dataResidencyErrorType is the error type of requests no provider can serve in their allowed jurisdictions
+const dataResidencyErrorType = "data_residency_violation"
+
+// dataResidencyPlugin restricts the keys serving the requests of virtual keys and customers matched by data
+// residency rules to their allowed jurisdictions. A provider without a compliant key is skipped for the next
+// fallback; the request fails when none is left.
+type dataResidencyPlugin struct {
+	config *lib.Config
+	// governanceStore resolves the customer of a virtual key (nil when governance is off)
+	governanceStore *governance.GovernanceStore
+}
+
+// GetName returns the name of the plugin
+func (p *dataResidencyPlugin) GetName() string {
+	return dataResidencyPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *dataResidencyPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook records the allowed jurisdictions of the request, for key selection, and fails the attempt when its
+// provider has no key in them
+func (p *dataResidencyPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	residency := p.config.DataResidency
+	if residency == nil {
+		return req, nil, nil
+	}
+	allowed, restricted := p.allowedJurisdictions(*ctx)
+	if !restricted {
+		return req, nil, nil
+	}
+	*ctx = context.WithValue(*ctx, lib.DataResidencyContextKey, allowed)
+
+	providerConfig, err := p.config.GetProviderConfigRaw(req.Provider)
+	if err != nil {
+		// Unknown providers fail on dispatch
+		return req, nil, nil
+	}
+	keys := providerConfig.Keys
+	if len(keys) == 0 {
+		// Providers without keys (e.g. self-hosted) only have the jurisdiction set for the provider
+		keys = []schemas.Key{{}}
+	}
+	if len(residency.FilterKeys(req.Provider, keys, allowed)) == 0 {
+		return req, &schemas.PluginShortCircuit{Error: dataResidencyError(req.Provider, allowed)}, nil
+	}
+	return req, nil, nil
+}
+
+// allowedJurisdictions returns the jurisdictions the request may be served in: those allowed by every rule
+// matching its virtual key, billing customer or authenticated customer, narrowed by the x-bf-data-residency header
+func (p *dataResidencyPlugin) allowedJurisdictions(ctx context.Context) ([]string, bool) {
+	residency := p.config.DataResidency
+	virtualKey, _ := ctx.Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	billingCustomer, _ := ctx.Value(schemas.BifrostContextKeyBillingCustomer).(string)
+	customer := authenticatedCustomer(ctx, p.governanceStore)
+
+	allowed, restricted := residency.AllowedJurisdictions(virtualKey, customer)
+	// The billing customer is set by the caller, so it can add restrictions but never lift those of the
+	// authenticated customer
+	if billingCustomer != "" && billingCustomer != customer {
+		if billingAllowed, ok := residency.AllowedJurisdictions("", billingCustomer); ok {
+			if restricted {
+				allowed = lib.IntersectJurisdictions(allowed, billingAllowed)
+			} else {
+				allowed, restricted = billingAllowed, true
+			}
+		}
+	}
+	if requested, ok := ctx.Value(lib.DataResidencyHeaderContextKey).([]string); ok && len(requested) > 0 {
+		if restricted {
+			allowed = lib.IntersectJurisdictions(allowed, requested)
+		} else {
+			allowed, restricted = requested, true
+		}
+	}
+	return allowed, restricted
+}
+
+// dataResidencyError builds the error of an attempt whose provider has no key in the allowed jurisdictions.
+// Fallbacks are attempted, since another provider may have one.
+func dataResidencyError(provider schemas.ModelProvider, allowed []string) *schemas.BifrostError {
+	statusCode := fasthttp.StatusForbidden
+	errorType := dataResidencyErrorType
+	allowFallbacks := true
+	jurisdictions := "none"
+	if len(allowed) > 0 {
+		jurisdictions = strings.Join(allowed, ", ")
+	}
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		AllowFallbacks: &allowFallbacks,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Message: fmt.Sprintf("no compliant provider available: %s has no keys in the jurisdictions allowed for this request (%s)", provider, jurisdictions),
+		},
+		ExtraFields: schemas.BifrostErrorExtraFields{
+			Provider: provider,
+		},
+	}
+}
+
+// PostHook is not used for this plugin
+func (p *dataResidencyPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *dataResidencyPlugin) Cleanup() error {
+	return nil
+}
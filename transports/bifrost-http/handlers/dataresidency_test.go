@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// newDataResidencyTestConfig returns a config with an EU Vertex key, a US Bedrock key and an OpenAI key of unknown
+// jurisdiction, where virtual key vk-eu is restricted to the EU
+func newDataResidencyTestConfig() *lib.Config {
+	return &lib.Config{
+		Providers: map[schemas.ModelProvider]configstore.ProviderConfig{
+			schemas.Vertex:  {Keys: []schemas.Key{{ID: "vertex-eu", VertexKeyConfig: &schemas.VertexKeyConfig{Region: "europe-west4"}}}},
+			schemas.Bedrock: {Keys: []schemas.Key{{ID: "bedrock-us", BedrockKeyConfig: &schemas.BedrockKeyConfig{Region: bifrost.Ptr("us-east-1")}}}},
+			schemas.OpenAI:  {Keys: []schemas.Key{{ID: "openai"}}},
+		},
+		DataResidency: &lib.DataResidencyConfig{
+			Rules: []lib.DataResidencyRule{{Name: "eu-tenants", VirtualKeys: []string{"vk-eu"}, Customers: []string{"acme"}, Jurisdictions: []string{"eu"}}},
+		},
+	}
+}
+
+// TestDataResidencyPlugin_Enforcement tests that restricted requests only reach providers with keys in their
+// jurisdictions, with a fallback-able error otherwise
+func TestDataResidencyPlugin_Enforcement(t *testing.T) {
+	plugin := &dataResidencyPlugin{config: newDataResidencyTestConfig()}
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "vk-eu")
+	_, shortCircuit, err := plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.Vertex})
+	if err != nil || shortCircuit != nil {
+		t.Fatalf("expected the EU Vertex key to serve the request, got %v %+v", err, shortCircuit)
+	}
+	if allowed, _ := ctx.Value(lib.DataResidencyContextKey).([]string); len(allowed) != 1 || allowed[0] != "eu" {
+		t.Errorf("expected the allowed jurisdictions in the context, got %v", allowed)
+	}
+
+	for _, provider := range []schemas.ModelProvider{schemas.Bedrock, schemas.OpenAI} {
+		ctx = context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "vk-eu")
+		_, shortCircuit, _ = plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: provider})
+		if shortCircuit == nil || shortCircuit.Error == nil {
+			t.Fatalf("expected %s to be rejected", provider)
+		}
+		bifrostErr := shortCircuit.Error
+		if *bifrostErr.StatusCode != 403 || *bifrostErr.Error.Type != dataResidencyErrorType || !*bifrostErr.AllowFallbacks {
+			t.Errorf("unexpected error for %s: %+v", provider, bifrostErr)
+		}
+	}
+
+	ctx = context.Background()
+	if _, shortCircuit, _ = plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI}); shortCircuit != nil {
+		t.Errorf("expected unrestricted requests to pass, got %+v", shortCircuit.Error)
+	}
+}
+
+// TestDataResidencyPlugin_Narrowing tests that the caller's billing customer and header narrow the rules
+func TestDataResidencyPlugin_Narrowing(t *testing.T) {
+	config := newDataResidencyTestConfig()
+	config.DataResidency.Keys = map[string]string{"openai": "us"}
+	plugin := &dataResidencyPlugin{config: config}
+
+	// A billing customer restricted to the EU cannot use the US OpenAI key
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyBillingCustomer, "acme")
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI}); shortCircuit == nil {
+		t.Error("expected the billing customer's rule to apply")
+	}
+
+	// The header narrows unrestricted requests, and can be satisfied by an exact region
+	ctx = context.WithValue(context.Background(), lib.DataResidencyHeaderContextKey, []string{"europe-west4"})
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.Vertex}); shortCircuit != nil {
+		t.Errorf("expected the Vertex region to match, got %+v", shortCircuit.Error)
+	}
+
+	// The header cannot widen a rule
+	ctx = context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "vk-eu")
+	ctx = context.WithValue(ctx, lib.DataResidencyHeaderContextKey, []string{"us"})
+	_, shortCircuit, _ := plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI})
+	if shortCircuit == nil {
+		t.Fatal("expected the header not to lift the EU rule")
+	}
+	if expected := "no compliant provider available: openai has no keys in the jurisdictions allowed for this request (none)"; shortCircuit.Error.Error.Message != expected {
+		t.Errorf("expected %q, got %q", expected, shortCircuit.Error.Error.Message)
+	}
+}
+
+// TestBaseAccount_DataResidencyKeys tests that key selection only sees keys in the allowed jurisdictions
+func TestBaseAccount_DataResidencyKeys(t *testing.T) {
+	config := newDataResidencyTestConfig()
+	config.Providers[schemas.Vertex] = configstore.ProviderConfig{Keys: []schemas.Key{
+		{ID: "vertex-eu", VertexKeyConfig: &schemas.VertexKeyConfig{Region: "europe-west4"}},
+		{ID: "vertex-us", VertexKeyConfig: &schemas.VertexKeyConfig{Region: "us-central1"}},
+	}}
+	account := lib.NewBaseAccount(config)
+
+	ctx := context.WithValue(context.Background(), lib.DataResidencyContextKey, []string{"eu"})
+	keys, err := account.GetKeysForProvider(&ctx, schemas.Vertex)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 1 || keys[0].ID != "vertex-eu" {
+		t.Errorf("expected only the EU key, got %+v", keys)
+	}
+
+	ctx = context.Background()
+	if keys, _ = account.GetKeysForProvider(&ctx, schemas.Vertex); len(keys) != 2 {
+		t.Errorf("expected all keys for unrestricted requests, got %d", len(keys))
+	}
+}
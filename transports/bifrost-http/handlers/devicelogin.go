@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// Device login paths. The start and token paths are public, see isPublicPath.
+const (
+	deviceAuthorizationPath = "/api/auth/device"
+	deviceTokenPath         = "/api/auth/device/token"
+)
+
+// deviceVerificationPath is the dashboard page where users confirm device login codes.
+const deviceVerificationPath = "/device"
+
+// Grant types accepted by POST /api/auth/device/token.
+const (
+	DeviceCodeGrantType   = "urn:ietf:params:oauth:grant-type:device_code"
+	RefreshTokenGrantType = "refresh_token"
+)
+
+// DeviceAuthorizationRequest is the body of POST /api/auth/device.
+type DeviceAuthorizationRequest struct {
+	ClientName string                  `json:"client_name,omitempty"` // Shown to the user confirming the code
+	Scopes     []serviceaccounts.Scope `json:"scopes,omitempty"`      // Limits the token to these scopes
+}
+
+// DeviceAuthorizationResponse is the response of POST /api/auth/device.
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"` // Seconds
+	Interval                int    `json:"interval"`   // Seconds to wait between polls
+}
+
+// DeviceTokenRequest is the body of POST /api/auth/device/token.
+type DeviceTokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	DeviceCode   string `json:"device_code,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// DeviceTokenResponse is the token issued to a confirmed device login.
+type DeviceTokenResponse struct {
+	AccessToken      string                  `json:"access_token"`
+	TokenType        string                  `json:"token_type"`
+	ExpiresIn        int                     `json:"expires_in"` // Seconds
+	RefreshToken     string                  `json:"refresh_token"`
+	RefreshExpiresIn int                     `json:"refresh_expires_in"` // Seconds
+	Scopes           []serviceaccounts.Scope `json:"scopes,omitempty"`
+}
+
+// DeviceTokenError is the error body of POST /api/auth/device/token, with the error codes of RFC 8628.
+type DeviceTokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// DeviceDecisionRequest is the body of POST /api/auth/device/approve.
+type DeviceDecisionRequest struct {
+	UserCode string                  `json:"user_code"`
+	Approve  bool                    `json:"approve"`
+	Scopes   []serviceaccounts.Scope `json:"scopes,omitempty"` // Narrows the requested scopes
+}
+
+// DeviceLoginHandler runs the device authorization flow of CLI logins.
+type DeviceLoginHandler struct {
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// NewDeviceLoginHandler creates a new device login handler.
+func NewDeviceLoginHandler(config *lib.Config, logger schemas.Logger) *DeviceLoginHandler {
+	return &DeviceLoginHandler{
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the device login routes.
+func (h *DeviceLoginHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST(deviceAuthorizationPath, lib.ChainMiddlewares(h.start, middlewares...))
+	r.POST(deviceTokenPath, lib.ChainMiddlewares(h.token, middlewares...))
+	r.GET(deviceAuthorizationPath, lib.ChainMiddlewares(h.getAuthorization, middlewares...))
+	r.POST("/api/auth/device/approve", lib.ChainMiddlewares(h.decide, middlewares...))
+}
+
+// start handles POST /api/auth/device - Start a device login and get the code the user confirms
+func (h *DeviceLoginHandler) start(ctx *fasthttp.RequestCtx) {
+	if strings.TrimSpace(h.config.GetAdminSecret()) == "" {
+		SendError(ctx, fasthttp.StatusConflict, "admin auth is not enabled, set BIFROST_ADMIN_PASSWORD to enable it", h.logger)
+		return
+	}
+	var req DeviceAuthorizationRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+			return
+		}
+	}
+	authorization, err := h.config.StartDeviceAuthorization(ctx, strings.TrimSpace(req.ClientName), req.Scopes)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	verificationURI := requestBaseURL(ctx) + deviceVerificationPath
+	SendJSON(ctx, DeviceAuthorizationResponse{
+		DeviceCode:              authorization.DeviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?code=" + authorization.UserCode,
+		ExpiresIn:               int(time.Until(authorization.ExpiresAt).Seconds()),
+		Interval:                int(authorization.Interval.Seconds()),
+	}, h.logger)
+}
+
+// token handles POST /api/auth/device/token - Poll for the token of a device login, or refresh it
+func (h *DeviceLoginHandler) token(ctx *fasthttp.RequestCtx) {
+	var req DeviceTokenRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		h.sendTokenError(ctx, "invalid_request", fmt.Sprintf("invalid request format: %v", err))
+		return
+	}
+	var token *lib.DeviceToken
+	var err error
+	switch req.GrantType {
+	case DeviceCodeGrantType:
+		if req.DeviceCode == "" {
+			h.sendTokenError(ctx, "invalid_request", "device_code is required")
+			return
+		}
+		token, err = h.config.PollDeviceAuthorization(ctx, req.DeviceCode)
+	case RefreshTokenGrantType:
+		if req.RefreshToken == "" {
+			h.sendTokenError(ctx, "invalid_request", "refresh_token is required")
+			return
+		}
+		token, err = h.config.RefreshDeviceToken(ctx, req.RefreshToken)
+	default:
+		h.sendTokenError(ctx, "unsupported_grant_type", fmt.Sprintf("grant_type must be %s or %s", DeviceCodeGrantType, RefreshTokenGrantType))
+		return
+	}
+	if err != nil {
+		for _, known := range []error{lib.ErrAuthorizationPending, lib.ErrSlowDown, lib.ErrAccessDenied, lib.ErrExpiredToken, lib.ErrInvalidGrant} {
+			if errors.Is(err, known) {
+				h.sendTokenError(ctx, known.Error(), "")
+				return
+			}
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to issue device token: %v", err), h.logger)
+		return
+	}
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	SendJSON(ctx, DeviceTokenResponse{
+		AccessToken:      token.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(time.Until(token.ExpiresAt).Seconds()),
+		RefreshToken:     token.RefreshToken,
+		RefreshExpiresIn: int(time.Until(token.RefreshExpiresAt).Seconds()),
+		Scopes:           token.Scopes,
+	}, h.logger)
+}
+
+// sendTokenError sends an RFC 8628 error of the token endpoint. Errors are 400 Bad Request, as the CLI tells the
+// pending states apart by their code.
+func (h *DeviceLoginHandler) sendTokenError(ctx *fasthttp.RequestCtx, code, description string) {
+	ctx.SetStatusCode(fasthttp.StatusBadRequest)
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	SendJSON(ctx, DeviceTokenError{Error: code, ErrorDescription: description}, h.logger)
+}
+
+// getAuthorization handles GET /api/auth/device?user_code= - Get the pending device login of a code to confirm
+func (h *DeviceLoginHandler) getAuthorization(ctx *fasthttp.RequestCtx) {
+	userCode := string(ctx.QueryArgs().Peek("user_code"))
+	if userCode == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "user_code is required", h.logger)
+		return
+	}
+	authorization := h.config.GetDeviceAuthorization(ctx, userCode)
+	if authorization == nil {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("unknown or expired code %q", userCode), h.logger)
+		return
+	}
+	SendJSON(ctx, authorization, h.logger)
+}
+
+// decide handles POST /api/auth/device/approve - Approve or deny a device login, optionally narrowing its scopes
+func (h *DeviceLoginHandler) decide(ctx *fasthttp.RequestCtx) {
+	var req DeviceDecisionRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.UserCode == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "user_code is required", h.logger)
+		return
+	}
+	authorization, err := h.config.DecideDeviceAuthorization(ctx, req.UserCode, req.Approve, req.Scopes)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	SendJSON(ctx, authorization, h.logger)
+}
+
+// authorizeDeviceToken checks that a device token is active and holds the scopes of the request. Device tokens
+// cannot call the inference endpoints, which take virtual keys. It returns false after sending the error response.
+func authorizeDeviceToken(ctx *fasthttp.RequestCtx, config *lib.Config, accessToken string, logger schemas.Logger) bool {
+	token := config.GetDeviceToken(ctx, accessToken)
+	if token == nil {
+		SendError(ctx, fasthttp.StatusUnauthorized, "invalid or expired device token", logger)
+		return false
+	}
+	scopes := serviceAccountScopes(string(ctx.Method()), string(ctx.Path()))
+	if scopes == nil || strings.HasPrefix(string(ctx.Path()), "/v1/") {
+		SendError(ctx, fasthttp.StatusForbidden, "device tokens can only call the management API", logger)
+		return false
+	}
+	for _, scope := range scopes {
+		if !token.Allows(scope) {
+			SendError(ctx, fasthttp.StatusForbidden, fmt.Sprintf("device token lacks the %s scope", scope), logger)
+			return false
+		}
+	}
+	return true
+}
+
+// requestBaseURL returns the scheme and host the client reached the server at, honouring the X-Forwarded-Proto of
+// a TLS terminating proxy.
+func requestBaseURL(ctx *fasthttp.RequestCtx) string {
+	scheme := "http"
+	if ctx.IsTLS() || strings.EqualFold(string(ctx.Request.Header.Peek("X-Forwarded-Proto")), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + string(ctx.Host())
+}
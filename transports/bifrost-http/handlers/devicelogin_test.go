@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestDeviceLogin_Flow tests that a CLI polling a device login gets a token once an admin confirms its code, that
+// the token is limited to the confirmed scopes and that refreshing rotates it
+func TestDeviceLogin_Flow(t *testing.T) {
+	testLogger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	config := &lib.Config{AdminSecret: "admin-secret"}
+	h := NewDeviceLoginHandler(config, testLogger)
+	auth := AdminAuthMiddleware(config, testLogger)
+
+	// The CLI starts the login without credentials
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI(deviceAuthorizationPath)
+	ctx.Request.Header.SetHost("bifrost.example")
+	ctx.Request.SetBodyString(`{"client_name": "bifrost-cli", "scopes": ["config:read", "logs:read"]}`)
+	auth(h.start)(ctx)
+	var start DeviceAuthorizationResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &start); err != nil || ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("start response %d %s, want a device code", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	if start.VerificationURI != "http://bifrost.example/device" || len(start.UserCode) != 9 || start.Interval != 5 {
+		t.Errorf("unexpected start response %+v", start)
+	}
+
+	poll := func(body string) (*DeviceTokenResponse, string) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetRequestURI(deviceTokenPath)
+		ctx.Request.SetBodyString(body)
+		auth(h.token)(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			var tokenErr DeviceTokenError
+			json.Unmarshal(ctx.Response.Body(), &tokenErr)
+			return nil, tokenErr.Error
+		}
+		var token DeviceTokenResponse
+		if err := json.Unmarshal(ctx.Response.Body(), &token); err != nil {
+			t.Fatalf("invalid token response: %v", err)
+		}
+		return &token, ""
+	}
+	pollBody := `{"grant_type": "` + DeviceCodeGrantType + `", "device_code": "` + start.DeviceCode + `"}`
+	if _, code := poll(pollBody); code != "authorization_pending" {
+		t.Fatalf("poll error = %q, want authorization_pending", code)
+	}
+	if _, code := poll(pollBody); code != "slow_down" {
+		t.Fatalf("poll error = %q, want slow_down when polling faster than the interval", code)
+	}
+
+	// A signed-in admin confirms the code, narrowing the scopes
+	if config.GetDeviceAuthorization(context.Background(), "bogus") != nil {
+		t.Errorf("expected unknown codes to be refused")
+	}
+	pending := config.GetDeviceAuthorization(context.Background(), start.UserCode)
+	if pending == nil || pending.ClientName != "bifrost-cli" {
+		t.Fatalf("pending authorization = %+v", pending)
+	}
+	if _, err := config.DecideDeviceAuthorization(context.Background(), start.UserCode, true, []serviceaccounts.Scope{serviceaccounts.ScopeAdmin}); err == nil {
+		t.Errorf("expected an approval widening the requested scopes to be refused")
+	}
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/api/auth/device/approve")
+	ctx.Request.SetBodyString(`{"user_code": "` + start.UserCode + `", "approve": true, "scopes": ["config:read"]}`)
+	auth(h.decide)(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Fatalf("status = %d, want approvals to require a signed-in admin", ctx.Response.StatusCode())
+	}
+	ctx.Response.Reset()
+	ctx.Request.Header.Set("Authorization", "Bearer admin-secret")
+	auth(h.decide)(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("approve response %d %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	token, code := poll(pollBody)
+	if token == nil || len(token.Scopes) != 1 || token.Scopes[0] != serviceaccounts.ScopeConfigRead || token.ExpiresIn <= 0 {
+		t.Fatalf("token = %+v, error = %q, want a config:read token", token, code)
+	}
+	if _, code := poll(pollBody); code != "invalid_grant" {
+		t.Errorf("poll error = %q, want device codes spent once a token is issued", code)
+	}
+
+	call := func(method, path, accessToken string) int {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.Header.Set("Authorization", "Bearer "+accessToken)
+		auth(func(ctx *fasthttp.RequestCtx) { ctx.SetStatusCode(fasthttp.StatusOK) })(ctx)
+		return ctx.Response.StatusCode()
+	}
+	if status := call(fasthttp.MethodGet, "/api/config", token.AccessToken); status != fasthttp.StatusOK {
+		t.Errorf("status = %d, want the token to read the config", status)
+	}
+	if status := call(fasthttp.MethodPut, "/api/config", token.AccessToken); status != fasthttp.StatusForbidden {
+		t.Errorf("status = %d, want the token refused writes", status)
+	}
+
+	refreshed, code := poll(`{"grant_type": "refresh_token", "refresh_token": "` + token.RefreshToken + `"}`)
+	if refreshed == nil || refreshed.AccessToken == token.AccessToken {
+		t.Fatalf("refreshed = %+v, error = %q, want a new token", refreshed, code)
+	}
+	if status := call(fasthttp.MethodGet, "/api/config", token.AccessToken); status != fasthttp.StatusUnauthorized {
+		t.Errorf("status = %d, want the old token revoked by the refresh", status)
+	}
+	if _, code := poll(`{"grant_type": "refresh_token", "refresh_token": "` + token.RefreshToken + `"}`); code != "invalid_grant" {
+		t.Errorf("refresh error = %q, want refresh tokens spent once used", code)
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set("Authorization", "Bearer "+refreshed.AccessToken)
+	NewAuthHandler(config, testLogger).me(ctx)
+	var session AuthSession
+	if err := json.Unmarshal(ctx.Response.Body(), &session); err != nil || session.Method != AuthMethodDevice || session.ExpiresAt == nil {
+		t.Errorf("expected /api/auth/me to report the device token, got %s", ctx.Response.Body())
+	}
+	config.SetAdminSecret(context.Background(), "rotated")
+	if status := call(fasthttp.MethodGet, "/api/config", refreshed.AccessToken); status != fasthttp.StatusUnauthorized {
+		t.Errorf("status = %d, want rotating the admin secret to end device logins", status)
+	}
+}
+
+// TestDeviceLogin_SharedStore tests that a device code started on one replica can be confirmed and redeemed on
+// another sharing the config store, and that codes and refresh tokens are still spent once
+func TestDeviceLogin_SharedStore(t *testing.T) {
+	ctx := context.Background()
+	testLogger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	lib.SetLogger(testLogger)
+	storeConfig := &configstore.Config{
+		Enabled: true,
+		Type:    configstore.ConfigStoreTypeSQLite,
+		Config:  &configstore.SQLiteConfig{Path: filepath.Join(t.TempDir(), "config.db")},
+	}
+	newReplica := func() *lib.Config {
+		t.Helper()
+		store, err := configstore.NewConfigStore(ctx, storeConfig, testLogger)
+		if err != nil {
+			t.Fatalf("failed to create config store: %v", err)
+		}
+		t.Cleanup(func() { store.Close(ctx) })
+		return &lib.Config{AdminSecret: "admin-secret", ConfigStore: store}
+	}
+	first, second := newReplica(), newReplica()
+
+	start, err := first.StartDeviceAuthorization(ctx, "bifrost-cli", []serviceaccounts.Scope{serviceaccounts.ScopeConfigRead, serviceaccounts.ScopeLogsRead})
+	if err != nil {
+		t.Fatalf("failed to start the device login: %v", err)
+	}
+	if _, err := first.PollDeviceAuthorization(ctx, start.DeviceCode); !errors.Is(err, lib.ErrAuthorizationPending) {
+		t.Fatalf("poll error = %v, want authorization_pending", err)
+	}
+	if _, err := second.PollDeviceAuthorization(ctx, start.DeviceCode); !errors.Is(err, lib.ErrSlowDown) {
+		t.Fatalf("poll error = %v, want slow_down when another replica was polled within the interval", err)
+	}
+	if _, err := second.DecideDeviceAuthorization(ctx, start.UserCode, true, []serviceaccounts.Scope{serviceaccounts.ScopeConfigRead}); err != nil {
+		t.Fatalf("failed to approve the code on another replica: %v", err)
+	}
+	if _, err := first.DecideDeviceAuthorization(ctx, start.UserCode, false, nil); err == nil {
+		t.Errorf("expected a decided code to be refused on the first replica")
+	}
+
+	token, err := second.PollDeviceAuthorization(ctx, start.DeviceCode)
+	if err != nil {
+		t.Fatalf("failed to redeem the code on another replica: %v", err)
+	}
+	if _, err := first.PollDeviceAuthorization(ctx, start.DeviceCode); !errors.Is(err, lib.ErrInvalidGrant) {
+		t.Errorf("poll error = %v, want the device code spent once redeemed", err)
+	}
+	active := first.GetDeviceToken(ctx, token.AccessToken)
+	if active == nil || active.ClientName != "bifrost-cli" || len(active.Scopes) != 1 || active.Scopes[0] != serviceaccounts.ScopeConfigRead {
+		t.Fatalf("token on the first replica = %+v, want the confirmed scopes", active)
+	}
+
+	refreshed, err := first.RefreshDeviceToken(ctx, token.RefreshToken)
+	if err != nil {
+		t.Fatalf("failed to refresh the token on the first replica: %v", err)
+	}
+	if _, err := second.RefreshDeviceToken(ctx, token.RefreshToken); !errors.Is(err, lib.ErrInvalidGrant) {
+		t.Errorf("refresh error = %v, want refresh tokens spent once used", err)
+	}
+	if second.GetDeviceToken(ctx, token.AccessToken) != nil || second.GetDeviceToken(ctx, refreshed.AccessToken) == nil {
+		t.Errorf("expected the refresh to rotate the token on every replica")
+	}
+	second.RevokeDeviceToken(ctx, refreshed.AccessToken)
+	if first.GetDeviceToken(ctx, refreshed.AccessToken) != nil {
+		t.Errorf("expected revoking the token on one replica to end it everywhere")
+	}
+}
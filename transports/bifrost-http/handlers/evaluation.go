@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/evaluation"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const evaluationPluginName = "bifrost-evaluation"
+
+// EvaluationHandler serves the recorded evaluation scores and their aggregation per model.
+type EvaluationHandler struct {
+	runner *evaluation.Runner
+	store  evaluation.Store
+	logger schemas.Logger
+}
+
+// NewEvaluationHandler creates a new evaluation handler.
+func NewEvaluationHandler(runner *evaluation.Runner, store evaluation.Store, logger schemas.Logger) *EvaluationHandler {
+	return &EvaluationHandler{
+		runner: runner,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the evaluation routes.
+func (h *EvaluationHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/evaluations/scores", lib.ChainMiddlewares(h.listScores, middlewares...))
+	r.GET("/api/evaluations/summary", lib.ChainMiddlewares(h.getSummary, middlewares...))
+}
+
+// evaluationFilter reads the score filter from the query parameters request_id, evaluator, provider, model,
+// start_time and end_time (RFC 3339).
+func evaluationFilter(ctx *fasthttp.RequestCtx) (evaluation.Filter, error) {
+	args := ctx.QueryArgs()
+	filter := evaluation.Filter{
+		RequestID: string(args.Peek("request_id")),
+		Evaluator: string(args.Peek("evaluator")),
+		Provider:  string(args.Peek("provider")),
+		Model:     string(args.Peek("model")),
+	}
+	if value := string(args.Peek("start_time")); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid start_time: %v", err)
+		}
+		filter.StartTime = &t
+	}
+	if value := string(args.Peek("end_time")); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid end_time: %v", err)
+		}
+		filter.EndTime = &t
+	}
+	return filter, nil
+}
+
+// listScores handles GET /api/evaluations/scores - List evaluation scores, newest first
+// Query parameters: request_id, evaluator, provider, model, start_time, end_time, limit (default 50) and offset.
+func (h *EvaluationHandler) listScores(ctx *fasthttp.RequestCtx) {
+	filter, err := evaluationFilter(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	args := ctx.QueryArgs()
+	limit, offset := 50, 0
+	if value := string(args.Peek("limit")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i <= 0 || i > maxListLimit {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit), h.logger)
+			return
+		}
+		limit = i
+	}
+	if value := string(args.Peek("offset")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			SendError(ctx, fasthttp.StatusBadRequest, "offset cannot be negative", h.logger)
+			return
+		}
+		offset = i
+	}
+
+	scores, total, err := h.store.List(ctx, filter, limit, offset)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to list evaluation scores: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"scores": scores,
+		"count":  len(scores),
+		"total":  total,
+	}, h.logger)
+}
+
+// getSummary handles GET /api/evaluations/summary - Get quality metrics per provider, model and evaluator
+// Query parameters: evaluator, provider, model, start_time and end_time.
+func (h *EvaluationHandler) getSummary(ctx *fasthttp.RequestCtx) {
+	filter, err := evaluationFilter(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	summaries, err := h.store.Summarize(ctx, filter)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to summarize evaluation scores: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"summary": summaries,
+		"dropped": h.runner.Dropped(),
+	}, h.logger)
+}
+
+// evaluationSampleContextKey holds the *evaluationSample of a request selected for evaluation.
+const evaluationSampleContextKey schemas.BifrostContextKey = "bifrost-evaluation-sample"
+
+// evaluationSample is the prompt of a sampled request and its completion, accumulated across stream chunks.
+type evaluationSample struct {
+	input  string
+	output strings.Builder
+}
+
+// evaluationPlugin captures the prompt of sampled chat and text completion requests in PreHook and submits
+// the completion for evaluation in PostHook, once the stream ends for streamed responses. It runs ahead of the
+// other plugins, so its PostHook sees the response as finally sent. Evaluation happens in the background and
+// never delays or changes the response. The requests of the evaluators themselves are not evaluated.
+type evaluationPlugin struct {
+	config *lib.Config
+}
+
+// GetName returns the name of the plugin
+func (p *evaluationPlugin) GetName() string {
+	return evaluationPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *evaluationPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook selects the requests to evaluate and captures their prompt
+func (p *evaluationPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	runner := p.config.Evaluations
+	if runner == nil || (req.ChatRequest == nil && req.TextCompletionRequest == nil) {
+		return req, nil, nil
+	}
+	if internal, _ := (*ctx).Value(lib.EvaluationRequestContextKey).(bool); internal {
+		return req, nil, nil
+	}
+	if !runner.Sampled() {
+		return req, nil, nil
+	}
+	*ctx = context.WithValue(*ctx, evaluationSampleContextKey, &evaluationSample{input: requestModerationText(req)})
+	return req, nil, nil
+}
+
+// PostHook submits the completion of a sampled request for evaluation
+func (p *evaluationPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	sample, ok := (*ctx).Value(evaluationSampleContextKey).(*evaluationSample)
+	if !ok || p.config.Evaluations == nil {
+		return result, bifrostErr, nil
+	}
+	// The content of zero data retention requests is not sent to evaluators
+	retentionClass, _ := (*ctx).Value(schemas.BifrostContextKeyRetentionClass).(string)
+	if bifrostErr != nil || result == nil || retentionClass == schemas.RetentionClassZeroDataRetention {
+		sample.output.Reset()
+		return result, bifrostErr, nil
+	}
+
+	if len(result.Choices) > 0 {
+		choice := result.Choices[0]
+		switch {
+		case choice.BifrostStreamResponseChoice != nil && choice.Delta != nil && choice.Delta.Content != nil:
+			sample.output.WriteString(*choice.Delta.Content)
+		case choice.BifrostNonStreamResponseChoice != nil && choice.Message != nil:
+			sample.output.WriteString(lib.ChatMessageText(*choice.Message))
+		case choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil:
+			sample.output.WriteString(*choice.Text)
+		}
+	}
+	requestType := result.ExtraFields.RequestType
+	if requestType == schemas.ChatCompletionStreamRequest || requestType == schemas.TextCompletionStreamRequest {
+		if isFinalChunk, _ := (*ctx).Value(schemas.BifrostContextKeyStreamEndIndicator).(bool); !isFinalChunk {
+			return result, bifrostErr, nil
+		}
+	}
+
+	// Scores are keyed like the request log entry, which uses the fallback request ID of fallback attempts
+	requestID, _ := (*ctx).Value(schemas.BifrostContextKeyRequestID).(string)
+	if fallbackRequestID, ok := (*ctx).Value(schemas.BifrostContextKeyFallbackRequestID).(string); ok && fallbackRequestID != "" {
+		requestID = fallbackRequestID
+	}
+	reference, _ := (*ctx).Value(lib.EvaluationReferenceContextKey).(string)
+	model := result.Model
+	if model == "" {
+		model = result.ExtraFields.ModelRequested
+	}
+	p.config.Evaluations.Submit(&evaluation.Sample{
+		RequestID: requestID,
+		Provider:  string(result.ExtraFields.Provider),
+		Model:     model,
+		Input:     sample.input,
+		Output:    sample.output.String(),
+		Reference: reference,
+	})
+	sample.output.Reset()
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *evaluationPlugin) Cleanup() error {
+	return nil
+}
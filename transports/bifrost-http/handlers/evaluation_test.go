@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/evaluation"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestEvaluationPlugin_SubmitsCompletions tests that streamed completions are submitted once complete with
+// their prompt and reference, that evaluator requests are skipped and that scores are aggregated per model
+func TestEvaluationPlugin_SubmitsCompletions(t *testing.T) {
+	config := &evaluation.Config{
+		Enabled:    true,
+		Evaluators: []evaluation.EvaluatorConfig{{Name: "polite", Type: evaluation.EvaluatorRegex, Patterns: []string{`(?i)please|thanks`}}},
+	}
+	store := evaluation.NewInMemoryStore()
+	runner, err := evaluation.NewRunner(config, store, evaluation.Backends{}, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	if err != nil {
+		t.Fatalf("failed to create runner: %v", err)
+	}
+	plugin := &evaluationPlugin{config: &lib.Config{Evaluations: runner, EvaluationScores: store}}
+
+	// Evaluator requests are not evaluated
+	ctx := context.WithValue(context.Background(), lib.EvaluationRequestContextKey, true)
+	plugin.PreHook(&ctx, moderationChatRequest("Grade this answer"))
+	if _, ok := ctx.Value(evaluationSampleContextKey).(*evaluationSample); ok {
+		t.Fatal("expected the evaluator request not to be sampled")
+	}
+
+	ctx = context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "req-1")
+	ctx = context.WithValue(ctx, lib.EvaluationReferenceContextKey, "Thanks for asking")
+	plugin.PreHook(&ctx, moderationChatRequest("Say hello"))
+	for i, content := range []string{"Hello, ", "thanks for asking!", ""} {
+		if i == 2 {
+			ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+		}
+		plugin.PostHook(&ctx, &schemas.BifrostResponse{
+			Model: "gpt-4o-mini",
+			Choices: []schemas.BifrostChatResponseChoice{{
+				BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{Content: &content}},
+			}},
+			ExtraFields: schemas.BifrostResponseExtraFields{Provider: schemas.OpenAI, RequestType: schemas.ChatCompletionStreamRequest},
+		}, nil)
+	}
+
+	ctx = context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "req-2")
+	plugin.PreHook(&ctx, moderationChatRequest("Say hello"))
+	plugin.PostHook(&ctx, &schemas.BifrostResponse{
+		Model: "gpt-4o-mini",
+		Choices: []schemas.BifrostChatResponseChoice{{
+			BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: bifrost.Ptr(sessionMessage(schemas.ChatMessageRoleAssistant, "Hello."))},
+		}},
+		ExtraFields: schemas.BifrostResponseExtraFields{Provider: schemas.OpenAI, RequestType: schemas.ChatCompletionRequest},
+	}, nil)
+
+	runner.Start()
+	runner.Stop(context.Background())
+
+	handler := NewEvaluationHandler(runner, store, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	req := &fasthttp.RequestCtx{}
+	req.Request.SetRequestURI("/api/evaluations/scores?request_id=req-1")
+	handler.listScores(req)
+	var scores struct {
+		Scores []evaluation.Score `json:"scores"`
+		Total  int64              `json:"total"`
+	}
+	if err := json.Unmarshal(req.Response.Body(), &scores); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if scores.Total != 1 || scores.Scores[0].Score != 1 || scores.Scores[0].Model != "gpt-4o-mini" || scores.Scores[0].Provider != "openai" {
+		t.Errorf("unexpected scores of the streamed completion %+v", scores)
+	}
+
+	req = &fasthttp.RequestCtx{}
+	req.Request.SetRequestURI("/api/evaluations/summary?evaluator=polite")
+	handler.getSummary(req)
+	var summary struct {
+		Summary []evaluation.Summary `json:"summary"`
+	}
+	if err := json.Unmarshal(req.Response.Body(), &summary); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(summary.Summary) != 1 || summary.Summary[0].Count != 2 || summary.Summary[0].PassRate != 0.5 {
+		t.Errorf("unexpected summary %+v", summary.Summary)
+	}
+
+	req = &fasthttp.RequestCtx{}
+	req.Request.SetRequestURI("/api/evaluations/summary?start_time=yesterday")
+	handler.getSummary(req)
+	if req.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("expected an invalid start_time to be rejected, got %d", req.Response.StatusCode())
+	}
+}
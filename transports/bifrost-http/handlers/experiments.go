@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// experimentsPluginName is the name of the internal plugin reporting usage and stream outcomes of experiment requests.
+const experimentsPluginName = "experiments"
+
+// ExperimentsHandler manages the A/B experiments and serves their results.
+type ExperimentsHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+	mu     sync.Mutex // Serializes read-modify-write updates of the experiments
+}
+
+// ExperimentResultsResponse is the response of GET /api/experiments/{name}/results.
+type ExperimentResultsResponse struct {
+	Experiment string                         `json:"experiment"`
+	Variants   []lib.ExperimentVariantResults `json:"variants"`
+}
+
+// ExperimentFeedbackRequest is the body of POST /api/experiments/{name}/feedback.
+type ExperimentFeedbackRequest struct {
+	Variant string  `json:"variant"` // From the x-bf-experiment-variant response header
+	Score   float64 `json:"score"`
+}
+
+// NewExperimentsHandler creates a new experiments handler.
+func NewExperimentsHandler(store *lib.Config, logger schemas.Logger) *ExperimentsHandler {
+	return &ExperimentsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the experiment routes.
+func (h *ExperimentsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/experiments", lib.ChainMiddlewares(h.listExperiments, middlewares...))
+	r.POST("/api/experiments", lib.ChainMiddlewares(h.createExperiment, middlewares...))
+	r.GET("/api/experiments/{name}", lib.ChainMiddlewares(h.getExperiment, middlewares...))
+	r.PUT("/api/experiments/{name}", lib.ChainMiddlewares(h.updateExperiment, middlewares...))
+	r.DELETE("/api/experiments/{name}", lib.ChainMiddlewares(h.deleteExperiment, middlewares...))
+	r.GET("/api/experiments/{name}/results", lib.ChainMiddlewares(h.getResults, middlewares...))
+	r.DELETE("/api/experiments/{name}/results", lib.ChainMiddlewares(h.resetResults, middlewares...))
+	r.POST("/api/experiments/{name}/feedback", lib.ChainMiddlewares(h.addFeedback, middlewares...))
+}
+
+// listExperiments handles GET /api/experiments - List the experiments
+func (h *ExperimentsHandler) listExperiments(ctx *fasthttp.RequestCtx) {
+	experiments := h.store.GetExperiments()
+	if experiments == nil {
+		experiments = []lib.Experiment{}
+	}
+	SendJSON(ctx, map[string]any{
+		"experiments": experiments,
+		"count":       len(experiments),
+	}, h.logger)
+}
+
+// createExperiment handles POST /api/experiments - Create an experiment
+func (h *ExperimentsHandler) createExperiment(ctx *fasthttp.RequestCtx) {
+	var experiment lib.Experiment
+	if err := json.Unmarshal(ctx.PostBody(), &experiment); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.store.GetExperiment(experiment.Name); ok {
+		SendError(ctx, fasthttp.StatusConflict, fmt.Sprintf("Experiment %s already exists", experiment.Name), h.logger)
+		return
+	}
+	experiments := append(append([]lib.Experiment{}, h.store.GetExperiments()...), experiment)
+	h.save(ctx, experiments, &experiment)
+}
+
+// getExperiment handles GET /api/experiments/{name} - Get an experiment
+func (h *ExperimentsHandler) getExperiment(ctx *fasthttp.RequestCtx) {
+	name := ctx.UserValue("name").(string)
+	experiment, ok := h.store.GetExperiment(name)
+	if !ok {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Experiment %s not found", name), h.logger)
+		return
+	}
+	SendJSON(ctx, experiment, h.logger)
+}
+
+// updateExperiment handles PUT /api/experiments/{name} - Replace an experiment
+// Changing the weights reassigns units, so results are best reset after reweighting.
+func (h *ExperimentsHandler) updateExperiment(ctx *fasthttp.RequestCtx) {
+	name := ctx.UserValue("name").(string)
+	var experiment lib.Experiment
+	if err := json.Unmarshal(ctx.PostBody(), &experiment); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if experiment.Name == "" {
+		experiment.Name = name
+	}
+	if experiment.Name != name {
+		SendError(ctx, fasthttp.StatusBadRequest, "Experiment name cannot be changed", h.logger)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	experiments := append([]lib.Experiment{}, h.store.GetExperiments()...)
+	for i := range experiments {
+		if experiments[i].Name == name {
+			experiments[i] = experiment
+			h.save(ctx, experiments, &experiment)
+			return
+		}
+	}
+	SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Experiment %s not found", name), h.logger)
+}
+
+// deleteExperiment handles DELETE /api/experiments/{name} - Delete an experiment and its results
+func (h *ExperimentsHandler) deleteExperiment(ctx *fasthttp.RequestCtx) {
+	name := ctx.UserValue("name").(string)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var experiments []lib.Experiment
+	for _, experiment := range h.store.GetExperiments() {
+		if experiment.Name != name {
+			experiments = append(experiments, experiment)
+		}
+	}
+	if len(experiments) == len(h.store.GetExperiments()) {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Experiment %s not found", name), h.logger)
+		return
+	}
+	if experiments == nil {
+		experiments = []lib.Experiment{}
+	}
+	if err := h.store.UpdateExperiments(ctx, experiments); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to delete experiment: %v", err), h.logger)
+		return
+	}
+	h.store.ExperimentResults.Reset(name)
+	SendJSON(ctx, map[string]any{
+		"status":  "success",
+		"message": fmt.Sprintf("Experiment %s deleted", name),
+	}, h.logger)
+}
+
+// save validates and activates experiments and responds with experiment.
+func (h *ExperimentsHandler) save(ctx *fasthttp.RequestCtx, experiments []lib.Experiment, experiment *lib.Experiment) {
+	if err := lib.ValidateExperiments(experiments); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid experiment: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateExperiments(ctx, experiments); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to save experiment: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, experiment, h.logger)
+}
+
+// getResults handles GET /api/experiments/{name}/results - Get the aggregated outcomes per variant
+func (h *ExperimentsHandler) getResults(ctx *fasthttp.RequestCtx) {
+	name := ctx.UserValue("name").(string)
+	experiment, ok := h.store.GetExperiment(name)
+	if !ok {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Experiment %s not found", name), h.logger)
+		return
+	}
+	variants := make([]string, len(experiment.Variants))
+	for i, variant := range experiment.Variants {
+		variants[i] = variant.Name
+	}
+	SendJSON(ctx, ExperimentResultsResponse{
+		Experiment: name,
+		Variants:   h.store.ExperimentResults.Get(name, variants),
+	}, h.logger)
+}
+
+// resetResults handles DELETE /api/experiments/{name}/results - Reset the results of an experiment
+func (h *ExperimentsHandler) resetResults(ctx *fasthttp.RequestCtx) {
+	name := ctx.UserValue("name").(string)
+	if _, ok := h.store.GetExperiment(name); !ok {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Experiment %s not found", name), h.logger)
+		return
+	}
+	h.store.ExperimentResults.Reset(name)
+	SendJSON(ctx, map[string]any{
+		"status":  "success",
+		"message": fmt.Sprintf("Results of experiment %s reset", name),
+	}, h.logger)
+}
+
+// addFeedback handles POST /api/experiments/{name}/feedback - Report a score for a response of a variant
+func (h *ExperimentsHandler) addFeedback(ctx *fasthttp.RequestCtx) {
+	name := ctx.UserValue("name").(string)
+	var req ExperimentFeedbackRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	experiment, ok := h.store.GetExperiment(name)
+	if !ok {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Experiment %s not found", name), h.logger)
+		return
+	}
+	for _, variant := range experiment.Variants {
+		if variant.Name == req.Variant {
+			h.store.ExperimentResults.RecordFeedback(name, req.Variant, req.Score)
+			SendJSON(ctx, map[string]any{"status": "success"}, h.logger)
+			return
+		}
+	}
+	SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Experiment %s has no variant %q", name, req.Variant), h.logger)
+}
+
+// ExperimentMiddleware assigns inference requests opting into an experiment with the x-bf-experiment header
+// to a variant and applies its overrides to the JSON body. It runs before TransformationMiddleware, so
+// transformation rules also constrain the variants. Unknown and disabled experiments leave requests unchanged.
+// The outcome of non-streamed requests is recorded here; streams are recorded by experimentsPlugin once they end.
+func ExperimentMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			name := string(ctx.Request.Header.Peek(lib.ExperimentHeader))
+			if name == "" || !ctx.IsPost() || strings.HasPrefix(string(ctx.Path()), "/api/") {
+				next(ctx)
+				return
+			}
+			experiment, ok := config.GetExperiment(name)
+			if !ok || experiment.Disabled {
+				next(ctx)
+				return
+			}
+			var body map[string]any
+			if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil || body == nil {
+				next(ctx)
+				return
+			}
+
+			variant := experiment.Assign(experimentUnit(ctx))
+			variant.Apply(body)
+			updatedBody, err := json.Marshal(body)
+			if err != nil {
+				SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to apply experiment variant: %v", err), logger)
+				return
+			}
+			ctx.Request.SetBody(updatedBody)
+			ctx.Response.Header.Set(lib.ExperimentHeader, experiment.Name)
+			ctx.Response.Header.Set(lib.ExperimentVariantHeader, variant.Name)
+
+			assignment := &lib.ExperimentAssignment{Experiment: experiment.Name, Variant: variant.Name, Start: time.Now()}
+			ctx.SetUserValue(lib.ExperimentAssignmentContextKey, assignment)
+			next(ctx)
+			if !ctx.Response.IsBodyStream() {
+				assignment.Finish(config.ExperimentResults, ctx.Response.StatusCode() >= fasthttp.StatusBadRequest)
+			}
+		}
+	}
+}
+
+// experimentUnit returns the bucketing unit of a request: the x-bf-experiment-unit header, else the
+// x-bf-user header, else the virtual key, else the client IP.
+func experimentUnit(ctx *fasthttp.RequestCtx) string {
+	for _, header := range []string{lib.ExperimentUnitHeader, "x-bf-user", "x-bf-vk"} {
+		if value := string(ctx.Request.Header.Peek(header)); value != "" {
+			return value
+		}
+	}
+	return ctx.RemoteIP().String()
+}
+
+// experimentsPlugin reports the usage and cost of experiment requests to their assignment, and records
+// the outcome of streams when they end. Fallback attempts overwrite the usage of the primary attempt.
+type experimentsPlugin struct {
+	config *lib.Config
+}
+
+// GetName returns the name of the plugin
+func (p *experimentsPlugin) GetName() string {
+	return experimentsPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *experimentsPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook is not used for this plugin
+func (p *experimentsPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	return req, nil, nil
+}
+
+// PostHook records the usage of the request, and the outcome of streams on their final chunk
+func (p *experimentsPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	assignment, ok := (*ctx).Value(lib.ExperimentAssignmentContextKey).(*lib.ExperimentAssignment)
+	if !ok {
+		return result, bifrostErr, nil
+	}
+	if result != nil && result.Usage != nil {
+		cost := 0.0
+		if p.config.PricingManager != nil {
+			cost = p.config.PricingManager.CalculateCost(result)
+		}
+		assignment.SetUsage(result.Usage, cost)
+	}
+	if isFinalChunk, _ := (*ctx).Value(schemas.BifrostContextKeyStreamEndIndicator).(bool); isFinalChunk {
+		assignment.Finish(p.config.ExperimentResults, bifrostErr != nil)
+	}
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *experimentsPlugin) Cleanup() error {
+	return nil
+}
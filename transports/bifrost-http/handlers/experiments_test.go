@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// experimentTestConfig returns a config with a 50/50 experiment between a control and a cheaper model with a new prompt
+func experimentTestConfig(t *testing.T) *lib.Config {
+	t.Helper()
+	config := &lib.Config{ExperimentResults: lib.NewExperimentResults()}
+	err := config.UpdateExperiments(context.Background(), []lib.Experiment{{
+		Name: "cheaper-model",
+		Variants: []lib.ExperimentVariant{
+			{Name: "control", Weight: 50},
+			{
+				Name:           "mini",
+				Weight:         50,
+				Model:          "openai/gpt-4o-mini",
+				SystemPrompt:   "Be brief.",
+				PromptTemplate: "Question: {{input}}",
+				Params:         map[string]any{"temperature": 0.2},
+			},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("failed to set experiments: %v", err)
+	}
+	return config
+}
+
+// runExperimentRequest sends a chat request for unit through the experiment middleware and returns the body the
+// next handler received
+func runExperimentRequest(t *testing.T, config *lib.Config, unit string, status int) (*fasthttp.RequestCtx, map[string]any) {
+	t.Helper()
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/v1/chat/completions")
+	ctx.Request.Header.Set(lib.ExperimentHeader, "cheaper-model")
+	ctx.Request.Header.Set(lib.ExperimentUnitHeader, unit)
+	ctx.Request.SetBody([]byte(`{"model":"openai/gpt-4o","messages":[{"role":"system","content":"You are verbose."},{"role":"user","content":"Why is the sky blue?"}]}`))
+
+	var body map[string]any
+	ExperimentMiddleware(config)(func(ctx *fasthttp.RequestCtx) {
+		if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+			t.Fatalf("invalid body: %v", err)
+		}
+		ctx.SetStatusCode(status)
+	})(ctx)
+	return ctx, body
+}
+
+// TestExperimentMiddleware_AssignsVariants tests that units are bucketed deterministically in proportion to the
+// weights, that variants rewrite the request and that outcomes are aggregated per variant
+func TestExperimentMiddleware_AssignsVariants(t *testing.T) {
+	config := experimentTestConfig(t)
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		unit := fmt.Sprintf("user-%d", i)
+		ctx, _ := runExperimentRequest(t, config, unit, fasthttp.StatusOK)
+		variant := string(ctx.Response.Header.Peek(lib.ExperimentVariantHeader))
+		again, _ := runExperimentRequest(t, config, unit, fasthttp.StatusInternalServerError)
+		if got := string(again.Response.Header.Peek(lib.ExperimentVariantHeader)); got != variant {
+			t.Fatalf("unit %s was assigned %s then %s", unit, variant, got)
+		}
+		counts[variant]++
+	}
+	if counts["control"] < 400 || counts["mini"] < 400 {
+		t.Errorf("expected a roughly even split, got %v", counts)
+	}
+
+	// Find a unit of each variant and check the rewritten bodies
+	for i := 0; ; i++ {
+		ctx, body := runExperimentRequest(t, config, fmt.Sprintf("probe-%d", i), fasthttp.StatusOK)
+		if string(ctx.Response.Header.Peek(lib.ExperimentVariantHeader)) != "mini" {
+			if body["model"] != "openai/gpt-4o" {
+				t.Errorf("expected the control to keep the model, got %v", body["model"])
+			}
+			continue
+		}
+		messages := body["messages"].([]any)
+		if body["model"] != "openai/gpt-4o-mini" || body["temperature"] != 0.2 || len(messages) != 2 {
+			t.Fatalf("unexpected variant body %v", body)
+		}
+		if content := messages[0].(map[string]any)["content"]; content != "Be brief." {
+			t.Errorf("expected the system prompt to be replaced, got %v", content)
+		}
+		if content := messages[1].(map[string]any)["content"]; content != "Question: Why is the sky blue?" {
+			t.Errorf("expected the prompt template to wrap the user message, got %v", content)
+		}
+		break
+	}
+
+	config.ExperimentResults.Reset("cheaper-model")
+	runExperimentRequest(t, config, "user-1", fasthttp.StatusOK)
+	runExperimentRequest(t, config, "user-1", fasthttp.StatusBadGateway)
+	config.ExperimentResults.RecordFeedback("cheaper-model", "control", 1)
+	config.ExperimentResults.RecordFeedback("cheaper-model", "control", 0)
+	results := config.ExperimentResults.Get("cheaper-model", []string{"control", "mini"})
+	requests := results[0].Requests + results[1].Requests
+	if requests != 2 || results[0].Errors+results[1].Errors != 1 {
+		t.Errorf("expected 2 requests with 1 error, got %+v", results)
+	}
+	if results[0].FeedbackCount != 2 || results[0].AvgScore != 0.5 {
+		t.Errorf("expected 2 feedback scores averaging 0.5, got %+v", results[0])
+	}
+}
+
+// TestValidateExperiments tests that malformed experiments are rejected
+func TestValidateExperiments(t *testing.T) {
+	tests := []struct {
+		name       string
+		experiment lib.Experiment
+	}{
+		{"single variant", lib.Experiment{Name: "e", Variants: []lib.ExperimentVariant{{Name: "a", Weight: 100}}}},
+		{"weights not 100", lib.Experiment{Name: "e", Variants: []lib.ExperimentVariant{{Name: "a", Weight: 50}, {Name: "b", Weight: 40}}}},
+		{"duplicate variant", lib.Experiment{Name: "e", Variants: []lib.ExperimentVariant{{Name: "a", Weight: 50}, {Name: "a", Weight: 50}}}},
+		{"template without input", lib.Experiment{Name: "e", Variants: []lib.ExperimentVariant{{Name: "a", Weight: 50}, {Name: "b", Weight: 50, PromptTemplate: "Be brief."}}}},
+	}
+	for _, tt := range tests {
+		if err := lib.ValidateExperiments([]lib.Experiment{tt.experiment}); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/finetuning"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// FineTuningHandler proxies fine-tuning jobs to providers and lists the jobs tracked by the gateway.
+type FineTuningHandler struct {
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// FineTuningJobsResponse is the page of tracked jobs shown in the UI.
+type FineTuningJobsResponse struct {
+	Enabled bool             `json:"enabled"`
+	Jobs    []finetuning.Job `json:"jobs"`
+	Total   int64            `json:"total"`
+}
+
+// NewFineTuningHandler creates a new fine-tuning handler.
+func NewFineTuningHandler(config *lib.Config, logger schemas.Logger) *FineTuningHandler {
+	return &FineTuningHandler{
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the fine-tuning routes.
+func (h *FineTuningHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST("/v1/fine_tuning/jobs", lib.ChainMiddlewares(h.createJob, middlewares...))
+	r.GET("/v1/fine_tuning/jobs", lib.ChainMiddlewares(h.listJobs, middlewares...))
+	r.GET("/v1/fine_tuning/jobs/{id}", lib.ChainMiddlewares(h.getJob, middlewares...))
+	r.POST("/v1/fine_tuning/jobs/{id}/cancel", lib.ChainMiddlewares(h.cancelJob, middlewares...))
+	r.GET("/v1/fine_tuning/jobs/{id}/events", lib.ChainMiddlewares(h.getJobEvents, middlewares...))
+	r.GET("/api/fine-tuning/jobs", lib.ChainMiddlewares(h.getTrackedJobs, middlewares...))
+}
+
+// fineTuner returns the fine-tuner, answering 404 when fine-tuning is off
+func (h *FineTuningHandler) fineTuner(ctx *fasthttp.RequestCtx) *lib.FineTuner {
+	if h.config.FineTuning == nil {
+		SendError(ctx, fasthttp.StatusNotFound, "fine-tuning is not enabled", h.logger)
+	}
+	return h.config.FineTuning
+}
+
+// requestVirtualKey returns the virtual key of the request
+func requestVirtualKey(ctx *fasthttp.RequestCtx) string {
+	return string(ctx.Request.Header.Peek("x-bf-vk"))
+}
+
+// createJob handles POST /v1/fine_tuning/jobs - Create a fine-tuning job on the provider prefixing the model
+func (h *FineTuningHandler) createJob(ctx *fasthttp.RequestCtx) {
+	tuner := h.fineTuner(ctx)
+	if tuner == nil {
+		return
+	}
+	var body map[string]any
+	if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	model, _ := body["model"].(string)
+	if model == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "model is required", h.logger)
+		return
+	}
+	provider, model := schemas.ParseModelString(model, schemas.OpenAI)
+	body["model"] = model
+	// Fallbacks added by the governance transport interceptor do not apply to fine-tuning
+	delete(body, "fallbacks")
+
+	bifrostCtx := lib.ConvertToBifrostContext(ctx, false)
+	vk := requestVirtualKey(ctx)
+	if vk == "" && tuner.RequiresVirtualKey() {
+		SendError(ctx, fasthttp.StatusBadRequest, "a virtual key is required to create fine-tuning jobs", h.logger)
+		return
+	}
+	// The virtual key must be allowed to use the provider and model, and be within its rate limits and budget
+	if governancePlugin, _ := FindPluginByName[*governance.GovernancePlugin](h.config.GetLoadedPlugins(), governance.PluginName); governancePlugin != nil {
+		_, shortCircuit, err := governancePlugin.PreHook(bifrostCtx, &schemas.BifrostRequest{Provider: provider, Model: model})
+		if err != nil {
+			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("governance check failed: %v", err), h.logger)
+			return
+		}
+		if shortCircuit != nil && shortCircuit.Error != nil {
+			SendBifrostError(ctx, shortCircuit.Error, h.logger)
+			return
+		}
+	}
+
+	trainingFile, _ := body["training_file"].(string)
+	validationFile, _ := body["validation_file"].(string)
+	if trainingFile == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "training_file is required", h.logger)
+		return
+	}
+	if err := tuner.CheckCreate(context.Background(), vk, model, []string{trainingFile, validationFile}); err != nil {
+		h.sendFailure(ctx, err)
+		return
+	}
+	response, err := tuner.Create(context.Background(), provider, vk, body)
+	h.sendProviderResponse(ctx, response, err)
+}
+
+// listJobs handles GET /v1/fine_tuning/jobs - List the jobs of a provider, limited to the tracked jobs of the virtual key
+func (h *FineTuningHandler) listJobs(ctx *fasthttp.RequestCtx) {
+	tuner := h.fineTuner(ctx)
+	if tuner == nil {
+		return
+	}
+	args := ctx.QueryArgs()
+	provider := schemas.ModelProvider(args.Peek("provider"))
+	if provider == "" {
+		provider = schemas.OpenAI
+	}
+	query := url.Values{}
+	for _, name := range []string{"limit", "after"} {
+		if value := string(args.Peek(name)); value != "" {
+			query.Set(name, value)
+		}
+	}
+	response, err := tuner.List(context.Background(), provider, query)
+	vk := requestVirtualKey(ctx)
+	if err != nil || response.StatusCode >= 300 {
+		h.sendProviderResponse(ctx, response, err)
+		return
+	}
+
+	// Jobs of other virtual keys and jobs created outside the gateway are hidden
+	var list map[string]any
+	if err := json.Unmarshal(response.Body, &list); err != nil {
+		SendError(ctx, fasthttp.StatusBadGateway, fmt.Sprintf("invalid fine-tuning jobs from %s: %v", provider, err), h.logger)
+		return
+	}
+	data, _ := list["data"].([]any)
+	visible := []any{}
+	for _, item := range data {
+		object, _ := item.(map[string]any)
+		id, _ := object["id"].(string)
+		if _, err := tuner.Job(context.Background(), id, vk); err == nil {
+			visible = append(visible, item)
+		}
+	}
+	list["data"] = visible
+	SendJSON(ctx, list, h.logger)
+}
+
+// trackedJob returns the tracked job of the id route parameter, answering 404 for other jobs
+func (h *FineTuningHandler) trackedJob(ctx *fasthttp.RequestCtx, tuner *lib.FineTuner) *finetuning.Job {
+	id, _ := ctx.UserValue("id").(string)
+	job, err := tuner.Job(context.Background(), id, requestVirtualKey(ctx))
+	if err != nil {
+		if errors.Is(err, finetuning.ErrNotFound) {
+			SendError(ctx, fasthttp.StatusNotFound, "fine-tuning job not found: only jobs created through Bifrost can be managed", h.logger)
+			return nil
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get fine-tuning job: %v", err), h.logger)
+		return nil
+	}
+	return job
+}
+
+// getJob handles GET /v1/fine_tuning/jobs/{id} - Get a job from its provider
+func (h *FineTuningHandler) getJob(ctx *fasthttp.RequestCtx) {
+	tuner := h.fineTuner(ctx)
+	if tuner == nil {
+		return
+	}
+	job := h.trackedJob(ctx, tuner)
+	if job == nil {
+		return
+	}
+	response, err := tuner.Retrieve(context.Background(), job)
+	h.sendProviderResponse(ctx, response, err)
+}
+
+// cancelJob handles POST /v1/fine_tuning/jobs/{id}/cancel - Cancel a job
+func (h *FineTuningHandler) cancelJob(ctx *fasthttp.RequestCtx) {
+	tuner := h.fineTuner(ctx)
+	if tuner == nil {
+		return
+	}
+	job := h.trackedJob(ctx, tuner)
+	if job == nil {
+		return
+	}
+	response, err := tuner.Cancel(context.Background(), job)
+	h.sendProviderResponse(ctx, response, err)
+}
+
+// getJobEvents handles GET /v1/fine_tuning/jobs/{id}/events - Get the progress events of a job
+func (h *FineTuningHandler) getJobEvents(ctx *fasthttp.RequestCtx) {
+	tuner := h.fineTuner(ctx)
+	if tuner == nil {
+		return
+	}
+	job := h.trackedJob(ctx, tuner)
+	if job == nil {
+		return
+	}
+	query := url.Values{}
+	for _, name := range []string{"limit", "after"} {
+		if value := string(ctx.QueryArgs().Peek(name)); value != "" {
+			query.Set(name, value)
+		}
+	}
+	response, err := tuner.Events(context.Background(), job, query)
+	h.sendProviderResponse(ctx, response, err)
+}
+
+// getTrackedJobs handles GET /api/fine-tuning/jobs - List the jobs created through Bifrost with their latest status
+func (h *FineTuningHandler) getTrackedJobs(ctx *fasthttp.RequestCtx) {
+	tuner := h.config.FineTuning
+	if tuner == nil {
+		SendJSON(ctx, FineTuningJobsResponse{Jobs: []finetuning.Job{}}, h.logger)
+		return
+	}
+	args := ctx.QueryArgs()
+	filter := finetuning.Filter{
+		Provider:   string(args.Peek("provider")),
+		VirtualKey: string(args.Peek("virtual_key")),
+		Status:     string(args.Peek("status")),
+	}
+	limit, offset := 50, 0
+	if value := string(args.Peek("limit")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i <= 0 || i > maxListLimit {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit), h.logger)
+			return
+		}
+		limit = i
+	}
+	if value := string(args.Peek("offset")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			SendError(ctx, fasthttp.StatusBadRequest, "offset cannot be negative", h.logger)
+			return
+		}
+		offset = i
+	}
+
+	jobs, total, err := tuner.Jobs(context.Background(), filter, limit, offset)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to list fine-tuning jobs: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, FineTuningJobsResponse{Enabled: true, Jobs: jobs, Total: total}, h.logger)
+}
+
+// sendProviderResponse passes the response of the provider through, or reports why there is none
+func (h *FineTuningHandler) sendProviderResponse(ctx *fasthttp.RequestCtx, response *lib.ProviderResponse, err error) {
+	if err != nil {
+		h.sendFailure(ctx, err)
+		return
+	}
+	ctx.SetStatusCode(response.StatusCode)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(response.Body)
+}
+
+// sendFailure answers with the status of requests rejected by the gateway, and 502 when the provider failed
+func (h *FineTuningHandler) sendFailure(ctx *fasthttp.RequestCtx, err error) {
+	if fineTuningErr, ok := lib.IsFineTuningError(err); ok {
+		SendError(ctx, fineTuningErr.StatusCode, fineTuningErr.Message, h.logger)
+		return
+	}
+	SendError(ctx, fasthttp.StatusBadGateway, err.Error(), h.logger)
+}
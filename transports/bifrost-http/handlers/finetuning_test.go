@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/finetuning"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// newFineTuningTestHandler creates a fine-tuning handler whose OpenAI key points to a stub of the fine-tuning API.
+// The stub records the created jobs, so they can be retrieved, listed and cancelled.
+func newFineTuningTestHandler(t *testing.T, config lib.FineTuningConfig) (*FineTuningHandler, *[]string) {
+	var requests []string
+	jobs := map[string]map[string]any{}
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		id := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/cancel"), "/v1/fine_tuning/jobs/")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/fine_tuning/jobs":
+			var body map[string]any
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &body)
+			if _, ok := body["fallbacks"]; ok {
+				t.Errorf("fallbacks should not be sent to the provider: %s", data)
+			}
+			id = "ftjob-" + string(rune('a'+len(jobs)))
+			jobs[id] = map[string]any{"id": id, "object": "fine_tuning.job", "model": body["model"], "status": "validating_files",
+				"training_file": body["training_file"], "created_at": 1700000000 + len(jobs)}
+			json.NewEncoder(w).Encode(jobs[id])
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/fine_tuning/jobs":
+			data := []any{map[string]any{"id": "ftjob-outside", "status": "succeeded"}}
+			for _, job := range jobs {
+				data = append(data, job)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"object": "list", "data": data, "has_more": false})
+		case jobs[id] != nil && r.Method == http.MethodPost:
+			jobs[id]["status"] = "cancelled"
+			json.NewEncoder(w).Encode(jobs[id])
+		case jobs[id] != nil:
+			json.NewEncoder(w).Encode(jobs[id])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"not found"}}`))
+		}
+	}))
+	t.Cleanup(stub.Close)
+
+	config.Enabled = true
+	gateway := &lib.Config{
+		Providers: map[schemas.ModelProvider]configstore.ProviderConfig{
+			schemas.OpenAI: {
+				Keys:          []schemas.Key{{ID: "primary", Value: "sk-test", Weight: 1}},
+				NetworkConfig: &schemas.NetworkConfig{BaseURL: stub.URL},
+			},
+		},
+	}
+	gateway.FineTuning = lib.NewFineTuner(config, finetuning.NewInMemoryStore(), gateway)
+	return NewFineTuningHandler(gateway, bifrost.NewDefaultLogger(schemas.LogLevelError)), &requests
+}
+
+// fineTuningRequest builds a request with the virtual key vk, the id route parameter and body
+func fineTuningRequest(method, id, vk, body string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	if vk != "" {
+		ctx.Request.Header.Set("x-bf-vk", vk)
+	}
+	if id != "" {
+		ctx.SetUserValue("id", id)
+	}
+	ctx.Request.SetBodyString(body)
+	return ctx
+}
+
+// TestFineTuning_JobsAreTrackedAndScopedToTheirVirtualKey tests that created jobs are tracked and that virtual
+// keys only see and manage their own jobs
+func TestFineTuning_JobsAreTrackedAndScopedToTheirVirtualKey(t *testing.T) {
+	h, requests := newFineTuningTestHandler(t, lib.FineTuningConfig{})
+
+	ctx := fineTuningRequest(fasthttp.MethodPost, "", "vk-a", `{"model":"openai/gpt-4o-mini-2024-07-18","training_file":"file-train","fallbacks":["azure/gpt-4o-mini"]}`)
+	h.createJob(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var created struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(ctx.Response.Body(), &created); err != nil || created.ID == "" || created.Model != "gpt-4o-mini-2024-07-18" {
+		t.Fatalf("unexpected created job %s", ctx.Response.Body())
+	}
+
+	tracked := fineTuningRequest(fasthttp.MethodGet, "", "", "")
+	h.getTrackedJobs(tracked)
+	var page FineTuningJobsResponse
+	if err := json.Unmarshal(tracked.Response.Body(), &page); err != nil || !page.Enabled || page.Total != 1 {
+		t.Fatalf("unexpected tracked jobs %s", tracked.Response.Body())
+	}
+	if job := page.Jobs[0]; job.ID != created.ID || job.VirtualKey != "vk-a" || job.KeyID != "primary" || job.Status != finetuning.StatusValidatingFiles || job.TrainingFile != "file-train" {
+		t.Errorf("unexpected tracked job %+v", job)
+	}
+
+	// Other virtual keys and callers without one neither see nor manage the job
+	for _, vk := range []string{"vk-b", ""} {
+		for _, ctx := range []*fasthttp.RequestCtx{fineTuningRequest(fasthttp.MethodGet, created.ID, vk, ""), fineTuningRequest(fasthttp.MethodPost, created.ID, vk, "")} {
+			if ctx.IsGet() {
+				h.getJob(ctx)
+			} else {
+				h.cancelJob(ctx)
+			}
+			if ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+				t.Errorf("expected 404 for virtual key %q, got %d", vk, ctx.Response.StatusCode())
+			}
+		}
+	}
+	// Jobs created outside the gateway cannot be managed
+	outside := fineTuningRequest(fasthttp.MethodGet, "ftjob-outside", "", "")
+	h.getJob(outside)
+	if outside.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("expected 404 for a job created outside the gateway, got %d", outside.Response.StatusCode())
+	}
+
+	list := fineTuningRequest(fasthttp.MethodGet, "", "vk-a", "")
+	h.listJobs(list)
+	var listed struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(list.Response.Body(), &listed); err != nil || len(listed.Data) != 1 || listed.Data[0].ID != created.ID {
+		t.Errorf("expected the virtual key to list only its job, got %s", list.Response.Body())
+	}
+
+	anonymous := fineTuningRequest(fasthttp.MethodGet, "", "", "")
+	h.listJobs(anonymous)
+	if err := json.Unmarshal(anonymous.Response.Body(), &listed); err != nil || len(listed.Data) != 0 {
+		t.Errorf("expected callers without a virtual key to list no job, got %s", anonymous.Response.Body())
+	}
+
+	cancel := fineTuningRequest(fasthttp.MethodPost, created.ID, "vk-a", "")
+	h.cancelJob(cancel)
+	if cancel.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", cancel.Response.StatusCode(), cancel.Response.Body())
+	}
+	job, err := h.config.FineTuning.Job(context.Background(), created.ID, "vk-a")
+	if err != nil || job.Status != finetuning.StatusCancelled {
+		t.Errorf("expected the tracked job to be cancelled, got %+v, %v", job, err)
+	}
+	if got := strings.Join(*requests, ", "); got != "POST /v1/fine_tuning/jobs, GET /v1/fine_tuning/jobs, GET /v1/fine_tuning/jobs, POST /v1/fine_tuning/jobs/"+created.ID+"/cancel" {
+		t.Errorf("unexpected provider requests %s", got)
+	}
+}
+
+// TestFineTuning_CreationPolicies tests that jobs are rejected before reaching the provider when the model,
+// the datasets or the virtual key are not allowed
+func TestFineTuning_CreationPolicies(t *testing.T) {
+	config := lib.FineTuningConfig{
+		AllowedModels:              []string{"gpt-4o-mini*"},
+		AllowedDatasets:            []string{"file-approved-*"},
+		RequireVirtualKey:          true,
+		MaxActiveJobsPerVirtualKey: 1,
+	}
+	tests := []struct {
+		name   string
+		vk     string
+		body   string
+		status int
+	}{
+		{name: "no virtual key", body: `{"model":"gpt-4o-mini","training_file":"file-approved-1"}`, status: fasthttp.StatusBadRequest},
+		{name: "model not allowed", vk: "vk-a", body: `{"model":"gpt-4o","training_file":"file-approved-1"}`, status: fasthttp.StatusForbidden},
+		{name: "training file not allowed", vk: "vk-a", body: `{"model":"gpt-4o-mini","training_file":"file-other"}`, status: fasthttp.StatusForbidden},
+		{name: "validation file not allowed", vk: "vk-a", body: `{"model":"gpt-4o-mini","training_file":"file-approved-1","validation_file":"file-other"}`, status: fasthttp.StatusForbidden},
+		{name: "unsupported provider", vk: "vk-a", body: `{"model":"anthropic/gpt-4o-mini","training_file":"file-approved-1"}`, status: fasthttp.StatusBadRequest},
+		{name: "no training file", vk: "vk-a", body: `{"model":"gpt-4o-mini"}`, status: fasthttp.StatusBadRequest},
+		{name: "allowed", vk: "vk-a", body: `{"model":"gpt-4o-mini","training_file":"file-approved-1"}`, status: fasthttp.StatusOK},
+		{name: "too many running jobs", vk: "vk-a", body: `{"model":"gpt-4o-mini","training_file":"file-approved-2"}`, status: fasthttp.StatusTooManyRequests},
+		{name: "another virtual key", vk: "vk-b", body: `{"model":"gpt-4o-mini","training_file":"file-approved-2"}`, status: fasthttp.StatusOK},
+	}
+	h, requests := newFineTuningTestHandler(t, config)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := fineTuningRequest(fasthttp.MethodPost, "", tt.vk, tt.body)
+			h.createJob(ctx)
+			if ctx.Response.StatusCode() != tt.status {
+				t.Errorf("expected %d, got %d: %s", tt.status, ctx.Response.StatusCode(), ctx.Response.Body())
+			}
+		})
+	}
+	if len(*requests) != 2 {
+		t.Errorf("expected only the allowed jobs to reach the provider, got %v", *requests)
+	}
+
+	// Fine-tuning is off
+	off := NewFineTuningHandler(&lib.Config{}, h.logger)
+	ctx := fineTuningRequest(fasthttp.MethodPost, "", "vk-a", `{"model":"gpt-4o-mini","training_file":"file-approved-1"}`)
+	off.createJob(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("expected 404 when fine-tuning is off, got %d", ctx.Response.StatusCode())
+	}
+}
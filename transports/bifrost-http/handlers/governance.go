@@ -5,6 +5,7 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/fasthttp/router"
@@ -49,12 +50,15 @@ type CreateVirtualKeyRequest struct {
 		Weight        float64  `json:"weight,omitempty"`
 		AllowedModels []string `json:"allowed_models,omitempty"` // Empty means all models allowed
 	} `json:"provider_configs,omitempty"` // Empty means all providers allowed
-	TeamID     *string                 `json:"team_id,omitempty"`     // Mutually exclusive with CustomerID
-	CustomerID *string                 `json:"customer_id,omitempty"` // Mutually exclusive with TeamID
+	ProjectID  *string                 `json:"project_id,omitempty"`  // Mutually exclusive with TeamID and CustomerID
+	TeamID     *string                 `json:"team_id,omitempty"`     // Mutually exclusive with ProjectID and CustomerID
+	CustomerID *string                 `json:"customer_id,omitempty"` // Mutually exclusive with ProjectID and TeamID
 	Budget     *CreateBudgetRequest    `json:"budget,omitempty"`
 	RateLimit  *CreateRateLimitRequest `json:"rate_limit,omitempty"`
 	KeyIDs     []string                `json:"key_ids,omitempty"` // List of DBKey UUIDs to associate with this VirtualKey
 	IsActive   *bool                   `json:"is_active,omitempty"`
+
+	ParameterGuardrails *configstore.ParameterGuardrails `json:"parameter_guardrails,omitempty"` // Limits on inference parameters
 }
 
 // UpdateVirtualKeyRequest represents the request body for updating a virtual key
@@ -67,24 +71,92 @@ type UpdateVirtualKeyRequest struct {
 		Weight        float64  `json:"weight,omitempty"`
 		AllowedModels []string `json:"allowed_models,omitempty"` // Empty means all models allowed
 	} `json:"provider_configs,omitempty"`
+	ProjectID  *string                 `json:"project_id,omitempty"`
 	TeamID     *string                 `json:"team_id,omitempty"`
 	CustomerID *string                 `json:"customer_id,omitempty"`
 	Budget     *UpdateBudgetRequest    `json:"budget,omitempty"`
 	RateLimit  *UpdateRateLimitRequest `json:"rate_limit,omitempty"`
 	KeyIDs     []string                `json:"key_ids,omitempty"` // List of DBKey UUIDs to associate with this VirtualKey
 	IsActive   *bool                   `json:"is_active,omitempty"`
+
+	ParameterGuardrails *configstore.ParameterGuardrails `json:"parameter_guardrails,omitempty"` // Limits on inference parameters; an empty object removes them
 }
 
 // CreateBudgetRequest represents the request body for creating a budget
 type CreateBudgetRequest struct {
-	MaxLimit      float64 `json:"max_limit" validate:"required"`      // Maximum budget in dollars
-	ResetDuration string  `json:"reset_duration" validate:"required"` // e.g., "30s", "5m", "1h", "1d", "1w", "1M"
+	MaxLimit      float64  `json:"max_limit" validate:"required"` // Maximum budget in dollars
+	ResetDuration string   `json:"reset_duration,omitempty"`      // e.g., "30s", "5m", "1h", "1d", "1w", "1M"; required for rolling budgets
+	ResetSchedule string   `json:"reset_schedule,omitempty"`      // "rolling" (default), "calendar_month" or a UTC cron expression
+	Rollover      bool     `json:"rollover,omitempty"`            // Carry unused quota over to the next period
+	RolloverCap   *float64 `json:"rollover_cap,omitempty"`        // Maximum quota carried over (default max_limit)
+	Shadow        bool     `json:"shadow,omitempty"`              // Only record the requests the budget would block
 }
 
 // UpdateBudgetRequest represents the request body for updating a budget
 type UpdateBudgetRequest struct {
 	MaxLimit      *float64 `json:"max_limit,omitempty"`
 	ResetDuration *string  `json:"reset_duration,omitempty"`
+	ResetSchedule *string  `json:"reset_schedule,omitempty"`
+	Rollover      *bool    `json:"rollover,omitempty"`
+	RolloverCap   *float64 `json:"rollover_cap,omitempty"`
+	Shadow        *bool    `json:"shadow,omitempty"`
+}
+
+// toTable creates the budget of the request, starting its first period now
+func (r *CreateBudgetRequest) toTable() configstore.TableBudget {
+	return configstore.TableBudget{
+		ID:            uuid.NewString(),
+		MaxLimit:      r.MaxLimit,
+		ResetDuration: r.ResetDuration,
+		ResetSchedule: r.ResetSchedule,
+		Rollover:      r.Rollover,
+		RolloverCap:   r.RolloverCap,
+		Shadow:        r.Shadow,
+		LastReset:     time.Now(),
+		CurrentUsage:  0,
+	}
+}
+
+// applyTo updates the fields of budget set in the request
+func (r *UpdateBudgetRequest) applyTo(budget *configstore.TableBudget) {
+	if r.MaxLimit != nil {
+		budget.MaxLimit = *r.MaxLimit
+	}
+	if r.ResetDuration != nil {
+		budget.ResetDuration = *r.ResetDuration
+	}
+	if r.ResetSchedule != nil {
+		budget.ResetSchedule = *r.ResetSchedule
+	}
+	if r.Rollover != nil {
+		budget.Rollover = *r.Rollover
+	}
+	if r.RolloverCap != nil {
+		budget.RolloverCap = r.RolloverCap
+	}
+	if r.Shadow != nil {
+		budget.Shadow = *r.Shadow
+	}
+}
+
+// newBudget creates a budget from an update request, for entities that had no budget yet
+func (r *UpdateBudgetRequest) newBudget() (configstore.TableBudget, error) {
+	if r.MaxLimit == nil {
+		return configstore.TableBudget{}, fmt.Errorf("max_limit is required when creating a new budget")
+	}
+	if *r.MaxLimit < 0 {
+		return configstore.TableBudget{}, fmt.Errorf("budget max_limit cannot be negative: %.2f", *r.MaxLimit)
+	}
+	budget := configstore.TableBudget{
+		ID:        uuid.NewString(),
+		MaxLimit:  *r.MaxLimit,
+		LastReset: time.Now(),
+	}
+	r.applyTo(&budget)
+	if err := configstore.ValidateBudgetReset(budget.ResetSchedule, budget.ResetDuration); err != nil {
+		return configstore.TableBudget{}, err
+	}
+	return budget, nil
 }
 
 // CreateRateLimitRequest represents the request body for creating a rate limit using flexible approach
@@ -93,6 +165,7 @@ type CreateRateLimitRequest struct {
 	TokenResetDuration   *string `json:"token_reset_duration,omitempty"`   // e.g., "30s", "5m", "1h", "1d", "1w", "1M"
 	RequestMaxLimit      *int64  `json:"request_max_limit,omitempty"`      // Maximum requests allowed
 	RequestResetDuration *string `json:"request_reset_duration,omitempty"` // e.g., "30s", "5m", "1h", "1d", "1w", "1M"
+	Shadow               bool    `json:"shadow,omitempty"`                 // Only record the requests the rate limit would block
 }
 
 // UpdateRateLimitRequest represents the request body for updating a rate limit using flexible approach
@@ -101,32 +174,86 @@ type UpdateRateLimitRequest struct {
 	TokenResetDuration   *string `json:"token_reset_duration,omitempty"`   // e.g., "30s", "5m", "1h", "1d", "1w", "1M"
 	RequestMaxLimit      *int64  `json:"request_max_limit,omitempty"`      // Maximum requests allowed
 	RequestResetDuration *string `json:"request_reset_duration,omitempty"` // e.g., "30s", "5m", "1h", "1d", "1w", "1M"
+	Shadow               *bool   `json:"shadow,omitempty"`                 // Only record the requests the rate limit would block
+}
+
+// toTable creates the rate limit of the request, starting its first windows now
+func (r *CreateRateLimitRequest) toTable() configstore.TableRateLimit {
+	return configstore.TableRateLimit{
+		ID:                   uuid.NewString(),
+		TokenMaxLimit:        r.TokenMaxLimit,
+		TokenResetDuration:   r.TokenResetDuration,
+		RequestMaxLimit:      r.RequestMaxLimit,
+		RequestResetDuration: r.RequestResetDuration,
+		Shadow:               r.Shadow,
+		TokenLastReset:       time.Now(),
+		RequestLastReset:     time.Now(),
+	}
+}
+
+// applyTo updates the fields of rateLimit set in the request
+func (r *UpdateRateLimitRequest) applyTo(rateLimit *configstore.TableRateLimit) {
+	if r.TokenMaxLimit != nil {
+		rateLimit.TokenMaxLimit = r.TokenMaxLimit
+	}
+	if r.TokenResetDuration != nil {
+		rateLimit.TokenResetDuration = r.TokenResetDuration
+	}
+	if r.RequestMaxLimit != nil {
+		rateLimit.RequestMaxLimit = r.RequestMaxLimit
+	}
+	if r.RequestResetDuration != nil {
+		rateLimit.RequestResetDuration = r.RequestResetDuration
+	}
+	if r.Shadow != nil {
+		rateLimit.Shadow = *r.Shadow
+	}
 }
 
 // CreateTeamRequest represents the request body for creating a team
 type CreateTeamRequest struct {
-	Name       string               `json:"name" validate:"required"`
-	CustomerID *string              `json:"customer_id,omitempty"` // Team can belong to a customer
-	Budget     *CreateBudgetRequest `json:"budget,omitempty"`      // Team can have its own budget
+	Name       string                  `json:"name" validate:"required"`
+	CustomerID *string                 `json:"customer_id,omitempty"` // Team can belong to a customer
+	Budget     *CreateBudgetRequest    `json:"budget,omitempty"`      // Team can have its own budget
+	RateLimit  *CreateRateLimitRequest `json:"rate_limit,omitempty"`  // Shared by all projects and virtual keys of the team
 }
 
 // UpdateTeamRequest represents the request body for updating a team
 type UpdateTeamRequest struct {
-	Name       *string              `json:"name,omitempty"`
-	CustomerID *string              `json:"customer_id,omitempty"`
-	Budget     *UpdateBudgetRequest `json:"budget,omitempty"`
+	Name       *string                 `json:"name,omitempty"`
+	CustomerID *string                 `json:"customer_id,omitempty"`
+	Budget     *UpdateBudgetRequest    `json:"budget,omitempty"`
+	RateLimit  *UpdateRateLimitRequest `json:"rate_limit,omitempty"`
+}
+
+// CreateProjectRequest represents the request body for creating a project
+type CreateProjectRequest struct {
+	Name      string                  `json:"name" validate:"required"`
+	TeamID    *string                 `json:"team_id,omitempty"` // Project can belong to a team
+	Budget    *CreateBudgetRequest    `json:"budget,omitempty"`
+	RateLimit *CreateRateLimitRequest `json:"rate_limit,omitempty"`
+}
+
+// UpdateProjectRequest represents the request body for updating a project
+type UpdateProjectRequest struct {
+	Name      *string                 `json:"name,omitempty"`
+	TeamID    *string                 `json:"team_id,omitempty"`
+	Budget    *UpdateBudgetRequest    `json:"budget,omitempty"`
+	RateLimit *UpdateRateLimitRequest `json:"rate_limit,omitempty"`
 }
 
 // CreateCustomerRequest represents the request body for creating a customer
 type CreateCustomerRequest struct {
-	Name   string               `json:"name" validate:"required"`
-	Budget *CreateBudgetRequest `json:"budget,omitempty"`
+	Name      string                  `json:"name" validate:"required"`
+	Budget    *CreateBudgetRequest    `json:"budget,omitempty"`
+	RateLimit *CreateRateLimitRequest `json:"rate_limit,omitempty"`
 }
 
 // UpdateCustomerRequest represents the request body for updating a customer
 type UpdateCustomerRequest struct {
-	Name   *string              `json:"name,omitempty"`
-	Budget *UpdateBudgetRequest `json:"budget,omitempty"`
+	Name      *string                 `json:"name,omitempty"`
+	Budget    *UpdateBudgetRequest    `json:"budget,omitempty"`
+	RateLimit *UpdateRateLimitRequest `json:"rate_limit,omitempty"`
 }
 
 // RegisterRoutes registers all governance-related routes for the new hierarchical system
@@ -145,18 +272,84 @@ func (h *GovernanceHandler) RegisterRoutes(r *router.Router, middlewares ...lib.
 	r.PUT("/api/governance/teams/{team_id}", lib.ChainMiddlewares(h.updateTeam, middlewares...))
 	r.DELETE("/api/governance/teams/{team_id}", lib.ChainMiddlewares(h.deleteTeam, middlewares...))
 
+	// Project CRUD operations
+	r.GET("/api/governance/projects", lib.ChainMiddlewares(h.getProjects, middlewares...))
+	r.POST("/api/governance/projects", lib.ChainMiddlewares(h.createProject, middlewares...))
+	r.GET("/api/governance/projects/{project_id}", lib.ChainMiddlewares(h.getProject, middlewares...))
+	r.PUT("/api/governance/projects/{project_id}", lib.ChainMiddlewares(h.updateProject, middlewares...))
+	r.DELETE("/api/governance/projects/{project_id}", lib.ChainMiddlewares(h.deleteProject, middlewares...))
+
 	// Customer CRUD operations
 	r.GET("/api/governance/customers", lib.ChainMiddlewares(h.getCustomers, middlewares...))
 	r.POST("/api/governance/customers", lib.ChainMiddlewares(h.createCustomer, middlewares...))
 	r.GET("/api/governance/customers/{customer_id}", lib.ChainMiddlewares(h.getCustomer, middlewares...))
 	r.PUT("/api/governance/customers/{customer_id}", lib.ChainMiddlewares(h.updateCustomer, middlewares...))
 	r.DELETE("/api/governance/customers/{customer_id}", lib.ChainMiddlewares(h.deleteCustomer, middlewares...))
+
+	// Aggregate usage per level of the hierarchy
+	r.GET("/api/governance/usage", lib.ChainMiddlewares(h.getUsage, middlewares...))
+
+	// Requests blocked by rules, compared with the ones rules in shadow mode would have blocked
+	r.GET("/api/governance/shadow-report", lib.ChainMiddlewares(h.getShadowReport, middlewares...))
+	r.DELETE("/api/governance/shadow-report", lib.ChainMiddlewares(h.resetShadowReport, middlewares...))
+}
+
+// List specs of the governance list endpoints, see pagination.go
+
+var virtualKeyListSpec = &listSpec[configstore.TableVirtualKey]{
+	id: func(vk configstore.TableVirtualKey) string { return vk.ID },
+	fields: map[string]listField[configstore.TableVirtualKey]{
+		"name":        {value: func(vk configstore.TableVirtualKey) string { return vk.Name }},
+		"is_active":   {value: func(vk configstore.TableVirtualKey) string { return strconv.FormatBool(vk.IsActive) }},
+		"project_id":  {value: func(vk configstore.TableVirtualKey) string { return optionalListValue(vk.ProjectID) }},
+		"team_id":     {value: func(vk configstore.TableVirtualKey) string { return optionalListValue(vk.TeamID) }},
+		"customer_id": {value: func(vk configstore.TableVirtualKey) string { return optionalListValue(vk.CustomerID) }},
+		"created_at":  {value: func(vk configstore.TableVirtualKey) string { return timeListValue(vk.CreatedAt) }, numeric: true},
+		"updated_at":  {value: func(vk configstore.TableVirtualKey) string { return timeListValue(vk.UpdatedAt) }, numeric: true},
+	},
+	defaultSort: "created_at",
+}
+
+var teamListSpec = &listSpec[configstore.TableTeam]{
+	id: func(team configstore.TableTeam) string { return team.ID },
+	fields: map[string]listField[configstore.TableTeam]{
+		"name":       {value: func(team configstore.TableTeam) string { return team.Name }},
+		"created_at": {value: func(team configstore.TableTeam) string { return timeListValue(team.CreatedAt) }, numeric: true},
+		"updated_at": {value: func(team configstore.TableTeam) string { return timeListValue(team.UpdatedAt) }, numeric: true},
+	},
+	defaultSort: "created_at",
+}
+
+var projectListSpec = &listSpec[configstore.TableProject]{
+	id: func(project configstore.TableProject) string { return project.ID },
+	fields: map[string]listField[configstore.TableProject]{
+		"name":       {value: func(project configstore.TableProject) string { return project.Name }},
+		"created_at": {value: func(project configstore.TableProject) string { return timeListValue(project.CreatedAt) }, numeric: true},
+		"updated_at": {value: func(project configstore.TableProject) string { return timeListValue(project.UpdatedAt) }, numeric: true},
+	},
+	defaultSort: "created_at",
+}
+
+var customerListSpec = &listSpec[configstore.TableCustomer]{
+	id: func(customer configstore.TableCustomer) string { return customer.ID },
+	fields: map[string]listField[configstore.TableCustomer]{
+		"name":       {value: func(customer configstore.TableCustomer) string { return customer.Name }},
+		"created_at": {value: func(customer configstore.TableCustomer) string { return timeListValue(customer.CreatedAt) }, numeric: true},
+		"updated_at": {value: func(customer configstore.TableCustomer) string { return timeListValue(customer.UpdatedAt) }, numeric: true},
+	},
+	defaultSort: "created_at",
 }
 
 // Virtual Key CRUD Operations
 
 // getVirtualKeys handles GET /api/governance/virtual-keys - Get all virtual keys with relationships
 func (h *GovernanceHandler) getVirtualKeys(ctx *fasthttp.RequestCtx) {
+	query, err := parseListQuery(ctx, virtualKeyListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
 	// Preload all relationships for complete information
 	virtualKeys, err := h.configStore.GetVirtualKeys(ctx)
 	if err != nil {
@@ -165,10 +358,7 @@ func (h *GovernanceHandler) getVirtualKeys(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	SendJSON(ctx, map[string]interface{}{
-		"virtual_keys": virtualKeys,
-		"count":        len(virtualKeys),
-	}, h.logger)
+	sendListPage(ctx, "virtual_keys", paginate(virtualKeys, virtualKeyListSpec, query), h.logger)
 }
 
 // createVirtualKey handles POST /api/governance/virtual-keys - Create a new virtual key
@@ -185,11 +375,21 @@ func (h *GovernanceHandler) createVirtualKey(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	// Validate mutually exclusive TeamID and CustomerID
+	// Validate mutually exclusive ProjectID, TeamID and CustomerID
 	if req.TeamID != nil && req.CustomerID != nil {
 		SendError(ctx, 400, "VirtualKey cannot be attached to both Team and Customer", h.logger)
 		return
 	}
+	if req.ProjectID != nil && (req.TeamID != nil || req.CustomerID != nil) {
+		SendError(ctx, 400, "VirtualKey in a Project cannot also be attached to a Team or Customer", h.logger)
+		return
+	}
+	if req.ParameterGuardrails != nil {
+		if err := req.ParameterGuardrails.Validate(); err != nil {
+			SendError(ctx, 400, err.Error(), h.logger)
+			return
+		}
+	}
 
 	// Validate budget if provided
 	if req.Budget != nil {
@@ -197,9 +397,9 @@ func (h *GovernanceHandler) createVirtualKey(ctx *fasthttp.RequestCtx) {
 			SendError(ctx, 400, fmt.Sprintf("Budget max_limit cannot be negative: %.2f", req.Budget.MaxLimit), h.logger)
 			return
 		}
-		// Validate reset duration format
-		if _, err := configstore.ParseDuration(req.Budget.ResetDuration); err != nil {
-			SendError(ctx, 400, fmt.Sprintf("Invalid reset duration format: %s", req.Budget.ResetDuration), h.logger)
+		// Validate reset schedule and duration format
+		if err := configstore.ValidateBudgetReset(req.Budget.ResetSchedule, req.Budget.ResetDuration); err != nil {
+			SendError(ctx, 400, err.Error(), h.logger)
 			return
 		}
 	}
@@ -230,20 +430,18 @@ func (h *GovernanceHandler) createVirtualKey(ctx *fasthttp.RequestCtx) {
 			Name:        req.Name,
 			Value:       uuid.NewString(),
 			Description: req.Description,
+			ProjectID:   req.ProjectID,
 			TeamID:      req.TeamID,
 			CustomerID:  req.CustomerID,
 			IsActive:    isActive,
 			Keys:        keys, // Set the keys for the many-to-many relationship
 		}
+		if !req.ParameterGuardrails.IsZero() {
+			vk.ParameterGuardrails = req.ParameterGuardrails
+		}
 
 		if req.Budget != nil {
-			budget := configstore.TableBudget{
-				ID:            uuid.NewString(),
-				MaxLimit:      req.Budget.MaxLimit,
-				ResetDuration: req.Budget.ResetDuration,
-				LastReset:     time.Now(),
-				CurrentUsage:  0,
-			}
+			budget := req.Budget.toTable()
 			if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
 				return err
 			}
@@ -251,15 +449,7 @@ func (h *GovernanceHandler) createVirtualKey(ctx *fasthttp.RequestCtx) {
 		}
 
 		if req.RateLimit != nil {
-			rateLimit := configstore.TableRateLimit{
-				ID:                   uuid.NewString(),
-				TokenMaxLimit:        req.RateLimit.TokenMaxLimit,
-				TokenResetDuration:   req.RateLimit.TokenResetDuration,
-				RequestMaxLimit:      req.RateLimit.RequestMaxLimit,
-				RequestResetDuration: req.RateLimit.RequestResetDuration,
-				TokenLastReset:       time.Now(),
-				RequestLastReset:     time.Now(),
-			}
+			rateLimit := req.RateLimit.toTable()
 			if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
 				return err
 			}
@@ -340,11 +530,21 @@ func (h *GovernanceHandler) updateVirtualKey(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	// Validate mutually exclusive TeamID and CustomerID
+	// Validate mutually exclusive ProjectID, TeamID and CustomerID
 	if req.TeamID != nil && req.CustomerID != nil {
 		SendError(ctx, 400, "VirtualKey cannot be attached to both Team and Customer", h.logger)
 		return
 	}
+	if req.ProjectID != nil && (req.TeamID != nil || req.CustomerID != nil) {
+		SendError(ctx, 400, "VirtualKey in a Project cannot also be attached to a Team or Customer", h.logger)
+		return
+	}
+	if req.ParameterGuardrails != nil {
+		if err := req.ParameterGuardrails.Validate(); err != nil {
+			SendError(ctx, 400, err.Error(), h.logger)
+			return
+		}
+	}
 
 	vk, err := h.configStore.GetVirtualKey(ctx, vkID)
 	if err != nil {
@@ -361,17 +561,30 @@ func (h *GovernanceHandler) updateVirtualKey(ctx *fasthttp.RequestCtx) {
 		if req.Description != nil {
 			vk.Description = *req.Description
 		}
+		if req.ProjectID != nil {
+			vk.ProjectID = req.ProjectID
+			vk.TeamID = nil // Clear TeamID and CustomerID if setting ProjectID
+			vk.CustomerID = nil
+		}
 		if req.TeamID != nil {
 			vk.TeamID = req.TeamID
-			vk.CustomerID = nil // Clear CustomerID if setting TeamID
+			vk.ProjectID = nil // Clear ProjectID and CustomerID if setting TeamID
+			vk.CustomerID = nil
 		}
 		if req.CustomerID != nil {
 			vk.CustomerID = req.CustomerID
-			vk.TeamID = nil // Clear TeamID if setting CustomerID
+			vk.ProjectID = nil // Clear ProjectID and TeamID if setting CustomerID
+			vk.TeamID = nil
 		}
 		if req.IsActive != nil {
 			vk.IsActive = *req.IsActive
 		}
+		if req.ParameterGuardrails != nil {
+			vk.ParameterGuardrails = req.ParameterGuardrails
+			if req.ParameterGuardrails.IsZero() {
+				vk.ParameterGuardrails = nil
+			}
+		}
 
 		// Handle budget updates
 		if req.Budget != nil {
@@ -382,12 +595,7 @@ func (h *GovernanceHandler) updateVirtualKey(ctx *fasthttp.RequestCtx) {
 					return err
 				}
 
-				if req.Budget.MaxLimit != nil {
-					budget.MaxLimit = *req.Budget.MaxLimit
-				}
-				if req.Budget.ResetDuration != nil {
-					budget.ResetDuration = *req.Budget.ResetDuration
-				}
+				req.Budget.applyTo(&budget)
 
 				if err := h.configStore.UpdateBudget(ctx, &budget, tx); err != nil {
 					return err
@@ -395,22 +603,9 @@ func (h *GovernanceHandler) updateVirtualKey(ctx *fasthttp.RequestCtx) {
 				vk.Budget = &budget
 			} else {
 				// Create new budget
-				if req.Budget.MaxLimit == nil || req.Budget.ResetDuration == nil {
-					return fmt.Errorf("both max_limit and reset_duration are required when creating a new budget")
-				}
-				if *req.Budget.MaxLimit < 0 {
-					return fmt.Errorf("budget max_limit cannot be negative: %.2f", *req.Budget.MaxLimit)
-				}
-				if _, err := configstore.ParseDuration(*req.Budget.ResetDuration); err != nil {
-					return fmt.Errorf("invalid reset duration format: %s", *req.Budget.ResetDuration)
-				}
-				// Storing now
-				budget := configstore.TableBudget{
-					ID:            uuid.NewString(),
-					MaxLimit:      *req.Budget.MaxLimit,
-					ResetDuration: *req.Budget.ResetDuration,
-					LastReset:     time.Now(),
-					CurrentUsage:  0,
+				budget, err := req.Budget.newBudget()
+				if err != nil {
+					return err
 				}
 				if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
 					return err
@@ -422,45 +617,12 @@ func (h *GovernanceHandler) updateVirtualKey(ctx *fasthttp.RequestCtx) {
 
 		// Handle rate limit updates
 		if req.RateLimit != nil {
-			if vk.RateLimitID != nil {
-				// Update existing rate limit
-				rateLimit := configstore.TableRateLimit{}
-				if err := tx.First(&rateLimit, "id = ?", *vk.RateLimitID).Error; err != nil {
-					return err
-				}
-
-				if req.RateLimit.TokenMaxLimit != nil {
-					rateLimit.TokenMaxLimit = req.RateLimit.TokenMaxLimit
-				}
-				if req.RateLimit.TokenResetDuration != nil {
-					rateLimit.TokenResetDuration = req.RateLimit.TokenResetDuration
-				}
-				if req.RateLimit.RequestMaxLimit != nil {
-					rateLimit.RequestMaxLimit = req.RateLimit.RequestMaxLimit
-				}
-				if req.RateLimit.RequestResetDuration != nil {
-					rateLimit.RequestResetDuration = req.RateLimit.RequestResetDuration
-				}
-
-				if err := h.configStore.UpdateRateLimit(ctx, &rateLimit, tx); err != nil {
-					return err
-				}
-			} else {
-				// Create new rate limit
-				rateLimit := configstore.TableRateLimit{
-					ID:                   uuid.NewString(),
-					TokenMaxLimit:        req.RateLimit.TokenMaxLimit,
-					TokenResetDuration:   req.RateLimit.TokenResetDuration,
-					RequestMaxLimit:      req.RateLimit.RequestMaxLimit,
-					RequestResetDuration: req.RateLimit.RequestResetDuration,
-					TokenLastReset:       time.Now(),
-					RequestLastReset:     time.Now(),
-				}
-				if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
-					return err
-				}
-				vk.RateLimitID = &rateLimit.ID
+			rateLimit, err := h.saveRateLimit(ctx, tx, vk.RateLimitID, req.RateLimit)
+			if err != nil {
+				return err
 			}
+			vk.RateLimitID = &rateLimit.ID
+			vk.RateLimit = rateLimit
 		}
 
 		// Handle DBKey associations if provided
@@ -608,12 +770,48 @@ func (h *GovernanceHandler) deleteVirtualKey(ctx *fasthttp.RequestCtx) {
 	}, h.logger)
 }
 
+// saveRateLimit applies a rate limit update to the rate limit with the given ID, or creates a rate limit from
+// the update when the ID is nil
+func (h *GovernanceHandler) saveRateLimit(ctx *fasthttp.RequestCtx, tx *gorm.DB, rateLimitID *string, req *UpdateRateLimitRequest) (*configstore.TableRateLimit, error) {
+	if rateLimitID != nil {
+		// Update existing rate limit
+		rateLimit := configstore.TableRateLimit{}
+		if err := tx.First(&rateLimit, "id = ?", *rateLimitID).Error; err != nil {
+			return nil, err
+		}
+		req.applyTo(&rateLimit)
+		if err := h.configStore.UpdateRateLimit(ctx, &rateLimit, tx); err != nil {
+			return nil, err
+		}
+		return &rateLimit, nil
+	}
+
+	// Create new rate limit
+	rateLimit := (&CreateRateLimitRequest{
+		TokenMaxLimit:        req.TokenMaxLimit,
+		TokenResetDuration:   req.TokenResetDuration,
+		RequestMaxLimit:      req.RequestMaxLimit,
+		RequestResetDuration: req.RequestResetDuration,
+		Shadow:               req.Shadow != nil && *req.Shadow,
+	}).toTable()
+	if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
+		return nil, err
+	}
+	return &rateLimit, nil
+}
+
 // Team CRUD Operations
 
 // getTeams handles GET /api/governance/teams - Get all teams
 func (h *GovernanceHandler) getTeams(ctx *fasthttp.RequestCtx) {
 	customerID := string(ctx.QueryArgs().Peek("customer_id"))
 
+	query, err := parseListQuery(ctx, teamListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
 	// Preload relationships for complete information
 	teams, err := h.configStore.GetTeams(ctx, customerID)
 	if err != nil {
@@ -622,10 +820,7 @@ func (h *GovernanceHandler) getTeams(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	SendJSON(ctx, map[string]interface{}{
-		"teams": teams,
-		"count": len(teams),
-	}, h.logger)
+	sendListPage(ctx, "teams", paginate(teams, teamListSpec, query), h.logger)
 }
 
 // createTeam handles POST /api/governance/teams - Create a new team
@@ -648,9 +843,9 @@ func (h *GovernanceHandler) createTeam(ctx *fasthttp.RequestCtx) {
 			SendError(ctx, 400, fmt.Sprintf("Budget max_limit cannot be negative: %.2f", req.Budget.MaxLimit), h.logger)
 			return
 		}
-		// Validate reset duration format
-		if _, err := configstore.ParseDuration(req.Budget.ResetDuration); err != nil {
-			SendError(ctx, 400, fmt.Sprintf("Invalid reset duration format: %s", req.Budget.ResetDuration), h.logger)
+		// Validate reset schedule and duration format
+		if err := configstore.ValidateBudgetReset(req.Budget.ResetSchedule, req.Budget.ResetDuration); err != nil {
+			SendError(ctx, 400, err.Error(), h.logger)
 			return
 		}
 	}
@@ -664,19 +859,21 @@ func (h *GovernanceHandler) createTeam(ctx *fasthttp.RequestCtx) {
 		}
 
 		if req.Budget != nil {
-			budget := configstore.TableBudget{
-				ID:            uuid.NewString(),
-				MaxLimit:      req.Budget.MaxLimit,
-				ResetDuration: req.Budget.ResetDuration,
-				LastReset:     time.Now(),
-				CurrentUsage:  0,
-			}
+			budget := req.Budget.toTable()
 			if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
 				return err
 			}
 			team.BudgetID = &budget.ID
 		}
 
+		if req.RateLimit != nil {
+			rateLimit := req.RateLimit.toTable()
+			if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
+				return err
+			}
+			team.RateLimitID = &rateLimit.ID
+		}
+
 		if err := h.configStore.CreateTeam(ctx, &team, tx); err != nil {
 			return err
 		}
@@ -765,12 +962,7 @@ func (h *GovernanceHandler) updateTeam(ctx *fasthttp.RequestCtx) {
 					return err
 				}
 
-				if req.Budget.MaxLimit != nil {
-					budget.MaxLimit = *req.Budget.MaxLimit
-				}
-				if req.Budget.ResetDuration != nil {
-					budget.ResetDuration = *req.Budget.ResetDuration
-				}
+				req.Budget.applyTo(budget)
 
 				if err := h.configStore.UpdateBudget(ctx, budget, tx); err != nil {
 					return err
@@ -778,12 +970,9 @@ func (h *GovernanceHandler) updateTeam(ctx *fasthttp.RequestCtx) {
 				team.Budget = budget
 			} else {
 				// Create new budget
-				budget := configstore.TableBudget{
-					ID:            uuid.NewString(),
-					MaxLimit:      *req.Budget.MaxLimit,
-					ResetDuration: *req.Budget.ResetDuration,
-					LastReset:     time.Now(),
-					CurrentUsage:  0,
+				budget, err := req.Budget.newBudget()
+				if err != nil {
+					return err
 				}
 				if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
 					return err
@@ -793,6 +982,16 @@ func (h *GovernanceHandler) updateTeam(ctx *fasthttp.RequestCtx) {
 			}
 		}
 
+		// Handle rate limit updates
+		if req.RateLimit != nil {
+			rateLimit, err := h.saveRateLimit(ctx, tx, team.RateLimitID, req.RateLimit)
+			if err != nil {
+				return err
+			}
+			team.RateLimitID = &rateLimit.ID
+			team.RateLimit = rateLimit
+		}
+
 		if err := h.configStore.UpdateTeam(ctx, team, tx); err != nil {
 			return err
 		}
@@ -864,10 +1063,279 @@ func (h *GovernanceHandler) deleteTeam(ctx *fasthttp.RequestCtx) {
 	}, h.logger)
 }
 
+// Project CRUD Operations
+
+// getProjects handles GET /api/governance/projects - Get all projects
+func (h *GovernanceHandler) getProjects(ctx *fasthttp.RequestCtx) {
+	teamID := string(ctx.QueryArgs().Peek("team_id"))
+
+	query, err := parseListQuery(ctx, projectListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	// Preload relationships for complete information
+	projects, err := h.configStore.GetProjects(ctx, teamID)
+	if err != nil {
+		h.logger.Error("failed to retrieve projects: %v", err)
+		SendError(ctx, 500, fmt.Sprintf("Failed to retrieve projects: %v", err), h.logger)
+		return
+	}
+
+	sendListPage(ctx, "projects", paginate(projects, projectListSpec, query), h.logger)
+}
+
+// createProject handles POST /api/governance/projects - Create a new project
+func (h *GovernanceHandler) createProject(ctx *fasthttp.RequestCtx) {
+	var req CreateProjectRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, 400, "Invalid JSON", h.logger)
+		return
+	}
+
+	// Validate required fields
+	if req.Name == "" {
+		SendError(ctx, 400, "Project name is required", h.logger)
+		return
+	}
+
+	// Validate budget if provided
+	if req.Budget != nil {
+		if req.Budget.MaxLimit < 0 {
+			SendError(ctx, 400, fmt.Sprintf("Budget max_limit cannot be negative: %.2f", req.Budget.MaxLimit), h.logger)
+			return
+		}
+		// Validate reset schedule and duration format
+		if err := configstore.ValidateBudgetReset(req.Budget.ResetSchedule, req.Budget.ResetDuration); err != nil {
+			SendError(ctx, 400, err.Error(), h.logger)
+			return
+		}
+	}
+
+	var project configstore.TableProject
+	if err := h.configStore.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+		project = configstore.TableProject{
+			ID:     uuid.NewString(),
+			Name:   req.Name,
+			TeamID: req.TeamID,
+		}
+
+		if req.Budget != nil {
+			budget := req.Budget.toTable()
+			if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
+				return err
+			}
+			project.BudgetID = &budget.ID
+		}
+
+		if req.RateLimit != nil {
+			rateLimit := req.RateLimit.toTable()
+			if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
+				return err
+			}
+			project.RateLimitID = &rateLimit.ID
+		}
+
+		if err := h.configStore.CreateProject(ctx, &project, tx); err != nil {
+			return err
+		}
+		return nil
+	}); err != nil {
+		h.logger.Error("failed to create project: %v", err)
+		SendError(ctx, 500, "failed to create project", h.logger)
+		return
+	}
+
+	// Load relationships for response
+	preloadedProject, err := h.configStore.GetProject(ctx, project.ID)
+	if err != nil {
+		h.logger.Error("failed to load relationships for created project: %v", err)
+		preloadedProject = &project
+	}
+
+	// Add to in-memory store
+	h.pluginStore.CreateProjectInMemory(preloadedProject)
+
+	// If budget was created, add it to in-memory store
+	if preloadedProject.BudgetID != nil {
+		h.pluginStore.CreateBudgetInMemory(preloadedProject.Budget)
+	}
+
+	SendJSON(ctx, map[string]interface{}{
+		"message": "Project created successfully",
+		"project": preloadedProject,
+	}, h.logger)
+}
+
+// getProject handles GET /api/governance/projects/{project_id} - Get a specific project
+func (h *GovernanceHandler) getProject(ctx *fasthttp.RequestCtx) {
+	projectID := ctx.UserValue("project_id").(string)
+
+	project, err := h.configStore.GetProject(ctx, projectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			SendError(ctx, 404, "Project not found", h.logger)
+			return
+		}
+		SendError(ctx, 500, "Failed to retrieve project", h.logger)
+		return
+	}
+
+	SendJSON(ctx, map[string]interface{}{
+		"project": project,
+	}, h.logger)
+}
+
+// updateProject handles PUT /api/governance/projects/{project_id} - Update a project
+func (h *GovernanceHandler) updateProject(ctx *fasthttp.RequestCtx) {
+	projectID := ctx.UserValue("project_id").(string)
+
+	var req UpdateProjectRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, 400, "Invalid JSON", h.logger)
+		return
+	}
+
+	project, err := h.configStore.GetProject(ctx, projectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			SendError(ctx, 404, "Project not found", h.logger)
+			return
+		}
+		SendError(ctx, 500, "Failed to retrieve project", h.logger)
+		return
+	}
+
+	if err := h.configStore.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+		// Update fields if provided
+		if req.Name != nil {
+			project.Name = *req.Name
+		}
+		if req.TeamID != nil {
+			project.TeamID = req.TeamID
+		}
+
+		// Handle budget updates
+		if req.Budget != nil {
+			if project.BudgetID != nil {
+				// Update existing budget
+				budget, err := h.configStore.GetBudget(ctx, *project.BudgetID, tx)
+				if err != nil {
+					return err
+				}
+
+				req.Budget.applyTo(budget)
+
+				if err := h.configStore.UpdateBudget(ctx, budget, tx); err != nil {
+					return err
+				}
+				project.Budget = budget
+			} else {
+				// Create new budget
+				budget, err := req.Budget.newBudget()
+				if err != nil {
+					return err
+				}
+				if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
+					return err
+				}
+				project.BudgetID = &budget.ID
+				project.Budget = &budget
+			}
+		}
+
+		// Handle rate limit updates
+		if req.RateLimit != nil {
+			rateLimit, err := h.saveRateLimit(ctx, tx, project.RateLimitID, req.RateLimit)
+			if err != nil {
+				return err
+			}
+			project.RateLimitID = &rateLimit.ID
+			project.RateLimit = rateLimit
+		}
+
+		if err := h.configStore.UpdateProject(ctx, project, tx); err != nil {
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		SendError(ctx, 500, "Failed to update project", h.logger)
+		return
+	}
+
+	// Update in-memory cache for budget changes
+	if req.Budget != nil && project.BudgetID != nil {
+		if err := h.pluginStore.UpdateBudgetInMemory(project.Budget); err != nil {
+			h.logger.Error("failed to update budget cache: %v", err)
+		}
+	}
+
+	// Load relationships for response
+	preloadedProject, err := h.configStore.GetProject(ctx, project.ID)
+	if err != nil {
+		h.logger.Error("failed to load relationships for updated project: %v", err)
+		preloadedProject = project
+	}
+
+	// Update in-memory store
+	h.pluginStore.UpdateProjectInMemory(preloadedProject)
+
+	SendJSON(ctx, map[string]interface{}{
+		"message": "Project updated successfully",
+		"project": preloadedProject,
+	}, h.logger)
+}
+
+// deleteProject handles DELETE /api/governance/projects/{project_id} - Delete a project
+func (h *GovernanceHandler) deleteProject(ctx *fasthttp.RequestCtx) {
+	projectID := ctx.UserValue("project_id").(string)
+
+	project, err := h.configStore.GetProject(ctx, projectID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			SendError(ctx, 404, "Project not found", h.logger)
+			return
+		}
+		SendError(ctx, 500, "Failed to retrieve project", h.logger)
+		return
+	}
+
+	budgetID := project.BudgetID
+
+	if err := h.configStore.DeleteProject(ctx, projectID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			SendError(ctx, 404, "Project not found", h.logger)
+			return
+		}
+		SendError(ctx, 500, "Failed to delete project", h.logger)
+		return
+	}
+
+	// Remove from in-memory store
+	h.pluginStore.DeleteProjectInMemory(projectID)
+
+	// Remove Budget from in-memory store
+	if budgetID != nil {
+		h.pluginStore.DeleteBudgetInMemory(*budgetID)
+	}
+
+	SendJSON(ctx, map[string]interface{}{
+		"message": "Project deleted successfully",
+	}, h.logger)
+}
+
 // Customer CRUD Operations
 
 // getCustomers handles GET /api/governance/customers - Get all customers
 func (h *GovernanceHandler) getCustomers(ctx *fasthttp.RequestCtx) {
+	query, err := parseListQuery(ctx, customerListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
 	customers, err := h.configStore.GetCustomers(ctx)
 	if err != nil {
 		h.logger.Error("failed to retrieve customers: %v", err)
@@ -875,10 +1343,7 @@ func (h *GovernanceHandler) getCustomers(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	SendJSON(ctx, map[string]interface{}{
-		"customers": customers,
-		"count":     len(customers),
-	}, h.logger)
+	sendListPage(ctx, "customers", paginate(customers, customerListSpec, query), h.logger)
 }
 
 // createCustomer handles POST /api/governance/customers - Create a new customer
@@ -901,9 +1366,9 @@ func (h *GovernanceHandler) createCustomer(ctx *fasthttp.RequestCtx) {
 			SendError(ctx, 400, fmt.Sprintf("Budget max_limit cannot be negative: %.2f", req.Budget.MaxLimit), h.logger)
 			return
 		}
-		// Validate reset duration format
-		if _, err := configstore.ParseDuration(req.Budget.ResetDuration); err != nil {
-			SendError(ctx, 400, fmt.Sprintf("Invalid reset duration format: %s", req.Budget.ResetDuration), h.logger)
+		// Validate reset schedule and duration format
+		if err := configstore.ValidateBudgetReset(req.Budget.ResetSchedule, req.Budget.ResetDuration); err != nil {
+			SendError(ctx, 400, err.Error(), h.logger)
 			return
 		}
 	}
@@ -916,19 +1381,21 @@ func (h *GovernanceHandler) createCustomer(ctx *fasthttp.RequestCtx) {
 		}
 
 		if req.Budget != nil {
-			budget := configstore.TableBudget{
-				ID:            uuid.NewString(),
-				MaxLimit:      req.Budget.MaxLimit,
-				ResetDuration: req.Budget.ResetDuration,
-				LastReset:     time.Now(),
-				CurrentUsage:  0,
-			}
+			budget := req.Budget.toTable()
 			if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
 				return err
 			}
 			customer.BudgetID = &budget.ID
 		}
 
+		if req.RateLimit != nil {
+			rateLimit := req.RateLimit.toTable()
+			if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
+				return err
+			}
+			customer.RateLimitID = &rateLimit.ID
+		}
+
 		if err := h.configStore.CreateCustomer(ctx, &customer, tx); err != nil {
 			return err
 		}
@@ -1013,12 +1480,7 @@ func (h *GovernanceHandler) updateCustomer(ctx *fasthttp.RequestCtx) {
 					return err
 				}
 
-				if req.Budget.MaxLimit != nil {
-					budget.MaxLimit = *req.Budget.MaxLimit
-				}
-				if req.Budget.ResetDuration != nil {
-					budget.ResetDuration = *req.Budget.ResetDuration
-				}
+				req.Budget.applyTo(budget)
 
 				if err := h.configStore.UpdateBudget(ctx, budget, tx); err != nil {
 					return err
@@ -1026,12 +1488,9 @@ func (h *GovernanceHandler) updateCustomer(ctx *fasthttp.RequestCtx) {
 				customer.Budget = budget
 			} else {
 				// Create new budget
-				budget := configstore.TableBudget{
-					ID:            uuid.NewString(),
-					MaxLimit:      *req.Budget.MaxLimit,
-					ResetDuration: *req.Budget.ResetDuration,
-					LastReset:     time.Now(),
-					CurrentUsage:  0,
+				budget, err := req.Budget.newBudget()
+				if err != nil {
+					return err
 				}
 				if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
 					return err
@@ -1041,6 +1500,16 @@ func (h *GovernanceHandler) updateCustomer(ctx *fasthttp.RequestCtx) {
 			}
 		}
 
+		// Handle rate limit updates
+		if req.RateLimit != nil {
+			rateLimit, err := h.saveRateLimit(ctx, tx, customer.RateLimitID, req.RateLimit)
+			if err != nil {
+				return err
+			}
+			customer.RateLimitID = &rateLimit.ID
+			customer.RateLimit = rateLimit
+		}
+
 		if err := h.configStore.UpdateCustomer(ctx, customer, tx); err != nil {
 			return err
 		}
@@ -1111,3 +1580,34 @@ func (h *GovernanceHandler) deleteCustomer(ctx *fasthttp.RequestCtx) {
 		"message": "Customer deleted successfully",
 	}, h.logger)
 }
+
+// getUsage handles GET /api/governance/usage - Get the aggregate usage of each customer, team, project and
+// virtual key, optionally filtered to one level with ?level=
+func (h *GovernanceHandler) getUsage(ctx *fasthttp.RequestCtx) {
+	level := string(ctx.QueryArgs().Peek("level"))
+
+	usage, err := h.pluginStore.UsageByLevel(level)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	SendJSON(ctx, map[string]interface{}{
+		"usage": usage,
+		"count": len(usage),
+	}, h.logger)
+}
+
+// getShadowReport handles GET /api/governance/shadow-report - Get the requests budgets, rate limits and parameter
+// guardrails blocked, and the ones those in shadow mode would have blocked
+func (h *GovernanceHandler) getShadowReport(ctx *fasthttp.RequestCtx) {
+	SendJSON(ctx, h.plugin.ShadowReport(), h.logger)
+}
+
+// resetShadowReport handles DELETE /api/governance/shadow-report - Start a new shadow report
+func (h *GovernanceHandler) resetShadowReport(ctx *fasthttp.RequestCtx) {
+	h.plugin.ResetShadowReport()
+	SendJSON(ctx, map[string]interface{}{
+		"message": "Shadow report reset successfully",
+	}, h.logger)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+)
+
+// TestGovernanceShadowMode tests that budgets, rate limits and parameter guardrails in shadow mode let requests they
+// would block through unchanged, and that the shadow report compares them with the requests enforced rules blocked,
+// with the labels of the last request each rule blocked
+func TestGovernanceShadowMode(t *testing.T) {
+	now := time.Now()
+	plugin, err := governance.Init(context.Background(), &governance.Config{}, bifrost.NewDefaultLogger(schemas.LogLevelError), nil, &configstore.GovernanceConfig{
+		VirtualKeys: []configstore.TableVirtualKey{
+			{
+				ID: "shadowed", Value: "sk-bf-shadowed", IsActive: true,
+				BudgetID: bifrost.Ptr("shadow-budget"), RateLimitID: bifrost.Ptr("shadow-rl"),
+				ParameterGuardrails: &configstore.ParameterGuardrails{Mode: configstore.GuardrailModeShadow, MaxTemperature: bifrost.Ptr(0.5)},
+			},
+			{ID: "enforced", Value: "sk-bf-enforced", IsActive: true, BudgetID: bifrost.Ptr("enforced-budget")},
+		},
+		Budgets: []configstore.TableBudget{
+			{ID: "shadow-budget", MaxLimit: 1, CurrentUsage: 2, ResetDuration: "1h", LastReset: now, Shadow: true},
+			{ID: "enforced-budget", MaxLimit: 1, CurrentUsage: 2, ResetDuration: "1h", LastReset: now},
+		},
+		RateLimits: []configstore.TableRateLimit{
+			{ID: "shadow-rl", RequestMaxLimit: bifrost.Ptr(int64(1)), RequestCurrentUsage: 1, RequestResetDuration: bifrost.Ptr("1h"), RequestLastReset: now, Shadow: true},
+		},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to init governance: %v", err)
+	}
+
+	preHook := func(virtualKey string) (*schemas.BifrostRequest, *schemas.PluginShortCircuit) {
+		t.Helper()
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, virtualKey)
+		ctx = context.WithValue(ctx, schemas.BifrostContextKeyLabels, map[string]string{"feature": "search"})
+		req, shortCircuit, err := plugin.PreHook(&ctx, &schemas.BifrostRequest{
+			Provider:    schemas.OpenAI,
+			Model:       "gpt-4o-mini",
+			RequestType: schemas.ChatCompletionRequest,
+			ChatRequest: &schemas.BifrostChatRequest{
+				Provider: schemas.OpenAI,
+				Model:    "gpt-4o-mini",
+				Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("hi")}}},
+				Params:   &schemas.ChatParameters{Temperature: bifrost.Ptr(1.0)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return req, shortCircuit
+	}
+
+	req, shortCircuit := preHook("sk-bf-shadowed")
+	if shortCircuit != nil {
+		t.Fatalf("expected shadow rules to let the request through, got %+v", shortCircuit.Error)
+	}
+	if temperature := *req.ChatRequest.Params.Temperature; temperature != 1.0 {
+		t.Errorf("temperature = %g, want the request unchanged", temperature)
+	}
+	if _, shortCircuit := preHook("sk-bf-enforced"); shortCircuit == nil || *shortCircuit.Error.StatusCode != 402 {
+		t.Fatalf("expected the enforced budget to block the request, got %+v", shortCircuit)
+	}
+
+	report := plugin.ShadowReport()
+	if report.Evaluated != 2 || report.Blocked != 1 || report.ShadowBlocked != 1 {
+		t.Errorf("evaluated, blocked, shadow blocked = %d, %d, %d, want 2, 1, 1", report.Evaluated, report.Blocked, report.ShadowBlocked)
+	}
+	want := map[string]bool{"shadow-budget": true, "enforced-budget": false, "shadow-rl": true, "shadowed": true}
+	if len(report.Rules) != len(want) {
+		t.Fatalf("rules = %+v, want %d", report.Rules, len(want))
+	}
+	for _, rule := range report.Rules {
+		if shadow, ok := want[rule.ID]; !ok || rule.Shadow != shadow || rule.Blocked != 1 || rule.LastReason == "" || rule.LastLabels["feature"] != "search" {
+			t.Errorf("unexpected rule %+v", rule)
+		}
+	}
+
+	plugin.ResetShadowReport()
+	if report := plugin.ShadowReport(); report.Evaluated != 0 || len(report.Rules) != 0 {
+		t.Errorf("expected an empty report after a reset, got %+v", report)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// healthCheckTimeout bounds how long a single store ping may take.
+const healthCheckTimeout = 2 * time.Second
+
+// HealthHandler reports whether the server and its storage backends are reachable.
+type HealthHandler struct {
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// NewHealthHandler creates a new health handler instance.
+func NewHealthHandler(config *lib.Config, logger schemas.Logger) *HealthHandler {
+	return &HealthHandler{
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the health check route.
+func (h *HealthHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/health", lib.ChainMiddlewares(h.getHealth, middlewares...))
+}
+
+// getHealth handles GET /health - Ping the config and logs stores
+// Responds 503 when any configured store is unreachable so load balancers can take the replica out of rotation.
+func (h *HealthHandler) getHealth(ctx *fasthttp.RequestCtx) {
+	checks := map[string]string{}
+	healthy := true
+	check := func(name string, ping func(context.Context) error) {
+		pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		if err := ping(pingCtx); err != nil {
+			h.logger.Warn("health check failed for %s: %v", name, err)
+			checks[name] = err.Error()
+			healthy = false
+			return
+		}
+		checks[name] = "ok"
+	}
+	if h.config.ConfigStore != nil {
+		check("config_store", h.config.ConfigStore.Ping)
+	}
+	if h.config.LogsStore != nil {
+		check("logs_store", h.config.LogsStore.Ping)
+	}
+	status := "ok"
+	if !healthy {
+		status = "unhealthy"
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	}
+	SendJSON(ctx, map[string]any{
+		"status": status,
+		"checks": checks,
+	}, h.logger)
+}
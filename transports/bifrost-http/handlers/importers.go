@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// importers parse the exports of other gateways, by the source named in POST /api/import/{source}.
+var importers = map[string]func(body []byte) (*ImportedConfig, error){
+	"litellm": parseLiteLLMExport,
+}
+
+// ImportedConfig is the Bifrost configuration mapped from the export of another gateway.
+type ImportedConfig struct {
+	Keys        map[schemas.ModelProvider][]schemas.Key // Merged into the keys of each provider, by key ID
+	Teams       []ImportedTeam
+	VirtualKeys []ImportedVirtualKey
+	Warnings    []string // Settings that could not be mapped
+}
+
+// ImportedTeam is a governance team of an import. Teams that already exist are left unchanged.
+type ImportedTeam struct {
+	ID        string
+	Name      string
+	Budget    *CreateBudgetRequest
+	RateLimit *CreateRateLimitRequest
+}
+
+// ImportedVirtualKey is a virtual key of an import. Virtual keys that already exist are left unchanged.
+type ImportedVirtualKey struct {
+	ID              string
+	Name            string
+	IsActive        bool
+	TeamID          *string
+	Budget          *CreateBudgetRequest
+	RateLimit       *CreateRateLimitRequest
+	ProviderConfigs []configstore.TableVirtualKeyProviderConfig // Empty allows every provider
+}
+
+// ImportedProviderModel is a model served by a provider.
+type ImportedProviderModel struct {
+	Provider schemas.ModelProvider
+	Model    string
+}
+
+// warn records a setting that could not be mapped.
+func (c *ImportedConfig) warn(format string, args ...any) {
+	c.Warnings = append(c.Warnings, fmt.Sprintf(format, args...))
+}
+
+// ImportResponse is the response of POST /api/import/{source}: the changes planned, or made unless dry_run is set.
+type ImportResponse struct {
+	Source      string                    `json:"source"`
+	DryRun      bool                      `json:"dry_run"`
+	Changes     []ApplyChange             `json:"changes"`
+	Warnings    []string                  `json:"warnings,omitempty"`
+	VirtualKeys []ImportedVirtualKeyValue `json:"virtual_keys,omitempty"` // Values of the virtual keys created
+}
+
+// ImportedVirtualKeyValue is a virtual key created by an import, with the value its clients switch to.
+type ImportedVirtualKeyValue struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ImportHandler imports the configuration of other gateways.
+type ImportHandler struct {
+	apply           *ApplyHandler
+	configStore     configstore.ConfigStore
+	governanceStore *governance.GovernanceStore // nil when governance is disabled
+	logger          schemas.Logger
+}
+
+// NewImportHandler creates a new import handler. Provider keys are applied through apply; configStore and
+// governanceStore may be nil.
+func NewImportHandler(apply *ApplyHandler, configStore configstore.ConfigStore, governanceStore *governance.GovernanceStore, logger schemas.Logger) *ImportHandler {
+	return &ImportHandler{
+		apply:           apply,
+		configStore:     configStore,
+		governanceStore: governanceStore,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes registers the import route.
+func (h *ImportHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST("/api/import/{source}", lib.ChainMiddlewares(h.importConfig, middlewares...))
+}
+
+// importConfig handles POST /api/import/{source} - Import the export of another gateway (?dry_run=true returns the
+// plan only)
+func (h *ImportHandler) importConfig(ctx *fasthttp.RequestCtx) {
+	source, _ := ctx.UserValue("source").(string)
+	parse, ok := importers[source]
+	if !ok {
+		sources := make([]string, 0, len(importers))
+		for name := range importers {
+			sources = append(sources, name)
+		}
+		slices.Sort(sources)
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("unknown import source %q, expected one of: %s", source, strings.Join(sources, ", ")), h.logger)
+		return
+	}
+	imported, err := parse(ctx.PostBody())
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	if (len(imported.Teams) > 0 || len(imported.VirtualKeys) > 0) && h.configStore == nil {
+		SendError(ctx, fasthttp.StatusBadRequest, "Teams and virtual keys require a config store", h.logger)
+		return
+	}
+	dryRun := string(ctx.QueryArgs().Peek("dry_run")) == "true"
+
+	h.apply.mu.Lock()
+	defer h.apply.mu.Unlock()
+
+	desired, err := h.desiredState(imported)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to read providers: %v", err), h.logger)
+		return
+	}
+	if err := validateDesiredState(desired); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	plan, err := h.apply.plan(ctx, desired)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to compute plan: %v", err), h.logger)
+		return
+	}
+	teams, virtualKeys, err := h.newGovernanceEntities(ctx, imported)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to compute plan: %v", err), h.logger)
+		return
+	}
+	changes := plan.changes
+	for _, team := range teams {
+		changes = append(changes, ApplyChange{Resource: "team", Name: team.Name, Action: ApplyActionCreate})
+	}
+	for _, vk := range virtualKeys {
+		changes = append(changes, ApplyChange{Resource: "virtual_key", Name: vk.Name, Action: ApplyActionCreate})
+	}
+	response := ImportResponse{Source: source, DryRun: dryRun, Changes: changes, Warnings: imported.Warnings}
+	if dryRun {
+		SendJSON(ctx, response, h.logger)
+		return
+	}
+
+	// Changes before a failing one stay applied; importing again skips them
+	if err := h.apply.execute(ctx, plan); err != nil {
+		h.logger.Error("failed to import %s providers: %v", source, err)
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to import providers: %v", err), h.logger)
+		return
+	}
+	for _, team := range teams {
+		if err := h.createTeam(ctx, team); err != nil {
+			h.logger.Error("failed to import %s team %s: %v", source, team.Name, err)
+			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to import team %s: %v", team.Name, err), h.logger)
+			return
+		}
+	}
+	for _, vk := range virtualKeys {
+		value, err := h.createVirtualKey(ctx, vk)
+		if err != nil {
+			h.logger.Error("failed to import %s virtual key %s: %v", source, vk.Name, err)
+			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to import virtual key %s: %v", vk.Name, err), h.logger)
+			return
+		}
+		response.VirtualKeys = append(response.VirtualKeys, ImportedVirtualKeyValue{ID: vk.ID, Name: vk.Name, Value: value})
+	}
+	h.logger.Info("imported %s configuration with %d changes", source, len(changes))
+	SendJSON(ctx, response, h.logger)
+}
+
+// desiredState returns the providers with the imported keys merged in. Existing keys stay in their declared form,
+// with env.VAR references, so the plan only lists the imported keys.
+func (h *ImportHandler) desiredState(imported *ImportedConfig) (*DesiredState, error) {
+	desired := &DesiredState{Providers: make(map[schemas.ModelProvider]DesiredProvider, len(imported.Keys))}
+	for provider, keys := range imported.Keys {
+		existing, err := h.apply.store.GetProviderConfigRaw(provider)
+		if err != nil && !errors.Is(err, lib.ErrNotFound) {
+			return nil, err
+		}
+		if existing == nil {
+			desired.Providers[provider] = DesiredProvider{Keys: keys}
+			continue
+		}
+		redacted, err := h.apply.store.GetProviderConfigRedacted(provider)
+		if err != nil {
+			return nil, err
+		}
+		redactedByID := make(map[string]schemas.Key, len(redacted.Keys))
+		for _, key := range redacted.Keys {
+			redactedByID[key.ID] = key
+		}
+		merged := make([]schemas.Key, 0, len(existing.Keys)+len(keys))
+		for _, key := range existing.Keys {
+			if !slices.ContainsFunc(keys, func(k schemas.Key) bool { return k.ID == key.ID }) {
+				merged = append(merged, declaredKey(key, redactedByID[key.ID]))
+			}
+		}
+		desired.Providers[provider] = DesiredProvider{
+			Keys:                     append(merged, keys...),
+			NetworkConfig:            existing.NetworkConfig,
+			ConcurrencyAndBufferSize: existing.ConcurrencyAndBufferSize,
+			ProxyConfig:              existing.ProxyConfig,
+			SendBackRawResponse:      existing.SendBackRawResponse,
+			CustomProviderConfig:     existing.CustomProviderConfig,
+			MockConfig:               existing.MockConfig,
+			RequestShaping:           existing.RequestShaping,
+			SyntheticStreaming:       existing.SyntheticStreaming,
+		}
+	}
+	return desired, nil
+}
+
+// newGovernanceEntities returns the imported teams and virtual keys that do not exist yet, sorted by name.
+func (h *ImportHandler) newGovernanceEntities(ctx context.Context, imported *ImportedConfig) ([]ImportedTeam, []ImportedVirtualKey, error) {
+	var teams []ImportedTeam
+	for _, team := range imported.Teams {
+		if _, err := h.configStore.GetTeam(ctx, team.ID); err == nil {
+			continue
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, configstore.ErrNotFound) {
+			return nil, nil, err
+		}
+		teams = append(teams, team)
+	}
+	var virtualKeys []ImportedVirtualKey
+	for _, vk := range imported.VirtualKeys {
+		if _, err := h.configStore.GetVirtualKey(ctx, vk.ID); err == nil {
+			continue
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) && !errors.Is(err, configstore.ErrNotFound) {
+			return nil, nil, err
+		}
+		virtualKeys = append(virtualKeys, vk)
+	}
+	sort.SliceStable(teams, func(i, j int) bool { return teams[i].Name < teams[j].Name })
+	sort.SliceStable(virtualKeys, func(i, j int) bool { return virtualKeys[i].Name < virtualKeys[j].Name })
+	return teams, virtualKeys, nil
+}
+
+// createTeam creates an imported team with its budget and rate limit.
+func (h *ImportHandler) createTeam(ctx context.Context, imported ImportedTeam) error {
+	team := configstore.TableTeam{ID: imported.ID, Name: imported.Name}
+	if err := h.configStore.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+		if imported.Budget != nil {
+			budget := imported.Budget.toTable()
+			if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
+				return err
+			}
+			team.BudgetID = &budget.ID
+		}
+		if imported.RateLimit != nil {
+			rateLimit := imported.RateLimit.toTable()
+			if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
+				return err
+			}
+			team.RateLimitID = &rateLimit.ID
+		}
+		return h.configStore.CreateTeam(ctx, &team, tx)
+	}); err != nil {
+		return err
+	}
+	if h.governanceStore != nil {
+		preloaded, err := h.configStore.GetTeam(ctx, team.ID)
+		if err != nil {
+			preloaded = &team
+		}
+		h.governanceStore.CreateTeamInMemory(preloaded)
+		if preloaded.Budget != nil {
+			h.governanceStore.CreateBudgetInMemory(preloaded.Budget)
+		}
+	}
+	return nil
+}
+
+// createVirtualKey creates an imported virtual key with a new value, which it returns.
+func (h *ImportHandler) createVirtualKey(ctx context.Context, imported ImportedVirtualKey) (string, error) {
+	vk := configstore.TableVirtualKey{
+		ID:       imported.ID,
+		Name:     imported.Name,
+		Value:    uuid.NewString(),
+		TeamID:   imported.TeamID,
+		IsActive: imported.IsActive,
+	}
+	if err := h.configStore.ExecuteTransaction(ctx, func(tx *gorm.DB) error {
+		if imported.Budget != nil {
+			budget := imported.Budget.toTable()
+			if err := h.configStore.CreateBudget(ctx, &budget, tx); err != nil {
+				return err
+			}
+			vk.BudgetID = &budget.ID
+		}
+		if imported.RateLimit != nil {
+			rateLimit := imported.RateLimit.toTable()
+			if err := h.configStore.CreateRateLimit(ctx, &rateLimit, tx); err != nil {
+				return err
+			}
+			vk.RateLimitID = &rateLimit.ID
+		}
+		if err := h.configStore.CreateVirtualKey(ctx, &vk, tx); err != nil {
+			return err
+		}
+		for _, pc := range imported.ProviderConfigs {
+			pc.VirtualKeyID = vk.ID
+			if err := h.configStore.CreateVirtualKeyProviderConfig(ctx, &pc, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	if h.governanceStore != nil {
+		preloaded, err := h.configStore.GetVirtualKey(ctx, vk.ID)
+		if err != nil {
+			preloaded = &vk
+		}
+		h.governanceStore.CreateVirtualKeyInMemory(preloaded)
+		if preloaded.Budget != nil {
+			h.governanceStore.CreateBudgetInMemory(preloaded.Budget)
+		}
+	}
+	return vk.Value, nil
+}
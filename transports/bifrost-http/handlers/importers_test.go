@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const liteLLMTestExport = `
+model_list:
+  - model_name: gpt-4o
+    litellm_params:
+      model: openai/gpt-4o
+      api_key: os.environ/OPENAI_API_KEY_2
+  - model_name: fast
+    litellm_params:
+      model: groq/llama-3.1-8b-instant
+      api_key: gsk-literal
+      api_base: https://groq.internal
+  - model_name: local
+    litellm_params:
+      model: unknown/model
+general_settings:
+  master_key: sk-1234
+router_settings:
+  routing_strategy: simple-shuffle
+LiteLLM_TeamTable:
+  - team_id: t1
+    team_alias: research
+    max_budget: 100
+    budget_duration: 1mo
+    rpm_limit: 60
+LiteLLM_VerificationToken:
+  - token: 9f86d081
+    key_alias: research-bot
+    team_id: t1
+    models: [gpt-4o]
+    max_budget: 10
+    budget_duration: 30d
+    tpm_limit: 10000
+  - token: 60303ae2
+    key_alias: orphan
+    team_id: gone
+    blocked: true
+`
+
+// TestParseLiteLLMExport tests that deployments, teams and keys map to keys, teams and virtual keys, and that the
+// settings that cannot be mapped are reported
+func TestParseLiteLLMExport(t *testing.T) {
+	imported, err := parseLiteLLMExport([]byte(liteLLMTestExport))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	openai := imported.Keys[schemas.OpenAI]
+	if len(openai) != 1 || openai[0].Value != "env.OPENAI_API_KEY_2" || openai[0].Models[0] != "gpt-4o" {
+		t.Errorf("openai keys = %+v, want the env reference converted", openai)
+	}
+	if groq := imported.Keys[schemas.Groq]; len(groq) != 1 || groq[0].Models[0] != "llama-3.1-8b-instant" {
+		t.Errorf("groq keys = %+v", groq)
+	}
+	if len(imported.Teams) != 1 || imported.Teams[0].Budget.ResetDuration != "1M" || *imported.Teams[0].RateLimit.RequestMaxLimit != 60 {
+		t.Errorf("teams = %+v, want the budget and rate limit mapped", imported.Teams)
+	}
+	if len(imported.VirtualKeys) != 2 {
+		t.Fatalf("virtual keys = %+v", imported.VirtualKeys)
+	}
+	bot, orphan := imported.VirtualKeys[0], imported.VirtualKeys[1]
+	if bot.TeamID == nil || *bot.TeamID != "litellm-team-t1" || len(bot.ProviderConfigs) != 1 || bot.ProviderConfigs[0].AllowedModels[0] != "gpt-4o" {
+		t.Errorf("virtual key = %+v, want the team and allowed models mapped", bot)
+	}
+	if orphan.TeamID != nil || orphan.IsActive {
+		t.Errorf("virtual key = %+v, want a blocked key without its missing team", orphan)
+	}
+	// Unsupported provider, api_base, aliases, unknown team, new values, master_key and router_settings
+	if len(imported.Warnings) != 7 {
+		t.Errorf("warnings = %q", imported.Warnings)
+	}
+
+	if _, err := parseLiteLLMExport([]byte("litellm_settings: {}")); err == nil {
+		t.Errorf("expected an export without models, teams or keys to be refused")
+	}
+}
+
+// TestImportLiteLLM tests that a dry run only reports the changes, that importing merges the keys into the existing
+// providers and creates the teams and virtual keys, and that importing again changes nothing
+func TestImportLiteLLM(t *testing.T) {
+	ctx := context.Background()
+	testLogger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	lib.SetLogger(testLogger)
+	configStore, err := configstore.NewConfigStore(ctx, &configstore.Config{
+		Enabled: true,
+		Type:    configstore.ConfigStoreTypeSQLite,
+		Config:  &configstore.SQLiteConfig{Path: filepath.Join(t.TempDir(), "config.db")},
+	}, testLogger)
+	if err != nil {
+		t.Fatalf("failed to create config store: %v", err)
+	}
+	// The existing OpenAI key of the apply test handler is sourced from env
+	t.Setenv("OPENAI_API_KEY", "sk-resolved")
+	t.Setenv("OPENAI_API_KEY_2", "sk-second")
+	apply := newApplyTestHandler()
+	r := router.New()
+	NewImportHandler(apply, configStore, nil, testLogger).RegisterRoutes(r)
+	post := func(path string) (ImportResponse, int) {
+		ctx := &fasthttp.RequestCtx{}
+		// Init gives the request a context usable by the config store
+		ctx.Init(&fasthttp.Request{}, nil, nil)
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.SetBodyString(liteLLMTestExport)
+		r.Handler(ctx)
+		var response ImportResponse
+		json.Unmarshal(ctx.Response.Body(), &response)
+		return response, ctx.Response.StatusCode()
+	}
+
+	if _, status := post("/api/import/kong"); status != fasthttp.StatusNotFound {
+		t.Errorf("status = %d, want unknown sources refused", status)
+	}
+
+	response, status := post("/api/import/litellm?dry_run=true")
+	if status != fasthttp.StatusOK || !response.DryRun || len(response.Changes) != 7 {
+		t.Fatalf("status = %d, response = %+v, want two providers with their keys, a team and two virtual keys planned", status, response)
+	}
+	if config, _ := apply.store.GetProviderConfigRaw(schemas.OpenAI); len(config.Keys) != 1 {
+		t.Fatalf("openai keys = %+v, want the dry run to change nothing", config.Keys)
+	}
+
+	response, status = post("/api/import/litellm")
+	if status != fasthttp.StatusOK || len(response.VirtualKeys) != 2 || response.VirtualKeys[0].Value == "" {
+		t.Fatalf("status = %d, response = %+v, want the import applied", status, response)
+	}
+	config, _ := apply.store.GetProviderConfigRaw(schemas.OpenAI)
+	if len(config.Keys) != 2 || config.Keys[0].ID != "primary" || config.Keys[1].Value != "sk-second" {
+		t.Errorf("openai keys = %+v, want the imported key added to the existing one", config.Keys)
+	}
+	if _, err := apply.store.GetProviderConfigRaw(schemas.Groq); err != nil {
+		t.Errorf("expected the groq provider to be created: %v", err)
+	}
+	vk, err := configStore.GetVirtualKey(ctx, response.VirtualKeys[1].ID)
+	if err != nil || vk.TeamID == nil || vk.Budget == nil || vk.RateLimit == nil || len(vk.ProviderConfigs) != 1 {
+		t.Errorf("virtual key = %+v, err = %v, want the team, budget, rate limit and models stored", vk, err)
+	}
+
+	if response, status = post("/api/import/litellm?dry_run=true"); status != fasthttp.StatusOK || len(response.Changes) != 0 {
+		t.Errorf("status = %d, changes = %+v, want importing again to change nothing", status, response.Changes)
+	}
+}
@@ -5,7 +5,6 @@ package handlers
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -13,6 +12,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bytedance/sonic"
 	"github.com/fasthttp/router"
@@ -27,9 +27,13 @@ type CompletionHandler struct {
 	client       *bifrost.Bifrost
 	handlerStore lib.HandlerStore
 	logger       schemas.Logger
+	streamLogger schemas.Logger // Sampled logger for per-chunk streaming errors
 	config       *lib.Config
 }
 
+// streamLogSampleRate is how many streaming write failures share a single log line.
+const streamLogSampleRate = 100
+
 // NewInferenceHandler creates a new completion handler instance
 func NewInferenceHandler(client *bifrost.Bifrost, config *lib.Config, logger schemas.Logger) *CompletionHandler {
 	return &CompletionHandler{
@@ -37,6 +41,7 @@ func NewInferenceHandler(client *bifrost.Bifrost, config *lib.Config, logger sch
 		handlerStore: config,
 		config:       config,
 		logger:       logger,
+		streamLogger: bifrost.Sampled(logger, streamLogSampleRate),
 	}
 }
 
@@ -45,6 +50,7 @@ var textParamsKnownFields = map[string]bool{
 	"model":             true,
 	"text":              true,
 	"fallbacks":         true,
+	"max_cost":          true,
 	"best_of":           true,
 	"echo":              true,
 	"frequency_penalty": true,
@@ -71,6 +77,8 @@ var chatParamsKnownFields = map[string]bool{
 	"logit_bias":            true,
 	"logprobs":              true,
 	"max_completion_tokens": true,
+	"max_cost":              true,
+	"max_tokens":            true,
 	"metadata":              true,
 	"modalities":            true,
 	"parallel_tool_calls":   true,
@@ -78,8 +86,10 @@ var chatParamsKnownFields = map[string]bool{
 	"prompt_cache_key":      true,
 	"reasoning_effort":      true,
 	"response_format":       true,
+	"retrieval":             true,
 	"safety_identifier":     true,
 	"service_tier":          true,
+	"session_id":            true,
 	"stream_options":        true,
 	"store":                 true,
 	"temperature":           true,
@@ -94,6 +104,7 @@ var responsesParamsKnownFields = map[string]bool{
 	"model":                true,
 	"input":                true,
 	"fallbacks":            true,
+	"max_cost":             true,
 	"stream":               true,
 	"background":           true,
 	"conversation":         true,
@@ -123,10 +134,22 @@ var embeddingParamsKnownFields = map[string]bool{
 	"model":           true,
 	"input":           true,
 	"fallbacks":       true,
+	"max_cost":        true,
 	"encoding_format": true,
 	"dimensions":      true,
 }
 
+var rerankParamsKnownFields = map[string]bool{
+	"model":              true,
+	"query":              true,
+	"documents":          true,
+	"fallbacks":          true,
+	"top_n":              true,
+	"top_k":              true,
+	"return_documents":   true,
+	"max_tokens_per_doc": true,
+}
+
 var speechParamsKnownFields = map[string]bool{
 	"model":           true,
 	"input":           true,
@@ -157,13 +180,18 @@ type BifrostParams struct {
 }
 
 type TextRequest struct {
-	Prompt *schemas.TextCompletionInput `json:"prompt"`
+	Prompt  *schemas.TextCompletionInput `json:"prompt"`
+	MaxCost *float64                     `json:"max_cost,omitempty"` // Ceiling in USD on the worst-case cost, see the cost_ceiling config
 	BifrostParams
 	*schemas.TextCompletionParameters
 }
 
 type ChatRequest struct {
-	Messages []schemas.ChatMessage `json:"messages"`
+	Messages  []schemas.ChatMessage `json:"messages"`
+	SessionID string                `json:"session_id,omitempty"` // Server-side session to continue, see the sessions config
+	Retrieval *lib.RetrievalOptions `json:"retrieval,omitempty"`  // Retrieval store to inject context from, see the retrieval config
+	MaxTokens *int                  `json:"max_tokens,omitempty"` // Legacy alias of max_completion_tokens
+	MaxCost   *float64              `json:"max_cost,omitempty"`   // Ceiling in USD on the worst-case cost, see the cost_ceiling config
 	BifrostParams
 	*schemas.ChatParameters
 }
@@ -193,18 +221,30 @@ func (r *ResponsesRequestInput) UnmarshalJSON(data []byte) error {
 
 // ResponsesRequest is a bifrost responses request
 type ResponsesRequest struct {
-	Input ResponsesRequestInput `json:"input"`
+	Input   ResponsesRequestInput `json:"input"`
+	MaxCost *float64              `json:"max_cost,omitempty"` // Ceiling in USD on the worst-case cost, see the cost_ceiling config
 	BifrostParams
 	*schemas.ResponsesParameters
 }
 
 // EmbeddingRequest is a bifrost embedding request
 type EmbeddingRequest struct {
-	Input *schemas.EmbeddingInput `json:"input"`
+	Input   *schemas.EmbeddingInput `json:"input"`
+	MaxCost *float64                `json:"max_cost,omitempty"` // Ceiling in USD on the cost, see the cost_ceiling config
 	BifrostParams
 	*schemas.EmbeddingParameters
 }
 
+// RerankRequest is a bifrost rerank request. It accepts the request bodies of the Cohere, Voyage and Jina
+// rerank APIs: documents are strings or objects with a text field, and top_k is read as top_n.
+type RerankRequest struct {
+	Query     string                   `json:"query"`
+	Documents []schemas.RerankDocument `json:"documents"`
+	TopK      *int                     `json:"top_k,omitempty"` // Voyage name of top_n
+	BifrostParams
+	*schemas.RerankParameters
+}
+
 type SpeechRequest struct {
 	*schemas.SpeechInput
 	BifrostParams
@@ -234,27 +274,9 @@ func parseFallbacks(fallbackStrings []string) ([]schemas.Fallback, error) {
 	return fallbacks, nil
 }
 
-// extractExtraParams processes unknown fields from JSON data into ExtraParams
+// extractExtraParams processes unknown fields from JSON data into ExtraParams, flattening extra_body
 func extractExtraParams(data []byte, knownFields map[string]bool) (map[string]interface{}, error) {
-	// Parse JSON to extract unknown fields
-	var rawData map[string]json.RawMessage
-	if err := json.Unmarshal(data, &rawData); err != nil {
-		return nil, err
-	}
-
-	// Extract unknown fields
-	extraParams := make(map[string]interface{})
-	for key, value := range rawData {
-		if !knownFields[key] {
-			var v interface{}
-			if err := json.Unmarshal(value, &v); err != nil {
-				continue // Skip fields that can't be unmarshaled
-			}
-			extraParams[key] = v
-		}
-	}
-
-	return extraParams, nil
+	return lib.ExtraParams(data, knownFields)
 }
 
 const (
@@ -279,6 +301,7 @@ func (h *CompletionHandler) RegisterRoutes(r *router.Router, middlewares ...lib.
 	r.POST("/v1/chat/completions", lib.ChainMiddlewares(h.chatCompletion, middlewares...))
 	r.POST("/v1/responses", lib.ChainMiddlewares(h.responses, middlewares...))
 	r.POST("/v1/embeddings", lib.ChainMiddlewares(h.embeddings, middlewares...))
+	r.POST("/v1/rerank", lib.ChainMiddlewares(h.rerank, middlewares...))
 	r.POST("/v1/audio/speech", lib.ChainMiddlewares(h.speech, middlewares...))
 	r.POST("/v1/audio/transcriptions", lib.ChainMiddlewares(h.transcription, middlewares...))
 }
@@ -306,16 +329,22 @@ func (h *CompletionHandler) textCompletion(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusBadRequest, "prompt is required for text completion", h.logger)
 		return
 	}
+	if req.MaxCost != nil {
+		if err := lib.ValidateMaxCost(*req.MaxCost); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+	}
 	// Extract extra params
 	if req.TextCompletionParameters == nil {
 		req.TextCompletionParameters = &schemas.TextCompletionParameters{}
 	}
 	extraParams, err := extractExtraParams(ctx.PostBody(), textParamsKnownFields)
 	if err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to extract extra params: %v", err))
-	} else {
-		req.TextCompletionParameters.ExtraParams = extraParams
+		SendError(ctx, fasthttp.StatusBadRequest, "Invalid extra parameters: "+err.Error(), h.logger)
+		return
 	}
+	req.TextCompletionParameters.ExtraParams = extraParams
 	// Adding fallback context
 	if h.config.ClientConfig.EnableLiteLLMFallbacks {
 		ctx.SetUserValue(schemas.BifrostContextKey("x-litellm-fallback"), "true")
@@ -334,10 +363,20 @@ func (h *CompletionHandler) textCompletion(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusInternalServerError, "Failed to convert context", h.logger)
 		return
 	}
+	if req.MaxCost != nil {
+		maxCostCtx := context.WithValue(*bifrostCtx, lib.MaxCostContextKey, *req.MaxCost)
+		bifrostCtx = &maxCostCtx
+	}
 	if req.Stream != nil && *req.Stream {
 		h.handleStreamingTextCompletion(ctx, bifrostTextReq, bifrostCtx)
 		return
 	}
+	if h.shouldAggregateStream(*bifrostCtx) {
+		h.handleAggregatedStream(ctx, schemas.TextCompletionRequest, bifrostCtx, func(bifrostCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+			return h.client.TextCompletionStreamRequest(bifrostCtx, bifrostTextReq)
+		})
+		return
+	}
 	resp, bifrostErr := h.client.TextCompletionRequest(*bifrostCtx, bifrostTextReq)
 	if bifrostErr != nil {
 		SendBifrostError(ctx, bifrostErr, h.logger)
@@ -374,18 +413,28 @@ func (h *CompletionHandler) chatCompletion(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusBadRequest, "Messages is required for chat completion", h.logger)
 		return
 	}
+	if req.MaxCost != nil {
+		if err := lib.ValidateMaxCost(*req.MaxCost); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+	}
 
 	// Extract extra params
 	if req.ChatParameters == nil {
 		req.ChatParameters = &schemas.ChatParameters{}
 	}
 
+	if req.ChatParameters.MaxCompletionTokens == nil && req.MaxTokens != nil {
+		req.ChatParameters.MaxCompletionTokens = req.MaxTokens
+	}
+
 	extraParams, err := extractExtraParams(ctx.PostBody(), chatParamsKnownFields)
 	if err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to extract extra params: %v", err))
-	} else {
-		req.ChatParameters.ExtraParams = extraParams
+		SendError(ctx, fasthttp.StatusBadRequest, "Invalid extra parameters: "+err.Error(), h.logger)
+		return
 	}
+	req.ChatParameters.ExtraParams = extraParams
 
 	// Create segregated BifrostChatRequest
 	bifrostChatReq := &schemas.BifrostChatRequest{
@@ -402,6 +451,18 @@ func (h *CompletionHandler) chatCompletion(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusInternalServerError, "Failed to convert context", h.logger)
 		return
 	}
+	if req.SessionID != "" {
+		sessionCtx := context.WithValue(*bifrostCtx, lib.SessionIDContextKey, req.SessionID)
+		bifrostCtx = &sessionCtx
+	}
+	if req.Retrieval != nil {
+		retrievalCtx := context.WithValue(*bifrostCtx, lib.RetrievalOptionsContextKey, req.Retrieval)
+		bifrostCtx = &retrievalCtx
+	}
+	if req.MaxCost != nil {
+		maxCostCtx := context.WithValue(*bifrostCtx, lib.MaxCostContextKey, *req.MaxCost)
+		bifrostCtx = &maxCostCtx
+	}
 
 	// Correlation id logging
 	cid := string(ctx.Request.Header.Peek("x-bf-trace-id"))
@@ -413,6 +474,12 @@ func (h *CompletionHandler) chatCompletion(ctx *fasthttp.RequestCtx) {
 		h.handleStreamingChatCompletion(ctx, bifrostChatReq, bifrostCtx)
 		return
 	}
+	if h.shouldAggregateStream(*bifrostCtx) {
+		h.handleAggregatedStream(ctx, schemas.ChatCompletionRequest, bifrostCtx, func(bifrostCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+			return h.client.ChatCompletionStreamRequest(bifrostCtx, bifrostChatReq)
+		})
+		return
+	}
 
 	resp, bifrostErr := h.client.ChatCompletionRequest(*bifrostCtx, bifrostChatReq)
 	if bifrostErr != nil {
@@ -450,6 +517,12 @@ func (h *CompletionHandler) responses(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusBadRequest, "Input is required for responses", h.logger)
 		return
 	}
+	if req.MaxCost != nil {
+		if err := lib.ValidateMaxCost(*req.MaxCost); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+	}
 
 	// Extract extra params
 	if req.ResponsesParameters == nil {
@@ -458,10 +531,10 @@ func (h *CompletionHandler) responses(ctx *fasthttp.RequestCtx) {
 
 	extraParams, err := extractExtraParams(ctx.PostBody(), responsesParamsKnownFields)
 	if err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to extract extra params: %v", err))
-	} else {
-		req.ResponsesParameters.ExtraParams = extraParams
+		SendError(ctx, fasthttp.StatusBadRequest, "Invalid extra parameters: "+err.Error(), h.logger)
+		return
 	}
+	req.ResponsesParameters.ExtraParams = extraParams
 
 	input := req.Input.ResponsesRequestInputArray
 	if input == nil {
@@ -488,6 +561,10 @@ func (h *CompletionHandler) responses(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusInternalServerError, "Failed to convert context", h.logger)
 		return
 	}
+	if req.MaxCost != nil {
+		maxCostCtx := context.WithValue(*bifrostCtx, lib.MaxCostContextKey, *req.MaxCost)
+		bifrostCtx = &maxCostCtx
+	}
 
 	if req.Stream != nil && *req.Stream {
 		h.handleStreamingResponses(ctx, bifrostResponsesReq, bifrostCtx)
@@ -530,6 +607,12 @@ func (h *CompletionHandler) embeddings(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusBadRequest, "Input is required for embeddings", h.logger)
 		return
 	}
+	if req.MaxCost != nil {
+		if err := lib.ValidateMaxCost(*req.MaxCost); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+	}
 
 	// Extract extra params
 	if req.EmbeddingParameters == nil {
@@ -538,10 +621,10 @@ func (h *CompletionHandler) embeddings(ctx *fasthttp.RequestCtx) {
 
 	extraParams, err := extractExtraParams(ctx.PostBody(), embeddingParamsKnownFields)
 	if err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to extract extra params: %v", err))
-	} else {
-		req.EmbeddingParameters.ExtraParams = extraParams
+		SendError(ctx, fasthttp.StatusBadRequest, "Invalid extra parameters: "+err.Error(), h.logger)
+		return
 	}
+	req.EmbeddingParameters.ExtraParams = extraParams
 
 	// Create segregated BifrostEmbeddingRequest
 	bifrostEmbeddingReq := &schemas.BifrostEmbeddingRequest{
@@ -558,6 +641,10 @@ func (h *CompletionHandler) embeddings(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusInternalServerError, "Failed to convert context", h.logger)
 		return
 	}
+	if req.MaxCost != nil {
+		maxCostCtx := context.WithValue(*bifrostCtx, lib.MaxCostContextKey, *req.MaxCost)
+		bifrostCtx = &maxCostCtx
+	}
 
 	resp, bifrostErr := h.client.EmbeddingRequest(*bifrostCtx, bifrostEmbeddingReq)
 	if bifrostErr != nil {
@@ -569,6 +656,84 @@ func (h *CompletionHandler) embeddings(ctx *fasthttp.RequestCtx) {
 	SendJSON(ctx, resp, h.logger)
 }
 
+// rerank handles POST /v1/rerank - Process rerank requests
+func (h *CompletionHandler) rerank(ctx *fasthttp.RequestCtx) {
+	var req RerankRequest
+	if err := sonic.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+
+	// Create BifrostRerankRequest directly using segregated structure
+	provider, modelName := schemas.ParseModelString(req.Model, "")
+	if provider == "" || modelName == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "model should be in provider/model format", h.logger)
+		return
+	}
+
+	// Parse fallbacks using helper function
+	fallbacks, err := parseFallbacks(req.Fallbacks)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	if req.Query == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "Query is required for rerank", h.logger)
+		return
+	}
+	if len(req.Documents) == 0 {
+		SendError(ctx, fasthttp.StatusBadRequest, "Documents are required for rerank", h.logger)
+		return
+	}
+
+	// Extract extra params
+	if req.RerankParameters == nil {
+		req.RerankParameters = &schemas.RerankParameters{}
+	}
+	if req.TopN == nil {
+		req.TopN = req.TopK
+	}
+
+	extraParams, err := extractExtraParams(ctx.PostBody(), rerankParamsKnownFields)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, "Invalid extra parameters: "+err.Error(), h.logger)
+		return
+	}
+	req.RerankParameters.ExtraParams = extraParams
+
+	documents := make([]string, len(req.Documents))
+	for i, document := range req.Documents {
+		documents[i] = document.Text
+	}
+
+	// Create segregated BifrostRerankRequest
+	bifrostRerankReq := &schemas.BifrostRerankRequest{
+		Provider:  schemas.ModelProvider(provider),
+		Model:     modelName,
+		Query:     req.Query,
+		Documents: documents,
+		Params:    req.RerankParameters,
+		Fallbacks: fallbacks,
+	}
+
+	// Convert context
+	bifrostCtx := lib.ConvertToBifrostContext(ctx, h.handlerStore.ShouldAllowDirectKeys())
+	if bifrostCtx == nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, "Failed to convert context", h.logger)
+		return
+	}
+
+	resp, bifrostErr := h.client.RerankRequest(*bifrostCtx, bifrostRerankReq)
+	if bifrostErr != nil {
+		SendBifrostError(ctx, bifrostErr, h.logger)
+		return
+	}
+
+	// Send successful response
+	SendJSON(ctx, resp, h.logger)
+}
+
 // speech handles POST /v1/audio/speech - Process speech completion requests
 func (h *CompletionHandler) speech(ctx *fasthttp.RequestCtx) {
 	var req SpeechRequest
@@ -613,10 +778,10 @@ func (h *CompletionHandler) speech(ctx *fasthttp.RequestCtx) {
 
 	extraParams, err := extractExtraParams(ctx.PostBody(), speechParamsKnownFields)
 	if err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to extract extra params: %v", err))
-	} else {
-		req.SpeechParameters.ExtraParams = extraParams
+		SendError(ctx, fasthttp.StatusBadRequest, "Invalid extra parameters: "+err.Error(), h.logger)
+		return
 	}
+	req.SpeechParameters.ExtraParams = extraParams
 
 	// Create segregated BifrostSpeechRequest
 	bifrostSpeechReq := &schemas.BifrostSpeechRequest{
@@ -773,47 +938,47 @@ func (h *CompletionHandler) transcription(ctx *fasthttp.RequestCtx) {
 
 // handleStreamingTextCompletion handles streaming text completion requests using Server-Sent Events (SSE)
 func (h *CompletionHandler) handleStreamingTextCompletion(ctx *fasthttp.RequestCtx, req *schemas.BifrostTextCompletionRequest, bifrostCtx *context.Context) {
-	getStream := func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
-		return h.client.TextCompletionStreamRequest(*bifrostCtx, req)
+	getStream := func(streamCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return h.client.TextCompletionStreamRequest(streamCtx, req)
 	}
 
 	extractResponse := func(response *schemas.BifrostStream) (interface{}, bool) {
 		return response, true
 	}
 
-	h.handleStreamingResponse(ctx, getStream, extractResponse)
+	h.handleStreamingResponse(ctx, bifrostCtx, getStream, extractResponse)
 }
 
 // handleStreamingChatCompletion handles streaming chat completion requests using Server-Sent Events (SSE)
 func (h *CompletionHandler) handleStreamingChatCompletion(ctx *fasthttp.RequestCtx, req *schemas.BifrostChatRequest, bifrostCtx *context.Context) {
-	getStream := func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
-		return h.client.ChatCompletionStreamRequest(*bifrostCtx, req)
+	getStream := func(streamCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return h.client.ChatCompletionStreamRequest(streamCtx, req)
 	}
 
 	extractResponse := func(response *schemas.BifrostStream) (interface{}, bool) {
 		return response, true
 	}
 
-	h.handleStreamingResponse(ctx, getStream, extractResponse)
+	h.handleStreamingResponse(ctx, bifrostCtx, getStream, extractResponse)
 }
 
 // handleStreamingResponses handles streaming responses requests using Server-Sent Events (SSE)
 func (h *CompletionHandler) handleStreamingResponses(ctx *fasthttp.RequestCtx, req *schemas.BifrostResponsesRequest, bifrostCtx *context.Context) {
-	getStream := func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
-		return h.client.ResponsesStreamRequest(*bifrostCtx, req)
+	getStream := func(streamCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return h.client.ResponsesStreamRequest(streamCtx, req)
 	}
 
 	extractResponse := func(response *schemas.BifrostStream) (interface{}, bool) {
 		return response, true
 	}
 
-	h.handleStreamingResponse(ctx, getStream, extractResponse)
+	h.handleStreamingResponse(ctx, bifrostCtx, getStream, extractResponse)
 }
 
 // handleStreamingSpeech handles streaming speech requests using Server-Sent Events (SSE)
 func (h *CompletionHandler) handleStreamingSpeech(ctx *fasthttp.RequestCtx, req *schemas.BifrostSpeechRequest, bifrostCtx *context.Context) {
-	getStream := func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
-		return h.client.SpeechStreamRequest(*bifrostCtx, req)
+	getStream := func(streamCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return h.client.SpeechStreamRequest(streamCtx, req)
 	}
 
 	extractResponse := func(response *schemas.BifrostStream) (interface{}, bool) {
@@ -823,13 +988,13 @@ func (h *CompletionHandler) handleStreamingSpeech(ctx *fasthttp.RequestCtx, req
 		return response.Speech, true
 	}
 
-	h.handleStreamingResponse(ctx, getStream, extractResponse)
+	h.handleStreamingResponse(ctx, bifrostCtx, getStream, extractResponse)
 }
 
 // handleStreamingTranscriptionRequest handles streaming transcription requests using Server-Sent Events (SSE)
 func (h *CompletionHandler) handleStreamingTranscriptionRequest(ctx *fasthttp.RequestCtx, req *schemas.BifrostTranscriptionRequest, bifrostCtx *context.Context) {
-	getStream := func() (chan *schemas.BifrostStream, *schemas.BifrostError) {
-		return h.client.TranscriptionStreamRequest(*bifrostCtx, req)
+	getStream := func(streamCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return h.client.TranscriptionStreamRequest(streamCtx, req)
 	}
 
 	extractResponse := func(response *schemas.BifrostStream) (interface{}, bool) {
@@ -839,11 +1004,13 @@ func (h *CompletionHandler) handleStreamingTranscriptionRequest(ctx *fasthttp.Re
 		return response.Transcribe, true
 	}
 
-	h.handleStreamingResponse(ctx, getStream, extractResponse)
+	h.handleStreamingResponse(ctx, bifrostCtx, getStream, extractResponse)
 }
 
-// handleStreamingResponse is a generic function to handle streaming responses using Server-Sent Events (SSE)
-func (h *CompletionHandler) handleStreamingResponse(ctx *fasthttp.RequestCtx, getStream func() (chan *schemas.BifrostStream, *schemas.BifrostError), extractResponse func(*schemas.BifrostStream) (interface{}, bool)) {
+// handleStreamingResponse is a generic function to handle streaming responses using Server-Sent Events (SSE).
+// The stream is requested with a context cancelled once the writer ends, so a client disconnecting midway cancels
+// the upstream request instead of leaving the provider generating for no one.
+func (h *CompletionHandler) handleStreamingResponse(ctx *fasthttp.RequestCtx, bifrostCtx *context.Context, getStream func(context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError), extractResponse func(*schemas.BifrostStream) (interface{}, bool)) {
 	// Set SSE headers
 	ctx.SetContentType("text/event-stream")
 	ctx.Response.Header.Set("Cache-Control", "no-cache")
@@ -851,8 +1018,10 @@ func (h *CompletionHandler) handleStreamingResponse(ctx *fasthttp.RequestCtx, ge
 	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
 
 	// Get the streaming channel
-	stream, bifrostErr := getStream()
+	streamCtx, cancel := context.WithCancel(*bifrostCtx)
+	stream, bifrostErr := getStream(streamCtx)
 	if bifrostErr != nil {
+		cancel()
 		// Send error in SSE format
 		SendSSEError(ctx, bifrostErr, h.logger)
 		return
@@ -860,10 +1029,24 @@ func (h *CompletionHandler) handleStreamingResponse(ctx *fasthttp.RequestCtx, ge
 
 	// Use streaming response writer
 	ctx.Response.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer lib.TrackStream()()
+		defer cancel()
 		defer w.Flush()
 
+		heartbeat := time.NewTicker(lib.SSEHeartbeatInterval)
+		defer heartbeat.Stop()
+
 		// Process streaming responses
-		for response := range stream {
+		for {
+			response, ok, err := lib.ReceiveStreamChunk(w, stream, heartbeat.C)
+			if err != nil {
+				h.streamLogger.Debug("client went away, abandoning stream: %v", err)
+				lib.AbandonStream(cancel, stream)
+				return
+			}
+			if !ok {
+				break
+			}
 			if response == nil {
 				continue
 			}
@@ -874,33 +1057,77 @@ func (h *CompletionHandler) handleStreamingResponse(ctx *fasthttp.RequestCtx, ge
 				continue
 			}
 
-			// Convert response to JSON
-			responseJSON, err := sonic.Marshal(data)
-			if err != nil {
-				h.logger.Warn(fmt.Sprintf("Failed to marshal streaming response: %v", err))
-				continue
-			}
-
-			// Send as SSE data
-			if _, err := fmt.Fprintf(w, "data: %s\n\n", responseJSON); err != nil {
-				h.logger.Warn(fmt.Sprintf("Failed to write SSE data: %v", err))
-				break
+			// Send as SSE data, encoded into a pooled buffer
+			if err := lib.WriteSSEJSON(w, data); err != nil {
+				if lib.IsSSEEncodeError(err) {
+					h.streamLogger.Warn("failed to marshal streaming response: %v", err)
+					continue
+				}
+				h.streamLogger.Debug("failed to write SSE data, abandoning stream: %v", err)
+				lib.AbandonStream(cancel, stream)
+				return
 			}
 
 			// Flush immediately to send the chunk
 			if err := w.Flush(); err != nil {
-				h.logger.Warn(fmt.Sprintf("Failed to flush SSE data: %v", err))
-				break
+				h.streamLogger.Debug("failed to flush SSE data, abandoning stream: %v", err)
+				lib.AbandonStream(cancel, stream)
+				return
 			}
 		}
 
 		// Send the [DONE] marker to indicate the end of the stream
-		if _, err := fmt.Fprint(w, "data: [DONE]\n\n"); err != nil {
-			h.logger.Warn(fmt.Sprintf("Failed to write SSE done marker: %v", err))
+		if _, err := w.Write(lib.SSEDoneFrame); err != nil {
+			h.streamLogger.Warn("failed to write SSE done marker: %v", err)
 		}
 	})
 }
 
+// shouldAggregateStream reports whether a non-streaming completion is streamed from the provider and aggregated,
+// opted into with the x-bf-stream-aggregation header or by the stream aggregation policy of its virtual key
+func (h *CompletionHandler) shouldAggregateStream(bifrostCtx context.Context) bool {
+	if aggregate, _ := bifrostCtx.Value(lib.StreamAggregationHeaderContextKey).(bool); aggregate {
+		return true
+	}
+	if h.config == nil || h.config.StreamAggregation == nil {
+		return false
+	}
+	virtualKey, _ := bifrostCtx.Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	return h.config.StreamAggregation.Applies(virtualKey)
+}
+
+// handleAggregatedStream streams a completion from the provider and sends the response aggregated from its chunks
+// as JSON. The chunks are buffered by Bifrost until the stream ends, so a stream failing midway falls back instead
+// of returning a partial response.
+func (h *CompletionHandler) handleAggregatedStream(ctx *fasthttp.RequestCtx, requestType schemas.RequestType, bifrostCtx *context.Context, getStream func(context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError)) {
+	stream, bifrostErr := getStream(context.WithValue(*bifrostCtx, schemas.BifrostContextKeyBufferStream, true))
+	if bifrostErr != nil {
+		SendBifrostError(ctx, bifrostErr, h.logger)
+		return
+	}
+
+	var chunks []*schemas.BifrostResponse
+	for response := range stream {
+		if response == nil {
+			continue
+		}
+		if response.BifrostError != nil {
+			// Let the stream end without blocking Bifrost
+			go func() {
+				for range stream {
+				}
+			}()
+			SendBifrostError(ctx, response.BifrostError, h.logger)
+			return
+		}
+		if response.BifrostResponse != nil {
+			chunks = append(chunks, response.BifrostResponse)
+		}
+	}
+
+	SendJSON(ctx, lib.AggregateStream(requestType, chunks), h.logger)
+}
+
 // validateAudioFile checks if the file size and format are valid
 func (h *CompletionHandler) validateAudioFile(fileHeader *multipart.FileHeader) error {
 	// Check file size
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/valyala/fasthttp"
+)
+
+// TestHandleStreamingResponse_WritesSSEFrames tests that streamed chunks are written as SSE data frames,
+// skipping chunks without a response, and that the stream ends with the [DONE] marker
+func TestHandleStreamingResponse_WritesSSEFrames(t *testing.T) {
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	h := &CompletionHandler{logger: logger, streamLogger: logger}
+
+	stream := make(chan *schemas.BifrostStream, 3)
+	stream <- &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{ID: "chunk-1"}}
+	stream <- &schemas.BifrostStream{}
+	stream <- &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{ID: "chunk-2"}}
+	close(stream)
+
+	ctx := &fasthttp.RequestCtx{}
+	bifrostCtx := context.Background()
+	h.handleStreamingResponse(ctx, &bifrostCtx, func(context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		return stream, nil
+	}, func(response *schemas.BifrostStream) (interface{}, bool) {
+		if response.BifrostResponse == nil {
+			return nil, false
+		}
+		return map[string]string{"id": response.BifrostResponse.ID}, true
+	})
+
+	// The body stream writer runs when the response body is read
+	expected := "data: {\"id\":\"chunk-1\"}\n\ndata: {\"id\":\"chunk-2\"}\n\ndata: [DONE]\n\n"
+	if body := string(ctx.Response.Body()); body != expected {
+		t.Errorf("expected %q, got %q", expected, body)
+	}
+	if contentType := string(ctx.Response.Header.ContentType()); contentType != "text/event-stream" {
+		t.Errorf("expected an event stream, got %q", contentType)
+	}
+}
+
+// TestHandleStreamingResponse_CancelsAbandonedStreams tests that a client going away mid-stream cancels the context
+// the stream was requested with, and that the stream is counted as abandoned
+func TestHandleStreamingResponse_CancelsAbandonedStreams(t *testing.T) {
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	h := &CompletionHandler{logger: logger, streamLogger: logger}
+	abandoned := testutil.ToFloat64(lib.AbandonedStreams)
+
+	// The provider streams until its request is cancelled
+	var streamCtx context.Context
+	ctx := &fasthttp.RequestCtx{}
+	bifrostCtx := context.Background()
+	h.handleStreamingResponse(ctx, &bifrostCtx, func(requestCtx context.Context) (chan *schemas.BifrostStream, *schemas.BifrostError) {
+		streamCtx = requestCtx
+		stream := make(chan *schemas.BifrostStream)
+		go func() {
+			defer close(stream)
+			for {
+				select {
+				case stream <- &schemas.BifrostStream{BifrostResponse: &schemas.BifrostResponse{ID: "chunk"}}:
+				case <-requestCtx.Done():
+					return
+				}
+			}
+		}()
+		return stream, nil
+	}, func(response *schemas.BifrostStream) (interface{}, bool) {
+		return response, true
+	})
+
+	// The client takes the first bytes and disconnects
+	ctx.Response.BodyWriteTo(&failingWriter{limit: 64})
+	select {
+	case <-streamCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the stream of the disconnected client to be cancelled")
+	}
+	if got := testutil.ToFloat64(lib.AbandonedStreams) - abandoned; got != 1 {
+		t.Errorf("expected one abandoned stream, got %v", got)
+	}
+}
+
+// failingWriter accepts limit bytes, then fails like a closed connection
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, io.ErrClosedPipe
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+// TestWriteSSEJSON_ReusesBuffers tests that writing SSE frames to a buffered writer reuses pooled buffers
+// instead of allocating the encoded chunk per frame
+func TestWriteSSEJSON_ReusesBuffers(t *testing.T) {
+	chunk := &schemas.BifrostResponse{ID: "chatcmpl-1", Model: "gpt-4o-mini", Object: "chat.completion.chunk"}
+	w := bufio.NewWriter(io.Discard)
+	if err := lib.WriteSSEJSON(w, chunk); err != nil {
+		t.Fatalf("failed to write frame: %v", err)
+	}
+
+	if !raceDetectorEnabled {
+		allocs := testing.AllocsPerRun(100, func() {
+			lib.WriteSSEJSON(w, chunk)
+		})
+		// The encoder boxes the value it is given, which is the only allocation left
+		if allocs > 1 {
+			t.Errorf("expected at most one allocation per frame, got %v", allocs)
+		}
+	}
+
+	if err := lib.WriteSSEJSON(w, make(chan int)); !lib.IsSSEEncodeError(err) {
+		t.Errorf("expected an encode error for an unsupported value, got %v", err)
+	}
+}
+
+// TestRerank_AcceptsProviderFormats tests that /v1/rerank accepts Voyage style bodies with object documents and
+// top_k, and that requests larger than a provider call are reranked in batches whose results are merged
+func TestRerank_AcceptsProviderFormats(t *testing.T) {
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: raceTestAccount{},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	h := NewInferenceHandler(client, &lib.Config{}, logger)
+
+	rerank := func(body string) *schemas.BifrostResponse {
+		t.Helper()
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBody([]byte(body))
+		h.rerank(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+		var resp schemas.BifrostResponse
+		if err := json.Unmarshal(ctx.Response.Body(), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return &resp
+	}
+
+	resp := rerank(`{"model": "mock/rerank", "query": "red apple", "documents": [{"text": "green pear"}, "red apple pie", "apple"], "top_k": 2, "return_documents": true}`)
+	if len(resp.Results) != 2 || resp.Results[0].Index != 1 || resp.Results[1].Index != 2 {
+		t.Fatalf("expected documents 1 and 2 by relevance, got %+v", resp.Results)
+	}
+	if resp.Results[0].Document == nil || resp.Results[0].Document.Text != "red apple pie" {
+		t.Errorf("expected the text of the document in the result, got %+v", resp.Results[0].Document)
+	}
+
+	documents := make([]string, 2500)
+	for i := range documents {
+		documents[i] = fmt.Sprintf("%q", "filler")
+	}
+	documents[1800] = `"red apple"`
+	documents[30] = `"apple"`
+	resp = rerank(`{"model": "mock/rerank", "query": "red apple", "documents": [` + strings.Join(documents, ",") + `], "top_n": 2}`)
+	if len(resp.Results) != 2 || resp.Results[0].Index != 1800 || resp.Results[1].Index != 30 || resp.Results[0].Document != nil {
+		t.Errorf("expected documents 1800 and 30 across batches, got %+v", resp.Results)
+	}
+	if resp.Usage == nil || resp.Usage.PromptTokens == 0 {
+		t.Errorf("expected the usage of every batch, got %+v", resp.Usage)
+	}
+}
+
+// declarativeTestAccount configures acme, a declarative custom provider served by baseURL
+type declarativeTestAccount struct {
+	baseURL string
+	spec    *schemas.ProviderSpec
+}
+
+func (a declarativeTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{"acme"}, nil
+}
+
+func (a declarativeTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	return []schemas.Key{{ID: "acme-key", Value: "secret", Weight: 1}}, nil
+}
+
+func (a declarativeTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	return &schemas.ProviderConfig{
+		NetworkConfig:            schemas.NetworkConfig{BaseURL: a.baseURL},
+		ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+		CustomProviderConfig:     &schemas.CustomProviderConfig{BaseProviderType: schemas.Declarative, Spec: a.spec},
+	}, nil
+}
+
+// TestDeclarativeProvider tests that a provider defined by a spec sends the mapped requests with the rendered auth
+// header and path, and maps completions, NDJSON streams, embeddings and errors back
+func TestDeclarativeProvider(t *testing.T) {
+	var spec schemas.ProviderSpec
+	if err := json.Unmarshal([]byte(`{
+		"auth_header": "X-Api-Key",
+		"auth_template": "Token {{.Key}}",
+		"chat_completion": {
+			"path": "/generate/{{.Model}}",
+			"request": {"inputs": ".messages[-1].content", "parameters.max_new_tokens": ".max_completion_tokens // .max_tokens", "stream": false},
+			"response": {"content": ".result.text", "finish_reason": ".stop", "prompt_tokens": ".meta.in", "completion_tokens": ".meta.out", "error": ".detail"},
+			"stream": {"format": "ndjson", "request": {"stream": true}, "chunk": {"content": ".token", "finish_reason": ".stop", "prompt_tokens": ".in", "completion_tokens": ".out"}}
+		},
+		"embedding": {
+			"path": "/embed",
+			"request": {"texts": ".input"},
+			"response": {"embeddings": ".vectors[].values"}
+		}
+	}`), &spec); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	if err := spec.Validate(); err != nil {
+		t.Fatalf("spec refused: %v", err)
+	}
+
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+		if r.Header.Get("X-Api-Key") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/generate/broken":
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"detail": "unknown model"}`)
+		case r.URL.Path == "/generate/tiny" && body["stream"] == true:
+			io.WriteString(w, "{\"token\": \"Hel\"}\n{\"token\": \"lo\"}\n{\"token\": \"\", \"stop\": \"length\", \"in\": 3, \"out\": 2}\n")
+		case r.URL.Path == "/generate/tiny":
+			io.WriteString(w, `{"result": {"text": ["Hel", "lo"]}, "stop": "length", "meta": {"in": 3, "out": 2}}`)
+		case r.URL.Path == "/embed":
+			io.WriteString(w, `{"vectors": [{"values": [0.5, 1]}, {"values": [1, 0.5]}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: declarativeTestAccount{baseURL: server.URL, spec: &spec},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+
+	chat := &schemas.BifrostChatRequest{
+		Provider: "acme",
+		Model:    "tiny",
+		Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Hi")}}},
+		Params:   &schemas.ChatParameters{MaxCompletionTokens: bifrost.Ptr(5)},
+	}
+	resp, bifrostErr := client.ChatCompletionRequest(context.Background(), chat)
+	if bifrostErr != nil {
+		t.Fatalf("chat completion failed: %v", bifrostErr.Error.Message)
+	}
+	if requests[0]["inputs"] != "Hi" || requests[0]["stream"] != false || requests[0]["parameters"].(map[string]any)["max_new_tokens"] != float64(5) {
+		t.Errorf("request body = %v, want the mapped fields", requests[0])
+	}
+	choice := resp.Choices[0]
+	if *choice.Message.Content.ContentStr != "Hello" || *choice.FinishReason != "length" || resp.Usage.TotalTokens != 5 {
+		t.Errorf("response = %+v, want the mapped completion and usage", resp)
+	}
+
+	stream, bifrostErr := client.ChatCompletionStreamRequest(context.Background(), chat)
+	if bifrostErr != nil {
+		t.Fatalf("chat completion stream failed: %v", bifrostErr.Error.Message)
+	}
+	var text string
+	var last *schemas.BifrostResponse
+	for chunk := range stream {
+		if chunk.BifrostError != nil {
+			t.Fatalf("stream error: %v", chunk.BifrostError.Error.Message)
+		}
+		last = chunk.BifrostResponse
+		if delta := last.Choices[0].Delta; delta != nil && delta.Content != nil {
+			text += *delta.Content
+		}
+	}
+	if text != "Hello" || last == nil || last.Usage == nil || last.Usage.TotalTokens != 5 || *last.Choices[0].FinishReason != "length" {
+		t.Errorf("streamed %q, last chunk %+v, want the chunks and the usage of the stream", text, last)
+	}
+
+	chat.Model = "broken"
+	if _, bifrostErr := client.ChatCompletionRequest(context.Background(), chat); bifrostErr == nil || bifrostErr.Error.Message != "unknown model" {
+		t.Errorf("error = %+v, want the mapped error message", bifrostErr)
+	}
+
+	embeddings, bifrostErr := client.EmbeddingRequest(context.Background(), &schemas.BifrostEmbeddingRequest{
+		Provider: "acme",
+		Model:    "embed",
+		Input:    &schemas.EmbeddingInput{Texts: []string{"a", "b"}},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("embedding failed: %v", bifrostErr.Error.Message)
+	}
+	if len(embeddings.Data) != 2 || embeddings.Data[1].Embedding.EmbeddingArray[0] != 1 {
+		t.Errorf("embeddings = %+v, want both vectors", embeddings.Data)
+	}
+
+	if _, bifrostErr := client.TextCompletionRequest(context.Background(), &schemas.BifrostTextCompletionRequest{
+		Provider: "acme",
+		Model:    "tiny",
+		Input:    &schemas.TextCompletionInput{PromptStr: bifrost.Ptr("Hi")},
+	}); bifrostErr == nil {
+		t.Errorf("expected operations missing from the spec to be unsupported")
+	}
+}
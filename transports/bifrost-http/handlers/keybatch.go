@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/valyala/fasthttp"
+)
+
+// maxKeyBatchSize bounds the keys of one batch request.
+const maxKeyBatchSize = 1000
+
+// Statuses of the keys of a batch.
+const (
+	BatchKeyStatusCreated    = "created"
+	BatchKeyStatusUpdated    = "updated"
+	BatchKeyStatusDeleted    = "deleted"
+	BatchKeyStatusFailed     = "failed"      // The key is invalid or its provider failed to update
+	BatchKeyStatusSkipped    = "skipped"     // The key is valid but an atomic batch failed on another key
+	BatchKeyStatusRolledBack = "rolled_back" // The key was applied, then undone when an atomic batch failed
+)
+
+// BatchKey is a key of POST /api/keys:batchCreate and POST /api/keys:batchUpdate. Updated keys are matched by
+// ID; redacted values echoed back from GET keep the stored ones.
+type BatchKey struct {
+	Provider schemas.ModelProvider `json:"provider"`
+	Key      schemas.Key           `json:"key"`
+}
+
+// BatchKeysRequest is the body of POST /api/keys:batchCreate and POST /api/keys:batchUpdate.
+type BatchKeysRequest struct {
+	Keys   []BatchKey `json:"keys"`
+	Atomic *bool      `json:"atomic,omitempty"` // Apply all keys or none, the default
+}
+
+// BatchKeyRef is a key of POST /api/keys:batchDelete.
+type BatchKeyRef struct {
+	Provider schemas.ModelProvider `json:"provider"`
+	KeyID    string                `json:"key_id"`
+}
+
+// BatchDeleteKeysRequest is the body of POST /api/keys:batchDelete.
+type BatchDeleteKeysRequest struct {
+	Keys   []BatchKeyRef `json:"keys"`
+	Atomic *bool         `json:"atomic,omitempty"` // Delete all keys or none, the default
+}
+
+// BatchKeyResult is the outcome of one key of a batch, in request order.
+type BatchKeyResult struct {
+	Index    int                   `json:"index"`
+	Provider schemas.ModelProvider `json:"provider"`
+	KeyID    string                `json:"key_id,omitempty"`
+	Status   string                `json:"status"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// BatchKeysResponse is the response of the batch key endpoints. It is sent with 200 when every key succeeded, 207
+// when a non-atomic batch partially failed, 400 when an atomic batch was refused and 500 when it was rolled back.
+type BatchKeysResponse struct {
+	Atomic    bool             `json:"atomic"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BatchKeyResult `json:"results"`
+}
+
+// batchKeyOp is the operation of a batch.
+type batchKeyOp int
+
+const (
+	batchKeyCreate batchKeyOp = iota
+	batchKeyUpdate
+	batchKeyDelete
+)
+
+// batchCreateKeys handles POST /api/keys:batchCreate - Add keys to their providers in bulk
+func (h *ProviderHandler) batchCreateKeys(ctx *fasthttp.RequestCtx) {
+	var req BatchKeysRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	h.batchKeys(ctx, batchKeyCreate, req.Keys, req.Atomic == nil || *req.Atomic)
+}
+
+// batchUpdateKeys handles POST /api/keys:batchUpdate - Replace existing keys in bulk
+func (h *ProviderHandler) batchUpdateKeys(ctx *fasthttp.RequestCtx) {
+	var req BatchKeysRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	h.batchKeys(ctx, batchKeyUpdate, req.Keys, req.Atomic == nil || *req.Atomic)
+}
+
+// batchDeleteKeys handles POST /api/keys:batchDelete - Remove keys from their providers in bulk
+func (h *ProviderHandler) batchDeleteKeys(ctx *fasthttp.RequestCtx) {
+	var req BatchDeleteKeysRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	keys := make([]BatchKey, len(req.Keys))
+	for i, ref := range req.Keys {
+		keys[i] = BatchKey{Provider: ref.Provider, Key: schemas.Key{ID: ref.KeyID}}
+	}
+	h.batchKeys(ctx, batchKeyDelete, keys, req.Atomic == nil || *req.Atomic)
+}
+
+// batchKeys validates every key of a batch, then updates each provider once with its keys. An atomic batch is
+// refused when a key is invalid, and the providers already updated are restored when a provider fails to update.
+// A non-atomic batch applies the valid keys and reports the others.
+func (h *ProviderHandler) batchKeys(ctx *fasthttp.RequestCtx, op batchKeyOp, keys []BatchKey, atomic bool) {
+	if len(keys) == 0 {
+		SendError(ctx, fasthttp.StatusBadRequest, "keys is required", h.logger)
+		return
+	}
+	if len(keys) > maxKeyBatchSize {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("a batch holds at most %d keys", maxKeyBatchSize), h.logger)
+		return
+	}
+
+	// Batches read and replace whole key lists, so two batches must not interleave
+	h.batchMu.Lock()
+	defer h.batchMu.Unlock()
+
+	existingIDs := make(map[string]bool)
+	allKeys, err := h.store.GetAllKeys()
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get keys: %v", err), h.logger)
+		return
+	}
+	for _, key := range allKeys {
+		existingIDs[key.KeyID] = true
+	}
+
+	response := BatchKeysResponse{Atomic: atomic, Results: make([]BatchKeyResult, len(keys))}
+	raw := make(map[schemas.ModelProvider]*configstore.ProviderConfig)
+	var providers []schemas.ModelProvider
+	seen := make(map[string]bool)
+	for i := range keys {
+		if op == batchKeyCreate && keys[i].Key.ID == "" {
+			keys[i].Key.ID = uuid.NewString()
+		}
+		item := keys[i]
+		result := &response.Results[i]
+		*result = BatchKeyResult{Index: i, Provider: item.Provider, KeyID: item.Key.ID}
+		fail := func(format string, args ...any) {
+			result.Status = BatchKeyStatusFailed
+			result.Error = fmt.Sprintf(format, args...)
+		}
+		if item.Provider == "" {
+			fail("provider is required")
+			continue
+		}
+		config, ok := raw[item.Provider]
+		if !ok {
+			if config, err = h.store.GetProviderConfigRaw(item.Provider); err != nil {
+				fail("unknown provider %s", item.Provider)
+				continue
+			}
+			raw[item.Provider] = config
+			providers = append(providers, item.Provider)
+		}
+		switch {
+		case item.Key.ID == "":
+			fail("key id is required")
+		case seen[item.Key.ID]:
+			fail("key %s appears more than once in the batch", item.Key.ID)
+		case op == batchKeyCreate && existingIDs[item.Key.ID]:
+			fail("key %s already exists", item.Key.ID)
+		case op == batchKeyCreate && item.Key.Value == "" && item.Key.AzureKeyConfig == nil && item.Key.VertexKeyConfig == nil && item.Key.BedrockKeyConfig == nil:
+			fail("key %s has no value", item.Key.ID)
+		case op != batchKeyCreate && !slices.ContainsFunc(config.Keys, func(k schemas.Key) bool { return k.ID == item.Key.ID }):
+			fail("key %s does not exist on provider %s", item.Key.ID, item.Provider)
+		}
+		seen[item.Key.ID] = true
+	}
+
+	failed := slices.ContainsFunc(response.Results, func(r BatchKeyResult) bool { return r.Status == BatchKeyStatusFailed })
+	if atomic && failed {
+		for i := range response.Results {
+			if response.Results[i].Status == "" {
+				response.Results[i].Status = BatchKeyStatusSkipped
+			}
+		}
+		h.sendBatchKeys(ctx, fasthttp.StatusBadRequest, response)
+		return
+	}
+
+	// Each provider is updated once with the keys of the batch
+	var applied []schemas.ModelProvider
+	previous := make(map[schemas.ModelProvider]configstore.ProviderConfig)
+	for _, provider := range providers {
+		var indexes []int
+		var toAdd, toUpdate, toDelete []schemas.Key
+		for i, item := range keys {
+			if item.Provider != provider || response.Results[i].Status != "" {
+				continue
+			}
+			indexes = append(indexes, i)
+			switch op {
+			case batchKeyCreate:
+				toAdd = append(toAdd, item.Key)
+			case batchKeyUpdate:
+				toUpdate = append(toUpdate, item.Key)
+			case batchKeyDelete:
+				toDelete = append(toDelete, item.Key)
+			}
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+		err := h.updateProviderKeys(ctx, provider, raw[provider], toAdd, toUpdate, toDelete, previous)
+		if err == nil {
+			applied = append(applied, provider)
+			for _, i := range indexes {
+				response.Results[i].Status = [...]string{BatchKeyStatusCreated, BatchKeyStatusUpdated, BatchKeyStatusDeleted}[op]
+			}
+			continue
+		}
+		h.logger.Warn("failed to update the keys of provider %s in a batch: %v", provider, err)
+		for _, i := range indexes {
+			response.Results[i].Status = BatchKeyStatusFailed
+			response.Results[i].Error = fmt.Sprintf("failed to update provider %s: %v", provider, err)
+		}
+		if atomic {
+			h.rollbackProviderKeys(ctx, applied, previous, &response)
+			h.sendBatchKeys(ctx, fasthttp.StatusInternalServerError, response)
+			return
+		}
+	}
+
+	status := fasthttp.StatusOK
+	if failed || slices.ContainsFunc(response.Results, func(r BatchKeyResult) bool { return r.Status == BatchKeyStatusFailed }) {
+		status = fasthttp.StatusMultiStatus
+	}
+	h.sendBatchKeys(ctx, status, response)
+}
+
+// updateProviderKeys replaces the keys of a provider with the keys of a batch merged in, recording its config
+// before the update in previous.
+func (h *ProviderHandler) updateProviderKeys(ctx *fasthttp.RequestCtx, provider schemas.ModelProvider, raw *configstore.ProviderConfig, toAdd, toUpdate, toDelete []schemas.Key, previous map[schemas.ModelProvider]configstore.ProviderConfig) error {
+	redacted, err := h.store.GetProviderConfigRedacted(provider)
+	if err != nil {
+		return err
+	}
+	keys, err := h.mergeKeys(provider, raw.Keys, redacted.Keys, toAdd, toDelete, toUpdate)
+	if err != nil {
+		return err
+	}
+	config := *raw
+	config.Keys = keys
+	before := *raw
+	before.Keys = slices.Clone(raw.Keys)
+	if err := h.store.UpdateProviderConfig(ctx, provider, config); err != nil {
+		return err
+	}
+	previous[provider] = before
+	return nil
+}
+
+// rollbackProviderKeys restores the configs of the providers an atomic batch updated before failing.
+func (h *ProviderHandler) rollbackProviderKeys(ctx *fasthttp.RequestCtx, applied []schemas.ModelProvider, previous map[schemas.ModelProvider]configstore.ProviderConfig, response *BatchKeysResponse) {
+	for _, provider := range applied {
+		status := BatchKeyStatusRolledBack
+		errMessage := ""
+		if err := h.store.UpdateProviderConfig(ctx, provider, previous[provider]); err != nil {
+			h.logger.Error("failed to roll back the keys of provider %s: %v", provider, err)
+			status = BatchKeyStatusFailed
+			errMessage = fmt.Sprintf("applied, but failed to roll back provider %s: %v", provider, err)
+		}
+		for i := range response.Results {
+			if response.Results[i].Provider == provider && response.Results[i].Status != BatchKeyStatusFailed {
+				response.Results[i].Status = status
+				response.Results[i].Error = errMessage
+			}
+		}
+	}
+	for i := range response.Results {
+		if response.Results[i].Status == "" {
+			response.Results[i].Status = BatchKeyStatusSkipped
+		}
+	}
+}
+
+// sendBatchKeys counts the outcomes of a batch and sends them with status.
+func (h *ProviderHandler) sendBatchKeys(ctx *fasthttp.RequestCtx, status int, response BatchKeysResponse) {
+	for _, result := range response.Results {
+		switch result.Status {
+		case BatchKeyStatusCreated, BatchKeyStatusUpdated, BatchKeyStatusDeleted:
+			response.Succeeded++
+		case BatchKeyStatusFailed:
+			response.Failed++
+		}
+	}
+	ctx.SetStatusCode(status)
+	SendJSON(ctx, response, h.logger)
+}
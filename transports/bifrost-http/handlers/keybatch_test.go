@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestBatchKeys tests that atomic batches apply every key or none, rolling back the providers already updated, and
+// that non-atomic batches report the keys that failed
+func TestBatchKeys(t *testing.T) {
+	testLogger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	lib.SetLogger(testLogger)
+	store := &lib.Config{
+		Providers: map[schemas.ModelProvider]configstore.ProviderConfig{
+			schemas.OpenAI:    {Keys: []schemas.Key{{ID: "primary", Value: "sk-openai", Weight: 1}}},
+			schemas.Anthropic: {Keys: []schemas.Key{{ID: "claude", Value: "sk-ant", Weight: 1}}},
+		},
+		EnvKeys: map[string][]configstore.EnvKeyInfo{},
+	}
+	r := router.New()
+	NewProviderHandler(store, nil, testLogger).RegisterRoutes(r)
+	batch := func(path, body string) (BatchKeysResponse, int) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.SetBodyString(body)
+		r.Handler(ctx)
+		var response BatchKeysResponse
+		if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+			t.Fatalf("invalid response %s: %v", ctx.Response.Body(), err)
+		}
+		return response, ctx.Response.StatusCode()
+	}
+	keyIDs := func(provider schemas.ModelProvider) []string {
+		config, _ := store.GetProviderConfigRaw(provider)
+		var ids []string
+		for _, key := range config.Keys {
+			ids = append(ids, key.ID)
+		}
+		return ids
+	}
+
+	// An invalid key refuses the whole atomic batch
+	response, status := batch("/api/keys:batchCreate", `{"keys": [
+		{"provider": "openai", "key": {"id": "second", "value": "sk-2", "weight": 1}},
+		{"provider": "openai", "key": {"id": "primary", "value": "sk-3", "weight": 1}},
+		{"provider": "groq", "key": {"value": "sk-4"}}
+	]}`)
+	if status != fasthttp.StatusBadRequest || response.Failed != 2 || response.Results[0].Status != BatchKeyStatusSkipped ||
+		response.Results[1].Error == "" || response.Results[2].Status != BatchKeyStatusFailed {
+		t.Fatalf("status = %d, response = %+v, want the batch refused", status, response)
+	}
+	if ids := keyIDs(schemas.OpenAI); len(ids) != 1 {
+		t.Fatalf("openai keys = %v, want none added", ids)
+	}
+
+	// A provider failing to update rolls back the providers updated before it
+	response, status = batch("/api/keys:batchCreate", `{"keys": [
+		{"provider": "openai", "key": {"id": "second", "value": "sk-2", "weight": 1}},
+		{"provider": "anthropic", "key": {"id": "claude-2", "value": "env.BIFROST_TEST_MISSING_KEY", "weight": 1}}
+	]}`)
+	if status != fasthttp.StatusInternalServerError || response.Results[0].Status != BatchKeyStatusRolledBack || response.Results[1].Status != BatchKeyStatusFailed {
+		t.Fatalf("status = %d, response = %+v, want the batch rolled back", status, response)
+	}
+	if ids := keyIDs(schemas.OpenAI); len(ids) != 1 {
+		t.Fatalf("openai keys = %v, want the added key rolled back", ids)
+	}
+
+	// A non-atomic batch applies the valid keys
+	response, status = batch("/api/keys:batchCreate", `{"atomic": false, "keys": [
+		{"provider": "openai", "key": {"value": "sk-2", "weight": 1}},
+		{"provider": "anthropic", "key": {"id": "claude-2", "value": "env.BIFROST_TEST_MISSING_KEY", "weight": 1}},
+		{"provider": "anthropic", "key": {"id": "primary", "value": "sk-5", "weight": 1}}
+	]}`)
+	if status != fasthttp.StatusMultiStatus || response.Succeeded != 1 || response.Failed != 2 || response.Results[0].KeyID == "" {
+		t.Fatalf("status = %d, response = %+v, want one key created", status, response)
+	}
+	generated := response.Results[0].KeyID
+	if ids := keyIDs(schemas.OpenAI); len(ids) != 2 || ids[1] != generated {
+		t.Fatalf("openai keys = %v, want the key created with a generated ID", ids)
+	}
+
+	// Updates keep the values redacted by GET
+	redacted, _ := store.GetProviderConfigRedacted(schemas.OpenAI)
+	response, status = batch("/api/keys:batchUpdate", `{"keys": [
+		{"provider": "openai", "key": {"id": "primary", "value": "`+redacted.Keys[0].Value+`", "weight": 3}}
+	]}`)
+	if status != fasthttp.StatusOK || response.Succeeded != 1 {
+		t.Fatalf("status = %d, response = %+v, want the key updated", status, response)
+	}
+	if config, _ := store.GetProviderConfigRaw(schemas.OpenAI); config.Keys[0].Value != "sk-openai" || config.Keys[0].Weight != 3 {
+		t.Errorf("key = %+v, want the weight updated and the value kept", config.Keys[0])
+	}
+
+	response, status = batch("/api/keys:batchDelete", `{"keys": [
+		{"provider": "openai", "key_id": "`+generated+`"},
+		{"provider": "anthropic", "key_id": "claude"}
+	]}`)
+	if status != fasthttp.StatusOK || response.Succeeded != 2 {
+		t.Fatalf("status = %d, response = %+v, want both keys deleted", status, response)
+	}
+	if ids := keyIDs(schemas.OpenAI); len(ids) != 1 || len(keyIDs(schemas.Anthropic)) != 0 {
+		t.Errorf("openai keys = %v, want the batch keys deleted", ids)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// keyLeakReportPath receives leak reports. It is public and authenticated by the signature of the report.
+const keyLeakReportPath = "/api/governance/key-leaks/report"
+
+// KeyLeaksResponse is the JSON body of GET /api/governance/key-leaks.
+type KeyLeaksResponse struct {
+	Leaks     []lib.KeyLeak     `json:"leaks"`                // Newest first
+	LastCheck *lib.KeyLeakCheck `json:"last_check,omitempty"` // Last poll of the leak feeds
+}
+
+// KeyLeakReport is the body of POST /api/governance/key-leaks/report, signed in the X-Bifrost-Signature header.
+type KeyLeakReport struct {
+	Source   string   `json:"source"`             // Scanner reporting the leak, e.g. github
+	Location string   `json:"location,omitempty"` // Where the secrets were found
+	Secrets  []string `json:"secrets"`            // Key values, or their hex SHA-256
+}
+
+// KeyLeakReportResponse is the response to a leak report. Matching keys are not disclosed to the reporter.
+type KeyLeakReportResponse struct {
+	Matched int `json:"matched"` // Virtual keys newly found leaked
+}
+
+// KeyLeaksHandler serves the leaked virtual keys and receives leak reports.
+type KeyLeaksHandler struct {
+	monitor *lib.KeyLeakMonitor
+	logger  schemas.Logger
+}
+
+// NewKeyLeaksHandler creates a new key leaks handler.
+func NewKeyLeaksHandler(monitor *lib.KeyLeakMonitor, logger schemas.Logger) *KeyLeaksHandler {
+	return &KeyLeaksHandler{
+		monitor: monitor,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the key leak routes. The report route is public, see isPublicPath.
+func (h *KeyLeaksHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/governance/key-leaks", lib.ChainMiddlewares(h.getLeaks, middlewares...))
+	r.POST("/api/governance/key-leaks/check", lib.ChainMiddlewares(h.check, middlewares...))
+	r.POST(keyLeakReportPath, lib.ChainMiddlewares(h.report, middlewares...))
+}
+
+// getLeaks handles GET /api/governance/key-leaks - Virtual keys found leaked and the last feed check
+func (h *KeyLeaksHandler) getLeaks(ctx *fasthttp.RequestCtx) {
+	leaks, lastCheck := h.monitor.Leaks()
+	SendJSON(ctx, KeyLeaksResponse{Leaks: leaks, LastCheck: lastCheck}, h.logger)
+}
+
+// check handles POST /api/governance/key-leaks/check - Poll the leak feeds now
+func (h *KeyLeaksHandler) check(ctx *fasthttp.RequestCtx) {
+	SendJSON(ctx, h.monitor.Check(ctx), h.logger)
+}
+
+// report handles POST /api/governance/key-leaks/report - Secrets found leaked by an external scanner
+func (h *KeyLeaksHandler) report(ctx *fasthttp.RequestCtx) {
+	body := ctx.PostBody()
+	if !h.monitor.VerifySignature(string(ctx.Request.Header.Peek(lib.KeyLeakSignatureHeader)), body) {
+		SendError(ctx, fasthttp.StatusUnauthorized, "invalid or missing leak report signature", h.logger)
+		return
+	}
+	var report KeyLeakReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid leak report: %v", err), h.logger)
+		return
+	}
+	if report.Source == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "source is required", h.logger)
+		return
+	}
+	leaks, err := h.monitor.Report(ctx, report.Source, report.Location, report.Secrets)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to handle leak report: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, KeyLeakReportResponse{Matched: len(leaks)}, h.logger)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestKeyLeaks_FeedAndReport tests that virtual keys found in a feed or in a signed report are suspended and
+// alerted once, and that unsigned reports are refused
+func TestKeyLeaks_FeedAndReport(t *testing.T) {
+	ctx := context.Background()
+	testLogger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	lib.SetLogger(testLogger)
+	store, err := configstore.NewConfigStore(ctx, &configstore.Config{
+		Enabled: true,
+		Type:    configstore.ConfigStoreTypeSQLite,
+		Config:  &configstore.SQLiteConfig{Path: filepath.Join(t.TempDir(), "config.db")},
+	}, testLogger)
+	if err != nil {
+		t.Fatalf("failed to create config store: %v", err)
+	}
+	if err := store.CreateTeam(ctx, &configstore.TableTeam{ID: "team-a", Name: "Team A"}); err != nil {
+		t.Fatalf("failed to create team: %v", err)
+	}
+	for _, vk := range []*configstore.TableVirtualKey{
+		{ID: "vk-feed", Name: "feed", Value: "sk-bf-feed", IsActive: true, TeamID: bifrost.Ptr("team-a")},
+		{ID: "vk-report", Name: "report", Value: "sk-bf-report", IsActive: true},
+		{ID: "vk-safe", Name: "safe", Value: "sk-bf-safe", IsActive: true},
+	} {
+		if err := store.CreateVirtualKey(ctx, vk); err != nil {
+			t.Fatalf("failed to create virtual key: %v", err)
+		}
+	}
+
+	feed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `["%s", "sk-unknown"]`, lib.KeyFingerprint("sk-bf-feed"))
+	}))
+	defer feed.Close()
+	var mu sync.Mutex
+	var alerts []lib.KeyLeakAlert
+	alertServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var alert lib.KeyLeakAlert
+		json.Unmarshal(body, &alert)
+		mu.Lock()
+		alerts = append(alerts, alert)
+		mu.Unlock()
+	}))
+	defer alertServer.Close()
+
+	monitor := lib.NewKeyLeakMonitor(lib.KeyLeakConfig{
+		Enabled:       true,
+		Feeds:         []lib.KeyLeakFeed{{Name: "test-feed", URL: feed.URL}},
+		WebhookSecret: "secret",
+		Alert:         &lib.KeyLeakAlertConfig{URL: alertServer.URL},
+	}, store, nil, nil)
+	var refreshed []string
+	monitor.OnUpdate(func(vk *configstore.TableVirtualKey) { refreshed = append(refreshed, vk.ID) })
+
+	check := monitor.Check(ctx)
+	if len(check.Errors) != 0 || len(check.Leaks) != 1 || check.Leaks[0].VirtualKeyID != "vk-feed" || !check.Leaks[0].Suspended || check.Leaks[0].TeamID != "team-a" {
+		t.Fatalf("check = %+v, want the feed key suspended", check)
+	}
+	if vk, _ := store.GetVirtualKey(ctx, "vk-feed"); vk == nil || vk.IsActive || len(refreshed) != 1 {
+		t.Fatalf("virtual key = %+v, refreshed = %v, want it deactivated and the governance cache refreshed", vk, refreshed)
+	}
+	if check := monitor.Check(ctx); len(check.Leaks) != 0 {
+		t.Errorf("second check = %+v, want keys already found left out", check)
+	}
+
+	h := NewKeyLeaksHandler(monitor, testLogger)
+	report := func(body, signature string) *fasthttp.RequestCtx {
+		ctx := billingRequestCtx(keyLeakReportPath)
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetBodyString(body)
+		if signature != "" {
+			ctx.Request.Header.Set(lib.KeyLeakSignatureHeader, signature)
+		}
+		h.report(ctx)
+		return ctx
+	}
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	body := `{"source": "github", "location": "https://github.com/acme/app/commit/1", "secrets": ["sk-bf-report"]}`
+	if ctx := report(body, ""); ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 for an unsigned report", ctx.Response.StatusCode())
+	}
+	if ctx := report(body, sign(body+" ")); ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 for a report signed for another body", ctx.Response.StatusCode())
+	}
+	for _, want := range []int{1, 0} {
+		ctx := report(body, sign(body))
+		var response KeyLeakReportResponse
+		if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil || response.Matched != want {
+			t.Fatalf("report response %s, want %d keys newly matched", ctx.Response.Body(), want)
+		}
+	}
+
+	leaks, lastCheck := monitor.Leaks()
+	if len(leaks) != 2 || leaks[0].VirtualKeyID != "vk-report" || leaks[0].Source != "github" || lastCheck == nil {
+		t.Errorf("leaks = %+v, want the reported key first", leaks)
+	}
+	if vk, _ := store.GetVirtualKey(ctx, "vk-safe"); vk == nil || !vk.IsActive {
+		t.Errorf("virtual key = %+v, want keys not leaked left active", vk)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		delivered := len(alerts)
+		mu.Unlock()
+		if delivered == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(alerts) != 2 || alerts[0].Type != lib.KeyLeakAlertEventType {
+		t.Errorf("alerts = %+v, want one per leaked key", alerts)
+	}
+}
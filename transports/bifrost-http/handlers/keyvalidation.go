@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// validateProviderKeys handles POST /api/providers/{provider}/keys/validate - Validate the keys of a provider with
+// a live call (?key_id= validates a single key). The results are saved and returned by key ID.
+func (h *ProviderHandler) validateProviderKeys(ctx *fasthttp.RequestCtx) {
+	provider, err := getProviderFromCtx(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid provider: %v", err), h.logger)
+		return
+	}
+	config, err := h.store.GetProviderConfigRaw(provider)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Provider not found: %v", err), h.logger)
+		return
+	}
+
+	keyIDs := make([]string, 0, len(config.Keys))
+	for _, key := range config.Keys {
+		keyIDs = append(keyIDs, key.ID)
+	}
+	if keyID := string(ctx.QueryArgs().Peek("key_id")); keyID != "" {
+		if !slices.Contains(keyIDs, keyID) {
+			SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Key %s not found", keyID), h.logger)
+			return
+		}
+		keyIDs = []string{keyID}
+	}
+
+	SendJSON(ctx, h.validateKeys(ctx, provider, keyIDs), h.logger)
+}
+
+// validateKeys validates the given keys of a provider concurrently and saves the results. It returns nil when
+// there is no client to send the validation calls through.
+func (h *ProviderHandler) validateKeys(ctx context.Context, provider schemas.ModelProvider, keyIDs []string) map[string]lib.KeyValidation {
+	if h.client == nil || len(keyIDs) == 0 {
+		return nil
+	}
+	config, err := h.store.GetProviderConfigRaw(provider)
+	if err != nil {
+		return nil
+	}
+	baseProvider := provider
+	if config.CustomProviderConfig != nil {
+		baseProvider = config.CustomProviderConfig.BaseProviderType
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]lib.KeyValidation, len(keyIDs))
+	for _, key := range config.Keys {
+		if !slices.Contains(keyIDs, key.ID) {
+			continue
+		}
+		wg.Add(1)
+		go func(key schemas.Key) {
+			defer wg.Done()
+			// The calls are bounded by their timeout rather than by the request context
+			result := lib.ValidateKey(context.Background(), h.client, provider, lib.KeyValidationModel(provider, baseProvider, key), key)
+			mu.Lock()
+			results[key.ID] = result
+			mu.Unlock()
+		}(key)
+	}
+	wg.Wait()
+
+	for id, result := range results {
+		if result.Status == lib.KeyValidationInvalid {
+			bifrost.WithFields(h.logger, "provider", provider, "key_id", id).Warn("key validation failed: %s", result.Error)
+		}
+	}
+	if err := h.store.UpdateKeyValidations(ctx, results, nil); err != nil {
+		bifrost.WithFields(h.logger, "provider", provider).Warn("failed to save key validations: %v", err)
+	}
+	return results
+}
+
+// changedKeyIDs returns the IDs of the keys added or changed between two configs of a provider, and of the keys
+// removed from it.
+func changedKeyIDs(oldKeys []schemas.Key, newKeys []schemas.Key) (changed []string, removed []string) {
+	for _, key := range newKeys {
+		i := slices.IndexFunc(oldKeys, func(k schemas.Key) bool { return k.ID == key.ID })
+		if i < 0 || !reflect.DeepEqual(oldKeys[i], key) {
+			changed = append(changed, key.ID)
+		}
+	}
+	for _, key := range oldKeys {
+		if !slices.ContainsFunc(newKeys, func(k schemas.Key) bool { return k.ID == key.ID }) {
+			removed = append(removed, key.ID)
+		}
+	}
+	return changed, removed
+}
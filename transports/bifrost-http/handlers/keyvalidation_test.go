@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestProviderKeyValidation tests that keys are validated with a live call when saved with ?validate=true or on
+// demand, and that the results of changed and removed keys are forgotten
+func TestProviderKeyValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer sk-good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"length"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	lib.SetLogger(logger)
+	networkConfig := schemas.DefaultNetworkConfig
+	networkConfig.BaseURL = server.URL
+	store := &lib.Config{
+		Providers: map[schemas.ModelProvider]configstore.ProviderConfig{
+			schemas.OpenAI: {
+				Keys:          []schemas.Key{{ID: "good", Value: "sk-good", Models: []string{}, Weight: 1}},
+				NetworkConfig: &networkConfig,
+			},
+		},
+		EnvKeys: map[string][]configstore.EnvKeyInfo{},
+	}
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{Account: lib.NewBaseAccount(store), Logger: logger})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+	h := NewProviderHandler(store, client, logger)
+
+	request := func(handler fasthttp.RequestHandler, method, uri, body string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(uri)
+		ctx.Request.SetBodyString(body)
+		ctx.SetUserValue("provider", "openai")
+		handler(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("%s %s failed with %d: %s", method, uri, ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+		return ctx
+	}
+	update := func(query string, keys string) ProviderResponse {
+		body := fmt.Sprintf(`{"keys": %s, "network_config": {"base_url": %q, "default_request_timeout_in_seconds": 30}, "concurrency_and_buffer_size": {"concurrency": 1000, "buffer_size": 5000}}`, keys, server.URL)
+		var response ProviderResponse
+		if err := json.Unmarshal(request(h.updateProvider, fasthttp.MethodPut, "/api/providers/openai"+query, body).Response.Body(), &response); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return response
+	}
+
+	response := update("?validate=true", `[{"id": "good", "value": "sk-good", "models": [], "weight": 1}, {"id": "bad", "value": "sk-bad", "models": [], "weight": 1}]`)
+	bad, ok := response.KeyValidations["bad"]
+	if !ok || bad.Status != lib.KeyValidationInvalid || bad.Model != "gpt-4o-mini" || !strings.Contains(bad.Error, "Incorrect API key") {
+		t.Fatalf("bad key validation = %+v, want it invalid with the upstream error", bad)
+	}
+	if _, ok := response.KeyValidations["good"]; ok {
+		t.Errorf("expected the unchanged key not to be validated, got %+v", response.KeyValidations)
+	}
+
+	var results map[string]lib.KeyValidation
+	if err := json.Unmarshal(request(h.validateProviderKeys, fasthttp.MethodPost, "/api/providers/openai/keys/validate", "").Response.Body(), &results); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if results["good"].Status != lib.KeyValidationValid || results["bad"].Status != lib.KeyValidationInvalid {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	response = update("", `[{"id": "good", "value": "sk-good", "models": [], "weight": 1}]`)
+	if len(response.KeyValidations) != 1 || response.KeyValidations["good"].Status != lib.KeyValidationValid {
+		t.Errorf("expected only the remaining key to keep its validation, got %+v", response.KeyValidations)
+	}
+	response = update("", `[{"id": "good", "value": "sk-good", "models": ["gpt-4o"], "weight": 1}]`)
+	if len(response.KeyValidations) != 0 {
+		t.Errorf("expected the validation of a changed key to be forgotten, got %+v", response.KeyValidations)
+	}
+}
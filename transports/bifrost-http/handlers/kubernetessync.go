@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// Reasons of the Kubernetes events emitted by the controller
+const (
+	kubernetesReasonInvalid = "InvalidBifrostConfig"
+	kubernetesReasonFailed  = "BifrostConfigFailed"
+	kubernetesReasonApplied = "BifrostConfigApplied"
+)
+
+// KubernetesConfigDocument is the document a ConfigMap or Secret holds under the data key. Every provider and
+// budget is declared by one document only; policies, when declared, by one document only too.
+type KubernetesConfigDocument struct {
+	Providers map[schemas.ModelProvider]DesiredProvider `json:"providers,omitempty"`
+	Budgets   []DesiredBudget                           `json:"budgets,omitempty"`
+	Policies  *KubernetesPolicies                       `json:"policies,omitempty"`
+}
+
+// KubernetesPolicies are the routing and governance policies declared by a document (see lib.PoliciesConfig).
+type KubernetesPolicies struct {
+	KeyAttributes []lib.PolicyKeyAttributes `json:"key_attributes,omitempty"`
+	Policies      []lib.Policy              `json:"policies"`
+}
+
+// KubernetesSyncSource is a ConfigMap or Secret holding a config document, as of the last reconciliation.
+type KubernetesSyncSource struct {
+	lib.KubernetesObject
+	Error string `json:"error,omitempty"` // Why the document was not applied
+}
+
+// KubernetesSyncStatus is the response of GET /api/kubernetes/sync.
+type KubernetesSyncStatus struct {
+	Enabled       bool                   `json:"enabled"`
+	Namespace     string                 `json:"namespace,omitempty"`
+	LabelSelector string                 `json:"label_selector,omitempty"`
+	LastSyncAt    *time.Time             `json:"last_sync_at,omitempty"`
+	LastError     string                 `json:"last_error,omitempty"`
+	Sources       []KubernetesSyncSource `json:"sources"`
+	Changes       []ApplyChange          `json:"changes"` // Changes of the last reconciliation that changed anything
+}
+
+// KubernetesSyncController reconciles the configuration to the documents of the ConfigMaps and Secrets matching
+// the label selector, in the background and on demand. Every replica reconciles its own configuration; only the
+// leader emits events, so that they are not repeated by every replica.
+type KubernetesSyncController struct {
+	config   *lib.KubernetesSyncConfig
+	client   *lib.KubernetesClient
+	store    *lib.Config
+	apply    *ApplyHandler          // Converges providers and budgets, and serializes with applies
+	versions *ConfigVersionsHandler // Records a configuration version after changes, nil in tests
+	logger   schemas.Logger
+
+	mu       sync.Mutex
+	status   KubernetesSyncStatus
+	reported map[string]string // Last warning emitted on each source, so that it is emitted once
+}
+
+// NewKubernetesSyncController creates a new controller. config and client are nil when the mode is off, in which
+// case the controller only reports its status.
+func NewKubernetesSyncController(config *lib.KubernetesSyncConfig, client *lib.KubernetesClient, store *lib.Config, apply *ApplyHandler, versions *ConfigVersionsHandler, logger schemas.Logger) *KubernetesSyncController {
+	status := KubernetesSyncStatus{Sources: []KubernetesSyncSource{}, Changes: []ApplyChange{}}
+	if config != nil && client != nil {
+		status.Enabled = true
+		status.Namespace = config.Namespace
+		status.LabelSelector = config.LabelSelector
+	}
+	return &KubernetesSyncController{
+		config:   config,
+		client:   client,
+		store:    store,
+		apply:    apply,
+		versions: versions,
+		logger:   logger,
+		status:   status,
+		reported: make(map[string]string),
+	}
+}
+
+// RegisterRoutes registers the Kubernetes sync routes.
+func (c *KubernetesSyncController) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/kubernetes/sync", lib.ChainMiddlewares(c.getStatus, middlewares...))
+	r.POST("/api/kubernetes/sync", lib.ChainMiddlewares(c.syncNow, middlewares...))
+}
+
+// Start reconciles right away, then every interval until ctx is done. It does nothing when the mode is off.
+func (c *KubernetesSyncController) Start(ctx context.Context) {
+	if !c.status.Enabled {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(c.config.IntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			c.Sync(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// getStatus handles GET /api/kubernetes/sync - Get the sources and outcome of the last reconciliation
+func (c *KubernetesSyncController) getStatus(ctx *fasthttp.RequestCtx) {
+	c.mu.Lock()
+	status := c.status
+	c.mu.Unlock()
+	SendJSON(ctx, status, c.logger)
+}
+
+// syncNow handles POST /api/kubernetes/sync - Reconcile right away instead of at the next interval
+func (c *KubernetesSyncController) syncNow(ctx *fasthttp.RequestCtx) {
+	if !c.status.Enabled {
+		SendError(ctx, fasthttp.StatusBadRequest, "Kubernetes sync is not enabled", c.logger)
+		return
+	}
+	SendJSON(ctx, c.Sync(ctx), c.logger)
+}
+
+// Sync reconciles the configuration to the documents of the sources once and returns the resulting status.
+// Nothing is applied while any document is invalid, since applying the others could prune what it declares.
+func (c *KubernetesSyncController) Sync(ctx context.Context) KubernetesSyncStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	c.status.LastSyncAt = &now
+	c.status.LastError = ""
+
+	objects, err := c.listSources(ctx)
+	if err != nil {
+		c.status.LastError = err.Error()
+		c.logger.Warn("kubernetes sync: %v", err)
+		return c.status
+	}
+	sources, desired, policies := c.merge(objects)
+	c.status.Sources = sources
+	invalid := 0
+	for _, source := range sources {
+		if source.Error != "" {
+			invalid++
+			c.report(ctx, source.KubernetesObject, lib.KubernetesEventWarning, kubernetesReasonInvalid, source.Error)
+		}
+	}
+	if invalid > 0 {
+		c.status.LastError = fmt.Sprintf("%d invalid config documents, nothing applied", invalid)
+		return c.status
+	}
+	if len(sources) == 0 {
+		// Pruning to an empty document would remove every provider
+		c.status.LastError = "no config documents found, nothing applied"
+		return c.status
+	}
+
+	changes, err := c.reconcile(ctx, desired, policies)
+	if err != nil {
+		c.status.LastError = err.Error()
+		c.logger.Warn("kubernetes sync: %v", err)
+		for _, source := range sources {
+			c.report(ctx, source.KubernetesObject, lib.KubernetesEventWarning, kubernetesReasonFailed, err.Error())
+		}
+		return c.status
+	}
+	for _, source := range sources {
+		delete(c.reported, sourceKey(source.KubernetesObject))
+	}
+	if len(changes) > 0 {
+		c.status.Changes = changes
+		c.logger.Info("kubernetes sync: applied %d changes", len(changes))
+		if c.versions != nil {
+			c.versions.record(ctx, "kubernetes")
+		}
+		message := fmt.Sprintf("Applied %d configuration changes", len(changes))
+		for _, source := range sources {
+			c.emit(ctx, source.KubernetesObject, lib.KubernetesEventNormal, kubernetesReasonApplied, message)
+		}
+	}
+	return c.status
+}
+
+// listSources lists the ConfigMaps and Secrets holding a document, sorted by kind and name
+func (c *KubernetesSyncController) listSources(ctx context.Context) ([]lib.KubernetesObject, error) {
+	configMaps, err := c.client.ListConfigMaps(ctx, c.config.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := c.client.ListSecrets(ctx, c.config.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	objects := append(configMaps, secrets...)
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Kind != objects[j].Kind {
+			return objects[i].Kind < objects[j].Kind
+		}
+		return objects[i].Name < objects[j].Name
+	})
+	return objects, nil
+}
+
+// merge decodes and validates the document of every object and merges them into one desired state. Objects
+// without the data key are skipped; objects whose document is invalid, or declares what another one already
+// does, carry the error.
+func (c *KubernetesSyncController) merge(objects []lib.KubernetesObject) ([]KubernetesSyncSource, *DesiredState, *KubernetesPolicies) {
+	desired := &DesiredState{Providers: map[schemas.ModelProvider]DesiredProvider{}, Prune: c.config.Prune}
+	var policies *KubernetesPolicies
+	providerSources := map[schemas.ModelProvider]string{}
+	budgetSources := map[string]string{}
+	policySource := ""
+	sources := []KubernetesSyncSource{}
+	for _, object := range objects {
+		data, ok := object.Data[c.config.DataKey]
+		if !ok {
+			continue
+		}
+		source := KubernetesSyncSource{KubernetesObject: object}
+		name := object.Kind + "/" + object.Name
+		document, err := decodeKubernetesDocument(data)
+		if err == nil {
+			for provider := range document.Providers {
+				if other, ok := providerSources[provider]; ok {
+					err = fmt.Errorf("provider %s is already declared by %s", provider, other)
+				}
+			}
+			for _, budget := range document.Budgets {
+				if other, ok := budgetSources[budget.ID]; ok {
+					err = fmt.Errorf("budget %s is already declared by %s", budget.ID, other)
+				}
+			}
+			if document.Policies != nil && policySource != "" {
+				err = fmt.Errorf("policies are already declared by %s", policySource)
+			}
+		}
+		if err != nil {
+			source.Error = err.Error()
+			sources = append(sources, source)
+			continue
+		}
+		for provider, config := range document.Providers {
+			desired.Providers[provider] = config
+			providerSources[provider] = name
+		}
+		for _, budget := range document.Budgets {
+			desired.Budgets = append(desired.Budgets, budget)
+			budgetSources[budget.ID] = name
+		}
+		if document.Policies != nil {
+			policies = document.Policies
+			policySource = name
+		}
+		sources = append(sources, source)
+	}
+	return sources, desired, policies
+}
+
+// decodeKubernetesDocument decodes and validates a config document
+func decodeKubernetesDocument(data string) (*KubernetesConfigDocument, error) {
+	var document KubernetesConfigDocument
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid config document: %w", err)
+	}
+	if err := validateDesiredState(&DesiredState{Providers: document.Providers, Budgets: document.Budgets}); err != nil {
+		return nil, err
+	}
+	if document.Policies != nil {
+		if _, err := lib.CompilePolicies(lib.PoliciesConfig{KeyAttributes: document.Policies.KeyAttributes, Policies: document.Policies.Policies}); err != nil {
+			return nil, err
+		}
+	}
+	return &document, nil
+}
+
+// reconcile converges providers and budgets to desired, and the policies to policies when declared, returning the
+// changes made
+func (c *KubernetesSyncController) reconcile(ctx context.Context, desired *DesiredState, policies *KubernetesPolicies) ([]ApplyChange, error) {
+	if len(desired.Budgets) > 0 && c.store.ConfigStore == nil {
+		return nil, fmt.Errorf("budgets require a config store")
+	}
+	c.apply.mu.Lock()
+	defer c.apply.mu.Unlock()
+	plan, err := c.apply.plan(ctx, desired)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute plan: %w", err)
+	}
+	if err := c.apply.execute(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to apply: %w", err)
+	}
+	changes := plan.changes
+	if policies != nil {
+		var current lib.PoliciesConfig
+		if active := c.store.GetPolicies(); active != nil {
+			current = active.Config()
+		}
+		if !reflect.DeepEqual(current.KeyAttributes, policies.KeyAttributes) || !reflect.DeepEqual(current.Policies, policies.Policies) {
+			if _, err := c.store.UpdatePolicies(ctx, lib.PoliciesConfig{KeyAttributes: policies.KeyAttributes, Policies: policies.Policies}); err != nil {
+				return changes, fmt.Errorf("failed to update policies: %w", err)
+			}
+			changes = append(changes, ApplyChange{Resource: "policies", Name: "policies", Action: ApplyActionUpdate})
+		}
+	}
+	return changes, nil
+}
+
+// report emits a warning on a source, unless the same warning was already emitted on the same version of it
+func (c *KubernetesSyncController) report(ctx context.Context, object lib.KubernetesObject, eventType, reason, message string) {
+	key, reported := sourceKey(object), object.ResourceVersion+" "+reason+": "+message
+	if c.reported[key] == reported {
+		return
+	}
+	c.reported[key] = reported
+	c.emit(ctx, object, eventType, reason, message)
+}
+
+// emit creates an event on a source when this replica is the leader
+func (c *KubernetesSyncController) emit(ctx context.Context, object lib.KubernetesObject, eventType, reason, message string) {
+	if c.store.LeaderElector != nil && !c.store.LeaderElector.IsLeader() {
+		return
+	}
+	if err := c.client.CreateEvent(ctx, object, eventType, reason, message); err != nil {
+		c.logger.Warn("kubernetes sync: %v", err)
+	}
+}
+
+// sourceKey identifies a source
+func sourceKey(object lib.KubernetesObject) string {
+	return object.Kind + "/" + object.Name
+}
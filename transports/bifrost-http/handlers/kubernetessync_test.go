@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// fakeKubernetesAPI serves labeled ConfigMaps and Secrets and records the events created
+type fakeKubernetesAPI struct {
+	mu         sync.Mutex
+	configMaps map[string]string // name -> document
+	secrets    map[string]string // name -> document
+	version    int
+	events     []map[string]any
+}
+
+func (f *fakeKubernetesAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.URL.Query().Get("labelSelector") == "" && r.Method == http.MethodGet {
+		http.Error(w, `{"message":"missing selector"}`, http.StatusBadRequest)
+		return
+	}
+	item := func(name string, data map[string]any) map[string]any {
+		return map[string]any{
+			"metadata": map[string]any{"name": name, "namespace": "bifrost", "uid": "uid-" + name, "resourceVersion": strconv.Itoa(f.version)},
+			"data":     data,
+		}
+	}
+	items := []map[string]any{}
+	switch r.URL.Path {
+	case "/api/v1/namespaces/bifrost/configmaps":
+		for name, document := range f.configMaps {
+			items = append(items, item(name, map[string]any{lib.DefaultKubernetesDataKey: document}))
+		}
+	case "/api/v1/namespaces/bifrost/secrets":
+		for name, document := range f.secrets {
+			items = append(items, item(name, map[string]any{lib.DefaultKubernetesDataKey: base64.StdEncoding.EncodeToString([]byte(document))}))
+		}
+	case "/api/v1/namespaces/bifrost/events":
+		var event map[string]any
+		json.NewDecoder(r.Body).Decode(&event)
+		f.events = append(f.events, event)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"items": items})
+}
+
+// eventReasons returns the reason and object of every event created, and forgets them
+func (f *fakeKubernetesAPI) eventReasons() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reasons := []string{}
+	for _, event := range f.events {
+		object := event["involvedObject"].(map[string]any)
+		reasons = append(reasons, event["type"].(string)+" "+event["reason"].(string)+" "+object["kind"].(string)+"/"+object["name"].(string))
+	}
+	f.events = nil
+	return reasons
+}
+
+// TestKubernetesSync tests that invalid documents are reported once as warning events and block the
+// reconciliation, and that valid documents converge the configuration, policies included
+func TestKubernetesSync(t *testing.T) {
+	api := &fakeKubernetesAPI{
+		configMaps: map[string]string{
+			"providers": `{"providers": {"openai": {"keys": [{"id": "primary", "value": "env.OPENAI_API_KEY", "weight": 1}]}}}`,
+			"policies":  `{"policies": {"policies": [{"name": "deny-test", "expression": "model == \"test\"", "action": "deny"}]}}`,
+		},
+		secrets: map[string]string{
+			"keys": `{"providers": {"openai": {"keys": []}}}`,
+		},
+		version: 1,
+	}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	config := &lib.KubernetesSyncConfig{Enabled: true, Namespace: "bifrost", APIServer: server.URL, Prune: true}
+	client, err := lib.NewKubernetesClient(config)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	apply := newApplyTestHandler()
+	controller := NewKubernetesSyncController(config, client, apply.store, apply, nil, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	status := controller.Sync(context.Background())
+	if status.LastError == "" || len(status.Sources) != 3 || status.Sources[2].Error == "" {
+		t.Fatalf("expected the secret redeclaring openai to block the sync, got %+v", status)
+	}
+	if reasons := api.eventReasons(); len(reasons) != 1 || reasons[0] != "Warning "+kubernetesReasonInvalid+" Secret/keys" {
+		t.Errorf("unexpected events %v", reasons)
+	}
+	if apply.store.GetPolicies() != nil {
+		t.Error("expected nothing applied while a document is invalid")
+	}
+	controller.Sync(context.Background())
+	if reasons := api.eventReasons(); len(reasons) != 0 {
+		t.Errorf("expected the warning to be emitted once, got %v", reasons)
+	}
+
+	api.mu.Lock()
+	api.secrets["keys"] = `{"providers": {"anthropic": {"keys": [{"id": "claude", "value": "sk-ant-literal", "weight": 1}]}}}`
+	api.version++
+	api.mu.Unlock()
+	status = controller.Sync(context.Background())
+	if status.LastError != "" {
+		t.Fatalf("unexpected error %q", status.LastError)
+	}
+	if len(status.Changes) != 1 || status.Changes[0].Resource != "policies" {
+		t.Errorf("expected only the policies to change, got %+v", status.Changes)
+	}
+	if policies := apply.store.GetPolicies(); policies == nil || len(policies.Config().Policies) != 1 {
+		t.Errorf("expected the policies to be applied, got %+v", policies)
+	}
+	if reasons := api.eventReasons(); len(reasons) != 3 || reasons[0] != "Normal "+kubernetesReasonApplied+" ConfigMap/policies" {
+		t.Errorf("unexpected events %v", reasons)
+	}
+
+	if status := controller.Sync(context.Background()); status.LastError != "" || len(api.eventReasons()) != 0 {
+		t.Errorf("expected a converged sync to change nothing, got %+v", status)
+	}
+}
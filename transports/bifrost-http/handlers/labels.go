@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// LabelsMiddleware attributes inference requests to the labels of their X-Bifrost-Labels header and of the string
+// entries of their metadata object, e.g. to a feature or environment sharing a virtual key. The labels are logged,
+// evaluated by policies, recorded by governance, broken down in billing reports and reported as the custom
+// Prometheus labels of the same names. Requests with an invalid header are rejected with 400; invalid metadata
+// entries are only left out of the labels, since metadata is also sent to providers.
+func LabelsMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			header := string(ctx.Request.Header.Peek(lib.LabelsHeader))
+			if !ctx.IsPost() || strings.HasPrefix(string(ctx.Path()), "/api/") {
+				next(ctx)
+				return
+			}
+
+			var body map[string]any
+			if bytes.Contains(ctx.Request.Body(), []byte(`"metadata"`)) {
+				// Bodies that are not JSON objects carry no metadata
+				_ = json.Unmarshal(ctx.Request.Body(), &body)
+			}
+			if header == "" && body == nil {
+				next(ctx)
+				return
+			}
+			labels, err := lib.RequestLabels(header, body)
+			if err != nil {
+				SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid %s header: %v", lib.LabelsHeader, err), logger)
+				return
+			}
+			if len(labels) > 0 {
+				ctx.SetUserValue(schemas.BifrostContextKeyLabels, labels)
+				if metricValues := config.MetricLabelValues(labels); metricValues != nil {
+					ctx.SetUserValue(lib.LabelMetricValuesContextKey, metricValues)
+				}
+			}
+			next(ctx)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestLabelsMiddleware tests that requests are labeled from the X-Bifrost-Labels header and the string entries of
+// their metadata, the header winning, and that invalid headers are rejected
+func TestLabelsMiddleware(t *testing.T) {
+	config := &lib.Config{}
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		body       string
+		wantStatus int
+		wantLabels map[string]string
+	}{
+		{
+			name:       "header",
+			header:     "feature=search, env=staging",
+			body:       `{"model":"openai/gpt-4o"}`,
+			wantLabels: map[string]string{"feature": "search", "env": "staging"},
+		},
+		{
+			name:       "metadata",
+			body:       `{"model":"openai/gpt-4o","metadata":{"feature":"chat","user id":"u1","retries":3,"note":""}}`,
+			wantLabels: map[string]string{"feature": "chat"},
+		},
+		{
+			name:       "header wins over metadata",
+			header:     "feature=search",
+			body:       `{"model":"openai/gpt-4o","metadata":{"feature":"chat","env":"prod"}}`,
+			wantLabels: map[string]string{"feature": "search", "env": "prod"},
+		},
+		{
+			name:       "invalid header",
+			header:     "feature",
+			body:       `{"model":"openai/gpt-4o"}`,
+			wantStatus: fasthttp.StatusBadRequest,
+		},
+		{
+			name:       "label set twice",
+			header:     "env=prod,env=staging",
+			wantStatus: fasthttp.StatusBadRequest,
+		},
+		{
+			name:   "management API",
+			path:   "/api/governance/virtual-keys",
+			header: "feature",
+			body:   `{"metadata":{"feature":"chat"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+			path := tt.path
+			if path == "" {
+				path = "/v1/chat/completions"
+			}
+			ctx.Request.SetRequestURI(path)
+			if tt.header != "" {
+				ctx.Request.Header.Set(lib.LabelsHeader, tt.header)
+			}
+			ctx.Request.SetBodyString(tt.body)
+
+			called := false
+			LabelsMiddleware(config)(func(ctx *fasthttp.RequestCtx) { called = true })(ctx)
+
+			if tt.wantStatus != 0 {
+				if called || ctx.Response.StatusCode() != tt.wantStatus {
+					t.Errorf("status = %d, want %d and the request rejected", ctx.Response.StatusCode(), tt.wantStatus)
+				}
+				return
+			}
+			labels, _ := ctx.UserValue(schemas.BifrostContextKeyLabels).(map[string]string)
+			if !called || !maps.Equal(labels, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", labels, tt.wantLabels)
+			}
+		})
+	}
+}
+
+// TestMetricLabelValues tests that only the labels configured as Prometheus labels are reported to metrics, and
+// that the values of a label past the limit are reported as other
+func TestMetricLabelValues(t *testing.T) {
+	config := &lib.Config{ClientConfig: configstore.ClientConfig{PrometheusLabels: []string{"env"}}}
+	for i := 0; i < lib.MaxLabelMetricValues; i++ {
+		values := config.MetricLabelValues(map[string]string{"env": fmt.Sprintf("env-%d", i), "feature": "chat"})
+		if !maps.Equal(values, map[string]string{"env": fmt.Sprintf("env-%d", i)}) {
+			t.Fatalf("values = %v, want env-%d only", values, i)
+		}
+	}
+	if values := config.MetricLabelValues(map[string]string{"env": "one-too-many"}); values["env"] != lib.LabelMetricOtherValue {
+		t.Errorf("env = %q past the limit, want %q", values["env"], lib.LabelMetricOtherValue)
+	}
+	if values := config.MetricLabelValues(map[string]string{"env": "env-0"}); values["env"] != "env-0" {
+		t.Errorf("env = %q for a value seen before the limit, want env-0", values["env"])
+	}
+	if values := config.MetricLabelValues(map[string]string{"feature": "chat"}); values != nil {
+		t.Errorf("values = %v without Prometheus labels, want none", values)
+	}
+}
+
+// TestFormatAccessLogEntry_Labels tests that the combined format quotes the labels of a request
+func TestFormatAccessLogEntry_Labels(t *testing.T) {
+	line, err := formatAccessLogEntry(lib.AccessLogFormatCombined, &accessLogEntry{Labels: map[string]string{"team": "growth ops", "env": "prod"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(string(line), ` labels="env=prod,team=growth ops"`+"\n") {
+		t.Errorf("unexpected line %q", line)
+	}
+	line, err = formatAccessLogEntry(lib.AccessLogFormatCombined, &accessLogEntry{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasSuffix(string(line), " labels=-\n") {
+		t.Errorf("unexpected line %q", line)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/langdetect"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// LanguageRoutingHandler manages the language routing configuration.
+type LanguageRoutingHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// DetectLanguageRequest is the body of POST /api/language-routing/detect.
+type DetectLanguageRequest struct {
+	Text string `json:"text"`
+}
+
+// NewLanguageRoutingHandler creates a new language routing handler.
+func NewLanguageRoutingHandler(store *lib.Config, logger schemas.Logger) *LanguageRoutingHandler {
+	return &LanguageRoutingHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the language routing routes.
+func (h *LanguageRoutingHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/language-routing", lib.ChainMiddlewares(h.getLanguageRouting, middlewares...))
+	r.PUT("/api/language-routing", lib.ChainMiddlewares(h.updateLanguageRouting, middlewares...))
+	r.POST("/api/language-routing/detect", lib.ChainMiddlewares(h.detectLanguage, middlewares...))
+}
+
+// getLanguageRouting handles GET /api/language-routing - Get the language routing configuration
+func (h *LanguageRoutingHandler) getLanguageRouting(ctx *fasthttp.RequestCtx) {
+	response := lib.LanguageRoutingConfig{}
+	if config := h.store.GetLanguageRouting(); config != nil {
+		response = *config
+	}
+	if response.Routes == nil {
+		response.Routes = []lib.LanguageRoute{}
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// updateLanguageRouting handles PUT /api/language-routing - Replace the language routing configuration
+func (h *LanguageRoutingHandler) updateLanguageRouting(ctx *fasthttp.RequestCtx) {
+	var req lib.LanguageRoutingConfig
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Routes == nil {
+		req.Routes = []lib.LanguageRoute{}
+	}
+	if err := req.Validate(); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid language routing: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateLanguageRouting(ctx, req); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update language routing: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, req, h.logger)
+}
+
+// detectLanguage handles POST /api/language-routing/detect - Detect the language of a text, to tune routes
+func (h *LanguageRoutingHandler) detectLanguage(ctx *fasthttp.RequestCtx) {
+	var req DetectLanguageRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Text == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "text is required", h.logger)
+		return
+	}
+	SendJSON(ctx, langdetect.Detect(req.Text), h.logger)
+}
+
+// LanguageRoutingMiddleware tags inference requests with the language of their prompt, taken from the
+// x-bf-language header or detected, and sends them to the model of the first language route matching them.
+// It runs before ExperimentMiddleware, so experiment variants override the routed model and transformation
+// rules match it. Management API requests, non-JSON bodies and prompts of undetected language are passed through.
+func LanguageRoutingMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			routing := config.GetLanguageRouting()
+			path := string(ctx.Path())
+			if routing == nil || !routing.Enabled || !ctx.IsPost() || strings.HasPrefix(path, "/api/") {
+				next(ctx)
+				return
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil || body == nil {
+				next(ctx)
+				return
+			}
+			language := langdetect.Normalize(string(ctx.Request.Header.Peek(lib.LanguageHeader)))
+			if language == "" {
+				language = routing.Detect(body)
+			}
+			if language == "" {
+				next(ctx)
+				return
+			}
+			ctx.SetUserValue(schemas.BifrostContextKeyLanguage, language)
+			ctx.Response.Header.Set(lib.LanguageHeader, language)
+
+			model, _ := body["model"].(string)
+			if route := routing.Route(language, path, string(ctx.Request.Header.Peek("x-bf-vk")), model); route != nil && route.Model != model {
+				body["model"] = route.Model
+				updatedBody, err := json.Marshal(body)
+				if err != nil {
+					SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to apply language route: %v", err), logger)
+					return
+				}
+				ctx.Request.SetBody(updatedBody)
+				ctx.Response.Header.Set(lib.LanguageRouteHeader, route.Name)
+			}
+			next(ctx)
+		}
+	}
+}
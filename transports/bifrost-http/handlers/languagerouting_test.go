@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestLanguageRoutingMiddleware tests that requests are tagged with the language of their prompt and that
+// the first matching route replaces the model
+func TestLanguageRoutingMiddleware(t *testing.T) {
+	config := &lib.Config{}
+	err := config.UpdateLanguageRouting(context.Background(), lib.LanguageRoutingConfig{
+		Enabled: true,
+		Routes: []lib.LanguageRoute{
+			{Name: "disabled", Disabled: true, Languages: []string{"ja"}, Model: "openai/gpt-4o-mini"},
+			{Name: "japanese-support", Languages: []string{"ja"}, Match: lib.TransformationMatch{VirtualKeys: []string{"sk-bf-support"}}, Model: "anthropic/claude-3-5-sonnet"},
+			{Name: "cjk", Languages: []string{"ja", "zh", "ko"}, Model: "openai/gpt-4o"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to set language routing: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		header    string
+		language  string
+		route     string
+		wantModel string
+	}{
+		{
+			name:      "detected language with a route",
+			body:      `{"model":"openai/gpt-4o-mini","messages":[{"role":"system","content":"You are a helpful assistant."},{"role":"user","content":"東京でおすすめのラーメン屋を教えてください。"}]}`,
+			language:  "ja",
+			route:     "japanese-support",
+			wantModel: "anthropic/claude-3-5-sonnet",
+		},
+		{
+			name:      "content parts",
+			body:      `{"model":"openai/gpt-4o-mini","messages":[{"role":"user","content":[{"type":"text","text":"请总结这篇文章的要点。"}]}]}`,
+			language:  "zh",
+			route:     "cjk",
+			wantModel: "openai/gpt-4o",
+		},
+		{
+			name:      "detected language without a route",
+			body:      `{"model":"openai/gpt-4o-mini","prompt":"Quelle est la meilleure façon de cuire des pâtes ?"}`,
+			language:  "fr",
+			wantModel: "openai/gpt-4o-mini",
+		},
+		{
+			name:      "language header",
+			body:      `{"model":"openai/gpt-4o-mini","messages":[{"role":"user","content":"OK"}]}`,
+			header:    "ko-KR",
+			language:  "ko",
+			route:     "cjk",
+			wantModel: "openai/gpt-4o",
+		},
+		{
+			name:      "undetected language",
+			body:      `{"model":"openai/gpt-4o-mini","messages":[{"role":"user","content":"1 + 1 = ?"}]}`,
+			wantModel: "openai/gpt-4o-mini",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+			ctx.Request.SetRequestURI("/v1/chat/completions")
+			ctx.Request.Header.Set("x-bf-vk", "sk-bf-support")
+			if tt.header != "" {
+				ctx.Request.Header.Set(lib.LanguageHeader, tt.header)
+			}
+			ctx.Request.SetBodyString(tt.body)
+
+			var body map[string]any
+			LanguageRoutingMiddleware(config)(func(ctx *fasthttp.RequestCtx) {
+				if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+					t.Fatalf("invalid body: %v", err)
+				}
+			})(ctx)
+
+			if body["model"] != tt.wantModel {
+				t.Errorf("model = %v, want %s", body["model"], tt.wantModel)
+			}
+			language, _ := ctx.UserValue(schemas.BifrostContextKeyLanguage).(string)
+			if language != tt.language || string(ctx.Response.Header.Peek(lib.LanguageHeader)) != tt.language {
+				t.Errorf("language = %q, header %q, want %q", language, ctx.Response.Header.Peek(lib.LanguageHeader), tt.language)
+			}
+			if got := string(ctx.Response.Header.Peek(lib.LanguageRouteHeader)); got != tt.route {
+				t.Errorf("%s header = %q, want %q", lib.LanguageRouteHeader, got, tt.route)
+			}
+			if tt.language != "" {
+				bifrostCtx := lib.ConvertToBifrostContext(ctx, false)
+				if got, _ := (*bifrostCtx).Value(schemas.BifrostContextKeyLanguage).(string); got != tt.language {
+					t.Errorf("language in the Bifrost context = %q, want %q", got, tt.language)
+				}
+			}
+		})
+	}
+}
+
+// TestLanguageRoutingHandler_RejectsInvalidConfig tests that invalid routes are rejected and leave the active
+// configuration in place
+func TestLanguageRoutingHandler_RejectsInvalidConfig(t *testing.T) {
+	config := &lib.Config{}
+	if err := config.UpdateLanguageRouting(context.Background(), lib.LanguageRoutingConfig{Enabled: true}); err != nil {
+		t.Fatalf("failed to set language routing: %v", err)
+	}
+	h := NewLanguageRoutingHandler(config, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	for _, body := range []string{
+		`{"enabled":true,"min_confidence":1.5}`,
+		`{"enabled":true,"routes":[{"name":"ja","languages":["Japanese"],"model":"openai/gpt-4o"}]}`,
+		`{"enabled":true,"routes":[{"name":"ja","languages":["ja"],"model":"gpt-4o"}]}`,
+		`{"enabled":true,"routes":[{"name":"ja","model":"openai/gpt-4o"}]}`,
+	} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBodyString(body)
+		h.updateLanguageRouting(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, ctx.Response.StatusCode())
+		}
+	}
+	if routing := config.GetLanguageRouting(); routing == nil || !routing.Enabled || len(routing.Routes) != 0 {
+		t.Errorf("language routing = %+v, want the original configuration", routing)
+	}
+}
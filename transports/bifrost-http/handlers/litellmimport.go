@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"gopkg.in/yaml.v3"
+)
+
+// liteLLMExport is a LiteLLM proxy config.yaml, in YAML or JSON, optionally carrying the rows of the
+// LiteLLM_TeamTable and LiteLLM_VerificationToken tables of its database.
+type liteLLMExport struct {
+	ModelList       []liteLLMDeployment `yaml:"model_list"`
+	LiteLLMSettings map[string]any      `yaml:"litellm_settings"`
+	RouterSettings  map[string]any      `yaml:"router_settings"`
+	GeneralSettings map[string]any      `yaml:"general_settings"`
+	Teams           []liteLLMTeam       `yaml:"teams"`
+	TeamTable       []liteLLMTeam       `yaml:"LiteLLM_TeamTable"`
+	Keys            []liteLLMKey        `yaml:"keys"`
+	KeyTable        []liteLLMKey        `yaml:"LiteLLM_VerificationToken"`
+}
+
+// liteLLMDeployment is an entry of the model_list of a LiteLLM config.
+type liteLLMDeployment struct {
+	ModelName string        `yaml:"model_name"`
+	Params    liteLLMParams `yaml:"litellm_params"`
+}
+
+// liteLLMParams are the litellm_params of a deployment.
+type liteLLMParams struct {
+	Model              string  `yaml:"model"` // provider/model, e.g. openai/gpt-4o or azure/<deployment>
+	APIKey             string  `yaml:"api_key"`
+	APIBase            string  `yaml:"api_base"`
+	APIVersion         string  `yaml:"api_version"`
+	Weight             float64 `yaml:"weight"`
+	AWSAccessKeyID     string  `yaml:"aws_access_key_id"`
+	AWSSecretAccessKey string  `yaml:"aws_secret_access_key"`
+	AWSSessionToken    string  `yaml:"aws_session_token"`
+	AWSRegionName      string  `yaml:"aws_region_name"`
+	VertexProject      string  `yaml:"vertex_project"`
+	VertexLocation     string  `yaml:"vertex_location"`
+	VertexCredentials  string  `yaml:"vertex_credentials"`
+}
+
+// liteLLMTeam is a row of LiteLLM_TeamTable.
+type liteLLMTeam struct {
+	TeamID         string   `yaml:"team_id"`
+	TeamAlias      string   `yaml:"team_alias"`
+	MaxBudget      *float64 `yaml:"max_budget"`
+	BudgetDuration string   `yaml:"budget_duration"`
+	TPMLimit       *int64   `yaml:"tpm_limit"`
+	RPMLimit       *int64   `yaml:"rpm_limit"`
+	Blocked        bool     `yaml:"blocked"`
+}
+
+// liteLLMKey is a row of LiteLLM_VerificationToken. Token holds the hash of the key, never its value.
+type liteLLMKey struct {
+	Token          string   `yaml:"token"`
+	KeyAlias       string   `yaml:"key_alias"`
+	KeyName        string   `yaml:"key_name"`
+	TeamID         *string  `yaml:"team_id"`
+	Models         []string `yaml:"models"`
+	MaxBudget      *float64 `yaml:"max_budget"`
+	BudgetDuration string   `yaml:"budget_duration"`
+	TPMLimit       *int64   `yaml:"tpm_limit"`
+	RPMLimit       *int64   `yaml:"rpm_limit"`
+	Blocked        bool     `yaml:"blocked"`
+}
+
+// liteLLMProviders maps LiteLLM provider prefixes to Bifrost providers.
+var liteLLMProviders = map[string]schemas.ModelProvider{
+	"openai":                 schemas.OpenAI,
+	"text-completion-openai": schemas.OpenAI,
+	"azure":                  schemas.Azure,
+	"anthropic":              schemas.Anthropic,
+	"bedrock":                schemas.Bedrock,
+	"vertex_ai":              schemas.Vertex,
+	"vertex_ai_beta":         schemas.Vertex,
+	"gemini":                 schemas.Gemini,
+	"groq":                   schemas.Groq,
+	"mistral":                schemas.Mistral,
+	"cohere":                 schemas.Cohere,
+	"cohere_chat":            schemas.Cohere,
+	"ollama":                 schemas.Ollama,
+	"ollama_chat":            schemas.Ollama,
+	"openrouter":             schemas.OpenRouter,
+	"cerebras":               schemas.Cerebras,
+	"voyage":                 schemas.Voyage,
+	"jina_ai":                schemas.Jina,
+}
+
+// parseLiteLLMExport maps a LiteLLM export to Bifrost: deployments sharing credentials become one provider key
+// serving their models, teams keep their budget and limits, and keys become virtual keys limited to the models
+// they were allowed.
+func parseLiteLLMExport(body []byte) (*ImportedConfig, error) {
+	var export liteLLMExport
+	if err := yaml.Unmarshal(body, &export); err != nil {
+		return nil, fmt.Errorf("invalid LiteLLM export: %w", err)
+	}
+	teams := append(export.Teams, export.TeamTable...)
+	keys := append(export.Keys, export.KeyTable...)
+	if len(export.ModelList) == 0 && len(teams) == 0 && len(keys) == 0 {
+		return nil, fmt.Errorf("the LiteLLM export has no model_list, teams or keys")
+	}
+	imported := &ImportedConfig{Keys: make(map[schemas.ModelProvider][]schemas.Key)}
+
+	// Deployments sharing credentials are one key; model names map to the deployments serving them
+	keyIndex := make(map[string]int)
+	deployments := make(map[string][]ImportedProviderModel)
+	var aliases []string
+	for i, deployment := range export.ModelList {
+		prefix, model, ok := strings.Cut(deployment.Params.Model, "/")
+		provider, known := liteLLMProviders[prefix]
+		if !ok || !known {
+			imported.warn("model_list[%d] (%s): provider of %q is not supported, skipped", i, deployment.ModelName, deployment.Params.Model)
+			continue
+		}
+		key := liteLLMKeyOf(provider, deployment.Params)
+		id, credentials := liteLLMKeyID(provider, key)
+		index, seen := keyIndex[credentials]
+		if !seen {
+			index = len(imported.Keys[provider])
+			keyIndex[credentials] = index
+			key.ID = id
+			imported.Keys[provider] = append(imported.Keys[provider], key)
+		}
+		stored := &imported.Keys[provider][index]
+		if provider == schemas.Azure {
+			// Azure deployments are named by the model of the deployment
+			stored.AzureKeyConfig.Deployments[deployment.ModelName] = model
+			model = deployment.ModelName
+		}
+		if !slices.Contains(stored.Models, model) {
+			stored.Models = append(stored.Models, model)
+		}
+		if deployment.Params.APIBase != "" && provider != schemas.Azure {
+			imported.warn("model_list[%d] (%s): api_base is not imported, set the base_url of the %s network config", i, deployment.ModelName, provider)
+		}
+		if deployment.ModelName != model {
+			aliases = append(aliases, fmt.Sprintf("%s -> %s/%s", deployment.ModelName, provider, model))
+		}
+		deployments[deployment.ModelName] = append(deployments[deployment.ModelName], ImportedProviderModel{Provider: provider, Model: model})
+	}
+	if len(aliases) > 0 {
+		slices.Sort(aliases)
+		aliases = slices.Compact(aliases)
+		imported.warn("model aliases are not imported, clients request provider/model instead: %s", strings.Join(aliases, ", "))
+	}
+
+	for _, team := range teams {
+		if team.TeamID == "" {
+			imported.warn("a team without team_id was skipped")
+			continue
+		}
+		name := team.TeamAlias
+		if name == "" {
+			name = team.TeamID
+		}
+		imported.Teams = append(imported.Teams, ImportedTeam{
+			ID:        "litellm-team-" + team.TeamID,
+			Name:      name,
+			Budget:    imported.liteLLMBudget("team "+name, team.MaxBudget, team.BudgetDuration),
+			RateLimit: liteLLMRateLimit(team.TPMLimit, team.RPMLimit),
+		})
+		if team.Blocked {
+			imported.warn("team %s is blocked in LiteLLM; Bifrost teams cannot be blocked, deactivate its virtual keys instead", name)
+		}
+	}
+
+	for i, key := range keys {
+		name := key.KeyAlias
+		if name == "" {
+			name = key.KeyName
+		}
+		if key.Token == "" && name == "" {
+			imported.warn("keys[%d]: a key without token or alias was skipped", i)
+			continue
+		}
+		hash := sha256.Sum256([]byte(key.Token + "/" + name))
+		if name == "" {
+			name = "litellm-" + hex.EncodeToString(hash[:4])
+		}
+		vk := ImportedVirtualKey{
+			ID:        "litellm-key-" + hex.EncodeToString(hash[:8]),
+			Name:      name,
+			IsActive:  !key.Blocked,
+			Budget:    imported.liteLLMBudget("key "+name, key.MaxBudget, key.BudgetDuration),
+			RateLimit: liteLLMRateLimit(key.TPMLimit, key.RPMLimit),
+		}
+		if key.TeamID != nil && *key.TeamID != "" {
+			if slices.ContainsFunc(teams, func(team liteLLMTeam) bool { return team.TeamID == *key.TeamID }) {
+				vk.TeamID = schemas.Ptr("litellm-team-" + *key.TeamID)
+			} else {
+				imported.warn("key %s: team %s is not in the export, the key is imported without a team", name, *key.TeamID)
+			}
+		}
+		// Keys limited to model names keep those models, on the providers of their deployments
+		allowed := make(map[schemas.ModelProvider][]string)
+		for _, modelName := range key.Models {
+			if modelName == "all-proxy-models" || modelName == "*" {
+				allowed = nil
+				break
+			}
+			served, ok := deployments[modelName]
+			if !ok {
+				imported.warn("key %s: model %s has no deployment, not allowed", name, modelName)
+				continue
+			}
+			for _, d := range served {
+				if !slices.Contains(allowed[d.Provider], d.Model) {
+					allowed[d.Provider] = append(allowed[d.Provider], d.Model)
+				}
+			}
+		}
+		for provider, models := range allowed {
+			vk.ProviderConfigs = append(vk.ProviderConfigs, configstore.TableVirtualKeyProviderConfig{Provider: string(provider), Weight: 1, AllowedModels: models})
+		}
+		sort.Slice(vk.ProviderConfigs, func(i, j int) bool { return vk.ProviderConfigs[i].Provider < vk.ProviderConfigs[j].Provider })
+		imported.VirtualKeys = append(imported.VirtualKeys, vk)
+	}
+	if len(imported.VirtualKeys) > 0 {
+		imported.warn("LiteLLM only stores the hashes of its keys: imported virtual keys get new values, which clients must switch to")
+	}
+
+	if _, ok := export.GeneralSettings["master_key"]; ok {
+		imported.warn("general_settings.master_key is not imported, protect the dashboard with BIFROST_ADMIN_PASSWORD")
+	}
+	for section, settings := range map[string]map[string]any{"litellm_settings": export.LiteLLMSettings, "router_settings": export.RouterSettings} {
+		if len(settings) > 0 {
+			names := make([]string, 0, len(settings))
+			for name := range settings {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			imported.warn("%s are not imported: %s", section, strings.Join(names, ", "))
+		}
+	}
+	sort.Strings(imported.Warnings)
+	return imported, nil
+}
+
+// liteLLMKeyOf returns the Bifrost key of the credentials of a deployment.
+func liteLLMKeyOf(provider schemas.ModelProvider, params liteLLMParams) schemas.Key {
+	weight := params.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	key := schemas.Key{Value: liteLLMValue(params.APIKey), Weight: weight}
+	switch provider {
+	case schemas.Azure:
+		key.AzureKeyConfig = &schemas.AzureKeyConfig{Endpoint: liteLLMValue(params.APIBase), Deployments: map[string]string{}}
+		if params.APIVersion != "" {
+			key.AzureKeyConfig.APIVersion = schemas.Ptr(liteLLMValue(params.APIVersion))
+		}
+	case schemas.Bedrock:
+		if params.AWSAccessKeyID != "" || params.AWSRegionName != "" {
+			key.BedrockKeyConfig = &schemas.BedrockKeyConfig{
+				AccessKey: liteLLMValue(params.AWSAccessKeyID),
+				SecretKey: liteLLMValue(params.AWSSecretAccessKey),
+			}
+			if params.AWSSessionToken != "" {
+				key.BedrockKeyConfig.SessionToken = schemas.Ptr(liteLLMValue(params.AWSSessionToken))
+			}
+			if params.AWSRegionName != "" {
+				key.BedrockKeyConfig.Region = schemas.Ptr(liteLLMValue(params.AWSRegionName))
+			}
+		}
+	case schemas.Vertex:
+		key.VertexKeyConfig = &schemas.VertexKeyConfig{
+			ProjectID:       liteLLMValue(params.VertexProject),
+			Region:          liteLLMValue(params.VertexLocation),
+			AuthCredentials: liteLLMValue(params.VertexCredentials),
+		}
+	}
+	return key
+}
+
+// liteLLMKeyID returns the ID of the key of some credentials, stable across imports, and the credentials
+// identifying it. Only a hash of the credentials is part of the ID.
+func liteLLMKeyID(provider schemas.ModelProvider, key schemas.Key) (string, string) {
+	parts := []string{string(provider), key.Value}
+	if azure := key.AzureKeyConfig; azure != nil {
+		parts = append(parts, azure.Endpoint)
+	}
+	if bedrock := key.BedrockKeyConfig; bedrock != nil {
+		parts = append(parts, bedrock.AccessKey, bedrock.SecretKey)
+	}
+	if vertex := key.VertexKeyConfig; vertex != nil {
+		parts = append(parts, vertex.ProjectID, vertex.Region, vertex.AuthCredentials)
+	}
+	credentials := strings.Join(parts, "\x00")
+	hash := sha256.Sum256([]byte(credentials))
+	return fmt.Sprintf("litellm-%s-%s", provider, hex.EncodeToString(hash[:6])), credentials
+}
+
+// liteLLMValue converts the os.environ/VAR references of LiteLLM to env.VAR references.
+func liteLLMValue(value string) string {
+	if name, ok := strings.CutPrefix(value, "os.environ/"); ok {
+		return "env." + name
+	}
+	return value
+}
+
+// liteLLMBudget returns the budget of a team or key, nil without max_budget. LiteLLM budget durations such as
+// 30d or 1mo reset rolling budgets; invalid ones leave the budget out with a warning.
+func (c *ImportedConfig) liteLLMBudget(owner string, maxBudget *float64, duration string) *CreateBudgetRequest {
+	if maxBudget == nil {
+		return nil
+	}
+	if duration == "" {
+		// LiteLLM budgets without a duration never reset; a century is as good as never
+		duration = "100Y"
+	}
+	duration = strings.Replace(duration, "mo", "M", 1)
+	if err := configstore.ValidateBudgetReset("", duration); err != nil {
+		c.warn("%s: budget duration %q is not supported, budget skipped", owner, duration)
+		return nil
+	}
+	return &CreateBudgetRequest{MaxLimit: *maxBudget, ResetDuration: duration}
+}
+
+// liteLLMRateLimit returns the per-minute limits of a team or key, nil without limits.
+func liteLLMRateLimit(tpm, rpm *int64) *CreateRateLimitRequest {
+	if tpm == nil && rpm == nil {
+		return nil
+	}
+	limit := &CreateRateLimitRequest{}
+	if tpm != nil {
+		limit.TokenMaxLimit = tpm
+		limit.TokenResetDuration = schemas.Ptr("1m")
+	}
+	if rpm != nil {
+		limit.RequestMaxLimit = rpm
+		limit.RequestResetDuration = schemas.Ptr("1m")
+	}
+	return limit
+}
@@ -41,66 +41,9 @@ func (h *LoggingHandler) RegisterRoutes(r *router.Router, middlewares ...lib.Bif
 // getLogs handles GET /api/logs - Get logs with filtering, search, and pagination via query parameters
 func (h *LoggingHandler) getLogs(ctx *fasthttp.RequestCtx) {
 	// Parse query parameters into filters
-	filters := &logstore.SearchFilters{}
+	filters := parseLogFilters(ctx.QueryArgs())
 	pagination := &logstore.PaginationOptions{}
 
-	// Extract filters from query parameters
-	if providers := string(ctx.QueryArgs().Peek("providers")); providers != "" {
-		filters.Providers = parseCommaSeparated(providers)
-	}
-	if models := string(ctx.QueryArgs().Peek("models")); models != "" {
-		filters.Models = parseCommaSeparated(models)
-	}
-	if statuses := string(ctx.QueryArgs().Peek("status")); statuses != "" {
-		filters.Status = parseCommaSeparated(statuses)
-	}
-	if objects := string(ctx.QueryArgs().Peek("objects")); objects != "" {
-		filters.Objects = parseCommaSeparated(objects)
-	}
-	if startTime := string(ctx.QueryArgs().Peek("start_time")); startTime != "" {
-		if t, err := time.Parse(time.RFC3339, startTime); err == nil {
-			filters.StartTime = &t
-		}
-	}
-	if endTime := string(ctx.QueryArgs().Peek("end_time")); endTime != "" {
-		if t, err := time.Parse(time.RFC3339, endTime); err == nil {
-			filters.EndTime = &t
-		}
-	}
-	if minLatency := string(ctx.QueryArgs().Peek("min_latency")); minLatency != "" {
-		if f, err := strconv.ParseFloat(minLatency, 64); err == nil {
-			filters.MinLatency = &f
-		}
-	}
-	if maxLatency := string(ctx.QueryArgs().Peek("max_latency")); maxLatency != "" {
-		if val, err := strconv.ParseFloat(maxLatency, 64); err == nil {
-			filters.MaxLatency = &val
-		}
-	}
-	if minTokens := string(ctx.QueryArgs().Peek("min_tokens")); minTokens != "" {
-		if val, err := strconv.Atoi(minTokens); err == nil {
-			filters.MinTokens = &val
-		}
-	}
-	if maxTokens := string(ctx.QueryArgs().Peek("max_tokens")); maxTokens != "" {
-		if val, err := strconv.Atoi(maxTokens); err == nil {
-			filters.MaxTokens = &val
-		}
-	}
-	if cost := string(ctx.QueryArgs().Peek("min_cost")); cost != "" {
-		if val, err := strconv.ParseFloat(cost, 64); err == nil {
-			filters.MinCost = &val
-		}
-	}
-	if maxCost := string(ctx.QueryArgs().Peek("max_cost")); maxCost != "" {
-		if val, err := strconv.ParseFloat(maxCost, 64); err == nil {
-			filters.MaxCost = &val
-		}
-	}
-	if contentSearch := string(ctx.QueryArgs().Peek("content_search")); contentSearch != "" {
-		filters.ContentSearch = contentSearch
-	}
-
 	// Extract pagination parameters
 	pagination.Limit = 50 // Default limit
 	if limit := string(ctx.QueryArgs().Peek("limit")); limit != "" {
@@ -143,15 +86,119 @@ func (h *LoggingHandler) getLogs(ctx *fasthttp.RequestCtx) {
 		}
 	}
 
+	// A cursor from a previous page takes precedence over offset
+	if cursor := string(ctx.QueryArgs().Peek("cursor")); cursor != "" {
+		offset, err := decodeLogsCursor(cursor, pagination)
+		if err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+		pagination.Offset = offset
+	}
+
 	result, err := h.logManager.Search(ctx, filters, pagination)
 	if err != nil {
 		h.logger.Error("failed to search logs: %v", err)
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Search failed: %v", err), h.logger)
 		return
 	}
+	if next := pagination.Offset + len(result.Logs); len(result.Logs) > 0 && int64(next) < result.Stats.TotalRequests {
+		ctx.Response.Header.Set(HeaderNextCursor, encodeListCursor(&listCursor{
+			SortBy: pagination.SortBy,
+			Desc:   pagination.Order == "desc",
+			Value:  strconv.Itoa(next),
+		}))
+	}
 	SendJSON(ctx, result, h.logger)
 }
 
+// parseLogFilters parses the log search filters of the query parameters of /api/logs. Invalid values are ignored.
+func parseLogFilters(args *fasthttp.Args) *logstore.SearchFilters {
+	filters := &logstore.SearchFilters{}
+	if providers := string(args.Peek("providers")); providers != "" {
+		filters.Providers = parseCommaSeparated(providers)
+	}
+	if models := string(args.Peek("models")); models != "" {
+		filters.Models = parseCommaSeparated(models)
+	}
+	if statuses := string(args.Peek("status")); statuses != "" {
+		filters.Status = parseCommaSeparated(statuses)
+	}
+	if objects := string(args.Peek("objects")); objects != "" {
+		filters.Objects = parseCommaSeparated(objects)
+	}
+	if startTime := string(args.Peek("start_time")); startTime != "" {
+		if t, err := time.Parse(time.RFC3339, startTime); err == nil {
+			filters.StartTime = &t
+		}
+	}
+	if endTime := string(args.Peek("end_time")); endTime != "" {
+		if t, err := time.Parse(time.RFC3339, endTime); err == nil {
+			filters.EndTime = &t
+		}
+	}
+	if minLatency := string(args.Peek("min_latency")); minLatency != "" {
+		if f, err := strconv.ParseFloat(minLatency, 64); err == nil {
+			filters.MinLatency = &f
+		}
+	}
+	if maxLatency := string(args.Peek("max_latency")); maxLatency != "" {
+		if val, err := strconv.ParseFloat(maxLatency, 64); err == nil {
+			filters.MaxLatency = &val
+		}
+	}
+	if minTokens := string(args.Peek("min_tokens")); minTokens != "" {
+		if val, err := strconv.Atoi(minTokens); err == nil {
+			filters.MinTokens = &val
+		}
+	}
+	if maxTokens := string(args.Peek("max_tokens")); maxTokens != "" {
+		if val, err := strconv.Atoi(maxTokens); err == nil {
+			filters.MaxTokens = &val
+		}
+	}
+	if cost := string(args.Peek("min_cost")); cost != "" {
+		if val, err := strconv.ParseFloat(cost, 64); err == nil {
+			filters.MinCost = &val
+		}
+	}
+	if maxCost := string(args.Peek("max_cost")); maxCost != "" {
+		if val, err := strconv.ParseFloat(maxCost, 64); err == nil {
+			filters.MaxCost = &val
+		}
+	}
+	if contentSearch := string(args.Peek("content_search")); contentSearch != "" {
+		filters.ContentSearch = contentSearch
+	}
+	if customers := string(args.Peek("customers")); customers != "" {
+		filters.Customers = parseCommaSeparated(customers)
+	}
+	if languages := string(args.Peek("languages")); languages != "" {
+		filters.Languages = parseCommaSeparated(languages)
+	}
+	if labels, err := lib.ParseLabelsHeader(string(args.Peek("labels"))); err == nil && len(labels) > 0 {
+		filters.Labels = labels
+	}
+	return filters
+}
+
+// decodeLogsCursor returns the offset stored in a logs cursor.
+// Logs are paged by the log store, so their cursors carry an offset instead of a sort key.
+func decodeLogsCursor(s string, pagination *logstore.PaginationOptions) (int, error) {
+	cursor, err := decodeListCursor(s)
+	if err != nil {
+		return 0, err
+	}
+	if cursor.SortBy != pagination.SortBy || cursor.Desc != (pagination.Order == "desc") {
+		return 0, fmt.Errorf("cursor does not match sort_by and order")
+	}
+	offset, err := strconv.Atoi(cursor.Value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
 // getDroppedRequests handles GET /api/logs/dropped - Get the number of dropped requests
 func (h *LoggingHandler) getDroppedRequests(ctx *fasthttp.RequestCtx) {
 	droppedRequests := h.logManager.GetDroppedRequests(ctx)
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// LogLevelHandler reads and changes the server log level at runtime.
+type LogLevelHandler struct {
+	logger schemas.Logger
+	mu     sync.Mutex
+	level  schemas.LogLevel
+}
+
+// LogLevelRequest is the body of PUT /api/logging/level.
+type LogLevelRequest struct {
+	Level schemas.LogLevel `json:"level"`
+}
+
+// NewLogLevelHandler creates a new log level handler starting at the given level.
+func NewLogLevelHandler(logger schemas.Logger, level schemas.LogLevel) *LogLevelHandler {
+	return &LogLevelHandler{
+		logger: logger,
+		level:  level,
+	}
+}
+
+// RegisterRoutes registers the log level routes.
+func (h *LogLevelHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/logging/level", lib.ChainMiddlewares(h.getLevel, middlewares...))
+	r.PUT("/api/logging/level", lib.ChainMiddlewares(h.setLevel, middlewares...))
+}
+
+// getLevel handles GET /api/logging/level - Get the current log level
+func (h *LogLevelHandler) getLevel(ctx *fasthttp.RequestCtx) {
+	h.mu.Lock()
+	level := h.level
+	h.mu.Unlock()
+	SendJSON(ctx, LogLevelRequest{Level: level}, h.logger)
+}
+
+// setLevel handles PUT /api/logging/level - Change the log level without a restart
+func (h *LogLevelHandler) setLevel(ctx *fasthttp.RequestCtx) {
+	var req LogLevelRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	switch req.Level {
+	case schemas.LogLevelDebug, schemas.LogLevelInfo, schemas.LogLevelWarn, schemas.LogLevelError:
+	default:
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid log level %q, expected one of debug, info, warn, error", req.Level), h.logger)
+		return
+	}
+	h.mu.Lock()
+	previous := h.level
+	h.level = req.Level
+	h.logger.SetLevel(req.Level)
+	h.mu.Unlock()
+	h.logger.Info("log level changed from %s to %s", previous, req.Level)
+	SendJSON(ctx, LogLevelRequest{Level: req.Level}, h.logger)
+}
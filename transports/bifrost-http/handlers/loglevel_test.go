@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+// levelRecordingLogger records the last level set on it
+type levelRecordingLogger struct {
+	schemas.Logger
+	level schemas.LogLevel
+}
+
+func (l *levelRecordingLogger) SetLevel(level schemas.LogLevel) {
+	l.level = level
+}
+
+// TestLogLevelHandler_SetLevel tests that valid levels are applied and invalid ones are rejected
+func TestLogLevelHandler_SetLevel(t *testing.T) {
+	logger := &levelRecordingLogger{Logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+	handler := NewLogLevelHandler(logger, schemas.LogLevelInfo)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetBodyString(`{"level":"verbose"}`)
+	handler.setLevel(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown level, got %d", ctx.Response.StatusCode())
+	}
+	if logger.level != "" {
+		t.Errorf("Expected level to be unchanged, got %s", logger.level)
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetBodyString(`{"level":"debug"}`)
+	handler.setLevel(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("Expected status 200, got %d", ctx.Response.StatusCode())
+	}
+	if logger.level != schemas.LogLevelDebug {
+		t.Errorf("Expected level debug, got %s", logger.level)
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	handler.getLevel(ctx)
+	if body := strings.TrimSpace(string(ctx.Response.Body())); body != `{"level":"debug"}` {
+		t.Errorf("Expected current level in response, got %s", body)
+	}
+}
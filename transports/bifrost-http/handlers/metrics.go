@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/telemetry"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/valyala/fasthttp"
+)
+
+// MetricsHandler documents the metrics exported on /metrics.
+type MetricsHandler struct {
+	config   *lib.Config
+	gatherer prometheus.Gatherer
+	logger   schemas.Logger
+}
+
+// MetricsCatalogResponse is the response of GET /api/metrics/catalog.
+type MetricsCatalogResponse struct {
+	Metrics        []telemetry.MetricDescription `json:"metrics"`
+	CustomLabels   []string                      `json:"custom_labels"`    // Set per request with x-bf-prom-<label> headers
+	MaxLabelValues int                           `json:"max_label_values"` // Distinct values kept per open-ended label
+	OverflowValue  string                        `json:"overflow_value"`   // Reported once a label reaches its limit
+}
+
+// NewMetricsHandler creates a new metrics handler reading the metrics of gatherer.
+func NewMetricsHandler(config *lib.Config, gatherer prometheus.Gatherer, logger schemas.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		config:   config,
+		gatherer: gatherer,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the metrics catalog route.
+func (h *MetricsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/metrics/catalog", lib.ChainMiddlewares(h.getCatalog, middlewares...))
+}
+
+// getCatalog handles GET /api/metrics/catalog - List every exported metric with its type, help, labels and buckets
+// Bifrost's own metrics are listed even before their first observation; metrics of other collectors (Go runtime,
+// process) are listed as gathered.
+func (h *MetricsHandler) getCatalog(ctx *fasthttp.RequestCtx) {
+	metrics := append(telemetry.Catalog(), upstreamConnectionCatalog()...)
+	known := map[string]bool{}
+	for _, metric := range metrics {
+		known[metric.Name] = true
+	}
+	families, err := h.gatherer.Gather()
+	if err != nil {
+		h.logger.Warn("failed to gather metrics for the catalog: %v", err)
+	}
+	for _, family := range families {
+		if known[family.GetName()] {
+			continue
+		}
+		description := telemetry.MetricDescription{
+			Name:   family.GetName(),
+			Type:   strings.ToLower(family.GetType().String()),
+			Help:   family.GetHelp(),
+			Labels: []string{},
+		}
+		if len(family.GetMetric()) > 0 {
+			for _, label := range family.GetMetric()[0].GetLabel() {
+				description.Labels = append(description.Labels, label.GetName())
+			}
+			if family.GetType() == dto.MetricType_HISTOGRAM {
+				for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+					description.Buckets = append(description.Buckets, bucket.GetUpperBound())
+				}
+			}
+		}
+		metrics = append(metrics, description)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+
+	response := MetricsCatalogResponse{
+		Metrics:        metrics,
+		CustomLabels:   h.config.ClientConfig.PrometheusLabels,
+		MaxLabelValues: telemetry.DefaultMaxLabelValues,
+		OverflowValue:  telemetry.OverflowLabelValue,
+	}
+	if response.CustomLabels == nil {
+		response.CustomLabels = []string{}
+	}
+	if h.config.Metrics != nil && h.config.Metrics.MaxLabelValues > 0 {
+		response.MaxLabelValues = h.config.Metrics.MaxLabelValues
+	}
+	SendJSON(ctx, response, h.logger)
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/plugins/telemetry"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/valyala/fasthttp"
+)
+
+var initTestTelemetryOnce sync.Once
+
+// initTestTelemetry registers the telemetry metrics once per test binary, with 3 values per label and a key tier
+func initTestTelemetry() {
+	initTestTelemetryOnce.Do(func() {
+		telemetry.InitPrometheusMetrics([]string{"team"}, &telemetry.Config{
+			KeyTiers:       map[string]string{"key-primary": "primary"},
+			MaxLabelValues: 3,
+		})
+	})
+}
+
+// findMetric returns the series of a gathered metric whose labels include labels
+func findMetric(t *testing.T, name string, labels map[string]string) *dto.Metric {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	series:
+		for _, metric := range family.GetMetric() {
+			values := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				values[label.GetName()] = label.GetValue()
+			}
+			for key, value := range labels {
+				if values[key] != value {
+					continue series
+				}
+			}
+			return metric
+		}
+	}
+	return nil
+}
+
+// TestTelemetry_LabelCardinality tests that label values beyond the limit are reported as __other__
+func TestTelemetry_LabelCardinality(t *testing.T) {
+	initTestTelemetry()
+	handler := telemetry.PrometheusMiddleware(func(ctx *fasthttp.RequestCtx) {})
+	for i := 0; i < 5; i++ {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(fmt.Sprintf("/api/cardinality-test/%d", i))
+		handler(ctx)
+	}
+	if findMetric(t, "http_requests_total", map[string]string{"path": telemetry.OverflowLabelValue}) == nil {
+		t.Error("expected paths beyond the limit to be reported as __other__")
+	}
+	if findMetric(t, "http_requests_total", map[string]string{"path": "/api/cardinality-test/4"}) != nil {
+		t.Error("expected the fifth path not to get its own series")
+	}
+	if metric := findMetric(t, "bifrost_metric_label_overflow_total", map[string]string{"label": "path"}); metric == nil || metric.GetCounter().GetValue() < 2 {
+		t.Errorf("expected the overflows to be counted, got %v", metric)
+	}
+}
+
+// TestTelemetry_KeyTierAndExemplars tests that upstream latency carries the key tier and a trace_id exemplar
+func TestTelemetry_KeyTierAndExemplars(t *testing.T) {
+	initTestTelemetry()
+	plugin, err := telemetry.Init(nil, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	if err != nil {
+		t.Fatalf("failed to init telemetry: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "0af76519-16cd-43dd-8448-eb211c80319c")
+	ctx = context.WithValue(ctx, schemas.BifrostContextKeySelectedKey, "key-primary")
+	plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-exemplar", RequestType: schemas.ChatCompletionRequest})
+	result := &schemas.BifrostResponse{ExtraFields: schemas.BifrostResponseExtraFields{
+		RequestType: schemas.ChatCompletionRequest, Provider: schemas.OpenAI, ModelRequested: "gpt-exemplar",
+	}}
+	plugin.PostHook(&ctx, result, nil)
+
+	labels := map[string]string{"model": "gpt-exemplar", "key_tier": "primary"}
+	var metric *dto.Metric
+	for deadline := time.Now().Add(5 * time.Second); metric == nil && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		metric = findMetric(t, "bifrost_upstream_latency_seconds", labels)
+	}
+	if metric == nil {
+		t.Fatal("expected a latency observation with the key tier")
+	}
+	var traceID string
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		for _, label := range bucket.GetExemplar().GetLabel() {
+			if label.GetName() == "trace_id" {
+				traceID = label.GetValue()
+			}
+		}
+	}
+	if traceID != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("expected the request ID as the trace_id exemplar, got %q", traceID)
+	}
+}
+
+// TestMetricsHandler_Catalog tests that the catalog lists registered metrics before their first observation
+func TestMetricsHandler_Catalog(t *testing.T) {
+	initTestTelemetry()
+	config := &lib.Config{
+		ClientConfig: configstore.ClientConfig{PrometheusLabels: []string{"team"}},
+		Metrics:      &telemetry.Config{MaxLabelValues: 3},
+	}
+	ctx := &fasthttp.RequestCtx{}
+	NewMetricsHandler(config, prometheus.DefaultGatherer, bifrost.NewDefaultLogger(schemas.LogLevelError)).getCatalog(ctx)
+
+	var response MetricsCatalogResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	metrics := map[string]telemetry.MetricDescription{}
+	for _, metric := range response.Metrics {
+		metrics[metric.Name] = metric
+	}
+	latency, ok := metrics["bifrost_stream_duration_seconds"]
+	if !ok || latency.Type != "histogram" || !latency.Exemplars || len(latency.Buckets) == 0 {
+		t.Errorf("unexpected description of the stream duration: %+v", latency)
+	}
+	errors := metrics["bifrost_error_requests_total"]
+	if fmt.Sprint(errors.Labels) != "[provider model method key_tier reason team]" {
+		t.Errorf("unexpected labels: %v", errors.Labels)
+	}
+	if _, ok := metrics["bifrost_upstream_connections_open"]; !ok {
+		t.Error("expected the upstream connection metrics")
+	}
+	if _, ok := metrics["go_goroutines"]; !ok {
+		t.Error("expected the gathered Go runtime metrics")
+	}
+	if response.MaxLabelValues != 3 || response.OverflowValue != telemetry.OverflowLabelValue {
+		t.Errorf("unexpected limits: %+v", response)
+	}
+}
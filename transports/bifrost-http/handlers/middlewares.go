@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	bifrost "github.com/maximhq/bifrost/core"
 	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
 	"github.com/maximhq/bifrost/plugins/governance"
 	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
 	"github.com/valyala/fasthttp"
@@ -40,6 +46,37 @@ func CorsMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
 	}
 }
 
+// SecurityHeadersMiddleware sets the configured security headers on every UI and API response, after the handler
+// ran so that responses built from scratch keep them. Strict-Transport-Security is only sent on TLS requests,
+// including those a TLS terminating proxy forwards with X-Forwarded-Proto: https. Headers set by the handler win.
+func SecurityHeadersMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	headers := config.SecurityHeaders.Resolve()
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			next(ctx)
+			for _, header := range headers.Headers {
+				if len(ctx.Response.Header.Peek(header[0])) == 0 {
+					ctx.Response.Header.Set(header[0], header[1])
+				}
+			}
+			if headers.HSTS != "" && (ctx.IsTLS() || strings.EqualFold(string(ctx.Request.Header.Peek("X-Forwarded-Proto")), "https")) {
+				ctx.Response.Header.Set("Strict-Transport-Security", headers.HSTS)
+			}
+		}
+	}
+}
+
+// ResponseHeadersMiddleware lets plugins add headers to inference responses through lib.ResponseHeaders.
+// Streaming handlers return before the body is written, so the headers also precede streamed bodies.
+func ResponseHeadersMiddleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		responseHeaders := &lib.ResponseHeaders{}
+		ctx.SetUserValue(lib.ResponseHeadersContextKey, responseHeaders)
+		next(ctx)
+		responseHeaders.WriteTo(&ctx.Response.Header)
+	}
+}
+
 func TransportInterceptorMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
 	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		return func(ctx *fasthttp.RequestCtx) {
@@ -63,13 +100,14 @@ func TransportInterceptorMiddleware(config *lib.Config) lib.BifrostHTTPMiddlewar
 				return
 			}
 
-			// Parse headers
-			headers := make(map[string]string)
-			originalHeaderNames := make([]string, 0, 16)
+			// Parse headers into pooled storage, released once they are applied back to the request
+			parsed := acquireInterceptorHeaders()
+			defer parsed.release()
+			headers := parsed.values
 			ctx.Request.Header.All()(func(key, value []byte) bool {
 				name := string(key)
 				headers[name] = string(value)
-				originalHeaderNames = append(originalHeaderNames, name)
+				parsed.names = append(parsed.names, name)
 
 				return true
 			})
@@ -80,17 +118,40 @@ func TransportInterceptorMiddleware(config *lib.Config) lib.BifrostHTTPMiddlewar
 			if len(bodyBytes) > 0 {
 				if err := json.Unmarshal(bodyBytes, &requestBody); err != nil {
 					// If body is not valid JSON, log warning and continue without interception
-					logger.Warn(fmt.Sprintf("TransportInterceptor: Failed to unmarshal request body: %v", err))
+					logger.Warn("TransportInterceptor: Failed to unmarshal request body: %v", err)
 					next(ctx)
 					return
 				}
 			}
 
-			// Call TransportInterceptor on all plugins
+			// Call TransportInterceptor on all plugins, recording what each one changes when the request is traced
+			trace, _ := ctx.UserValue(lib.RequestTraceContextKey).(*lib.RequestTrace)
 			for _, plugin := range plugins {
+				var headersBefore, bodyBefore any
+				var started time.Time
+				if trace != nil {
+					headersBefore, bodyBefore, started = schemas.SnapshotJSON(headers), schemas.SnapshotJSON(requestBody), time.Now()
+				}
 				modifiedHeaders, modifiedBody, err := plugin.TransportInterceptor(string(ctx.Request.URI().RequestURI()), headers, requestBody)
+				if trace != nil {
+					mutation := lib.InterceptorMutation{Plugin: plugin.GetName(), Latency: time.Since(started)}
+					if err != nil {
+						mutation.Error = err.Error()
+					} else {
+						headersAfter, bodyAfter := headers, requestBody
+						if modifiedHeaders != nil {
+							headersAfter = modifiedHeaders
+						}
+						if modifiedBody != nil {
+							bodyAfter = modifiedBody
+						}
+						mutation.Headers = schemas.DiffJSON(headersBefore, schemas.SnapshotJSON(headersAfter))
+						mutation.Body = schemas.DiffJSON(bodyBefore, schemas.SnapshotJSON(bodyAfter))
+					}
+					trace.AddInterceptorMutation(mutation)
+				}
 				if err != nil {
-					logger.Warn(fmt.Sprintf("TransportInterceptor: Plugin '%s' returned error: %v", plugin.GetName(), err))
+					bifrost.WithFields(logger, "plugin", plugin.GetName()).Warn("TransportInterceptor: plugin returned error: %v", err)
 					// Continue with unmodified headers/body
 					continue
 				}
@@ -112,7 +173,7 @@ func TransportInterceptorMiddleware(config *lib.Config) lib.BifrostHTTPMiddlewar
 			ctx.Request.SetBody(updatedBody)
 
 			// Remove headers that were present originally but removed by plugins
-			for _, name := range originalHeaderNames {
+			for _, name := range parsed.names {
 				if _, exists := headers[name]; !exists {
 					ctx.Request.Header.Del(name)
 				}
@@ -122,12 +183,175 @@ func TransportInterceptorMiddleware(config *lib.Config) lib.BifrostHTTPMiddlewar
 			for key, value := range headers {
 				ctx.Request.Header.Set(key, value)
 			}
+			parsed.release()
+
+			next(ctx)
+		}
+	}
+}
+
+// interceptorHeaders holds the request headers passed to TransportInterceptor. It is pooled because the
+// middleware runs for every request; plugins must not keep the map after returning.
+type interceptorHeaders struct {
+	values   map[string]string
+	names    []string // Original header names, to detect headers removed by plugins
+	released bool
+}
+
+var interceptorHeadersPool = sync.Pool{
+	New: func() any {
+		return &interceptorHeaders{values: make(map[string]string, 16), names: make([]string, 0, 16)}
+	},
+}
+
+// acquireInterceptorHeaders returns empty headers from the pool.
+func acquireInterceptorHeaders() *interceptorHeaders {
+	h := interceptorHeadersPool.Get().(*interceptorHeaders)
+	h.released = false
+	return h
+}
+
+// release clears the headers and returns them to the pool. Calling it again is a no-op.
+func (h *interceptorHeaders) release() {
+	if h.released {
+		return
+	}
+	h.released = true
+	clear(h.values)
+	h.names = h.names[:0]
+	interceptorHeadersPool.Put(h)
+}
+
+// ClientCertificateMiddleware identifies requests by the verified client certificate of their TLS connection.
+// The identity is set in the x-bf-client-identity header, and the virtual key, team, customer and user mapped
+// to it replace the governance headers sent by the client. Identity headers sent by clients are discarded.
+// In the inference client auth mode, inference routes without a verified certificate are rejected.
+func ClientCertificateMiddleware(config *lib.Config, logger schemas.Logger) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.Request.Header.Del(lib.ClientIdentityHeader)
+			if config.TLS == nil {
+				next(ctx)
+				return
+			}
+			state := ctx.TLSConnectionState()
+			if state == nil || len(state.VerifiedChains) == 0 {
+				if config.TLS.ClientAuth == lib.ClientAuthInference && isInferencePath(string(ctx.Path())) {
+					SendError(ctx, fasthttp.StatusUnauthorized, "client certificate required", logger)
+					return
+				}
+				next(ctx)
+				return
+			}
 
+			identity := config.TLS.Identify(state.VerifiedChains[0][0])
+			if identity.Subject != "" {
+				ctx.Request.Header.Set(lib.ClientIdentityHeader, identity.Subject)
+			}
+			setGovernanceHeaders(ctx, identity.VirtualKey, identity.Team, identity.Customer, identity.User)
+			next(ctx)
+		}
+	}
+}
+
+// RequestSigningMiddleware verifies the HMAC signatures of inference requests (see lib.RequestSigningConfig).
+// The virtual key, team, customer and user of the signing key replace the governance headers sent by the
+// client. Unsigned requests pass unless signatures are required.
+func RequestSigningMiddleware(config *lib.Config, logger schemas.Logger) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			verifier := config.RequestVerifier
+			if verifier == nil || !isInferencePath(string(ctx.Path())) {
+				next(ctx)
+				return
+			}
+			header := string(ctx.Request.Header.Peek(lib.RequestSignatureHeader))
+			if header == "" && !verifier.Required() {
+				next(ctx)
+				return
+			}
+			path := lib.SignedPath(string(ctx.Path()), string(ctx.URI().QueryString()))
+			key, err := verifier.Verify(header, string(ctx.Method()), path, ctx.Request.Body(), time.Now())
+			if err != nil {
+				SendError(ctx, fasthttp.StatusUnauthorized, err.Error(), logger)
+				return
+			}
+			setGovernanceHeaders(ctx, key.VirtualKey, key.Team, key.Customer, key.User)
 			next(ctx)
 		}
 	}
 }
 
+// JWTAuthMiddleware validates the bearer JWTs of inference requests (see lib.JWTAuthConfig). The subject,
+// org, virtual key and team resolved from the claims replace the governance headers sent by the client, and
+// the token is removed so it is never used as a provider key. Bearer values that are not JWTs pass unless
+// JWTs are required.
+func JWTAuthMiddleware(config *lib.Config, logger schemas.Logger) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			validator := config.JWTValidator
+			if validator == nil || !isInferencePath(string(ctx.Path())) {
+				next(ctx)
+				return
+			}
+			token, _ := bearerToken(ctx)
+			if !lib.IsJWT(token) {
+				if validator.Required() {
+					SendError(ctx, fasthttp.StatusUnauthorized, lib.ErrJWTMissing.Error(), logger)
+					return
+				}
+				next(ctx)
+				return
+			}
+			identity, err := validator.Validate(token, time.Now())
+			if err != nil {
+				SendError(ctx, fasthttp.StatusUnauthorized, err.Error(), logger)
+				return
+			}
+			ctx.Request.Header.Del(fasthttp.HeaderAuthorization)
+			setGovernanceHeaders(ctx, identity.VirtualKey, identity.Team, identity.Org, identity.Subject)
+			next(ctx)
+		}
+	}
+}
+
+// setGovernanceHeaders replaces the governance headers of a request with those of an authenticated identity.
+// The team, customer and user headers sent by the client are always removed, so an identity that maps only
+// some of them cannot be extended by the client. The virtual key is replaced only when the identity maps one,
+// since otherwise it is the credential of the client.
+func setGovernanceHeaders(ctx *fasthttp.RequestCtx, virtualKey, team, customer, user string) {
+	for _, header := range [...]struct{ name, value string }{
+		{"x-bf-vk", virtualKey},
+		{"x-bf-team", team},
+		{"x-bf-customer", customer},
+		{"x-bf-user", user},
+	} {
+		if header.value != "" {
+			ctx.Request.Header.Set(header.name, header.value)
+		} else if header.name != "x-bf-vk" {
+			ctx.Request.Header.Del(header.name)
+		}
+	}
+}
+
+// ExposureMiddleware answers 404 to requests outside the route groups a listener exposes (see lib.RouteGroup).
+func ExposureMiddleware(expose []string, logger schemas.Logger) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			if !slices.Contains(expose, lib.RouteGroup(string(ctx.Path()))) {
+				SendError(ctx, fasthttp.StatusNotFound, "Route not found: "+string(ctx.Path()), logger)
+				return
+			}
+			next(ctx)
+		}
+	}
+}
+
+// isInferencePath reports whether path is an inference route, native or of an SDK integration.
+func isInferencePath(path string) bool {
+	return lib.RouteGroup(path) == lib.ExposeInference
+}
+
 // ChainMiddlewares chains multiple middlewares together
 // Middlewares are applied in order: the first middleware wraps the second, etc.
 // This allows earlier middlewares to short-circuit by not calling next(ctx)
@@ -148,24 +372,37 @@ func ChainMiddlewares(handler fasthttp.RequestHandler, middlewares ...lib.Bifros
 // AdminAuthMiddleware protects management APIs and the UI when Bifrost is public.
 // Auth is satisfied if any of the following is true:
 // - Authorization: Bearer <secret> matches configured AdminSecret
-// - Cookie <AdminCookieName> equals the AdminSecret
+// - Cookie <AdminCookieName> is the ID of a dashboard session holding the scopes of the route (see POST /api/auth/login)
+// - Authorization: Bearer bf-ui-... is the internal token of such a session, on requests dispatched by the UI proxy
+// - Authorization: Bearer bf-sa-... is the token of an active service account holding the scopes of the route
+// - Authorization: Bearer bf-dev-... is the token of a CLI device login holding the scopes of the route
 //
-// Public endpoints (always allowed):
+// Public endpoints (configurable through public route rules, see lib.DefaultPublicRoutes):
 // - GET /metrics
 // - POST /v1/* (OpenAI-compatible inference APIs)
+// - GET /v1/async/* (async jobs, shown to the callers that queued them)
+// - GET /v1/fine_tuning/jobs and /v1/fine_tuning/jobs/* (fine-tuning jobs, shown to the virtual keys that created them)
+// - GET and DELETE /v1/assistants/* and /v1/threads/* (Assistants API objects, shown to the callers that created them)
 // - POST /openai/* and /openai/v1/* (OpenAI-compatible inference APIs)
 // - GET /openai/models and /openai/v1/models
-// - Static UI assets under /ui/_next/ and /ui/assets/ if login page needs them (we keep UI behind auth except /login)
-// - GET/POST /admin/login (login form)
 // - GET /api/version (safe)
+// - GET /health (load balancer probes)
 //
-// On unauthorized browser requests for HTML, this middleware redirects to /admin/login?next=<path>.
+// Always public:
+// - GET /login and the static assets it loads (the rest of the UI stays behind auth)
+// - GET /admin/login (redirects to /login)
+// - POST /api/auth/login, POST /api/auth/logout and GET /api/auth/me (session endpoints of the login page)
+// - GET /api/ui/locales (locales of the login page)
+// - POST /api/auth/device and POST /api/auth/device/token (device login of the CLI, confirmed by a signed-in admin)
+//
+// On unauthorized browser requests for HTML, this middleware redirects to /login?next=<path>.
 // On API requests (Accept: application/json or X-Requested-With), it returns 401 JSON.
 func AdminAuthMiddleware(config *lib.Config, logger schemas.Logger) lib.BifrostHTTPMiddleware {
 	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		return func(ctx *fasthttp.RequestCtx) {
 			// If no admin secret configured, allow all
-			if strings.TrimSpace(config.AdminSecret) == "" {
+			adminSecret := config.GetAdminSecret()
+			if strings.TrimSpace(adminSecret) == "" {
 				next(ctx)
 				return
 			}
@@ -174,25 +411,50 @@ func AdminAuthMiddleware(config *lib.Config, logger schemas.Logger) lib.BifrostH
 			path := string(ctx.Path())
 
 			// Allowlist public paths
-			if isPublicPath(method, path) {
+			if isPublicPath(config, method, path) {
 				next(ctx)
 				return
 			}
 
 			// Check Authorization header: Bearer <secret>
-			if auth := string(ctx.Request.Header.Peek("Authorization")); auth != "" {
-				if strings.HasPrefix(strings.ToLower(strings.TrimSpace(auth)), "bearer ") {
-					token := strings.TrimSpace(auth[len("Bearer "):])
-					if token == config.AdminSecret {
-						next(ctx)
+			if token, ok := bearerToken(ctx); ok {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(adminSecret)) == 1 {
+					next(ctx)
+					return
+				}
+				// Internal session tokens are only accepted on requests dispatched by the UI proxy
+				if strings.HasPrefix(token, lib.UISessionTokenPrefix) {
+					session := requestUISession(ctx, config)
+					if session == nil {
+						SendError(ctx, fasthttp.StatusUnauthorized, "invalid or expired session token", logger)
 						return
 					}
+					if authorizeUISession(ctx, session, logger) {
+						next(ctx)
+					}
+					return
+				}
+				// Device tokens of CLI logins are limited to the scopes confirmed for them
+				if strings.HasPrefix(token, lib.DeviceTokenPrefix) {
+					if authorizeDeviceToken(ctx, config, token, logger) {
+						next(ctx)
+					}
+					return
+				}
+				// Service account tokens are limited to the scopes of their account
+				if strings.HasPrefix(token, serviceaccounts.TokenPrefix) && config.ServiceAccounts != nil {
+					if authorizeServiceAccount(ctx, config.ServiceAccounts, token, logger) {
+						next(ctx)
+					}
+					return
 				}
 			}
 
-			// Check cookie
-			if c := string(ctx.Request.Header.Cookie(config.AdminCookieName)); c != "" && c == config.AdminSecret {
-				next(ctx)
+			// Dashboard sessions are limited to their scopes
+			if session := requestUISession(ctx, config); session != nil {
+				if authorizeUISession(ctx, session, logger) {
+					next(ctx)
+				}
 				return
 			}
 
@@ -208,30 +470,48 @@ func AdminAuthMiddleware(config *lib.Config, logger schemas.Logger) lib.BifrostH
 
 			// Redirect to login with next parameter
 			nextParam := url.QueryEscape(path)
-			ctx.Response.Header.Set("Location", "/admin/login?next="+nextParam)
+			ctx.Response.Header.Set("Location", "/login?next="+nextParam)
 			ctx.SetStatusCode(fasthttp.StatusFound)
 		}
 	}
 }
 
-func isPublicPath(method, path string) bool {
-	if path == "/metrics" && method == fasthttp.MethodGet {
+// isPublicPath reports whether a request skips admin authentication. The login page and its session endpoints
+// are always public so admins cannot lock themselves out; other routes follow the configured public route rules,
+// or the defaults when config is nil.
+func isPublicPath(config *lib.Config, method, path string) bool {
+	if path == "/admin/login" && method == fasthttp.MethodGet {
+		return true
+	}
+	if (path == "/api/auth/login" || path == "/api/auth/logout") && method == fasthttp.MethodPost {
 		return true
 	}
-	if strings.HasPrefix(path, "/v1/") && method == fasthttp.MethodPost {
+	if (path == "/api/auth/me" || path == "/api/ui/locales") && method == fasthttp.MethodGet {
 		return true
 	}
-	// OpenAI-compatible routes under /openai and /openai/v1 should be public for inference
-	if (strings.HasPrefix(path, "/openai/") || strings.HasPrefix(path, "/openai/v1/")) && method == fasthttp.MethodPost {
+	// CLIs start device logins and poll for their tokens before holding any credential
+	if (path == deviceAuthorizationPath || path == deviceTokenPath) && method == fasthttp.MethodPost {
 		return true
 	}
-	if (path == "/openai/models" || path == "/openai/v1/models") && method == fasthttp.MethodGet {
+	// Leak reports of external scanners are authenticated by their signature
+	if path == keyLeakReportPath && method == fasthttp.MethodPost && config != nil && config.KeyLeaks != nil {
 		return true
 	}
-	if strings.HasPrefix(path, "/admin/login") { // GET or POST
+	// The login page of the dashboard and the static assets it loads
+	if method == fasthttp.MethodGet && (isLoginPagePath(path) || strings.HasPrefix(path, "/_next/static/")) {
 		return true
 	}
-	if path == "/api/version" && method == fasthttp.MethodGet {
+	var rules []lib.PublicRoute
+	if config != nil {
+		rules = config.GetPublicRoutes()
+	}
+	return lib.IsPublicRoute(rules, method, path)
+}
+
+// isLoginPagePath reports whether path is the exported login page, its RSC payload or a logo it shows.
+func isLoginPagePath(path string) bool {
+	switch path {
+	case "/login", "/login/", "/login.txt", "/login/index.txt", "/bifrost-logo.png", "/bifrost-logo-dark.png":
 		return true
 	}
 	return false
@@ -1,11 +1,41 @@
 package handlers
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
 	"github.com/maximhq/bifrost/framework/configstore"
 	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
 )
 
 // TestCorsMiddleware_LocalhostOrigins tests that localhost origins are always allowed
@@ -511,3 +541,471 @@ func TestChainMiddlewares_ShortCircuitMiddlePosition(t *testing.T) {
 		t.Errorf("Expected body 'Unauthorized', got '%s'", string(ctx.Response.Body()))
 	}
 }
+
+// TestClientCertificateMiddleware tests that verified client certificates are mapped to governance headers
+// over a real TLS listener, and that inference routes require a certificate in the inference mode
+func TestClientCertificateMiddleware(t *testing.T) {
+	dir := t.TempDir()
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	issue := func(serial int64, commonName string, usage x509.ExtKeyUsage) tls.Certificate {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: commonName},
+			DNSNames:     []string{commonName},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("failed to issue %s: %v", commonName, err)
+		}
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+
+	server := issue(2, "localhost", x509.ExtKeyUsageServerAuth)
+	serverKey, _ := x509.MarshalECPrivateKey(server.PrivateKey.(*ecdsa.PrivateKey))
+	certFile, keyFile := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate[0]}), 0600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: serverKey}), 0600)
+
+	tlsSettings := &lib.ListenerTLSConfig{
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ClientCA:   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		ClientAuth: lib.ClientAuthInference,
+		Identities: []lib.ClientIdentity{{Subject: "billing-service", VirtualKey: "sk-bf-billing", Team: "team-billing"}},
+	}
+	if err := tlsSettings.Validate(); err != nil {
+		t.Fatalf("expected valid TLS settings, got %v", err)
+	}
+	serverTLS, err := tlsSettings.ServerTLSConfig()
+	if err != nil {
+		t.Fatalf("failed to build TLS config: %v", err)
+	}
+
+	handler := ClientCertificateMiddleware(&lib.Config{TLS: tlsSettings}, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+		fmt.Fprintf(ctx, "%s|%s|%s", ctx.Request.Header.Peek(lib.ClientIdentityHeader), ctx.Request.Header.Peek("x-bf-vk"), ctx.Request.Header.Peek("x-bf-team"))
+	})
+	listener := fasthttputil.NewInmemoryListener()
+	defer listener.Close()
+	go (&fasthttp.Server{Handler: handler}).Serve(tls.NewListener(listener, serverTLS))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	request := func(path string, certificates ...tls.Certificate) (int, string) {
+		client := &fasthttp.Client{
+			Dial:      func(string) (net.Conn, error) { return listener.Dial() },
+			TLSConfig: &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: certificates},
+		}
+		req, resp := fasthttp.AcquireRequest(), fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseRequest(req)
+		defer fasthttp.ReleaseResponse(resp)
+		req.SetRequestURI("https://localhost" + path)
+		req.Header.Set(lib.ClientIdentityHeader, "spoofed")
+		req.Header.Set("x-bf-vk", "sk-bf-client")
+		req.Header.Set("x-bf-team", "team-spoofed")
+		if err := client.Do(req, resp); err != nil {
+			t.Fatalf("request to %s failed: %v", path, err)
+		}
+		return resp.StatusCode(), string(resp.Body())
+	}
+
+	if status, body := request("/v1/chat/completions", issue(3, "billing-service", x509.ExtKeyUsageClientAuth)); status != fasthttp.StatusOK || body != "billing-service|sk-bf-billing|team-billing" {
+		t.Errorf("expected the mapped identity, got %d %q", status, body)
+	}
+	if status, body := request("/v1/chat/completions", issue(4, "reporting", x509.ExtKeyUsageClientAuth)); status != fasthttp.StatusOK || body != "reporting|sk-bf-client|" {
+		t.Errorf("expected an unmapped identity keeping the client virtual key without the client team, got %d %q", status, body)
+	}
+	if status, _ := request("/v1/chat/completions"); status != fasthttp.StatusUnauthorized {
+		t.Errorf("expected inference without a certificate to be rejected, got %d", status)
+	}
+	if status, body := request("/api/providers"); status != fasthttp.StatusOK || body != "|sk-bf-client|team-spoofed" {
+		t.Errorf("expected management routes without a certificate to pass without an identity, got %d %q", status, body)
+	}
+}
+
+// TestRequestSigningMiddleware tests that signed inference requests are verified, mapped to the virtual key
+// of their signing key and rejected when tampered with, expired or replayed, by signature or by nonce
+func TestRequestSigningMiddleware(t *testing.T) {
+	config := &lib.Config{RequestVerifier: lib.NewRequestVerifier(lib.RequestSigningConfig{
+		Enabled:  true,
+		Required: true,
+		Keys:     []lib.SigningKey{{ID: "billing", Secret: "s3cret", VirtualKey: "sk-bf-billing"}},
+	})}
+	handler := RequestSigningMiddleware(config, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+		fmt.Fprintf(ctx, "%s|%s", ctx.Request.Header.Peek("x-bf-vk"), ctx.Request.Header.Peek("x-bf-team"))
+	})
+
+	body := []byte(`{"model":"openai/gpt-4o-mini"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	signed := "key=billing,t=" + now + ",sig=" + lib.SignRequest("s3cret", now, "POST", "/v1/chat/completions", body)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	withNonce := func(nonce string, body []byte) string {
+		return "key=billing,t=" + now + ",nonce=" + nonce + ",sig=" + lib.SignRequestWithNonce("s3cret", now, nonce, "POST", "/v1/chat/completions", body)
+	}
+	replays := testutil.ToFloat64(lib.ReplayAttempts.WithLabelValues(lib.ReplayMethodSignature))
+
+	tests := []struct {
+		name      string
+		path      string
+		signature string
+		body      []byte
+		status    int
+	}{
+		{"valid signature", "/v1/chat/completions", signed, body, fasthttp.StatusOK},
+		{"replayed signature", "/v1/chat/completions", signed, body, fasthttp.StatusUnauthorized},
+		{"missing signature", "/v1/chat/completions", "", body, fasthttp.StatusUnauthorized},
+		{"tampered body", "/v1/chat/completions", "key=billing,t=" + now + ",sig=" + lib.SignRequest("s3cret", now, "POST", "/v1/chat/completions", []byte("{}")), body, fasthttp.StatusUnauthorized},
+		{"unknown key", "/v1/chat/completions", "key=other,t=" + now + ",sig=00", body, fasthttp.StatusUnauthorized},
+		{"expired timestamp", "/v1/chat/completions", "key=billing,t=" + stale + ",sig=" + lib.SignRequest("s3cret", stale, "POST", "/v1/chat/completions", body), body, fasthttp.StatusUnauthorized},
+		{"identical request with a nonce", "/v1/chat/completions", withNonce("n-1", body), body, fasthttp.StatusOK},
+		{"identical request with another nonce", "/v1/chat/completions", withNonce("n-2", body), body, fasthttp.StatusOK},
+		{"replayed nonce", "/v1/chat/completions", withNonce("n-1", []byte(`{"model":"openai/gpt-4o"}`)), []byte(`{"model":"openai/gpt-4o"}`), fasthttp.StatusUnauthorized},
+		{"nonce stripped", "/v1/chat/completions", "key=billing,t=" + now + ",sig=" + lib.SignRequestWithNonce("s3cret", now, "n-3", "POST", "/v1/chat/completions", body), body, fasthttp.StatusUnauthorized},
+		{"signed query string", "/v1/chat/completions?api-version=2024-10-21", "key=billing,t=" + now + ",nonce=q-1,sig=" + lib.SignRequestWithNonce("s3cret", now, "q-1", "POST", "/v1/chat/completions?api-version=2024-10-21", body), body, fasthttp.StatusOK},
+		{"unsigned query string", "/v1/chat/completions?api-version=2024-10-21", withNonce("q-2", body), body, fasthttp.StatusUnauthorized},
+		{"management route", "/api/providers", "", nil, fasthttp.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod("POST")
+			ctx.Request.SetRequestURI(tt.path)
+			ctx.Request.SetBody(tt.body)
+			ctx.Request.Header.Set("x-bf-team", "team-spoofed")
+			if tt.signature != "" {
+				ctx.Request.Header.Set(lib.RequestSignatureHeader, tt.signature)
+			}
+			handler(ctx)
+			if ctx.Response.StatusCode() != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, ctx.Response.StatusCode(), ctx.Response.Body())
+			}
+			if tt.name == "valid signature" && string(ctx.Response.Body()) != "sk-bf-billing|" {
+				t.Errorf("expected the virtual key of the signing key without the client team, got %q", ctx.Response.Body())
+			}
+		})
+	}
+	if got := testutil.ToFloat64(lib.ReplayAttempts.WithLabelValues(lib.ReplayMethodSignature)) - replays; got != 2 {
+		t.Errorf("expected 2 replay attempts counted, got %v", got)
+	}
+
+	config.RequestVerifier = lib.NewRequestVerifier(lib.RequestSigningConfig{
+		Enabled:      true,
+		RequireNonce: true,
+		Keys:         []lib.SigningKey{{ID: "billing", Secret: "s3cret"}},
+	})
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("POST")
+	ctx.Request.SetRequestURI("/v1/chat/completions")
+	ctx.Request.SetBody(body)
+	ctx.Request.Header.Set(lib.RequestSignatureHeader, signed)
+	handler(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusUnauthorized || !strings.Contains(string(ctx.Response.Body()), lib.ErrSignatureNoNonce.Error()) {
+		t.Errorf("expected a signature without a nonce to be rejected when nonces are required, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+}
+
+// testSharedNonces is the nonce store of a cluster state shared by replicas
+type testSharedNonces struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+	err  error
+}
+
+func (s *testSharedNonces) Remember(ctx context.Context, nonce string, expiry time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return false, s.err
+	}
+	if until, ok := s.seen[nonce]; ok && time.Now().Before(until) {
+		return false, nil
+	}
+	s.seen[nonce] = expiry
+	return true, nil
+}
+
+// TestRequestSigningSharedNonces tests that a signed request accepted by one replica is rejected by another one
+// sharing the cluster state, and that replicas fall back to their own nonces when the cluster state fails
+func TestRequestSigningSharedNonces(t *testing.T) {
+	shared := &testSharedNonces{seen: make(map[string]time.Time)}
+	replica := func() fasthttp.RequestHandler {
+		verifier := lib.NewRequestVerifier(lib.RequestSigningConfig{Enabled: true, Keys: []lib.SigningKey{{ID: "billing", Secret: "s3cret"}}})
+		verifier.SetSharedNonces(shared)
+		return RequestSigningMiddleware(&lib.Config{RequestVerifier: verifier}, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {})
+	}
+	first, second := replica(), replica()
+
+	body := []byte(`{"model":"openai/gpt-4o-mini"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	send := func(handler fasthttp.RequestHandler, nonce string) int {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod("POST")
+		ctx.Request.SetRequestURI("/v1/chat/completions")
+		ctx.Request.SetBody(body)
+		ctx.Request.Header.Set(lib.RequestSignatureHeader, "key=billing,t="+now+",nonce="+nonce+",sig="+lib.SignRequestWithNonce("s3cret", now, nonce, "POST", "/v1/chat/completions", body))
+		handler(ctx)
+		return ctx.Response.StatusCode()
+	}
+
+	if status := send(first, "n-1"); status != fasthttp.StatusOK {
+		t.Fatalf("expected the first replica to accept the request, got %d", status)
+	}
+	if status := send(second, "n-1"); status != fasthttp.StatusUnauthorized {
+		t.Errorf("expected the second replica to reject the replay, got %d", status)
+	}
+	for nonce := range shared.seen {
+		if strings.Contains(nonce, "n-1") {
+			t.Errorf("expected nonces to be hashed in the cluster state, got %q", nonce)
+		}
+	}
+
+	shared.err = errors.New("connection refused")
+	if status := send(first, "n-2"); status != fasthttp.StatusOK {
+		t.Fatalf("expected requests to be accepted when the cluster state fails, got %d", status)
+	}
+	if status := send(first, "n-2"); status != fasthttp.StatusUnauthorized {
+		t.Errorf("expected the replica to reject its own replay when the cluster state fails, got %d", status)
+	}
+}
+
+// TestJWTAuthMiddleware tests that JWTs are validated against the JWKS of their issuer and their claims
+// resolve the governance identity of the request
+func TestJWTAuthMiddleware(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	encode := func(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"rsa-1","use":"sig","n":%q,"e":"AQAB"},{"kty":"EC","kid":"ec-1","crv":"P-256","x":%q,"y":%q}]}`,
+		encode(rsaKey.N.Bytes()), encode(ecKey.X.FillBytes(make([]byte, 32))), encode(ecKey.Y.FillBytes(make([]byte, 32))))
+	fetches := 0
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write([]byte(jwks))
+	}))
+	defer jwksServer.Close()
+
+	sign := func(alg, kid string, claims map[string]any) string {
+		header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		signed := encode(header) + "." + encode(payload)
+		digest := sha256.Sum256([]byte(signed))
+		var signature []byte
+		if alg == "RS256" {
+			signature, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+		} else {
+			r, s, _ := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+		return signed + "." + encode(signature)
+	}
+	claims := func(overrides map[string]any) map[string]any {
+		claims := map[string]any{"iss": "https://idp.example.com", "aud": []string{"bifrost"}, "sub": "alice", "org": "acme",
+			"scope": "llm:invoke llm:admin", "exp": time.Now().Add(time.Hour).Unix()}
+		maps.Copy(claims, overrides)
+		return claims
+	}
+
+	config := &lib.Config{JWTValidator: lib.NewJWTValidator(lib.JWTAuthConfig{
+		Enabled:  true,
+		Required: true,
+		Issuers: []lib.JWTIssuer{{
+			Issuer:         "https://idp.example.com",
+			JWKSURL:        jwksServer.URL,
+			Audience:       "bifrost",
+			RequiredScopes: []string{"llm:invoke"},
+			Mappings: []lib.JWTClaimMapping{
+				{Org: "acme", Scope: "llm:admin", VirtualKey: "sk-bf-acme-admin", Team: "platform"},
+				{Org: "acme", VirtualKey: "sk-bf-acme"},
+			},
+		}, {
+			Issuer:    "https://once.example.com",
+			JWKSURL:   jwksServer.URL,
+			SingleUse: true,
+		}},
+	})}
+	singleUse := sign("RS256", "rsa-1", claims(map[string]any{"iss": "https://once.example.com", "jti": "token-1"}))
+	replays := testutil.ToFloat64(lib.ReplayAttempts.WithLabelValues(lib.ReplayMethodJWT))
+	handler := JWTAuthMiddleware(config, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+		fmt.Fprintf(ctx, "%s|%s|%s|%s|%s", ctx.Request.Header.Peek("x-bf-user"), ctx.Request.Header.Peek("x-bf-customer"),
+			ctx.Request.Header.Peek("x-bf-vk"), ctx.Request.Header.Peek("x-bf-team"), ctx.Request.Header.Peek("Authorization"))
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{"rsa token", "/v1/chat/completions", sign("RS256", "rsa-1", claims(nil)), fasthttp.StatusOK, "alice|acme|sk-bf-acme-admin|platform|"},
+		{"ec token", "/openai/v1/chat/completions", sign("ES256", "ec-1", claims(map[string]any{"scope": "llm:invoke"})), fasthttp.StatusOK, "alice|acme|sk-bf-acme||"},
+		{"expired", "/v1/chat/completions", sign("RS256", "rsa-1", claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})), fasthttp.StatusUnauthorized, ""},
+		{"wrong audience", "/v1/chat/completions", sign("RS256", "rsa-1", claims(map[string]any{"aud": "other"})), fasthttp.StatusUnauthorized, ""},
+		{"missing scope", "/v1/chat/completions", sign("RS256", "rsa-1", claims(map[string]any{"scope": "llm:admin"})), fasthttp.StatusUnauthorized, ""},
+		{"untrusted issuer", "/v1/chat/completions", sign("RS256", "rsa-1", claims(map[string]any{"iss": "https://evil.example.com"})), fasthttp.StatusUnauthorized, ""},
+		{"key of another algorithm", "/v1/chat/completions", sign("ES256", "rsa-1", claims(nil)), fasthttp.StatusUnauthorized, ""},
+		{"unknown key", "/v1/chat/completions", sign("RS256", "rsa-2", claims(nil)), fasthttp.StatusUnauthorized, ""},
+		{"provider key", "/v1/chat/completions", "sk-provider-key", fasthttp.StatusUnauthorized, ""},
+		{"single-use token", "/v1/chat/completions", singleUse, fasthttp.StatusOK, "alice|acme|||"},
+		{"replayed single-use token", "/v1/chat/completions", singleUse, fasthttp.StatusUnauthorized, ""},
+		{"single-use token without jti", "/v1/chat/completions", sign("RS256", "rsa-1", claims(map[string]any{"iss": "https://once.example.com"})), fasthttp.StatusUnauthorized, ""},
+		{"reused token of another issuer", "/v1/chat/completions", sign("RS256", "rsa-1", claims(map[string]any{"jti": "token-1"})), fasthttp.StatusOK, "alice|acme|sk-bf-acme-admin|platform|"},
+		{"management route", "/api/providers", "sk-provider-key", fasthttp.StatusOK, "|||team-spoofed|Bearer sk-provider-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI(tt.path)
+			ctx.Request.Header.Set("Authorization", "Bearer "+tt.token)
+			ctx.Request.Header.Set("x-bf-team", "team-spoofed")
+			handler(ctx)
+			if ctx.Response.StatusCode() != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, ctx.Response.StatusCode(), ctx.Response.Body())
+			}
+			if tt.body != "" && string(ctx.Response.Body()) != tt.body {
+				t.Errorf("expected %q, got %q", tt.body, ctx.Response.Body())
+			}
+		})
+	}
+	if fetches != 2 {
+		t.Errorf("expected the JWKS to be fetched once per issuer and cached, got %d fetches", fetches)
+	}
+	if got := testutil.ToFloat64(lib.ReplayAttempts.WithLabelValues(lib.ReplayMethodJWT)) - replays; got != 1 {
+		t.Errorf("expected 1 replay attempt counted, got %v", got)
+	}
+}
+
+// TestSecurityHeadersMiddleware tests that the security headers are sent with their defaults, that they can be
+// replaced or omitted, and that HSTS is only sent over TLS
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	run := func(config *lib.SecurityHeadersConfig, forwardedProto string, handler fasthttp.RequestHandler) *fasthttp.ResponseHeader {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/api/config")
+		if forwardedProto != "" {
+			ctx.Request.Header.Set("X-Forwarded-Proto", forwardedProto)
+		}
+		SecurityHeadersMiddleware(&lib.Config{SecurityHeaders: config})(handler)(ctx)
+		return &ctx.Response.Header
+	}
+	ok := func(ctx *fasthttp.RequestCtx) { ctx.SetStatusCode(fasthttp.StatusOK) }
+
+	header := run(nil, "", func(ctx *fasthttp.RequestCtx) {
+		// Errors replace the response headers set so far
+		ctx.Error("not found", fasthttp.StatusNotFound)
+	})
+	want := map[string]string{
+		"Content-Security-Policy": lib.DefaultContentSecurityPolicy,
+		"X-Frame-Options":         "DENY",
+		"X-Content-Type-Options":  "nosniff",
+		"Referrer-Policy":         "strict-origin-when-cross-origin",
+	}
+	for name, value := range want {
+		if got := string(header.Peek(name)); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if hsts := header.Peek("Strict-Transport-Security"); len(hsts) != 0 {
+		t.Errorf("expected no HSTS over plain HTTP, got %q", hsts)
+	}
+
+	header = run(&lib.SecurityHeadersConfig{FrameOptions: "SAMEORIGIN", ReferrerPolicy: lib.SecurityHeaderOff, HSTSIncludeSubdomains: true}, "https", ok)
+	if got := string(header.Peek("X-Frame-Options")); got != "SAMEORIGIN" {
+		t.Errorf("X-Frame-Options = %q, want SAMEORIGIN", got)
+	}
+	if got := header.Peek("Referrer-Policy"); len(got) != 0 {
+		t.Errorf("expected Referrer-Policy to be omitted, got %q", got)
+	}
+	if got := string(header.Peek("Strict-Transport-Security")); got != "max-age=31536000; includeSubDomains" {
+		t.Errorf("Strict-Transport-Security = %q", got)
+	}
+
+	header = run(&lib.SecurityHeadersConfig{}, "", func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("Content-Security-Policy", "default-src 'none'")
+	})
+	if got := string(header.Peek("Content-Security-Policy")); got != "default-src 'none'" {
+		t.Errorf("expected the handler's policy to win, got %q", got)
+	}
+
+	disabled := false
+	header = run(&lib.SecurityHeadersConfig{Enabled: &disabled}, "https", ok)
+	if len(header.Peek("Content-Security-Policy")) != 0 || len(header.Peek("Strict-Transport-Security")) != 0 {
+		t.Errorf("expected no security headers when disabled")
+	}
+
+	if err := (&lib.SecurityHeadersConfig{HSTSPreload: true}).Validate(); err == nil {
+		t.Errorf("expected hsts_preload without hsts_include_subdomains to be rejected")
+	}
+	if err := (&lib.SecurityHeadersConfig{FrameOptions: "ALLOW-FROM https://example.com"}).Validate(); err == nil {
+		t.Errorf("expected an invalid frame_options to be rejected")
+	}
+}
+
+// TestListenerHandler tests that a listener only serves the route groups it exposes, and leaves the middlewares it
+// skips out of its chain while the handler serving every route keeps them
+func TestListenerHandler(t *testing.T) {
+	denyAll := func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusUnauthorized)
+		}
+	}
+	pipeline := func(ctx *fasthttp.RequestCtx) { ctx.SetStatusCode(fasthttp.StatusOK) }
+	middlewares := []namedMiddleware{{"admin_auth", denyAll}}
+	listener := &lib.ListenerConfig{Name: "admin", Address: "127.0.0.1:9090", Expose: []string{lib.ExposeManagement, lib.ExposeUI}, SkipMiddlewares: []string{"admin_auth"}}
+
+	cases := []struct {
+		listener *lib.ListenerConfig
+		path     string
+		status   int
+	}{
+		{listener, "/api/providers", fasthttp.StatusOK},
+		{listener, "/logs", fasthttp.StatusOK},
+		{listener, "/v1/chat/completions", fasthttp.StatusNotFound},
+		{listener, "/metrics", fasthttp.StatusNotFound},
+		{nil, "/api/providers", fasthttp.StatusUnauthorized},
+		{nil, "/metrics", fasthttp.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(tc.path)
+		buildHandler(TraceMiddleware(&lib.Config{}, nil), pipeline, middlewares, nil, tc.listener)(ctx)
+		if status := ctx.Response.StatusCode(); status != tc.status {
+			t.Errorf("status of %s = %d (listener %v), want %d", tc.path, status, tc.listener != nil, tc.status)
+		}
+	}
+
+	valid := []lib.ListenerConfig{
+		{Name: "public", Address: "0.0.0.0:8080", Expose: []string{lib.ExposeInference, lib.ExposeHealth}},
+		{Name: "admin", Address: "127.0.0.1:9090", Expose: []string{lib.ExposeManagement, lib.ExposeUI}},
+		{Name: "metrics", Address: ":9091", Expose: []string{lib.ExposeMetrics}},
+	}
+	if err := lib.ValidateListeners(valid, false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for name, listeners := range map[string][]lib.ListenerConfig{
+		"duplicate address": {valid[0], {Name: "other", Address: "0.0.0.0:8080", Expose: []string{lib.ExposeUI}}},
+		"missing port":      {{Name: "public", Address: "0.0.0.0", Expose: []string{lib.ExposeUI}}},
+		"unknown group":     {{Name: "public", Address: ":8080", Expose: []string{"admin"}}},
+		"unknown skip":      {{Name: "public", Address: ":8080", Expose: []string{lib.ExposeUI}, SkipMiddlewares: []string{"auth"}}},
+		"tls without tls":   {{Name: "public", Address: ":8443", Expose: []string{lib.ExposeUI}, TLS: true}},
+	} {
+		if err := lib.ValidateListeners(listeners, false); err == nil {
+			t.Errorf("expected %s to be rejected", name)
+		}
+	}
+}
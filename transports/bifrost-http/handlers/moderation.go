@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/moderation"
+	"github.com/maximhq/bifrost/framework/redaction"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const moderationPluginName = "bifrost-moderation"
+
+// Response headers reporting moderation outcomes.
+const (
+	ModerationFlaggedHeader  = "x-bf-moderation-flagged"   // Flagged categories of content that was let through
+	ModerationReviewIDHeader = "x-bf-moderation-review-id" // Event of a request held for review, to resubmit once approved
+)
+
+// ModerationHandler serves the recorded moderation outcomes and their review.
+type ModerationHandler struct {
+	store   moderation.Store
+	reviews *moderation.Reviews
+	logger  schemas.Logger
+}
+
+// ReviewRequest represents the request body of a review decision.
+type ReviewRequest struct {
+	Status   moderation.ReviewStatus `json:"status,omitempty"` // approved or denied, empty to only annotate
+	Reviewer string                  `json:"reviewer,omitempty"`
+	Note     string                  `json:"note,omitempty"`
+}
+
+// NewModerationHandler creates a new moderation handler.
+func NewModerationHandler(store moderation.Store, reviews *moderation.Reviews, logger schemas.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		store:   store,
+		reviews: reviews,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the moderation routes.
+func (h *ModerationHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/moderation/events", lib.ChainMiddlewares(h.listEvents, middlewares...))
+	r.GET("/api/moderation/events/{event_id}", lib.ChainMiddlewares(h.getEvent, middlewares...))
+	r.POST("/api/moderation/events/{event_id}/review", lib.ChainMiddlewares(h.reviewEvent, middlewares...))
+}
+
+// listEvents handles GET /api/moderation/events - List moderation events, newest first
+// Query parameters: stage, action, virtual_key, review_status, limit (default 50) and offset.
+// The review queue is the list of pending events.
+func (h *ModerationHandler) listEvents(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	filter := moderation.Filter{
+		Stage:        moderation.Stage(args.Peek("stage")),
+		Action:       moderation.Action(args.Peek("action")),
+		VirtualKey:   string(args.Peek("virtual_key")),
+		ReviewStatus: moderation.ReviewStatus(args.Peek("review_status")),
+	}
+	limit, offset := 50, 0
+	if value := string(args.Peek("limit")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i <= 0 || i > maxListLimit {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit), h.logger)
+			return
+		}
+		limit = i
+	}
+	if value := string(args.Peek("offset")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			SendError(ctx, fasthttp.StatusBadRequest, "offset cannot be negative", h.logger)
+			return
+		}
+		offset = i
+	}
+
+	events, total, err := h.store.List(ctx, filter, limit, offset)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to list moderation events: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"events": events,
+		"count":  len(events),
+		"total":  total,
+	}, h.logger)
+}
+
+// getEvent handles GET /api/moderation/events/{event_id} - Get a moderation event
+func (h *ModerationHandler) getEvent(ctx *fasthttp.RequestCtx) {
+	id := ctx.UserValue("event_id").(string)
+	event, err := h.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, moderation.ErrNotFound) {
+			SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Moderation event %s not found", id), h.logger)
+			return
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get moderation event: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, event, h.logger)
+}
+
+// reviewEvent handles POST /api/moderation/events/{event_id}/review - Approve, deny or annotate a moderation event
+// Approving or denying an event releases the requests held for it.
+func (h *ModerationHandler) reviewEvent(ctx *fasthttp.RequestCtx) {
+	id := ctx.UserValue("event_id").(string)
+	var req ReviewRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Status != "" && req.Status != moderation.ReviewApproved && req.Status != moderation.ReviewDenied {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("status must be %s or %s", moderation.ReviewApproved, moderation.ReviewDenied), h.logger)
+		return
+	}
+	event, err := h.reviews.Review(ctx, id, req.Status, req.Reviewer, req.Note)
+	if err != nil {
+		if errors.Is(err, moderation.ErrNotFound) {
+			SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Moderation event %s not found", id), h.logger)
+			return
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to review moderation event: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, event, h.logger)
+}
+
+// moderationOutputContextKey holds the *strings.Builder accumulating a streamed completion for output moderation.
+const moderationOutputContextKey schemas.BifrostContextKey = "bifrost-moderation-output"
+
+// moderationPlugin moderates prompts in PreHook and completions in PostHook. It runs after governance, so
+// rejected requests are not sent to the classifier, and after logging, so request logs record blocked requests.
+// Only the messages following the last assistant message are moderated, since earlier turns were moderated
+// when they were sent. Streamed completions are moderated once complete; blocking one replaces its final chunk
+// with an error, as the earlier chunks were already delivered.
+// Held content waits for a review up to the hold timeout. A held request still pending afterwards is rejected
+// with its event ID, and once approved it can be resubmitted with the x-bf-moderation-review-id header.
+type moderationPlugin struct {
+	config *lib.Config
+	logger schemas.Logger
+	// governanceStore resolves the virtual key of a request (nil when governance is off)
+	governanceStore *governance.GovernanceStore
+}
+
+// GetName returns the name of the plugin
+func (p *moderationPlugin) GetName() string {
+	return moderationPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *moderationPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook moderates the prompt and blocks it when the matching policy says so
+func (p *moderationPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	moderator := p.config.Moderator
+	if moderator == nil {
+		return req, nil, nil
+	}
+	if moderator.ChecksOutput() && (req.ChatRequest != nil || req.TextCompletionRequest != nil) {
+		*ctx = context.WithValue(*ctx, moderationOutputContextKey, &strings.Builder{})
+	}
+	if !moderator.ChecksInput() {
+		return req, nil, nil
+	}
+
+	bifrostErr := p.moderate(*ctx, moderation.StageInput, req.Provider, req.Model, requestModerationText(req))
+	if bifrostErr != nil {
+		return req, &schemas.PluginShortCircuit{Error: bifrostErr}, nil
+	}
+	return req, nil, nil
+}
+
+// PostHook moderates the completion and replaces it with an error when the matching policy blocks it
+func (p *moderationPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	moderator := p.config.Moderator
+	if moderator == nil || !moderator.ChecksOutput() || bifrostErr != nil || result == nil || len(result.Choices) == 0 {
+		return result, bifrostErr, nil
+	}
+
+	choice := result.Choices[0]
+	var text string
+	switch {
+	case choice.BifrostStreamResponseChoice != nil:
+		output, ok := (*ctx).Value(moderationOutputContextKey).(*strings.Builder)
+		if !ok {
+			return result, bifrostErr, nil
+		}
+		if choice.Delta != nil && choice.Delta.Content != nil {
+			output.WriteString(*choice.Delta.Content)
+		}
+		if isFinalChunk, _ := (*ctx).Value(schemas.BifrostContextKeyStreamEndIndicator).(bool); !isFinalChunk {
+			return result, bifrostErr, nil
+		}
+		text = output.String()
+	case choice.BifrostNonStreamResponseChoice != nil && choice.Message != nil:
+		text = lib.ChatMessageText(*choice.Message)
+	case choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil:
+		text = *choice.Text
+	}
+
+	if blocked := p.moderate(*ctx, moderation.StageOutput, result.ExtraFields.Provider, result.Model, text); blocked != nil {
+		return nil, blocked, nil
+	}
+	return result, bifrostErr, nil
+}
+
+// moderate classifies text, records flagged content and returns the error to reply with when it is blocked
+// or when the classifier fails and moderation fails closed.
+func (p *moderationPlugin) moderate(ctx context.Context, stage moderation.Stage, provider schemas.ModelProvider, model string, text string) *schemas.BifrostError {
+	if reviewID, ok := ctx.Value(lib.ModerationReviewIDContextKey).(string); ok && stage == moderation.StageInput && p.config.ModerationReviews.Released(ctx, reviewID, text) {
+		return nil
+	}
+	virtualKeys, _ := resolveCaller(ctx, p.governanceStore)
+	decision, err := p.config.Moderator.Moderate(ctx, virtualKeys, text)
+	if err != nil {
+		return moderationError(fasthttp.StatusServiceUnavailable, "moderation_unavailable", fmt.Sprintf("content moderation is unavailable: %v", err))
+	}
+	if decision == nil {
+		return nil
+	}
+
+	requestID, _ := ctx.Value(schemas.BifrostContextKeyRequestID).(string)
+	event := &moderation.Event{
+		ID:        uuid.NewString(),
+		RequestID: requestID,
+		Stage:     stage,
+		Action:    decision.Action,
+		Policy:    decision.Policy,
+		Provider:  string(provider),
+		Model:     model,
+		Scores:    decision.Scores,
+		Flagged:   decision.Flagged,
+	}
+	if decision.Action == moderation.ActionFlag || decision.Action == moderation.ActionHold {
+		event.ReviewStatus = moderation.ReviewPending
+		event.ContentHash = moderation.ContentHash(text)
+	}
+	if len(virtualKeys) > 1 {
+		event.VirtualKey = virtualKeys[1] // Name
+	}
+	// Under zero data retention only the decision is recorded; the content hash still lets reviewers release it
+	if retentionClass, _ := ctx.Value(schemas.BifrostContextKeyRetentionClass).(string); retentionClass != schemas.RetentionClassZeroDataRetention {
+		event.Content = p.config.Redactor.RedactString(redaction.TenantFromContext(ctx), text)
+	}
+	if err := p.config.ModerationEvents.Save(context.Background(), event); err != nil {
+		p.logger.Warn("failed to record moderation event: %v", err)
+	}
+
+	flagged := strings.Join(decision.Flagged, ",")
+	responseHeaders, _ := ctx.Value(lib.ResponseHeadersContextKey).(*lib.ResponseHeaders)
+	switch decision.Action {
+	case moderation.ActionBlock:
+		return moderationError(fasthttp.StatusBadRequest, "content_policy_violation", fmt.Sprintf("%s blocked by moderation policy %s: %s", stage, decision.Policy, flagged))
+	case moderation.ActionHold:
+		switch p.config.ModerationReviews.Wait(ctx, event.ID, p.config.Moderator.HoldTimeout()) {
+		case moderation.ReviewApproved:
+			return nil
+		case moderation.ReviewDenied:
+			return moderationError(fasthttp.StatusBadRequest, "content_policy_violation", fmt.Sprintf("%s denied by reviewer (moderation event %s)", stage, event.ID))
+		}
+		if responseHeaders != nil {
+			responseHeaders.Set(ModerationReviewIDHeader, event.ID)
+		}
+		return moderationError(fasthttp.StatusForbidden, "pending_review", fmt.Sprintf("%s held for review as moderation event %s: %s", stage, event.ID, flagged))
+	}
+	if responseHeaders != nil {
+		responseHeaders.Set(ModerationFlaggedHeader, flagged)
+	}
+	return nil
+}
+
+// moderationError builds the error of a blocked or held request. Fallbacks are not attempted, since other
+// providers would receive the same content.
+func moderationError(statusCode int, errorType string, message string) *schemas.BifrostError {
+	allowFallbacks := false
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		AllowFallbacks: &allowFallbacks,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Message: message,
+		},
+	}
+}
+
+// requestModerationText returns the new content of a request: the chat messages following the last
+// assistant message, or the text completion prompt.
+func requestModerationText(req *schemas.BifrostRequest) string {
+	var parts []string
+	switch {
+	case req.ChatRequest != nil:
+		input := req.ChatRequest.Input
+		start := 0
+		for i := len(input) - 1; i >= 0; i-- {
+			if input[i].Role == schemas.ChatMessageRoleAssistant {
+				start = i + 1
+				break
+			}
+		}
+		for _, message := range input[start:] {
+			if message.Role == schemas.ChatMessageRoleUser || message.Role == schemas.ChatMessageRoleSystem || message.Role == schemas.ChatMessageRoleDeveloper {
+				if text := lib.ChatMessageText(message); text != "" {
+					parts = append(parts, text)
+				}
+			}
+		}
+	case req.TextCompletionRequest != nil && req.TextCompletionRequest.Input != nil:
+		if req.TextCompletionRequest.Input.PromptStr != nil {
+			parts = append(parts, *req.TextCompletionRequest.Input.PromptStr)
+		}
+		parts = append(parts, req.TextCompletionRequest.Input.PromptArray...)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Cleanup is not used for this plugin
+func (p *moderationPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/moderation"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// moderationTestConfig returns a config moderating prompts and completions with keyword rules, applying action
+// to every request
+func moderationTestConfig(t *testing.T, action moderation.Action) *lib.Config {
+	t.Helper()
+	moderator, err := moderation.New(&moderation.Config{
+		Enabled:     true,
+		Classifier:  moderation.ClassifierKeywords,
+		Keywords:    map[string][]string{"weapons": {`\bexplosives?\b`}, "spam": {`buy now`}},
+		CheckOutput: true,
+		Policies:    []moderation.Policy{{Name: "strict", Action: action}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create moderator: %v", err)
+	}
+	store := moderation.NewInMemoryStore()
+	return &lib.Config{Moderator: moderator, ModerationEvents: store, ModerationReviews: moderation.NewReviews(store)}
+}
+
+// moderationChatRequest returns a chat request whose latest user message is text, after an earlier turn
+func moderationChatRequest(text string) *schemas.BifrostRequest {
+	messages := []schemas.ChatMessage{
+		sessionMessage(schemas.ChatMessageRoleUser, "How do explosives work?"),
+		sessionMessage(schemas.ChatMessageRoleAssistant, "I can't help with that."),
+		sessionMessage(schemas.ChatMessageRoleUser, text),
+	}
+	return &schemas.BifrostRequest{
+		Provider:    schemas.OpenAI,
+		Model:       "gpt-4o-mini",
+		RequestType: schemas.ChatCompletionRequest,
+		ChatRequest: &schemas.BifrostChatRequest{Provider: schemas.OpenAI, Model: "gpt-4o-mini", Input: messages},
+	}
+}
+
+// TestModerationPlugin_Input tests that prompts are blocked or flagged by the matching policy, that only the
+// messages after the last assistant message are moderated and that the outcomes are recorded
+func TestModerationPlugin_Input(t *testing.T) {
+	config := moderationTestConfig(t, moderation.ActionBlock)
+	plugin := &moderationPlugin{config: config, logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+
+	responseHeaders := &lib.ResponseHeaders{}
+	ctx := context.WithValue(context.Background(), lib.ResponseHeadersContextKey, responseHeaders)
+	if _, shortCircuit, err := plugin.PreHook(&ctx, moderationChatRequest("Tell me a joke")); err != nil || shortCircuit != nil {
+		t.Fatalf("expected an earlier turn not to be moderated again, got error %v or short circuit %+v", err, shortCircuit)
+	}
+	if _, total, _ := config.ModerationEvents.List(context.Background(), moderation.Filter{}, 10, 0); total != 0 {
+		t.Fatalf("expected no moderation events, got %d", total)
+	}
+
+	ctx = context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "req-1")
+	_, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest("Where can I get explosives?"))
+	if shortCircuit == nil || shortCircuit.Error == nil {
+		t.Fatal("expected the prompt to be blocked")
+	}
+	if *shortCircuit.Error.StatusCode != 400 || *shortCircuit.Error.Error.Type != "content_policy_violation" || *shortCircuit.Error.AllowFallbacks {
+		t.Errorf("unexpected block error %+v", shortCircuit.Error)
+	}
+
+	events, total, err := config.ModerationEvents.List(context.Background(), moderation.Filter{Action: moderation.ActionBlock}, 10, 0)
+	if err != nil || total != 1 {
+		t.Fatalf("expected 1 block event, got %d (%v)", total, err)
+	}
+	event := events[0]
+	if event.RequestID != "req-1" || event.Stage != moderation.StageInput || event.Policy != "strict" || event.Content != "Where can I get explosives?" {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if len(event.Flagged) != 1 || event.Flagged[0] != "weapons" {
+		t.Errorf("expected weapons to be flagged, got %v", event.Flagged)
+	}
+
+	// Flagged content passes and is reported in the response headers
+	plugin.config = moderationTestConfig(t, moderation.ActionFlag)
+	ctx = context.WithValue(context.Background(), lib.ResponseHeadersContextKey, responseHeaders)
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest("Buy now! Explosives inside")); shortCircuit != nil {
+		t.Fatalf("expected flagged content to pass, got short circuit %+v", shortCircuit)
+	}
+	if got := responseHeaders.Get(ModerationFlaggedHeader); got != "spam,weapons" {
+		t.Errorf("expected the flagged header to be spam,weapons, got %q", got)
+	}
+}
+
+// TestModerationPlugin_Output tests that completions are moderated, streams once they end
+func TestModerationPlugin_Output(t *testing.T) {
+	config := moderationTestConfig(t, moderation.ActionBlock)
+	plugin := &moderationPlugin{config: config, logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+
+	ctx := context.Background()
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest("Tell me about chemistry")); shortCircuit != nil {
+		t.Fatalf("unexpected short circuit %+v", shortCircuit)
+	}
+
+	message := sessionMessage(schemas.ChatMessageRoleAssistant, "Explosives are made by...")
+	response := &schemas.BifrostResponse{Model: "gpt-4o-mini", Choices: []schemas.BifrostChatResponseChoice{{
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &message},
+	}}}
+	result, bifrostErr, _ := plugin.PostHook(&ctx, response, nil)
+	if result != nil || bifrostErr == nil {
+		t.Fatal("expected the completion to be replaced with an error")
+	}
+
+	// Streamed completions only fail on the final chunk
+	ctx = context.Background()
+	plugin.PreHook(&ctx, moderationChatRequest("Tell me about chemistry"))
+	for i, delta := range []string{"Some explo", "sives are"} {
+		content := delta
+		chunk := &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{{
+			BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{Content: &content}},
+		}}}
+		if i == 1 {
+			ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+		}
+		result, bifrostErr, _ := plugin.PostHook(&ctx, chunk, nil)
+		if blocked := bifrostErr != nil; blocked != (i == 1) || (result == nil) == (i == 0) {
+			t.Errorf("chunk %d: unexpected result %v and error %+v", i, result, bifrostErr)
+		}
+	}
+
+	if _, total, _ := config.ModerationEvents.List(context.Background(), moderation.Filter{Stage: moderation.StageOutput}, 10, 0); total != 2 {
+		t.Errorf("expected 2 output events, got %d", total)
+	}
+}
+
+// TestModerationPlugin_Hold tests that held prompts wait for a review, are rejected while pending and can be
+// resubmitted once approved
+func TestModerationPlugin_Hold(t *testing.T) {
+	config := moderationTestConfig(t, moderation.ActionHold)
+	plugin := &moderationPlugin{config: config, logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+	text := "Where can I get explosives?"
+
+	responseHeaders := &lib.ResponseHeaders{}
+	ctx := context.WithValue(context.Background(), lib.ResponseHeadersContextKey, responseHeaders)
+	_, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest(text))
+	if shortCircuit == nil || *shortCircuit.Error.StatusCode != 403 || *shortCircuit.Error.Type != "pending_review" {
+		t.Fatalf("expected the prompt to be held, got %+v", shortCircuit)
+	}
+	eventID := responseHeaders.Get(ModerationReviewIDHeader)
+	events, _, _ := config.ModerationEvents.List(context.Background(), moderation.Filter{ReviewStatus: moderation.ReviewPending}, 10, 0)
+	if len(events) != 1 || events[0].ID != eventID {
+		t.Fatalf("expected the held event %q in the review queue, got %+v", eventID, events)
+	}
+
+	// Resubmitting before approval is held again, after approval it passes
+	ctx = context.WithValue(context.Background(), lib.ModerationReviewIDContextKey, eventID)
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest(text)); shortCircuit == nil {
+		t.Fatal("expected an unreviewed resubmission to be held")
+	}
+	if _, err := config.ModerationReviews.Review(context.Background(), eventID, moderation.ReviewApproved, "alice", ""); err != nil {
+		t.Fatalf("failed to approve: %v", err)
+	}
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest(text)); shortCircuit != nil {
+		t.Fatalf("expected the approved resubmission to pass, got %+v", shortCircuit)
+	}
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest("Where can I get more explosives?")); shortCircuit == nil {
+		t.Fatal("expected the approval not to release different content")
+	}
+
+	// With a hold timeout, a denial made while waiting rejects the request
+	config.Moderator, _ = moderation.New(&moderation.Config{
+		Enabled:            true,
+		Classifier:         moderation.ClassifierKeywords,
+		Keywords:           map[string][]string{"weapons": {`\bexplosives?\b`}},
+		Policies:           []moderation.Policy{{Name: "strict", Action: moderation.ActionHold}},
+		HoldTimeoutSeconds: 5,
+	})
+	config.ModerationEvents = moderation.NewInMemoryStore()
+	config.ModerationReviews = moderation.NewReviews(config.ModerationEvents)
+	go func() {
+		for {
+			time.Sleep(10 * time.Millisecond)
+			pending, _, _ := config.ModerationEvents.List(context.Background(), moderation.Filter{ReviewStatus: moderation.ReviewPending}, 1, 0)
+			if len(pending) == 1 {
+				config.ModerationReviews.Review(context.Background(), pending[0].ID, moderation.ReviewDenied, "bob", "no")
+				return
+			}
+		}
+	}()
+	ctx = context.Background()
+	_, shortCircuit, _ = plugin.PreHook(&ctx, moderationChatRequest("Explosives for sale"))
+	if shortCircuit == nil || *shortCircuit.Error.StatusCode != 400 {
+		t.Fatalf("expected the denied prompt to be rejected, got %+v", shortCircuit)
+	}
+}
+
+// TestModerationPlugin_ZeroDataRetention tests that moderation events of requests under zero data retention record
+// the decision without the content
+func TestModerationPlugin_ZeroDataRetention(t *testing.T) {
+	config := moderationTestConfig(t, moderation.ActionBlock)
+	plugin := &moderationPlugin{config: config, logger: bifrost.NewDefaultLogger(schemas.LogLevelError)}
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRetentionClass, schemas.RetentionClassZeroDataRetention)
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, moderationChatRequest("Where can I get explosives?")); shortCircuit == nil {
+		t.Fatal("expected the prompt to be blocked")
+	}
+	events, total, err := config.ModerationEvents.List(context.Background(), moderation.Filter{}, 10, 0)
+	if err != nil || total != 1 {
+		t.Fatalf("expected 1 event, got %d (%v)", total, err)
+	}
+	if events[0].Content != "" || events[0].Policy != "strict" || len(events[0].Flagged) != 1 {
+		t.Errorf("expected the decision without the content, got %+v", events[0])
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package handlers
+
+// raceDetectorEnabled is set when tests run with -race, whose instrumentation allocates
+const raceDetectorEnabled = false
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/providers/anthropic"
+	"github.com/maximhq/bifrost/core/schemas/providers/bedrock"
+	"github.com/maximhq/bifrost/core/schemas/providers/cohere"
+	"github.com/maximhq/bifrost/core/schemas/providers/gemini"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden files of the normalization tests")
+
+// normalizationFixture is an upstream response of a provider, in its own format
+type normalizationFixture struct {
+	Provider schemas.ModelProvider `json:"provider"`
+	Response json.RawMessage       `json:"response"`
+}
+
+// toBifrostResponse converts an upstream response the way its provider does
+func (f normalizationFixture) toBifrostResponse(t *testing.T) *schemas.BifrostResponse {
+	t.Helper()
+	var err error
+	var resp *schemas.BifrostResponse
+	switch f.Provider {
+	case schemas.Anthropic:
+		var upstream anthropic.AnthropicMessageResponse
+		if err = json.Unmarshal(f.Response, &upstream); err == nil {
+			resp = upstream.ToBifrostResponse()
+		}
+	case schemas.Bedrock:
+		var upstream bedrock.BedrockConverseResponse
+		if err = json.Unmarshal(f.Response, &upstream); err == nil {
+			resp, err = upstream.ToBifrostResponse()
+		}
+	case schemas.Cohere:
+		var upstream cohere.CohereChatResponse
+		if err = json.Unmarshal(f.Response, &upstream); err == nil {
+			resp = upstream.ToBifrostResponse()
+		}
+	default:
+		// OpenAI-compatible APIs, including the ones of Gemini, Vertex and Mistral
+		resp = &schemas.BifrostResponse{}
+		err = json.Unmarshal(f.Response, resp)
+	}
+	if err != nil {
+		t.Fatalf("failed to convert the %s response: %v", f.Provider, err)
+	}
+	return resp
+}
+
+// TestNormalizeResponse_Golden tests that the responses of every provider are normalized to the OpenAI shape.
+// Run with -update-golden to rewrite the golden files after an intended change.
+func TestNormalizeResponse_Golden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "normalization", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range fixtures {
+		if strings.HasSuffix(path, ".golden.json") {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fixture normalizationFixture
+			if err := json.Unmarshal(data, &fixture); err != nil {
+				t.Fatalf("invalid fixture: %v", err)
+			}
+			resp := fixture.toBifrostResponse(t)
+			schemas.NormalizeResponse(fixture.Provider, resp)
+			assertOpenAIShape(t, resp)
+			canonicalizeToolCallArguments(t, resp)
+
+			got, err := json.MarshalIndent(resp, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+			goldenPath := strings.TrimSuffix(path, ".json") + ".golden.json"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("missing golden file, run with -update-golden: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("normalized response differs from %s:\n%s", goldenPath, got)
+			}
+		})
+	}
+}
+
+// canonicalizeToolCallArguments sorts the keys of tool call arguments, which some providers (e.g. Bedrock) marshal
+// from maps in random order
+func canonicalizeToolCallArguments(t *testing.T, resp *schemas.BifrostResponse) {
+	t.Helper()
+	for _, choice := range resp.Choices {
+		if choice.BifrostNonStreamResponseChoice == nil || choice.Message == nil || choice.Message.ChatAssistantMessage == nil {
+			continue
+		}
+		for i := range choice.Message.ToolCalls {
+			function := &choice.Message.ToolCalls[i].Function
+			var arguments any
+			if err := json.Unmarshal([]byte(function.Arguments), &arguments); err != nil {
+				continue
+			}
+			canonical, err := json.Marshal(arguments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			function.Arguments = string(canonical)
+		}
+	}
+}
+
+// assertOpenAIShape checks the invariants clients rely on, whatever the provider
+func assertOpenAIShape(t *testing.T, resp *schemas.BifrostResponse) {
+	t.Helper()
+	if !slices.Contains([]string{"chat.completion", "chat.completion.chunk", "text_completion"}, resp.Object) {
+		t.Errorf("unexpected object %q", resp.Object)
+	}
+	for _, choice := range resp.Choices {
+		if choice.FinishReason != nil && !slices.Contains([]string{"stop", "length", "tool_calls", "content_filter"}, *choice.FinishReason) {
+			t.Errorf("unexpected finish reason %q", *choice.FinishReason)
+		}
+		if choice.BifrostNonStreamResponseChoice == nil || choice.Message == nil {
+			continue
+		}
+		if choice.Message.Role != schemas.ChatMessageRoleAssistant {
+			t.Errorf("unexpected role %q", choice.Message.Role)
+		}
+		if choice.Message.ChatAssistantMessage == nil {
+			continue
+		}
+		for _, toolCall := range choice.Message.ToolCalls {
+			if toolCall.ID == nil || *toolCall.ID == "" || toolCall.Type == nil || *toolCall.Type != "function" {
+				t.Errorf("incomplete tool call: %+v", toolCall)
+			}
+			if !json.Valid([]byte(toolCall.Function.Arguments)) {
+				t.Errorf("invalid tool call arguments %q", toolCall.Function.Arguments)
+			}
+		}
+		if len(choice.Message.ToolCalls) > 0 && (choice.FinishReason == nil || *choice.FinishReason != "tool_calls") {
+			t.Errorf("expected tool calls to finish with tool_calls, got %v", choice.FinishReason)
+		}
+	}
+	if resp.Usage != nil && resp.Usage.TotalTokens < resp.Usage.PromptTokens+resp.Usage.CompletionTokens {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+// TestNormalizeResponse_ToolCallIDs tests that generated tool call IDs are stable for a response and differ across responses
+func TestNormalizeResponse_ToolCallIDs(t *testing.T) {
+	newResponse := func(id string) *schemas.BifrostResponse {
+		return &schemas.BifrostResponse{ID: id, Choices: []schemas.BifrostChatResponseChoice{{
+			BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &schemas.ChatMessage{
+				ChatAssistantMessage: &schemas.ChatAssistantMessage{ToolCalls: []schemas.ChatAssistantMessageToolCall{
+					{Function: schemas.ChatAssistantMessageToolCallFunction{Name: schemas.Ptr("a")}},
+					{Function: schemas.ChatAssistantMessageToolCallFunction{Name: schemas.Ptr("b")}},
+				}},
+			}},
+		}}}
+	}
+	toolCallIDs := func(resp *schemas.BifrostResponse) []string {
+		schemas.NormalizeResponse(schemas.Gemini, resp)
+		var ids []string
+		for _, toolCall := range resp.Choices[0].Message.ToolCalls {
+			ids = append(ids, *toolCall.ID)
+		}
+		return ids
+	}
+	first, again, other, anonymous := toolCallIDs(newResponse("resp-1")), toolCallIDs(newResponse("resp-1")), toolCallIDs(newResponse("resp-2")), toolCallIDs(newResponse(""))
+	if !slices.Equal(first, again) || first[0] == first[1] {
+		t.Errorf("expected stable and distinct IDs for a response, got %v and %v", first, again)
+	}
+	if slices.Contains(other, first[0]) || slices.Contains(anonymous, first[0]) || anonymous[0] == anonymous[1] {
+		t.Errorf("expected IDs to differ across responses, got %v, %v and %v", first, other, anonymous)
+	}
+	for _, id := range slices.Concat(first, anonymous) {
+		if !strings.HasPrefix(id, "call_") || len(id) != len("call_")+24 {
+			t.Errorf("unexpected ID format %q", id)
+		}
+	}
+}
+
+// TestNormalizeResponse_StreamToolCallDeltas tests that argument deltas of a streamed tool call get no ID of their own
+func TestNormalizeResponse_StreamToolCallDeltas(t *testing.T) {
+	chunk := &schemas.BifrostResponse{ID: "chunk", Choices: []schemas.BifrostChatResponseChoice{{
+		BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{
+			ToolCalls: []schemas.ChatAssistantMessageToolCall{{Function: schemas.ChatAssistantMessageToolCallFunction{Arguments: `"Par`}}},
+		}},
+	}}}
+	schemas.NormalizeResponse(schemas.Gemini, chunk)
+	if toolCall := chunk.Choices[0].Delta.ToolCalls[0]; toolCall.ID != nil || toolCall.Function.Arguments != `"Par` {
+		t.Errorf("expected the delta to be left as is, got %+v", toolCall)
+	}
+	if chunk.Object != "chat.completion.chunk" {
+		t.Errorf("unexpected object %q", chunk.Object)
+	}
+}
+
+// TestGeminiIntegration_FinishReason tests that normalized finish reasons are mapped back for Gemini clients
+func TestGeminiIntegration_FinishReason(t *testing.T) {
+	resp := &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{{
+		FinishReason: schemas.Ptr("length"),
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &schemas.ChatMessage{
+			Role:    schemas.ChatMessageRoleAssistant,
+			Content: &schemas.ChatMessageContent{ContentStr: schemas.Ptr("The answer is")},
+		}},
+	}}}
+	genaiResp, ok := gemini.ToGeminiGenerationResponse(resp).(*gemini.GenerateContentResponse)
+	if !ok || len(genaiResp.Candidates) != 1 {
+		t.Fatalf("unexpected response: %#v", genaiResp)
+	}
+	if reason := genaiResp.Candidates[0].FinishReason; reason != gemini.FinishReasonMaxTokens {
+		t.Errorf("expected MAX_TOKENS, got %q", reason)
+	}
+}
@@ -0,0 +1,651 @@
+package handlers
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/ingestion"
+	"github.com/maximhq/bifrost/framework/langdetect"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/framework/moderation"
+	"github.com/maximhq/bifrost/framework/recording"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/framework/sessions"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// openAPIOperation describes a route for the OpenAPI document.
+// Request and Response are zero values of the body types, nil when the route has no JSON body.
+type openAPIOperation struct {
+	Summary  string
+	Tag      string
+	Request  any
+	Response any
+}
+
+// openAPIOperations describes the documented routes, keyed by "METHOD path".
+var openAPIOperations = map[string]openAPIOperation{
+	// Inference
+	"POST /v1/completions":          {Summary: "Create a text completion", Tag: "Inference", Request: TextRequest{}, Response: schemas.BifrostResponse{}},
+	"POST /v1/chat/completions":     {Summary: "Create a chat completion", Tag: "Inference", Request: ChatRequest{}, Response: schemas.BifrostResponse{}},
+	"POST /v1/responses":            {Summary: "Create a response", Tag: "Inference", Request: ResponsesRequest{}, Response: schemas.BifrostResponse{}},
+	"POST /v1/embeddings":           {Summary: "Create embeddings", Tag: "Inference", Request: EmbeddingRequest{}, Response: schemas.BifrostResponse{}},
+	"POST /v1/rerank":               {Summary: "Rerank documents by relevance to a query (Cohere, Voyage and Jina request formats)", Tag: "Inference", Request: RerankRequest{}, Response: schemas.BifrostResponse{}},
+	"POST /v1/audio/speech":         {Summary: "Generate speech from text", Tag: "Inference", Request: SpeechRequest{}},
+	"POST /v1/audio/transcriptions": {Summary: "Transcribe audio (multipart/form-data)", Tag: "Inference", Response: schemas.BifrostResponse{}},
+	"POST /v1/mcp/tool/execute":     {Summary: "Execute an MCP tool call", Tag: "MCP", Request: schemas.ChatAssistantMessageToolCall{}, Response: schemas.ChatMessage{}},
+	"GET /v1/async/{id}":            {Summary: "Get the state of a request sent with X-Bifrost-Async: true, and its response once finished", Tag: "Inference", Response: lib.AsyncJobResponse{}},
+
+	// Retrieval
+	"GET /api/retrieval/stores":                           {Summary: "List the retrieval stores", Tag: "Retrieval"},
+	"POST /api/retrieval/query":                           {Summary: "Return the chunks of a retrieval store closest to a query, most similar first", Tag: "Retrieval", Request: RetrievalQueryRequest{}},
+	"GET /api/retrieval/documents":                        {Summary: "List the ingested documents without their content (paginated)", Tag: "Retrieval"},
+	"POST /api/retrieval/documents":                       {Summary: "Ingest a document into a retrieval store, chunking and embedding it in the background", Tag: "Retrieval", Request: lib.IngestionRequest{}, Response: ingestion.Document{}},
+	"GET /api/retrieval/documents/{document_id}":          {Summary: "Get an ingested document and its indexing progress", Tag: "Retrieval", Response: ingestion.Document{}},
+	"DELETE /api/retrieval/documents/{document_id}":       {Summary: "Delete an ingested document and its chunks", Tag: "Retrieval"},
+	"POST /api/retrieval/documents/{document_id}/reindex": {Summary: "Index a document again, optionally with a new chunking or embedding model", Tag: "Retrieval", Request: ReindexRequest{}, Response: ingestion.Document{}},
+
+	// Fine-tuning
+	"POST /v1/fine_tuning/jobs":             {Summary: "Create a fine-tuning job on the provider prefixing the model, subject to governance and the allowed models and datasets", Tag: "Fine-tuning"},
+	"GET /v1/fine_tuning/jobs":              {Summary: "List the fine-tuning jobs of a provider, only those of the virtual key when one is sent (provider, limit, after)", Tag: "Fine-tuning"},
+	"GET /v1/fine_tuning/jobs/{id}":         {Summary: "Get a fine-tuning job created through Bifrost", Tag: "Fine-tuning"},
+	"POST /v1/fine_tuning/jobs/{id}/cancel": {Summary: "Cancel a fine-tuning job created through Bifrost", Tag: "Fine-tuning"},
+	"GET /v1/fine_tuning/jobs/{id}/events":  {Summary: "Get the progress events of a fine-tuning job created through Bifrost (limit, after)", Tag: "Fine-tuning"},
+	"GET /api/fine-tuning/jobs":             {Summary: "List the fine-tuning jobs created through Bifrost with their latest status (provider, status, virtual_key; limit/offset)", Tag: "Fine-tuning", Response: FineTuningJobsResponse{}},
+
+	// Assistants
+	"POST /v1/assistants":                                            {Summary: "Create an assistant with a provider/model, instructions and function tools", Tag: "Assistants", Response: AssistantObject{}},
+	"GET /v1/assistants":                                             {Summary: "List assistants (limit, order, after, before)", Tag: "Assistants"},
+	"GET /v1/assistants/{assistant_id}":                              {Summary: "Get an assistant", Tag: "Assistants", Response: AssistantObject{}},
+	"POST /v1/assistants/{assistant_id}":                             {Summary: "Modify the fields of an assistant present in the body", Tag: "Assistants", Response: AssistantObject{}},
+	"DELETE /v1/assistants/{assistant_id}":                           {Summary: "Delete an assistant", Tag: "Assistants", Response: DeletedObject{}},
+	"POST /v1/threads":                                               {Summary: "Create a thread, stored as a conversation session, optionally with messages", Tag: "Assistants", Response: ThreadObject{}},
+	"POST /v1/threads/runs":                                          {Summary: "Create a thread and run an assistant on it", Tag: "Assistants", Response: RunObject{}},
+	"GET /v1/threads/{thread_id}":                                    {Summary: "Get a thread", Tag: "Assistants", Response: ThreadObject{}},
+	"POST /v1/threads/{thread_id}":                                   {Summary: "Replace the metadata of a thread", Tag: "Assistants", Response: ThreadObject{}},
+	"DELETE /v1/threads/{thread_id}":                                 {Summary: "Delete a thread with its messages and runs, cancelling its active run", Tag: "Assistants", Response: DeletedObject{}},
+	"POST /v1/threads/{thread_id}/messages":                          {Summary: "Add a user or assistant message to a thread without an active run", Tag: "Assistants", Response: MessageObject{}},
+	"GET /v1/threads/{thread_id}/messages":                           {Summary: "List the messages of a thread (limit, order, after, before, run_id)", Tag: "Assistants"},
+	"GET /v1/threads/{thread_id}/messages/{message_id}":              {Summary: "Get a message of a thread", Tag: "Assistants", Response: MessageObject{}},
+	"POST /v1/threads/{thread_id}/runs":                              {Summary: "Run an assistant on a thread in the background, executing MCP tools and stopping for function tool outputs", Tag: "Assistants", Response: RunObject{}},
+	"GET /v1/threads/{thread_id}/runs":                               {Summary: "List the runs of a thread (limit, order, after, before)", Tag: "Assistants"},
+	"GET /v1/threads/{thread_id}/runs/{run_id}":                      {Summary: "Get a run, polled until it completes or requires action", Tag: "Assistants", Response: RunObject{}},
+	"POST /v1/threads/{thread_id}/runs/{run_id}/submit_tool_outputs": {Summary: "Resume a run requiring action with the outputs of all its function calls", Tag: "Assistants", Response: RunObject{}},
+	"POST /v1/threads/{thread_id}/runs/{run_id}/cancel":              {Summary: "Cancel an active run", Tag: "Assistants", Response: RunObject{}},
+
+	// Providers and keys
+	"GET /api/providers":               {Summary: "List providers", Tag: "Providers", Response: ListProvidersResponse{}},
+	"GET /api/providers/{provider}":    {Summary: "Get a provider", Tag: "Providers", Response: ProviderResponse{}},
+	"GET /api/providers/health":        {Summary: "Get provider availability, probe latency history and rate limit cooldowns", Tag: "Providers", Response: ProviderHealthResponse{}},
+	"POST /api/providers":              {Summary: "Add a provider (validate=true validates its keys with a live call)", Tag: "Providers", Response: ProviderResponse{}},
+	"PUT /api/providers/{provider}":    {Summary: "Update a provider (validate=true validates its added and changed keys with a live call)", Tag: "Providers", Response: ProviderResponse{}},
+	"DELETE /api/providers/{provider}": {Summary: "Delete a provider", Tag: "Providers", Response: ProviderResponse{}},
+
+	// Key validation
+	"POST /api/providers/{provider}/keys/validate": {Summary: "Validate the keys of a provider with a live call (key_id validates a single key)", Tag: "Providers", Response: map[string]lib.KeyValidation{}},
+	"GET /api/keys":              {Summary: "List provider keys (paginated)", Tag: "Providers", Response: []configstore.TableKey{}},
+	"POST /api/keys:batchCreate": {Summary: "Add keys to their providers in bulk, all or none unless atomic is false", Tag: "Providers", Request: BatchKeysRequest{}, Response: BatchKeysResponse{}},
+	"POST /api/keys:batchUpdate": {Summary: "Replace existing keys in bulk, all or none unless atomic is false", Tag: "Providers", Request: BatchKeysRequest{}, Response: BatchKeysResponse{}},
+	"POST /api/keys:batchDelete": {Summary: "Remove keys from their providers in bulk, all or none unless atomic is false", Tag: "Providers", Request: BatchDeleteKeysRequest{}, Response: BatchKeysResponse{}},
+
+	// Configuration
+	"GET /api/config":         {Summary: "Get the client configuration", Tag: "Configuration"},
+	"PUT /api/config":         {Summary: "Update the client configuration", Tag: "Configuration", Request: configstore.ClientConfig{}},
+	"GET /api/version":        {Summary: "Get the Bifrost version", Tag: "Configuration"},
+	"GET /api/logging/level":  {Summary: "Get the log level", Tag: "Configuration", Response: LogLevelRequest{}},
+	"PUT /api/logging/level":  {Summary: "Change the log level", Tag: "Configuration", Request: LogLevelRequest{}, Response: LogLevelRequest{}},
+	"GET /api/cluster/status": {Summary: "Get the cluster status", Tag: "Configuration"},
+	"GET /health":             {Summary: "Health check", Tag: "Configuration"},
+	"PUT /api/admin/password": {Summary: "Rotate the admin password", Tag: "Configuration", Request: RotatePasswordRequest{}},
+	"POST /api/apply":         {Summary: "Apply a desired-state document (dry_run=true returns the plan)", Tag: "Configuration", Request: DesiredState{}, Response: ApplyResponse{}},
+
+	// Imports
+	"POST /api/import/{source}": {Summary: "Import the config and team and key tables exported by another gateway (source: litellm; dry_run=true returns the plan)", Tag: "Configuration", Response: ImportResponse{}},
+
+	// Config validation
+	"POST /api/config/validate": {Summary: "Validate a candidate config.json document without applying it (connectivity=false skips the provider dry-runs); 422 when invalid", Tag: "Configuration", Response: lib.ConfigValidationReport{}},
+
+	// Config versions
+	"GET /api/config/versions":                {Summary: "List the configuration versions with their changes, newest first", Tag: "Configuration", Response: []ConfigVersionSummary{}},
+	"GET /api/config/versions/{id}":           {Summary: "Get a configuration version with its redacted snapshot", Tag: "Configuration", Response: ConfigVersionResponse{}},
+	"POST /api/config/versions/{id}/rollback": {Summary: "Roll back to a configuration version (dry_run=true returns the changes only)", Tag: "Configuration", Response: ConfigRollbackResponse{}},
+
+	// Transformations
+	"GET /api/transformations":     {Summary: "Get the request transformation rules", Tag: "Configuration", Response: TransformationRulesRequest{}},
+	"PUT /api/transformations":     {Summary: "Replace the request transformation rules", Tag: "Configuration", Request: TransformationRulesRequest{}, Response: TransformationRulesRequest{}},
+	"GET /api/response-transforms": {Summary: "Get the response transform rules", Tag: "Configuration", Response: ResponseTransformRulesRequest{}},
+	"PUT /api/response-transforms": {Summary: "Replace the response transform rules", Tag: "Configuration", Request: ResponseTransformRulesRequest{}, Response: ResponseTransformRulesRequest{}},
+
+	// System prompt policies
+	"GET /api/system-prompt-policies": {Summary: "Get the mandatory system prompt policies", Tag: "Configuration", Response: SystemPromptPoliciesRequest{}},
+	"PUT /api/system-prompt-policies": {Summary: "Replace the mandatory system prompt policies", Tag: "Configuration", Request: SystemPromptPoliciesRequest{}, Response: SystemPromptPoliciesRequest{}},
+
+	// Sessions
+	"GET /api/sessions":                 {Summary: "List conversation sessions (paginated)", Tag: "Sessions"},
+	"POST /api/sessions":                {Summary: "Create a conversation session", Tag: "Sessions", Request: SessionRequest{}, Response: sessions.Session{}},
+	"GET /api/sessions/{session_id}":    {Summary: "Get a conversation session with its messages", Tag: "Sessions", Response: sessions.Session{}},
+	"PUT /api/sessions/{session_id}":    {Summary: "Replace the summary and messages of a session", Tag: "Sessions", Request: SessionRequest{}, Response: sessions.Session{}},
+	"DELETE /api/sessions/{session_id}": {Summary: "Delete a conversation session", Tag: "Sessions"},
+
+	// Experiments
+	"GET /api/experiments":                   {Summary: "List A/B experiments", Tag: "Experiments"},
+	"POST /api/experiments":                  {Summary: "Create an A/B experiment", Tag: "Experiments", Request: lib.Experiment{}, Response: lib.Experiment{}},
+	"GET /api/experiments/{name}":            {Summary: "Get an A/B experiment", Tag: "Experiments", Response: lib.Experiment{}},
+	"PUT /api/experiments/{name}":            {Summary: "Replace an A/B experiment", Tag: "Experiments", Request: lib.Experiment{}, Response: lib.Experiment{}},
+	"DELETE /api/experiments/{name}":         {Summary: "Delete an A/B experiment and its results", Tag: "Experiments"},
+	"GET /api/experiments/{name}/results":    {Summary: "Get the outcome metrics per variant", Tag: "Experiments", Response: ExperimentResultsResponse{}},
+	"DELETE /api/experiments/{name}/results": {Summary: "Reset the results of an experiment", Tag: "Experiments"},
+	"POST /api/experiments/{name}/feedback":  {Summary: "Report a score for a response of a variant", Tag: "Experiments", Request: ExperimentFeedbackRequest{}},
+
+	// Language routing
+	"GET /api/language-routing":         {Summary: "Get the language detection and routing configuration", Tag: "Configuration", Response: lib.LanguageRoutingConfig{}},
+	"PUT /api/language-routing":         {Summary: "Replace the language detection and routing configuration", Tag: "Configuration", Request: lib.LanguageRoutingConfig{}, Response: lib.LanguageRoutingConfig{}},
+	"POST /api/language-routing/detect": {Summary: "Detect the language of a text", Tag: "Configuration", Request: DetectLanguageRequest{}, Response: langdetect.Result{}},
+
+	// Routing overrides
+	"GET /api/routing-overrides": {Summary: "Get the policies allowing virtual keys to override routing with the x-bifrost-provider and x-bifrost-fallbacks headers", Tag: "Configuration", Response: lib.RoutingOverridesConfig{}},
+	"PUT /api/routing-overrides": {Summary: "Replace the routing override policies", Tag: "Configuration", Request: lib.RoutingOverridesConfig{}, Response: lib.RoutingOverridesConfig{}},
+	"GET /api/policies":          {Summary: "Get the active routing and governance policies", Tag: "Configuration", Response: lib.PoliciesConfig{}},
+	"PUT /api/policies":          {Summary: "Replace the routing and governance policies with a new version", Tag: "Configuration", Request: lib.PoliciesConfig{}, Response: lib.PoliciesConfig{}},
+	"GET /api/policies/versions": {Summary: "List the versions of the policies kept, oldest first", Tag: "Configuration"},
+	"POST /api/policies/test":    {Summary: "Evaluate the active or given policies against a request without sending it", Tag: "Configuration", Request: PolicyTestRequest{}, Response: lib.PolicyDecision{}},
+
+	// Tenant domains
+	"GET /api/tenant-domains": {Summary: "List the custom domains and branding of tenants, with their passwords redacted", Tag: "Configuration", Response: []lib.TenantDomain{}},
+	"PUT /api/tenant-domains": {Summary: "Replace the tenant domains; redacted passwords keep their current value", Tag: "Configuration", Request: []lib.TenantDomain{}},
+
+	// Moderation
+	"GET /api/moderation/events":                    {Summary: "List moderation events (filter by stage, action, virtual_key, review_status; limit/offset)", Tag: "Moderation"},
+	"GET /api/moderation/events/{event_id}":         {Summary: "Get a moderation event", Tag: "Moderation", Response: moderation.Event{}},
+	"POST /api/moderation/events/{event_id}/review": {Summary: "Approve, deny or annotate a moderation event, releasing held requests", Tag: "Moderation", Request: ReviewRequest{}, Response: moderation.Event{}},
+
+	// Auth
+	"POST /api/auth/login":          {Summary: "Sign in with the admin password and start a cookie session, optionally limited to scopes", Tag: "Auth", Request: LoginRequest{}, Response: AuthSession{}},
+	"POST /api/auth/logout":         {Summary: "End the cookie session, or the device login of a bearer device token", Tag: "Auth", Response: AuthSession{}},
+	"GET /api/auth/me":              {Summary: "Get how the caller is authenticated", Tag: "Auth", Response: AuthSession{}},
+	"POST /api/auth/device":         {Summary: "Start a CLI device login and get the code a signed-in admin confirms", Tag: "Auth", Request: DeviceAuthorizationRequest{}, Response: DeviceAuthorizationResponse{}},
+	"POST /api/auth/device/token":   {Summary: "Poll for the scoped token of a confirmed device login, or refresh it", Tag: "Auth", Request: DeviceTokenRequest{}, Response: DeviceTokenResponse{}},
+	"GET /api/auth/device":          {Summary: "Get the pending device login of a user code (user_code)", Tag: "Auth", Response: lib.DeviceAuthorization{}},
+	"POST /api/auth/device/approve": {Summary: "Approve or deny a device login, optionally narrowing its scopes", Tag: "Auth", Request: DeviceDecisionRequest{}, Response: lib.DeviceAuthorization{}},
+
+	// Public routes
+	"GET /api/public-routes": {Summary: "Get the rules deciding which routes skip admin authentication, and the defaults they override", Tag: "Auth", Response: PublicRoutesResponse{}},
+	"PUT /api/public-routes": {Summary: "Replace the public route rules; the first matching rule wins", Tag: "Auth", Request: PublicRoutesRequest{}, Response: PublicRoutesResponse{}},
+
+	// Dashboard locales
+	"GET /api/ui/locales": {Summary: "List the locales of the dashboard and the one negotiated for the caller (bf_locale cookie, then Accept-Language)", Tag: "Configuration", Response: UILocalesResponse{}},
+
+	// System mode
+	"GET /api/system/mode": {Summary: "Get the maintenance and read-only mode toggles", Tag: "Configuration", Response: lib.SystemMode{}},
+	"PUT /api/system/mode": {Summary: "Switch maintenance mode (inference returns 503 with Retry-After) or read-only mode (management API changes are rejected)", Tag: "Configuration", Request: SystemModeRequest{}, Response: lib.SystemMode{}},
+
+	// Service accounts
+	"GET /api/service-accounts":              {Summary: "List service accounts and the available scopes", Tag: "Service Accounts"},
+	"POST /api/service-accounts":             {Summary: "Create a service account; its token is only returned once", Tag: "Service Accounts", Request: CreateServiceAccountRequest{}, Response: ServiceAccountTokenResponse{}},
+	"GET /api/service-accounts/{id}":         {Summary: "Get a service account", Tag: "Service Accounts", Response: serviceaccounts.Account{}},
+	"PUT /api/service-accounts/{id}":         {Summary: "Change the description, scopes, expiry or disabled state of a service account", Tag: "Service Accounts", Request: serviceaccounts.Changes{}, Response: serviceaccounts.Account{}},
+	"DELETE /api/service-accounts/{id}":      {Summary: "Delete a service account, revoking its token", Tag: "Service Accounts"},
+	"POST /api/service-accounts/{id}/rotate": {Summary: "Replace the token of a service account", Tag: "Service Accounts", Response: ServiceAccountTokenResponse{}},
+
+	// Evaluation
+	"GET /api/evaluations/scores":  {Summary: "List evaluation scores (filter by request_id, evaluator, provider, model, start_time, end_time; limit/offset)", Tag: "Evaluation"},
+	"GET /api/evaluations/summary": {Summary: "Get quality metrics per provider, model and evaluator", Tag: "Evaluation"},
+
+	// Recording
+	"GET /api/recordings":         {Summary: "List upstream recordings without bodies (filter by request_id, provider, model; limit/offset)", Tag: "Recording"},
+	"GET /api/recordings/{id}":    {Summary: "Get an upstream recording", Tag: "Recording", Response: recording.Recording{}},
+	"DELETE /api/recordings/{id}": {Summary: "Delete an upstream recording", Tag: "Recording"},
+	"POST /api/replay/{id}":       {Summary: "Replay a recorded request against the same or another provider and compare the responses", Tag: "Recording", Request: ReplayRequest{}, Response: ReplayResponse{}},
+
+	// Benchmark
+	"POST /api/benchmark/run": {Summary: "Send synthetic traffic through the full pipeline to the mock provider and report throughput and latencies", Tag: "Benchmark", Request: BenchmarkRequest{}, Response: BenchmarkResult{}},
+
+	// MCP
+	"GET /api/mcp/clients":                  {Summary: "List MCP clients", Tag: "MCP"},
+	"POST /api/mcp/client":                  {Summary: "Add an MCP client", Tag: "MCP", Request: schemas.MCPClientConfig{}},
+	"PUT /api/mcp/client/{name}":            {Summary: "Edit the tools of an MCP client", Tag: "MCP"},
+	"DELETE /api/mcp/client/{name}":         {Summary: "Remove an MCP client", Tag: "MCP"},
+	"POST /api/mcp/client/{name}/reconnect": {Summary: "Reconnect an MCP client", Tag: "MCP"},
+
+	// Plugins
+	"GET /api/plugins":           {Summary: "List plugins (paginated)", Tag: "Plugins"},
+	"GET /api/plugins/{name}":    {Summary: "Get a plugin", Tag: "Plugins", Response: configstore.TablePlugin{}},
+	"POST /api/plugins":          {Summary: "Create a plugin", Tag: "Plugins", Request: CreatePluginRequest{}},
+	"PUT /api/plugins/{name}":    {Summary: "Update a plugin", Tag: "Plugins", Request: UpdatePluginRequest{}},
+	"DELETE /api/plugins/{name}": {Summary: "Delete a plugin", Tag: "Plugins"},
+
+	// Logs
+	"GET /api/logs":         {Summary: "Search logs (paginated)", Tag: "Logs", Response: logstore.SearchResult{}},
+	"GET /api/logs/dropped": {Summary: "Get the number of dropped log entries", Tag: "Logs"},
+	"GET /api/logs/models":  {Summary: "List the models seen in logs", Tag: "Logs"},
+
+	// Live streams
+	"GET /api/stream/logs":    {Summary: "Push log events matching the /api/logs filters over WebSocket or SSE, starting with the last recent ones", Tag: "Logs"},
+	"GET /api/stream/metrics": {Summary: "Push the metrics of the last minute of requests matching the /api/logs filters every interval seconds over WebSocket or SSE", Tag: "Logs", Response: StreamMetrics{}},
+
+	// Playground
+	"POST /api/playground/chat": {Summary: "Send a test chat request through the full pipeline under the playground cost cap, returning the response with the trace of plugin hooks, provider attempts and upstream exchanges", Tag: "Playground", Request: PlaygroundChatRequest{}, Response: PlaygroundChatResponse{}},
+
+	// Traces
+	"GET /api/traces/{request_id}": {Summary: "Timeline of a recent inference request: time in each middleware, interceptor and plugin changes, provider attempts, fallbacks and token usage", Tag: "Traces", Response: lib.RequestTimeline{}},
+
+	// Metrics
+	"GET /api/metrics/catalog": {Summary: "Every metric exported on /metrics with its type, help, labels, buckets and exemplars", Tag: "Metrics", Response: MetricsCatalogResponse{}},
+
+	// SLOs
+	"GET /api/slos": {Summary: "Compliance, remaining error budget, burn rates and burn-rate alerts of every SLO", Tag: "SLOs", Response: SLOStatusResponse{}},
+
+	// Kubernetes
+	"GET /api/kubernetes/sync":  {Summary: "ConfigMaps and Secrets the configuration is reconciled to, and the outcome of the last reconciliation", Tag: "Kubernetes", Response: KubernetesSyncStatus{}},
+	"POST /api/kubernetes/sync": {Summary: "Reconcile the configuration to the ConfigMaps and Secrets right away", Tag: "Kubernetes", Response: KubernetesSyncStatus{}},
+
+	// Scaling
+	"GET /api/scaling/recommendation": {Summary: "Replica count recommended from the provider queue depth, in-flight streams, CPU and memory of this replica (?current_replicas=N)", Tag: "Scaling", Response: ScalingRecommendationResponse{}},
+
+	// Racing
+	"GET /api/race/stats": {Summary: "Share of the requests of every race and hedge rule sent to a second provider, and how often it answered first", Tag: "Racing", Response: RaceStatsResponse{}},
+
+	// Billing
+	"GET /api/billing/customers":             {Summary: "Usage and cost per customer between start and end (group_by=customer, or model and/or labels, customers, format=json|csv)", Tag: "Billing", Response: BillingReportResponse{}},
+	"GET /api/billing/prompt-cache":          {Summary: "Prompt cache hit rate and savings per provider between start and end (group_by=key,model, providers)", Tag: "Billing", Response: PromptCacheReportResponse{}},
+	"GET /api/billing/stripe/exports":        {Summary: "Most recent Stripe meter event exports (limit)", Tag: "Billing", Response: StripeExportsResponse{}},
+	"POST /api/billing/stripe/export":        {Summary: "Export the usage between start and end to Stripe now (dry_run=true to only compute it)", Tag: "Billing", Response: StripeExportsResponse{}},
+	"GET /api/billing/stripe/reconciliation": {Summary: "Usage in the logs vs usage exported to Stripe per customer and meter between start and end", Tag: "Billing", Response: StripeReconciliationResponse{}},
+
+	// Key leaks
+	"GET /api/governance/key-leaks":         {Summary: "Virtual keys found leaked, newest first, and the last check of the leak feeds", Tag: "Governance", Response: KeyLeaksResponse{}},
+	"POST /api/governance/key-leaks/check":  {Summary: "Check the leak feeds now, suspending and alerting the virtual keys found in them", Tag: "Governance", Response: lib.KeyLeakCheck{}},
+	"POST /api/governance/key-leaks/report": {Summary: "Report secrets found leaked (public, signed with X-Bifrost-Signature: sha256=HMAC of the body with the webhook secret)", Tag: "Governance", Request: KeyLeakReport{}, Response: KeyLeakReportResponse{}},
+
+	// Analytics
+	"GET /api/analytics/latency-matrix": {Summary: "p50, p95 and p99 latency of successful requests per provider, model and hour over the last days (days, providers, models)", Tag: "Analytics", Response: LatencyMatrixResponse{}},
+
+	// Governance
+	"GET /api/governance/virtual-keys":               {Summary: "List virtual keys (paginated)", Tag: "Governance"},
+	"POST /api/governance/virtual-keys":              {Summary: "Create a virtual key", Tag: "Governance", Request: CreateVirtualKeyRequest{}},
+	"GET /api/governance/virtual-keys/{vk_id}":       {Summary: "Get a virtual key", Tag: "Governance"},
+	"PUT /api/governance/virtual-keys/{vk_id}":       {Summary: "Update a virtual key", Tag: "Governance", Request: UpdateVirtualKeyRequest{}},
+	"DELETE /api/governance/virtual-keys/{vk_id}":    {Summary: "Delete a virtual key", Tag: "Governance"},
+	"GET /api/governance/teams":                      {Summary: "List teams (paginated)", Tag: "Governance"},
+	"POST /api/governance/teams":                     {Summary: "Create a team", Tag: "Governance", Request: CreateTeamRequest{}},
+	"GET /api/governance/teams/{team_id}":            {Summary: "Get a team", Tag: "Governance"},
+	"PUT /api/governance/teams/{team_id}":            {Summary: "Update a team", Tag: "Governance", Request: UpdateTeamRequest{}},
+	"DELETE /api/governance/teams/{team_id}":         {Summary: "Delete a team", Tag: "Governance"},
+	"GET /api/governance/projects":                   {Summary: "List projects (paginated)", Tag: "Governance"},
+	"POST /api/governance/projects":                  {Summary: "Create a project", Tag: "Governance", Request: CreateProjectRequest{}},
+	"GET /api/governance/projects/{project_id}":      {Summary: "Get a project", Tag: "Governance"},
+	"PUT /api/governance/projects/{project_id}":      {Summary: "Update a project", Tag: "Governance", Request: UpdateProjectRequest{}},
+	"DELETE /api/governance/projects/{project_id}":   {Summary: "Delete a project", Tag: "Governance"},
+	"GET /api/governance/customers":                  {Summary: "List customers (paginated)", Tag: "Governance"},
+	"POST /api/governance/customers":                 {Summary: "Create a customer", Tag: "Governance", Request: CreateCustomerRequest{}},
+	"GET /api/governance/customers/{customer_id}":    {Summary: "Get a customer", Tag: "Governance"},
+	"PUT /api/governance/customers/{customer_id}":    {Summary: "Update a customer", Tag: "Governance", Request: UpdateCustomerRequest{}},
+	"DELETE /api/governance/customers/{customer_id}": {Summary: "Delete a customer", Tag: "Governance"},
+	"GET /api/governance/usage":                      {Summary: "Get the aggregate usage per customer, team, project and virtual key", Tag: "Governance"},
+	"GET /api/governance/shadow-report":              {Summary: "Get the requests governance rules blocked and the ones shadow rules would have blocked", Tag: "Governance", Response: governance.ShadowReport{}},
+	"DELETE /api/governance/shadow-report":           {Summary: "Start a new governance shadow report", Tag: "Governance"},
+	"DELETE /api/cache/clear/{requestId}":            {Summary: "Clear the semantic cache entries of a request", Tag: "Cache"},
+	"DELETE /api/cache/clear-by-key/{cacheKey}":      {Summary: "Clear the semantic cache entries of a cache key", Tag: "Cache"},
+}
+
+// openAPIExcludedPaths are registered routes left out of the document (UI, websocket and UI proxy routes).
+var openAPIExcludedPaths = map[string]bool{
+	"/":                   true,
+	"/{filepath:*}":       true,
+	"/ws":                 true,
+	"/api/openapi.json":   true,
+	"/api/docs":           true,
+	"/api/proxy/{path:*}": true,
+}
+
+// routeParamRegex matches fasthttp router path parameters, e.g. {name} or {filepath:*}.
+var routeParamRegex = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// OpenAPIHandler serves the OpenAPI document of the routes registered on a router and a Swagger UI page.
+type OpenAPIHandler struct {
+	router *router.Router
+	logger schemas.Logger
+
+	once     sync.Once
+	document []byte
+	err      error
+}
+
+// NewOpenAPIHandler creates a new OpenAPI handler for the routes of r.
+// The document is built on first request, so routes registered after this handler are included.
+func NewOpenAPIHandler(r *router.Router, logger schemas.Logger) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		router: r,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the OpenAPI routes.
+func (h *OpenAPIHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/openapi.json", lib.ChainMiddlewares(h.getDocument, middlewares...))
+	r.GET("/api/docs", lib.ChainMiddlewares(h.getSwaggerUI, middlewares...))
+}
+
+// getDocument handles GET /api/openapi.json - Get the OpenAPI document
+func (h *OpenAPIHandler) getDocument(ctx *fasthttp.RequestCtx) {
+	h.once.Do(func() {
+		h.document, h.err = json.Marshal(buildOpenAPIDocument(h.router.List(), version))
+	})
+	if h.err != nil {
+		h.logger.Error("failed to build OpenAPI document: %v", h.err)
+		SendError(ctx, fasthttp.StatusInternalServerError, "Failed to build OpenAPI document", h.logger)
+		return
+	}
+	ctx.SetContentType("application/json")
+	ctx.SetBody(h.document)
+}
+
+// getSwaggerUI handles GET /api/docs - Swagger UI for the OpenAPI document
+func (h *OpenAPIHandler) getSwaggerUI(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.SetBodyString(swaggerUIPage)
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at the OpenAPI document.
+// Requests from the page carry the admin cookie, so it works behind admin auth.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Bifrost API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// buildOpenAPIDocument builds the OpenAPI document for the given registered routes (method -> paths).
+func buildOpenAPIDocument(routes map[string][]string, apiVersion string) map[string]any {
+	generator := newOpenAPISchemaGenerator()
+	paths := make(map[string]any)
+	tags := make(map[string]bool)
+
+	for method, routePaths := range routes {
+		for _, routePath := range routePaths {
+			if openAPIExcludedPaths[routePath] {
+				continue
+			}
+			path, params := openAPIPath(routePath)
+			operation, documented := openAPIOperations[method+" "+routePath]
+			if !documented {
+				operation = openAPIOperation{Tag: openAPIDefaultTag(routePath)}
+			}
+			tags[operation.Tag] = true
+
+			op := map[string]any{
+				"operationId": openAPIOperationID(method, path),
+				"tags":        []string{operation.Tag},
+				"responses":   openAPIResponses(generator, operation.Response),
+			}
+			if operation.Summary != "" {
+				op["summary"] = operation.Summary
+			}
+			if len(params) > 0 {
+				parameters := make([]map[string]any, 0, len(params))
+				for _, param := range params {
+					parameters = append(parameters, map[string]any{
+						"name":     param,
+						"in":       "path",
+						"required": true,
+						"schema":   map[string]any{"type": "string"},
+					})
+				}
+				op["parameters"] = parameters
+			}
+			if operation.Request != nil {
+				op["requestBody"] = map[string]any{
+					"required": true,
+					"content": map[string]any{
+						"application/json": map[string]any{"schema": generator.schemaFor(reflect.TypeOf(operation.Request))},
+					},
+				}
+			}
+			// Routes are documented with the default public route rules
+			if isPublicPath(nil, method, path) {
+				op["security"] = []any{}
+			}
+
+			item, ok := paths[path].(map[string]any)
+			if !ok {
+				item = make(map[string]any)
+				paths[path] = item
+			}
+			item[strings.ToLower(method)] = op
+		}
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for tag := range tags {
+		tagNames = append(tagNames, tag)
+	}
+	sort.Strings(tagNames)
+	tagList := make([]map[string]any, 0, len(tagNames))
+	for _, tag := range tagNames {
+		tagList = append(tagList, map[string]any{"name": tag})
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "Bifrost API",
+			"description": "Inference and management API of the Bifrost AI gateway.",
+			"version":     apiVersion,
+		},
+		"paths": paths,
+		"tags":  tagList,
+		"components": map[string]any{
+			"schemas": generator.components,
+			"securitySchemes": map[string]any{
+				"adminBearer": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Admin secret, required when admin auth is enabled",
+				},
+			},
+		},
+		"security": []any{map[string]any{"adminBearer": []string{}}},
+	}
+}
+
+// openAPIResponses returns the responses object of an operation.
+func openAPIResponses(generator *openAPISchemaGenerator, response any) map[string]any {
+	success := map[string]any{"description": "Successful response"}
+	if response != nil {
+		success["content"] = map[string]any{
+			"application/json": map[string]any{"schema": generator.schemaFor(reflect.TypeOf(response))},
+		}
+	}
+	errorResponse := map[string]any{
+		"description": "Error response",
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": generator.schemaFor(reflect.TypeOf(schemas.BifrostError{}))},
+		},
+	}
+	return map[string]any{
+		"200":     success,
+		"default": errorResponse,
+	}
+}
+
+// openAPIPath converts a router path to an OpenAPI path and returns its parameter names.
+func openAPIPath(routePath string) (string, []string) {
+	var params []string
+	path := routeParamRegex.ReplaceAllStringFunc(routePath, func(match string) string {
+		name := routeParamRegex.FindStringSubmatch(match)[1]
+		params = append(params, name)
+		return "{" + name + "}"
+	})
+	return path, params
+}
+
+// openAPIOperationID derives a unique operation ID from method and path, e.g. get_api_providers_provider.
+func openAPIOperationID(method string, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.' || r == ':'
+	}) {
+		id += "_" + segment
+	}
+	return id
+}
+
+// openAPIDefaultTag groups undocumented routes by their first path segment (integrations use their name, e.g. openai).
+func openAPIDefaultTag(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(segments) >= 2 && segments[0] == "api":
+		return segments[1]
+	case segments[0] == "":
+		return "Other"
+	default:
+		return segments[0]
+	}
+}
+
+// openAPISchemaGenerator derives JSON schemas from Go types. Named struct types become components.
+type openAPISchemaGenerator struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+// newOpenAPISchemaGenerator creates an empty schema generator.
+func newOpenAPISchemaGenerator() *openAPISchemaGenerator {
+	return &openAPISchemaGenerator{
+		components: make(map[string]any),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaFor returns the schema of t, a $ref for named struct types.
+func (g *openAPISchemaGenerator) schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		// Custom JSON encodings (unions) cannot be derived from the struct fields
+		return map[string]any{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + g.componentName(t)}
+	default:
+		return map[string]any{}
+	}
+}
+
+// componentName registers a named struct type as a component and returns its name.
+// Types with the same name in different packages are prefixed with their package name.
+func (g *openAPISchemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.components[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+	}
+	g.names[t] = name
+	g.components[name] = map[string]any{} // Placeholder for recursive types
+	g.components[name] = g.structSchema(t)
+	return name
+}
+
+// structSchema returns the object schema of a struct type, following encoding/json field rules.
+func (g *openAPISchemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	g.addStructFields(t, properties, &required)
+	schema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addStructFields adds the JSON fields of t, including promoted fields of embedded structs.
+// Fields without omitempty are listed as required.
+func (g *openAPISchemaGenerator) addStructFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.addStructFields(fieldType, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
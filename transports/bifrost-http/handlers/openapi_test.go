@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/fasthttp/router"
+	"github.com/valyala/fasthttp"
+)
+
+// TestBuildOpenAPIDocument tests that registered routes are documented with path parameters, schemas and security
+func TestBuildOpenAPIDocument(t *testing.T) {
+	r := router.New()
+	noop := func(ctx *fasthttp.RequestCtx) {}
+	r.POST("/v1/chat/completions", noop)
+	r.GET("/api/providers/{provider}", noop)
+	r.PUT("/api/logging/level", noop)
+	r.GET("/{filepath:*}", noop)
+
+	document := buildOpenAPIDocument(r.List(), "v1.2.3")
+	data, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("Expected document to marshal: %v", err)
+	}
+	var doc struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Expected document to unmarshal: %v", err)
+	}
+
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("Expected OpenAPI 3.1.0, got %s", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/{filepath}"]; ok {
+		t.Error("Expected the UI catch-all route to be excluded")
+	}
+
+	chat, ok := doc.Paths["/v1/chat/completions"]["post"]
+	if !ok {
+		t.Fatal("Expected POST /v1/chat/completions to be documented")
+	}
+	if security, ok := chat["security"].([]any); !ok || len(security) != 0 {
+		t.Errorf("Expected the public inference route to override security, got %v", chat["security"])
+	}
+	messages := doc.Components.Schemas["ChatRequest"].Properties
+	for _, field := range []string{"messages", "model", "fallbacks", "temperature"} {
+		if _, ok := messages[field]; !ok {
+			t.Errorf("Expected ChatRequest schema to have field %q (including embedded structs)", field)
+		}
+	}
+
+	provider := doc.Paths["/api/providers/{provider}"]["get"]
+	if _, ok := provider["security"]; ok {
+		t.Error("Expected management routes to use the default admin security")
+	}
+	if params, ok := provider["parameters"].([]any); !ok || len(params) != 1 {
+		t.Errorf("Expected one path parameter, got %v", provider["parameters"])
+	}
+}
+
+// TestOpenAPISchemaGenerator_Recursive tests that recursive types are emitted as component references
+func TestOpenAPISchemaGenerator_Recursive(t *testing.T) {
+	type node struct {
+		Name     string  `json:"name"`
+		Children []*node `json:"children,omitempty"`
+	}
+	generator := newOpenAPISchemaGenerator()
+	schema := generator.schemaFor(reflect.TypeOf(node{}))
+	if schema["$ref"] != "#/components/schemas/node" {
+		t.Fatalf("Expected a component reference, got %v", schema)
+	}
+	component := generator.components["node"].(map[string]any)
+	if required := component["required"].([]string); len(required) != 1 || required[0] != "name" {
+		t.Errorf("Expected only name to be required, got %v", required)
+	}
+}
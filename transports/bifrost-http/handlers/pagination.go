@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/valyala/fasthttp"
+)
+
+// maxListLimit is the largest page size accepted by list endpoints.
+const maxListLimit = 1000
+
+// Response headers set by list endpoints that return a bare array.
+const (
+	HeaderTotalCount = "X-Total-Count"
+	HeaderNextCursor = "X-Next-Cursor"
+)
+
+// listField exposes one field of a list item for filtering and sorting.
+type listField[T any] struct {
+	value   func(item T) string // Value used for filters and cursors
+	numeric bool                // Sort numerically instead of lexically
+}
+
+// listSpec describes the filterable and sortable fields of a list endpoint.
+type listSpec[T any] struct {
+	id          func(item T) string // Unique ID, breaks ties between equal sort values
+	fields      map[string]listField[T]
+	defaultSort string
+}
+
+// listQuery holds the parsed list query parameters.
+type listQuery struct {
+	limit   int // 0 means no limit
+	cursor  *listCursor
+	sortBy  string
+	desc    bool
+	filters map[string][]string
+}
+
+// listCursor is the decoded form of a cursor.
+type listCursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Value  string `json:"v"`
+	ID     string `json:"i"`
+}
+
+// listPage is one page of a list.
+type listPage[T any] struct {
+	items      []T
+	total      int    // Number of items matching the filters
+	nextCursor string // Empty on the last page
+}
+
+// parseListQuery parses the list query parameters of a request against spec.
+func parseListQuery[T any](ctx *fasthttp.RequestCtx, spec *listSpec[T]) (*listQuery, error) {
+	args := ctx.QueryArgs()
+	query := &listQuery{
+		sortBy:  spec.defaultSort,
+		filters: make(map[string][]string),
+	}
+
+	if limit := string(args.Peek("limit")); limit != "" {
+		i, err := strconv.Atoi(limit)
+		if err != nil || i <= 0 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		if i > maxListLimit {
+			return nil, fmt.Errorf("limit cannot exceed %d", maxListLimit)
+		}
+		query.limit = i
+	}
+
+	if sortBy := string(args.Peek("sort_by")); sortBy != "" {
+		if _, ok := spec.fields[sortBy]; !ok {
+			return nil, fmt.Errorf("cannot sort by %q, supported fields: %s", sortBy, strings.Join(spec.fieldNames(), ", "))
+		}
+		query.sortBy = sortBy
+	}
+
+	switch order := string(args.Peek("order")); order {
+	case "", "asc":
+	case "desc":
+		query.desc = true
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if cursor := string(args.Peek("cursor")); cursor != "" {
+		decoded, err := decodeListCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.SortBy != query.sortBy || decoded.Desc != query.desc {
+			return nil, fmt.Errorf("cursor does not match sort_by and order")
+		}
+		query.cursor = decoded
+	}
+
+	for name := range spec.fields {
+		if values := string(args.Peek(name)); values != "" {
+			query.filters[name] = parseCommaSeparated(values)
+		}
+	}
+
+	return query, nil
+}
+
+// paginate filters, sorts and pages items according to query.
+func paginate[T any](items []T, spec *listSpec[T], query *listQuery) *listPage[T] {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if spec.matches(item, query.filters) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	sortField := spec.fields[query.sortBy]
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return spec.before(sortField, query.desc, filtered[i], filtered[j])
+	})
+
+	page := &listPage[T]{total: len(filtered)}
+	start := 0
+	if query.cursor != nil {
+		start = sort.Search(len(filtered), func(i int) bool {
+			return spec.afterCursor(sortField, query.desc, filtered[i], query.cursor)
+		})
+	}
+	end := len(filtered)
+	if query.limit > 0 && start+query.limit < end {
+		end = start + query.limit
+	}
+	page.items = filtered[start:end]
+
+	if end < len(filtered) && end > start {
+		last := filtered[end-1]
+		page.nextCursor = encodeListCursor(&listCursor{
+			SortBy: query.sortBy,
+			Desc:   query.desc,
+			Value:  sortField.value(last),
+			ID:     spec.id(last),
+		})
+	}
+	return page
+}
+
+// sendListPage sends a page as a JSON object: the items under key, plus count, total and next_cursor.
+func sendListPage[T any](ctx *fasthttp.RequestCtx, key string, page *listPage[T], logger schemas.Logger) {
+	response := map[string]any{
+		key:     page.items,
+		"count": len(page.items),
+		"total": page.total,
+	}
+	if page.nextCursor != "" {
+		response["next_cursor"] = page.nextCursor
+	}
+	SendJSON(ctx, response, logger)
+}
+
+// setListPageHeaders reports total and next cursor of a page as headers, for endpoints that return a bare array.
+func setListPageHeaders[T any](ctx *fasthttp.RequestCtx, page *listPage[T]) {
+	ctx.Response.Header.Set(HeaderTotalCount, strconv.Itoa(page.total))
+	if page.nextCursor != "" {
+		ctx.Response.Header.Set(HeaderNextCursor, page.nextCursor)
+	}
+}
+
+// fieldNames returns the sorted field names of a spec.
+func (s *listSpec[T]) fieldNames() []string {
+	names := make([]string, 0, len(s.fields))
+	for name := range s.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matches reports whether an item passes all filters.
+func (s *listSpec[T]) matches(item T, filters map[string][]string) bool {
+	for name, values := range filters {
+		value := s.fields[name].value(item)
+		found := false
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// before reports whether a sorts before b.
+func (s *listSpec[T]) before(field listField[T], desc bool, a, b T) bool {
+	return compareListKeys(field, desc, field.value(a), s.id(a), field.value(b), s.id(b)) < 0
+}
+
+// afterCursor reports whether an item sorts after the cursor position.
+func (s *listSpec[T]) afterCursor(field listField[T], desc bool, item T, cursor *listCursor) bool {
+	return compareListKeys(field, desc, field.value(item), s.id(item), cursor.Value, cursor.ID) > 0
+}
+
+// compareListKeys compares two (sort value, ID) keys in list order. IDs always sort ascending.
+func compareListKeys[T any](field listField[T], desc bool, valueA, idA, valueB, idB string) int {
+	c := compareListValues(field.numeric, valueA, valueB)
+	if desc {
+		c = -c
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(idA, idB)
+}
+
+// compareListValues compares two field values, numerically for numeric fields.
+func compareListValues(numeric bool, a, b string) int {
+	if numeric {
+		ia, errA := strconv.ParseInt(a, 10, 64)
+		ib, errB := strconv.ParseInt(b, 10, 64)
+		if errA == nil && errB == nil {
+			return cmp.Compare(ia, ib)
+		}
+		fa, errA := strconv.ParseFloat(a, 64)
+		fb, errB := strconv.ParseFloat(b, 64)
+		if errA == nil && errB == nil {
+			return cmp.Compare(fa, fb)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// timeListValue renders a timestamp as a numeric list value.
+func timeListValue(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// optionalListValue renders an optional field as a list value, empty when unset.
+func optionalListValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// encodeListCursor encodes a cursor as an opaque URL-safe string.
+func encodeListCursor(cursor *listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor decodes a cursor produced by encodeListCursor.
+func decodeListCursor(s string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
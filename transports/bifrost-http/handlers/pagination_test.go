@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+// testListItem is a list item used by the pagination tests
+type testListItem struct {
+	id    string
+	group string
+	size  int
+}
+
+var testListSpec = &listSpec[testListItem]{
+	id: func(item testListItem) string { return item.id },
+	fields: map[string]listField[testListItem]{
+		"group": {value: func(item testListItem) string { return item.group }},
+		"size":  {value: func(item testListItem) string { return strconv.Itoa(item.size) }, numeric: true},
+	},
+	defaultSort: "size",
+}
+
+// parseTestListQuery parses the list query of a request to /items?<query>
+func parseTestListQuery(t *testing.T, query string) *listQuery {
+	t.Helper()
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/items?" + query)
+	parsed, err := parseListQuery(ctx, testListSpec)
+	if err != nil {
+		t.Fatalf("Expected query %q to parse, got %v", query, err)
+	}
+	return parsed
+}
+
+// pageIDs returns the IDs of the items of a page
+func pageIDs(page *listPage[testListItem]) []string {
+	ids := make([]string, 0, len(page.items))
+	for _, item := range page.items {
+		ids = append(ids, item.id)
+	}
+	return ids
+}
+
+// TestPaginate_CursorWalk tests that following next_cursor visits every item once in order, even when items change between pages
+func TestPaginate_CursorWalk(t *testing.T) {
+	items := []testListItem{
+		{id: "a", group: "x", size: 10},
+		{id: "b", group: "y", size: 2},
+		{id: "c", group: "x", size: 2},
+		{id: "d", group: "y", size: 30},
+		{id: "e", group: "x", size: 5},
+	}
+
+	page := paginate(items, testListSpec, parseTestListQuery(t, "limit=2"))
+	if got := pageIDs(page); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Fatalf("Expected first page [b c], got %v", got)
+	}
+	if page.total != 5 || page.nextCursor == "" {
+		t.Fatalf("Expected total 5 and a next cursor, got %d %q", page.total, page.nextCursor)
+	}
+
+	// Deleting an already returned item must not shift the next page
+	items = items[:1:1]
+	items = append(items, testListItem{id: "d", group: "y", size: 30}, testListItem{id: "e", group: "x", size: 5})
+	page = paginate(items, testListSpec, parseTestListQuery(t, "limit=2&cursor="+page.nextCursor))
+	if got := pageIDs(page); len(got) != 2 || got[0] != "e" || got[1] != "a" {
+		t.Fatalf("Expected second page [e a], got %v", got)
+	}
+
+	page = paginate(items, testListSpec, parseTestListQuery(t, "limit=2&cursor="+page.nextCursor))
+	if got := pageIDs(page); len(got) != 1 || got[0] != "d" || page.nextCursor != "" {
+		t.Fatalf("Expected last page [d] without a cursor, got %v %q", got, page.nextCursor)
+	}
+}
+
+// TestPaginate_FilterAndOrder tests field filters and descending numeric order
+func TestPaginate_FilterAndOrder(t *testing.T) {
+	items := []testListItem{
+		{id: "a", group: "x", size: 10},
+		{id: "b", group: "y", size: 2},
+		{id: "c", group: "x", size: 9},
+	}
+
+	page := paginate(items, testListSpec, parseTestListQuery(t, "group=x&sort_by=size&order=desc"))
+	if got := pageIDs(page); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("Expected [a c], got %v", got)
+	}
+	if page.total != 2 {
+		t.Errorf("Expected total 2, got %d", page.total)
+	}
+}
+
+// TestParseListQuery_Invalid tests that invalid list parameters are rejected
+func TestParseListQuery_Invalid(t *testing.T) {
+	cursor := encodeListCursor(&listCursor{SortBy: "size", Value: "1", ID: "a"})
+	for _, query := range []string{
+		"limit=0",
+		"limit=1001",
+		"sort_by=unknown",
+		"order=sideways",
+		"cursor=not-a-cursor",
+		"order=desc&cursor=" + cursor,
+	} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/items?" + query)
+		if _, err := parseListQuery(ctx, testListSpec); err == nil {
+			t.Errorf("Expected query %q to be rejected", query)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+)
+
+// TestParameterGuardrails tests that hard guardrails reject requests exceeding the limits of their virtual key with
+// a descriptive 400 and that soft guardrails clamp them
+func TestParameterGuardrails(t *testing.T) {
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	plugin, err := governance.Init(context.Background(), nil, logger, nil, &configstore.GovernanceConfig{
+		VirtualKeys: []configstore.TableVirtualKey{
+			{ID: "hard", Value: "sk-bf-hard", IsActive: true, ParameterGuardrails: &configstore.ParameterGuardrails{
+				MaxTemperature: bifrost.Ptr(1.0), MaxTokens: bifrost.Ptr(512), DisallowTools: true, ResponseFormat: "json_object",
+			}},
+			{ID: "soft", Value: "sk-bf-soft", IsActive: true, ParameterGuardrails: &configstore.ParameterGuardrails{
+				Mode: configstore.GuardrailModeSoft, MaxTemperature: bifrost.Ptr(1.0), MaxTokens: bifrost.Ptr(512), DisallowTools: true, ResponseFormat: "json_object",
+			}},
+		},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to init governance: %v", err)
+	}
+
+	chatRequest := func(params *schemas.ChatParameters) *schemas.BifrostRequest {
+		return &schemas.BifrostRequest{
+			Provider:    schemas.OpenAI,
+			Model:       "gpt-4o-mini",
+			RequestType: schemas.ChatCompletionRequest,
+			ChatRequest: &schemas.BifrostChatRequest{
+				Provider: schemas.OpenAI,
+				Model:    "gpt-4o-mini",
+				Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("hi")}}},
+				Params:   params,
+			},
+		}
+	}
+	tools := []schemas.ChatTool{{Type: schemas.ChatToolTypeFunction, Function: &schemas.ChatToolFunction{Name: "lookup"}}}
+	var jsonSchema any = map[string]any{"type": "json_schema"}
+
+	hardTests := []struct {
+		name    string
+		params  *schemas.ChatParameters
+		message string
+	}{
+		{"temperature", &schemas.ChatParameters{Temperature: bifrost.Ptr(1.5)}, "temperature 1.5 exceeds the maximum of 1 allowed for this virtual key"},
+		{"max tokens", &schemas.ChatParameters{MaxCompletionTokens: bifrost.Ptr(4096)}, "max_completion_tokens 4096 exceeds the maximum of 512 allowed for this virtual key"},
+		{"tools", &schemas.ChatParameters{Tools: tools}, "tools are not allowed for this virtual key"},
+		{"response format", &schemas.ChatParameters{ResponseFormat: &jsonSchema}, `response_format "json_schema" is not allowed for this virtual key, which requires "json_object"`},
+	}
+	for _, tt := range hardTests {
+		t.Run("hard "+tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "sk-bf-hard")
+			_, shortCircuit, err := plugin.PreHook(&ctx, chatRequest(tt.params))
+			if err != nil {
+				t.Fatalf("PreHook failed: %v", err)
+			}
+			if shortCircuit == nil || shortCircuit.Error == nil {
+				t.Fatal("expected the request to be rejected")
+			}
+			if *shortCircuit.Error.StatusCode != 400 || shortCircuit.Error.Error.Message != tt.message {
+				t.Errorf("error = %d %q, want 400 %q", *shortCircuit.Error.StatusCode, shortCircuit.Error.Error.Message, tt.message)
+			}
+		})
+	}
+
+	t.Run("hard within limits", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "sk-bf-hard")
+		req, shortCircuit, _ := plugin.PreHook(&ctx, chatRequest(&schemas.ChatParameters{Temperature: bifrost.Ptr(0.2)}))
+		if shortCircuit != nil {
+			t.Fatalf("unexpected rejection: %s", shortCircuit.Error.Error.Message)
+		}
+		params := req.ChatRequest.Params
+		if params.MaxCompletionTokens == nil || *params.MaxCompletionTokens != 512 {
+			t.Errorf("max_completion_tokens = %v, want the limit", params.MaxCompletionTokens)
+		}
+		if format, _ := (*params.ResponseFormat).(map[string]any); format["type"] != "json_object" {
+			t.Errorf("response_format = %v, want json_object", format)
+		}
+	})
+
+	t.Run("soft clamps", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "sk-bf-soft")
+		req, shortCircuit, _ := plugin.PreHook(&ctx, chatRequest(&schemas.ChatParameters{
+			Temperature:         bifrost.Ptr(1.5),
+			MaxCompletionTokens: bifrost.Ptr(4096),
+			Tools:               tools,
+			ResponseFormat:      &jsonSchema,
+		}))
+		if shortCircuit != nil {
+			t.Fatalf("unexpected rejection: %s", shortCircuit.Error.Error.Message)
+		}
+		params := req.ChatRequest.Params
+		if *params.Temperature != 1 || *params.MaxCompletionTokens != 512 || params.Tools != nil {
+			t.Errorf("params = temperature %v, max_completion_tokens %v, tools %v; want them clamped", *params.Temperature, *params.MaxCompletionTokens, params.Tools)
+		}
+		if format, _ := (*params.ResponseFormat).(map[string]any); format["type"] != "json_object" {
+			t.Errorf("response_format = %v, want json_object", format)
+		}
+	})
+}
+
+// TestParameterGuardrailsValidate tests that invalid guardrails are rejected
+func TestParameterGuardrailsValidate(t *testing.T) {
+	for _, guardrails := range []configstore.ParameterGuardrails{
+		{Mode: "strict"},
+		{MaxTemperature: bifrost.Ptr(-1.0)},
+		{MaxTokens: bifrost.Ptr(0)},
+		{ResponseFormat: "json_schema"},
+	} {
+		if err := guardrails.Validate(); err == nil {
+			t.Errorf("%+v: expected a validation error", guardrails)
+		}
+	}
+}
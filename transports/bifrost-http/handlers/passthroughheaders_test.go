@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// passthroughTestAccount configures an OpenAI provider reaching a test server and forwarding tracing headers
+type passthroughTestAccount struct {
+	baseURL string
+}
+
+func (passthroughTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{schemas.OpenAI}, nil
+}
+
+func (passthroughTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	return []schemas.Key{{ID: "test", Value: "sk-upstream", Weight: 1}}, nil
+}
+
+func (a passthroughTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	if providerKey != schemas.OpenAI {
+		return nil, fmt.Errorf("provider %s is not configured", providerKey)
+	}
+	config := &schemas.ProviderConfig{
+		NetworkConfig:            schemas.DefaultNetworkConfig,
+		ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+	}
+	config.NetworkConfig.BaseURL = a.baseURL
+	config.NetworkConfig.ExtraHeaders = map[string]string{"x-trace-id": "set-by-bifrost"}
+	config.NetworkConfig.PassthroughHeaders = &schemas.ForwardingPolicy{
+		Allow:     []string{"OpenAI-Beta", "x-trace-*"},
+		Sensitive: []string{"x-trace-token"},
+	}
+	return config, nil
+}
+
+// passthroughRecorder collects the upstream exchanges of a request
+type passthroughRecorder struct {
+	mu        sync.Mutex
+	exchanges []*schemas.UpstreamExchange
+}
+
+func (r *passthroughRecorder) RecordUpstream(ctx context.Context, exchange *schemas.UpstreamExchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exchanges = append(r.exchanges, exchange)
+}
+
+// TestPassthroughHeaders tests that only the inbound headers allowed by the provider policy are forwarded, without
+// replacing the headers Bifrost sets, and that sensitive values are redacted in upstream recordings
+func TestPassthroughHeaders(t *testing.T) {
+	received := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o-mini\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: passthroughTestAccount{baseURL: server.URL},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+
+	requestCtx := &fasthttp.RequestCtx{}
+	requestCtx.Request.Header.Set("OpenAI-Beta", "assistants=v2")
+	requestCtx.Request.Header.Set("X-Trace-Id", "client-trace")
+	requestCtx.Request.Header.Set("X-Trace-Token", "secret-token")
+	requestCtx.Request.Header.Set("X-Tenant", "acme")
+	requestCtx.Request.Header.Set("Authorization", "Bearer sk-client")
+	bifrostCtx := lib.ConvertToBifrostContext(requestCtx, false)
+	recorder := &passthroughRecorder{}
+	ctx := context.WithValue(*bifrostCtx, schemas.BifrostContextKeyUpstreamRecorder, recorder)
+
+	req := &schemas.BifrostChatRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o-mini",
+		Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Hello")}}},
+	}
+	if _, bifrostErr := client.ChatCompletionRequest(ctx, req); bifrostErr != nil {
+		t.Fatalf("chat completion failed: %v", bifrostErr.Error.Message)
+	}
+	stream, bifrostErr := client.ChatCompletionStreamRequest(ctx, req)
+	if bifrostErr != nil {
+		t.Fatalf("chat completion stream failed: %v", bifrostErr.Error.Message)
+	}
+	for range stream {
+	}
+
+	for _, mode := range []string{"request", "stream"} {
+		headers := <-received
+		for name, want := range map[string]string{
+			"Openai-Beta":   "assistants=v2",
+			"X-Trace-Token": "secret-token",
+			"X-Trace-Id":    "set-by-bifrost",
+			"X-Tenant":      "",
+			"Authorization": "Bearer sk-upstream",
+		} {
+			if got := headers.Get(name); got != want {
+				t.Errorf("%s: upstream %s = %q, want %q", mode, name, got, want)
+			}
+		}
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.exchanges) == 0 {
+		t.Fatal("expected the upstream request to be recorded")
+	}
+	for name, value := range recorder.exchanges[0].RequestHeaders {
+		if value == "secret-token" {
+			t.Errorf("recorded header %s leaks the sensitive value", name)
+		}
+	}
+}
+
+// TestValidateNetworkConfig_PassthroughHeaders tests that policies forwarding credentials or Bifrost headers are rejected
+func TestValidateNetworkConfig_PassthroughHeaders(t *testing.T) {
+	for _, policy := range []schemas.ForwardingPolicy{
+		{Allow: []string{"Authorization"}},
+		{Allow: []string{"x-bf-vk"}},
+		{Allow: []string{"x trace"}},
+		{Allow: []string{"x-trace-id"}, Sensitive: []string{""}},
+	} {
+		config := configstore.ProviderConfig{NetworkConfig: &schemas.NetworkConfig{PassthroughHeaders: &policy}}
+		if err := lib.ValidateNetworkConfig(config); err == nil {
+			t.Errorf("%+v: expected a validation error", policy)
+		}
+	}
+	config := configstore.ProviderConfig{NetworkConfig: &schemas.NetworkConfig{PassthroughHeaders: &schemas.ForwardingPolicy{Allow: []string{"OpenAI-Beta", "x-trace-*"}}}}
+	if err := lib.ValidateNetworkConfig(config); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/providers/openai"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/integrations"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// passthroughParamsTestAccount configures an OpenAI provider reaching a test server and forwarding vendor parameters
+type passthroughParamsTestAccount struct {
+	passthroughTestAccount
+}
+
+func (a passthroughParamsTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	config, err := a.passthroughTestAccount.GetConfigForProvider(providerKey)
+	if err != nil {
+		return nil, err
+	}
+	config.NetworkConfig.PassthroughParams = &schemas.ParamForwardingPolicy{Allow: []string{"top_k", "vendor_*", "temperature"}}
+	return config, nil
+}
+
+// TestPassthroughParams tests that the extra parameters of an OpenAI request, at the top level or in extra_body,
+// reach the provider when its policy allows them, without replacing the parameters Bifrost sets
+func TestPassthroughParams(t *testing.T) {
+	received := make(chan map[string]any, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		received <- body
+		if r.Header.Get("Accept") == "text/event-stream" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o-mini\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: passthroughParamsTestAccount{passthroughTestAccount{baseURL: server.URL}},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+
+	for _, stream := range []bool{false, true} {
+		requestCtx := &fasthttp.RequestCtx{}
+		requestCtx.Request.SetBody([]byte(fmt.Sprintf(`{"model": "openai/gpt-4o-mini", "messages": [{"role": "user", "content": "Hello"}], "temperature": 0.5, "stream": %t,
+			"top_k": 40, "safe_prompt": true, "extra_body": {"vendor_cache": {"ttl": 60}, "top_k": 1}}`, stream)))
+		req := &openai.OpenAIChatRequest{}
+		if err := json.Unmarshal(requestCtx.Request.Body(), req); err != nil {
+			t.Fatalf("failed to decode: %v", err)
+		}
+		if err := integrations.ExtraParamsPreHook(nil)(requestCtx, req); err != nil {
+			t.Fatalf("pre hook failed: %v", err)
+		}
+		chatReq := req.ToBifrostRequest()
+		bifrostCtx := lib.ConvertToBifrostContext(requestCtx, false)
+		if stream {
+			chunks, bifrostErr := client.ChatCompletionStreamRequest(*bifrostCtx, chatReq)
+			if bifrostErr != nil {
+				t.Fatalf("chat completion stream failed: %v", bifrostErr.Error.Message)
+			}
+			for range chunks {
+			}
+		} else if _, bifrostErr := client.ChatCompletionRequest(*bifrostCtx, chatReq); bifrostErr != nil {
+			t.Fatalf("chat completion failed: %v", bifrostErr.Error.Message)
+		}
+
+		body := <-received
+		if body["top_k"] != float64(40) || body["temperature"] != 0.5 {
+			t.Errorf("stream %t: expected top_k 40 and temperature 0.5, got %v and %v", stream, body["top_k"], body["temperature"])
+		}
+		if cache, ok := body["vendor_cache"].(map[string]any); !ok || cache["ttl"] != float64(60) {
+			t.Errorf("stream %t: expected vendor_cache from extra_body, got %v", stream, body["vendor_cache"])
+		}
+		for _, name := range []string{"safe_prompt", "extra_body"} {
+			if _, ok := body[name]; ok {
+				t.Errorf("stream %t: %s is not allowed but was forwarded", stream, name)
+			}
+		}
+	}
+
+	for _, body := range []string{
+		`{"model": "gpt-4o-mini", "messages": [], "extra_body": "top_k=1"}`,
+		`{"model": "gpt-4o-mini", "messages": [], "extra_body": {"temperature": 1}}`,
+	} {
+		requestCtx := &fasthttp.RequestCtx{}
+		requestCtx.Request.SetBody([]byte(body))
+		if err := integrations.ExtraParamsPreHook(nil)(requestCtx, &openai.OpenAIChatRequest{}); err == nil {
+			t.Errorf("%s: expected extra_body to be rejected", body)
+		}
+	}
+}
+
+// TestValidateNetworkConfig_PassthroughParams tests that policies forwarding the parameters Bifrost sets are rejected
+func TestValidateNetworkConfig_PassthroughParams(t *testing.T) {
+	for _, policy := range []schemas.ParamForwardingPolicy{
+		{Allow: []string{"model"}},
+		{Allow: []string{"messages"}},
+		{Allow: []string{"top k"}},
+		{Allow: []string{""}},
+	} {
+		config := configstore.ProviderConfig{NetworkConfig: &schemas.NetworkConfig{PassthroughParams: &policy}}
+		if err := lib.ValidateNetworkConfig(config); err == nil {
+			t.Errorf("%+v: expected a validation error", policy)
+		}
+	}
+	config := configstore.ProviderConfig{NetworkConfig: &schemas.NetworkConfig{PassthroughParams: &schemas.ParamForwardingPolicy{Allow: []string{"top_k", "vendor_*"}}}}
+	if err := lib.ValidateNetworkConfig(config); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
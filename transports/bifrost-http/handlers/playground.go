@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/tokenizer"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// PlaygroundChatRequest is the body of POST /api/playground/chat.
+type PlaygroundChatRequest struct {
+	Provider schemas.ModelProvider   `json:"provider"`
+	Model    string                  `json:"model"`
+	KeyID    string                  `json:"key_id,omitempty"` // Key of the provider to use, else the key is selected as usual
+	Messages []schemas.ChatMessage   `json:"messages"`
+	Params   *schemas.ChatParameters `json:"params,omitempty"`
+	MaxCost  *float64                `json:"max_cost,omitempty"` // Lowers the cost cap of the playground for this request
+}
+
+// PlaygroundChatResponse is the response of POST /api/playground/chat. Errors of the pipeline are returned along
+// with the trace rather than as an error response.
+type PlaygroundChatResponse struct {
+	Response      *schemas.BifrostResponse      `json:"response,omitempty"`
+	Error         *schemas.BifrostError         `json:"error,omitempty"`
+	MaxTokens     int                           `json:"max_tokens"`               // Completion token limit the request was sent with
+	EstimatedCost float64                       `json:"estimated_cost,omitempty"` // Worst-case cost checked against the cap
+	Cost          float64                       `json:"cost,omitempty"`           // Cost of the response
+	Trace         schemas.PipelineTraceSnapshot `json:"trace"`
+}
+
+// PlaygroundHandler sends test prompts of admins through the full pipeline with a cost cap.
+type PlaygroundHandler struct {
+	client *bifrost.Bifrost
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// NewPlaygroundHandler creates a new playground handler.
+func NewPlaygroundHandler(client *bifrost.Bifrost, config *lib.Config, logger schemas.Logger) *PlaygroundHandler {
+	return &PlaygroundHandler{
+		client: client,
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the playground routes.
+func (h *PlaygroundHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.POST("/api/playground/chat", lib.ChainMiddlewares(h.chat, middlewares...))
+}
+
+// chat handles POST /api/playground/chat - Send a chat request through the plugins and the provider, with an
+// optional provider key, and return the response with the trace of the plugin hooks, the provider attempts and
+// the HTTP exchanges with the provider. The completion is capped at the token limit of the playground, and the
+// request is refused when its worst-case cost exceeds the cost cap.
+func (h *PlaygroundHandler) chat(ctx *fasthttp.RequestCtx) {
+	if !h.config.Playground.IsEnabled() {
+		SendError(ctx, fasthttp.StatusNotFound, "the playground is not enabled", h.logger)
+		return
+	}
+	var req PlaygroundChatRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Provider == "" || req.Model == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "provider and model are required", h.logger)
+		return
+	}
+	if len(req.Messages) == 0 {
+		SendError(ctx, fasthttp.StatusBadRequest, "messages are required", h.logger)
+		return
+	}
+	providerConfig, err := h.config.GetProviderConfigRaw(req.Provider)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Provider not found: %v", err), h.logger)
+		return
+	}
+	var key *schemas.Key
+	if req.KeyID != "" {
+		i := slices.IndexFunc(providerConfig.Keys, func(k schemas.Key) bool { return k.ID == req.KeyID })
+		if i < 0 {
+			SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Key %s not found", req.KeyID), h.logger)
+			return
+		}
+		key = &providerConfig.Keys[i]
+	}
+
+	params := &schemas.ChatParameters{}
+	if req.Params != nil {
+		copied := *req.Params
+		params = &copied
+	}
+	maxTokens := h.config.Playground.TokenLimit()
+	if params.MaxCompletionTokens == nil || *params.MaxCompletionTokens <= 0 || *params.MaxCompletionTokens > maxTokens {
+		params.MaxCompletionTokens = bifrost.Ptr(maxTokens)
+	}
+	maxCost := h.config.Playground.MaxCost()
+	if req.MaxCost != nil && *req.MaxCost >= 0 && *req.MaxCost < maxCost {
+		maxCost = *req.MaxCost
+	}
+
+	response := PlaygroundChatResponse{MaxTokens: *params.MaxCompletionTokens}
+	response.EstimatedCost = h.estimateCost(req.Provider, req.Model, req.Messages, *params.MaxCompletionTokens)
+	if response.EstimatedCost == 0 && !h.config.Playground.AllowsUnpricedModels() {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("no pricing is known for %s/%s, so the cost cap cannot be enforced", req.Provider, req.Model), h.logger)
+		return
+	}
+	if response.EstimatedCost > maxCost {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("the request may cost up to $%.4f, over the playground cap of $%.4f; lower max_completion_tokens or shorten the prompt", response.EstimatedCost, maxCost), h.logger)
+		return
+	}
+
+	bifrostCtx := lib.ConvertToBifrostContext(ctx, false)
+	if bifrostCtx == nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, "Failed to convert context", h.logger)
+		return
+	}
+	trace := &schemas.PipelineTrace{}
+	requestCtx := context.WithValue(*bifrostCtx, schemas.BifrostContextKeyPipelineTrace, trace)
+	if key != nil {
+		requestCtx = context.WithValue(requestCtx, schemas.BifrostContextKeyDirectKey, *key)
+	}
+	response.Response, response.Error = h.client.ChatCompletionRequest(requestCtx, &schemas.BifrostChatRequest{
+		Provider: req.Provider,
+		Model:    req.Model,
+		Input:    req.Messages,
+		Params:   params,
+	})
+	if response.Response != nil && h.config.PricingManager != nil {
+		response.Cost = h.config.PricingManager.CalculateCost(response.Response)
+	}
+	response.Trace = trace.Snapshot()
+	SendJSON(ctx, response, h.logger)
+}
+
+// estimateCost returns the worst-case cost of a chat request: its estimated prompt tokens and the maximum
+// completion. It returns 0 when the model has no pricing.
+func (h *PlaygroundHandler) estimateCost(provider schemas.ModelProvider, model string, messages []schemas.ChatMessage, maxTokens int) float64 {
+	if h.config.PricingManager == nil {
+		return 0
+	}
+	promptTokens := tokenizer.EstimateMessages(messages)
+	return h.config.PricingManager.CalculateCostFromUsage(string(provider), model, &schemas.LLMUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: maxTokens,
+		TotalTokens:      promptTokens + maxTokens,
+	}, schemas.ChatCompletionRequest, false, false, nil, nil)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestPlayground_Chat tests that playground requests use the selected key with the completion capped, and return
+// the trace of the plugin hooks, the provider attempt and the upstream exchange with credentials redacted
+func TestPlayground_Chat(t *testing.T) {
+	var upstreamBodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamBodies = append(upstreamBodies, r.Header.Get("Authorization")+" "+string(body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	}))
+	defer server.Close()
+
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	lib.SetLogger(logger)
+	networkConfig := schemas.DefaultNetworkConfig
+	networkConfig.BaseURL = server.URL
+	config := &lib.Config{
+		Providers: map[schemas.ModelProvider]configstore.ProviderConfig{
+			schemas.OpenAI: {
+				Keys: []schemas.Key{
+					{ID: "default", Value: "sk-default", Models: []string{}, Weight: 1},
+					{ID: "playground", Value: "sk-playground", Models: []string{}, Weight: 0},
+				},
+				NetworkConfig: &networkConfig,
+			},
+		},
+		EnvKeys:    map[string][]configstore.EnvKeyInfo{},
+		Playground: &lib.PlaygroundConfig{MaxTokens: 64},
+	}
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: lib.NewBaseAccount(config),
+		Plugins: []schemas.Plugin{&raceTestPlugin{rejected: map[string]bool{"blocked": true}}},
+		Logger:  logger,
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+	h := NewPlaygroundHandler(client, config, logger)
+
+	chat := func(body string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetRequestURI("/api/playground/chat")
+		ctx.Request.SetBodyString(body)
+		h.chat(ctx)
+		return ctx
+	}
+
+	// Without pricing the cost cap cannot be enforced
+	if ctx := chat(`{"provider": "openai", "model": "gpt-4o-mini", "messages": [{"role": "user", "content": "Hello"}]}`); ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 for a model without pricing", ctx.Response.StatusCode())
+	}
+	config.Playground.AllowUnpricedModels = true
+	if ctx := chat(`{"provider": "openai", "model": "gpt-4o-mini", "key_id": "missing", "messages": [{"role": "user", "content": "Hello"}]}`); ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Fatalf("status = %d, want 404 for an unknown key", ctx.Response.StatusCode())
+	}
+
+	ctx := chat(`{"provider": "openai", "model": "gpt-4o-mini", "key_id": "playground", "messages": [{"role": "user", "content": "Hello"}], "params": {"max_completion_tokens": 4096}}`)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("playground request failed with %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var response PlaygroundChatResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.Response == nil || response.Error != nil || response.MaxTokens != 64 {
+		t.Fatalf("unexpected response %s", ctx.Response.Body())
+	}
+	if len(upstreamBodies) != 1 || !strings.HasPrefix(upstreamBodies[0], "Bearer sk-playground ") || !strings.Contains(upstreamBodies[0], `"max_tokens":64`) {
+		t.Fatalf("upstream requests = %v, want one with the selected key and the capped completion", upstreamBodies)
+	}
+	steps := response.Trace.Steps
+	if len(steps) != 3 || steps[0].Stage != schemas.PipelineTraceStagePreHook || steps[0].Plugin != "race-test" ||
+		steps[1].Stage != schemas.PipelineTraceStageProvider || steps[1].KeyID != "playground" || steps[1].Attempt != 1 ||
+		steps[2].Stage != schemas.PipelineTraceStagePostHook {
+		t.Fatalf("trace steps = %+v, want the pre-hook, the provider attempt and the post-hook", steps)
+	}
+	if len(response.Trace.Upstream) != 1 || response.Trace.Upstream[0].RequestHeaders["Authorization"] != "[REDACTED]" || response.Trace.Upstream[0].StatusCode != http.StatusOK {
+		t.Fatalf("trace upstream = %+v, want the exchange with the key redacted", response.Trace.Upstream)
+	}
+
+	// Decisions of the plugins are returned with the trace rather than as an error response
+	ctx = chat(`{"provider": "openai", "model": "blocked", "messages": [{"role": "user", "content": "Hello"}]}`)
+	response = PlaygroundChatResponse{}
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if response.Error == nil || len(response.Trace.Steps) == 0 || response.Trace.Steps[0].Decision != schemas.PipelineTraceDecisionShortCircuit ||
+		response.Trace.Steps[0].Error != "rejected by policy" || len(upstreamBodies) != 1 {
+		t.Fatalf("unexpected response %s, want the short circuit of the plugin without an upstream request", ctx.Response.Body())
+	}
+
+	config.Playground.Enabled = bifrost.Ptr(false)
+	if ctx := chat(`{}`); ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("status = %d, want 404 when the playground is off", ctx.Response.StatusCode())
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// policyTestPlugin records its hooks in calls, and allows requests or fails its hooks when set to
+type policyTestPlugin struct {
+	name            string
+	calls           *[]string
+	allow           bool
+	preErr, postErr error
+}
+
+func (p *policyTestPlugin) GetName() string { return p.name }
+func (p *policyTestPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+func (p *policyTestPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	*p.calls = append(*p.calls, "pre:"+p.name)
+	if p.allow {
+		return req, &schemas.PluginShortCircuit{Allow: true}, nil
+	}
+	return req, nil, p.preErr
+}
+func (p *policyTestPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	*p.calls = append(*p.calls, "post:"+p.name)
+	return result, err, p.postErr
+}
+func (p *policyTestPlugin) Cleanup() error { return nil }
+
+// declaredPolicyTestPlugin declares its own policy
+type declaredPolicyTestPlugin struct {
+	policyTestPlugin
+	policy schemas.PluginPolicy
+}
+
+func (p *declaredPolicyTestPlugin) PluginPolicy() schemas.PluginPolicy { return p.policy }
+
+// TestPluginPolicies tests that plugins run in the order of their declared or configured priority, that a plugin
+// allowing a request skips the remaining plugins, and that the errors of hooks fail requests only for plugins failing
+// closed
+func TestPluginPolicies(t *testing.T) {
+	var upstreamCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "chatcmpl-1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "message": {"role": "assistant", "content": "hi"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	run := func(t *testing.T, policies map[string]schemas.PluginPolicy, plugins ...schemas.Plugin) *schemas.BifrostError {
+		t.Helper()
+		client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+			Account:        syntheticStreamingTestAccount{baseURL: server.URL},
+			Plugins:        plugins,
+			PluginPolicies: policies,
+			Logger:         bifrost.NewDefaultLogger(schemas.LogLevelError),
+		})
+		if err != nil {
+			t.Fatalf("failed to init bifrost: %v", err)
+		}
+		defer client.Shutdown()
+		_, bifrostErr := client.ChatCompletionRequest(context.Background(), &schemas.BifrostChatRequest{
+			Provider: "nostream",
+			Model:    "gpt-4o",
+			Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("hi")}}},
+		})
+		return bifrostErr
+	}
+
+	tests := []struct {
+		name          string
+		policies      map[string]schemas.PluginPolicy
+		setup         func(a, b, c *policyTestPlugin)
+		wantCalls     []string
+		wantErr       string // Message of the plugin error failing the request
+		wantUpstreams int
+	}{
+		{
+			name:          "priorities",
+			policies:      map[string]schemas.PluginPolicy{"c": {Priority: 5}},
+			wantCalls:     []string{"pre:b", "pre:c", "pre:a", "post:a", "post:c", "post:b"},
+			wantUpstreams: 1,
+		},
+		{
+			name:          "allow skips the remaining plugins",
+			setup:         func(a, b, c *policyTestPlugin) { b.allow = true },
+			wantCalls:     []string{"pre:b", "post:b"},
+			wantUpstreams: 1,
+		},
+		{
+			name:          "pre-hook failing open",
+			setup:         func(a, b, c *policyTestPlugin) { a.preErr = errors.New("store down") },
+			wantCalls:     []string{"pre:b", "pre:a", "pre:c", "post:c", "post:a", "post:b"},
+			wantUpstreams: 1,
+		},
+		{
+			name:      "pre-hook failing closed",
+			policies:  map[string]schemas.PluginPolicy{"a": {FailurePolicy: schemas.PluginFailClosed}},
+			setup:     func(a, b, c *policyTestPlugin) { a.preErr = errors.New("store down") },
+			wantCalls: []string{"pre:b", "pre:a", "post:a", "post:b"},
+			wantErr:   "PreHook of plugin a failed: store down",
+		},
+		{
+			name:          "post-hook failing closed",
+			policies:      map[string]schemas.PluginPolicy{"c": {FailurePolicy: schemas.PluginFailClosed}},
+			setup:         func(a, b, c *policyTestPlugin) { c.postErr = errors.New("store down") },
+			wantCalls:     []string{"pre:b", "pre:a", "pre:c", "post:c", "post:a", "post:b"},
+			wantErr:       "PostHook of plugin c failed: store down",
+			wantUpstreams: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			a := &policyTestPlugin{name: "a", calls: &calls}
+			b := &declaredPolicyTestPlugin{policyTestPlugin: policyTestPlugin{name: "b", calls: &calls}, policy: schemas.PluginPolicy{Priority: 10}}
+			c := &policyTestPlugin{name: "c", calls: &calls}
+			if tt.setup != nil {
+				tt.setup(a, &b.policyTestPlugin, c)
+			}
+			upstreamCalls = 0
+
+			err := run(t, tt.policies, a, b, c)
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if upstreamCalls != tt.wantUpstreams {
+				t.Errorf("upstream calls = %d, want %d", upstreamCalls, tt.wantUpstreams)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err.Error.Message)
+				}
+				return
+			}
+			if err == nil || err.Type == nil || *err.Type != "plugin_error" || *err.StatusCode != 500 {
+				t.Fatalf("expected a 500 plugin_error, got %+v", err)
+			}
+			if err.Error.Message != tt.wantErr {
+				t.Errorf("message = %q, want %q", err.Error.Message, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+			Account:        syntheticStreamingTestAccount{baseURL: server.URL},
+			PluginPolicies: map[string]schemas.PluginPolicy{"a": {FailurePolicy: "fail_sideways"}},
+		})
+		if err == nil {
+			t.Fatal("expected an unknown failure policy to be rejected")
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/fasthttp/router"
 	"github.com/maximhq/bifrost/core/schemas"
@@ -57,8 +58,26 @@ func (h *PluginsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.Bif
 	r.DELETE("/api/plugins/{name}", lib.ChainMiddlewares(h.deletePlugin, middlewares...))
 }
 
+// pluginListSpec is the list spec of GET /api/plugins, see pagination.go
+var pluginListSpec = &listSpec[configstore.TablePlugin]{
+	id: func(plugin configstore.TablePlugin) string { return plugin.Name },
+	fields: map[string]listField[configstore.TablePlugin]{
+		"name":       {value: func(plugin configstore.TablePlugin) string { return plugin.Name }},
+		"enabled":    {value: func(plugin configstore.TablePlugin) string { return strconv.FormatBool(plugin.Enabled) }},
+		"created_at": {value: func(plugin configstore.TablePlugin) string { return timeListValue(plugin.CreatedAt) }, numeric: true},
+		"updated_at": {value: func(plugin configstore.TablePlugin) string { return timeListValue(plugin.UpdatedAt) }, numeric: true},
+	},
+	defaultSort: "name",
+}
+
 // getPlugins gets all plugins
 func (h *PluginsHandler) getPlugins(ctx *fasthttp.RequestCtx) {
+	query, err := parseListQuery(ctx, pluginListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
 	plugins, err := h.configStore.GetPlugins(ctx)
 	if err != nil {
 		h.logger.Error("failed to get plugins: %v", err)
@@ -66,10 +85,7 @@ func (h *PluginsHandler) getPlugins(ctx *fasthttp.RequestCtx) {
 		return
 	}
 
-	SendJSON(ctx, map[string]any{
-		"plugins": plugins,
-		"count":   len(plugins),
-	}, h.logger)
+	sendListPage(ctx, "plugins", paginate(plugins, pluginListSpec, query), h.logger)
 }
 
 // getPlugin gets a plugin by name
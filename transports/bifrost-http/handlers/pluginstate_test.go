@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// pluginStateCounter counts the requests of each virtual key in the shared plugin state
+type pluginStateCounter struct{}
+
+func (pluginStateCounter) GetName() string { return "counter" }
+func (pluginStateCounter) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+func (pluginStateCounter) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	virtualKey, _ := (*ctx).Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	_, err := bifrost.GetPluginState(*ctx).Increment("counter", virtualKey, 1, time.Minute)
+	return req, nil, err
+}
+func (pluginStateCounter) PostHook(ctx *context.Context, result *schemas.BifrostResponse, err *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, err, nil
+}
+func (pluginStateCounter) Cleanup() error { return nil }
+
+// pluginStateLimiter rejects the requests of virtual keys the counter has seen more than twice
+type pluginStateLimiter struct{ pluginStateCounter }
+
+func (pluginStateLimiter) GetName() string { return "limiter" }
+func (pluginStateLimiter) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	virtualKey, _ := (*ctx).Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	if count, _ := bifrost.GetPluginState(*ctx).Get("counter", virtualKey); count.(int64) > 2 {
+		return req, &schemas.PluginShortCircuit{Error: &schemas.BifrostError{StatusCode: bifrost.Ptr(429), Error: &schemas.ErrorField{Message: "limited"}}}, nil
+	}
+	return req, nil, nil
+}
+
+// TestPluginState tests that plugins coordinate through the state shared by a Bifrost instance, and the atomic
+// operations and expiry of the in-memory state
+func TestPluginState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "chatcmpl-1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "message": {"role": "assistant", "content": "hi"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: syntheticStreamingTestAccount{baseURL: server.URL},
+		Plugins: []schemas.Plugin{pluginStateCounter{}, pluginStateLimiter{}},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+
+	chat := func(virtualKey string) *schemas.BifrostError {
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, virtualKey)
+		_, bifrostErr := client.ChatCompletionRequest(ctx, &schemas.BifrostChatRequest{
+			Provider: "nostream",
+			Model:    "gpt-4o",
+			Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("hi")}}},
+		})
+		return bifrostErr
+	}
+	for i := 1; i <= 3; i++ {
+		err := chat("vk-a")
+		if limited := err != nil && err.Error.Message == "limited"; limited != (i == 3) {
+			t.Errorf("request %d: error = %+v", i, err)
+		}
+	}
+	if err := chat("vk-b"); err != nil {
+		t.Errorf("expected the requests of another key to go through, got %+v", err.Error)
+	}
+	if count, _ := client.PluginState().Get("counter", "vk-a"); count != int64(3) {
+		t.Errorf("count = %v, want 3 from the state of the instance", count)
+	}
+
+	state := bifrost.NewInMemoryPluginState()
+	if value, stored := state.SetIfAbsent("ns", "key", "first", 0); !stored || value != "first" {
+		t.Errorf("SetIfAbsent on a missing key = %v, %v", value, stored)
+	}
+	if value, stored := state.SetIfAbsent("ns", "key", "second", 0); stored || value != "first" {
+		t.Errorf("SetIfAbsent on a set key = %v, %v", value, stored)
+	}
+	if state.CompareAndSwap("ns", "key", "second", "third") || !state.CompareAndSwap("ns", "key", "first", "third") {
+		t.Errorf("expected CompareAndSwap to only swap the current value")
+	}
+	if _, err := state.Increment("ns", "key", 1, 0); err != schemas.ErrPluginStateNotCounter {
+		t.Errorf("Increment of a string = %v, want ErrPluginStateNotCounter", err)
+	}
+	if _, ok := state.Get("other", "key"); ok {
+		t.Errorf("expected namespaces to be separate")
+	}
+	state.Set("ns", "expiring", true, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := state.Get("ns", "expiring"); ok {
+		t.Errorf("expected the value to expire")
+	}
+	if count, _ := state.Increment("ns", "expiring", 2, 0); count != 2 {
+		t.Errorf("Increment of an expired key = %d, want a new counter", count)
+	}
+	state.Delete("ns", "key")
+	if _, ok := state.Get("ns", "key"); ok {
+		t.Errorf("expected the key to be deleted")
+	}
+}
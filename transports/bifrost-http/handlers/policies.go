@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// PolicyHeader names the policy that decided a request.
+const PolicyHeader = "x-bf-policy"
+
+// PoliciesHandler manages the routing and governance policies.
+type PoliciesHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// PolicyTestRequest is the body of POST /api/policies/test.
+type PolicyTestRequest struct {
+	Policies *lib.PoliciesConfig `json:"policies,omitempty"` // Policies to test instead of the active ones, e.g. before saving them
+	Request  lib.PolicyInput     `json:"request"`
+}
+
+// NewPoliciesHandler creates a new policies handler.
+func NewPoliciesHandler(store *lib.Config, logger schemas.Logger) *PoliciesHandler {
+	return &PoliciesHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the policies routes.
+func (h *PoliciesHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/policies", lib.ChainMiddlewares(h.getPolicies, middlewares...))
+	r.PUT("/api/policies", lib.ChainMiddlewares(h.updatePolicies, middlewares...))
+	r.GET("/api/policies/versions", lib.ChainMiddlewares(h.getPolicyVersions, middlewares...))
+	r.POST("/api/policies/test", lib.ChainMiddlewares(h.testPolicies, middlewares...))
+}
+
+// getPolicies handles GET /api/policies - Get the active policies
+func (h *PoliciesHandler) getPolicies(ctx *fasthttp.RequestCtx) {
+	response := lib.PoliciesConfig{}
+	if policies := h.store.GetPolicies(); policies != nil {
+		response = policies.Config()
+	}
+	if response.Policies == nil {
+		response.Policies = []lib.Policy{}
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// updatePolicies handles PUT /api/policies - Replace the policies with a new version
+func (h *PoliciesHandler) updatePolicies(ctx *fasthttp.RequestCtx) {
+	var req lib.PoliciesConfig
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Policies == nil {
+		req.Policies = []lib.Policy{}
+	}
+	if _, err := lib.CompilePolicies(req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid policies: %v", err), h.logger)
+		return
+	}
+	version, err := h.store.UpdatePolicies(ctx, req)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update policies: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, version, h.logger)
+}
+
+// getPolicyVersions handles GET /api/policies/versions - Get the versions of the policies kept, oldest first
+func (h *PoliciesHandler) getPolicyVersions(ctx *fasthttp.RequestCtx) {
+	versions := h.store.GetPolicyVersions()
+	if versions == nil {
+		versions = []lib.PoliciesConfig{}
+	}
+	SendJSON(ctx, map[string]interface{}{
+		"versions": versions,
+		"count":    len(versions),
+	}, h.logger)
+}
+
+// testPolicies handles POST /api/policies/test - Evaluate the active or given policies against a request, without
+// sending it
+func (h *PoliciesHandler) testPolicies(ctx *fasthttp.RequestCtx) {
+	var req PolicyTestRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	policies := h.store.GetPolicies()
+	if req.Policies != nil {
+		compiled, err := lib.CompilePolicies(*req.Policies)
+		if err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid policies: %v", err), h.logger)
+			return
+		}
+		policies = compiled
+	}
+	if policies == nil {
+		SendJSON(ctx, lib.PolicyDecision{}, h.logger)
+		return
+	}
+	SendJSON(ctx, policies.Evaluate(req.Request), h.logger)
+}
+
+// PolicyMiddleware enforces the policies on the JSON body of requests to the /v1 inference endpoints: requests
+// denied are rejected with 403, and requests routed are sent to the target of their policy. It runs after routing
+// overrides, so policies see and can overrule the caller's routing, and before transformation rules, which match
+// the routed request. Expressions failing to evaluate are skipped silently; POST /api/policies/test reports them.
+func PolicyMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			policies := config.GetPolicies()
+			path := string(ctx.Path())
+			if policies == nil || !ctx.IsPost() || !strings.HasPrefix(path, "/v1/") {
+				next(ctx)
+				return
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil || body == nil {
+				next(ctx)
+				return
+			}
+			headers := make(map[string]string)
+			ctx.Request.Header.VisitAll(func(key, value []byte) {
+				headers[strings.ToLower(string(key))] = string(value)
+			})
+
+			labels, _ := ctx.UserValue(schemas.BifrostContextKeyLabels).(map[string]string)
+			decision := policies.Evaluate(lib.PolicyInput{
+				Path:       path,
+				VirtualKey: string(ctx.Request.Header.Peek("x-bf-vk")),
+				Headers:    headers,
+				Labels:     labels,
+				Body:       body,
+			})
+			if decision.Policy != "" {
+				ctx.Response.Header.Set(PolicyHeader, decision.Policy)
+			}
+			switch decision.Action {
+			case lib.PolicyActionDeny:
+				SendError(ctx, fasthttp.StatusForbidden, decision.Message, logger)
+				return
+			case lib.PolicyActionRoute:
+				body["model"] = decision.Target
+				updatedBody, err := json.Marshal(body)
+				if err != nil {
+					SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to apply policy: %v", err), logger)
+					return
+				}
+				ctx.Request.SetBody(updatedBody)
+			}
+			next(ctx)
+		}
+	}
+}
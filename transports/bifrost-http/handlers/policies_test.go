@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// testPoliciesConfig blocks tools for free keys after 6pm UTC unless they use gpt-4o-mini, routes the requests of the
+// batch header to a cheaper model and denies a retired model
+var testPoliciesConfig = lib.PoliciesConfig{
+	KeyAttributes: []lib.PolicyKeyAttributes{
+		{VirtualKeys: []string{"sk-bf-free-*"}, Attributes: map[string]string{"tier": "free"}},
+	},
+	Policies: []lib.Policy{
+		{
+			Name:       "free-tools-after-hours",
+			Expression: `key.tier == "free" && has(request.tools) && now.getHours("UTC") >= 18 && model != "gpt-4o-mini"`,
+			Action:     lib.PolicyActionDeny,
+			Message:    "tools are not available to free keys after 6pm UTC",
+		},
+		{
+			Name:       "batch-to-mini",
+			Expression: `headers["x-batch"] == "true" && provider == "openai"`,
+			Action:     lib.PolicyActionRoute,
+			Target:     "openai/gpt-4o-mini",
+		},
+		{
+			Name:       "retired-model",
+			Expression: `request.model.startsWith("openai/gpt-3.5")`,
+			Action:     lib.PolicyActionDeny,
+			Message:    "gpt-3.5 models are retired",
+		},
+	},
+}
+
+// TestPolicyMiddleware tests that requests matching a deny policy are rejected, that requests matching a route
+// policy are sent to its target, and that failing expressions do not match
+func TestPolicyMiddleware(t *testing.T) {
+	config := &lib.Config{}
+	if _, err := config.UpdatePolicies(context.Background(), testPoliciesConfig); err != nil {
+		t.Fatalf("failed to set policies: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		virtualKey string
+		headers    map[string]string
+		body       string
+		wantStatus int
+		wantModel  string
+		wantPolicy string
+	}{
+		{
+			name:       "routed",
+			virtualKey: "sk-bf-pro",
+			headers:    map[string]string{"x-batch": "true"},
+			body:       `{"model":"openai/gpt-4o","messages":[]}`,
+			wantModel:  "openai/gpt-4o-mini",
+			wantPolicy: "batch-to-mini",
+		},
+		{
+			name:       "denied",
+			virtualKey: "sk-bf-pro",
+			body:       `{"model":"openai/gpt-3.5-turbo","messages":[]}`,
+			wantStatus: fasthttp.StatusForbidden,
+			wantPolicy: "retired-model",
+		},
+		{
+			name:       "not matched",
+			virtualKey: "sk-bf-pro",
+			body:       `{"model":"openai/gpt-4o","messages":[]}`,
+			wantModel:  "openai/gpt-4o",
+		},
+		{
+			name:       "missing header fails to evaluate",
+			virtualKey: "sk-bf-pro",
+			body:       `{"model":"anthropic/claude-3-5-sonnet","messages":[]}`,
+			wantModel:  "anthropic/claude-3-5-sonnet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+			ctx.Request.SetRequestURI("/v1/chat/completions")
+			ctx.Request.Header.Set("x-bf-vk", tt.virtualKey)
+			for name, value := range tt.headers {
+				ctx.Request.Header.Set(name, value)
+			}
+			ctx.Request.SetBodyString(tt.body)
+
+			var got map[string]any
+			PolicyMiddleware(config)(func(ctx *fasthttp.RequestCtx) {
+				if err := json.Unmarshal(ctx.Request.Body(), &got); err != nil {
+					t.Fatalf("invalid body: %v", err)
+				}
+			})(ctx)
+
+			if tt.wantStatus != 0 {
+				if ctx.Response.StatusCode() != tt.wantStatus || got != nil {
+					t.Errorf("status = %d, want %d and the request rejected", ctx.Response.StatusCode(), tt.wantStatus)
+				}
+			} else if got["model"] != tt.wantModel {
+				t.Errorf("model = %v, want %s", got["model"], tt.wantModel)
+			}
+			if policy := string(ctx.Response.Header.Peek(PolicyHeader)); policy != tt.wantPolicy {
+				t.Errorf("%s = %q, want %q", PolicyHeader, policy, tt.wantPolicy)
+			}
+		})
+	}
+}
+
+// TestPoliciesEndpoints tests that policies are versioned on every update, rejected when their expressions are
+// invalid, and evaluated against requests by the test endpoint
+func TestPoliciesEndpoints(t *testing.T) {
+	handler := NewPoliciesHandler(&lib.Config{}, nil)
+	call := func(handle fasthttp.RequestHandler, body any) *fasthttp.RequestCtx {
+		t.Helper()
+		ctx := &fasthttp.RequestCtx{}
+		if body != nil {
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Fatalf("failed to marshal body: %v", err)
+			}
+			ctx.Request.SetBody(data)
+		}
+		handle(ctx)
+		return ctx
+	}
+
+	for i := 1; i <= 2; i++ {
+		ctx := call(handler.updatePolicies, testPoliciesConfig)
+		var version lib.PoliciesConfig
+		if err := json.Unmarshal(ctx.Response.Body(), &version); err != nil || version.Version != i {
+			t.Fatalf("update %d: version = %d (%v), body %s", i, version.Version, err, ctx.Response.Body())
+		}
+	}
+	var versions struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(call(handler.getPolicyVersions, nil).Response.Body(), &versions); err != nil || versions.Count != 2 {
+		t.Errorf("versions = %d (%v), want 2", versions.Count, err)
+	}
+
+	invalid := lib.PoliciesConfig{Policies: []lib.Policy{{Name: "typo", Expression: `request.model ==`, Action: lib.PolicyActionDeny}}}
+	if ctx := call(handler.updatePolicies, invalid); ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("invalid expression: status = %d, want 400", ctx.Response.StatusCode())
+	}
+	notBool := lib.PoliciesConfig{Policies: []lib.Policy{{Name: "model", Expression: `request.model`, Action: lib.PolicyActionDeny}}}
+	if ctx := call(handler.updatePolicies, notBool); ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("non-bool expression: status = %d, want 400", ctx.Response.StatusCode())
+	}
+
+	tests := []struct {
+		name       string
+		virtualKey string
+		model      string
+		now        string
+		wantPolicy string
+		wantErrors int
+	}{
+		{name: "free key after hours", virtualKey: "sk-bf-free-1", model: "openai/gpt-4o", now: "2025-01-01T19:00:00Z", wantPolicy: "free-tools-after-hours"},
+		{name: "free key before hours", virtualKey: "sk-bf-free-1", model: "openai/gpt-4o", now: "2025-01-01T09:00:00Z", wantErrors: 1},
+		{name: "free key on the allowed model", virtualKey: "sk-bf-free-1", model: "openai/gpt-4o-mini", now: "2025-01-01T19:00:00Z", wantErrors: 1},
+		{name: "key without a tier", virtualKey: "sk-bf-pro", model: "openai/gpt-4o", now: "2025-01-01T19:00:00Z", wantErrors: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req PolicyTestRequest
+			input := `{"request": {"path": "/v1/chat/completions", "virtual_key": "` + tt.virtualKey + `", "now": "` + tt.now + `",
+				"body": {"model": "` + tt.model + `", "tools": [{"type": "function"}]}}}`
+			if err := json.Unmarshal([]byte(input), &req); err != nil {
+				t.Fatalf("invalid input: %v", err)
+			}
+			var decision lib.PolicyDecision
+			if err := json.Unmarshal(call(handler.testPolicies, req).Response.Body(), &decision); err != nil {
+				t.Fatalf("invalid decision: %v", err)
+			}
+			if decision.Policy != tt.wantPolicy || decision.Version != 2 {
+				t.Errorf("decision = %+v, want policy %q of version 2", decision, tt.wantPolicy)
+			}
+			if len(decision.Errors) != tt.wantErrors {
+				t.Errorf("errors = %+v, want %d", decision.Errors, tt.wantErrors)
+			}
+		})
+	}
+
+	unsaved := lib.PoliciesConfig{Policies: []lib.Policy{{Name: "deny-all", Expression: `true`, Action: lib.PolicyActionDeny}}}
+	var decision lib.PolicyDecision
+	if err := json.Unmarshal(call(handler.testPolicies, PolicyTestRequest{Policies: &unsaved}).Response.Body(), &decision); err != nil {
+		t.Fatalf("invalid decision: %v", err)
+	}
+	if decision.Action != lib.PolicyActionDeny || decision.Message != "request denied by policy deny-all" {
+		t.Errorf("decision of the given policies = %+v", decision)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/providers/anthropic"
+	"github.com/maximhq/bifrost/core/schemas/providers/openai"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/valyala/fasthttp"
+)
+
+// TestPromptCache_AnthropicPassThrough tests that the cache breakpoints of Anthropic clients reach Anthropic
+func TestPromptCache_AnthropicPassThrough(t *testing.T) {
+	var clientReq anthropic.AnthropicMessageRequest
+	if err := json.Unmarshal([]byte(`{"model":"claude-sonnet-4","max_tokens":1024,
+		"system":[{"type":"text","text":"You are a librarian.","cache_control":{"type":"ephemeral","ttl":"1h"}}],
+		"tools":[{"name":"search","description":"Search the catalog","input_schema":{"type":"object"},"cache_control":{"type":"ephemeral"}}],
+		"messages":[{"role":"user","content":[{"type":"text","text":"A long book...","cache_control":{"type":"ephemeral"}}]}]}`), &clientReq); err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(anthropic.ToAnthropicChatCompletionRequest(clientReq.ToBifrostRequest()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent struct {
+		System []anthropic.AnthropicContentBlock `json:"system"`
+		Tools  []anthropic.AnthropicTool         `json:"tools"`
+		// A single text block with a breakpoint must not be collapsed into a string
+		Messages []struct {
+			Content []anthropic.AnthropicContentBlock `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &sent); err != nil {
+		t.Fatalf("unexpected body %s: %v", body, err)
+	}
+	if control := sent.System[0].CacheControl; control == nil || control.Type != schemas.CacheControlTypeEphemeral || control.TTL == nil || *control.TTL != "1h" {
+		t.Errorf("expected the system breakpoint with its TTL, got %s", body)
+	}
+	if sent.Tools[0].CacheControl == nil || sent.Messages[0].Content[0].CacheControl == nil {
+		t.Errorf("expected the tool and message breakpoints, got %s", body)
+	}
+}
+
+// TestPromptCache_OpenAICompatibleStripping tests that breakpoints are only sent to OpenRouter among
+// OpenAI-compatible providers, without changing the request of the caller
+func TestPromptCache_OpenAICompatibleStripping(t *testing.T) {
+	bifrostReq := &schemas.BifrostChatRequest{
+		Model: "claude-sonnet-4",
+		Input: []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentBlocks: []schemas.ChatContentBlock{
+			{Type: schemas.ChatContentBlockTypeText, Text: schemas.Ptr("A long book..."), CacheControl: &schemas.CacheControl{Type: schemas.CacheControlTypeEphemeral}},
+		}}}},
+		Params: &schemas.ChatParameters{Tools: []schemas.ChatTool{
+			{Type: schemas.ChatToolTypeFunction, Function: &schemas.ChatToolFunction{Name: "search"}, CacheControl: &schemas.CacheControl{Type: schemas.CacheControlTypeEphemeral}},
+		}},
+	}
+	for provider, kept := range map[schemas.ModelProvider]bool{schemas.OpenRouter: true, schemas.OpenAI: false, schemas.Groq: false} {
+		bifrostReq.Provider = provider
+		req := openai.ToOpenAIChatRequest(bifrostReq)
+		if got := req.Messages[0].Content.ContentBlocks[0].CacheControl != nil; got != kept {
+			t.Errorf("%s: expected the message breakpoint to be sent: %v, got %v", provider, kept, got)
+		}
+		if got := req.Tools[0].CacheControl != nil; got != kept {
+			t.Errorf("%s: expected the tool breakpoint to be sent: %v, got %v", provider, kept, got)
+		}
+	}
+	if bifrostReq.Input[0].Content.ContentBlocks[0].CacheControl == nil || bifrostReq.Params.Tools[0].CacheControl == nil {
+		t.Error("expected the request of the caller to be left unchanged")
+	}
+}
+
+// TestPromptCache_AnthropicUsage tests that cache reads and writes are counted in the prompt tokens and reported
+// back to Anthropic clients apart from the input tokens
+func TestPromptCache_AnthropicUsage(t *testing.T) {
+	var response anthropic.AnthropicMessageResponse
+	if err := json.Unmarshal([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Hi"}],
+		"usage":{"input_tokens":12,"cache_creation_input_tokens":300,"cache_read_input_tokens":2000,"output_tokens":40}}`), &response); err != nil {
+		t.Fatal(err)
+	}
+	bifrostResp := response.ToBifrostResponse()
+	usage := bifrostResp.Usage
+	if usage.PromptTokens != 2312 || usage.TotalTokens != 2352 || usage.PromptTokensDetails == nil ||
+		usage.PromptTokensDetails.CachedTokens != 2000 || usage.PromptTokensDetails.CacheCreationTokens != 300 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	if back := anthropic.ToAnthropicChatCompletionResponse(bifrostResp).Usage; *back != *response.Usage {
+		t.Errorf("expected the Anthropic usage to survive the round trip, got %+v", back)
+	}
+}
+
+// TestBillingHandler_PromptCacheReport tests the cache hit rate report per key
+func TestBillingHandler_PromptCacheReport(t *testing.T) {
+	store, err := logstore.NewLogStore(context.Background(), &logstore.Config{
+		Type:   logstore.LogStoreTypeSQLite,
+		Config: &logstore.SQLiteConfig{Path: filepath.Join(t.TempDir(), "logs.db")},
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create log store: %v", err)
+	}
+	day := time.Date(2025, 2, 3, 12, 0, 0, 0, time.UTC)
+	for _, entry := range []*logstore.Log{
+		{ID: "1", KeyID: "key-a", Provider: "anthropic", Model: "claude", Status: "success", CacheSavings: bifrost.Ptr(0.25),
+			TokenUsageParsed: &schemas.LLMUsage{PromptTokens: 1000, PromptTokensDetails: &schemas.TokenDetails{CachedTokens: 750}}},
+		{ID: "2", KeyID: "key-b", Provider: "anthropic", Model: "claude", Status: "success", TokenUsageParsed: &schemas.LLMUsage{PromptTokens: 1000}},
+	} {
+		entry.Timestamp, entry.CreatedAt = day, day
+		if err := store.Create(context.Background(), entry); err != nil {
+			t.Fatalf("failed to create log: %v", err)
+		}
+	}
+	handler := NewBillingHandler(store, nil, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	ctx := billingRequestCtx("/api/billing/prompt-cache?start=2025-02-01&end=2025-02-28&group_by=key")
+	handler.getPromptCacheReport(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var report PromptCacheReportResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if len(report.Lines) != 2 || report.Lines[0].KeyID != "key-a" || report.Lines[0].TokenHitRate != 0.75 || report.Lines[1].TokenHitRate != 0 {
+		t.Errorf("unexpected lines: %+v", report.Lines)
+	}
+	if report.Totals.Requests != 2 || report.Totals.CacheHitRequests != 1 || report.Totals.TokenHitRate != 0.375 || report.Totals.CacheSavings != 0.25 {
+		t.Errorf("unexpected totals: %+v", report.Totals)
+	}
+
+	ctx = billingRequestCtx("/api/billing/prompt-cache?group_by=customer")
+	handler.getPromptCacheReport(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid group_by, got %d", ctx.Response.StatusCode())
+	}
+}
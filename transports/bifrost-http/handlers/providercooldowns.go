@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const providerCooldownsPluginName = "bifrost-provider-cooldowns"
+
+// providerCooldownsPlugin starts the cooldown of providers answering 429 with a Retry-After, and skips providers
+// cooling down as long as a fallback that is not cooling down remains (see lib.ProviderCooldownsConfig). The skip
+// is a fallback-eligible error, so core moves on to the next fallback.
+type providerCooldownsPlugin struct {
+	cooldowns *lib.ProviderCooldowns
+}
+
+// GetName returns the name of the plugin
+func (p *providerCooldownsPlugin) GetName() string {
+	return providerCooldownsPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *providerCooldownsPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook short-circuits requests to a provider cooling down when a fallback is left, or when requests without one
+// are rejected
+func (p *providerCooldownsPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if isProbe, _ := (*ctx).Value(lib.ProviderHealthProbeContextKey).(bool); isProbe {
+		return req, nil, nil
+	}
+	now := time.Now()
+	remaining := p.cooldowns.Remaining(req.Provider, now)
+	if remaining <= 0 {
+		return req, nil, nil
+	}
+	hasFallback := false
+	for _, fallback := range remainingFallbacks(req) {
+		if p.cooldowns.Remaining(fallback.Provider, now) <= 0 {
+			hasFallback = true
+			break
+		}
+	}
+	if !hasFallback && !p.cooldowns.RejectWithoutFallback() {
+		return req, nil, nil
+	}
+	p.cooldowns.Avoided(req.Provider)
+
+	retryAfter := int(math.Ceil(remaining.Seconds()))
+	bifrostErr := &schemas.BifrostError{
+		Type:       bifrost.Ptr("provider_cooling_down"),
+		StatusCode: bifrost.Ptr(fasthttp.StatusTooManyRequests),
+		Error: &schemas.ErrorField{
+			Message: fmt.Sprintf("provider %s is rate limited for %ds, trying fallbacks", req.Provider, retryAfter),
+		},
+	}
+	if !hasFallback {
+		bifrostErr.Error.Message = fmt.Sprintf("provider %s is rate limited, retry in %ds", req.Provider, retryAfter)
+		bifrostErr.AllowFallbacks = bifrost.Ptr(false)
+		if responseHeaders, ok := (*ctx).Value(lib.ResponseHeadersContextKey).(*lib.ResponseHeaders); ok {
+			responseHeaders.Set("Retry-After", strconv.Itoa(retryAfter))
+		}
+	}
+	return req, &schemas.PluginShortCircuit{Error: bifrostErr}, nil
+}
+
+// PostHook starts the cooldown of the provider of a 429 carrying a Retry-After
+func (p *providerCooldownsPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	if bifrostErr == nil || bifrostErr.StatusCode == nil || *bifrostErr.StatusCode != fasthttp.StatusTooManyRequests {
+		return result, bifrostErr, nil
+	}
+	if retryAfter := bifrostErr.ExtraFields.RetryAfterSeconds; retryAfter > 0 && bifrostErr.ExtraFields.Provider != "" {
+		p.cooldowns.Throttled(bifrostErr.ExtraFields.Provider, time.Duration(retryAfter*float64(time.Second)), time.Now())
+	}
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *providerCooldownsPlugin) Cleanup() error {
+	return nil
+}
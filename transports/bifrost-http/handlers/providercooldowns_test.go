@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestProviderCooldownsPlugin tests that a 429 with a Retry-After cools its provider down for the advertised
+// window, capped, that requests then skip the provider while a fallback that is not cooling down remains, and that
+// requests without one are sent unless they are rejected with a Retry-After
+func TestProviderCooldownsPlugin(t *testing.T) {
+	cooldowns := lib.NewProviderCooldowns(lib.ProviderCooldownsConfig{Enabled: true, MaxCooldownSeconds: 60}, bifrost.NewInMemoryPluginState())
+	plugin := &providerCooldownsPlugin{cooldowns: cooldowns}
+	throttle := func(provider schemas.ModelProvider, statusCode int, retryAfterSeconds float64) {
+		t.Helper()
+		ctx := context.Background()
+		bifrostErr := &schemas.BifrostError{StatusCode: bifrost.Ptr(statusCode), Error: &schemas.ErrorField{Message: "rate limited"}}
+		bifrostErr.ExtraFields = schemas.BifrostErrorExtraFields{Provider: provider, RetryAfterSeconds: retryAfterSeconds}
+		if _, _, err := plugin.PostHook(&ctx, nil, bifrostErr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	throttle(schemas.OpenAI, fasthttp.StatusTooManyRequests, 3600)
+	throttle(schemas.Anthropic, fasthttp.StatusTooManyRequests, 0)
+	throttle(schemas.Mistral, fasthttp.StatusServiceUnavailable, 30)
+
+	now := time.Now()
+	if remaining := cooldowns.Remaining(schemas.OpenAI, now); remaining <= 50*time.Second || remaining > time.Minute {
+		t.Errorf("cooldown = %v, want the advertised hour capped to a minute", remaining)
+	}
+	for _, provider := range []schemas.ModelProvider{schemas.Anthropic, schemas.Mistral} {
+		if remaining := cooldowns.Remaining(provider, now); remaining != 0 {
+			t.Errorf("cooldown of %s = %v without a 429 carrying a Retry-After, want none", provider, remaining)
+		}
+	}
+
+	fallbacks := []schemas.Fallback{{Provider: schemas.Anthropic, Model: "claude-3-5-haiku"}}
+	preHook := func(plugin *providerCooldownsPlugin, req *schemas.BifrostRequest) (*schemas.PluginShortCircuit, *lib.ResponseHeaders) {
+		t.Helper()
+		headers := &lib.ResponseHeaders{}
+		ctx := context.WithValue(context.Background(), lib.ResponseHeadersContextKey, headers)
+		_, shortCircuit, err := plugin.PreHook(&ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return shortCircuit, headers
+	}
+
+	shortCircuit, _ := preHook(plugin, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Fallbacks: fallbacks})
+	if shortCircuit == nil || *shortCircuit.Error.StatusCode != fasthttp.StatusTooManyRequests || shortCircuit.Error.AllowFallbacks != nil {
+		t.Fatalf("expected the cooling down provider to be skipped for the fallback, got %+v", shortCircuit)
+	}
+	if shortCircuit, _ := preHook(plugin, &schemas.BifrostRequest{Provider: schemas.Anthropic, Model: "claude-3-5-haiku", Fallbacks: fallbacks}); shortCircuit != nil {
+		t.Errorf("expected the fallback to be sent, got %+v", shortCircuit.Error)
+	}
+	if shortCircuit, _ := preHook(plugin, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}); shortCircuit != nil {
+		t.Errorf("expected requests without fallback to be sent, got %+v", shortCircuit.Error)
+	}
+
+	rejecting := &providerCooldownsPlugin{cooldowns: lib.NewProviderCooldowns(lib.ProviderCooldownsConfig{Enabled: true, RejectWithoutFallback: true}, bifrost.NewInMemoryPluginState())}
+	rejecting.cooldowns.Throttled(schemas.OpenAI, 20*time.Second, time.Now())
+	shortCircuit, headers := preHook(rejecting, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"})
+	if shortCircuit == nil || shortCircuit.Error.AllowFallbacks == nil || *shortCircuit.Error.AllowFallbacks {
+		t.Fatalf("expected requests without fallback to be rejected, got %+v", shortCircuit)
+	}
+	if retryAfter := headers.Get("Retry-After"); retryAfter != "20" {
+		t.Errorf("Retry-After = %q, want 20", retryAfter)
+	}
+
+	snapshot := cooldowns.Snapshot(time.Now())
+	if len(snapshot) != 1 || snapshot[0].Provider != schemas.OpenAI || !snapshot[0].CoolingDown || snapshot[0].Throttles != 1 || snapshot[0].Avoided != 1 {
+		t.Errorf("unexpected cooldowns %+v", snapshot)
+	}
+	if snapshot := cooldowns.Snapshot(time.Now().Add(2 * time.Minute)); snapshot[0].CoolingDown || snapshot[0].RemainingSeconds != 0 {
+		t.Errorf("expected the cooldown to end, got %+v", snapshot[0])
+	}
+}
+
+// TestProviderCooldowns_SharedState tests that cooldowns kept in the same plugin state are seen by every holder, that
+// a shorter window does not cut a cooldown short and that concurrent throttles are all counted
+func TestProviderCooldowns_SharedState(t *testing.T) {
+	state := bifrost.NewInMemoryPluginState()
+	config := lib.ProviderCooldownsConfig{Enabled: true}
+	first, second := lib.NewProviderCooldowns(config, state), lib.NewProviderCooldowns(config, state)
+
+	now := time.Now()
+	first.Throttled(schemas.OpenAI, time.Minute, now)
+	second.Throttled(schemas.OpenAI, time.Second, now)
+	if remaining := second.Remaining(schemas.OpenAI, now); remaining != time.Minute {
+		t.Errorf("cooldown = %v, want the longer window", remaining)
+	}
+
+	var wg sync.WaitGroup
+	for _, cooldowns := range []*lib.ProviderCooldowns{first, second} {
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				cooldowns.Throttled(schemas.Anthropic, time.Second, now)
+			}()
+		}
+	}
+	wg.Wait()
+	second.Avoided(schemas.Anthropic)
+
+	snapshot := first.Snapshot(now)
+	if len(snapshot) != 2 || snapshot[0].Provider != schemas.Anthropic || snapshot[1].Provider != schemas.OpenAI {
+		t.Fatalf("unexpected cooldowns %+v", snapshot)
+	}
+	if snapshot[0].Throttles != 100 || snapshot[0].Avoided != 1 || snapshot[1].Throttles != 2 {
+		t.Errorf("unexpected counters %+v", snapshot)
+	}
+}
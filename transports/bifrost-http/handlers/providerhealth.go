@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const providerHealthPluginName = "bifrost-provider-health"
+
+// ProviderHealthHandler serves the probe history and the cooldowns of the configured providers.
+type ProviderHealthHandler struct {
+	tracker   *lib.ProviderHealthTracker
+	cooldowns *lib.ProviderCooldowns
+	logger    schemas.Logger
+}
+
+// ProviderHealthResponse is the response of GET /api/providers/health.
+type ProviderHealthResponse struct {
+	Enabled   bool                   `json:"enabled"`
+	Providers []lib.ProviderHealth   `json:"providers"`
+	Cooldowns []lib.ProviderCooldown `json:"cooldowns"` // Providers throttled since the server started, with their current cooldown
+}
+
+// NewProviderHealthHandler creates a new provider health handler. tracker is nil when probing is disabled, and
+// cooldowns when provider cooldowns are.
+func NewProviderHealthHandler(tracker *lib.ProviderHealthTracker, cooldowns *lib.ProviderCooldowns, logger schemas.Logger) *ProviderHealthHandler {
+	return &ProviderHealthHandler{
+		tracker:   tracker,
+		cooldowns: cooldowns,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the provider health route.
+func (h *ProviderHealthHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/providers/health", lib.ChainMiddlewares(h.getProviderHealth, middlewares...))
+}
+
+// getProviderHealth handles GET /api/providers/health - Get the availability and latency history, and the
+// cooldown, of each provider
+func (h *ProviderHealthHandler) getProviderHealth(ctx *fasthttp.RequestCtx) {
+	response := ProviderHealthResponse{Providers: []lib.ProviderHealth{}, Cooldowns: []lib.ProviderCooldown{}}
+	if h.tracker != nil {
+		response.Enabled = true
+		response.Providers = h.tracker.Snapshot()
+	}
+	if h.cooldowns != nil {
+		response.Cooldowns = h.cooldowns.Snapshot(time.Now())
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// providerHealthPlugin skips providers the prober marked unhealthy, as long as a fallback that is not
+// unhealthy remains. The skip is a fallback-eligible error, so core moves on to the next fallback.
+type providerHealthPlugin struct {
+	tracker *lib.ProviderHealthTracker
+}
+
+// GetName returns the name of the plugin
+func (p *providerHealthPlugin) GetName() string {
+	return providerHealthPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *providerHealthPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook short-circuits requests to an unhealthy provider when a healthier candidate is left
+func (p *providerHealthPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if isProbe, _ := (*ctx).Value(lib.ProviderHealthProbeContextKey).(bool); isProbe {
+		return req, nil, nil
+	}
+	if !p.tracker.IsUnhealthy(req.Provider) || !p.hasHealthyFallback(req) {
+		return req, nil, nil
+	}
+	return req, &schemas.PluginShortCircuit{
+		Error: &schemas.BifrostError{
+			Type:       bifrost.Ptr("provider_unhealthy"),
+			StatusCode: bifrost.Ptr(fasthttp.StatusServiceUnavailable),
+			Error: &schemas.ErrorField{
+				Message: fmt.Sprintf("provider %s is failing health probes, trying fallbacks", req.Provider),
+			},
+		},
+	}, nil
+}
+
+// hasHealthyFallback reports whether a fallback after the current attempt is not unhealthy.
+func (p *providerHealthPlugin) hasHealthyFallback(req *schemas.BifrostRequest) bool {
+	for _, fallback := range remainingFallbacks(req) {
+		if !p.tracker.IsUnhealthy(fallback.Provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// remainingFallbacks returns the fallbacks after the current attempt. Fallback attempts carry the full fallback
+// list, so the current position is found by provider and model.
+func remainingFallbacks(req *schemas.BifrostRequest) []schemas.Fallback {
+	for i, fallback := range req.Fallbacks {
+		if fallback.Provider == req.Provider && fallback.Model == req.Model {
+			return req.Fallbacks[i+1:]
+		}
+	}
+	return req.Fallbacks
+}
+
+// PostHook is not used for this plugin
+func (p *providerHealthPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *providerHealthPlugin) Cleanup() error {
+	return nil
+}
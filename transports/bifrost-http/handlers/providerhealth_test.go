@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// newFailingTracker returns a tracker where every listed provider failed its probes
+func newFailingTracker(providers ...schemas.ModelProvider) *lib.ProviderHealthTracker {
+	tracker := lib.NewProviderHealthTracker(lib.ProviderHealthConfig{Enabled: true, FailureThreshold: 1})
+	for _, provider := range providers {
+		tracker.Record(provider, "model", lib.ProviderHealthSample{Timestamp: time.Now(), Error: "connection refused"})
+	}
+	return tracker
+}
+
+// TestProviderHealthPlugin_SkipsUnhealthyProvider tests that unhealthy providers are skipped only while a healthier fallback remains
+func TestProviderHealthPlugin_SkipsUnhealthyProvider(t *testing.T) {
+	fallbacks := []schemas.Fallback{
+		{Provider: schemas.Anthropic, Model: "claude-3-5-haiku"},
+		{Provider: schemas.Mistral, Model: "mistral-small"},
+	}
+	cases := []struct {
+		name      string
+		unhealthy []schemas.ModelProvider
+		req       *schemas.BifrostRequest
+		skipped   bool
+	}{
+		{"unhealthy primary with healthy fallback", []schemas.ModelProvider{schemas.OpenAI},
+			&schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Fallbacks: fallbacks}, true},
+		{"unhealthy primary without fallbacks", []schemas.ModelProvider{schemas.OpenAI},
+			&schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}, false},
+		{"all candidates unhealthy", []schemas.ModelProvider{schemas.OpenAI, schemas.Anthropic, schemas.Mistral},
+			&schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Fallbacks: fallbacks}, false},
+		{"unhealthy fallback with healthy one after it", []schemas.ModelProvider{schemas.OpenAI, schemas.Anthropic},
+			&schemas.BifrostRequest{Provider: schemas.Anthropic, Model: "claude-3-5-haiku", Fallbacks: fallbacks}, true},
+		{"unhealthy last fallback", []schemas.ModelProvider{schemas.Mistral},
+			&schemas.BifrostRequest{Provider: schemas.Mistral, Model: "mistral-small", Fallbacks: fallbacks}, false},
+		{"healthy primary", []schemas.ModelProvider{schemas.Anthropic},
+			&schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Fallbacks: fallbacks}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plugin := &providerHealthPlugin{tracker: newFailingTracker(tc.unhealthy...)}
+			ctx := context.Background()
+			_, shortCircuit, err := plugin.PreHook(&ctx, tc.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if skipped := shortCircuit != nil; skipped != tc.skipped {
+				t.Fatalf("expected skipped=%v, got %v", tc.skipped, skipped)
+			}
+			if tc.skipped && shortCircuit.Error.AllowFallbacks != nil {
+				t.Error("skip errors must leave fallbacks enabled")
+			}
+		})
+	}
+
+	// Probes always reach the provider
+	plugin := &providerHealthPlugin{tracker: newFailingTracker(schemas.OpenAI)}
+	ctx := context.WithValue(context.Background(), lib.ProviderHealthProbeContextKey, true)
+	if _, shortCircuit, _ := plugin.PreHook(&ctx, cases[0].req); shortCircuit != nil {
+		t.Error("expected probe requests to pass through")
+	}
+}
+
+// TestProviderHealthTracker_History tests status transitions, availability and latency over the bounded history
+func TestProviderHealthTracker_History(t *testing.T) {
+	tracker := lib.NewProviderHealthTracker(lib.ProviderHealthConfig{Enabled: true, FailureThreshold: 2, HistorySize: 3})
+	record := func(success bool, latency float64) {
+		tracker.Record(schemas.OpenAI, "gpt-4o-mini", lib.ProviderHealthSample{Timestamp: time.Now(), Success: success, LatencyMs: latency})
+	}
+
+	record(true, 100)
+	record(false, 0)
+	if tracker.IsUnhealthy(schemas.OpenAI) {
+		t.Fatal("expected a single failure to stay below the threshold")
+	}
+	record(false, 0)
+	if !tracker.IsUnhealthy(schemas.OpenAI) {
+		t.Fatal("expected the provider to be unhealthy after 2 consecutive failures")
+	}
+	record(true, 300)
+
+	health := tracker.Snapshot()[0]
+	if health.Status != lib.ProviderHealthStatusHealthy || health.ConsecutiveFailures != 0 {
+		t.Errorf("expected a successful probe to restore the provider, got %+v", health)
+	}
+	if len(health.History) != 3 {
+		t.Fatalf("expected history to be capped at 3 samples, got %d", len(health.History))
+	}
+	if health.Availability != 1.0/3 || health.AvgLatencyMs != 300 {
+		t.Errorf("unexpected availability %v or average latency %v", health.Availability, health.AvgLatencyMs)
+	}
+}
+
+// TestProviderHealthHandler_Route tests that the health route is served next to GET /api/providers/{provider}
+func TestProviderHealthHandler_Route(t *testing.T) {
+	r := router.New()
+	r.GET("/api/providers/{provider}", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusTeapot)
+	})
+	NewProviderHealthHandler(newFailingTracker(schemas.OpenAI), nil, bifrost.NewDefaultLogger(schemas.LogLevelError)).RegisterRoutes(r)
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod("GET")
+	ctx.Request.SetRequestURI("/api/providers/health")
+	r.Handler(ctx)
+
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected the health route to match, got status %d", ctx.Response.StatusCode())
+	}
+	var response ProviderHealthResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !response.Enabled || len(response.Providers) != 1 || response.Providers[0].Status != lib.ProviderHealthStatusUnhealthy {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
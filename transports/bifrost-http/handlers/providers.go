@@ -8,9 +8,12 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"reflect"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fasthttp/router"
@@ -25,9 +28,10 @@ import (
 
 // ProviderHandler manages HTTP requests for provider operations
 type ProviderHandler struct {
-	store  *lib.Config
-	client *bifrost.Bifrost
-	logger schemas.Logger
+	store   *lib.Config
+	client  *bifrost.Bifrost
+	logger  schemas.Logger
+	batchMu sync.Mutex // Serializes the batch key endpoints
 }
 
 // NewProviderHandler creates a new provider handler instance
@@ -41,13 +45,17 @@ func NewProviderHandler(store *lib.Config, client *bifrost.Bifrost, logger schem
 
 // ProviderResponse represents the response for provider operations
 type ProviderResponse struct {
-	Name                     schemas.ModelProvider            `json:"name"`
-	Keys                     []schemas.Key                    `json:"keys"`                             // API keys for the provider
-	NetworkConfig            schemas.NetworkConfig            `json:"network_config"`                   // Network-related settings
-	ConcurrencyAndBufferSize schemas.ConcurrencyAndBufferSize `json:"concurrency_and_buffer_size"`      // Concurrency settings
-	ProxyConfig              *schemas.ProxyConfig             `json:"proxy_config"`                     // Proxy configuration
-	SendBackRawResponse      bool                             `json:"send_back_raw_response"`           // Include raw response in BifrostResponse
-	CustomProviderConfig     *schemas.CustomProviderConfig    `json:"custom_provider_config,omitempty"` // Custom provider configuration
+	Name                     schemas.ModelProvider             `json:"name"`
+	Keys                     []schemas.Key                     `json:"keys"`                             // API keys for the provider
+	NetworkConfig            schemas.NetworkConfig             `json:"network_config"`                   // Network-related settings
+	ConcurrencyAndBufferSize schemas.ConcurrencyAndBufferSize  `json:"concurrency_and_buffer_size"`      // Concurrency settings
+	ProxyConfig              *schemas.ProxyConfig              `json:"proxy_config"`                     // Proxy configuration
+	SendBackRawResponse      bool                              `json:"send_back_raw_response"`           // Include raw response in BifrostResponse
+	CustomProviderConfig     *schemas.CustomProviderConfig     `json:"custom_provider_config,omitempty"` // Custom provider configuration
+	MockConfig               *schemas.MockProviderConfig       `json:"mock_config,omitempty"`            // Mock provider responses
+	RequestShaping           *schemas.RequestShapingConfig     `json:"request_shaping,omitempty"`        // Parameter defaults and overrides of the requests
+	SyntheticStreaming       *schemas.SyntheticStreamingConfig `json:"synthetic_streaming,omitempty"`    // Synthesized streams of the completions the provider cannot stream
+	KeyValidations           map[string]lib.KeyValidation      `json:"key_validations,omitempty"`        // Latest validation result of each key, by key ID
 }
 
 // ListProvidersResponse represents the response for listing all providers
@@ -210,7 +218,11 @@ func (h *ProviderHandler) RegisterRoutes(r *router.Router, middlewares ...lib.Bi
 	r.POST("/api/providers", lib.ChainMiddlewares(h.addProvider, middlewares...))
 	r.PUT("/api/providers/{provider}", lib.ChainMiddlewares(h.updateProvider, middlewares...))
 	r.DELETE("/api/providers/{provider}", lib.ChainMiddlewares(h.deleteProvider, middlewares...))
+	r.POST("/api/providers/{provider}/keys/validate", lib.ChainMiddlewares(h.validateProviderKeys, middlewares...))
 	r.GET("/api/keys", lib.ChainMiddlewares(h.listKeys, middlewares...))
+	r.POST("/api/keys:batchCreate", lib.ChainMiddlewares(h.batchCreateKeys, middlewares...))
+	r.POST("/api/keys:batchUpdate", lib.ChainMiddlewares(h.batchUpdateKeys, middlewares...))
+	r.POST("/api/keys:batchDelete", lib.ChainMiddlewares(h.batchDeleteKeys, middlewares...))
 	// OpenAI-compatible models listing for direct connections from Open WebUI
 	r.GET("/openai/models", lib.ChainMiddlewares(h.listOpenAIModels, middlewares...))
 	r.GET("/openai/v1/models", lib.ChainMiddlewares(h.listOpenAIModels, middlewares...))
@@ -307,7 +319,7 @@ func (h *ProviderHandler) listProviders(ctx *fasthttp.RequestCtx) {
 	for _, provider := range providers {
 		config, err := h.store.GetProviderConfigRedacted(provider)
 		if err != nil {
-			h.logger.Warn(fmt.Sprintf("Failed to get config for provider %s: %v", provider, err))
+			bifrost.WithFields(h.logger, "provider", provider).Warn("failed to get provider config: %v", err)
 			// Include provider even if config fetch fails
 			providerResponses = append(providerResponses, ProviderResponse{
 				Name: provider,
@@ -356,6 +368,9 @@ func (h *ProviderHandler) addProvider(ctx *fasthttp.RequestCtx) {
 		ProxyConfig              *schemas.ProxyConfig              `json:"proxy_config,omitempty"`                // Proxy configuration
 		SendBackRawResponse      *bool                             `json:"send_back_raw_response,omitempty"`      // Include raw response in BifrostResponse
 		CustomProviderConfig     *schemas.CustomProviderConfig     `json:"custom_provider_config,omitempty"`      // Custom provider configuration
+		MockConfig               *schemas.MockProviderConfig       `json:"mock_config,omitempty"`                 // Mock provider responses
+		RequestShaping           *schemas.RequestShapingConfig     `json:"request_shaping,omitempty"`             // Parameter defaults and overrides of the requests
+		SyntheticStreaming       *schemas.SyntheticStreamingConfig `json:"synthetic_streaming,omitempty"`         // Synthesized streams of the completions the provider cannot stream
 	}{}
 
 	if err := json.Unmarshal(ctx.PostBody(), &payload); err != nil {
@@ -388,6 +403,23 @@ func (h *ProviderHandler) addProvider(ctx *fasthttp.RequestCtx) {
 		}
 	}
 
+	if err := lib.ValidateMockProvider(configstore.ProviderConfig{MockConfig: payload.MockConfig}, payload.Provider); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	if err := lib.ValidateRequestShaping(configstore.ProviderConfig{RequestShaping: payload.RequestShaping}); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	if err := lib.ValidateSyntheticStreaming(configstore.ProviderConfig{SyntheticStreaming: payload.SyntheticStreaming}); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	if err := lib.ValidateNetworkConfig(configstore.ProviderConfig{NetworkConfig: payload.NetworkConfig, ProxyConfig: payload.ProxyConfig}); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
 	if payload.ConcurrencyAndBufferSize != nil {
 		if payload.ConcurrencyAndBufferSize.Concurrency == 0 {
 			SendError(ctx, fasthttp.StatusBadRequest, "Concurrency must be greater than 0", h.logger)
@@ -418,21 +450,32 @@ func (h *ProviderHandler) addProvider(ctx *fasthttp.RequestCtx) {
 		ConcurrencyAndBufferSize: payload.ConcurrencyAndBufferSize,
 		SendBackRawResponse:      payload.SendBackRawResponse != nil && *payload.SendBackRawResponse,
 		CustomProviderConfig:     payload.CustomProviderConfig,
+		MockConfig:               payload.MockConfig,
+		RequestShaping:           payload.RequestShaping,
+		SyntheticStreaming:       payload.SyntheticStreaming,
 	}
 
 	// Add provider to store (env vars will be processed by store)
 	if err := h.store.AddProvider(ctx, payload.Provider, config); err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to add provider %s: %v", payload.Provider, err))
+		bifrost.WithFields(h.logger, "provider", payload.Provider).Warn("failed to add provider: %v", err)
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to add provider: %v", err), h.logger)
 		return
 	}
 
-	h.logger.Info(fmt.Sprintf("Provider %s added successfully", payload.Provider))
+	bifrost.WithFields(h.logger, "provider", payload.Provider).Info("provider added")
+
+	// ?validate=true validates the keys with a live call before traffic is routed to them
+	if string(ctx.QueryArgs().Peek("validate")) == "true" {
+		if added, err := h.store.GetProviderConfigRaw(payload.Provider); err == nil {
+			changed, _ := changedKeyIDs(nil, added.Keys)
+			h.validateKeys(ctx, payload.Provider, changed)
+		}
+	}
 
 	// Get redacted config for response
 	redactedConfig, err := h.store.GetProviderConfigRedacted(payload.Provider)
 	if err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to get redacted config for provider %s: %v", payload.Provider, err))
+		bifrost.WithFields(h.logger, "provider", payload.Provider).Warn("failed to get redacted provider config: %v", err)
 		// Fall back to the raw config (no keys)
 		response := h.getProviderResponseFromConfig(payload.Provider, configstore.ProviderConfig{
 			NetworkConfig:            config.NetworkConfig,
@@ -440,6 +483,9 @@ func (h *ProviderHandler) addProvider(ctx *fasthttp.RequestCtx) {
 			ProxyConfig:              config.ProxyConfig,
 			SendBackRawResponse:      config.SendBackRawResponse,
 			CustomProviderConfig:     config.CustomProviderConfig,
+			MockConfig:               config.MockConfig,
+			RequestShaping:           config.RequestShaping,
+			SyntheticStreaming:       config.SyntheticStreaming,
 		})
 		SendJSON(ctx, response, h.logger)
 		return
@@ -464,12 +510,15 @@ func (h *ProviderHandler) updateProvider(ctx *fasthttp.RequestCtx) {
 	}
 
 	var payload = struct {
-		Keys                     []schemas.Key                    `json:"keys"`                             // API keys for the provider
-		NetworkConfig            schemas.NetworkConfig            `json:"network_config"`                   // Network-related settings
-		ConcurrencyAndBufferSize schemas.ConcurrencyAndBufferSize `json:"concurrency_and_buffer_size"`      // Concurrency settings
-		ProxyConfig              *schemas.ProxyConfig             `json:"proxy_config,omitempty"`           // Proxy configuration
-		SendBackRawResponse      *bool                            `json:"send_back_raw_response,omitempty"` // Include raw response in BifrostResponse
-		CustomProviderConfig     *schemas.CustomProviderConfig    `json:"custom_provider_config,omitempty"` // Custom provider configuration
+		Keys                     []schemas.Key                     `json:"keys"`                             // API keys for the provider
+		NetworkConfig            schemas.NetworkConfig             `json:"network_config"`                   // Network-related settings
+		ConcurrencyAndBufferSize schemas.ConcurrencyAndBufferSize  `json:"concurrency_and_buffer_size"`      // Concurrency settings
+		ProxyConfig              *schemas.ProxyConfig              `json:"proxy_config,omitempty"`           // Proxy configuration
+		SendBackRawResponse      *bool                             `json:"send_back_raw_response,omitempty"` // Include raw response in BifrostResponse
+		CustomProviderConfig     *schemas.CustomProviderConfig     `json:"custom_provider_config,omitempty"` // Custom provider configuration
+		MockConfig               *schemas.MockProviderConfig       `json:"mock_config,omitempty"`            // Mock provider responses, kept when omitted
+		RequestShaping           *schemas.RequestShapingConfig     `json:"request_shaping,omitempty"`        // Parameter defaults and overrides, kept when omitted; an empty object removes them
+		SyntheticStreaming       *schemas.SyntheticStreamingConfig `json:"synthetic_streaming,omitempty"`    // Synthesized streams of the completions the provider cannot stream, kept when omitted
 	}{}
 
 	if err := json.Unmarshal(ctx.PostBody(), &payload); err != nil {
@@ -481,7 +530,7 @@ func (h *ProviderHandler) updateProvider(ctx *fasthttp.RequestCtx) {
 	oldConfigRaw, err := h.store.GetProviderConfigRaw(provider)
 	if err != nil {
 		if !errors.Is(err, lib.ErrNotFound) {
-			h.logger.Warn(fmt.Sprintf("Failed to get old config for provider %s: %v", provider, err))
+			bifrost.WithFields(h.logger, "provider", provider).Warn("failed to get old provider config: %v", err)
 			SendError(ctx, fasthttp.StatusInternalServerError, err.Error(), h.logger)
 			return
 		}
@@ -490,11 +539,12 @@ func (h *ProviderHandler) updateProvider(ctx *fasthttp.RequestCtx) {
 	if oldConfigRaw == nil {
 		oldConfigRaw = &configstore.ProviderConfig{}
 	}
+	oldKeys := slices.Clone(oldConfigRaw.Keys)
 
 	oldConfigRedacted, err := h.store.GetProviderConfigRedacted(provider)
 	if err != nil {
 		if !errors.Is(err, lib.ErrNotFound) {
-			h.logger.Warn(fmt.Sprintf("Failed to get old redacted config for provider %s: %v", provider, err))
+			bifrost.WithFields(h.logger, "provider", provider).Warn("failed to get old redacted provider config: %v", err)
 			SendError(ctx, fasthttp.StatusInternalServerError, err.Error(), h.logger)
 			return
 		}
@@ -511,6 +561,9 @@ func (h *ProviderHandler) updateProvider(ctx *fasthttp.RequestCtx) {
 		ConcurrencyAndBufferSize: oldConfigRaw.ConcurrencyAndBufferSize,
 		ProxyConfig:              oldConfigRaw.ProxyConfig,
 		CustomProviderConfig:     oldConfigRaw.CustomProviderConfig,
+		MockConfig:               oldConfigRaw.MockConfig,
+		RequestShaping:           oldConfigRaw.RequestShaping,
+		SyntheticStreaming:       oldConfigRaw.SyntheticStreaming,
 	}
 
 	// Environment variable cleanup is now handled automatically by mergeKeys function
@@ -568,22 +621,61 @@ func (h *ProviderHandler) updateProvider(ctx *fasthttp.RequestCtx) {
 
 	config.ConcurrencyAndBufferSize = &payload.ConcurrencyAndBufferSize
 	config.NetworkConfig = &payload.NetworkConfig
+	// Clients that do not know the HTTP client settings keep the existing ones
+	if config.NetworkConfig.HTTPClient == nil && oldConfigRaw.NetworkConfig != nil {
+		config.NetworkConfig.HTTPClient = oldConfigRaw.NetworkConfig.HTTPClient
+	}
+	// A redacted client key echoed back from GET keeps the stored one
+	if httpClient := config.NetworkConfig.HTTPClient; httpClient != nil && lib.IsRedacted(httpClient.ClientKey) &&
+		oldConfigRaw.NetworkConfig != nil && oldConfigRaw.NetworkConfig.HTTPClient != nil {
+		restored := *httpClient
+		restored.ClientKey = oldConfigRaw.NetworkConfig.HTTPClient.ClientKey
+		config.NetworkConfig.HTTPClient = &restored
+	}
+	if err := lib.ValidateNetworkConfig(config); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
 	config.ProxyConfig = payload.ProxyConfig
 	config.CustomProviderConfig = payload.CustomProviderConfig
 	if payload.SendBackRawResponse != nil {
 		config.SendBackRawResponse = *payload.SendBackRawResponse
 	}
+	if payload.MockConfig != nil {
+		if err := lib.ValidateMockProvider(configstore.ProviderConfig{MockConfig: payload.MockConfig}, provider); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+		config.MockConfig = payload.MockConfig
+	}
+	if payload.RequestShaping != nil {
+		if err := lib.ValidateRequestShaping(configstore.ProviderConfig{RequestShaping: payload.RequestShaping}); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+		config.RequestShaping = payload.RequestShaping
+		if payload.RequestShaping.IsZero() {
+			config.RequestShaping = nil
+		}
+	}
+	if payload.SyntheticStreaming != nil {
+		if err := lib.ValidateSyntheticStreaming(configstore.ProviderConfig{SyntheticStreaming: payload.SyntheticStreaming}); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+			return
+		}
+		config.SyntheticStreaming = payload.SyntheticStreaming
+	}
 
 	// Update provider config in store (env vars will be processed by store)
 	if err := h.store.UpdateProviderConfig(ctx, provider, config); err != nil {
 		if !errors.Is(err, lib.ErrNotFound) {
-			h.logger.Warn(fmt.Sprintf("Failed to update provider %s: %v", provider, err))
+			bifrost.WithFields(h.logger, "provider", provider).Warn("failed to update provider: %v", err)
 			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update provider: %v", err), h.logger)
 			return
 		}
 		// Creating provider instance with current config
 		if addErr := h.store.AddProvider(ctx, provider, config); addErr != nil {
-			h.logger.Warn(fmt.Sprintf("Failed to add provider %s: %v", provider, addErr))
+			bifrost.WithFields(h.logger, "provider", provider).Warn("failed to add provider: %v", addErr)
 			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to upsert provider: %v", addErr), h.logger)
 			return
 		}
@@ -594,19 +686,40 @@ func (h *ProviderHandler) updateProvider(ctx *fasthttp.RequestCtx) {
 		oldConcurrencyAndBufferSize = oldConfigRaw.ConcurrencyAndBufferSize
 	}
 
+	// The mock provider reads its responses, declarative providers their spec, and every provider builds its HTTP
+	// clients and proxy and reads its request shaping and synthetic streaming when created, so they are applied by
+	// recreating it
 	if config.ConcurrencyAndBufferSize.Concurrency != oldConcurrencyAndBufferSize.Concurrency ||
-		config.ConcurrencyAndBufferSize.BufferSize != oldConcurrencyAndBufferSize.BufferSize {
+		config.ConcurrencyAndBufferSize.BufferSize != oldConcurrencyAndBufferSize.BufferSize ||
+		!reflect.DeepEqual(config.MockConfig, oldConfigRaw.MockConfig) ||
+		!reflect.DeepEqual(config.RequestShaping, oldConfigRaw.RequestShaping) ||
+		!reflect.DeepEqual(config.SyntheticStreaming, oldConfigRaw.SyntheticStreaming) ||
+		!reflect.DeepEqual(providerSpec(config.CustomProviderConfig), providerSpec(oldConfigRaw.CustomProviderConfig)) ||
+		!reflect.DeepEqual(httpClientConfig(config.NetworkConfig), httpClientConfig(oldConfigRaw.NetworkConfig)) ||
+		!reflect.DeepEqual(config.ProxyConfig, oldConfigRaw.ProxyConfig) {
 		// Update concurrency and queue configuration in Bifrost
 		if err := h.client.UpdateProviderConcurrency(provider); err != nil {
 			// Note: Store update succeeded, continue but log the concurrency update failure
-			h.logger.Warn(fmt.Sprintf("Failed to update concurrency for provider %s: %v", provider, err))
+			bifrost.WithFields(h.logger, "provider", provider).Warn("failed to update provider concurrency: %v", err)
+		}
+	}
+
+	// Validation results of removed or changed keys no longer apply, ?validate=true validates the changed keys
+	// with a live call before traffic is routed to them
+	if updated, err := h.store.GetProviderConfigRaw(provider); err == nil {
+		changed, removed := changedKeyIDs(oldKeys, updated.Keys)
+		if err := h.store.UpdateKeyValidations(ctx, nil, append(changed, removed...)); err != nil {
+			bifrost.WithFields(h.logger, "provider", provider).Warn("failed to clear key validations: %v", err)
+		}
+		if string(ctx.QueryArgs().Peek("validate")) == "true" {
+			h.validateKeys(ctx, provider, changed)
 		}
 	}
 
 	// Get redacted config for response
 	redactedConfig, err := h.store.GetProviderConfigRedacted(provider)
 	if err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to get redacted config for provider %s: %v", provider, err))
+		bifrost.WithFields(h.logger, "provider", provider).Warn("failed to get redacted provider config: %v", err)
 		// Fall back to sanitized config (no keys)
 		response := h.getProviderResponseFromConfig(provider, configstore.ProviderConfig{
 			NetworkConfig:            config.NetworkConfig,
@@ -614,6 +727,9 @@ func (h *ProviderHandler) updateProvider(ctx *fasthttp.RequestCtx) {
 			ProxyConfig:              config.ProxyConfig,
 			SendBackRawResponse:      config.SendBackRawResponse,
 			CustomProviderConfig:     config.CustomProviderConfig,
+			MockConfig:               config.MockConfig,
+			RequestShaping:           config.RequestShaping,
+			SyntheticStreaming:       config.SyntheticStreaming,
 		})
 		SendJSON(ctx, response, h.logger)
 		return
@@ -633,19 +749,25 @@ func (h *ProviderHandler) deleteProvider(ctx *fasthttp.RequestCtx) {
 	}
 
 	// Check if provider exists
-	if _, err := h.store.GetProviderConfigRedacted(provider); err != nil {
+	existing, err := h.store.GetProviderConfigRedacted(provider)
+	if err != nil {
 		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Provider not found: %v", err), h.logger)
 		return
 	}
 
 	// Remove provider from store
 	if err := h.store.RemoveProvider(ctx, provider); err != nil {
-		h.logger.Warn(fmt.Sprintf("Failed to remove provider %s: %v", provider, err))
+		bifrost.WithFields(h.logger, "provider", provider).Warn("failed to remove provider: %v", err)
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to remove provider: %v", err), h.logger)
 		return
 	}
 
-	h.logger.Info(fmt.Sprintf("Provider %s removed successfully", provider))
+	bifrost.WithFields(h.logger, "provider", provider).Info("provider removed")
+
+	_, removed := changedKeyIDs(existing.Keys, nil)
+	if err := h.store.UpdateKeyValidations(ctx, nil, removed); err != nil {
+		bifrost.WithFields(h.logger, "provider", provider).Warn("failed to clear key validations: %v", err)
+	}
 
 	response := ProviderResponse{
 		Name: provider,
@@ -654,15 +776,34 @@ func (h *ProviderHandler) deleteProvider(ctx *fasthttp.RequestCtx) {
 	SendJSON(ctx, response, h.logger)
 }
 
+// keyListSpec is the list spec of GET /api/keys, see pagination.go
+var keyListSpec = &listSpec[configstore.TableKey]{
+	id: func(key configstore.TableKey) string { return key.KeyID },
+	fields: map[string]listField[configstore.TableKey]{
+		"provider": {value: func(key configstore.TableKey) string { return key.Provider }},
+		"weight":   {value: func(key configstore.TableKey) string { return strconv.FormatFloat(key.Weight, 'f', -1, 64) }, numeric: true},
+	},
+	defaultSort: "provider",
+}
+
 // listKeys handles GET /api/keys - List all keys
+// The response stays a bare array; total and next cursor are returned in the X-Total-Count and X-Next-Cursor headers.
 func (h *ProviderHandler) listKeys(ctx *fasthttp.RequestCtx) {
+	query, err := parseListQuery(ctx, keyListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
 	keys, err := h.store.GetAllKeys()
 	if err != nil {
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get keys: %v", err), h.logger)
 		return
 	}
 
-	SendJSON(ctx, keys, h.logger)
+	page := paginate(keys, keyListSpec, query)
+	setListPageHeaders(ctx, page)
+	SendJSON(ctx, page.items, h.logger)
 }
 
 // mergeKeys merges new keys with old, preserving values that are redacted in the new config
@@ -816,6 +957,10 @@ func (h *ProviderHandler) getProviderResponseFromConfig(provider schemas.ModelPr
 		ProxyConfig:              config.ProxyConfig,
 		SendBackRawResponse:      config.SendBackRawResponse,
 		CustomProviderConfig:     config.CustomProviderConfig,
+		MockConfig:               config.MockConfig,
+		RequestShaping:           config.RequestShaping,
+		SyntheticStreaming:       config.SyntheticStreaming,
+		KeyValidations:           h.store.GetKeyValidations(config.Keys),
 	}
 }
 
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// PublicRoutesHandler manages the public route rules.
+type PublicRoutesHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// PublicRoutesRequest is the body of PUT /api/public-routes.
+type PublicRoutesRequest struct {
+	Rules []lib.PublicRoute `json:"rules"`
+}
+
+// PublicRoutesResponse is the response of GET and PUT /api/public-routes.
+type PublicRoutesResponse struct {
+	Rules    []lib.PublicRoute `json:"rules"`
+	Defaults []lib.PublicRoute `json:"defaults"`
+}
+
+// NewPublicRoutesHandler creates a new public routes handler.
+func NewPublicRoutesHandler(store *lib.Config, logger schemas.Logger) *PublicRoutesHandler {
+	return &PublicRoutesHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the public routes routes.
+func (h *PublicRoutesHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/public-routes", lib.ChainMiddlewares(h.getPublicRoutes, middlewares...))
+	r.PUT("/api/public-routes", lib.ChainMiddlewares(h.updatePublicRoutes, middlewares...))
+}
+
+// getPublicRoutes handles GET /api/public-routes - Get the public route rules and the defaults they override
+func (h *PublicRoutesHandler) getPublicRoutes(ctx *fasthttp.RequestCtx) {
+	rules := h.store.GetPublicRoutes()
+	if rules == nil {
+		rules = []lib.PublicRoute{}
+	}
+	SendJSON(ctx, PublicRoutesResponse{Rules: rules, Defaults: lib.DefaultPublicRoutes}, h.logger)
+}
+
+// updatePublicRoutes handles PUT /api/public-routes - Replace the public route rules
+func (h *PublicRoutesHandler) updatePublicRoutes(ctx *fasthttp.RequestCtx) {
+	var req PublicRoutesRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Rules == nil {
+		req.Rules = []lib.PublicRoute{}
+	}
+	if err := lib.ValidatePublicRoutes(req.Rules); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid public routes: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdatePublicRoutes(ctx, req.Rules); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update public routes: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, PublicRoutesResponse{Rules: req.Rules, Defaults: lib.DefaultPublicRoutes}, h.logger)
+}
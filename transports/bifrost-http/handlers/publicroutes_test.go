@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestAdminAuthMiddleware_PublicRoutes tests that configured public route rules lock down default public routes
+// and expose others, while the login page stays reachable and the management API cannot be exposed
+func TestAdminAuthMiddleware_PublicRoutes(t *testing.T) {
+	config := &lib.Config{AdminSecret: "admin-secret", AdminCookieName: "bf_admin"}
+	run := func(method, path string) int {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(path)
+		AdminAuthMiddleware(config, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusOK)
+		})(ctx)
+		return ctx.Response.StatusCode()
+	}
+
+	if got := run(fasthttp.MethodGet, "/metrics"); got != fasthttp.StatusOK {
+		t.Fatalf("expected /metrics to be public by default, got %d", got)
+	}
+
+	err := config.UpdatePublicRoutes(context.Background(), []lib.PublicRoute{
+		{Method: "GET", Path: "/metrics", Public: false},
+		{Method: "GET", Path: "/v1/models", Public: true},
+		{Path: "/v1/*/batches", Public: false},
+		{Method: "*", Path: "/login*", Public: false},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePublicRoutes() error = %v", err)
+	}
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{fasthttp.MethodGet, "/metrics", fasthttp.StatusFound},
+		{fasthttp.MethodGet, "/v1/models", fasthttp.StatusOK},
+		{fasthttp.MethodPost, "/v1/chat/completions", fasthttp.StatusOK},
+		{fasthttp.MethodPost, "/v1/openai/batches", fasthttp.StatusUnauthorized},
+		{fasthttp.MethodGet, "/health", fasthttp.StatusOK},
+		{fasthttp.MethodGet, "/login/", fasthttp.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := run(tt.method, tt.path); got != tt.want {
+			t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	for _, rules := range [][]lib.PublicRoute{
+		{{Path: "/api/*", Public: true}},
+		{{Method: "POST", Path: "/api/*", Public: true}},
+		{{Method: "GET", Path: "/api/providers/*", Public: true}},
+		{{Method: "PUT", Path: "/api/governance", Public: true}},
+		{{Method: "POST", Path: "/api/apply", Public: true}},
+		{{Method: "PUT", Path: "/api/public-routes", Public: true}},
+		{{Path: "/ws", Public: true}},
+		{{Path: "/*/version", Public: true}},
+		{{Path: "/a*", Public: true}},
+		{{Method: "GET", Path: "/*", Public: true}},
+		{{Path: "metrics", Public: false}},
+		{{Path: "/v1/[", Public: true}},
+	} {
+		if err := lib.ValidatePublicRoutes(rules); err == nil {
+			t.Errorf("expected %+v to be rejected", rules)
+		}
+	}
+	if err := lib.ValidatePublicRoutes([]lib.PublicRoute{{Method: "GET", Path: "/api/version", Public: true}, {Path: "/api/*", Public: false}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
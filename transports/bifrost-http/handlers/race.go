@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// RaceHandler reports how often race and hedge rules sent requests to a second provider, and how often it won.
+type RaceHandler struct {
+	racer  *lib.Racer
+	logger schemas.Logger
+}
+
+// RaceStatsResponse is the response of GET /api/race/stats.
+type RaceStatsResponse struct {
+	Enabled bool                `json:"enabled"` // Whether race rules are configured
+	Rules   []lib.RaceRuleStats `json:"rules"`
+}
+
+// NewRaceHandler creates a new race handler; racer is nil when no race rules are configured.
+func NewRaceHandler(racer *lib.Racer, logger schemas.Logger) *RaceHandler {
+	return &RaceHandler{
+		racer:  racer,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the race routes.
+func (h *RaceHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/race/stats", lib.ChainMiddlewares(h.getRaceStats, middlewares...))
+}
+
+// getRaceStats handles GET /api/race/stats - Get the hedge rate and win rate of every race rule
+func (h *RaceHandler) getRaceStats(ctx *fasthttp.RequestCtx) {
+	response := RaceStatsResponse{Rules: []lib.RaceRuleStats{}}
+	if h.racer != nil {
+		response.Enabled = true
+		response.Rules = h.racer.Stats()
+	}
+	SendJSON(ctx, response, h.logger)
+}
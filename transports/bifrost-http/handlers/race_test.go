@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// raceTestAccount configures the mock provider only
+type raceTestAccount struct{}
+
+func (raceTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{schemas.Mock}, nil
+}
+
+func (raceTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	return nil, nil
+}
+
+func (raceTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	if providerKey != schemas.Mock {
+		return nil, fmt.Errorf("provider %s is not configured", providerKey)
+	}
+	return &schemas.ProviderConfig{
+		ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+		MockConfig:               &schemas.MockProviderConfig{TokenDelayMs: 5},
+	}, nil
+}
+
+// raceTestPlugin slows down or rejects the attempts of some models and reports the slowed down attempts that are cancelled
+type raceTestPlugin struct {
+	delays    map[string]time.Duration
+	rejected  map[string]bool
+	cancelled chan string // Models of the cancelled attempts
+}
+
+func (p *raceTestPlugin) GetName() string {
+	return "race-test"
+}
+
+func (p *raceTestPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+func (p *raceTestPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if p.rejected[req.Model] {
+		return req, &schemas.PluginShortCircuit{Error: &schemas.BifrostError{
+			AllowFallbacks: bifrost.Ptr(false),
+			Error:          &schemas.ErrorField{Type: bifrost.Ptr("rejected"), Message: "rejected by policy"},
+		}}, nil
+	}
+	select {
+	case <-time.After(p.delays[req.Model]):
+	case <-(*ctx).Done():
+		// Cancelled attempts end here, so they do not outlive the client of the test
+		p.cancelled <- req.Model
+		return req, &schemas.PluginShortCircuit{Error: &schemas.BifrostError{
+			Error: &schemas.ErrorField{Type: bifrost.Ptr(schemas.RequestCancelled), Message: "cancelled"},
+		}}, nil
+	}
+	return req, nil, nil
+}
+
+func (p *raceTestPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+func (p *raceTestPlugin) Cleanup() error {
+	return nil
+}
+
+// newRaceTestClient returns a client racing every request against mock/fast, after the hedge delay
+func newRaceTestClient(t *testing.T, plugin *raceTestPlugin, hedgeDelay time.Duration, onOutcome func(challengerSent, challengerWon bool)) *bifrost.Bifrost {
+	t.Helper()
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: raceTestAccount{},
+		Plugins: []schemas.Plugin{plugin},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+		RaceSelector: func(ctx *context.Context, req *schemas.BifrostRequest) *schemas.RaceChallenger {
+			return &schemas.RaceChallenger{Provider: schemas.Mock, Model: "fast", Delay: hedgeDelay, OnOutcome: onOutcome}
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+	return client
+}
+
+// expectRaceCancellation waits for the attempt of the model to be cancelled
+func expectRaceCancellation(t *testing.T, plugin *raceTestPlugin, model string) {
+	t.Helper()
+	select {
+	case got := <-plugin.cancelled:
+		if got != model {
+			t.Errorf("expected the %s attempt to be cancelled, got %s", model, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the %s attempt to be cancelled", model)
+	}
+}
+
+// TestRace_FirstAttemptWins tests that the fastest of the raced providers answers and the other attempt is cancelled
+func TestRace_FirstAttemptWins(t *testing.T) {
+	plugin := &raceTestPlugin{delays: map[string]time.Duration{"slow": time.Second}, cancelled: make(chan string, 16)}
+	client := newRaceTestClient(t, plugin, 0, nil)
+	request := func() *schemas.BifrostChatRequest {
+		return &schemas.BifrostChatRequest{Provider: schemas.Mock, Model: "slow", Input: []schemas.ChatMessage{
+			{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Hello there")}},
+		}}
+	}
+
+	resp, bifrostErr := client.ChatCompletionRequest(context.Background(), request())
+	if bifrostErr != nil {
+		t.Fatalf("unexpected error: %v", bifrostErr.Error.Message)
+	}
+	if resp.ExtraFields.ModelRequested != "fast" {
+		t.Errorf("expected the fast model to win, got %s", resp.ExtraFields.ModelRequested)
+	}
+	expectRaceCancellation(t, plugin, "slow")
+
+	stream, bifrostErr := client.ChatCompletionStreamRequest(context.Background(), request())
+	if bifrostErr != nil {
+		t.Fatalf("unexpected error: %v", bifrostErr.Error.Message)
+	}
+	chunks := 0
+	for msg := range stream {
+		if msg.BifrostError != nil {
+			t.Fatalf("unexpected stream error: %v", msg.BifrostError.Error.Message)
+		}
+		if msg.BifrostResponse.ExtraFields.ModelRequested != "fast" {
+			t.Errorf("expected every chunk to come from the fast model, got %s", msg.BifrostResponse.ExtraFields.ModelRequested)
+		}
+		chunks++
+	}
+	if chunks < 2 {
+		t.Errorf("expected the whole stream, got %d chunks", chunks)
+	}
+	expectRaceCancellation(t, plugin, "slow")
+}
+
+// TestRace_PrimaryRejectionEndsRace tests that errors not allowing fallbacks are not overridden by the challenger
+func TestRace_PrimaryRejectionEndsRace(t *testing.T) {
+	plugin := &raceTestPlugin{
+		delays:    map[string]time.Duration{"fast": 200 * time.Millisecond},
+		rejected:  map[string]bool{"blocked": true},
+		cancelled: make(chan string, 16),
+	}
+	client := newRaceTestClient(t, plugin, 0, nil)
+	request := &schemas.BifrostChatRequest{Provider: schemas.Mock, Model: "blocked", Input: []schemas.ChatMessage{
+		{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Hello there")}},
+	}}
+	if _, bifrostErr := client.ChatCompletionRequest(context.Background(), request); bifrostErr == nil || bifrostErr.Error.Message != "rejected by policy" {
+		t.Fatalf("expected the policy rejection, got %+v", bifrostErr)
+	}
+	expectRaceCancellation(t, plugin, "fast")
+	if _, bifrostErr := client.ChatCompletionStreamRequest(context.Background(), request); bifrostErr == nil || bifrostErr.Error.Message != "rejected by policy" {
+		t.Fatalf("expected the policy rejection of the stream, got %+v", bifrostErr)
+	}
+	expectRaceCancellation(t, plugin, "fast")
+}
+
+// TestRacer_Select tests which requests race rules apply to
+func TestRacer_Select(t *testing.T) {
+	slos := lib.NewSLOTracker(lib.SLOConfig{Objectives: []lib.SLOObjective{
+		{Name: "latency", Indicator: lib.SLOIndicatorLatency, ThresholdMs: 1000, Target: 99, Providers: []schemas.ModelProvider{schemas.OpenAI}},
+	}})
+	never := 0.0
+	config := lib.RaceConfig{Rules: []lib.RaceRule{
+		{Name: "sampled-out", Provider: schemas.OpenAI, Models: []string{"gpt-4o-mini"}, Against: "groq/llama-3.3-70b-versatile", SampleRate: &never},
+		{Name: "at-risk", Provider: schemas.OpenAI, Against: "anthropic/claude-sonnet-4", OnlyWhenSLOAtRisk: true},
+		{Name: "always", Provider: schemas.Azure, Against: "openai/gpt-4o"},
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	racer := lib.NewRacer(config, slos)
+	ctx := context.Background()
+
+	if challenger := racer.Select(&ctx, &schemas.BifrostRequest{Provider: schemas.Azure, Model: "gpt-4o"}); challenger == nil || challenger.Provider != schemas.OpenAI || challenger.Model != "gpt-4o" {
+		t.Errorf("expected azure to race openai/gpt-4o, got %+v", challenger)
+	}
+	if challenger := racer.Select(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o-mini"}); challenger != nil {
+		t.Errorf("expected the first matching rule to apply and sample the request out, got %+v", challenger)
+	}
+	if challenger := racer.Select(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}); challenger != nil {
+		t.Errorf("expected no race while the SLO is met, got %+v", challenger)
+	}
+	slos.Record(lib.SLOEvent{Provider: schemas.OpenAI, Model: "gpt-4o", Latency: 5 * time.Second})
+	if challenger := racer.Select(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}); challenger == nil || challenger.Provider != schemas.Anthropic {
+		t.Errorf("expected a race once the SLO is at risk, got %+v", challenger)
+	}
+	probeCtx := context.WithValue(ctx, lib.ProviderHealthProbeContextKey, true)
+	if challenger := racer.Select(&probeCtx, &schemas.BifrostRequest{Provider: schemas.Azure, Model: "gpt-4o"}); challenger != nil {
+		t.Errorf("expected health probes not to race, got %+v", challenger)
+	}
+
+	for _, invalid := range []lib.RaceRule{
+		{Name: "no-provider", Against: "openai/gpt-4o"},
+		{Name: "no-model", Provider: schemas.Azure, Against: "openai"},
+		{Name: "bad-rate", Provider: schemas.Azure, Against: "openai/gpt-4o", SampleRate: bifrost.Ptr(1.5)},
+		{Name: "race-without-against", Provider: schemas.Azure},
+	} {
+		if err := (&lib.RaceConfig{Rules: []lib.RaceRule{invalid}}).Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", invalid.Name)
+		}
+	}
+}
+
+// raceOutcome is the outcome reported by a race
+type raceOutcome struct {
+	sent, won bool
+}
+
+// TestHedge_SentOnlyAfterDelay tests that the hedge is only sent when the primary provider has not answered in time
+func TestHedge_SentOnlyAfterDelay(t *testing.T) {
+	plugin := &raceTestPlugin{delays: map[string]time.Duration{"slow": time.Second}, cancelled: make(chan string, 16)}
+	outcomes := make(chan raceOutcome, 16)
+	client := newRaceTestClient(t, plugin, 100*time.Millisecond, func(challengerSent, challengerWon bool) {
+		outcomes <- raceOutcome{challengerSent, challengerWon}
+	})
+	request := func(model string) *schemas.BifrostChatRequest {
+		return &schemas.BifrostChatRequest{Provider: schemas.Mock, Model: model, Input: []schemas.ChatMessage{
+			{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Hello there")}},
+		}}
+	}
+
+	resp, bifrostErr := client.ChatCompletionRequest(context.Background(), request("quick"))
+	if bifrostErr != nil || resp.ExtraFields.ModelRequested != "quick" {
+		t.Fatalf("expected the primary provider to answer, got %+v %+v", resp, bifrostErr)
+	}
+	if outcome := <-outcomes; outcome.sent {
+		t.Errorf("expected no hedge when the primary provider answers in time, got %+v", outcome)
+	}
+
+	resp, bifrostErr = client.ChatCompletionRequest(context.Background(), request("slow"))
+	if bifrostErr != nil || resp.ExtraFields.ModelRequested != "fast" {
+		t.Fatalf("expected the hedge to answer, got %+v %+v", resp, bifrostErr)
+	}
+	if outcome := <-outcomes; !outcome.sent || !outcome.won {
+		t.Errorf("expected the hedge to be sent and win, got %+v", outcome)
+	}
+	expectRaceCancellation(t, plugin, "slow")
+
+	stream, bifrostErr := client.ChatCompletionStreamRequest(context.Background(), request("quick"))
+	if bifrostErr != nil {
+		t.Fatalf("unexpected error: %v", bifrostErr.Error.Message)
+	}
+	for msg := range stream {
+		if msg.BifrostError != nil || msg.BifrostResponse.ExtraFields.ModelRequested != "quick" {
+			t.Fatalf("expected the stream of the primary provider, got %+v", msg)
+		}
+	}
+	if outcome := <-outcomes; outcome.sent {
+		t.Errorf("expected no hedge when the primary stream starts in time, got %+v", outcome)
+	}
+
+	stream, bifrostErr = client.ChatCompletionStreamRequest(context.Background(), request("slow"))
+	if bifrostErr != nil {
+		t.Fatalf("unexpected error: %v", bifrostErr.Error.Message)
+	}
+	for msg := range stream {
+		if msg.BifrostError != nil || msg.BifrostResponse.ExtraFields.ModelRequested != "fast" {
+			t.Fatalf("expected the stream of the hedge, got %+v", msg)
+		}
+	}
+	if outcome := <-outcomes; !outcome.sent || !outcome.won {
+		t.Errorf("expected the hedge stream to be sent and win, got %+v", outcome)
+	}
+	expectRaceCancellation(t, plugin, "slow")
+}
+
+// TestRaceHandler_Stats tests that hedges default to the first fallback and that hedge and win rates are reported
+func TestRaceHandler_Stats(t *testing.T) {
+	racer := lib.NewRacer(lib.RaceConfig{Rules: []lib.RaceRule{{Name: "hedge-openai", Provider: schemas.OpenAI, HedgeAfterMs: 800}}}, nil)
+	ctx := context.Background()
+	if challenger := racer.Select(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"}); challenger != nil {
+		t.Errorf("expected no hedge without a fallback, got %+v", challenger)
+	}
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Fallbacks: []schemas.Fallback{{Provider: schemas.Azure, Model: "gpt-4o"}}}
+	for _, outcome := range []raceOutcome{{false, false}, {false, false}, {true, false}, {true, true}} {
+		challenger := racer.Select(&ctx, req)
+		if challenger == nil || challenger.Provider != schemas.Azure || challenger.Delay != 800*time.Millisecond {
+			t.Fatalf("expected a hedge to the first fallback after 800ms, got %+v", challenger)
+		}
+		challenger.OnOutcome(outcome.sent, outcome.won)
+	}
+
+	httpCtx := &fasthttp.RequestCtx{}
+	NewRaceHandler(racer, bifrost.NewDefaultLogger(schemas.LogLevelError)).getRaceStats(httpCtx)
+	var response RaceStatsResponse
+	if err := json.Unmarshal(httpCtx.Response.Body(), &response); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if !response.Enabled || len(response.Rules) != 1 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	if stats := response.Rules[0]; stats.Requests != 4 || stats.ChallengersSent != 2 || stats.ChallengerWins != 1 || stats.SentRate != 0.5 || stats.WinRate != 0.5 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+const rateLimitHeadersPluginName = "bifrost-rate-limit-headers"
+
+// rateLimitHeadersPlugin reports the rate limits and budgets left to the virtual key of a request in its response
+// headers (see lib.RateLimitLimitHeader). It runs before governance, so requests governance rejects report their
+// exhausted limits too.
+type rateLimitHeadersPlugin struct {
+	governanceStore *governance.GovernanceStore
+}
+
+// GetName returns the name of the plugin
+func (p *rateLimitHeadersPlugin) GetName() string {
+	return rateLimitHeadersPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *rateLimitHeadersPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook sets the quota headers of the virtual key of the request, counting the request itself
+func (p *rateLimitHeadersPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	responseHeaders, _ := (*ctx).Value(lib.ResponseHeadersContextKey).(*lib.ResponseHeaders)
+	vkValue, _ := (*ctx).Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	if responseHeaders == nil || vkValue == "" {
+		return req, nil, nil
+	}
+	vk, ok := p.governanceStore.GetVirtualKey(vkValue)
+	if !ok {
+		return req, nil, nil
+	}
+	quota := p.governanceStore.QuotaOfVirtualKey(vk, time.Now())
+	if quota.Requests != nil {
+		requests := *quota.Requests
+		requests.Remaining = max(requests.Remaining-1, 0)
+		quota.Requests = &requests
+	}
+	setQuotaHeaders(responseHeaders, quota)
+	return req, nil, nil
+}
+
+// PostHook is not used for this plugin
+func (p *rateLimitHeadersPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *rateLimitHeadersPlugin) Cleanup() error {
+	return nil
+}
+
+// setQuotaHeaders sets the headers of the request and token limits, and of the budget, of a quota. The RateLimit
+// headers report the request or token limit with the smallest share left.
+func setQuotaHeaders(headers *lib.ResponseHeaders, quota governance.Quota) {
+	closest := quota.Requests
+	if tokens := quota.Tokens; tokens != nil && (closest == nil || tokens.Remaining*closest.Limit < closest.Remaining*tokens.Limit) {
+		closest = tokens
+	}
+	if closest != nil {
+		limit, remaining, reset := quotaHeaderValues(closest)
+		headers.Set(lib.RateLimitLimitHeader, limit)
+		headers.Set(lib.RateLimitRemainingHeader, remaining)
+		headers.Set(lib.RateLimitResetHeader, reset)
+		headers.Set(lib.XRateLimitLimitHeader, limit)
+		headers.Set(lib.XRateLimitRemainingHeader, remaining)
+		headers.Set(lib.XRateLimitResetHeader, reset)
+	}
+	if quota.Requests != nil {
+		limit, remaining, reset := quotaHeaderValues(quota.Requests)
+		headers.Set(lib.XRateLimitLimitRequestsHeader, limit)
+		headers.Set(lib.XRateLimitRemainingRequestsHeader, remaining)
+		headers.Set(lib.XRateLimitResetRequestsHeader, reset)
+	}
+	if quota.Tokens != nil {
+		limit, remaining, reset := quotaHeaderValues(quota.Tokens)
+		headers.Set(lib.XRateLimitLimitTokensHeader, limit)
+		headers.Set(lib.XRateLimitRemainingTokensHeader, remaining)
+		headers.Set(lib.XRateLimitResetTokensHeader, reset)
+	}
+	if quota.Budget != nil {
+		_, _, reset := quotaHeaderValues(quota.Budget)
+		headers.Set(lib.BudgetLimitHeader, formatCost(quota.Budget.Limit))
+		headers.Set(lib.BudgetRemainingHeader, formatCost(quota.Budget.Remaining))
+		headers.Set(lib.BudgetResetHeader, reset)
+	}
+}
+
+// quotaHeaderValues formats the limit, remaining quota and seconds until reset, rounded up, of a window
+func quotaHeaderValues(window *governance.QuotaWindow) (string, string, string) {
+	reset := int64(math.Ceil(window.Reset.Seconds()))
+	return strconv.FormatInt(int64(window.Limit), 10), strconv.FormatInt(int64(window.Remaining), 10), strconv.FormatInt(reset, 10)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// TestRateLimitHeadersPlugin tests that responses report the request and token limits and the budget left to the
+// virtual key across its hierarchy, the RateLimit headers following the limit closest to exhaustion, and that
+// limits in shadow mode are left out
+func TestRateLimitHeadersPlugin(t *testing.T) {
+	now := time.Now()
+	plugin, err := governance.Init(context.Background(), &governance.Config{}, bifrost.NewDefaultLogger(schemas.LogLevelError), nil, &configstore.GovernanceConfig{
+		VirtualKeys: []configstore.TableVirtualKey{
+			{ID: "limited", Value: "sk-bf-limited", IsActive: true, TeamID: bifrost.Ptr("team"), RateLimitID: bifrost.Ptr("vk-rl")},
+			{ID: "exhausted", Value: "sk-bf-exhausted", IsActive: true, RateLimitID: bifrost.Ptr("exhausted-rl")},
+			{ID: "shadowed", Value: "sk-bf-shadowed", IsActive: true, RateLimitID: bifrost.Ptr("shadow-rl")},
+		},
+		Teams: []configstore.TableTeam{
+			{ID: "team", Name: "team", BudgetID: bifrost.Ptr("team-budget")},
+		},
+		Budgets: []configstore.TableBudget{
+			{ID: "team-budget", MaxLimit: 10, CurrentUsage: 2.5, ResetDuration: "1d", LastReset: now},
+		},
+		RateLimits: []configstore.TableRateLimit{
+			{
+				ID:              "vk-rl",
+				RequestMaxLimit: bifrost.Ptr(int64(10)), RequestCurrentUsage: 4, RequestResetDuration: bifrost.Ptr("1m"), RequestLastReset: now,
+				TokenMaxLimit: bifrost.Ptr(int64(1000)), TokenCurrentUsage: 900, TokenResetDuration: bifrost.Ptr("1h"), TokenLastReset: now,
+			},
+			{ID: "exhausted-rl", RequestMaxLimit: bifrost.Ptr(int64(5)), RequestCurrentUsage: 5, RequestResetDuration: bifrost.Ptr("1m"), RequestLastReset: now.Add(-30 * time.Second)},
+			{ID: "shadow-rl", RequestMaxLimit: bifrost.Ptr(int64(5)), RequestCurrentUsage: 5, RequestResetDuration: bifrost.Ptr("1m"), RequestLastReset: now, Shadow: true},
+		},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to init governance: %v", err)
+	}
+	headersPlugin := &rateLimitHeadersPlugin{governanceStore: plugin.GetGovernanceStore()}
+
+	preHook := func(virtualKey string) *lib.ResponseHeaders {
+		t.Helper()
+		headers := &lib.ResponseHeaders{}
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, virtualKey)
+		ctx = context.WithValue(ctx, lib.ResponseHeadersContextKey, headers)
+		if _, shortCircuit, err := headersPlugin.PreHook(&ctx, &schemas.BifrostRequest{}); err != nil || shortCircuit != nil {
+			t.Fatalf("unexpected error or short circuit: %v %+v", err, shortCircuit)
+		}
+		return headers
+	}
+
+	headers := preHook("sk-bf-limited")
+	for name, want := range map[string]string{
+		lib.RateLimitLimitHeader:              "1000",
+		lib.RateLimitRemainingHeader:          "100",
+		lib.RateLimitResetHeader:              "3600",
+		lib.XRateLimitRemainingHeader:         "100",
+		lib.XRateLimitLimitRequestsHeader:     "10",
+		lib.XRateLimitRemainingRequestsHeader: "5",
+		lib.XRateLimitResetRequestsHeader:     "60",
+		lib.XRateLimitRemainingTokensHeader:   "100",
+		lib.BudgetLimitHeader:                 "10",
+		lib.BudgetRemainingHeader:             "7.5",
+		lib.BudgetResetHeader:                 "86400",
+	} {
+		if got := headers.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	headers = preHook("sk-bf-exhausted")
+	if got := headers.Get(lib.RateLimitRemainingHeader); got != "0" {
+		t.Errorf("%s = %q for an exhausted limit, want 0", lib.RateLimitRemainingHeader, got)
+	}
+	if got := headers.Get(lib.RateLimitResetHeader); got != "30" {
+		t.Errorf("%s = %q, want the 30 seconds left of the window", lib.RateLimitResetHeader, got)
+	}
+	if got := headers.Get(lib.BudgetLimitHeader); got != "" {
+		t.Errorf("%s = %q without a budget, want none", lib.BudgetLimitHeader, got)
+	}
+
+	for _, virtualKey := range []string{"sk-bf-shadowed", "sk-bf-unknown"} {
+		if got := preHook(virtualKey).Get(lib.RateLimitLimitHeader); got != "" {
+			t.Errorf("%s = %q for %s, want none", lib.RateLimitLimitHeader, got, virtualKey)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/core/schemas/providers/anthropic"
+	"github.com/maximhq/bifrost/core/schemas/providers/openai"
+)
+
+// translateOpenAIRequest parses a client chat request and returns the body sent upstream to provider
+func translateOpenAIRequest(t *testing.T, provider schemas.ModelProvider, body string) map[string]any {
+	t.Helper()
+	var clientReq openai.OpenAIChatRequest
+	if err := json.Unmarshal([]byte(body), &clientReq); err != nil {
+		t.Fatal(err)
+	}
+	bifrostReq := clientReq.ToBifrostRequest()
+	bifrostReq.Provider = provider
+	upstream, err := json.Marshal(openai.ToOpenAIChatRequest(bifrostReq))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent map[string]any
+	if err := json.Unmarshal(upstream, &sent); err != nil {
+		t.Fatal(err)
+	}
+	return sent
+}
+
+// TestReasoningParams_OpenAICompatible tests that the same client request is adapted to reasoning and chat models
+func TestReasoningParams_OpenAICompatible(t *testing.T) {
+	const body = `{"model":"%s","messages":[{"role":"user","content":"hi"}],"max_tokens":500,"temperature":0.2,"top_p":0.9,"reasoning_effort":"high"}`
+	tests := []struct {
+		provider        schemas.ModelProvider
+		model           string
+		maxTokensField  string
+		sampling        bool
+		reasoningEffort bool
+	}{
+		{schemas.OpenAI, "o3-mini", "max_completion_tokens", false, true},
+		{schemas.OpenAI, "gpt-5", "max_completion_tokens", false, true},
+		{schemas.OpenRouter, "openai/o4-mini", "max_completion_tokens", false, true},
+		{schemas.OpenAI, "gpt-4o", "max_tokens", true, false},
+		{schemas.OpenAI, "gpt-5-chat-latest", "max_tokens", true, false},
+		{schemas.Groq, "qwen/qwen3-32b", "max_tokens", true, true},
+		{schemas.Gemini, "gemini-2.5-flash", "max_tokens", true, true},
+	}
+	for _, tt := range tests {
+		sent := translateOpenAIRequest(t, tt.provider, fmt.Sprintf(body, tt.model))
+		if sent[tt.maxTokensField] != float64(500) || len(sent) != 3+boolCount(tt.sampling)*2+boolCount(tt.reasoningEffort) {
+			t.Errorf("%s/%s: unexpected body %v", tt.provider, tt.model, sent)
+		}
+		if _, ok := sent["temperature"]; ok != tt.sampling {
+			t.Errorf("%s/%s: expected temperature to be sent: %v, got %v", tt.provider, tt.model, tt.sampling, sent)
+		}
+		if _, ok := sent["reasoning_effort"]; ok != tt.reasoningEffort {
+			t.Errorf("%s/%s: expected reasoning_effort to be sent: %v, got %v", tt.provider, tt.model, tt.reasoningEffort, sent)
+		}
+	}
+}
+
+// boolCount returns 1 for true and 0 for false
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// TestReasoningParams_Anthropic tests that reasoning_effort enables extended thinking within max_tokens, and that
+// the thinking budget of Anthropic clients survives the round trip
+func TestReasoningParams_Anthropic(t *testing.T) {
+	input := []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: schemas.Ptr("hi")}}}
+	toAnthropic := func(params schemas.ChatParameters) *anthropic.AnthropicMessageRequest {
+		return anthropic.ToAnthropicChatCompletionRequest(&schemas.BifrostChatRequest{Provider: schemas.Anthropic, Model: "claude-sonnet-4", Input: input, Params: &params})
+	}
+
+	req := toAnthropic(schemas.ChatParameters{ReasoningEffort: schemas.Ptr("medium"), MaxCompletionTokens: schemas.Ptr(8000), Temperature: schemas.Ptr(0.2)})
+	if req.Thinking == nil || req.Thinking.Type != "enabled" || *req.Thinking.BudgetTokens != 4096 || req.Temperature != nil {
+		t.Errorf("expected a 4096 token budget without temperature, got %+v", req)
+	}
+	req = toAnthropic(schemas.ChatParameters{ReasoningEffort: schemas.Ptr("high")})
+	if req.Thinking == nil || *req.Thinking.BudgetTokens != anthropic.AnthropicDefaultMaxTokens-1 {
+		t.Errorf("expected the budget to stay below max_tokens, got %+v", req.Thinking)
+	}
+	for _, params := range []schemas.ChatParameters{
+		{ReasoningEffort: schemas.Ptr("minimal")},
+		{ReasoningEffort: schemas.Ptr("low"), MaxCompletionTokens: schemas.Ptr(1000)},
+	} {
+		if req := toAnthropic(params); req.Thinking != nil {
+			t.Errorf("expected no thinking for %+v, got %+v", params, req.Thinking)
+		}
+	}
+
+	var clientReq anthropic.AnthropicMessageRequest
+	if err := json.Unmarshal([]byte(`{"model":"claude-sonnet-4","max_tokens":20000,"messages":[{"role":"user","content":"hi"}],"thinking":{"type":"enabled","budget_tokens":10000}}`), &clientReq); err != nil {
+		t.Fatal(err)
+	}
+	bifrostReq := clientReq.ToBifrostRequest()
+	if effort := bifrostReq.Params.ReasoningEffort; effort == nil || *effort != "high" {
+		t.Fatalf("expected a high reasoning effort, got %v", effort)
+	}
+	if req := anthropic.ToAnthropicChatCompletionRequest(bifrostReq); req.Thinking == nil || *req.Thinking.BudgetTokens != 10000 {
+		t.Errorf("expected the budget of the client to be kept, got %+v", req.Thinking)
+	}
+}
+
+// TestReasoningUsage tests that reasoning and cached tokens are reported in the chat and Responses shapes
+func TestReasoningUsage(t *testing.T) {
+	var resp schemas.BifrostResponse
+	if err := json.Unmarshal([]byte(`{"object":"chat.completion","choices":[],"usage":{"prompt_tokens":20,"completion_tokens":300,"total_tokens":320,"completion_tokens_details":{"reasoning_tokens":256}}}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if details := resp.Usage.CompletionTokensDetails; details == nil || details.ReasoningTokens != 256 {
+		t.Fatalf("expected the reasoning tokens to be kept, got %+v", resp.Usage)
+	}
+	resp.ToResponsesOnly()
+	if details := resp.Usage.OutputTokensDetails; details == nil || details.ReasoningTokens != 256 || resp.Usage.OutputTokens != 300 {
+		t.Errorf("expected the reasoning tokens in output_tokens_details, got %+v", resp.Usage.ResponsesExtendedResponseUsage)
+	}
+
+	responses := &schemas.BifrostResponse{Usage: &schemas.LLMUsage{ResponsesExtendedResponseUsage: &schemas.ResponsesExtendedResponseUsage{
+		InputTokens:         40,
+		InputTokensDetails:  &schemas.ResponsesResponseInputTokens{CachedTokens: 32},
+		OutputTokens:        100,
+		OutputTokensDetails: &schemas.ResponsesResponseOutputTokens{ReasoningTokens: 64},
+	}}}
+	schemas.NormalizeResponse(schemas.OpenAI, responses)
+	usage := responses.Usage
+	if usage.CompletionTokensDetails == nil || usage.CompletionTokensDetails.ReasoningTokens != 64 || usage.PromptTokensDetails == nil || usage.PromptTokensDetails.CachedTokens != 32 || usage.TotalTokens != 140 {
+		t.Errorf("expected normalized token details, got %+v", usage)
+	}
+}
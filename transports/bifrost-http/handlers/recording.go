@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/recording"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const recordingPluginName = "bifrost-recording"
+
+// RecordingHandler serves upstream recordings and replays them.
+type RecordingHandler struct {
+	client *bifrost.Bifrost
+	store  recording.Store
+	logger schemas.Logger
+}
+
+// ReplayRequest is the body of POST /api/replay/{id}. Empty fields keep the provider and model of the recording.
+type ReplayRequest struct {
+	Provider schemas.ModelProvider `json:"provider,omitempty"`
+	Model    string                `json:"model,omitempty"`
+	Record   bool                  `json:"record,omitempty"` // Record the exchanges of the replay as well
+}
+
+// ReplayOutcome is the upstream status and body of a recording or a replay.
+type ReplayOutcome struct {
+	Provider   schemas.ModelProvider `json:"provider"`
+	Model      string                `json:"model"`
+	StatusCode int                   `json:"status_code,omitempty"`
+	Body       json.RawMessage       `json:"body,omitempty"`
+	Error      string                `json:"error,omitempty"`
+	LatencyMs  int64                 `json:"latency_ms"`
+}
+
+// ReplayResponse compares a recording with its replay.
+type ReplayResponse struct {
+	RecordingID string        `json:"recording_id"`
+	RequestID   string        `json:"request_id"` // Request ID of the replay, its recordings are listed under it
+	Original    ReplayOutcome `json:"original"`
+	Replay      ReplayOutcome `json:"replay"`
+}
+
+// NewRecordingHandler creates a new recording handler.
+func NewRecordingHandler(client *bifrost.Bifrost, store recording.Store, logger schemas.Logger) *RecordingHandler {
+	return &RecordingHandler{
+		client: client,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the recording routes.
+func (h *RecordingHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/recordings", lib.ChainMiddlewares(h.listRecordings, middlewares...))
+	r.GET("/api/recordings/{id}", lib.ChainMiddlewares(h.getRecording, middlewares...))
+	r.DELETE("/api/recordings/{id}", lib.ChainMiddlewares(h.deleteRecording, middlewares...))
+	r.POST("/api/replay/{id}", lib.ChainMiddlewares(h.replay, middlewares...))
+}
+
+// listRecordings handles GET /api/recordings - List upstream recordings without their bodies, newest first
+// Query parameters: request_id, provider, model, limit (default 50) and offset.
+func (h *RecordingHandler) listRecordings(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	filter := recording.Filter{
+		RequestID: string(args.Peek("request_id")),
+		Provider:  string(args.Peek("provider")),
+		Model:     string(args.Peek("model")),
+	}
+	limit, offset := 50, 0
+	if value := string(args.Peek("limit")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i <= 0 || i > maxListLimit {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxListLimit), h.logger)
+			return
+		}
+		limit = i
+	}
+	if value := string(args.Peek("offset")); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			SendError(ctx, fasthttp.StatusBadRequest, "offset cannot be negative", h.logger)
+			return
+		}
+		offset = i
+	}
+
+	recordings, total, err := h.store.List(ctx, filter, limit, offset)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to list recordings: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"recordings": recordings,
+		"count":      len(recordings),
+		"total":      total,
+	}, h.logger)
+}
+
+// getRecording handles GET /api/recordings/{id} - Get an upstream recording with its request and response
+func (h *RecordingHandler) getRecording(ctx *fasthttp.RequestCtx) {
+	rec, err := h.store.Get(ctx, ctx.UserValue("id").(string))
+	if err != nil {
+		h.sendRecordingError(ctx, err)
+		return
+	}
+	SendJSON(ctx, rec, h.logger)
+}
+
+// deleteRecording handles DELETE /api/recordings/{id} - Delete an upstream recording
+func (h *RecordingHandler) deleteRecording(ctx *fasthttp.RequestCtx) {
+	if err := h.store.Delete(ctx, ctx.UserValue("id").(string)); err != nil {
+		h.sendRecordingError(ctx, err)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"status":  "success",
+		"message": "Recording deleted",
+	}, h.logger)
+}
+
+// replay handles POST /api/replay/{id} - Re-issue a recorded request against the same or another provider
+// The replay goes through the gateway like any request, without fallbacks, and its response is returned next to
+// the recorded one for comparison.
+func (h *RecordingHandler) replay(ctx *fasthttp.RequestCtx) {
+	var req ReplayRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+			return
+		}
+	}
+	rec, err := h.store.Get(ctx, ctx.UserValue("id").(string))
+	if err != nil {
+		h.sendRecordingError(ctx, err)
+		return
+	}
+	if rec.Request == "" {
+		SendError(ctx, fasthttp.StatusUnprocessableEntity, fmt.Sprintf("%s requests cannot be replayed", rec.RequestType), h.logger)
+		return
+	}
+	provider, model := schemas.ModelProvider(rec.Provider), rec.Model
+	if req.Provider != "" {
+		provider = req.Provider
+	}
+	if req.Model != "" {
+		model = req.Model
+	}
+
+	requestID := uuid.NewString()
+	replayCtx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, requestID)
+	if req.Record {
+		replayCtx = context.WithValue(replayCtx, lib.RecordContextKey, true)
+	}
+	start := time.Now()
+	resp, bifrostErr, err := replayRecordedRequest(replayCtx, h.client, schemas.RequestType(rec.RequestType), rec.Request, provider, model)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusUnprocessableEntity, fmt.Sprintf("Failed to replay recording: %v", err), h.logger)
+		return
+	}
+
+	result := ReplayResponse{
+		RecordingID: rec.ID,
+		RequestID:   requestID,
+		Original: ReplayOutcome{
+			Provider:   schemas.ModelProvider(rec.Provider),
+			Model:      rec.Model,
+			StatusCode: rec.StatusCode,
+			Error:      rec.Error,
+			LatencyMs:  rec.LatencyMs,
+		},
+		Replay: ReplayOutcome{
+			Provider:  provider,
+			Model:     model,
+			LatencyMs: time.Since(start).Milliseconds(),
+		},
+	}
+	if json.Valid([]byte(rec.ResponseBody)) {
+		result.Original.Body = json.RawMessage(rec.ResponseBody)
+	} else if rec.ResponseBody != "" {
+		result.Original.Body, _ = json.Marshal(rec.ResponseBody)
+	}
+	if bifrostErr != nil {
+		result.Replay.StatusCode = fasthttp.StatusInternalServerError
+		if bifrostErr.StatusCode != nil {
+			result.Replay.StatusCode = *bifrostErr.StatusCode
+		}
+		result.Replay.Body, _ = json.Marshal(bifrostErr)
+		if bifrostErr.Error != nil {
+			result.Replay.Error = bifrostErr.Error.Message
+		}
+	} else {
+		result.Replay.StatusCode = fasthttp.StatusOK
+		result.Replay.Body, _ = json.Marshal(resp)
+	}
+	SendJSON(ctx, result, h.logger)
+}
+
+// replayRecordedRequest decodes the gateway request of a recording and sends it to provider and model.
+func replayRecordedRequest(ctx context.Context, client *bifrost.Bifrost, requestType schemas.RequestType, request string, provider schemas.ModelProvider, model string) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	switch requestType {
+	case schemas.ChatCompletionRequest, schemas.ChatCompletionStreamRequest:
+		var req schemas.BifrostChatRequest
+		if err := json.Unmarshal([]byte(request), &req); err != nil {
+			return nil, nil, err
+		}
+		req.Provider, req.Model, req.Fallbacks = provider, model, nil
+		resp, bifrostErr := client.ChatCompletionRequest(ctx, &req)
+		return resp, bifrostErr, nil
+	case schemas.TextCompletionRequest, schemas.TextCompletionStreamRequest:
+		var req schemas.BifrostTextCompletionRequest
+		if err := json.Unmarshal([]byte(request), &req); err != nil {
+			return nil, nil, err
+		}
+		req.Provider, req.Model, req.Fallbacks = provider, model, nil
+		resp, bifrostErr := client.TextCompletionRequest(ctx, &req)
+		return resp, bifrostErr, nil
+	case schemas.ResponsesRequest, schemas.ResponsesStreamRequest:
+		var req schemas.BifrostResponsesRequest
+		if err := json.Unmarshal([]byte(request), &req); err != nil {
+			return nil, nil, err
+		}
+		req.Provider, req.Model, req.Fallbacks = provider, model, nil
+		resp, bifrostErr := client.ResponsesRequest(ctx, &req)
+		return resp, bifrostErr, nil
+	case schemas.EmbeddingRequest:
+		var req schemas.BifrostEmbeddingRequest
+		if err := json.Unmarshal([]byte(request), &req); err != nil {
+			return nil, nil, err
+		}
+		req.Provider, req.Model, req.Fallbacks = provider, model, nil
+		resp, bifrostErr := client.EmbeddingRequest(ctx, &req)
+		return resp, bifrostErr, nil
+	case schemas.RerankRequest:
+		var req schemas.BifrostRerankRequest
+		if err := json.Unmarshal([]byte(request), &req); err != nil {
+			return nil, nil, err
+		}
+		req.Provider, req.Model, req.Fallbacks = provider, model, nil
+		resp, bifrostErr := client.RerankRequest(ctx, &req)
+		return resp, bifrostErr, nil
+	}
+	return nil, nil, fmt.Errorf("%s requests cannot be replayed", requestType)
+}
+
+// sendRecordingError maps recording errors to HTTP statuses.
+func (h *RecordingHandler) sendRecordingError(ctx *fasthttp.RequestCtx, err error) {
+	if errors.Is(err, recording.ErrNotFound) {
+		SendError(ctx, fasthttp.StatusNotFound, err.Error(), h.logger)
+		return
+	}
+	SendError(ctx, fasthttp.StatusInternalServerError, err.Error(), h.logger)
+}
+
+// recordedRequest serializes the gateway request that replays re-issue. It is empty for request types
+// that cannot be replayed.
+func recordedRequest(req *schemas.BifrostRequest) string {
+	var v any
+	switch {
+	case req.ChatRequest != nil:
+		v = req.ChatRequest
+	case req.TextCompletionRequest != nil:
+		v = req.TextCompletionRequest
+	case req.ResponsesRequest != nil:
+		v = req.ResponsesRequest
+	case req.EmbeddingRequest != nil:
+		v = req.EmbeddingRequest
+	case req.RerankRequest != nil:
+		v = req.RerankRequest
+	default:
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// recordingPlugin attaches a recording.Recorder to every request while recording is enabled. The recorder
+// decides which upstream exchanges to save once the provider key serving the request is known. PreHooks run
+// again for each fallback, so every attempt is recorded with its own provider and model.
+type recordingPlugin struct {
+	config *lib.Config
+}
+
+// GetName returns the name of the plugin
+func (p *recordingPlugin) GetName() string {
+	return recordingPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *recordingPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook attaches a recorder holding the request to replay
+func (p *recordingPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if p.config.Recordings == nil || p.config.Recording == nil || !p.config.Recording.Enabled {
+		return req, nil, nil
+	}
+	// The upstream exchanges of zero data retention requests are never stored
+	if retentionClass, _ := (*ctx).Value(schemas.BifrostContextKeyRetentionClass).(string); retentionClass == schemas.RetentionClassZeroDataRetention {
+		return req, nil, nil
+	}
+	// Recordings are keyed like the request log entry, which uses the fallback request ID of fallback attempts
+	requestID, _ := (*ctx).Value(schemas.BifrostContextKeyRequestID).(string)
+	if fallbackRequestID, ok := (*ctx).Value(schemas.BifrostContextKeyFallbackRequestID).(string); ok && fallbackRequestID != "" {
+		requestID = fallbackRequestID
+	}
+	requested, _ := (*ctx).Value(lib.RecordContextKey).(bool)
+	virtualKey, _ := (*ctx).Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+
+	recorder := recording.NewRecorder(p.config.Recording, p.config.Recordings, logger)
+	recorder.Requested = requested
+	recorder.RequestID = requestID
+	recorder.RequestType = req.RequestType
+	recorder.Provider = req.Provider
+	recorder.Model = req.Model
+	recorder.VirtualKey = virtualKey
+	recorder.Request = recordedRequest(req)
+	*ctx = context.WithValue(*ctx, schemas.BifrostContextKeyUpstreamRecorder, recorder)
+	return req, nil, nil
+}
+
+// PostHook is not used for this plugin
+func (p *recordingPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *recordingPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/recording"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestRecordingPlugin_RecordsUpstreamExchanges tests that the plugin attaches a recorder saving the upstream
+// exchanges of requests asking to be recorded with the gateway request to replay, and that recordings can be
+// listed, fetched and deleted
+func TestRecordingPlugin_RecordsUpstreamExchanges(t *testing.T) {
+	store := recording.NewInMemoryStore()
+	plugin := &recordingPlugin{config: &lib.Config{Recording: &recording.Config{Enabled: true}, Recordings: store}}
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "req-1")
+	plugin.PreHook(&ctx, moderationChatRequest("Say hello"))
+	recorder, ok := ctx.Value(schemas.BifrostContextKeyUpstreamRecorder).(schemas.UpstreamRecorder)
+	if !ok {
+		t.Fatal("expected a recorder to be attached")
+	}
+	// Requests not asking to be recorded are not saved
+	recorder.RecordUpstream(ctx, &schemas.UpstreamExchange{Method: "POST", StatusCode: 200})
+
+	ctx = context.WithValue(context.Background(), schemas.BifrostContextKeyRequestID, "req-2")
+	ctx = context.WithValue(ctx, lib.RecordContextKey, true)
+	plugin.PreHook(&ctx, moderationChatRequest("Say hello"))
+	ctx.Value(schemas.BifrostContextKeyUpstreamRecorder).(schemas.UpstreamRecorder).RecordUpstream(ctx, &schemas.UpstreamExchange{
+		Method:       "POST",
+		URL:          "https://api.openai.com/v1/chat/completions",
+		RequestBody:  []byte(`{"model":"gpt-4o-mini"}`),
+		StatusCode:   200,
+		ResponseBody: []byte(`{"id":"chatcmpl-1"}`),
+		Latency:      120 * time.Millisecond,
+	})
+
+	handler := NewRecordingHandler(nil, store, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	listCtx := &fasthttp.RequestCtx{}
+	handler.listRecordings(listCtx)
+	var list struct {
+		Recordings []recording.Recording `json:"recordings"`
+		Total      int64                 `json:"total"`
+	}
+	if err := json.Unmarshal(listCtx.Response.Body(), &list); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if list.Total != 1 || list.Recordings[0].RequestID != "req-2" {
+		t.Fatalf("expected only the requested recording, got %s", listCtx.Response.Body())
+	}
+
+	getCtx := &fasthttp.RequestCtx{}
+	getCtx.SetUserValue("id", list.Recordings[0].ID)
+	handler.getRecording(getCtx)
+	var rec recording.Recording
+	if err := json.Unmarshal(getCtx.Response.Body(), &rec); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	var request schemas.BifrostChatRequest
+	if err := json.Unmarshal([]byte(rec.Request), &request); err != nil || request.Model != "gpt-4o-mini" || len(request.Input) != 3 {
+		t.Errorf("expected the gateway request to be recorded for replays, got %q", rec.Request)
+	}
+	if rec.ResponseBody != `{"id":"chatcmpl-1"}` || rec.Provider != string(schemas.OpenAI) || rec.LatencyMs != 120 {
+		t.Errorf("unexpected recording %+v", rec)
+	}
+
+	deleteCtx := &fasthttp.RequestCtx{}
+	deleteCtx.SetUserValue("id", rec.ID)
+	handler.deleteRecording(deleteCtx)
+	if deleteCtx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected the recording to be deleted, got %d", deleteCtx.Response.StatusCode())
+	}
+	getCtx.Response.Reset()
+	handler.getRecording(getCtx)
+	if getCtx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("expected a deleted recording to be missing, got %d", getCtx.Response.StatusCode())
+	}
+}
+
+// recordingTestAccount configures OpenAI served by baseURL
+type recordingTestAccount struct {
+	baseURL string
+}
+
+func (a recordingTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{schemas.OpenAI}, nil
+}
+
+func (a recordingTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	return []schemas.Key{{ID: "openai-key", Value: "sk-secret", Weight: 1}}, nil
+}
+
+func (a recordingTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	return &schemas.ProviderConfig{
+		NetworkConfig:            schemas.NetworkConfig{BaseURL: a.baseURL},
+		ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+	}, nil
+}
+
+// TestRecordingPlugin_RecordsStreams tests that streamed exchanges, sent with net/http, are recorded with the whole
+// streamed body once the stream ends, with credentials redacted
+func TestRecordingPlugin_RecordsStreams(t *testing.T) {
+	const events = "data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n" +
+		"data: [DONE]\n\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(events))
+	}))
+	defer server.Close()
+
+	store := recording.NewInMemoryStore()
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: recordingTestAccount{baseURL: server.URL},
+		Plugins: []schemas.Plugin{&recordingPlugin{config: &lib.Config{Recording: &recording.Config{Enabled: true}, Recordings: store}}},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	defer client.Shutdown()
+
+	ctx := context.WithValue(context.Background(), lib.RecordContextKey, true)
+	stream, bifrostErr := client.ChatCompletionStreamRequest(ctx, &schemas.BifrostChatRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("hi")}}},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("unexpected error: %+v", bifrostErr.Error)
+	}
+	for range stream {
+	}
+
+	// The exchange is recorded once the provider closes the response body, which may follow the end of the stream
+	var recordings []recording.Recording
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if recordings, _, _ = store.List(context.Background(), recording.Filter{}, 10, 0); len(recordings) > 0 {
+			break
+		}
+	}
+	if len(recordings) != 1 {
+		t.Fatalf("expected the stream to be recorded once, got %d recordings", len(recordings))
+	}
+	rec, err := store.Get(context.Background(), recordings[0].ID)
+	if err != nil {
+		t.Fatalf("failed to get recording: %v", err)
+	}
+	if rec.StatusCode != 200 || rec.ResponseBody != events || !strings.Contains(rec.RequestBody, `"stream":true`) {
+		t.Errorf("expected the whole stream to be recorded, got status %d, request %q and response %q", rec.StatusCode, rec.RequestBody, rec.ResponseBody)
+	}
+	if got := rec.RequestHeaders["Authorization"]; got != "[REDACTED]" {
+		t.Errorf("expected the provider key to be redacted, got %q", got)
+	}
+}
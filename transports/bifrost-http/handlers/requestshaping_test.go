@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// requestShapingTestAccount configures OpenAI, served by baseURL, with request shaping
+type requestShapingTestAccount struct {
+	baseURL string
+	shaping *schemas.RequestShapingConfig
+}
+
+func (a requestShapingTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{schemas.OpenAI}, nil
+}
+
+func (a requestShapingTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	return []schemas.Key{{ID: "openai-key", Value: "sk-test", Weight: 1}}, nil
+}
+
+func (a requestShapingTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	return &schemas.ProviderConfig{
+		NetworkConfig:            schemas.NetworkConfig{BaseURL: a.baseURL},
+		ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+		RequestShaping:           a.shaping,
+	}, nil
+}
+
+// TestRequestShaping tests that provider and model defaults only fill the parameters a request leaves unset, that
+// overrides replace them with model overrides taking precedence, and that the changes are traced with their rule
+func TestRequestShaping(t *testing.T) {
+	var shaping schemas.RequestShapingConfig
+	if err := json.Unmarshal([]byte(`{
+		"defaults": {"temperature": 0.2, "max_tokens": 100, "seed": 7},
+		"overrides": {"top_p": 0.5, "safe_mode": true, "user": null},
+		"models": {
+			"gpt-4o*": {"overrides": {"top_p": 0.9}},
+			"gpt-4o-mini": {"defaults": {"seed": 42}}
+		}
+	}`), &shaping); err != nil {
+		t.Fatalf("invalid request shaping: %v", err)
+	}
+	if err := shaping.Validate(); err != nil {
+		t.Fatalf("request shaping refused: %v", err)
+	}
+
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "chatcmpl-1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "message": {"role": "assistant", "content": "Hi"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: requestShapingTestAccount{baseURL: server.URL, shaping: &shaping},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+
+	params := &schemas.ChatParameters{Temperature: bifrost.Ptr(0.7), TopP: bifrost.Ptr(0.1), User: bifrost.Ptr("alice")}
+	trace := &schemas.PipelineTrace{}
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyPipelineTrace, trace)
+	if _, bifrostErr := client.ChatCompletionRequest(ctx, &schemas.BifrostChatRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o",
+		Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Hi")}}},
+		Params:   params,
+	}); bifrostErr != nil {
+		t.Fatalf("chat completion failed: %v", bifrostErr.Error.Message)
+	}
+	if body["temperature"] != 0.7 || body["max_tokens"] != float64(100) || body["seed"] != float64(7) || body["top_p"] != 0.9 || body["user"] != nil {
+		t.Errorf("request body = %v, want the request temperature, the defaults and the model top_p override", body)
+	}
+	if *params.TopP != 0.1 || *params.User != "alice" || params.ExtraParams != nil {
+		t.Errorf("params = %+v, want the parameters of the caller untouched", params)
+	}
+
+	var changes []schemas.TraceChange
+	for _, step := range trace.Snapshot().Steps {
+		if step.Stage == schemas.PipelineTraceStageShaping {
+			changes = step.Changes
+		}
+	}
+	rules := map[string]string{}
+	for _, change := range changes {
+		rules[change.Path] = change.Rule
+	}
+	want := map[string]string{
+		"ChatRequest.Params.max_completion_tokens": schemas.RequestShapingProviderDefaults,
+		"ChatRequest.Params.seed":                  schemas.RequestShapingProviderDefaults,
+		"ChatRequest.Params.safe_mode":             schemas.RequestShapingProviderOverrides,
+		"ChatRequest.Params.user":                  schemas.RequestShapingProviderOverrides,
+		"ChatRequest.Params.top_p":                 "models[gpt-4o*].overrides",
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("traced changes = %+v, want %v", changes, want)
+	}
+	for path, rule := range want {
+		if rules[path] != rule {
+			t.Errorf("rule of %s = %q, want %q", path, rules[path], rule)
+		}
+	}
+
+	// The exact model key wins over the longer prefix key
+	if _, bifrostErr := client.ChatCompletionRequest(context.Background(), &schemas.BifrostChatRequest{
+		Provider: schemas.OpenAI,
+		Model:    "gpt-4o-mini",
+		Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Hi")}}},
+	}); bifrostErr != nil {
+		t.Fatalf("chat completion failed: %v", bifrostErr.Error.Message)
+	}
+	if body["seed"] != float64(42) || body["top_p"] != 0.5 || body["temperature"] != 0.2 {
+		t.Errorf("request body = %v, want the gpt-4o-mini seed and the provider top_p", body)
+	}
+}
+
+// TestRequestShapingValidate tests that values not decoding into their parameter, null defaults and misplaced
+// wildcards are refused
+func TestRequestShapingValidate(t *testing.T) {
+	for name, config := range map[string]string{
+		"temperature string": `{"defaults": {"temperature": "hot"}}`,
+		"null default":       `{"defaults": {"seed": null}}`,
+		"max tokens list":    `{"models": {"gpt-4o": {"overrides": {"max_tokens": [1]}}}}`,
+		"inner wildcard":     `{"models": {"gpt-*-mini": {"defaults": {"seed": 1}}}}`,
+	} {
+		var shaping schemas.RequestShapingConfig
+		if err := json.Unmarshal([]byte(config), &shaping); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", name, err)
+		}
+		if err := shaping.Validate(); err == nil {
+			t.Errorf("%s: expected the request shaping to be refused", name)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const responseTransformsPluginName = "bifrost-response-transforms"
+
+// ResponseTransformsHandler manages the response transform rules.
+type ResponseTransformsHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// ResponseTransformRulesRequest is the body of PUT /api/response-transforms and the response of
+// GET /api/response-transforms.
+type ResponseTransformRulesRequest struct {
+	Rules []lib.ResponseTransformRule `json:"rules"`
+}
+
+// NewResponseTransformsHandler creates a new response transform rules handler.
+func NewResponseTransformsHandler(store *lib.Config, logger schemas.Logger) *ResponseTransformsHandler {
+	return &ResponseTransformsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the response transform rules routes.
+func (h *ResponseTransformsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/response-transforms", lib.ChainMiddlewares(h.getResponseTransforms, middlewares...))
+	r.PUT("/api/response-transforms", lib.ChainMiddlewares(h.updateResponseTransforms, middlewares...))
+}
+
+// getResponseTransforms handles GET /api/response-transforms - Get the response transform rules
+func (h *ResponseTransformsHandler) getResponseTransforms(ctx *fasthttp.RequestCtx) {
+	rules := h.store.GetResponseTransforms().Rules()
+	if rules == nil {
+		rules = []lib.ResponseTransformRule{}
+	}
+	SendJSON(ctx, ResponseTransformRulesRequest{Rules: rules}, h.logger)
+}
+
+// updateResponseTransforms handles PUT /api/response-transforms - Replace the response transform rules
+func (h *ResponseTransformsHandler) updateResponseTransforms(ctx *fasthttp.RequestCtx) {
+	var req ResponseTransformRulesRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Rules == nil {
+		req.Rules = []lib.ResponseTransformRule{}
+	}
+	if _, err := lib.CompileResponseTransformRules(req.Rules); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid response transform rules: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateResponseTransformRules(ctx, req.Rules); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update response transform rules: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, req, h.logger)
+}
+
+// responseTransformsContextKey holds the *responseTransformState of a chat or text completion request
+const responseTransformsContextKey schemas.BifrostContextKey = "bifrost-response-transforms"
+
+// responseTransformState is the transformer matching a request and, for streams, the transformation state of
+// each choice
+type responseTransformState struct {
+	transformer    *lib.ResponseTransformer
+	stream         bool
+	textCompletion bool
+
+	mu      sync.Mutex
+	streams map[int]*lib.ResponseStream // By choice index
+}
+
+// responseStream returns the transformation state of a streamed choice
+func (s *responseTransformState) responseStream(index int) *lib.ResponseStream {
+	stream, ok := s.streams[index]
+	if !ok {
+		stream = s.transformer.NewStream()
+		s.streams[index] = stream
+	}
+	return stream
+}
+
+// responseTransformsPlugin rewrites the text of chat and text completion responses with the response transform
+// rules matching the route, virtual key, provider and model of the request, in streams too.
+// It is registered after moderation and ahead of stream usage, so its PostHook runs after usage is counted on
+// what the provider generated, and before moderation, logging and sessions, which see what the client receives.
+type responseTransformsPlugin struct {
+	config *lib.Config
+}
+
+// GetName returns the name of the plugin
+func (p *responseTransformsPlugin) GetName() string {
+	return responseTransformsPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *responseTransformsPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook selects the rules matching the request, again on each fallback since the provider and model change
+func (p *responseTransformsPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	transforms := p.config.GetResponseTransforms()
+	if transforms == nil || (req.ChatRequest == nil && req.TextCompletionRequest == nil) {
+		return req, nil, nil
+	}
+	path, _ := (*ctx).Value(lib.RequestPathContextKey).(string)
+	virtualKey, _ := (*ctx).Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	transformer := transforms.Match(path, virtualKey, string(req.Provider), req.Model)
+	if transformer == nil {
+		*ctx = context.WithValue(*ctx, responseTransformsContextKey, (*responseTransformState)(nil))
+		return req, nil, nil
+	}
+	*ctx = context.WithValue(*ctx, responseTransformsContextKey, &responseTransformState{
+		transformer:    transformer,
+		stream:         req.RequestType == schemas.ChatCompletionStreamRequest || req.RequestType == schemas.TextCompletionStreamRequest,
+		textCompletion: req.TextCompletionRequest != nil,
+		streams:        make(map[int]*lib.ResponseStream),
+	})
+	if responseHeaders, ok := (*ctx).Value(lib.ResponseHeadersContextKey).(*lib.ResponseHeaders); ok {
+		responseHeaders.Set(lib.ResponseTransformsAppliedHeader, strings.Join(transformer.Applied, ","))
+	}
+	return req, nil, nil
+}
+
+// PostHook transforms the text of responses. Streamed text is transformed as it arrives, and what is held back
+// is released on the final chunk.
+func (p *responseTransformsPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	state, _ := (*ctx).Value(responseTransformsContextKey).(*responseTransformState)
+	if state == nil || result == nil {
+		return result, bifrostErr, nil
+	}
+	if !state.stream {
+		for _, choice := range result.Choices {
+			transformResponseChoice(state.transformer, choice)
+		}
+		return result, bifrostErr, nil
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	for _, choice := range result.Choices {
+		switch {
+		case choice.BifrostStreamResponseChoice != nil && choice.Delta != nil && choice.Delta.Content != nil:
+			text := state.responseStream(choice.Index).Write(*choice.Delta.Content)
+			choice.Delta.Content = &text
+		case choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil:
+			text := state.responseStream(choice.Index).Write(*choice.Text)
+			choice.Text = &text
+		}
+	}
+	if bifrost.IsFinalChunk(ctx) {
+		indexes := make([]int, 0, len(state.streams))
+		for index := range state.streams {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		for _, index := range indexes {
+			if rest := state.streams[index].Flush(); rest != "" {
+				appendStreamedText(result, index, rest, state.textCompletion)
+			}
+		}
+	}
+	return result, bifrostErr, nil
+}
+
+// transformResponseChoice transforms the text of a complete choice in place
+func transformResponseChoice(transformer *lib.ResponseTransformer, choice schemas.BifrostChatResponseChoice) {
+	if choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil {
+		text := transformer.Apply(*choice.Text)
+		choice.Text = &text
+	}
+	if choice.BifrostNonStreamResponseChoice == nil || choice.Message == nil || choice.Message.Content == nil {
+		return
+	}
+	content := choice.Message.Content
+	if content.ContentStr != nil {
+		text := transformer.Apply(*content.ContentStr)
+		content.ContentStr = &text
+	}
+	for i := range content.ContentBlocks {
+		if block := &content.ContentBlocks[i]; block.Text != nil {
+			text := transformer.Apply(*block.Text)
+			block.Text = &text
+		}
+	}
+}
+
+// appendStreamedText adds text to the choice of a chunk with the given index, adding the choice when the chunk
+// has none
+func appendStreamedText(chunk *schemas.BifrostResponse, index int, text string, textCompletion bool) {
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if choice.Index != index {
+			continue
+		}
+		if choice.BifrostTextCompletionResponseChoice != nil {
+			if choice.Text != nil {
+				text = *choice.Text + text
+			}
+			choice.Text = &text
+			return
+		}
+		if choice.BifrostStreamResponseChoice == nil {
+			choice.BifrostStreamResponseChoice = &schemas.BifrostStreamResponseChoice{}
+		}
+		if choice.Delta == nil {
+			choice.Delta = &schemas.BifrostStreamDelta{}
+		}
+		if choice.Delta.Content != nil {
+			text = *choice.Delta.Content + text
+		}
+		choice.Delta.Content = &text
+		return
+	}
+	choice := schemas.BifrostChatResponseChoice{Index: index}
+	if textCompletion {
+		choice.BifrostTextCompletionResponseChoice = &schemas.BifrostTextCompletionResponseChoice{Text: &text}
+	} else {
+		choice.BifrostStreamResponseChoice = &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{Content: &text}}
+	}
+	chunk.Choices = append(chunk.Choices, choice)
+}
+
+// Cleanup is not used for this plugin
+func (p *responseTransformsPlugin) Cleanup() error {
+	return nil
+}
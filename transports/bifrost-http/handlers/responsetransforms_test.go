@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const responseTransformsTestText = "## Leave\n\n" +
+	"Employees get **25 days** of paid leave [1] and *one* extra day【2†handbook.pdf】.\n" +
+	"- Ask your manager via the [portal](https://hr.example.com).\n" +
+	"```\nleave --days 3\n```\n" +
+	"Contact hr@example.com for `exceptions`.\n" +
+	"[^1]: Handbook, page 3"
+
+// newResponseTransformsTestConfig creates a config with rules for the /v1/chat/completions route
+func newResponseTransformsTestConfig(t *testing.T, transforms ...lib.ResponseTransform) *lib.Config {
+	t.Helper()
+	config := &lib.Config{}
+	err := config.UpdateResponseTransformRules(context.Background(), []lib.ResponseTransformRule{{
+		Name:       "plain-text",
+		Match:      lib.TransformationMatch{Paths: []string{"/v1/chat/completions"}, VirtualKeys: []string{"sk-bf-sms"}},
+		Transforms: transforms,
+	}})
+	if err != nil {
+		t.Fatalf("failed to set rules: %v", err)
+	}
+	return config
+}
+
+// TestResponseTransforms_Apply tests each transform on a complete response
+func TestResponseTransforms_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms []lib.ResponseTransform
+		want       []string // Substrings of the result
+	}{
+		{
+			name:       "strip markdown",
+			transforms: []lib.ResponseTransform{{Type: lib.ResponseTransformStripMarkdown}},
+			want: []string{"Leave\n\n" +
+				"Employees get 25 days of paid leave [1] and one extra day【2†handbook.pdf】.\n" +
+				"Ask your manager via the portal.\n" +
+				"leave --days 3\n" +
+				"Contact hr@example.com for exceptions.\n" +
+				"[^1]: Handbook, page 3"},
+		},
+		{
+			name:       "superscript citations",
+			transforms: []lib.ResponseTransform{{Type: lib.ResponseTransformCitations, CitationFormat: lib.CitationFormatSuperscript}},
+			want:       []string{"of paid leave¹ and *one* extra day².", "\n[^1]: Handbook"},
+		},
+		{
+			name:       "removed citations",
+			transforms: []lib.ResponseTransform{{Type: lib.ResponseTransformCitations, CitationFormat: lib.CitationFormatRemove}},
+			want:       []string{"of paid leave and *one* extra day."},
+		},
+		{
+			name: "regex replacements",
+			transforms: []lib.ResponseTransform{{Type: lib.ResponseTransformRegexReplace, Replacements: []lib.RegexReplacement{
+				{Pattern: `[\w.]+@example\.com`, Replacement: "[email]"},
+				{Pattern: `(\d+) days`, Replacement: "$1 working days"},
+			}}},
+			want: []string{"Contact [email] for", "**25 working days**"},
+		},
+		{
+			name: "max length",
+			transforms: []lib.ResponseTransform{
+				{Type: lib.ResponseTransformMaxLength, MaxLength: 29, Suffix: "…"},
+				{Type: lib.ResponseTransformStripMarkdown},
+			},
+			want: []string{"Leave\n\nEmployees get 25 days…"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := newResponseTransformsTestConfig(t, tt.transforms...)
+			transformer := config.GetResponseTransforms().Match("/v1/chat/completions", "sk-bf-sms", "openai", "gpt-4o")
+			if transformer == nil {
+				t.Fatal("rule did not match")
+			}
+			got := transformer.Apply(responseTransformsTestText)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Apply() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+
+	config := newResponseTransformsTestConfig(t, lib.ResponseTransform{Type: lib.ResponseTransformStripMarkdown})
+	if config.GetResponseTransforms().Match("/v1/chat/completions", "sk-bf-web", "openai", "gpt-4o") != nil {
+		t.Error("rule matched another virtual key")
+	}
+}
+
+// TestResponseTransforms_StreamsMatchCompleteResponses tests that streamed responses are transformed as the
+// complete response would be, however the text is split into chunks
+func TestResponseTransforms_StreamsMatchCompleteResponses(t *testing.T) {
+	config := newResponseTransformsTestConfig(t,
+		lib.ResponseTransform{Type: lib.ResponseTransformStripMarkdown},
+		lib.ResponseTransform{Type: lib.ResponseTransformCitations, CitationFormat: lib.CitationFormatParentheses},
+		lib.ResponseTransform{Type: lib.ResponseTransformMaxLength, MaxLength: 120, Suffix: "…"},
+	)
+	transformer := config.GetResponseTransforms().Match("/v1/chat/completions", "sk-bf-sms", "openai", "gpt-4o")
+	want := transformer.Apply(responseTransformsTestText)
+
+	for _, size := range []int{1, 3, 7, 64} {
+		stream := transformer.NewStream()
+		var got strings.Builder
+		runes := []rune(responseTransformsTestText)
+		for start := 0; start < len(runes); start += size {
+			got.WriteString(stream.Write(string(runes[start:min(start+size, len(runes))])))
+		}
+		got.WriteString(stream.Flush())
+		if got.String() != want {
+			t.Errorf("chunks of %d: streamed %q, want %q", size, got.String(), want)
+		}
+	}
+}
+
+// TestResponseTransformsPlugin tests that the plugin transforms complete responses and streams of matching
+// requests, releasing the held back text on the final chunk
+func TestResponseTransformsPlugin(t *testing.T) {
+	config := newResponseTransformsTestConfig(t, lib.ResponseTransform{Type: lib.ResponseTransformStripMarkdown})
+	plugin := &responseTransformsPlugin{config: config}
+	newCtx := func(virtualKey string, responseHeaders *lib.ResponseHeaders) *context.Context {
+		ctx := context.WithValue(context.Background(), lib.RequestPathContextKey, "/v1/chat/completions")
+		ctx = context.WithValue(ctx, schemas.BifrostContextKeyVirtualKeyHeader, virtualKey)
+		ctx = context.WithValue(ctx, lib.ResponseHeadersContextKey, responseHeaders)
+		return &ctx
+	}
+	chatRequest := func(requestType schemas.RequestType) *schemas.BifrostRequest {
+		return &schemas.BifrostRequest{Provider: "openai", Model: "gpt-4o", RequestType: requestType, ChatRequest: &schemas.BifrostChatRequest{}}
+	}
+
+	responseHeaders := &lib.ResponseHeaders{}
+	ctx := newCtx("sk-bf-sms", responseHeaders)
+	plugin.PreHook(ctx, chatRequest(schemas.ChatCompletionRequest))
+	result, _, _ := plugin.PostHook(ctx, &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{{
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &schemas.ChatMessage{
+			Role:    schemas.ChatMessageRoleAssistant,
+			Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("# Hi\n**Bold** move")},
+		}},
+	}}}, nil)
+	if got := *result.Choices[0].Message.Content.ContentStr; got != "Hi\nBold move" {
+		t.Errorf("content = %q", got)
+	}
+	if got := responseHeaders.Get(lib.ResponseTransformsAppliedHeader); got != "plain-text" {
+		t.Errorf("%s header = %q", lib.ResponseTransformsAppliedHeader, got)
+	}
+
+	ctx = newCtx("sk-bf-sms", &lib.ResponseHeaders{})
+	plugin.PreHook(ctx, chatRequest(schemas.ChatCompletionStreamRequest))
+	var streamed strings.Builder
+	for _, delta := range []string{"# H", "i\n**Bo", "ld** mo", "ve"} {
+		chunk := &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{{
+			BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{Content: bifrost.Ptr(delta)}},
+		}}}
+		if delta == "ve" {
+			*ctx = context.WithValue(*ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+		}
+		chunk, _, _ = plugin.PostHook(ctx, chunk, nil)
+		streamed.WriteString(*chunk.Choices[0].Delta.Content)
+	}
+	if streamed.String() != "Hi\nBold move" {
+		t.Errorf("streamed content = %q", streamed.String())
+	}
+
+	ctx = newCtx("sk-bf-web", &lib.ResponseHeaders{})
+	plugin.PreHook(ctx, chatRequest(schemas.ChatCompletionRequest))
+	result, _, _ = plugin.PostHook(ctx, &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{{
+		BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &schemas.ChatMessage{
+			Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("**Bold**")},
+		}},
+	}}}, nil)
+	if got := *result.Choices[0].Message.Content.ContentStr; got != "**Bold**" {
+		t.Errorf("content of another virtual key = %q, want it untouched", got)
+	}
+}
+
+// TestResponseTransformsHandler_RejectsInvalidRules tests that invalid rules are rejected and leave the active
+// rules in place
+func TestResponseTransformsHandler_RejectsInvalidRules(t *testing.T) {
+	config := newResponseTransformsTestConfig(t, lib.ResponseTransform{Type: lib.ResponseTransformStripMarkdown})
+	h := NewResponseTransformsHandler(config, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	for _, body := range []string{
+		`{"rules":[{"name":"cut","transforms":[{"type":"max_length"}]}]}`,
+		`{"rules":[{"name":"cite","transforms":[{"type":"citations","citation_format":"mla"}]}]}`,
+		`{"rules":[{"name":"mask","transforms":[{"type":"regex_replace","replacements":[{"pattern":"(unclosed"}]}]}]}`,
+	} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBodyString(body)
+		h.updateResponseTransforms(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, ctx.Response.StatusCode())
+		}
+	}
+	if rules := config.GetResponseTransforms().Rules(); len(rules) != 1 || rules[0].Name != "plain-text" {
+		t.Errorf("rules = %+v, want the original rule", rules)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/plugins/semanticcache"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+const zeroDataRetentionPluginName = "bifrost-zero-data-retention"
+
+// zeroDataRetentionPlugin marks requests under a zero data retention policy, or opting in with the
+// x-bf-zero-data-retention header, with the zero_data_retention retention class. The logging plugin then stores
+// their metadata only, the recording and semantic cache plugins skip them, and providers that support it are
+// asked not to store them. It runs ahead of logging.
+type zeroDataRetentionPlugin struct {
+	config *lib.Config
+	// governanceStore resolves the customer of a virtual key (nil when governance is off)
+	governanceStore *governance.GovernanceStore
+}
+
+// GetName returns the name of the plugin
+func (p *zeroDataRetentionPlugin) GetName() string {
+	return zeroDataRetentionPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *zeroDataRetentionPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook sets the retention class of zero data retention requests and their provider opt-outs
+func (p *zeroDataRetentionPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if !p.applies(*ctx) {
+		return req, nil, nil
+	}
+	*ctx = context.WithValue(*ctx, schemas.BifrostContextKeyRetentionClass, schemas.RetentionClassZeroDataRetention)
+	*ctx = context.WithValue(*ctx, semanticcache.CacheNoStoreKey, true)
+	applyProviderRetentionOptOut(req)
+	return req, nil, nil
+}
+
+// applies reports whether the request falls under zero data retention
+func (p *zeroDataRetentionPlugin) applies(ctx context.Context) bool {
+	if optedIn, _ := ctx.Value(lib.ZeroDataRetentionHeaderContextKey).(bool); optedIn {
+		return true
+	}
+	if p.config.ZeroDataRetention == nil {
+		return false
+	}
+	virtualKey, _ := ctx.Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	billingCustomer, _ := ctx.Value(schemas.BifrostContextKeyBillingCustomer).(string)
+	return p.config.ZeroDataRetention.Applies(virtualKey, billingCustomer, authenticatedCustomer(ctx, p.governanceStore))
+}
+
+// applyProviderRetentionOptOut sets the provider parameters disabling the storage of a request. OpenAI and Azure
+// store completions unless store is false; other providers have no per-request control.
+func applyProviderRetentionOptOut(req *schemas.BifrostRequest) {
+	if req.Provider != schemas.OpenAI && req.Provider != schemas.Azure {
+		return
+	}
+	store := false
+	switch {
+	case req.ChatRequest != nil:
+		if req.ChatRequest.Params == nil {
+			req.ChatRequest.Params = &schemas.ChatParameters{}
+		}
+		req.ChatRequest.Params.Store = &store
+	case req.ResponsesRequest != nil:
+		if req.ResponsesRequest.Params == nil {
+			req.ResponsesRequest.Params = &schemas.ResponsesParameters{}
+		}
+		req.ResponsesRequest.Params.Store = &store
+	}
+}
+
+// PostHook is not used for this plugin
+func (p *zeroDataRetentionPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *zeroDataRetentionPlugin) Cleanup() error {
+	return nil
+}
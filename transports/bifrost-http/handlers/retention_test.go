@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/semanticcache"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// TestZeroDataRetentionPlugin_Policy tests that requests of listed virtual keys and customers, or opting in with
+// the header, are marked and opted out of provider storage
+func TestZeroDataRetentionPlugin_Policy(t *testing.T) {
+	plugin := &zeroDataRetentionPlugin{config: &lib.Config{
+		ZeroDataRetention: &lib.ZeroDataRetentionConfig{VirtualKeys: []string{"vk-zdr"}, Customers: []string{"acme"}},
+	}}
+
+	for name, ctx := range map[string]context.Context{
+		"virtual key": context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "vk-zdr"),
+		"customer":    context.WithValue(context.Background(), schemas.BifrostContextKeyBillingCustomer, "acme"),
+		"header":      context.WithValue(context.Background(), lib.ZeroDataRetentionHeaderContextKey, true),
+	} {
+		req := &schemas.BifrostRequest{Provider: schemas.OpenAI, ChatRequest: &schemas.BifrostChatRequest{}}
+		if _, _, err := plugin.PreHook(&ctx, req); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if class, _ := ctx.Value(schemas.BifrostContextKeyRetentionClass).(string); class != schemas.RetentionClassZeroDataRetention {
+			t.Errorf("%s: expected the zero data retention class, got %q", name, class)
+		}
+		if noStore, _ := ctx.Value(semanticcache.CacheNoStoreKey).(bool); !noStore {
+			t.Errorf("%s: expected the response not to be cached", name)
+		}
+		if req.ChatRequest.Params == nil || req.ChatRequest.Params.Store == nil || *req.ChatRequest.Params.Store {
+			t.Errorf("%s: expected store=false for OpenAI", name)
+		}
+	}
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "vk-other")
+	req := &schemas.BifrostRequest{Provider: schemas.OpenAI, ChatRequest: &schemas.BifrostChatRequest{}}
+	plugin.PreHook(&ctx, req)
+	if ctx.Value(schemas.BifrostContextKeyRetentionClass) != nil || req.ChatRequest.Params != nil {
+		t.Error("expected requests outside the policy to be left alone")
+	}
+}
+
+// TestApplyProviderRetentionOptOut tests that only providers with a storage opt-out get one
+func TestApplyProviderRetentionOptOut(t *testing.T) {
+	store := true
+	req := &schemas.BifrostRequest{Provider: schemas.Azure, ResponsesRequest: &schemas.BifrostResponsesRequest{
+		Params: &schemas.ResponsesParameters{Store: &store},
+	}}
+	applyProviderRetentionOptOut(req)
+	if *req.ResponsesRequest.Params.Store {
+		t.Error("expected store=false to override the caller for Azure")
+	}
+
+	req = &schemas.BifrostRequest{Provider: schemas.Anthropic, ChatRequest: &schemas.BifrostChatRequest{}}
+	applyProviderRetentionOptOut(req)
+	if req.ChatRequest.Params != nil {
+		t.Error("expected no parameters for providers without an opt-out")
+	}
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/ingestion"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	retrievalPluginName = "bifrost-retrieval"
+	retrievalErrorType  = "retrieval_error"
+)
+
+// RetrievalHandler searches the retrieval stores directly and ingests documents into them.
+type RetrievalHandler struct {
+	retriever *lib.Retriever
+	ingester  *lib.Ingester
+	logger    schemas.Logger
+}
+
+// RetrievalQueryRequest is the body of POST /api/retrieval/query.
+type RetrievalQueryRequest struct {
+	lib.RetrievalOptions
+	Query string `json:"query"`
+}
+
+// ReindexRequest is the body of POST /api/retrieval/documents/{document_id}/reindex. Unset fields keep the
+// chunking and embedding model of the document.
+type ReindexRequest struct {
+	Chunking       *ingestion.ChunkingConfig `json:"chunking,omitempty"`
+	EmbeddingModel string                    `json:"embedding_model,omitempty"`
+}
+
+// NewRetrievalHandler creates a new retrieval handler. retriever and ingester are nil when retrieval is off.
+func NewRetrievalHandler(retriever *lib.Retriever, ingester *lib.Ingester, logger schemas.Logger) *RetrievalHandler {
+	return &RetrievalHandler{
+		retriever: retriever,
+		ingester:  ingester,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes registers the retrieval routes.
+func (h *RetrievalHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/retrieval/stores", lib.ChainMiddlewares(h.listStores, middlewares...))
+	r.POST("/api/retrieval/query", lib.ChainMiddlewares(h.query, middlewares...))
+	r.GET("/api/retrieval/documents", lib.ChainMiddlewares(h.listDocuments, middlewares...))
+	r.POST("/api/retrieval/documents", lib.ChainMiddlewares(h.ingestDocument, middlewares...))
+	r.GET("/api/retrieval/documents/{document_id}", lib.ChainMiddlewares(h.getDocument, middlewares...))
+	r.DELETE("/api/retrieval/documents/{document_id}", lib.ChainMiddlewares(h.deleteDocument, middlewares...))
+	r.POST("/api/retrieval/documents/{document_id}/reindex", lib.ChainMiddlewares(h.reindexDocument, middlewares...))
+}
+
+// enabled answers 404 when retrieval is off
+func (h *RetrievalHandler) enabled(ctx *fasthttp.RequestCtx) bool {
+	if h.retriever == nil || h.ingester == nil {
+		SendError(ctx, fasthttp.StatusNotFound, "retrieval is not enabled", h.logger)
+		return false
+	}
+	return true
+}
+
+// listStores handles GET /api/retrieval/stores - List the retrieval stores
+func (h *RetrievalHandler) listStores(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	SendJSON(ctx, map[string]any{"stores": h.retriever.Stores()}, h.logger)
+}
+
+// query handles POST /api/retrieval/query - Return the chunks of a store closest to a query
+func (h *RetrievalHandler) query(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	var req RetrievalQueryRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Store == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "store is required", h.logger)
+		return
+	}
+	sources, err := h.retriever.Retrieve(ctx, req.RetrievalOptions, req.Query)
+	if err != nil {
+		if retrievalErr, ok := lib.IsRetrievalError(err); ok {
+			SendError(ctx, retrievalErr.StatusCode, retrievalErr.Message, h.logger)
+			return
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, err.Error(), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"store":   req.Store,
+		"query":   req.Query,
+		"results": sources,
+	}, h.logger)
+}
+
+var documentListSpec = &listSpec[ingestion.Document]{
+	id: func(document ingestion.Document) string { return document.ID },
+	fields: map[string]listField[ingestion.Document]{
+		"store":       {value: func(document ingestion.Document) string { return document.Store }},
+		"status":      {value: func(document ingestion.Document) string { return document.Status }},
+		"name":        {value: func(document ingestion.Document) string { return document.Name }},
+		"chunk_count": {value: func(document ingestion.Document) string { return strconv.Itoa(document.ChunkCount) }, numeric: true},
+		"created_at":  {value: func(document ingestion.Document) string { return timeListValue(document.CreatedAt) }, numeric: true},
+		"updated_at":  {value: func(document ingestion.Document) string { return timeListValue(document.UpdatedAt) }, numeric: true},
+	},
+	defaultSort: "created_at",
+}
+
+// listDocuments handles GET /api/retrieval/documents - List the ingested documents without their content
+func (h *RetrievalHandler) listDocuments(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	query, err := parseListQuery(ctx, documentListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+	documents, err := h.ingester.List(ctx, "")
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to list documents: %v", err), h.logger)
+		return
+	}
+	sendListPage(ctx, "documents", paginate(documents, documentListSpec, query), h.logger)
+}
+
+// ingestDocument handles POST /api/retrieval/documents - Store a document and queue it for indexing
+func (h *RetrievalHandler) ingestDocument(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	var req lib.IngestionRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Store == "" {
+		SendError(ctx, fasthttp.StatusBadRequest, "store is required", h.logger)
+		return
+	}
+	document, err := h.ingester.Ingest(ctx, req)
+	if err != nil {
+		h.sendDocumentError(ctx, req.ID, err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusAccepted)
+	SendJSON(ctx, document, h.logger)
+}
+
+// getDocument handles GET /api/retrieval/documents/{document_id} - Get a document and its indexing progress
+func (h *RetrievalHandler) getDocument(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	id := ctx.UserValue("document_id").(string)
+	document, err := h.ingester.Get(ctx, id)
+	if err != nil {
+		h.sendDocumentError(ctx, id, err)
+		return
+	}
+	SendJSON(ctx, document, h.logger)
+}
+
+// deleteDocument handles DELETE /api/retrieval/documents/{document_id} - Delete a document and its chunks
+func (h *RetrievalHandler) deleteDocument(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	id := ctx.UserValue("document_id").(string)
+	if err := h.ingester.Delete(ctx, id); err != nil {
+		h.sendDocumentError(ctx, id, err)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"status":  "success",
+		"message": "Document deleted successfully",
+	}, h.logger)
+}
+
+// reindexDocument handles POST /api/retrieval/documents/{document_id}/reindex - Index a document again
+func (h *RetrievalHandler) reindexDocument(ctx *fasthttp.RequestCtx) {
+	if !h.enabled(ctx) {
+		return
+	}
+	var req ReindexRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+			return
+		}
+	}
+	id := ctx.UserValue("document_id").(string)
+	document, err := h.ingester.Reindex(ctx, id, req.Chunking, req.EmbeddingModel)
+	if err != nil {
+		h.sendDocumentError(ctx, id, err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusAccepted)
+	SendJSON(ctx, document, h.logger)
+}
+
+// sendDocumentError sends the error of a document operation
+func (h *RetrievalHandler) sendDocumentError(ctx *fasthttp.RequestCtx, id string, err error) {
+	if errors.Is(err, ingestion.ErrNotFound) {
+		SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Document %s not found", id), h.logger)
+		return
+	}
+	if retrievalErr, ok := lib.IsRetrievalError(err); ok {
+		SendError(ctx, retrievalErr.StatusCode, retrievalErr.Message, h.logger)
+		return
+	}
+	SendError(ctx, fasthttp.StatusInternalServerError, err.Error(), h.logger)
+}
+
+// retrievalSourcesContextKey holds the []schemas.BifrostSource retrieved for a chat request, so fallback
+// attempts reuse them and the response can attribute them.
+const retrievalSourcesContextKey schemas.BifrostContextKey = "bifrost-retrieval-sources"
+
+// retrievalPlugin injects the chunks closest to the last user message of chat requests naming a retrieval store,
+// through the retrieval field or the x-bf-retrieval-store header, and lists them as sources of the response.
+// It runs after sessions, so retrieved context is not stored in the session, and before the context window
+// plugin, so the budget covers the injected context.
+type retrievalPlugin struct {
+	config *lib.Config
+}
+
+// GetName returns the name of the plugin
+func (p *retrievalPlugin) GetName() string {
+	return retrievalPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *retrievalPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook retrieves the chunks once per request and adds them as a system message after the client's leading
+// system messages. Requests naming an unknown store or whose retrieval fails are rejected.
+func (p *retrievalPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if req.ChatRequest == nil {
+		return req, nil, nil
+	}
+	options, ok := (*ctx).Value(lib.RetrievalOptionsContextKey).(*lib.RetrievalOptions)
+	if !ok || options == nil {
+		store, _ := (*ctx).Value(lib.RetrievalStoreContextKey).(string)
+		if store == "" {
+			return req, nil, nil
+		}
+		options = &lib.RetrievalOptions{Store: store}
+	}
+	retriever := p.config.Retrieval
+	if retriever == nil {
+		return req, &schemas.PluginShortCircuit{Error: retrievalError(fasthttp.StatusBadRequest, "retrieval is not enabled")}, nil
+	}
+
+	sources, retrieved := (*ctx).Value(retrievalSourcesContextKey).([]schemas.BifrostSource)
+	if !retrieved {
+		var err error
+		sources, err = retriever.Retrieve(*ctx, *options, lib.LastUserMessageText(req.ChatRequest.Input))
+		if err != nil {
+			if retrievalErr, ok := lib.IsRetrievalError(err); ok {
+				return req, &schemas.PluginShortCircuit{Error: retrievalError(retrievalErr.StatusCode, retrievalErr.Message)}, nil
+			}
+			return req, &schemas.PluginShortCircuit{Error: retrievalError(fasthttp.StatusInternalServerError, err.Error())}, nil
+		}
+		*ctx = context.WithValue(*ctx, retrievalSourcesContextKey, sources)
+	}
+	if len(sources) == 0 {
+		return req, nil, nil
+	}
+
+	input := req.ChatRequest.Input
+	leading := lib.LeadingSystemMessages(input)
+	messages := make([]schemas.ChatMessage, 0, len(input)+1)
+	messages = append(messages, input[:leading]...)
+	messages = append(messages, lib.RetrievalContextMessage(retriever.Prompt(), sources))
+	messages = append(messages, input[leading:]...)
+
+	withContext := *req
+	chatReq := *req.ChatRequest
+	chatReq.Input = messages
+	withContext.ChatRequest = &chatReq
+	return &withContext, nil, nil
+}
+
+// retrievalError is the error of a request whose retrieval failed. Fallbacks would retrieve the same way, so
+// they are not attempted.
+func retrievalError(statusCode int, message string) *schemas.BifrostError {
+	errorType := retrievalErrorType
+	allowFallbacks := false
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		AllowFallbacks: &allowFallbacks,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Message: message,
+		},
+	}
+}
+
+// PostHook lists the retrieved chunks as sources of the response, on the final chunk of streams
+func (p *retrievalPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	sources, _ := (*ctx).Value(retrievalSourcesContextKey).([]schemas.BifrostSource)
+	if result == nil || len(sources) == 0 {
+		return result, bifrostErr, nil
+	}
+	if len(result.Choices) > 0 && result.Choices[0].BifrostStreamResponseChoice != nil {
+		if isFinalChunk, _ := (*ctx).Value(schemas.BifrostContextKeyStreamEndIndicator).(bool); !isFinalChunk {
+			return result, bifrostErr, nil
+		}
+	}
+	result.ExtraFields.Sources = sources
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *retrievalPlugin) Cleanup() error {
+	return nil
+}
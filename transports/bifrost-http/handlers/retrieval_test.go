@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/ingestion"
+	"github.com/maximhq/bifrost/framework/vectorstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// retrievalTestStore returns a fixed chunk from GetNearest, records the queries it was given and keeps the
+// chunks added to it
+type retrievalTestStore struct {
+	vectorstore.VectorStore
+	err     error
+	vectors [][]float32
+	queries [][]vectorstore.Query
+
+	mu     sync.Mutex
+	chunks map[string]map[string]interface{}
+}
+
+func (s *retrievalTestStore) Add(ctx context.Context, namespace string, id string, embedding []float32, metadata map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chunks == nil {
+		s.chunks = make(map[string]map[string]interface{})
+	}
+	s.chunks[id] = metadata
+	return nil
+}
+
+func (s *retrievalTestStore) Delete(ctx context.Context, namespace string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.chunks, id)
+	return nil
+}
+
+// chunkIDs returns the IDs of the stored chunks, sorted
+func (s *retrievalTestStore) chunkIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.chunks))
+	for id := range s.chunks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (s *retrievalTestStore) GetNearest(ctx context.Context, namespace string, vector []float32, queries []vectorstore.Query, selectFields []string, threshold float64, limit int64) ([]vectorstore.SearchResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.vectors = append(s.vectors, vector)
+	s.queries = append(s.queries, queries)
+	score := 0.91
+	return []vectorstore.SearchResult{{
+		ID:         "handbook#3",
+		Score:      &score,
+		Properties: map[string]interface{}{"text": "Employees get 25 days of paid leave.", "source": "handbook.pdf", "page": 3.0},
+	}}, nil
+}
+
+// retrievalTestPlugin plays the embedding model and the chat model, recording their input
+type retrievalTestPlugin struct {
+	inputs   [][]schemas.ChatMessage
+	embedded []string // Texts of the embedding requests
+}
+
+func (p *retrievalTestPlugin) GetName() string {
+	return "retrieval-test"
+}
+
+func (p *retrievalTestPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+func (p *retrievalTestPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if req.EmbeddingRequest != nil {
+		p.embedded = append(p.embedded, req.EmbeddingRequest.Input.Texts...)
+		data := make([]schemas.BifrostEmbedding, len(req.EmbeddingRequest.Input.Texts))
+		for i := range data {
+			data[i] = schemas.BifrostEmbedding{Index: i, Embedding: schemas.BifrostEmbeddingResponse{EmbeddingArray: []float32{0.6, 0.8}}}
+		}
+		return req, &schemas.PluginShortCircuit{Response: &schemas.BifrostResponse{Data: data}}, nil
+	}
+	p.inputs = append(p.inputs, req.ChatRequest.Input)
+	return req, &schemas.PluginShortCircuit{Response: &schemas.BifrostResponse{
+		Choices: []schemas.BifrostChatResponseChoice{{BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{
+			Message: &schemas.ChatMessage{Role: schemas.ChatMessageRoleAssistant, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("25 days [1].")}},
+		}}},
+	}}, nil
+}
+
+func (p *retrievalTestPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+func (p *retrievalTestPlugin) Cleanup() error {
+	return nil
+}
+
+// newRetrievalTestGateway creates a gateway retrieving from store through the retrieval plugin
+func newRetrievalTestGateway(t *testing.T, store vectorstore.VectorStore, plugin *retrievalTestPlugin) (*lib.Config, *bifrost.Bifrost) {
+	t.Helper()
+	gateway := &lib.Config{}
+	config := lib.RetrievalConfig{Enabled: true, Stores: []lib.RetrievalStoreConfig{{
+		Name:           "docs",
+		VectorStore:    vectorstore.Config{Type: vectorstore.VectorStoreTypePgVector},
+		Namespace:      "handbook",
+		EmbeddingModel: "mock/embed",
+	}}}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	gateway.Retrieval = lib.NewRetriever(config, map[string]vectorstore.VectorStore{"docs": store}, gateway)
+	gateway.Ingestion = lib.NewIngester(gateway.Retrieval, ingestion.NewInMemoryStore(), 1)
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: raceTestAccount{},
+		Plugins: []schemas.Plugin{&retrievalPlugin{config: gateway}, plugin},
+		Logger:  bifrost.NewDefaultLogger(schemas.LogLevelError),
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+	gateway.SetBifrostClient(client)
+	return gateway, client
+}
+
+// TestRetrieval_InjectsContextAndSources tests that chat requests naming a store get the retrieved chunks as a
+// system message after their own system prompt, and the chunks as sources of the response
+func TestRetrieval_InjectsContextAndSources(t *testing.T) {
+	store := &retrievalTestStore{}
+	plugin := &retrievalTestPlugin{}
+	_, client := newRetrievalTestGateway(t, store, plugin)
+
+	ctx := context.WithValue(context.Background(), lib.RetrievalOptionsContextKey, &lib.RetrievalOptions{
+		Store:   "docs",
+		Filters: map[string]any{"department": "hr"},
+	})
+	resp, bifrostErr := client.ChatCompletionRequest(ctx, &schemas.BifrostChatRequest{
+		Provider: "mock",
+		Model:    "chat",
+		Input: []schemas.ChatMessage{
+			{Role: schemas.ChatMessageRoleSystem, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("Be brief.")}},
+			{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("How much leave do I get?")}},
+		},
+	})
+	if bifrostErr != nil {
+		t.Fatalf("chat request failed: %+v", bifrostErr.Error)
+	}
+
+	if len(plugin.inputs) != 1 || len(plugin.inputs[0]) != 3 {
+		t.Fatalf("chat inputs = %+v, want the system prompt, the context and the question", plugin.inputs)
+	}
+	injected := plugin.inputs[0][1]
+	if injected.Role != schemas.ChatMessageRoleSystem || !strings.Contains(*injected.Content.ContentStr, "[1] (source: handbook.pdf)\nEmployees get 25 days of paid leave.") {
+		t.Errorf("injected message = %+v", injected)
+	}
+	if len(store.queries) != 1 || len(store.queries[0]) != 1 || store.queries[0][0].Field != "department" || store.queries[0][0].Value != "hr" {
+		t.Errorf("store queries = %+v, want the department filter", store.queries)
+	}
+
+	sources := resp.ExtraFields.Sources
+	if len(sources) != 1 || sources[0].ID != "handbook#3" || sources[0].Store != "docs" || sources[0].Source != "handbook.pdf" || sources[0].Metadata["page"] != 3.0 {
+		t.Errorf("sources = %+v", sources)
+	}
+}
+
+// TestRetrieval_Failures tests that requests whose retrieval fails are rejected with the status of the failure
+func TestRetrieval_Failures(t *testing.T) {
+	store := &retrievalTestStore{}
+	plugin := &retrievalTestPlugin{}
+	_, client := newRetrievalTestGateway(t, store, plugin)
+	question := []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("How much leave do I get?")}}}
+
+	ctx := context.WithValue(context.Background(), lib.RetrievalStoreContextKey, "wiki")
+	_, bifrostErr := client.ChatCompletionRequest(ctx, &schemas.BifrostChatRequest{Provider: "mock", Model: "chat", Input: question})
+	if bifrostErr == nil || bifrostErr.StatusCode == nil || *bifrostErr.StatusCode != fasthttp.StatusBadRequest {
+		t.Errorf("unknown store error = %+v, want 400", bifrostErr)
+	}
+
+	store.err = errors.New("connection refused")
+	ctx = context.WithValue(context.Background(), lib.RetrievalStoreContextKey, "docs")
+	_, bifrostErr = client.ChatCompletionRequest(ctx, &schemas.BifrostChatRequest{Provider: "mock", Model: "chat", Input: question})
+	if bifrostErr == nil || bifrostErr.StatusCode == nil || *bifrostErr.StatusCode != fasthttp.StatusBadGateway {
+		t.Errorf("store failure error = %+v, want 502", bifrostErr)
+	}
+	if len(plugin.inputs) != 0 {
+		t.Errorf("chat requests were sent despite failed retrievals: %+v", plugin.inputs)
+	}
+}
+
+// TestRetrieval_QueryEndpoint tests searching a store directly
+func TestRetrieval_QueryEndpoint(t *testing.T) {
+	store := &retrievalTestStore{}
+	gateway, _ := newRetrievalTestGateway(t, store, &retrievalTestPlugin{})
+	h := NewRetrievalHandler(gateway.Retrieval, gateway.Ingestion, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetBodyString(`{"store":"docs","query":"paid leave","top_k":2}`)
+	h.query(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("query status = %d, body = %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var body struct {
+		Results []schemas.BifrostSource `json:"results"`
+	}
+	if err := json.Unmarshal(ctx.Response.Body(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Results) != 1 || body.Results[0].Text != "Employees get 25 days of paid leave." || *body.Results[0].Score != 0.91 {
+		t.Errorf("results = %+v", body.Results)
+	}
+	if len(store.vectors) != 1 || len(store.vectors[0]) != 2 {
+		t.Errorf("store was searched with %+v, want the query embedding", store.vectors)
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetBodyString(`{"query":"paid leave"}`)
+	h.query(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("query without store status = %d, want 400", ctx.Response.StatusCode())
+	}
+
+	disabled := NewRetrievalHandler(nil, nil, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	ctx = &fasthttp.RequestCtx{}
+	disabled.listStores(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("disabled retrieval status = %d, want 404", ctx.Response.StatusCode())
+	}
+}
+
+// waitForDocument polls a document until it is finished
+func waitForDocument(t *testing.T, ingester *lib.Ingester, id string) *ingestion.Document {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		document, err := ingester.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("failed to get document: %v", err)
+		}
+		if document.IsFinished() {
+			return document
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("document %s is still %s", id, document.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRetrieval_DocumentIngestion tests ingesting, reindexing and deleting a document through the endpoints
+func TestRetrieval_DocumentIngestion(t *testing.T) {
+	store := &retrievalTestStore{}
+	plugin := &retrievalTestPlugin{}
+	gateway, _ := newRetrievalTestGateway(t, store, plugin)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	gateway.Ingestion.Start(ctx)
+	h := NewRetrievalHandler(gateway.Retrieval, gateway.Ingestion, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	reqCtx := &fasthttp.RequestCtx{}
+	reqCtx.Request.SetBodyString(`{"id":"handbook","store":"docs","name":"handbook.pdf","content":"Leave.\n\nPay.\n\nTravel.","metadata":{"department":"hr"},"chunking":{"strategy":"paragraph","size":10,"overlap":0}}`)
+	h.ingestDocument(reqCtx)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusAccepted {
+		t.Fatalf("ingest status = %d, body = %s", reqCtx.Response.StatusCode(), reqCtx.Response.Body())
+	}
+	document := waitForDocument(t, gateway.Ingestion, "handbook")
+	if document.Status != ingestion.StatusCompleted || document.ChunkCount != 3 || document.EmbeddedChunks != 3 {
+		t.Fatalf("document = %+v, want 3 embedded chunks", document)
+	}
+	if ids := store.chunkIDs(); strings.Join(ids, ",") != "handbook#0,handbook#1,handbook#2" {
+		t.Errorf("stored chunks = %v", ids)
+	}
+	chunk := store.chunks["handbook#1"]
+	if chunk["text"] != "Pay." || chunk["source"] != "handbook.pdf" || chunk["department"] != "hr" || chunk["document_id"] != "handbook" || chunk["chunk_index"] != 1 {
+		t.Errorf("chunk metadata = %+v", chunk)
+	}
+
+	reqCtx = &fasthttp.RequestCtx{}
+	reqCtx.Request.SetBodyString(`{"id":"handbook","store":"docs","content":"Leave."}`)
+	h.ingestDocument(reqCtx)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusConflict {
+		t.Errorf("duplicate ingest status = %d, want 409", reqCtx.Response.StatusCode())
+	}
+	reqCtx = &fasthttp.RequestCtx{}
+	reqCtx.Request.SetBodyString(`{"store":"docs","content":"Leave.","chunking":{"size":10,"overlap":10}}`)
+	h.ingestDocument(reqCtx)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("invalid chunking status = %d, want 400", reqCtx.Response.StatusCode())
+	}
+
+	// Reindexing into fewer chunks removes the ones left over
+	reqCtx = &fasthttp.RequestCtx{}
+	reqCtx.SetUserValue("document_id", "handbook")
+	reqCtx.Request.SetBodyString(`{"chunking":{"strategy":"fixed","size":100}}`)
+	h.reindexDocument(reqCtx)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusAccepted {
+		t.Fatalf("reindex status = %d, body = %s", reqCtx.Response.StatusCode(), reqCtx.Response.Body())
+	}
+	document = waitForDocument(t, gateway.Ingestion, "handbook")
+	if document.Status != ingestion.StatusCompleted || document.ChunkCount != 1 {
+		t.Fatalf("reindexed document = %+v, want 1 chunk", document)
+	}
+	if ids := store.chunkIDs(); strings.Join(ids, ",") != "handbook#0" {
+		t.Errorf("stored chunks after reindex = %v", ids)
+	}
+
+	reqCtx = &fasthttp.RequestCtx{}
+	reqCtx.SetUserValue("document_id", "handbook")
+	h.deleteDocument(reqCtx)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("delete status = %d, body = %s", reqCtx.Response.StatusCode(), reqCtx.Response.Body())
+	}
+	if ids := store.chunkIDs(); len(ids) != 0 {
+		t.Errorf("stored chunks after delete = %v", ids)
+	}
+	reqCtx = &fasthttp.RequestCtx{}
+	reqCtx.SetUserValue("document_id", "handbook")
+	h.getDocument(reqCtx)
+	if reqCtx.Response.StatusCode() != fasthttp.StatusNotFound {
+		t.Errorf("deleted document status = %d, want 404", reqCtx.Response.StatusCode())
+	}
+}
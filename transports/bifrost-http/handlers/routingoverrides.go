@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// RoutingOverridesHandler manages the policies gating the routing override headers.
+type RoutingOverridesHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// NewRoutingOverridesHandler creates a new routing overrides handler.
+func NewRoutingOverridesHandler(store *lib.Config, logger schemas.Logger) *RoutingOverridesHandler {
+	return &RoutingOverridesHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the routing overrides routes.
+func (h *RoutingOverridesHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/routing-overrides", lib.ChainMiddlewares(h.getRoutingOverrides, middlewares...))
+	r.PUT("/api/routing-overrides", lib.ChainMiddlewares(h.updateRoutingOverrides, middlewares...))
+}
+
+// getRoutingOverrides handles GET /api/routing-overrides - Get the routing override policies
+func (h *RoutingOverridesHandler) getRoutingOverrides(ctx *fasthttp.RequestCtx) {
+	response := lib.RoutingOverridesConfig{}
+	if config := h.store.GetRoutingOverrides(); config != nil {
+		response = *config
+	}
+	if response.Policies == nil {
+		response.Policies = []lib.RoutingOverridePolicy{}
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// updateRoutingOverrides handles PUT /api/routing-overrides - Replace the routing override policies
+func (h *RoutingOverridesHandler) updateRoutingOverrides(ctx *fasthttp.RequestCtx) {
+	var req lib.RoutingOverridesConfig
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Policies == nil {
+		req.Policies = []lib.RoutingOverridePolicy{}
+	}
+	if err := req.Validate(); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid routing overrides: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateRoutingOverrides(ctx, req); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update routing overrides: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, req, h.logger)
+}
+
+// RoutingOverrideMiddleware applies the x-bifrost-provider and x-bifrost-fallbacks headers of requests to the
+// /v1 inference endpoints, pinning their provider or replacing their fallbacks. Requests whose virtual key has no
+// policy allowing the override are rejected with 403. It runs after language routing and experiments, so the
+// caller's choice wins over them, and before transformation rules, which match the overridden route. The headers
+// are ignored while routing overrides are disabled.
+func RoutingOverrideMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			overrides := config.GetRoutingOverrides()
+			provider := string(ctx.Request.Header.Peek(lib.ProviderOverrideHeader))
+			fallbacks := string(ctx.Request.Header.Peek(lib.FallbacksOverrideHeader))
+			if overrides == nil || !overrides.Enabled || (provider == "" && fallbacks == "") ||
+				!ctx.IsPost() || !strings.HasPrefix(string(ctx.Path()), "/v1/") {
+				next(ctx)
+				return
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil || body == nil {
+				next(ctx)
+				return
+			}
+			changed, err := overrides.Apply(body, string(ctx.Request.Header.Peek("x-bf-vk")), provider, fallbacks)
+			if errors.Is(err, lib.ErrRoutingOverrideForbidden) {
+				SendError(ctx, fasthttp.StatusForbidden, err.Error(), logger)
+				return
+			}
+			if err != nil {
+				SendError(ctx, fasthttp.StatusBadRequest, err.Error(), logger)
+				return
+			}
+			if changed {
+				updatedBody, err := json.Marshal(body)
+				if err != nil {
+					SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to apply routing override: %v", err), logger)
+					return
+				}
+				ctx.Request.SetBody(updatedBody)
+			}
+			next(ctx)
+		}
+	}
+}
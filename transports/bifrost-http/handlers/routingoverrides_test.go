@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestRoutingOverrideMiddleware tests that the override headers pin providers and replace fallbacks for the virtual
+// keys allowed to, and are rejected for the others
+func TestRoutingOverrideMiddleware(t *testing.T) {
+	config := &lib.Config{}
+	err := config.UpdateRoutingOverrides(context.Background(), lib.RoutingOverridesConfig{
+		Enabled: true,
+		Policies: []lib.RoutingOverridePolicy{
+			{Name: "trusted", VirtualKeys: []string{"sk-bf-trusted-*"}, AllowProvider: true, AllowFallbacks: true},
+			{Name: "restricted", VirtualKeys: []string{"sk-bf-app"}, AllowProvider: true, Providers: []string{"openai", "azure"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to set routing overrides: %v", err)
+	}
+
+	const body = `{"model":"openai/gpt-4o","fallbacks":["anthropic/claude-3-5-sonnet","azure/gpt-4o"],"messages":[{"role":"user","content":"hi"}]}`
+	tests := []struct {
+		name          string
+		virtualKey    string
+		provider      string
+		fallbacks     string
+		wantStatus    int
+		wantModel     string
+		wantFallbacks []any
+	}{
+		{
+			name:          "pinned provider among the fallbacks",
+			virtualKey:    "sk-bf-trusted-1",
+			provider:      "anthropic",
+			wantModel:     "anthropic/claude-3-5-sonnet",
+			wantFallbacks: []any{"openai/gpt-4o", "azure/gpt-4o"},
+		},
+		{
+			name:          "pinned provider and model",
+			virtualKey:    "sk-bf-trusted-1",
+			provider:      "vertex/gemini-1.5-pro",
+			wantModel:     "vertex/gemini-1.5-pro",
+			wantFallbacks: []any{"openai/gpt-4o", "anthropic/claude-3-5-sonnet", "azure/gpt-4o"},
+		},
+		{
+			name:          "reordered fallbacks",
+			virtualKey:    "sk-bf-trusted-2",
+			fallbacks:     "azure, groq/llama-3.1-70b, openai",
+			wantModel:     "openai/gpt-4o",
+			wantFallbacks: []any{"azure/gpt-4o", "groq/llama-3.1-70b"},
+		},
+		{
+			name:       "fallbacks disabled",
+			virtualKey: "sk-bf-trusted-2",
+			provider:   "azure",
+			fallbacks:  "none",
+			wantModel:  "azure/gpt-4o",
+		},
+		{
+			name:          "provider with the requested model",
+			virtualKey:    "sk-bf-app",
+			provider:      "azure",
+			wantModel:     "azure/gpt-4o",
+			wantFallbacks: []any{"openai/gpt-4o", "anthropic/claude-3-5-sonnet"},
+		},
+		{
+			name:       "provider not allowed by the policy",
+			virtualKey: "sk-bf-app",
+			provider:   "anthropic",
+			wantStatus: fasthttp.StatusForbidden,
+		},
+		{
+			name:       "fallbacks not allowed by the policy",
+			virtualKey: "sk-bf-app",
+			fallbacks:  "azure",
+			wantStatus: fasthttp.StatusForbidden,
+		},
+		{
+			name:       "virtual key without a policy",
+			virtualKey: "sk-bf-other",
+			provider:   "anthropic",
+			wantStatus: fasthttp.StatusForbidden,
+		},
+		{
+			name:       "no virtual key",
+			provider:   "anthropic",
+			wantStatus: fasthttp.StatusForbidden,
+		},
+		{
+			name:          "no override",
+			virtualKey:    "sk-bf-other",
+			wantModel:     "openai/gpt-4o",
+			wantFallbacks: []any{"anthropic/claude-3-5-sonnet", "azure/gpt-4o"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+			ctx.Request.SetRequestURI("/v1/chat/completions")
+			if tt.virtualKey != "" {
+				ctx.Request.Header.Set("x-bf-vk", tt.virtualKey)
+			}
+			if tt.provider != "" {
+				ctx.Request.Header.Set(lib.ProviderOverrideHeader, tt.provider)
+			}
+			if tt.fallbacks != "" {
+				ctx.Request.Header.Set(lib.FallbacksOverrideHeader, tt.fallbacks)
+			}
+			ctx.Request.SetBodyString(body)
+
+			var got map[string]any
+			RoutingOverrideMiddleware(config)(func(ctx *fasthttp.RequestCtx) {
+				if err := json.Unmarshal(ctx.Request.Body(), &got); err != nil {
+					t.Fatalf("invalid body: %v", err)
+				}
+			})(ctx)
+
+			if tt.wantStatus != 0 {
+				if ctx.Response.StatusCode() != tt.wantStatus || got != nil {
+					t.Errorf("status = %d, want %d and the request rejected", ctx.Response.StatusCode(), tt.wantStatus)
+				}
+				return
+			}
+			if got["model"] != tt.wantModel {
+				t.Errorf("model = %v, want %s", got["model"], tt.wantModel)
+			}
+			if fallbacks, _ := got["fallbacks"].([]any); !reflect.DeepEqual(fallbacks, tt.wantFallbacks) {
+				t.Errorf("fallbacks = %v, want %v", fallbacks, tt.wantFallbacks)
+			}
+		})
+	}
+}
+
+// TestRoutingOverrideMiddleware_Disabled tests that the override headers are ignored while routing overrides are disabled
+func TestRoutingOverrideMiddleware_Disabled(t *testing.T) {
+	config := &lib.Config{}
+	if err := config.UpdateRoutingOverrides(context.Background(), lib.RoutingOverridesConfig{}); err != nil {
+		t.Fatalf("failed to set routing overrides: %v", err)
+	}
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/v1/chat/completions")
+	ctx.Request.Header.Set(lib.ProviderOverrideHeader, "anthropic")
+	ctx.Request.SetBodyString(`{"model":"openai/gpt-4o"}`)
+
+	called := false
+	RoutingOverrideMiddleware(config)(func(ctx *fasthttp.RequestCtx) {
+		called = true
+		if body := string(ctx.Request.Body()); body != `{"model":"openai/gpt-4o"}` {
+			t.Errorf("body = %s, want it unchanged", body)
+		}
+	})(ctx)
+	if !called {
+		t.Error("expected the request to be passed through")
+	}
+}
+
+// TestRoutingOverridesHandler_RejectsInvalidConfig tests that invalid policies are rejected
+func TestRoutingOverridesHandler_RejectsInvalidConfig(t *testing.T) {
+	config := &lib.Config{}
+	h := NewRoutingOverridesHandler(config, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	for _, body := range []string{
+		`{"enabled":true,"policies":[{"virtual_keys":["sk-bf-app"],"allow_provider":true}]}`,
+		`{"enabled":true,"policies":[{"name":"app","allow_provider":true}]}`,
+		`{"enabled":true,"policies":[{"name":"app","virtual_keys":["sk-bf-app"]}]}`,
+		`{"enabled":true,"policies":[{"name":"app","virtual_keys":["sk-bf-app"],"allow_provider":true},{"name":"app","virtual_keys":["sk-bf-other"],"allow_fallbacks":true}]}`,
+	} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBodyString(body)
+		h.updateRoutingOverrides(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, ctx.Response.StatusCode())
+		}
+	}
+	if config.GetRoutingOverrides() != nil {
+		t.Error("expected no routing overrides to be activated")
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// ScalingHandler serves replica recommendations computed from the load of this replica, for Kubernetes
+// autoscalers (KEDA metrics-api triggers, or HPA external metrics through an adapter).
+type ScalingHandler struct {
+	scaler *lib.Scaler
+	queues func() map[schemas.ModelProvider]schemas.ProviderQueueStats
+	logger schemas.Logger
+}
+
+// ScalingRecommendationResponse is the response of GET /api/scaling/recommendation.
+type ScalingRecommendationResponse struct {
+	lib.ScalingRecommendation
+	Queues map[schemas.ModelProvider]schemas.ProviderQueueStats `json:"queues"` // Requests waiting for each provider
+}
+
+// NewScalingHandler creates a new scaling handler reading the provider queues from queues.
+func NewScalingHandler(scaler *lib.Scaler, queues func() map[schemas.ModelProvider]schemas.ProviderQueueStats, logger schemas.Logger) *ScalingHandler {
+	return &ScalingHandler{
+		scaler: scaler,
+		queues: queues,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the scaling recommendation route.
+func (h *ScalingHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/scaling/recommendation", lib.ChainMiddlewares(h.getRecommendation, middlewares...))
+}
+
+// getRecommendation handles GET /api/scaling/recommendation - Get the replica count recommended for the load of
+// this replica. current_replicas is the replica count the load is spread over (default: 1).
+func (h *ScalingHandler) getRecommendation(ctx *fasthttp.RequestCtx) {
+	currentReplicas := 1
+	if value := string(ctx.QueryArgs().Peek("current_replicas")); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			SendError(ctx, fasthttp.StatusBadRequest, "current_replicas must be a positive integer", h.logger)
+			return
+		}
+		currentReplicas = parsed
+	}
+
+	queues := h.queues()
+	queueDepth := 0
+	for _, queue := range queues {
+		queueDepth += queue.Queued
+	}
+	load := h.scaler.Load(queueDepth, time.Now())
+	SendJSON(ctx, ScalingRecommendationResponse{
+		ScalingRecommendation: h.scaler.Recommend(load, currentReplicas),
+		Queues:                queues,
+	}, h.logger)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestScalerRecommend tests that the recommended replicas follow the signal furthest over its target, scaled by
+// the current replicas and bounded, and that signals which cannot be measured are left out
+func TestScalerRecommend(t *testing.T) {
+	scaler := lib.NewScaler(lib.ScalingConfig{MinReplicas: 2, MaxReplicas: 8, TargetQueueDepth: 10, TargetInFlightStreams: 100, TargetCPUUtilization: 0.5})
+	cpu := 0.75
+	cases := []struct {
+		name            string
+		load            lib.ScalingLoad
+		currentReplicas int
+		desired         int
+		limiting        string
+	}{
+		{"idle", lib.ScalingLoad{}, 4, 2, ""},
+		{"queue over target", lib.ScalingLoad{QueueDepth: 25, InFlightStreams: 50}, 2, 5, lib.ScalingSignalQueueDepth},
+		{"streams on target", lib.ScalingLoad{InFlightStreams: 100}, 3, 3, lib.ScalingSignalInFlightStreams},
+		{"cpu over target", lib.ScalingLoad{QueueDepth: 5, CPUUtilization: &cpu}, 2, 3, lib.ScalingSignalCPU},
+		{"memory capped", lib.ScalingLoad{MemoryBytes: 900, MemoryLimitBytes: 1000}, 10, 8, lib.ScalingSignalMemory},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recommendation := scaler.Recommend(tc.load, tc.currentReplicas)
+			if recommendation.DesiredReplicas != tc.desired || recommendation.LimitingSignal != tc.limiting {
+				t.Errorf("recommended %d replicas limited by %q, want %d limited by %q", recommendation.DesiredReplicas, recommendation.LimitingSignal, tc.desired, tc.limiting)
+			}
+			_, hasCPU := recommendation.Signals[lib.ScalingSignalCPU]
+			_, hasMemory := recommendation.Signals[lib.ScalingSignalMemory]
+			if hasCPU != (tc.load.CPUUtilization != nil) || hasMemory != (tc.load.MemoryLimitBytes > 0) {
+				t.Errorf("unexpected signals %+v", recommendation.Signals)
+			}
+		})
+	}
+}
+
+// TestScalingHandler tests that the recommendation counts the requests waiting in every provider queue and the
+// streams in flight, and rejects invalid replica counts
+func TestScalingHandler(t *testing.T) {
+	handler := NewScalingHandler(lib.NewScaler(lib.ScalingConfig{TargetQueueDepth: 10, TargetInFlightStreams: 1, MemoryLimitBytes: 1 << 40}), func() map[schemas.ModelProvider]schemas.ProviderQueueStats {
+		return map[schemas.ModelProvider]schemas.ProviderQueueStats{
+			schemas.OpenAI:    {Queued: 12, Capacity: 100},
+			schemas.Anthropic: {Queued: 3, Capacity: 100},
+		}
+	}, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	get := func(query string) *fasthttp.RequestCtx {
+		t.Helper()
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI("/api/scaling/recommendation" + query)
+		handler.getRecommendation(ctx)
+		return ctx
+	}
+
+	done := lib.TrackStream()
+	ctx := get("?current_replicas=2")
+	done()
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("unexpected status %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var response ScalingRecommendationResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if queue := response.Signals[lib.ScalingSignalQueueDepth]; queue.Value != 15 || queue.Utilization != 1.5 {
+		t.Errorf("unexpected queue depth signal %+v", queue)
+	}
+	if streams := response.Signals[lib.ScalingSignalInFlightStreams]; streams.Value != 1 {
+		t.Errorf("unexpected in-flight streams signal %+v", streams)
+	}
+	if _, ok := response.Signals[lib.ScalingSignalMemory]; !ok {
+		t.Error("expected the memory signal with a memory limit configured")
+	}
+	if response.CurrentReplicas != 2 || response.DesiredReplicas != 3 || response.Queues[schemas.OpenAI].Queued != 12 {
+		t.Errorf("unexpected recommendation %+v", response)
+	}
+
+	for _, query := range []string{"?current_replicas=0", "?current_replicas=many"} {
+		if status := get(query).Response.StatusCode(); status != fasthttp.StatusBadRequest {
+			t.Errorf("status = %d for %s, want 400", status, query)
+		}
+	}
+}
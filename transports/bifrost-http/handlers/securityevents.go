@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const securityEventsPluginName = "bifrost-security-events"
+
+// securityEventsTargetContextKey holds the provider and model of the current attempt of a request
+const securityEventsTargetContextKey schemas.BifrostContextKey = "bifrost-security-events-target"
+
+// SecurityEventsMiddleware reports auth events and admin actions:
+//   - logins, failed logins and logouts of the dashboard
+//   - requests rejected as unauthenticated (401), and management requests rejected as forbidden (403)
+//   - management API changes (POST, PUT, PATCH and DELETE outside /api/auth), successful or not
+//
+// Policy violations of inference requests are reported by securityEventsPlugin instead, which knows the reason.
+func SecurityEventsMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			path := string(ctx.Path())
+			method := string(ctx.Method())
+			// The session is resolved before the handler runs, as logout clears it
+			session := authSession(ctx, config)
+			next(ctx)
+			// Requests the UI proxy dispatched are reported by the dispatched pass
+			if proxied, _ := ctx.UserValue(lib.UIProxyContextKey).(bool); proxied && strings.HasPrefix(path, uiProxyPrefix) {
+				return
+			}
+			if event, ok := classifySecurityEvent(method, path, ctx.Response.StatusCode(), session); ok {
+				event.SourceIP = ctx.RemoteIP().String()
+				event.Method = method
+				event.Path = path
+				event.Status = ctx.Response.StatusCode()
+				config.SecurityEvents.Emit(event)
+			}
+		}
+	}
+}
+
+// classifySecurityEvent returns the security event of an HTTP request, if it is one
+func classifySecurityEvent(method, path string, status int, session AuthSession) (lib.SecurityEvent, bool) {
+	outcome := "success"
+	if status >= 400 {
+		outcome = "failure"
+	}
+	switch {
+	case method == fasthttp.MethodPost && path == "/api/auth/login":
+		if outcome == "success" {
+			return lib.SecurityEvent{Category: lib.SecurityEventCategoryAuth, Type: "login", Outcome: outcome, Severity: 3, Actor: "admin", Message: "admin signed in"}, true
+		}
+		return lib.SecurityEvent{Category: lib.SecurityEventCategoryAuth, Type: "login_failed", Outcome: outcome, Severity: 6, Actor: "anonymous", Message: "admin sign-in failed"}, true
+	case method == fasthttp.MethodPost && path == "/api/auth/logout":
+		return lib.SecurityEvent{Category: lib.SecurityEventCategoryAuth, Type: "logout", Outcome: outcome, Severity: 1, Actor: securityActor(session), Message: "admin signed out"}, true
+	case strings.HasPrefix(path, "/api/auth/"):
+		return lib.SecurityEvent{}, false
+	case status == fasthttp.StatusUnauthorized:
+		return lib.SecurityEvent{Category: lib.SecurityEventCategoryAuth, Type: "authentication_failed", Outcome: outcome, Severity: 5, Actor: securityActor(session), Message: "request rejected as unauthenticated"}, true
+	case status == fasthttp.StatusForbidden && !isInferencePath(path):
+		return lib.SecurityEvent{Category: lib.SecurityEventCategoryAuth, Type: "authorization_denied", Outcome: outcome, Severity: 6, Actor: securityActor(session), Message: "request rejected as forbidden"}, true
+	case strings.HasPrefix(path, "/api/") && method != fasthttp.MethodGet && method != fasthttp.MethodHead && method != fasthttp.MethodOptions:
+		severity := 3
+		if method == fasthttp.MethodDelete {
+			severity = 5
+		}
+		return lib.SecurityEvent{Category: lib.SecurityEventCategoryAdmin, Type: "admin_action", Outcome: outcome, Severity: severity, Actor: securityActor(session), Message: method + " " + path}, true
+	}
+	return lib.SecurityEvent{}, false
+}
+
+// securityActor names the caller of a management request
+func securityActor(session AuthSession) string {
+	switch {
+	case session.Method == AuthMethodServiceAccount:
+		return "service_account:" + session.ServiceAccount
+	case session.Authenticated && session.AuthEnabled:
+		return "admin"
+	case !session.AuthEnabled:
+		return "unauthenticated" // Admin auth is off
+	}
+	return "anonymous"
+}
+
+// securityEventsPlugin reports inference requests rejected by governance, moderation or data residency. It runs
+// first so its PostHook sees the rejections of every later plugin.
+type securityEventsPlugin struct {
+	exporter *lib.SecurityEventExporter
+	// governanceStore resolves virtual key values to their IDs (nil when governance is off)
+	governanceStore *governance.GovernanceStore
+}
+
+// GetName returns the name of the plugin
+func (p *securityEventsPlugin) GetName() string {
+	return securityEventsPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *securityEventsPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook remembers the provider and model of the attempt, as short-circuit errors do not carry them
+func (p *securityEventsPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	*ctx = context.WithValue(*ctx, securityEventsTargetContextKey, [2]string{string(req.Provider), req.Model})
+	return req, nil, nil
+}
+
+// PostHook emits a policy event for policy rejections
+func (p *securityEventsPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	errorType := policyErrorType(bifrostErr)
+	if errorType == "" {
+		return result, bifrostErr, nil
+	}
+	event := lib.SecurityEvent{
+		Category: lib.SecurityEventCategoryPolicy,
+		Type:     errorType,
+		Outcome:  "failure",
+		Severity: policyViolationSeverity(errorType),
+		Actor:    p.actor(*ctx),
+		Details:  map[string]string{},
+	}
+	if bifrostErr.Error != nil {
+		event.Message = bifrostErr.Error.Message
+	}
+	event.SourceIP, _ = (*ctx).Value(lib.ClientIPContextKey).(string)
+	if bifrostErr.StatusCode != nil {
+		event.Status = *bifrostErr.StatusCode
+	}
+	if target, ok := (*ctx).Value(securityEventsTargetContextKey).([2]string); ok {
+		event.Details["provider"], event.Details["model"] = target[0], target[1]
+	}
+	if requestID, ok := (*ctx).Value(schemas.BifrostContextKeyRequestID).(string); ok {
+		event.Details["request_id"] = requestID
+	}
+	if customer := authenticatedCustomer(*ctx, p.governanceStore); customer != "" {
+		event.Details["customer"] = customer
+	}
+	p.exporter.Emit(event)
+	return result, bifrostErr, nil
+}
+
+// policyErrorType returns the type of a policy rejection, empty for other errors. Governance sets the type of the
+// error, moderation and data residency that of its error field.
+func policyErrorType(bifrostErr *schemas.BifrostError) string {
+	if bifrostErr == nil {
+		return ""
+	}
+	if bifrostErr.Type != nil && lib.IsPolicyViolation(*bifrostErr.Type) {
+		return *bifrostErr.Type
+	}
+	if bifrostErr.Error != nil && bifrostErr.Error.Type != nil && lib.IsPolicyViolation(*bifrostErr.Error.Type) {
+		return *bifrostErr.Error.Type
+	}
+	return ""
+}
+
+// actor names the caller of an inference request by virtual key ID, never by its secret value
+func (p *securityEventsPlugin) actor(ctx context.Context) string {
+	vkValue, _ := ctx.Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	if vkValue == "" {
+		return "anonymous"
+	}
+	if p.governanceStore != nil {
+		if vk, ok := p.governanceStore.GetVirtualKey(vkValue); ok {
+			return "virtual_key:" + vk.ID
+		}
+	}
+	return "virtual_key:unknown"
+}
+
+// policyViolationSeverity rates policy rejections: blocked or unknown credentials rank above exhausted limits
+func policyViolationSeverity(errorType string) int {
+	switch errorType {
+	case "virtual_key_not_found", "virtual_key_blocked", "content_policy_violation", "data_residency_violation":
+		return 7
+	case "model_blocked", "provider_blocked", "virtual_key_required", "pending_review":
+		return 5
+	}
+	return 4 // Rate, token, request and budget limits
+}
+
+// Cleanup is not used for this plugin
+func (p *securityEventsPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// securityEventCollector is an HTTP collector recording the events it receives
+type securityEventCollector struct {
+	mu      sync.Mutex
+	events  []lib.SecurityEvent
+	headers http.Header
+}
+
+func (c *securityEventCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var batch []lib.SecurityEvent
+	json.Unmarshal(body, &batch)
+	c.mu.Lock()
+	c.events = append(c.events, batch...)
+	c.headers = r.Header.Clone()
+	c.mu.Unlock()
+}
+
+// newSecurityEventsTestExporter starts an exporter posting to a test collector; stop flushes it
+func newSecurityEventsTestExporter(t *testing.T, categories ...string) (*lib.SecurityEventExporter, *securityEventCollector, func()) {
+	collector := &securityEventCollector{}
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+	exporter := lib.NewSecurityEventExporter(lib.SecurityEventsConfig{
+		Enabled:    true,
+		Categories: categories,
+		HTTP:       &lib.SecurityHTTPSinkConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Splunk token"}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	exporter.Start(ctx, "1.2.3")
+	return exporter, collector, func() {
+		cancel()
+		exporter.Wait()
+	}
+}
+
+// TestFormatCEF tests the CEF header and extension escaping
+func TestFormatCEF(t *testing.T) {
+	event := lib.SecurityEvent{
+		Time:     time.UnixMilli(1700000000000),
+		Category: lib.SecurityEventCategoryPolicy,
+		Type:     "budget_exceeded",
+		Outcome:  "failure",
+		Severity: 4,
+		Actor:    "virtual_key:vk|1",
+		Message:  "budget a=b\nexceeded",
+		Details:  map[string]string{"virtual_key": `c:\keys`, "model": "gpt-4o"},
+	}
+	expected := `CEF:0|Maxim|Bifrost|1.2.3|policy:budget_exceeded|budget exceeded (failure)|4|` +
+		`rt=1700000000000 cat=policy outcome=failure suser=virtual_key:vk|1 msg=budget a\=b\nexceeded ` +
+		`bifrostModel=gpt-4o bifrostVirtualKey=c:\\keys`
+	if got := lib.FormatCEF(event, "1.2.3"); got != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, got)
+	}
+	if got := lib.FormatCEF(lib.SecurityEvent{Type: "x"}, "1|2"); !strings.HasPrefix(got, `CEF:0|Maxim|Bifrost|1\|2|`) {
+		t.Errorf("expected pipes to be escaped in the header, got %s", got)
+	}
+}
+
+// TestClassifySecurityEvent tests which HTTP requests are auth events and admin actions
+func TestClassifySecurityEvent(t *testing.T) {
+	admin := AuthSession{AuthEnabled: true, Authenticated: true, Method: AuthMethodCookie}
+	anonymous := AuthSession{AuthEnabled: true}
+	serviceAccount := AuthSession{AuthEnabled: true, Authenticated: true, Method: AuthMethodServiceAccount, ServiceAccount: "ci"}
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		session      AuthSession
+		eventType    string
+		actor        string
+	}{
+		{"POST", "/api/auth/login", 200, anonymous, "login", "admin"},
+		{"POST", "/api/auth/login", 401, anonymous, "login_failed", "anonymous"},
+		{"POST", "/api/auth/logout", 200, admin, "logout", "admin"},
+		{"GET", "/api/auth/me", 200, anonymous, "", ""},
+		{"GET", "/api/providers", 401, anonymous, "authentication_failed", "anonymous"},
+		{"POST", "/v1/chat/completions", 401, anonymous, "authentication_failed", "anonymous"},
+		{"PUT", "/api/config", 403, serviceAccount, "authorization_denied", "service_account:ci"},
+		{"POST", "/v1/chat/completions", 403, anonymous, "", ""},
+		{"DELETE", "/api/governance/virtual-keys/vk1", 200, admin, "admin_action", "admin"},
+		{"POST", "/api/providers", 200, AuthSession{Authenticated: true}, "admin_action", "unauthenticated"},
+		{"GET", "/api/providers", 200, admin, "", ""},
+		{"POST", "/v1/chat/completions", 200, anonymous, "", ""},
+	} {
+		event, ok := classifySecurityEvent(tc.method, tc.path, tc.status, tc.session)
+		if tc.eventType == "" {
+			if ok {
+				t.Errorf("%s %s %d: expected no event, got %s", tc.method, tc.path, tc.status, event.Type)
+			}
+			continue
+		}
+		if !ok || event.Type != tc.eventType || event.Actor != tc.actor {
+			t.Errorf("%s %s %d: expected %s by %s, got %s by %s", tc.method, tc.path, tc.status, tc.eventType, tc.actor, event.Type, event.Actor)
+		}
+	}
+}
+
+// TestSecurityEventsMiddleware tests that admin actions reach the collector with the request details, once for
+// requests sent through the UI proxy
+func TestSecurityEventsMiddleware(t *testing.T) {
+	exporter, collector, stop := newSecurityEventsTestExporter(t)
+	config := &lib.Config{SecurityEvents: exporter}
+	var handler fasthttp.RequestHandler
+	proxy := NewUIProxyHandler(config, func(ctx *fasthttp.RequestCtx) { handler(ctx) }, nil)
+	handler = SecurityEventsMiddleware(config)(func(ctx *fasthttp.RequestCtx) {
+		if strings.HasPrefix(string(ctx.Path()), uiProxyPrefix) {
+			proxy.proxy(ctx)
+			return
+		}
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	})
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodDelete)
+	ctx.Request.SetRequestURI("/api/proxy/governance/virtual-keys/vk1")
+	handler(ctx)
+	stop()
+
+	if len(collector.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(collector.events))
+	}
+	event := collector.events[0]
+	if event.Category != lib.SecurityEventCategoryAdmin || event.Method != "DELETE" || event.Path != "/api/governance/virtual-keys/vk1" || event.Status != 200 || event.Severity != 5 {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if collector.headers.Get("Authorization") != "Splunk token" {
+		t.Errorf("expected the configured headers, got %v", collector.headers)
+	}
+}
+
+// TestSecurityEventsPlugin_PolicyViolations tests that governance, moderation and residency rejections are
+// reported, and other errors and unexported categories are not
+func TestSecurityEventsPlugin_PolicyViolations(t *testing.T) {
+	exporter, collector, stop := newSecurityEventsTestExporter(t)
+	plugin := &securityEventsPlugin{exporter: exporter}
+
+	ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "sk-bf-secret")
+	ctx = context.WithValue(ctx, lib.ClientIPContextKey, "10.0.0.1")
+	plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-4o"})
+	// Governance sets the type of the error
+	plugin.PostHook(&ctx, nil, &schemas.BifrostError{Type: bifrost.Ptr("budget_exceeded"), StatusCode: bifrost.Ptr(402), Error: &schemas.ErrorField{Message: "budget exceeded"}})
+	// Moderation sets the type of the error field
+	plugin.PostHook(&ctx, nil, moderationError(fasthttp.StatusBadRequest, "content_policy_violation", "input blocked"))
+	// Provider errors are not policy violations
+	plugin.PostHook(&ctx, nil, &schemas.BifrostError{StatusCode: bifrost.Ptr(500), Error: &schemas.ErrorField{Message: "upstream error"}})
+	stop()
+
+	if len(collector.events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(collector.events), collector.events)
+	}
+	event := collector.events[0]
+	if event.Type != "budget_exceeded" || event.Status != 402 || event.SourceIP != "10.0.0.1" || event.Details["provider"] != "openai" || event.Details["model"] != "gpt-4o" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.Actor != "virtual_key:unknown" {
+		t.Errorf("expected the virtual key value not to be exported, got %q", event.Actor)
+	}
+	if collector.events[1].Type != "content_policy_violation" || collector.events[1].Severity != 7 {
+		t.Errorf("unexpected event: %+v", collector.events[1])
+	}
+
+	exporter, collector, stop = newSecurityEventsTestExporter(t, lib.SecurityEventCategoryAuth)
+	plugin = &securityEventsPlugin{exporter: exporter}
+	plugin.PostHook(&ctx, nil, &schemas.BifrostError{Type: bifrost.Ptr("rate_limited")})
+	stop()
+	if len(collector.events) != 0 {
+		t.Errorf("expected policy events to be filtered out, got %+v", collector.events)
+	}
+}
+
+// TestSecurityEventsConfig_Validate tests the validation of sinks and categories
+func TestSecurityEventsConfig_Validate(t *testing.T) {
+	for _, config := range []lib.SecurityEventsConfig{
+		{},
+		{Categories: []string{"billing"}, HTTP: &lib.SecurityHTTPSinkConfig{URL: "https://siem.example.com"}},
+		{Syslog: &lib.SecuritySyslogConfig{Address: "siem.example.com:514"}},
+		{Syslog: &lib.SecuritySyslogConfig{Address: "udp://siem.example.com:514", Format: "leef"}},
+		{HTTP: &lib.SecurityHTTPSinkConfig{URL: "https://siem.example.com", Format: "xml"}},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", config)
+		}
+	}
+	valid := lib.SecurityEventsConfig{
+		Syslog: &lib.SecuritySyslogConfig{Address: "tcp://siem.example.com:514", Format: "json"},
+		HTTP:   &lib.SecurityHTTPSinkConfig{URL: "https://siem.example.com/services/collector", Format: "splunk_hec"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestSecurityEventExporter_Syslog tests that events are sent as RFC 5424 messages with a CEF body
+func TestSecurityEventExporter_Syslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+	exporter := lib.NewSecurityEventExporter(lib.SecurityEventsConfig{
+		Enabled: true,
+		Syslog:  &lib.SecuritySyslogConfig{Address: "udp://" + conn.LocalAddr().String()},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	exporter.Start(ctx, "1.2.3")
+	exporter.Emit(lib.SecurityEvent{Category: lib.SecurityEventCategoryAuth, Type: "login_failed", Outcome: "failure", Severity: 6})
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	cancel()
+	exporter.Wait()
+	if err != nil {
+		t.Fatalf("expected a syslog message: %v", err)
+	}
+	message := string(buf[:n])
+	// local0 (16) * 8 + warning (4)
+	if !strings.HasPrefix(message, "<132>1 ") || !strings.Contains(message, " bifrost - auth - CEF:0|Maxim|Bifrost|1.2.3|auth:login_failed|") {
+		t.Errorf("unexpected syslog message: %q", message)
+	}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"cmp"
 	"context"
+	"crypto/tls"
 	"embed"
 	"fmt"
 	"net"
@@ -9,6 +11,8 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,6 +21,9 @@ import (
 	bifrost "github.com/maximhq/bifrost/core"
 	"github.com/maximhq/bifrost/core/schemas"
 	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/evaluation"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/plugins/eventstream"
 	"github.com/maximhq/bifrost/plugins/governance"
 	"github.com/maximhq/bifrost/plugins/logging"
 	"github.com/maximhq/bifrost/plugins/maxim"
@@ -62,6 +69,8 @@ type BifrostHTTPServer struct {
 	Server           *fasthttp.Server
 	Router           *router.Router
 	WebSocketHandler *WebSocketHandler
+
+	listeners []listenerServer // Servers of the configured listeners, bound instead of Server
 }
 
 // NewBifrostHTTPServer creates a new instance of BifrostHTTPServer.
@@ -185,6 +194,10 @@ func LoadPlugin[T schemas.Plugin](ctx context.Context, name string, pluginConfig
 		if err != nil {
 			return zero, err
 		}
+		if bifrostConfig.LeaderElector != nil {
+			plugin.SetLeaderChecker(bifrostConfig.LeaderElector)
+		}
+		plugin.SetRedactor(bifrostConfig.Redactor)
 		if p, ok := any(plugin).(T); ok {
 			return p, nil
 		}
@@ -241,10 +254,25 @@ func LoadPlugin[T schemas.Plugin](ctx context.Context, name string, pluginConfig
 		if err != nil {
 			return zero, err
 		}
+		plugin.SetRedactor(bifrostConfig.Redactor)
 		if p, ok := any(plugin).(T); ok {
 			return p, nil
 		}
 		return zero, fmt.Errorf("otel plugin type mismatch")
+	case eventstream.PluginName:
+		eventstreamConfig, err := MarshalPluginConfig[eventstream.Config](pluginConfig)
+		if err != nil {
+			return zero, fmt.Errorf("failed to marshal eventstream plugin config: %v", err)
+		}
+		plugin, err := eventstream.Init(ctx, eventstreamConfig, logger, bifrostConfig.PricingManager)
+		if err != nil {
+			return zero, err
+		}
+		plugin.SetRedactor(bifrostConfig.Redactor)
+		if p, ok := any(plugin).(T); ok {
+			return p, nil
+		}
+		return zero, fmt.Errorf("eventstream plugin type mismatch")
 	}
 	return zero, fmt.Errorf("plugin %s not found", name)
 }
@@ -261,9 +289,66 @@ func LoadPlugins(ctx context.Context, config *lib.Config) ([]schemas.Plugin, err
 	} else {
 		plugins = append(plugins, promPlugin)
 	}
+	// Reporting policy violations to the SIEM, first so that its PostHook sees the rejections of every later plugin
+	var securityEvents *securityEventsPlugin
+	if config.SecurityEvents != nil {
+		securityEvents = &securityEventsPlugin{exporter: config.SecurityEvents}
+		plugins = append(plugins, securityEvents)
+	}
+	// Measuring every upstream attempt against the SLOs, ahead of the plugins that may still fail or replace it
+	if config.SLOs != nil {
+		plugins = append(plugins, &sloPlugin{tracker: config.SLOs})
+	}
+	// Reporting provider, model and key to the access log, ahead of governance so rejected requests are covered
+	if config.AccessLog != nil && config.AccessLog.Enabled {
+		plugins = append(plugins, &accessLogPlugin{})
+	}
+	// Reporting usage and stream outcomes of A/B experiment requests
+	plugins = append(plugins, &experimentsPlugin{config: config})
+	// Submitting responses for evaluation, ahead of the plugins that may still replace the response
+	if config.Evaluations != nil {
+		plugins = append(plugins, &evaluationPlugin{config: config})
+	}
+	// Skipping providers that fail health probes, ahead of the plugins that record or meter the request
+	if config.ProviderHealth != nil && config.ProviderHealth.AvoidUnhealthy() {
+		plugins = append(plugins, &providerHealthPlugin{tracker: config.ProviderHealth})
+	}
+	// Skipping providers cooling down after a 429, and starting cooldowns from the Retry-After of provider errors
+	if config.ProviderCooldowns != nil {
+		plugins = append(plugins, &providerCooldownsPlugin{cooldowns: config.ProviderCooldowns})
+	}
+	// Keeping session histories ahead of the system prompt policies, so injected prompts are not stored
+	if config.Sessions != nil {
+		plugins = append(plugins, &sessionPlugin{config: config, logger: logger})
+	}
+	// Injecting mandatory system prompts ahead of logging, so request logs show the effective prompt
+	systemPrompts := &systemPromptPlugin{config: config}
+	plugins = append(plugins, systemPrompts)
+	// Injecting retrieved context after sessions, so it is not stored, and ahead of the context window budget
+	if config.Retrieval != nil {
+		plugins = append(plugins, &retrievalPlugin{config: config})
+	}
+	// Fitting chat requests into the context window once sessions and system prompts have been added
+	if config.ContextWindow != nil {
+		plugins = append(plugins, &contextWindowPlugin{config: config, logger: logger})
+	}
+	// Enforcing cost ceilings on the final prompts, before logging so request logs show the clamped max tokens
+	if config.PricingManager != nil {
+		plugins = append(plugins, &costCeilingPlugin{config: config, pricing: config.PricingManager, logger: logger})
+	}
+	// Checking, inlining and downscaling images ahead of logging, so request logs show the images providers received
+	if config.Vision != nil {
+		plugins = append(plugins, &visionPlugin{processor: config.Vision})
+	}
+	// Marking zero data retention requests ahead of logging, recording and caching, which keep their content out
+	zeroDataRetention := &zeroDataRetentionPlugin{config: config}
+	plugins = append(plugins, zeroDataRetention)
 	// Initializing logger plugin
 	var loggingPlugin *logging.LoggerPlugin
+	billing := &billingPlugin{}
 	if config.ClientConfig.EnableLogging && config.LogsStore != nil {
+		// Attributing requests to their billing customer ahead of logging, which stores the customer
+		plugins = append(plugins, billing)
 		// Use dedicated logs database with high-scale optimizations
 		loggingPlugin, err = LoadPlugin[*logging.LoggerPlugin](ctx, logging.PluginName, nil, config)
 		if err != nil {
@@ -272,6 +357,18 @@ func LoadPlugins(ctx context.Context, config *lib.Config) ([]schemas.Plugin, err
 			plugins = append(plugins, loggingPlugin)
 		}
 	}
+	// Initializing eventstream plugin ahead of governance so that its PostHook also sees governance rejections
+	for _, plugin := range config.PluginConfigs {
+		if plugin.Name != eventstream.PluginName || !plugin.Enabled {
+			continue
+		}
+		eventstreamPlugin, err := LoadPlugin[*eventstream.EventStreamPlugin](ctx, eventstream.PluginName, plugin.Config, config)
+		if err != nil {
+			logger.Error("failed to initialize eventstream plugin: %v", err)
+		} else {
+			plugins = append(plugins, eventstreamPlugin)
+		}
+	}
 	// Initializing governance plugin
 	var governancePlugin *governance.GovernancePlugin
 	if config.ClientConfig.EnableGovernance {
@@ -282,13 +379,46 @@ func LoadPlugins(ctx context.Context, config *lib.Config) ([]schemas.Plugin, err
 		if err != nil {
 			logger.Error("failed to initialize governance plugin: %s", err.Error())
 		} else {
-			plugins = append(plugins, governancePlugin)
+			// Reporting the quota left in response headers ahead of governance, so rejected requests report it too
+			plugins = append(plugins, &rateLimitHeadersPlugin{governanceStore: governancePlugin.GetGovernanceStore()}, governancePlugin)
+			systemPrompts.governanceStore = governancePlugin.GetGovernanceStore()
+			billing.governanceStore = governancePlugin.GetGovernanceStore()
+			zeroDataRetention.governanceStore = governancePlugin.GetGovernanceStore()
+			if securityEvents != nil {
+				securityEvents.governanceStore = governancePlugin.GetGovernanceStore()
+			}
+		}
+	}
+	// Enforcing data residency once governance has resolved the virtual key and customer of the request
+	if config.DataResidency != nil {
+		dataResidency := &dataResidencyPlugin{config: config}
+		if governancePlugin != nil {
+			dataResidency.governanceStore = governancePlugin.GetGovernanceStore()
+		}
+		plugins = append(plugins, dataResidency)
+	}
+	// Moderating content once governance has accepted the request, so rejected requests are not classified
+	if config.Moderator != nil {
+		moderationPlugin := &moderationPlugin{config: config, logger: logger}
+		if governancePlugin != nil {
+			moderationPlugin.governanceStore = governancePlugin.GetGovernanceStore()
 		}
+		plugins = append(plugins, moderationPlugin)
+	}
+	// Transforming response text ahead of stream usage, so usage counts what the provider generated, and after
+	// moderation, so moderation, logging and sessions see what the client receives
+	plugins = append(plugins, &responseTransformsPlugin{config: config})
+	// Completing the usage of streams after governance, logging and telemetry, so their PostHooks see it, and ahead
+	// of recording, which keeps what the provider sent
+	plugins = append(plugins, &streamUsagePlugin{})
+	// Attaching upstream recorders last, so requests short-circuited by earlier plugins are not prepared for recording
+	if config.Recordings != nil {
+		plugins = append(plugins, &recordingPlugin{config: config})
 	}
 	// Currently we support first party plugins only
 	// Eventually same flow will be used for third party plugins
 	for _, plugin := range config.PluginConfigs {
-		if !plugin.Enabled {
+		if !plugin.Enabled || plugin.Name == eventstream.PluginName {
 			continue
 		}
 		pluginInstance, err := LoadPlugin[schemas.Plugin](ctx, plugin.Name, plugin.Config, config)
@@ -298,6 +428,14 @@ func LoadPlugins(ctx context.Context, config *lib.Config) ([]schemas.Plugin, err
 			plugins = append(plugins, pluginInstance)
 		}
 	}
+	// Registering the evaluators provided by plugins
+	if config.Evaluations != nil {
+		for _, plugin := range plugins {
+			if evaluator, ok := plugin.(evaluation.Evaluator); ok {
+				config.Evaluations.Register(evaluator)
+			}
+		}
+	}
 
 	// Atomically publish the plugin state
 	config.Plugins.Store(&plugins)
@@ -440,6 +578,10 @@ func (s *BifrostHTTPServer) RegisterRoutes(ctx context.Context, middlewares ...l
 		if err != nil {
 			return fmt.Errorf("failed to initialize governance handler: %v", err)
 		}
+		// Suspended leaked keys are rejected as soon as they are found
+		if s.Config.KeyLeaks != nil {
+			s.Config.KeyLeaks.OnUpdate(governancePlugin.GetGovernanceStore().UpdateVirtualKeyInMemory)
+		}
 	}
 	var cacheHandler *CacheHandler
 	semanticCachePlugin, _ := FindPluginByName[*semanticcache.Plugin](s.Plugins, semanticcache.PluginName)
@@ -448,15 +590,23 @@ func (s *BifrostHTTPServer) RegisterRoutes(ctx context.Context, middlewares ...l
 	}
 	// Websocket handler needs to go below UI handler
 	logger.Debug("initializing websocket server")
+	var streamHandler *StreamHandler
 	if loggerPlugin != nil {
 		s.WebSocketHandler = NewWebSocketHandler(ctx, loggerPlugin.GetPluginLogManager(), logger, s.Config.ClientConfig.AllowedOrigins)
-		loggerPlugin.SetLogCallback(s.WebSocketHandler.BroadcastLogUpdate)
+		streamHandler = NewStreamHandler(ctx, logger, s.Config.ClientConfig.AllowedOrigins)
+		loggerPlugin.SetLogCallback(func(logEntry *logstore.Log) {
+			s.WebSocketHandler.BroadcastLogUpdate(logEntry)
+			streamHandler.PublishLog(logEntry)
+		})
 	} else {
 		s.WebSocketHandler = NewWebSocketHandler(ctx, nil, logger, s.Config.ClientConfig.AllowedOrigins)
 	}
 	// Start WebSocket heartbeat
 	s.WebSocketHandler.StartHeartbeat()
-	middlewaresWithTelemetry := append(middlewares, telemetry.PrometheusMiddleware)
+	middlewaresWithTelemetry := append(middlewares,
+		traced(s.Config, "prometheus", telemetry.PrometheusMiddleware),
+		traced(s.Config, "response_headers", ResponseHeadersMiddleware),
+		traced(s.Config, "maintenance", MaintenanceMiddleware(s.Config)))
 	// Chaining all middlewares
 	// lib.ChainMiddlewares chains multiple middlewares together
 	// Initialize handlers
@@ -466,13 +616,76 @@ func (s *BifrostHTTPServer) RegisterRoutes(ctx context.Context, middlewares ...l
 	integrationHandler := NewIntegrationHandler(s.Client, s.Config)
 	configHandler := NewConfigHandler(s.Client, logger, s.Config, s)
 	pluginsHandler := NewPluginsHandler(s, s.Config.ConfigStore, logger)
+	clusterHandler := NewClusterHandler(s.Config, logger)
+	healthHandler := NewHealthHandler(s.Config, logger)
+	logLevelHandler := NewLogLevelHandler(logger, schemas.LogLevel(s.LogLevel))
+	openAPIHandler := NewOpenAPIHandler(s.Router, logger)
+	var governanceStore *governance.GovernanceStore
+	if governancePlugin != nil {
+		governanceStore = governancePlugin.GetGovernanceStore()
+	}
+	applyHandler := NewApplyHandler(s.Config, s.Client, governanceStore, logger)
+	configVersionsHandler := NewConfigVersionsHandler(s.Config, s.Client, applyHandler, s, s, logger)
+	// Changes made through these routes are recorded as configuration versions
+	versionedMiddlewares := append(slices.Clone(middlewares), configVersionsHandler.RecordVersion)
+	adminHandler := NewAdminHandler(s.Config, logger)
+	providerHealthHandler := NewProviderHealthHandler(s.Config.ProviderHealth, s.Config.ProviderCooldowns, logger)
+	transformationsHandler := NewTransformationsHandler(s.Config, logger)
+	experimentsHandler := NewExperimentsHandler(s.Config, logger)
+	systemPromptPoliciesHandler := NewSystemPromptPoliciesHandler(s.Config, logger)
 	// Register all handler routes
-	providerHandler.RegisterRoutes(s.Router, middlewares...)
+	providerHandler.RegisterRoutes(s.Router, versionedMiddlewares...)
 	inferenceHandler.RegisterRoutes(s.Router, middlewaresWithTelemetry...)
 	mcpHandler.RegisterRoutes(s.Router, middlewares...)
 	integrationHandler.RegisterRoutes(s.Router, middlewaresWithTelemetry...)
-	configHandler.RegisterRoutes(s.Router, middlewares...)
-	pluginsHandler.RegisterRoutes(s.Router, middlewares...)
+	configHandler.RegisterRoutes(s.Router, versionedMiddlewares...)
+	pluginsHandler.RegisterRoutes(s.Router, versionedMiddlewares...)
+	clusterHandler.RegisterRoutes(s.Router, middlewares...)
+	healthHandler.RegisterRoutes(s.Router, middlewares...)
+	logLevelHandler.RegisterRoutes(s.Router, middlewares...)
+	openAPIHandler.RegisterRoutes(s.Router, middlewares...)
+	applyHandler.RegisterRoutes(s.Router, versionedMiddlewares...)
+	NewImportHandler(applyHandler, s.Config.ConfigStore, governanceStore, logger).RegisterRoutes(s.Router, versionedMiddlewares...)
+	configVersionsHandler.RegisterRoutes(s.Router, middlewares...)
+	// The configuration loaded at startup is recorded when it differs from the latest version
+	configVersionsHandler.record(ctx, "startup")
+	// In controller mode, the configuration is reconciled to the ConfigMaps and Secrets of the cluster
+	kubernetesSync := NewKubernetesSyncController(s.Config.KubernetesSync, s.Config.Kubernetes, s.Config, applyHandler, configVersionsHandler, logger)
+	kubernetesSync.RegisterRoutes(s.Router, middlewares...)
+	kubernetesSync.Start(ctx)
+	adminHandler.RegisterRoutes(s.Router, middlewares...)
+	NewAuthHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewDeviceLoginHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewUIProxyHandler(s.Config, func(ctx *fasthttp.RequestCtx) { s.Server.Handler(ctx) }, logger).RegisterRoutes(s.Router, middlewares...)
+	NewPublicRoutesHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewSystemModeHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	providerHealthHandler.RegisterRoutes(s.Router, middlewares...)
+	NewScalingHandler(s.Config.Scaler, s.Client.GetQueueStats, logger).RegisterRoutes(s.Router, middlewares...)
+	transformationsHandler.RegisterRoutes(s.Router, middlewares...)
+	NewResponseTransformsHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	experimentsHandler.RegisterRoutes(s.Router, middlewares...)
+	NewLanguageRoutingHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewRoutingOverridesHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewPoliciesHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewTenantDomainsHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	systemPromptPoliciesHandler.RegisterRoutes(s.Router, middlewares...)
+	if s.Config.Sessions != nil {
+		NewSessionsHandler(s.Config.Sessions, logger).RegisterRoutes(s.Router, middlewares...)
+	}
+	if s.Config.ModerationEvents != nil {
+		NewModerationHandler(s.Config.ModerationEvents, s.Config.ModerationReviews, logger).RegisterRoutes(s.Router, middlewares...)
+	}
+	if s.Config.ServiceAccounts != nil {
+		NewServiceAccountsHandler(s.Config.ServiceAccounts, logger).RegisterRoutes(s.Router, middlewares...)
+	}
+	if s.Config.Evaluations != nil {
+		NewEvaluationHandler(s.Config.Evaluations, s.Config.EvaluationScores, logger).RegisterRoutes(s.Router, middlewares...)
+	}
+	if s.Config.Recordings != nil {
+		NewRecordingHandler(s.Client, s.Config.Recordings, logger).RegisterRoutes(s.Router, middlewares...)
+	}
+	// The complete server handler is built after the routes, so benchmarks resolve it when they run
+	NewBenchmarkHandler(s.Config, func(ctx *fasthttp.RequestCtx) { s.Server.Handler(ctx) }, logger).RegisterRoutes(s.Router, middlewares...)
 	if cacheHandler != nil {
 		cacheHandler.RegisterRoutes(s.Router, middlewares...)
 	}
@@ -482,12 +695,33 @@ func (s *BifrostHTTPServer) RegisterRoutes(ctx context.Context, middlewares ...l
 	if loggingHandler != nil {
 		loggingHandler.RegisterRoutes(s.Router, middlewares...)
 	}
+	if s.Config.LogsStore != nil {
+		NewBillingHandler(s.Config.LogsStore, s.Config.StripeMetering, logger).RegisterRoutes(s.Router, middlewares...)
+		NewAnalyticsHandler(s.Config.LogsStore, logger).RegisterRoutes(s.Router, middlewares...)
+	}
 	if s.WebSocketHandler != nil {
 		s.WebSocketHandler.RegisterRoutes(s.Router, middlewares...)
 	}
+	if streamHandler != nil {
+		streamHandler.RegisterRoutes(s.Router, middlewares...)
+	}
 	//
-	// Add Prometheus /metrics endpoint
-	s.Router.GET("/metrics", fasthttpadaptor.NewFastHTTPHandler(promhttp.Handler()))
+	NewMetricsHandler(s.Config, prometheus.DefaultGatherer, logger).RegisterRoutes(s.Router, middlewares...)
+	NewSLOHandler(s.Config.SLOs, logger).RegisterRoutes(s.Router, middlewares...)
+	NewRaceHandler(s.Config.Races, logger).RegisterRoutes(s.Router, middlewares...)
+	NewAsyncHandler(s.Config.AsyncQueue, logger).RegisterRoutes(s.Router, middlewares...)
+	NewFineTuningHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewAssistantsHandler(s.Config.Assistants, logger).RegisterRoutes(s.Router, middlewares...)
+	NewRetrievalHandler(s.Config.Retrieval, s.Config.Ingestion, logger).RegisterRoutes(s.Router, middlewares...)
+	NewPlaygroundHandler(s.Client, s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	NewTracesHandler(s.Config, logger).RegisterRoutes(s.Router, middlewares...)
+	if s.Config.KeyLeaks != nil {
+		NewKeyLeaksHandler(s.Config.KeyLeaks, logger).RegisterRoutes(s.Router, middlewares...)
+	}
+	// Add Prometheus /metrics endpoint, in the OpenMetrics format when scrapers ask for it so exemplars are exported
+	s.Router.GET("/metrics", fasthttpadaptor.NewFastHTTPHandler(promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer, promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)))
 	// 404 handler
 	s.Router.NotFound = func(ctx *fasthttp.RequestCtx) {
 		SendError(ctx, fasthttp.StatusNotFound, "Route not found: "+string(ctx.Path()), logger)
@@ -499,8 +733,10 @@ func (s *BifrostHTTPServer) RegisterRoutes(ctx context.Context, middlewares ...l
 func (s *BifrostHTTPServer) InitializeTelemetry() {
 	RegisterCollectorSafely(collectors.NewGoCollector())
 	RegisterCollectorSafely(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	RegisterCollectorSafely(lib.AbandonedStreams)
+	RegisterCollectorSafely(lib.InFlightStreamsGauge)
 	// Initialize prometheus telemetry
-	telemetry.InitPrometheusMetrics(s.Config.ClientConfig.PrometheusLabels)
+	telemetry.InitPrometheusMetrics(s.Config.ClientConfig.PrometheusLabels, s.Config.Metrics)
 }
 
 // RegisterUIHandler registers the UI handler with the specified router
@@ -551,6 +787,10 @@ func (s *BifrostHTTPServer) Bootstrap(ctx context.Context) error {
 	// Create account backed by the high-performance store (all processing is done in LoadFromDatabase)
 	// The account interface now benefits from ultra-fast config access times via in-memory storage
 	account := lib.NewBaseAccount(s.Config)
+	var raceSelector schemas.RaceSelector
+	if s.Config.Races != nil {
+		raceSelector = s.Config.Races.Select
+	}
 	s.Client, err = bifrost.Init(ctx, schemas.BifrostConfig{
 		Account:            account,
 		InitialPoolSize:    s.Config.ClientConfig.InitialPoolSize,
@@ -558,11 +798,39 @@ func (s *BifrostHTTPServer) Bootstrap(ctx context.Context) error {
 		Plugins:            s.Plugins,
 		MCPConfig:          s.Config.MCPConfig,
 		Logger:             logger,
+		RaceSelector:       raceSelector,
+		PluginPolicies:     s.Config.PluginPolicies,
+		PluginState:        s.Config.PluginState,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize bifrost: %v", err)
 	}
 	s.Config.SetBifrostClient(s.Client)
+	RegisterCollectorSafely(newUpstreamConnectionCollector(s.Client.GetUpstreamConnectionStats))
+	if s.Config.ProviderHealth != nil {
+		s.Config.ProviderHealth.Start(s.ctx, s.Config, s.Client)
+	}
+	if s.Config.StripeMetering != nil {
+		s.Config.StripeMetering.Start(s.ctx)
+	}
+	if s.Config.KeyLeaks != nil {
+		s.Config.KeyLeaks.Start(s.ctx)
+	}
+	if s.Config.SecurityEvents != nil {
+		s.Config.SecurityEvents.Start(s.ctx, s.Version)
+	}
+	if s.Config.StatsD != nil {
+		s.Config.StatsD.Start(s.ctx)
+	}
+	if s.Config.SLOs != nil {
+		s.Config.SLOs.Start(s.ctx)
+	}
+	if s.Config.FineTuning != nil {
+		s.Config.FineTuning.Start(s.ctx)
+	}
+	if s.Config.Ingestion != nil {
+		s.Config.Ingestion.Start(s.ctx)
+	}
 	// Initialize routes
 	s.Router = router.New()
 	// Register routes
@@ -572,14 +840,117 @@ func (s *BifrostHTTPServer) Bootstrap(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to initialize routes: %v", err)
 	}
+	zeroDataRetention, _ := FindPluginByName[*zeroDataRetentionPlugin](s.Plugins, zeroDataRetentionPluginName)
+	// Async requests are queued once authenticated and transformed, and replayed through the rest of the pipeline
+	pipeline := traced(s.Config, "transport_interceptor", TransportInterceptorMiddleware(s.Config))(s.Router.Handler)
+	if s.Config.AsyncQueue != nil {
+		s.Config.AsyncQueue.Start(s.ctx, asyncExecutor(s.Config.AsyncQueue, pipeline))
+	}
+	// Traced requests record the time each middleware spends on them
+	middlewares := []namedMiddleware{
+		{"security_headers", traced(s.Config, "security_headers", SecurityHeadersMiddleware(s.Config))},
+		{"cors", traced(s.Config, "cors", CorsMiddleware(s.Config))},
+		{"client_certificate", traced(s.Config, "client_certificate", ClientCertificateMiddleware(s.Config, logger))},
+		{"request_signing", traced(s.Config, "request_signing", RequestSigningMiddleware(s.Config, logger))},
+		{"jwt_auth", traced(s.Config, "jwt_auth", JWTAuthMiddleware(s.Config, logger))},
+		{"tenant", traced(s.Config, "tenant", TenantMiddleware(s.Config))},
+		{"admin_auth", traced(s.Config, "admin_auth", AdminAuthMiddleware(s.Config, logger))},
+		{"read_only", traced(s.Config, "read_only", ReadOnlyMiddleware(s.Config))},
+		{"labels", traced(s.Config, "labels", LabelsMiddleware(s.Config))},
+		{"language_routing", traced(s.Config, "language_routing", LanguageRoutingMiddleware(s.Config))},
+		{"experiment", traced(s.Config, "experiment", ExperimentMiddleware(s.Config))},
+		{"routing_override", traced(s.Config, "routing_override", RoutingOverrideMiddleware(s.Config))},
+		{"policy", traced(s.Config, "policy", PolicyMiddleware(s.Config))},
+		{"transformation", traced(s.Config, "transformation", TransformationMiddleware(s.Config))},
+		{"async", traced(s.Config, "async", AsyncMiddleware(s.Config, zeroDataRetention, logger))},
+	}
+	// Outer middlewares are shared by every listener, so that e.g. access log lines are not interleaved
+	var outer []lib.BifrostHTTPMiddleware
+	if s.Config.AccessLog != nil && s.Config.AccessLog.Enabled {
+		accessLogWriter, err := lib.OpenAccessLogWriter(s.Config.AccessLog)
+		if err != nil {
+			return err
+		}
+		outer = append(outer, AccessLogMiddleware(s.Config.AccessLog, accessLogWriter))
+	}
+	if s.Config.SecurityEvents != nil {
+		outer = append(outer, SecurityEventsMiddleware(s.Config))
+	}
+	trace := TraceMiddleware(s.Config, zeroDataRetention)
 	// Create fasthttp server instance
 	s.Server = &fasthttp.Server{
-		Handler:            CorsMiddleware(s.Config)(AdminAuthMiddleware(s.Config, logger)(TransportInterceptorMiddleware(s.Config)(s.Router.Handler))),
+		Handler:            buildHandler(trace, pipeline, middlewares, outer, nil),
 		MaxRequestBodySize: s.Config.ClientConfig.MaxRequestBodySizeMB * 1024 * 1024,
 	}
+	s.listeners = nil
+	for _, listener := range s.Config.Listeners {
+		s.listeners = append(s.listeners, listenerServer{
+			config: listener,
+			server: &fasthttp.Server{
+				Handler:            buildHandler(trace, pipeline, middlewares, outer, &listener),
+				MaxRequestBodySize: s.Config.ClientConfig.MaxRequestBodySizeMB * 1024 * 1024,
+			},
+		})
+	}
 	return nil
 }
 
+// namedMiddleware is an HTTP middleware with the name listeners skip it by (see lib.ListenerMiddlewares).
+type namedMiddleware struct {
+	name       string
+	middleware lib.BifrostHTTPMiddleware
+}
+
+// listenerServer is the server of a configured listener.
+type listenerServer struct {
+	config lib.ListenerConfig
+	server *fasthttp.Server
+}
+
+// buildHandler chains trace and the middlewares around pipeline, leaving out the ones listener skips, and wraps the
+// chain in the outer middlewares. listener is nil for the handler serving every route group; the handler of a
+// listener answers 404 to the routes it does not expose.
+func buildHandler(trace lib.BifrostHTTPMiddleware, pipeline fasthttp.RequestHandler, middlewares []namedMiddleware, outer []lib.BifrostHTTPMiddleware, listener *lib.ListenerConfig) fasthttp.RequestHandler {
+	chain := []lib.BifrostHTTPMiddleware{trace}
+	for _, m := range middlewares {
+		if listener == nil || !slices.Contains(listener.SkipMiddlewares, m.name) {
+			chain = append(chain, m.middleware)
+		}
+	}
+	handler := lib.ChainMiddlewares(pipeline, chain...)
+	for _, middleware := range outer {
+		handler = middleware(handler)
+	}
+	if listener != nil {
+		handler = ExposureMiddleware(listener.Expose, logger)(handler)
+	}
+	return handler
+}
+
+// listenAndServe serves on addr, over TLS when the tls config section is set.
+func (s *BifrostHTTPServer) listenAndServe(addr string) error {
+	return s.serve(s.Server, addr, s.Config.TLS != nil, "UI")
+}
+
+// serve serves server on addr, over TLS with the tls config section when useTLS is set. exposes describes what the
+// listener serves, for the startup log.
+func (s *BifrostHTTPServer) serve(server *fasthttp.Server, addr string, useTLS bool, exposes string) error {
+	if !useTLS {
+		logger.Info("successfully started bifrost, serving %s on http://%s", exposes, addr)
+		return server.ListenAndServe(addr)
+	}
+	tlsConfig, err := s.Config.TLS.ServerTLSConfig()
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started bifrost, serving %s on https://%s (client certificates: %s)", exposes, addr, cmp.Or(s.Config.TLS.ClientAuth, lib.ClientAuthNone))
+	return server.Serve(tls.NewListener(listener, tlsConfig))
+}
+
 // Start starts the HTTP server at the specified host and port
 // Also watches signals and errors
 func (s *BifrostHTTPServer) Start() error {
@@ -590,12 +961,24 @@ func (s *BifrostHTTPServer) Start() error {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	// Start server in a goroutine
 	serverAddr := net.JoinHostPort(s.Host, s.Port)
-	go func() {
-		logger.Info("successfully started bifrost, serving UI on http://%s:%s", s.Host, s.Port)
-		if err := s.Server.ListenAndServe(serverAddr); err != nil {
-			errChan <- err
+	if len(s.listeners) == 0 {
+		go func() {
+			if err := s.listenAndServe(serverAddr); err != nil {
+				errChan <- err
+			}
+		}()
+	} else {
+		// Configured listeners replace the one of the host and port flags
+		errChan = make(chan error, len(s.listeners))
+		for _, listener := range s.listeners {
+			go func() {
+				exposes := strings.Join(listener.config.Expose, ", ") + " (listener " + listener.config.Name + ")"
+				if err := s.serve(listener.server, listener.config.Address, listener.config.TLS, exposes); err != nil {
+					errChan <- fmt.Errorf("listener %s: %w", listener.config.Name, err)
+				}
+			}()
 		}
-	}()
+	}
 	// Wait for either termination signal or server error
 	select {
 	case sig := <-sigChan:
@@ -609,6 +992,11 @@ func (s *BifrostHTTPServer) Start() error {
 		} else {
 			logger.Info("server gracefully shutdown")
 		}
+		for _, listener := range s.listeners {
+			if err := listener.server.Shutdown(); err != nil {
+				logger.Error("error during graceful shutdown of listener %s: %v", listener.config.Name, err)
+			}
+		}
 		// Cancelling main context
 		if s.cancel != nil {
 			s.cancel()
@@ -617,6 +1005,10 @@ func (s *BifrostHTTPServer) Start() error {
 		done := make(chan struct{})
 		go func() {
 			defer close(done)
+			if s.Config != nil && s.Config.Evaluations != nil {
+				logger.Info("finishing pending evaluations...")
+				s.Config.Evaluations.Stop(shutdownCtx)
+			}
 			logger.Info("shutting down bifrost client...")
 			s.Client.Shutdown()
 			logger.Info("bifrost client shutdown completed")
@@ -625,6 +1017,11 @@ func (s *BifrostHTTPServer) Start() error {
 			if s.Config != nil && s.Config.PricingManager != nil {
 				s.Config.PricingManager.Cleanup()
 			}
+			if s.Config != nil && s.Config.LeaderElector != nil {
+				if err := s.Config.LeaderElector.Close(shutdownCtx); err != nil {
+					logger.Warn("failed to release cluster leadership: %v", err)
+				}
+			}
 			if s.Config != nil && s.Config.ConfigStore != nil {
 				s.Config.ConfigStore.Close(shutdownCtx)
 			}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// ServiceAccountsHandler mints and manages service account tokens.
+type ServiceAccountsHandler struct {
+	accounts *serviceaccounts.Manager
+	logger   schemas.Logger
+}
+
+// CreateServiceAccountRequest is the body of POST /api/service-accounts.
+type CreateServiceAccountRequest struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Scopes      []serviceaccounts.Scope `json:"scopes"`
+	ExpiresAt   *time.Time              `json:"expires_at,omitempty"`
+}
+
+// ServiceAccountTokenResponse returns a service account with its token, which is only shown once.
+type ServiceAccountTokenResponse struct {
+	Account *serviceaccounts.Account `json:"account"`
+	Token   string                   `json:"token"`
+}
+
+// NewServiceAccountsHandler creates a new service accounts handler.
+func NewServiceAccountsHandler(accounts *serviceaccounts.Manager, logger schemas.Logger) *ServiceAccountsHandler {
+	return &ServiceAccountsHandler{
+		accounts: accounts,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the service account routes.
+func (h *ServiceAccountsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/service-accounts", lib.ChainMiddlewares(h.listAccounts, middlewares...))
+	r.POST("/api/service-accounts", lib.ChainMiddlewares(h.createAccount, middlewares...))
+	r.GET("/api/service-accounts/{id}", lib.ChainMiddlewares(h.getAccount, middlewares...))
+	r.PUT("/api/service-accounts/{id}", lib.ChainMiddlewares(h.updateAccount, middlewares...))
+	r.DELETE("/api/service-accounts/{id}", lib.ChainMiddlewares(h.deleteAccount, middlewares...))
+	r.POST("/api/service-accounts/{id}/rotate", lib.ChainMiddlewares(h.rotateToken, middlewares...))
+}
+
+// listAccounts handles GET /api/service-accounts - List service accounts
+func (h *ServiceAccountsHandler) listAccounts(ctx *fasthttp.RequestCtx) {
+	accounts, err := h.accounts.List(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to list service accounts: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"service_accounts": accounts,
+		"count":            len(accounts),
+		"scopes":           serviceaccounts.Scopes,
+	}, h.logger)
+}
+
+// createAccount handles POST /api/service-accounts - Create a service account and mint its token
+func (h *ServiceAccountsHandler) createAccount(ctx *fasthttp.RequestCtx) {
+	var req CreateServiceAccountRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	account, token, err := h.accounts.Create(ctx, req.Name, req.Description, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		h.sendAccountError(ctx, err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	SendJSON(ctx, ServiceAccountTokenResponse{Account: account, Token: token}, h.logger)
+}
+
+// getAccount handles GET /api/service-accounts/{id} - Get a service account
+func (h *ServiceAccountsHandler) getAccount(ctx *fasthttp.RequestCtx) {
+	account, err := h.accounts.Get(ctx, ctx.UserValue("id").(string))
+	if err != nil {
+		h.sendAccountError(ctx, err)
+		return
+	}
+	SendJSON(ctx, account, h.logger)
+}
+
+// updateAccount handles PUT /api/service-accounts/{id} - Change the description, scopes, expiry or disabled state of a service account
+func (h *ServiceAccountsHandler) updateAccount(ctx *fasthttp.RequestCtx) {
+	var changes serviceaccounts.Changes
+	if err := json.Unmarshal(ctx.PostBody(), &changes); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	account, err := h.accounts.Update(ctx, ctx.UserValue("id").(string), changes)
+	if err != nil {
+		h.sendAccountError(ctx, err)
+		return
+	}
+	SendJSON(ctx, account, h.logger)
+}
+
+// deleteAccount handles DELETE /api/service-accounts/{id} - Delete a service account, revoking its token
+func (h *ServiceAccountsHandler) deleteAccount(ctx *fasthttp.RequestCtx) {
+	if err := h.accounts.Delete(ctx, ctx.UserValue("id").(string)); err != nil {
+		h.sendAccountError(ctx, err)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"status":  "success",
+		"message": "Service account deleted",
+	}, h.logger)
+}
+
+// rotateToken handles POST /api/service-accounts/{id}/rotate - Replace the token of a service account
+func (h *ServiceAccountsHandler) rotateToken(ctx *fasthttp.RequestCtx) {
+	account, token, err := h.accounts.Rotate(ctx, ctx.UserValue("id").(string))
+	if err != nil {
+		h.sendAccountError(ctx, err)
+		return
+	}
+	SendJSON(ctx, ServiceAccountTokenResponse{Account: account, Token: token}, h.logger)
+}
+
+// sendAccountError maps service account errors to HTTP statuses.
+func (h *ServiceAccountsHandler) sendAccountError(ctx *fasthttp.RequestCtx, err error) {
+	switch {
+	case errors.Is(err, serviceaccounts.ErrNotFound):
+		SendError(ctx, fasthttp.StatusNotFound, err.Error(), h.logger)
+	case errors.Is(err, serviceaccounts.ErrDuplicateName):
+		SendError(ctx, fasthttp.StatusConflict, err.Error(), h.logger)
+	default:
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+	}
+}
+
+// serviceAccountScopes returns the scopes a service account needs for a request, or nil for routes outside the
+// management API, which service accounts cannot call.
+func serviceAccountScopes(method, path string) []serviceaccounts.Scope {
+	read := method == fasthttp.MethodGet || method == fasthttp.MethodHead
+	switch {
+	case strings.HasPrefix(path, "/api/service-accounts") || path == "/api/admin/password" || path == "/api/public-routes" || path == "/api/system/mode" || strings.HasPrefix(path, "/api/playground/") || strings.HasPrefix(path, "/api/traces/") || strings.HasPrefix(path, "/api/auth/device"):
+		return []serviceaccounts.Scope{serviceaccounts.ScopeAdmin}
+	case path == "/ws" || strings.HasPrefix(path, "/api/logs") || strings.HasPrefix(path, "/api/stream/"):
+		if read {
+			return []serviceaccounts.Scope{serviceaccounts.ScopeLogsRead}
+		}
+		return []serviceaccounts.Scope{serviceaccounts.ScopeConfigWrite}
+	case path == "/api/config/validate":
+		// Validating a candidate config changes nothing
+		return []serviceaccounts.Scope{serviceaccounts.ScopeConfigRead}
+	case path == "/api/apply" || strings.HasPrefix(path, "/api/import/") || (strings.HasPrefix(path, "/api/config/versions/") && strings.HasSuffix(path, "/rollback")):
+		// Provisioning plans and rollbacks cover providers and virtual keys along with the rest of the configuration
+		return []serviceaccounts.Scope{serviceaccounts.ScopeConfigWrite, serviceaccounts.ScopeKeysWrite}
+	case path == "/api/keys" || strings.HasPrefix(path, "/api/keys:") || strings.HasPrefix(path, "/api/providers") || strings.HasPrefix(path, "/api/governance/virtual-keys") || strings.HasPrefix(path, "/api/governance/key-leaks"):
+		if read {
+			return []serviceaccounts.Scope{serviceaccounts.ScopeConfigRead}
+		}
+		return []serviceaccounts.Scope{serviceaccounts.ScopeKeysWrite}
+	case strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/v1/"):
+		if read {
+			return []serviceaccounts.Scope{serviceaccounts.ScopeConfigRead}
+		}
+		return []serviceaccounts.Scope{serviceaccounts.ScopeConfigWrite}
+	}
+	return nil
+}
+
+// authorizeServiceAccount authenticates a service account token and checks that it holds the scopes of the request.
+// It returns false after sending the error response.
+func authorizeServiceAccount(ctx *fasthttp.RequestCtx, accounts *serviceaccounts.Manager, token string, logger schemas.Logger) bool {
+	account, err := accounts.Authenticate(ctx, token)
+	if err != nil {
+		if errors.Is(err, serviceaccounts.ErrInvalidToken) || errors.Is(err, serviceaccounts.ErrInactive) {
+			SendError(ctx, fasthttp.StatusUnauthorized, err.Error(), logger)
+		} else {
+			SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to authenticate service account: %v", err), logger)
+		}
+		return false
+	}
+	scopes := serviceAccountScopes(string(ctx.Method()), string(ctx.Path()))
+	if scopes == nil {
+		SendError(ctx, fasthttp.StatusForbidden, "service accounts can only call the management API", logger)
+		return false
+	}
+	for _, scope := range scopes {
+		if !account.Allows(scope) {
+			SendError(ctx, fasthttp.StatusForbidden, fmt.Sprintf("service account %s lacks the %s scope", account.Name, scope), logger)
+			return false
+		}
+	}
+	return true
+}
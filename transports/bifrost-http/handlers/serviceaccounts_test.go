@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// runAdminAuth sends a request with a bearer token through the admin auth middleware and returns the status
+func runAdminAuth(config *lib.Config, method, path, token string) int {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(path)
+	ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	AdminAuthMiddleware(config, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	})(ctx)
+	return ctx.Response.StatusCode()
+}
+
+// TestAdminAuthMiddleware_ServiceAccounts tests that service account tokens minted through the API are limited
+// to the scopes of their account and stop working once the account is disabled
+func TestAdminAuthMiddleware_ServiceAccounts(t *testing.T) {
+	accounts := serviceaccounts.NewManager(serviceaccounts.NewInMemoryStore())
+	config := &lib.Config{AdminSecret: "admin-secret", AdminCookieName: "bf_admin", ServiceAccounts: accounts}
+	handler := NewServiceAccountsHandler(accounts, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetBody([]byte(`{"name":"ci","scopes":["config:read","logs:read"]}`))
+	handler.createAccount(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusCreated {
+		t.Fatalf("expected the account to be created, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var created ServiceAccountTokenResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &created); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	token := created.Token
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{fasthttp.MethodGet, "/api/config", fasthttp.StatusOK},
+		{fasthttp.MethodGet, "/api/logs", fasthttp.StatusOK},
+		{fasthttp.MethodGet, "/api/providers/openai", fasthttp.StatusOK},
+		{fasthttp.MethodPut, "/api/config", fasthttp.StatusForbidden},
+		{fasthttp.MethodPut, "/api/providers/openai", fasthttp.StatusForbidden},
+		{fasthttp.MethodPost, "/api/service-accounts", fasthttp.StatusForbidden},
+		{fasthttp.MethodGet, "/logs", fasthttp.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := runAdminAuth(config, tt.method, tt.path, token); got != tt.want {
+			t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, got, tt.want)
+		}
+	}
+	if got := runAdminAuth(config, fasthttp.MethodGet, "/api/config", serviceaccounts.TokenPrefix+"forged"); got != fasthttp.StatusUnauthorized {
+		t.Errorf("expected an unknown token to be rejected, got %d", got)
+	}
+
+	// A keys:write account can change provider keys but not the rest of the configuration
+	_, keysToken, err := accounts.Create(context.Background(), "key-rotator", "", []serviceaccounts.Scope{serviceaccounts.ScopeKeysWrite}, nil)
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	if got := runAdminAuth(config, fasthttp.MethodPut, "/api/providers/openai", keysToken); got != fasthttp.StatusOK {
+		t.Errorf("expected keys:write to update provider keys, got %d", got)
+	}
+	if got := runAdminAuth(config, fasthttp.MethodPost, "/api/apply", keysToken); got != fasthttp.StatusForbidden {
+		t.Errorf("expected provisioning to also require config:write, got %d", got)
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.SetUserValue("id", created.Account.ID)
+	ctx.Request.SetBody([]byte(`{"disabled":true}`))
+	handler.updateAccount(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected the account to be disabled, got %d", ctx.Response.StatusCode())
+	}
+	if got := runAdminAuth(config, fasthttp.MethodGet, "/api/config", token); got != fasthttp.StatusUnauthorized {
+		t.Errorf("expected a disabled account to be rejected, got %d", got)
+	}
+}
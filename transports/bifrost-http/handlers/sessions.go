@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/sessions"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const sessionPluginName = "bifrost-sessions"
+
+// SessionsHandler manages server-side conversation sessions.
+type SessionsHandler struct {
+	store  sessions.Store
+	logger schemas.Logger
+}
+
+// SessionRequest is the body of POST /api/sessions and PUT /api/sessions/{session_id}.
+type SessionRequest struct {
+	ID       string                `json:"id,omitempty"` // Generated when empty, only used on create
+	Summary  string                `json:"summary,omitempty"`
+	Messages []schemas.ChatMessage `json:"messages,omitempty"`
+}
+
+// NewSessionsHandler creates a new sessions handler.
+func NewSessionsHandler(store sessions.Store, logger schemas.Logger) *SessionsHandler {
+	return &SessionsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the session routes.
+func (h *SessionsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/sessions", lib.ChainMiddlewares(h.listSessions, middlewares...))
+	r.POST("/api/sessions", lib.ChainMiddlewares(h.createSession, middlewares...))
+	r.GET("/api/sessions/{session_id}", lib.ChainMiddlewares(h.getSession, middlewares...))
+	r.PUT("/api/sessions/{session_id}", lib.ChainMiddlewares(h.updateSession, middlewares...))
+	r.DELETE("/api/sessions/{session_id}", lib.ChainMiddlewares(h.deleteSession, middlewares...))
+}
+
+var sessionListSpec = &listSpec[sessions.Session]{
+	id: func(session sessions.Session) string { return session.ID },
+	fields: map[string]listField[sessions.Session]{
+		"message_count": {value: func(session sessions.Session) string { return strconv.Itoa(session.MessageCount) }, numeric: true},
+		"token_count":   {value: func(session sessions.Session) string { return strconv.Itoa(session.TokenCount) }, numeric: true},
+		"created_at":    {value: func(session sessions.Session) string { return timeListValue(session.CreatedAt) }, numeric: true},
+		"updated_at":    {value: func(session sessions.Session) string { return timeListValue(session.UpdatedAt) }, numeric: true},
+	},
+	defaultSort: "updated_at",
+}
+
+// listSessions handles GET /api/sessions - List sessions without their messages
+func (h *SessionsHandler) listSessions(ctx *fasthttp.RequestCtx) {
+	query, err := parseListQuery(ctx, sessionListSpec)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, err.Error(), h.logger)
+		return
+	}
+
+	list, err := h.store.List(ctx)
+	if err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to list sessions: %v", err), h.logger)
+		return
+	}
+
+	sendListPage(ctx, "sessions", paginate(list, sessionListSpec, query), h.logger)
+}
+
+// createSession handles POST /api/sessions - Create a session, optionally seeded with a summary and messages
+func (h *SessionsHandler) createSession(ctx *fasthttp.RequestCtx) {
+	var req SessionRequest
+	if body := ctx.PostBody(); len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+			return
+		}
+	}
+	if req.ID == "" {
+		req.ID = uuid.NewString()
+	}
+	if len(req.ID) > sessions.MaxIDLength {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Session ID cannot be longer than %d characters", sessions.MaxIDLength), h.logger)
+		return
+	}
+
+	if _, err := h.store.Get(ctx, req.ID); err == nil {
+		SendError(ctx, fasthttp.StatusConflict, fmt.Sprintf("Session %s already exists", req.ID), h.logger)
+		return
+	} else if !errors.Is(err, sessions.ErrNotFound) {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get session: %v", err), h.logger)
+		return
+	}
+
+	session := &sessions.Session{
+		ID:         req.ID,
+		Summary:    req.Summary,
+		Messages:   req.Messages,
+		TokenCount: sessions.EstimateTokens(req.Summary, req.Messages),
+	}
+	if err := h.store.Save(ctx, session); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to create session: %v", err), h.logger)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusCreated)
+	SendJSON(ctx, session, h.logger)
+}
+
+// getSession handles GET /api/sessions/{session_id} - Get a session with its messages
+func (h *SessionsHandler) getSession(ctx *fasthttp.RequestCtx) {
+	session, ok := h.loadSession(ctx)
+	if !ok {
+		return
+	}
+	SendJSON(ctx, session, h.logger)
+}
+
+// updateSession handles PUT /api/sessions/{session_id} - Replace the summary and messages of a session
+func (h *SessionsHandler) updateSession(ctx *fasthttp.RequestCtx) {
+	var req SessionRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	session, ok := h.loadSession(ctx)
+	if !ok {
+		return
+	}
+
+	session.Summary = req.Summary
+	session.Messages = req.Messages
+	session.TokenCount = sessions.EstimateTokens(req.Summary, req.Messages)
+	if err := h.store.Save(ctx, session); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update session: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, session, h.logger)
+}
+
+// deleteSession handles DELETE /api/sessions/{session_id} - Delete a session
+func (h *SessionsHandler) deleteSession(ctx *fasthttp.RequestCtx) {
+	id := ctx.UserValue("session_id").(string)
+	if err := h.store.Delete(ctx, id); err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Session %s not found", id), h.logger)
+			return
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to delete session: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{
+		"status":  "success",
+		"message": "Session deleted successfully",
+	}, h.logger)
+}
+
+// loadSession loads the session named in the path, sending an error response when it cannot.
+func (h *SessionsHandler) loadSession(ctx *fasthttp.RequestCtx) (*sessions.Session, bool) {
+	id := ctx.UserValue("session_id").(string)
+	session, err := h.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, sessions.ErrNotFound) {
+			SendError(ctx, fasthttp.StatusNotFound, fmt.Sprintf("Session %s not found", id), h.logger)
+			return nil, false
+		}
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to get session: %v", err), h.logger)
+		return nil, false
+	}
+	return session, true
+}
+
+// sessionTurnContextKey holds the *sessionTurn of a chat request that continues a session.
+const sessionTurnContextKey schemas.BifrostContextKey = "bifrost-session-turn"
+
+// sessionTurn is the part of a chat request and its reply that gets appended to the session.
+type sessionTurn struct {
+	sessionID string
+	owner     string                // Owner of the request (see lib.RequestOwner)
+	messages  []schemas.ChatMessage // Client messages of this turn, without leading system messages
+	reply     strings.Builder       // Streamed reply content
+}
+
+// sessionPlugin prepends the history of the session named by a chat request and appends the new turn once the
+// reply is complete. It runs ahead of the system prompt policies, so injected prompts never end up in a session.
+type sessionPlugin struct {
+	config *lib.Config
+	logger schemas.Logger
+
+	// mu serializes the read-modify-write of sessions, so that concurrent turns are all kept
+	mu sync.Mutex
+	// summarizeMu serializes summarization, so that every summary builds on the previous one
+	summarizeMu sync.Mutex
+}
+
+// GetName returns the name of the plugin
+func (p *sessionPlugin) GetName() string {
+	return sessionPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *sessionPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook prepends the session summary and history to the client messages. The client's leading system
+// messages stay first and are not stored, so clients can keep sending their system prompt on every turn.
+// Sessions of another caller are rejected.
+func (p *sessionPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	sessionID, _ := (*ctx).Value(lib.SessionIDContextKey).(string)
+	if sessionID == "" || req.ChatRequest == nil || p.config.Sessions == nil {
+		return req, nil, nil
+	}
+	if len(sessionID) > sessions.MaxIDLength {
+		return req, nil, fmt.Errorf("session ID cannot be longer than %d characters", sessions.MaxIDLength)
+	}
+
+	session, err := p.config.Sessions.Get(*ctx, sessionID)
+	if err != nil && !errors.Is(err, sessions.ErrNotFound) {
+		return req, nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	owner, _ := (*ctx).Value(lib.RequestOwnerContextKey).(string)
+	if session != nil && !lib.OwnedBy(session.Owner, owner) {
+		return req, &schemas.PluginShortCircuit{Error: sessionForbiddenError(sessionID)}, nil
+	}
+
+	input := req.ChatRequest.Input
+	leading := lib.LeadingSystemMessages(input)
+	*ctx = context.WithValue(*ctx, sessionTurnContextKey, &sessionTurn{sessionID: sessionID, owner: owner, messages: input[leading:]})
+	if session == nil || (session.Summary == "" && len(session.Messages) == 0) {
+		return req, nil, nil
+	}
+
+	messages := make([]schemas.ChatMessage, 0, len(input)+len(session.Messages)+1)
+	messages = append(messages, input[:leading]...)
+	if session.Summary != "" {
+		messages = append(messages, lib.SummaryMessage(session.Summary))
+	}
+	messages = append(messages, session.Messages...)
+	messages = append(messages, input[leading:]...)
+
+	withHistory := *req
+	chatReq := *req.ChatRequest
+	chatReq.Input = messages
+	withHistory.ChatRequest = &chatReq
+	return &withHistory, nil, nil
+}
+
+// PostHook appends the turn to the session once the reply is complete. Failed turns, and turns under zero data
+// retention, are not stored.
+func (p *sessionPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	turn, _ := (*ctx).Value(sessionTurnContextKey).(*sessionTurn)
+	if turn == nil || bifrostErr != nil || result == nil || len(result.Choices) == 0 {
+		return result, bifrostErr, nil
+	}
+	if retentionClass, _ := (*ctx).Value(schemas.BifrostContextKeyRetentionClass).(string); retentionClass == schemas.RetentionClassZeroDataRetention {
+		return result, bifrostErr, nil
+	}
+
+	choice := result.Choices[0]
+	if choice.BifrostStreamResponseChoice != nil {
+		if choice.Delta != nil && choice.Delta.Content != nil {
+			turn.reply.WriteString(*choice.Delta.Content)
+		}
+		if isFinalChunk, _ := (*ctx).Value(schemas.BifrostContextKeyStreamEndIndicator).(bool); !isFinalChunk {
+			return result, bifrostErr, nil
+		}
+		reply := turn.reply.String()
+		return result, bifrostErr, p.appendTurn(turn, schemas.ChatMessage{
+			Role:    schemas.ChatMessageRoleAssistant,
+			Content: &schemas.ChatMessageContent{ContentStr: &reply},
+		})
+	}
+	if choice.BifrostNonStreamResponseChoice != nil && choice.Message != nil {
+		return result, bifrostErr, p.appendTurn(turn, *choice.Message)
+	}
+	return result, bifrostErr, nil
+}
+
+// appendTurn appends the client messages and the reply of a turn to its session, dropping the oldest
+// messages past the token window and summarizing them in the background when a summarize model is set.
+func (p *sessionPlugin) appendTurn(turn *sessionTurn, reply schemas.ChatMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx := context.Background()
+	session, err := p.config.Sessions.Get(ctx, turn.sessionID)
+	if errors.Is(err, sessions.ErrNotFound) {
+		session, err = &sessions.Session{ID: turn.sessionID, Owner: turn.owner}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load session %s: %w", turn.sessionID, err)
+	}
+	if !lib.OwnedBy(session.Owner, turn.owner) {
+		// Created by another caller since the turn started
+		return fmt.Errorf("session %s belongs to another caller", turn.sessionID)
+	}
+
+	messages := append(session.Messages, turn.messages...)
+	messages = append(messages, reply)
+	kept, dropped := sessions.Trim(session.Summary, messages, p.config.SessionsConfig.GetMaxTokens())
+	session.Messages = kept
+	session.TokenCount = sessions.EstimateTokens(session.Summary, kept)
+	if err := p.config.Sessions.Save(ctx, session); err != nil {
+		return fmt.Errorf("failed to save session %s: %w", turn.sessionID, err)
+	}
+
+	if len(dropped) > 0 && p.config.SessionsConfig.SummarizeModel != "" {
+		go p.summarize(turn.sessionID, dropped)
+	}
+	return nil
+}
+
+// sessionForbiddenError is returned to requests continuing a session created by another caller.
+func sessionForbiddenError(sessionID string) *schemas.BifrostError {
+	statusCode := fasthttp.StatusForbidden
+	allowFallbacks := false
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		AllowFallbacks: &allowFallbacks,
+		Error: &schemas.ErrorField{
+			Message: fmt.Sprintf("session %s belongs to another caller", sessionID),
+		},
+	}
+}
+
+// summarize folds dropped messages into the session summary using the summarize model.
+func (p *sessionPlugin) summarize(sessionID string, dropped []schemas.ChatMessage) {
+	p.summarizeMu.Lock()
+	defer p.summarizeMu.Unlock()
+
+	ctx := context.Background()
+	session, err := p.config.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		p.logger.Warn("failed to load session %s for summarization: %v", sessionID, err)
+		return
+	}
+	summary, err := p.config.SummarizeMessages(ctx, p.config.SessionsConfig.SummarizeModel, session.Summary, dropped)
+	if err != nil {
+		p.logger.Warn("failed to summarize session %s: %v", sessionID, err)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	session, err = p.config.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return // Deleted while summarizing
+	}
+	session.Summary = summary
+	session.TokenCount = sessions.EstimateTokens(summary, session.Messages)
+	if err := p.config.Sessions.Save(ctx, session); err != nil {
+		p.logger.Warn("failed to save summary of session %s: %v", sessionID, err)
+	}
+}
+
+// Cleanup is not used for this plugin
+func (p *sessionPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/sessions"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+func sessionChatRequest(messages ...schemas.ChatMessage) *schemas.BifrostRequest {
+	return &schemas.BifrostRequest{
+		Provider:    schemas.OpenAI,
+		Model:       "gpt-4o",
+		RequestType: schemas.ChatCompletionRequest,
+		ChatRequest: &schemas.BifrostChatRequest{Provider: schemas.OpenAI, Model: "gpt-4o", Input: messages},
+	}
+}
+
+func sessionMessage(role schemas.ChatMessageRole, text string) schemas.ChatMessage {
+	return schemas.ChatMessage{Role: role, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr(text)}}
+}
+
+// TestSessionPlugin_KeepsHistoryAcrossTurns tests that a stateless client continues a conversation by session ID,
+// with its system prompt kept first and not stored, and that streamed replies are stored once complete
+func TestSessionPlugin_KeepsHistoryAcrossTurns(t *testing.T) {
+	store := sessions.NewInMemoryStore()
+	plugin := &sessionPlugin{config: &lib.Config{SessionsConfig: &sessions.Config{Enabled: true}, Sessions: store}}
+	system := sessionMessage(schemas.ChatMessageRoleSystem, "You are terse.")
+
+	// First turn, answered in one response
+	ctx := context.WithValue(context.Background(), lib.SessionIDContextKey, "s1")
+	req, _, err := plugin.PreHook(&ctx, sessionChatRequest(system, sessionMessage(schemas.ChatMessageRoleUser, "My name is Ada.")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(req.ChatRequest.Input) != 2 {
+		t.Fatalf("expected a new session to add no history, got %d messages", len(req.ChatRequest.Input))
+	}
+	reply := sessionMessage(schemas.ChatMessageRoleAssistant, "Hi Ada.")
+	_, _, err = plugin.PostHook(&ctx, &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{
+		{BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &reply}},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Second turn, streamed
+	ctx = context.WithValue(context.Background(), lib.SessionIDContextKey, "s1")
+	req, _, err = plugin.PreHook(&ctx, sessionChatRequest(system, sessionMessage(schemas.ChatMessageRoleUser, "What is my name?")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var roles []schemas.ChatMessageRole
+	for _, message := range req.ChatRequest.Input {
+		roles = append(roles, message.Role)
+	}
+	if len(roles) != 4 || roles[0] != schemas.ChatMessageRoleSystem || roles[1] != schemas.ChatMessageRoleUser || roles[2] != schemas.ChatMessageRoleAssistant {
+		t.Fatalf("expected system prompt, history and new message, got roles %v", roles)
+	}
+	for i, delta := range []string{"Your name ", "is Ada."} {
+		if i == 1 {
+			ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+		}
+		_, _, err = plugin.PostHook(&ctx, &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{
+			{BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{Content: bifrost.Ptr(delta)}}},
+		}}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	session, err := store.Get(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if len(session.Messages) != 4 {
+		t.Fatalf("expected 4 stored messages without the system prompt, got %d", len(session.Messages))
+	}
+	if got := *session.Messages[3].Content.ContentStr; got != "Your name is Ada." {
+		t.Errorf("expected the streamed reply to be stored whole, got %q", got)
+	}
+}
+
+// TestSessionPlugin_SkipsFailedTurns tests that a turn whose request failed is not stored
+func TestSessionPlugin_SkipsFailedTurns(t *testing.T) {
+	store := sessions.NewInMemoryStore()
+	plugin := &sessionPlugin{config: &lib.Config{SessionsConfig: &sessions.Config{Enabled: true}, Sessions: store}}
+
+	ctx := context.WithValue(context.Background(), lib.SessionIDContextKey, "s1")
+	if _, _, err := plugin.PreHook(&ctx, sessionChatRequest(sessionMessage(schemas.ChatMessageRoleUser, "hi"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := plugin.PostHook(&ctx, nil, &schemas.BifrostError{Error: &schemas.ErrorField{Message: "rate limited"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Get(context.Background(), "s1"); err == nil {
+		t.Error("expected the failed turn not to create a session")
+	}
+}
+
+// TestSessionPlugin_RejectsOtherOwners tests that a session created with one virtual key cannot be read or
+// continued with another one
+func TestSessionPlugin_RejectsOtherOwners(t *testing.T) {
+	store := sessions.NewInMemoryStore()
+	plugin := &sessionPlugin{config: &lib.Config{SessionsConfig: &sessions.Config{Enabled: true}, Sessions: store}}
+	turn := func(owner, text string) (*schemas.BifrostRequest, *schemas.PluginShortCircuit) {
+		t.Helper()
+		ctx := context.WithValue(context.Background(), lib.SessionIDContextKey, "s1")
+		ctx = context.WithValue(ctx, lib.RequestOwnerContextKey, owner)
+		req, shortCircuit, err := plugin.PreHook(&ctx, sessionChatRequest(sessionMessage(schemas.ChatMessageRoleUser, text)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if shortCircuit == nil {
+			reply := sessionMessage(schemas.ChatMessageRoleAssistant, "ok")
+			if _, _, err := plugin.PostHook(&ctx, &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{
+				{BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &reply}},
+			}}, nil); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		return req, shortCircuit
+	}
+
+	if _, shortCircuit := turn("owner-a", "My password is hunter2."); shortCircuit != nil {
+		t.Fatalf("expected the owner to create the session, got %+v", shortCircuit.Error)
+	}
+	_, shortCircuit := turn("owner-b", "What did I say?")
+	if shortCircuit == nil || shortCircuit.Error == nil || *shortCircuit.Error.StatusCode != 403 {
+		t.Fatalf("expected another virtual key to be rejected, got %+v", shortCircuit)
+	}
+	if req, shortCircuit := turn("owner-a", "And now?"); shortCircuit != nil || len(req.ChatRequest.Input) != 3 {
+		t.Fatalf("expected the owner to continue the session with its history, got %+v", shortCircuit)
+	}
+
+	session, err := store.Get(context.Background(), "s1")
+	if err != nil {
+		t.Fatalf("failed to get session: %v", err)
+	}
+	if len(session.Messages) != 4 {
+		t.Errorf("expected only the turns of the owner to be stored, got %d messages", len(session.Messages))
+	}
+}
+
+// TestSessionPlugin_ZeroDataRetention tests that turns under zero data retention are not stored
+func TestSessionPlugin_ZeroDataRetention(t *testing.T) {
+	store := sessions.NewInMemoryStore()
+	plugin := &sessionPlugin{config: &lib.Config{SessionsConfig: &sessions.Config{Enabled: true}, Sessions: store}}
+
+	ctx := context.WithValue(context.Background(), lib.SessionIDContextKey, "s1")
+	ctx = context.WithValue(ctx, schemas.BifrostContextKeyRetentionClass, schemas.RetentionClassZeroDataRetention)
+	if _, _, err := plugin.PreHook(&ctx, sessionChatRequest(sessionMessage(schemas.ChatMessageRoleUser, "My card is 4242."))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	reply := sessionMessage(schemas.ChatMessageRoleAssistant, "Noted.")
+	if _, _, err := plugin.PostHook(&ctx, &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{
+		{BifrostNonStreamResponseChoice: &schemas.BifrostNonStreamResponseChoice{Message: &reply}},
+	}}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Get(context.Background(), "s1"); err == nil {
+		t.Error("expected the turn under zero data retention not to be stored")
+	}
+}
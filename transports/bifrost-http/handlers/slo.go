@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const sloPluginName = "slo"
+
+// sloAttemptContextKey holds the timings of the current upstream attempt
+const sloAttemptContextKey schemas.BifrostContextKey = "bifrost-slo-attempt"
+
+// SLOHandler reports the state of the service level objectives.
+type SLOHandler struct {
+	tracker *lib.SLOTracker
+	logger  schemas.Logger
+}
+
+// SLOStatusResponse is the response of GET /api/slos.
+type SLOStatusResponse struct {
+	Enabled    bool            `json:"enabled"` // Whether objectives are configured
+	Objectives []lib.SLOStatus `json:"objectives"`
+}
+
+// NewSLOHandler creates a new SLO handler; tracker is nil when no objectives are configured.
+func NewSLOHandler(tracker *lib.SLOTracker, logger schemas.Logger) *SLOHandler {
+	return &SLOHandler{
+		tracker: tracker,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes registers the SLO routes.
+func (h *SLOHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/slos", lib.ChainMiddlewares(h.getSLOs, middlewares...))
+}
+
+// getSLOs handles GET /api/slos - Get the compliance, error budget, burn rates and alerts of every objective
+func (h *SLOHandler) getSLOs(ctx *fasthttp.RequestCtx) {
+	response := SLOStatusResponse{Objectives: []lib.SLOStatus{}}
+	if h.tracker != nil {
+		response.Enabled = true
+		response.Objectives = h.tracker.Status()
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// sloAttempt holds the timings of an upstream attempt. It is stored as a pointer in the context so every chunk's
+// PostHook sees the same value.
+type sloAttempt struct {
+	start      time.Time
+	stream     bool
+	firstChunk time.Time
+	once       sync.Once
+	recorded   sync.Once
+}
+
+// sloPlugin records the latency, time to first token and outcome of every upstream attempt against the
+// objectives, so that each provider and model is measured even when a fallback serves the request.
+type sloPlugin struct {
+	tracker *lib.SLOTracker
+}
+
+// GetName returns the name of the plugin
+func (p *sloPlugin) GetName() string {
+	return sloPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *sloPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook records the start of the attempt
+func (p *sloPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	*ctx = context.WithValue(*ctx, sloAttemptContextKey, &sloAttempt{start: time.Now(), stream: bifrost.IsStreamRequestType(req.RequestType)})
+	return req, nil, nil
+}
+
+// PostHook records the attempt once it fails, or once the response or its final chunk arrives
+func (p *sloPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	attempt, ok := (*ctx).Value(sloAttemptContextKey).(*sloAttempt)
+	if !ok {
+		return result, bifrostErr, nil
+	}
+	now := time.Now()
+	if attempt.stream {
+		attempt.once.Do(func() { attempt.firstChunk = now })
+		if isFinalChunk, _ := (*ctx).Value(schemas.BifrostContextKeyStreamEndIndicator).(bool); !isFinalChunk && bifrostErr == nil {
+			return result, bifrostErr, nil
+		}
+	}
+	attempt.recorded.Do(func() {
+		_, provider, model := bifrost.GetRequestFields(result, bifrostErr)
+		event := lib.SLOEvent{
+			Provider: provider,
+			Model:    model,
+			Latency:  now.Sub(attempt.start),
+			Stream:   attempt.stream,
+		}
+		if attempt.stream {
+			event.TTFT = attempt.firstChunk.Sub(attempt.start)
+		}
+		if bifrostErr != nil {
+			event.Rejected = isClientError(bifrostErr)
+			event.Failed = !event.Rejected
+		}
+		p.tracker.Record(event)
+	})
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *sloPlugin) Cleanup() error {
+	return nil
+}
+
+// isClientError tells whether an error is caused by the request rather than by Bifrost or the provider: policy
+// rejections, cancellations and 4xx responses other than timeouts and rate limits.
+func isClientError(bifrostErr *schemas.BifrostError) bool {
+	if policyErrorType(bifrostErr) != "" {
+		return true
+	}
+	if bifrostErr.Error != nil && bifrostErr.Error.Type != nil && *bifrostErr.Error.Type == schemas.RequestCancelled {
+		return true
+	}
+	if bifrostErr.StatusCode == nil {
+		return false
+	}
+	status := *bifrostErr.StatusCode
+	return status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// runSLOAttempt runs an upstream attempt through the SLO plugin, as a stream of chunks when chunks > 0
+func runSLOAttempt(plugin *sloPlugin, model string, chunks int, bifrostErr *schemas.BifrostError) {
+	requestType := schemas.ChatCompletionRequest
+	if chunks > 0 {
+		requestType = schemas.ChatCompletionStreamRequest
+	}
+	ctx := context.Background()
+	plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: model, RequestType: requestType})
+	if bifrostErr != nil {
+		bifrostErr.ExtraFields = schemas.BifrostErrorExtraFields{Provider: schemas.OpenAI, ModelRequested: model, RequestType: requestType}
+		plugin.PostHook(&ctx, nil, bifrostErr)
+		return
+	}
+	result := &schemas.BifrostResponse{ExtraFields: schemas.BifrostResponseExtraFields{
+		RequestType: requestType, Provider: schemas.OpenAI, ModelRequested: model,
+	}}
+	for i := 1; i < chunks; i++ {
+		plugin.PostHook(&ctx, result, nil)
+	}
+	if chunks > 0 {
+		ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+	}
+	plugin.PostHook(&ctx, result, nil)
+}
+
+// getSLOStatus returns the statuses reported by GET /api/slos by objective and model
+func getSLOStatus(t *testing.T, tracker *lib.SLOTracker) map[string]lib.SLOStatus {
+	t.Helper()
+	ctx := &fasthttp.RequestCtx{}
+	NewSLOHandler(tracker, bifrost.NewDefaultLogger(schemas.LogLevelError)).getSLOs(ctx)
+	var response SLOStatusResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	statuses := map[string]lib.SLOStatus{}
+	for _, status := range response.Objectives {
+		statuses[status.Name+"/"+status.Model] = status
+	}
+	return statuses
+}
+
+// TestSLOPlugin_RecordsAttempts tests which attempts each indicator counts, and how they are reported
+func TestSLOPlugin_RecordsAttempts(t *testing.T) {
+	tracker := lib.NewSLOTracker(lib.SLOConfig{Objectives: []lib.SLOObjective{
+		{Name: "ttft", Indicator: lib.SLOIndicatorTTFT, ThresholdMs: 2000, Target: 99, PerModel: true},
+		{Name: "availability", Indicator: lib.SLOIndicatorAvailability, Target: 90, Models: []string{"gpt-slo"}},
+	}})
+	plugin := &sloPlugin{tracker: tracker}
+
+	runSLOAttempt(plugin, "gpt-slo", 0, nil)
+	runSLOAttempt(plugin, "gpt-slo", 3, nil)
+	runSLOAttempt(plugin, "gpt-slo-mini", 2, nil)
+	runSLOAttempt(plugin, "gpt-slo", 0, &schemas.BifrostError{StatusCode: schemas.Ptr(http.StatusBadRequest), Error: &schemas.ErrorField{Message: "bad request"}})
+	runSLOAttempt(plugin, "gpt-slo", 1, &schemas.BifrostError{StatusCode: schemas.Ptr(http.StatusServiceUnavailable), Error: &schemas.ErrorField{Message: "overloaded"}})
+	runSLOAttempt(plugin, "gpt-slo", 0, &schemas.BifrostError{Type: schemas.Ptr("budget_exceeded"), StatusCode: schemas.Ptr(http.StatusPaymentRequired), Error: &schemas.ErrorField{Message: "budget exceeded"}})
+
+	statuses := getSLOStatus(t, tracker)
+	if status := statuses["ttft/gpt-slo"]; status.Total != 1 || status.Good != 1 {
+		t.Errorf("expected the one successful gpt-slo stream to count once, got %+v", status)
+	}
+	if status := statuses["ttft/gpt-slo-mini"]; status.Total != 1 || status.Compliance != 100 {
+		t.Errorf("expected a series for gpt-slo-mini, got %+v", status)
+	}
+	availability := statuses["availability/"]
+	if availability.Total != 3 || availability.Good != 2 {
+		t.Errorf("expected 2 of 3 attempts to be available, excluding client errors, got %+v", availability)
+	}
+	// 1 bad event in 3 against a 10% budget: the budget is overspent by 2/3 of an event in 3
+	if budget := availability.ErrorBudgetRemaining; budget > -233 || budget < -234 {
+		t.Errorf("unexpected error budget remaining: %v", budget)
+	}
+	if rate := availability.BurnRates["5m"]; rate < 3.33 || rate > 3.34 {
+		t.Errorf("unexpected 5m burn rate: %v", rate)
+	}
+	if len(availability.Alerts) != len(lib.DefaultSLOBurnRateAlerts) || availability.Alerts[0].Firing {
+		t.Errorf("expected the default alerts not to fire before an evaluation, got %+v", availability.Alerts)
+	}
+}
+
+// TestSLOTracker_BurnRateAlerts tests that alerts fire once, are sent to the webhook and exported as metrics
+func TestSLOTracker_BurnRateAlerts(t *testing.T) {
+	lib.SetLogger(bifrost.NewDefaultLogger(schemas.LogLevelError))
+	received := make(chan lib.SLOAlert, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert lib.SLOAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil || r.Header.Get("Authorization") != "Bearer slo" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- alert
+	}))
+	defer server.Close()
+
+	tracker := lib.NewSLOTracker(lib.SLOConfig{
+		Objectives: []lib.SLOObjective{{Name: "latency-alerts", Indicator: lib.SLOIndicatorLatency, ThresholdMs: 1, Target: 99}},
+		BurnRateAlerts: []lib.SLOBurnRateAlert{
+			{Severity: "page", LongWindowMinutes: 60, ShortWindowMinutes: 5, BurnRate: 14.4},
+		},
+		Webhook: &lib.SLOWebhookConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer slo"}},
+	})
+	plugin := &sloPlugin{tracker: tracker}
+	for i := 0; i < 3; i++ {
+		ctx := context.Background()
+		plugin.PreHook(&ctx, &schemas.BifrostRequest{Provider: schemas.OpenAI, Model: "gpt-slo", RequestType: schemas.ChatCompletionRequest})
+		time.Sleep(2 * time.Millisecond)
+		plugin.PostHook(&ctx, &schemas.BifrostResponse{ExtraFields: schemas.BifrostResponseExtraFields{
+			RequestType: schemas.ChatCompletionRequest, Provider: schemas.OpenAI, ModelRequested: "gpt-slo",
+		}}, nil)
+	}
+
+	alerts := tracker.Evaluate()
+	if len(alerts) != 1 || alerts[0].Status != "firing" || alerts[0].Type != lib.SLOAlertEventType || alerts[0].LongBurnRate != 100 {
+		t.Fatalf("expected the page alert to fire at 100x, got %+v", alerts)
+	}
+	select {
+	case alert := <-received:
+		if alert.SLO != "latency-alerts" || alert.Severity != "page" {
+			t.Errorf("unexpected alert delivered: %+v", alert)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the alert to be delivered to the webhook")
+	}
+	if alerts := tracker.Evaluate(); len(alerts) != 0 {
+		t.Errorf("expected a firing alert not to be sent again, got %+v", alerts)
+	}
+	if status := getSLOStatus(t, tracker)["latency-alerts/"]; len(status.Alerts) != 1 || !status.Alerts[0].Firing || status.Alerts[0].Since == nil {
+		t.Errorf("expected the alert to be reported as firing, got %+v", status.Alerts)
+	}
+	if metric := findMetric(t, "bifrost_slo_alert_firing", map[string]string{"slo": "latency-alerts", "severity": "page"}); metric == nil || metric.GetGauge().GetValue() != 1 {
+		t.Errorf("expected the firing alert to be exported, got %v", metric)
+	}
+	if metric := findMetric(t, "bifrost_slo_burn_rate", map[string]string{"slo": "latency-alerts", "window": "1h"}); metric == nil || metric.GetGauge().GetValue() != 100 {
+		t.Errorf("expected the 1h burn rate to be exported, got %v", metric)
+	}
+}
+
+// TestSLOConfig_Validate tests that invalid objectives and alerts are rejected
+func TestSLOConfig_Validate(t *testing.T) {
+	valid := lib.SLOObjective{Name: "ttft", Indicator: lib.SLOIndicatorTTFT, ThresholdMs: 2000, Target: 99}
+	tests := map[string]lib.SLOConfig{
+		"no objectives":     {},
+		"missing threshold": {Objectives: []lib.SLOObjective{{Name: "ttft", Indicator: lib.SLOIndicatorTTFT, Target: 99}}},
+		"invalid indicator": {Objectives: []lib.SLOObjective{{Name: "errors", Indicator: "errors", Target: 99}}},
+		"target of 100":     {Objectives: []lib.SLOObjective{{Name: "ttft", Indicator: lib.SLOIndicatorTTFT, ThresholdMs: 2000, Target: 100}}},
+		"duplicate name":    {Objectives: []lib.SLOObjective{valid, valid}},
+		"inverted windows":  {Objectives: []lib.SLOObjective{valid}, BurnRateAlerts: []lib.SLOBurnRateAlert{{Severity: "page", LongWindowMinutes: 5, ShortWindowMinutes: 60, BurnRate: 14.4}}},
+		"webhook url":       {Objectives: []lib.SLOObjective{valid}, Webhook: &lib.SLOWebhookConfig{}},
+	}
+	for name, config := range tests {
+		if err := config.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if err := (&lib.SLOConfig{Objectives: []lib.SLOObjective{valid}}).Validate(); err != nil {
+		t.Errorf("expected a valid config, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newStatsDTestRegistry returns a registry with a counter, a gauge and a histogram
+func newStatsDTestRegistry() (*prometheus.Registry, *prometheus.CounterVec, prometheus.Gauge, prometheus.Histogram) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "bifrost_upstream_requests_total"}, []string{"provider", "customer"})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "http_inflight"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "bifrost_upstream_latency_seconds"})
+	registry.MustRegister(requests, inflight, latency)
+	return registry, requests, inflight, latency
+}
+
+// TestStatsDExporter_DogStatsD tests that counters are pushed as increases with static and renamed label tags
+func TestStatsDExporter_DogStatsD(t *testing.T) {
+	registry, requests, inflight, latency := newStatsDTestRegistry()
+	exporter := lib.NewStatsDExporter(lib.StatsDConfig{
+		Tags:      map[string]string{"env": "prod", "service": "bifrost"},
+		LabelTags: map[string]string{"customer": "tenant"},
+	}, registry)
+
+	requests.WithLabelValues("openai", "acme").Add(3)
+	inflight.Set(2)
+	latency.Observe(0.5)
+	lines, err := exporter.Lines()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"bifrost.bifrost_upstream_requests_total:3|c|#env:prod,provider:openai,service:bifrost,tenant:acme",
+		"bifrost.http_inflight:2|g|#env:prod,service:bifrost",
+		"bifrost.bifrost_upstream_latency_seconds.count:1|c|#env:prod,service:bifrost",
+		"bifrost.bifrost_upstream_latency_seconds.sum:0.5|c|#env:prod,service:bifrost",
+	} {
+		if !slices.Contains(lines, expected) {
+			t.Errorf("expected %q in %v", expected, lines)
+		}
+	}
+
+	// Only the increase is pushed, and unchanged counters are skipped
+	requests.WithLabelValues("openai", "acme").Add(2)
+	lines, _ = exporter.Lines()
+	if !slices.Contains(lines, "bifrost.bifrost_upstream_requests_total:2|c|#env:prod,provider:openai,service:bifrost,tenant:acme") {
+		t.Errorf("expected the increase of the counter, got %v", lines)
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(line, "bifrost.bifrost_upstream_latency_seconds.") {
+			t.Errorf("expected unchanged histograms to be skipped, got %q", line)
+		}
+	}
+}
+
+// TestStatsDExporter_PlainStatsD tests that series are summed without tags, and pushed over UDP
+func TestStatsDExporter_PlainStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+	registry, requests, _, _ := newStatsDTestRegistry()
+	exporter := lib.NewStatsDExporter(lib.StatsDConfig{
+		Address: "udp://" + conn.LocalAddr().String(),
+		Flavor:  lib.StatsDFlavorStatsD,
+		Prefix:  "gw.",
+		Include: []string{"bifrost_upstream_requests"},
+	}, registry)
+	requests.WithLabelValues("openai", "acme").Add(3)
+	requests.WithLabelValues("anthropic", "globex").Add(4)
+	if err := exporter.Push(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("expected a datagram: %v", err)
+	}
+	if packet := string(buf[:n]); packet != "gw.bifrost_upstream_requests_total:7|c" {
+		t.Errorf("unexpected packet: %q", packet)
+	}
+}
+
+// TestStatsDConfig_Validate tests the validation of addresses and flavors
+func TestStatsDConfig_Validate(t *testing.T) {
+	for _, config := range []lib.StatsDConfig{
+		{Address: "127.0.0.1:8125"},
+		{Address: "tcp://127.0.0.1:8125"},
+		{Flavor: "graphite"},
+	} {
+		if err := config.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", config)
+		}
+	}
+	for _, config := range []lib.StatsDConfig{{}, {Address: "udp://datadog-agent:8125"}, {Address: "unix:///var/run/datadog/dsd.socket"}} {
+		if err := config.Validate(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", config, err)
+		}
+	}
+}
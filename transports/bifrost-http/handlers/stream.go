@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/fasthttp/websocket"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	streamRecentLogs       = 200              // Log events kept to replay to new subscribers
+	streamDefaultRecent    = 50               // Log events replayed when recent is not given
+	streamMetricsWindow    = time.Minute      // Completed requests the metrics are computed over
+	streamMaxSamples       = 50000            // Completed requests kept for the metrics, the oldest are forgotten first
+	streamQueueSize        = 256              // Log events queued per subscriber, later ones are dropped until it catches up
+	streamHeartbeat        = 30 * time.Second // Keeps idle connections alive through proxies
+	streamDefaultInterval  = 5 * time.Second
+	streamMinInterval      = time.Second
+	streamMaxInterval      = time.Minute
+	streamWriteTimeout     = 10 * time.Second
+	streamWebSocketTimeout = 60 * time.Second
+)
+
+// StreamMetrics are the key metrics of the requests completed during the window, pushed by /api/stream/metrics.
+type StreamMetrics struct {
+	Timestamp         time.Time `json:"timestamp"`
+	WindowSeconds     int       `json:"window_seconds"`
+	Requests          int       `json:"requests"`
+	Errors            int       `json:"errors"`
+	ErrorRate         float64   `json:"error_rate"` // Percentage of the requests
+	RequestsPerSecond float64   `json:"requests_per_second"`
+	AvgLatency        float64   `json:"avg_latency"` // Milliseconds
+	P95Latency        float64   `json:"p95_latency"` // Milliseconds
+	TotalTokens       int       `json:"total_tokens"`
+	TotalCost         float64   `json:"total_cost"`
+}
+
+// streamSample is a completed request, with the fields the log filters and the metrics need.
+type streamSample struct {
+	completed time.Time // When the event was published, orders the samples of the metrics window
+	timestamp time.Time
+	object    string
+	provider  string
+	model     string
+	status    string
+	customer  string
+	language  string
+	latency   *float64
+	tokens    int
+	cost      *float64
+}
+
+// streamSubscriber is a connection to /api/stream/logs.
+type streamSubscriber struct {
+	filters *logstore.SearchFilters
+	events  chan []byte
+	dropped int // Events dropped since the last one delivered, guarded by the handler lock
+}
+
+// StreamHandler pushes request log events and metrics to the dashboard over WebSocket or server-sent events,
+// filtered server-side with the filters of /api/logs.
+type StreamHandler struct {
+	ctx            context.Context
+	logger         schemas.Logger
+	allowedOrigins []string
+
+	mu          sync.Mutex
+	recent      []*logstore.Log // Most recent log events, oldest first
+	samples     []streamSample  // Completed requests of the metrics window, oldest first
+	subscribers map[*streamSubscriber]struct{}
+}
+
+// NewStreamHandler creates a new stream handler. Streams end when ctx is done.
+func NewStreamHandler(ctx context.Context, logger schemas.Logger, allowedOrigins []string) *StreamHandler {
+	return &StreamHandler{
+		ctx:            ctx,
+		logger:         logger,
+		allowedOrigins: allowedOrigins,
+		subscribers:    make(map[*streamSubscriber]struct{}),
+	}
+}
+
+// RegisterRoutes registers the stream routes
+func (h *StreamHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/stream/logs", lib.ChainMiddlewares(h.streamLogs, middlewares...))
+	r.GET("/api/stream/metrics", lib.ChainMiddlewares(h.streamMetrics, middlewares...))
+}
+
+// PublishLog records a log event, pushing it to the subscribers whose filters it matches. It never blocks: the
+// events of subscribers too slow to keep up are dropped.
+func (h *StreamHandler) PublishLog(logEntry *logstore.Log) {
+	sample := newStreamSample(logEntry)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recent = append(h.recent, logEntry)
+	if len(h.recent) > streamRecentLogs {
+		h.recent = slices.Delete(h.recent, 0, len(h.recent)-streamRecentLogs)
+	}
+	if sample.status == "success" || sample.status == "error" {
+		sample.completed = time.Now()
+		h.samples = append(h.samples, sample)
+		h.pruneSamples(sample.completed)
+	}
+
+	var data []byte
+	for subscriber := range h.subscribers {
+		if !matchesLogFilters(sample, logEntry.ContentSummary, subscriber.filters) {
+			continue
+		}
+		if data == nil {
+			var err error
+			if data, err = marshalLogUpdate(logEntry); err != nil {
+				h.logger.Error("failed to marshal log entry: %v", err)
+				return
+			}
+		}
+		select {
+		case subscriber.events <- data:
+		default:
+			subscriber.dropped++
+		}
+	}
+}
+
+// pruneSamples forgets the samples older than the metrics window, and the oldest beyond the sample limit. The
+// caller holds the lock.
+func (h *StreamHandler) pruneSamples(now time.Time) {
+	cutoff := now.Add(-streamMetricsWindow)
+	i, _ := slices.BinarySearchFunc(h.samples, cutoff, func(s streamSample, t time.Time) int { return s.completed.Compare(t) })
+	i = max(i, len(h.samples)-streamMaxSamples)
+	if i > 0 {
+		h.samples = slices.Delete(h.samples, 0, i)
+	}
+}
+
+// subscribe registers a subscriber to the log events matching filters, returning with it the last recent
+// matching events.
+func (h *StreamHandler) subscribe(filters *logstore.SearchFilters, recent int) (*streamSubscriber, []*logstore.Log) {
+	subscriber := &streamSubscriber{filters: filters, events: make(chan []byte, streamQueueSize)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[subscriber] = struct{}{}
+	var backlog []*logstore.Log
+	for i := len(h.recent) - 1; i >= 0 && len(backlog) < recent; i-- {
+		if matchesLogFilters(newStreamSample(h.recent[i]), h.recent[i].ContentSummary, filters) {
+			backlog = append(backlog, h.recent[i])
+		}
+	}
+	slices.Reverse(backlog)
+	return subscriber, backlog
+}
+
+// unsubscribe removes a subscriber.
+func (h *StreamHandler) unsubscribe(subscriber *streamSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, subscriber)
+}
+
+// takeDropped returns and resets the number of events dropped for a subscriber.
+func (h *StreamHandler) takeDropped(subscriber *streamSubscriber) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dropped := subscriber.dropped
+	subscriber.dropped = 0
+	return dropped
+}
+
+// metrics computes the metrics of the completed requests matching filters.
+func (h *StreamHandler) metrics(filters *logstore.SearchFilters) StreamMetrics {
+	now := time.Now()
+	h.mu.Lock()
+	h.pruneSamples(now)
+	var latencies []float64
+	metrics := StreamMetrics{Timestamp: now.UTC(), WindowSeconds: int(streamMetricsWindow / time.Second)}
+	for _, sample := range h.samples {
+		if !matchesLogFilters(sample, "", filters) {
+			continue
+		}
+		metrics.Requests++
+		if sample.status == "error" {
+			metrics.Errors++
+		}
+		if sample.latency != nil {
+			latencies = append(latencies, *sample.latency)
+		}
+		metrics.TotalTokens += sample.tokens
+		if sample.cost != nil {
+			metrics.TotalCost += *sample.cost
+		}
+	}
+	h.mu.Unlock()
+
+	if metrics.Requests > 0 {
+		metrics.ErrorRate = float64(metrics.Errors) / float64(metrics.Requests) * 100
+		metrics.RequestsPerSecond = float64(metrics.Requests) / streamMetricsWindow.Seconds()
+	}
+	if len(latencies) > 0 {
+		var sum float64
+		for _, latency := range latencies {
+			sum += latency
+		}
+		metrics.AvgLatency = sum / float64(len(latencies))
+		slices.Sort(latencies)
+		metrics.P95Latency = latencies[(len(latencies)*95-1)/100]
+	}
+	return metrics
+}
+
+// streamLogs handles GET /api/stream/logs - Push log events matching the /api/logs filters, starting with the
+// last recent ones (?recent=, 50 by default)
+func (h *StreamHandler) streamLogs(ctx *fasthttp.RequestCtx) {
+	filters := parseLogFilters(ctx.QueryArgs())
+	recent := streamDefaultRecent
+	if value := string(ctx.QueryArgs().Peek("recent")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > streamRecentLogs {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("recent must be between 0 and %d", streamRecentLogs), h.logger)
+			return
+		}
+		recent = n
+	}
+
+	h.serveStream(ctx, func(send streamSender, done <-chan struct{}) {
+		subscriber, backlog := h.subscribe(filters, recent)
+		defer h.unsubscribe(subscriber)
+		for _, logEntry := range backlog {
+			data, err := marshalLogUpdate(logEntry)
+			if err != nil {
+				continue
+			}
+			if err := send("log", data); err != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case data := <-subscriber.events:
+				if dropped := h.takeDropped(subscriber); dropped > 0 {
+					notice, _ := json.Marshal(map[string]any{"type": "dropped", "count": dropped})
+					if err := send("dropped", notice); err != nil {
+						return
+					}
+				}
+				if err := send("log", data); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if err := send("", nil); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	})
+}
+
+// streamMetrics handles GET /api/stream/metrics - Push the metrics of the last minute of requests matching the
+// /api/logs filters every interval seconds (?interval=, 5 by default). The content search does not apply.
+func (h *StreamHandler) streamMetrics(ctx *fasthttp.RequestCtx) {
+	filters := parseLogFilters(ctx.QueryArgs())
+	filters.ContentSearch = ""
+	interval := streamDefaultInterval
+	if value := string(ctx.QueryArgs().Peek("interval")); value != "" {
+		seconds, err := strconv.Atoi(value)
+		interval = time.Duration(seconds) * time.Second
+		if err != nil || interval < streamMinInterval || interval > streamMaxInterval {
+			SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("interval must be between %d and %d seconds", int(streamMinInterval.Seconds()), int(streamMaxInterval.Seconds())), h.logger)
+			return
+		}
+	}
+
+	h.serveStream(ctx, func(send streamSender, done <-chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			data, err := json.Marshal(map[string]any{"type": "metrics", "payload": h.metrics(filters)})
+			if err != nil {
+				h.logger.Error("failed to marshal stream metrics: %v", err)
+				return
+			}
+			if err := send("metrics", data); err != nil {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	})
+}
+
+// streamSender sends an event of a stream, a heartbeat when event is empty. An error ends the stream.
+type streamSender func(event string, data []byte) error
+
+// serveStream runs a stream over WebSocket when the request asks for an upgrade, else over server-sent events
+// with the event name of each message. done is closed when the client goes away or the server shuts down.
+func (h *StreamHandler) serveStream(ctx *fasthttp.RequestCtx, run func(send streamSender, done <-chan struct{})) {
+	if websocket.FastHTTPIsWebSocketUpgrade(ctx) {
+		upgrader := newWebSocketUpgrader(h.allowedOrigins)
+		err := upgrader.Upgrade(ctx, func(ws *websocket.Conn) {
+			defer ws.Close()
+			ws.SetReadLimit(1 << 10)
+			ws.SetReadDeadline(time.Now().Add(streamWebSocketTimeout))
+			ws.SetPongHandler(func(string) error {
+				return ws.SetReadDeadline(time.Now().Add(streamWebSocketTimeout))
+			})
+			// Reads process control frames and notice the client leaving; clients send nothing else
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				for {
+					if _, _, err := ws.ReadMessage(); err != nil {
+						return
+					}
+				}
+			}()
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-closed:
+				case <-h.ctx.Done():
+				}
+				close(done)
+			}()
+
+			run(func(event string, data []byte) error {
+				ws.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+				if event == "" {
+					return ws.WriteMessage(websocket.PingMessage, nil)
+				}
+				return ws.WriteMessage(websocket.TextMessage, data)
+			}, done)
+		})
+		if err != nil {
+			h.logger.Warn("stream websocket upgrade error: %v", err)
+		}
+		return
+	}
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("Connection", "keep-alive")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+	ctx.Response.SetBodyStreamWriter(func(w *bufio.Writer) {
+		// A client going away is noticed by the next failed write, heartbeats included
+		run(func(event string, data []byte) error {
+			if event == "" {
+				w.WriteString(": ping\n\n")
+			} else {
+				w.WriteString("event: " + event + "\n")
+				if err := lib.WriteSSEData(w, data); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		}, h.ctx.Done())
+	})
+}
+
+// newStreamSample returns the sample of a log event.
+func newStreamSample(logEntry *logstore.Log) streamSample {
+	tokens := logEntry.TotalTokens
+	if tokens == 0 && logEntry.TokenUsageParsed != nil {
+		tokens = logEntry.TokenUsageParsed.TotalTokens
+	}
+	return streamSample{
+		timestamp: logEntry.Timestamp,
+		object:    logEntry.Object,
+		provider:  logEntry.Provider,
+		model:     logEntry.Model,
+		status:    logEntry.Status,
+		customer:  logEntry.Customer,
+		language:  logEntry.Language,
+		latency:   logEntry.Latency,
+		tokens:    tokens,
+		cost:      logEntry.Cost,
+	}
+}
+
+// matchesLogFilters reports whether a log event matches the filters the way the log store applies them: bounds
+// exclude requests without the value, and the content search looks into the content summary of the request,
+// known once it completes.
+func matchesLogFilters(sample streamSample, contentSummary string, filters *logstore.SearchFilters) bool {
+	if filters == nil {
+		return true
+	}
+	in := func(values []string, value string) bool {
+		return len(values) == 0 || slices.Contains(values, value)
+	}
+	if !in(filters.Providers, sample.provider) || !in(filters.Models, sample.model) || !in(filters.Status, sample.status) ||
+		!in(filters.Objects, sample.object) || !in(filters.Customers, sample.customer) || !in(filters.Languages, sample.language) {
+		return false
+	}
+	if (filters.StartTime != nil && sample.timestamp.Before(*filters.StartTime)) || (filters.EndTime != nil && sample.timestamp.After(*filters.EndTime)) {
+		return false
+	}
+	if filters.MinLatency != nil && (sample.latency == nil || *sample.latency < *filters.MinLatency) {
+		return false
+	}
+	if filters.MaxLatency != nil && (sample.latency == nil || *sample.latency > *filters.MaxLatency) {
+		return false
+	}
+	if (filters.MinTokens != nil && sample.tokens < *filters.MinTokens) || (filters.MaxTokens != nil && sample.tokens > *filters.MaxTokens) {
+		return false
+	}
+	if filters.MinCost != nil && (sample.cost == nil || *sample.cost < *filters.MinCost) {
+		return false
+	}
+	if filters.MaxCost != nil && (sample.cost == nil || *sample.cost > *filters.MaxCost) {
+		return false
+	}
+	if filters.ContentSearch != "" && !strings.Contains(strings.ToLower(contentSummary), strings.ToLower(filters.ContentSearch)) {
+		return false
+	}
+	return true
+}
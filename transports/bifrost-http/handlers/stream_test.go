@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/valyala/fasthttp"
+)
+
+// TestStreamHandler_Filters tests that log events are pushed to the subscribers whose filters they match, that new
+// subscribers get the recent matching events, and that metrics cover the matching completed requests
+func TestStreamHandler_Filters(t *testing.T) {
+	h := NewStreamHandler(context.Background(), bifrost.NewDefaultLogger(schemas.LogLevelError), nil)
+	latency, cost := 120.0, 0.5
+	now := time.Now()
+	h.PublishLog(&logstore.Log{ID: "1", Provider: "openai", Model: "gpt-4o", Status: "processing", Timestamp: now, CreatedAt: now})
+	h.PublishLog(&logstore.Log{ID: "1", Provider: "openai", Model: "gpt-4o", Status: "success", Timestamp: now, CreatedAt: now, Latency: &latency, Cost: &cost, TotalTokens: 10})
+	h.PublishLog(&logstore.Log{ID: "2", Provider: "anthropic", Model: "claude", Status: "error", Timestamp: now, CreatedAt: now})
+
+	filters := &logstore.SearchFilters{Providers: []string{"openai"}}
+	subscriber, backlog := h.subscribe(filters, 1)
+	defer h.unsubscribe(subscriber)
+	if len(backlog) != 1 || backlog[0].ID != "1" || backlog[0].Status != "success" {
+		t.Fatalf("backlog = %+v, want the last openai event", backlog)
+	}
+
+	h.PublishLog(&logstore.Log{ID: "3", Provider: "anthropic", Status: "processing", Timestamp: now, CreatedAt: now})
+	h.PublishLog(&logstore.Log{ID: "4", Provider: "openai", Status: "processing", Timestamp: now, CreatedAt: now})
+	select {
+	case data := <-subscriber.events:
+		var message struct {
+			Type      string       `json:"type"`
+			Operation string       `json:"operation"`
+			Payload   logstore.Log `json:"payload"`
+		}
+		if err := json.Unmarshal(data, &message); err != nil {
+			t.Fatalf("invalid event: %v", err)
+		}
+		if message.Type != "log" || message.Operation != "create" || message.Payload.ID != "4" {
+			t.Errorf("unexpected event %+v", message)
+		}
+	default:
+		t.Fatal("expected the matching event to be pushed")
+	}
+	if len(subscriber.events) != 0 {
+		t.Errorf("expected events of other providers not to be pushed")
+	}
+
+	metrics := h.metrics(nil)
+	if metrics.Requests != 2 || metrics.Errors != 1 || metrics.ErrorRate != 50 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+	metrics = h.metrics(filters)
+	if metrics.Requests != 1 || metrics.AvgLatency != 120 || metrics.P95Latency != 120 || metrics.TotalTokens != 10 || metrics.TotalCost != 0.5 {
+		t.Errorf("unexpected filtered metrics %+v", metrics)
+	}
+
+	minLatency := 200.0
+	if matchesLogFilters(newStreamSample(&logstore.Log{Latency: &latency}), "", &logstore.SearchFilters{MinLatency: &minLatency}) {
+		t.Errorf("expected a request below the minimum latency not to match")
+	}
+	if matchesLogFilters(newStreamSample(&logstore.Log{}), "", &logstore.SearchFilters{MinLatency: &minLatency}) {
+		t.Errorf("expected a request without latency not to match a latency bound")
+	}
+	if !matchesLogFilters(newStreamSample(&logstore.Log{}), "Tell me a JOKE", &logstore.SearchFilters{ContentSearch: "joke"}) {
+		t.Errorf("expected the content search to be case-insensitive")
+	}
+}
+
+// TestStreamHandler_SSE tests that log events are streamed as server-sent events named after their type
+func TestStreamHandler_SSE(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := NewStreamHandler(ctx, bifrost.NewDefaultLogger(schemas.LogLevelError), nil)
+	now := time.Now()
+	h.PublishLog(&logstore.Log{ID: "recent", Provider: "openai", Status: "success", Timestamp: now, CreatedAt: now})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	server := &fasthttp.Server{Handler: func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Path()) {
+		case "/api/stream/logs":
+			h.streamLogs(ctx)
+		case "/api/stream/metrics":
+			h.streamMetrics(ctx)
+		}
+	}}
+	go server.Serve(listener)
+	defer server.Shutdown()
+	// Streams end with the handler context, before the server waits for their connections
+	defer cancel()
+
+	response, err := http.Get("http://" + listener.Addr().String() + "/api/stream/logs?providers=openai")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", contentType)
+	}
+	reader := bufio.NewReader(response.Body)
+	readEvent := func() (string, string) {
+		var event, data string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read event: %v", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return event, data
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+
+	if event, data := readEvent(); event != "log" || !strings.Contains(data, `"id":"recent"`) {
+		t.Fatalf("first event = %s %s, want the recent log", event, data)
+	}
+	// The subscriber is registered before the backlog is sent
+	h.PublishLog(&logstore.Log{ID: "other", Provider: "anthropic", Status: "processing", Timestamp: now, CreatedAt: now})
+	h.PublishLog(&logstore.Log{ID: "live", Provider: "openai", Status: "processing", Timestamp: now, CreatedAt: now})
+	if event, data := readEvent(); event != "log" || !strings.Contains(data, `"id":"live"`) {
+		t.Fatalf("next event = %s %s, want the live openai log", event, data)
+	}
+
+	response, err = http.Get("http://" + listener.Addr().String() + "/api/stream/metrics?interval=0")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 for an invalid interval", response.StatusCode)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// streamAggregationTestAccount configures OpenAI, served by baseURL, and the mock provider as its fallback
+type streamAggregationTestAccount struct {
+	baseURL string
+}
+
+func (a streamAggregationTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{schemas.OpenAI, schemas.Mock}, nil
+}
+
+func (a streamAggregationTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	if providerKey != schemas.OpenAI {
+		return nil, nil
+	}
+	return []schemas.Key{{ID: "openai-key", Value: "sk-test", Weight: 1}}, nil
+}
+
+func (a streamAggregationTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	if providerKey == schemas.Mock {
+		return &schemas.ProviderConfig{
+			ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+			MockConfig:               &schemas.MockProviderConfig{Response: "from mock"},
+		}, nil
+	}
+	return &schemas.ProviderConfig{
+		NetworkConfig:            schemas.NetworkConfig{BaseURL: a.baseURL},
+		ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+	}, nil
+}
+
+// TestStreamAggregation tests that non-streaming chat completions opting in with the header or through their virtual
+// key are streamed upstream and answered with the aggregated response, and that a stream cut midway falls back
+// without its partial content reaching the client
+func TestStreamAggregation(t *testing.T) {
+	// The upstream stream is cut after some content when truncated is set
+	truncated := false
+	var streamed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		streamed, _ = body["stream"].(bool)
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"id": "chatcmpl-1", "object": "chat.completion.chunk", "model": "gpt-4o", "created": 1700000000, "choices": [{"index": 0, "delta": {"role": "assistant", "content": "Hel"}}]}`,
+			`{"id": "chatcmpl-1", "object": "chat.completion.chunk", "model": "gpt-4o", "created": 1700000000, "choices": [{"index": 0, "delta": {"content": "lo"}}]}`,
+		}
+		if !truncated {
+			chunks = append(chunks,
+				`{"id": "chatcmpl-1", "object": "chat.completion.chunk", "model": "gpt-4o", "created": 1700000000, "choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "id": "call-1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":"}}]}}]}`,
+				`{"id": "chatcmpl-1", "object": "chat.completion.chunk", "model": "gpt-4o", "created": 1700000000, "choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": "\"hi\"}"}}]}}]}`,
+				`{"id": "chatcmpl-1", "object": "chat.completion.chunk", "model": "gpt-4o", "created": 1700000000, "choices": [{"index": 0, "delta": {}, "finish_reason": "tool_calls"}], "usage": {"prompt_tokens": 3, "completion_tokens": 5, "total_tokens": 8}}`,
+			)
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		if truncated {
+			// The connection drops before the end of the chunked body
+			w.(http.Flusher).Flush()
+			if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+				conn.Close()
+			}
+			return
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+		Account: streamAggregationTestAccount{baseURL: server.URL},
+		Logger:  logger,
+	})
+	if err != nil {
+		t.Fatalf("failed to init bifrost: %v", err)
+	}
+	t.Cleanup(client.Shutdown)
+	h := NewInferenceHandler(client, &lib.Config{StreamAggregation: &lib.StreamAggregationConfig{VirtualKeys: []string{"vk-aggregated"}}}, logger)
+
+	chat := func(header, value string) *schemas.BifrostResponse {
+		t.Helper()
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.Set(header, value)
+		ctx.Request.SetBody([]byte(`{"model": "openai/gpt-4o", "messages": [{"role": "user", "content": "Hi"}], "fallbacks": ["mock/echo"]}`))
+		h.chatCompletion(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+		if contentType := string(ctx.Response.Header.ContentType()); contentType != "application/json" {
+			t.Fatalf("Content-Type = %q, want a JSON response", contentType)
+		}
+		var resp schemas.BifrostResponse
+		if err := json.Unmarshal(ctx.Response.Body(), &resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return &resp
+	}
+
+	resp := chat("x-bf-stream-aggregation", "true")
+	if !streamed {
+		t.Errorf("expected the upstream request to stream")
+	}
+	if resp.ID != "chatcmpl-1" || resp.Object != "chat.completion" || len(resp.Choices) != 1 || resp.Usage == nil || resp.Usage.TotalTokens != 8 {
+		t.Fatalf("unexpected aggregated response %+v", resp)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason == nil || *choice.FinishReason != "tool_calls" || choice.BifrostNonStreamResponseChoice == nil || choice.Message == nil {
+		t.Fatalf("unexpected aggregated choice %+v", choice)
+	}
+	message := choice.Message
+	if message.Content == nil || *message.Content.ContentStr != "Hello" || message.ChatAssistantMessage == nil || len(message.ToolCalls) != 1 {
+		t.Fatalf("unexpected aggregated message %+v", message)
+	}
+	if toolCall := message.ToolCalls[0]; *toolCall.ID != "call-1" || *toolCall.Function.Name != "lookup" || toolCall.Function.Arguments != `{"q":"hi"}` {
+		t.Errorf("unexpected aggregated tool call %+v", toolCall)
+	}
+
+	// The virtual key policy aggregates too, and the stream cut after some content falls back to the mock provider
+	truncated = true
+	resp = chat("x-bf-vk", "vk-aggregated")
+	if len(resp.Choices) != 1 || resp.Choices[0].Message == nil || *resp.Choices[0].Message.Content.ContentStr != "from mock" {
+		t.Fatalf("response = %+v, want the complete fallback response only", resp)
+	}
+	if resp.ExtraFields.Provider != schemas.Mock {
+		t.Errorf("provider = %s, want the mock fallback", resp.ExtraFields.Provider)
+	}
+
+	// Other requests are sent as they come
+	truncated = false
+	chat("x-bf-vk", "vk-other")
+	if streamed {
+		t.Errorf("expected the upstream request of another virtual key not to stream")
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/plugins/governance"
+)
+
+func streamTokenLimitsChunk(content string, usage *schemas.LLMUsage) *schemas.BifrostResponse {
+	return &schemas.BifrostResponse{
+		Choices: []schemas.BifrostChatResponseChoice{{
+			BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{Content: &content}},
+		}},
+		Usage: usage,
+		ExtraFields: schemas.BifrostResponseExtraFields{
+			RequestType:    schemas.ChatCompletionStreamRequest,
+			Provider:       schemas.OpenAI,
+			ModelRequested: "gpt-4o-mini",
+		},
+	}
+}
+
+// TestStreamTokenLimits tests that the output of streams is charged to token limits as it arrives, that streams past
+// a limit are terminated or paused until it resets, and that the reported usage replaces the estimates
+func TestStreamTokenLimits(t *testing.T) {
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	// initPlugin creates a governance plugin with the sk-bf-limited virtual key, limited to tokenLimit tokens per
+	// resetDuration
+	initPlugin := func(config *governance.StreamTokenLimitsConfig, tokenLimit int64, resetDuration string) *governance.GovernancePlugin {
+		t.Helper()
+		plugin, err := governance.Init(context.Background(), &governance.Config{StreamTokenLimits: config}, logger, nil, &configstore.GovernanceConfig{
+			VirtualKeys: []configstore.TableVirtualKey{{ID: "limited", Value: "sk-bf-limited", IsActive: true, RateLimitID: bifrost.Ptr("limited-rl")}},
+			RateLimits:  []configstore.TableRateLimit{{ID: "limited-rl", TokenMaxLimit: &tokenLimit, TokenResetDuration: &resetDuration, TokenLastReset: time.Now()}},
+		}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to init governance: %v", err)
+		}
+		return plugin
+	}
+	startStream := func(plugin *governance.GovernancePlugin) context.Context {
+		t.Helper()
+		// Virtual keys are stored under the keys of both core and governance, like the transport does
+		ctx := context.WithValue(context.Background(), schemas.BifrostContextKeyVirtualKeyHeader, "sk-bf-limited")
+		ctx = context.WithValue(ctx, governance.ContextKey("x-bf-vk"), "sk-bf-limited")
+		_, shortCircuit, err := plugin.PreHook(&ctx, &schemas.BifrostRequest{
+			Provider:    schemas.OpenAI,
+			Model:       "gpt-4o-mini",
+			RequestType: schemas.ChatCompletionStreamRequest,
+			ChatRequest: &schemas.BifrostChatRequest{
+				Provider: schemas.OpenAI,
+				Model:    "gpt-4o-mini",
+				Input:    []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("hi")}}},
+			},
+		})
+		if err != nil || shortCircuit != nil {
+			t.Fatalf("expected the stream to start, got %v %+v", err, shortCircuit)
+		}
+		return ctx
+	}
+	// Each chunk of 40 characters is estimated at 10 tokens
+	chunk := strings.Repeat("word ", 8)
+
+	t.Run("terminate", func(t *testing.T) {
+		plugin := initPlugin(&governance.StreamTokenLimitsConfig{}, 25, "1h")
+		ctx := startStream(plugin)
+		for i := 0; i < 2; i++ {
+			if _, err, _ := plugin.PostHook(&ctx, streamTokenLimitsChunk(chunk, nil), nil); err != nil {
+				t.Fatalf("chunk %d: unexpected error %s", i, err.Error.Message)
+			}
+		}
+		_, err, _ := plugin.PostHook(&ctx, streamTokenLimitsChunk(chunk, nil), nil)
+		if err == nil || *err.StatusCode != 429 || *err.Error.Type != string(governance.DecisionTokenLimited) || *err.AllowFallbacks {
+			t.Fatalf("expected the stream to be terminated with a token_limited 429, got %+v", err)
+		}
+		if !strings.Contains(err.Error.Message, "VK rate limits exceeded while streaming") {
+			t.Errorf("message = %q", err.Error.Message)
+		}
+	})
+
+	t.Run("pause until the limit resets", func(t *testing.T) {
+		plugin := initPlugin(&governance.StreamTokenLimitsConfig{Action: governance.StreamLimitActionPause, MaxPauseMs: 5000}, 15, "1s")
+		ctx := startStream(plugin)
+		if _, err, _ := plugin.PostHook(&ctx, streamTokenLimitsChunk(chunk, nil), nil); err != nil {
+			t.Fatalf("unexpected error %s", err.Error.Message)
+		}
+		start := time.Now()
+		if _, err, _ := plugin.PostHook(&ctx, streamTokenLimitsChunk(chunk, nil), nil); err != nil {
+			t.Fatalf("expected the stream to resume after the reset, got %s", err.Error.Message)
+		}
+		if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+			t.Errorf("stream resumed after %v, want it paused until the limit resets", elapsed)
+		}
+		vk, _ := plugin.GetGovernanceStore().GetVirtualKey("sk-bf-limited")
+		rateLimits, _ := plugin.GetGovernanceStore().CollectRateLimitsFromHierarchy(vk)
+		if usage := rateLimits[0].TokenCurrentUsage; usage != 10 {
+			t.Errorf("usage = %d, want the chunk charged to the new window", usage)
+		}
+	})
+
+	t.Run("pause longer than the maximum", func(t *testing.T) {
+		plugin := initPlugin(&governance.StreamTokenLimitsConfig{Action: governance.StreamLimitActionPause, MaxPauseMs: 100}, 5, "1h")
+		ctx := startStream(plugin)
+		if _, err, _ := plugin.PostHook(&ctx, streamTokenLimitsChunk(chunk, nil), nil); err == nil || *err.StatusCode != 429 {
+			t.Fatalf("expected the stream to be terminated, got %+v", err)
+		}
+	})
+
+	t.Run("reported usage replaces the estimates", func(t *testing.T) {
+		plugin := initPlugin(&governance.StreamTokenLimitsConfig{}, 1000, "1h")
+		rateLimit := func() int64 {
+			vk, _ := plugin.GetGovernanceStore().GetVirtualKey("sk-bf-limited")
+			rateLimits, _ := plugin.GetGovernanceStore().CollectRateLimitsFromHierarchy(vk)
+			return rateLimits[0].TokenCurrentUsage
+		}
+		ctx := startStream(plugin)
+		plugin.PostHook(&ctx, streamTokenLimitsChunk(chunk, nil), nil)
+		plugin.PostHook(&ctx, streamTokenLimitsChunk(chunk, nil), nil)
+		if usage := rateLimit(); usage != 20 {
+			t.Fatalf("usage = %d, want the 20 estimated tokens", usage)
+		}
+
+		finalCtx := context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+		plugin.PostHook(&finalCtx, streamTokenLimitsChunk("", &schemas.LLMUsage{PromptTokens: 4, CompletionTokens: 13, TotalTokens: 17}), nil)
+		deadline := time.Now().Add(2 * time.Second)
+		for rateLimit() != 17 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if usage := rateLimit(); usage != 17 {
+			t.Errorf("usage = %d, want the 17 reported tokens", usage)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/tokenizer"
+)
+
+const streamUsagePluginName = "stream-usage"
+
+// streamUsageContextKey holds the usage reported and the output produced so far by a stream
+const streamUsageContextKey schemas.BifrostContextKey = "bifrost-stream-usage"
+
+// streamUsage accumulates what a stream reported and produced. Chunks of a stream run their PostHooks one after
+// the other, so it needs no lock.
+type streamUsage struct {
+	promptEstimate int
+	reported       schemas.LLMUsage
+	output         strings.Builder
+}
+
+// streamUsagePlugin makes the final chunk of every chat and text completion stream carry its usage: the usage the
+// provider reported in any chunk, completed with estimates of the prompt and output tokens it did not report.
+// Registered after governance, logging and telemetry so that their PostHooks, which run in reverse order, see it.
+type streamUsagePlugin struct{}
+
+// GetName returns the name of the plugin
+func (p *streamUsagePlugin) GetName() string {
+	return streamUsagePluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *streamUsagePlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook estimates the prompt tokens of chat and text completion streams
+func (p *streamUsagePlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	if req.RequestType != schemas.ChatCompletionStreamRequest && req.RequestType != schemas.TextCompletionStreamRequest {
+		return req, nil, nil
+	}
+	promptEstimate, ok := estimatePromptTokens(req)
+	if !ok {
+		return req, nil, nil
+	}
+	*ctx = context.WithValue(*ctx, streamUsageContextKey, &streamUsage{promptEstimate: promptEstimate})
+	return req, nil, nil
+}
+
+// estimatePromptTokens estimates the prompt tokens of a chat or text completion, a responses request or an
+// embedding request, tools and instructions included. ok is false for other requests.
+func estimatePromptTokens(req *schemas.BifrostRequest) (tokens int, ok bool) {
+	switch {
+	case req.ChatRequest != nil:
+		tokens = tokenizer.EstimateMessages(req.ChatRequest.Input)
+		if req.ChatRequest.Params != nil && len(req.ChatRequest.Params.Tools) > 0 {
+			if tools, err := json.Marshal(req.ChatRequest.Params.Tools); err == nil {
+				tokens += tokenizer.EstimateText(string(tools))
+			}
+		}
+	case req.TextCompletionRequest != nil && req.TextCompletionRequest.Input != nil:
+		if input := req.TextCompletionRequest.Input; input.PromptStr != nil {
+			tokens = tokenizer.EstimateText(*input.PromptStr)
+		} else {
+			tokens = tokenizer.EstimateText(strings.Join(input.PromptArray, ""))
+		}
+	case req.ResponsesRequest != nil:
+		chatReq := req.ResponsesRequest.ToChatRequest()
+		tokens = tokenizer.EstimateMessages(chatReq.Input)
+		if params := req.ResponsesRequest.Params; params != nil {
+			if params.Instructions != nil {
+				tokens += tokenizer.EstimateText(*params.Instructions)
+			}
+			if len(params.Tools) > 0 {
+				if tools, err := json.Marshal(params.Tools); err == nil {
+					tokens += tokenizer.EstimateText(string(tools))
+				}
+			}
+		}
+	case req.EmbeddingRequest != nil && req.EmbeddingRequest.Input != nil:
+		// Pre-tokenized inputs are counted as they are
+		input := req.EmbeddingRequest.Input
+		switch {
+		case input.Text != nil:
+			tokens = tokenizer.EstimateText(*input.Text)
+		case len(input.Texts) > 0:
+			for _, text := range input.Texts {
+				tokens += tokenizer.EstimateText(text)
+			}
+		case len(input.Embedding) > 0:
+			tokens = len(input.Embedding)
+		default:
+			for _, embedding := range input.Embeddings {
+				tokens += len(embedding)
+			}
+		}
+	default:
+		return 0, false
+	}
+	return tokens, true
+}
+
+// PostHook records the usage and output of every chunk, and completes the usage of the final chunk
+func (p *streamUsagePlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	state, ok := (*ctx).Value(streamUsageContextKey).(*streamUsage)
+	if !ok || result == nil {
+		return result, bifrostErr, nil
+	}
+	state.observe(result)
+	if bifrost.IsFinalChunk(ctx) {
+		state.complete(result)
+	}
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *streamUsagePlugin) Cleanup() error {
+	return nil
+}
+
+// observe keeps the highest usage reported for each count, the latest token details, and the generated text, tool calls and reasoning
+func (s *streamUsage) observe(chunk *schemas.BifrostResponse) {
+	if usage := chunk.Usage; usage != nil {
+		s.reported.PromptTokens = max(s.reported.PromptTokens, usage.PromptTokens)
+		s.reported.CompletionTokens = max(s.reported.CompletionTokens, usage.CompletionTokens)
+		s.reported.TotalTokens = max(s.reported.TotalTokens, usage.TotalTokens)
+		if usage.PromptTokensDetails != nil {
+			s.reported.PromptTokensDetails = usage.PromptTokensDetails
+		}
+		if usage.CompletionTokensDetails != nil {
+			s.reported.CompletionTokensDetails = usage.CompletionTokensDetails
+		}
+	}
+	for _, choice := range chunk.Choices {
+		if choice.BifrostTextCompletionResponseChoice != nil && choice.Text != nil {
+			s.output.WriteString(*choice.Text)
+		}
+		if choice.BifrostStreamResponseChoice == nil || choice.Delta == nil {
+			continue
+		}
+		for _, text := range []*string{choice.Delta.Content, choice.Delta.Thought, choice.Delta.Refusal} {
+			if text != nil {
+				s.output.WriteString(*text)
+			}
+		}
+		for _, toolCall := range choice.Delta.ToolCalls {
+			if toolCall.Function.Name != nil {
+				s.output.WriteString(*toolCall.Function.Name)
+			}
+			s.output.WriteString(toolCall.Function.Arguments)
+		}
+	}
+}
+
+// complete sets the usage of the final chunk, estimating the counts the provider did not report
+func (s *streamUsage) complete(chunk *schemas.BifrostResponse) {
+	usage := s.reported
+	if chunk.Usage != nil {
+		usage.ResponsesExtendedResponseUsage = chunk.Usage.ResponsesExtendedResponseUsage
+	}
+	estimated := false
+	if usage.PromptTokens == 0 && s.promptEstimate > 0 {
+		usage.PromptTokens = s.promptEstimate
+		estimated = true
+	}
+	if usage.CompletionTokens == 0 && s.output.Len() > 0 {
+		usage.CompletionTokens = tokenizer.EstimateText(s.output.String())
+		estimated = true
+	}
+	if usage.TotalTokens < usage.PromptTokens+usage.CompletionTokens {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	if usage.TotalTokens == 0 {
+		return
+	}
+	chunk.Usage = &usage
+	chunk.ExtraFields.UsageEstimated = estimated
+}
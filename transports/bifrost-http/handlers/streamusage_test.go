@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// runStream runs the chunks of a chat completion stream through the stream usage plugin and returns the final one
+func runStream(t *testing.T, input []schemas.ChatMessage, chunks ...*schemas.BifrostResponse) *schemas.BifrostResponse {
+	t.Helper()
+	plugin := &streamUsagePlugin{}
+	ctx := context.Background()
+	plugin.PreHook(&ctx, &schemas.BifrostRequest{
+		RequestType: schemas.ChatCompletionStreamRequest,
+		ChatRequest: &schemas.BifrostChatRequest{Provider: schemas.Ollama, Model: "llama3", Input: input},
+	})
+	for i, chunk := range chunks {
+		if i == len(chunks)-1 {
+			ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+		}
+		plugin.PostHook(&ctx, chunk, nil)
+	}
+	return chunks[len(chunks)-1]
+}
+
+// deltaChunk returns a stream chunk with content
+func deltaChunk(content string) *schemas.BifrostResponse {
+	return &schemas.BifrostResponse{Choices: []schemas.BifrostChatResponseChoice{{
+		BifrostStreamResponseChoice: &schemas.BifrostStreamResponseChoice{Delta: &schemas.BifrostStreamDelta{Content: schemas.Ptr(content)}},
+	}}}
+}
+
+// TestStreamUsage_EstimatesMissingUsage tests that a stream without usage ends with estimated usage
+func TestStreamUsage_EstimatesMissingUsage(t *testing.T) {
+	input := []schemas.ChatMessage{{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: schemas.Ptr("Write a haiku about the sea")}}}
+	final := &schemas.BifrostResponse{Usage: &schemas.LLMUsage{}, Choices: []schemas.BifrostChatResponseChoice{{FinishReason: schemas.Ptr("stop")}}}
+	runStream(t, input, deltaChunk("Waves fold into foam, "), deltaChunk("salt wind carries gull voices, "), deltaChunk("the tide keeps its time."), final)
+
+	// 27 characters of prompt, 4 tokens of framing and 3 priming the reply; 77 characters of output
+	if final.Usage == nil || final.Usage.PromptTokens != 14 || final.Usage.CompletionTokens != 20 || final.Usage.TotalTokens != 34 {
+		t.Fatalf("unexpected usage: %+v", final.Usage)
+	}
+	if !final.ExtraFields.UsageEstimated {
+		t.Error("expected the usage to be marked as estimated")
+	}
+}
+
+// TestStreamUsage_KeepsReportedUsage tests that usage reported by the provider is kept, even from an earlier chunk
+func TestStreamUsage_KeepsReportedUsage(t *testing.T) {
+	final := runStream(t, nil, deltaChunk("Hello"), &schemas.BifrostResponse{Usage: &schemas.LLMUsage{PromptTokens: 12, CompletionTokens: 2, TotalTokens: 14}})
+	if final.Usage.PromptTokens != 12 || final.Usage.CompletionTokens != 2 || final.Usage.TotalTokens != 14 || final.ExtraFields.UsageEstimated {
+		t.Errorf("expected the reported usage, got %+v (estimated: %v)", final.Usage, final.ExtraFields.UsageEstimated)
+	}
+
+	early := deltaChunk("Hello there")
+	early.Usage = &schemas.LLMUsage{PromptTokens: 40}
+	final = runStream(t, nil, early, deltaChunk("!"), &schemas.BifrostResponse{})
+	if final.Usage == nil || final.Usage.PromptTokens != 40 || final.Usage.CompletionTokens != 3 || final.Usage.TotalTokens != 43 || !final.ExtraFields.UsageEstimated {
+		t.Errorf("expected the reported prompt tokens and estimated completion tokens, got %+v", final.Usage)
+	}
+}
+
+// TestStreamUsage_IgnoresOtherRequests tests that non-streaming requests are left alone
+func TestStreamUsage_IgnoresOtherRequests(t *testing.T) {
+	plugin := &streamUsagePlugin{}
+	ctx := context.Background()
+	plugin.PreHook(&ctx, &schemas.BifrostRequest{RequestType: schemas.ChatCompletionRequest, ChatRequest: &schemas.BifrostChatRequest{}})
+	ctx = context.WithValue(ctx, schemas.BifrostContextKeyStreamEndIndicator, true)
+	result := deltaChunk("Hello")
+	plugin.PostHook(&ctx, result, nil)
+	if result.Usage != nil {
+		t.Errorf("expected no usage, got %+v", result.Usage)
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// fakeStripe records the meter events it receives, rejecting those of rejectCustomer
+type fakeStripe struct {
+	mu             sync.Mutex
+	events         []url.Values
+	rejectCustomer string
+}
+
+func (f *fakeStripe) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/billing/meter_events" || r.Header.Get("Authorization") != "Bearer sk_test" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("payload[stripe_customer_id]") == f.rejectCustomer {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"No such customer"}}`))
+		return
+	}
+	f.mu.Lock()
+	f.events = append(f.events, r.PostForm)
+	f.mu.Unlock()
+	w.Write([]byte(`{"object":"billing.meter_event"}`))
+}
+
+// newStripeTestHandler returns a billing handler exporting to a fake Stripe
+func newStripeTestHandler(t *testing.T, stripe *fakeStripe, dryRun bool) *BillingHandler {
+	t.Helper()
+	server := httptest.NewServer(stripe)
+	t.Cleanup(server.Close)
+	exporter := lib.NewStripeMeteringExporter(lib.StripeMeteringConfig{
+		Enabled: true,
+		APIKey:  "sk_test",
+		BaseURL: server.URL,
+		Meters: []lib.StripeMeter{
+			{EventName: "llm_requests", Metric: lib.StripeMetricRequests},
+			{EventName: "llm_cost", Metric: lib.StripeMetricCostCents},
+		},
+		Customers: map[string]string{"acme, inc": "cus_acme"},
+		DryRun:    dryRun,
+	}, newBillingTestStore(t), nil)
+	return NewBillingHandler(nil, exporter, bifrost.NewDefaultLogger(schemas.LogLevelError))
+}
+
+// stripeExportRequestCtx returns a request context for a POST of uri
+func stripeExportRequestCtx(uri string) *fasthttp.RequestCtx {
+	ctx := billingRequestCtx(uri)
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	return ctx
+}
+
+// TestStripeMetering_Export tests that mapped customers are sent, unmapped ones recorded, and that identifiers
+// are stable across re-exports of the same window
+func TestStripeMetering_Export(t *testing.T) {
+	stripe := &fakeStripe{}
+	handler := newStripeTestHandler(t, stripe, false)
+
+	for i := 0; i < 2; i++ {
+		ctx := stripeExportRequestCtx("/api/billing/stripe/export?start=2025-02-03&end=2025-02-03")
+		handler.exportToStripe(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+		var response StripeExportsResponse
+		if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		statuses := make(map[string]string)
+		for _, record := range response.Exports {
+			statuses[record.Customer+"/"+record.EventName] = record.Status
+		}
+		if statuses["acme, inc/llm_requests"] != lib.StripeExportSent || statuses["acme, inc/llm_cost"] != lib.StripeExportSent ||
+			statuses["globex/llm_requests"] != lib.StripeExportUnmapped {
+			t.Errorf("unexpected export outcomes: %v", statuses)
+		}
+	}
+
+	if len(stripe.events) != 4 {
+		t.Fatalf("expected 2 events per export, got %d", len(stripe.events))
+	}
+	values := make(map[string]string)
+	for _, event := range stripe.events[:2] {
+		if event.Get("payload[stripe_customer_id]") != "cus_acme" {
+			t.Errorf("unexpected customer %q", event.Get("payload[stripe_customer_id]"))
+		}
+		values[event.Get("event_name")] = event.Get("payload[value]")
+	}
+	if values["llm_requests"] != "2" || values["llm_cost"] != "75" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if stripe.events[0].Get("identifier") == "" || stripe.events[0].Get("identifier") != stripe.events[2].Get("identifier") {
+		t.Errorf("expected the same identifier when re-exporting a window, got %q and %q",
+			stripe.events[0].Get("identifier"), stripe.events[2].Get("identifier"))
+	}
+
+	ctx := billingRequestCtx("/api/billing/stripe/exports?limit=1")
+	handler.getStripeExports(ctx)
+	var recent StripeExportsResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &recent); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if recent.Count != 1 {
+		t.Errorf("expected 1 record, got %d", recent.Count)
+	}
+}
+
+// TestStripeMetering_DryRunAndReconciliation tests that dry runs send nothing and that reconciliation reports
+// the usage not exported yet
+func TestStripeMetering_DryRunAndReconciliation(t *testing.T) {
+	stripe := &fakeStripe{rejectCustomer: "cus_acme"}
+	handler := newStripeTestHandler(t, stripe, false)
+
+	ctx := stripeExportRequestCtx("/api/billing/stripe/export?start=2025-02-03&end=2025-02-03&dry_run=true")
+	handler.exportToStripe(ctx)
+	var response StripeExportsResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !response.DryRun || len(stripe.events) != 0 {
+		t.Errorf("expected a dry run without events, got %+v and %d events", response, len(stripe.events))
+	}
+
+	ctx = stripeExportRequestCtx("/api/billing/stripe/export?start=2025-02-03&end=2025-02-03")
+	handler.exportToStripe(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadGateway {
+		t.Fatalf("expected 502 when Stripe rejects events, got %d", ctx.Response.StatusCode())
+	}
+
+	ctx = billingRequestCtx("/api/billing/stripe/reconciliation?start=2025-02-01&end=2025-02-28")
+	handler.getStripeReconciliation(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	var reconciliation StripeReconciliationResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &reconciliation); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	lines := make(map[string]lib.StripeReconciliationLine)
+	for _, line := range reconciliation.Lines {
+		lines[line.Customer+"/"+line.EventName] = line
+	}
+	cost := lines["acme, inc/llm_cost"]
+	if cost.Usage != 75 || cost.Exported != 0 || cost.Difference != 75 || cost.FailedExports != 1 {
+		t.Errorf("unexpected reconciliation of acme: %+v", cost)
+	}
+	if !lines["globex/llm_requests"].UnmappedUsage || lines["globex/llm_requests"].Usage != 1 {
+		t.Errorf("expected globex to be unmapped, got %+v", lines["globex/llm_requests"])
+	}
+}
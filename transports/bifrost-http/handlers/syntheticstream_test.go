@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// syntheticStreamingTestAccount configures nostream, an OpenAI-based provider served by baseURL that is not allowed
+// to stream, with synthetic streaming
+type syntheticStreamingTestAccount struct {
+	baseURL   string
+	streaming *schemas.SyntheticStreamingConfig
+}
+
+func (a syntheticStreamingTestAccount) GetConfiguredProviders() ([]schemas.ModelProvider, error) {
+	return []schemas.ModelProvider{"nostream"}, nil
+}
+
+func (a syntheticStreamingTestAccount) GetKeysForProvider(ctx *context.Context, providerKey schemas.ModelProvider) ([]schemas.Key, error) {
+	return []schemas.Key{{ID: "nostream-key", Value: "sk-test", Weight: 1}}, nil
+}
+
+func (a syntheticStreamingTestAccount) GetConfigForProvider(providerKey schemas.ModelProvider) (*schemas.ProviderConfig, error) {
+	return &schemas.ProviderConfig{
+		NetworkConfig:            schemas.NetworkConfig{BaseURL: a.baseURL},
+		ConcurrencyAndBufferSize: schemas.DefaultConcurrencyAndBufferSize,
+		CustomProviderConfig: &schemas.CustomProviderConfig{
+			BaseProviderType: schemas.OpenAI,
+			AllowedRequests:  &schemas.AllowedRequests{ChatCompletion: true},
+		},
+		SyntheticStreaming: a.streaming,
+	}, nil
+}
+
+// TestSyntheticStreaming tests that the chat completion streams of a provider that cannot stream are synthesized from
+// a regular completion, chunked by words, and that they keep failing without synthetic streaming
+func TestSyntheticStreaming(t *testing.T) {
+	var streamed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		streamed, _ = body["stream"].(bool)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "chatcmpl-1", "object": "chat.completion", "model": "gpt-4o", "choices": [{"index": 0, "message": {"role": "assistant", "content": "one two three four five", "tool_calls": [{"id": "call-1", "type": "function", "function": {"name": "lookup", "arguments": "{}"}}]}, "finish_reason": "tool_calls"}], "usage": {"prompt_tokens": 2, "completion_tokens": 5, "total_tokens": 7}}`))
+	}))
+	defer server.Close()
+
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	stream := func(streaming *schemas.SyntheticStreamingConfig) *fasthttp.RequestCtx {
+		t.Helper()
+		client, err := bifrost.Init(context.Background(), schemas.BifrostConfig{
+			Account: syntheticStreamingTestAccount{baseURL: server.URL, streaming: streaming},
+			Logger:  logger,
+		})
+		if err != nil {
+			t.Fatalf("failed to init bifrost: %v", err)
+		}
+		t.Cleanup(client.Shutdown)
+		h := NewInferenceHandler(client, &lib.Config{}, logger)
+
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBody([]byte(`{"model": "nostream/gpt-4o", "messages": [{"role": "user", "content": "Count"}], "stream": true}`))
+		h.chatCompletion(ctx)
+		return ctx
+	}
+
+	ctx := stream(&schemas.SyntheticStreamingConfig{Enabled: true, ChunkWords: 2, IntervalMs: 1})
+	if streamed {
+		t.Errorf("expected the upstream request not to stream")
+	}
+	var content strings.Builder
+	var chunks []schemas.BifrostResponse
+	for _, event := range strings.Split(string(ctx.Response.Body()), "\n\n") {
+		data := strings.TrimPrefix(event, "data: ")
+		if data == "" || data == "[DONE]" {
+			continue
+		}
+		var chunk schemas.BifrostResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %s: %v", data, err)
+		}
+		if chunk.Choices[0].BifrostStreamResponseChoice == nil || chunk.Choices[0].Delta == nil {
+			t.Fatalf("chunk %s is not a chat completion chunk", data)
+		}
+		if chunk.Choices[0].Delta.Content != nil {
+			content.WriteString(*chunk.Choices[0].Delta.Content)
+		}
+		chunks = append(chunks, chunk)
+	}
+	// Three content chunks of up to two words, the tool calls and the final chunk
+	if len(chunks) != 5 || content.String() != "one two three four five" {
+		t.Fatalf("chunks = %+v, want the content in three chunks, the tool calls and the final chunk", chunks)
+	}
+	if first := chunks[0].Choices[0].Delta; first.Role == nil || *first.Content != "one two " {
+		t.Errorf("first chunk = %+v, want the assistant role and two words", first)
+	}
+	if toolCalls := chunks[3].Choices[0].Delta.ToolCalls; len(toolCalls) != 1 || *toolCalls[0].Function.Name != "lookup" {
+		t.Errorf("tool calls = %+v, want the lookup call", toolCalls)
+	}
+	final := chunks[4]
+	if final.Choices[0].FinishReason == nil || *final.Choices[0].FinishReason != "tool_calls" || final.Usage == nil || final.Usage.TotalTokens != 7 {
+		t.Errorf("final chunk = %+v, want the finish reason and the usage", final)
+	}
+	for i, chunk := range chunks {
+		if chunk.ID != "chatcmpl-1" || chunk.Model != "gpt-4o" {
+			t.Errorf("chunk %d = %+v, want the ID and model of the response", i, chunk)
+		}
+	}
+
+	// Without synthetic streaming the stream is refused
+	ctx = stream(nil)
+	if body := string(ctx.Response.Body()); !strings.Contains(body, "is not supported by nostream provider") {
+		t.Errorf("body = %s, want the unsupported operation error", body)
+	}
+}
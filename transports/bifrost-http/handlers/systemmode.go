@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// SystemModeHandler toggles maintenance and read-only mode.
+type SystemModeHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// SystemModeRequest is the body of PUT /api/system/mode. Omitted fields are left unchanged.
+type SystemModeRequest struct {
+	Maintenance       *bool   `json:"maintenance,omitempty"`
+	ReadOnly          *bool   `json:"read_only,omitempty"`
+	Message           *string `json:"message,omitempty"`
+	RetryAfterSeconds *int    `json:"retry_after_seconds,omitempty"`
+}
+
+// NewSystemModeHandler creates a new system mode handler.
+func NewSystemModeHandler(store *lib.Config, logger schemas.Logger) *SystemModeHandler {
+	return &SystemModeHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the system mode routes.
+func (h *SystemModeHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/system/mode", lib.ChainMiddlewares(h.getSystemMode, middlewares...))
+	r.PUT("/api/system/mode", lib.ChainMiddlewares(h.updateSystemMode, middlewares...))
+}
+
+// getSystemMode handles GET /api/system/mode - Get the maintenance and read-only toggles
+func (h *SystemModeHandler) getSystemMode(ctx *fasthttp.RequestCtx) {
+	SendJSON(ctx, h.store.GetSystemMode(), h.logger)
+}
+
+// updateSystemMode handles PUT /api/system/mode - Switch maintenance or read-only mode on or off
+// It stays available in read-only mode so the mode can be switched off again.
+func (h *SystemModeHandler) updateSystemMode(ctx *fasthttp.RequestCtx) {
+	var req SystemModeRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	mode := h.store.GetSystemMode()
+	if req.Maintenance != nil {
+		mode.Maintenance = *req.Maintenance
+	}
+	if req.ReadOnly != nil {
+		mode.ReadOnly = *req.ReadOnly
+	}
+	if req.Message != nil {
+		mode.Message = *req.Message
+	}
+	if req.RetryAfterSeconds != nil {
+		mode.RetryAfterSeconds = *req.RetryAfterSeconds
+	}
+	if err := mode.Validate(); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid system mode: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateSystemMode(ctx, mode); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update system mode: %v", err), h.logger)
+		return
+	}
+	h.logger.Info("system mode updated: maintenance=%t read_only=%t", mode.Maintenance, mode.ReadOnly)
+	SendJSON(ctx, h.store.GetSystemMode(), h.logger)
+}
+
+// MaintenanceMiddleware rejects inference requests with 503 and a Retry-After header while maintenance mode is on.
+// It is only chained on inference routes, so the management API and UI stay reachable.
+func MaintenanceMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			mode := config.GetSystemMode()
+			if !mode.Maintenance {
+				next(ctx)
+				return
+			}
+			message := mode.Message
+			if message == "" {
+				message = "Bifrost is under maintenance, retry later"
+			}
+			ctx.Response.Header.Set("Retry-After", strconv.Itoa(mode.RetryAfter()))
+			SendError(ctx, fasthttp.StatusServiceUnavailable, message, logger)
+		}
+	}
+}
+
+// ReadOnlyMiddleware rejects management API changes while read-only mode is on. Reads, inference requests,
+// sign-in, config validation and the system mode endpoint itself are passed through.
+func ReadOnlyMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			if !config.GetSystemMode().ReadOnly {
+				next(ctx)
+				return
+			}
+			method := string(ctx.Method())
+			path := string(ctx.Path())
+			if method == fasthttp.MethodGet || method == fasthttp.MethodHead || method == fasthttp.MethodOptions ||
+				!strings.HasPrefix(path, "/api/") || path == "/api/system/mode" || strings.HasPrefix(path, "/api/auth/") ||
+				path == "/api/config/validate" {
+				next(ctx)
+				return
+			}
+			SendError(ctx, fasthttp.StatusForbidden, "Bifrost is in read-only mode, configuration changes are disabled", logger)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestSystemMode tests that maintenance mode rejects inference with a Retry-After while read-only mode rejects
+// management changes, and that both can be switched off again through the API
+func TestSystemMode(t *testing.T) {
+	config := &lib.Config{}
+	handler := NewSystemModeHandler(config, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	ok := func(ctx *fasthttp.RequestCtx) { ctx.SetStatusCode(fasthttp.StatusOK) }
+	run := func(middleware lib.BifrostHTTPMiddleware, method, path string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(path)
+		middleware(ok)(ctx)
+		return ctx
+	}
+	update := func(body string) lib.SystemMode {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBody([]byte(body))
+		handler.updateSystemMode(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			t.Fatalf("update %s: got status %d: %s", body, ctx.Response.StatusCode(), ctx.Response.Body())
+		}
+		var mode lib.SystemMode
+		if err := json.Unmarshal(ctx.Response.Body(), &mode); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return mode
+	}
+
+	if ctx := run(MaintenanceMiddleware(config), fasthttp.MethodPost, "/v1/chat/completions"); ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("expected inference to be served outside maintenance, got %d", ctx.Response.StatusCode())
+	}
+
+	mode := update(`{"maintenance":true,"message":"Upgrading","retry_after_seconds":120}`)
+	if !mode.Maintenance || mode.ReadOnly || mode.UpdatedAt == nil {
+		t.Errorf("unexpected mode %+v", mode)
+	}
+	ctx := run(MaintenanceMiddleware(config), fasthttp.MethodPost, "/v1/chat/completions")
+	if ctx.Response.StatusCode() != fasthttp.StatusServiceUnavailable || string(ctx.Response.Header.Peek("Retry-After")) != "120" {
+		t.Errorf("expected 503 with Retry-After 120, got %d %q", ctx.Response.StatusCode(), ctx.Response.Header.Peek("Retry-After"))
+	}
+
+	mode = update(`{"maintenance":false,"read_only":true}`)
+	if mode.Maintenance || !mode.ReadOnly || mode.Message != "Upgrading" {
+		t.Errorf("expected omitted fields to be kept, got %+v", mode)
+	}
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{fasthttp.MethodPut, "/api/config", fasthttp.StatusForbidden},
+		{fasthttp.MethodDelete, "/api/providers/openai", fasthttp.StatusForbidden},
+		{fasthttp.MethodGet, "/api/config", fasthttp.StatusOK},
+		{fasthttp.MethodPost, "/v1/chat/completions", fasthttp.StatusOK},
+		{fasthttp.MethodPut, "/api/system/mode", fasthttp.StatusOK},
+		{fasthttp.MethodPost, "/api/auth/login", fasthttp.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := run(ReadOnlyMiddleware(config), tt.method, tt.path).Response.StatusCode(); got != tt.want {
+			t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, got, tt.want)
+		}
+	}
+
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetBody([]byte(`{"retry_after_seconds":-1}`))
+	handler.updateSystemMode(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("expected a negative Retry-After to be rejected, got %d", ctx.Response.StatusCode())
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/plugins/governance"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const systemPromptPluginName = "bifrost-system-prompt-policies"
+
+// SystemPromptPoliciesHandler manages the mandatory system prompt policies.
+type SystemPromptPoliciesHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// SystemPromptPoliciesRequest is the body of PUT /api/system-prompt-policies and the response of GET /api/system-prompt-policies.
+type SystemPromptPoliciesRequest struct {
+	Policies []lib.SystemPromptPolicy `json:"policies"`
+}
+
+// NewSystemPromptPoliciesHandler creates a new system prompt policies handler.
+func NewSystemPromptPoliciesHandler(store *lib.Config, logger schemas.Logger) *SystemPromptPoliciesHandler {
+	return &SystemPromptPoliciesHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the system prompt policy routes.
+func (h *SystemPromptPoliciesHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/system-prompt-policies", lib.ChainMiddlewares(h.getPolicies, middlewares...))
+	r.PUT("/api/system-prompt-policies", lib.ChainMiddlewares(h.updatePolicies, middlewares...))
+}
+
+// getPolicies handles GET /api/system-prompt-policies - Get the system prompt policies
+func (h *SystemPromptPoliciesHandler) getPolicies(ctx *fasthttp.RequestCtx) {
+	policies := h.store.GetSystemPromptPolicies()
+	if policies == nil {
+		policies = []lib.SystemPromptPolicy{}
+	}
+	SendJSON(ctx, SystemPromptPoliciesRequest{Policies: policies}, h.logger)
+}
+
+// updatePolicies handles PUT /api/system-prompt-policies - Replace the system prompt policies
+func (h *SystemPromptPoliciesHandler) updatePolicies(ctx *fasthttp.RequestCtx) {
+	var req SystemPromptPoliciesRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Policies == nil {
+		req.Policies = []lib.SystemPromptPolicy{}
+	}
+	if err := lib.ValidateSystemPromptPolicies(req.Policies); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid system prompt policies: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateSystemPromptPolicies(ctx, req.Policies); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update system prompt policies: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, req, h.logger)
+}
+
+// systemPromptPlugin injects the mandatory system prompt policies. It runs before the logging plugin,
+// so request logs show the effective system prompt the provider received.
+type systemPromptPlugin struct {
+	config *lib.Config
+	// governanceStore resolves the virtual key and team of a request (nil when governance is off)
+	governanceStore *governance.GovernanceStore
+}
+
+// GetName returns the name of the plugin
+func (p *systemPromptPlugin) GetName() string {
+	return systemPromptPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *systemPromptPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook injects the prefixes and suffixes of the policies matching the request
+func (p *systemPromptPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	policies := p.config.GetSystemPromptPolicies()
+	if len(policies) == 0 {
+		return req, nil, nil
+	}
+
+	injected, _ := lib.ApplySystemPromptPolicies(policies, p.subject(*ctx, req), req)
+	return injected, nil, nil
+}
+
+// subject resolves the virtual key and team of the request
+func (p *systemPromptPlugin) subject(ctx context.Context, req *schemas.BifrostRequest) lib.SystemPromptSubject {
+	virtualKeys, teams := resolveCaller(ctx, p.governanceStore)
+	return lib.SystemPromptSubject{VirtualKeys: virtualKeys, Teams: teams, Model: req.Model}
+}
+
+// resolveCaller returns the ID and name of the virtual key of a request and the IDs and names of its team,
+// from the virtual key or the x-bf-team header. governanceStore may be nil when governance is off.
+func resolveCaller(ctx context.Context, governanceStore *governance.GovernanceStore) (virtualKeys []string, teams []string) {
+	if team, ok := ctx.Value(governance.ContextKey("x-bf-team")).(string); ok && team != "" {
+		teams = append(teams, team)
+	}
+	vkValue, _ := ctx.Value(schemas.BifrostContextKeyVirtualKeyHeader).(string)
+	if vkValue == "" || governanceStore == nil {
+		return virtualKeys, teams
+	}
+	vk, ok := governanceStore.GetVirtualKey(vkValue)
+	if !ok {
+		return virtualKeys, teams
+	}
+	virtualKeys = append(virtualKeys, vk.ID, vk.Name)
+	if vk.TeamID != nil {
+		teams = append(teams, *vk.TeamID)
+	}
+	if vk.Team != nil {
+		teams = append(teams, vk.Team.Name)
+	}
+	return virtualKeys, teams
+}
+
+// PostHook is not used for this plugin
+func (p *systemPromptPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *systemPromptPlugin) Cleanup() error {
+	return nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TenantDomainsHandler manages the custom domains and branding of tenants.
+type TenantDomainsHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// NewTenantDomainsHandler creates a new tenant domains handler.
+func NewTenantDomainsHandler(store *lib.Config, logger schemas.Logger) *TenantDomainsHandler {
+	return &TenantDomainsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the tenant domains routes.
+func (h *TenantDomainsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/tenant-domains", lib.ChainMiddlewares(h.getTenantDomains, middlewares...))
+	r.PUT("/api/tenant-domains", lib.ChainMiddlewares(h.updateTenantDomains, middlewares...))
+}
+
+// getTenantDomains handles GET /api/tenant-domains - Get the tenant domains with their passwords redacted
+func (h *TenantDomainsHandler) getTenantDomains(ctx *fasthttp.RequestCtx) {
+	domains := h.store.GetTenantDomains()
+	response := make([]lib.TenantDomain, 0, len(domains))
+	for _, domain := range domains {
+		if domain.Password != "" && !strings.HasPrefix(domain.Password, "env.") {
+			domain.Password = lib.RedactKey(domain.Password)
+		}
+		response = append(response, domain)
+	}
+	SendJSON(ctx, response, h.logger)
+}
+
+// updateTenantDomains handles PUT /api/tenant-domains - Replace the tenant domains
+// Redacted passwords keep the password of the tenant domain with the same host.
+func (h *TenantDomainsHandler) updateTenantDomains(ctx *fasthttp.RequestCtx) {
+	var req []lib.TenantDomain
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req == nil {
+		req = []lib.TenantDomain{}
+	}
+	existing := h.store.GetTenantDomains()
+	for i := range req {
+		if !lib.IsRedacted(req[i].Password) || strings.HasPrefix(req[i].Password, "env.") {
+			continue
+		}
+		for _, domain := range existing {
+			if domain.Host == req[i].Host {
+				req[i].Password = domain.Password
+				break
+			}
+		}
+	}
+	if err := lib.ValidateTenantDomains(req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid tenant domains: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateTenantDomains(ctx, req); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update tenant domains: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, map[string]any{"status": "success", "message": "Tenant domains updated successfully"}, h.logger)
+}
+
+// TenantMiddleware resolves the tenant domain of the host a request was sent to and stores it in the request
+// context for the admin auth middleware, the login endpoint and the dashboard. Requests to a tenant domain with a
+// customer are attributed to it, replacing any x-bf-customer header sent by the caller.
+func TenantMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			if tenant := config.ResolveTenant(string(ctx.Host())); tenant != nil {
+				ctx.SetUserValue(lib.TenantContextKey, tenant)
+				if tenant.Customer != "" {
+					ctx.Request.Header.Set("x-bf-customer", tenant.Customer)
+				}
+			}
+			next(ctx)
+		}
+	}
+}
+
+// requestTenant returns the tenant domain resolved by TenantMiddleware, or nil.
+func requestTenant(ctx *fasthttp.RequestCtx) *lib.TenantDomain {
+	tenant, _ := ctx.UserValue(lib.TenantContextKey).(*lib.TenantDomain)
+	return tenant
+}
+
+var htmlTitlePattern = regexp.MustCompile(`(?s)<title>.*?</title>`)
+
+// injectBranding replaces the title of a dashboard page with the tenant's name and exposes its branding to the
+// dashboard scripts as window.__BIFROST_BRANDING__.
+func injectBranding(page []byte, branding lib.TenantBranding) []byte {
+	data, err := json.Marshal(branding)
+	if err != nil {
+		return page
+	}
+	title := []byte("<title>" + html.EscapeString(branding.Name) + "</title>")
+	page = htmlTitlePattern.ReplaceAllLiteral(page, title)
+	script := []byte("<script>window.__BIFROST_BRANDING__=" + string(data) + "</script></head>")
+	return bytes.Replace(page, []byte("</head>"), script, 1)
+}
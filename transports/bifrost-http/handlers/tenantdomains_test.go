@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+func newTenantConfig(t *testing.T) *lib.Config {
+	t.Helper()
+	config := &lib.Config{AdminSecret: "admin-secret", AdminCookieName: "bf_admin"}
+	err := config.UpdateTenantDomains(context.Background(), []lib.TenantDomain{
+		{Host: "ai.acme.com", Name: "Acme AI", Customer: "customer-acme", LogoURL: "https://cdn.acme.com/logo.png", Password: "acme-secret"},
+		{Host: "beta.example.com", Name: "Beta <AI>", Scopes: []serviceaccounts.Scope{serviceaccounts.ScopeConfigWrite}},
+	})
+	if err != nil {
+		t.Fatalf("failed to set tenant domains: %v", err)
+	}
+	return config
+}
+
+// tenantRequest runs a request sent to a host through the tenant and admin auth middlewares, returning the
+// x-bf-customer header seen by the handler, or the error status.
+func tenantRequest(config *lib.Config, host, method, path, cookie, customer string) (int, string) {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI(path)
+	ctx.Request.Header.SetHost(host)
+	if cookie != "" {
+		ctx.Request.Header.SetCookie("bf_admin", cookie)
+	}
+	if customer != "" {
+		ctx.Request.Header.Set("x-bf-customer", customer)
+	}
+	var seen string
+	TenantMiddleware(config)(AdminAuthMiddleware(config, bifrost.NewDefaultLogger(schemas.LogLevelError))(func(ctx *fasthttp.RequestCtx) {
+		seen = string(ctx.Request.Header.Peek("x-bf-customer"))
+		ctx.SetStatusCode(fasthttp.StatusOK)
+	}))(ctx)
+	return ctx.Response.StatusCode(), seen
+}
+
+// TestTenantMiddleware tests that requests are attributed to the customer of their host, that tenant sessions
+// are limited to the tenant's scopes and that cookies carrying a password instead of a session are rejected
+func TestTenantMiddleware(t *testing.T) {
+	config := newTenantConfig(t)
+	admin, err := config.CreateUISession(context.Background(), "", nil)
+	if err != nil {
+		t.Fatalf("failed to start the admin session: %v", err)
+	}
+	acme, err := config.CreateUISession(context.Background(), "Acme AI", lib.DefaultTenantScopes)
+	if err != nil {
+		t.Fatalf("failed to start the tenant session: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		host         string
+		method       string
+		path         string
+		cookie       string
+		wantStatus   int
+		wantCustomer string
+	}{
+		{"customer replaced on the tenant host", "AI.acme.com:8080", "GET", "/api/config", admin.ID, fasthttp.StatusOK, "customer-acme"},
+		{"customer kept on other hosts", "localhost:8080", "GET", "/api/config", admin.ID, fasthttp.StatusOK, "spoofed"},
+		{"tenant session reads the config", "ai.acme.com", "GET", "/api/config", acme.ID, fasthttp.StatusOK, "customer-acme"},
+		{"tenant session browses the dashboard", "ai.acme.com", "GET", "/logs", acme.ID, fasthttp.StatusOK, "customer-acme"},
+		{"tenant session lacks config:write", "ai.acme.com", "PUT", "/api/config", acme.ID, fasthttp.StatusForbidden, ""},
+		{"tenant session cannot manage service accounts", "ai.acme.com", "GET", "/api/service-accounts", acme.ID, fasthttp.StatusForbidden, ""},
+		{"inference stays public on the tenant host", "ai.acme.com", "POST", "/v1/chat/completions", "", fasthttp.StatusOK, "customer-acme"},
+		{"tenant session on another host", "localhost", "GET", "/api/config", acme.ID, fasthttp.StatusUnauthorized, ""},
+		{"admin session on the tenant host", "ai.acme.com", "PUT", "/api/config", admin.ID, fasthttp.StatusOK, "customer-acme"},
+		{"tenant password as cookie", "ai.acme.com", "GET", "/api/config", "acme-secret", fasthttp.StatusUnauthorized, ""},
+		{"admin secret as cookie", "localhost", "GET", "/api/config", "admin-secret", fasthttp.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, customer := tenantRequest(config, tt.host, tt.method, tt.path, tt.cookie, "spoofed")
+			if status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if customer != tt.wantCustomer {
+				t.Errorf("x-bf-customer = %q, want %q", customer, tt.wantCustomer)
+			}
+		})
+	}
+}
+
+// TestAuthHandler_TenantLogin tests that the tenant password signs in on the tenant host only and that the session
+// reports the tenant's scopes and branding
+func TestAuthHandler_TenantLogin(t *testing.T) {
+	config := newTenantConfig(t)
+	handler := NewAuthHandler(config, bifrost.NewDefaultLogger(schemas.LogLevelError))
+	login := func(host, password string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetRequestURI("/api/auth/login")
+		ctx.Request.Header.SetHost(host)
+		ctx.Request.SetBodyString(`{"password":"` + password + `"}`)
+		TenantMiddleware(config)(handler.login)(ctx)
+		return ctx
+	}
+
+	if ctx := login("localhost", "acme-secret"); ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Errorf("expected the tenant password to be rejected on other hosts, got %d", ctx.Response.StatusCode())
+	}
+	if ctx := login("beta.example.com", ""); ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("expected an empty password to be rejected on a tenant without one, got %d", ctx.Response.StatusCode())
+	}
+
+	ctx := login("ai.acme.com", "acme-secret")
+	var session AuthSession
+	if err := json.Unmarshal(ctx.Response.Body(), &session); err != nil {
+		t.Fatalf("invalid response: %s", ctx.Response.Body())
+	}
+	if !session.Authenticated || session.Method != AuthMethodTenant || session.Tenant != "Acme AI" || len(session.Scopes) != len(lib.DefaultTenantScopes) {
+		t.Errorf("unexpected session %+v", session)
+	}
+	if session.Branding == nil || session.Branding.LogoDarkURL != "https://cdn.acme.com/logo.png" {
+		t.Errorf("branding = %+v, want the tenant logo for both themes", session.Branding)
+	}
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+	cookie.SetKey("bf_admin")
+	if !ctx.Response.Header.Cookie(cookie) || len(cookie.Value()) == 0 {
+		t.Fatalf("expected a session cookie, got %q", cookie.String())
+	}
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetHost("ai.acme.com")
+	ctx.Request.Header.SetCookie("bf_admin", string(cookie.Value()))
+	TenantMiddleware(config)(handler.me)(ctx)
+	if err := json.Unmarshal(ctx.Response.Body(), &session); err != nil || session.Method != AuthMethodTenant {
+		t.Errorf("expected /api/auth/me to report the tenant session, got %s", ctx.Response.Body())
+	}
+}
+
+// TestInjectBranding tests that the tenant name replaces the page title and the branding is exposed to the scripts
+func TestInjectBranding(t *testing.T) {
+	page := injectBranding([]byte("<html><head><title>Bifrost</title></head><body></body></html>"), lib.TenantBranding{Name: "Beta <AI>", LogoURL: "https://cdn.example.com/</script>.png"})
+	if !strings.Contains(string(page), "<title>Beta &lt;AI&gt;</title>") {
+		t.Errorf("expected the escaped tenant name as title, got %s", page)
+	}
+	if !strings.Contains(string(page), `<script>window.__BIFROST_BRANDING__={"name":"Beta \u003cAI\u003e","logo_url":"https://cdn.example.com/\u003c/script\u003e.png"}</script></head>`) {
+		t.Errorf("expected the escaped branding before </head>, got %s", page)
+	}
+}
+
+// TestTenantDomainsHandler tests that passwords are redacted, kept when sent back redacted, and that invalid domains
+// are rejected
+func TestTenantDomainsHandler(t *testing.T) {
+	config := newTenantConfig(t)
+	h := NewTenantDomainsHandler(config, bifrost.NewDefaultLogger(schemas.LogLevelError))
+
+	ctx := &fasthttp.RequestCtx{}
+	h.getTenantDomains(ctx)
+	var domains []lib.TenantDomain
+	if err := json.Unmarshal(ctx.Response.Body(), &domains); err != nil || len(domains) != 2 {
+		t.Fatalf("unexpected response: %s", ctx.Response.Body())
+	}
+	if domains[0].Password == "acme-secret" || !lib.IsRedacted(domains[0].Password) {
+		t.Fatalf("password = %q, want it redacted", domains[0].Password)
+	}
+
+	domains[0].Name = "Acme Intelligence"
+	body, _ := json.Marshal(domains)
+	ctx = &fasthttp.RequestCtx{}
+	ctx.Request.SetBody(body)
+	h.updateTenantDomains(ctx)
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("update failed: %d %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	if tenant := config.ResolveTenant("ai.acme.com"); tenant == nil || tenant.Name != "Acme Intelligence" || tenant.LoginPassword() != "acme-secret" {
+		t.Errorf("expected the renamed tenant to keep its password, got %+v", tenant)
+	}
+
+	for _, body := range []string{
+		`[{"host":"ai.acme.com"}]`,
+		`[{"host":"https://ai.acme.com","name":"Acme"}]`,
+		`[{"host":"AI.acme.com","name":"Acme"}]`,
+		`[{"host":"ai.acme.com","name":"Acme"},{"host":"ai.acme.com","name":"Other"}]`,
+		`[{"host":"ai.acme.com","name":"Acme","scopes":["everything"]}]`,
+	} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetBodyString(body)
+		h.updateTenantDomains(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, ctx.Response.StatusCode())
+		}
+	}
+}
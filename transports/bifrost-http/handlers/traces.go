@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TraceMiddleware starts the trace of inference requests, POSTed outside /api/, and keeps their timeline once
+// answered. The request ID is set as the x-request-id header when missing so that the pipeline and the logs use
+// it, and returned in X-Request-Id. For streamed responses the timeline ends with the first byte. The content
+// of requests falling under zero data retention is left out of their timeline; retention may be nil when zero
+// data retention is only requested with the header.
+func TraceMiddleware(config *lib.Config, retention *zeroDataRetentionPlugin) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		if config.Traces == nil {
+			return next
+		}
+		return func(ctx *fasthttp.RequestCtx) {
+			path := string(ctx.Path())
+			if !ctx.IsPost() || strings.HasPrefix(path, "/api/") {
+				next(ctx)
+				return
+			}
+			requestID := string(ctx.Request.Header.Peek("x-request-id"))
+			if requestID == "" {
+				requestID = uuid.New().String()
+				ctx.Request.Header.Set("x-request-id", requestID)
+			}
+			ctx.Response.Header.Set("X-Request-Id", requestID)
+			trace := lib.NewRequestTrace(requestID, string(ctx.Method()), path)
+			ctx.SetUserValue(lib.RequestTraceContextKey, trace)
+			next(ctx)
+			// The middlewares have applied the identity of the caller to the headers by now
+			tenant := string(ctx.Request.Header.Peek("x-bf-vk"))
+			config.Traces.Finish(trace, ctx.Response.StatusCode(), tenant, isZeroDataRetention(ctx, retention))
+		}
+	}
+}
+
+// traced records the time mw spends on traced requests, and the changes it makes to their body, under name.
+// It returns mw unchanged when tracing is off.
+func traced(config *lib.Config, name string, mw lib.BifrostHTTPMiddleware) lib.BifrostHTTPMiddleware {
+	if config.Traces == nil {
+		return mw
+	}
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		handler := mw(func(ctx *fasthttp.RequestCtx) {
+			if trace, ok := ctx.UserValue(lib.RequestTraceContextKey).(*lib.RequestTrace); ok {
+				trace.PassMiddleware(ctx.Request.Body())
+			}
+			next(ctx)
+		})
+		return func(ctx *fasthttp.RequestCtx) {
+			trace, ok := ctx.UserValue(lib.RequestTraceContextKey).(*lib.RequestTrace)
+			if !ok {
+				handler(ctx)
+				return
+			}
+			trace.EnterMiddleware(name, ctx.Request.Body())
+			handler(ctx)
+			trace.ReturnMiddleware()
+		}
+	}
+}
+
+// TracesHandler serves the timelines of traced requests.
+type TracesHandler struct {
+	config *lib.Config
+	logger schemas.Logger
+}
+
+// NewTracesHandler creates a new traces handler.
+func NewTracesHandler(config *lib.Config, logger schemas.Logger) *TracesHandler {
+	return &TracesHandler{
+		config: config,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the traces routes.
+func (h *TracesHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/traces/{request_id}", lib.ChainMiddlewares(h.getTrace, middlewares...))
+}
+
+// getTrace handles GET /api/traces/{request_id}: the timeline of a request, with the time spent in each
+// middleware, the changes of the transport interceptors, the plugin hooks, provider attempts and fallbacks,
+// and the token usage.
+func (h *TracesHandler) getTrace(ctx *fasthttp.RequestCtx) {
+	if h.config.Traces == nil {
+		SendError(ctx, fasthttp.StatusNotFound, "Request tracing is not enabled", h.logger)
+		return
+	}
+	timeline, ok := h.config.Traces.Get(ctx.UserValue("request_id").(string))
+	if !ok {
+		SendError(ctx, fasthttp.StatusNotFound, "Trace not found, it may have been evicted", h.logger)
+		return
+	}
+	SendJSON(ctx, timeline, h.logger)
+}
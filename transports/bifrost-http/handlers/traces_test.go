@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/redaction"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestTraces_Timeline tests that traced requests record the middlewares they went through with the changes to
+// the body, the middleware that answered them, and the pipeline steps, and that the timeline is served by ID
+func TestTraces_Timeline(t *testing.T) {
+	config := &lib.Config{Traces: lib.NewTraceStore(lib.TracesConfig{Enabled: true, MaxTraces: 2})}
+	rewrite := func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.Request.SetBodyString(`{"model": "gpt-4o-mini", "messages": []}`)
+			next(ctx)
+		}
+	}
+	block := func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Request.Header.Peek("x-block")) != "" {
+				ctx.SetStatusCode(fasthttp.StatusForbidden)
+				return
+			}
+			next(ctx)
+		}
+	}
+	var requestID any
+	handler := lib.ChainMiddlewares(func(ctx *fasthttp.RequestCtx) {
+		bifrostCtx := *lib.ConvertToBifrostContext(ctx, false)
+		requestID = bifrostCtx.Value(schemas.BifrostContextKeyRequestID)
+		pipeline, _ := bifrostCtx.Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace)
+		pipeline.AddStep(schemas.PipelineTraceStep{Stage: schemas.PipelineTraceStageProvider, Provider: schemas.OpenAI, Model: "gpt-4o-mini", Attempt: 1, Latency: time.Millisecond,
+			Usage: &schemas.LLMUsage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4}})
+		pipeline.AddStep(schemas.PipelineTraceStep{Stage: schemas.PipelineTraceStageFallback, Provider: schemas.Anthropic, Model: "claude-3-5-haiku"})
+	}, TraceMiddleware(config, nil), traced(config, "block", block), traced(config, "rewrite", rewrite))
+
+	send := func(path string, headers map[string]string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetRequestURI(path)
+		ctx.Request.SetBodyString(`{"model": "gpt-4o", "messages": []}`)
+		for name, value := range headers {
+			ctx.Request.Header.Set(name, value)
+		}
+		handler(ctx)
+		return ctx
+	}
+	getTrace := func(id string) (*lib.RequestTimeline, int) {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.SetUserValue("request_id", id)
+		NewTracesHandler(config, logger).getTrace(ctx)
+		if ctx.Response.StatusCode() != fasthttp.StatusOK {
+			return nil, ctx.Response.StatusCode()
+		}
+		var timeline lib.RequestTimeline
+		if err := json.Unmarshal(ctx.Response.Body(), &timeline); err != nil {
+			t.Fatalf("invalid timeline: %v", err)
+		}
+		return &timeline, fasthttp.StatusOK
+	}
+
+	ctx := send("/v1/chat/completions", nil)
+	id := string(ctx.Response.Header.Peek("X-Request-Id"))
+	if id == "" || requestID != id {
+		t.Fatalf("request ID = %q in the response and %v in the pipeline, want the same generated ID", id, requestID)
+	}
+	timeline, status := getTrace(id)
+	if status != fasthttp.StatusOK {
+		t.Fatalf("status = %d, want the timeline of the request", status)
+	}
+	if len(timeline.Middlewares) != 2 || timeline.Middlewares[0].Name != "block" || len(timeline.Middlewares[0].Changes) != 0 ||
+		timeline.Middlewares[1].Name != "rewrite" || len(timeline.Middlewares[1].Changes) != 1 || timeline.Middlewares[1].Changes[0].Path != "model" ||
+		timeline.Middlewares[1].Changes[0].Old != "gpt-4o" || timeline.Middlewares[1].Changes[0].New != "gpt-4o-mini" {
+		t.Fatalf("middlewares = %+v, want the rewrite of the model", timeline.Middlewares)
+	}
+	summary := timeline.Summary
+	if summary.Attempts != 1 || summary.Fallbacks != 1 || summary.ProviderLatency != time.Millisecond || summary.Usage == nil ||
+		summary.Usage.TotalTokens != 4 || summary.StoppedBy != "" || len(timeline.Pipeline.Steps) != 2 {
+		t.Fatalf("summary = %+v, want the provider attempt, the fallback and the usage", summary)
+	}
+
+	ctx = send("/v1/chat/completions", map[string]string{"x-request-id": "blocked", "x-block": "1"})
+	timeline, _ = getTrace("blocked")
+	if timeline == nil || timeline.StatusCode != fasthttp.StatusForbidden || timeline.Summary.StoppedBy != "block" || len(timeline.Middlewares) != 1 || !timeline.Middlewares[0].Stopped {
+		t.Fatalf("timeline = %+v, want the request stopped by the block middleware", timeline)
+	}
+
+	// Management routes are not traced, and the oldest traces are evicted
+	send("/api/providers", map[string]string{"x-request-id": "management"})
+	send("/v1/chat/completions", map[string]string{"x-request-id": "latest"})
+	if _, status := getTrace("management"); status != fasthttp.StatusNotFound {
+		t.Errorf("status = %d, want 404 for a management request", status)
+	}
+	if _, status := getTrace(id); status != fasthttp.StatusNotFound {
+		t.Errorf("status = %d, want 404 for an evicted trace", status)
+	}
+	if _, status := getTrace("latest"); status != fasthttp.StatusOK {
+		t.Errorf("status = %d, want the latest trace", status)
+	}
+}
+
+// TestTraces_Content tests that the values changed by the middlewares and the upstream bodies kept in timelines are
+// redacted, and dropped for zero data retention requests
+func TestTraces_Content(t *testing.T) {
+	redactor, err := redaction.New(&redaction.Config{Enabled: true, Policy: redaction.Policy{
+		Detectors:  []redaction.Detector{redaction.DetectorEmail},
+		FieldPaths: []string{"model"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create redactor: %v", err)
+	}
+	config := &lib.Config{Traces: lib.NewTraceStore(lib.TracesConfig{Enabled: true, UpstreamBodies: true})}
+	config.Traces.SetRedactor(redactor)
+	rewrite := func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			ctx.Request.SetBodyString(`{"model": "gpt-4o-mini", "user": "jane@example.com"}`)
+			next(ctx)
+		}
+	}
+	handler := lib.ChainMiddlewares(func(ctx *fasthttp.RequestCtx) {
+		bifrostCtx := *lib.ConvertToBifrostContext(ctx, false)
+		pipeline, _ := bifrostCtx.Value(schemas.BifrostContextKeyPipelineTrace).(*schemas.PipelineTrace)
+		pipeline.RecordUpstream(&schemas.UpstreamExchange{
+			Method:       fasthttp.MethodPost,
+			URL:          "https://api.openai.com/v1/chat/completions",
+			RequestBody:  []byte(`{"messages":[{"role":"user","content":"write to jane@example.com"}]}`),
+			ResponseBody: []byte(`{"choices":[]}`),
+		})
+	}, TraceMiddleware(config, nil), traced(config, "rewrite", rewrite))
+
+	trace := func(requestID string, headers map[string]string) *lib.RequestTimeline {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+		ctx.Request.SetRequestURI("/v1/chat/completions")
+		ctx.Request.Header.Set("x-request-id", requestID)
+		for name, value := range headers {
+			ctx.Request.Header.Set(name, value)
+		}
+		ctx.Request.SetBodyString(`{"model": "gpt-4o"}`)
+		handler(ctx)
+		timeline, ok := config.Traces.Get(requestID)
+		if !ok || len(timeline.Middlewares) != 1 || len(timeline.Pipeline.Upstream) != 1 {
+			t.Fatalf("timeline = %+v, want the rewrite and the upstream exchange", timeline)
+		}
+		return timeline
+	}
+
+	changes := map[string]schemas.TraceChange{}
+	timeline := trace("redacted", nil)
+	for _, change := range timeline.Middlewares[0].Changes {
+		changes[change.Path] = change
+	}
+	if changes["model"].Old != redaction.DefaultReplacement || changes["model"].New != redaction.DefaultReplacement || changes["user"].New != redaction.DefaultReplacement {
+		t.Errorf("changes = %+v, want the model and the email redacted", timeline.Middlewares[0].Changes)
+	}
+	if body := string(timeline.Pipeline.Upstream[0].RequestBody); strings.Contains(body, "jane@example.com") || !strings.Contains(body, redaction.DefaultReplacement) {
+		t.Errorf("upstream request body = %s, want the email redacted", body)
+	}
+
+	timeline = trace("zero-data-retention", map[string]string{"x-bf-zero-data-retention": "true"})
+	if len(timeline.Middlewares[0].Changes) != 2 {
+		t.Fatalf("changes = %+v, want the changed paths", timeline.Middlewares[0].Changes)
+	}
+	for _, change := range timeline.Middlewares[0].Changes {
+		if change.Old != nil || change.New != nil {
+			t.Errorf("change = %+v, want no values for a zero data retention request", change)
+		}
+	}
+	if exchange := timeline.Pipeline.Upstream[0]; exchange.RequestBody != nil || exchange.ResponseBody != nil {
+		t.Errorf("upstream exchange = %+v, want no bodies for a zero data retention request", exchange)
+	}
+}
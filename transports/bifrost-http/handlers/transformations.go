@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TransformationsAppliedHeader lists the transformation rules applied to a request, in order.
+const TransformationsAppliedHeader = "x-bf-transformations"
+
+// TransformationsHandler manages the request transformation rules.
+type TransformationsHandler struct {
+	store  *lib.Config
+	logger schemas.Logger
+}
+
+// TransformationRulesRequest is the body of PUT /api/transformations and the response of GET /api/transformations.
+type TransformationRulesRequest struct {
+	Rules []lib.TransformationRule `json:"rules"`
+}
+
+// NewTransformationsHandler creates a new transformation rules handler.
+func NewTransformationsHandler(store *lib.Config, logger schemas.Logger) *TransformationsHandler {
+	return &TransformationsHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes registers the transformation rules routes.
+func (h *TransformationsHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.GET("/api/transformations", lib.ChainMiddlewares(h.getTransformations, middlewares...))
+	r.PUT("/api/transformations", lib.ChainMiddlewares(h.updateTransformations, middlewares...))
+}
+
+// getTransformations handles GET /api/transformations - Get the transformation rules
+func (h *TransformationsHandler) getTransformations(ctx *fasthttp.RequestCtx) {
+	rules := h.store.GetTransformationRules()
+	if rules == nil {
+		rules = []lib.TransformationRule{}
+	}
+	SendJSON(ctx, TransformationRulesRequest{Rules: rules}, h.logger)
+}
+
+// updateTransformations handles PUT /api/transformations - Replace the transformation rules
+func (h *TransformationsHandler) updateTransformations(ctx *fasthttp.RequestCtx) {
+	var req TransformationRulesRequest
+	if err := json.Unmarshal(ctx.PostBody(), &req); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid request format: %v", err), h.logger)
+		return
+	}
+	if req.Rules == nil {
+		req.Rules = []lib.TransformationRule{}
+	}
+	if err := lib.ValidateTransformationRules(req.Rules); err != nil {
+		SendError(ctx, fasthttp.StatusBadRequest, fmt.Sprintf("Invalid transformation rules: %v", err), h.logger)
+		return
+	}
+	if err := h.store.UpdateTransformationRules(ctx, req.Rules); err != nil {
+		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to update transformation rules: %v", err), h.logger)
+		return
+	}
+	SendJSON(ctx, req, h.logger)
+}
+
+// TransformationMiddleware applies the transformation rules to the JSON body of inference requests.
+// It runs before TransportInterceptorMiddleware, so plugins see the transformed request.
+// Management API requests and non-JSON bodies (e.g. multipart audio uploads) are passed through.
+func TransformationMiddleware(config *lib.Config) lib.BifrostHTTPMiddleware {
+	return func(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+		return func(ctx *fasthttp.RequestCtx) {
+			rules := config.GetTransformationRules()
+			path := string(ctx.Path())
+			if len(rules) == 0 || !ctx.IsPost() || strings.HasPrefix(path, "/api/") {
+				next(ctx)
+				return
+			}
+
+			var body map[string]any
+			if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil || body == nil {
+				next(ctx)
+				return
+			}
+
+			applied := lib.ApplyTransformationRules(rules, path, string(ctx.Request.Header.Peek("x-bf-vk")), body)
+			if len(applied) > 0 {
+				updatedBody, err := json.Marshal(body)
+				if err != nil {
+					SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to apply transformation rules: %v", err), logger)
+					return
+				}
+				ctx.Request.SetBody(updatedBody)
+				ctx.Response.Header.Set(TransformationsAppliedHeader, strings.Join(applied, ","))
+			}
+			next(ctx)
+		}
+	}
+}
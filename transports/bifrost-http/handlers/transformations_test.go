@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestTransformationMiddleware_AppliesMatchingRules tests that matching rules rewrite the body before the next handler runs
+func TestTransformationMiddleware_AppliesMatchingRules(t *testing.T) {
+	maxTemperature := 1.0
+	config := &lib.Config{}
+	err := config.UpdateTransformationRules(context.Background(), []lib.TransformationRule{
+		{
+			Name:  "clamp-temperature",
+			Match: lib.TransformationMatch{Models: []string{"gpt-4o*"}},
+			Operations: []lib.TransformationOperation{
+				{Op: lib.TransformationOpClamp, Field: "temperature", Max: &maxTemperature},
+				{Op: lib.TransformationOpDefault, Field: "params.top_p", Value: 0.9},
+			},
+		},
+		{
+			Name:  "strip-anthropic-seed",
+			Match: lib.TransformationMatch{Providers: []string{"anthropic"}},
+			Operations: []lib.TransformationOperation{
+				{Op: lib.TransformationOpRemove, Field: "seed"},
+			},
+		},
+		{
+			Name:  "team-prompt",
+			Match: lib.TransformationMatch{Paths: []string{"/v1/*"}, VirtualKeys: []string{"sk-bf-team"}},
+			Operations: []lib.TransformationOperation{
+				{Op: lib.TransformationOpSystemPrompt, Value: "Answer in English."},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to set rules: %v", err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/v1/chat/completions")
+	ctx.Request.Header.Set("x-bf-vk", "sk-bf-team")
+	ctx.Request.SetBody([]byte(`{"model":"openai/gpt-4o-mini","temperature":1.7,"seed":7,"messages":[{"role":"user","content":"hi"}]}`))
+
+	var body map[string]any
+	TransformationMiddleware(config)(func(ctx *fasthttp.RequestCtx) {
+		if err := json.Unmarshal(ctx.Request.Body(), &body); err != nil {
+			t.Fatalf("invalid body: %v", err)
+		}
+	})(ctx)
+
+	if body["temperature"] != 1.0 {
+		t.Errorf("expected temperature to be clamped to 1, got %v", body["temperature"])
+	}
+	if params, _ := body["params"].(map[string]any); params["top_p"] != 0.9 {
+		t.Errorf("expected params.top_p to default to 0.9, got %v", body["params"])
+	}
+	if body["seed"] != 7.0 {
+		t.Errorf("expected seed to be kept for openai, got %v", body["seed"])
+	}
+	messages, _ := body["messages"].([]any)
+	if len(messages) != 2 || messages[0].(map[string]any)["role"] != "system" {
+		t.Errorf("expected a system message to be prepended, got %v", messages)
+	}
+	if got := string(ctx.Response.Header.Peek(TransformationsAppliedHeader)); got != "clamp-temperature,team-prompt" {
+		t.Errorf("unexpected applied rules header %q", got)
+	}
+}
+
+// TestValidateTransformationRules tests that malformed rules are rejected
+func TestValidateTransformationRules(t *testing.T) {
+	lower, upper := 2.0, 1.0
+	cases := map[string][]lib.TransformationRule{
+		"missing name":   {{Operations: []lib.TransformationOperation{{Op: lib.TransformationOpRemove, Field: "seed"}}}},
+		"duplicate name": {{Name: "a", Operations: []lib.TransformationOperation{{Op: lib.TransformationOpRemove, Field: "seed"}}}, {Name: "a", Operations: []lib.TransformationOperation{{Op: lib.TransformationOpRemove, Field: "seed"}}}},
+		"no operations":  {{Name: "a"}},
+		"unknown op":     {{Name: "a", Operations: []lib.TransformationOperation{{Op: "rename", Field: "seed"}}}},
+		"missing field":  {{Name: "a", Operations: []lib.TransformationOperation{{Op: lib.TransformationOpSet, Value: 1}}}},
+		"inverted clamp": {{Name: "a", Operations: []lib.TransformationOperation{{Op: lib.TransformationOpClamp, Field: "temperature", Min: &lower, Max: &upper}}}},
+		"empty prompt":   {{Name: "a", Operations: []lib.TransformationOperation{{Op: lib.TransformationOpSystemPrompt}}}},
+	}
+	for name, rules := range cases {
+		t.Run(name, func(t *testing.T) {
+			if err := lib.ValidateTransformationRules(rules); err == nil {
+				t.Error("expected validation to fail")
+			}
+		})
+	}
+}
@@ -1,13 +1,11 @@
 package handlers
 
 import (
-	"embed"
-	"fmt"
-	"mime"
+	"bytes"
+	"io/fs"
+	"net/url"
 	"path"
-	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/fasthttp/router"
 	"github.com/maximhq/bifrost/core/schemas"
@@ -17,194 +15,220 @@ import (
 
 // UIHandler handles UI routes.
 type UIHandler struct {
-	uiContent embed.FS
+	uiContent fs.FS
+	assets    map[string]*uiAsset
+	routes    *uiRouteManifest            // Default bundle
+	locales   map[string]*uiRouteManifest // Localized bundles by locale
+	cache     *uiAssetCache
 	config    *lib.Config
 	logger    schemas.Logger
 }
 
 // NewUIHandler creates a new UIHandler instance.
-func NewUIHandler(uiContent embed.FS) *UIHandler {
+func NewUIHandler(uiContent fs.FS) *UIHandler {
+	return NewUIHandlerWithDeps(uiContent, nil, nil)
+}
+
+// NewUIHandlerWithDeps constructs UIHandler with config and logger dependencies. The assets, routes and locales
+// of uiContent are indexed once here.
+func NewUIHandlerWithDeps(uiContent fs.FS, config *lib.Config, logger schemas.Logger) *UIHandler {
+	assets := indexUIAssets(uiContent)
 	return &UIHandler{
 		uiContent: uiContent,
+		assets:    assets,
+		routes:    buildUIRoutes(assets, "ui"),
+		locales:   indexUILocales(assets),
+		cache:     newUIAssetCache(uiCacheMaxBytes),
+		config:    config,
+		logger:    logger,
 	}
 }
 
-// NewUIHandlerWithDeps constructs UIHandler with config and logger dependencies.
-func NewUIHandlerWithDeps(uiContent embed.FS, config *lib.Config, logger schemas.Logger) *UIHandler {
-	return &UIHandler{uiContent: uiContent, config: config, logger: logger}
-}
-
 // RegisterRoutes registers the UI routes with the provided router.
 func (h *UIHandler) RegisterRoutes(router *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
-	// Admin login/logout endpoints (public)
-	router.GET("/admin/login", h.loginPage)
-	router.POST("/admin/login", h.loginSubmit)
+	// Former admin login/logout pages (public), the dashboard signs in through /api/auth
+	router.GET("/admin/login", h.loginRedirect)
 	router.GET("/admin/logout", h.logout)
+	// Locales of the dashboard (public, the login page is localized too)
+	router.GET("/api/ui/locales", h.getLocales)
 	// UI routes (protected via AdminAuthMiddleware when wired globally)
 	router.GET("/", lib.ChainMiddlewares(h.serveDashboard, middlewares...))
 	router.GET("/{filepath:*}", lib.ChainMiddlewares(h.serveDashboard, middlewares...))
 }
 
 // ServeDashboard serves the dashboard UI.
+// Precompressed variants are served to clients accepting their encoding. Hot assets are served from memory and
+// large ones streamed from the embedded filesystem. Unknown pages get the exported 404 page with a 404 status.
+// The bundle of the negotiated locale is preferred, falling back to the default bundle for the files it lacks.
 func (h *UIHandler) serveDashboard(ctx *fasthttp.RequestCtx) {
-	// Get the request path
 	requestPath := string(ctx.Path())
-
-	// Clean the path to prevent directory traversal
-	cleanPath := path.Clean(requestPath)
-
-	// Handle .txt files (Next.js RSC payload files) - map from /{page}.txt to /{page}/index.txt
-	if strings.HasSuffix(cleanPath, ".txt") {
-		// Remove .txt extension and add /index.txt
-		basePath := strings.TrimSuffix(cleanPath, ".txt")
-		if basePath == "/" || basePath == "" {
-			basePath = "/index"
+	asset, found := h.resolveAsset(h.routes, requestPath)
+	if len(h.locales) > 0 {
+		ctx.Response.Header.Add("Vary", "Accept-Language, Cookie")
+		if routes, ok := h.locales[h.requestLocale(ctx)]; ok {
+			if localized, localizedFound := h.resolveAsset(routes, requestPath); localizedFound || (!found && localized != nil) {
+				asset, found = localized, localizedFound
+			}
 		}
-		cleanPath = basePath + "/index.txt"
 	}
-
-	// Remove leading slash and add ui prefix
-	if cleanPath == "/" {
-		cleanPath = "ui/index.html"
-	} else {
-		cleanPath = "ui" + cleanPath
+	if asset == nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		if strings.Contains(path.Base(requestPath), ".") {
+			ctx.SetBodyString("404 - Static asset not found: " + requestPath)
+		} else {
+			ctx.SetBodyString("404 - File not found")
+		}
+		return
 	}
-
-	// Check if this is a static asset request (has file extension)
-	hasExtension := strings.Contains(filepath.Base(cleanPath), ".")
-
-	// Try to read the file from embedded filesystem
-	data, err := h.uiContent.ReadFile(cleanPath)
-	if err != nil {
-
-		// If it's a static asset (has extension) and not found, return 404
-		if hasExtension {
+	ctx.SetContentType(asset.contentType)
+	ctx.Response.Header.Set("Cache-Control", asset.cacheControl)
+
+	// The 404 page is served at any depth: its asset paths are made relative to the request path, and the pages
+	// served on tenant domains are branded
+	tenant := requestTenant(ctx)
+	if !found || (tenant != nil && path.Ext(asset.name) == ".html") {
+		data, err := h.readAsset(asset.name, asset.size)
+		if err != nil {
 			ctx.SetStatusCode(fasthttp.StatusNotFound)
-			ctx.SetBodyString("404 - Static asset not found: " + requestPath)
+			ctx.SetBodyString("404 - File not found")
 			return
 		}
+		if !found {
+			ctx.SetStatusCode(fasthttp.StatusNotFound)
+			data = relocateUIPage(data, requestPath)
+		}
+		if tenant != nil {
+			data = injectBranding(data, tenant.Branding())
+		}
+		ctx.SetBody(data)
+		return
+	}
 
-		// For routes without extensions (SPA routing), try {path}/index.html first
-		if !hasExtension {
-			indexPath := cleanPath + "/index.html"
-			data, err = h.uiContent.ReadFile(indexPath)
-			if err == nil {
-				cleanPath = indexPath
-			} else {
-				// If that fails, serve root index.html as fallback
-				data, err = h.uiContent.ReadFile("ui/index.html")
-				if err != nil {
-					ctx.SetStatusCode(fasthttp.StatusNotFound)
-					ctx.SetBodyString("404 - File not found")
-					return
-				}
-				cleanPath = "ui/index.html"
+	name, size := asset.name, asset.size
+	if len(asset.variants) > 0 {
+		ctx.Response.Header.Add("Vary", "Accept-Encoding")
+		for _, e := range uiEncodings {
+			if variantSize, ok := asset.variants[e.encoding]; ok && ctx.Request.Header.HasAcceptEncoding(e.encoding) {
+				name, size = name+e.ext, variantSize
+				ctx.Response.Header.Set("Content-Encoding", e.encoding)
+				break
 			}
-		} else {
+		}
+	}
+
+	if size > uiCacheMaxEntryBytes {
+		file, err := h.uiContent.Open(name)
+		if err != nil {
 			ctx.SetStatusCode(fasthttp.StatusNotFound)
 			ctx.SetBodyString("404 - File not found")
 			return
 		}
+		// The file is closed once the body is sent
+		ctx.SetBodyStream(file, int(size))
+		return
+	}
+	data, err := h.readAsset(name, size)
+	if err != nil {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.SetBodyString("404 - File not found")
+		return
 	}
+	ctx.Response.SetBodyRaw(data)
+}
 
-	// Set content type based on file extension
-	ext := filepath.Ext(cleanPath)
-	contentType := mime.TypeByExtension(ext)
-	if contentType == "" {
-		contentType = "application/octet-stream"
+// resolveAsset returns the asset of a bundle serving a request path, and whether it was found. Paths without an
+// extension are dashboard routes, served by the {route}/index.html of a static page or the export folder of a matching
+// dynamic page; unknown routes resolve to the exported 404 page. The asset is nil when there is nothing to serve.
+func (h *UIHandler) resolveAsset(routes *uiRouteManifest, requestPath string) (*uiAsset, bool) {
+	// Clean the path to prevent directory traversal
+	cleanPath := path.Clean(requestPath)
+	// Localized bundles are only served through negotiation
+	if strings.HasPrefix(cleanPath, "/"+uiLocalesDir+"/") {
+		return routes.notFound, false
 	}
-	ctx.SetContentType(contentType)
+	root := routes.root
 
-	// Set cache headers for static assets
-	if strings.HasPrefix(cleanPath, "ui/_next/static/") {
-		ctx.Response.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
-	} else if ext == ".html" {
-		ctx.Response.Header.Set("Cache-Control", "no-cache")
-	} else {
-		ctx.Response.Header.Set("Cache-Control", "public, max-age=3600")
+	// Handle .txt files (Next.js RSC payload files) - map from /{page}.txt to /{page}/index.txt
+	page := "index.html"
+	if strings.HasSuffix(cleanPath, ".txt") {
+		if asset, ok := h.assets[root+cleanPath]; ok {
+			return asset, true
+		}
+		cleanPath = strings.TrimSuffix(cleanPath, ".txt")
+		if cleanPath == "/" || cleanPath == "" || cleanPath == "/index" {
+			cleanPath = "/"
+		}
+		page = "index.txt"
+	} else if cleanPath != "/" {
+		if asset, ok := h.assets[root+cleanPath]; ok {
+			return asset, true
+		}
+		if strings.Contains(path.Base(cleanPath), ".") {
+			return nil, false
+		}
 	}
 
-	// Send the file content
-	ctx.SetBody(data)
+	folder := root + strings.TrimSuffix(cleanPath, "/")
+	if asset, ok := h.assets[folder+"/"+page]; ok {
+		return asset, true
+	}
+	var segments []string
+	if cleanPath != "/" {
+		segments = strings.Split(strings.TrimPrefix(cleanPath, "/"), "/")
+	}
+	if folder, ok := routes.match(segments); ok {
+		if asset, ok := h.assets[folder+"/"+page]; ok {
+			return asset, true
+		}
+	}
+	if page != "index.html" {
+		return nil, false
+	}
+	return routes.notFound, false
 }
 
-// loginPage renders a simple password form with instructions.
-func (h *UIHandler) loginPage(ctx *fasthttp.RequestCtx) {
-	ctx.SetContentType("text/html; charset=utf-8")
-	next := string(ctx.QueryArgs().Peek("next"))
-	if next == "" {
-		next = "/"
-	}
-	body := fmt.Sprintf(`<!doctype html>
-<html><head><meta charset="utf-8"><title>Bifrost Admin Login</title>
-<style>body{font-family:system-ui,-apple-system,Segoe UI,Roboto,Ubuntu,Cantarell,Noto Sans,sans-serif;max-width:420px;margin:10vh auto;padding:24px}form{display:flex;flex-direction:column;gap:12px}input[type=password]{padding:10px;font-size:16px}button{padding:10px 14px;font-size:16px;cursor:pointer}</style>
-</head><body>
-<h2>Admin Login</h2>
-<p>To obtain the admin password, run <code>operator bifrost password</code> locally.</p>
-<form method="post" action="/admin/login">
-  <input type="hidden" name="next" value="%s" />
-  <label>Password</label>
-  <input type="password" name="password" autofocus required />
-  <button type="submit">Sign in</button>
-</form>
-</body></html>`, next)
-	ctx.SetStatusCode(fasthttp.StatusOK)
-	ctx.SetBodyString(body)
+// relocateUIPage rewrites the asset paths of an exported page, made relative to the root by fix-paths.js, to be
+// relative to the request path instead.
+func relocateUIPage(page []byte, requestPath string) []byte {
+	depth := strings.Count(requestPath, "/") - 1
+	if depth <= 0 {
+		return page
+	}
+	return bytes.ReplaceAll(page, []byte(`"./_next/`), []byte(`"`+strings.Repeat("../", depth)+"_next/"))
 }
 
-// loginSubmit validates password and sets admin cookie.
-func (h *UIHandler) loginSubmit(ctx *fasthttp.RequestCtx) {
-	if h.config == nil || strings.TrimSpace(h.config.AdminSecret) == "" {
-		SendError(ctx, fasthttp.StatusServiceUnavailable, "admin auth not configured", h.logger)
-		return
+// readAsset returns the content of an embedded file from the cache, reading and caching it on a miss. The
+// content must not be modified.
+func (h *UIHandler) readAsset(name string, size int64) ([]byte, error) {
+	if data, ok := h.cache.get(name); ok {
+		return data, nil
 	}
-	// Read form-encoded body
-	password := string(ctx.PostArgs().Peek("password"))
-	next := string(ctx.PostArgs().Peek("next"))
-	if password == "" {
-		SendError(ctx, fasthttp.StatusBadRequest, "password is required", h.logger)
-		return
+	data, err := fs.ReadFile(h.uiContent, name)
+	if err != nil {
+		return nil, err
 	}
-	if password != h.config.AdminSecret {
-		// Re-render with error
-		ctx.SetContentType("text/html; charset=utf-8")
-		ctx.SetStatusCode(fasthttp.StatusUnauthorized)
-		ctx.SetBodyString(`<html><body><p>Invalid password</p><a href="/admin/login">Try again</a></body></html>`)
-		return
+	if size <= uiCacheMaxEntryBytes {
+		h.cache.add(name, data)
 	}
-	// Set cookie; HttpOnly; Path=/; no explicit Max-Age (session cookie)
-	cookieName := h.config.AdminCookieName
-	if cookieName == "" {
-		cookieName = "bf_admin"
-	}
-	var c fasthttp.Cookie
-	c.SetKey(cookieName)
-	c.SetValue(h.config.AdminSecret)
-	c.SetPath("/")
-	c.SetHTTPOnly(true)
-	ctx.Response.Header.SetCookie(&c)
-	// Redirect to next
-	if next == "" || strings.Contains(next, "://") {
-		next = "/"
-	}
-	ctx.Response.Header.Set("Location", next)
+	return data, nil
+}
+
+// loginRedirect sends the former /admin/login page to the dashboard login page, keeping the next parameter.
+func (h *UIHandler) loginRedirect(ctx *fasthttp.RequestCtx) {
+	location := "/login"
+	if next := string(ctx.QueryArgs().Peek("next")); next != "" {
+		location += "?next=" + url.QueryEscape(safeRedirect(next))
+	}
+	ctx.Response.Header.Set("Location", location)
 	ctx.SetStatusCode(fasthttp.StatusFound)
 }
 
-// logout clears the admin cookie.
+// logout ends the dashboard session and returns to the login page.
 func (h *UIHandler) logout(ctx *fasthttp.RequestCtx) {
-	cookieName := "bf_admin"
-	if h.config != nil && strings.TrimSpace(h.config.AdminCookieName) != "" {
-		cookieName = h.config.AdminCookieName
-	}
-	// Expire cookie
-	var c fasthttp.Cookie
-	c.SetKey(cookieName)
-	c.SetValue("")
-	c.SetPath("/")
-	c.SetExpire(time.Unix(0, 0))
-	c.SetMaxAge(-1)
-	ctx.Response.Header.SetCookie(&c)
-	ctx.Response.Header.Set("Location", "/admin/login")
+	if c := ctx.Request.Header.Cookie(adminCookieName(h.config)); len(c) > 0 && h.config != nil {
+		h.config.DeleteUISession(ctx, string(c))
+	}
+	clearAdminCookie(ctx, h.config)
+	ctx.Response.Header.Set("Location", "/login")
 	ctx.SetStatusCode(fasthttp.StatusFound)
 }
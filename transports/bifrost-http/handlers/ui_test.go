@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/valyala/fasthttp"
+)
+
+// TestUIHandler_ServeDashboard tests that dashboard routes resolve to their static or dynamic pages, that unknown
+// routes get the 404 page, that precompressed variants
+// are served to clients accepting them, that hot assets are cached and that large assets are streamed
+func TestUIHandler_ServeDashboard(t *testing.T) {
+	large := bytes.Repeat([]byte("a"), uiCacheMaxEntryBytes+1)
+	content := fstest.MapFS{
+		"ui/index.html":                    {Data: []byte("<html><head><title>Bifrost</title></head>root</html>")},
+		"ui/logs/index.html":               {Data: []byte("logs page")},
+		"ui/logs/index.txt":                {Data: []byte("logs payload")},
+		"ui/404.html":                      {Data: []byte(`<script src="./_next/static/chunks/app.js"></script>not found`)},
+		"ui/teams/[id]/index.html":         {Data: []byte("team page")},
+		"ui/teams/[id]/index.txt":          {Data: []byte("team payload")},
+		"ui/teams/new/index.html":          {Data: []byte("new team page")},
+		"ui/docs/[...slug]/index.html":     {Data: []byte("docs page")},
+		"ui/_next/static/chunks/app.js":    {Data: []byte("console.log('app')")},
+		"ui/_next/static/chunks/app.js.br": {Data: []byte("brotli")},
+		"ui/_next/static/chunks/app.js.gz": {Data: []byte("gzip")},
+		"ui/_next/static/chunks/large.js":  {Data: large},
+	}
+	h := NewUIHandler(content)
+
+	serve := func(path, acceptEncoding string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		if acceptEncoding != "" {
+			ctx.Request.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		h.serveDashboard(ctx)
+		return ctx
+	}
+
+	tests := []struct {
+		path           string
+		acceptEncoding string
+		status         int
+		body           string
+		encoding       string
+	}{
+		{"/", "", fasthttp.StatusOK, "<html><head><title>Bifrost</title></head>root</html>", ""},
+		{"/logs", "", fasthttp.StatusOK, "logs page", ""},
+		{"/logs.txt", "", fasthttp.StatusOK, "logs payload", ""},
+		{"/teams/t1", "", fasthttp.StatusOK, "team page", ""},
+		{"/teams/t1.txt", "", fasthttp.StatusOK, "team payload", ""},
+		{"/teams/new", "", fasthttp.StatusOK, "new team page", ""},
+		{"/teams/t1/members", "", fasthttp.StatusNotFound, "", ""},
+		{"/docs/guides/setup", "", fasthttp.StatusOK, "docs page", ""},
+		{"/docs", "", fasthttp.StatusNotFound, "", ""},
+		{"/providers/openai", "", fasthttp.StatusNotFound, `<script src="../_next/static/chunks/app.js"></script>not found`, ""},
+		{"/missing", "", fasthttp.StatusNotFound, `<script src="./_next/static/chunks/app.js"></script>not found`, ""},
+		{"/missing.txt", "", fasthttp.StatusNotFound, "", ""},
+		{"/_next/static/chunks/app.js", "", fasthttp.StatusOK, "console.log('app')", ""},
+		{"/_next/static/chunks/app.js", "gzip, deflate, br", fasthttp.StatusOK, "brotli", "br"},
+		{"/_next/static/chunks/app.js", "gzip", fasthttp.StatusOK, "gzip", "gzip"},
+		{"/_next/static/chunks/app.js.gz", "", fasthttp.StatusNotFound, "", ""},
+		{"/_next/static/chunks/missing.js", "", fasthttp.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		ctx := serve(tt.path, tt.acceptEncoding)
+		if ctx.Response.StatusCode() != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, ctx.Response.StatusCode(), tt.status)
+			continue
+		}
+		if tt.body == "" {
+			continue
+		}
+		if body := string(ctx.Response.Body()); body != tt.body {
+			t.Errorf("%s (%s): body = %q, want %q", tt.path, tt.acceptEncoding, body, tt.body)
+		}
+		if encoding := string(ctx.Response.Header.Peek("Content-Encoding")); encoding != tt.encoding {
+			t.Errorf("%s (%s): Content-Encoding = %q, want %q", tt.path, tt.acceptEncoding, encoding, tt.encoding)
+		}
+	}
+
+	ctx := serve("/_next/static/chunks/app.js", "br")
+	if vary := string(ctx.Response.Header.Peek("Vary")); vary != "Accept-Encoding" {
+		t.Errorf("Vary = %q, want Accept-Encoding", vary)
+	}
+	if cacheControl := string(ctx.Response.Header.Peek("Cache-Control")); !strings.Contains(cacheControl, "immutable") {
+		t.Errorf("Cache-Control = %q, want hashed assets to be immutable", cacheControl)
+	}
+	if _, ok := h.cache.get("ui/_next/static/chunks/app.js.br"); !ok {
+		t.Errorf("expected the served variant to be cached")
+	}
+
+	ctx = serve("/_next/static/chunks/large.js", "")
+	if !ctx.Response.IsBodyStream() || ctx.Response.Header.ContentLength() != len(large) {
+		t.Errorf("expected the large asset to be streamed with its length, got content length %d", ctx.Response.Header.ContentLength())
+	}
+	if _, ok := h.cache.get("ui/_next/static/chunks/large.js"); ok {
+		t.Errorf("expected the large asset not to be cached")
+	}
+}
+
+// TestUIAssetCache tests that the least recently used assets are evicted beyond the capacity of the cache
+func TestUIAssetCache(t *testing.T) {
+	cache := newUIAssetCache(10)
+	cache.add("a", []byte("1234"))
+	cache.add("b", []byte("1234"))
+	cache.get("a")
+	cache.add("c", []byte("1234"))
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("expected the least recently used entry to be evicted")
+	}
+	if _, ok := cache.get("a"); !ok {
+		t.Errorf("expected the recently used entry to be kept")
+	}
+	cache.add("d", []byte("12345678901"))
+	if _, ok := cache.get("d"); ok || cache.size != 8 {
+		t.Errorf("expected an entry larger than the cache not to be cached, size %d", cache.size)
+	}
+}
+
+// TestUIHandler_Locales tests that the bundle of the locale chosen by the cookie or Accept-Language is served,
+// falling back to the default bundle for the files it lacks, and that the locales are listed
+func TestUIHandler_Locales(t *testing.T) {
+	content := fstest.MapFS{
+		"ui/index.html":                  {Data: []byte("home")},
+		"ui/logs/index.html":             {Data: []byte("logs")},
+		"ui/404.html":                    {Data: []byte("not found")},
+		"ui/_locales/fr/index.html":      {Data: []byte("accueil")},
+		"ui/_locales/fr/404.html":        {Data: []byte("introuvable")},
+		"ui/_locales/pt-br/index.html":   {Data: []byte("início")},
+		"ui/_locales/pt-br/logs/x.js":    {Data: []byte("pt")},
+		"ui/_locales/de/README.md":       {Data: []byte("not a bundle")},
+		"ui/_next/static/chunks/main.js": {Data: []byte("main")},
+	}
+	h := NewUIHandler(content)
+
+	tests := []struct {
+		path           string
+		cookie         string
+		acceptLanguage string
+		status         int
+		body           string
+	}{
+		{"/", "", "", fasthttp.StatusOK, "home"},
+		{"/", "", "fr-CA,fr;q=0.9,en;q=0.8", fasthttp.StatusOK, "accueil"},
+		{"/", "", "de, en;q=0.9, fr;q=0.8", fasthttp.StatusOK, "home"},
+		{"/", "", "en;q=0.5, pt-BR", fasthttp.StatusOK, "início"},
+		{"/", "fr", "pt-BR", fasthttp.StatusOK, "accueil"},
+		{"/", "en", "fr", fasthttp.StatusOK, "home"},
+		{"/", "xx", "fr", fasthttp.StatusOK, "accueil"},
+		{"/logs", "fr", "", fasthttp.StatusOK, "logs"},
+		{"/missing", "fr", "", fasthttp.StatusNotFound, "introuvable"},
+		{"/missing", "pt-br", "", fasthttp.StatusNotFound, "not found"},
+		{"/_next/static/chunks/main.js", "fr", "", fasthttp.StatusOK, "main"},
+		{"/_locales/fr/index.html", "", "", fasthttp.StatusNotFound, "not found"},
+	}
+	for _, tt := range tests {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(tt.path)
+		if tt.cookie != "" {
+			ctx.Request.Header.SetCookie(uiLocaleCookie, tt.cookie)
+		}
+		if tt.acceptLanguage != "" {
+			ctx.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		h.serveDashboard(ctx)
+		if status, body := ctx.Response.StatusCode(), string(ctx.Response.Body()); status != tt.status || body != tt.body {
+			t.Errorf("%s (cookie %q, Accept-Language %q) = %d %q, want %d %q", tt.path, tt.cookie, tt.acceptLanguage, status, body, tt.status, tt.body)
+		}
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set("Accept-Language", "pt-BR")
+	h.getLocales(ctx)
+	var response UILocalesResponse
+	if err := json.Unmarshal(ctx.Response.Body(), &response); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if strings.Join(response.Locales, ",") != "en,fr,pt-br" || response.Default != "en" || response.Current != "pt-br" {
+		t.Errorf("unexpected locales %+v", response)
+	}
+}
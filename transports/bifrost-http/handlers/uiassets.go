@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"container/list"
+	"io/fs"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	uiCacheMaxBytes      = 32 << 20 // Bytes of hot assets kept in memory
+	uiCacheMaxEntryBytes = 1 << 20  // Larger assets are always streamed from the embedded filesystem
+)
+
+// uiEncodings are the content encodings of precompressed variants, most preferred first. A variant is the
+// compressed file next to its asset, e.g. app.js.br for app.js.
+var uiEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// uiAsset is a file of the embedded dashboard, indexed when the UI handler is created.
+type uiAsset struct {
+	name         string // Path in the embedded filesystem, e.g. ui/_next/static/chunks/app.js
+	size         int64
+	contentType  string
+	cacheControl string
+	variants     map[string]int64 // Sizes of the precompressed variants, by content encoding
+}
+
+// indexUIAssets indexes the files under ui/ of the embedded filesystem by path. Precompressed variants are
+// attached to their asset instead of being served on their own. The index is empty when there is no UI.
+func indexUIAssets(content fs.FS) map[string]*uiAsset {
+	assets := make(map[string]*uiAsset)
+	if content == nil {
+		return assets
+	}
+	_ = fs.WalkDir(content, "ui", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		assets[name] = &uiAsset{
+			name:         name,
+			size:         info.Size(),
+			contentType:  uiContentType(name),
+			cacheControl: uiCacheControl(name),
+		}
+		return nil
+	})
+	for name, asset := range assets {
+		for _, e := range uiEncodings {
+			original, ok := assets[strings.TrimSuffix(name, e.ext)]
+			if !strings.HasSuffix(name, e.ext) || !ok {
+				continue
+			}
+			if original.variants == nil {
+				original.variants = make(map[string]int64, len(uiEncodings))
+			}
+			original.variants[e.encoding] = asset.size
+			delete(assets, name)
+		}
+	}
+	return assets
+}
+
+// uiRouteManifest lists the pages of the exported dashboard. Static pages are the {route}/index.html files of the
+// index; dynamic pages are export folders with [param], [...param] or [[...param]] segments, as Next.js names them.
+type uiRouteManifest struct {
+	root     string    // Folder of the exported bundle, ui or ui/_locales/{locale}
+	dynamic  []uiRoute // Most specific first
+	notFound *uiAsset  // Exported 404 page, nil when the export has none
+}
+
+// uiRoute is a dynamic page of the dashboard.
+type uiRoute struct {
+	folder   string   // Export folder of the page, e.g. ui/providers/[provider]
+	segments []string // Route segments of the folder, without the root of the bundle
+}
+
+// Kinds of route segments, in the order Next.js prefers them when several routes match
+const (
+	uiSegmentStatic = iota
+	uiSegmentDynamic
+	uiSegmentCatchAll
+	uiSegmentOptionalCatchAll
+)
+
+// buildUIRoutes builds the route manifest of the bundle exported to root. Localized bundles under root are not
+// part of it.
+func buildUIRoutes(assets map[string]*uiAsset, root string) *uiRouteManifest {
+	manifest := &uiRouteManifest{root: root, notFound: assets[root+"/404.html"]}
+	if manifest.notFound == nil {
+		manifest.notFound = assets[root+"/404/index.html"]
+	}
+	for name := range assets {
+		if path.Base(name) != "index.html" || !strings.Contains(name, "[") || !strings.HasPrefix(name, root+"/") ||
+			strings.HasPrefix(name, root+"/"+uiLocalesDir+"/") {
+			continue
+		}
+		folder := path.Dir(name)
+		manifest.dynamic = append(manifest.dynamic, uiRoute{
+			folder:   folder,
+			segments: strings.Split(strings.TrimPrefix(folder, root+"/"), "/"),
+		})
+	}
+	sort.Slice(manifest.dynamic, func(i, j int) bool {
+		a, b := manifest.dynamic[i].segments, manifest.dynamic[j].segments
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if ka, kb := uiSegmentKind(a[k]), uiSegmentKind(b[k]); ka != kb {
+				return ka < kb
+			}
+		}
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return strings.Join(a, "/") < strings.Join(b, "/")
+	})
+	return manifest
+}
+
+// match returns the export folder of the dynamic page serving a route, given as its segments.
+func (m *uiRouteManifest) match(segments []string) (string, bool) {
+	for _, route := range m.dynamic {
+		if route.matches(segments) {
+			return route.folder, true
+		}
+	}
+	return "", false
+}
+
+// matches reports whether the route serves the given segments. Catch-all segments end a route.
+func (r uiRoute) matches(segments []string) bool {
+	for i, segment := range r.segments {
+		switch uiSegmentKind(segment) {
+		case uiSegmentCatchAll:
+			return len(segments) > i
+		case uiSegmentOptionalCatchAll:
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if uiSegmentKind(segment) == uiSegmentStatic && segment != segments[i] {
+			return false
+		}
+	}
+	return len(segments) == len(r.segments)
+}
+
+// uiSegmentKind returns the kind of a segment of an export folder.
+func uiSegmentKind(segment string) int {
+	switch {
+	case strings.HasPrefix(segment, "[[...") && strings.HasSuffix(segment, "]]"):
+		return uiSegmentOptionalCatchAll
+	case strings.HasPrefix(segment, "[...") && strings.HasSuffix(segment, "]"):
+		return uiSegmentCatchAll
+	case strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]"):
+		return uiSegmentDynamic
+	}
+	return uiSegmentStatic
+}
+
+// uiContentType returns the content type of an asset from its extension.
+func uiContentType(name string) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// uiCacheControl returns the Cache-Control header of an asset: hashed Next.js assets never change, pages are
+// revalidated on every load.
+func uiCacheControl(name string) string {
+	switch {
+	case strings.HasPrefix(name, "ui/_next/static/"):
+		return "public, max-age=31536000, immutable"
+	case path.Ext(name) == ".html":
+		return "no-cache"
+	}
+	return "public, max-age=3600"
+}
+
+// uiAssetCache keeps the contents of hot assets in memory, evicting the least recently used ones.
+type uiAssetCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	entries  map[string]*list.Element
+	order    *list.List // Most recently used first
+}
+
+// uiCacheEntry is the content of a cached file.
+type uiCacheEntry struct {
+	name string
+	data []byte
+}
+
+// newUIAssetCache creates a cache holding up to maxBytes of file contents.
+func newUIAssetCache(maxBytes int) *uiAssetCache {
+	return &uiAssetCache{
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns the cached content of a file. The content must not be modified.
+func (c *uiAssetCache) get(name string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[name]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*uiCacheEntry).data, true
+}
+
+// add caches the content of a file, evicting the least recently used files beyond the capacity of the cache.
+func (c *uiAssetCache) add(name string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[name]; ok || len(data) > c.maxBytes {
+		return
+	}
+	c.entries[name] = c.order.PushFront(&uiCacheEntry{name: name, data: data})
+	c.size += len(data)
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*uiCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.name)
+		c.size -= len(entry.data)
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	uiLocalesDir     = "_locales"  // Localized bundles are exported under ui/_locales/{locale}/
+	uiDefaultLocale  = "en"        // Locale of the bundle at the root of ui/
+	uiLocaleCookie   = "bf_locale" // Cookie choosing a locale over Accept-Language
+	uiLocaleMaxCount = 16          // Languages of Accept-Language considered, most preferred first
+)
+
+// UILocalesResponse is the response of GET /api/ui/locales.
+type UILocalesResponse struct {
+	Default string   `json:"default"`
+	Locales []string `json:"locales"` // Available locales, the default first
+	Current string   `json:"current"` // Locale served to this request
+}
+
+// indexUILocales returns the route manifests of the localized bundles by locale. A bundle is a folder of
+// ui/_locales/ with an index.html; locales are lowercase language tags such as fr or pt-br.
+func indexUILocales(assets map[string]*uiAsset) map[string]*uiRouteManifest {
+	locales := make(map[string]*uiRouteManifest)
+	prefix := "ui/" + uiLocalesDir + "/"
+	for name := range assets {
+		locale, ok := strings.CutSuffix(strings.TrimPrefix(name, prefix), "/index.html")
+		if !strings.HasPrefix(name, prefix) || !ok || strings.Contains(locale, "/") || locale != strings.ToLower(locale) || locale == uiDefaultLocale {
+			continue
+		}
+		locales[locale] = buildUIRoutes(assets, prefix+locale)
+	}
+	return locales
+}
+
+// localeNames returns the available locales, the default first.
+func (h *UIHandler) localeNames() []string {
+	names := make([]string, 0, len(h.locales)+1)
+	for locale := range h.locales {
+		names = append(names, locale)
+	}
+	sort.Strings(names)
+	return append([]string{uiDefaultLocale}, names...)
+}
+
+// requestLocale returns the locale of the bundle serving a request: the locale of the cookie when available,
+// else the most preferred available language of Accept-Language, else the default. A language matches a
+// locale with the same tag or, failing that, with its primary language (fr-CA is served fr).
+func (h *UIHandler) requestLocale(ctx *fasthttp.RequestCtx) string {
+	if len(h.locales) == 0 {
+		return uiDefaultLocale
+	}
+	if locale := strings.ToLower(string(ctx.Request.Header.Cookie(uiLocaleCookie))); locale != "" {
+		if _, ok := h.locales[locale]; ok || locale == uiDefaultLocale {
+			return locale
+		}
+	}
+	for _, tag := range parseAcceptLanguage(string(ctx.Request.Header.Peek(fasthttp.HeaderAcceptLanguage))) {
+		primary, _, _ := strings.Cut(tag, "-")
+		for _, candidate := range []string{tag, primary} {
+			if _, ok := h.locales[candidate]; ok || candidate == uiDefaultLocale {
+				return candidate
+			}
+		}
+	}
+	return uiDefaultLocale
+}
+
+// parseAcceptLanguage returns the lowercase language tags of an Accept-Language header, most preferred first.
+// The wildcard and tags with a zero quality are left out.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		if len(tags) == uiLocaleMaxCount {
+			break
+		}
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			tags = append(tags, weighted{tag, quality})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// getLocales handles GET /api/ui/locales - List the locales of the dashboard and the one served to the caller.
+func (h *UIHandler) getLocales(ctx *fasthttp.RequestCtx) {
+	SendJSON(ctx, UILocalesResponse{
+		Default: uiDefaultLocale,
+		Locales: h.localeNames(),
+		Current: h.requestLocale(ctx),
+	}, h.logger)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/fasthttp/router"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// uiProxyPrefix is the path prefix of the UI proxy routes.
+const uiProxyPrefix = "/api/proxy/"
+
+// UIProxyHandler lets the dashboard call the management API with its session cookie. Each request is dispatched
+// again through the server handler as /api/<path>, with the cookie exchanged for the internal token of the session,
+// so the browser never holds the admin secret and the session's scopes apply to every call.
+type UIProxyHandler struct {
+	config   *lib.Config
+	dispatch fasthttp.RequestHandler
+	logger   schemas.Logger
+}
+
+// NewUIProxyHandler creates a new UI proxy handler dispatching requests to dispatch, the complete server handler.
+func NewUIProxyHandler(config *lib.Config, dispatch fasthttp.RequestHandler, logger schemas.Logger) *UIProxyHandler {
+	return &UIProxyHandler{
+		config:   config,
+		dispatch: dispatch,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes registers the UI proxy routes.
+func (h *UIProxyHandler) RegisterRoutes(r *router.Router, middlewares ...lib.BifrostHTTPMiddleware) {
+	r.ANY(uiProxyPrefix+"{path:*}", lib.ChainMiddlewares(h.proxy, middlewares...))
+}
+
+// proxy handles /api/proxy/{path} - Call /api/{path} with the caller's dashboard session
+func (h *UIProxyHandler) proxy(ctx *fasthttp.RequestCtx) {
+	path := "/api/" + strings.TrimPrefix(string(ctx.Path()), uiProxyPrefix)
+	if strings.HasPrefix(path, uiProxyPrefix) || strings.HasPrefix(path, "/api/auth/") {
+		SendError(ctx, fasthttp.StatusBadRequest, "the proxy only calls the management API, call /api/auth directly", h.logger)
+		return
+	}
+
+	// Only the internal token authenticates the dispatched request
+	token := ""
+	if strings.TrimSpace(h.config.GetAdminSecret()) != "" {
+		session := requestUISession(ctx, h.config)
+		if session == nil {
+			SendError(ctx, fasthttp.StatusUnauthorized, "dashboard session required, sign in again", h.logger)
+			return
+		}
+		token = session.Token
+	}
+	ctx.Request.Header.Del("Authorization")
+	ctx.Request.Header.DelAllCookies()
+	if token != "" {
+		ctx.Request.Header.Set("Authorization", "Bearer "+token)
+	}
+	ctx.SetUserValue(lib.UIProxyContextKey, true)
+	ctx.Request.URI().SetPath(path)
+	h.dispatch(ctx)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/fasthttp/router"
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+// TestUIProxy tests that the UI proxy exchanges the session cookie for the internal token of the session, that the
+// session's scopes apply to the dispatched request and that internal tokens are rejected outside the proxy
+func TestUIProxy(t *testing.T) {
+	logger := bifrost.NewDefaultLogger(schemas.LogLevelError)
+	config := &lib.Config{AdminSecret: "admin-secret", AdminCookieName: "bf_admin"}
+	r := router.New()
+	var handler fasthttp.RequestHandler
+	NewUIProxyHandler(config, func(ctx *fasthttp.RequestCtx) { handler(ctx) }, logger).RegisterRoutes(r)
+	var seen string
+	r.ANY("/api/providers/{provider}", func(ctx *fasthttp.RequestCtx) {
+		seen = string(ctx.Request.Header.Peek("Authorization")) + " " + string(ctx.Request.Header.Cookie("bf_admin")) + " " + string(ctx.QueryArgs().Peek("validate"))
+		SendJSON(ctx, map[string]string{"status": "ok"}, logger)
+	})
+	handler = AdminAuthMiddleware(config, logger)(r.Handler)
+
+	request := func(method, uri, cookie, authorization string) *fasthttp.RequestCtx {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.Header.SetMethod(method)
+		ctx.Request.SetRequestURI(uri)
+		if cookie != "" {
+			ctx.Request.Header.SetCookie("bf_admin", cookie)
+		}
+		if authorization != "" {
+			ctx.Request.Header.Set("Authorization", authorization)
+		}
+		handler(ctx)
+		return ctx
+	}
+
+	readOnly, err := config.CreateUISession(context.Background(), "", []serviceaccounts.Scope{serviceaccounts.ScopeConfigRead})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	ctx := request(fasthttp.MethodGet, "/api/proxy/providers/openai?validate=true", readOnly.ID, "Bearer admin-secret")
+	if ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Fatalf("proxied read failed with %d: %s", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+	if want := "Bearer " + readOnly.Token + "  true"; seen != want {
+		t.Errorf("dispatched request saw %q, want %q", seen, want)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(ctx.Response.Body(), &body); err != nil || body["status"] != "ok" {
+		t.Errorf("unexpected response %s", ctx.Response.Body())
+	}
+	if ctx := request(fasthttp.MethodPut, "/api/proxy/providers/openai", readOnly.ID, ""); ctx.Response.StatusCode() != fasthttp.StatusForbidden {
+		t.Errorf("status = %d, want 403 for a write with a read-only session", ctx.Response.StatusCode())
+	}
+
+	admin, err := config.CreateUISession(context.Background(), "", nil)
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if ctx := request(fasthttp.MethodPut, "/api/proxy/providers/openai", admin.ID, ""); ctx.Response.StatusCode() != fasthttp.StatusOK {
+		t.Errorf("status = %d, want 200 for a write with an admin session", ctx.Response.StatusCode())
+	}
+	if ctx := request(fasthttp.MethodGet, "/api/proxy/providers/openai", "", ""); ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Errorf("status = %d, want 401 without a session", ctx.Response.StatusCode())
+	}
+	if ctx := request(fasthttp.MethodGet, "/api/proxy/proxy/providers/openai", admin.ID, ""); ctx.Response.StatusCode() != fasthttp.StatusBadRequest {
+		t.Errorf("status = %d, want 400 for the proxy calling itself", ctx.Response.StatusCode())
+	}
+	if ctx := request(fasthttp.MethodGet, "/api/providers/openai", "", "Bearer "+admin.Token); ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Errorf("status = %d, want 401 for an internal token outside the proxy", ctx.Response.StatusCode())
+	}
+
+	config.SetAdminSecret(context.Background(), "rotated-secret")
+	if ctx := request(fasthttp.MethodGet, "/api/proxy/providers/openai", admin.ID, ""); ctx.Response.StatusCode() != fasthttp.StatusUnauthorized {
+		t.Errorf("status = %d, want 401 once the admin secret is rotated", ctx.Response.StatusCode())
+	}
+}
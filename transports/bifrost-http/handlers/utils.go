@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
 	"github.com/valyala/fasthttp"
 )
 
@@ -16,7 +17,7 @@ import (
 func SendJSON(ctx *fasthttp.RequestCtx, data interface{}, logger schemas.Logger) {
 	ctx.SetContentType("application/json")
 	if err := json.NewEncoder(ctx).Encode(data); err != nil {
-		logger.Warn(fmt.Sprintf("Failed to encode JSON response: %v", err))
+		logger.Warn("Failed to encode JSON response: %v", err)
 		SendError(ctx, fasthttp.StatusInternalServerError, fmt.Sprintf("Failed to encode response: %v", err), logger)
 	}
 }
@@ -45,7 +46,7 @@ func SendBifrostError(ctx *fasthttp.RequestCtx, bifrostErr *schemas.BifrostError
 
 	ctx.SetContentType("application/json")
 	if encodeErr := json.NewEncoder(ctx).Encode(bifrostErr); encodeErr != nil {
-		logger.Warn(fmt.Sprintf("Failed to encode error response: %v", encodeErr))
+		logger.Warn("Failed to encode error response: %v", encodeErr)
 		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
 		ctx.SetBodyString(fmt.Sprintf("Failed to encode error response: %v", encodeErr))
 	}
@@ -53,17 +54,13 @@ func SendBifrostError(ctx *fasthttp.RequestCtx, bifrostErr *schemas.BifrostError
 
 // SendSSEError sends an error in Server-Sent Events format
 func SendSSEError(ctx *fasthttp.RequestCtx, bifrostErr *schemas.BifrostError, logger schemas.Logger) {
-	errorJSON, err := json.Marshal(map[string]interface{}{
-		"error": bifrostErr,
-	})
-	if err != nil {
-		logger.Error("failed to marshal error for SSE: %v", err)
-		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
-		return
-	}
-
-	if _, err := fmt.Fprintf(ctx, "data: %s\n\n", errorJSON); err != nil {
-		logger.Warn(fmt.Sprintf("Failed to write SSE error: %v", err))
+	if err := lib.WriteSSEJSON(ctx, map[string]interface{}{"error": bifrostErr}); err != nil {
+		if lib.IsSSEEncodeError(err) {
+			logger.Error("failed to marshal error for SSE: %v", err)
+			ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+			return
+		}
+		logger.Warn("Failed to write SSE error: %v", err)
 	}
 }
 
@@ -144,3 +141,9 @@ func ParseModel(model string) (string, string, error) {
 	}
 	return provider, name, nil
 }
+
+// ownedBy reports whether the caller may see an object of owner. Objects of anonymous callers are seen by anyone
+// knowing their ID.
+func ownedBy(ctx *fasthttp.RequestCtx, owner string) bool {
+	return lib.OwnedBy(owner, lib.RequestOwner(ctx))
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+	"github.com/valyala/fasthttp"
+)
+
+const visionPluginName = "bifrost-vision"
+
+// invalidImageErrorType is the error type of requests with an image Bifrost cannot accept
+const invalidImageErrorType = "invalid_image"
+
+// visionPlugin checks the image content parts of chat and responses requests, inlining remote images for the
+// providers that need it and downscaling oversized ones. It runs for every attempt, since fallback providers may
+// need images inline when the primary one does not. Requests are copied before images are replaced, so the
+// original images are what the next attempt starts from.
+type visionPlugin struct {
+	processor *lib.VisionProcessor
+}
+
+// GetName returns the name of the plugin
+func (p *visionPlugin) GetName() string {
+	return visionPluginName
+}
+
+// TransportInterceptor is not used for this plugin
+func (p *visionPlugin) TransportInterceptor(url string, headers map[string]string, body map[string]any) (map[string]string, map[string]any, error) {
+	return headers, body, nil
+}
+
+// PreHook processes the images of the request, rejecting it when one is invalid, too large or cannot be fetched
+func (p *visionPlugin) PreHook(ctx *context.Context, req *schemas.BifrostRequest) (*schemas.BifrostRequest, *schemas.PluginShortCircuit, error) {
+	switch {
+	case req.ChatRequest != nil:
+		input, err := p.processChatMessages(*ctx, req.ChatRequest.Input, req.Provider)
+		if err != nil {
+			return req, &schemas.PluginShortCircuit{Error: invalidImageError(req.Provider, err)}, nil
+		}
+		if input != nil {
+			processed, chatReq := *req, *req.ChatRequest
+			chatReq.Input = input
+			processed.ChatRequest = &chatReq
+			return &processed, nil, nil
+		}
+	case req.ResponsesRequest != nil:
+		input, err := p.processResponsesMessages(*ctx, req.ResponsesRequest.Input, req.Provider)
+		if err != nil {
+			return req, &schemas.PluginShortCircuit{Error: invalidImageError(req.Provider, err)}, nil
+		}
+		if input != nil {
+			processed, responsesReq := *req, *req.ResponsesRequest
+			responsesReq.Input = input
+			processed.ResponsesRequest = &responsesReq
+			return &processed, nil, nil
+		}
+	}
+	return req, nil, nil
+}
+
+// processChatMessages processes the images of chat messages. It returns a copy of messages with the replaced
+// images, or nil when every image is sent as it is.
+func (p *visionPlugin) processChatMessages(ctx context.Context, messages []schemas.ChatMessage, provider schemas.ModelProvider) ([]schemas.ChatMessage, error) {
+	var processed []schemas.ChatMessage
+	images := 0
+	for i, message := range messages {
+		if message.Content == nil {
+			continue
+		}
+		var blocks []schemas.ChatContentBlock
+		for j, block := range message.Content.ContentBlocks {
+			if block.ImageURLStruct == nil {
+				continue
+			}
+			if images++; p.tooManyImages(images) {
+				return nil, &lib.ImageError{Message: fmt.Sprintf("requests may contain at most %d images", p.processor.MaxImages())}
+			}
+			url, err := p.processor.ProcessImageURL(ctx, block.ImageURLStruct.URL, provider)
+			if err != nil {
+				return nil, err
+			}
+			if url == block.ImageURLStruct.URL {
+				continue
+			}
+			if blocks == nil {
+				blocks = slices.Clone(message.Content.ContentBlocks)
+			}
+			image := *block.ImageURLStruct
+			image.URL = url
+			blocks[j].ImageURLStruct = &image
+		}
+		if blocks != nil {
+			if processed == nil {
+				processed = slices.Clone(messages)
+			}
+			content := *message.Content
+			content.ContentBlocks = blocks
+			processed[i].Content = &content
+		}
+	}
+	return processed, nil
+}
+
+// processResponsesMessages processes the input images of responses messages, like processChatMessages
+func (p *visionPlugin) processResponsesMessages(ctx context.Context, messages []schemas.ResponsesMessage, provider schemas.ModelProvider) ([]schemas.ResponsesMessage, error) {
+	var processed []schemas.ResponsesMessage
+	images := 0
+	for i, message := range messages {
+		if message.Content == nil {
+			continue
+		}
+		var blocks []schemas.ResponsesMessageContentBlock
+		for j, block := range message.Content.ContentBlocks {
+			if block.ResponsesInputMessageContentBlockImage == nil || block.ImageURL == nil {
+				continue
+			}
+			if images++; p.tooManyImages(images) {
+				return nil, &lib.ImageError{Message: fmt.Sprintf("requests may contain at most %d images", p.processor.MaxImages())}
+			}
+			url, err := p.processor.ProcessImageURL(ctx, *block.ImageURL, provider)
+			if err != nil {
+				return nil, err
+			}
+			if url == *block.ImageURL {
+				continue
+			}
+			if blocks == nil {
+				blocks = slices.Clone(message.Content.ContentBlocks)
+			}
+			image := *block.ResponsesInputMessageContentBlockImage
+			image.ImageURL = &url
+			blocks[j].ResponsesInputMessageContentBlockImage = &image
+		}
+		if blocks != nil {
+			if processed == nil {
+				processed = slices.Clone(messages)
+			}
+			content := *message.Content
+			content.ContentBlocks = blocks
+			processed[i].Content = &content
+		}
+	}
+	return processed, nil
+}
+
+// tooManyImages reports whether a request with the given number of images exceeds the limit
+func (p *visionPlugin) tooManyImages(images int) bool {
+	return p.processor.MaxImages() > 0 && images > p.processor.MaxImages()
+}
+
+// invalidImageError builds the error of a request whose images cannot be processed. Invalid images are
+// reported as 400s without fallbacks, since no provider would accept them.
+func invalidImageError(provider schemas.ModelProvider, err error) *schemas.BifrostError {
+	statusCode := fasthttp.StatusBadRequest
+	errorType := invalidImageErrorType
+	var imageErr *lib.ImageError
+	if !errors.As(err, &imageErr) {
+		statusCode = fasthttp.StatusInternalServerError
+		errorType = "image_processing_failed"
+	}
+	allowFallbacks := false
+	return &schemas.BifrostError{
+		IsBifrostError: true,
+		StatusCode:     &statusCode,
+		Type:           &errorType,
+		AllowFallbacks: &allowFallbacks,
+		Error: &schemas.ErrorField{
+			Type:    &errorType,
+			Message: err.Error(),
+		},
+		ExtraFields: schemas.BifrostErrorExtraFields{
+			Provider: provider,
+		},
+	}
+}
+
+// PostHook is not used for this plugin
+func (p *visionPlugin) PostHook(ctx *context.Context, result *schemas.BifrostResponse, bifrostErr *schemas.BifrostError) (*schemas.BifrostResponse, *schemas.BifrostError, error) {
+	return result, bifrostErr, nil
+}
+
+// Cleanup is not used for this plugin
+func (p *visionPlugin) Cleanup() error {
+	return nil
+}
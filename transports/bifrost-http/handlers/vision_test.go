@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
+)
+
+// testPNG returns a PNG image of width x height pixels
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newVisionPlugin returns the vision plugin of a config
+func newVisionPlugin(t *testing.T, config lib.VisionConfig) *visionPlugin {
+	t.Helper()
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &visionPlugin{processor: lib.NewVisionProcessor(&config)}
+}
+
+// imageChatRequest returns a chat request to provider with one image part per URL
+func imageChatRequest(provider schemas.ModelProvider, urls ...string) *schemas.BifrostRequest {
+	blocks := []schemas.ChatContentBlock{{Type: schemas.ChatContentBlockTypeText, Text: schemas.Ptr("What is in these images?")}}
+	for _, url := range urls {
+		blocks = append(blocks, schemas.ChatContentBlock{Type: schemas.ChatContentBlockTypeImage, ImageURLStruct: &schemas.ChatInputImage{URL: url}})
+	}
+	return &schemas.BifrostRequest{
+		Provider:    provider,
+		Model:       "vision-model",
+		RequestType: schemas.ChatCompletionRequest,
+		ChatRequest: &schemas.BifrostChatRequest{Provider: provider, Model: "vision-model", Input: []schemas.ChatMessage{
+			{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentBlocks: blocks}},
+		}},
+	}
+}
+
+// processedImageURLs runs a request through the plugin and returns the image URLs it would send, or its error
+func processedImageURLs(t *testing.T, plugin *visionPlugin, req *schemas.BifrostRequest) ([]string, *schemas.BifrostError) {
+	t.Helper()
+	ctx := context.Background()
+	processed, shortCircuit, err := plugin.PreHook(&ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shortCircuit != nil {
+		return nil, shortCircuit.Error
+	}
+	var urls []string
+	for _, block := range processed.ChatRequest.Input[0].Content.ContentBlocks {
+		if block.ImageURLStruct != nil {
+			urls = append(urls, block.ImageURLStruct.URL)
+		}
+	}
+	return urls, nil
+}
+
+// decodeDataURL returns the media type and the image dimensions of a data URL
+func decodeDataURL(t *testing.T, url string) (string, image.Config) {
+	t.Helper()
+	mediaType, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ";base64,")
+	if !ok {
+		t.Fatalf("expected a base64 data URL, got %.40s", url)
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mediaType, config
+}
+
+// TestVisionPlugin_Base64Images tests that base64 images are validated, size-limited and downscaled
+func TestVisionPlugin_Base64Images(t *testing.T) {
+	plugin := newVisionPlugin(t, lib.VisionConfig{Enabled: true, MaxDimension: 100, MaxImageBytes: 64 << 10})
+	small := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 80, 40))
+	large := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 400, 200))
+
+	req := imageChatRequest(schemas.OpenAI, small, large)
+	urls, bifrostErr := processedImageURLs(t, plugin, req)
+	if bifrostErr != nil {
+		t.Fatalf("unexpected error: %v", bifrostErr.Error.Message)
+	}
+	if urls[0] != small {
+		t.Error("expected the small image to be sent as it is")
+	}
+	if mediaType, config := decodeDataURL(t, urls[1]); mediaType != "image/png" || config.Width != 100 || config.Height != 50 {
+		t.Errorf("expected a 100x50 PNG, got %s of %dx%d", mediaType, config.Width, config.Height)
+	}
+	if req.ChatRequest.Input[0].Content.ContentBlocks[2].ImageURLStruct.URL != large {
+		t.Error("expected the request of the caller to be left unchanged")
+	}
+
+	for name, url := range map[string]string{
+		"too large":      "data:image/png;base64," + base64.StdEncoding.EncodeToString(make([]byte, 70<<10)),
+		"corrupted":      "data:image/png;base64," + base64.StdEncoding.EncodeToString([]byte("not a png")),
+		"malformed":      "data:image/png;base64,@@@@",
+		"not an image":   "data:text/plain;base64," + base64.StdEncoding.EncodeToString([]byte("hello")),
+		"invalid scheme": "ftp://example.com/cat.png",
+	} {
+		_, bifrostErr := processedImageURLs(t, plugin, imageChatRequest(schemas.OpenAI, url))
+		if bifrostErr == nil || *bifrostErr.StatusCode != http.StatusBadRequest || *bifrostErr.Type != invalidImageErrorType || *bifrostErr.AllowFallbacks {
+			t.Errorf("%s: expected an invalid_image error, got %+v", name, bifrostErr)
+		}
+	}
+
+	limited := newVisionPlugin(t, lib.VisionConfig{Enabled: true, MaxImagesPerRequest: 1})
+	if _, bifrostErr := processedImageURLs(t, limited, imageChatRequest(schemas.OpenAI, small, small)); bifrostErr == nil || !strings.Contains(bifrostErr.Error.Message, "at most 1 images") {
+		t.Errorf("expected the image limit to be enforced, got %+v", bifrostErr)
+	}
+}
+
+// TestVisionPlugin_FetchRemoteImages tests that remote images are inlined for the providers that need it
+func TestVisionPlugin_FetchRemoteImages(t *testing.T) {
+	picture := testPNG(t, 300, 300)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(picture)
+		case "/redirect":
+			http.Redirect(w, r, "http://example.com/cat.png", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	plugin := newVisionPlugin(t, lib.VisionConfig{Enabled: true, FetchRemoteImages: lib.VisionFetchAuto, MaxDimension: 150, AllowPrivateNetworks: true, AllowedHosts: []string{"127.0.0.1"}})
+	urls, bifrostErr := processedImageURLs(t, plugin, imageChatRequest(schemas.Bedrock, server.URL+"/cat.png"))
+	if bifrostErr != nil {
+		t.Fatalf("unexpected error: %v", bifrostErr.Error.Message)
+	}
+	if mediaType, config := decodeDataURL(t, urls[0]); mediaType != "image/png" || config.Width != 150 {
+		t.Errorf("expected an inlined 150px PNG, got %s of %dx%d", mediaType, config.Width, config.Height)
+	}
+	if urls, _ := processedImageURLs(t, plugin, imageChatRequest(schemas.OpenAI, server.URL+"/cat.png")); urls[0] != server.URL+"/cat.png" {
+		t.Errorf("expected the URL to be sent to OpenAI as it is, got %.40s", urls[0])
+	}
+
+	for name, url := range map[string]string{
+		"missing":            server.URL + "/missing.png",
+		"redirected outside": server.URL + "/redirect",
+	} {
+		if _, bifrostErr := processedImageURLs(t, plugin, imageChatRequest(schemas.Bedrock, url)); bifrostErr == nil || *bifrostErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected an invalid_image error, got %+v", name, bifrostErr)
+		}
+	}
+
+	guarded := newVisionPlugin(t, lib.VisionConfig{Enabled: true, FetchRemoteImages: lib.VisionFetchAlways})
+	_, bifrostErr = processedImageURLs(t, guarded, imageChatRequest(schemas.OpenAI, server.URL+"/cat.png"))
+	if bifrostErr == nil || !strings.Contains(bifrostErr.Error.Message, "private address") {
+		t.Errorf("expected images on private addresses not to be fetched, got %+v", bifrostErr)
+	}
+}
+
+// TestIsPublicAddr tests which addresses images may be fetched from
+func TestIsPublicAddr(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":          true,
+		"2606:4700::6810:85e5":   true,
+		"::ffff:93.184.216.34":   true,
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"224.0.0.1":              false,
+		"::1":                    false,
+		"fd00::1":                false,
+		"fe80::1":                false,
+		"64:ff9b::a00:1":         false,
+		"::ffff:169.254.169.254": false,
+	} {
+		if got := lib.IsPublicAddr(netip.MustParseAddr(addr)); got != public {
+			t.Errorf("%s: expected public %v, got %v", addr, public, got)
+		}
+	}
+}
@@ -56,6 +56,11 @@ func (h *WebSocketHandler) RegisterRoutes(r *router.Router, middlewares ...lib.B
 
 // getUpgrader returns a WebSocket upgrader configured with the current allowed origins
 func (h *WebSocketHandler) getUpgrader() websocket.FastHTTPUpgrader {
+	return newWebSocketUpgrader(h.allowedOrigins)
+}
+
+// newWebSocketUpgrader returns a WebSocket upgrader accepting localhost and the allowed origins
+func newWebSocketUpgrader(allowedOrigins []string) websocket.FastHTTPUpgrader {
 	return websocket.FastHTTPUpgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
@@ -67,7 +72,7 @@ func (h *WebSocketHandler) getUpgrader() websocket.FastHTTPUpgrader {
 				return isLocalhost(host)
 			}
 			// Check if origin is allowed (localhost always allowed + configured origins)
-			return IsOriginAllowed(origin, h.allowedOrigins)
+			return IsOriginAllowed(origin, allowedOrigins)
 		},
 	}
 }
@@ -174,7 +179,18 @@ func (h *WebSocketHandler) BroadcastLogUpdate(logEntry *logstore.Log) {
 		}
 	}()
 
-	// Determine operation type based on log status and timestamp
+	data, err := marshalLogUpdate(logEntry)
+	if err != nil {
+		h.logger.Error("failed to marshal log entry: %v", err)
+		return
+	}
+
+	h.BroadcastMarshaledMessage(data)
+}
+
+// marshalLogUpdate encodes the message of a log update, a "create" operation for the initial entry of a request
+// and an "update" for the later ones
+func marshalLogUpdate(logEntry *logstore.Log) ([]byte, error) {
 	operationType := "update"
 	if logEntry.Status == "processing" && logEntry.CreatedAt.Equal(logEntry.Timestamp) {
 		operationType = "create"
@@ -189,14 +205,7 @@ func (h *WebSocketHandler) BroadcastLogUpdate(logEntry *logstore.Log) {
 		Operation: operationType,
 		Payload:   logEntry,
 	}
-
-	data, err := json.Marshal(message)
-	if err != nil {
-		h.logger.Error("failed to marshal log entry: %v", err)
-		return
-	}
-
-	h.BroadcastMarshaledMessage(data)
+	return json.Marshal(message)
 }
 
 // BroadcastMarshaledMessage sends an adaptive routing update to all connected WebSocket clients
@@ -90,6 +90,38 @@ func AzureEndpointPreHook(handlerStore lib.HandlerStore) func(ctx *fasthttp.Requ
 	}
 }
 
+// ExtraParamsPreHook collects the fields of an OpenAI request body the request type does not know, and the fields
+// of its extra_body object, into the extra parameters of the request before running next. Providers receive
+// those their passthrough policy allows.
+func ExtraParamsPreHook(next PreRequestCallback) PreRequestCallback {
+	return func(ctx *fasthttp.RequestCtx, req interface{}) error {
+		if body := ctx.Request.Body(); len(body) > 0 {
+			extraParams, err := lib.ExtraParams(body, lib.KnownFields(req))
+			if err != nil {
+				return err
+			}
+			if len(extraParams) > 0 {
+				switch r := req.(type) {
+				case *openai.OpenAITextCompletionRequest:
+					r.TextCompletionParameters.ExtraParams = extraParams
+				case *openai.OpenAIChatRequest:
+					r.ChatParameters.ExtraParams = extraParams
+				case *openai.OpenAIResponsesRequest:
+					r.ResponsesParameters.ExtraParams = extraParams
+				case *openai.OpenAIEmbeddingRequest:
+					r.EmbeddingParameters.ExtraParams = extraParams
+				case *openai.OpenAISpeechRequest:
+					r.SpeechParameters.ExtraParams = extraParams
+				}
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		return next(ctx, req)
+	}
+}
+
 // CreateOpenAIRouteConfigs creates route configurations for OpenAI endpoints.
 func CreateOpenAIRouteConfigs(pathPrefix string, handlerStore lib.HandlerStore) []RouteConfig {
 	var routes []RouteConfig
@@ -128,7 +160,7 @@ func CreateOpenAIRouteConfigs(pathPrefix string, handlerStore lib.HandlerStore)
 					return err
 				},
 			},
-			PreCallback: AzureEndpointPreHook(handlerStore),
+			PreCallback: ExtraParamsPreHook(AzureEndpointPreHook(handlerStore)),
 		})
 	}
 
@@ -167,7 +199,7 @@ func CreateOpenAIRouteConfigs(pathPrefix string, handlerStore lib.HandlerStore)
 					return err
 				},
 			},
-			PreCallback: AzureEndpointPreHook(handlerStore),
+			PreCallback: ExtraParamsPreHook(AzureEndpointPreHook(handlerStore)),
 		})
 	}
 
@@ -205,7 +237,7 @@ func CreateOpenAIRouteConfigs(pathPrefix string, handlerStore lib.HandlerStore)
 					return err
 				},
 			},
-			PreCallback: AzureEndpointPreHook(handlerStore),
+			PreCallback: ExtraParamsPreHook(AzureEndpointPreHook(handlerStore)),
 		})
 	}
 
@@ -235,7 +267,7 @@ func CreateOpenAIRouteConfigs(pathPrefix string, handlerStore lib.HandlerStore)
 			ErrorConverter: func(err *schemas.BifrostError) interface{} {
 				return err
 			},
-			PreCallback: AzureEndpointPreHook(handlerStore),
+			PreCallback: ExtraParamsPreHook(AzureEndpointPreHook(handlerStore)),
 		})
 	}
 
@@ -274,7 +306,7 @@ func CreateOpenAIRouteConfigs(pathPrefix string, handlerStore lib.HandlerStore)
 					return err
 				},
 			},
-			PreCallback: AzureEndpointPreHook(handlerStore),
+			PreCallback: ExtraParamsPreHook(AzureEndpointPreHook(handlerStore)),
 		})
 	}
 
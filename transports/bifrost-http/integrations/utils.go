@@ -55,6 +55,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"bufio"
 
@@ -371,24 +372,28 @@ func (g *GenericRouter) handleStreamingRequest(ctx *fasthttp.RequestCtx, config
 	ctx.Response.Header.Set("Connection", "keep-alive")
 	ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
 
+	// The stream is cancelled once written, so a client disconnecting midway cancels the upstream request
+	streamCtx, cancel := context.WithCancel(*bifrostCtx)
+
 	var stream chan *schemas.BifrostStream
 	var bifrostErr *schemas.BifrostError
 
 	// Handle different request types
 	if bifrostReq.TextCompletionRequest != nil {
-		stream, bifrostErr = g.client.TextCompletionStreamRequest(*bifrostCtx, bifrostReq.TextCompletionRequest)
+		stream, bifrostErr = g.client.TextCompletionStreamRequest(streamCtx, bifrostReq.TextCompletionRequest)
 	} else if bifrostReq.ChatRequest != nil {
-		stream, bifrostErr = g.client.ChatCompletionStreamRequest(*bifrostCtx, bifrostReq.ChatRequest)
+		stream, bifrostErr = g.client.ChatCompletionStreamRequest(streamCtx, bifrostReq.ChatRequest)
 	} else if bifrostReq.SpeechRequest != nil {
-		stream, bifrostErr = g.client.SpeechStreamRequest(*bifrostCtx, bifrostReq.SpeechRequest)
+		stream, bifrostErr = g.client.SpeechStreamRequest(streamCtx, bifrostReq.SpeechRequest)
 	} else if bifrostReq.TranscriptionRequest != nil {
-		stream, bifrostErr = g.client.TranscriptionStreamRequest(*bifrostCtx, bifrostReq.TranscriptionRequest)
+		stream, bifrostErr = g.client.TranscriptionStreamRequest(streamCtx, bifrostReq.TranscriptionRequest)
 	} else if bifrostReq.ResponsesRequest != nil {
-		stream, bifrostErr = g.client.ResponsesStreamRequest(*bifrostCtx, bifrostReq.ResponsesRequest)
+		stream, bifrostErr = g.client.ResponsesStreamRequest(streamCtx, bifrostReq.ResponsesRequest)
 	}
 
 	// Get the streaming channel from Bifrost
 	if bifrostErr != nil {
+		cancel()
 		// Send error in SSE format
 		g.sendStreamError(ctx, config, bifrostErr)
 		return
@@ -396,12 +401,13 @@ func (g *GenericRouter) handleStreamingRequest(ctx *fasthttp.RequestCtx, config
 
 	// Check if streaming is configured for this route
 	if config.StreamConfig == nil {
+		lib.AbandonStream(cancel, stream)
 		g.sendStreamError(ctx, config, newBifrostError(nil, "streaming is not supported for this integration"))
 		return
 	}
 
 	// Handle streaming using the centralized approach
-	g.handleStreaming(ctx, config, stream)
+	g.handleStreaming(ctx, config, stream, cancel)
 }
 
 // handleStreaming processes a stream of BifrostResponse objects and sends them as Server-Sent Events (SSE).
@@ -448,13 +454,26 @@ func (g *GenericRouter) handleStreamingRequest(ctx *fasthttp.RequestCtx, config
 // - Include data: lines with JSON content
 // - End with \n\n for proper SSE formatting
 // - Follow the provider's specific SSE event specification
-func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteConfig, streamChan chan *schemas.BifrostStream) {
+func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteConfig, streamChan chan *schemas.BifrostStream, cancel context.CancelFunc) {
 	// Use streaming response writer
 	ctx.Response.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer lib.TrackStream()()
+		defer cancel()
 		defer w.Flush()
 
+		heartbeat := time.NewTicker(lib.SSEHeartbeatInterval)
+		defer heartbeat.Stop()
+
 		// Process streaming responses
-		for response := range streamChan {
+		for {
+			response, ok, err := lib.ReceiveStreamChunk(w, streamChan, heartbeat.C)
+			if err != nil {
+				lib.AbandonStream(cancel, streamChan) // Network error, the client went away
+				return
+			}
+			if !ok {
+				return
+			}
 			if response == nil {
 				continue
 			}
@@ -492,7 +511,7 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 					// CUSTOM SSE FORMAT: The converter returned a complete SSE string
 					// This is used by providers like Anthropic that need custom event types
 					// Example: "event: error\ndata: {...}\n\n"
-					if _, err := fmt.Fprint(w, sseErrorString); err != nil {
+					if _, err := w.WriteString(sseErrorString); err != nil {
 						return
 					}
 				} else {
@@ -514,7 +533,7 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 					}
 
 					// Send error as SSE data
-					if _, err := fmt.Fprintf(w, "data: %s\n\n", errorJSON); err != nil {
+					if err := lib.WriteSSEData(w, errorJSON); err != nil {
 						return
 					}
 				}
@@ -550,29 +569,30 @@ func (g *GenericRouter) handleStreaming(ctx *fasthttp.RequestCtx, config RouteCo
 					// CUSTOM SSE FORMAT: The converter returned a complete SSE string
 					// This is used by providers like Anthropic that need custom event types
 					// Example: "event: content_block_delta\ndata: {...}\n\n"
-					if _, err := fmt.Fprint(w, sseString); err != nil {
-						return // Network error, stop streaming
+					if _, err := w.WriteString(sseString); err != nil {
+						lib.AbandonStream(cancel, streamChan) // Network error, the client went away
+						return
 					}
 				} else {
 					// STANDARD SSE FORMAT: The converter returned an object
 					// This will be JSON marshaled and wrapped as "data: {json}\n\n"
 					// Used by most providers (OpenAI, Google, etc.)
-					responseJSON, err := json.Marshal(convertedResponse)
-					if err != nil {
-						// Log JSON marshaling error but continue processing
-						log.Printf("Failed to marshal streaming response: %v", err)
-						continue
-					}
-
-					// Send as SSE data
-					if _, err := fmt.Fprintf(w, "data: %s\n\n", responseJSON); err != nil {
-						return // Network error, stop streaming
+					// Send as SSE data, encoded into a pooled buffer
+					if err := lib.WriteSSEJSON(w, convertedResponse); err != nil {
+						if lib.IsSSEEncodeError(err) {
+							// Log JSON marshaling error but continue processing
+							log.Printf("Failed to marshal streaming response: %v", err)
+							continue
+						}
+						lib.AbandonStream(cancel, streamChan) // Network error, the client went away
+						return
 					}
 				}
 
 				// Flush immediately to send the chunk
 				if err := w.Flush(); err != nil {
-					return // Network error, stop streaming
+					lib.AbandonStream(cancel, streamChan) // Network error, the client went away
+					return
 				}
 			}
 		}
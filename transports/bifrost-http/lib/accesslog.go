@@ -0,0 +1,116 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// AccessLogFormat is the line format of the HTTP access log.
+type AccessLogFormat string
+
+const (
+	AccessLogFormatJSON     AccessLogFormat = "json"
+	AccessLogFormatCombined AccessLogFormat = "combined" // Apache combined log format
+)
+
+// RequestInfoContextKey stores the *RequestInfo of a request, both as a fasthttp user value and in the Bifrost context.
+const RequestInfoContextKey ContextKey = "bifrost-request-info"
+
+// AccessLogRoute overrides the sample rate for all paths starting with PathPrefix.
+type AccessLogRoute struct {
+	PathPrefix string  `json:"path_prefix"`
+	SampleRate float64 `json:"sample_rate"` // 0..1
+}
+
+// AccessLogConfig represents the configuration of the HTTP access log.
+type AccessLogConfig struct {
+	Enabled      bool             `json:"enabled"`
+	Format       AccessLogFormat  `json:"format,omitempty"`        // json (default) or combined
+	Output       string           `json:"output,omitempty"`        // stdout (default), stderr or a file path
+	SampleRate   *float64         `json:"sample_rate,omitempty"`   // Default sample rate for all routes (default: 1)
+	Routes       []AccessLogRoute `json:"routes,omitempty"`        // Per-route sample rates, the longest matching prefix wins
+	ExcludePaths []string         `json:"exclude_paths,omitempty"` // Paths never logged (default: /health, /metrics)
+}
+
+// DefaultAccessLogExcludePaths are the paths left out of the access log unless exclude_paths is set.
+var DefaultAccessLogExcludePaths = []string{"/health", "/metrics"}
+
+// RequestInfo carries what the inference pipeline learns about a request (provider, model, selected key)
+// back to the HTTP layer. It is only attached to requests when access logging is enabled.
+type RequestInfo struct {
+	mu       sync.Mutex
+	provider string
+	model    string
+	keyID    string
+}
+
+// SetRoute records the provider and model a request was routed to.
+func (r *RequestInfo) SetRoute(provider string, model string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.provider = provider
+	r.model = model
+}
+
+// SetKeyID records the ID of the provider key used to serve the request.
+func (r *RequestInfo) SetKeyID(keyID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keyID = keyID
+}
+
+// Get returns the recorded provider, model and key ID.
+func (r *RequestInfo) Get() (provider string, model string, keyID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.provider, r.model, r.keyID
+}
+
+// SampleRateFor returns the sample rate of a request path.
+func (c *AccessLogConfig) SampleRateFor(path string) float64 {
+	rate := 1.0
+	if c.SampleRate != nil {
+		rate = *c.SampleRate
+	}
+	longest := -1
+	for _, route := range c.Routes {
+		if strings.HasPrefix(path, route.PathPrefix) && len(route.PathPrefix) > longest {
+			longest = len(route.PathPrefix)
+			rate = route.SampleRate
+		}
+	}
+	return rate
+}
+
+// IsExcluded reports whether a request path is left out of the access log.
+func (c *AccessLogConfig) IsExcluded(path string) bool {
+	excludePaths := c.ExcludePaths
+	if excludePaths == nil {
+		excludePaths = DefaultAccessLogExcludePaths
+	}
+	for _, excluded := range excludePaths {
+		if path == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAccessLogWriter returns the writer access log lines are written to.
+func OpenAccessLogWriter(config *AccessLogConfig) (io.Writer, error) {
+	switch config.Output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		file, err := os.OpenFile(config.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open access log file: %w", err)
+		}
+		return file, nil
+	}
+}
@@ -71,6 +71,12 @@ func (baseAccount *BaseAccount) GetKeysForProvider(ctx *context.Context, provide
 		}
 	}
 
+	if baseAccount.store.DataResidency != nil {
+		if allowed, ok := (*ctx).Value(DataResidencyContextKey).([]string); ok {
+			keys = baseAccount.store.DataResidency.FilterKeys(providerKey, keys, allowed)
+		}
+	}
+
 	return keys, nil
 }
 
@@ -111,5 +117,19 @@ func (baseAccount *BaseAccount) GetConfigForProvider(providerKey schemas.ModelPr
 		providerConfig.CustomProviderConfig = config.CustomProviderConfig
 	}
 
+	if config.MockConfig != nil {
+		providerConfig.MockConfig = config.MockConfig
+	}
+
+	if config.RequestShaping != nil {
+		providerConfig.RequestShaping = config.RequestShaping
+	}
+
+	if config.SyntheticStreaming != nil {
+		providerConfig.SyntheticStreaming = config.SyntheticStreaming
+	}
+
+	baseAccount.store.Egress.applyEgress(providerConfig)
+
 	return providerConfig, nil
 }
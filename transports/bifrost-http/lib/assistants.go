@@ -0,0 +1,523 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/assistants"
+	"github.com/maximhq/bifrost/framework/sessions"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultAssistantsMaxSteps          = 10
+	DefaultAssistantsRunTimeoutSeconds = 600
+)
+
+// AssistantsConfig enables the Assistants API compatibility layer: /v1/assistants and /v1/threads, with threads
+// kept in the sessions store and runs executed through the gateway on any provider. Tool calls of MCP tools
+// are executed by the gateway; calls of the function tools of an assistant are returned to the client.
+type AssistantsConfig struct {
+	Enabled           bool `json:"enabled"`
+	MaxSteps          int  `json:"max_steps,omitempty"`           // Model calls of a run before it fails (default: 10)
+	RunTimeoutSeconds int  `json:"run_timeout_seconds,omitempty"` // Time a run may execute for between tool outputs before it expires (default: 600)
+}
+
+// Validate checks the configuration.
+func (c *AssistantsConfig) Validate() error {
+	if c.MaxSteps < 0 || c.RunTimeoutSeconds < 0 {
+		return fmt.Errorf("assistants: max_steps and run_timeout_seconds must not be negative")
+	}
+	return nil
+}
+
+// AssistantsError is a request the Assistants API layer rejects.
+type AssistantsError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *AssistantsError) Error() string {
+	return e.Message
+}
+
+// IsAssistantsError reports whether err is a rejected request, and returns it.
+func IsAssistantsError(err error) (*AssistantsError, bool) {
+	var assistantsErr *AssistantsError
+	ok := errors.As(err, &assistantsErr)
+	return assistantsErr, ok
+}
+
+// ThreadMessage is a message of a thread with its ID and timestamp.
+type ThreadMessage struct {
+	assistants.MessageInfo
+	Message schemas.ChatMessage
+}
+
+// ToolOutput is the output of a function tool call returned by the client.
+type ToolOutput struct {
+	ToolCallID string `json:"tool_call_id"`
+	Output     string `json:"output"`
+}
+
+// Assistants runs assistants on threads. Thread messages live in the sessions store under the thread ID, so
+// threads are also visible as sessions; a thread runs one assistant at a time.
+type Assistants struct {
+	config  AssistantsConfig
+	store   assistants.Store
+	threads sessions.Store
+	gateway *Config
+
+	// mu serializes the changes to threads and to the status of runs
+	mu sync.Mutex
+	// running holds the cancel functions of the runs being executed
+	running map[string]context.CancelFunc
+}
+
+// NewAssistants creates the Assistants API layer over store, keeping thread messages in threads.
+func NewAssistants(config AssistantsConfig, store assistants.Store, threads sessions.Store, gateway *Config) *Assistants {
+	if config.MaxSteps == 0 {
+		config.MaxSteps = DefaultAssistantsMaxSteps
+	}
+	if config.RunTimeoutSeconds == 0 {
+		config.RunTimeoutSeconds = DefaultAssistantsRunTimeoutSeconds
+	}
+	return &Assistants{
+		config:  config,
+		store:   store,
+		threads: threads,
+		gateway: gateway,
+		running: make(map[string]context.CancelFunc),
+	}
+}
+
+// NewAssistantsID returns a new ID with the prefix of its object, e.g. asst, thread, msg or run.
+func NewAssistantsID(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// Store returns the store of assistants, threads and runs.
+func (a *Assistants) Store() assistants.Store {
+	return a.store
+}
+
+// CreateThread creates a thread of owner starting with messages.
+func (a *Assistants) CreateThread(ctx context.Context, owner string, metadata map[string]string, messages []ThreadMessage) (*assistants.Thread, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	thread := &assistants.Thread{ID: NewAssistantsID("thread"), Metadata: metadata, Owner: owner}
+	session := &sessions.Session{ID: thread.ID}
+	for _, message := range messages {
+		thread.Messages = append(thread.Messages, message.MessageInfo)
+		session.Messages = append(session.Messages, message.Message)
+	}
+	session.TokenCount = sessions.EstimateTokens("", session.Messages)
+	if err := a.threads.Save(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save thread messages: %w", err)
+	}
+	if err := a.store.SaveThread(ctx, thread); err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// DeleteThread deletes a thread, its messages and its runs, cancelling its running run.
+func (a *Assistants) DeleteThread(ctx context.Context, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	runs, err := a.store.ListRuns(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if cancel, ok := a.running[run.ID]; ok {
+			cancel()
+		}
+	}
+	if err := a.store.DeleteThread(ctx, id); err != nil {
+		return err
+	}
+	if err := a.threads.Delete(ctx, id); err != nil && !errors.Is(err, sessions.ErrNotFound) {
+		return fmt.Errorf("failed to delete thread messages: %w", err)
+	}
+	return nil
+}
+
+// Messages returns the messages of a thread, oldest first.
+func (a *Assistants) Messages(ctx context.Context, thread *assistants.Thread) ([]ThreadMessage, error) {
+	session, err := a.threads.Get(ctx, thread.ID)
+	if errors.Is(err, sessions.ErrNotFound) {
+		return []ThreadMessage{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thread messages: %w", err)
+	}
+	messages := make([]ThreadMessage, 0, len(session.Messages))
+	for i, message := range session.Messages {
+		info := untrackedMessageInfo(thread, i, session.UpdatedAt)
+		if i < len(thread.Messages) {
+			info = thread.Messages[i]
+		}
+		messages = append(messages, ThreadMessage{MessageInfo: info, Message: message})
+	}
+	return messages, nil
+}
+
+// untrackedMessageInfo describes the message at index of a thread written through the sessions API, which has
+// no info of its own and belongs to the owner of the thread
+func untrackedMessageInfo(thread *assistants.Thread, index int, updatedAt time.Time) assistants.MessageInfo {
+	return assistants.MessageInfo{ID: fmt.Sprintf("msg_%s_%d", strings.TrimPrefix(thread.ID, "thread_"), index), CreatedAt: updatedAt.Unix(), Owner: thread.Owner}
+}
+
+// AddMessage appends a message to a thread. Messages cannot be added while a run is active on the thread.
+func (a *Assistants) AddMessage(ctx context.Context, threadID string, message ThreadMessage) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.checkIdle(ctx, threadID); err != nil {
+		return err
+	}
+	return a.appendMessage(ctx, threadID, message)
+}
+
+// appendMessage appends a message to a thread and its session; mu must be held
+func (a *Assistants) appendMessage(ctx context.Context, threadID string, message ThreadMessage) error {
+	thread, err := a.store.GetThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	session, err := a.threads.Get(ctx, threadID)
+	if errors.Is(err, sessions.ErrNotFound) {
+		session, err = &sessions.Session{ID: threadID}, nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load thread messages: %w", err)
+	}
+	// Keep the infos aligned with the messages, even when the session was changed through the sessions API
+	infos := thread.Messages
+	for len(infos) < len(session.Messages) {
+		infos = append(infos, untrackedMessageInfo(thread, len(infos), session.UpdatedAt))
+	}
+	thread.Messages = append(infos[:len(session.Messages)], message.MessageInfo)
+	session.Messages = append(session.Messages, message.Message)
+	session.TokenCount = sessions.EstimateTokens(session.Summary, session.Messages)
+	if err := a.threads.Save(ctx, session); err != nil {
+		return fmt.Errorf("failed to save thread messages: %w", err)
+	}
+	return a.store.SaveThread(ctx, thread)
+}
+
+// checkIdle rejects changes to a thread while a run is active on it; mu must be held
+func (a *Assistants) checkIdle(ctx context.Context, threadID string) error {
+	runs, err := a.store.ListRuns(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if !assistants.IsFinished(run.Status) {
+			return &AssistantsError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("thread %s already has an active run %s", threadID, run.ID)}
+		}
+	}
+	return nil
+}
+
+// StartRun queues run and executes it in the background with the values of bifrostCtx, such as its virtual key.
+func (a *Assistants) StartRun(bifrostCtx context.Context, run *assistants.Run) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.checkIdle(bifrostCtx, run.ThreadID); err != nil {
+		return err
+	}
+	run.Status = assistants.StatusQueued
+	if err := a.store.SaveRun(bifrostCtx, run); err != nil {
+		return err
+	}
+	a.launch(bifrostCtx, run)
+	return nil
+}
+
+// SubmitToolOutputs adds the outputs of the pending function calls of a run and resumes it.
+func (a *Assistants) SubmitToolOutputs(bifrostCtx context.Context, threadID, runID string, outputs []ToolOutput) (*assistants.Run, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	run, err := a.store.GetRun(bifrostCtx, threadID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != assistants.StatusRequiresAction {
+		return nil, &AssistantsError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("run %s is %s, not waiting for tool outputs", run.ID, run.Status)}
+	}
+	byID := make(map[string]string, len(outputs))
+	for _, output := range outputs {
+		if !slices.ContainsFunc(run.PendingToolCalls, func(call schemas.ChatAssistantMessageToolCall) bool { return *call.ID == output.ToolCallID }) {
+			return nil, &AssistantsError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("tool call %s is not pending", output.ToolCallID)}
+		}
+		byID[output.ToolCallID] = output.Output
+	}
+	for _, call := range run.PendingToolCalls {
+		output, ok := byID[*call.ID]
+		if !ok {
+			return nil, &AssistantsError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("missing the output of tool call %s", *call.ID)}
+		}
+		run.Steps = append(run.Steps, toolMessage(*call.ID, output))
+	}
+	run.PendingToolCalls = nil
+	run.Status = assistants.StatusQueued
+	if err := a.store.SaveRun(bifrostCtx, run); err != nil {
+		return nil, err
+	}
+	a.launch(bifrostCtx, run)
+	return run, nil
+}
+
+// CancelRun cancels an active run. Runs being executed become cancelled once their current step ends.
+func (a *Assistants) CancelRun(ctx context.Context, threadID, runID string) (*assistants.Run, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	run, err := a.store.GetRun(ctx, threadID, runID)
+	if err != nil {
+		return nil, err
+	}
+	if assistants.IsFinished(run.Status) {
+		return nil, &AssistantsError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("cannot cancel run %s with status %s", run.ID, run.Status)}
+	}
+	if cancel, ok := a.running[run.ID]; ok {
+		run.Status = assistants.StatusCancelling
+		cancel()
+	} else {
+		now := time.Now()
+		run.Status, run.CancelledAt, run.PendingToolCalls = assistants.StatusCancelled, &now, nil
+	}
+	if err := a.store.SaveRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// launch executes a copy of run in the background; mu must be held
+func (a *Assistants) launch(bifrostCtx context.Context, queued *assistants.Run) {
+	run := *queued
+	ctx, cancel := context.WithTimeout(bifrostCtx, time.Duration(a.config.RunTimeoutSeconds)*time.Second)
+	a.running[run.ID] = cancel
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			delete(a.running, run.ID)
+			a.mu.Unlock()
+			cancel()
+		}()
+		a.execute(ctx, &run)
+	}()
+}
+
+// execute calls the model of run on the thread until it replies without tool calls, executing the calls of
+// MCP tools and stopping at the calls of function tools, whose outputs the client submits.
+func (a *Assistants) execute(ctx context.Context, run *assistants.Run) {
+	now := time.Now()
+	if run.StartedAt == nil {
+		run.StartedAt = &now
+	}
+	run.Status = assistants.StatusInProgress
+	a.saveRun(run)
+
+	client := a.gateway.GetBifrostClient()
+	if client == nil {
+		a.finish(ctx, run, nil, fmt.Errorf("bifrost client is not initialized"))
+		return
+	}
+	provider, model := schemas.ParseModelString(run.Model, schemas.OpenAI)
+	for {
+		if steps := countAssistantSteps(run.Steps); steps >= a.config.MaxSteps {
+			a.finish(ctx, run, nil, fmt.Errorf("run exceeded %d model calls", a.config.MaxSteps))
+			return
+		}
+		session, err := a.threads.Get(ctx, run.ThreadID)
+		if err != nil && !errors.Is(err, sessions.ErrNotFound) {
+			a.finish(ctx, run, nil, fmt.Errorf("failed to load thread messages: %w", err))
+			return
+		}
+		input := []schemas.ChatMessage{}
+		if run.Instructions != "" {
+			instructions := run.Instructions
+			input = append(input, schemas.ChatMessage{Role: schemas.ChatMessageRoleSystem, Content: &schemas.ChatMessageContent{ContentStr: &instructions}})
+		}
+		if session != nil {
+			if session.Summary != "" {
+				input = append(input, SummaryMessage(session.Summary))
+			}
+			input = append(input, session.Messages...)
+		}
+		input = append(input, run.Steps...)
+		var params *schemas.ChatParameters
+		if len(run.Tools) > 0 {
+			params = &schemas.ChatParameters{Tools: run.Tools}
+		}
+
+		// Every model call is a request of its own in the logs
+		stepCtx := context.WithValue(ctx, schemas.BifrostContextKeyRequestID, uuid.NewString())
+		resp, bifrostErr := client.ChatCompletionRequest(stepCtx, &schemas.BifrostChatRequest{Provider: provider, Model: model, Input: input, Params: params})
+		if ctx.Err() != nil {
+			a.finish(ctx, run, nil, ctx.Err())
+			return
+		}
+		if bifrostErr != nil {
+			message := "model request failed"
+			if bifrostErr.Error != nil {
+				message = bifrostErr.Error.Message
+			}
+			a.finish(ctx, run, nil, errors.New(message))
+			return
+		}
+		if resp.Usage != nil {
+			run.PromptTokens += resp.Usage.PromptTokens
+			run.CompletionTokens += resp.Usage.CompletionTokens
+			run.TotalTokens += resp.Usage.TotalTokens
+		}
+		if len(resp.Choices) == 0 || resp.Choices[0].BifrostNonStreamResponseChoice == nil || resp.Choices[0].Message == nil {
+			a.finish(ctx, run, nil, fmt.Errorf("model returned no message"))
+			return
+		}
+		reply := *resp.Choices[0].Message
+		if reply.ChatAssistantMessage == nil || len(reply.ToolCalls) == 0 {
+			a.finish(ctx, run, &reply, nil)
+			return
+		}
+
+		run.Steps = append(run.Steps, reply)
+		for _, call := range reply.ToolCalls {
+			if call.ID == nil {
+				call.ID = schemas.Ptr(NewAssistantsID("call"))
+			}
+			if call.Function.Name != nil && hasFunctionTool(run.Tools, *call.Function.Name) {
+				run.PendingToolCalls = append(run.PendingToolCalls, call)
+				continue
+			}
+			result, bifrostErr := client.ExecuteMCPTool(ctx, call)
+			if bifrostErr != nil || result == nil {
+				// The model is told about the failure, so it can recover from it
+				message := "tool execution failed"
+				if bifrostErr != nil && bifrostErr.Error != nil {
+					message = bifrostErr.Error.Message
+				}
+				run.Steps = append(run.Steps, toolMessage(*call.ID, "Error: "+message))
+				continue
+			}
+			if result.ChatToolMessage == nil {
+				result.ChatToolMessage = &schemas.ChatToolMessage{}
+			}
+			result.ToolCallID = call.ID
+			run.Steps = append(run.Steps, *result)
+		}
+		if len(run.PendingToolCalls) > 0 {
+			run.Status = assistants.StatusRequiresAction
+			a.saveRun(run)
+			return
+		}
+		a.saveRun(run)
+	}
+}
+
+// finish ends run with its reply, which is added to the thread, or with the error that stopped it
+func (a *Assistants) finish(ctx context.Context, run *assistants.Run, reply *schemas.ChatMessage, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.store.GetThread(context.Background(), run.ThreadID); errors.Is(err, assistants.ErrNotFound) {
+		return // The thread was deleted with its runs
+	}
+	now := time.Now()
+	run.PendingToolCalls = nil
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		run.Status, run.LastError = assistants.StatusExpired, "run timed out"
+	case errors.Is(err, context.Canceled):
+		run.Status, run.CancelledAt = assistants.StatusCancelled, &now
+	case err != nil:
+		run.Status, run.LastError, run.FailedAt = assistants.StatusFailed, err.Error(), &now
+	default:
+		message := ThreadMessage{
+			MessageInfo: assistants.MessageInfo{ID: NewAssistantsID("msg"), CreatedAt: now.Unix(), AssistantID: run.AssistantID, RunID: run.ID, Owner: run.Owner},
+			Message:     schemas.ChatMessage{Role: schemas.ChatMessageRoleAssistant, Content: reply.Content},
+		}
+		if err := a.appendMessage(context.Background(), run.ThreadID, message); err != nil {
+			run.Status, run.LastError, run.FailedAt = assistants.StatusFailed, fmt.Sprintf("failed to add the reply to the thread: %v", err), &now
+			break
+		}
+		run.Status, run.CompletedAt = assistants.StatusCompleted, &now
+	}
+	if err := a.store.SaveRun(context.Background(), run); err != nil {
+		logger.Warn("failed to save assistants run %s: %v", run.ID, err)
+	}
+}
+
+// saveRun saves the progress of a run being executed
+func (a *Assistants) saveRun(run *assistants.Run) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.store.SaveRun(context.Background(), run); err != nil {
+		logger.Warn("failed to save assistants run %s: %v", run.ID, err)
+	}
+}
+
+// countAssistantSteps returns the number of model calls recorded in steps
+func countAssistantSteps(steps []schemas.ChatMessage) int {
+	count := 0
+	for _, step := range steps {
+		if step.Role == schemas.ChatMessageRoleAssistant {
+			count++
+		}
+	}
+	return count
+}
+
+// hasFunctionTool reports whether tools declare the function name
+func hasFunctionTool(tools []schemas.ChatTool, name string) bool {
+	return slices.ContainsFunc(tools, func(tool schemas.ChatTool) bool {
+		return tool.Function != nil && tool.Function.Name == name
+	})
+}
+
+// toolMessage is the output of a tool call
+func toolMessage(callID, output string) schemas.ChatMessage {
+	return schemas.ChatMessage{
+		Role:            schemas.ChatMessageRoleTool,
+		Content:         &schemas.ChatMessageContent{ContentStr: &output},
+		ChatToolMessage: &schemas.ChatToolMessage{ToolCallID: &callID},
+	}
+}
+
+// initAssistants sets up the Assistants API layer. Threads are kept in the sessions store, or in a sessions
+// store of their own when sessions are off; assistants and runs are kept in the config store database or in memory.
+func (s *Config) initAssistants(ctx context.Context, config *AssistantsConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	var db *gorm.DB
+	if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("assistants, threads and runs are kept in memory since no config store is configured")
+	}
+	threads := s.Sessions
+	if threads == nil {
+		var err error
+		if threads, err = sessions.NewStore(ctx, db); err != nil {
+			return fmt.Errorf("failed to initialize assistants threads: %w", err)
+		}
+	}
+	store, err := assistants.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize assistants store: %w", err)
+	}
+	s.Assistants = NewAssistants(*config, store, threads, s)
+	return nil
+}
@@ -0,0 +1,568 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/asyncqueue"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const (
+	// AsyncHeader asks for a request to be queued and executed in the background
+	AsyncHeader = "x-bifrost-async"
+	// AsyncWebhookHeader names the webhook receiving the result of an async request
+	AsyncWebhookHeader = "x-bifrost-async-webhook"
+	// AsyncSignatureHeader signs webhook deliveries, in the format "t=<unix seconds>,sig=<hex signature>" where
+	// the signature is the hex HMAC-SHA256, with the webhook secret, of "<timestamp>.<body>"
+	AsyncSignatureHeader = "x-bifrost-signature"
+)
+
+const (
+	asyncPollInterval      = time.Second
+	asyncPurgeInterval     = 10 * time.Minute
+	asyncStaleAfter        = 10 * time.Minute // Running jobs not updated for this long were abandoned, e.g. by a crash
+	asyncWebhookAttempts   = 3
+	asyncWebhookTimeout    = 10 * time.Second
+	asyncWebhookRetryDelay = time.Second
+
+	// asyncCredentialsKeyConfigKey stores the key encrypting the credential headers of queued requests
+	asyncCredentialsKeyConfigKey = "async_credentials_key"
+)
+
+// AsyncResult is the response of one attempt of an async job.
+type AsyncResult struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// AsyncExecutor executes the request of a job through the gateway.
+type AsyncExecutor func(ctx context.Context, job *asyncqueue.Job) AsyncResult
+
+// AsyncJobResponse is the state of an async job, returned when polling it and posted to webhooks once it finishes.
+type AsyncJobResponse struct {
+	ID            string            `json:"id"`
+	Object        string            `json:"object"`
+	Status        asyncqueue.Status `json:"status"`
+	Provider      string            `json:"provider,omitempty"`
+	Model         string            `json:"model,omitempty"`
+	Attempts      int               `json:"attempts"`
+	NextAttemptAt *time.Time        `json:"next_attempt_at,omitempty"` // Set while queued
+	StatusCode    int               `json:"status_code,omitempty"`     // HTTP status of the last attempt
+	Response      json.RawMessage   `json:"response,omitempty"`        // Body of the last attempt, a string when it is not JSON
+	Error         string            `json:"error,omitempty"`
+	WebhookStatus string            `json:"webhook_status,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	CompletedAt   *time.Time        `json:"completed_at,omitempty"`
+}
+
+// NewAsyncJobResponse describes a job.
+func NewAsyncJobResponse(job *asyncqueue.Job) AsyncJobResponse {
+	response := AsyncJobResponse{
+		ID:            job.ID,
+		Object:        "async.job",
+		Status:        job.Status,
+		Provider:      job.Provider,
+		Model:         job.Model,
+		Attempts:      job.Attempts,
+		StatusCode:    job.StatusCode,
+		Error:         job.Error,
+		WebhookStatus: job.WebhookStatus,
+		CreatedAt:     job.CreatedAt,
+		CompletedAt:   job.CompletedAt,
+	}
+	if job.Status == asyncqueue.StatusQueued {
+		nextAttemptAt := job.NextAttemptAt
+		response.NextAttemptAt = &nextAttemptAt
+	}
+	if job.ResponseBody != "" {
+		if json.Valid([]byte(job.ResponseBody)) {
+			response.Response = json.RawMessage(job.ResponseBody)
+		} else if quoted, err := json.Marshal(job.ResponseBody); err == nil {
+			response.Response = quoted
+		}
+	}
+	return response
+}
+
+// AsyncQueue executes queued requests in the background. Attempts failing with a transient status are retried
+// with exponential backoff, and requests to a provider the health probes report as unhealthy wait for it to
+// recover without spending attempts.
+type AsyncQueue struct {
+	config        asyncqueue.Config
+	store         asyncqueue.Store
+	health        *ProviderHealthTracker
+	client        *http.Client // Delivers to the configured webhook
+	requestClient *http.Client // Delivers to request webhooks, only to public addresses unless private ones are allowed
+	credentials   cipher.AEAD  // Encrypts the credential headers of queued requests
+	wake          chan struct{}
+}
+
+// NewAsyncQueue creates a queue over store, applying defaults to unset config values. health may be nil.
+// Credentials are encrypted with a random key, so jobs stored by another process cannot be replayed with them
+// unless the queue is given the key they were encrypted with.
+func NewAsyncQueue(config asyncqueue.Config, store asyncqueue.Store, health *ProviderHealthTracker) *AsyncQueue {
+	config = config.WithDefaults()
+	dialer := &net.Dialer{Timeout: asyncWebhookTimeout}
+	if !config.AllowPrivateWebhooks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if addr, err := netip.ParseAddr(host); err != nil || !IsPublicAddr(addr) {
+				return fmt.Errorf("webhooks may not point to the private address %s", host)
+			}
+			return nil
+		}
+	}
+	requestClient := &http.Client{
+		Timeout:   asyncWebhookTimeout,
+		Transport: &http.Transport{Proxy: nil, DialContext: dialer.DialContext, TLSHandshakeTimeout: asyncWebhookTimeout},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	registerAsyncMetrics()
+	queue := &AsyncQueue{
+		config:        config,
+		store:         store,
+		health:        health,
+		client:        &http.Client{Timeout: asyncWebhookTimeout},
+		requestClient: requestClient,
+		wake:          make(chan struct{}, 1),
+	}
+	if err := queue.setCredentialsKey(newAsyncCredentialsKey()); err != nil {
+		panic(err) // A 32 byte key is always valid
+	}
+	return queue
+}
+
+// newAsyncCredentialsKey generates a key encrypting credential headers
+func newAsyncCredentialsKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// setCredentialsKey sets the AES-256 key encrypting credential headers
+func (q *AsyncQueue) setCredentialsKey(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	q.credentials = aead
+	return nil
+}
+
+// SealCredentials encrypts the credential headers of a request for storage with its job.
+func (q *AsyncQueue) SealCredentials(headers map[string]string) (string, error) {
+	plaintext, err := json.Marshal(headers)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, q.credentials.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(q.credentials.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// OpenCredentials decrypts the credential headers sealed with SealCredentials.
+func (q *AsyncQueue) OpenCredentials(sealed string) (map[string]string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < q.credentials.NonceSize() {
+		return nil, errors.New("sealed credentials are too short")
+	}
+	nonce, ciphertext := data[:q.credentials.NonceSize()], data[q.credentials.NonceSize():]
+	plaintext, err := q.credentials.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(plaintext, &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// CheckRequestWebhook reports why a request may not name rawURL as its webhook, or nil if it may.
+func (q *AsyncQueue) CheckRequestWebhook(rawURL string) error {
+	if !q.config.RequestWebhooks {
+		return fmt.Errorf("request webhooks are disabled")
+	}
+	if err := asyncqueue.ValidateWebhookURL(rawURL); err != nil {
+		return err
+	}
+	parsed, _ := url.Parse(rawURL)
+	if !schemas.EgressHostAllowed(q.config.AllowedWebhookHosts, parsed.Hostname()) {
+		return fmt.Errorf("webhooks may not point to %s", parsed.Hostname())
+	}
+	if addr, err := netip.ParseAddr(parsed.Hostname()); err == nil && !q.config.AllowPrivateWebhooks && !IsPublicAddr(addr) {
+		return fmt.Errorf("webhooks may not point to the private address %s", parsed.Hostname())
+	}
+	return nil
+}
+
+// Enqueue assigns an ID to a job and queues it for immediate execution.
+func (q *AsyncQueue) Enqueue(ctx context.Context, job *asyncqueue.Job) error {
+	now := time.Now()
+	job.ID = uuid.NewString()
+	job.Status = asyncqueue.StatusQueued
+	job.NextAttemptAt = now
+	job.CreatedAt = now
+	if err := q.store.Save(ctx, job); err != nil {
+		return err
+	}
+	asyncJobs.WithLabelValues(string(asyncqueue.StatusQueued)).Inc()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Get returns a job, or asyncqueue.ErrNotFound.
+func (q *AsyncQueue) Get(ctx context.Context, id string) (*asyncqueue.Job, error) {
+	return q.store.Get(ctx, id)
+}
+
+// Start executes due jobs with execute until ctx is done.
+func (q *AsyncQueue) Start(ctx context.Context, execute AsyncExecutor) {
+	go q.run(ctx, execute)
+}
+
+// run claims due jobs whenever workers are free, and requeues abandoned jobs and purges old results periodically
+func (q *AsyncQueue) run(ctx context.Context, execute AsyncExecutor) {
+	poll := time.NewTicker(asyncPollInterval)
+	defer poll.Stop()
+	purge := time.NewTicker(asyncPurgeInterval)
+	defer purge.Stop()
+	slots := make(chan struct{}, q.config.Workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	q.maintain(ctx)
+	for {
+		if free := cap(slots) - len(slots); free > 0 {
+			jobs, err := q.store.Claim(ctx, time.Now(), free)
+			if err != nil && ctx.Err() == nil {
+				logger.Warn("failed to claim async jobs: %v", err)
+			}
+			for i := range jobs {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func(job *asyncqueue.Job) {
+					defer func() {
+						<-slots
+						wg.Done()
+						select {
+						case q.wake <- struct{}{}:
+						default:
+						}
+					}()
+					q.execute(ctx, job, execute)
+				}(&jobs[i])
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-poll.C:
+		case <-purge.C:
+			q.maintain(ctx)
+		}
+	}
+}
+
+// maintain requeues abandoned jobs and deletes the results kept longer than the retention
+func (q *AsyncQueue) maintain(ctx context.Context) {
+	now := time.Now()
+	if requeued, err := q.store.Requeue(ctx, now.Add(-asyncStaleAfter)); err != nil {
+		logger.Warn("failed to requeue abandoned async jobs: %v", err)
+	} else if requeued > 0 {
+		logger.Info("requeued %d abandoned async jobs", requeued)
+	}
+	if _, err := q.store.Purge(ctx, now.Add(-time.Duration(q.config.RetentionHours)*time.Hour)); err != nil {
+		logger.Warn("failed to purge async jobs: %v", err)
+	}
+}
+
+// execute runs one attempt of a claimed job, unless it expired or its provider is unhealthy, and saves the outcome
+func (q *AsyncQueue) execute(ctx context.Context, job *asyncqueue.Job, execute AsyncExecutor) {
+	now := time.Now()
+	switch {
+	case now.Sub(job.CreatedAt) > time.Duration(q.config.MaxAgeHours)*time.Hour:
+		job.Status = asyncqueue.StatusExpired
+		if job.Error == "" {
+			job.Error = fmt.Sprintf("not completed within %d hours", q.config.MaxAgeHours)
+		}
+	case q.health != nil && job.Provider != "" && q.health.IsUnhealthy(schemas.ModelProvider(job.Provider)):
+		job.Status = asyncqueue.StatusQueued
+		job.NextAttemptAt = now.Add(time.Duration(q.config.RetryIntervalSeconds) * time.Second)
+	default:
+		result := execute(ctx, job)
+		if ctx.Err() != nil {
+			// Shutting down: the job is requeued once abandoned
+			return
+		}
+		job.Attempts++
+		job.StatusCode = result.StatusCode
+		job.ResponseContentType = result.ContentType
+		job.ResponseBody = string(result.Body)
+		job.Error = ""
+		switch {
+		case result.StatusCode >= 200 && result.StatusCode < 300:
+			job.Status = asyncqueue.StatusSucceeded
+		case isTransientAsyncStatus(result.StatusCode) && job.Attempts < q.config.MaxAttempts:
+			job.Status = asyncqueue.StatusQueued
+			job.NextAttemptAt = now.Add(q.backoff(job.Attempts))
+			job.Error = asyncErrorMessage(result)
+		default:
+			job.Status = asyncqueue.StatusFailed
+			job.Error = asyncErrorMessage(result)
+		}
+	}
+	if job.Status.Finished() {
+		completedAt := time.Now()
+		job.CompletedAt = &completedAt
+		// Credentials are only kept while they may be needed to replay the request
+		job.RequestCredentials = ""
+		asyncJobs.WithLabelValues(string(job.Status)).Inc()
+	}
+	if err := q.store.Save(ctx, job); err != nil {
+		logger.Warn("failed to save async job %s: %v", job.ID, err)
+		return
+	}
+	if job.Status.Finished() && (q.config.Webhook != nil || job.WebhookURL != "") {
+		job.WebhookStatus = "delivered"
+		if err := q.deliver(ctx, job); err != nil {
+			logger.Warn("failed to deliver the result of async job %s: %v", job.ID, err)
+			job.WebhookStatus = "failed"
+		}
+		if err := q.store.Save(ctx, job); err != nil {
+			logger.Warn("failed to save async job %s: %v", job.ID, err)
+		}
+	}
+}
+
+// backoff is the wait after the given number of failed attempts
+func (q *AsyncQueue) backoff(attempts int) time.Duration {
+	interval := time.Duration(q.config.RetryIntervalSeconds) * time.Second
+	limit := time.Duration(q.config.MaxRetryIntervalSeconds) * time.Second
+	for i := 1; i < attempts && interval < limit; i++ {
+		interval *= 2
+	}
+	return min(interval, limit)
+}
+
+// isTransientAsyncStatus reports whether an attempt failing with status is retried. Status 0 means the request
+// could not be executed.
+func isTransientAsyncStatus(status int) bool {
+	switch status {
+	case 0, http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// asyncErrorMessage extracts the error message of a failed attempt
+func asyncErrorMessage(result AsyncResult) string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(result.Body, &body) == nil && body.Error.Message != "" {
+		return body.Error.Message
+	}
+	if result.StatusCode == 0 {
+		return "request could not be executed"
+	}
+	return fmt.Sprintf("request failed with status %d", result.StatusCode)
+}
+
+// deliver posts the result of a finished job to the configured webhook and to the webhook of the request
+func (q *AsyncQueue) deliver(ctx context.Context, job *asyncqueue.Job) error {
+	body, err := json.Marshal(NewAsyncJobResponse(job))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	if q.config.Webhook != nil {
+		errs = append(errs, q.post(ctx, q.client, q.config.Webhook.URL, q.config.Webhook.Headers, body))
+	}
+	if job.WebhookURL != "" {
+		errs = append(errs, q.post(ctx, q.requestClient, job.WebhookURL, nil, body))
+	}
+	return errors.Join(errs...)
+}
+
+// post delivers a result to a webhook, retrying failed deliveries a few times
+func (q *AsyncQueue) post(ctx context.Context, client *http.Client, webhookURL string, headers map[string]string, body []byte) error {
+	var err error
+	for attempt := 0; attempt < asyncWebhookAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(asyncWebhookRetryDelay << (attempt - 1)):
+			}
+		}
+		if err = q.postOnce(ctx, client, webhookURL, headers, body); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// postOnce sends one webhook delivery
+func (q *AsyncQueue) postOnce(ctx context.Context, client *http.Client, webhookURL string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if q.config.Webhook != nil && q.config.Webhook.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(q.config.Webhook.Secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set(AsyncSignatureHeader, "t="+timestamp+",sig="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// asyncJobs counts the jobs queued and finished, by status
+var asyncJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "bifrost_async_jobs_total",
+	Help: "Async requests queued and finished, by status (queued, succeeded, failed or expired).",
+}, []string{"status"})
+
+// registerAsyncMetrics registers the async job counter with the default registry
+func registerAsyncMetrics() {
+	if err := prometheus.Register(asyncJobs); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) {
+			logger.Warn("failed to register async metrics: %v", err)
+		}
+	}
+}
+
+// initAsync sets up the queue of async requests. Jobs are stored in the logs database, in the config store
+// database when logs are kept elsewhere, or in memory. The queue starts once the server runs.
+func (s *Config) initAsync(ctx context.Context, config *asyncqueue.Config) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	resolved := *config
+	if config.Webhook != nil {
+		webhook := *config.Webhook
+		webhook.Headers = make(map[string]string, len(config.Webhook.Headers))
+		for name, value := range config.Webhook.Headers {
+			resolvedValue, _, err := s.processEnvValue(value)
+			if err != nil {
+				return fmt.Errorf("async: webhook header %s: %w", name, err)
+			}
+			webhook.Headers[name] = resolvedValue
+		}
+		secret, _, err := s.processEnvValue(config.Webhook.Secret)
+		if err != nil {
+			return fmt.Errorf("async: webhook secret: %w", err)
+		}
+		webhook.Secret = secret
+		resolved.Webhook = &webhook
+	}
+
+	var db *gorm.DB
+	if logsDB, ok := s.LogsStore.(interface{ DB() *gorm.DB }); ok {
+		db = logsDB.DB()
+	} else if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("async requests are queued in memory since no logs or config store database is configured")
+	}
+	store, err := asyncqueue.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize async jobs store: %w", err)
+	}
+	queue := NewAsyncQueue(resolved, store, s.ProviderHealth)
+	// Replicas sharing the config store share the key, so that any of them can replay the jobs of the others
+	key, err := s.loadAsyncCredentialsKey(ctx)
+	if err != nil {
+		return fmt.Errorf("async: failed to load the credentials key: %w", err)
+	}
+	if key != nil {
+		if err := queue.setCredentialsKey(key); err != nil {
+			return fmt.Errorf("async: invalid credentials key: %w", err)
+		}
+	}
+	s.AsyncQueue = queue
+	return nil
+}
+
+// loadAsyncCredentialsKey returns the key encrypting the credential headers of queued requests from the config
+// store, generating it on first use. It returns nil without a config store.
+func (s *Config) loadAsyncCredentialsKey(ctx context.Context) ([]byte, error) {
+	if s.ConfigStore == nil {
+		return nil, nil
+	}
+	var encoded string
+	found, err := s.loadStoredConfig(ctx, asyncCredentialsKeyConfigKey, &encoded)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		encoded = base64.StdEncoding.EncodeToString(newAsyncCredentialsKey())
+		if err := s.saveStoredConfig(ctx, asyncCredentialsKeyConfigKey, encoded); err != nil {
+			return nil, err
+		}
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
@@ -16,11 +16,20 @@ import (
 	"github.com/google/uuid"
 	bifrost "github.com/maximhq/bifrost/core"
 	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/asyncqueue"
+	"github.com/maximhq/bifrost/framework/cluster"
 	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/evaluation"
 	"github.com/maximhq/bifrost/framework/logstore"
+	"github.com/maximhq/bifrost/framework/moderation"
 	"github.com/maximhq/bifrost/framework/pricing"
+	"github.com/maximhq/bifrost/framework/recording"
+	"github.com/maximhq/bifrost/framework/redaction"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"github.com/maximhq/bifrost/framework/sessions"
 	"github.com/maximhq/bifrost/framework/vectorstore"
 	"github.com/maximhq/bifrost/plugins/semanticcache"
+	"github.com/maximhq/bifrost/plugins/telemetry"
 	"gorm.io/gorm"
 )
 
@@ -44,6 +53,52 @@ type ConfigData struct {
 	ConfigStoreConfig *configstore.Config                   `json:"config_store,omitempty"`
 	LogsStoreConfig   *logstore.Config                      `json:"logs_store,omitempty"`
 	Plugins           []*schemas.PluginConfig               `json:"plugins,omitempty"`
+	Cluster           *cluster.Config                       `json:"cluster,omitempty"`
+	Redaction         *redaction.Config                     `json:"redaction,omitempty"`
+	AccessLog         *AccessLogConfig                      `json:"access_log,omitempty"`
+	ProviderHealth    *ProviderHealthConfig                 `json:"provider_health,omitempty"`
+	ProviderCooldowns *ProviderCooldownsConfig              `json:"provider_cooldowns,omitempty"`
+	Scaling           *ScalingConfig                        `json:"scaling,omitempty"`
+	Kubernetes        *KubernetesSyncConfig                 `json:"kubernetes,omitempty"`
+	Transformations   []TransformationRule                  `json:"transformation_rules,omitempty"`
+	ResponseRules     []ResponseTransformRule               `json:"response_transform_rules,omitempty"`
+	SystemPrompts     []SystemPromptPolicy                  `json:"system_prompt_policies,omitempty"`
+	Sessions          *sessions.Config                      `json:"sessions,omitempty"`
+	ContextWindow     *ContextWindowConfig                  `json:"context_window,omitempty"`
+	Vision            *VisionConfig                         `json:"vision,omitempty"`
+	Moderation        *moderation.Config                    `json:"moderation,omitempty"`
+	Experiments       []Experiment                          `json:"experiments,omitempty"`
+	LanguageRouting   *LanguageRoutingConfig                `json:"language_routing,omitempty"`
+	RoutingOverrides  *RoutingOverridesConfig               `json:"routing_overrides,omitempty"`
+	TenantDomains     []TenantDomain                        `json:"tenant_domains,omitempty"`
+	Evaluation        *evaluation.Config                    `json:"evaluation,omitempty"`
+	PublicRoutes      []PublicRoute                         `json:"public_routes,omitempty"`
+	Recording         *recording.Config                     `json:"recording,omitempty"`
+	Egress            *EgressConfig                         `json:"egress,omitempty"`
+	TLS               *ListenerTLSConfig                    `json:"tls,omitempty"`
+	Listeners         []ListenerConfig                      `json:"listeners,omitempty"`
+	SecurityHeaders   *SecurityHeadersConfig                `json:"security_headers,omitempty"`
+	RequestSigning    *RequestSigningConfig                 `json:"request_signing,omitempty"`
+	JWTAuth           *JWTAuthConfig                        `json:"jwt_auth,omitempty"`
+	StripeMetering    *StripeMeteringConfig                 `json:"stripe_metering,omitempty"`
+	DataResidency     *DataResidencyConfig                  `json:"data_residency,omitempty"`
+	ZeroDataRetention *ZeroDataRetentionConfig              `json:"zero_data_retention,omitempty"`
+	StreamAggregation *StreamAggregationConfig              `json:"stream_aggregation,omitempty"`
+	CostCeiling       *CostCeilingConfig                    `json:"cost_ceiling,omitempty"`
+	PluginPolicies    PluginPoliciesConfig                  `json:"plugin_policies,omitempty"`
+	Policies          *PoliciesConfig                       `json:"policies,omitempty"`
+	SecurityEvents    *SecurityEventsConfig                 `json:"security_events,omitempty"`
+	StatsD            *StatsDConfig                         `json:"statsd,omitempty"`
+	Metrics           *telemetry.Config                     `json:"metrics,omitempty"`
+	SLOs              *SLOConfig                            `json:"slos,omitempty"`
+	Race              *RaceConfig                           `json:"race,omitempty"`
+	Async             *asyncqueue.Config                    `json:"async,omitempty"`
+	FineTuning        *FineTuningConfig                     `json:"fine_tuning,omitempty"`
+	Assistants        *AssistantsConfig                     `json:"assistants,omitempty"`
+	Retrieval         *RetrievalConfig                      `json:"retrieval,omitempty"`
+	Playground        *PlaygroundConfig                     `json:"playground,omitempty"`
+	Traces            *TracesConfig                         `json:"traces,omitempty"`
+	KeyLeaks          *KeyLeakConfig                        `json:"key_leaks,omitempty"`
 }
 
 // UnmarshalJSON unmarshals the ConfigData from JSON using internal unmarshallers
@@ -60,6 +115,52 @@ func (cd *ConfigData) UnmarshalJSON(data []byte) error {
 		ConfigStoreConfig json.RawMessage                       `json:"config_store,omitempty"`
 		LogsStoreConfig   json.RawMessage                       `json:"logs_store,omitempty"`
 		Plugins           []*schemas.PluginConfig               `json:"plugins,omitempty"`
+		Cluster           *cluster.Config                       `json:"cluster,omitempty"`
+		Redaction         *redaction.Config                     `json:"redaction,omitempty"`
+		AccessLog         *AccessLogConfig                      `json:"access_log,omitempty"`
+		ProviderHealth    *ProviderHealthConfig                 `json:"provider_health,omitempty"`
+		ProviderCooldowns *ProviderCooldownsConfig              `json:"provider_cooldowns,omitempty"`
+		Scaling           *ScalingConfig                        `json:"scaling,omitempty"`
+		Kubernetes        *KubernetesSyncConfig                 `json:"kubernetes,omitempty"`
+		Transformations   []TransformationRule                  `json:"transformation_rules,omitempty"`
+		ResponseRules     []ResponseTransformRule               `json:"response_transform_rules,omitempty"`
+		SystemPrompts     []SystemPromptPolicy                  `json:"system_prompt_policies,omitempty"`
+		Sessions          *sessions.Config                      `json:"sessions,omitempty"`
+		ContextWindow     *ContextWindowConfig                  `json:"context_window,omitempty"`
+		Vision            *VisionConfig                         `json:"vision,omitempty"`
+		Moderation        *moderation.Config                    `json:"moderation,omitempty"`
+		Experiments       []Experiment                          `json:"experiments,omitempty"`
+		LanguageRouting   *LanguageRoutingConfig                `json:"language_routing,omitempty"`
+		RoutingOverrides  *RoutingOverridesConfig               `json:"routing_overrides,omitempty"`
+		TenantDomains     []TenantDomain                        `json:"tenant_domains,omitempty"`
+		Evaluation        *evaluation.Config                    `json:"evaluation,omitempty"`
+		PublicRoutes      []PublicRoute                         `json:"public_routes,omitempty"`
+		Recording         *recording.Config                     `json:"recording,omitempty"`
+		Egress            *EgressConfig                         `json:"egress,omitempty"`
+		TLS               *ListenerTLSConfig                    `json:"tls,omitempty"`
+		Listeners         []ListenerConfig                      `json:"listeners,omitempty"`
+		SecurityHeaders   *SecurityHeadersConfig                `json:"security_headers,omitempty"`
+		RequestSigning    *RequestSigningConfig                 `json:"request_signing,omitempty"`
+		JWTAuth           *JWTAuthConfig                        `json:"jwt_auth,omitempty"`
+		StripeMetering    *StripeMeteringConfig                 `json:"stripe_metering,omitempty"`
+		DataResidency     *DataResidencyConfig                  `json:"data_residency,omitempty"`
+		ZeroDataRetention *ZeroDataRetentionConfig              `json:"zero_data_retention,omitempty"`
+		StreamAggregation *StreamAggregationConfig              `json:"stream_aggregation,omitempty"`
+		CostCeiling       *CostCeilingConfig                    `json:"cost_ceiling,omitempty"`
+		PluginPolicies    PluginPoliciesConfig                  `json:"plugin_policies,omitempty"`
+		Policies          *PoliciesConfig                       `json:"policies,omitempty"`
+		SecurityEvents    *SecurityEventsConfig                 `json:"security_events,omitempty"`
+		StatsD            *StatsDConfig                         `json:"statsd,omitempty"`
+		Metrics           *telemetry.Config                     `json:"metrics,omitempty"`
+		SLOs              *SLOConfig                            `json:"slos,omitempty"`
+		Race              *RaceConfig                           `json:"race,omitempty"`
+		Async             *asyncqueue.Config                    `json:"async,omitempty"`
+		FineTuning        *FineTuningConfig                     `json:"fine_tuning,omitempty"`
+		Assistants        *AssistantsConfig                     `json:"assistants,omitempty"`
+		Retrieval         *RetrievalConfig                      `json:"retrieval,omitempty"`
+		Playground        *PlaygroundConfig                     `json:"playground,omitempty"`
+		Traces            *TracesConfig                         `json:"traces,omitempty"`
+		KeyLeaks          *KeyLeakConfig                        `json:"key_leaks,omitempty"`
 	}
 
 	var temp TempConfigData
@@ -73,6 +174,52 @@ func (cd *ConfigData) UnmarshalJSON(data []byte) error {
 	cd.MCP = temp.MCP
 	cd.Governance = temp.Governance
 	cd.Plugins = temp.Plugins
+	cd.Cluster = temp.Cluster
+	cd.Redaction = temp.Redaction
+	cd.AccessLog = temp.AccessLog
+	cd.ProviderHealth = temp.ProviderHealth
+	cd.ProviderCooldowns = temp.ProviderCooldowns
+	cd.Scaling = temp.Scaling
+	cd.Kubernetes = temp.Kubernetes
+	cd.Transformations = temp.Transformations
+	cd.ResponseRules = temp.ResponseRules
+	cd.SystemPrompts = temp.SystemPrompts
+	cd.Sessions = temp.Sessions
+	cd.ContextWindow = temp.ContextWindow
+	cd.Vision = temp.Vision
+	cd.Moderation = temp.Moderation
+	cd.Experiments = temp.Experiments
+	cd.LanguageRouting = temp.LanguageRouting
+	cd.RoutingOverrides = temp.RoutingOverrides
+	cd.TenantDomains = temp.TenantDomains
+	cd.Evaluation = temp.Evaluation
+	cd.PublicRoutes = temp.PublicRoutes
+	cd.Recording = temp.Recording
+	cd.Egress = temp.Egress
+	cd.TLS = temp.TLS
+	cd.Listeners = temp.Listeners
+	cd.SecurityHeaders = temp.SecurityHeaders
+	cd.RequestSigning = temp.RequestSigning
+	cd.JWTAuth = temp.JWTAuth
+	cd.StripeMetering = temp.StripeMetering
+	cd.DataResidency = temp.DataResidency
+	cd.ZeroDataRetention = temp.ZeroDataRetention
+	cd.StreamAggregation = temp.StreamAggregation
+	cd.CostCeiling = temp.CostCeiling
+	cd.PluginPolicies = temp.PluginPolicies
+	cd.Policies = temp.Policies
+	cd.SecurityEvents = temp.SecurityEvents
+	cd.StatsD = temp.StatsD
+	cd.Metrics = temp.Metrics
+	cd.SLOs = temp.SLOs
+	cd.Race = temp.Race
+	cd.Async = temp.Async
+	cd.FineTuning = temp.FineTuning
+	cd.Assistants = temp.Assistants
+	cd.Retrieval = temp.Retrieval
+	cd.Playground = temp.Playground
+	cd.Traces = temp.Traces
+	cd.KeyLeaks = temp.KeyLeaks
 
 	// Parse VectorStoreConfig using its internal unmarshaler
 	if len(temp.VectorStoreConfig) > 0 {
@@ -144,10 +291,182 @@ type Config struct {
 	// Pricing manager
 	PricingManager *pricing.PricingManager
 
+	// Leader elector coordinating background work across replicas
+	LeaderElector cluster.LeaderElector
+
+	// State shared by the plugins of the Bifrost instance, and by the transport features built like plugins
+	PluginState schemas.PluginState
+
+	// Redactor scrubbing PII from content before it reaches logs, event streams and traces (nil when disabled)
+	Redactor *redaction.Redactor
+
+	// HTTP access log settings (nil when access logging is off)
+	AccessLog *AccessLogConfig
+
+	// Provider health prober and its probe history (nil when probing is off)
+	ProviderHealth *ProviderHealthTracker
+
+	// Cooldowns of the providers that answered 429 with a Retry-After (nil when cooldowns are off)
+	ProviderCooldowns *ProviderCooldowns
+
+	// Load measurement and replica recommendations for autoscaling
+	Scaler *Scaler
+
+	// Kubernetes controller mode settings, with defaults applied, and its API client (nil when the mode is off)
+	KubernetesSync *KubernetesSyncConfig
+	Kubernetes     *KubernetesClient
+
+	// Server-side conversation sessions (nil when sessions are off)
+	SessionsConfig *sessions.Config
+	Sessions       sessions.Store
+
+	// Context window management settings (nil when it is off)
+	ContextWindow *ContextWindowConfig
+
+	// Validation, fetching and downscaling of image content parts (nil when it is off)
+	Vision *VisionProcessor
+
+	// Content moderation, its recorded outcomes and their review queue (nil when moderation is off)
+	Moderator         *moderation.Moderator
+	ModerationEvents  moderation.Store
+	ModerationReviews *moderation.Reviews
+
+	// Request transformation rules - atomic for lock-free reads on the request path
+	transformationRules atomic.Pointer[[]TransformationRule]
+
+	// Response transform rules, compiled - atomic for lock-free reads on the request path
+	responseTransforms atomic.Pointer[ResponseTransforms]
+
+	// Mandatory system prompt policies - atomic for lock-free reads on the request path
+	systemPromptPolicies atomic.Pointer[[]SystemPromptPolicy]
+
+	// A/B experiments - atomic for lock-free reads on the request path - and their aggregated outcomes
+	experiments       atomic.Pointer[[]Experiment]
+	ExperimentResults *ExperimentResults
+
+	// Language detection and routing by language - atomic for lock-free reads on the request path
+	languageRouting atomic.Pointer[LanguageRoutingConfig]
+
+	// Policies gating the routing override headers - atomic for lock-free reads on the request path
+	routingOverrides atomic.Pointer[RoutingOverridesConfig]
+
+	// Routing and governance policies, compiled - atomic for lock-free reads on the request path - and their versions
+	policies         atomic.Pointer[Policies]
+	policyVersions   []PoliciesConfig
+	policyVersionsMu sync.Mutex
+
+	// Values of the request labels reported to Prometheus, to bound the cardinality of the metrics
+	labelCardinality labelCardinality
+
+	// Custom hostnames of tenants and their dashboard branding - atomic for lock-free reads on the request path
+	tenantDomains atomic.Pointer[[]TenantDomain]
+
+	// History of applied configuration changes, for diffs and rollbacks
+	configVersions configVersions
+
+	// Latest live validation result of each provider key
+	keyValidations keyValidations
+
+	// Response evaluation and its recorded scores (nil when evaluation is off)
+	Evaluations      *evaluation.Runner
+	EvaluationScores evaluation.Store
+
+	// Upstream request recording and its recordings (nil when recording is off)
+	Recording  *recording.Config
+	Recordings recording.Store
+
+	// Proxy and host allowlist of provider connections (nil when unrestricted)
+	Egress *EgressConfig
+
+	// Jurisdictions of provider keys and the virtual keys and customers restricted to some (nil when unrestricted)
+	DataResidency *DataResidencyConfig
+
+	// Virtual keys and customers whose request content is never persisted (nil when no policy is set)
+	ZeroDataRetention *ZeroDataRetentionConfig
+
+	// Virtual keys whose non-streaming completions are streamed upstream and aggregated (nil when no policy is set)
+	StreamAggregation *StreamAggregationConfig
+
+	// Cost ceiling mode and default ceilings of virtual keys (nil when not configured; request ceilings still apply)
+	CostCeiling *CostCeilingConfig
+
+	// Priority and failure policy of plugins by name (nil when plugins run with the ones they declare)
+	PluginPolicies PluginPoliciesConfig
+
+	// TLS listener and client certificate authentication (nil when serving plain HTTP)
+	TLS *ListenerTLSConfig
+
+	// Listeners bound instead of the host and port flags, each serving the route groups it exposes (empty when
+	// one listener serves everything)
+	Listeners []ListenerConfig
+
+	// Security headers of UI and API responses (nil sends the defaults)
+	SecurityHeaders *SecurityHeadersConfig
+
+	// HMAC signature verification of inference requests (nil when request signing is off)
+	RequestVerifier *RequestVerifier
+
+	// JWT validation of inference requests against the JWKS of trusted issuers (nil when JWT auth is off)
+	JWTValidator *JWTValidator
+
+	// Exporter of customer usage to Stripe meters (nil when Stripe metering is off)
+	StripeMetering *StripeMeteringExporter
+
+	// Exporter of auth events, admin actions and policy violations to a SIEM (nil when security events are off)
+	SecurityEvents *SecurityEventExporter
+
+	// Pusher of Prometheus metrics to a DogStatsD agent or StatsD server (nil when StatsD export is off)
+	StatsD *StatsDExporter
+
+	// Key tiers and label cardinality limits of the Prometheus metrics (nil for the defaults)
+	Metrics *telemetry.Config
+
+	// Latency and availability objectives with their burn-rate alerts (nil when no SLOs are defined)
+	SLOs *SLOTracker
+
+	// Rules sending requests to two providers at once, keeping the first answer (nil when no races are defined)
+	Races *Racer
+
+	// Queue of requests executed in the background in async mode (nil when async mode is off)
+	AsyncQueue *AsyncQueue
+
+	// Proxy of fine-tuning jobs and the jobs it tracks (nil when fine-tuning is off)
+	FineTuning *FineTuner
+
+	// Assistants API compatibility layer, running assistants on threads (nil when it is off)
+	Assistants *Assistants
+
+	// Vector stores searched for retrieval-augmented generation (nil when retrieval is off)
+	Retrieval *Retriever
+
+	// Background indexing of the documents ingested into the retrieval stores (nil when retrieval is off)
+	Ingestion *Ingester
+
+	// Caps of the request playground of the dashboard (nil for the defaults)
+	Playground *PlaygroundConfig
+
+	// Timelines of the most recent inference requests (nil when tracing is off)
+	Traces *TraceStore
+
+	// Detection and suspension of leaked virtual keys (nil when off)
+	KeyLeaks *KeyLeakMonitor
+
 	// Admin authentication
 	// AdminSecret is a shared secret (password) used to protect management APIs and UI when Bifrost is exposed publicly.
-	// It is sourced from environment variables (BIFROST_ADMIN_PASSWORD or BIFROST_ADMIN_SECRET) at startup.
-	AdminSecret string
+	// It is sourced from environment variables (BIFROST_ADMIN_PASSWORD or BIFROST_ADMIN_SECRET) at startup
+	// and can be rotated at runtime; read it through GetAdminSecret once the server is running.
+	AdminSecret   string
+	adminSecretMu sync.RWMutex
+	// Dashboard sessions started by a login when there is no config store, see CreateUISession
+	uiSessions uiSessions
+	// CLI logins through the device authorization flow when there is no config store, see StartDeviceAuthorization
+	deviceLogins deviceLogins
+	// Public route rules overriding DefaultPublicRoutes - atomic for lock-free reads on the request path
+	publicRoutes atomic.Pointer[[]PublicRoute]
+	// Maintenance and read-only toggles - atomic for lock-free reads on the request path
+	systemMode atomic.Pointer[SystemMode]
+	// ServiceAccounts holds the scoped machine-to-machine tokens accepted alongside the admin secret
+	ServiceAccounts *serviceaccounts.Manager
 	// AdminCookieName is the name of the cookie used to persist an authenticated admin session.
 	// Defaults to "bf_admin".
 	AdminCookieName string
@@ -166,6 +485,46 @@ var DefaultClientConfig = configstore.ClientConfig{
 	EnableLiteLLMFallbacks:  false,
 }
 
+// PostgresDSNEnvVar is the environment variable that selects Postgres for the config and logs stores
+// when no config file is present. Without it Bifrost falls back to SQLite files in the app directory.
+const PostgresDSNEnvVar = "BIFROST_POSTGRES_DSN"
+
+// defaultConfigStoreConfig returns the config store used when no config file is present.
+func defaultConfigStoreConfig(configDBPath string) *configstore.Config {
+	if dsn := os.Getenv(PostgresDSNEnvVar); dsn != "" {
+		return &configstore.Config{
+			Enabled: true,
+			Type:    configstore.ConfigStoreTypePostgres,
+			Config:  &configstore.PostgresConfig{DSN: dsn},
+		}
+	}
+	return &configstore.Config{
+		Enabled: true,
+		Type:    configstore.ConfigStoreTypeSQLite,
+		Config: &configstore.SQLiteConfig{
+			Path: configDBPath,
+		},
+	}
+}
+
+// defaultLogsStoreConfig returns the logs store used when neither the config file nor the config store define one.
+func defaultLogsStoreConfig(logsDBPath string) *logstore.Config {
+	if dsn := os.Getenv(PostgresDSNEnvVar); dsn != "" {
+		return &logstore.Config{
+			Enabled: true,
+			Type:    logstore.LogStoreTypePostgres,
+			Config:  &logstore.PostgresConfig{DSN: dsn},
+		}
+	}
+	return &logstore.Config{
+		Enabled: true,
+		Type:    logstore.LogStoreTypeSQLite,
+		Config: &logstore.SQLiteConfig{
+			Path: logsDBPath,
+		},
+	}
+}
+
 // LoadConfig loads initial configuration from a JSON config file into memory
 // with full preprocessing including environment variable resolution and key config parsing.
 // All processing is done upfront to ensure zero latency when retrieving data.
@@ -187,10 +546,11 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 	logsDBPath := filepath.Join(configDirPath, "logs.db")
 	// Initialize config
 	config := &Config{
-		configPath: configFilePath,
-		EnvKeys:    make(map[string][]configstore.EnvKeyInfo),
-		Providers:  make(map[schemas.ModelProvider]configstore.ProviderConfig),
-		Plugins:    atomic.Pointer[[]schemas.Plugin]{},
+		configPath:  configFilePath,
+		EnvKeys:     make(map[string][]configstore.EnvKeyInfo),
+		Providers:   make(map[schemas.ModelProvider]configstore.ProviderConfig),
+		Plugins:     atomic.Pointer[[]schemas.Plugin]{},
+		PluginState: bifrost.NewInMemoryPluginState(),
 	}
 	// Initialize admin auth defaults early so they are available regardless of config source.
 	config.AdminCookieName = "bf_admin"
@@ -211,13 +571,7 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 		if os.IsNotExist(err) {
 			logger.Info("config file not found at path: %s, initializing with default values", absConfigFilePath)
 			// Initializing with default values
-			config.ConfigStore, err = configstore.NewConfigStore(ctx, &configstore.Config{
-				Enabled: true,
-				Type:    configstore.ConfigStoreTypeSQLite,
-				Config: &configstore.SQLiteConfig{
-					Path: configDBPath,
-				},
-			}, logger)
+			config.ConfigStore, err = configstore.NewConfigStore(ctx, defaultConfigStoreConfig(configDBPath), logger)
 			if err != nil {
 				return nil, fmt.Errorf("failed to initialize config store: %w", err)
 			}
@@ -245,13 +599,7 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 				return nil, fmt.Errorf("failed to get logs store config: %w", err)
 			}
 			if logStoreConfig == nil {
-				logStoreConfig = &logstore.Config{
-					Enabled: true,
-					Type:    logstore.LogStoreTypeSQLite,
-					Config: &logstore.SQLiteConfig{
-						Path: logsDBPath,
-					},
-				}
+				logStoreConfig = defaultLogsStoreConfig(logsDBPath)
 			}
 			logger.Info("config store initialized; initializing logs store.")
 			config.LogsStore, err = logstore.NewLogStore(ctx, logStoreConfig, logger)
@@ -302,6 +650,9 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 						ProxyConfig:              dbProvider.ProxyConfig,
 						SendBackRawResponse:      dbProvider.SendBackRawResponse,
 						CustomProviderConfig:     dbProvider.CustomProviderConfig,
+						MockConfig:               dbProvider.MockConfig,
+						RequestShaping:           dbProvider.RequestShaping,
+						SyntheticStreaming:       dbProvider.SyntheticStreaming,
 					}
 					if err := ValidateCustomProvider(providerConfig, provider); err != nil {
 						logger.Warn("invalid custom provider config for %s: %v", provider, err)
@@ -389,12 +740,58 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 			if err != nil {
 				return nil, fmt.Errorf("failed to update env keys: %w", err)
 			}
+			// Initializing leader elector
+			if err := config.initLeaderElector(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadTransformationRules(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadResponseTransformRules(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadSystemPromptPolicies(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadExperiments(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadLanguageRouting(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadRoutingOverrides(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadPolicies(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadTenantDomains(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadConfigVersions(ctx); err != nil {
+				return nil, err
+			}
+			if err := config.loadKeyValidations(ctx); err != nil {
+				return nil, err
+			}
+			if err := config.initServiceAccounts(ctx); err != nil {
+				return nil, err
+			}
+			if err := config.loadPublicRoutes(ctx, nil); err != nil {
+				return nil, err
+			}
+			if err := config.loadSystemMode(ctx); err != nil {
+				return nil, err
+			}
 			// Initializing pricing manager
 			pricingManager, err := pricing.Init(ctx, config.ConfigStore, logger)
 			if err != nil {
 				logger.Warn("failed to initialize pricing manager: %v", err)
 			}
 			config.PricingManager = pricingManager
+			if pricingManager != nil {
+				pricingManager.SetLeaderChecker(config.LeaderElector)
+			}
 			return config, nil
 		}
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -634,6 +1031,13 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 	} else if configData.Governance != nil {
 		logger.Debug("no governance config found in store, processing from config file")
 		config.GovernanceConfig = configData.Governance
+		for _, virtualKey := range configData.Governance.VirtualKeys {
+			if virtualKey.ParameterGuardrails != nil {
+				if err := virtualKey.ParameterGuardrails.Validate(); err != nil {
+					return nil, fmt.Errorf("virtual key %s: %w", virtualKey.ID, err)
+				}
+			}
+		}
 
 		if config.ConfigStore != nil {
 			logger.Debug("updating governance config in store")
@@ -666,6 +1070,13 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 					}
 				}
 
+				// Create projects
+				for _, project := range config.GovernanceConfig.Projects {
+					if err := config.ConfigStore.CreateProject(ctx, &project, tx); err != nil {
+						return fmt.Errorf("failed to create project %s: %w", project.ID, err)
+					}
+				}
+
 				// Create virtual keys
 				for _, virtualKey := range config.GovernanceConfig.VirtualKeys {
 					// Look up existing provider keys by key_id and populate the Keys field
@@ -778,16 +1189,243 @@ func LoadConfig(ctx context.Context, configDirPath string) (*Config, error) {
 		config.EnvKeys = make(map[string][]configstore.EnvKeyInfo)
 	}
 
+	// Initializing leader elector
+	if err := config.initLeaderElector(ctx, configData.Cluster); err != nil {
+		return nil, err
+	}
+
+	// Initializing redaction policies
+	config.Redactor, err = redaction.New(configData.Redaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize redaction: %w", err)
+	}
+
+	config.AccessLog = configData.AccessLog
+	if configData.ProviderHealth != nil && configData.ProviderHealth.Enabled {
+		config.ProviderHealth = NewProviderHealthTracker(*configData.ProviderHealth)
+	}
+	if configData.ProviderCooldowns != nil && configData.ProviderCooldowns.Enabled {
+		if err := configData.ProviderCooldowns.Validate(); err != nil {
+			return nil, err
+		}
+		config.ProviderCooldowns = NewProviderCooldowns(*configData.ProviderCooldowns, config.PluginState)
+	}
+	scalingConfig := ScalingConfig{}
+	if configData.Scaling != nil {
+		if err := configData.Scaling.Validate(); err != nil {
+			return nil, err
+		}
+		scalingConfig = *configData.Scaling
+	}
+	config.Scaler = NewScaler(scalingConfig)
+	if configData.Kubernetes != nil && configData.Kubernetes.Enabled {
+		if err := configData.Kubernetes.Validate(); err != nil {
+			return nil, err
+		}
+		kubernetesConfig := *configData.Kubernetes
+		if config.Kubernetes, err = NewKubernetesClient(&kubernetesConfig); err != nil {
+			return nil, err
+		}
+		config.KubernetesSync = &kubernetesConfig
+	}
+	if err := config.loadTransformationRules(ctx, configData.Transformations); err != nil {
+		return nil, err
+	}
+	if err := config.loadResponseTransformRules(ctx, configData.ResponseRules); err != nil {
+		return nil, err
+	}
+	if err := config.loadSystemPromptPolicies(ctx, configData.SystemPrompts); err != nil {
+		return nil, err
+	}
+	if err := config.initSessions(ctx, configData.Sessions); err != nil {
+		return nil, err
+	}
+	if configData.ContextWindow != nil && configData.ContextWindow.Enabled {
+		if err := configData.ContextWindow.Validate(); err != nil {
+			return nil, err
+		}
+		config.ContextWindow = configData.ContextWindow
+	}
+	if configData.Vision != nil && configData.Vision.Enabled {
+		if err := configData.Vision.Validate(); err != nil {
+			return nil, err
+		}
+		config.Vision = NewVisionProcessor(configData.Vision)
+	}
+	if err := config.initModeration(ctx, configData.Moderation); err != nil {
+		return nil, err
+	}
+	if err := config.loadExperiments(ctx, configData.Experiments); err != nil {
+		return nil, err
+	}
+	if err := config.loadLanguageRouting(ctx, configData.LanguageRouting); err != nil {
+		return nil, err
+	}
+	if err := config.loadRoutingOverrides(ctx, configData.RoutingOverrides); err != nil {
+		return nil, err
+	}
+	if err := config.loadPolicies(ctx, configData.Policies); err != nil {
+		return nil, err
+	}
+	if err := config.loadTenantDomains(ctx, configData.TenantDomains); err != nil {
+		return nil, err
+	}
+	if err := config.loadConfigVersions(ctx); err != nil {
+		return nil, err
+	}
+	if err := config.loadKeyValidations(ctx); err != nil {
+		return nil, err
+	}
+	if err := config.initEvaluation(ctx, configData.Evaluation); err != nil {
+		return nil, err
+	}
+	if err := config.initRecording(ctx, configData.Recording); err != nil {
+		return nil, err
+	}
+	if configData.Egress != nil {
+		if err := configData.Egress.Validate(); err != nil {
+			return nil, err
+		}
+		config.Egress = configData.Egress
+	}
+	if configData.DataResidency != nil {
+		if err := configData.DataResidency.Validate(); err != nil {
+			return nil, err
+		}
+		config.DataResidency = configData.DataResidency
+	}
+	if configData.ZeroDataRetention != nil {
+		if err := configData.ZeroDataRetention.Validate(); err != nil {
+			return nil, err
+		}
+		config.ZeroDataRetention = configData.ZeroDataRetention
+	}
+	if configData.StreamAggregation != nil {
+		if err := configData.StreamAggregation.Validate(); err != nil {
+			return nil, err
+		}
+		config.StreamAggregation = configData.StreamAggregation
+	}
+	if configData.CostCeiling != nil {
+		if err := configData.CostCeiling.Validate(); err != nil {
+			return nil, err
+		}
+		config.CostCeiling = configData.CostCeiling
+	}
+	if configData.PluginPolicies != nil {
+		if err := configData.PluginPolicies.Validate(); err != nil {
+			return nil, err
+		}
+		config.PluginPolicies = configData.PluginPolicies
+	}
+	if configData.TLS != nil {
+		if err := configData.TLS.Validate(); err != nil {
+			return nil, err
+		}
+		config.TLS = configData.TLS
+	}
+	if len(configData.Listeners) > 0 {
+		if err := ValidateListeners(configData.Listeners, configData.TLS != nil); err != nil {
+			return nil, err
+		}
+		config.Listeners = configData.Listeners
+	}
+	if configData.SecurityHeaders != nil {
+		if err := configData.SecurityHeaders.Validate(); err != nil {
+			return nil, err
+		}
+		config.SecurityHeaders = configData.SecurityHeaders
+	}
+	if err := config.initRequestSigning(configData.RequestSigning); err != nil {
+		return nil, err
+	}
+	if err := config.initJWTAuth(configData.JWTAuth); err != nil {
+		return nil, err
+	}
+	if err := config.initStripeMetering(configData.StripeMetering); err != nil {
+		return nil, err
+	}
+	if err := config.initSecurityEvents(configData.SecurityEvents); err != nil {
+		return nil, err
+	}
+	if err := config.initStatsD(configData.StatsD); err != nil {
+		return nil, err
+	}
+	config.Metrics = configData.Metrics
+	if err := config.initSLOs(configData.SLOs); err != nil {
+		return nil, err
+	}
+	if err := config.initRaces(configData.Race); err != nil {
+		return nil, err
+	}
+	if err := config.initAsync(ctx, configData.Async); err != nil {
+		return nil, err
+	}
+	if err := config.initFineTuning(ctx, configData.FineTuning); err != nil {
+		return nil, err
+	}
+	if err := config.initAssistants(ctx, configData.Assistants); err != nil {
+		return nil, err
+	}
+	if err := config.initRetrieval(ctx, configData.Retrieval); err != nil {
+		return nil, err
+	}
+	if configData.Playground != nil {
+		if err := configData.Playground.Validate(); err != nil {
+			return nil, err
+		}
+		config.Playground = configData.Playground
+	}
+	if err := config.initTraces(configData.Traces); err != nil {
+		return nil, err
+	}
+	if err := config.initKeyLeaks(configData.KeyLeaks); err != nil {
+		return nil, err
+	}
+	if err := config.initServiceAccounts(ctx); err != nil {
+		return nil, err
+	}
+	if err := config.loadPublicRoutes(ctx, configData.PublicRoutes); err != nil {
+		return nil, err
+	}
+	if err := config.loadSystemMode(ctx); err != nil {
+		return nil, err
+	}
+
 	// Initializing pricing manager
 	pricingManager, err := pricing.Init(ctx, config.ConfigStore, logger)
 	if err != nil {
 		logger.Warn("failed to initialize pricing manager: %v", err)
 	}
 	config.PricingManager = pricingManager
+	if pricingManager != nil {
+		pricingManager.SetLeaderChecker(config.LeaderElector)
+	}
 
 	return config, nil
 }
 
+// initLeaderElector creates and starts the leader elector used to run background work on a single replica.
+// Without a cluster config the replica runs standalone and always considers itself leader.
+func (s *Config) initLeaderElector(ctx context.Context, clusterConfig *cluster.Config) error {
+	var db *gorm.DB
+	if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	}
+	elector, err := cluster.NewLeaderElector(ctx, clusterConfig, db, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize leader elector: %w", err)
+	}
+	if err := elector.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start leader elector: %w", err)
+	}
+	s.LeaderElector = elector
+	if clusterConfig != nil && clusterConfig.Enabled {
+		logger.Info("leader election initialized using %s", clusterConfig.Type)
+	}
+	return nil
+}
+
 // GetRawConfigString returns the raw configuration string.
 func (s *Config) GetRawConfigString() string {
 	data, err := os.ReadFile(s.configPath)
@@ -953,6 +1591,18 @@ func (s *Config) GetProviderConfigRedacted(provider schemas.ModelProvider) (*con
 		ProxyConfig:              config.ProxyConfig,
 		SendBackRawResponse:      config.SendBackRawResponse,
 		CustomProviderConfig:     config.CustomProviderConfig,
+		MockConfig:               config.MockConfig,
+		RequestShaping:           config.RequestShaping,
+		SyntheticStreaming:       config.SyntheticStreaming,
+	}
+
+	// Redact an inline client key of the upstream HTTP client, file paths are kept as-is
+	if config.NetworkConfig != nil && config.NetworkConfig.HTTPClient != nil && strings.Contains(config.NetworkConfig.HTTPClient.ClientKey, "-----BEGIN") {
+		networkConfig := *config.NetworkConfig
+		httpClient := *networkConfig.HTTPClient
+		httpClient.ClientKey = RedactKey(httpClient.ClientKey)
+		networkConfig.HTTPClient = &httpClient
+		redactedConfig.NetworkConfig = &networkConfig
 	}
 
 	// Create redacted keys
@@ -1119,6 +1769,18 @@ func (s *Config) AddProvider(ctx context.Context, provider schemas.ModelProvider
 	if err := ValidateCustomProvider(config, provider); err != nil {
 		return err
 	}
+	if err := ValidateMockProvider(config, provider); err != nil {
+		return err
+	}
+	if err := ValidateRequestShaping(config); err != nil {
+		return err
+	}
+	if err := ValidateSyntheticStreaming(config); err != nil {
+		return err
+	}
+	if err := ValidateNetworkConfig(config); err != nil {
+		return err
+	}
 	newEnvKeys := make(map[string]struct{})
 
 	// Process environment variables in keys (including key-level configs)
@@ -1223,6 +1885,18 @@ func (s *Config) UpdateProviderConfig(ctx context.Context, provider schemas.Mode
 	if err := ValidateCustomProviderUpdate(config, existingConfig, provider); err != nil {
 		return err
 	}
+	if err := ValidateMockProvider(config, provider); err != nil {
+		return err
+	}
+	if err := ValidateRequestShaping(config); err != nil {
+		return err
+	}
+	if err := ValidateSyntheticStreaming(config); err != nil {
+		return err
+	}
+	if err := ValidateNetworkConfig(config); err != nil {
+		return err
+	}
 	// Track new environment variables being added
 	newEnvKeys := make(map[string]struct{})
 
@@ -1317,6 +1991,24 @@ func (s *Config) RemoveProvider(ctx context.Context, provider schemas.ModelProvi
 	return nil
 }
 
+// GetAdminSecret returns the current admin secret, empty when admin auth is disabled.
+func (s *Config) GetAdminSecret() string {
+	s.adminSecretMu.RLock()
+	defer s.adminSecretMu.RUnlock()
+	return s.AdminSecret
+}
+
+// SetAdminSecret replaces the admin secret in memory and ends the existing dashboard sessions and device logins.
+// The new secret does not survive a restart unless the BIFROST_ADMIN_PASSWORD environment variable is updated as
+// well.
+func (s *Config) SetAdminSecret(ctx context.Context, secret string) {
+	s.adminSecretMu.Lock()
+	s.AdminSecret = secret
+	s.adminSecretMu.Unlock()
+	s.clearUISessions(ctx)
+	s.clearDeviceLogins(ctx)
+}
+
 // GetAllKeys returns the redacted keys
 func (s *Config) GetAllKeys() ([]configstore.TableKey, error) {
 	s.Mu.RLock()
@@ -1394,6 +2086,14 @@ func (s *Config) SetBifrostClient(client *bifrost.Bifrost) {
 	s.client = client
 }
 
+// GetBifrostClient returns the Bifrost client, or nil before the server has created it.
+func (s *Config) GetBifrostClient() *bifrost.Bifrost {
+	s.muMCP.RLock()
+	defer s.muMCP.RUnlock()
+
+	return s.client
+}
+
 // AddMCPClient adds a new MCP client to the configuration.
 // This method is called when a new MCP client is added via the HTTP API.
 //
@@ -2167,9 +2867,79 @@ func ValidateCustomProvider(config configstore.ProviderConfig, provider schemas.
 	if !bifrost.IsSupportedBaseProvider(cpc.BaseProviderType) {
 		return fmt.Errorf("custom provider validation failed: unsupported base_provider_type: %s", cpc.BaseProviderType)
 	}
+
+	// Only declarative providers are defined by a spec, and they need the base URL its paths are relative to
+	if cpc.BaseProviderType != schemas.Declarative {
+		if cpc.Spec != nil {
+			return fmt.Errorf("custom provider validation failed: spec is only supported by the %s base provider", schemas.Declarative)
+		}
+		return nil
+	}
+	if cpc.Spec == nil {
+		return fmt.Errorf("custom provider validation failed: the %s base provider requires a spec", schemas.Declarative)
+	}
+	if err := cpc.Spec.Validate(); err != nil {
+		return fmt.Errorf("custom provider validation failed: %w", err)
+	}
+	if config.NetworkConfig == nil || config.NetworkConfig.BaseURL == "" {
+		return fmt.Errorf("custom provider validation failed: the %s base provider requires network_config.base_url", schemas.Declarative)
+	}
 	return nil
 }
 
+// ValidateNetworkConfig validates the upstream connection settings of a provider: its HTTP client, header and
+// parameter passthrough policies and proxy
+func ValidateNetworkConfig(config configstore.ProviderConfig) error {
+	if config.NetworkConfig != nil && config.NetworkConfig.HTTPClient != nil {
+		if err := config.NetworkConfig.HTTPClient.Validate(); err != nil {
+			return fmt.Errorf("network_config.http_client: %w", err)
+		}
+	}
+	if config.NetworkConfig != nil && config.NetworkConfig.PassthroughHeaders != nil {
+		if err := config.NetworkConfig.PassthroughHeaders.Validate(); err != nil {
+			return fmt.Errorf("network_config.passthrough_headers: %w", err)
+		}
+	}
+	if config.NetworkConfig != nil && config.NetworkConfig.PassthroughParams != nil {
+		if err := config.NetworkConfig.PassthroughParams.Validate(); err != nil {
+			return fmt.Errorf("network_config.passthrough_params: %w", err)
+		}
+	}
+	if config.ProxyConfig != nil {
+		if err := config.ProxyConfig.Validate(); err != nil {
+			return fmt.Errorf("proxy_config: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValidateMockProvider validates the mock provider configuration, which only the mock provider accepts
+func ValidateMockProvider(config configstore.ProviderConfig, provider schemas.ModelProvider) error {
+	if config.MockConfig == nil {
+		return nil
+	}
+	if provider != schemas.Mock {
+		return fmt.Errorf("mock_config is only supported by the %s provider", schemas.Mock)
+	}
+	return config.MockConfig.Validate()
+}
+
+// ValidateRequestShaping validates the parameter defaults and overrides of the requests of a provider
+func ValidateRequestShaping(config configstore.ProviderConfig) error {
+	if config.RequestShaping == nil {
+		return nil
+	}
+	return config.RequestShaping.Validate()
+}
+
+// ValidateSyntheticStreaming validates the chunking and pacing of the streams synthesized for a provider
+func ValidateSyntheticStreaming(config configstore.ProviderConfig) error {
+	if config.SyntheticStreaming == nil {
+		return nil
+	}
+	return config.SyntheticStreaming.Validate()
+}
+
 // ValidateCustomProviderUpdate validates that immutable fields in CustomProviderConfig are not changed during updates
 func ValidateCustomProviderUpdate(newConfig, existingConfig configstore.ProviderConfig, provider schemas.ModelProvider) error {
 	// If neither config has CustomProviderConfig, no validation needed
@@ -2199,7 +2969,8 @@ func ValidateCustomProviderUpdate(newConfig, existingConfig configstore.Provider
 			provider, existingCPC.BaseProviderType, newCPC.BaseProviderType)
 	}
 
-	return nil
+	// The spec of declarative providers may change
+	return ValidateCustomProvider(newConfig, provider)
 }
 
 func (s *Config) AddProviderKeysToSemanticCacheConfig(config *schemas.PluginConfig) error {
@@ -0,0 +1,409 @@
+package lib
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/redaction"
+)
+
+// Stages of config validation, in the order they run.
+const (
+	ConfigStageSchema       = "schema"       // JSON syntax, field types and unknown sections
+	ConfigStageEnvironment  = "environment"  // env.VAR references
+	ConfigStageSections     = "sections"     // Validation of each configured section
+	ConfigStageProviders    = "providers"    // Provider names, custom and mock providers, network settings and keys
+	ConfigStageConnectivity = "connectivity" // Dry-run connections to the provider endpoints
+	ConfigStagePlugins      = "plugins"      // Plugin configs, initialized in dry mode
+)
+
+// Statuses of config validation checks.
+const (
+	ConfigCheckOK      = "ok"
+	ConfigCheckWarning = "warning"
+	ConfigCheckError   = "error"
+	ConfigCheckSkipped = "skipped"
+)
+
+// DefaultConfigValidationTimeout bounds each connectivity dry-run.
+const DefaultConfigValidationTimeout = 5 * time.Second
+
+// defaultProviderEndpoints are the endpoints connectivity dry-runs dial for providers without a base_url. Azure
+// keys carry their endpoint; Bedrock and Vertex endpoints depend on the region of the key.
+var defaultProviderEndpoints = map[schemas.ModelProvider]string{
+	schemas.OpenAI:     "https://api.openai.com",
+	schemas.Anthropic:  "https://api.anthropic.com",
+	schemas.Cohere:     "https://api.cohere.ai",
+	schemas.Mistral:    "https://api.mistral.ai",
+	schemas.Groq:       "https://api.groq.com",
+	schemas.Parasail:   "https://api.parasail.io",
+	schemas.Cerebras:   "https://api.cerebras.ai",
+	schemas.Gemini:     "https://generativelanguage.googleapis.com",
+	schemas.OpenRouter: "https://openrouter.ai",
+	schemas.Voyage:     "https://api.voyageai.com",
+	schemas.Jina:       "https://api.jina.ai",
+}
+
+// ConfigValidationOptions control the checks of ValidateConfigDocument.
+type ConfigValidationOptions struct {
+	Connectivity bool          // Dial the endpoint of every provider
+	Timeout      time.Duration // Timeout of each connectivity dry-run (default: 5s)
+	// ValidatePlugin initializes a plugin config in dry mode, without starting the plugin. Without it, plugins
+	// are skipped.
+	ValidatePlugin func(ctx context.Context, plugin *schemas.PluginConfig, candidate *ConfigData) error
+}
+
+// ConfigValidationCheck is the outcome of one check of a candidate config.
+type ConfigValidationCheck struct {
+	Stage   string `json:"stage"`
+	Path    string `json:"path,omitempty"` // Location in the document, e.g. providers.openai.keys[0].value
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ConfigValidationReport is the structured outcome of validating a candidate config. The config is valid when no
+// check failed; warnings flag settings that would be ignored or degraded.
+type ConfigValidationReport struct {
+	Valid    bool                    `json:"valid"`
+	Errors   int                     `json:"errors"`
+	Warnings int                     `json:"warnings"`
+	Checks   []ConfigValidationCheck `json:"checks"`
+}
+
+// add records a check and updates the counters.
+func (r *ConfigValidationReport) add(stage, path, status, message string) {
+	switch status {
+	case ConfigCheckError:
+		r.Errors++
+	case ConfigCheckWarning:
+		r.Warnings++
+	}
+	r.Checks = append(r.Checks, ConfigValidationCheck{Stage: stage, Path: path, Status: status, Message: message})
+}
+
+// addResult records an error check for err, or an ok check.
+func (r *ConfigValidationReport) addResult(stage, path string, err error) {
+	if err != nil {
+		r.add(stage, path, ConfigCheckError, err.Error())
+		return
+	}
+	r.add(stage, path, ConfigCheckOK, "")
+}
+
+// ValidateConfigDocument runs the validation of a config.json document without applying it: its schema, the
+// environment variables it references, every configured section, the providers and, optionally, connectivity to
+// the providers and plugin initialization in dry mode. The running configuration is not read or changed.
+func ValidateConfigDocument(ctx context.Context, data []byte, opts ConfigValidationOptions) (report ConfigValidationReport) {
+	report.Checks = []ConfigValidationCheck{}
+	defer func() { report.Valid = report.Errors == 0 }()
+
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		report.add(ConfigStageSchema, "", ConfigCheckError, fmt.Sprintf("invalid JSON object: %v", err))
+		return report
+	}
+	var candidate ConfigData
+	if err := json.Unmarshal(data, &candidate); err != nil {
+		report.add(ConfigStageSchema, "", ConfigCheckError, err.Error())
+		return report
+	}
+	known := configDataSections()
+	unknown := make([]string, 0)
+	for key := range document {
+		if _, ok := known[key]; !ok && key != "$schema" {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		report.add(ConfigStageSchema, key, ConfigCheckWarning, "unknown section, ignored")
+	}
+	report.add(ConfigStageSchema, "", ConfigCheckOK, "")
+
+	validateEnvReferences(&report, "", document)
+	validateConfigSections(&report, &candidate)
+	validateProviders(&report, &candidate)
+	if opts.Connectivity {
+		checkProviderConnectivity(ctx, &report, &candidate, opts.Timeout)
+	}
+	for i, plugin := range candidate.Plugins {
+		if plugin == nil {
+			continue
+		}
+		path := fmt.Sprintf("plugins[%d]", i)
+		switch {
+		case !plugin.Enabled:
+			report.add(ConfigStagePlugins, path, ConfigCheckSkipped, fmt.Sprintf("plugin %s is disabled", plugin.Name))
+		case opts.ValidatePlugin == nil:
+			report.add(ConfigStagePlugins, path, ConfigCheckSkipped, "plugin validation is not available")
+		default:
+			if err := opts.ValidatePlugin(ctx, plugin, &candidate); err != nil {
+				report.add(ConfigStagePlugins, path, ConfigCheckError, fmt.Sprintf("plugin %s: %v", plugin.Name, err))
+			} else {
+				report.add(ConfigStagePlugins, path, ConfigCheckOK, "")
+			}
+		}
+	}
+	return report
+}
+
+// configDataSections returns the top-level keys of config.json.
+func configDataSections() map[string]struct{} {
+	sections := make(map[string]struct{})
+	t := reflect.TypeOf(ConfigData{})
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			sections[name] = struct{}{}
+		}
+	}
+	return sections
+}
+
+// validateEnvReferences reports the env.VAR references of a document whose environment variable is not set.
+func validateEnvReferences(report *ConfigValidationReport, path string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			validateEnvReferences(report, child, v[key])
+		}
+	case []any:
+		for i, item := range v {
+			validateEnvReferences(report, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case string:
+		reference := strings.TrimSpace(v)
+		if !strings.HasPrefix(reference, "env.") {
+			return
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(reference, "env."))
+		if name == "" {
+			report.add(ConfigStageEnvironment, path, ConfigCheckError, fmt.Sprintf("environment variable name missing in %q", v))
+		} else if _, ok := os.LookupEnv(name); !ok {
+			report.add(ConfigStageEnvironment, path, ConfigCheckError, fmt.Sprintf("environment variable %s not found", name))
+		}
+	}
+}
+
+// validateConfigSections runs the validation LoadConfig applies to each configured section.
+func validateConfigSections(report *ConfigValidationReport, cd *ConfigData) {
+	sections := []struct {
+		path     string
+		present  bool
+		validate func() error
+	}{
+		{"governance", cd.Governance != nil, func() error {
+			for _, virtualKey := range cd.Governance.VirtualKeys {
+				if virtualKey.ParameterGuardrails == nil {
+					continue
+				}
+				if err := virtualKey.ParameterGuardrails.Validate(); err != nil {
+					return fmt.Errorf("virtual key %s: %w", virtualKey.ID, err)
+				}
+			}
+			return nil
+		}},
+		{"redaction", cd.Redaction != nil, func() error { _, err := redaction.New(cd.Redaction); return err }},
+		{"transformation_rules", cd.Transformations != nil, func() error { return ValidateTransformationRules(cd.Transformations) }},
+		{"response_transform_rules", cd.ResponseRules != nil, func() error { _, err := CompileResponseTransformRules(cd.ResponseRules); return err }},
+		{"system_prompt_policies", cd.SystemPrompts != nil, func() error { return ValidateSystemPromptPolicies(cd.SystemPrompts) }},
+		{"context_window", cd.ContextWindow != nil && cd.ContextWindow.Enabled, func() error { return cd.ContextWindow.Validate() }},
+		{"vision", cd.Vision != nil && cd.Vision.Enabled, func() error { return cd.Vision.Validate() }},
+		{"moderation", cd.Moderation != nil && cd.Moderation.Enabled, func() error { return cd.Moderation.Validate() }},
+		{"experiments", cd.Experiments != nil, func() error { return ValidateExperiments(cd.Experiments) }},
+		{"language_routing", cd.LanguageRouting != nil, func() error { return cd.LanguageRouting.Validate() }},
+		{"routing_overrides", cd.RoutingOverrides != nil, func() error { return cd.RoutingOverrides.Validate() }},
+		{"policies", cd.Policies != nil, func() error { _, err := CompilePolicies(*cd.Policies); return err }},
+		{"tenant_domains", cd.TenantDomains != nil, func() error { return ValidateTenantDomains(cd.TenantDomains) }},
+		{"evaluation", cd.Evaluation != nil && cd.Evaluation.Enabled, func() error { return cd.Evaluation.Validate() }},
+		{"public_routes", cd.PublicRoutes != nil, func() error { return ValidatePublicRoutes(cd.PublicRoutes) }},
+		{"recording", cd.Recording != nil && cd.Recording.Enabled, func() error { return cd.Recording.Validate() }},
+		{"egress", cd.Egress != nil, func() error { return cd.Egress.Validate() }},
+		{"tls", cd.TLS != nil, func() error { return cd.TLS.Validate() }},
+		{"listeners", len(cd.Listeners) > 0, func() error { return ValidateListeners(cd.Listeners, cd.TLS != nil) }},
+		{"security_headers", cd.SecurityHeaders != nil, func() error { return cd.SecurityHeaders.Validate() }},
+		{"request_signing", cd.RequestSigning != nil && cd.RequestSigning.Enabled, func() error { return cd.RequestSigning.Validate() }},
+		{"jwt_auth", cd.JWTAuth != nil && cd.JWTAuth.Enabled, func() error { return cd.JWTAuth.Validate() }},
+		{"stripe_metering", cd.StripeMetering != nil && cd.StripeMetering.Enabled, func() error { return cd.StripeMetering.Validate() }},
+		{"data_residency", cd.DataResidency != nil, func() error { return cd.DataResidency.Validate() }},
+		{"zero_data_retention", cd.ZeroDataRetention != nil, func() error { return cd.ZeroDataRetention.Validate() }},
+		{"stream_aggregation", cd.StreamAggregation != nil, func() error { return cd.StreamAggregation.Validate() }},
+		{"cost_ceiling", cd.CostCeiling != nil, func() error { return cd.CostCeiling.Validate() }},
+		{"plugin_policies", cd.PluginPolicies != nil, func() error { return cd.PluginPolicies.Validate() }},
+		{"security_events", cd.SecurityEvents != nil && cd.SecurityEvents.Enabled, func() error { return cd.SecurityEvents.Validate() }},
+		{"statsd", cd.StatsD != nil && cd.StatsD.Enabled, func() error { return cd.StatsD.Validate() }},
+		{"slos", cd.SLOs != nil, func() error { return cd.SLOs.Validate() }},
+		{"provider_cooldowns", cd.ProviderCooldowns != nil && cd.ProviderCooldowns.Enabled, func() error { return cd.ProviderCooldowns.Validate() }},
+		{"scaling", cd.Scaling != nil, func() error { return cd.Scaling.Validate() }},
+		{"kubernetes", cd.Kubernetes != nil && cd.Kubernetes.Enabled, func() error { return cd.Kubernetes.Validate() }},
+		{"race", cd.Race != nil && len(cd.Race.Rules) > 0, func() error { return cd.Race.Validate() }},
+		{"async", cd.Async != nil && cd.Async.Enabled, func() error { return cd.Async.Validate() }},
+		{"fine_tuning", cd.FineTuning != nil && cd.FineTuning.Enabled, func() error { return cd.FineTuning.Validate() }},
+		{"assistants", cd.Assistants != nil && cd.Assistants.Enabled, func() error { return cd.Assistants.Validate() }},
+		{"retrieval", cd.Retrieval != nil && cd.Retrieval.Enabled, func() error { return cd.Retrieval.Validate() }},
+		{"playground", cd.Playground != nil, func() error { return cd.Playground.Validate() }},
+		{"traces", cd.Traces != nil, func() error { return cd.Traces.Validate() }},
+		{"key_leaks", cd.KeyLeaks != nil, func() error { return cd.KeyLeaks.Validate() }},
+	}
+	for _, section := range sections {
+		if section.present {
+			report.addResult(ConfigStageSections, section.path, section.validate())
+		}
+	}
+}
+
+// validateProviders checks the provider names and the settings LoadConfig and the providers API validate.
+func validateProviders(report *ConfigValidationReport, cd *ConfigData) {
+	for _, name := range sortedProviderNames(cd) {
+		config := cd.Providers[name]
+		provider := schemas.ModelProvider(name)
+		path := "providers." + name
+		if !bifrost.IsStandardProvider(provider) && config.CustomProviderConfig == nil {
+			report.add(ConfigStageProviders, path, ConfigCheckError, "unknown provider, custom providers require custom_provider_config")
+			continue
+		}
+		if err := validateProviderConfig(config, provider); err != nil {
+			report.add(ConfigStageProviders, path, ConfigCheckError, err.Error())
+			continue
+		}
+		if len(config.Keys) == 0 && provider != schemas.Ollama && provider != schemas.SGL && provider != schemas.Mock {
+			report.add(ConfigStageProviders, path, ConfigCheckWarning, "no keys configured, requests to the provider will fail")
+			continue
+		}
+		report.add(ConfigStageProviders, path, ConfigCheckOK, "")
+	}
+}
+
+// validateProviderConfig runs the provider validations of AddProvider.
+func validateProviderConfig(config configstore.ProviderConfig, provider schemas.ModelProvider) error {
+	if err := ValidateCustomProvider(config, provider); err != nil {
+		return err
+	}
+	if err := ValidateMockProvider(config, provider); err != nil {
+		return err
+	}
+	if err := ValidateRequestShaping(config); err != nil {
+		return err
+	}
+	if err := ValidateSyntheticStreaming(config); err != nil {
+		return err
+	}
+	return ValidateNetworkConfig(config)
+}
+
+// sortedProviderNames returns the configured provider names in order.
+func sortedProviderNames(cd *ConfigData) []string {
+	names := make([]string, 0, len(cd.Providers))
+	for name := range cd.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkProviderConnectivity dials the endpoint of every provider, or its proxy, concurrently. HTTPS endpoints
+// also complete a TLS handshake. No request is sent.
+func checkProviderConnectivity(ctx context.Context, report *ConfigValidationReport, cd *ConfigData, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultConfigValidationTimeout
+	}
+	names := sortedProviderNames(cd)
+	results := make([]ConfigValidationCheck, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		path := "providers." + name
+		endpoint := providerEndpoint(schemas.ModelProvider(name), cd.Providers[name])
+		if endpoint == "" {
+			results[i] = ConfigValidationCheck{Path: path, Status: ConfigCheckSkipped, Message: "no endpoint to dial, set network_config.base_url"}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, path, endpoint string) {
+			defer wg.Done()
+			if err := dialEndpoint(ctx, endpoint, timeout); err != nil {
+				results[i] = ConfigValidationCheck{Path: path, Status: ConfigCheckError, Message: fmt.Sprintf("%s: %v", endpoint, err)}
+				return
+			}
+			results[i] = ConfigValidationCheck{Path: path, Status: ConfigCheckOK, Message: endpoint}
+		}(i, path, endpoint)
+	}
+	wg.Wait()
+	for _, result := range results {
+		report.add(ConfigStageConnectivity, result.Path, result.Status, result.Message)
+	}
+}
+
+// providerEndpoint returns the URL requests to a provider connect to first: its proxy, its base_url, the
+// endpoint of its first Azure key or the default endpoint of the provider or of its base provider.
+func providerEndpoint(provider schemas.ModelProvider, config configstore.ProviderConfig) string {
+	if config.ProxyConfig != nil && config.ProxyConfig.URL != "" {
+		return config.ProxyConfig.URL
+	}
+	if config.NetworkConfig != nil && config.NetworkConfig.BaseURL != "" {
+		return config.NetworkConfig.BaseURL
+	}
+	for _, key := range config.Keys {
+		if key.AzureKeyConfig != nil && key.AzureKeyConfig.Endpoint != "" && !strings.HasPrefix(key.AzureKeyConfig.Endpoint, "env.") {
+			return key.AzureKeyConfig.Endpoint
+		}
+	}
+	if config.CustomProviderConfig != nil {
+		provider = config.CustomProviderConfig.BaseProviderType
+	}
+	return defaultProviderEndpoints[provider]
+}
+
+// dialEndpoint opens a connection to the host of an endpoint URL, with a TLS handshake for https.
+func dialEndpoint(ctx context.Context, endpoint string, timeout time.Duration) error {
+	if name, ok := strings.CutPrefix(strings.TrimSpace(endpoint), "env."); ok {
+		endpoint = os.Getenv(strings.TrimSpace(name))
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid endpoint URL")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	address := net.JoinHostPort(u.Hostname(), port)
+	if u.Scheme == "https" {
+		conn, err := (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(dialCtx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	conn, err := dialer.DialContext(dialCtx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
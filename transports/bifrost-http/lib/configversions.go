@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// ConfigVersionsConfigKey is the config store key of the configuration version history.
+const ConfigVersionsConfigKey = "config_versions"
+
+// MaxConfigVersions is the number of configuration versions kept, the oldest are dropped first.
+const MaxConfigVersions = 50
+
+// ConfigSnapshot is the state of the runtime configuration captured by a version.
+// Provider keys are kept in their declared form, with env.VAR references in place of resolved values.
+type ConfigSnapshot struct {
+	Client    configstore.ClientConfig                             `json:"client"`
+	Providers map[schemas.ModelProvider]configstore.ProviderConfig `json:"providers"`
+	Plugins   []schemas.PluginConfig                               `json:"plugins,omitempty"` // Only captured with a config store
+}
+
+// ConfigVersion is one applied configuration change.
+type ConfigVersion struct {
+	ID        int            `json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Source    string         `json:"source"` // What applied the change, e.g. "PUT /api/providers/openai" or "rollback to 3"
+	Snapshot  ConfigSnapshot `json:"snapshot"`
+}
+
+// configVersions is the configuration version history, oldest first.
+type configVersions struct {
+	mu       sync.RWMutex
+	versions []ConfigVersion
+}
+
+// GetConfigVersions returns the configuration versions, oldest first.
+func (s *Config) GetConfigVersions() []ConfigVersion {
+	s.configVersions.mu.RLock()
+	defer s.configVersions.mu.RUnlock()
+	return append([]ConfigVersion(nil), s.configVersions.versions...)
+}
+
+// GetConfigVersion returns a configuration version and the version preceding it, which is nil for the oldest
+// version kept.
+func (s *Config) GetConfigVersion(id int) (version *ConfigVersion, previous *ConfigVersion, err error) {
+	s.configVersions.mu.RLock()
+	defer s.configVersions.mu.RUnlock()
+	for i := range s.configVersions.versions {
+		if s.configVersions.versions[i].ID != id {
+			continue
+		}
+		version = &s.configVersions.versions[i]
+		if i > 0 {
+			previous = &s.configVersions.versions[i-1]
+		}
+		return version, previous, nil
+	}
+	return nil, nil, ErrNotFound
+}
+
+// RecordConfigVersion records a snapshot as a new configuration version, persisting the history in the config
+// store when one is configured. Nothing is recorded when the snapshot matches the latest version, in which case
+// the returned version is nil.
+func (s *Config) RecordConfigVersion(ctx context.Context, source string, snapshot ConfigSnapshot) (*ConfigVersion, error) {
+	s.configVersions.mu.Lock()
+	defer s.configVersions.mu.Unlock()
+
+	versions := s.configVersions.versions
+	id := 1
+	if len(versions) > 0 {
+		latest := versions[len(versions)-1]
+		if sameSnapshot(latest.Snapshot, snapshot) {
+			return nil, nil
+		}
+		id = latest.ID + 1
+	}
+	version := ConfigVersion{
+		ID:        id,
+		CreatedAt: time.Now().UTC(),
+		Source:    source,
+		Snapshot:  snapshot,
+	}
+	updated := append(append(make([]ConfigVersion, 0, len(versions)+1), versions...), version)
+	if len(updated) > MaxConfigVersions {
+		updated = updated[len(updated)-MaxConfigVersions:]
+	}
+	if err := s.saveStoredConfig(ctx, ConfigVersionsConfigKey, updated); err != nil {
+		return nil, fmt.Errorf("failed to save config versions: %w", err)
+	}
+	s.configVersions.versions = updated
+	return &version, nil
+}
+
+// sameSnapshot reports whether two snapshots capture the same configuration. They are compared in their JSON form
+// since plugin configs restored from the store are decoded into generic values.
+func sameSnapshot(a, b ConfigSnapshot) bool {
+	encodedA, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	encodedB, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(encodedA, encodedB)
+}
+
+// loadConfigVersions restores the configuration version history saved in the config store.
+func (s *Config) loadConfigVersions(ctx context.Context) error {
+	var versions []ConfigVersion
+	if _, err := s.loadStoredConfig(ctx, ConfigVersionsConfigKey, &versions); err != nil {
+		return fmt.Errorf("failed to load config versions: %w", err)
+	}
+	s.configVersions.mu.Lock()
+	s.configVersions.versions = versions
+	s.configVersions.mu.Unlock()
+	return nil
+}
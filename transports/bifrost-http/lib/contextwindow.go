@@ -0,0 +1,155 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/tokenizer"
+)
+
+// DefaultContextWindowReserveOutputTokens is the number of tokens kept free for the reply when a request sets no max_completion_tokens.
+const DefaultContextWindowReserveOutputTokens = 1024
+
+// Response headers reporting context window truncation.
+const (
+	ContextTruncationHeader      = "x-bf-context-truncation"       // Strategy applied: drop_oldest or summarize
+	ContextDroppedMessagesHeader = "x-bf-context-dropped-messages" // Number of messages removed from the request
+	ContextTokensHeader          = "x-bf-context-tokens"           // Estimated prompt tokens sent to the provider
+)
+
+// ContextWindowStrategy decides what happens to chat requests whose messages exceed the model's context window.
+type ContextWindowStrategy string
+
+const (
+	// ContextWindowStrategyDropOldest drops the oldest messages after the leading system messages.
+	ContextWindowStrategyDropOldest ContextWindowStrategy = "drop_oldest"
+	// ContextWindowStrategySummarize replaces the oldest messages with a summary written by the summarize model.
+	ContextWindowStrategySummarize ContextWindowStrategy = "summarize"
+	// ContextWindowStrategyError rejects the request with a context_length_exceeded error.
+	ContextWindowStrategyError ContextWindowStrategy = "error"
+)
+
+// ContextWindowConfig represents the configuration of automatic context window management.
+// Windows come from the models setting, then from the built-in table of the tokenizer package;
+// requests to models with an unknown window are left alone.
+type ContextWindowConfig struct {
+	Enabled             bool                  `json:"enabled"`
+	Strategy            ContextWindowStrategy `json:"strategy,omitempty"`              // drop_oldest (default), summarize or error
+	SummarizeModel      string                `json:"summarize_model,omitempty"`       // Model in provider/model format, required by the summarize strategy
+	ReserveOutputTokens int                   `json:"reserve_output_tokens,omitempty"` // Tokens kept for the reply without max_completion_tokens (default: 1024)
+	Models              map[string]int        `json:"models,omitempty"`                // Context window per model name, a trailing * matches a prefix
+}
+
+// Validate checks the strategy and the windows of a context window config.
+func (c *ContextWindowConfig) Validate() error {
+	switch c.Strategy {
+	case "", ContextWindowStrategyDropOldest, ContextWindowStrategyError:
+	case ContextWindowStrategySummarize:
+		if provider, model := schemas.ParseModelString(c.SummarizeModel, ""); provider == "" || model == "" {
+			return fmt.Errorf("context_window summarize_model should be in provider/model format")
+		}
+	default:
+		return fmt.Errorf("unknown context_window strategy %q", c.Strategy)
+	}
+	for model, window := range c.Models {
+		if window <= 0 {
+			return fmt.Errorf("context_window of model %s must be positive", model)
+		}
+	}
+	if c.ReserveOutputTokens < 0 {
+		return fmt.Errorf("context_window reserve_output_tokens cannot be negative")
+	}
+	return nil
+}
+
+// GetStrategy returns the configured strategy, applying the default.
+func (c *ContextWindowConfig) GetStrategy() ContextWindowStrategy {
+	if c.Strategy == "" {
+		return ContextWindowStrategyDropOldest
+	}
+	return c.Strategy
+}
+
+// Window returns the context window of a model in tokens, or 0 when it is not known.
+// An exact model entry wins over prefix patterns, and longer prefixes win over shorter ones.
+func (c *ContextWindowConfig) Window(model string) int {
+	if window, ok := c.Models[model]; ok {
+		return window
+	}
+	window, matched := 0, -1
+	for pattern, size := range c.Models {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && len(prefix) > matched && strings.HasPrefix(model, prefix) {
+			window, matched = size, len(prefix)
+		}
+	}
+	if matched >= 0 {
+		return window
+	}
+	return tokenizer.ContextWindow(model)
+}
+
+// PromptBudget returns the tokens available to the messages of a chat request, or 0 when the model's window is unknown.
+func (c *ContextWindowConfig) PromptBudget(model string, params *schemas.ChatParameters) int {
+	window := c.Window(model)
+	if window == 0 {
+		return 0
+	}
+	reserve := c.ReserveOutputTokens
+	if reserve == 0 {
+		reserve = DefaultContextWindowReserveOutputTokens
+	}
+	if params != nil && params.MaxCompletionTokens != nil && *params.MaxCompletionTokens > 0 {
+		reserve = *params.MaxCompletionTokens
+	}
+	if reserve >= window {
+		return 1
+	}
+	return window - reserve
+}
+
+// FitChatMessages drops the oldest messages following the leading system messages until the estimated
+// tokens fit budget. The leading system messages and the latest message are always kept, and tool results
+// whose tool call was dropped are dropped with it. It returns the kept and the dropped messages.
+func FitChatMessages(messages []schemas.ChatMessage, budget int) ([]schemas.ChatMessage, []schemas.ChatMessage) {
+	tokens := tokenizer.EstimateMessages(messages)
+	if tokens <= budget {
+		return messages, nil
+	}
+
+	leading := LeadingSystemMessages(messages)
+	end := leading
+	for end < len(messages)-1 && tokens > budget {
+		tokens -= tokenizer.EstimateMessage(messages[end])
+		end++
+	}
+	for end < len(messages)-1 && messages[end].Role == schemas.ChatMessageRoleTool {
+		end++
+	}
+	if end == leading {
+		return messages, nil
+	}
+
+	kept := make([]schemas.ChatMessage, 0, len(messages)-(end-leading))
+	kept = append(kept, messages[:leading]...)
+	kept = append(kept, messages[end:]...)
+	return kept, messages[leading:end]
+}
+
+// LeadingSystemMessages returns the number of system and developer messages at the start of messages.
+func LeadingSystemMessages(messages []schemas.ChatMessage) int {
+	leading := 0
+	for leading < len(messages) && (messages[leading].Role == schemas.ChatMessageRoleSystem || messages[leading].Role == schemas.ChatMessageRoleDeveloper) {
+		leading++
+	}
+	return leading
+}
+
+// SummaryMessage returns the system message carrying the summary of dropped messages.
+func SummaryMessage(summary string) schemas.ChatMessage {
+	content := "Summary of the earlier conversation:\n" + summary
+	return schemas.ChatMessage{
+		Role:    schemas.ChatMessageRoleSystem,
+		Content: &schemas.ChatMessageContent{ContentStr: &content},
+	}
+}
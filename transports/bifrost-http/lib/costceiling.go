@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+)
+
+// MaxCostContextKey holds the cost ceiling of a request in USD, taken from its max_cost field or the x-bf-max-cost
+// header
+const MaxCostContextKey ContextKey = "x-bf-max-cost"
+
+// Response headers reporting the cost ceiling of a request.
+const (
+	MaxCostHeader           = "x-bf-max-cost"                // Ceiling applied to the request, in USD
+	CostEstimateHeader      = "x-bf-cost-estimate"           // Worst-case cost of the request sent to the provider, in USD
+	CostClampedTokensHeader = "x-bf-cost-clamped-max-tokens" // Max tokens the request was clamped to, when it was
+)
+
+// CostCeilingMode decides what happens to requests whose worst-case cost exceeds their ceiling.
+type CostCeilingMode string
+
+const (
+	// CostCeilingModeReject rejects the request with a cost_limit_exceeded error.
+	CostCeilingModeReject CostCeilingMode = "reject"
+	// CostCeilingModeClamp lowers the max tokens of the request to what the ceiling affords.
+	CostCeilingModeClamp CostCeilingMode = "clamp"
+)
+
+// CostCeilingUnpricedPolicy decides what happens to requests with a ceiling whose model has no pricing, and whose
+// cost cannot be estimated.
+type CostCeilingUnpricedPolicy string
+
+const (
+	// CostCeilingUnpricedReject rejects the request with a cost_unknown error, failing closed.
+	CostCeilingUnpricedReject CostCeilingUnpricedPolicy = "reject"
+	// CostCeilingUnpricedAllow sends the request without enforcing its ceiling.
+	CostCeilingUnpricedAllow CostCeilingUnpricedPolicy = "allow"
+)
+
+// CostCeilingConfig represents the enforcement of per-request cost ceilings. A request sets its ceiling with
+// max_cost or the x-bf-max-cost header, and requests of the listed virtual keys that set none get the ceiling of
+// their key. The worst-case cost is estimated from the prompt tokens and max tokens of chat and text completions
+// and responses requests, and from the input tokens of embedding requests, before they are sent, with the pricing of
+// the model of each attempt.
+type CostCeilingConfig struct {
+	Mode        CostCeilingMode           `json:"mode,omitempty"`         // reject (default) or clamp
+	Unpriced    CostCeilingUnpricedPolicy `json:"unpriced,omitempty"`     // reject (default) or allow requests to unpriced models
+	VirtualKeys map[string]float64        `json:"virtual_keys,omitempty"` // Default ceiling in USD by virtual key value
+}
+
+// Validate checks the mode and the ceilings of a cost ceiling config.
+func (c *CostCeilingConfig) Validate() error {
+	switch c.Mode {
+	case "", CostCeilingModeReject, CostCeilingModeClamp:
+	default:
+		return fmt.Errorf("unknown cost_ceiling mode %q", c.Mode)
+	}
+	switch c.Unpriced {
+	case "", CostCeilingUnpricedReject, CostCeilingUnpricedAllow:
+	default:
+		return fmt.Errorf("unknown cost_ceiling unpriced policy %q", c.Unpriced)
+	}
+	for virtualKey, maxCost := range c.VirtualKeys {
+		if err := ValidateMaxCost(maxCost); err != nil {
+			return fmt.Errorf("cost_ceiling of virtual key %s: %w", virtualKey, err)
+		}
+	}
+	return nil
+}
+
+// GetMode returns the configured mode, applying the default.
+func (c *CostCeilingConfig) GetMode() CostCeilingMode {
+	if c == nil || c.Mode == "" {
+		return CostCeilingModeReject
+	}
+	return c.Mode
+}
+
+// AllowsUnpriced reports whether requests to models without pricing are sent without enforcing their ceiling.
+func (c *CostCeilingConfig) AllowsUnpriced() bool {
+	return c != nil && c.Unpriced == CostCeilingUnpricedAllow
+}
+
+// MaxCost returns the ceiling of a request: its own when set, else the one of its virtual key, else 0.
+func (c *CostCeilingConfig) MaxCost(requestMaxCost float64, virtualKey string) float64 {
+	if requestMaxCost > 0 {
+		return requestMaxCost
+	}
+	if c == nil || virtualKey == "" {
+		return 0
+	}
+	return c.VirtualKeys[virtualKey]
+}
+
+// ValidateMaxCost checks that a cost ceiling is a positive amount.
+func ValidateMaxCost(maxCost float64) error {
+	if maxCost <= 0 || math.IsNaN(maxCost) || math.IsInf(maxCost, 0) {
+		return fmt.Errorf("max_cost must be a positive amount in USD")
+	}
+	return nil
+}
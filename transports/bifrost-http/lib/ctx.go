@@ -45,6 +45,7 @@ import (
 //   - x-bf-team: Team identifier for team-based governance rules
 //   - x-bf-user: User identifier for user-based governance rules
 //   - x-bf-customer: Customer identifier for customer-based governance rules
+//   - x-bf-client-identity: Identity of the verified client certificate, set by the server only
 //
 // 5. API Key Headers:
 //   - Authorization: Bearer token format only (e.g., "Bearer sk-...") - OpenAI style
@@ -52,6 +53,40 @@ import (
 //   - Keys are extracted and stored in the context using schemas.BifrostContextKey
 //   - This enables explicit key usage for requests via headers
 //
+// 6. Session Header:
+//   - x-bf-session-id: Server-side session whose history is prepended to chat requests
+//
+// 7. Moderation Header:
+//   - x-bf-moderation-review-id: Approved moderation event releasing a resubmitted held request
+//
+// 8. Evaluation Header:
+//   - x-bf-eval-reference: Expected answer the response evaluators compare the completion to
+//
+// 9. Recording Header:
+//   - x-bf-record: "true" records the exchanges with providers of the request when recording is enabled
+//
+// 10. Billing Header:
+//   - x-bifrost-customer: End customer the request is billed to in billing reports
+//
+// 11. Data Residency Header:
+//   - x-bf-data-residency: Comma-separated jurisdictions the request may be served in, narrowing the data residency rules
+//
+// 12. Zero Data Retention Header:
+//   - x-bf-zero-data-retention: "true" keeps the content of the request out of logs, recordings and caches
+//
+// 13. Retrieval Header:
+//   - x-bf-retrieval-store: Retrieval store whose chunks closest to the last user message are injected as context
+//
+// 14. Stream Aggregation Header:
+//   - x-bf-stream-aggregation: "true" streams a non-streaming completion upstream and returns the aggregated response
+//
+// 15. Cost Ceiling Header:
+//   - x-bf-max-cost: Ceiling in USD on the worst-case cost of a chat or text completion, like its max_cost field
+//
+// 16. Passthrough Headers:
+//   - All headers are shared, by lowercase name, with the passthrough policies of the providers
+//     (network_config.passthrough_headers), which forward the allowed ones upstream
+//
 
 // Parameters:
 //   - ctx: The FastHTTP request context containing the original headers
@@ -76,13 +111,20 @@ func ConvertToBifrostContext(ctx *fasthttp.RequestCtx, allowDirectKeys bool) *co
 		requestID = uuid.New().String()
 	}
 	bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKeyRequestID, requestID)
+	bifrostCtx = context.WithValue(bifrostCtx, ClientIPContextKey, ctx.RemoteIP().String())
+	bifrostCtx = context.WithValue(bifrostCtx, RequestPathContextKey, string(ctx.Path()))
+	bifrostCtx = context.WithValue(bifrostCtx, RequestOwnerContextKey, RequestOwner(ctx))
 
 	// Initialize tags map for collecting maxim tags
 	maximTags := make(map[string]string)
 
+	// Headers shared with the passthrough policies of the providers
+	requestHeaders := make(map[string]string)
+
 	// Then process other headers
 	ctx.Request.Header.All()(func(key, value []byte) bool {
 		keyStr := strings.ToLower(string(key))
+		requestHeaders[keyStr] = string(value)
 		if labelName, ok := strings.CutPrefix(keyStr, "x-bf-prom-"); ok {
 			bifrostCtx = context.WithValue(bifrostCtx, telemetry.ContextKey(labelName), string(value))
 			return true
@@ -123,8 +165,8 @@ func ConvertToBifrostContext(ctx *fasthttp.RequestCtx, allowDirectKeys bool) *co
 				return true
 			}
 		}
-		// Handle governance headers (x-bf-team, x-bf-user, x-bf-customer)
-		if keyStr == "x-bf-team" || keyStr == "x-bf-user" || keyStr == "x-bf-customer" {
+		// Handle governance headers (x-bf-team, x-bf-user, x-bf-customer, x-bf-client-identity)
+		if keyStr == "x-bf-team" || keyStr == "x-bf-user" || keyStr == "x-bf-customer" || keyStr == ClientIdentityHeader {
 			bifrostCtx = context.WithValue(bifrostCtx, governance.ContextKey(keyStr), string(value))
 			return true
 		}
@@ -133,6 +175,72 @@ func ConvertToBifrostContext(ctx *fasthttp.RequestCtx, allowDirectKeys bool) *co
 			bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKey("x-bf-trace-id"), string(value))
 			return true
 		}
+		// Session header
+		if keyStr == "x-bf-session-id" {
+			bifrostCtx = context.WithValue(bifrostCtx, SessionIDContextKey, string(value))
+			return true
+		}
+		// Retrieval store header
+		if keyStr == "x-bf-retrieval-store" {
+			bifrostCtx = context.WithValue(bifrostCtx, RetrievalStoreContextKey, string(value))
+			return true
+		}
+		// Moderation review header
+		if keyStr == "x-bf-moderation-review-id" {
+			bifrostCtx = context.WithValue(bifrostCtx, ModerationReviewIDContextKey, string(value))
+			return true
+		}
+		// Evaluation reference header
+		if keyStr == "x-bf-eval-reference" {
+			bifrostCtx = context.WithValue(bifrostCtx, EvaluationReferenceContextKey, string(value))
+			return true
+		}
+		// Billing customer header
+		if keyStr == "x-bifrost-customer" {
+			bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKeyBillingCustomer, string(value))
+			return true
+		}
+		// Data residency header
+		if keyStr == "x-bf-data-residency" {
+			var jurisdictions []string
+			for _, jurisdiction := range strings.Split(string(value), ",") {
+				if jurisdiction = strings.TrimSpace(jurisdiction); jurisdiction != "" {
+					jurisdictions = append(jurisdictions, jurisdiction)
+				}
+			}
+			if len(jurisdictions) > 0 {
+				bifrostCtx = context.WithValue(bifrostCtx, DataResidencyHeaderContextKey, jurisdictions)
+			}
+			return true
+		}
+		// Zero data retention header
+		if keyStr == "x-bf-zero-data-retention" {
+			if zdr, err := strconv.ParseBool(string(value)); err == nil && zdr {
+				bifrostCtx = context.WithValue(bifrostCtx, ZeroDataRetentionHeaderContextKey, true)
+			}
+			return true
+		}
+		// Stream aggregation header
+		if keyStr == "x-bf-stream-aggregation" {
+			if aggregate, err := strconv.ParseBool(string(value)); err == nil && aggregate {
+				bifrostCtx = context.WithValue(bifrostCtx, StreamAggregationHeaderContextKey, true)
+			}
+			return true
+		}
+		// Cost ceiling header
+		if keyStr == "x-bf-max-cost" {
+			if maxCost, err := strconv.ParseFloat(string(value), 64); err == nil && ValidateMaxCost(maxCost) == nil {
+				bifrostCtx = context.WithValue(bifrostCtx, MaxCostContextKey, maxCost)
+			}
+			return true
+		}
+		// Recording header
+		if keyStr == "x-bf-record" {
+			if record, err := strconv.ParseBool(string(value)); err == nil && record {
+				bifrostCtx = context.WithValue(bifrostCtx, RecordContextKey, true)
+			}
+			return true
+		}
 		// Handle virtual key header (x-bf-vk)
 		if keyStr == "x-bf-vk" {
 			// Store under both governance and core schema keys for compatibility
@@ -195,6 +303,10 @@ func ConvertToBifrostContext(ctx *fasthttp.RequestCtx, allowDirectKeys bool) *co
 		return true
 	})
 
+	if len(requestHeaders) > 0 {
+		bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKeyRequestHeaders, requestHeaders)
+	}
+
 	// Store the collected maxim tags in the context
 	if len(maximTags) > 0 {
 		bifrostCtx = context.WithValue(bifrostCtx, maxim.ContextKey(maxim.TagsKey), maximTags)
@@ -242,6 +354,42 @@ func ConvertToBifrostContext(ctx *fasthttp.RequestCtx, allowDirectKeys bool) *co
 	if ctx.UserValue(schemas.BifrostContextKey("x-litellm-fallback")) != nil {
 		bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKey("x-litellm-fallback"), "true")
 	}
+	// Sharing the access log request info so that the pipeline can report provider, model and key back
+	if requestInfo, ok := ctx.UserValue(RequestInfoContextKey).(*RequestInfo); ok {
+		bifrostCtx = context.WithValue(bifrostCtx, RequestInfoContextKey, requestInfo)
+	}
+	// Sharing the pipeline trace so that plugin hooks, provider attempts and upstream exchanges join the request trace
+	if trace, ok := ctx.UserValue(RequestTraceContextKey).(*RequestTrace); ok {
+		bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKeyPipelineTrace, trace.Pipeline())
+	}
+	// Sharing the response headers so that plugins can add headers to the HTTP response
+	if responseHeaders, ok := ctx.UserValue(ResponseHeadersContextKey).(*ResponseHeaders); ok {
+		bifrostCtx = context.WithValue(bifrostCtx, ResponseHeadersContextKey, responseHeaders)
+	}
+	// Sharing the experiment assignment so that the pipeline can report the outcome of the request
+	if assignment, ok := ctx.UserValue(ExperimentAssignmentContextKey).(*ExperimentAssignment); ok {
+		bifrostCtx = context.WithValue(bifrostCtx, ExperimentAssignmentContextKey, assignment)
+	}
+	// Sharing the language of the request so that it is logged, and reported as the "language" Prometheus label
+	// when that custom label is configured and not set with an x-bf-prom-language header
+	if language, ok := ctx.UserValue(schemas.BifrostContextKeyLanguage).(string); ok {
+		bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKeyLanguage, language)
+		if bifrostCtx.Value(telemetry.ContextKey("language")) == nil {
+			bifrostCtx = context.WithValue(bifrostCtx, telemetry.ContextKey("language"), language)
+		}
+	}
+	// Sharing the labels of the request so that governance, logs and billing attribute it, and reporting them as the
+	// custom Prometheus labels of the same names when they are not set with x-bf-prom- headers
+	if labels, ok := ctx.UserValue(schemas.BifrostContextKeyLabels).(map[string]string); ok {
+		bifrostCtx = context.WithValue(bifrostCtx, schemas.BifrostContextKeyLabels, labels)
+	}
+	if metricValues, ok := ctx.UserValue(LabelMetricValuesContextKey).(map[string]string); ok {
+		for name, value := range metricValues {
+			if bifrostCtx.Value(telemetry.ContextKey(name)) == nil {
+				bifrostCtx = context.WithValue(bifrostCtx, telemetry.ContextKey(name), value)
+			}
+		}
+	}
 
 	return &bifrostCtx
 }
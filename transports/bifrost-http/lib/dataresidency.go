@@ -0,0 +1,154 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// DataResidencyContextKey holds the jurisdictions a request may be served in ([]string), set by the data
+// residency plugin before dispatch. Keys outside them are not selected.
+const DataResidencyContextKey ContextKey = "bifrost-data-residency"
+
+// DataResidencyHeaderContextKey holds the jurisdictions a caller restricts its request to, taken from the
+// x-bf-data-residency header. It can only narrow the jurisdictions allowed by the rules.
+const DataResidencyHeaderContextKey ContextKey = "x-bf-data-residency"
+
+// DataResidencyConfig restricts the providers and keys serving the requests of some virtual keys or customers to
+// allowed jurisdictions. The jurisdiction of a key is, in order: its entry in keys, the entry of its provider in
+// providers, or the one inferred from its Vertex or Bedrock region (eu-*/europe-* is "eu", us-* is "us", ...).
+// Keys without a jurisdiction never serve restricted requests.
+type DataResidencyConfig struct {
+	Keys      map[string]string                `json:"keys,omitempty"`      // Key ID -> jurisdiction
+	Providers map[schemas.ModelProvider]string `json:"providers,omitempty"` // Provider -> jurisdiction of its keys
+	Rules     []DataResidencyRule              `json:"rules"`
+}
+
+// DataResidencyRule allows the requests of virtual keys or customers to be served in some jurisdictions only.
+// A request matched by several rules may only be served in the jurisdictions they all allow.
+type DataResidencyRule struct {
+	Name          string   `json:"name"`
+	VirtualKeys   []string `json:"virtual_keys,omitempty"` // Virtual key values
+	Customers     []string `json:"customers,omitempty"`    // Billing or governance customers
+	Jurisdictions []string `json:"jurisdictions"`          // Jurisdictions ("eu") or exact regions ("europe-west4")
+}
+
+// regionJurisdictions maps the prefixes of cloud regions to their jurisdiction
+var regionJurisdictions = []struct {
+	prefix       string
+	jurisdiction string
+}{
+	{"eu-", "eu"},
+	{"europe-", "eu"},
+	{"us-", "us"},
+	{"northamerica-", "us"},
+	{"ca-", "ca"},
+	{"ap-", "apac"},
+	{"asia-", "apac"},
+	{"australia-", "apac"},
+	{"me-", "me"},
+	{"sa-", "sa"},
+	{"southamerica-", "sa"},
+}
+
+// Validate checks the rules.
+func (c *DataResidencyConfig) Validate() error {
+	if len(c.Rules) == 0 {
+		return fmt.Errorf("data_residency: at least one rule is required")
+	}
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("data_residency: rule %d: name is required", i)
+		}
+		if len(rule.VirtualKeys) == 0 && len(rule.Customers) == 0 {
+			return fmt.Errorf("data_residency: rule %q: virtual_keys or customers is required", rule.Name)
+		}
+		if len(rule.Jurisdictions) == 0 {
+			return fmt.Errorf("data_residency: rule %q: jurisdictions is required", rule.Name)
+		}
+	}
+	return nil
+}
+
+// AllowedJurisdictions returns the jurisdictions a request of a virtual key and customer may be served in, and
+// false when no rule restricts it.
+func (c *DataResidencyConfig) AllowedJurisdictions(virtualKey, customer string) ([]string, bool) {
+	var allowed []string
+	restricted := false
+	for _, rule := range c.Rules {
+		if !(virtualKey != "" && slices.Contains(rule.VirtualKeys, virtualKey)) && !(customer != "" && slices.Contains(rule.Customers, customer)) {
+			continue
+		}
+		if !restricted {
+			allowed = slices.Clone(rule.Jurisdictions)
+			restricted = true
+			continue
+		}
+		allowed = IntersectJurisdictions(allowed, rule.Jurisdictions)
+	}
+	return allowed, restricted
+}
+
+// IntersectJurisdictions returns the jurisdictions of a that are in b.
+func IntersectJurisdictions(a, b []string) []string {
+	result := []string{}
+	for _, jurisdiction := range a {
+		if slices.ContainsFunc(b, func(other string) bool { return strings.EqualFold(other, jurisdiction) }) {
+			result = append(result, jurisdiction)
+		}
+	}
+	return result
+}
+
+// KeyJurisdiction returns the jurisdiction and region of a key of a provider, "" when unknown.
+func (c *DataResidencyConfig) KeyJurisdiction(provider schemas.ModelProvider, key schemas.Key) (jurisdiction string, region string) {
+	region = keyRegion(key)
+	if jurisdiction, ok := c.Keys[key.ID]; ok {
+		return jurisdiction, region
+	}
+	if jurisdiction, ok := c.Providers[provider]; ok {
+		return jurisdiction, region
+	}
+	lower := strings.ToLower(region)
+	for _, entry := range regionJurisdictions {
+		if strings.HasPrefix(lower, entry.prefix) {
+			return entry.jurisdiction, region
+		}
+	}
+	return "", region
+}
+
+// KeyAllowed reports whether a key of a provider may serve a request allowed in some jurisdictions.
+func (c *DataResidencyConfig) KeyAllowed(provider schemas.ModelProvider, key schemas.Key, allowed []string) bool {
+	jurisdiction, region := c.KeyJurisdiction(provider, key)
+	for _, candidate := range allowed {
+		if (jurisdiction != "" && strings.EqualFold(candidate, jurisdiction)) || (region != "" && strings.EqualFold(candidate, region)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterKeys returns the keys of a provider that may serve a request allowed in some jurisdictions.
+func (c *DataResidencyConfig) FilterKeys(provider schemas.ModelProvider, keys []schemas.Key, allowed []string) []schemas.Key {
+	filtered := make([]schemas.Key, 0, len(keys))
+	for _, key := range keys {
+		if c.KeyAllowed(provider, key, allowed) {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered
+}
+
+// keyRegion returns the cloud region of a key, "" when it has none
+func keyRegion(key schemas.Key) string {
+	if key.VertexKeyConfig != nil && key.VertexKeyConfig.Region != "" {
+		return key.VertexKeyConfig.Region
+	}
+	if key.BedrockKeyConfig != nil && key.BedrockKeyConfig.Region != nil {
+		return *key.BedrockKeyConfig.Region
+	}
+	return ""
+}
@@ -0,0 +1,636 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+)
+
+// Device login lifetimes, following the OAuth 2.0 device authorization grant (RFC 8628).
+const (
+	DeviceCodeTTL        = 10 * time.Minute    // How long a user has to confirm a device login
+	DevicePollInterval   = 5 * time.Second     // Minimum wait between two polls of the token endpoint
+	DeviceAccessTokenTTL = time.Hour           // How long a device access token lasts
+	DeviceRefreshTTL     = 30 * 24 * time.Hour // How long a refresh token can renew the access token
+)
+
+// DeviceTokenPrefix starts the access tokens of device logins, telling them apart from the admin secret, session
+// and service account tokens.
+const DeviceTokenPrefix = "bf-dev-"
+
+// DeviceRefreshTokenPrefix starts the refresh tokens of device logins.
+const DeviceRefreshTokenPrefix = "bf-devr-"
+
+// userCodeAlphabet leaves out vowels and look-alike characters so user codes are easy to read out and type.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// Errors returned while polling for a device token, named after the error codes of RFC 8628.
+var (
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	ErrSlowDown             = errors.New("slow_down")
+	ErrAccessDenied         = errors.New("access_denied")
+	ErrExpiredToken         = errors.New("expired_token")
+	ErrInvalidGrant         = errors.New("invalid_grant")
+)
+
+// Device authorization states.
+const (
+	DeviceAuthorizationPending  = "pending"
+	DeviceAuthorizationApproved = "approved"
+	DeviceAuthorizationDenied   = "denied"
+)
+
+// DeviceAuthorization is a device login waiting for a user to confirm its code in the dashboard.
+type DeviceAuthorization struct {
+	DeviceCode     string                  `json:"-"` // Only set when the login starts; stores keep its hash
+	UserCode       string                  `json:"user_code"`
+	ClientName     string                  `json:"client_name,omitempty"` // Name the CLI gave itself, shown when confirming
+	Scopes         []serviceaccounts.Scope `json:"scopes,omitempty"`      // Empty requests everything the admin secret allows
+	Status         string                  `json:"status"`
+	Interval       time.Duration           `json:"-"`
+	CreatedAt      time.Time               `json:"created_at"`
+	ExpiresAt      time.Time               `json:"expires_at"`
+	deviceCodeHash string
+	lastPolledAt   time.Time
+}
+
+// DeviceToken is the scoped access token a confirmed device login receives, renewed with its refresh token.
+type DeviceToken struct {
+	AccessToken      string                  `json:"-"` // Only set when the token is issued; stores keep its hash
+	RefreshToken     string                  `json:"-"` // Only set when the token is issued; stores keep its hash
+	ClientName       string                  `json:"client_name,omitempty"`
+	Scopes           []serviceaccounts.Scope `json:"scopes,omitempty"` // Empty grants everything the admin secret allows
+	CreatedAt        time.Time               `json:"created_at"`
+	ExpiresAt        time.Time               `json:"expires_at"`
+	RefreshExpiresAt time.Time               `json:"refresh_expires_at"`
+	accessTokenHash  string
+	refreshTokenHash string
+}
+
+// Allows reports whether the token is granted a scope. The admin scope allows everything.
+func (t *DeviceToken) Allows(scope serviceaccounts.Scope) bool {
+	return (&UISession{Scopes: t.Scopes}).Allows(scope)
+}
+
+// deviceLoginStore keeps the device authorizations and tokens, keyed by the hashes of their codes and tokens.
+// Lookups return nil without an error when nothing matches.
+type deviceLoginStore interface {
+	createAuthorization(ctx context.Context, authorization *DeviceAuthorization) error
+	authorization(ctx context.Context, deviceCodeHash string) (*DeviceAuthorization, error)
+	authorizationByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error)
+	// updateAuthorization saves an authorization still in status, reporting whether it was.
+	updateAuthorization(ctx context.Context, authorization *DeviceAuthorization, status string) (bool, error)
+	// deleteAuthorization drops an authorization, reporting whether it existed so a device code is spent once.
+	deleteAuthorization(ctx context.Context, deviceCodeHash string) (bool, error)
+	createToken(ctx context.Context, token *DeviceToken) error
+	token(ctx context.Context, accessTokenHash string) (*DeviceToken, error)
+	tokenByRefreshToken(ctx context.Context, refreshTokenHash string) (*DeviceToken, error)
+	// deleteToken drops a token, reporting whether it existed so a refresh token is spent once.
+	deleteToken(ctx context.Context, accessTokenHash string) (bool, error)
+	clear(ctx context.Context) error
+	prune(ctx context.Context, now time.Time) error
+}
+
+// deviceLoginStore returns the store of the device logins: the config store when there is one, so that a code
+// started on one replica can be confirmed and redeemed on another, else memory.
+func (s *Config) deviceLoginStore() deviceLoginStore {
+	if s.ConfigStore != nil {
+		return configDeviceLogins{store: s.ConfigStore}
+	}
+	return &s.deviceLogins
+}
+
+// StartDeviceAuthorization starts a device login for scopes, every scope when empty.
+func (s *Config) StartDeviceAuthorization(ctx context.Context, clientName string, scopes []serviceaccounts.Scope) (*DeviceAuthorization, error) {
+	for _, scope := range scopes {
+		if !serviceaccounts.ValidScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	deviceCode, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate device code: %w", err)
+	}
+	now := time.Now().UTC()
+	store := s.deviceLoginStore()
+	if err := store.prune(ctx, now); err != nil {
+		logger.Warn("failed to delete expired device logins: %v", err)
+	}
+	var userCode string
+	for userCode == "" {
+		if userCode, err = randomUserCode(); err != nil {
+			return nil, fmt.Errorf("failed to generate user code: %w", err)
+		}
+		taken, err := store.authorizationByUserCode(ctx, userCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check user code: %w", err)
+		}
+		if taken != nil {
+			userCode = ""
+		}
+	}
+	authorization := &DeviceAuthorization{
+		DeviceCode:     deviceCode,
+		UserCode:       userCode,
+		ClientName:     clientName,
+		Scopes:         scopes,
+		Status:         DeviceAuthorizationPending,
+		Interval:       DevicePollInterval,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(DeviceCodeTTL),
+		deviceCodeHash: serviceaccounts.HashToken(deviceCode),
+	}
+	if err := store.createAuthorization(ctx, authorization); err != nil {
+		return nil, fmt.Errorf("failed to save device authorization: %w", err)
+	}
+	return authorization, nil
+}
+
+// GetDeviceAuthorization returns the pending device login of a user code, nil when it is unknown, expired or
+// already decided.
+func (s *Config) GetDeviceAuthorization(ctx context.Context, userCode string) *DeviceAuthorization {
+	authorization, err := s.deviceLoginStore().authorizationByUserCode(ctx, NormalizeUserCode(userCode))
+	if err != nil {
+		logger.Warn("failed to get device authorization: %v", err)
+		return nil
+	}
+	if authorization == nil || authorization.Status != DeviceAuthorizationPending || !time.Now().Before(authorization.ExpiresAt) {
+		return nil
+	}
+	return authorization
+}
+
+// DecideDeviceAuthorization approves or denies the pending device login of a user code. An approval may narrow the
+// requested scopes; it cannot widen them.
+func (s *Config) DecideDeviceAuthorization(ctx context.Context, userCode string, approve bool, scopes []serviceaccounts.Scope) (*DeviceAuthorization, error) {
+	store := s.deviceLoginStore()
+	authorization, err := store.authorizationByUserCode(ctx, NormalizeUserCode(userCode))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device authorization: %w", err)
+	}
+	if authorization == nil || authorization.Status != DeviceAuthorizationPending || !time.Now().Before(authorization.ExpiresAt) {
+		return nil, fmt.Errorf("unknown or expired code %q", userCode)
+	}
+	authorization.Status = DeviceAuthorizationDenied
+	if approve {
+		if len(scopes) > 0 {
+			requested := &UISession{Scopes: authorization.Scopes}
+			for _, scope := range scopes {
+				if !serviceaccounts.ValidScope(scope) {
+					return nil, fmt.Errorf("unknown scope %q", scope)
+				}
+				if !requested.Allows(scope) {
+					return nil, fmt.Errorf("the device did not request the %s scope", scope)
+				}
+			}
+			authorization.Scopes = scopes
+		}
+		authorization.Status = DeviceAuthorizationApproved
+	}
+	decided, err := store.updateAuthorization(ctx, authorization, DeviceAuthorizationPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save device authorization: %w", err)
+	}
+	if !decided {
+		return nil, fmt.Errorf("unknown or expired code %q", userCode)
+	}
+	return authorization, nil
+}
+
+// PollDeviceAuthorization exchanges the device code of an approved login for a device token. Until then it returns
+// ErrAuthorizationPending, ErrSlowDown when a pending login is polled faster than the interval (which then grows
+// by five seconds), ErrAccessDenied or ErrExpiredToken. The device code is spent once a token is issued.
+func (s *Config) PollDeviceAuthorization(ctx context.Context, deviceCode string) (*DeviceToken, error) {
+	now := time.Now().UTC()
+	store := s.deviceLoginStore()
+	deviceCodeHash := serviceaccounts.HashToken(deviceCode)
+	authorization, err := store.authorization(ctx, deviceCodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device authorization: %w", err)
+	}
+	if authorization == nil {
+		return nil, ErrInvalidGrant
+	}
+	if !now.Before(authorization.ExpiresAt) {
+		store.deleteAuthorization(ctx, deviceCodeHash)
+		return nil, ErrExpiredToken
+	}
+	switch authorization.Status {
+	case DeviceAuthorizationDenied:
+		store.deleteAuthorization(ctx, deviceCodeHash)
+		return nil, ErrAccessDenied
+	case DeviceAuthorizationPending:
+		polledAt := authorization.lastPolledAt
+		authorization.lastPolledAt = now
+		tooFast := !polledAt.IsZero() && now.Sub(polledAt) < authorization.Interval
+		if tooFast {
+			authorization.Interval += 5 * time.Second
+		}
+		// A decision made meanwhile wins over the poll, which the next poll then sees
+		if _, err := store.updateAuthorization(ctx, authorization, DeviceAuthorizationPending); err != nil {
+			return nil, fmt.Errorf("failed to save device authorization: %w", err)
+		}
+		if tooFast {
+			return nil, ErrSlowDown
+		}
+		return nil, ErrAuthorizationPending
+	}
+	spent, err := store.deleteAuthorization(ctx, deviceCodeHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to spend device code: %w", err)
+	}
+	if !spent {
+		return nil, ErrInvalidGrant
+	}
+	return s.issueDeviceToken(ctx, store, authorization.ClientName, authorization.Scopes, now)
+}
+
+// RefreshDeviceToken renews a device token with its refresh token. Both tokens are rotated: the old ones stop
+// working.
+func (s *Config) RefreshDeviceToken(ctx context.Context, refreshToken string) (*DeviceToken, error) {
+	now := time.Now().UTC()
+	store := s.deviceLoginStore()
+	token, err := store.tokenByRefreshToken(ctx, serviceaccounts.HashToken(refreshToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device token: %w", err)
+	}
+	if token == nil || !now.Before(token.RefreshExpiresAt) {
+		return nil, ErrInvalidGrant
+	}
+	spent, err := store.deleteToken(ctx, token.accessTokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to spend refresh token: %w", err)
+	}
+	if !spent {
+		return nil, ErrInvalidGrant
+	}
+	return s.issueDeviceToken(ctx, store, token.ClientName, token.Scopes, now)
+}
+
+// GetDeviceToken returns the device token of an access token, nil when it is unknown or expired.
+func (s *Config) GetDeviceToken(ctx context.Context, accessToken string) *DeviceToken {
+	token, err := s.deviceLoginStore().token(ctx, serviceaccounts.HashToken(accessToken))
+	if err != nil {
+		logger.Warn("failed to get device token: %v", err)
+		return nil
+	}
+	if token == nil || !time.Now().Before(token.ExpiresAt) {
+		return nil
+	}
+	return token
+}
+
+// RevokeDeviceToken ends the device login of an access token.
+func (s *Config) RevokeDeviceToken(ctx context.Context, accessToken string) {
+	if _, err := s.deviceLoginStore().deleteToken(ctx, serviceaccounts.HashToken(accessToken)); err != nil {
+		logger.Warn("failed to revoke device token: %v", err)
+	}
+}
+
+// clearDeviceLogins ends every device login, pending or confirmed.
+func (s *Config) clearDeviceLogins(ctx context.Context) {
+	if err := s.deviceLoginStore().clear(ctx); err != nil {
+		logger.Warn("failed to delete device logins: %v", err)
+	}
+}
+
+// issueDeviceToken issues a device token and saves it to store.
+func (s *Config) issueDeviceToken(ctx context.Context, store deviceLoginStore, clientName string, scopes []serviceaccounts.Scope, now time.Time) (*DeviceToken, error) {
+	access, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	refresh, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := &DeviceToken{
+		AccessToken:      DeviceTokenPrefix + access,
+		RefreshToken:     DeviceRefreshTokenPrefix + refresh,
+		ClientName:       clientName,
+		Scopes:           scopes,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(DeviceAccessTokenTTL),
+		RefreshExpiresAt: now.Add(DeviceRefreshTTL),
+	}
+	token.accessTokenHash = serviceaccounts.HashToken(token.AccessToken)
+	token.refreshTokenHash = serviceaccounts.HashToken(token.RefreshToken)
+	if err := store.createToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to save device token: %w", err)
+	}
+	return token, nil
+}
+
+// deviceLogins holds the device authorizations and tokens in memory when there is no config store. Neither survives
+// a restart nor is shared between replicas; CLIs log in again.
+type deviceLogins struct {
+	mu             sync.Mutex
+	byDeviceCode   map[string]*DeviceAuthorization
+	byUserCode     map[string]*DeviceAuthorization
+	byAccessToken  map[string]*DeviceToken
+	byRefreshToken map[string]*DeviceToken
+}
+
+// createAuthorization keeps a copy of an authorization, without its device code.
+func (d *deviceLogins) createAuthorization(ctx context.Context, authorization *DeviceAuthorization) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.byDeviceCode == nil {
+		d.byDeviceCode = make(map[string]*DeviceAuthorization)
+		d.byUserCode = make(map[string]*DeviceAuthorization)
+	}
+	stored := *authorization
+	stored.DeviceCode = ""
+	d.byDeviceCode[stored.deviceCodeHash] = &stored
+	d.byUserCode[stored.UserCode] = &stored
+	return nil
+}
+
+// authorization returns a copy of the authorization of a device code hash.
+func (d *deviceLogins) authorization(ctx context.Context, deviceCodeHash string) (*DeviceAuthorization, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return copyDeviceAuthorization(d.byDeviceCode[deviceCodeHash]), nil
+}
+
+// authorizationByUserCode returns a copy of the authorization of a user code.
+func (d *deviceLogins) authorizationByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return copyDeviceAuthorization(d.byUserCode[userCode]), nil
+}
+
+// updateAuthorization replaces an authorization still in status.
+func (d *deviceLogins) updateAuthorization(ctx context.Context, authorization *DeviceAuthorization, status string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stored := d.byDeviceCode[authorization.deviceCodeHash]
+	if stored == nil || stored.Status != status {
+		return false, nil
+	}
+	*stored = *authorization
+	stored.DeviceCode = ""
+	return true, nil
+}
+
+// deleteAuthorization forgets an authorization.
+func (d *deviceLogins) deleteAuthorization(ctx context.Context, deviceCodeHash string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stored := d.byDeviceCode[deviceCodeHash]
+	if stored == nil {
+		return false, nil
+	}
+	delete(d.byDeviceCode, deviceCodeHash)
+	delete(d.byUserCode, stored.UserCode)
+	return true, nil
+}
+
+// createToken keeps a copy of a token, without the tokens themselves.
+func (d *deviceLogins) createToken(ctx context.Context, token *DeviceToken) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.byAccessToken == nil {
+		d.byAccessToken = make(map[string]*DeviceToken)
+		d.byRefreshToken = make(map[string]*DeviceToken)
+	}
+	stored := *token
+	stored.AccessToken, stored.RefreshToken = "", ""
+	d.byAccessToken[stored.accessTokenHash] = &stored
+	d.byRefreshToken[stored.refreshTokenHash] = &stored
+	return nil
+}
+
+// token returns a copy of the token of an access token hash.
+func (d *deviceLogins) token(ctx context.Context, accessTokenHash string) (*DeviceToken, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return copyDeviceToken(d.byAccessToken[accessTokenHash]), nil
+}
+
+// tokenByRefreshToken returns a copy of the token of a refresh token hash.
+func (d *deviceLogins) tokenByRefreshToken(ctx context.Context, refreshTokenHash string) (*DeviceToken, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return copyDeviceToken(d.byRefreshToken[refreshTokenHash]), nil
+}
+
+// deleteToken forgets a token.
+func (d *deviceLogins) deleteToken(ctx context.Context, accessTokenHash string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stored := d.byAccessToken[accessTokenHash]
+	if stored == nil {
+		return false, nil
+	}
+	delete(d.byAccessToken, accessTokenHash)
+	delete(d.byRefreshToken, stored.refreshTokenHash)
+	return true, nil
+}
+
+// clear forgets every authorization and token.
+func (d *deviceLogins) clear(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byDeviceCode = nil
+	d.byUserCode = nil
+	d.byAccessToken = nil
+	d.byRefreshToken = nil
+	return nil
+}
+
+// prune forgets the authorizations and the tokens whose refresh token expired at now.
+func (d *deviceLogins) prune(ctx context.Context, now time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for hash, authorization := range d.byDeviceCode {
+		if !now.Before(authorization.ExpiresAt) {
+			delete(d.byDeviceCode, hash)
+			delete(d.byUserCode, authorization.UserCode)
+		}
+	}
+	for hash, token := range d.byAccessToken {
+		if !now.Before(token.RefreshExpiresAt) {
+			delete(d.byAccessToken, hash)
+			delete(d.byRefreshToken, token.refreshTokenHash)
+		}
+	}
+	return nil
+}
+
+// copyDeviceAuthorization returns a copy of authorization, nil when it is nil.
+func copyDeviceAuthorization(authorization *DeviceAuthorization) *DeviceAuthorization {
+	if authorization == nil {
+		return nil
+	}
+	result := *authorization
+	return &result
+}
+
+// copyDeviceToken returns a copy of token, nil when it is nil.
+func copyDeviceToken(token *DeviceToken) *DeviceToken {
+	if token == nil {
+		return nil
+	}
+	result := *token
+	return &result
+}
+
+// configDeviceLogins keeps the device authorizations and tokens in the config store, shared by every replica.
+type configDeviceLogins struct {
+	store configstore.ConfigStore
+}
+
+// createAuthorization saves an authorization.
+func (c configDeviceLogins) createAuthorization(ctx context.Context, authorization *DeviceAuthorization) error {
+	return c.store.CreateDeviceAuthorization(ctx, deviceAuthorizationToTable(authorization))
+}
+
+// authorization loads the authorization of a device code hash.
+func (c configDeviceLogins) authorization(ctx context.Context, deviceCodeHash string) (*DeviceAuthorization, error) {
+	return deviceAuthorizationFromTable(c.store.GetDeviceAuthorization(ctx, deviceCodeHash))
+}
+
+// authorizationByUserCode loads the authorization of a user code.
+func (c configDeviceLogins) authorizationByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error) {
+	return deviceAuthorizationFromTable(c.store.GetDeviceAuthorizationByUserCode(ctx, userCode))
+}
+
+// updateAuthorization saves an authorization still in status.
+func (c configDeviceLogins) updateAuthorization(ctx context.Context, authorization *DeviceAuthorization, status string) (bool, error) {
+	return c.store.UpdateDeviceAuthorization(ctx, deviceAuthorizationToTable(authorization), status)
+}
+
+// deleteAuthorization deletes an authorization.
+func (c configDeviceLogins) deleteAuthorization(ctx context.Context, deviceCodeHash string) (bool, error) {
+	return c.store.DeleteDeviceAuthorization(ctx, deviceCodeHash)
+}
+
+// createToken saves a token.
+func (c configDeviceLogins) createToken(ctx context.Context, token *DeviceToken) error {
+	return c.store.CreateDeviceToken(ctx, &configstore.TableDeviceToken{
+		AccessTokenHash:  token.accessTokenHash,
+		RefreshTokenHash: token.refreshTokenHash,
+		ClientName:       token.ClientName,
+		Scopes:           scopeStrings(token.Scopes),
+		CreatedAt:        token.CreatedAt,
+		ExpiresAt:        token.ExpiresAt,
+		RefreshExpiresAt: token.RefreshExpiresAt,
+	})
+}
+
+// token loads the token of an access token hash.
+func (c configDeviceLogins) token(ctx context.Context, accessTokenHash string) (*DeviceToken, error) {
+	return deviceTokenFromTable(c.store.GetDeviceToken(ctx, accessTokenHash))
+}
+
+// tokenByRefreshToken loads the token of a refresh token hash.
+func (c configDeviceLogins) tokenByRefreshToken(ctx context.Context, refreshTokenHash string) (*DeviceToken, error) {
+	return deviceTokenFromTable(c.store.GetDeviceTokenByRefreshToken(ctx, refreshTokenHash))
+}
+
+// deleteToken deletes a token.
+func (c configDeviceLogins) deleteToken(ctx context.Context, accessTokenHash string) (bool, error) {
+	return c.store.DeleteDeviceToken(ctx, accessTokenHash)
+}
+
+// clear deletes every authorization and token.
+func (c configDeviceLogins) clear(ctx context.Context) error {
+	return c.store.DeleteDeviceLogins(ctx)
+}
+
+// prune deletes the authorizations and the tokens whose refresh token expired at now.
+func (c configDeviceLogins) prune(ctx context.Context, now time.Time) error {
+	return c.store.DeleteExpiredDeviceLogins(ctx, now)
+}
+
+// deviceAuthorizationToTable converts a device authorization to its config store row.
+func deviceAuthorizationToTable(authorization *DeviceAuthorization) *configstore.TableDeviceAuthorization {
+	row := &configstore.TableDeviceAuthorization{
+		DeviceCodeHash: authorization.deviceCodeHash,
+		UserCode:       authorization.UserCode,
+		ClientName:     authorization.ClientName,
+		Scopes:         scopeStrings(authorization.Scopes),
+		Status:         authorization.Status,
+		Interval:       authorization.Interval,
+		CreatedAt:      authorization.CreatedAt,
+		ExpiresAt:      authorization.ExpiresAt,
+	}
+	if !authorization.lastPolledAt.IsZero() {
+		row.LastPolledAt = &authorization.lastPolledAt
+	}
+	return row
+}
+
+// deviceAuthorizationFromTable converts the result of a config store lookup, nil when nothing matched.
+func deviceAuthorizationFromTable(row *configstore.TableDeviceAuthorization, err error) (*DeviceAuthorization, error) {
+	if err != nil {
+		if errors.Is(err, configstore.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	authorization := &DeviceAuthorization{
+		UserCode:       row.UserCode,
+		ClientName:     row.ClientName,
+		Scopes:         scopesOf(row.Scopes),
+		Status:         row.Status,
+		Interval:       row.Interval,
+		CreatedAt:      row.CreatedAt,
+		ExpiresAt:      row.ExpiresAt,
+		deviceCodeHash: row.DeviceCodeHash,
+	}
+	if row.LastPolledAt != nil {
+		authorization.lastPolledAt = *row.LastPolledAt
+	}
+	return authorization, nil
+}
+
+// deviceTokenFromTable converts the result of a config store lookup, nil when nothing matched.
+func deviceTokenFromTable(row *configstore.TableDeviceToken, err error) (*DeviceToken, error) {
+	if err != nil {
+		if errors.Is(err, configstore.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &DeviceToken{
+		ClientName:       row.ClientName,
+		Scopes:           scopesOf(row.Scopes),
+		CreatedAt:        row.CreatedAt,
+		ExpiresAt:        row.ExpiresAt,
+		RefreshExpiresAt: row.RefreshExpiresAt,
+		accessTokenHash:  row.AccessTokenHash,
+		refreshTokenHash: row.RefreshTokenHash,
+	}, nil
+}
+
+// NormalizeUserCode uppercases a user code typed by a user and restores its dash, e.g. "bcdf ghjk" to "BCDF-GHJK".
+func NormalizeUserCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 8 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// randomUserCode returns a random user code of two groups of four characters.
+func randomUserCode() (string, error) {
+	b := make([]byte, 8)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(b[:4]) + "-" + string(b[4:]), nil
+}
@@ -0,0 +1,41 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// EgressConfig controls the connections Bifrost opens to provider APIs, for deployments in locked-down networks.
+type EgressConfig struct {
+	Proxy        *schemas.ProxyConfig `json:"proxy,omitempty"`         // Proxy of providers without their own proxy_config
+	AllowedHosts []string             `json:"allowed_hosts,omitempty"` // Hosts providers may connect to, "*.example.com" matches subdomains
+}
+
+// Validate checks the proxy and the allowlist patterns.
+func (c *EgressConfig) Validate() error {
+	if c.Proxy != nil {
+		if err := c.Proxy.Validate(); err != nil {
+			return fmt.Errorf("egress proxy: %w", err)
+		}
+	}
+	for _, host := range c.AllowedHosts {
+		pattern := strings.TrimPrefix(host, "*.")
+		if pattern == "" || strings.ContainsAny(pattern, "*/: ") {
+			return fmt.Errorf("egress allowed_hosts: invalid host %q, expected a hostname or *.domain", host)
+		}
+	}
+	return nil
+}
+
+// applyEgress sets the egress settings on the config of a provider.
+func (c *EgressConfig) applyEgress(config *schemas.ProviderConfig) {
+	if c == nil {
+		return
+	}
+	if config.ProxyConfig == nil {
+		config.ProxyConfig = c.Proxy
+	}
+	config.AllowedEgressHosts = c.AllowedHosts
+}
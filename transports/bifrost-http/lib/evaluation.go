@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/evaluation"
+	"gorm.io/gorm"
+)
+
+// EvaluationReferenceContextKey holds the expected answer of a request, taken from the x-bf-eval-reference header
+const EvaluationReferenceContextKey ContextKey = "x-bf-eval-reference"
+
+// EvaluationRequestContextKey marks the requests of the evaluators themselves, which are not evaluated
+const EvaluationRequestContextKey ContextKey = "bifrost-evaluation-request"
+
+// initEvaluation sets up the evaluation runner and the store of its scores. Scores are stored in the logs
+// database next to the request logs, in the config store database when logs are kept elsewhere, or in memory.
+func (s *Config) initEvaluation(ctx context.Context, config *evaluation.Config) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	var db *gorm.DB
+	if logsDB, ok := s.LogsStore.(interface{ DB() *gorm.DB }); ok {
+		db = logsDB.DB()
+	} else if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("evaluation scores are kept in memory since no logs or config store database is configured")
+	}
+	store, err := evaluation.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize evaluation scores store: %w", err)
+	}
+	runner, err := evaluation.NewRunner(config, store, evaluation.Backends{Complete: s.evaluationComplete, Embed: s.evaluationEmbed}, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize evaluation: %w", err)
+	}
+	runner.Start()
+	s.Evaluations = runner
+	s.EvaluationScores = store
+	return nil
+}
+
+// evaluationComplete sends the prompt of an LLM judge through the gateway.
+func (s *Config) evaluationComplete(ctx context.Context, model, system, prompt string) (string, error) {
+	client := s.GetBifrostClient()
+	if client == nil {
+		return "", fmt.Errorf("bifrost client is not initialized")
+	}
+	provider, modelName := schemas.ParseModelString(model, "")
+	resp, bifrostErr := client.ChatCompletionRequest(context.WithValue(ctx, EvaluationRequestContextKey, true), &schemas.BifrostChatRequest{
+		Provider: schemas.ModelProvider(provider),
+		Model:    modelName,
+		Input: []schemas.ChatMessage{
+			{Role: schemas.ChatMessageRoleSystem, Content: &schemas.ChatMessageContent{ContentStr: &system}},
+			{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: &prompt}},
+		},
+	})
+	if bifrostErr != nil {
+		if bifrostErr.Error != nil {
+			return "", fmt.Errorf("judge request failed: %s", bifrostErr.Error.Message)
+		}
+		return "", fmt.Errorf("judge request failed")
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].BifrostNonStreamResponseChoice == nil || resp.Choices[0].Message == nil {
+		return "", fmt.Errorf("judge returned no message")
+	}
+	return ChatMessageText(*resp.Choices[0].Message), nil
+}
+
+// evaluationEmbed computes the embeddings of a similarity evaluator through the gateway.
+func (s *Config) evaluationEmbed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	client := s.GetBifrostClient()
+	if client == nil {
+		return nil, fmt.Errorf("bifrost client is not initialized")
+	}
+	provider, modelName := schemas.ParseModelString(model, "")
+	resp, bifrostErr := client.EmbeddingRequest(context.WithValue(ctx, EvaluationRequestContextKey, true), &schemas.BifrostEmbeddingRequest{
+		Provider: schemas.ModelProvider(provider),
+		Model:    modelName,
+		Input:    &schemas.EmbeddingInput{Texts: texts},
+	})
+	if bifrostErr != nil {
+		if bifrostErr.Error != nil {
+			return nil, fmt.Errorf("embedding request failed: %s", bifrostErr.Error.Message)
+		}
+		return nil, fmt.Errorf("embedding request failed")
+	}
+	embeddings := make([][]float64, len(resp.Data))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(embeddings) || data.Embedding.EmbeddingArray == nil {
+			return nil, fmt.Errorf("embedding response has no float embedding for index %d", data.Index)
+		}
+		embedding := make([]float64, len(data.Embedding.EmbeddingArray))
+		for i, value := range data.Embedding.EmbeddingArray {
+			embedding[i] = float64(value)
+		}
+		embeddings[data.Index] = embedding
+	}
+	return embeddings, nil
+}
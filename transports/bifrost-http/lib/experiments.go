@@ -0,0 +1,365 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// ExperimentsConfigKey is the config store key holding the experiments.
+const ExperimentsConfigKey = "experiments"
+
+// Experiment headers. Requests opt into an experiment with ExperimentHeader, and responses report
+// the experiment and variant they were assigned to.
+const (
+	ExperimentHeader        = "x-bf-experiment"         // Experiment to opt into, echoed in the response
+	ExperimentUnitHeader    = "x-bf-experiment-unit"    // Bucketing unit, e.g. a user ID (default: x-bf-user, then x-bf-vk, then the client IP)
+	ExperimentVariantHeader = "x-bf-experiment-variant" // Variant the request was assigned to
+)
+
+// ExperimentAssignmentContextKey stores the *ExperimentAssignment of a request, both as a fasthttp user value
+// and in the Bifrost context.
+const ExperimentAssignmentContextKey ContextKey = "bifrost-experiment-assignment"
+
+// Experiment splits opted-in requests between variants. A unit (user, key or client) is always assigned
+// the same variant as long as the experiment name and weights do not change.
+type Experiment struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Disabled    bool                `json:"disabled,omitempty"` // Disabled experiments leave requests unchanged
+	Variants    []ExperimentVariant `json:"variants"`
+}
+
+// ExperimentVariant is one arm of an experiment. Empty fields leave the request unchanged, so a variant
+// without overrides is the control.
+type ExperimentVariant struct {
+	Name           string         `json:"name"`
+	Weight         int            `json:"weight"`                    // Percent of the traffic, the weights of an experiment sum to 100
+	Model          string         `json:"model,omitempty"`           // Model replacing the requested one, e.g. "openai/gpt-4o-mini"
+	SystemPrompt   string         `json:"system_prompt,omitempty"`   // Replaces the leading system messages (or the Anthropic system field)
+	PromptTemplate string         `json:"prompt_template,omitempty"` // Wraps the latest user message or the prompt, {{input}} marks where it goes
+	Params         map[string]any `json:"params,omitempty"`          // Body fields to set, e.g. {"temperature": 0.2}
+}
+
+// ValidateExperiments checks that experiments are named uniquely and that their variants are well formed.
+func ValidateExperiments(experiments []Experiment) error {
+	names := make(map[string]struct{}, len(experiments))
+	for i, experiment := range experiments {
+		if experiment.Name == "" {
+			return fmt.Errorf("experiment %d: name is required", i)
+		}
+		if _, ok := names[experiment.Name]; ok {
+			return fmt.Errorf("experiment %s: duplicate name", experiment.Name)
+		}
+		names[experiment.Name] = struct{}{}
+		if err := experiment.Validate(); err != nil {
+			return fmt.Errorf("experiment %s: %w", experiment.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the variants are named uniquely and that their weights sum to 100.
+func (e *Experiment) Validate() error {
+	if len(e.Variants) < 2 {
+		return fmt.Errorf("at least two variants are required")
+	}
+	names := make(map[string]struct{}, len(e.Variants))
+	total := 0
+	for i, variant := range e.Variants {
+		if variant.Name == "" {
+			return fmt.Errorf("variant %d: name is required", i)
+		}
+		if _, ok := names[variant.Name]; ok {
+			return fmt.Errorf("variant %s: duplicate name", variant.Name)
+		}
+		names[variant.Name] = struct{}{}
+		if variant.Weight < 0 {
+			return fmt.Errorf("variant %s: weight cannot be negative", variant.Name)
+		}
+		if variant.PromptTemplate != "" && !strings.Contains(variant.PromptTemplate, "{{input}}") {
+			return fmt.Errorf("variant %s: prompt_template must contain {{input}}", variant.Name)
+		}
+		if _, ok := variant.Params["messages"]; ok {
+			return fmt.Errorf("variant %s: params cannot set messages", variant.Name)
+		}
+		total += variant.Weight
+	}
+	if total != 100 {
+		return fmt.Errorf("variant weights sum to %d, want 100", total)
+	}
+	return nil
+}
+
+// Assign returns the variant of a bucketing unit. Units are hashed with the experiment name, so
+// a unit's variants in different experiments are independent.
+func (e *Experiment) Assign(unit string) *ExperimentVariant {
+	hash := fnv.New32a()
+	hash.Write([]byte(e.Name + "/" + unit))
+	bucket := int(hash.Sum32() % 100)
+	for i := range e.Variants {
+		bucket -= e.Variants[i].Weight
+		if bucket < 0 {
+			return &e.Variants[i]
+		}
+	}
+	return &e.Variants[len(e.Variants)-1]
+}
+
+// Apply rewrites a JSON request body with the overrides of the variant.
+func (v *ExperimentVariant) Apply(body map[string]any) {
+	if v.Model != "" {
+		body["model"] = v.Model
+	}
+	for field, value := range v.Params {
+		body[field] = value
+	}
+
+	messages, _ := body["messages"].([]any)
+	if v.SystemPrompt != "" {
+		if _, ok := body["system"]; ok {
+			body["system"] = v.SystemPrompt
+		} else if messages != nil {
+			leading := 0
+			for leading < len(messages) && isSystemMessage(messages[leading]) {
+				leading++
+			}
+			messages = append([]any{map[string]any{"role": "system", "content": v.SystemPrompt}}, messages[leading:]...)
+			body["messages"] = messages
+		}
+	}
+	if v.PromptTemplate != "" {
+		if prompt, ok := body["prompt"].(string); ok {
+			body["prompt"] = v.applyTemplate(prompt)
+		}
+		for i := len(messages) - 1; i >= 0; i-- {
+			message, ok := messages[i].(map[string]any)
+			if !ok || message["role"] != "user" {
+				continue
+			}
+			if content, ok := message["content"].(string); ok {
+				message["content"] = v.applyTemplate(content)
+			}
+			break
+		}
+	}
+}
+
+// applyTemplate places input into the prompt template.
+func (v *ExperimentVariant) applyTemplate(input string) string {
+	return strings.ReplaceAll(v.PromptTemplate, "{{input}}", input)
+}
+
+// isSystemMessage reports whether a JSON message has the system or developer role.
+func isSystemMessage(message any) bool {
+	m, ok := message.(map[string]any)
+	return ok && (m["role"] == "system" || m["role"] == "developer")
+}
+
+// ExperimentAssignment is the variant a request was assigned to and the outcome of the request.
+// Fallback attempts overwrite the usage of the primary attempt; the outcome is recorded once.
+type ExperimentAssignment struct {
+	Experiment string
+	Variant    string
+	Start      time.Time
+
+	mu       sync.Mutex
+	usage    *schemas.LLMUsage
+	cost     float64
+	recorded bool
+}
+
+// SetUsage records the usage and cost of the attempt that served the request.
+func (a *ExperimentAssignment) SetUsage(usage *schemas.LLMUsage, cost float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.usage = usage
+	a.cost = cost
+}
+
+// Finish records the outcome of the request in results, once.
+func (a *ExperimentAssignment) Finish(results *ExperimentResults, failed bool) {
+	a.mu.Lock()
+	if a.recorded {
+		a.mu.Unlock()
+		return
+	}
+	a.recorded = true
+	usage, cost := a.usage, a.cost
+	a.mu.Unlock()
+	if results != nil {
+		results.RecordOutcome(a.Experiment, a.Variant, time.Since(a.Start), usage, cost, failed)
+	}
+}
+
+// ExperimentVariantResults are the aggregated outcomes of the requests assigned to a variant.
+type ExperimentVariantResults struct {
+	Variant          string  `json:"variant"`
+	Requests         int64   `json:"requests"`
+	Errors           int64   `json:"errors"`
+	ErrorRate        float64 `json:"error_rate"`
+	AvgLatencyMs     float64 `json:"avg_latency_ms"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	AvgTotalTokens   float64 `json:"avg_total_tokens"` // Per successful request
+	Cost             float64 `json:"cost"`             // Total cost in dollars, when pricing is known
+	FeedbackCount    int64   `json:"feedback_count"`
+	AvgScore         float64 `json:"avg_score"` // Mean of the feedback scores
+}
+
+// experimentVariantStats accumulates the outcomes of a variant.
+type experimentVariantStats struct {
+	requests         int64
+	errors           int64
+	latency          time.Duration
+	promptTokens     int64
+	completionTokens int64
+	cost             float64
+	feedbackCount    int64
+	scoreSum         float64
+}
+
+// ExperimentResults aggregates experiment outcomes in memory. Results are per node and lost on restart.
+type ExperimentResults struct {
+	mu    sync.Mutex
+	stats map[string]map[string]*experimentVariantStats // Experiment name -> variant name -> stats
+}
+
+// NewExperimentResults creates an empty results aggregator.
+func NewExperimentResults() *ExperimentResults {
+	return &ExperimentResults{stats: make(map[string]map[string]*experimentVariantStats)}
+}
+
+// variant returns the stats of a variant, creating them. Callers hold mu.
+func (r *ExperimentResults) variant(experiment, variant string) *experimentVariantStats {
+	variants, ok := r.stats[experiment]
+	if !ok {
+		variants = make(map[string]*experimentVariantStats)
+		r.stats[experiment] = variants
+	}
+	stats, ok := variants[variant]
+	if !ok {
+		stats = &experimentVariantStats{}
+		variants[variant] = stats
+	}
+	return stats
+}
+
+// RecordOutcome adds the outcome of a request to the results of its variant.
+func (r *ExperimentResults) RecordOutcome(experiment, variant string, latency time.Duration, usage *schemas.LLMUsage, cost float64, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.variant(experiment, variant)
+	stats.requests++
+	stats.latency += latency
+	if failed {
+		stats.errors++
+		return
+	}
+	if usage != nil {
+		stats.promptTokens += int64(usage.PromptTokens)
+		stats.completionTokens += int64(usage.CompletionTokens)
+	}
+	stats.cost += cost
+}
+
+// RecordFeedback adds a client reported score, e.g. a rating of the answer, to the results of a variant.
+func (r *ExperimentResults) RecordFeedback(experiment, variant string, score float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.variant(experiment, variant)
+	stats.feedbackCount++
+	stats.scoreSum += score
+}
+
+// Get returns the results of the variants of an experiment, in the order of variants.
+func (r *ExperimentResults) Get(experiment string, variants []string) []ExperimentVariantResults {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]ExperimentVariantResults, 0, len(variants))
+	for _, name := range variants {
+		result := ExperimentVariantResults{Variant: name}
+		if stats, ok := r.stats[experiment][name]; ok {
+			result.Requests = stats.requests
+			result.Errors = stats.errors
+			result.PromptTokens = stats.promptTokens
+			result.CompletionTokens = stats.completionTokens
+			result.Cost = stats.cost
+			result.FeedbackCount = stats.feedbackCount
+			if stats.requests > 0 {
+				result.ErrorRate = float64(stats.errors) / float64(stats.requests)
+				result.AvgLatencyMs = float64(stats.latency.Microseconds()) / 1000 / float64(stats.requests)
+			}
+			if succeeded := stats.requests - stats.errors; succeeded > 0 {
+				result.AvgTotalTokens = float64(stats.promptTokens+stats.completionTokens) / float64(succeeded)
+			}
+			if stats.feedbackCount > 0 {
+				result.AvgScore = stats.scoreSum / float64(stats.feedbackCount)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Reset drops the results of an experiment.
+func (r *ExperimentResults) Reset(experiment string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.stats, experiment)
+}
+
+// GetExperiments returns the experiments.
+func (s *Config) GetExperiments() []Experiment {
+	experiments := s.experiments.Load()
+	if experiments == nil {
+		return nil
+	}
+	return *experiments
+}
+
+// GetExperiment returns the experiment with the given name.
+func (s *Config) GetExperiment(name string) (*Experiment, bool) {
+	for _, experiment := range s.GetExperiments() {
+		if experiment.Name == name {
+			return &experiment, true
+		}
+	}
+	return nil, false
+}
+
+// UpdateExperiments validates and activates experiments, persisting them in the config store when one is configured.
+func (s *Config) UpdateExperiments(ctx context.Context, experiments []Experiment) error {
+	if err := ValidateExperiments(experiments); err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, ExperimentsConfigKey, experiments); err != nil {
+		return fmt.Errorf("failed to save experiments: %w", err)
+	}
+	s.experiments.Store(&experiments)
+	return nil
+}
+
+// loadExperiments activates the experiments saved in the config store. Without saved experiments,
+// the experiments from the config file are used and saved to bootstrap the store.
+func (s *Config) loadExperiments(ctx context.Context, fileExperiments []Experiment) error {
+	s.ExperimentResults = NewExperimentResults()
+	var experiments []Experiment
+	found, err := s.loadStoredConfig(ctx, ExperimentsConfigKey, &experiments)
+	if err != nil {
+		return fmt.Errorf("failed to load experiments: %w", err)
+	}
+	if found {
+		s.experiments.Store(&experiments)
+		return nil
+	}
+	if len(fileExperiments) == 0 {
+		return nil
+	}
+	return s.UpdateExperiments(ctx, fileExperiments)
+}
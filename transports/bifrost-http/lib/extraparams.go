@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ExtraBodyField is the request body object holding vendor-specific parameters, as sent by clients such as
+// LiteLLM. OpenAI SDKs merge their extra_body argument into the body instead, where Bifrost finds its fields too.
+const ExtraBodyField = "extra_body"
+
+// ExtraParams returns the fields of a JSON object request body that are not known fields, with the fields of its
+// extra_body object flattened into them. Fields set at the top level win over the same fields in extra_body.
+// Providers only receive the extra parameters their passthrough policy (network_config.passthrough_params)
+// allows, the others being dropped unless the provider interprets them itself.
+func ExtraParams(body []byte, knownFields map[string]bool) (map[string]any, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	extraParams := make(map[string]any)
+	for name, value := range fields {
+		if knownFields[name] || name == ExtraBodyField {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(value, &v); err != nil {
+			continue
+		}
+		extraParams[name] = v
+	}
+
+	raw, ok := fields[ExtraBodyField]
+	if !ok || string(raw) == "null" {
+		return extraParams, nil
+	}
+	var extraBody map[string]any
+	if err := json.Unmarshal(raw, &extraBody); err != nil {
+		return nil, fmt.Errorf("%s must be an object", ExtraBodyField)
+	}
+	for name, value := range extraBody {
+		if knownFields[name] {
+			return nil, fmt.Errorf("%s.%s is a standard parameter, set it at the top level of the request", ExtraBodyField, name)
+		}
+		if _, ok := extraParams[name]; !ok {
+			extraParams[name] = value
+		}
+	}
+	return extraParams, nil
+}
+
+// knownFieldsCache caches the known fields of request types by type
+var knownFieldsCache sync.Map // reflect.Type -> map[string]bool
+
+// KnownFields returns the JSON field names of a request struct, or pointer to one, including the fields of its
+// embedded structs.
+func KnownFields(req any) map[string]bool {
+	t := reflect.TypeOf(req)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if cached, ok := knownFieldsCache.Load(t); ok {
+		return cached.(map[string]bool)
+	}
+	fields := make(map[string]bool)
+	collectKnownFields(t, fields)
+	knownFieldsCache.Store(t, fields)
+	return fields
+}
+
+// collectKnownFields adds the JSON field names of struct type t to fields
+func collectKnownFields(t reflect.Type, fields map[string]bool) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			collectKnownFields(embedded, fields)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = true
+	}
+}
@@ -0,0 +1,477 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/finetuning"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultFineTuningPollIntervalSeconds = 60
+	defaultAzureFineTuningAPIVersion     = "2024-10-21"
+	maxFineTuningResponseBytes           = 10 << 20
+)
+
+// FineTuningProviders are the providers fine-tuning jobs can be proxied to.
+var FineTuningProviders = []schemas.ModelProvider{schemas.OpenAI, schemas.Azure}
+
+// FineTuningConfig enables the fine-tuning job endpoints, which proxy job creation, listing and cancellation
+// to the providers supporting it with the keys configured in Bifrost. Creation is subject to the governance
+// checks of the virtual key, including its budget, and to the models and datasets allowed here.
+type FineTuningConfig struct {
+	Enabled                    bool     `json:"enabled"`
+	PollIntervalSeconds        int      `json:"poll_interval_seconds,omitempty"`           // How often the status of running jobs is refreshed (default: 60)
+	AllowedModels              []string `json:"allowed_models,omitempty"`                  // Base models that may be fine-tuned, a trailing * matches a prefix (default: all)
+	AllowedDatasets            []string `json:"allowed_datasets,omitempty"`                // File IDs usable as training or validation files, a trailing * matches a prefix (default: all)
+	RequireVirtualKey          bool     `json:"require_virtual_key,omitempty"`             // Reject job creation without a virtual key
+	MaxActiveJobsPerVirtualKey int      `json:"max_active_jobs_per_virtual_key,omitempty"` // Jobs of a virtual key not finished yet (default: no limit)
+}
+
+// Validate checks the configuration.
+func (c *FineTuningConfig) Validate() error {
+	if c.PollIntervalSeconds < 0 || c.MaxActiveJobsPerVirtualKey < 0 {
+		return fmt.Errorf("fine_tuning: poll_interval_seconds and max_active_jobs_per_virtual_key must not be negative")
+	}
+	return nil
+}
+
+// FineTuningError is a request the gateway rejects before reaching the provider.
+type FineTuningError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *FineTuningError) Error() string {
+	return e.Message
+}
+
+// ProviderResponse is the raw response of a provider to a proxied request.
+type ProviderResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// FineTuner proxies fine-tuning requests to providers and tracks the jobs created through the gateway.
+type FineTuner struct {
+	config  FineTuningConfig
+	store   finetuning.Store
+	gateway *Config
+}
+
+// NewFineTuner creates a fine-tuner over store, sending requests with the provider keys of gateway.
+func NewFineTuner(config FineTuningConfig, store finetuning.Store, gateway *Config) *FineTuner {
+	if config.PollIntervalSeconds == 0 {
+		config.PollIntervalSeconds = DefaultFineTuningPollIntervalSeconds
+	}
+	return &FineTuner{config: config, store: store, gateway: gateway}
+}
+
+// RequiresVirtualKey reports whether jobs may only be created with a virtual key.
+func (f *FineTuner) RequiresVirtualKey() bool {
+	return f.config.RequireVirtualKey
+}
+
+// CheckCreate rejects, with a *FineTuningError, a job of virtualKey fine-tuning model on files when the
+// model or a file is not allowed or the virtual key has too many running jobs.
+func (f *FineTuner) CheckCreate(ctx context.Context, virtualKey, model string, files []string) error {
+	if len(f.config.AllowedModels) > 0 && !slices.ContainsFunc(f.config.AllowedModels, func(pattern string) bool { return matchesFineTuningPattern(pattern, model) }) {
+		return &FineTuningError{StatusCode: http.StatusForbidden, Message: fmt.Sprintf("model %s may not be fine-tuned", model)}
+	}
+	for _, file := range files {
+		if file != "" && len(f.config.AllowedDatasets) > 0 && !slices.ContainsFunc(f.config.AllowedDatasets, func(pattern string) bool { return matchesFineTuningPattern(pattern, file) }) {
+			return &FineTuningError{StatusCode: http.StatusForbidden, Message: fmt.Sprintf("dataset %s is not allowed for fine-tuning", file)}
+		}
+	}
+	if virtualKey != "" && f.config.MaxActiveJobsPerVirtualKey > 0 {
+		_, active, err := f.store.List(ctx, finetuning.Filter{VirtualKey: virtualKey, Active: true}, 1, 0)
+		if err != nil {
+			return err
+		}
+		if active >= int64(f.config.MaxActiveJobsPerVirtualKey) {
+			return &FineTuningError{StatusCode: http.StatusTooManyRequests, Message: fmt.Sprintf("virtual key already has %d running fine-tuning jobs", active)}
+		}
+	}
+	return nil
+}
+
+// matchesFineTuningPattern reports whether value equals pattern, or starts with it when it ends with *
+func matchesFineTuningPattern(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+	return pattern == value
+}
+
+// Create creates a job from body, whose model has no provider prefix, and tracks it for virtualKey.
+func (f *FineTuner) Create(ctx context.Context, provider schemas.ModelProvider, virtualKey string, body map[string]any) (*ProviderResponse, error) {
+	model, _ := body["model"].(string)
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	response, key, err := f.send(ctx, provider, "", model, http.MethodPost, "", nil, payload)
+	if err != nil || response.StatusCode >= 300 {
+		return response, err
+	}
+	job := &finetuning.Job{Provider: string(provider), KeyID: key.ID, VirtualKey: virtualKey}
+	if err := f.track(ctx, job, response.Body); err != nil {
+		logger.Warn("failed to track fine-tuning job: %v", err)
+	}
+	return response, nil
+}
+
+// Job returns a tracked job of virtualKey. Jobs created with a virtual key are only visible to it, and jobs created
+// without one to callers without one, as the fine-tuning routes are public.
+func (f *FineTuner) Job(ctx context.Context, id, virtualKey string) (*finetuning.Job, error) {
+	job, err := f.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(job.VirtualKey), []byte(virtualKey)) != 1 {
+		return nil, finetuning.ErrNotFound
+	}
+	return job, nil
+}
+
+// Jobs returns a page of the tracked jobs matching filter.
+func (f *FineTuner) Jobs(ctx context.Context, filter finetuning.Filter, limit, offset int) ([]finetuning.Job, int64, error) {
+	return f.store.List(ctx, filter, limit, offset)
+}
+
+// Retrieve fetches a tracked job from its provider and updates its status.
+func (f *FineTuner) Retrieve(ctx context.Context, job *finetuning.Job) (*ProviderResponse, error) {
+	response, _, err := f.send(ctx, schemas.ModelProvider(job.Provider), job.KeyID, "", http.MethodGet, "/"+job.ID, nil, nil)
+	if err == nil && response.StatusCode < 300 {
+		if err := f.track(ctx, job, response.Body); err != nil {
+			logger.Warn("failed to track fine-tuning job %s: %v", job.ID, err)
+		}
+	}
+	return response, err
+}
+
+// Cancel cancels a tracked job and updates its status.
+func (f *FineTuner) Cancel(ctx context.Context, job *finetuning.Job) (*ProviderResponse, error) {
+	response, _, err := f.send(ctx, schemas.ModelProvider(job.Provider), job.KeyID, "", http.MethodPost, "/"+job.ID+"/cancel", nil, nil)
+	if err == nil && response.StatusCode < 300 {
+		if err := f.track(ctx, job, response.Body); err != nil {
+			logger.Warn("failed to track fine-tuning job %s: %v", job.ID, err)
+		}
+	}
+	return response, err
+}
+
+// Events fetches the progress events of a tracked job; query carries the limit and after pagination parameters.
+func (f *FineTuner) Events(ctx context.Context, job *finetuning.Job, query url.Values) (*ProviderResponse, error) {
+	response, _, err := f.send(ctx, schemas.ModelProvider(job.Provider), job.KeyID, "", http.MethodGet, "/"+job.ID+"/events", query, nil)
+	return response, err
+}
+
+// List fetches the jobs of the first key of provider; query carries the limit and after pagination parameters.
+func (f *FineTuner) List(ctx context.Context, provider schemas.ModelProvider, query url.Values) (*ProviderResponse, error) {
+	response, _, err := f.send(ctx, provider, "", "", http.MethodGet, "", query, nil)
+	return response, err
+}
+
+// Start refreshes the status and latest event of the running jobs until ctx is done.
+func (f *FineTuner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(f.config.PollIntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				f.refresh(ctx)
+			}
+		}
+	}()
+}
+
+// refresh updates the running jobs from their providers
+func (f *FineTuner) refresh(ctx context.Context) {
+	jobs, _, err := f.store.List(ctx, finetuning.Filter{Active: true}, 0, 0)
+	if err != nil {
+		logger.Warn("failed to list running fine-tuning jobs: %v", err)
+		return
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if response, err := f.Retrieve(ctx, job); err != nil || response.StatusCode >= 300 {
+			logger.Debug("failed to refresh fine-tuning job %s: %v", job.ID, fineTuningFailure(response, err))
+			continue
+		}
+		response, err := f.Events(ctx, job, url.Values{"limit": {"1"}})
+		if err != nil || response.StatusCode >= 300 {
+			continue
+		}
+		var events struct {
+			Data []struct {
+				Message string `json:"message"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(response.Body, &events) == nil && len(events.Data) > 0 && events.Data[0].Message != job.LastEvent {
+			job.LastEvent = events.Data[0].Message
+			if err := f.store.Save(ctx, job); err != nil {
+				logger.Warn("failed to save fine-tuning job %s: %v", job.ID, err)
+			}
+		}
+	}
+}
+
+// fineTuningFailure describes a failed provider request
+func fineTuningFailure(response *ProviderResponse, err error) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("provider returned status %d", response.StatusCode)
+}
+
+// track updates job from the job object returned by its provider and saves it
+func (f *FineTuner) track(ctx context.Context, job *finetuning.Job, body []byte) error {
+	var object struct {
+		ID              string `json:"id"`
+		Model           string `json:"model"`
+		FineTunedModel  string `json:"fine_tuned_model"`
+		Status          string `json:"status"`
+		TrainingFile    string `json:"training_file"`
+		ValidationFile  string `json:"validation_file"`
+		TrainedTokens   int64  `json:"trained_tokens"`
+		EstimatedFinish int64  `json:"estimated_finish"`
+		FinishedAt      int64  `json:"finished_at"`
+		CreatedAt       int64  `json:"created_at"`
+		Error           *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &object); err != nil {
+		return err
+	}
+	if object.ID == "" {
+		return fmt.Errorf("provider returned a job without id")
+	}
+	job.ID = object.ID
+	job.Model = object.Model
+	job.FineTunedModel = object.FineTunedModel
+	job.Status = object.Status
+	job.TrainingFile = object.TrainingFile
+	job.ValidationFile = object.ValidationFile
+	job.TrainedTokens = object.TrainedTokens
+	job.EstimatedFinish = unixTime(object.EstimatedFinish)
+	job.FinishedAt = unixTime(object.FinishedAt)
+	if object.CreatedAt > 0 && job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Unix(object.CreatedAt, 0)
+	}
+	job.Error = ""
+	if object.Error != nil {
+		job.Error = object.Error.Message
+	}
+	return f.store.Save(ctx, job)
+}
+
+// unixTime converts unix seconds, 0 meaning unset
+func unixTime(seconds int64) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	t := time.Unix(seconds, 0)
+	return &t
+}
+
+// send makes a fine-tuning API request to provider with the key keyID, or else the first key serving model, or
+// else the first key. path is relative to the fine-tuning jobs collection.
+func (f *FineTuner) send(ctx context.Context, provider schemas.ModelProvider, keyID, model, method, path string, query url.Values, body []byte) (*ProviderResponse, *schemas.Key, error) {
+	if !slices.Contains(FineTuningProviders, provider) {
+		return nil, nil, &FineTuningError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("fine-tuning is not supported for provider %s", provider)}
+	}
+	config, err := f.gateway.GetProviderConfigRaw(provider)
+	if err != nil {
+		return nil, nil, &FineTuningError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("provider %s is not configured", provider)}
+	}
+	key := selectFineTuningKey(config.Keys, keyID, model)
+	if key == nil {
+		return nil, nil, &FineTuningError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("no key of provider %s can serve fine-tuning requests", provider)}
+	}
+
+	requestURL, headers, err := fineTuningEndpoint(provider, config, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	requestURL.Path += path
+	values := requestURL.Query()
+	for name, value := range query {
+		values[name] = value
+	}
+	requestURL.RawQuery = values.Encode()
+	var egressHosts []string
+	if f.gateway.Egress != nil {
+		egressHosts = f.gateway.Egress.AllowedHosts
+	}
+	if !schemas.EgressHostAllowed(egressHosts, requestURL.Hostname()) {
+		return nil, nil, &FineTuningError{StatusCode: http.StatusForbidden, Message: fmt.Sprintf("egress to %s is not allowed", requestURL.Hostname())}
+	}
+
+	client, err := f.httpClient(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL.String(), reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fine-tuning request to %s failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFineTuningResponseBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the fine-tuning response of %s: %w", provider, err)
+	}
+	return &ProviderResponse{StatusCode: resp.StatusCode, Body: data}, key, nil
+}
+
+// selectFineTuningKey returns the key with ID keyID, or else the first key serving model, or else the first key
+func selectFineTuningKey(keys []schemas.Key, keyID, model string) *schemas.Key {
+	if keyID != "" {
+		for i := range keys {
+			if keys[i].ID == keyID {
+				return &keys[i]
+			}
+		}
+		return nil
+	}
+	for i := range keys {
+		if model != "" && slices.Contains(keys[i].Models, model) {
+			return &keys[i]
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return &keys[0]
+}
+
+// fineTuningEndpoint returns the URL of the fine-tuning jobs collection of a provider and the headers
+// authenticating requests with key
+func fineTuningEndpoint(provider schemas.ModelProvider, config *configstore.ProviderConfig, key *schemas.Key) (*url.URL, map[string]string, error) {
+	headers := map[string]string{}
+	if config.NetworkConfig != nil {
+		for name, value := range config.NetworkConfig.ExtraHeaders {
+			headers[name] = value
+		}
+	}
+	var rawURL string
+	switch provider {
+	case schemas.Azure:
+		if key.AzureKeyConfig == nil || key.AzureKeyConfig.Endpoint == "" {
+			return nil, nil, &FineTuningError{StatusCode: http.StatusBadRequest, Message: "the azure key has no endpoint"}
+		}
+		apiVersion := defaultAzureFineTuningAPIVersion
+		if key.AzureKeyConfig.APIVersion != nil && *key.AzureKeyConfig.APIVersion != "" {
+			apiVersion = *key.AzureKeyConfig.APIVersion
+		}
+		rawURL = strings.TrimRight(key.AzureKeyConfig.Endpoint, "/") + "/openai/fine_tuning/jobs?api-version=" + url.QueryEscape(apiVersion)
+		headers["api-key"] = key.Value
+	default:
+		baseURL := "https://api.openai.com"
+		if config.NetworkConfig != nil && config.NetworkConfig.BaseURL != "" {
+			baseURL = strings.TrimRight(config.NetworkConfig.BaseURL, "/")
+		}
+		rawURL = baseURL + "/v1/fine_tuning/jobs"
+		headers["Authorization"] = "Bearer " + key.Value
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid fine-tuning endpoint: %w", err)
+	}
+	return parsed, headers, nil
+}
+
+// httpClient returns a client honoring the timeout and proxy of a provider, or else the egress proxy
+func (f *FineTuner) httpClient(config *configstore.ProviderConfig) (*http.Client, error) {
+	timeout := time.Duration(schemas.DefaultRequestTimeoutInSeconds) * time.Second
+	if config.NetworkConfig != nil && config.NetworkConfig.DefaultRequestTimeoutInSeconds > 0 {
+		timeout = time.Duration(config.NetworkConfig.DefaultRequestTimeoutInSeconds) * time.Second
+	}
+	proxy := config.ProxyConfig
+	if proxy == nil && f.gateway.Egress != nil {
+		proxy = f.gateway.Egress.Proxy
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if proxy != nil {
+		switch proxy.Type {
+		case schemas.HTTPProxy:
+			proxyURL, err := url.Parse(proxy.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proxy url: %w", err)
+			}
+			if proxy.Username != "" {
+				proxyURL.User = url.UserPassword(proxy.Username, proxy.Password)
+			}
+			transport.Proxy = http.ProxyURL(proxyURL)
+		case schemas.EnvProxy:
+			transport.Proxy = http.ProxyFromEnvironment
+		case schemas.Socks5Proxy:
+			return nil, &FineTuningError{StatusCode: http.StatusBadRequest, Message: "fine-tuning requests do not support socks5 proxies"}
+		}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// IsFineTuningError reports whether err is a request rejected by the gateway, and returns it.
+func IsFineTuningError(err error) (*FineTuningError, bool) {
+	var fineTuningErr *FineTuningError
+	ok := errors.As(err, &fineTuningErr)
+	return fineTuningErr, ok
+}
+
+// initFineTuning sets up the fine-tuning proxy. Jobs are tracked in the logs database, in the config store
+// database when logs are kept elsewhere, or in memory. Running jobs are refreshed once the server runs.
+func (s *Config) initFineTuning(ctx context.Context, config *FineTuningConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	var db *gorm.DB
+	if logsDB, ok := s.LogsStore.(interface{ DB() *gorm.DB }); ok {
+		db = logsDB.DB()
+	} else if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("fine-tuning jobs are tracked in memory since no logs or config store database is configured")
+	}
+	store, err := finetuning.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize fine-tuning jobs store: %w", err)
+	}
+	s.FineTuning = NewFineTuner(*config, store, s)
+	return nil
+}
@@ -0,0 +1,392 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/ingestion"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultIngestionWorkers = 2
+	ingestionBatchSize      = 32 // Chunks embedded per embedding request
+)
+
+// IngestionRequest is a document to split into chunks, embed and store in a retrieval store.
+type IngestionRequest struct {
+	ID             string                    `json:"id,omitempty"` // Generated when empty
+	Store          string                    `json:"store"`
+	Name           string                    `json:"name"`
+	Content        string                    `json:"content"`
+	Metadata       map[string]interface{}    `json:"metadata,omitempty"`        // Stored with every chunk, so retrievals can filter on it
+	Chunking       *ingestion.ChunkingConfig `json:"chunking,omitempty"`        // Default: the chunking of the retrieval config
+	EmbeddingModel string                    `json:"embedding_model,omitempty"` // Default: the embedding model of the store
+}
+
+// ingestionJob is a document being indexed by a worker
+type ingestionJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Ingester indexes documents into the retrieval stores in the background. Their chunks are embedded in batches
+// through the gateway, and documents left unfinished by a restart are indexed again from the start.
+type Ingester struct {
+	retriever *Retriever
+	store     ingestion.Store
+	workers   int
+
+	mu      sync.Mutex
+	pending []string                 // IDs of the queued documents, oldest first
+	running map[string]*ingestionJob // Jobs by document ID
+	wake    chan struct{}
+}
+
+// NewIngester creates an ingester indexing the documents of store into the stores of retriever.
+func NewIngester(retriever *Retriever, store ingestion.Store, workers int) *Ingester {
+	if workers <= 0 {
+		workers = DefaultIngestionWorkers
+	}
+	return &Ingester{
+		retriever: retriever,
+		store:     store,
+		workers:   workers,
+		running:   make(map[string]*ingestionJob),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Ingest validates a document, stores it and queues it for indexing.
+func (g *Ingester) Ingest(ctx context.Context, req IngestionRequest) (*ingestion.Document, error) {
+	config, ok := g.retriever.storeConfig(req.Store)
+	if !ok {
+		return nil, &RetrievalError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("unknown retrieval store %q", req.Store)}
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, &RetrievalError{StatusCode: http.StatusBadRequest, Message: "content is required"}
+	}
+	model := req.EmbeddingModel
+	if model == "" {
+		model = config.EmbeddingModel
+	}
+	if provider, modelName := schemas.ParseModelString(model, ""); provider == "" || modelName == "" {
+		return nil, &RetrievalError{StatusCode: http.StatusBadRequest, Message: "embedding_model must be provider/model"}
+	}
+	chunking, err := g.chunking(req.Chunking)
+	if err != nil {
+		return nil, err
+	}
+	if req.ID == "" {
+		req.ID = uuid.NewString()
+	} else if _, err := g.store.Get(ctx, req.ID); err == nil {
+		return nil, &RetrievalError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("document %s already exists", req.ID)}
+	} else if !errors.Is(err, ingestion.ErrNotFound) {
+		return nil, err
+	}
+	name := req.Name
+	if name == "" {
+		name = req.ID
+	}
+
+	document := &ingestion.Document{
+		ID:             req.ID,
+		Store:          req.Store,
+		Name:           name,
+		Content:        req.Content,
+		Metadata:       req.Metadata,
+		Chunking:       chunking,
+		EmbeddingModel: model,
+		Status:         ingestion.StatusQueued,
+	}
+	if err := g.store.Save(ctx, document); err != nil {
+		return nil, err
+	}
+	g.enqueue(document.ID)
+	return document, nil
+}
+
+// chunking returns the chunking of a document, with defaults from the retrieval config
+func (g *Ingester) chunking(chunking *ingestion.ChunkingConfig) (ingestion.ChunkingConfig, error) {
+	var config ingestion.ChunkingConfig
+	if chunking != nil {
+		config = *chunking
+	}
+	config = config.WithDefaults(g.retriever.config.Chunking)
+	if err := config.Validate(); err != nil {
+		return config, &RetrievalError{StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+	return config, nil
+}
+
+// Get returns a document, or ingestion.ErrNotFound.
+func (g *Ingester) Get(ctx context.Context, id string) (*ingestion.Document, error) {
+	return g.store.Get(ctx, id)
+}
+
+// List returns the documents of a retrieval store, or of all stores when store is empty, newest first.
+func (g *Ingester) List(ctx context.Context, store string) ([]ingestion.Document, error) {
+	return g.store.List(ctx, store)
+}
+
+// Reindex indexes a document again, optionally with a new chunking or embedding model. A running indexing of
+// the document is cancelled first; its chunks beyond the new chunk count are removed once indexing completes.
+func (g *Ingester) Reindex(ctx context.Context, id string, chunking *ingestion.ChunkingConfig, model string) (*ingestion.Document, error) {
+	if provider, modelName := schemas.ParseModelString(model, ""); model != "" && (provider == "" || modelName == "") {
+		return nil, &RetrievalError{StatusCode: http.StatusBadRequest, Message: "embedding_model must be provider/model"}
+	}
+	g.stop(id)
+	document, err := g.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if chunking != nil {
+		if document.Chunking, err = g.chunking(chunking); err != nil {
+			return nil, err
+		}
+	}
+	if model != "" {
+		document.EmbeddingModel = model
+	}
+	document.Status = ingestion.StatusQueued
+	document.ChunkCount = 0
+	document.EmbeddedChunks = 0
+	document.Error = ""
+	document.CompletedAt = nil
+	if err := g.store.Save(ctx, document); err != nil {
+		return nil, err
+	}
+	g.enqueue(document.ID)
+	return document, nil
+}
+
+// Delete cancels the indexing of a document, removes its chunks from the vector store and deletes it.
+func (g *Ingester) Delete(ctx context.Context, id string) error {
+	g.stop(id)
+	document, err := g.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := g.deleteChunks(ctx, document, 0); err != nil {
+		return err
+	}
+	return g.store.Delete(ctx, id)
+}
+
+// deleteChunks removes the chunks of a document from index on, and forgets them. The chunks of documents whose
+// store is no longer configured cannot be reached, and are left alone.
+func (g *Ingester) deleteChunks(ctx context.Context, document *ingestion.Document, from int) error {
+	config, _ := g.retriever.storeConfig(document.Store)
+	store := g.retriever.stores[document.Store]
+	if store == nil {
+		return nil
+	}
+	for i := document.StoredChunks - 1; i >= from; i-- {
+		if err := store.Delete(ctx, config.Namespace, ingestion.ChunkID(document.ID, i)); err != nil {
+			return &RetrievalError{StatusCode: http.StatusBadGateway, Message: fmt.Sprintf("failed to delete chunks of document %s: %v", document.ID, err)}
+		}
+		document.StoredChunks = i
+	}
+	return nil
+}
+
+// enqueue queues a document for a worker
+func (g *Ingester) enqueue(id string) {
+	g.mu.Lock()
+	g.pending = append(g.pending, id)
+	g.mu.Unlock()
+	select {
+	case g.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop unqueues a document and cancels its indexing, waiting for the worker to let go of it
+func (g *Ingester) stop(id string) {
+	g.mu.Lock()
+	g.pending = slices.DeleteFunc(g.pending, func(pending string) bool { return pending == id })
+	job := g.running[id]
+	g.mu.Unlock()
+	if job != nil {
+		job.cancel()
+		<-job.done
+	}
+}
+
+// Start queues the documents left unfinished by a previous run and indexes queued documents until ctx is done.
+func (g *Ingester) Start(ctx context.Context) {
+	documents, err := g.store.List(ctx, "")
+	if err != nil {
+		logger.Warn("failed to list retrieval documents: %v", err)
+	}
+	for i := len(documents) - 1; i >= 0; i-- {
+		if documents[i].IsFinished() {
+			continue
+		}
+		if documents[i].Status != ingestion.StatusQueued {
+			document, err := g.store.Get(ctx, documents[i].ID)
+			if err != nil {
+				continue
+			}
+			document.Status = ingestion.StatusQueued
+			if err := g.store.Save(ctx, document); err != nil {
+				logger.Warn("failed to requeue retrieval document %s: %v", document.ID, err)
+				continue
+			}
+		}
+		g.enqueue(documents[i].ID)
+	}
+	for range g.workers {
+		go g.work(ctx)
+	}
+}
+
+// work indexes queued documents one at a time until ctx is done
+func (g *Ingester) work(ctx context.Context) {
+	for {
+		id, job, jobCtx := g.claim(ctx)
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-g.wake:
+			}
+			continue
+		}
+		g.index(jobCtx, id)
+		job.cancel()
+		g.mu.Lock()
+		delete(g.running, id)
+		g.mu.Unlock()
+		close(job.done)
+	}
+}
+
+// claim takes the oldest queued document not being indexed yet, waking another worker when more are queued
+func (g *Ingester) claim(ctx context.Context) (string, *ingestionJob, context.Context) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for len(g.pending) > 0 {
+		id := g.pending[0]
+		g.pending = g.pending[1:]
+		if _, ok := g.running[id]; ok {
+			continue
+		}
+		jobCtx, cancel := context.WithCancel(ctx)
+		job := &ingestionJob{cancel: cancel, done: make(chan struct{})}
+		g.running[id] = job
+		if len(g.pending) > 0 {
+			select {
+			case g.wake <- struct{}{}:
+			default:
+			}
+		}
+		return id, job, jobCtx
+	}
+	return "", nil, nil
+}
+
+// index splits a queued document into chunks and stores their embeddings batch by batch, recording progress
+// after each batch. Cancelled indexing leaves the document as it was for whoever cancelled it.
+func (g *Ingester) index(ctx context.Context, id string) {
+	document, err := g.store.Get(ctx, id)
+	if err != nil {
+		if !errors.Is(err, ingestion.ErrNotFound) && ctx.Err() == nil {
+			logger.Warn("failed to load retrieval document %s: %v", id, err)
+		}
+		return
+	}
+	if document.Status != ingestion.StatusQueued {
+		return
+	}
+	if err := g.indexChunks(ctx, document); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("failed to index retrieval document %s: %v", id, err)
+		document.Status = ingestion.StatusFailed
+		document.Error = err.Error()
+	} else {
+		now := time.Now()
+		document.Status = ingestion.StatusCompleted
+		document.CompletedAt = &now
+	}
+	if err := g.store.Save(ctx, document); err != nil && ctx.Err() == nil {
+		logger.Warn("failed to save retrieval document %s: %v", id, err)
+	}
+}
+
+// indexChunks stores the chunks of a document, then removes the ones left over from a previous indexing
+func (g *Ingester) indexChunks(ctx context.Context, document *ingestion.Document) error {
+	config, ok := g.retriever.storeConfig(document.Store)
+	store := g.retriever.stores[document.Store]
+	if !ok || store == nil {
+		return fmt.Errorf("unknown retrieval store %q", document.Store)
+	}
+	chunks := ingestion.Split(document.Content, document.Chunking)
+	document.Status = ingestion.StatusProcessing
+	document.ChunkCount = len(chunks)
+	document.EmbeddedChunks = 0
+	if err := g.store.Save(ctx, document); err != nil {
+		return err
+	}
+
+	textField, sourceField := config.fields()
+	for start := 0; start < len(chunks); start += ingestionBatchSize {
+		end := min(start+ingestionBatchSize, len(chunks))
+		embeddings, err := g.retriever.embed(ctx, document.EmbeddingModel, chunks[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		// Recording the chunk IDs ahead of storing them, so a crash leaves none that a delete would miss
+		if end > document.StoredChunks {
+			document.StoredChunks = end
+			if err := g.store.Save(ctx, document); err != nil {
+				return err
+			}
+		}
+		for i, embedding := range embeddings {
+			metadata := make(map[string]interface{}, len(document.Metadata)+4)
+			for key, value := range document.Metadata {
+				metadata[key] = value
+			}
+			metadata[textField] = chunks[start+i]
+			metadata[sourceField] = document.Name
+			metadata["document_id"] = document.ID
+			metadata["chunk_index"] = start + i
+			if err := store.Add(ctx, config.Namespace, ingestion.ChunkID(document.ID, start+i), embedding, metadata); err != nil {
+				return fmt.Errorf("failed to store chunk %d: %w", start+i, err)
+			}
+		}
+		document.EmbeddedChunks = end
+		if err := g.store.Save(ctx, document); err != nil {
+			return err
+		}
+	}
+	return g.deleteChunks(ctx, document, len(chunks))
+}
+
+// initIngestion creates the ingester of the retrieval stores, keeping documents in the config store when there
+// is one
+func (s *Config) initIngestion(ctx context.Context, config *RetrievalConfig) error {
+	var db *gorm.DB
+	if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("retrieval documents are kept in memory since no config store is configured")
+	}
+	store, err := ingestion.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize retrieval documents store: %w", err)
+	}
+	s.Ingestion = NewIngester(s.Retrieval, store, config.IngestionWorkers)
+	return nil
+}
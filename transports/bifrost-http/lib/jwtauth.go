@@ -0,0 +1,494 @@
+package lib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/cluster"
+)
+
+// Defaults of JWT validation
+const (
+	DefaultJWKSRefreshInterval = time.Hour
+	DefaultJWTClockSkew        = time.Minute
+	jwksMinRefreshInterval     = 30 * time.Second // Unknown key IDs refetch the JWKS at most this often
+	jwksFetchTimeout           = 10 * time.Second
+)
+
+// Errors of JWT validation, reported to callers as 401 responses
+var (
+	ErrJWTMissing       = errors.New("bearer token required")
+	ErrJWTMalformed     = errors.New("malformed JWT")
+	ErrJWTUnknownIssuer = errors.New("JWT issuer is not trusted")
+	ErrJWTUnknownKey    = errors.New("JWT signing key not found in the JWKS of the issuer")
+	ErrJWTSignature     = errors.New("invalid JWT signature")
+	ErrJWTExpired       = errors.New("JWT is expired or not yet valid")
+	ErrJWTAudience      = errors.New("JWT audience does not match")
+	ErrJWTScope         = errors.New("JWT lacks a required scope")
+	ErrJWTNoID          = errors.New("single-use JWT must carry a jti claim")
+	ErrJWTReplayed      = errors.New("single-use JWT was already used")
+)
+
+// JWTAuthConfig validates caller JWTs against the JWKS of trusted issuers, so organizations can reuse their
+// identity tokens instead of distributing Bifrost keys. Claims resolve the governance identity of requests.
+type JWTAuthConfig struct {
+	Enabled  bool        `json:"enabled"`
+	Required bool        `json:"required,omitempty"` // Reject inference requests without a valid JWT; otherwise bearer values that are not JWTs pass through
+	Issuers  []JWTIssuer `json:"issuers"`
+}
+
+// JWTIssuer is a trusted token issuer and how its claims map to governance.
+type JWTIssuer struct {
+	Issuer                     string            `json:"issuer"`                                  // Expected iss claim
+	JWKSURL                    string            `json:"jwks_url"`                                // JWKS endpoint of the issuer
+	Audience                   string            `json:"audience,omitempty"`                      // Expected aud claim, unchecked when empty
+	RequiredScopes             []string          `json:"required_scopes,omitempty"`               // Scopes every token must grant
+	SubjectClaim               string            `json:"subject_claim,omitempty"`                 // Claim of the user ID, x-bf-user (default sub)
+	OrgClaim                   string            `json:"org_claim,omitempty"`                     // Claim of the customer ID, x-bf-customer (default org)
+	ScopesClaim                string            `json:"scopes_claim,omitempty"`                  // Space separated string or array of scopes (default scope)
+	Mappings                   []JWTClaimMapping `json:"mappings,omitempty"`                      // Virtual key and team of tokens, first match wins
+	JWKSRefreshIntervalSeconds int               `json:"jwks_refresh_interval_seconds,omitempty"` // How often keys are refetched (default 3600)
+	ClockSkewSeconds           int               `json:"clock_skew_seconds,omitempty"`            // Tolerance of exp and nbf (default 60)
+	SingleUse                  bool              `json:"single_use,omitempty"`                    // Accept each token once, by its jti claim, until it expires
+}
+
+// JWTClaimMapping resolves the virtual key, and with it the rate limits and budgets, of matching tokens.
+type JWTClaimMapping struct {
+	Org        string `json:"org,omitempty"`   // Org claim value, empty matches any
+	Scope      string `json:"scope,omitempty"` // Scope the token must grant, empty matches any
+	VirtualKey string `json:"virtual_key"`     // Virtual key applied to the requests, replacing x-bf-vk
+	Team       string `json:"team,omitempty"`  // Team ID, replacing x-bf-team
+}
+
+// JWTIdentity is the governance identity resolved from a validated token.
+type JWTIdentity struct {
+	Subject    string
+	Org        string
+	Scopes     []string
+	VirtualKey string
+	Team       string
+}
+
+// Validate checks that issuers are complete and unique.
+func (c *JWTAuthConfig) Validate() error {
+	if c.Enabled && len(c.Issuers) == 0 {
+		return fmt.Errorf("jwt_auth: at least one issuer is required")
+	}
+	seen := make(map[string]bool, len(c.Issuers))
+	for i, issuer := range c.Issuers {
+		switch {
+		case issuer.Issuer == "":
+			return fmt.Errorf("jwt_auth: issuers[%d]: issuer is required", i)
+		case !strings.HasPrefix(issuer.JWKSURL, "https://") && !strings.HasPrefix(issuer.JWKSURL, "http://"):
+			return fmt.Errorf("jwt_auth: issuers[%d]: jwks_url must be an http(s) URL", i)
+		case issuer.JWKSRefreshIntervalSeconds < 0 || issuer.ClockSkewSeconds < 0:
+			return fmt.Errorf("jwt_auth: issuers[%d]: intervals must not be negative", i)
+		case seen[issuer.Issuer]:
+			return fmt.Errorf("jwt_auth: duplicate issuer %q", issuer.Issuer)
+		}
+		seen[issuer.Issuer] = true
+		for j, mapping := range issuer.Mappings {
+			if mapping.VirtualKey == "" && mapping.Team == "" {
+				return fmt.Errorf("jwt_auth: issuers[%d].mappings[%d]: virtual_key or team is required", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// JWTValidator validates tokens of the configured issuers, caching their JWKS.
+type JWTValidator struct {
+	required bool
+	issuers  map[string]*jwtIssuerKeys
+	client   *http.Client
+	nonces   *NonceStore // IDs of the single-use tokens seen
+}
+
+// jwtIssuerKeys is an issuer and its cached signing keys.
+type jwtIssuerKeys struct {
+	JWTIssuer
+	refreshInterval time.Duration
+	clockSkew       time.Duration
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey // Key ID -> key
+	fetchedAt time.Time
+}
+
+// NewJWTValidator creates a validator for the issuers of config. Keys are fetched on first use.
+func NewJWTValidator(config JWTAuthConfig) *JWTValidator {
+	v := &JWTValidator{
+		required: config.Required,
+		issuers:  make(map[string]*jwtIssuerKeys, len(config.Issuers)),
+		client:   &http.Client{Timeout: jwksFetchTimeout},
+		nonces:   NewNonceStore(),
+	}
+	for _, issuer := range config.Issuers {
+		keys := &jwtIssuerKeys{JWTIssuer: issuer, refreshInterval: DefaultJWKSRefreshInterval, clockSkew: DefaultJWTClockSkew}
+		if issuer.JWKSRefreshIntervalSeconds > 0 {
+			keys.refreshInterval = time.Duration(issuer.JWKSRefreshIntervalSeconds) * time.Second
+		}
+		if issuer.ClockSkewSeconds > 0 {
+			keys.clockSkew = time.Duration(issuer.ClockSkewSeconds) * time.Second
+		}
+		v.issuers[issuer.Issuer] = keys
+	}
+	registerReplayMetrics()
+	return v
+}
+
+// SetSharedNonces records the IDs of the single-use tokens seen in the cluster state shared by the replicas, so a
+// token accepted by one replica is rejected by the others. It must be called before the validator is used.
+func (v *JWTValidator) SetSharedNonces(shared cluster.NonceStore) {
+	v.nonces.shared = shared
+}
+
+// Required reports whether inference requests without a valid JWT are rejected.
+func (v *JWTValidator) Required() bool {
+	return v.required
+}
+
+// IsJWT reports whether a bearer value has the shape of a JWT, telling tokens apart from provider keys.
+func IsJWT(token string) bool {
+	header, _, ok := strings.Cut(token, ".")
+	if !ok || strings.Count(token, ".") != 2 {
+		return false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(header)
+	return err == nil && json.Valid(data) && strings.Contains(string(data), `"alg"`)
+}
+
+// Validate verifies a token and resolves its governance identity.
+func (v *JWTValidator) Validate(token string, now time.Time) (*JWTIdentity, error) {
+	if token == "" {
+		return nil, ErrJWTMissing
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	claims := make(map[string]any)
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, ErrJWTMalformed
+	}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, ErrJWTMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	issuerName, _ := claims["iss"].(string)
+	issuer, ok := v.issuers[issuerName]
+	if !ok {
+		return nil, ErrJWTUnknownIssuer
+	}
+	key, err := issuer.key(v.client, header.Kid, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	exp, hasExp := claims["exp"].(float64)
+	if !hasExp || now.After(time.Unix(int64(exp), 0).Add(issuer.clockSkew)) {
+		return nil, ErrJWTExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(issuer.clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, ErrJWTExpired
+	}
+	if issuer.Audience != "" && !slices.Contains(claimStrings(claims["aud"]), issuer.Audience) {
+		return nil, ErrJWTAudience
+	}
+
+	identity := &JWTIdentity{
+		Subject: claimString(claims, issuer.SubjectClaim, "sub"),
+		Org:     claimString(claims, issuer.OrgClaim, "org"),
+	}
+	scopesClaim := issuer.ScopesClaim
+	if scopesClaim == "" {
+		scopesClaim = "scope"
+	}
+	if scopes, ok := claims[scopesClaim].(string); ok {
+		identity.Scopes = strings.Fields(scopes)
+	} else {
+		identity.Scopes = claimStrings(claims[scopesClaim])
+	}
+	for _, scope := range issuer.RequiredScopes {
+		if !slices.Contains(identity.Scopes, scope) {
+			return nil, ErrJWTScope
+		}
+	}
+	if issuer.SingleUse {
+		// Checked last, so tokens rejected for another reason are not spent
+		id, _ := claims["jti"].(string)
+		if id == "" {
+			return nil, ErrJWTNoID
+		}
+		if !v.nonces.Remember(issuer.Issuer+":"+id, time.Unix(int64(exp), 0).Add(issuer.clockSkew), now) {
+			ReplayAttempts.WithLabelValues(ReplayMethodJWT).Inc()
+			return nil, ErrJWTReplayed
+		}
+	}
+	for _, mapping := range issuer.Mappings {
+		if (mapping.Org == "" || mapping.Org == identity.Org) && (mapping.Scope == "" || slices.Contains(identity.Scopes, mapping.Scope)) {
+			identity.VirtualKey = mapping.VirtualKey
+			identity.Team = mapping.Team
+			break
+		}
+	}
+	return identity, nil
+}
+
+// key returns the signing key with the given ID, refetching the JWKS when it is stale or misses the key.
+func (k *jwtIssuerKeys) key(client *http.Client, kid string, now time.Time) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key, found := k.lookup(kid)
+	age := now.Sub(k.fetchedAt)
+	if found && age <= k.refreshInterval {
+		return key, nil
+	}
+	if !found && age < jwksMinRefreshInterval {
+		return nil, ErrJWTUnknownKey
+	}
+
+	keys, err := fetchJWKS(client, k.JWKSURL)
+	if err != nil {
+		if found {
+			// Keep serving the cached key while the endpoint is unavailable
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrJWTUnknownKey, err)
+	}
+	k.keys = keys
+	k.fetchedAt = now
+	if key, found = k.lookup(kid); !found {
+		return nil, ErrJWTUnknownKey
+	}
+	return key, nil
+}
+
+// lookup finds a cached key by ID. Tokens without a key ID match a JWKS holding a single key.
+func (k *jwtIssuerKeys) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// jsonWebKey is a key of a JWKS document.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchJWKS downloads and parses the signing keys of a JWKS endpoint. Keys of unsupported types are skipped.
+func fetchJWKS(client *http.Client, url string) (map[string]crypto.PublicKey, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA, EC or Ed25519 key.
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	decode := func(value string) (*big.Int, error) {
+		data, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil || len(data) == 0 {
+			return nil, fmt.Errorf("invalid key parameter")
+		}
+		return new(big.Int).SetBytes(data), nil
+	}
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decode(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[jwk.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := decode(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if jwk.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+}
+
+// verifyJWTSignature checks the signature of signed with an asymmetric algorithm. HMAC and none are rejected,
+// as are keys of another type than the algorithm.
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	hashes := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}
+	if alg == "EdDSA" {
+		if key, ok := key.(ed25519.PublicKey); ok && ed25519.Verify(key, signed, signature) {
+			return nil
+		}
+		return ErrJWTSignature
+	}
+	if len(alg) != 5 {
+		return ErrJWTSignature
+	}
+	hash, ok := hashes[alg[2:]]
+	if !ok {
+		return ErrJWTSignature
+	}
+	var digest []byte
+	switch hash {
+	case crypto.SHA256:
+		sum := sha256.Sum256(signed)
+		digest = sum[:]
+	case crypto.SHA384:
+		sum := sha512.Sum384(signed)
+		digest = sum[:]
+	default:
+		sum := sha512.Sum512(signed)
+		digest = sum[:]
+	}
+
+	switch alg[:2] {
+	case "RS":
+		if key, ok := key.(*rsa.PublicKey); ok && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil {
+			return nil
+		}
+	case "PS":
+		if key, ok := key.(*rsa.PublicKey); ok && rsa.VerifyPSS(key, hash, digest, signature, nil) == nil {
+			return nil
+		}
+	case "ES":
+		key, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrJWTSignature
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return ErrJWTSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if ecdsa.Verify(key, digest, r, s) {
+			return nil
+		}
+	}
+	return ErrJWTSignature
+}
+
+// decodeJWTSegment decodes a base64url JSON segment of a token.
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// claimString returns a string claim, read from name or fallback when name is empty.
+func claimString(claims map[string]any, name, fallback string) string {
+	if name == "" {
+		name = fallback
+	}
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimStrings returns a claim that is a string or an array of strings as a slice.
+func claimStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// initJWTAuth creates the JWT validator when JWT authentication is enabled.
+func (s *Config) initJWTAuth(config *JWTAuthConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	s.JWTValidator = NewJWTValidator(*config)
+	if slices.ContainsFunc(config.Issuers, func(issuer JWTIssuer) bool { return issuer.SingleUse }) {
+		s.JWTValidator.SetSharedNonces(s.sharedNonces("jwt_auth"))
+	}
+	return nil
+}
@@ -0,0 +1,435 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/cluster"
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+const (
+	DefaultKeyLeakCheckIntervalMinutes = 60
+
+	// KeyLeakAlertEventType is the type of key leak alert payloads.
+	KeyLeakAlertEventType = "virtual_key.leaked"
+	// KeyLeakSignatureHeader carries the signature of leak reports: sha256= followed by the hex HMAC-SHA256 of the
+	// body with the webhook secret.
+	KeyLeakSignatureHeader = "X-Bifrost-Signature"
+
+	keyLeakHistorySize     = 1000
+	keyLeakFeedMaxBytes    = 16 << 20
+	keyLeakDeliveryTimeout = 10 * time.Second
+)
+
+// KeyLeakConfig configures the detection of leaked virtual keys: leak feeds polled in the background and leak
+// reports posted by scanners to /api/governance/key-leaks/report. Leaked keys are suspended and their owners alerted.
+type KeyLeakConfig struct {
+	Enabled              bool                `json:"enabled"`
+	Feeds                []KeyLeakFeed       `json:"feeds,omitempty"`
+	CheckIntervalMinutes int                 `json:"check_interval_minutes,omitempty"` // Default: 60
+	WebhookSecret        string              `json:"webhook_secret,omitempty"`         // Secret signing leak reports, or env.VAR_NAME; reports are refused without it
+	AutoSuspend          *bool               `json:"auto_suspend,omitempty"`           // Deactivate leaked keys (default: true)
+	Alert                *KeyLeakAlertConfig `json:"alert,omitempty"`                  // Where alerts are sent (leaks are only logged and emitted as security events without it)
+}
+
+// KeyLeakFeed is a URL returning a JSON array of leaked secrets, each a key value or the hex SHA-256 of one.
+type KeyLeakFeed struct {
+	Name    string            `json:"name"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // e.g. authorization; values may reference env.VAR_NAME
+}
+
+// KeyLeakAlertConfig is the endpoint receiving key leak alerts.
+type KeyLeakAlertConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // e.g. authorization; values may reference env.VAR_NAME
+}
+
+// KeyLeak is a virtual key found in a leak feed or report.
+type KeyLeak struct {
+	VirtualKeyID   string    `json:"virtual_key_id"`
+	VirtualKeyName string    `json:"virtual_key_name"`
+	Source         string    `json:"source"`             // Feed name, or the source named by the report
+	Location       string    `json:"location,omitempty"` // Where the key was found, e.g. the URL of a public commit
+	Suspended      bool      `json:"suspended"`
+	ProjectID      string    `json:"project_id,omitempty"` // Owner of the key
+	TeamID         string    `json:"team_id,omitempty"`
+	CustomerID     string    `json:"customer_id,omitempty"`
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// KeyLeakAlert is the payload posted to the alert webhook.
+type KeyLeakAlert struct {
+	Type string `json:"type"`
+	KeyLeak
+}
+
+// KeyLeakCheck is the outcome of polling the leak feeds.
+type KeyLeakCheck struct {
+	CheckedAt time.Time         `json:"checked_at"`
+	Leaks     []KeyLeak         `json:"leaks"`            // Keys newly found leaked
+	Errors    map[string]string `json:"errors,omitempty"` // Per feed that could not be read
+}
+
+// Validate checks the feeds and the alert webhook.
+func (c *KeyLeakConfig) Validate() error {
+	if c.CheckIntervalMinutes < 0 {
+		return fmt.Errorf("key_leaks: check_interval_minutes cannot be negative")
+	}
+	names := make(map[string]bool, len(c.Feeds))
+	for _, feed := range c.Feeds {
+		if feed.Name == "" || names[feed.Name] {
+			return fmt.Errorf("key_leaks: feeds need unique names")
+		}
+		names[feed.Name] = true
+		if u, err := url.Parse(feed.URL); err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("key_leaks: invalid url %q of feed %s", feed.URL, feed.Name)
+		}
+	}
+	if c.Alert != nil && c.Alert.URL == "" {
+		return fmt.Errorf("key_leaks: alert url is required")
+	}
+	return nil
+}
+
+// KeyLeakMonitor matches leaked secrets against the virtual keys, suspending the leaked keys and alerting their
+// owners once per key.
+type KeyLeakMonitor struct {
+	config   KeyLeakConfig
+	store    configstore.ConfigStore
+	leader   cluster.LeaderChecker
+	events   *SecurityEventExporter
+	client   *http.Client
+	onUpdate func(*configstore.TableVirtualKey) // Refreshes the governance cache with a suspended key
+
+	mu        sync.Mutex
+	leaks     []KeyLeak       // Oldest first, at most keyLeakHistorySize
+	alerted   map[string]bool // Virtual keys already found leaked
+	lastCheck *KeyLeakCheck
+}
+
+// NewKeyLeakMonitor creates a monitor. leader may be nil outside a cluster, events nil without security events.
+func NewKeyLeakMonitor(config KeyLeakConfig, store configstore.ConfigStore, leader cluster.LeaderChecker, events *SecurityEventExporter) *KeyLeakMonitor {
+	if config.CheckIntervalMinutes == 0 {
+		config.CheckIntervalMinutes = DefaultKeyLeakCheckIntervalMinutes
+	}
+	return &KeyLeakMonitor{
+		config:  config,
+		store:   store,
+		leader:  leader,
+		events:  events,
+		client:  &http.Client{Timeout: keyLeakDeliveryTimeout},
+		alerted: make(map[string]bool),
+	}
+}
+
+// OnUpdate sets the callback receiving virtual keys once suspended, to refresh the governance cache.
+func (m *KeyLeakMonitor) OnUpdate(fn func(*configstore.TableVirtualKey)) {
+	m.onUpdate = fn
+}
+
+// Start polls the feeds every interval until ctx is done, on the leader only in a cluster.
+func (m *KeyLeakMonitor) Start(ctx context.Context) {
+	if len(m.config.Feeds) == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(m.config.CheckIntervalMinutes) * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if m.leader != nil && !m.leader.IsLeader() {
+					continue
+				}
+				check := m.Check(ctx)
+				for feed, err := range check.Errors {
+					logger.Warn("key leaks: failed to read feed %s: %s", feed, err)
+				}
+			}
+		}
+	}()
+}
+
+// Check polls the leak feeds now and handles the virtual keys found in them.
+func (m *KeyLeakMonitor) Check(ctx context.Context) *KeyLeakCheck {
+	check := &KeyLeakCheck{CheckedAt: time.Now().UTC(), Leaks: []KeyLeak{}}
+	for _, feed := range m.config.Feeds {
+		secrets, err := m.fetchFeed(ctx, feed)
+		if err != nil {
+			if check.Errors == nil {
+				check.Errors = make(map[string]string)
+			}
+			check.Errors[feed.Name] = err.Error()
+			continue
+		}
+		leaks, err := m.Report(ctx, feed.Name, "", secrets)
+		if err != nil {
+			if check.Errors == nil {
+				check.Errors = make(map[string]string)
+			}
+			check.Errors[feed.Name] = err.Error()
+			continue
+		}
+		check.Leaks = append(check.Leaks, leaks...)
+	}
+	m.mu.Lock()
+	m.lastCheck = check
+	m.mu.Unlock()
+	return check
+}
+
+// Report handles secrets found leaked by source at location: the virtual keys they match, by value or hex SHA-256,
+// are suspended unless auto_suspend is off, and alerted once. It returns the keys newly found leaked.
+func (m *KeyLeakMonitor) Report(ctx context.Context, source, location string, secrets []string) ([]KeyLeak, error) {
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	leaked := make(map[string]bool, len(secrets))
+	for _, secret := range secrets {
+		leaked[strings.TrimSpace(secret)] = true
+	}
+	virtualKeys, err := m.store.GetVirtualKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load virtual keys: %w", err)
+	}
+	var leaks []KeyLeak
+	for i := range virtualKeys {
+		vk := &virtualKeys[i]
+		if !leaked[vk.Value] && !leaked[KeyFingerprint(vk.Value)] {
+			continue
+		}
+		m.mu.Lock()
+		seen := m.alerted[vk.ID]
+		m.alerted[vk.ID] = true
+		m.mu.Unlock()
+		if seen {
+			continue
+		}
+		leak := KeyLeak{
+			VirtualKeyID:   vk.ID,
+			VirtualKeyName: vk.Name,
+			Source:         source,
+			Location:       location,
+			DetectedAt:     time.Now().UTC(),
+		}
+		if vk.ProjectID != nil {
+			leak.ProjectID = *vk.ProjectID
+		}
+		if vk.TeamID != nil {
+			leak.TeamID = *vk.TeamID
+		}
+		if vk.CustomerID != nil {
+			leak.CustomerID = *vk.CustomerID
+		}
+		if m.config.AutoSuspend == nil || *m.config.AutoSuspend {
+			if err := m.suspend(ctx, vk); err != nil {
+				logger.Error("key leaks: failed to suspend virtual key %s: %v", vk.ID, err)
+			} else {
+				leak.Suspended = true
+			}
+		}
+		m.record(leak)
+		leaks = append(leaks, leak)
+	}
+	return leaks, nil
+}
+
+// Leaks returns the most recent leaks, newest first, and the last feed check.
+func (m *KeyLeakMonitor) Leaks() ([]KeyLeak, *KeyLeakCheck) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	leaks := make([]KeyLeak, 0, len(m.leaks))
+	for i := len(m.leaks) - 1; i >= 0; i-- {
+		leaks = append(leaks, m.leaks[i])
+	}
+	return leaks, m.lastCheck
+}
+
+// VerifySignature checks the signature of a leak report body. Reports are refused without a webhook secret.
+func (m *KeyLeakMonitor) VerifySignature(header string, body []byte) bool {
+	if m.config.WebhookSecret == "" {
+		return false
+	}
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(m.config.WebhookSecret))
+	mac.Write(body)
+	return hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(strings.ToLower(signature)))
+}
+
+// KeyFingerprint returns the hex SHA-256 of a key value, as listed by feeds that do not share key values.
+func KeyFingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// suspend deactivates a leaked virtual key and refreshes the governance cache.
+func (m *KeyLeakMonitor) suspend(ctx context.Context, vk *configstore.TableVirtualKey) error {
+	if !vk.IsActive {
+		return nil
+	}
+	vk.IsActive = false
+	if err := m.store.UpdateVirtualKey(ctx, vk); err != nil {
+		return err
+	}
+	if m.onUpdate != nil {
+		updated, err := m.store.GetVirtualKey(ctx, vk.ID)
+		if err != nil {
+			updated = vk
+		}
+		m.onUpdate(updated)
+	}
+	return nil
+}
+
+// record keeps a leak and notifies about it: in the logs, as a security event and to the alert webhook.
+func (m *KeyLeakMonitor) record(leak KeyLeak) {
+	m.mu.Lock()
+	m.leaks = append(m.leaks, leak)
+	if len(m.leaks) > keyLeakHistorySize {
+		m.leaks = m.leaks[len(m.leaks)-keyLeakHistorySize:]
+	}
+	m.mu.Unlock()
+
+	logger.Warn("key leaks: virtual key %s (%s) found leaked by %s, suspended: %t", leak.VirtualKeyID, leak.VirtualKeyName, leak.Source, leak.Suspended)
+	if m.events != nil {
+		m.events.Emit(SecurityEvent{
+			Category: SecurityEventCategoryAuth,
+			Type:     "key_leaked",
+			Outcome:  "success",
+			Severity: 9,
+			Actor:    "virtual_key:" + leak.VirtualKeyID,
+			Message:  fmt.Sprintf("virtual key %s found leaked by %s", leak.VirtualKeyName, leak.Source),
+			Details:  map[string]string{"source": leak.Source, "location": leak.Location, "suspended": fmt.Sprint(leak.Suspended)},
+		})
+	}
+	if m.config.Alert != nil {
+		go func() {
+			if err := m.post(KeyLeakAlert{Type: KeyLeakAlertEventType, KeyLeak: leak}); err != nil {
+				logger.Warn("failed to deliver leak alert of virtual key %s: %v", leak.VirtualKeyID, err)
+			}
+		}()
+	}
+}
+
+// fetchFeed reads the secrets listed by a feed
+func (m *KeyLeakMonitor) fetchFeed(ctx context.Context, feed KeyLeakFeed) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range feed.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed returned status %d", resp.StatusCode)
+	}
+	var secrets []string
+	if err := json.NewDecoder(io.LimitReader(resp.Body, keyLeakFeedMaxBytes)).Decode(&secrets); err != nil {
+		return nil, fmt.Errorf("invalid feed, expected a JSON array of strings: %w", err)
+	}
+	return secrets, nil
+}
+
+// post sends one alert to the webhook
+func (m *KeyLeakMonitor) post(alert KeyLeakAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, m.config.Alert.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range m.config.Alert.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// initKeyLeaks creates the key leak monitor; its feeds are polled once the server runs.
+func (s *Config) initKeyLeaks(config *KeyLeakConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if s.ConfigStore == nil {
+		return fmt.Errorf("key_leaks: a config store is required")
+	}
+	resolved := *config
+	if resolved.WebhookSecret != "" {
+		secret, _, err := s.processEnvValue(resolved.WebhookSecret)
+		if err != nil {
+			return fmt.Errorf("key_leaks: webhook_secret: %w", err)
+		}
+		resolved.WebhookSecret = secret
+	}
+	resolved.Feeds = make([]KeyLeakFeed, len(config.Feeds))
+	for i, feed := range config.Feeds {
+		headers, err := s.resolveKeyLeakHeaders(feed.Headers)
+		if err != nil {
+			return fmt.Errorf("key_leaks: feed %s: %w", feed.Name, err)
+		}
+		feed.Headers = headers
+		resolved.Feeds[i] = feed
+	}
+	if config.Alert != nil {
+		headers, err := s.resolveKeyLeakHeaders(config.Alert.Headers)
+		if err != nil {
+			return fmt.Errorf("key_leaks: alert: %w", err)
+		}
+		resolved.Alert = &KeyLeakAlertConfig{URL: config.Alert.URL, Headers: headers}
+	}
+	var leader cluster.LeaderChecker
+	if s.LeaderElector != nil {
+		leader = s.LeaderElector
+	}
+	s.KeyLeaks = NewKeyLeakMonitor(resolved, s.ConfigStore, leader, s.SecurityEvents)
+	return nil
+}
+
+// resolveKeyLeakHeaders replaces the env.VAR_NAME references of header values
+func (s *Config) resolveKeyLeakHeaders(headers map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(headers))
+	for name, value := range headers {
+		resolvedValue, _, err := s.processEnvValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		resolved[name] = resolvedValue
+	}
+	return resolved, nil
+}
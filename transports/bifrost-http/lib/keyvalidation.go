@@ -0,0 +1,170 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// KeyValidationsConfigKey is the config store key of the provider key validation results.
+const KeyValidationsConfigKey = "key_validations"
+
+// DefaultKeyValidationTimeout bounds the validation call of a key.
+const DefaultKeyValidationTimeout = 15 * time.Second
+
+// KeyValidationStatus is the outcome of a key validation.
+type KeyValidationStatus string
+
+const (
+	KeyValidationValid   KeyValidationStatus = "valid"
+	KeyValidationInvalid KeyValidationStatus = "invalid"
+	KeyValidationUnknown KeyValidationStatus = "unknown" // No model to validate the key with
+)
+
+// DefaultKeyValidationModels are the models validated keys are called with when they do not list any.
+var DefaultKeyValidationModels = map[schemas.ModelProvider]string{
+	schemas.OpenAI:     "gpt-4o-mini",
+	schemas.Anthropic:  "claude-3-5-haiku-latest",
+	schemas.Cohere:     "command-r",
+	schemas.Mistral:    "mistral-small-latest",
+	schemas.Groq:       "llama-3.1-8b-instant",
+	schemas.Cerebras:   "llama3.1-8b",
+	schemas.Gemini:     "gemini-1.5-flash",
+	schemas.OpenRouter: "openai/gpt-4o-mini",
+}
+
+// KeyValidation is the result of the live validation of a provider key.
+type KeyValidation struct {
+	Status      KeyValidationStatus `json:"status"`
+	Model       string              `json:"model,omitempty"` // Model the key was called with
+	Error       string              `json:"error,omitempty"`
+	ValidatedAt time.Time           `json:"validated_at"`
+}
+
+// keyValidations holds the latest validation result of each key, by key ID.
+type keyValidations struct {
+	mu      sync.RWMutex
+	results map[string]KeyValidation
+}
+
+// GetKeyValidations returns the validation results of the given keys, by key ID. Keys never validated are left out.
+func (s *Config) GetKeyValidations(keys []schemas.Key) map[string]KeyValidation {
+	s.keyValidations.mu.RLock()
+	defer s.keyValidations.mu.RUnlock()
+	var results map[string]KeyValidation
+	for _, key := range keys {
+		if result, ok := s.keyValidations.results[key.ID]; ok {
+			if results == nil {
+				results = make(map[string]KeyValidation)
+			}
+			results[key.ID] = result
+		}
+	}
+	return results
+}
+
+// UpdateKeyValidations records validation results and forgets those of the keys in cleared, which were removed
+// or changed since they were validated. The results are persisted in the config store when one is configured.
+func (s *Config) UpdateKeyValidations(ctx context.Context, results map[string]KeyValidation, cleared []string) error {
+	s.keyValidations.mu.Lock()
+	defer s.keyValidations.mu.Unlock()
+
+	updated := make(map[string]KeyValidation, len(s.keyValidations.results)+len(results))
+	for id, result := range s.keyValidations.results {
+		updated[id] = result
+	}
+	for _, id := range cleared {
+		delete(updated, id)
+	}
+	for id, result := range results {
+		updated[id] = result
+	}
+	if err := s.saveStoredConfig(ctx, KeyValidationsConfigKey, updated); err != nil {
+		return fmt.Errorf("failed to save key validations: %w", err)
+	}
+	s.keyValidations.results = updated
+	return nil
+}
+
+// loadKeyValidations restores the key validation results saved in the config store.
+func (s *Config) loadKeyValidations(ctx context.Context) error {
+	var results map[string]KeyValidation
+	if _, err := s.loadStoredConfig(ctx, KeyValidationsConfigKey, &results); err != nil {
+		return fmt.Errorf("failed to load key validations: %w", err)
+	}
+	s.keyValidations.mu.Lock()
+	s.keyValidations.results = results
+	s.keyValidations.mu.Unlock()
+	return nil
+}
+
+// KeyValidationModel returns the model a key is validated with: the first model it lists, the first model of its
+// deployments, or the default model of its provider ("" when none is known).
+func KeyValidationModel(provider schemas.ModelProvider, baseProvider schemas.ModelProvider, key schemas.Key) string {
+	if len(key.Models) > 0 {
+		return key.Models[0]
+	}
+	var deployments map[string]string
+	if key.AzureKeyConfig != nil {
+		deployments = key.AzureKeyConfig.Deployments
+	} else if key.BedrockKeyConfig != nil {
+		deployments = key.BedrockKeyConfig.Deployments
+	}
+	if len(deployments) > 0 {
+		models := make([]string, 0, len(deployments))
+		for model := range deployments {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		return models[0]
+	}
+	if model, ok := DefaultKeyValidationModels[provider]; ok {
+		return model
+	}
+	return DefaultKeyValidationModels[baseProvider]
+}
+
+// ValidateKey validates a provider key with a 1 token chat completion sent through the client with the key, whose
+// values must be resolved. Validation requests are marked with ProviderHealthProbeContextKey, so they are kept out
+// of provider health and races like health probes.
+func ValidateKey(ctx context.Context, client *bifrost.Bifrost, provider schemas.ModelProvider, model string, key schemas.Key) KeyValidation {
+	result := KeyValidation{Model: model, ValidatedAt: time.Now().UTC()}
+	if model == "" {
+		result.Status = KeyValidationUnknown
+		result.Error = "no model to validate the key with, list one on the key"
+		return result
+	}
+
+	validationCtx, cancel := context.WithTimeout(ctx, DefaultKeyValidationTimeout)
+	defer cancel()
+	validationCtx = context.WithValue(validationCtx, ProviderHealthProbeContextKey, true)
+	validationCtx = context.WithValue(validationCtx, schemas.BifrostContextKeyDirectKey, key)
+
+	_, bifrostErr := client.ChatCompletionRequest(validationCtx, &schemas.BifrostChatRequest{
+		Provider: provider,
+		Model:    model,
+		Input: []schemas.ChatMessage{{
+			Role:    schemas.ChatMessageRoleUser,
+			Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("ping")},
+		}},
+		Params: &schemas.ChatParameters{MaxCompletionTokens: bifrost.Ptr(1)},
+	})
+	if bifrostErr == nil {
+		result.Status = KeyValidationValid
+		return result
+	}
+	result.Status = KeyValidationInvalid
+	result.Error = "validation call failed"
+	if bifrostErr.Error != nil && bifrostErr.Error.Message != "" {
+		result.Error = bifrostErr.Error.Message
+	}
+	if bifrostErr.StatusCode != nil {
+		result.Error = fmt.Sprintf("%d: %s", *bifrostErr.StatusCode, result.Error)
+	}
+	return result
+}
@@ -0,0 +1,262 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultKubernetesLabelSelector   = "bifrost.dev/config=true"
+	DefaultKubernetesDataKey         = "bifrost.json"
+	DefaultKubernetesIntervalSeconds = 30
+
+	// KubernetesEventComponent is the component reported as the source of the events Bifrost emits
+	KubernetesEventComponent = "bifrost"
+
+	// Files of the service account mounted into pods
+	kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	kubernetesTokenFile         = kubernetesServiceAccountDir + "/token"
+	kubernetesCAFile            = kubernetesServiceAccountDir + "/ca.crt"
+	kubernetesNamespaceFile     = kubernetesServiceAccountDir + "/namespace"
+)
+
+// Types of Kubernetes events
+const (
+	KubernetesEventNormal  = "Normal"
+	KubernetesEventWarning = "Warning"
+)
+
+// KubernetesSyncConfig represents the configuration of the Kubernetes controller mode. The ConfigMaps and Secrets
+// matching the label selector hold config documents (providers, keys, budgets and policies) under the data key;
+// the controller reconciles the running configuration to the union of the documents, and reports documents it
+// cannot apply as Kubernetes events on them. Keys are best kept in Secrets.
+type KubernetesSyncConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Namespace       string `json:"namespace,omitempty"`        // Namespace watched (default: namespace of the pod)
+	LabelSelector   string `json:"label_selector,omitempty"`   // Selects the ConfigMaps and Secrets holding config documents (default: bifrost.dev/config=true)
+	DataKey         string `json:"data_key,omitempty"`         // Data entry holding the document (default: bifrost.json)
+	IntervalSeconds int    `json:"interval_seconds,omitempty"` // Seconds between reconciliations (default: 30)
+	Prune           bool   `json:"prune,omitempty"`            // Remove providers missing from every document
+	APIServer       string `json:"api_server,omitempty"`       // API server URL (default: in-cluster, from KUBERNETES_SERVICE_HOST)
+}
+
+// Validate checks the interval and the API server URL.
+func (c *KubernetesSyncConfig) Validate() error {
+	if c.IntervalSeconds < 0 {
+		return fmt.Errorf("kubernetes: interval_seconds must not be negative")
+	}
+	if c.APIServer != "" {
+		if parsed, err := url.Parse(c.APIServer); err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+			return fmt.Errorf("kubernetes: api_server must be an http(s) URL")
+		}
+	}
+	return nil
+}
+
+// KubernetesObject is a ConfigMap or Secret, with the data of Secrets decoded.
+type KubernetesObject struct {
+	Kind            string            `json:"kind"`
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace"`
+	UID             string            `json:"uid"`
+	ResourceVersion string            `json:"resource_version"`
+	Data            map[string]string `json:"-"`
+}
+
+// KubernetesClient is a minimal client of the Kubernetes API, listing ConfigMaps and Secrets and creating events
+// with the token of the service account of the pod.
+type KubernetesClient struct {
+	baseURL   string
+	namespace string
+	tokenFile string // Re-read on every request, since projected tokens rotate
+	client    *http.Client
+}
+
+// NewKubernetesClient creates a client of the API server of config, in-cluster by default, applying defaults to
+// unset config values.
+func NewKubernetesClient(config *KubernetesSyncConfig) (*KubernetesClient, error) {
+	if config.Namespace == "" {
+		namespace, err := os.ReadFile(kubernetesNamespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: namespace is not set and the pod namespace cannot be read: %w", err)
+		}
+		config.Namespace = strings.TrimSpace(string(namespace))
+	}
+	if config.LabelSelector == "" {
+		config.LabelSelector = DefaultKubernetesLabelSelector
+	}
+	if config.DataKey == "" {
+		config.DataKey = DefaultKubernetesDataKey
+	}
+	if config.IntervalSeconds <= 0 {
+		config.IntervalSeconds = DefaultKubernetesIntervalSeconds
+	}
+	baseURL := config.APIServer
+	if baseURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, fmt.Errorf("kubernetes: api_server is not set and bifrost is not running in a cluster")
+		}
+		baseURL = "https://" + net.JoinHostPort(host, port)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ca, err := os.ReadFile(kubernetesCAFile); err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("kubernetes: invalid service account CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &KubernetesClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		namespace: config.Namespace,
+		tokenFile: kubernetesTokenFile,
+		client:    &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}, nil
+}
+
+// kubernetesObjectList is a list of ConfigMaps or Secrets returned by the API server
+type kubernetesObjectList struct {
+	Items []struct {
+		Metadata struct {
+			Name            string `json:"name"`
+			Namespace       string `json:"namespace"`
+			UID             string `json:"uid"`
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+		Data json.RawMessage `json:"data"`
+	} `json:"items"`
+}
+
+// ListConfigMaps lists the ConfigMaps of the namespace matching selector.
+func (c *KubernetesClient) ListConfigMaps(ctx context.Context, selector string) ([]KubernetesObject, error) {
+	return c.listObjects(ctx, "configmaps", "ConfigMap", selector)
+}
+
+// ListSecrets lists the Secrets of the namespace matching selector, with their data decoded.
+func (c *KubernetesClient) ListSecrets(ctx context.Context, selector string) ([]KubernetesObject, error) {
+	return c.listObjects(ctx, "secrets", "Secret", selector)
+}
+
+// listObjects lists the objects of resource matching selector. Secret data is base64, which encoding/json decodes
+// into byte slices.
+func (c *KubernetesClient) listObjects(ctx context.Context, resource, kind, selector string) ([]KubernetesObject, error) {
+	var list kubernetesObjectList
+	if err := c.do(ctx, http.MethodGet, c.namespacedPath(resource)+"?labelSelector="+url.QueryEscape(selector), nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", resource, err)
+	}
+	objects := make([]KubernetesObject, 0, len(list.Items))
+	for _, item := range list.Items {
+		object := KubernetesObject{
+			Kind:            kind,
+			Name:            item.Metadata.Name,
+			Namespace:       item.Metadata.Namespace,
+			UID:             item.Metadata.UID,
+			ResourceVersion: item.Metadata.ResourceVersion,
+			Data:            map[string]string{},
+		}
+		if len(item.Data) > 0 {
+			if kind == "Secret" {
+				var data map[string][]byte
+				if err := json.Unmarshal(item.Data, &data); err != nil {
+					return nil, fmt.Errorf("failed to decode secret %s: %w", object.Name, err)
+				}
+				for key, value := range data {
+					object.Data[key] = string(value)
+				}
+			} else if err := json.Unmarshal(item.Data, &object.Data); err != nil {
+				return nil, fmt.Errorf("failed to decode configmap %s: %w", object.Name, err)
+			}
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// CreateEvent records an event of eventType (KubernetesEventNormal or KubernetesEventWarning) on object.
+func (c *KubernetesClient) CreateEvent(ctx context.Context, object KubernetesObject, eventType, reason, message string) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	host, _ := os.Hostname()
+	event := map[string]any{
+		"metadata": map[string]any{
+			"generateName": strings.ToLower(object.Name) + ".",
+			"namespace":    object.Namespace,
+		},
+		"involvedObject": map[string]any{
+			"apiVersion":      "v1",
+			"kind":            object.Kind,
+			"name":            object.Name,
+			"namespace":       object.Namespace,
+			"uid":             object.UID,
+			"resourceVersion": object.ResourceVersion,
+		},
+		"type":               eventType,
+		"reason":             reason,
+		"message":            message,
+		"source":             map[string]any{"component": KubernetesEventComponent, "host": host},
+		"reportingComponent": KubernetesEventComponent,
+		"reportingInstance":  host,
+		"firstTimestamp":     now,
+		"lastTimestamp":      now,
+		"count":              1,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if err := c.do(ctx, http.MethodPost, c.namespacedPath("events"), body, nil); err != nil {
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+	return nil
+}
+
+// namespacedPath returns the API path of a core resource in the namespace of the client
+func (c *KubernetesClient) namespacedPath(resource string) string {
+	return "/api/v1/namespaces/" + url.PathEscape(c.namespace) + "/" + resource
+}
+
+// do sends a request to the API server and decodes its response into out, unless out is nil
+func (c *KubernetesClient) do(ctx context.Context, method, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if token, err := os.ReadFile(c.tokenFile); err == nil {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &status) == nil && status.Message != "" {
+			return fmt.Errorf("kubernetes api returned status %d: %s", resp.StatusCode, status.Message)
+		}
+		return fmt.Errorf("kubernetes api returned status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,144 @@
+package lib
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// LabelsHeader carries labels attributing a request, e.g. to a feature or environment, as comma-separated
+// name=value pairs: "feature=search,env=staging".
+const LabelsHeader = "x-bifrost-labels"
+
+// LabelMetricValuesContextKey holds the values of the labels of a request reported to Prometheus, by label name
+// (map[string]string)
+const LabelMetricValuesContextKey ContextKey = "bifrost-label-metric-values"
+
+// Limits of the labels of a request.
+const (
+	MaxRequestLabels    = 16  // Labels per request, as many as the OpenAI metadata object holds
+	MaxLabelNameLength  = 64  // Characters of a label name
+	MaxLabelValueLength = 128 // Characters of a label value
+)
+
+// MaxLabelMetricValues is the number of distinct values of a label reported to Prometheus, the values seen after
+// them are reported as LabelMetricOtherValue.
+const MaxLabelMetricValues = 50
+
+// LabelMetricOtherValue is the value reported to Prometheus for the values of a label past MaxLabelMetricValues.
+const LabelMetricOtherValue = "other"
+
+// labelNamePattern matches valid label names.
+var labelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// validateLabel checks the name and value of a label.
+func validateLabel(name, value string) error {
+	if len(name) > MaxLabelNameLength || !labelNamePattern.MatchString(name) {
+		return fmt.Errorf("label name %q must be at most %d letters, digits, '_', '.' or '-'", name, MaxLabelNameLength)
+	}
+	if value == "" || len(value) > MaxLabelValueLength {
+		return fmt.Errorf("label %s must have a value of 1 to %d characters", name, MaxLabelValueLength)
+	}
+	if strings.ContainsFunc(value, unicode.IsControl) {
+		return fmt.Errorf("label %s must not contain control characters", name)
+	}
+	return nil
+}
+
+// ParseLabelsHeader parses the name=value pairs of the X-Bifrost-Labels header.
+func ParseLabelsHeader(header string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("label %q must be a name=value pair", pair)
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if err := validateLabel(name, value); err != nil {
+			return nil, err
+		}
+		if _, ok := labels[name]; ok {
+			return nil, fmt.Errorf("label %s is set twice", name)
+		}
+		labels[name] = value
+	}
+	if len(labels) > MaxRequestLabels {
+		return nil, fmt.Errorf("at most %d labels can be set, got %d", MaxRequestLabels, len(labels))
+	}
+	return labels, nil
+}
+
+// RequestLabels returns the labels of a request: the ones of its X-Bifrost-Labels header, and the string entries
+// of the metadata object of its body that are valid labels. The header wins over metadata entries of the same
+// name, and metadata entries are taken in name order up to MaxRequestLabels. Metadata is left in the body, since
+// providers accept it too.
+func RequestLabels(header string, body map[string]any) (map[string]string, error) {
+	labels, err := ParseLabelsHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	metadata, _ := body["metadata"].(map[string]any)
+	names := make([]string, 0, len(metadata))
+	for name := range metadata {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if len(labels) >= MaxRequestLabels {
+			break
+		}
+		value, ok := metadata[name].(string)
+		if _, set := labels[name]; !ok || set || validateLabel(name, value) != nil {
+			continue
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
+
+// labelCardinality bounds the distinct values of each label reported to Prometheus.
+type labelCardinality struct {
+	mu     sync.Mutex
+	values map[string]map[string]struct{}
+}
+
+// MetricLabelValues returns the values of the labels reported to Prometheus, the custom Prometheus labels
+// configured, by name. Values past the first MaxLabelMetricValues seen for a label are reported as
+// LabelMetricOtherValue, bounding the cardinality of the metrics.
+func (s *Config) MetricLabelValues(labels map[string]string) map[string]string {
+	s.labelCardinality.mu.Lock()
+	defer s.labelCardinality.mu.Unlock()
+	var values map[string]string
+	for _, name := range s.ClientConfig.PrometheusLabels {
+		value, ok := labels[name]
+		if !ok {
+			continue
+		}
+		if s.labelCardinality.values == nil {
+			s.labelCardinality.values = make(map[string]map[string]struct{})
+		}
+		seen, ok := s.labelCardinality.values[name]
+		if !ok {
+			seen = make(map[string]struct{})
+			s.labelCardinality.values[name] = seen
+		}
+		if _, ok := seen[value]; !ok {
+			if len(seen) >= MaxLabelMetricValues {
+				value = LabelMetricOtherValue
+			} else {
+				seen[value] = struct{}{}
+			}
+		}
+		if values == nil {
+			values = make(map[string]string)
+		}
+		values[name] = value
+	}
+	return values
+}
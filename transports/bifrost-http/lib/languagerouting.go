@@ -0,0 +1,200 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maximhq/bifrost/framework/langdetect"
+)
+
+// LanguageRoutingConfigKey is the config store key holding the language routing configuration.
+const LanguageRoutingConfigKey = "language_routing"
+
+// Language routing headers. Clients may send the language of a request with LanguageHeader to skip detection;
+// responses report the language of the request and the route it took.
+const (
+	LanguageHeader      = "x-bf-language"       // Language or locale of the request, e.g. "ja" or "ja-JP"
+	LanguageRouteHeader = "x-bf-language-route" // Language route applied to the request
+)
+
+// DefaultLanguageMinConfidence is the confidence below which detected languages are ignored, when none is configured.
+const DefaultLanguageMinConfidence = 0.5
+
+// LanguageRoutingConfig configures the detection of the language of inference requests and the routes sending
+// requests in a language to a model suited to it. Detected languages are recorded with the request logs.
+type LanguageRoutingConfig struct {
+	Enabled       bool            `json:"enabled"`
+	MinConfidence float64         `json:"min_confidence,omitempty"` // Detections below are ignored (default: 0.5)
+	Routes        []LanguageRoute `json:"routes,omitempty"`
+}
+
+// LanguageRoute replaces the model of requests in one of its languages. Routes are evaluated in order and the
+// first matching route is applied.
+type LanguageRoute struct {
+	Name      string              `json:"name"`
+	Disabled  bool                `json:"disabled,omitempty"`
+	Languages []string            `json:"languages"`       // ISO 639-1 codes, e.g. "ja"
+	Match     TransformationMatch `json:"match,omitempty"` // Requests the route applies to, by path, virtual key and requested model
+	Model     string              `json:"model"`           // Model replacing the requested one, e.g. "openai/gpt-4o"
+}
+
+// Validate checks that the minimum confidence is a probability and that the routes are named uniquely and well formed.
+func (c *LanguageRoutingConfig) Validate() error {
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be between 0 and 1")
+	}
+	names := make(map[string]struct{}, len(c.Routes))
+	for i, route := range c.Routes {
+		if route.Name == "" {
+			return fmt.Errorf("route %d: name is required", i)
+		}
+		if _, ok := names[route.Name]; ok {
+			return fmt.Errorf("route %s: duplicate name", route.Name)
+		}
+		names[route.Name] = struct{}{}
+		if len(route.Languages) == 0 {
+			return fmt.Errorf("route %s: at least one language is required", route.Name)
+		}
+		for _, language := range route.Languages {
+			if langdetect.Normalize(language) != language {
+				return fmt.Errorf("route %s: language %q is not a lowercase ISO 639-1 code", route.Name, language)
+			}
+		}
+		if provider, model, ok := strings.Cut(route.Model, "/"); !ok || provider == "" || model == "" {
+			return fmt.Errorf("route %s: model must be in provider/model format", route.Name)
+		}
+	}
+	return nil
+}
+
+// Detect returns the language of the prompt of a JSON request body, or an empty string when the body has no
+// text or its language is not detected with the minimum confidence.
+func (c *LanguageRoutingConfig) Detect(body map[string]any) string {
+	text := promptText(body)
+	if text == "" {
+		return ""
+	}
+	minConfidence := c.MinConfidence
+	if minConfidence == 0 {
+		minConfidence = DefaultLanguageMinConfidence
+	}
+	result := langdetect.Detect(text)
+	if result.Confidence < minConfidence {
+		return ""
+	}
+	return result.Language
+}
+
+// Route returns the first enabled route of language matching the request, or nil.
+func (c *LanguageRoutingConfig) Route(language string, path string, virtualKey string, model string) *LanguageRoute {
+	provider, modelName := "", model
+	if before, after, found := strings.Cut(model, "/"); found {
+		provider, modelName = before, after
+	}
+	for i := range c.Routes {
+		route := &c.Routes[i]
+		if route.Disabled || !matchesAny(route.Languages, language) {
+			continue
+		}
+		if !matchesAny(route.Match.Paths, path) || !matchesAny(route.Match.VirtualKeys, virtualKey) ||
+			!matchesAny(route.Match.Providers, provider) || !matchesAny(route.Match.Models, modelName) {
+			continue
+		}
+		return route
+	}
+	return nil
+}
+
+// promptText returns the text the language of a JSON request body is detected from: the latest user message
+// of chat and Anthropic messages bodies, else the prompt of text completions or the input of responses and
+// embedding requests.
+func promptText(body map[string]any) string {
+	messages, _ := body["messages"].([]any)
+	if input, ok := body["input"].([]any); ok && messages == nil {
+		messages = input
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		message, ok := messages[i].(map[string]any)
+		if !ok || message["role"] != "user" {
+			continue
+		}
+		return contentText(message["content"])
+	}
+	for _, field := range []string{"prompt", "input"} {
+		switch value := body[field].(type) {
+		case string:
+			return value
+		case []any:
+			if text, ok := firstString(value); ok {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// contentText joins the text of a message content, either a string or a list of content parts or blocks.
+func contentText(content any) string {
+	switch content := content.(type) {
+	case string:
+		return content
+	case []any:
+		var texts []string
+		for _, part := range content {
+			if part, ok := part.(map[string]any); ok {
+				if text, ok := part["text"].(string); ok && text != "" {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
+
+// firstString returns the first string of a list of prompts or inputs.
+func firstString(values []any) (string, bool) {
+	for _, value := range values {
+		if text, ok := value.(string); ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// GetLanguageRouting returns the active language routing configuration, or nil when none is configured.
+func (s *Config) GetLanguageRouting() *LanguageRoutingConfig {
+	return s.languageRouting.Load()
+}
+
+// UpdateLanguageRouting validates and activates a language routing configuration, persisting it in the config
+// store when one is configured.
+func (s *Config) UpdateLanguageRouting(ctx context.Context, config LanguageRoutingConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, LanguageRoutingConfigKey, config); err != nil {
+		return fmt.Errorf("failed to save language routing: %w", err)
+	}
+	s.languageRouting.Store(&config)
+	return nil
+}
+
+// loadLanguageRouting activates the language routing saved in the config store. Without a saved configuration,
+// the one from the config file is used and saved to bootstrap the store.
+func (s *Config) loadLanguageRouting(ctx context.Context, fileConfig *LanguageRoutingConfig) error {
+	var config LanguageRoutingConfig
+	found, err := s.loadStoredConfig(ctx, LanguageRoutingConfigKey, &config)
+	if err != nil {
+		return fmt.Errorf("failed to load language routing: %w", err)
+	}
+	if found {
+		s.languageRouting.Store(&config)
+		return nil
+	}
+	if fileConfig == nil {
+		return nil
+	}
+	return s.UpdateLanguageRouting(ctx, *fileConfig)
+}
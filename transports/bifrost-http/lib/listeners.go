@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// Route groups a listener can expose
+const (
+	ExposeInference  = "inference"  // Native and SDK integration inference routes
+	ExposeManagement = "management" // Management API (/api/...) and websockets
+	ExposeUI         = "ui"         // Dashboard
+	ExposeMetrics    = "metrics"    // Prometheus scraping endpoint (/metrics)
+	ExposeHealth     = "health"     // Health check (/health)
+)
+
+// ListenerMiddlewares are the names of the HTTP middlewares a listener can leave out of its chain, in the order
+// requests go through them.
+var ListenerMiddlewares = []string{
+	"security_headers", "cors", "client_certificate", "request_signing", "jwt_auth", "tenant", "admin_auth",
+	"read_only", "labels", "language_routing", "experiment", "routing_override", "policy", "transformation", "async",
+}
+
+// ListenerConfig represents an additional listener. When listeners are configured, Bifrost binds them instead of
+// the host and port flags, and each one only serves the route groups it exposes, with its own middleware chain, so
+// that e.g. the management API and the UI are bound to localhost while inference is public.
+type ListenerConfig struct {
+	Name            string   `json:"name"`
+	Address         string   `json:"address"`                    // host:port to bind, e.g. 127.0.0.1:9090 or :8080
+	Expose          []string `json:"expose"`                     // Route groups served: inference, management, ui, metrics, health
+	TLS             bool     `json:"tls,omitempty"`              // Serve over TLS with the settings of the tls section
+	SkipMiddlewares []string `json:"skip_middlewares,omitempty"` // Middlewares left out of the chain of this listener, e.g. admin_auth on a loopback listener
+}
+
+// ValidateListeners checks the listeners: unique names and addresses, known route groups and middlewares, and a
+// tls section for the listeners serving TLS.
+func ValidateListeners(listeners []ListenerConfig, hasTLS bool) error {
+	names := make(map[string]bool, len(listeners))
+	addresses := make(map[string]bool, len(listeners))
+	for i, listener := range listeners {
+		if listener.Name == "" {
+			return fmt.Errorf("listeners[%d]: name is required", i)
+		}
+		if names[listener.Name] {
+			return fmt.Errorf("listeners: duplicate name %s", listener.Name)
+		}
+		names[listener.Name] = true
+		if _, port, err := net.SplitHostPort(listener.Address); err != nil || port == "" {
+			return fmt.Errorf("listener %s: invalid address %q, expected host:port", listener.Name, listener.Address)
+		}
+		if addresses[listener.Address] {
+			return fmt.Errorf("listener %s: address %s is already bound by another listener", listener.Name, listener.Address)
+		}
+		addresses[listener.Address] = true
+		if len(listener.Expose) == 0 {
+			return fmt.Errorf("listener %s: expose at least one of inference, management, ui, metrics or health", listener.Name)
+		}
+		for _, group := range listener.Expose {
+			switch group {
+			case ExposeInference, ExposeManagement, ExposeUI, ExposeMetrics, ExposeHealth:
+			default:
+				return fmt.Errorf("listener %s: invalid route group %q, expected inference, management, ui, metrics or health", listener.Name, group)
+			}
+		}
+		for _, middleware := range listener.SkipMiddlewares {
+			if !slices.Contains(ListenerMiddlewares, middleware) {
+				return fmt.Errorf("listener %s: unknown middleware %q, expected one of %s", listener.Name, middleware, strings.Join(ListenerMiddlewares, ", "))
+			}
+		}
+		if listener.TLS && !hasTLS {
+			return fmt.Errorf("listener %s: tls requires the tls section", listener.Name)
+		}
+	}
+	return nil
+}
+
+// RouteGroup returns the route group of a request path, for listeners to serve only what they expose.
+func RouteGroup(path string) string {
+	switch {
+	case path == "/metrics":
+		return ExposeMetrics
+	case path == "/health":
+		return ExposeHealth
+	case strings.HasPrefix(path, "/api/") || path == "/api" || strings.HasPrefix(path, "/ws"):
+		return ExposeManagement
+	}
+	for _, prefix := range []string{"/v1/", "/openai/", "/anthropic/", "/genai/", "/litellm/", "/langchain/"} {
+		if strings.HasPrefix(path, prefix) {
+			return ExposeInference
+		}
+	}
+	return ExposeUI
+}
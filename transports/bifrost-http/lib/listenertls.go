@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Client certificate modes of the listener
+const (
+	ClientAuthNone      = "none"      // Client certificates are not requested
+	ClientAuthOptional  = "optional"  // Certificates are verified when presented
+	ClientAuthInference = "inference" // Inference routes require a verified certificate, other routes accept one
+	ClientAuthRequire   = "require"   // Every connection must present a verified certificate
+)
+
+// ClientIdentityHeader carries the identity of the verified client certificate of a request.
+// Values sent by clients are always discarded.
+const ClientIdentityHeader = "x-bf-client-identity"
+
+// ListenerTLSConfig serves Bifrost over TLS and authenticates clients with certificates, for
+// zero-trust environments that forbid bearer tokens.
+type ListenerTLSConfig struct {
+	CertFile   string           `json:"cert_file"`             // PEM certificate chain of the server
+	KeyFile    string           `json:"key_file"`              // PEM private key of the server
+	ClientCA   string           `json:"client_ca,omitempty"`   // PEM certificates, or the path of a PEM file, client certificates must chain to
+	ClientAuth string           `json:"client_auth,omitempty"` // none (default), optional, inference or require
+	Identities []ClientIdentity `json:"identities,omitempty"`  // Governance identities of client certificates, first match wins
+}
+
+// ClientIdentity maps a client certificate to the virtual key and tenant its requests are governed by.
+type ClientIdentity struct {
+	Subject    string `json:"subject"`               // URI, DNS or email SAN, or common name of the certificate
+	VirtualKey string `json:"virtual_key,omitempty"` // Virtual key applied to the requests, replacing x-bf-vk
+	Team       string `json:"team,omitempty"`        // Team ID, replacing x-bf-team
+	Customer   string `json:"customer,omitempty"`    // Customer ID, replacing x-bf-customer
+	User       string `json:"user,omitempty"`        // User ID, replacing x-bf-user
+}
+
+// Validate checks the mode and that the certificates can be loaded.
+func (c *ListenerTLSConfig) Validate() error {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return fmt.Errorf("tls: cert_file and key_file are required")
+	}
+	switch c.ClientAuth {
+	case "", ClientAuthNone:
+	case ClientAuthOptional, ClientAuthInference, ClientAuthRequire:
+		if c.ClientCA == "" {
+			return fmt.Errorf("tls: client_ca is required when client_auth is %s", c.ClientAuth)
+		}
+	default:
+		return fmt.Errorf("tls: invalid client_auth %q, expected none, optional, inference or require", c.ClientAuth)
+	}
+	for i, identity := range c.Identities {
+		if identity.Subject == "" {
+			return fmt.Errorf("tls: identities[%d]: subject is required", i)
+		}
+	}
+	_, err := c.ServerTLSConfig()
+	return err
+}
+
+// ServerTLSConfig builds the TLS settings of the listener.
+func (c *ListenerTLSConfig) ServerTLSConfig() (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: failed to load the server certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientAuth == "" || c.ClientAuth == ClientAuthNone {
+		return config, nil
+	}
+
+	pem := []byte(c.ClientCA)
+	if !strings.Contains(c.ClientCA, "-----BEGIN") {
+		if pem, err = os.ReadFile(c.ClientCA); err != nil {
+			return nil, fmt.Errorf("tls: failed to read client_ca: %w", err)
+		}
+	}
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("tls: client_ca contains no PEM certificates")
+	}
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	if c.ClientAuth == ClientAuthRequire {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// Identify returns the identity of a verified client certificate. Certificates matching no configured
+// identity are identified by their first subject, without governance mappings.
+func (c *ListenerTLSConfig) Identify(certificate *x509.Certificate) ClientIdentity {
+	subjects := CertificateSubjects(certificate)
+	for _, identity := range c.Identities {
+		for _, subject := range subjects {
+			if identity.Subject == subject {
+				return identity
+			}
+		}
+	}
+	if len(subjects) == 0 {
+		return ClientIdentity{}
+	}
+	return ClientIdentity{Subject: subjects[0]}
+}
+
+// CertificateSubjects returns the names a certificate identifies: its URI (e.g. SPIFFE IDs), DNS and
+// email SANs, then its common name.
+func CertificateSubjects(certificate *x509.Certificate) []string {
+	var subjects []string
+	for _, uri := range certificate.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	subjects = append(subjects, certificate.DNSNames...)
+	subjects = append(subjects, certificate.EmailAddresses...)
+	if certificate.Subject.CommonName != "" {
+		subjects = append(subjects, certificate.Subject.CommonName)
+	}
+	return subjects
+}
@@ -0,0 +1,64 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/logstore"
+)
+
+// RunMigrations brings the config and logs store schemas up to date and exits without starting the server.
+// Store selection follows LoadConfig: config.json when present, otherwise BIFROST_POSTGRES_DSN or the SQLite defaults.
+func RunMigrations(ctx context.Context, configDirPath string) error {
+	configFilePath := filepath.Join(configDirPath, "config.json")
+	configStoreConfig := defaultConfigStoreConfig(filepath.Join(configDirPath, "config.db"))
+	var logsStoreConfig *logstore.Config
+
+	data, err := os.ReadFile(configFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	fromFile := err == nil
+	if fromFile {
+		var configData ConfigData
+		if err := json.Unmarshal(data, &configData); err != nil {
+			return fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+		configStoreConfig = configData.ConfigStoreConfig
+		logsStoreConfig = configData.LogsStoreConfig
+	}
+
+	// Opening a store runs its migrations
+	if configStoreConfig != nil && configStoreConfig.Enabled {
+		logger.Info("migrating config store (%s)", configStoreConfig.Type)
+		store, err := configstore.NewConfigStore(ctx, configStoreConfig, logger)
+		if err != nil {
+			return fmt.Errorf("failed to migrate config store: %w", err)
+		}
+		defer store.Close(ctx)
+		// Without a config file the logs store config lives in the config store
+		if !fromFile {
+			logsStoreConfig, err = store.GetLogsStoreConfig(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get logs store config: %w", err)
+			}
+		}
+	}
+	if logsStoreConfig == nil && !fromFile {
+		logsStoreConfig = defaultLogsStoreConfig(filepath.Join(configDirPath, "logs.db"))
+	}
+	if logsStoreConfig != nil && logsStoreConfig.Enabled {
+		logger.Info("migrating logs store (%s)", logsStoreConfig.Type)
+		store, err := logstore.NewLogStore(ctx, logsStoreConfig, logger)
+		if err != nil {
+			return fmt.Errorf("failed to migrate logs store: %w", err)
+		}
+		defer store.Close(ctx)
+	}
+	logger.Info("migrations completed")
+	return nil
+}
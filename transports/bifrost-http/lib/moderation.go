@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maximhq/bifrost/framework/moderation"
+	"gorm.io/gorm"
+)
+
+// ModerationReviewIDContextKey holds the approved moderation event releasing a resubmitted held request,
+// taken from the x-bf-moderation-review-id header
+const ModerationReviewIDContextKey ContextKey = "x-bf-moderation-review-id"
+
+// initModeration sets up the moderator, the store of moderation events and their review queue. API keys may reference
+// environment variables with the env. prefix. Events are stored in the config store database, or in
+// memory when there is no config store.
+func (s *Config) initModeration(ctx context.Context, config *moderation.Config) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	var err error
+	if config.OpenAI != nil {
+		if config.OpenAI.APIKey, _, err = s.processEnvValue(config.OpenAI.APIKey); err != nil {
+			return fmt.Errorf("failed to resolve moderation openai.api_key: %w", err)
+		}
+	}
+	if config.Azure != nil {
+		if config.Azure.APIKey, _, err = s.processEnvValue(config.Azure.APIKey); err != nil {
+			return fmt.Errorf("failed to resolve moderation azure.api_key: %w", err)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	moderator, err := moderation.New(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize moderation: %w", err)
+	}
+
+	var db *gorm.DB
+	if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("moderation events are kept in memory since no config store is configured")
+	}
+	store, err := moderation.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize moderation events store: %w", err)
+	}
+	s.Moderator = moderator
+	s.ModerationEvents = store
+	s.ModerationReviews = moderation.NewReviews(store)
+	return nil
+}
@@ -0,0 +1,33 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RequestOwnerContextKey holds the owner of a request (see RequestOwner), so plugins can scope the objects they
+// keep, such as sessions, to the caller that created them.
+const RequestOwnerContextKey ContextKey = "bifrost-request-owner"
+
+// RequestOwner identifies the caller owning the objects it creates, such as async jobs, assistants and sessions:
+// the hash of its virtual key, or of its authorization header without one. It is empty for anonymous callers.
+func RequestOwner(ctx *fasthttp.RequestCtx) string {
+	credential := ctx.Request.Header.Peek("x-bf-vk")
+	if len(credential) == 0 {
+		credential = ctx.Request.Header.Peek(fasthttp.HeaderAuthorization)
+	}
+	if len(credential) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(credential)
+	return hex.EncodeToString(sum[:])
+}
+
+// OwnedBy reports whether requester, a RequestOwner, may see an object of owner. Objects of anonymous callers are
+// seen by anyone knowing their ID.
+func OwnedBy(owner, requester string) bool {
+	return owner == "" || subtle.ConstantTimeCompare([]byte(owner), []byte(requester)) == 1
+}
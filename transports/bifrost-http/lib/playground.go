@@ -0,0 +1,55 @@
+package lib
+
+import "fmt"
+
+const (
+	DefaultPlaygroundMaxCost   = 0.05 // USD per playground request
+	DefaultPlaygroundMaxTokens = 1024 // Completion tokens per playground request
+)
+
+// PlaygroundConfig configures the request playground of the dashboard, which sends test prompts through the full
+// pipeline. A request is refused when its worst-case cost, the estimated prompt with the maximum completion,
+// exceeds the cap.
+type PlaygroundConfig struct {
+	Enabled             *bool   `json:"enabled,omitempty"`               // Default: true
+	MaxCostPerRequest   float64 `json:"max_cost_per_request,omitempty"`  // USD, default DefaultPlaygroundMaxCost
+	MaxTokens           int     `json:"max_tokens,omitempty"`            // Completion token cap, default DefaultPlaygroundMaxTokens
+	AllowUnpricedModels bool    `json:"allow_unpriced_models,omitempty"` // Send requests to models without pricing, whose cost cannot be capped
+}
+
+// Validate checks the caps.
+func (c *PlaygroundConfig) Validate() error {
+	if c.MaxCostPerRequest < 0 {
+		return fmt.Errorf("playground: max_cost_per_request must not be negative")
+	}
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("playground: max_tokens must not be negative")
+	}
+	return nil
+}
+
+// IsEnabled reports whether the playground is on. A nil config enables it with the defaults.
+func (c *PlaygroundConfig) IsEnabled() bool {
+	return c == nil || c.Enabled == nil || *c.Enabled
+}
+
+// MaxCost returns the cost cap of a request.
+func (c *PlaygroundConfig) MaxCost() float64 {
+	if c == nil || c.MaxCostPerRequest == 0 {
+		return DefaultPlaygroundMaxCost
+	}
+	return c.MaxCostPerRequest
+}
+
+// TokenLimit returns the completion token cap of a request.
+func (c *PlaygroundConfig) TokenLimit() int {
+	if c == nil || c.MaxTokens == 0 {
+		return DefaultPlaygroundMaxTokens
+	}
+	return c.MaxTokens
+}
+
+// AllowsUnpricedModels reports whether requests are sent to models without pricing.
+func (c *PlaygroundConfig) AllowsUnpricedModels() bool {
+	return c != nil && c.AllowUnpricedModels
+}
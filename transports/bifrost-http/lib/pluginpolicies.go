@@ -0,0 +1,22 @@
+package lib
+
+import (
+	"fmt"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// PluginPoliciesConfig sets the priority and failure policy of plugins by name, overriding the ones they declare.
+// Plugins of higher priority run their PreHooks first; plugins failing closed fail requests when their hooks error,
+// where plugins failing open, the default, are skipped.
+type PluginPoliciesConfig map[string]schemas.PluginPolicy
+
+// Validate checks the failure policies of a plugin policies config.
+func (c PluginPoliciesConfig) Validate() error {
+	for name, policy := range c {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("plugin_policies of %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+)
+
+// PoliciesConfigKey is the config store key holding the versions of the policies, the active one last.
+const PoliciesConfigKey = "policies"
+
+// MaxPolicyVersions is the number of policy versions kept, the oldest are dropped first.
+const MaxPolicyVersions = 20
+
+// PolicyAction is what a policy does with the requests its expression matches.
+type PolicyAction string
+
+const (
+	PolicyActionAllow PolicyAction = "allow" // Let the request through unchanged, skipping the remaining policies
+	PolicyActionDeny  PolicyAction = "deny"  // Reject the request with 403
+	PolicyActionRoute PolicyAction = "route" // Send the request to the provider/model of the target
+)
+
+// PoliciesConfig holds routing and governance policies written as CEL expressions. They are evaluated in order
+// against inference requests, and the first one whose expression is true decides what happens to the request.
+//
+// Expressions read the request through these variables:
+//   - request: the JSON body of the request, e.g. has(request.tools) or request.temperature > 1.0
+//   - path: the request path, e.g. "/v1/chat/completions"
+//   - virtual_key: the virtual key sent in the x-bf-vk header
+//   - key: the attributes of the virtual key from key_attributes, e.g. key.tier == "free"
+//   - headers: the request headers, with lower-case names
+//   - labels: the labels of the request, from the X-Bifrost-Labels header and metadata, e.g. labels.env == "prod"
+//   - provider, model: the provider and model of the request, e.g. "openai" and "gpt-4o" for "openai/gpt-4o"
+//   - now: the time of the request, e.g. now.getHours("UTC") >= 18
+//
+// Expressions failing to evaluate, e.g. reading a missing map key, do not match.
+type PoliciesConfig struct {
+	Version       int                   `json:"version,omitempty"`    // Set on every update, starting at 1
+	UpdatedAt     time.Time             `json:"updated_at,omitempty"` // Set on every update
+	KeyAttributes []PolicyKeyAttributes `json:"key_attributes,omitempty"`
+	Policies      []Policy              `json:"policies"`
+}
+
+// PolicyKeyAttributes sets attributes of virtual keys for policies to read in key, e.g. their tier. When several
+// entries match a virtual key, the first one setting an attribute wins.
+type PolicyKeyAttributes struct {
+	VirtualKeys []string          `json:"virtual_keys"` // Virtual key values; patterns ending in * match by prefix
+	Attributes  map[string]string `json:"attributes"`
+}
+
+// Policy applies its action to the requests its expression matches.
+type Policy struct {
+	Name       string       `json:"name"`
+	Disabled   bool         `json:"disabled,omitempty"`
+	Expression string       `json:"expression"` // CEL expression evaluating to a bool
+	Action     PolicyAction `json:"action"`
+	Message    string       `json:"message,omitempty"` // Error message of the requests denied
+	Target     string       `json:"target,omitempty"`  // provider/model the requests routed are sent to
+}
+
+// PolicyInput is a request policies are evaluated against.
+type PolicyInput struct {
+	Path       string            `json:"path"`
+	VirtualKey string            `json:"virtual_key,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"` // Names are lower-cased
+	Labels     map[string]string `json:"labels,omitempty"`
+	Body       map[string]any    `json:"body"`
+	Now        time.Time         `json:"now,omitempty"` // Defaults to the current time
+}
+
+// PolicyDecision is the outcome of evaluating policies against a request.
+type PolicyDecision struct {
+	Version int           `json:"version"`           // Version of the policies evaluated
+	Policy  string        `json:"policy,omitempty"`  // Policy that decided, empty when none matched
+	Action  PolicyAction  `json:"action,omitempty"`  // Action of the policy, empty when none matched
+	Message string        `json:"message,omitempty"` // Error message of denied requests
+	Target  string        `json:"target,omitempty"`  // provider/model of routed requests
+	Errors  []PolicyError `json:"errors,omitempty"`  // Expressions that failed to evaluate
+}
+
+// PolicyError is the error of a policy expression failing to evaluate.
+type PolicyError struct {
+	Policy string `json:"policy"`
+	Error  string `json:"error"`
+}
+
+// policyEnv is the CEL environment of policy expressions.
+var policyEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("path", cel.StringType),
+		cel.Variable("virtual_key", cel.StringType),
+		cel.Variable("key", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("labels", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("provider", cel.StringType),
+		cel.Variable("model", cel.StringType),
+		cel.Variable("now", cel.TimestampType),
+		cel.CrossTypeNumericComparisons(true),
+	)
+})
+
+// Policies are policies with their expressions compiled, ready to be evaluated.
+type Policies struct {
+	config   PoliciesConfig
+	programs []cel.Program // By policy, nil for disabled ones
+}
+
+// CompilePolicies checks that the policies are named uniquely with a valid action, and compiles their expressions.
+func CompilePolicies(config PoliciesConfig) (*Policies, error) {
+	env, err := policyEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the policy environment: %w", err)
+	}
+	for i, attributes := range config.KeyAttributes {
+		if len(attributes.VirtualKeys) == 0 {
+			return nil, fmt.Errorf("key_attributes %d: at least one virtual key is required", i)
+		}
+	}
+	names := make(map[string]struct{}, len(config.Policies))
+	programs := make([]cel.Program, len(config.Policies))
+	for i, policy := range config.Policies {
+		if policy.Name == "" {
+			return nil, fmt.Errorf("policy %d: name is required", i)
+		}
+		if _, ok := names[policy.Name]; ok {
+			return nil, fmt.Errorf("policy %s: duplicate name", policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+		switch policy.Action {
+		case PolicyActionAllow, PolicyActionDeny:
+		case PolicyActionRoute:
+			if provider, model, ok := strings.Cut(policy.Target, "/"); !ok || provider == "" || model == "" {
+				return nil, fmt.Errorf("policy %s: target must be in provider/model format", policy.Name)
+			}
+		default:
+			return nil, fmt.Errorf("policy %s: action must be %q, %q or %q", policy.Name, PolicyActionAllow, PolicyActionDeny, PolicyActionRoute)
+		}
+		if policy.Expression == "" {
+			return nil, fmt.Errorf("policy %s: expression is required", policy.Name)
+		}
+		ast, issues := env.Compile(policy.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("policy %s: invalid expression: %w", policy.Name, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("policy %s: expression must evaluate to a bool, not %s", policy.Name, ast.OutputType())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: %w", policy.Name, err)
+		}
+		if !policy.Disabled {
+			programs[i] = program
+		}
+	}
+	return &Policies{config: config, programs: programs}, nil
+}
+
+// Config returns the policies as configured.
+func (p *Policies) Config() PoliciesConfig {
+	return p.config
+}
+
+// Evaluate evaluates the enabled policies in order against a request, until one matches.
+func (p *Policies) Evaluate(input PolicyInput) PolicyDecision {
+	decision := PolicyDecision{Version: p.config.Version}
+	now := input.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	body := input.Body
+	if body == nil {
+		body = map[string]any{}
+	}
+	headers := input.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	labels := input.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	model, _ := body["model"].(string)
+	provider, modelName := "", model
+	if before, after, found := strings.Cut(model, "/"); found {
+		provider, modelName = before, after
+	}
+	activation := map[string]any{
+		"request":     body,
+		"path":        input.Path,
+		"virtual_key": input.VirtualKey,
+		"key":         p.keyAttributes(input.VirtualKey),
+		"headers":     headers,
+		"labels":      labels,
+		"provider":    provider,
+		"model":       modelName,
+		"now":         now,
+	}
+
+	for i, policy := range p.config.Policies {
+		if p.programs[i] == nil {
+			continue
+		}
+		out, _, err := p.programs[i].Eval(activation)
+		if err != nil {
+			decision.Errors = append(decision.Errors, PolicyError{Policy: policy.Name, Error: err.Error()})
+			continue
+		}
+		if matched, _ := out.Value().(bool); !matched {
+			continue
+		}
+		decision.Policy, decision.Action = policy.Name, policy.Action
+		switch policy.Action {
+		case PolicyActionDeny:
+			decision.Message = policy.Message
+			if decision.Message == "" {
+				decision.Message = fmt.Sprintf("request denied by policy %s", policy.Name)
+			}
+		case PolicyActionRoute:
+			decision.Target = policy.Target
+		}
+		return decision
+	}
+	return decision
+}
+
+// keyAttributes returns the attributes of a virtual key.
+func (p *Policies) keyAttributes(virtualKey string) map[string]string {
+	attributes := map[string]string{}
+	if virtualKey == "" {
+		return attributes
+	}
+	for _, entry := range p.config.KeyAttributes {
+		if !matchesAny(entry.VirtualKeys, virtualKey) {
+			continue
+		}
+		for name, value := range entry.Attributes {
+			if _, ok := attributes[name]; !ok {
+				attributes[name] = value
+			}
+		}
+	}
+	return attributes
+}
+
+// GetPolicies returns the active policies, or nil when none is configured.
+func (s *Config) GetPolicies() *Policies {
+	return s.policies.Load()
+}
+
+// GetPolicyVersions returns the versions of the policies kept, oldest first.
+func (s *Config) GetPolicyVersions() []PoliciesConfig {
+	s.policyVersionsMu.Lock()
+	defer s.policyVersionsMu.Unlock()
+	return append([]PoliciesConfig(nil), s.policyVersions...)
+}
+
+// UpdatePolicies validates and activates policies as a new version, persisting the versions in the config store
+// when one is configured. It returns the version activated.
+func (s *Config) UpdatePolicies(ctx context.Context, config PoliciesConfig) (PoliciesConfig, error) {
+	s.policyVersionsMu.Lock()
+	defer s.policyVersionsMu.Unlock()
+
+	config.Version = 1
+	if len(s.policyVersions) > 0 {
+		config.Version = s.policyVersions[len(s.policyVersions)-1].Version + 1
+	}
+	config.UpdatedAt = time.Now().UTC()
+	policies, err := CompilePolicies(config)
+	if err != nil {
+		return PoliciesConfig{}, err
+	}
+	versions := append(append([]PoliciesConfig(nil), s.policyVersions...), config)
+	if len(versions) > MaxPolicyVersions {
+		versions = versions[len(versions)-MaxPolicyVersions:]
+	}
+	if err := s.saveStoredConfig(ctx, PoliciesConfigKey, versions); err != nil {
+		return PoliciesConfig{}, fmt.Errorf("failed to save policies: %w", err)
+	}
+	s.policyVersions = versions
+	s.policies.Store(policies)
+	return config, nil
+}
+
+// loadPolicies activates the latest version of the policies saved in the config store. Without saved policies, the
+// ones from the config file are used and saved to bootstrap the store.
+func (s *Config) loadPolicies(ctx context.Context, fileConfig *PoliciesConfig) error {
+	var versions []PoliciesConfig
+	found, err := s.loadStoredConfig(ctx, PoliciesConfigKey, &versions)
+	if err != nil {
+		return fmt.Errorf("failed to load policies: %w", err)
+	}
+	if found && len(versions) > 0 {
+		policies, err := CompilePolicies(versions[len(versions)-1])
+		if err != nil {
+			return fmt.Errorf("invalid stored policies: %w", err)
+		}
+		s.policyVersionsMu.Lock()
+		s.policyVersions = versions
+		s.policyVersionsMu.Unlock()
+		s.policies.Store(policies)
+		return nil
+	}
+	if fileConfig == nil {
+		return nil
+	}
+	_, err = s.UpdatePolicies(ctx, *fileConfig)
+	return err
+}
@@ -0,0 +1,180 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// DefaultProviderMaxCooldownSeconds caps the cooldowns advertised by providers when no cap is configured.
+const DefaultProviderMaxCooldownSeconds = 300
+
+// ProviderCooldownsConfig represents the configuration of provider cooldowns. When a provider answers 429 with a
+// Retry-After, it cools down for the advertised window: requests skip it for the next fallback instead of
+// hammering it, as long as a fallback that is not cooling down remains.
+type ProviderCooldownsConfig struct {
+	Enabled               bool `json:"enabled"`
+	MaxCooldownSeconds    int  `json:"max_cooldown_seconds,omitempty"`    // Longest cooldown applied, whatever the provider advertises (default: 300)
+	RejectWithoutFallback bool `json:"reject_without_fallback,omitempty"` // Reject requests to a cooling down provider with 429 when no fallback is left, instead of sending them
+}
+
+// Validate checks the cooldown cap.
+func (c *ProviderCooldownsConfig) Validate() error {
+	if c.MaxCooldownSeconds < 0 {
+		return fmt.Errorf("provider_cooldowns: max_cooldown_seconds must not be negative")
+	}
+	return nil
+}
+
+// ProviderCooldown is the cooldown state of a provider that asked to be retried later.
+type ProviderCooldown struct {
+	Provider         schemas.ModelProvider `json:"provider"`
+	CoolingDown      bool                  `json:"cooling_down"`
+	Until            time.Time             `json:"until"`             // End of the last cooldown
+	RemainingSeconds float64               `json:"remaining_seconds"` // Time left of the cooldown, 0 once it ended
+	Throttles        int64                 `json:"throttles"`         // 429 responses with a Retry-After since the server started
+	Avoided          int64                 `json:"avoided"`           // Requests sent to a fallback, or rejected, during cooldowns
+}
+
+// providerCooldownsNamespace is the plugin state namespace of the cooldowns.
+const providerCooldownsNamespace = "provider_cooldowns"
+
+// providerCooldownsIndexKey is the plugin state key listing the providers throttled so far, comma-separated and
+// sorted, so snapshots can find their keys.
+const providerCooldownsIndexKey = "providers"
+
+// ProviderCooldowns keeps the cooldowns of the providers in the plugin state of the Bifrost instance, shared by all
+// requests and, with a shared state, by all replicas.
+type ProviderCooldowns struct {
+	config ProviderCooldownsConfig
+	state  schemas.PluginState
+}
+
+// NewProviderCooldowns creates the cooldowns kept in the given plugin state, applying defaults to unset config
+// values.
+func NewProviderCooldowns(config ProviderCooldownsConfig, state schemas.PluginState) *ProviderCooldowns {
+	if config.MaxCooldownSeconds <= 0 {
+		config.MaxCooldownSeconds = DefaultProviderMaxCooldownSeconds
+	}
+	return &ProviderCooldowns{config: config, state: state}
+}
+
+// RejectWithoutFallback reports whether requests to a cooling down provider without fallback are rejected.
+func (c *ProviderCooldowns) RejectWithoutFallback() bool {
+	return c.config.RejectWithoutFallback
+}
+
+// Throttled starts or extends the cooldown of a provider that asked to be retried after retryAfter, capped by
+// MaxCooldownSeconds. A shorter window never cuts a cooldown short.
+func (c *ProviderCooldowns) Throttled(provider schemas.ModelProvider, retryAfter time.Duration, now time.Time) {
+	if retryAfter <= 0 {
+		return
+	}
+	retryAfter = min(retryAfter, time.Duration(c.config.MaxCooldownSeconds)*time.Second)
+	c.index(provider)
+	if _, err := c.state.Increment(providerCooldownsNamespace, "throttles:"+string(provider), 1, 0); err != nil {
+		logger.Warn("failed to count the throttle of %s: %v", provider, err)
+	}
+	until := now.Add(retryAfter).UnixNano()
+	key := "until:" + string(provider)
+	for {
+		current, ok := c.state.Get(providerCooldownsNamespace, key)
+		if !ok {
+			if _, set := c.state.SetIfAbsent(providerCooldownsNamespace, key, until, 0); set {
+				return
+			}
+			continue
+		}
+		if currentUntil, _ := current.(int64); currentUntil >= until || c.state.CompareAndSwap(providerCooldownsNamespace, key, current, until) {
+			return
+		}
+	}
+}
+
+// index adds a provider to the list of throttled providers.
+func (c *ProviderCooldowns) index(provider schemas.ModelProvider) {
+	for {
+		current, ok := c.state.Get(providerCooldownsNamespace, providerCooldownsIndexKey)
+		if !ok {
+			if _, set := c.state.SetIfAbsent(providerCooldownsNamespace, providerCooldownsIndexKey, string(provider), 0); set {
+				return
+			}
+			continue
+		}
+		list, _ := current.(string)
+		providers := strings.Split(list, ",")
+		if slices.Contains(providers, string(provider)) {
+			return
+		}
+		providers = append(providers, string(provider))
+		sort.Strings(providers)
+		if c.state.CompareAndSwap(providerCooldownsNamespace, providerCooldownsIndexKey, current, strings.Join(providers, ",")) {
+			return
+		}
+	}
+}
+
+// until returns the end of the last cooldown of a provider, zero when it was never throttled.
+func (c *ProviderCooldowns) until(provider schemas.ModelProvider) time.Time {
+	value, ok := c.state.Get(providerCooldownsNamespace, "until:"+string(provider))
+	if !ok {
+		return time.Time{}
+	}
+	until, _ := value.(int64)
+	return time.Unix(0, until)
+}
+
+// counter returns the value of a counter of a provider, 0 when it is not set.
+func (c *ProviderCooldowns) counter(provider schemas.ModelProvider, name string) int64 {
+	value, _ := c.state.Get(providerCooldownsNamespace, name+":"+string(provider))
+	counter, _ := value.(int64)
+	return counter
+}
+
+// Remaining returns the time left of the cooldown of a provider, 0 when it is not cooling down.
+func (c *ProviderCooldowns) Remaining(provider schemas.ModelProvider, now time.Time) time.Duration {
+	if until := c.until(provider); until.After(now) {
+		return until.Sub(now)
+	}
+	return 0
+}
+
+// Avoided counts a request sent to a fallback, or rejected, because its provider was cooling down.
+func (c *ProviderCooldowns) Avoided(provider schemas.ModelProvider) {
+	if c.until(provider).IsZero() {
+		return
+	}
+	if _, err := c.state.Increment(providerCooldownsNamespace, "avoided:"+string(provider), 1, 0); err != nil {
+		logger.Warn("failed to count the avoided request of %s: %v", provider, err)
+	}
+}
+
+// Snapshot returns the cooldowns of the providers throttled since the state was created, sorted by provider.
+func (c *ProviderCooldowns) Snapshot(now time.Time) []ProviderCooldown {
+	value, _ := c.state.Get(providerCooldownsNamespace, providerCooldownsIndexKey)
+	list, _ := value.(string)
+	if list == "" {
+		return []ProviderCooldown{}
+	}
+	providers := strings.Split(list, ",")
+	cooldowns := make([]ProviderCooldown, 0, len(providers))
+	for _, name := range providers {
+		provider := schemas.ModelProvider(name)
+		cooldown := ProviderCooldown{
+			Provider:  provider,
+			Until:     c.until(provider),
+			Throttles: c.counter(provider, "throttles"),
+			Avoided:   c.counter(provider, "avoided"),
+		}
+		if remaining := cooldown.Until.Sub(now); remaining > 0 {
+			cooldown.CoolingDown = true
+			cooldown.RemainingSeconds = remaining.Seconds()
+		}
+		cooldowns = append(cooldowns, cooldown)
+	}
+	return cooldowns
+}
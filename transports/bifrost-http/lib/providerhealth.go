@@ -0,0 +1,276 @@
+package lib
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	bifrost "github.com/maximhq/bifrost/core"
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+const (
+	DefaultProviderHealthIntervalSeconds  = 60
+	DefaultProviderHealthTimeoutSeconds   = 10
+	DefaultProviderHealthFailureThreshold = 3
+	DefaultProviderHealthHistorySize      = 60
+)
+
+// ProviderHealthProbeContextKey marks the requests sent by the provider health prober.
+const ProviderHealthProbeContextKey ContextKey = "bifrost-provider-health-probe"
+
+// ProviderHealthConfig represents the configuration of active provider health probing.
+type ProviderHealthConfig struct {
+	Enabled          bool              `json:"enabled"`
+	IntervalSeconds  int               `json:"interval_seconds,omitempty"`  // Time between probe rounds (default: 60)
+	TimeoutSeconds   int               `json:"timeout_seconds,omitempty"`   // Timeout of a single probe (default: 10)
+	FailureThreshold int               `json:"failure_threshold,omitempty"` // Consecutive failed probes before a provider is unhealthy (default: 3)
+	HistorySize      int               `json:"history_size,omitempty"`      // Probe results kept per provider (default: 60)
+	ProbeModels      map[string]string `json:"probe_models,omitempty"`      // Model probed per provider (default: the first model listed on its keys)
+	AvoidUnhealthy   *bool             `json:"avoid_unhealthy,omitempty"`   // Skip unhealthy providers when a healthy fallback exists (default: true)
+}
+
+// ProviderHealthStatus is the probed state of a provider.
+type ProviderHealthStatus string
+
+const (
+	ProviderHealthStatusHealthy   ProviderHealthStatus = "healthy"
+	ProviderHealthStatusUnhealthy ProviderHealthStatus = "unhealthy"
+	ProviderHealthStatusUnknown   ProviderHealthStatus = "unknown" // Not probed yet, or no model to probe
+)
+
+// ProviderHealthSample is the result of a single probe.
+type ProviderHealthSample struct {
+	Timestamp time.Time `json:"timestamp"`
+	LatencyMs float64   `json:"latency_ms"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ProviderHealth is the health report of a provider.
+type ProviderHealth struct {
+	Provider            schemas.ModelProvider  `json:"provider"`
+	Model               string                 `json:"model,omitempty"` // Probed model
+	Status              ProviderHealthStatus   `json:"status"`
+	ConsecutiveFailures int                    `json:"consecutive_failures"`
+	LastChecked         *time.Time             `json:"last_checked,omitempty"`
+	LastError           string                 `json:"last_error,omitempty"`
+	Availability        float64                `json:"availability"`   // Share of successful probes in the history, 0..1
+	AvgLatencyMs        float64                `json:"avg_latency_ms"` // Average latency of successful probes in the history
+	History             []ProviderHealthSample `json:"history"`        // Oldest first
+}
+
+// ProviderHealthTracker keeps the probe history of every configured provider and runs the prober.
+type ProviderHealthTracker struct {
+	config    ProviderHealthConfig
+	mu        sync.RWMutex
+	providers map[schemas.ModelProvider]*ProviderHealth
+}
+
+// NewProviderHealthTracker creates a tracker, applying defaults to unset config values.
+func NewProviderHealthTracker(config ProviderHealthConfig) *ProviderHealthTracker {
+	if config.IntervalSeconds <= 0 {
+		config.IntervalSeconds = DefaultProviderHealthIntervalSeconds
+	}
+	if config.TimeoutSeconds <= 0 {
+		config.TimeoutSeconds = DefaultProviderHealthTimeoutSeconds
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = DefaultProviderHealthFailureThreshold
+	}
+	if config.HistorySize <= 0 {
+		config.HistorySize = DefaultProviderHealthHistorySize
+	}
+	return &ProviderHealthTracker{
+		config:    config,
+		providers: make(map[schemas.ModelProvider]*ProviderHealth),
+	}
+}
+
+// AvoidUnhealthy reports whether routing should skip unhealthy providers.
+func (t *ProviderHealthTracker) AvoidUnhealthy() bool {
+	return t.config.AvoidUnhealthy == nil || *t.config.AvoidUnhealthy
+}
+
+// IsUnhealthy reports whether the provider failed its last FailureThreshold probes.
+// Providers that were never probed are not unhealthy.
+func (t *ProviderHealthTracker) IsUnhealthy(provider schemas.ModelProvider) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	health, ok := t.providers[provider]
+	return ok && health.Status == ProviderHealthStatusUnhealthy
+}
+
+// Record adds a probe result to the provider's history and updates its status.
+func (t *ProviderHealthTracker) Record(provider schemas.ModelProvider, model string, sample ProviderHealthSample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	health := t.getOrCreate(provider)
+	health.Model = model
+	health.LastChecked = &sample.Timestamp
+	health.History = append(health.History, sample)
+	if len(health.History) > t.config.HistorySize {
+		health.History = health.History[len(health.History)-t.config.HistorySize:]
+	}
+	if sample.Success {
+		health.ConsecutiveFailures = 0
+		health.LastError = ""
+		health.Status = ProviderHealthStatusHealthy
+	} else {
+		health.ConsecutiveFailures++
+		health.LastError = sample.Error
+		if health.ConsecutiveFailures >= t.config.FailureThreshold {
+			health.Status = ProviderHealthStatusUnhealthy
+		}
+	}
+
+	successes := 0
+	latency := 0.0
+	for _, s := range health.History {
+		if s.Success {
+			successes++
+			latency += s.LatencyMs
+		}
+	}
+	health.Availability = float64(successes) / float64(len(health.History))
+	health.AvgLatencyMs = 0
+	if successes > 0 {
+		health.AvgLatencyMs = latency / float64(successes)
+	}
+}
+
+// Snapshot returns a copy of the health of all tracked providers, sorted by provider.
+func (t *ProviderHealthTracker) Snapshot() []ProviderHealth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	result := make([]ProviderHealth, 0, len(t.providers))
+	for _, health := range t.providers {
+		copied := *health
+		copied.History = append([]ProviderHealthSample(nil), health.History...)
+		result = append(result, copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Provider < result[j].Provider
+	})
+	return result
+}
+
+// getOrCreate returns the health entry of a provider. The caller must hold the write lock.
+func (t *ProviderHealthTracker) getOrCreate(provider schemas.ModelProvider) *ProviderHealth {
+	health, ok := t.providers[provider]
+	if !ok {
+		health = &ProviderHealth{
+			Provider: provider,
+			Status:   ProviderHealthStatusUnknown,
+			History:  []ProviderHealthSample{},
+		}
+		t.providers[provider] = health
+	}
+	return health
+}
+
+// retain drops providers that are no longer configured and adds entries for new ones.
+func (t *ProviderHealthTracker) retain(providers map[schemas.ModelProvider]string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for provider := range t.providers {
+		if _, ok := providers[provider]; !ok {
+			delete(t.providers, provider)
+		}
+	}
+	for provider, model := range providers {
+		health := t.getOrCreate(provider)
+		if model == "" {
+			health.Status = ProviderHealthStatusUnknown
+			health.LastError = "no model to probe, set provider_health.probe_models"
+		}
+	}
+}
+
+// Start probes all configured providers every IntervalSeconds until ctx is cancelled.
+// Probes are 1 token chat completions sent through the client, so they go through the plugin chain
+// like any other request and are marked with ProviderHealthProbeContextKey.
+func (t *ProviderHealthTracker) Start(ctx context.Context, config *Config, client *bifrost.Bifrost) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(t.config.IntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			t.probeAll(ctx, config, client)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// probeAll probes every configured provider concurrently.
+func (t *ProviderHealthTracker) probeAll(ctx context.Context, config *Config, client *bifrost.Bifrost) {
+	models := t.probeModels(config)
+	t.retain(models)
+
+	var wg sync.WaitGroup
+	for provider, model := range models {
+		if model == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(provider schemas.ModelProvider, model string) {
+			defer wg.Done()
+			t.Record(provider, model, t.probe(ctx, client, provider, model))
+		}(provider, model)
+	}
+	wg.Wait()
+}
+
+// probe sends a single probe request.
+func (t *ProviderHealthTracker) probe(ctx context.Context, client *bifrost.Bifrost, provider schemas.ModelProvider, model string) ProviderHealthSample {
+	probeCtx, cancel := context.WithTimeout(ctx, time.Duration(t.config.TimeoutSeconds)*time.Second)
+	defer cancel()
+	probeCtx = context.WithValue(probeCtx, ProviderHealthProbeContextKey, true)
+
+	start := time.Now()
+	_, bifrostErr := client.ChatCompletionRequest(probeCtx, &schemas.BifrostChatRequest{
+		Provider: provider,
+		Model:    model,
+		Input: []schemas.ChatMessage{{
+			Role:    schemas.ChatMessageRoleUser,
+			Content: &schemas.ChatMessageContent{ContentStr: bifrost.Ptr("ping")},
+		}},
+		Params: &schemas.ChatParameters{MaxCompletionTokens: bifrost.Ptr(1)},
+	})
+	sample := ProviderHealthSample{
+		Timestamp: start,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Success:   bifrostErr == nil,
+	}
+	if bifrostErr != nil {
+		sample.Error = "probe failed"
+		if bifrostErr.Error != nil && bifrostErr.Error.Message != "" {
+			sample.Error = bifrostErr.Error.Message
+		}
+	}
+	return sample
+}
+
+// probeModels returns the model to probe for every configured provider ("" when none is known).
+func (t *ProviderHealthTracker) probeModels(config *Config) map[schemas.ModelProvider]string {
+	config.Mu.RLock()
+	defer config.Mu.RUnlock()
+	models := make(map[schemas.ModelProvider]string, len(config.Providers))
+	for provider, providerConfig := range config.Providers {
+		model := t.config.ProbeModels[string(provider)]
+		for _, key := range providerConfig.Keys {
+			if model != "" {
+				break
+			}
+			if len(key.Models) > 0 {
+				model = key.Models[0]
+			}
+		}
+		models[provider] = model
+	}
+	return models
+}
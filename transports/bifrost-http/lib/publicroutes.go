@@ -0,0 +1,160 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// PublicRoutesConfigKey is the config store key holding the public route rules.
+const PublicRoutesConfigKey = "public_routes"
+
+// PublicRoute decides whether requests matching a method and path glob skip admin authentication.
+// Rules are evaluated in order before DefaultPublicRoutes and the first matching rule wins, so a rule
+// with Public false locks down a route that is public by default.
+type PublicRoute struct {
+	Method string `json:"method,omitempty"` // HTTP method, empty or "*" for any
+	Path   string `json:"path"`             // Path glob, e.g. "/metrics", "/v1/*" or "/v1/*/batches"; a trailing * matches by prefix
+	Public bool   `json:"public"`
+}
+
+// DefaultPublicRoutes are the routes reachable without admin authentication unless a configured rule says otherwise.
+var DefaultPublicRoutes = []PublicRoute{
+	{Method: "GET", Path: "/metrics", Public: true},
+	{Method: "POST", Path: "/v1/*", Public: true},
+	// Async jobs are polled by the clients that queued them, and only shown to them
+	{Method: "GET", Path: "/v1/async/*", Public: true},
+	// Fine-tuning jobs are read by the virtual keys that created them, and only shown to them
+	{Method: "GET", Path: "/v1/fine_tuning/jobs", Public: true},
+	{Method: "GET", Path: "/v1/fine_tuning/jobs/*", Public: true},
+	// Assistants API objects are read and deleted by the clients that created them, and only shown to them
+	{Method: "GET", Path: "/v1/assistants", Public: true},
+	{Method: "GET", Path: "/v1/assistants/*", Public: true},
+	{Method: "DELETE", Path: "/v1/assistants/*", Public: true},
+	{Method: "GET", Path: "/v1/threads/*", Public: true},
+	{Method: "DELETE", Path: "/v1/threads/*", Public: true},
+	// OpenAI-compatible routes under /openai and /openai/v1 are public for inference
+	{Method: "POST", Path: "/openai/*", Public: true},
+	{Method: "GET", Path: "/openai/models", Public: true},
+	{Method: "GET", Path: "/openai/v1/models", Public: true},
+	{Method: "GET", Path: "/api/version", Public: true},
+	{Method: "GET", Path: "/health", Public: true},
+}
+
+// managementPrefixes are the prefixes of the management API and websocket routes, which rules cannot make public
+// whatever the method: a public rule whose path may match a route under them is rejected.
+var managementPrefixes = []string{"/api", "/ws"}
+
+// publicManagementPaths are the management routes rules may make public, by exact path.
+var publicManagementPaths = []string{"/api/version"}
+
+// Matches reports whether the rule applies to a request.
+func (r PublicRoute) Matches(method, requestPath string) bool {
+	if r.Method != "" && r.Method != "*" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(r.Path, "*"); ok && !strings.ContainsAny(prefix, "*?[") {
+		return strings.HasPrefix(requestPath, prefix)
+	}
+	matched, _ := path.Match(r.Path, requestPath)
+	return matched
+}
+
+// ValidatePublicRoutes checks that every rule has a valid path glob and that no rule exposes the management API.
+func ValidatePublicRoutes(rules []PublicRoute) error {
+	for i, rule := range rules {
+		if !strings.HasPrefix(rule.Path, "/") {
+			return fmt.Errorf("rule %d: path must start with /", i)
+		}
+		if _, err := path.Match(rule.Path, ""); err != nil {
+			return fmt.Errorf("rule %d: invalid path glob %q: %w", i, rule.Path, err)
+		}
+		if !rule.Public {
+			continue
+		}
+		if exposesManagementRoutes(rule.Path) {
+			return fmt.Errorf("rule %d: %s may match management routes under /api or /ws, which cannot be made public", i, rule.Path)
+		}
+	}
+	return nil
+}
+
+// exposesManagementRoutes reports whether a path glob may match a management route. The literal part of the glob,
+// up to its first wildcard, is compared with the management prefixes, so "/*" and "/a*" are rejected as well.
+func exposesManagementRoutes(glob string) bool {
+	if slices.Contains(publicManagementPaths, glob) {
+		return false
+	}
+	literal := glob
+	if i := strings.IndexAny(glob, "*?[\\"); i >= 0 {
+		literal = glob[:i]
+	}
+	for _, prefix := range managementPrefixes {
+		if strings.HasPrefix(literal, prefix) || (len(literal) < len(glob) && strings.HasPrefix(prefix, literal)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPublicRoute reports whether a request skips admin authentication according to rules,
+// falling back to DefaultPublicRoutes.
+func IsPublicRoute(rules []PublicRoute, method, requestPath string) bool {
+	for _, rule := range rules {
+		if rule.Matches(method, requestPath) {
+			return rule.Public
+		}
+	}
+	for _, rule := range DefaultPublicRoutes {
+		if rule.Matches(method, requestPath) {
+			return rule.Public
+		}
+	}
+	return false
+}
+
+// GetPublicRoutes returns the configured public route rules.
+func (s *Config) GetPublicRoutes() []PublicRoute {
+	rules := s.publicRoutes.Load()
+	if rules == nil {
+		return nil
+	}
+	return *rules
+}
+
+// UpdatePublicRoutes validates and activates rules, persisting them in the config store when one is configured.
+func (s *Config) UpdatePublicRoutes(ctx context.Context, rules []PublicRoute) error {
+	if err := ValidatePublicRoutes(rules); err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, PublicRoutesConfigKey, rules); err != nil {
+		return fmt.Errorf("failed to save public routes: %w", err)
+	}
+	s.publicRoutes.Store(&rules)
+	return nil
+}
+
+// loadPublicRoutes activates the rules saved in the config store. Without saved rules,
+// the rules from the config file are used and saved to bootstrap the store.
+func (s *Config) loadPublicRoutes(ctx context.Context, fileRules []PublicRoute) error {
+	var rules []PublicRoute
+	found, err := s.loadStoredConfig(ctx, PublicRoutesConfigKey, &rules)
+	if err != nil {
+		return fmt.Errorf("failed to load public routes: %w", err)
+	}
+	if found {
+		// Rules saved by earlier versions may expose routes that can no longer be made public
+		if err := ValidatePublicRoutes(rules); err != nil {
+			logger.Warn("ignoring the saved public routes, update them through PUT /api/public-routes: %v", err)
+			return nil
+		}
+		s.publicRoutes.Store(&rules)
+		return nil
+	}
+	if len(fileRules) == 0 {
+		return nil
+	}
+	return s.UpdatePublicRoutes(ctx, fileRules)
+}
@@ -0,0 +1,225 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	DefaultRaceBurnRate      = 1.0
+	DefaultRaceWindowMinutes = 5
+)
+
+// RaceConfig sends matching requests to two providers. The response, or the stream, of the provider answering
+// first is returned and the other request is cancelled. Racing trades spend for latency, so rules can be limited
+// to a share of the traffic, to the periods in which a latency SLO is at risk, or hedge: only send the second
+// request when the primary provider has not started answering within a threshold.
+type RaceConfig struct {
+	Rules []RaceRule `json:"rules"`
+}
+
+// RaceRule races or hedges the requests to a provider, or to some of its models, with another provider and model.
+// The first rule matching a request applies.
+type RaceRule struct {
+	Name              string                `json:"name"`
+	Provider          schemas.ModelProvider `json:"provider"`                        // Primary provider the rule applies to
+	Models            []string              `json:"models,omitempty"`                // Primary models the rule applies to (default: all)
+	Against           string                `json:"against,omitempty"`               // Provider and model raced against the primary one, e.g. "groq/llama-3.3-70b-versatile" (default for hedges: the first fallback of the request)
+	HedgeAfterMs      int                   `json:"hedge_after_ms,omitempty"`        // Only send the second request when no response or first chunk arrived within this time (default: race from the start)
+	SampleRate        *float64              `json:"sample_rate,omitempty"`           // Share of the matching requests raced, 0 to 1 (default: 1)
+	OnlyWhenSLOAtRisk bool                  `json:"only_when_slo_at_risk,omitempty"` // Only race while a ttft or latency SLO of the primary provider and model is at risk
+	BurnRate          float64               `json:"burn_rate,omitempty"`             // Error budget burn rate from which an SLO is at risk (default: 1)
+	WindowMinutes     int                   `json:"window_minutes,omitempty"`        // Window the burn rate is measured over, up to the longest SLO alert window (default: 5)
+}
+
+// RaceRuleStats are the outcomes of the requests raced or hedged by a rule since the server started.
+type RaceRuleStats struct {
+	Rule            string  `json:"rule"`
+	HedgeAfterMs    int     `json:"hedge_after_ms,omitempty"`
+	Requests        int64   `json:"requests"`         // Requests the rule applied to
+	ChallengersSent int64   `json:"challengers_sent"` // Requests also sent to the second provider
+	ChallengerWins  int64   `json:"challenger_wins"`  // Requests answered first by the second provider
+	SentRate        float64 `json:"sent_rate"`        // Share of the requests also sent to the second provider, i.e. the hedge rate
+	WinRate         float64 `json:"win_rate"`         // Share of the requests sent to the second provider it answered first
+}
+
+// Validate checks the rules.
+func (c *RaceConfig) Validate() error {
+	names := map[string]bool{}
+	for i, rule := range c.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("race: rule %d: name is required", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("race: duplicate rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Provider == "" {
+			return fmt.Errorf("race: rule %q: provider is required", rule.Name)
+		}
+		if rule.HedgeAfterMs < 0 {
+			return fmt.Errorf("race: rule %q: hedge_after_ms must not be negative", rule.Name)
+		}
+		if rule.Against == "" && rule.HedgeAfterMs == 0 {
+			return fmt.Errorf("race: rule %q: against is required unless the rule hedges with the first fallback", rule.Name)
+		}
+		if provider, model := schemas.ParseModelString(rule.Against, ""); rule.Against != "" && (provider == "" || model == "") {
+			return fmt.Errorf("race: rule %q: against must be in provider/model format", rule.Name)
+		}
+		if rule.SampleRate != nil && (*rule.SampleRate < 0 || *rule.SampleRate > 1) {
+			return fmt.Errorf("race: rule %q: sample_rate must be between 0 and 1", rule.Name)
+		}
+		if rule.BurnRate < 0 || rule.WindowMinutes < 0 {
+			return fmt.Errorf("race: rule %q: burn_rate and window_minutes must not be negative", rule.Name)
+		}
+	}
+	return nil
+}
+
+// raceRuleCounters count the outcomes of a rule
+type raceRuleCounters struct {
+	requests        atomic.Int64
+	challengersSent atomic.Int64
+	challengerWins  atomic.Int64
+}
+
+// Racer picks the provider raced against, or hedging, the primary provider of each request.
+type Racer struct {
+	rules    []RaceRule
+	counters []*raceRuleCounters // By rule
+	slos     *SLOTracker
+}
+
+// NewRacer creates a racer, applying defaults to unset rule values. slos is required by the rules racing only
+// while an SLO is at risk.
+func NewRacer(config RaceConfig, slos *SLOTracker) *Racer {
+	racer := &Racer{rules: make([]RaceRule, len(config.Rules)), counters: make([]*raceRuleCounters, len(config.Rules)), slos: slos}
+	for i, rule := range config.Rules {
+		if rule.BurnRate == 0 {
+			rule.BurnRate = DefaultRaceBurnRate
+		}
+		if rule.WindowMinutes == 0 {
+			rule.WindowMinutes = DefaultRaceWindowMinutes
+		}
+		racer.rules[i] = rule
+		racer.counters[i] = &raceRuleCounters{}
+	}
+	registerRaceMetrics()
+	return racer
+}
+
+// Select returns the provider and model raced against the primary ones, or nil when the request is not raced.
+// It implements schemas.RaceSelector.
+func (r *Racer) Select(ctx *context.Context, req *schemas.BifrostRequest) *schemas.RaceChallenger {
+	if isProbe, _ := (*ctx).Value(ProviderHealthProbeContextKey).(bool); isProbe {
+		return nil
+	}
+	for i, rule := range r.rules {
+		if rule.Provider != req.Provider || (len(rule.Models) > 0 && !slices.Contains(rule.Models, req.Model)) {
+			continue
+		}
+		if rule.OnlyWhenSLOAtRisk && (r.slos == nil || !r.slos.LatencyAtRisk(req.Provider, req.Model, time.Duration(rule.WindowMinutes)*time.Minute, rule.BurnRate)) {
+			return nil
+		}
+		if rule.SampleRate != nil && rand.Float64() >= *rule.SampleRate {
+			return nil
+		}
+		challenger := &schemas.RaceChallenger{Delay: time.Duration(rule.HedgeAfterMs) * time.Millisecond}
+		if rule.Against != "" {
+			challenger.Provider, challenger.Model = schemas.ParseModelString(rule.Against, "")
+		} else if len(req.Fallbacks) > 0 {
+			challenger.Provider, challenger.Model = req.Fallbacks[0].Provider, req.Fallbacks[0].Model
+		} else {
+			return nil
+		}
+		counters, name := r.counters[i], rule.Name
+		challenger.OnOutcome = func(challengerSent, challengerWon bool) {
+			counters.requests.Add(1)
+			raceRequests.WithLabelValues(name).Inc()
+			if !challengerSent {
+				return
+			}
+			counters.challengersSent.Add(1)
+			outcome := "lost"
+			if challengerWon {
+				counters.challengerWins.Add(1)
+				outcome = "won"
+			}
+			raceChallengers.WithLabelValues(name, outcome).Inc()
+		}
+		return challenger
+	}
+	return nil
+}
+
+// Stats returns the outcomes of every rule.
+func (r *Racer) Stats() []RaceRuleStats {
+	stats := make([]RaceRuleStats, len(r.rules))
+	for i, rule := range r.rules {
+		counters := r.counters[i]
+		stats[i] = RaceRuleStats{
+			Rule:            rule.Name,
+			HedgeAfterMs:    rule.HedgeAfterMs,
+			Requests:        counters.requests.Load(),
+			ChallengersSent: counters.challengersSent.Load(),
+			ChallengerWins:  counters.challengerWins.Load(),
+		}
+		if stats[i].Requests > 0 {
+			stats[i].SentRate = float64(stats[i].ChallengersSent) / float64(stats[i].Requests)
+		}
+		if stats[i].ChallengersSent > 0 {
+			stats[i].WinRate = float64(stats[i].ChallengerWins) / float64(stats[i].ChallengersSent)
+		}
+	}
+	return stats
+}
+
+var (
+	// raceRequests counts the requests each rule applied to
+	raceRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bifrost_race_requests_total",
+		Help: "Requests a race or hedge rule applied to.",
+	}, []string{"rule"})
+	// raceChallengers counts the requests also sent to the second provider of a rule, by whether it answered first
+	raceChallengers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "bifrost_race_challengers_total",
+		Help: "Requests also sent to the second provider of a race or hedge rule, by outcome (won or lost).",
+	}, []string{"rule", "outcome"})
+)
+
+// registerRaceMetrics registers the race counters with the default registry
+func registerRaceMetrics() {
+	for _, collector := range []prometheus.Collector{raceRequests, raceChallengers} {
+		if err := prometheus.Register(collector); err != nil {
+			var alreadyRegistered prometheus.AlreadyRegisteredError
+			if !errors.As(err, &alreadyRegistered) {
+				logger.Warn("failed to register race metrics: %v", err)
+			}
+		}
+	}
+}
+
+// initRaces creates the racer once the SLOs it may depend on are set up.
+func (s *Config) initRaces(config *RaceConfig) error {
+	if config == nil || len(config.Rules) == 0 {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	for _, rule := range config.Rules {
+		if rule.OnlyWhenSLOAtRisk && s.SLOs == nil {
+			return fmt.Errorf("race: rule %q races only when an SLO is at risk, but no SLOs are defined", rule.Name)
+		}
+	}
+	s.Races = NewRacer(*config, s.SLOs)
+	return nil
+}
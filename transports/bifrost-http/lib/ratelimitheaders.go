@@ -0,0 +1,26 @@
+package lib
+
+// Response headers reporting the governance quota left to the virtual key of an inference request, so clients can
+// throttle themselves. RateLimit-* follow the IETF RateLimit header fields (RFC 9331 style) for the request or
+// token limit closest to exhaustion, with X-RateLimit-* aliases; resets are in seconds.
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+
+	XRateLimitLimitHeader     = "X-RateLimit-Limit"
+	XRateLimitRemainingHeader = "X-RateLimit-Remaining"
+	XRateLimitResetHeader     = "X-RateLimit-Reset"
+
+	// Per kind of limit, as sent by OpenAI
+	XRateLimitLimitRequestsHeader     = "X-RateLimit-Limit-Requests"
+	XRateLimitRemainingRequestsHeader = "X-RateLimit-Remaining-Requests"
+	XRateLimitResetRequestsHeader     = "X-RateLimit-Reset-Requests"
+	XRateLimitLimitTokensHeader       = "X-RateLimit-Limit-Tokens"
+	XRateLimitRemainingTokensHeader   = "X-RateLimit-Remaining-Tokens"
+	XRateLimitResetTokensHeader       = "X-RateLimit-Reset-Tokens"
+
+	BudgetLimitHeader     = "x-bf-budget-limit"     // Budget of the current period, in USD
+	BudgetRemainingHeader = "x-bf-budget-remaining" // Budget left in the current period, in USD
+	BudgetResetHeader     = "x-bf-budget-reset"     // Seconds until the budget resets
+)
@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maximhq/bifrost/framework/recording"
+	"gorm.io/gorm"
+)
+
+// RecordContextKey marks requests asking to be recorded through the x-bf-record header
+const RecordContextKey ContextKey = "x-bf-record"
+
+// initRecording sets up the store of upstream recordings. Recordings are stored in the logs database next to
+// the request logs, in the config store database when logs are kept elsewhere, or in memory.
+func (s *Config) initRecording(ctx context.Context, config *recording.Config) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	var db *gorm.DB
+	if logsDB, ok := s.LogsStore.(interface{ DB() *gorm.DB }); ok {
+		db = logsDB.DB()
+	} else if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("upstream recordings are kept in memory since no logs or config store database is configured")
+	}
+	store, err := recording.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize recordings store: %w", err)
+	}
+	s.Recording = config
+	s.Recordings = store
+	return nil
+}
@@ -0,0 +1,112 @@
+package lib
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/cluster"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Methods of authentication whose replays are counted
+const (
+	ReplayMethodSignature = "signature"
+	ReplayMethodJWT       = "jwt"
+)
+
+// nonceSweepInterval is how often expired nonces are dropped at most.
+const nonceSweepInterval = time.Minute
+
+// sharedNonceTimeout bounds the time spent recording a nonce in the cluster state.
+const sharedNonceTimeout = 2 * time.Second
+
+// NonceStore remembers the nonces of authenticated requests, signatures or token IDs, until they expire, so a
+// request can only be accepted once while it is valid. Nonces are kept in memory, and also recorded in the
+// cluster state when it is shared (see Config.sharedNonces), so a request accepted by one replica is rejected by
+// the others; otherwise a replay sent to another replica is accepted.
+type NonceStore struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time // Nonce -> when it can be forgotten
+	nextSweep time.Time
+	shared    cluster.NonceStore // Cluster state shared by the replicas, nil on a single replica
+}
+
+// NewNonceStore creates an empty nonce store.
+func NewNonceStore() *NonceStore {
+	return &NonceStore{seen: make(map[string]time.Time)}
+}
+
+// Remember records a nonce until expiry, reporting false when it is already recorded and not yet expired, by
+// this replica or, when the cluster state is shared, by another one. When the cluster state cannot be reached,
+// only the nonces of this replica are checked.
+func (s *NonceStore) Remember(nonce string, expiry, now time.Time) bool {
+	if !s.rememberLocally(nonce, expiry, now) {
+		return false
+	}
+	if s.shared == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedNonceTimeout)
+	defer cancel()
+	// Hashed, so shared keys have a fixed length and do not reveal token IDs
+	digest := sha256.Sum256([]byte(nonce))
+	recorded, err := s.shared.Remember(ctx, hex.EncodeToString(digest[:]), expiry)
+	if err != nil {
+		if logger != nil {
+			logger.Warn("failed to record nonce in the cluster state, replays are only detected by this replica: %v", err)
+		}
+		return true
+	}
+	return recorded
+}
+
+// rememberLocally records a nonce in memory, reporting false when this replica already recorded it.
+func (s *NonceStore) rememberLocally(nonce string, expiry, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.After(s.nextSweep) {
+		for seen, until := range s.seen {
+			if now.After(until) {
+				delete(s.seen, seen)
+			}
+		}
+		s.nextSweep = now.Add(nonceSweepInterval)
+	}
+	if until, replayed := s.seen[nonce]; replayed && !now.After(until) {
+		return false
+	}
+	s.seen[nonce] = expiry
+	return true
+}
+
+// sharedNonces returns the nonce store of the cluster state when the leader elector shares one (Postgres or
+// Redis), and otherwise nil, warning that the replays of feature are only detected by the replica receiving them.
+func (s *Config) sharedNonces(feature string) cluster.NonceStore {
+	if shared, ok := s.LeaderElector.(cluster.NonceStore); ok {
+		return shared
+	}
+	if logger != nil {
+		logger.Warn("%s: replay protection is local to each replica, enable cluster coordination with postgres or redis when running several replicas", feature)
+	}
+	return nil
+}
+
+// ReplayAttempts counts the requests rejected for reusing a signature, signature nonce or single-use JWT
+var ReplayAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "bifrost_replay_attempts_total",
+	Help: "Authenticated requests rejected as replays, by authentication method (signature or jwt).",
+}, []string{"method"})
+
+// registerReplayMetrics registers the replay counter with the default registry
+func registerReplayMetrics() {
+	if err := prometheus.Register(ReplayAttempts); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) && logger != nil {
+			logger.Warn("failed to register replay metrics: %v", err)
+		}
+	}
+}
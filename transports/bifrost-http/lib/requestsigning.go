@@ -0,0 +1,225 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/cluster"
+)
+
+// RequestSignatureHeader carries the HMAC signature of an inference request, in the format
+// "key=<key id>,t=<unix seconds>,sig=<hex signature>", or "key=<key id>,t=<unix seconds>,nonce=<nonce>,sig=<hex
+// signature>" for signatures carrying a nonce.
+const RequestSignatureHeader = "x-bf-signature"
+
+// DefaultSignatureMaxSkew is how far the timestamp of a signature may be from the server clock by default.
+const DefaultSignatureMaxSkew = 5 * time.Minute
+
+// MaxSignatureNonceLength is the length of the longest nonce accepted in a signature.
+const MaxSignatureNonceLength = 128
+
+// Errors of signature verification, reported to callers as 401 responses
+var (
+	ErrSignatureMissing    = errors.New("request signature required")
+	ErrSignatureMalformed  = errors.New("malformed request signature, expected key=<id>,t=<unix seconds>[,nonce=<nonce>],sig=<hex>")
+	ErrSignatureNoNonce    = errors.New("request signature must carry a nonce")
+	ErrSignatureUnknownKey = errors.New("unknown request signing key")
+	ErrSignatureExpired    = errors.New("request signature timestamp is outside the allowed window")
+	ErrSignatureInvalid    = errors.New("invalid request signature")
+	ErrSignatureReplayed   = errors.New("request signature was already used")
+)
+
+// RequestSigningConfig verifies HMAC signatures of inference requests from server-side callers, as an
+// alternative to bearer keys. The signature is the hex HMAC-SHA256, with the secret of the key, of
+// "<timestamp>.<method>.<path>.<hex SHA-256 of the body>", or of
+// "<timestamp>.<nonce>.<method>.<path>.<hex SHA-256 of the body>" for signatures carrying a nonce, where path is
+// followed by "?" and the raw query string when the request has one. A signature, or the nonce of a key, is
+// accepted once while its timestamp is within the skew window, by all replicas when the cluster state is shared
+// (Postgres or Redis coordination) and otherwise by each replica; nonces let callers send identical requests
+// within the same second.
+type RequestSigningConfig struct {
+	Enabled          bool         `json:"enabled"`
+	Required         bool         `json:"required,omitempty"`            // Reject unsigned inference requests; otherwise only signed ones are verified
+	RequireNonce     bool         `json:"require_nonce,omitempty"`       // Reject signatures without a nonce
+	MaxSkewInSeconds int          `json:"max_skew_in_seconds,omitempty"` // Allowed distance of the timestamp from the server clock (default 300)
+	Keys             []SigningKey `json:"keys"`
+}
+
+// SigningKey is a signing secret and the governance identity of the requests it signs.
+type SigningKey struct {
+	ID         string `json:"id"`
+	Secret     string `json:"secret"`                // Supports env.VAR references
+	VirtualKey string `json:"virtual_key,omitempty"` // Virtual key applied to the requests, replacing x-bf-vk
+	Team       string `json:"team,omitempty"`        // Team ID, replacing x-bf-team
+	Customer   string `json:"customer,omitempty"`    // Customer ID, replacing x-bf-customer
+	User       string `json:"user,omitempty"`        // User ID, replacing x-bf-user
+}
+
+// Validate checks that keys have unique IDs and secrets.
+func (c *RequestSigningConfig) Validate() error {
+	if c.MaxSkewInSeconds < 0 {
+		return fmt.Errorf("request_signing: max_skew_in_seconds must not be negative")
+	}
+	if c.Enabled && len(c.Keys) == 0 {
+		return fmt.Errorf("request_signing: at least one key is required")
+	}
+	seen := make(map[string]bool, len(c.Keys))
+	for i, key := range c.Keys {
+		switch {
+		case key.ID == "":
+			return fmt.Errorf("request_signing: keys[%d]: id is required", i)
+		case key.Secret == "":
+			return fmt.Errorf("request_signing: keys[%d]: secret is required", i)
+		case seen[key.ID]:
+			return fmt.Errorf("request_signing: duplicate key id %q", key.ID)
+		}
+		seen[key.ID] = true
+	}
+	return nil
+}
+
+// RequestVerifier checks request signatures and rejects signatures, or nonces, seen before within the skew window.
+type RequestVerifier struct {
+	required     bool
+	requireNonce bool
+	maxSkew      time.Duration
+	keys         map[string]SigningKey
+	nonces       *NonceStore
+}
+
+// NewRequestVerifier creates a verifier for the keys of config, whose secrets must already be resolved.
+func NewRequestVerifier(config RequestSigningConfig) *RequestVerifier {
+	v := &RequestVerifier{
+		required:     config.Required,
+		requireNonce: config.RequireNonce,
+		maxSkew:      DefaultSignatureMaxSkew,
+		keys:         make(map[string]SigningKey, len(config.Keys)),
+		nonces:       NewNonceStore(),
+	}
+	if config.MaxSkewInSeconds > 0 {
+		v.maxSkew = time.Duration(config.MaxSkewInSeconds) * time.Second
+	}
+	for _, key := range config.Keys {
+		v.keys[key.ID] = key
+	}
+	registerReplayMetrics()
+	return v
+}
+
+// SetSharedNonces records the signatures and nonces seen in the cluster state shared by the replicas, so a
+// request accepted by one replica is rejected by the others. It must be called before the verifier is used.
+func (v *RequestVerifier) SetSharedNonces(shared cluster.NonceStore) {
+	v.nonces.shared = shared
+}
+
+// Required reports whether unsigned inference requests are rejected.
+func (v *RequestVerifier) Required() bool {
+	return v.required
+}
+
+// Verify checks the signature header of a request and returns the key that signed it. path is the request path
+// followed by "?" and the raw query string when there is one (see SignedPath).
+func (v *RequestVerifier) Verify(header, method, path string, body []byte, now time.Time) (SigningKey, error) {
+	if header == "" {
+		return SigningKey{}, ErrSignatureMissing
+	}
+	var keyID, timestamp, nonce, signature string
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch name {
+		case "key":
+			keyID = value
+		case "t":
+			timestamp = value
+		case "nonce":
+			nonce = value
+		case "sig":
+			signature = value
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if keyID == "" || signature == "" || err != nil || len(nonce) > MaxSignatureNonceLength || strings.Contains(nonce, ".") {
+		return SigningKey{}, ErrSignatureMalformed
+	}
+	if nonce == "" && v.requireNonce {
+		return SigningKey{}, ErrSignatureNoNonce
+	}
+	key, ok := v.keys[keyID]
+	if !ok {
+		return SigningKey{}, ErrSignatureUnknownKey
+	}
+	signedAt := time.Unix(seconds, 0)
+	if signedAt.Before(now.Add(-v.maxSkew)) || signedAt.After(now.Add(v.maxSkew)) {
+		return SigningKey{}, ErrSignatureExpired
+	}
+	expected := SignRequestWithNonce(key.Secret, timestamp, nonce, method, path, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return SigningKey{}, ErrSignatureInvalid
+	}
+	// A nonce is single-use per key whatever it signs; signatures without one are single-use themselves
+	replayKey := "sig:" + expected
+	if nonce != "" {
+		replayKey = "nonce:" + keyID + ":" + nonce
+	}
+	if !v.nonces.Remember(replayKey, signedAt.Add(v.maxSkew), now) {
+		ReplayAttempts.WithLabelValues(ReplayMethodSignature).Inc()
+		return SigningKey{}, ErrSignatureReplayed
+	}
+	return key, nil
+}
+
+// SignedPath returns the path covered by request signatures: path, followed by "?" and the raw query string when
+// it is not empty.
+func SignedPath(path, query string) string {
+	if query == "" {
+		return path
+	}
+	return path + "?" + query
+}
+
+// SignRequest returns the hex signature of a request signed at timestamp (unix seconds).
+func SignRequest(secret, timestamp, method, path string, body []byte) string {
+	return SignRequestWithNonce(secret, timestamp, "", method, path, body)
+}
+
+// SignRequestWithNonce returns the hex signature of a request signed at timestamp (unix seconds) with a nonce,
+// the signature of SignRequest when nonce is empty.
+func SignRequestWithNonce(secret, timestamp, nonce, method, path string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	signed := timestamp + "."
+	if nonce != "" {
+		signed += nonce + "."
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed + method + "." + path + "." + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// initRequestSigning resolves the signing secrets and creates the request verifier when signing is enabled.
+func (s *Config) initRequestSigning(config *RequestSigningConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	resolved := *config
+	resolved.Keys = make([]SigningKey, len(config.Keys))
+	for i, key := range config.Keys {
+		secret, _, err := s.processEnvValue(key.Secret)
+		if err != nil {
+			return fmt.Errorf("request_signing: key %s: %w", key.ID, err)
+		}
+		key.Secret = secret
+		resolved.Keys[i] = key
+	}
+	s.RequestVerifier = NewRequestVerifier(resolved)
+	s.RequestVerifier.SetSharedNonces(s.sharedNonces("request_signing"))
+	return nil
+}
@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ResponseHeadersContextKey stores the *ResponseHeaders of an inference request, both as a fasthttp user value
+// and in the Bifrost context.
+const ResponseHeadersContextKey ContextKey = "bifrost-response-headers"
+
+// ResponseHeaders collects headers that plugins add to the HTTP response of an inference request.
+// Plugins run inside the Bifrost client, away from the fasthttp response, so they record headers here
+// and the HTTP layer writes them once the handler returns (before a streamed body starts).
+type ResponseHeaders struct {
+	mu      sync.Mutex
+	headers map[string]string
+}
+
+// Set records a response header, replacing an earlier value.
+func (h *ResponseHeaders) Set(key, value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.headers == nil {
+		h.headers = make(map[string]string)
+	}
+	h.headers[key] = value
+}
+
+// Get returns a recorded response header.
+func (h *ResponseHeaders) Get(key string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.headers[key]
+}
+
+// WriteTo sets the recorded headers on a fasthttp response.
+func (h *ResponseHeaders) WriteTo(header *fasthttp.ResponseHeader) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for key, value := range h.headers {
+		header.Set(key, value)
+	}
+}
@@ -0,0 +1,398 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ResponseTransformRulesConfigKey is the config store key holding the response transform rules.
+const ResponseTransformRulesConfigKey = "response_transform_rules"
+
+// ResponseTransformsAppliedHeader lists the response transform rules applied to a response, in order.
+const ResponseTransformsAppliedHeader = "x-bf-response-transforms"
+
+// RequestPathContextKey holds the path of the HTTP request an inference request came from.
+const RequestPathContextKey ContextKey = "bifrost-request-path"
+
+// ResponseTransformType is a rewrite of the text of responses.
+type ResponseTransformType string
+
+const (
+	ResponseTransformStripMarkdown ResponseTransformType = "strip_markdown" // Remove markdown formatting, keeping the text
+	ResponseTransformMaxLength     ResponseTransformType = "max_length"     // Truncate the response to max_length characters
+	ResponseTransformCitations     ResponseTransformType = "citations"      // Rewrite numbered citations in citation_format
+	ResponseTransformRegexReplace  ResponseTransformType = "regex_replace"  // Apply replacements in order
+)
+
+// Citation formats of the citations transform
+const (
+	CitationFormatBrackets    = "brackets"    // [1]
+	CitationFormatFootnote    = "footnote"    // [^1]
+	CitationFormatParentheses = "parentheses" // (1)
+	CitationFormatSuperscript = "superscript" // ¹
+	CitationFormatRemove      = "remove"      // Citations are dropped
+)
+
+// ResponseTransformRule rewrites the text of responses to requests matching all of its match conditions.
+// Transforms of every matching rule are applied in order, except max_length, which applies to the transformed
+// text. Streamed responses are transformed line by line, so text is held back until its line is complete and
+// patterns cannot span lines.
+type ResponseTransformRule struct {
+	Name       string              `json:"name"`
+	Disabled   bool                `json:"disabled,omitempty"`
+	Match      TransformationMatch `json:"match"`
+	Transforms []ResponseTransform `json:"transforms"`
+}
+
+// ResponseTransform is a single rewrite of the text of responses.
+type ResponseTransform struct {
+	Type           ResponseTransformType `json:"type"`
+	MaxLength      int                   `json:"max_length,omitempty"`      // Characters kept by max_length
+	Suffix         string                `json:"suffix,omitempty"`          // Appended to responses truncated by max_length, e.g. "…"
+	CitationFormat string                `json:"citation_format,omitempty"` // Format citations are rewritten in
+	Replacements   []RegexReplacement    `json:"replacements,omitempty"`    // Replacements of regex_replace
+}
+
+// RegexReplacement replaces the matches of Pattern with Replacement, which may refer to groups as $1 or ${name}.
+type RegexReplacement struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+func (t ResponseTransform) validate() error {
+	switch t.Type {
+	case ResponseTransformStripMarkdown:
+	case ResponseTransformMaxLength:
+		if t.MaxLength <= 0 {
+			return errors.New("max_length must be positive")
+		}
+	case ResponseTransformCitations:
+		switch t.CitationFormat {
+		case CitationFormatBrackets, CitationFormatFootnote, CitationFormatParentheses, CitationFormatSuperscript, CitationFormatRemove:
+		default:
+			return fmt.Errorf("unknown citation_format %q: use brackets, footnote, parentheses, superscript or remove", t.CitationFormat)
+		}
+	case ResponseTransformRegexReplace:
+		if len(t.Replacements) == 0 {
+			return errors.New("at least one replacement is required")
+		}
+		for i, replacement := range t.Replacements {
+			if replacement.Pattern == "" {
+				return fmt.Errorf("replacement %d: pattern is required", i)
+			}
+			if _, err := regexp.Compile(replacement.Pattern); err != nil {
+				return fmt.Errorf("replacement %d: %w", i, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type %q", t.Type)
+	}
+	return nil
+}
+
+// ResponseTransforms are validated response transform rules, with their patterns compiled.
+type ResponseTransforms struct {
+	rules    []ResponseTransformRule
+	patterns map[string]*regexp.Regexp
+}
+
+// CompileResponseTransformRules checks that every rule is named uniquely and that its transforms are well
+// formed, and compiles their patterns.
+func CompileResponseTransformRules(rules []ResponseTransformRule) (*ResponseTransforms, error) {
+	names := make(map[string]struct{}, len(rules))
+	compiled := &ResponseTransforms{rules: rules, patterns: make(map[string]*regexp.Regexp)}
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule %d: name is required", i)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return nil, fmt.Errorf("rule %s: duplicate name", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+		if len(rule.Transforms) == 0 {
+			return nil, fmt.Errorf("rule %s: at least one transform is required", rule.Name)
+		}
+		for j, transform := range rule.Transforms {
+			if err := transform.validate(); err != nil {
+				return nil, fmt.Errorf("rule %s: transform %d: %w", rule.Name, j, err)
+			}
+			for _, replacement := range transform.Replacements {
+				compiled.patterns[replacement.Pattern] = regexp.MustCompile(replacement.Pattern)
+			}
+		}
+	}
+	return compiled, nil
+}
+
+// Rules returns the rules.
+func (t *ResponseTransforms) Rules() []ResponseTransformRule {
+	if t == nil {
+		return nil
+	}
+	return t.rules
+}
+
+// Match returns the transforms of the enabled rules matching a request, or nil when none match.
+func (t *ResponseTransforms) Match(path, virtualKey, provider, model string) *ResponseTransformer {
+	if t == nil {
+		return nil
+	}
+	var transformer *ResponseTransformer
+	for _, rule := range t.rules {
+		if rule.Disabled || !matchesAny(rule.Match.Paths, path) || !matchesAny(rule.Match.VirtualKeys, virtualKey) ||
+			!matchesAny(rule.Match.Providers, provider) || !matchesAny(rule.Match.Models, model) {
+			continue
+		}
+		if transformer == nil {
+			transformer = &ResponseTransformer{patterns: t.patterns}
+		}
+		transformer.Applied = append(transformer.Applied, rule.Name)
+		for _, transform := range rule.Transforms {
+			if transform.Type != ResponseTransformMaxLength {
+				transformer.transforms = append(transformer.transforms, transform)
+			} else if transformer.maxLength == 0 || transform.MaxLength < transformer.maxLength {
+				transformer.maxLength = transform.MaxLength
+				transformer.suffix = transform.Suffix
+			}
+		}
+	}
+	return transformer
+}
+
+// ResponseTransformer applies the transforms of the rules matching a request.
+type ResponseTransformer struct {
+	Applied    []string // Names of the matching rules
+	transforms []ResponseTransform
+	patterns   map[string]*regexp.Regexp
+	maxLength  int // 0 when unlimited
+	suffix     string
+}
+
+// Apply transforms the whole text of a response.
+func (t *ResponseTransformer) Apply(text string) string {
+	stream := t.NewStream()
+	return stream.Write(text) + stream.Flush()
+}
+
+// NewStream starts transforming a streamed response.
+func (t *ResponseTransformer) NewStream() *ResponseStream {
+	return &ResponseStream{transformer: t}
+}
+
+// ResponseStream transforms the text of a streamed response as it arrives. Text is released line by line when
+// there are transforms other than max_length, so the whole response is transformed as Apply would.
+type ResponseStream struct {
+	transformer *ResponseTransformer
+	pending     string // Text of the incomplete line
+	inCode      bool   // Whether the released lines end inside a fenced code block
+	length      int    // Characters released so far
+	truncated   bool
+}
+
+// Write adds text to the stream and returns the transformed text that can be released.
+func (s *ResponseStream) Write(text string) string {
+	if s.truncated {
+		return ""
+	}
+	if len(s.transformer.transforms) == 0 {
+		return s.limit(text)
+	}
+	s.pending += text
+	end := strings.LastIndexByte(s.pending, '\n')
+	if end < 0 {
+		return ""
+	}
+	lines := s.pending[:end+1]
+	s.pending = s.pending[end+1:]
+	return s.limit(s.transform(lines))
+}
+
+// Flush returns the transformed text still held back, at the end of the stream.
+func (s *ResponseStream) Flush() string {
+	if s.truncated || s.pending == "" {
+		return ""
+	}
+	lines := s.pending
+	s.pending = ""
+	return s.limit(s.transform(lines))
+}
+
+// transform applies the transforms to complete lines, in order
+func (s *ResponseStream) transform(lines string) string {
+	for _, transform := range s.transformer.transforms {
+		switch transform.Type {
+		case ResponseTransformStripMarkdown:
+			lines = s.stripMarkdown(lines)
+		case ResponseTransformCitations:
+			lines = formatCitations(lines, transform.CitationFormat)
+		case ResponseTransformRegexReplace:
+			for _, replacement := range transform.Replacements {
+				lines = s.transformer.patterns[replacement.Pattern].ReplaceAllString(lines, replacement.Replacement)
+			}
+		}
+	}
+	return lines
+}
+
+// limit truncates released text to the maximum length, adding the suffix once it is reached
+func (s *ResponseStream) limit(text string) string {
+	maxLength := s.transformer.maxLength
+	if maxLength == 0 {
+		return text
+	}
+	length := utf8.RuneCountInString(text)
+	if s.length+length <= maxLength {
+		s.length += length
+		return text
+	}
+	runes := []rune(text)
+	kept := strings.TrimRightFunc(string(runes[:maxLength-s.length]), func(r rune) bool { return r == ' ' || r == '\t' })
+	s.length = maxLength
+	s.truncated = true
+	return kept + s.transformer.suffix
+}
+
+var (
+	markdownFence         = regexp.MustCompile("^\\s*(```|~~~)")
+	markdownHeading       = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	markdownBlockquote    = regexp.MustCompile(`^\s{0,3}>\s?`)
+	markdownListMarker    = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	markdownRule          = regexp.MustCompile(`^\s{0,3}([-*_])(\s*[-*_]){2,}\s*$`)
+	markdownTableDivider  = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	markdownImage         = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink          = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownBold          = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	markdownItalic        = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	markdownStrikethrough = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	markdownInlineCode    = regexp.MustCompile("`([^`]+)`")
+)
+
+// stripMarkdown removes markdown formatting from complete lines, keeping the content of code blocks verbatim
+func (s *ResponseStream) stripMarkdown(lines string) string {
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(lines, "\n") {
+		if line == "" {
+			continue
+		}
+		text, newline := strings.CutSuffix(line, "\n")
+		if markdownFence.MatchString(text) {
+			s.inCode = !s.inCode
+			continue
+		}
+		if !s.inCode {
+			text = stripMarkdownLine(text)
+		}
+		sb.WriteString(text)
+		if newline {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// stripMarkdownLine removes the markdown formatting of a line outside code blocks
+func stripMarkdownLine(line string) string {
+	if markdownRule.MatchString(line) || markdownTableDivider.MatchString(line) {
+		return ""
+	}
+	line = markdownHeading.ReplaceAllString(line, "")
+	line = markdownBlockquote.ReplaceAllString(line, "")
+	line = markdownListMarker.ReplaceAllString(line, "$1")
+	line = markdownImage.ReplaceAllString(line, "$1")
+	line = markdownLink.ReplaceAllString(line, "$1")
+	line = markdownInlineCode.ReplaceAllString(line, "$1")
+	line = markdownBold.ReplaceAllString(line, "$1$2")
+	line = markdownItalic.ReplaceAllString(line, "$1")
+	return markdownStrikethrough.ReplaceAllString(line, "$1")
+}
+
+// citation matches numbered citations written as [1], [^1] or 【1†source】, with the spaces before them
+var citation = regexp.MustCompile(`([ \t]*)(?:\[\^?(\d+)\]|【(\d+)(?:†[^】]*)?】)`)
+
+// formatCitations rewrites the numbered citations of text in format. Footnote definitions ([^1]: ...) are kept.
+func formatCitations(text, format string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range citation.FindAllStringSubmatchIndex(text, -1) {
+		space := text[loc[2]:loc[3]]
+		if strings.HasPrefix(text[loc[3]:], "[^") && strings.HasPrefix(text[loc[1]:], ":") {
+			continue
+		}
+		number := ""
+		if loc[4] >= 0 {
+			number = text[loc[4]:loc[5]]
+		} else {
+			number = text[loc[6]:loc[7]]
+		}
+		sb.WriteString(text[last:loc[0]])
+		last = loc[1]
+		switch format {
+		case CitationFormatFootnote:
+			sb.WriteString(space + "[^" + number + "]")
+		case CitationFormatParentheses:
+			sb.WriteString(space + "(" + number + ")")
+		case CitationFormatSuperscript:
+			sb.WriteString(superscript(number))
+		case CitationFormatRemove:
+		default:
+			sb.WriteString(space + "[" + number + "]")
+		}
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// superscript writes the digits of number as superscripts
+func superscript(number string) string {
+	digits := []rune("⁰¹²³⁴⁵⁶⁷⁸⁹")
+	var sb strings.Builder
+	for _, digit := range number {
+		sb.WriteRune(digits[digit-'0'])
+	}
+	return sb.String()
+}
+
+// GetResponseTransforms returns the active response transform rules, or nil when there are none.
+func (s *Config) GetResponseTransforms() *ResponseTransforms {
+	return s.responseTransforms.Load()
+}
+
+// UpdateResponseTransformRules validates and activates rules, persisting them in the config store when one is
+// configured.
+func (s *Config) UpdateResponseTransformRules(ctx context.Context, rules []ResponseTransformRule) error {
+	transforms, err := CompileResponseTransformRules(rules)
+	if err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, ResponseTransformRulesConfigKey, rules); err != nil {
+		return fmt.Errorf("failed to save response transform rules: %w", err)
+	}
+	s.responseTransforms.Store(transforms)
+	return nil
+}
+
+// loadResponseTransformRules activates the rules saved in the config store. Without saved rules, the rules from
+// the config file are used and saved to bootstrap the store.
+func (s *Config) loadResponseTransformRules(ctx context.Context, fileRules []ResponseTransformRule) error {
+	var rules []ResponseTransformRule
+	found, err := s.loadStoredConfig(ctx, ResponseTransformRulesConfigKey, &rules)
+	if err != nil {
+		return fmt.Errorf("failed to load response transform rules: %w", err)
+	}
+	if found {
+		transforms, err := CompileResponseTransformRules(rules)
+		if err != nil {
+			return fmt.Errorf("invalid stored response transform rules: %w", err)
+		}
+		s.responseTransforms.Store(transforms)
+		return nil
+	}
+	if len(fileRules) == 0 {
+		return nil
+	}
+	return s.UpdateResponseTransformRules(ctx, fileRules)
+}
@@ -0,0 +1,39 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ZeroDataRetentionHeaderContextKey marks requests opting into zero data retention through the
+// x-bf-zero-data-retention header
+const ZeroDataRetentionHeaderContextKey ContextKey = "x-bf-zero-data-retention"
+
+// ZeroDataRetentionConfig lists the virtual keys and customers whose requests are never persisted: their logs keep
+// metadata (tokens, cost, latency, status) and the retention class only, they are neither recorded nor cached, and
+// providers supporting it (OpenAI, Azure) are asked not to store them.
+type ZeroDataRetentionConfig struct {
+	VirtualKeys []string `json:"virtual_keys,omitempty"` // Virtual key values
+	Customers   []string `json:"customers,omitempty"`    // Billing or governance customers
+}
+
+// Validate checks that the policy applies to someone.
+func (c *ZeroDataRetentionConfig) Validate() error {
+	if len(c.VirtualKeys) == 0 && len(c.Customers) == 0 {
+		return fmt.Errorf("zero_data_retention: virtual_keys or customers is required")
+	}
+	return nil
+}
+
+// Applies reports whether the requests of a virtual key or of any of some customers fall under the policy.
+func (c *ZeroDataRetentionConfig) Applies(virtualKey string, customers ...string) bool {
+	if virtualKey != "" && slices.Contains(c.VirtualKeys, virtualKey) {
+		return true
+	}
+	for _, customer := range customers {
+		if customer != "" && slices.Contains(c.Customers, customer) {
+			return true
+		}
+	}
+	return false
+}
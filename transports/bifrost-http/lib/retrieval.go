@@ -0,0 +1,341 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/ingestion"
+	"github.com/maximhq/bifrost/framework/vectorstore"
+)
+
+const (
+	DefaultRetrievalTopK        = 4
+	DefaultRetrievalTextField   = "text"
+	DefaultRetrievalSourceField = "source"
+	DefaultRetrievalPrompt      = "Answer using the context below when it is relevant. Cite the sources you use by their number, e.g. [1]."
+)
+
+// RetrievalStoreContextKey holds the retrieval store named by the x-bf-retrieval-store header
+const RetrievalStoreContextKey ContextKey = "x-bf-retrieval-store"
+
+// RetrievalOptionsContextKey holds the *RetrievalOptions of a chat request, from its retrieval field
+const RetrievalOptionsContextKey ContextKey = "bifrost-retrieval-options"
+
+// RetrievalRequestContextKey marks the embedding requests of the retriever itself
+const RetrievalRequestContextKey ContextKey = "bifrost-retrieval-request"
+
+// RetrievalConfig enables retrieval-augmented generation: chat requests naming a store get the chunks closest to
+// their last user message injected as context, and /api/retrieval/query searches the stores directly. Documents
+// ingested through /api/retrieval/documents are chunked and embedded into the stores in the background.
+type RetrievalConfig struct {
+	Enabled          bool                     `json:"enabled"`
+	TopK             int                      `json:"top_k,omitempty"`  // Chunks retrieved per request (default: 4)
+	Prompt           string                   `json:"prompt,omitempty"` // Instructions preceding the injected context
+	Stores           []RetrievalStoreConfig   `json:"stores"`
+	Chunking         ingestion.ChunkingConfig `json:"chunking,omitempty"`          // Default chunking of ingested documents
+	IngestionWorkers int                      `json:"ingestion_workers,omitempty"` // Documents indexed concurrently (default: 2)
+}
+
+// RetrievalStoreConfig is a vector store chunks are retrieved from. Queries are embedded through the gateway with
+// EmbeddingModel, which must be the model the chunks were embedded with.
+type RetrievalStoreConfig struct {
+	Name           string             `json:"name"`
+	VectorStore    vectorstore.Config `json:"vector_store"`
+	Namespace      string             `json:"namespace"`              // Collection, table or index namespace holding the chunks
+	EmbeddingModel string             `json:"embedding_model"`        // provider/model
+	Dimension      int                `json:"dimension,omitempty"`    // Creates the namespace at startup when set
+	TextField      string             `json:"text_field,omitempty"`   // Metadata field holding the chunk text (default: text)
+	SourceField    string             `json:"source_field,omitempty"` // Metadata field naming the document of the chunk (default: source)
+	Threshold      float64            `json:"threshold,omitempty"`    // Minimum cosine similarity of retrieved chunks
+}
+
+// fields returns the metadata fields holding the text and the source of chunks.
+func (c RetrievalStoreConfig) fields() (textField, sourceField string) {
+	textField, sourceField = c.TextField, c.SourceField
+	if textField == "" {
+		textField = DefaultRetrievalTextField
+	}
+	if sourceField == "" {
+		sourceField = DefaultRetrievalSourceField
+	}
+	return textField, sourceField
+}
+
+// Validate checks the configuration.
+func (c *RetrievalConfig) Validate() error {
+	if c.TopK < 0 || c.IngestionWorkers < 0 {
+		return fmt.Errorf("retrieval: top_k and ingestion_workers must not be negative")
+	}
+	if err := c.Chunking.Validate(); err != nil {
+		return fmt.Errorf("retrieval: chunking: %w", err)
+	}
+	if len(c.Stores) == 0 {
+		return fmt.Errorf("retrieval: at least one store is required")
+	}
+	names := make(map[string]struct{}, len(c.Stores))
+	for i, store := range c.Stores {
+		if store.Name == "" {
+			return fmt.Errorf("retrieval: store %d: name is required", i)
+		}
+		if _, ok := names[store.Name]; ok {
+			return fmt.Errorf("retrieval: store %s: duplicate name", store.Name)
+		}
+		names[store.Name] = struct{}{}
+		if store.Namespace == "" {
+			return fmt.Errorf("retrieval: store %s: namespace is required", store.Name)
+		}
+		if provider, model := schemas.ParseModelString(store.EmbeddingModel, ""); provider == "" || model == "" {
+			return fmt.Errorf("retrieval: store %s: embedding_model must be provider/model", store.Name)
+		}
+		if store.Threshold < 0 || store.Threshold > 1 {
+			return fmt.Errorf("retrieval: store %s: threshold must be between 0 and 1", store.Name)
+		}
+	}
+	return nil
+}
+
+// RetrievalOptions selects the store and chunks of a retrieval. Filters match chunk metadata: lists match any
+// of their values, other values must be equal.
+type RetrievalOptions struct {
+	Store     string         `json:"store"`
+	TopK      *int           `json:"top_k,omitempty"`
+	Threshold *float64       `json:"threshold,omitempty"`
+	Filters   map[string]any `json:"filters,omitempty"`
+}
+
+// RetrievalError is a retrieval that failed, with the HTTP status to report.
+type RetrievalError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RetrievalError) Error() string {
+	return e.Message
+}
+
+// IsRetrievalError reports whether err is a failed retrieval, and returns it.
+func IsRetrievalError(err error) (*RetrievalError, bool) {
+	var retrievalErr *RetrievalError
+	ok := errors.As(err, &retrievalErr)
+	return retrievalErr, ok
+}
+
+// RetrievalStoreInfo describes a configured store.
+type RetrievalStoreInfo struct {
+	Name           string                      `json:"name"`
+	Type           vectorstore.VectorStoreType `json:"type"`
+	Namespace      string                      `json:"namespace"`
+	EmbeddingModel string                      `json:"embedding_model"`
+}
+
+// Retriever searches the configured stores for the chunks closest to a query.
+type Retriever struct {
+	config  RetrievalConfig
+	stores  map[string]vectorstore.VectorStore
+	gateway *Config
+}
+
+// NewRetriever creates a retriever over stores, keyed by store name, embedding queries through gateway.
+func NewRetriever(config RetrievalConfig, stores map[string]vectorstore.VectorStore, gateway *Config) *Retriever {
+	return &Retriever{config: config, stores: stores, gateway: gateway}
+}
+
+// Stores returns the configured stores.
+func (r *Retriever) Stores() []RetrievalStoreInfo {
+	infos := make([]RetrievalStoreInfo, 0, len(r.config.Stores))
+	for _, store := range r.config.Stores {
+		infos = append(infos, RetrievalStoreInfo{
+			Name:           store.Name,
+			Type:           store.VectorStore.Type,
+			Namespace:      store.Namespace,
+			EmbeddingModel: store.EmbeddingModel,
+		})
+	}
+	return infos
+}
+
+// Prompt returns the instructions preceding the injected context.
+func (r *Retriever) Prompt() string {
+	if r.config.Prompt != "" {
+		return r.config.Prompt
+	}
+	return DefaultRetrievalPrompt
+}
+
+// storeConfig returns the configuration of the named store.
+func (r *Retriever) storeConfig(name string) (RetrievalStoreConfig, bool) {
+	for _, store := range r.config.Stores {
+		if store.Name == name {
+			return store, true
+		}
+	}
+	return RetrievalStoreConfig{}, false
+}
+
+// Retrieve returns the chunks of the store named in options closest to query, most similar first.
+func (r *Retriever) Retrieve(ctx context.Context, options RetrievalOptions, query string) ([]schemas.BifrostSource, error) {
+	config, ok := r.storeConfig(options.Store)
+	store := r.stores[options.Store]
+	if !ok || store == nil {
+		return nil, &RetrievalError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("unknown retrieval store %q", options.Store)}
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, &RetrievalError{StatusCode: http.StatusBadRequest, Message: "retrieval query is empty"}
+	}
+	topK := r.config.TopK
+	if options.TopK != nil {
+		topK = *options.TopK
+	}
+	if topK <= 0 {
+		topK = DefaultRetrievalTopK
+	}
+	threshold := config.Threshold
+	if options.Threshold != nil {
+		threshold = *options.Threshold
+	}
+
+	embeddings, err := r.embed(ctx, config.EmbeddingModel, []string{query})
+	if err != nil {
+		return nil, &RetrievalError{StatusCode: http.StatusBadGateway, Message: fmt.Sprintf("failed to embed retrieval query: %v", err)}
+	}
+	results, err := store.GetNearest(ctx, config.Namespace, embeddings[0], retrievalQueries(options.Filters), nil, threshold, int64(topK))
+	if err != nil {
+		return nil, &RetrievalError{StatusCode: http.StatusBadGateway, Message: fmt.Sprintf("failed to search retrieval store %s: %v", config.Name, err)}
+	}
+
+	textField, sourceField := config.fields()
+	sources := make([]schemas.BifrostSource, 0, len(results))
+	for _, result := range results {
+		source := schemas.BifrostSource{ID: result.ID, Store: config.Name, Score: result.Score}
+		metadata := make(map[string]interface{}, len(result.Properties))
+		for key, value := range result.Properties {
+			switch key {
+			case textField:
+				source.Text = fmt.Sprint(value)
+			case sourceField:
+				source.Source = fmt.Sprint(value)
+			default:
+				metadata[key] = value
+			}
+		}
+		if len(metadata) > 0 {
+			source.Metadata = metadata
+		}
+		sources = append(sources, source)
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Score != nil && (sources[j].Score == nil || *sources[i].Score > *sources[j].Score)
+	})
+	return sources, nil
+}
+
+// embed computes the embeddings of texts through the gateway.
+func (r *Retriever) embed(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	client := r.gateway.GetBifrostClient()
+	if client == nil {
+		return nil, fmt.Errorf("bifrost client is not initialized")
+	}
+	provider, modelName := schemas.ParseModelString(model, "")
+	resp, bifrostErr := client.EmbeddingRequest(context.WithValue(ctx, RetrievalRequestContextKey, true), &schemas.BifrostEmbeddingRequest{
+		Provider: schemas.ModelProvider(provider),
+		Model:    modelName,
+		Input:    &schemas.EmbeddingInput{Texts: texts},
+	})
+	if bifrostErr != nil {
+		if bifrostErr.Error != nil {
+			return nil, fmt.Errorf("%s", bifrostErr.Error.Message)
+		}
+		return nil, fmt.Errorf("embedding request failed")
+	}
+	embeddings := make([][]float32, len(texts))
+	for _, data := range resp.Data {
+		if data.Index < 0 || data.Index >= len(embeddings) || data.Embedding.EmbeddingArray == nil {
+			return nil, fmt.Errorf("embedding response has no float embedding for index %d", data.Index)
+		}
+		embeddings[data.Index] = data.Embedding.EmbeddingArray
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("embedding response has no embedding for index %d", i)
+		}
+	}
+	return embeddings, nil
+}
+
+// retrievalQueries converts metadata filters to vector store queries, in field order.
+func retrievalQueries(filters map[string]any) []vectorstore.Query {
+	fields := make([]string, 0, len(filters))
+	for field := range filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	queries := make([]vectorstore.Query, 0, len(fields))
+	for _, field := range fields {
+		value := filters[field]
+		operator := vectorstore.QueryOperatorEqual
+		if _, ok := value.([]any); ok {
+			operator = vectorstore.QueryOperatorContainsAny
+		}
+		queries = append(queries, vectorstore.Query{Field: field, Operator: operator, Value: value})
+	}
+	return queries
+}
+
+// RetrievalContextMessage formats sources as the system message carrying the retrieved context. Sources are
+// numbered in order, so replies can cite them.
+func RetrievalContextMessage(prompt string, sources []schemas.BifrostSource) schemas.ChatMessage {
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	for i, source := range sources {
+		fmt.Fprintf(&sb, "\n\n[%d]", i+1)
+		if source.Source != "" {
+			fmt.Fprintf(&sb, " (source: %s)", source.Source)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(source.Text)
+	}
+	content := sb.String()
+	return schemas.ChatMessage{Role: schemas.ChatMessageRoleSystem, Content: &schemas.ChatMessageContent{ContentStr: &content}}
+}
+
+// LastUserMessageText returns the text of the last user message of messages.
+func LastUserMessageText(messages []schemas.ChatMessage) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == schemas.ChatMessageRoleUser {
+			return ChatMessageText(messages[i])
+		}
+	}
+	return ""
+}
+
+// initRetrieval connects to the retrieval stores, creating their namespaces when a dimension is configured.
+func (s *Config) initRetrieval(ctx context.Context, config *RetrievalConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	stores := make(map[string]vectorstore.VectorStore, len(config.Stores))
+	for _, storeConfig := range config.Stores {
+		vectorStoreConfig := storeConfig.VectorStore
+		vectorStoreConfig.Enabled = true
+		store, err := vectorstore.NewVectorStore(ctx, &vectorStoreConfig, logger)
+		if err != nil {
+			return fmt.Errorf("failed to connect to retrieval store %s: %w", storeConfig.Name, err)
+		}
+		if storeConfig.Dimension > 0 {
+			if err := store.CreateNamespace(ctx, storeConfig.Namespace, storeConfig.Dimension, nil); err != nil {
+				return fmt.Errorf("failed to create namespace of retrieval store %s: %w", storeConfig.Name, err)
+			}
+		}
+		stores[storeConfig.Name] = store
+	}
+	s.Retrieval = NewRetriever(*config, stores, s)
+	return s.initIngestion(ctx, config)
+}
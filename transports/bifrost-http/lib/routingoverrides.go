@@ -0,0 +1,210 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// RoutingOverridesConfigKey is the config store key holding the routing override policies.
+const RoutingOverridesConfigKey = "routing_overrides"
+
+// Routing override headers. Callers allowed by a policy send them to pin the provider serving a request or to
+// replace its fallbacks.
+const (
+	ProviderOverrideHeader  = "x-bifrost-provider"  // Provider ("anthropic") or provider and model ("anthropic/claude-3-5-sonnet") serving the request
+	FallbacksOverrideHeader = "x-bifrost-fallbacks" // Comma-separated providers or provider/models tried in order, or "none"
+)
+
+// ErrRoutingOverrideForbidden is returned when a request overrides its routing without a policy allowing it.
+var ErrRoutingOverrideForbidden = errors.New("routing override not allowed")
+
+// RoutingOverridesConfig gates the routing override headers. Requests sending them are rejected unless the
+// first policy of their virtual key allows the override.
+type RoutingOverridesConfig struct {
+	Enabled  bool                    `json:"enabled"`
+	Policies []RoutingOverridePolicy `json:"policies,omitempty"`
+}
+
+// RoutingOverridePolicy allows the callers of some virtual keys to override the routing of their requests.
+type RoutingOverridePolicy struct {
+	Name           string   `json:"name"`
+	VirtualKeys    []string `json:"virtual_keys"`              // Virtual key values sent in the x-bf-vk header; patterns ending in * match by prefix
+	AllowProvider  bool     `json:"allow_provider,omitempty"`  // Allow pinning the provider with x-bifrost-provider
+	AllowFallbacks bool     `json:"allow_fallbacks,omitempty"` // Allow replacing the fallbacks with x-bifrost-fallbacks
+	Providers      []string `json:"providers,omitempty"`       // Providers the headers may route to (default: any)
+}
+
+// Validate checks that the policies are named uniquely, apply to virtual keys and allow an override.
+func (c *RoutingOverridesConfig) Validate() error {
+	names := make(map[string]struct{}, len(c.Policies))
+	for i, policy := range c.Policies {
+		if policy.Name == "" {
+			return fmt.Errorf("policy %d: name is required", i)
+		}
+		if _, ok := names[policy.Name]; ok {
+			return fmt.Errorf("policy %s: duplicate name", policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+		if len(policy.VirtualKeys) == 0 {
+			return fmt.Errorf("policy %s: at least one virtual key is required", policy.Name)
+		}
+		if !policy.AllowProvider && !policy.AllowFallbacks {
+			return fmt.Errorf("policy %s: allow_provider or allow_fallbacks is required", policy.Name)
+		}
+		if slices.Contains(policy.Providers, "") {
+			return fmt.Errorf("policy %s: providers cannot be empty", policy.Name)
+		}
+	}
+	return nil
+}
+
+// Policy returns the first policy of a virtual key, or nil.
+func (c *RoutingOverridesConfig) Policy(virtualKey string) *RoutingOverridePolicy {
+	if virtualKey == "" {
+		return nil
+	}
+	for i := range c.Policies {
+		if matchesAny(c.Policies[i].VirtualKeys, virtualKey) {
+			return &c.Policies[i]
+		}
+	}
+	return nil
+}
+
+// Apply applies the routing override headers of a request to its JSON body, whose model and fallbacks are in
+// provider/model format. A pinned provider serves the request with the first of its models among the requested
+// model and fallbacks, or else the requested model; the other candidates remain fallbacks unless replaced.
+// It reports whether the body changed, and returns an error wrapping ErrRoutingOverrideForbidden when the
+// policy of the virtual key does not allow the override.
+func (c *RoutingOverridesConfig) Apply(body map[string]any, virtualKey string, provider string, fallbacks string) (bool, error) {
+	if provider == "" && fallbacks == "" {
+		return false, nil
+	}
+	policy := c.Policy(virtualKey)
+	switch {
+	case policy == nil:
+		return false, fmt.Errorf("%w: no policy for the virtual key", ErrRoutingOverrideForbidden)
+	case provider != "" && !policy.AllowProvider:
+		return false, fmt.Errorf("%w: policy %s does not allow pinning the provider", ErrRoutingOverrideForbidden, policy.Name)
+	case fallbacks != "" && !policy.AllowFallbacks:
+		return false, fmt.Errorf("%w: policy %s does not allow overriding fallbacks", ErrRoutingOverrideForbidden, policy.Name)
+	}
+
+	model, _ := body["model"].(string)
+	if requested, _, ok := strings.Cut(model, "/"); !ok || requested == "" {
+		return false, fmt.Errorf("model must be in provider/model format to override its routing")
+	}
+	candidates := []string{model}
+	if values, ok := body["fallbacks"].([]any); ok {
+		for _, value := range values {
+			if fallback, ok := value.(string); ok {
+				candidates = append(candidates, fallback)
+			}
+		}
+	}
+
+	primary := model
+	if provider != "" {
+		target, err := policy.resolve(strings.TrimSpace(provider), candidates)
+		if err != nil {
+			return false, err
+		}
+		primary = target
+	}
+	var routed []string
+	if fallbacks == "" {
+		for _, candidate := range candidates {
+			if candidate != primary && !slices.Contains(routed, candidate) {
+				routed = append(routed, candidate)
+			}
+		}
+	} else if !strings.EqualFold(strings.TrimSpace(fallbacks), "none") {
+		for _, entry := range strings.Split(fallbacks, ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			target, err := policy.resolve(entry, candidates)
+			if err != nil {
+				return false, err
+			}
+			if target != primary && !slices.Contains(routed, target) {
+				routed = append(routed, target)
+			}
+		}
+	}
+
+	body["model"] = primary
+	routedFallbacks := make([]any, len(routed))
+	for i, fallback := range routed {
+		routedFallbacks[i] = fallback
+	}
+	if len(routedFallbacks) > 0 {
+		body["fallbacks"] = routedFallbacks
+	} else {
+		delete(body, "fallbacks")
+	}
+	return true, nil
+}
+
+// resolve returns the provider/model an override entry routes to: the entry itself when it names a model, else the
+// first candidate of its provider, else the model of the first candidate on that provider.
+func (p *RoutingOverridePolicy) resolve(entry string, candidates []string) (string, error) {
+	provider, model, hasModel := strings.Cut(entry, "/")
+	if provider == "" || (hasModel && model == "") {
+		return "", fmt.Errorf("invalid routing override %q: expected a provider or provider/model", entry)
+	}
+	if !matchesAny(p.Providers, provider) {
+		return "", fmt.Errorf("%w: policy %s does not allow routing to %s", ErrRoutingOverrideForbidden, p.Name, provider)
+	}
+	if hasModel {
+		return entry, nil
+	}
+	for _, candidate := range candidates {
+		if candidateProvider, _, _ := strings.Cut(candidate, "/"); candidateProvider == provider {
+			return candidate, nil
+		}
+	}
+	_, model = schemas.ParseModelString(candidates[0], "")
+	return provider + "/" + model, nil
+}
+
+// GetRoutingOverrides returns the active routing override policies, or nil when none is configured.
+func (s *Config) GetRoutingOverrides() *RoutingOverridesConfig {
+	return s.routingOverrides.Load()
+}
+
+// UpdateRoutingOverrides validates and activates routing override policies, persisting them in the config store
+// when one is configured.
+func (s *Config) UpdateRoutingOverrides(ctx context.Context, config RoutingOverridesConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, RoutingOverridesConfigKey, config); err != nil {
+		return fmt.Errorf("failed to save routing overrides: %w", err)
+	}
+	s.routingOverrides.Store(&config)
+	return nil
+}
+
+// loadRoutingOverrides activates the routing override policies saved in the config store. Without saved policies,
+// the ones from the config file are used and saved to bootstrap the store.
+func (s *Config) loadRoutingOverrides(ctx context.Context, fileConfig *RoutingOverridesConfig) error {
+	var config RoutingOverridesConfig
+	found, err := s.loadStoredConfig(ctx, RoutingOverridesConfigKey, &config)
+	if err != nil {
+		return fmt.Errorf("failed to load routing overrides: %w", err)
+	}
+	if found {
+		s.routingOverrides.Store(&config)
+		return nil
+	}
+	if fileConfig == nil {
+		return nil
+	}
+	return s.UpdateRoutingOverrides(ctx, *fileConfig)
+}
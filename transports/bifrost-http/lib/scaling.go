@@ -0,0 +1,272 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the scaling config, applied to unset values.
+const (
+	DefaultScalingMinReplicas             = 1
+	DefaultScalingMaxReplicas             = 10
+	DefaultScalingTargetQueueDepth        = 50
+	DefaultScalingTargetInFlightStreams   = 200
+	DefaultScalingTargetCPUUtilization    = 0.7
+	DefaultScalingTargetMemoryUtilization = 0.8
+)
+
+// Names of the signals a scaling recommendation is computed from.
+const (
+	ScalingSignalQueueDepth      = "queue_depth"
+	ScalingSignalInFlightStreams = "in_flight_streams"
+	ScalingSignalCPU             = "cpu"
+	ScalingSignalMemory          = "memory"
+)
+
+// cpuSampleWindow is the shortest window the CPU utilization is measured over. Scrapes closer to the last sample
+// report the utilization of the previous window.
+const cpuSampleWindow = time.Second
+
+// userHZ is the clock tick rate of the CPU times in /proc, fixed at 100 on Linux.
+const userHZ = 100
+
+// ScalingConfig represents the per-replica targets autoscaling holds each signal at. A replica above a target
+// asks for more replicas in proportion, and the most loaded signal decides.
+type ScalingConfig struct {
+	MinReplicas             int     `json:"min_replicas,omitempty"`              // Fewest replicas recommended (default: 1)
+	MaxReplicas             int     `json:"max_replicas,omitempty"`              // Most replicas recommended (default: 10)
+	TargetQueueDepth        int     `json:"target_queue_depth,omitempty"`        // Requests waiting in provider queues per replica (default: 50)
+	TargetInFlightStreams   int     `json:"target_in_flight_streams,omitempty"`  // Streams being written per replica (default: 200)
+	TargetCPUUtilization    float64 `json:"target_cpu_utilization,omitempty"`    // Share of the CPU available to a replica, from 0 to 1 (default: 0.7)
+	TargetMemoryUtilization float64 `json:"target_memory_utilization,omitempty"` // Share of the memory limit of a replica, from 0 to 1 (default: 0.8)
+	MemoryLimitBytes        int64   `json:"memory_limit_bytes,omitempty"`        // Memory available to a replica (default: GOMEMLIMIT, then the cgroup limit)
+}
+
+// Validate checks the replica bounds and the targets.
+func (c *ScalingConfig) Validate() error {
+	if c.MinReplicas < 0 || c.MaxReplicas < 0 {
+		return fmt.Errorf("scaling: min_replicas and max_replicas must not be negative")
+	}
+	if c.MinReplicas > 0 && c.MaxReplicas > 0 && c.MinReplicas > c.MaxReplicas {
+		return fmt.Errorf("scaling: min_replicas (%d) must not exceed max_replicas (%d)", c.MinReplicas, c.MaxReplicas)
+	}
+	if c.TargetQueueDepth < 0 || c.TargetInFlightStreams < 0 || c.MemoryLimitBytes < 0 {
+		return fmt.Errorf("scaling: targets and memory_limit_bytes must not be negative")
+	}
+	if c.TargetCPUUtilization < 0 || c.TargetCPUUtilization > 1 || c.TargetMemoryUtilization < 0 || c.TargetMemoryUtilization > 1 {
+		return fmt.Errorf("scaling: target_cpu_utilization and target_memory_utilization must be between 0 and 1")
+	}
+	return nil
+}
+
+// ScalingLoad is the load of a replica at one point in time.
+type ScalingLoad struct {
+	QueueDepth       int      // Requests waiting in provider queues
+	InFlightStreams  int64    // Streams being written to their client
+	CPUUtilization   *float64 // Share of the CPU available to the replica used since the last sample, nil when unknown
+	MemoryBytes      uint64   // Memory obtained from the OS and not returned to it
+	MemoryLimitBytes uint64   // Memory available to the replica, 0 when unknown
+}
+
+// ScalingSignal is one signal of a scaling recommendation.
+type ScalingSignal struct {
+	Value       float64 `json:"value"`
+	Target      float64 `json:"target"`
+	Utilization float64 `json:"utilization"` // Value over target; above 1 the replica is over its target
+}
+
+// ScalingRecommendation is the replica count recommended for the load of a replica. Utilization is the ratio
+// Kubernetes scales on: an HPA, or a KEDA metrics-api trigger, holding it at a target of 1 converges to
+// DesiredReplicas.
+type ScalingRecommendation struct {
+	DesiredReplicas int                      `json:"desired_replicas"`
+	CurrentReplicas int                      `json:"current_replicas"`
+	MinReplicas     int                      `json:"min_replicas"`
+	MaxReplicas     int                      `json:"max_replicas"`
+	Utilization     float64                  `json:"utilization"`               // Highest utilization of the signals
+	LimitingSignal  string                   `json:"limiting_signal,omitempty"` // Signal with the highest utilization
+	Signals         map[string]ScalingSignal `json:"signals"`                   // Signals that could be measured, by name
+}
+
+// Scaler measures the load of the replica and recommends replica counts from it.
+type Scaler struct {
+	config ScalingConfig
+
+	mu         sync.Mutex
+	cpuAt      time.Time
+	cpuSeconds float64
+	cpuUsage   *float64
+}
+
+// NewScaler creates a scaler, applying defaults to unset config values, and takes the first CPU sample so that
+// the first recommendation has a window to measure.
+func NewScaler(config ScalingConfig) *Scaler {
+	if config.MinReplicas <= 0 {
+		config.MinReplicas = DefaultScalingMinReplicas
+	}
+	if config.MaxReplicas <= 0 {
+		config.MaxReplicas = max(DefaultScalingMaxReplicas, config.MinReplicas)
+	}
+	if config.TargetQueueDepth <= 0 {
+		config.TargetQueueDepth = DefaultScalingTargetQueueDepth
+	}
+	if config.TargetInFlightStreams <= 0 {
+		config.TargetInFlightStreams = DefaultScalingTargetInFlightStreams
+	}
+	if config.TargetCPUUtilization <= 0 {
+		config.TargetCPUUtilization = DefaultScalingTargetCPUUtilization
+	}
+	if config.TargetMemoryUtilization <= 0 {
+		config.TargetMemoryUtilization = DefaultScalingTargetMemoryUtilization
+	}
+	scaler := &Scaler{config: config}
+	scaler.sampleCPU(time.Now())
+	return scaler
+}
+
+// Load measures the load of the replica, given the requests waiting in provider queues.
+func (s *Scaler) Load(queueDepth int, now time.Time) ScalingLoad {
+	load := ScalingLoad{
+		QueueDepth:      queueDepth,
+		InFlightStreams: InFlightStreams(),
+		CPUUtilization:  s.sampleCPU(now),
+		MemoryBytes:     processMemoryBytes(),
+	}
+	if s.config.MemoryLimitBytes > 0 {
+		load.MemoryLimitBytes = uint64(s.config.MemoryLimitBytes)
+	} else {
+		load.MemoryLimitBytes = memoryLimitBytes()
+	}
+	return load
+}
+
+// Recommend computes the replica count for load, assuming currentReplicas replicas share the traffic evenly.
+// CPU and memory are left out when they cannot be measured.
+func (s *Scaler) Recommend(load ScalingLoad, currentReplicas int) ScalingRecommendation {
+	currentReplicas = max(currentReplicas, 1)
+	recommendation := ScalingRecommendation{
+		CurrentReplicas: currentReplicas,
+		MinReplicas:     s.config.MinReplicas,
+		MaxReplicas:     s.config.MaxReplicas,
+		Signals:         make(map[string]ScalingSignal),
+	}
+	signal := func(name string, value, target float64) {
+		utilization := value / target
+		recommendation.Signals[name] = ScalingSignal{Value: value, Target: target, Utilization: utilization}
+		if utilization > recommendation.Utilization {
+			recommendation.Utilization = utilization
+			recommendation.LimitingSignal = name
+		}
+	}
+	signal(ScalingSignalQueueDepth, float64(load.QueueDepth), float64(s.config.TargetQueueDepth))
+	signal(ScalingSignalInFlightStreams, float64(load.InFlightStreams), float64(s.config.TargetInFlightStreams))
+	if load.CPUUtilization != nil {
+		signal(ScalingSignalCPU, *load.CPUUtilization, s.config.TargetCPUUtilization)
+	}
+	if load.MemoryLimitBytes > 0 {
+		signal(ScalingSignalMemory, float64(load.MemoryBytes)/float64(load.MemoryLimitBytes), s.config.TargetMemoryUtilization)
+	}
+
+	// The epsilon keeps float error from adding a replica when the load is exactly on target
+	desired := int(math.Ceil(float64(currentReplicas)*recommendation.Utilization - 1e-9))
+	recommendation.DesiredReplicas = min(max(desired, s.config.MinReplicas), s.config.MaxReplicas)
+	return recommendation
+}
+
+// sampleCPU returns the share of the CPU available to the process it used since the previous sample, or nil
+// when the CPU time of the process cannot be read.
+func (s *Scaler) sampleCPU(now time.Time) *float64 {
+	seconds, ok := processCPUSeconds()
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed := now.Sub(s.cpuAt)
+	if s.cpuAt.IsZero() || elapsed < cpuSampleWindow {
+		if s.cpuAt.IsZero() {
+			s.cpuAt, s.cpuSeconds = now, seconds
+		}
+		return s.cpuUsage
+	}
+	usage := max(seconds-s.cpuSeconds, 0) / (elapsed.Seconds() * cpuCapacity())
+	s.cpuAt, s.cpuSeconds, s.cpuUsage = now, seconds, &usage
+	return s.cpuUsage
+}
+
+// processCPUSeconds returns the user and system CPU time of the process from /proc, which only Linux has.
+func processCPUSeconds() (float64, bool) {
+	stat, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, false
+	}
+	// The command name may contain spaces, the fields are counted from the parenthesis closing it
+	end := strings.LastIndexByte(string(stat), ')')
+	if end < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err := strconv.ParseFloat(fields[11], 64)
+	if err != nil {
+		return 0, false
+	}
+	stime, err := strconv.ParseFloat(fields[12], 64)
+	if err != nil {
+		return 0, false
+	}
+	return (utime + stime) / userHZ, true
+}
+
+// cpuCapacity returns the CPUs available to the process: GOMAXPROCS, lowered to the cgroup CPU quota if any.
+func cpuCapacity() float64 {
+	capacity := float64(runtime.GOMAXPROCS(0))
+	if cpuMax, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		if fields := strings.Fields(string(cpuMax)); len(fields) == 2 && fields[0] != "max" {
+			quota, quotaErr := strconv.ParseFloat(fields[0], 64)
+			period, periodErr := strconv.ParseFloat(fields[1], 64)
+			if quotaErr == nil && periodErr == nil && quota > 0 && period > 0 {
+				capacity = min(capacity, quota/period)
+			}
+		}
+	}
+	return capacity
+}
+
+// processMemoryBytes returns the memory the Go runtime obtained from the OS and did not release to it, which is
+// close to the resident set of the process and read without stopping the world.
+func processMemoryBytes() uint64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() != metrics.KindUint64 || samples[1].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
+
+// memoryLimitBytes returns the soft memory limit of the runtime (GOMEMLIMIT), else the cgroup memory limit, or 0
+// when there is neither.
+func memoryLimitBytes() uint64 {
+	if limit := debug.SetMemoryLimit(-1); limit > 0 && limit < math.MaxInt64 {
+		return uint64(limit)
+	}
+	if memoryMax, err := os.ReadFile("/sys/fs/cgroup/memory.max"); err == nil {
+		if limit, err := strconv.ParseUint(strings.TrimSpace(string(memoryMax)), 10, 64); err == nil {
+			return limit
+		}
+	}
+	return 0
+}
@@ -0,0 +1,518 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// ClientIPContextKey holds the address of the client of an inference request
+const ClientIPContextKey ContextKey = "bifrost-client-ip"
+
+// Categories of security events
+const (
+	SecurityEventCategoryAuth   = "auth"   // Logins, logouts and rejected credentials
+	SecurityEventCategoryAdmin  = "admin"  // Changes made through the management API
+	SecurityEventCategoryPolicy = "policy" // Inference requests rejected by governance, moderation or data residency
+)
+
+// Formats of security event sinks
+const (
+	SecurityEventFormatCEF       = "cef"        // ArcSight Common Event Format (syslog)
+	SecurityEventFormatJSON      = "json"       // One JSON object per syslog message, or a JSON array per HTTP batch
+	SecurityEventFormatSplunkHEC = "splunk_hec" // Splunk HTTP Event Collector envelopes (HTTP)
+)
+
+const (
+	DefaultSecurityEventsBufferSize    = 10000
+	DefaultSecurityEventsBatchSize     = 100
+	DefaultSecurityEventsFlushInterval = 5 // Seconds
+)
+
+// SecurityEventsConfig configures the export of security events to a SIEM over syslog and/or HTTPS.
+type SecurityEventsConfig struct {
+	Enabled    bool                    `json:"enabled"`
+	Categories []string                `json:"categories,omitempty"`  // auth, admin and/or policy (default: all)
+	BufferSize int                     `json:"buffer_size,omitempty"` // Events queued before new ones are dropped (default: 10000)
+	Syslog     *SecuritySyslogConfig   `json:"syslog,omitempty"`
+	HTTP       *SecurityHTTPSinkConfig `json:"http,omitempty"`
+}
+
+// SecuritySyslogConfig sends events as RFC 5424 syslog messages.
+type SecuritySyslogConfig struct {
+	Address string `json:"address"`            // udp://host:514, tcp://host:514 or tls://host:6514
+	Format  string `json:"format,omitempty"`   // cef (default) or json
+	AppName string `json:"app_name,omitempty"` // Syslog APP-NAME (default: bifrost)
+}
+
+// SecurityHTTPSinkConfig posts batches of events to an HTTPS collector.
+type SecurityHTTPSinkConfig struct {
+	URL                  string            `json:"url"`
+	Format               string            `json:"format,omitempty"`                 // json (default) or splunk_hec
+	Headers              map[string]string `json:"headers,omitempty"`                // e.g. Authorization; values may reference env.VAR_NAME
+	BatchSize            int               `json:"batch_size,omitempty"`             // Events per request (default: 100)
+	FlushIntervalSeconds int               `json:"flush_interval_seconds,omitempty"` // Longest wait before a partial batch is sent (default: 5)
+}
+
+// SecurityEvent is an auth event, admin action or policy violation.
+type SecurityEvent struct {
+	Time     time.Time         `json:"time"`
+	Category string            `json:"category"`
+	Type     string            `json:"type"`                // e.g. login_failed, admin_action, budget_exceeded
+	Outcome  string            `json:"outcome"`             // success or failure
+	Severity int               `json:"severity"`            // 0 (lowest) to 10, as in CEF
+	Actor    string            `json:"actor,omitempty"`     // admin, service_account:<name>, virtual_key:<id> or anonymous
+	SourceIP string            `json:"source_ip,omitempty"` // Client address
+	Method   string            `json:"method,omitempty"`
+	Path     string            `json:"path,omitempty"`
+	Status   int               `json:"status,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Details  map[string]string `json:"details,omitempty"`
+}
+
+// Validate checks the categories and sinks.
+func (c *SecurityEventsConfig) Validate() error {
+	for _, category := range c.Categories {
+		switch category {
+		case SecurityEventCategoryAuth, SecurityEventCategoryAdmin, SecurityEventCategoryPolicy:
+		default:
+			return fmt.Errorf("security_events: invalid category %q, expected auth, admin or policy", category)
+		}
+	}
+	if c.Syslog == nil && c.HTTP == nil {
+		return fmt.Errorf("security_events: a syslog or http sink is required")
+	}
+	if c.Syslog != nil {
+		u, err := url.Parse(c.Syslog.Address)
+		if err != nil || u.Host == "" || (u.Scheme != "udp" && u.Scheme != "tcp" && u.Scheme != "tls") {
+			return fmt.Errorf("security_events: invalid syslog address %q, expected udp://, tcp:// or tls://host:port", c.Syslog.Address)
+		}
+		if c.Syslog.Format != "" && c.Syslog.Format != SecurityEventFormatCEF && c.Syslog.Format != SecurityEventFormatJSON {
+			return fmt.Errorf("security_events: invalid syslog format %q, expected cef or json", c.Syslog.Format)
+		}
+	}
+	if c.HTTP != nil {
+		u, err := url.Parse(c.HTTP.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return fmt.Errorf("security_events: invalid http url %q", c.HTTP.URL)
+		}
+		if c.HTTP.Format != "" && c.HTTP.Format != SecurityEventFormatJSON && c.HTTP.Format != SecurityEventFormatSplunkHEC {
+			return fmt.Errorf("security_events: invalid http format %q, expected json or splunk_hec", c.HTTP.Format)
+		}
+		if c.HTTP.BatchSize < 0 || c.HTTP.FlushIntervalSeconds < 0 {
+			return fmt.Errorf("security_events: batch_size and flush_interval_seconds cannot be negative")
+		}
+	}
+	return nil
+}
+
+// SecurityEventExporter queues security events and ships them to the configured sinks in the background.
+// Emitting never blocks: events are dropped when the queue is full, and counted.
+type SecurityEventExporter struct {
+	config     SecurityEventsConfig
+	categories map[string]bool
+	events     chan SecurityEvent
+	dropped    atomic.Int64
+	client     *http.Client
+	hostname   string
+	version    string
+
+	mu     sync.Mutex
+	syslog net.Conn // Reconnected on write failures
+	done   chan struct{}
+}
+
+// NewSecurityEventExporter creates an exporter, applying defaults to unset config values.
+func NewSecurityEventExporter(config SecurityEventsConfig) *SecurityEventExporter {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultSecurityEventsBufferSize
+	}
+	if config.Syslog != nil {
+		syslog := *config.Syslog
+		if syslog.Format == "" {
+			syslog.Format = SecurityEventFormatCEF
+		}
+		if syslog.AppName == "" {
+			syslog.AppName = "bifrost"
+		}
+		config.Syslog = &syslog
+	}
+	if config.HTTP != nil {
+		sink := *config.HTTP
+		if sink.Format == "" {
+			sink.Format = SecurityEventFormatJSON
+		}
+		if sink.BatchSize <= 0 {
+			sink.BatchSize = DefaultSecurityEventsBatchSize
+		}
+		if sink.FlushIntervalSeconds <= 0 {
+			sink.FlushIntervalSeconds = DefaultSecurityEventsFlushInterval
+		}
+		config.HTTP = &sink
+	}
+	categories := map[string]bool{}
+	for _, category := range config.Categories {
+		categories[category] = true
+	}
+	if len(categories) == 0 {
+		categories = map[string]bool{SecurityEventCategoryAuth: true, SecurityEventCategoryAdmin: true, SecurityEventCategoryPolicy: true}
+	}
+	hostname, _ := os.Hostname()
+	return &SecurityEventExporter{
+		config:     config,
+		categories: categories,
+		events:     make(chan SecurityEvent, config.BufferSize),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		hostname:   hostname,
+		version:    "unknown",
+		done:       make(chan struct{}),
+	}
+}
+
+// Emit queues an event of an exported category.
+func (e *SecurityEventExporter) Emit(event SecurityEvent) {
+	if !e.categories[event.Category] {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	select {
+	case e.events <- event:
+	default:
+		if e.dropped.Add(1)%1000 == 1 {
+			logger.Warn("security events: queue is full, dropping events (%d dropped so far)", e.dropped.Load())
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because the queue was full.
+func (e *SecurityEventExporter) Dropped() int64 {
+	return e.dropped.Load()
+}
+
+// Start ships queued events until ctx is done, then flushes what is left. version is the Bifrost version reported
+// in CEF headers.
+func (e *SecurityEventExporter) Start(ctx context.Context, version string) {
+	if version != "" {
+		e.version = version
+	}
+	go func() {
+		defer close(e.done)
+		var batch []SecurityEvent
+		var flush <-chan time.Time
+		var timer *time.Timer
+		send := func() {
+			if len(batch) > 0 {
+				e.sendHTTP(batch)
+				batch = nil
+			}
+			if timer != nil {
+				timer.Stop()
+				timer, flush = nil, nil
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				for {
+					select {
+					case event := <-e.events:
+						e.sendSyslog(event)
+						if e.config.HTTP != nil {
+							batch = append(batch, event)
+						}
+					default:
+						send()
+						e.closeSyslog()
+						return
+					}
+				}
+			case event := <-e.events:
+				e.sendSyslog(event)
+				if e.config.HTTP == nil {
+					continue
+				}
+				batch = append(batch, event)
+				if len(batch) >= e.config.HTTP.BatchSize {
+					send()
+				} else if timer == nil {
+					timer = time.NewTimer(time.Duration(e.config.HTTP.FlushIntervalSeconds) * time.Second)
+					flush = timer.C
+				}
+			case <-flush:
+				timer, flush = nil, nil
+				send()
+			}
+		}
+	}()
+}
+
+// Wait blocks until the exporter has flushed its events after its context was done.
+func (e *SecurityEventExporter) Wait() {
+	<-e.done
+}
+
+// sendSyslog writes an event to the syslog sink, reconnecting once on failure
+func (e *SecurityEventExporter) sendSyslog(event SecurityEvent) {
+	if e.config.Syslog == nil {
+		return
+	}
+	message, err := e.syslogMessage(event)
+	if err != nil {
+		logger.Warn("security events: failed to format event: %v", err)
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for attempt := 0; attempt < 2; attempt++ {
+		if e.syslog == nil {
+			if e.syslog, err = dialSyslog(e.config.Syslog.Address); err != nil {
+				logger.Warn("security events: failed to connect to syslog: %v", err)
+				return
+			}
+		}
+		e.syslog.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if _, err = e.syslog.Write(message); err == nil {
+			return
+		}
+		e.syslog.Close()
+		e.syslog = nil
+	}
+	logger.Warn("security events: failed to write to syslog: %v", err)
+}
+
+// closeSyslog closes the syslog connection
+func (e *SecurityEventExporter) closeSyslog() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.syslog != nil {
+		e.syslog.Close()
+		e.syslog = nil
+	}
+}
+
+// dialSyslog connects to a udp://, tcp:// or tls:// syslog address
+func dialSyslog(address string) (net.Conn, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if u.Scheme == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", u.Host, &tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	return dialer.Dial(u.Scheme, u.Host)
+}
+
+// syslogMessage renders an event as an RFC 5424 message (facility local0). Stream transports use newline framing.
+func (e *SecurityEventExporter) syslogMessage(event SecurityEvent) ([]byte, error) {
+	var body string
+	if e.config.Syslog.Format == SecurityEventFormatJSON {
+		data, err := sonic.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		body = string(data)
+	} else {
+		body = FormatCEF(event, e.version)
+	}
+	const facilityLocal0 = 16
+	priority := facilityLocal0*8 + syslogSeverity(event.Severity)
+	hostname := e.hostname
+	if hostname == "" {
+		hostname = "-"
+	}
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s - %s - %s\n", priority, event.Time.UTC().Format(time.RFC3339Nano),
+		hostname, e.config.Syslog.AppName, event.Category, body)), nil
+}
+
+// syslogSeverity maps a CEF severity to a syslog severity
+func syslogSeverity(severity int) int {
+	switch {
+	case severity >= 9:
+		return 2 // Critical
+	case severity >= 7:
+		return 3 // Error
+	case severity >= 4:
+		return 4 // Warning
+	case severity >= 2:
+		return 5 // Notice
+	}
+	return 6 // Informational
+}
+
+// sendHTTP posts a batch of events to the HTTP sink
+func (e *SecurityEventExporter) sendHTTP(batch []SecurityEvent) {
+	body, err := e.httpBody(batch)
+	if err != nil {
+		logger.Warn("security events: failed to encode %d events: %v", len(batch), err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, e.config.HTTP.URL, bytes.NewReader(body))
+	if err != nil {
+		logger.Warn("security events: failed to build request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.config.HTTP.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		logger.Warn("security events: failed to send %d events: %v", len(batch), err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		logger.Warn("security events: collector rejected %d events with status %d", len(batch), resp.StatusCode)
+	}
+}
+
+// httpBody encodes a batch as a JSON array, or as concatenated Splunk HEC envelopes
+func (e *SecurityEventExporter) httpBody(batch []SecurityEvent) ([]byte, error) {
+	if e.config.HTTP.Format != SecurityEventFormatSplunkHEC {
+		return sonic.Marshal(batch)
+	}
+	var buf bytes.Buffer
+	for _, event := range batch {
+		envelope := map[string]any{
+			"time":       float64(event.Time.UnixMilli()) / 1000,
+			"host":       e.hostname,
+			"source":     "bifrost",
+			"sourcetype": "bifrost:security",
+			"event":      event,
+		}
+		data, err := sonic.Marshal(envelope)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatCEF renders an event in ArcSight Common Event Format. Details become extension fields prefixed with
+// bifrost, in key order.
+func FormatCEF(event SecurityEvent, version string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|Maxim|Bifrost|%s|%s|%s|%d|",
+		cefHeaderEscape(version), cefHeaderEscape(event.Category+":"+event.Type), cefHeaderEscape(cefName(event)), event.Severity)
+	extensions := [][2]string{
+		{"rt", strconv.FormatInt(event.Time.UnixMilli(), 10)},
+		{"cat", event.Category},
+		{"outcome", event.Outcome},
+		{"suser", event.Actor},
+		{"src", event.SourceIP},
+		{"requestMethod", event.Method},
+		{"request", event.Path},
+		{"msg", event.Message},
+	}
+	if event.Status != 0 {
+		extensions = append(extensions, [2]string{"cn1Label", "status"}, [2]string{"cn1", strconv.Itoa(event.Status)})
+	}
+	keys := make([]string, 0, len(event.Details))
+	for key := range event.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		extensions = append(extensions, [2]string{"bifrost" + cefKey(key), event.Details[key]})
+	}
+	first := true
+	for _, extension := range extensions {
+		if extension[1] == "" {
+			continue
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(extension[0])
+		b.WriteByte('=')
+		b.WriteString(cefExtensionEscape(extension[1]))
+	}
+	return b.String()
+}
+
+// cefName returns the human readable name of an event
+func cefName(event SecurityEvent) string {
+	name := strings.ReplaceAll(event.Type, "_", " ")
+	if event.Outcome != "" {
+		name += " (" + event.Outcome + ")"
+	}
+	return name
+}
+
+// cefKey turns a detail key like virtual_key into an extension key suffix like VirtualKey
+func cefKey(key string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool { return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// cefHeaderEscape escapes backslashes and pipes of CEF header fields
+func cefHeaderEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ").Replace(value)
+}
+
+// cefExtensionEscape escapes backslashes, equal signs and newlines of CEF extension values
+func cefExtensionEscape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
+
+// IsPolicyViolation reports whether an inference error type is a policy rejection worth a security event.
+func IsPolicyViolation(errorType string) bool {
+	return slices.Contains(securityPolicyErrorTypes, errorType)
+}
+
+// securityPolicyErrorTypes are the error types of requests rejected by governance, moderation and data residency
+var securityPolicyErrorTypes = []string{
+	"virtual_key_required", "virtual_key_not_found", "virtual_key_blocked", "model_blocked", "provider_blocked",
+	"rate_limited", "token_limited", "request_limited", "budget_exceeded",
+	"content_policy_violation", "pending_review", "data_residency_violation",
+}
+
+// initSecurityEvents creates the security event exporter; it is started once the server runs.
+func (s *Config) initSecurityEvents(config *SecurityEventsConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	resolved := *config
+	if config.HTTP != nil {
+		sink := *config.HTTP
+		sink.Headers = make(map[string]string, len(config.HTTP.Headers))
+		for name, value := range config.HTTP.Headers {
+			resolvedValue, _, err := s.processEnvValue(value)
+			if err != nil {
+				return fmt.Errorf("security_events: header %s: %w", name, err)
+			}
+			sink.Headers[name] = resolvedValue
+		}
+		resolved.HTTP = &sink
+	}
+	s.SecurityEvents = NewSecurityEventExporter(resolved)
+	return nil
+}
@@ -0,0 +1,115 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// SecurityHeaderOff omits a security header.
+const SecurityHeaderOff = "off"
+
+// DefaultContentSecurityPolicy allows the embedded dashboard: its Next.js assets and inline bootstrap scripts,
+// the Swagger UI of /api/docs, logos of tenant branding, the release check and the websocket of live logs.
+// Other pages cannot frame Bifrost.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline' https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://unpkg.com; " +
+	"img-src 'self' data: blob: https:; " +
+	"font-src 'self' data:; " +
+	"connect-src 'self' ws: wss: https://getbifrost.ai; " +
+	"frame-ancestors 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'; " +
+	"object-src 'none'"
+
+const (
+	DefaultFrameOptions       = "DENY"
+	DefaultContentTypeOptions = "nosniff"
+	DefaultReferrerPolicy     = "strict-origin-when-cross-origin"
+	DefaultHSTSMaxAgeSeconds  = 31536000 // One year
+)
+
+// referrerPolicies are the valid values of the Referrer-Policy header.
+var referrerPolicies = []string{
+	"no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin", "same-origin",
+	"strict-origin", "strict-origin-when-cross-origin", "unsafe-url",
+}
+
+// SecurityHeadersConfig configures the security headers set on UI and API responses. The headers are sent with
+// their defaults unless enabled is false; each can be replaced, or omitted with "off".
+type SecurityHeadersConfig struct {
+	Enabled               *bool  `json:"enabled,omitempty"`                 // Default: true
+	ContentSecurityPolicy string `json:"content_security_policy,omitempty"` // Default: DefaultContentSecurityPolicy
+	FrameOptions          string `json:"frame_options,omitempty"`           // DENY (default) or SAMEORIGIN
+	ContentTypeOptions    string `json:"content_type_options,omitempty"`    // nosniff (default)
+	ReferrerPolicy        string `json:"referrer_policy,omitempty"`         // Default: strict-origin-when-cross-origin
+	HSTSMaxAgeSeconds     int    `json:"hsts_max_age_seconds,omitempty"`    // Default: one year, negative omits HSTS
+	HSTSIncludeSubdomains bool   `json:"hsts_include_subdomains,omitempty"`
+	HSTSPreload           bool   `json:"hsts_preload,omitempty"`
+}
+
+// SecurityHeaders are the resolved headers of a SecurityHeadersConfig.
+type SecurityHeaders struct {
+	Headers [][2]string // Name and value of the headers of every response
+	HSTS    string      // Strict-Transport-Security value of TLS responses, empty when omitted
+}
+
+// Validate checks the header values.
+func (c *SecurityHeadersConfig) Validate() error {
+	switch c.FrameOptions {
+	case "", "DENY", "SAMEORIGIN", SecurityHeaderOff:
+	default:
+		return fmt.Errorf("security_headers: invalid frame_options %q, expected DENY, SAMEORIGIN or off", c.FrameOptions)
+	}
+	switch c.ContentTypeOptions {
+	case "", "nosniff", SecurityHeaderOff:
+	default:
+		return fmt.Errorf("security_headers: invalid content_type_options %q, expected nosniff or off", c.ContentTypeOptions)
+	}
+	if c.ReferrerPolicy != "" && c.ReferrerPolicy != SecurityHeaderOff && !slices.Contains(referrerPolicies, c.ReferrerPolicy) {
+		return fmt.Errorf("security_headers: invalid referrer_policy %q", c.ReferrerPolicy)
+	}
+	if c.HSTSPreload && (!c.HSTSIncludeSubdomains || (c.HSTSMaxAgeSeconds != 0 && c.HSTSMaxAgeSeconds < DefaultHSTSMaxAgeSeconds)) {
+		return fmt.Errorf("security_headers: hsts_preload requires hsts_include_subdomains and a max age of at least %d seconds", DefaultHSTSMaxAgeSeconds)
+	}
+	return nil
+}
+
+// Resolve returns the headers to send, applying the defaults. A nil config sends every default.
+func (c *SecurityHeadersConfig) Resolve() SecurityHeaders {
+	if c == nil {
+		c = &SecurityHeadersConfig{}
+	}
+	var resolved SecurityHeaders
+	if c.Enabled != nil && !*c.Enabled {
+		return resolved
+	}
+	add := func(name, value, defaultValue string) {
+		if value == "" {
+			value = defaultValue
+		}
+		if value != SecurityHeaderOff {
+			resolved.Headers = append(resolved.Headers, [2]string{name, value})
+		}
+	}
+	add("Content-Security-Policy", c.ContentSecurityPolicy, DefaultContentSecurityPolicy)
+	add("X-Frame-Options", c.FrameOptions, DefaultFrameOptions)
+	add("X-Content-Type-Options", c.ContentTypeOptions, DefaultContentTypeOptions)
+	add("Referrer-Policy", c.ReferrerPolicy, DefaultReferrerPolicy)
+
+	maxAge := c.HSTSMaxAgeSeconds
+	if maxAge == 0 {
+		maxAge = DefaultHSTSMaxAgeSeconds
+	}
+	if maxAge > 0 {
+		resolved.HSTS = "max-age=" + strconv.Itoa(maxAge)
+		if c.HSTSIncludeSubdomains {
+			resolved.HSTS += "; includeSubDomains"
+		}
+		if c.HSTSPreload {
+			resolved.HSTS += "; preload"
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,24 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+	"gorm.io/gorm"
+)
+
+// initServiceAccounts sets up the service accounts accepted by the management API. Accounts are stored in the
+// config store database, or in memory when there is no config store, in which case they are lost on restart.
+func (s *Config) initServiceAccounts(ctx context.Context) error {
+	var db *gorm.DB
+	if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	}
+	store, err := serviceaccounts.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize service accounts store: %w", err)
+	}
+	s.ServiceAccounts = serviceaccounts.NewManager(store)
+	return nil
+}
@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/sessions"
+	"gorm.io/gorm"
+)
+
+// SessionIDContextKey holds the session ID of a chat request, taken from the x-bf-session-id header
+// or the session_id body field.
+const SessionIDContextKey ContextKey = "x-bf-session-id"
+
+// initSessions sets up the session store. Sessions are stored in the config store database,
+// or in memory when there is no config store.
+func (s *Config) initSessions(ctx context.Context, config *sessions.Config) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if config.SummarizeModel != "" {
+		if provider, model := schemas.ParseModelString(config.SummarizeModel, ""); provider == "" || model == "" {
+			return fmt.Errorf("sessions summarize_model should be in provider/model format")
+		}
+	}
+	var db *gorm.DB
+	if s.ConfigStore != nil {
+		db = s.ConfigStore.DB()
+	} else {
+		logger.Warn("sessions are kept in memory since no config store is configured")
+	}
+	store, err := sessions.NewStore(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to initialize sessions: %w", err)
+	}
+	s.SessionsConfig = config
+	s.Sessions = store
+	return nil
+}
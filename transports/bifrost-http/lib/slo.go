@@ -0,0 +1,619 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Service level indicators
+const (
+	SLOIndicatorTTFT         = "ttft"         // Time to first token of successful streams is under the threshold
+	SLOIndicatorLatency      = "latency"      // Latency of successful requests is under the threshold
+	SLOIndicatorAvailability = "availability" // Requests do not fail with a server or provider error
+)
+
+// SLOAlertEventType is the type of SLO alert payloads.
+const SLOAlertEventType = "slo.burn_rate"
+
+const (
+	DefaultSLOPeriodDays         = 30
+	DefaultSLOEvaluationInterval = 60 // Seconds
+	maxSLOBurnRateWindowMinutes  = 3 * 24 * 60
+	sloAllModels                 = "*"
+	sloAlertStatusFiring         = "firing"
+	sloAlertStatusResolved       = "resolved"
+	sloAlertDeliveryTimeout      = 10 * time.Second
+)
+
+// DefaultSLOBurnRateAlerts are the multi-window burn-rate alerts of the SRE workbook for a 30 day period: a page
+// when 2% of the error budget burns in an hour, a ticket when 5% burns in six hours.
+var DefaultSLOBurnRateAlerts = []SLOBurnRateAlert{
+	{Severity: "page", LongWindowMinutes: 60, ShortWindowMinutes: 5, BurnRate: 14.4},
+	{Severity: "ticket", LongWindowMinutes: 360, ShortWindowMinutes: 30, BurnRate: 6},
+}
+
+// SLOConfig defines service level objectives, evaluated from the outcome and timings of every upstream attempt,
+// and the burn-rate alerts raised when their error budgets burn too fast. Each replica evaluates its own traffic.
+type SLOConfig struct {
+	Objectives                []SLOObjective     `json:"objectives"`
+	BurnRateAlerts            []SLOBurnRateAlert `json:"burn_rate_alerts,omitempty"`            // Default: DefaultSLOBurnRateAlerts
+	Webhook                   *SLOWebhookConfig  `json:"webhook,omitempty"`                     // Where alerts are sent (alerts are only exported as metrics without it)
+	EvaluationIntervalSeconds int                `json:"evaluation_interval_seconds,omitempty"` // Default: 60
+}
+
+// SLOObjective is a target share of good events, e.g. 99% of streams with a time to first token under 2s.
+type SLOObjective struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Indicator   string                  `json:"indicator"`              // ttft, latency or availability
+	ThresholdMs int                     `json:"threshold_ms,omitempty"` // Required for ttft and latency
+	Target      float64                 `json:"target"`                 // Percentage of good events, e.g. 99 or 99.9
+	Providers   []schemas.ModelProvider `json:"providers,omitempty"`    // Default: all providers
+	Models      []string                `json:"models,omitempty"`       // Default: all models
+	PerModel    bool                    `json:"per_model,omitempty"`    // Track and alert on every model separately
+	PeriodDays  int                     `json:"period_days,omitempty"`  // Compliance period (default: 30)
+}
+
+// SLOBurnRateAlert fires when the error budget burns at least BurnRate times faster than sustainable over both
+// windows: the long window makes the alert significant, the short one makes it resolve quickly.
+type SLOBurnRateAlert struct {
+	Severity           string  `json:"severity"` // e.g. page or ticket
+	LongWindowMinutes  int     `json:"long_window_minutes"`
+	ShortWindowMinutes int     `json:"short_window_minutes"`
+	BurnRate           float64 `json:"burn_rate"`
+}
+
+// SLOWebhookConfig is the endpoint receiving SLO alerts when they fire and resolve.
+type SLOWebhookConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"` // e.g. authorization; values may reference env.VAR_NAME
+}
+
+// SLOAlert is the payload posted to the webhook.
+type SLOAlert struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"` // firing or resolved
+	SLO                string    `json:"slo"`
+	Model              string    `json:"model,omitempty"` // Set for per-model objectives
+	Severity           string    `json:"severity"`
+	Target             float64   `json:"target"`
+	LongWindowMinutes  int       `json:"long_window_minutes"`
+	ShortWindowMinutes int       `json:"short_window_minutes"`
+	BurnRateThreshold  float64   `json:"burn_rate_threshold"`
+	LongBurnRate       float64   `json:"long_burn_rate"`
+	ShortBurnRate      float64   `json:"short_burn_rate"`
+	Timestamp          time.Time `json:"timestamp"`
+}
+
+// SLOStatus is the current state of an objective, or of one model of a per-model objective.
+type SLOStatus struct {
+	Name                 string             `json:"name"`
+	Description          string             `json:"description,omitempty"`
+	Indicator            string             `json:"indicator"`
+	ThresholdMs          int                `json:"threshold_ms,omitempty"`
+	Target               float64            `json:"target"`
+	PeriodDays           int                `json:"period_days"`
+	Model                string             `json:"model,omitempty"`
+	Total                int64              `json:"total"`                  // Events in the period
+	Good                 int64              `json:"good"`                   // Good events in the period
+	Compliance           float64            `json:"compliance"`             // Percentage of good events in the period (100 without events)
+	ErrorBudgetRemaining float64            `json:"error_budget_remaining"` // Percentage of the period's error budget left, negative once exhausted
+	BurnRates            map[string]float64 `json:"burn_rates"`             // Per window, e.g. "5m", "1h"
+	Alerts               []SLOAlertState    `json:"alerts"`
+}
+
+// SLOAlertState is the state of a burn-rate alert of an objective.
+type SLOAlertState struct {
+	SLOBurnRateAlert
+	Firing bool       `json:"firing"`
+	Since  *time.Time `json:"since,omitempty"` // When it started firing
+}
+
+// Validate checks the objectives and alerts.
+func (c *SLOConfig) Validate() error {
+	if len(c.Objectives) == 0 {
+		return fmt.Errorf("slo: at least one objective is required")
+	}
+	names := map[string]bool{}
+	for _, objective := range c.Objectives {
+		if objective.Name == "" {
+			return fmt.Errorf("slo: objective name is required")
+		}
+		if names[objective.Name] {
+			return fmt.Errorf("slo: duplicate objective %q", objective.Name)
+		}
+		names[objective.Name] = true
+		switch objective.Indicator {
+		case SLOIndicatorTTFT, SLOIndicatorLatency:
+			if objective.ThresholdMs <= 0 {
+				return fmt.Errorf("slo: objective %q: threshold_ms is required for %s", objective.Name, objective.Indicator)
+			}
+		case SLOIndicatorAvailability:
+		default:
+			return fmt.Errorf("slo: objective %q: invalid indicator %q, expected ttft, latency or availability", objective.Name, objective.Indicator)
+		}
+		if objective.Target <= 0 || objective.Target >= 100 {
+			return fmt.Errorf("slo: objective %q: target must be between 0 and 100 exclusive", objective.Name)
+		}
+		if objective.PeriodDays < 0 || objective.PeriodDays > 90 {
+			return fmt.Errorf("slo: objective %q: period_days must be at most 90", objective.Name)
+		}
+	}
+	for _, alert := range c.BurnRateAlerts {
+		if alert.Severity == "" || alert.BurnRate <= 0 || alert.ShortWindowMinutes <= 0 || alert.LongWindowMinutes < alert.ShortWindowMinutes {
+			return fmt.Errorf("slo: burn rate alert %q needs a severity, a positive burn rate and a short window within the long one", alert.Severity)
+		}
+		if alert.LongWindowMinutes > maxSLOBurnRateWindowMinutes {
+			return fmt.Errorf("slo: burn rate alert %q: windows cannot exceed %d minutes", alert.Severity, maxSLOBurnRateWindowMinutes)
+		}
+	}
+	if c.Webhook != nil && c.Webhook.URL == "" {
+		return fmt.Errorf("slo: webhook url is required")
+	}
+	return nil
+}
+
+// SLOEvent is the outcome of an upstream attempt.
+type SLOEvent struct {
+	Provider schemas.ModelProvider
+	Model    string
+	Latency  time.Duration
+	TTFT     time.Duration // Zero for non-streaming requests
+	Stream   bool
+	Failed   bool // Server or provider error
+	Rejected bool // Client error (bad request, policy rejection); not counted by any indicator
+}
+
+// sloCounts are the events of a time bucket
+type sloCounts struct {
+	bucket int64 // Index of the minute or hour the counts belong to
+	good   int64
+	total  int64
+}
+
+// sloRing counts events in consecutive buckets of a fixed width
+type sloRing struct {
+	width   time.Duration
+	buckets []sloCounts
+}
+
+func newSLORing(width time.Duration, size int) *sloRing {
+	return &sloRing{width: width, buckets: make([]sloCounts, size)}
+}
+
+// add counts an event at t
+func (r *sloRing) add(t time.Time, good bool) {
+	index := t.UnixNano() / int64(r.width)
+	slot := &r.buckets[index%int64(len(r.buckets))]
+	if slot.bucket != index {
+		*slot = sloCounts{bucket: index}
+	}
+	slot.total++
+	if good {
+		slot.good++
+	}
+}
+
+// sum returns the events of the window ending at now
+func (r *sloRing) sum(now time.Time, window time.Duration) (good, total int64) {
+	last := now.UnixNano() / int64(r.width)
+	first := last - int64(window/r.width) + 1
+	for _, slot := range r.buckets {
+		if slot.bucket >= first && slot.bucket <= last {
+			good += slot.good
+			total += slot.total
+		}
+	}
+	return good, total
+}
+
+// sloSeries tracks an objective, or one model of a per-model objective
+type sloSeries struct {
+	minutes *sloRing // For burn-rate windows
+	hours   *sloRing // For the compliance period
+	since   map[string]time.Time
+}
+
+// sloObjectiveState tracks the series of an objective
+type sloObjectiveState struct {
+	objective SLOObjective
+	series    map[string]*sloSeries // By model, or sloAllModels
+}
+
+// SLOTracker records upstream attempts against the objectives and evaluates burn-rate alerts.
+type SLOTracker struct {
+	config     SLOConfig
+	objectives []*sloObjectiveState
+	maxWindow  time.Duration
+	client     *http.Client
+	now        func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewSLOTracker creates a tracker, applying defaults to unset config values.
+func NewSLOTracker(config SLOConfig) *SLOTracker {
+	if len(config.BurnRateAlerts) == 0 {
+		config.BurnRateAlerts = DefaultSLOBurnRateAlerts
+	}
+	if config.EvaluationIntervalSeconds <= 0 {
+		config.EvaluationIntervalSeconds = DefaultSLOEvaluationInterval
+	}
+	tracker := &SLOTracker{
+		config: config,
+		client: &http.Client{Timeout: sloAlertDeliveryTimeout},
+		now:    time.Now,
+	}
+	for _, alert := range config.BurnRateAlerts {
+		tracker.maxWindow = max(tracker.maxWindow, time.Duration(alert.LongWindowMinutes)*time.Minute)
+	}
+	for _, objective := range config.Objectives {
+		if objective.PeriodDays == 0 {
+			objective.PeriodDays = DefaultSLOPeriodDays
+		}
+		tracker.objectives = append(tracker.objectives, &sloObjectiveState{objective: objective, series: map[string]*sloSeries{}})
+	}
+	registerSLOMetrics()
+	return tracker
+}
+
+// Record counts an upstream attempt against the objectives it matches.
+func (t *SLOTracker) Record(event SLOEvent) {
+	if event.Rejected {
+		return
+	}
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, state := range t.objectives {
+		objective := state.objective
+		if len(objective.Providers) > 0 && !slices.Contains(objective.Providers, event.Provider) {
+			continue
+		}
+		if len(objective.Models) > 0 && !slices.Contains(objective.Models, event.Model) {
+			continue
+		}
+		var good bool
+		switch objective.Indicator {
+		case SLOIndicatorTTFT:
+			if !event.Stream || event.Failed {
+				continue
+			}
+			good = event.TTFT <= time.Duration(objective.ThresholdMs)*time.Millisecond
+		case SLOIndicatorLatency:
+			if event.Failed {
+				continue
+			}
+			good = event.Latency <= time.Duration(objective.ThresholdMs)*time.Millisecond
+		case SLOIndicatorAvailability:
+			good = !event.Failed
+		}
+		key := sloAllModels
+		if objective.PerModel {
+			key = event.Model
+		}
+		series, ok := state.series[key]
+		if !ok {
+			series = &sloSeries{
+				minutes: newSLORing(time.Minute, int(t.maxWindow/time.Minute)+1),
+				hours:   newSLORing(time.Hour, objective.PeriodDays*24+1),
+				since:   map[string]time.Time{},
+			}
+			state.series[key] = series
+		}
+		series.minutes.add(now, good)
+		series.hours.add(now, good)
+	}
+}
+
+// Start evaluates the alerts every interval until ctx is done.
+func (t *SLOTracker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(t.config.EvaluationIntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.Evaluate()
+			}
+		}
+	}()
+}
+
+// Evaluate updates the SLO metrics and the state of the burn-rate alerts, sending the alerts that fired or
+// resolved to the webhook. It returns them.
+func (t *SLOTracker) Evaluate() []SLOAlert {
+	var alerts []SLOAlert
+	statuses := t.evaluate(func(state *sloObjectiveState, model string, series *sloSeries, rule SLOBurnRateAlert, long, short float64, now time.Time) {
+		firing := long >= rule.BurnRate && short >= rule.BurnRate
+		_, wasFiring := series.since[rule.Severity]
+		if firing == wasFiring {
+			return
+		}
+		status := sloAlertStatusResolved
+		if firing {
+			status = sloAlertStatusFiring
+			series.since[rule.Severity] = now
+		} else {
+			delete(series.since, rule.Severity)
+		}
+		alert := SLOAlert{
+			Type:               SLOAlertEventType,
+			Status:             status,
+			SLO:                state.objective.Name,
+			Severity:           rule.Severity,
+			Target:             state.objective.Target,
+			LongWindowMinutes:  rule.LongWindowMinutes,
+			ShortWindowMinutes: rule.ShortWindowMinutes,
+			BurnRateThreshold:  rule.BurnRate,
+			LongBurnRate:       long,
+			ShortBurnRate:      short,
+			Timestamp:          now.UTC(),
+		}
+		if model != sloAllModels {
+			alert.Model = model
+		}
+		alerts = append(alerts, alert)
+	})
+	for _, status := range statuses {
+		model := status.Model
+		sloErrorBudgetRemaining.WithLabelValues(status.Name, model).Set(status.ErrorBudgetRemaining)
+		for window, rate := range status.BurnRates {
+			sloBurnRate.WithLabelValues(status.Name, model, window).Set(rate)
+		}
+		for _, alert := range status.Alerts {
+			firing := 0.0
+			if alert.Firing {
+				firing = 1
+			}
+			sloAlertFiring.WithLabelValues(status.Name, model, alert.Severity).Set(firing)
+		}
+	}
+	t.send(alerts)
+	return alerts
+}
+
+// Status returns the state of every tracked objective, without changing the alerts.
+func (t *SLOTracker) Status() []SLOStatus {
+	return t.evaluate(nil)
+}
+
+// LatencyAtRisk reports whether a ttft or latency objective covering the provider and model burned its error
+// budget at least burnRate times faster than sustainable over the last window.
+func (t *SLOTracker) LatencyAtRisk(provider schemas.ModelProvider, model string, window time.Duration, burnRate float64) bool {
+	now := t.now()
+	minutes := max(int(window/time.Minute), 1)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, state := range t.objectives {
+		objective := state.objective
+		if objective.Indicator != SLOIndicatorTTFT && objective.Indicator != SLOIndicatorLatency {
+			continue
+		}
+		if len(objective.Providers) > 0 && !slices.Contains(objective.Providers, provider) {
+			continue
+		}
+		if len(objective.Models) > 0 && !slices.Contains(objective.Models, model) {
+			continue
+		}
+		key := sloAllModels
+		if objective.PerModel {
+			key = model
+		}
+		if state.series[key].burnRate(now, minutes, (100-objective.Target)/100) >= burnRate {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluate computes the status of every series, calling onRule with the burn rates of every alert rule
+func (t *SLOTracker) evaluate(onRule func(state *sloObjectiveState, model string, series *sloSeries, rule SLOBurnRateAlert, long, short float64, now time.Time)) []SLOStatus {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := []SLOStatus{}
+	for _, state := range t.objectives {
+		objective := state.objective
+		models := make([]string, 0, len(state.series))
+		for model := range state.series {
+			models = append(models, model)
+		}
+		sort.Strings(models)
+		if len(models) == 0 && !objective.PerModel {
+			models = []string{sloAllModels}
+		}
+		for _, model := range models {
+			series := state.series[model]
+			status := SLOStatus{
+				Name:        objective.Name,
+				Description: objective.Description,
+				Indicator:   objective.Indicator,
+				ThresholdMs: objective.ThresholdMs,
+				Target:      objective.Target,
+				PeriodDays:  objective.PeriodDays,
+				Compliance:  100,
+				BurnRates:   map[string]float64{},
+				Alerts:      []SLOAlertState{},
+			}
+			if model != sloAllModels {
+				status.Model = model
+			}
+			if series != nil {
+				status.Good, status.Total = series.hours.sum(now, time.Duration(objective.PeriodDays)*24*time.Hour)
+			}
+			budget := (100 - objective.Target) / 100
+			status.ErrorBudgetRemaining = 100
+			if status.Total > 0 {
+				badRatio := float64(status.Total-status.Good) / float64(status.Total)
+				status.Compliance = 100 * float64(status.Good) / float64(status.Total)
+				status.ErrorBudgetRemaining = 100 * (1 - badRatio/budget)
+			}
+			for _, rule := range t.config.BurnRateAlerts {
+				long := series.burnRate(now, rule.LongWindowMinutes, budget)
+				short := series.burnRate(now, rule.ShortWindowMinutes, budget)
+				status.BurnRates[sloWindowName(rule.LongWindowMinutes)] = long
+				status.BurnRates[sloWindowName(rule.ShortWindowMinutes)] = short
+				if onRule != nil && series != nil {
+					onRule(state, model, series, rule, long, short, now)
+				}
+				alertState := SLOAlertState{SLOBurnRateAlert: rule}
+				if series != nil {
+					if since, ok := series.since[rule.Severity]; ok {
+						alertState.Firing = true
+						alertState.Since = &since
+					}
+				}
+				status.Alerts = append(status.Alerts, alertState)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// burnRate returns how many times faster than sustainable the error budget burned over the last minutes
+func (s *sloSeries) burnRate(now time.Time, minutes int, budget float64) float64 {
+	if s == nil {
+		return 0
+	}
+	good, total := s.minutes.sum(now, time.Duration(minutes)*time.Minute)
+	if total == 0 {
+		return 0
+	}
+	return float64(total-good) / float64(total) / budget
+}
+
+// sloWindowName formats a window in minutes, e.g. 5m, 1h or 3d
+func sloWindowName(minutes int) string {
+	switch {
+	case minutes%(24*60) == 0:
+		return strconv.Itoa(minutes/(24*60)) + "d"
+	case minutes%60 == 0:
+		return strconv.Itoa(minutes/60) + "h"
+	}
+	return strconv.Itoa(minutes) + "m"
+}
+
+// send delivers alerts to the webhook in the background. Failed deliveries are logged and not retried.
+func (t *SLOTracker) send(alerts []SLOAlert) {
+	for _, alert := range alerts {
+		logger.Warn("slo %s%s: %s burn rate alert %s (%.1fx over %s, %.1fx over %s)", alert.SLO, sloModelSuffix(alert.Model),
+			alert.Severity, alert.Status, alert.LongBurnRate, sloWindowName(alert.LongWindowMinutes), alert.ShortBurnRate, sloWindowName(alert.ShortWindowMinutes))
+	}
+	if t.config.Webhook == nil || len(alerts) == 0 {
+		return
+	}
+	go func() {
+		for _, alert := range alerts {
+			if err := t.post(alert); err != nil {
+				logger.Warn("failed to deliver %s alert of slo %s: %v", alert.Severity, alert.SLO, err)
+			}
+		}
+	}()
+}
+
+// sloModelSuffix formats the model of a per-model alert for logs
+func sloModelSuffix(model string) string {
+	if model == "" {
+		return ""
+	}
+	return " (" + model + ")"
+}
+
+// post sends one alert to the webhook
+func (t *SLOTracker) post(alert SLOAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, t.config.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.config.Webhook.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+var (
+	sloMetricsOnce sync.Once
+	// sloBurnRate is the burn rate of each objective over each alert window
+	sloBurnRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bifrost_slo_burn_rate",
+		Help: "How many times faster than sustainable the error budget of an SLO burned over the window.",
+	}, []string{"slo", "model", "window"})
+	// sloErrorBudgetRemaining is the share of the error budget left in the period of each objective
+	sloErrorBudgetRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bifrost_slo_error_budget_remaining_percent",
+		Help: "Percentage of the error budget of an SLO left in its period, negative once exhausted.",
+	}, []string{"slo", "model"})
+	// sloAlertFiring tells which burn-rate alerts are firing
+	sloAlertFiring = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bifrost_slo_alert_firing",
+		Help: "1 while a burn-rate alert of an SLO is firing, 0 otherwise.",
+	}, []string{"slo", "model", "severity"})
+)
+
+// registerSLOMetrics registers the SLO gauges with the default registry once
+func registerSLOMetrics() {
+	sloMetricsOnce.Do(func() {
+		for _, collector := range []prometheus.Collector{sloBurnRate, sloErrorBudgetRemaining, sloAlertFiring} {
+			if err := prometheus.Register(collector); err != nil {
+				var alreadyRegistered prometheus.AlreadyRegisteredError
+				if !errors.As(err, &alreadyRegistered) {
+					logger.Warn("failed to register slo metrics: %v", err)
+				}
+			}
+		}
+	})
+}
+
+// initSLOs creates the SLO tracker; its alerts are evaluated once the server runs.
+func (s *Config) initSLOs(config *SLOConfig) error {
+	if config == nil {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	resolved := *config
+	if config.Webhook != nil {
+		webhook := *config.Webhook
+		webhook.Headers = make(map[string]string, len(config.Webhook.Headers))
+		for name, value := range config.Webhook.Headers {
+			resolvedValue, _, err := s.processEnvValue(value)
+			if err != nil {
+				return fmt.Errorf("slo: webhook header %s: %w", name, err)
+			}
+			webhook.Headers[name] = resolvedValue
+		}
+		resolved.Webhook = &webhook
+	}
+	s.SLOs = NewSLOTracker(resolved)
+	return nil
+}
@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic/encoder"
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxPooledSSEBufferSize caps the buffers returned to the pool, so that one huge chunk does not pin its memory.
+const maxPooledSSEBufferSize = 1 << 20
+
+var (
+	sseDataPrefix = []byte("data: ")
+	sseFrameEnd   = []byte("\n\n")
+
+	// SSEDoneFrame is the frame ending OpenAI-compatible streams.
+	SSEDoneFrame = []byte("data: [DONE]\n\n")
+
+	// SSEHeartbeatFrame is the SSE comment sent while a stream waits for its next chunk. Clients ignore comments.
+	SSEHeartbeatFrame = []byte(": keep-alive\n\n")
+
+	// SSEHeartbeatInterval is how long a stream waits for a chunk before sending a heartbeat. Writing is the only
+	// way to notice a client gone, so without heartbeats a client leaving during a long pause of the provider would
+	// go unnoticed until its next chunk.
+	SSEHeartbeatInterval = 15 * time.Second
+
+	// AbandonedStreams counts the streams whose client went away before their end
+	AbandonedStreams = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "bifrost_streams_abandoned_total",
+		Help: "Streams whose client disconnected before their end, cancelling their upstream request.",
+	})
+
+	// inFlightStreams counts the streams being written to their client
+	inFlightStreams atomic.Int64
+
+	// InFlightStreamsGauge exports the streams being written to their client
+	InFlightStreamsGauge = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "bifrost_streams_in_flight",
+		Help: "Streams currently being written to their client.",
+	}, func() float64 { return float64(inFlightStreams.Load()) })
+)
+
+// sseBufferPool holds the buffers streamed chunks are encoded into. Streams encode one chunk per token, so
+// allocating a buffer per chunk dominates GC pressure at high QPS.
+var sseBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 4096)
+		return &buf
+	},
+}
+
+// WriteSSEJSON encodes v as JSON and writes it to w as one SSE data frame ("data: <json>\n\n").
+// The JSON is encoded into a pooled buffer, so writing to a buffered writer does not allocate a buffer per frame.
+// Encoding errors are returned before anything is written; use IsSSEEncodeError to tell them from write errors.
+func WriteSSEJSON(w io.Writer, v any) error {
+	buf := sseBufferPool.Get().(*[]byte)
+	defer releaseSSEBuffer(buf)
+
+	*buf = (*buf)[:0]
+	if err := encoder.EncodeInto(buf, v, encoder.NoEncoderNewline); err != nil {
+		return &sseEncodeError{err: err}
+	}
+	return WriteSSEData(w, *buf)
+}
+
+// WriteSSEData writes data to w as one SSE data frame ("data: <data>\n\n") without copying it.
+func WriteSSEData(w io.Writer, data []byte) error {
+	if _, err := w.Write(sseDataPrefix); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err := w.Write(sseFrameEnd)
+	return err
+}
+
+// IsSSEEncodeError reports whether an error of WriteSSEJSON comes from encoding the value rather than from
+// writing it, in which case nothing was written and the stream can continue.
+func IsSSEEncodeError(err error) bool {
+	_, ok := err.(*sseEncodeError)
+	return ok
+}
+
+type sseEncodeError struct {
+	err error
+}
+
+func (e *sseEncodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sseEncodeError) Unwrap() error {
+	return e.err
+}
+
+func releaseSSEBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledSSEBufferSize {
+		return
+	}
+	sseBufferPool.Put(buf)
+}
+
+// ReceiveStreamChunk waits for the next chunk of stream, writing a heartbeat to w on every tick of heartbeat
+// meanwhile. ok is false once the stream is closed, and err is the error of a heartbeat the client did not take,
+// in which case the stream should be abandoned.
+func ReceiveStreamChunk(w *bufio.Writer, stream chan *schemas.BifrostStream, heartbeat <-chan time.Time) (response *schemas.BifrostStream, ok bool, err error) {
+	for {
+		select {
+		case response, ok = <-stream:
+			return response, ok, nil
+		case <-heartbeat:
+			if _, err := w.Write(SSEHeartbeatFrame); err != nil {
+				return nil, false, err
+			}
+			if err := w.Flush(); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+}
+
+// TrackStream counts a stream as in flight until the returned function is called, when the stream ends.
+func TrackStream() (done func()) {
+	inFlightStreams.Add(1)
+	return func() { inFlightStreams.Add(-1) }
+}
+
+// InFlightStreams returns the number of streams being written to their client.
+func InFlightStreams() int64 {
+	return inFlightStreams.Load()
+}
+
+// AbandonStream gives up a stream whose client went away. cancel cancels the context of its Bifrost request, which
+// aborts the upstream request so that the provider stops generating, and frees its concurrency slot; the chunks
+// still in flight are drained in the background so that nothing blocks on sending them.
+func AbandonStream(cancel context.CancelFunc, stream chan *schemas.BifrostStream) {
+	AbandonedStreams.Inc()
+	cancel()
+	go func() {
+		for range stream {
+		}
+	}()
+}
@@ -0,0 +1,336 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Flavors of the StatsD protocol
+const (
+	StatsDFlavorDogStatsD = "dogstatsd" // Datadog agent: labels are sent as tags
+	StatsDFlavorStatsD    = "statsd"    // Plain StatsD: no tags, series of a metric are summed
+)
+
+const (
+	DefaultStatsDAddress       = "udp://127.0.0.1:8125"
+	DefaultStatsDPrefix        = "bifrost."
+	DefaultStatsDInterval      = 10   // Seconds
+	DefaultStatsDMaxPacketSize = 1432 // Fits a standard Ethernet MTU
+)
+
+// StatsDConfig configures pushing the Prometheus metrics of Bifrost to a DogStatsD agent or a StatsD server, for
+// teams that do not scrape /metrics.
+type StatsDConfig struct {
+	Enabled         bool              `json:"enabled"`
+	Address         string            `json:"address,omitempty"`          // udp://host:port or unix:///path/to/dsd.socket (default: udp://127.0.0.1:8125)
+	Flavor          string            `json:"flavor,omitempty"`           // dogstatsd (default) or statsd
+	Prefix          string            `json:"prefix,omitempty"`           // Prepended to metric names (default: bifrost.)
+	IntervalSeconds int               `json:"interval_seconds,omitempty"` // Push interval (default: 10)
+	Tags            map[string]string `json:"tags,omitempty"`             // Static tags added to every metric, e.g. env, service; values may reference env.VAR_NAME
+	LabelTags       map[string]string `json:"label_tags,omitempty"`       // Renames metric labels to tags, e.g. customer: tenant; an empty name drops the label
+	Include         []string          `json:"include,omitempty"`          // Metric name prefixes to push (default: all)
+	MaxPacketSize   int               `json:"max_packet_size,omitempty"`  // Largest datagram in bytes (default: 1432)
+}
+
+// Validate checks the address and flavor.
+func (c *StatsDConfig) Validate() error {
+	if c.Address != "" {
+		if _, _, err := statsDNetwork(c.Address); err != nil {
+			return err
+		}
+	}
+	if c.Flavor != "" && c.Flavor != StatsDFlavorDogStatsD && c.Flavor != StatsDFlavorStatsD {
+		return fmt.Errorf("statsd: invalid flavor %q, expected dogstatsd or statsd", c.Flavor)
+	}
+	if c.IntervalSeconds < 0 || c.MaxPacketSize < 0 {
+		return fmt.Errorf("statsd: interval_seconds and max_packet_size cannot be negative")
+	}
+	return nil
+}
+
+// statsDNetwork returns the network and address to dial for a udp:// or unix:// address
+func statsDNetwork(address string) (string, string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", fmt.Errorf("statsd: invalid address %q: %w", address, err)
+	}
+	switch u.Scheme {
+	case "udp":
+		if u.Host == "" {
+			break
+		}
+		return "udp", u.Host, nil
+	case "unix":
+		if u.Path == "" {
+			break
+		}
+		return "unixgram", u.Path, nil
+	}
+	return "", "", fmt.Errorf("statsd: invalid address %q, expected udp://host:port or unix:///path", address)
+}
+
+// StatsDExporter periodically converts the gathered Prometheus metrics to StatsD metrics: counters are sent as the
+// increase since the previous push, gauges as their value, and histograms and summaries as the increase of their
+// count and sum.
+type StatsDExporter struct {
+	config   StatsDConfig
+	gatherer prometheus.Gatherer
+	tags     []string // Rendered static tags
+
+	mu       sync.Mutex
+	previous map[string]float64 // Last cumulative value of each counter series
+}
+
+// NewStatsDExporter creates an exporter of the metrics of gatherer, applying defaults to unset config values.
+func NewStatsDExporter(config StatsDConfig, gatherer prometheus.Gatherer) *StatsDExporter {
+	if config.Address == "" {
+		config.Address = DefaultStatsDAddress
+	}
+	if config.Flavor == "" {
+		config.Flavor = StatsDFlavorDogStatsD
+	}
+	if config.Prefix == "" {
+		config.Prefix = DefaultStatsDPrefix
+	}
+	if config.IntervalSeconds <= 0 {
+		config.IntervalSeconds = DefaultStatsDInterval
+	}
+	if config.MaxPacketSize <= 0 {
+		config.MaxPacketSize = DefaultStatsDMaxPacketSize
+	}
+	var tags []string
+	for name, value := range config.Tags {
+		tags = append(tags, statsDTag(name, value))
+	}
+	sort.Strings(tags)
+	return &StatsDExporter{
+		config:   config,
+		gatherer: gatherer,
+		tags:     tags,
+		previous: map[string]float64{},
+	}
+}
+
+// Start pushes metrics every interval until ctx is done, then pushes once more.
+func (e *StatsDExporter) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(e.config.IntervalSeconds) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := e.Push(); err != nil {
+					logger.Warn("statsd: final push failed: %v", err)
+				}
+				return
+			case <-ticker.C:
+				if err := e.Push(); err != nil {
+					logger.Warn("statsd: push failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Push gathers the metrics and sends them in as few datagrams as fit the packet size.
+func (e *StatsDExporter) Push() error {
+	lines, err := e.Lines()
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	network, address, err := statsDNetwork(e.config.Address)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout(network, address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var packet strings.Builder
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write([]byte(packet.String()))
+		packet.Reset()
+		return err
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > e.config.MaxPacketSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	return flush()
+}
+
+// Lines gathers the metrics and renders them as StatsD lines, advancing the counter baselines.
+func (e *StatsDExporter) Lines() ([]string, error) {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Plain StatsD has no tags, so the series of a metric are summed into one value
+	type point struct {
+		value float64
+		kind  string
+	}
+	var order []string
+	points := map[string]*point{}
+	add := func(name string, tags []string, value float64, kind string) {
+		if kind == "c" && value == 0 {
+			return
+		}
+		key := name
+		if e.config.Flavor == StatsDFlavorDogStatsD {
+			key = name + "|" + strings.Join(tags, ",")
+		}
+		if p, ok := points[key]; ok {
+			p.value += value
+			return
+		}
+		order = append(order, key)
+		points[key] = &point{value: value, kind: kind}
+	}
+	for _, family := range families {
+		name := family.GetName()
+		if !e.included(name) {
+			continue
+		}
+		metricName := e.config.Prefix + statsDMetricName(name)
+		for _, metric := range family.GetMetric() {
+			tags := e.metricTags(metric)
+			seriesKey := name + "{" + strings.Join(tags, ",") + "}"
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				add(metricName, tags, e.increase(seriesKey, metric.GetCounter().GetValue()), "c")
+			case dto.MetricType_GAUGE:
+				add(metricName, tags, metric.GetGauge().GetValue(), "g")
+			case dto.MetricType_UNTYPED:
+				add(metricName, tags, metric.GetUntyped().GetValue(), "g")
+			case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				add(metricName+".count", tags, e.increase(seriesKey+".count", float64(histogram.GetSampleCount())), "c")
+				add(metricName+".sum", tags, e.increase(seriesKey+".sum", histogram.GetSampleSum()), "c")
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				add(metricName+".count", tags, e.increase(seriesKey+".count", float64(summary.GetSampleCount())), "c")
+				add(metricName+".sum", tags, e.increase(seriesKey+".sum", summary.GetSampleSum()), "c")
+			}
+		}
+	}
+	lines := make([]string, 0, len(order))
+	for _, key := range order {
+		p := points[key]
+		name, tags, _ := strings.Cut(key, "|")
+		line := name + ":" + strconv.FormatFloat(p.value, 'f', -1, 64) + "|" + p.kind
+		if e.config.Flavor == StatsDFlavorDogStatsD && tags != "" {
+			line += "|#" + tags
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// increase returns how much a cumulative value grew since the previous push. Values going down mean the series was
+// reset, so the whole value is the increase.
+func (e *StatsDExporter) increase(seriesKey string, value float64) float64 {
+	previous, seen := e.previous[seriesKey]
+	e.previous[seriesKey] = value
+	if !seen || value < previous || math.IsNaN(previous) {
+		return value
+	}
+	return value - previous
+}
+
+// included reports whether a metric is pushed
+func (e *StatsDExporter) included(name string) bool {
+	if len(e.config.Include) == 0 {
+		return true
+	}
+	for _, prefix := range e.config.Include {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// metricTags returns the sorted static and label tags of a series
+func (e *StatsDExporter) metricTags(metric *dto.Metric) []string {
+	tags := append([]string(nil), e.tags...)
+	for _, label := range metric.GetLabel() {
+		name := label.GetName()
+		if renamed, ok := e.config.LabelTags[name]; ok {
+			if renamed == "" {
+				continue
+			}
+			name = renamed
+		}
+		if label.GetValue() == "" {
+			continue
+		}
+		tags = append(tags, statsDTag(name, label.GetValue()))
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// statsDMetricName replaces the characters StatsD servers do not accept in metric names
+func statsDMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// statsDTag renders a name:value tag, replacing the separators of the DogStatsD protocol
+func statsDTag(name, value string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_", ":", "_").Replace(name) + ":" +
+		strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_").Replace(value)
+}
+
+// initStatsD creates the StatsD exporter of the default Prometheus registry; it is started once the server runs.
+func (s *Config) initStatsD(config *StatsDConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	resolved := *config
+	resolved.Tags = make(map[string]string, len(config.Tags))
+	for name, value := range config.Tags {
+		resolvedValue, _, err := s.processEnvValue(value)
+		if err != nil {
+			return fmt.Errorf("statsd: tag %s: %w", name, err)
+		}
+		resolved.Tags[name] = resolvedValue
+	}
+	s.StatsD = NewStatsDExporter(resolved, prometheus.DefaultGatherer)
+	return nil
+}
@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/maximhq/bifrost/framework/configstore"
+)
+
+// loadStoredConfig decodes the JSON value saved under key in the config store into v.
+// It reports false when there is no config store or nothing is saved under key.
+func (s *Config) loadStoredConfig(ctx context.Context, key string, v any) (bool, error) {
+	if s.ConfigStore == nil {
+		return false, nil
+	}
+	saved, err := s.ConfigStore.GetConfig(ctx, key)
+	if errors.Is(err, configstore.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(saved.Value), v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// saveStoredConfig saves v as JSON under key in the config store. It is a no-op without a config store.
+func (s *Config) saveStoredConfig(ctx context.Context, key string, v any) error {
+	if s.ConfigStore == nil {
+		return nil
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.ConfigStore.UpdateConfig(ctx, &configstore.TableConfig{Key: key, Value: string(value)})
+}
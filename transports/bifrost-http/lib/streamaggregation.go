@@ -0,0 +1,163 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// StreamAggregationHeaderContextKey marks non-streaming requests opting into stream aggregation through the
+// x-bf-stream-aggregation header
+const StreamAggregationHeaderContextKey ContextKey = "x-bf-stream-aggregation"
+
+// StreamAggregationConfig lists the virtual keys whose non-streaming chat and text completion requests are streamed
+// from the provider and answered with a single JSON response aggregated from the chunks. The client gets the
+// response of a regular request, while a stream failing midway is retried on the next fallback.
+type StreamAggregationConfig struct {
+	VirtualKeys []string `json:"virtual_keys,omitempty"` // Virtual key values
+}
+
+// Validate checks that the policy applies to someone.
+func (c *StreamAggregationConfig) Validate() error {
+	if len(c.VirtualKeys) == 0 {
+		return fmt.Errorf("stream_aggregation: virtual_keys is required")
+	}
+	return nil
+}
+
+// Applies reports whether the requests of a virtual key are aggregated.
+func (c *StreamAggregationConfig) Applies(virtualKey string) bool {
+	return virtualKey != "" && slices.Contains(c.VirtualKeys, virtualKey)
+}
+
+// streamAggregationChoice accumulates the chunks of a choice.
+type streamAggregationChoice struct {
+	index        int
+	role         string
+	content      strings.Builder
+	refusal      strings.Builder
+	toolCalls    []schemas.ChatAssistantMessageToolCall
+	finishReason *string
+	logProbs     *schemas.LogProbs
+}
+
+// AggregateStream builds the chat or text completion response the chunks of a stream amount to, following
+// requestType. Contents are concatenated by choice, tool call deltas with a name or ID start a call and the others
+// extend the arguments of the last one, and the usage and finish reasons are taken from the chunks carrying them.
+func AggregateStream(requestType schemas.RequestType, chunks []*schemas.BifrostResponse) *schemas.BifrostResponse {
+	result := &schemas.BifrostResponse{}
+	var choices []*streamAggregationChoice
+	choiceAt := func(index int) *streamAggregationChoice {
+		for _, choice := range choices {
+			if choice.index == index {
+				return choice
+			}
+		}
+		choice := &streamAggregationChoice{index: index}
+		choices = append(choices, choice)
+		return choice
+	}
+
+	for _, chunk := range chunks {
+		if chunk == nil {
+			continue
+		}
+		if result.ID == "" {
+			result.ID = chunk.ID
+		}
+		if result.Model == "" {
+			result.Model = chunk.Model
+		}
+		if result.Created == 0 {
+			result.Created = chunk.Created
+		}
+		if chunk.ServiceTier != nil {
+			result.ServiceTier = chunk.ServiceTier
+		}
+		if chunk.SystemFingerprint != nil {
+			result.SystemFingerprint = chunk.SystemFingerprint
+		}
+		if chunk.Usage != nil {
+			result.Usage = chunk.Usage
+		}
+		result.ExtraFields = chunk.ExtraFields
+
+		for _, chunkChoice := range chunk.Choices {
+			choice := choiceAt(chunkChoice.Index)
+			if chunkChoice.FinishReason != nil {
+				choice.finishReason = chunkChoice.FinishReason
+			}
+			if chunkChoice.LogProbs != nil {
+				choice.logProbs = chunkChoice.LogProbs
+			}
+			if chunkChoice.BifrostTextCompletionResponseChoice != nil && chunkChoice.Text != nil {
+				choice.content.WriteString(*chunkChoice.Text)
+			}
+			if chunkChoice.BifrostStreamResponseChoice == nil || chunkChoice.Delta == nil {
+				continue
+			}
+			delta := chunkChoice.Delta
+			if delta.Role != nil {
+				choice.role = *delta.Role
+			}
+			if delta.Content != nil {
+				choice.content.WriteString(*delta.Content)
+			}
+			if delta.Refusal != nil {
+				choice.refusal.WriteString(*delta.Refusal)
+			}
+			for _, toolCall := range delta.ToolCalls {
+				if toolCall.ID != nil || toolCall.Function.Name != nil || len(choice.toolCalls) == 0 {
+					choice.toolCalls = append(choice.toolCalls, toolCall)
+					continue
+				}
+				choice.toolCalls[len(choice.toolCalls)-1].Function.Arguments += toolCall.Function.Arguments
+			}
+		}
+	}
+
+	isText := requestType == schemas.TextCompletionRequest || requestType == schemas.TextCompletionStreamRequest
+	result.Object = "chat.completion"
+	result.ExtraFields.RequestType = schemas.ChatCompletionRequest
+	if isText {
+		result.Object = "text_completion"
+		result.ExtraFields.RequestType = schemas.TextCompletionRequest
+	}
+	result.ExtraFields.ChunkIndex = 0
+
+	slices.SortFunc(choices, func(a, b *streamAggregationChoice) int { return a.index - b.index })
+	for _, choice := range choices {
+		content := choice.content.String()
+		aggregated := schemas.BifrostChatResponseChoice{
+			Index:        choice.index,
+			FinishReason: choice.finishReason,
+			LogProbs:     choice.logProbs,
+		}
+		if isText {
+			aggregated.BifrostTextCompletionResponseChoice = &schemas.BifrostTextCompletionResponseChoice{Text: &content}
+			result.Choices = append(result.Choices, aggregated)
+			continue
+		}
+
+		role := schemas.ChatMessageRoleAssistant
+		if choice.role != "" {
+			role = schemas.ChatMessageRole(choice.role)
+		}
+		message := &schemas.ChatMessage{Role: role}
+		if content != "" || len(choice.toolCalls) == 0 {
+			message.Content = &schemas.ChatMessageContent{ContentStr: &content}
+		}
+		if choice.refusal.Len() > 0 || len(choice.toolCalls) > 0 {
+			message.ChatAssistantMessage = &schemas.ChatAssistantMessage{ToolCalls: choice.toolCalls}
+			if choice.refusal.Len() > 0 {
+				refusal := choice.refusal.String()
+				message.ChatAssistantMessage.Refusal = &refusal
+			}
+		}
+		aggregated.BifrostNonStreamResponseChoice = &schemas.BifrostNonStreamResponseChoice{Message: message}
+		result.Choices = append(result.Choices, aggregated)
+	}
+	return result
+}
@@ -0,0 +1,480 @@
+package lib
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/cluster"
+	"github.com/maximhq/bifrost/framework/logstore"
+)
+
+const (
+	DefaultStripeMeteringIntervalMinutes = 60
+	DefaultStripeMeteringSettleSeconds   = 120
+	DefaultStripeBaseURL                 = "https://api.stripe.com"
+
+	// stripeMeteringHistorySize is the number of export records kept for reconciliation
+	stripeMeteringHistorySize = 10000
+	// stripeIdentifierWindow is how long Stripe deduplicates meter events by identifier;
+	// windows are only re-sent within it, so retries never double bill
+	stripeIdentifierWindow = 24 * time.Hour
+)
+
+// Usage metrics a Stripe meter can report
+const (
+	StripeMetricRequests         = "requests"
+	StripeMetricPromptTokens     = "prompt_tokens"
+	StripeMetricCompletionTokens = "completion_tokens"
+	StripeMetricTotalTokens      = "total_tokens"
+	StripeMetricCostCents        = "cost_cents" // Fractions of a cent are carried over to the next window
+)
+
+// Outcomes of exporting the usage of a customer to a Stripe meter
+const (
+	StripeExportSent     = "sent"
+	StripeExportDryRun   = "dry_run"
+	StripeExportFailed   = "failed"
+	StripeExportUnmapped = "unmapped" // The customer has no Stripe customer
+)
+
+// StripeMeteringConfig represents the configuration of the Stripe usage exporter.
+// Usage is read from the logs store, so it covers requests attributed to a billing customer.
+type StripeMeteringConfig struct {
+	Enabled         bool              `json:"enabled"`
+	APIKey          string            `json:"api_key"`                    // Stripe secret key, or env.VAR_NAME
+	BaseURL         string            `json:"base_url,omitempty"`         // Stripe API URL (default: https://api.stripe.com)
+	IntervalMinutes int               `json:"interval_minutes,omitempty"` // Length of a metering window (default: 60)
+	SettleSeconds   int               `json:"settle_seconds,omitempty"`   // Wait after a window closes before exporting it, so in-flight logs are written (default: 120)
+	Meters          []StripeMeter     `json:"meters"`
+	Customers       map[string]string `json:"customers,omitempty"` // Billing customer -> Stripe customer ID; billing customers starting with cus_ map to themselves
+	DryRun          bool              `json:"dry_run,omitempty"`   // Compute and record exports without sending them
+}
+
+// StripeMeter maps a usage metric to a Stripe billing meter.
+type StripeMeter struct {
+	EventName string `json:"event_name"` // Event name of the Stripe meter
+	Metric    string `json:"metric"`     // requests, prompt_tokens, completion_tokens, total_tokens or cost_cents
+}
+
+// Validate checks the configuration of the exporter.
+func (c *StripeMeteringConfig) Validate() error {
+	if c.APIKey == "" && !c.DryRun {
+		return fmt.Errorf("stripe_metering: api_key is required unless dry_run is set")
+	}
+	if c.IntervalMinutes < 0 || c.SettleSeconds < 0 {
+		return fmt.Errorf("stripe_metering: interval_minutes and settle_seconds cannot be negative")
+	}
+	if c.IntervalMinutes > 0 && time.Duration(c.IntervalMinutes)*time.Minute >= stripeIdentifierWindow {
+		return fmt.Errorf("stripe_metering: interval_minutes must be less than a day")
+	}
+	if len(c.Meters) == 0 {
+		return fmt.Errorf("stripe_metering: at least one meter is required")
+	}
+	seen := make(map[string]bool)
+	for i, meter := range c.Meters {
+		if meter.EventName == "" {
+			return fmt.Errorf("stripe_metering: meter %d: event_name is required", i)
+		}
+		if seen[meter.EventName] {
+			return fmt.Errorf("stripe_metering: duplicate meter %q", meter.EventName)
+		}
+		seen[meter.EventName] = true
+		switch meter.Metric {
+		case StripeMetricRequests, StripeMetricPromptTokens, StripeMetricCompletionTokens, StripeMetricTotalTokens, StripeMetricCostCents:
+		default:
+			return fmt.Errorf("stripe_metering: meter %q: invalid metric %q", meter.EventName, meter.Metric)
+		}
+	}
+	return nil
+}
+
+// metricValue returns the usage of a billing line in the unit of a metric
+func metricValue(line logstore.BillingLine, metric string) float64 {
+	switch metric {
+	case StripeMetricRequests:
+		return float64(line.Requests)
+	case StripeMetricPromptTokens:
+		return float64(line.PromptTokens)
+	case StripeMetricCompletionTokens:
+		return float64(line.CompletionTokens)
+	case StripeMetricTotalTokens:
+		return float64(line.TotalTokens)
+	case StripeMetricCostCents:
+		return line.Cost * 100
+	}
+	return 0
+}
+
+// StripeExportRecord is the outcome of exporting the usage of a customer in a window to a meter.
+type StripeExportRecord struct {
+	WindowStart    time.Time `json:"window_start"`
+	WindowEnd      time.Time `json:"window_end"` // Exclusive
+	Customer       string    `json:"customer"`
+	StripeCustomer string    `json:"stripe_customer,omitempty"`
+	EventName      string    `json:"event_name"`
+	Metric         string    `json:"metric"`
+	Value          int64     `json:"value"`
+	Identifier     string    `json:"identifier,omitempty"` // Idempotency identifier of the meter event
+	Status         string    `json:"status"`
+	Error          string    `json:"error,omitempty"`
+	ExportedAt     time.Time `json:"exported_at"`
+}
+
+// StripeReconciliationLine compares the usage of a customer in the logs with what was exported to a meter.
+type StripeReconciliationLine struct {
+	Customer       string  `json:"customer"`
+	StripeCustomer string  `json:"stripe_customer,omitempty"`
+	EventName      string  `json:"event_name"`
+	Metric         string  `json:"metric"`
+	Usage          float64 `json:"usage"`      // From the logs, in the unit of the metric
+	Exported       int64   `json:"exported"`   // Sent to Stripe
+	DryRun         int64   `json:"dry_run"`    // Computed by dry runs only
+	Difference     float64 `json:"difference"` // Usage not exported yet
+	FailedExports  int     `json:"failed"`     // Exports that failed
+	UnmappedUsage  bool    `json:"unmapped"`   // The customer has no Stripe customer
+}
+
+// StripeMeteringExporter pushes the usage of billing customers to Stripe meters, one meter event per customer,
+// meter and window. Windows are exported by the cluster leader; meter event identifiers are derived from the
+// window, so a window re-sent after a failure or a leadership change is deduplicated by Stripe.
+type StripeMeteringExporter struct {
+	config   StripeMeteringConfig
+	interval time.Duration
+	settle   time.Duration
+	store    logstore.LogStore
+	leader   cluster.LeaderChecker
+	client   *http.Client
+
+	mu      sync.Mutex
+	next    time.Time          // Start of the next window to export
+	carry   map[string]float64 // Unreported fractions per customer and meter
+	history []StripeExportRecord
+}
+
+// NewStripeMeteringExporter creates an exporter, applying defaults to unset config values. leader may be nil
+// on a standalone replica.
+func NewStripeMeteringExporter(config StripeMeteringConfig, store logstore.LogStore, leader cluster.LeaderChecker) *StripeMeteringExporter {
+	if config.IntervalMinutes <= 0 {
+		config.IntervalMinutes = DefaultStripeMeteringIntervalMinutes
+	}
+	if config.SettleSeconds <= 0 {
+		config.SettleSeconds = DefaultStripeMeteringSettleSeconds
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultStripeBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	return &StripeMeteringExporter{
+		config:   config,
+		interval: time.Duration(config.IntervalMinutes) * time.Minute,
+		settle:   time.Duration(config.SettleSeconds) * time.Second,
+		store:    store,
+		leader:   leader,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		carry:    make(map[string]float64),
+	}
+}
+
+// DryRun reports whether exports are computed without being sent.
+func (e *StripeMeteringExporter) DryRun() bool {
+	return e.config.DryRun
+}
+
+// Start exports closed windows in the background until ctx is done. The window before the current one is
+// exported again on start, so usage is not lost across restarts.
+func (e *StripeMeteringExporter) Start(ctx context.Context) {
+	now := time.Now().UTC()
+	e.mu.Lock()
+	e.next = now.Truncate(e.interval).Add(-e.interval)
+	e.mu.Unlock()
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.exportDue(ctx, time.Now().UTC())
+			}
+		}
+	}()
+}
+
+// exportDue exports the windows that closed and settled before now, stopping at the first failing one
+func (e *StripeMeteringExporter) exportDue(ctx context.Context, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Windows older than the deduplication window of Stripe are never re-sent
+	horizon := now.Add(-stripeIdentifierWindow).Truncate(e.interval).Add(e.interval)
+	if e.next.Before(horizon) {
+		logger.Warn("stripe metering: skipping usage before %s, it can no longer be exported safely", horizon.Format(time.RFC3339))
+		e.next = horizon
+	}
+	if e.leader != nil && !e.leader.IsLeader() {
+		return
+	}
+	for {
+		start := e.next
+		end := start.Add(e.interval)
+		if end.Add(e.settle).After(now) {
+			return
+		}
+		if _, err := e.exportWindow(ctx, start, end, e.config.DryRun, true); err != nil {
+			logger.Warn("stripe metering: failed to export usage of %s - %s, retrying: %v", start.Format(time.RFC3339), end.Format(time.RFC3339), err)
+			return
+		}
+		e.next = end
+	}
+}
+
+// Export exports the usage of [start, end) right away, without carrying fractions over, and returns the records.
+// Dry runs compute the records without sending them.
+func (e *StripeMeteringExporter) Export(ctx context.Context, start, end time.Time, dryRun bool) ([]StripeExportRecord, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.exportWindow(ctx, start, end, dryRun || e.config.DryRun, false)
+}
+
+// exportWindow sends one meter event per customer and meter for the usage of a window. With carryOver, fractions
+// of a unit are added to the next window once every event of the window was sent. Callers hold e.mu.
+func (e *StripeMeteringExporter) exportWindow(ctx context.Context, start, end time.Time, dryRun, carryOver bool) ([]StripeExportRecord, error) {
+	lines, err := e.store.BillingReport(ctx, logstore.BillingFilters{StartTime: &start, EndTime: &end})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	var records []StripeExportRecord
+	carry := make(map[string]float64, len(e.carry))
+	var failed int
+	for _, line := range lines {
+		stripeCustomer := e.stripeCustomer(line.Customer)
+		for _, meter := range e.config.Meters {
+			record := StripeExportRecord{
+				WindowStart:    start,
+				WindowEnd:      end,
+				Customer:       line.Customer,
+				StripeCustomer: stripeCustomer,
+				EventName:      meter.EventName,
+				Metric:         meter.Metric,
+				ExportedAt:     time.Now().UTC(),
+			}
+			usage := metricValue(line, meter.Metric)
+			carryKey := line.Customer + "\x00" + meter.EventName
+			if carryOver {
+				usage += e.carry[carryKey]
+			}
+			record.Value = int64(math.Floor(usage + 1e-9))
+			if carryOver {
+				carry[carryKey] = usage - float64(record.Value)
+			}
+			if record.Value <= 0 {
+				continue
+			}
+			switch {
+			case stripeCustomer == "":
+				record.Status = StripeExportUnmapped
+			case dryRun:
+				record.Identifier = stripeEventIdentifier(meter.EventName, stripeCustomer, start, end)
+				record.Status = StripeExportDryRun
+			default:
+				record.Identifier = stripeEventIdentifier(meter.EventName, stripeCustomer, start, end)
+				if err := e.sendMeterEvent(ctx, record); err != nil {
+					record.Status = StripeExportFailed
+					record.Error = err.Error()
+					failed++
+				} else {
+					record.Status = StripeExportSent
+				}
+			}
+			records = append(records, record)
+		}
+	}
+	e.remember(records)
+	if failed > 0 {
+		return records, fmt.Errorf("%d meter events failed", failed)
+	}
+	if carryOver && !dryRun {
+		e.carry = carry
+	}
+	return records, nil
+}
+
+// stripeCustomer returns the Stripe customer of a billing customer, or "" when it has none
+func (e *StripeMeteringExporter) stripeCustomer(customer string) string {
+	if stripeCustomer, ok := e.config.Customers[customer]; ok {
+		return stripeCustomer
+	}
+	if strings.HasPrefix(customer, "cus_") {
+		return customer
+	}
+	return ""
+}
+
+// stripeEventIdentifier returns the idempotency identifier of the meter event of a customer and window
+func stripeEventIdentifier(eventName, stripeCustomer string, start, end time.Time) string {
+	sum := sha256.Sum256([]byte(eventName + "\x00" + stripeCustomer + "\x00" + start.UTC().Format(time.RFC3339) + "\x00" + end.UTC().Format(time.RFC3339)))
+	return "bifrost_" + hex.EncodeToString(sum[:16])
+}
+
+// sendMeterEvent creates a Stripe meter event; the event is dated at the last second of its window
+func (e *StripeMeteringExporter) sendMeterEvent(ctx context.Context, record StripeExportRecord) error {
+	form := url.Values{}
+	form.Set("event_name", record.EventName)
+	form.Set("identifier", record.Identifier)
+	form.Set("timestamp", strconv.FormatInt(record.WindowEnd.Add(-time.Second).Unix(), 10))
+	form.Set("payload[stripe_customer_id]", record.StripeCustomer)
+	form.Set("payload[value]", strconv.FormatInt(record.Value, 10))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.BaseURL+"/v1/billing/meter_events", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+e.config.APIKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	var stripeErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &stripeErr) == nil && stripeErr.Error.Message != "" {
+		return fmt.Errorf("stripe returned status %d: %s", resp.StatusCode, stripeErr.Error.Message)
+	}
+	return fmt.Errorf("stripe returned status %d", resp.StatusCode)
+}
+
+// remember appends records to the bounded export history. Callers hold e.mu.
+func (e *StripeMeteringExporter) remember(records []StripeExportRecord) {
+	e.history = append(e.history, records...)
+	if overflow := len(e.history) - stripeMeteringHistorySize; overflow > 0 {
+		e.history = append([]StripeExportRecord(nil), e.history[overflow:]...)
+	}
+}
+
+// Records returns the most recent export records, newest first.
+func (e *StripeMeteringExporter) Records(limit int) []StripeExportRecord {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if limit <= 0 || limit > len(e.history) {
+		limit = len(e.history)
+	}
+	records := make([]StripeExportRecord, 0, limit)
+	for i := len(e.history) - 1; i >= 0 && len(records) < limit; i-- {
+		records = append(records, e.history[i])
+	}
+	return records
+}
+
+// Reconcile compares the usage in the logs between start and end with the exports of windows within that range.
+// Exports are remembered in memory, so only those made by this replica since it started are counted.
+func (e *StripeMeteringExporter) Reconcile(ctx context.Context, start, end time.Time) ([]StripeReconciliationLine, error) {
+	lines, err := e.store.BillingReport(ctx, logstore.BillingFilters{StartTime: &start, EndTime: &end})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %w", err)
+	}
+	byKey := make(map[string]*StripeReconciliationLine)
+	entry := func(customer string, meter StripeMeter) *StripeReconciliationLine {
+		key := customer + "\x00" + meter.EventName
+		if line, ok := byKey[key]; ok {
+			return line
+		}
+		stripeCustomer := e.stripeCustomer(customer)
+		line := &StripeReconciliationLine{Customer: customer, StripeCustomer: stripeCustomer, EventName: meter.EventName, Metric: meter.Metric, UnmappedUsage: stripeCustomer == ""}
+		byKey[key] = line
+		return line
+	}
+	for _, line := range lines {
+		for _, meter := range e.config.Meters {
+			entry(line.Customer, meter).Usage += metricValue(line, meter.Metric)
+		}
+	}
+
+	meters := make(map[string]StripeMeter, len(e.config.Meters))
+	for _, meter := range e.config.Meters {
+		meters[meter.EventName] = meter
+	}
+	e.mu.Lock()
+	// A window exported several times (retries, manual exports) counts once, with its latest outcome
+	latest := make(map[string]StripeExportRecord)
+	for _, record := range e.history {
+		if record.WindowStart.Before(start) || record.WindowEnd.After(end) {
+			continue
+		}
+		latest[record.Customer+"\x00"+record.EventName+"\x00"+record.WindowStart.String()+"\x00"+record.WindowEnd.String()] = record
+	}
+	e.mu.Unlock()
+	for _, record := range latest {
+		meter, ok := meters[record.EventName]
+		if !ok {
+			continue
+		}
+		line := entry(record.Customer, meter)
+		switch record.Status {
+		case StripeExportSent:
+			line.Exported += record.Value
+		case StripeExportDryRun:
+			line.DryRun += record.Value
+		case StripeExportFailed:
+			line.FailedExports++
+		}
+	}
+
+	result := make([]StripeReconciliationLine, 0, len(byKey))
+	for _, line := range byKey {
+		line.Difference = line.Usage - float64(line.Exported)
+		result = append(result, *line)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Customer != result[j].Customer {
+			return result[i].Customer < result[j].Customer
+		}
+		return result[i].EventName < result[j].EventName
+	})
+	return result, nil
+}
+
+// initStripeMetering creates the Stripe usage exporter; it is started once the server runs.
+func (s *Config) initStripeMetering(config *StripeMeteringConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if s.LogsStore == nil {
+		return fmt.Errorf("stripe_metering: a logs store is required")
+	}
+	resolved := *config
+	if resolved.APIKey != "" {
+		apiKey, _, err := s.processEnvValue(resolved.APIKey)
+		if err != nil {
+			return fmt.Errorf("stripe_metering: %w", err)
+		}
+		resolved.APIKey = apiKey
+	}
+	var leader cluster.LeaderChecker
+	if s.LeaderElector != nil {
+		leader = s.LeaderElector
+	}
+	s.StripeMetering = NewStripeMeteringExporter(resolved, s.LogsStore, leader)
+	return nil
+}
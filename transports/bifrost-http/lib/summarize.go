@@ -0,0 +1,79 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// summaryPrompt instructs the summarize model to fold older messages into a running summary.
+const summaryPrompt = "You maintain the running summary of a conversation between a user and an assistant. " +
+	"Merge the existing summary and the transcript of older messages into one concise summary that keeps the facts, " +
+	"decisions, names and open questions needed to continue the conversation. Reply with the summary only."
+
+// SummarizeMessages asks model, in provider/model form, to merge messages into an existing summary
+// and returns the new summary. It is used to keep conversations within token budgets.
+func (s *Config) SummarizeMessages(ctx context.Context, model string, summary string, messages []schemas.ChatMessage) (string, error) {
+	client := s.GetBifrostClient()
+	if client == nil {
+		return "", fmt.Errorf("bifrost client is not initialized")
+	}
+	provider, modelName := schemas.ParseModelString(model, "")
+	if provider == "" || modelName == "" {
+		return "", fmt.Errorf("summarize model should be in provider/model format")
+	}
+
+	var transcript strings.Builder
+	if summary != "" {
+		transcript.WriteString("Existing summary:\n" + summary + "\n\n")
+	}
+	transcript.WriteString("Older messages:\n")
+	for _, message := range messages {
+		if text := ChatMessageText(message); text != "" {
+			transcript.WriteString(string(message.Role) + ": " + text + "\n")
+		}
+	}
+	prompt := summaryPrompt
+	content := transcript.String()
+	resp, bifrostErr := client.ChatCompletionRequest(ctx, &schemas.BifrostChatRequest{
+		Provider: schemas.ModelProvider(provider),
+		Model:    modelName,
+		Input: []schemas.ChatMessage{
+			{Role: schemas.ChatMessageRoleSystem, Content: &schemas.ChatMessageContent{ContentStr: &prompt}},
+			{Role: schemas.ChatMessageRoleUser, Content: &schemas.ChatMessageContent{ContentStr: &content}},
+		},
+	})
+	if bifrostErr != nil {
+		if bifrostErr.Error != nil {
+			return "", fmt.Errorf("summarization failed: %s", bifrostErr.Error.Message)
+		}
+		return "", fmt.Errorf("summarization failed")
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].BifrostNonStreamResponseChoice == nil || resp.Choices[0].Message == nil {
+		return "", fmt.Errorf("summarization returned no message")
+	}
+	result := strings.TrimSpace(ChatMessageText(*resp.Choices[0].Message))
+	if result == "" {
+		return "", fmt.Errorf("summarization returned an empty summary")
+	}
+	return result, nil
+}
+
+// ChatMessageText returns the text content of a chat message.
+func ChatMessageText(message schemas.ChatMessage) string {
+	if message.Content == nil {
+		return ""
+	}
+	if message.Content.ContentStr != nil {
+		return *message.Content.ContentStr
+	}
+	var parts []string
+	for _, block := range message.Content.ContentBlocks {
+		if block.Text != nil {
+			parts = append(parts, *block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,80 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SystemModeConfigKey is the config store key holding the system mode.
+const SystemModeConfigKey = "system_mode"
+
+// DefaultMaintenanceRetryAfter is the Retry-After sent during maintenance when none is configured.
+const DefaultMaintenanceRetryAfter = 60
+
+// SystemMode holds the runtime toggles that take Bifrost out of normal operation.
+type SystemMode struct {
+	// Maintenance rejects inference requests with 503 while the management API and UI stay reachable
+	Maintenance bool `json:"maintenance"`
+	// ReadOnly rejects changes made through the management API
+	ReadOnly bool `json:"read_only"`
+	// Message is shown to clients and in the UI banner
+	Message string `json:"message,omitempty"`
+	// RetryAfterSeconds is sent in the Retry-After header of requests rejected for maintenance
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+// Validate checks the system mode.
+func (m SystemMode) Validate() error {
+	if m.RetryAfterSeconds < 0 {
+		return errors.New("retry_after_seconds must not be negative")
+	}
+	return nil
+}
+
+// RetryAfter returns the Retry-After of requests rejected for maintenance, in seconds.
+func (m SystemMode) RetryAfter() int {
+	if m.RetryAfterSeconds > 0 {
+		return m.RetryAfterSeconds
+	}
+	return DefaultMaintenanceRetryAfter
+}
+
+// GetSystemMode returns the current system mode.
+func (s *Config) GetSystemMode() SystemMode {
+	mode := s.systemMode.Load()
+	if mode == nil {
+		return SystemMode{}
+	}
+	return *mode
+}
+
+// UpdateSystemMode validates and activates a system mode, persisting it in the config store when one is configured
+// so it survives restarts.
+func (s *Config) UpdateSystemMode(ctx context.Context, mode SystemMode) error {
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	mode.UpdatedAt = &now
+	if err := s.saveStoredConfig(ctx, SystemModeConfigKey, mode); err != nil {
+		return fmt.Errorf("failed to save system mode: %w", err)
+	}
+	s.systemMode.Store(&mode)
+	return nil
+}
+
+// loadSystemMode activates the system mode saved in the config store.
+func (s *Config) loadSystemMode(ctx context.Context) error {
+	var mode SystemMode
+	found, err := s.loadStoredConfig(ctx, SystemModeConfigKey, &mode)
+	if err != nil {
+		return fmt.Errorf("failed to load system mode: %w", err)
+	}
+	if found {
+		s.systemMode.Store(&mode)
+	}
+	return nil
+}
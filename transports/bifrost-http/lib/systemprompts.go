@@ -0,0 +1,213 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// SystemPromptPoliciesConfigKey is the config store key holding the system prompt policies.
+const SystemPromptPoliciesConfigKey = "system_prompt_policies"
+
+// SystemPromptPolicy is a mandatory system prompt prefix and/or suffix injected into matching chat and responses requests.
+//
+// Ordering is fixed regardless of what the client sends: the prefixes of all matching policies come first,
+// in policy order, then the client's own system prompt, then the suffixes of all matching policies, in policy order.
+type SystemPromptPolicy struct {
+	Name   string                  `json:"name"`
+	Match  SystemPromptPolicyMatch `json:"match"`
+	Prefix string                  `json:"prefix,omitempty"`
+	Suffix string                  `json:"suffix,omitempty"`
+}
+
+// SystemPromptPolicyMatch selects the requests a policy applies to. Empty lists match everything.
+// Patterns ending in * match by prefix.
+type SystemPromptPolicyMatch struct {
+	VirtualKeys []string `json:"virtual_keys,omitempty"` // Virtual key IDs or names
+	Teams       []string `json:"teams,omitempty"`        // Team IDs or names, from the virtual key or the x-bf-team header
+	Models      []string `json:"models,omitempty"`       // Requested model names
+}
+
+// SystemPromptSubject identifies the caller of a request for policy matching.
+type SystemPromptSubject struct {
+	VirtualKeys []string // ID and name of the virtual key used
+	Teams       []string // ID and name of the team
+	Model       string
+}
+
+// ValidateSystemPromptPolicies checks that every policy is named uniquely and injects something.
+func ValidateSystemPromptPolicies(policies []SystemPromptPolicy) error {
+	names := make(map[string]struct{}, len(policies))
+	for i, policy := range policies {
+		if policy.Name == "" {
+			return fmt.Errorf("policy %d: name is required", i)
+		}
+		if _, ok := names[policy.Name]; ok {
+			return fmt.Errorf("policy %s: duplicate name", policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+		if strings.TrimSpace(policy.Prefix) == "" && strings.TrimSpace(policy.Suffix) == "" {
+			return fmt.Errorf("policy %s: prefix or suffix is required", policy.Name)
+		}
+	}
+	return nil
+}
+
+// ApplySystemPromptPolicies injects the prefixes and suffixes of the policies matching subject into req.
+// req is left untouched, since fallback attempts are derived from it; the injected request is returned
+// along with the names of the applied policies. req is returned as is when no policy applies.
+func ApplySystemPromptPolicies(policies []SystemPromptPolicy, subject SystemPromptSubject, req *schemas.BifrostRequest) (*schemas.BifrostRequest, []string) {
+	if req.ChatRequest == nil && req.ResponsesRequest == nil {
+		return req, nil
+	}
+
+	var prefixes, suffixes []string
+	var applied []string
+	for _, policy := range policies {
+		if !matchesAnyOf(policy.Match.VirtualKeys, subject.VirtualKeys) || !matchesAnyOf(policy.Match.Teams, subject.Teams) ||
+			!matchesAny(policy.Match.Models, subject.Model) {
+			continue
+		}
+		if policy.Prefix != "" {
+			prefixes = append(prefixes, policy.Prefix)
+		}
+		if policy.Suffix != "" {
+			suffixes = append(suffixes, policy.Suffix)
+		}
+		applied = append(applied, policy.Name)
+	}
+	if len(applied) == 0 {
+		return req, nil
+	}
+
+	injected := *req
+	if req.ChatRequest != nil {
+		chatReq := *req.ChatRequest
+		chatReq.Input = injectChatSystemPrompt(chatReq.Input, prefixes, suffixes)
+		injected.ChatRequest = &chatReq
+	}
+	if req.ResponsesRequest != nil {
+		responsesReq := *req.ResponsesRequest
+		params := schemas.ResponsesParameters{}
+		if responsesReq.Params != nil {
+			params = *responsesReq.Params
+		}
+		instructions := ""
+		if params.Instructions != nil {
+			instructions = *params.Instructions
+		}
+		instructions = joinSystemPrompt(prefixes, instructions, suffixes)
+		params.Instructions = &instructions
+		responsesReq.Params = &params
+		injected.ResponsesRequest = &responsesReq
+	}
+	return &injected, applied
+}
+
+// injectChatSystemPrompt returns a copy of messages with the prefixes added to the first leading system message
+// and the suffixes to the last one. Without a leading system message a new one is inserted.
+func injectChatSystemPrompt(messages []schemas.ChatMessage, prefixes, suffixes []string) []schemas.ChatMessage {
+	leading := 0
+	for leading < len(messages) && (messages[leading].Role == schemas.ChatMessageRoleSystem || messages[leading].Role == schemas.ChatMessageRoleDeveloper) {
+		leading++
+	}
+	if leading == 0 {
+		content := joinSystemPrompt(prefixes, "", suffixes)
+		return append([]schemas.ChatMessage{{
+			Role:    schemas.ChatMessageRoleSystem,
+			Content: &schemas.ChatMessageContent{ContentStr: &content},
+		}}, messages...)
+	}
+
+	result := append([]schemas.ChatMessage(nil), messages...)
+	result[0].Content = extendChatContent(result[0].Content, prefixes, nil)
+	result[leading-1].Content = extendChatContent(result[leading-1].Content, nil, suffixes)
+	return result
+}
+
+// extendChatContent returns a copy of content with the prefixes before and the suffixes after the existing text.
+func extendChatContent(content *schemas.ChatMessageContent, prefixes, suffixes []string) *schemas.ChatMessageContent {
+	if content == nil || content.ContentBlocks == nil {
+		text := ""
+		if content != nil && content.ContentStr != nil {
+			text = *content.ContentStr
+		}
+		text = joinSystemPrompt(prefixes, text, suffixes)
+		return &schemas.ChatMessageContent{ContentStr: &text}
+	}
+
+	blocks := make([]schemas.ChatContentBlock, 0, len(prefixes)+len(content.ContentBlocks)+len(suffixes))
+	for _, prefix := range prefixes {
+		blocks = append(blocks, schemas.ChatContentBlock{Type: schemas.ChatContentBlockTypeText, Text: &prefix})
+	}
+	blocks = append(blocks, content.ContentBlocks...)
+	for _, suffix := range suffixes {
+		blocks = append(blocks, schemas.ChatContentBlock{Type: schemas.ChatContentBlockTypeText, Text: &suffix})
+	}
+	return &schemas.ChatMessageContent{ContentBlocks: blocks}
+}
+
+// joinSystemPrompt joins the prefixes, the client prompt and the suffixes into one prompt, separated by blank lines.
+func joinSystemPrompt(prefixes []string, prompt string, suffixes []string) string {
+	parts := make([]string, 0, len(prefixes)+1+len(suffixes))
+	parts = append(parts, prefixes...)
+	if prompt != "" {
+		parts = append(parts, prompt)
+	}
+	parts = append(parts, suffixes...)
+	return strings.Join(parts, "\n\n")
+}
+
+// matchesAnyOf reports whether one of values matches one of the patterns. An empty pattern list matches everything.
+func matchesAnyOf(patterns []string, values []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if matchesAny(patterns, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSystemPromptPolicies returns the active system prompt policies.
+func (s *Config) GetSystemPromptPolicies() []SystemPromptPolicy {
+	policies := s.systemPromptPolicies.Load()
+	if policies == nil {
+		return nil
+	}
+	return *policies
+}
+
+// UpdateSystemPromptPolicies validates and activates policies, persisting them in the config store when one is configured.
+func (s *Config) UpdateSystemPromptPolicies(ctx context.Context, policies []SystemPromptPolicy) error {
+	if err := ValidateSystemPromptPolicies(policies); err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, SystemPromptPoliciesConfigKey, policies); err != nil {
+		return fmt.Errorf("failed to save system prompt policies: %w", err)
+	}
+	s.systemPromptPolicies.Store(&policies)
+	return nil
+}
+
+// loadSystemPromptPolicies activates the policies saved in the config store. Without saved policies,
+// the policies from the config file are used and saved to bootstrap the store.
+func (s *Config) loadSystemPromptPolicies(ctx context.Context, filePolicies []SystemPromptPolicy) error {
+	var policies []SystemPromptPolicy
+	found, err := s.loadStoredConfig(ctx, SystemPromptPoliciesConfigKey, &policies)
+	if err != nil {
+		return fmt.Errorf("failed to load system prompt policies: %w", err)
+	}
+	if found {
+		s.systemPromptPolicies.Store(&policies)
+		return nil
+	}
+	if len(filePolicies) == 0 {
+		return nil
+	}
+	return s.UpdateSystemPromptPolicies(ctx, filePolicies)
+}
@@ -0,0 +1,181 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+)
+
+// TenantDomainsConfigKey is the config store key holding the tenant domains.
+const TenantDomainsConfigKey = "tenant_domains"
+
+// TenantContextKey holds the *TenantDomain of the host a request was sent to, set by the tenant middleware.
+const TenantContextKey ContextKey = "bifrost-tenant"
+
+// DefaultTenantScopes are the scopes of tenant sessions when a tenant domain configures none.
+var DefaultTenantScopes = []serviceaccounts.Scope{serviceaccounts.ScopeConfigRead, serviceaccounts.ScopeLogsRead}
+
+// TenantDomain maps a custom hostname to a tenant. The dashboard served on the host shows the tenant's name and
+// logos, and with a password, its login page signs tenant members in with sessions limited to the tenant's scopes.
+// Requests sent to the host are attributed to the tenant's governance customer.
+type TenantDomain struct {
+	Host        string                  `json:"host"`                    // Hostname, e.g. "ai.acme.com"
+	Name        string                  `json:"name"`                    // Product name shown in the dashboard, e.g. "Acme AI"
+	Customer    string                  `json:"customer,omitempty"`      // Governance customer ID, replacing x-bf-customer on requests to the host
+	LogoURL     string                  `json:"logo_url,omitempty"`      // Logo shown in the light theme
+	LogoDarkURL string                  `json:"logo_dark_url,omitempty"` // Logo shown in the dark theme (default: logo_url)
+	Password    string                  `json:"password,omitempty"`      // Tenant login password, or env.VAR; redacted in the API
+	Scopes      []serviceaccounts.Scope `json:"scopes,omitempty"`        // Scopes of tenant sessions (default: config:read, logs:read)
+
+	password string // Password with environment variable references resolved
+}
+
+// TenantBranding is the branding of the dashboard served on a host, injected into its HTML.
+type TenantBranding struct {
+	Name        string `json:"name"`
+	LogoURL     string `json:"logo_url,omitempty"`
+	LogoDarkURL string `json:"logo_dark_url,omitempty"`
+}
+
+// Branding returns the branding of the tenant.
+func (t *TenantDomain) Branding() TenantBranding {
+	branding := TenantBranding{Name: t.Name, LogoURL: t.LogoURL, LogoDarkURL: t.LogoDarkURL}
+	if branding.LogoDarkURL == "" {
+		branding.LogoDarkURL = branding.LogoURL
+	}
+	return branding
+}
+
+// LoginPassword returns the resolved password of tenant logins, empty when tenant members cannot sign in.
+func (t *TenantDomain) LoginPassword() string {
+	return t.password
+}
+
+// SessionScopes returns the scopes of tenant sessions.
+func (t *TenantDomain) SessionScopes() []serviceaccounts.Scope {
+	if len(t.Scopes) == 0 {
+		return DefaultTenantScopes
+	}
+	return t.Scopes
+}
+
+// Allows reports whether tenant sessions are granted a scope.
+func (t *TenantDomain) Allows(scope serviceaccounts.Scope) bool {
+	for _, s := range t.SessionScopes() {
+		if s == scope || s == serviceaccounts.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTenantDomains checks that the hosts are bare lowercase hostnames mapped once, and that tenants are named
+// and scoped validly.
+func ValidateTenantDomains(domains []TenantDomain) error {
+	hosts := make(map[string]struct{}, len(domains))
+	for i, domain := range domains {
+		if domain.Host == "" {
+			return fmt.Errorf("tenant domain %d: host is required", i)
+		}
+		if domain.Host != strings.ToLower(domain.Host) || strings.ContainsAny(domain.Host, ":/ ") {
+			return fmt.Errorf("tenant domain %s: host must be a lowercase hostname without scheme or port", domain.Host)
+		}
+		if _, ok := hosts[domain.Host]; ok {
+			return fmt.Errorf("tenant domain %s: duplicate host", domain.Host)
+		}
+		hosts[domain.Host] = struct{}{}
+		if domain.Name == "" {
+			return fmt.Errorf("tenant domain %s: name is required", domain.Host)
+		}
+		for _, scope := range domain.Scopes {
+			if !serviceaccounts.ValidScope(scope) {
+				return fmt.Errorf("tenant domain %s: unknown scope %q", domain.Host, scope)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveTenant returns the tenant domain of a Host header value, or nil when the host is not a tenant domain.
+func (s *Config) ResolveTenant(host string) *TenantDomain {
+	domains := s.tenantDomains.Load()
+	if domains == nil || len(*domains) == 0 {
+		return nil
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for i := range *domains {
+		if (*domains)[i].Host == host {
+			return &(*domains)[i]
+		}
+	}
+	return nil
+}
+
+// GetTenantDomains returns the active tenant domains.
+func (s *Config) GetTenantDomains() []TenantDomain {
+	domains := s.tenantDomains.Load()
+	if domains == nil {
+		return nil
+	}
+	return *domains
+}
+
+// UpdateTenantDomains validates and activates tenant domains, persisting them in the config store when one is
+// configured.
+func (s *Config) UpdateTenantDomains(ctx context.Context, domains []TenantDomain) error {
+	if err := ValidateTenantDomains(domains); err != nil {
+		return err
+	}
+	resolved, err := s.resolveTenantDomains(domains)
+	if err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, TenantDomainsConfigKey, domains); err != nil {
+		return fmt.Errorf("failed to save tenant domains: %w", err)
+	}
+	s.tenantDomains.Store(&resolved)
+	return nil
+}
+
+// resolveTenantDomains returns a copy of the tenant domains with the passwords referencing environment variables
+// resolved.
+func (s *Config) resolveTenantDomains(domains []TenantDomain) ([]TenantDomain, error) {
+	resolved := make([]TenantDomain, len(domains))
+	for i, domain := range domains {
+		password, _, err := s.processEnvValue(domain.Password)
+		if err != nil {
+			return nil, fmt.Errorf("tenant domain %s: password: %w", domain.Host, err)
+		}
+		domain.password = password
+		resolved[i] = domain
+	}
+	return resolved, nil
+}
+
+// loadTenantDomains activates the tenant domains saved in the config store. Without saved domains, the ones from
+// the config file are used and saved to bootstrap the store.
+func (s *Config) loadTenantDomains(ctx context.Context, fileDomains []TenantDomain) error {
+	var domains []TenantDomain
+	found, err := s.loadStoredConfig(ctx, TenantDomainsConfigKey, &domains)
+	if err != nil {
+		return fmt.Errorf("failed to load tenant domains: %w", err)
+	}
+	if found {
+		resolved, err := s.resolveTenantDomains(domains)
+		if err != nil {
+			return err
+		}
+		s.tenantDomains.Store(&resolved)
+		return nil
+	}
+	if fileDomains == nil {
+		return nil
+	}
+	return s.UpdateTenantDomains(ctx, fileDomains)
+}
@@ -0,0 +1,336 @@
+package lib
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/framework/redaction"
+)
+
+// RequestTraceContextKey stores the *RequestTrace of a request as a fasthttp user value.
+const RequestTraceContextKey ContextKey = "bifrost-request-trace"
+
+// DefaultMaxTraces is the number of most recent request traces kept unless max_traces is set.
+const DefaultMaxTraces = 1000
+
+// TracesConfig configures the timelines of inference requests kept in memory for GET /api/traces/{request_id}.
+// Requests are traced when they are POSTed outside /api/, and identified by their x-request-id header or a
+// generated ID returned in X-Request-Id.
+type TracesConfig struct {
+	Enabled        bool `json:"enabled"`
+	MaxTraces      int  `json:"max_traces,omitempty"`      // Most recent traces kept, default DefaultMaxTraces
+	UpstreamBodies bool `json:"upstream_bodies,omitempty"` // Keep the bodies of the exchanges with providers, which hold the prompts and completions
+}
+
+// Validate checks the trace limit.
+func (c *TracesConfig) Validate() error {
+	if c.MaxTraces < 0 {
+		return fmt.Errorf("traces: max_traces must not be negative")
+	}
+	return nil
+}
+
+// MiddlewareTiming is the time an HTTP middleware spent on a request, and what it changed in the request body,
+// such as the model rewritten by a routing rule.
+type MiddlewareTiming struct {
+	Name    string                `json:"name"`
+	Before  time.Duration         `json:"before"`            // Until it called the next handler, or returned when it answered itself
+	After   time.Duration         `json:"after"`             // Once the next handler returned
+	Stopped bool                  `json:"stopped,omitempty"` // Answered without calling the next handler
+	Changes []schemas.TraceChange `json:"changes,omitempty"` // Changes to the JSON request body before the next handler
+
+	started  time.Time
+	passed   time.Time
+	returned time.Time
+	body     []byte // Request body when it started
+}
+
+// InterceptorMutation is what the TransportInterceptor of a plugin changed in the headers and body of a request.
+type InterceptorMutation struct {
+	Plugin  string                `json:"plugin"`
+	Headers []schemas.TraceChange `json:"headers,omitempty"`
+	Body    []schemas.TraceChange `json:"body,omitempty"`
+	Error   string                `json:"error,omitempty"`
+	Latency time.Duration         `json:"latency"`
+}
+
+// TraceSummary answers why a request was slow or blocked.
+type TraceSummary struct {
+	MiddlewareLatency time.Duration     `json:"middleware_latency"`
+	PluginLatency     time.Duration     `json:"plugin_latency"`   // Interceptors and hooks
+	ProviderLatency   time.Duration     `json:"provider_latency"` // Provider attempts, retries and fallbacks included
+	Attempts          int               `json:"attempts"`
+	Fallbacks         int               `json:"fallbacks"`
+	StoppedBy         string            `json:"stopped_by,omitempty"` // Middleware or plugin that answered the request itself
+	Provider          string            `json:"provider,omitempty"`   // Provider and model of the last attempt
+	Model             string            `json:"model,omitempty"`
+	Usage             *schemas.LLMUsage `json:"usage,omitempty"` // Token usage of the attempt that answered
+}
+
+// RequestTimeline is the trace of a finished request.
+type RequestTimeline struct {
+	RequestID    string                        `json:"request_id"`
+	Method       string                        `json:"method"`
+	Path         string                        `json:"path"`
+	StartedAt    time.Time                     `json:"started_at"`
+	Duration     time.Duration                 `json:"duration"`
+	StatusCode   int                           `json:"status_code"`
+	Summary      TraceSummary                  `json:"summary"`
+	Middlewares  []MiddlewareTiming            `json:"middlewares"`
+	Interceptors []InterceptorMutation         `json:"interceptors,omitempty"`
+	Pipeline     schemas.PipelineTraceSnapshot `json:"pipeline"` // Plugin hooks, provider attempts and upstream exchanges
+}
+
+// RequestTrace records the timeline of a request as it goes through the middlewares, the transport interceptors
+// and the pipeline. Middlewares and interceptors run on the request goroutine; the pipeline records its steps
+// in its own PipelineTrace.
+type RequestTrace struct {
+	timeline    RequestTimeline
+	middlewares []*MiddlewareTiming
+	pipeline    *schemas.PipelineTrace
+}
+
+// NewRequestTrace starts the trace of a request.
+func NewRequestTrace(requestID, method, path string) *RequestTrace {
+	return &RequestTrace{
+		timeline: RequestTimeline{RequestID: requestID, Method: method, Path: path, StartedAt: time.Now()},
+		pipeline: &schemas.PipelineTrace{},
+	}
+}
+
+// Pipeline returns the trace the pipeline records its steps in.
+func (t *RequestTrace) Pipeline() *schemas.PipelineTrace {
+	return t.pipeline
+}
+
+// EnterMiddleware records that a middleware started handling the request, whose body was body.
+func (t *RequestTrace) EnterMiddleware(name string, body []byte) {
+	t.middlewares = append(t.middlewares, &MiddlewareTiming{Name: name, started: time.Now(), body: append([]byte(nil), body...)})
+}
+
+// PassMiddleware records that the innermost middleware still running called the next handler with body.
+func (t *RequestTrace) PassMiddleware(body []byte) {
+	for i := len(t.middlewares) - 1; i >= 0; i-- {
+		if m := t.middlewares[i]; m.passed.IsZero() && m.returned.IsZero() {
+			m.passed = time.Now()
+			m.Changes = schemas.DiffJSON(schemas.SnapshotJSONBytes(m.body), schemas.SnapshotJSONBytes(body))
+			m.body = nil
+			return
+		}
+	}
+}
+
+// ReturnMiddleware records that the innermost middleware still running returned.
+func (t *RequestTrace) ReturnMiddleware() {
+	for i := len(t.middlewares) - 1; i >= 0; i-- {
+		if m := t.middlewares[i]; m.returned.IsZero() {
+			m.returned = time.Now()
+			m.body = nil
+			return
+		}
+	}
+}
+
+// AddInterceptorMutation records what the TransportInterceptor of a plugin changed.
+func (t *RequestTrace) AddInterceptorMutation(mutation InterceptorMutation) {
+	t.timeline.Interceptors = append(t.timeline.Interceptors, mutation)
+}
+
+// traceContent is how a timeline keeps the content of its request: dropped for zero data retention requests,
+// otherwise scrubbed with the redaction policy of the tenant
+type traceContent struct {
+	redactor          *redaction.Redactor
+	tenant            string
+	zeroDataRetention bool
+}
+
+// finish ends the trace with the status code of the response and returns the timeline. Bodies of upstream
+// exchanges are dropped unless upstreamBodies is set, and content applies to what is kept.
+func (t *RequestTrace) finish(statusCode int, upstreamBodies bool, content traceContent) *RequestTimeline {
+	timeline := t.timeline
+	timeline.Duration = time.Since(timeline.StartedAt)
+	timeline.StatusCode = statusCode
+	timeline.Pipeline = t.pipeline.Snapshot()
+	summary := &timeline.Summary
+
+	timeline.Middlewares = make([]MiddlewareTiming, 0, len(t.middlewares))
+	for _, m := range t.middlewares {
+		timing := *m
+		if m.passed.IsZero() {
+			timing.Stopped = true
+			timing.Before = m.returned.Sub(m.started)
+			if summary.StoppedBy == "" {
+				summary.StoppedBy = m.Name
+			}
+		} else {
+			timing.Before = m.passed.Sub(m.started)
+		}
+		summary.MiddlewareLatency += timing.Before
+		timeline.Middlewares = append(timeline.Middlewares, timing)
+	}
+	// The time after the next handler returned is the time until the middleware returned, less the time the
+	// middlewares it wraps spent after their own next handler
+	for i := range timeline.Middlewares {
+		m := t.middlewares[i]
+		if m.passed.IsZero() || m.returned.IsZero() {
+			continue
+		}
+		innerReturned := m.passed
+		for j := i + 1; j < len(t.middlewares); j++ {
+			if !t.middlewares[j].returned.IsZero() {
+				innerReturned = t.middlewares[j].returned
+				break
+			}
+		}
+		if after := m.returned.Sub(innerReturned); after > 0 {
+			timeline.Middlewares[i].After = after
+			summary.MiddlewareLatency += after
+		}
+	}
+
+	for _, mutation := range timeline.Interceptors {
+		summary.PluginLatency += mutation.Latency
+	}
+	for _, step := range timeline.Pipeline.Steps {
+		switch step.Stage {
+		case schemas.PipelineTraceStagePreHook, schemas.PipelineTraceStagePostHook:
+			summary.PluginLatency += step.Latency
+			if step.Decision == schemas.PipelineTraceDecisionShortCircuit && summary.StoppedBy == "" {
+				summary.StoppedBy = step.Plugin
+			}
+		case schemas.PipelineTraceStageProvider:
+			summary.ProviderLatency += step.Latency
+			summary.Attempts++
+			summary.Provider, summary.Model = string(step.Provider), step.Model
+			if step.Usage != nil {
+				summary.Usage = step.Usage
+			}
+		case schemas.PipelineTraceStageFallback:
+			summary.Fallbacks++
+		}
+	}
+	if !upstreamBodies || content.zeroDataRetention {
+		for i := range timeline.Pipeline.Upstream {
+			timeline.Pipeline.Upstream[i].RequestBody = nil
+			timeline.Pipeline.Upstream[i].ResponseBody = nil
+		}
+	}
+	content.apply(&timeline)
+	return &timeline
+}
+
+// apply drops or redacts the changed values and upstream exchanges of a timeline
+func (c traceContent) apply(timeline *RequestTimeline) {
+	if !c.zeroDataRetention && c.redactor == nil {
+		return
+	}
+	for i := range timeline.Middlewares {
+		timeline.Middlewares[i].Changes = c.changes(timeline.Middlewares[i].Changes)
+	}
+	timeline.Interceptors = slices.Clone(timeline.Interceptors)
+	for i := range timeline.Interceptors {
+		timeline.Interceptors[i].Headers = c.changes(timeline.Interceptors[i].Headers)
+		timeline.Interceptors[i].Body = c.changes(timeline.Interceptors[i].Body)
+	}
+	for i := range timeline.Pipeline.Steps {
+		timeline.Pipeline.Steps[i].Changes = c.changes(timeline.Pipeline.Steps[i].Changes)
+	}
+	if c.zeroDataRetention {
+		return
+	}
+	for i := range timeline.Pipeline.Upstream {
+		exchange := &timeline.Pipeline.Upstream[i]
+		exchange.RequestBody = c.redactor.RedactJSON(c.tenant, "request_body", exchange.RequestBody)
+		exchange.ResponseBody = c.redactor.RedactJSON(c.tenant, "response_body", exchange.ResponseBody)
+	}
+}
+
+// changes returns a copy of changes keeping only their paths and rules for zero data retention requests, and
+// with their values redacted otherwise
+func (c traceContent) changes(changes []schemas.TraceChange) []schemas.TraceChange {
+	if len(changes) == 0 {
+		return changes
+	}
+	kept := make([]schemas.TraceChange, len(changes))
+	for i, change := range changes {
+		kept[i] = schemas.TraceChange{Path: change.Path, Rule: change.Rule}
+		if !c.zeroDataRetention {
+			kept[i].Old = c.redactor.RedactValueAt(c.tenant, change.Path, change.Old)
+			kept[i].New = c.redactor.RedactValueAt(c.tenant, change.Path, change.New)
+		}
+	}
+	return kept
+}
+
+// TraceStore keeps the timelines of the most recent requests.
+type TraceStore struct {
+	config   TracesConfig
+	redactor *redaction.Redactor // Scrubs the content kept in timelines (nil when redaction is off)
+	mu       sync.RWMutex
+	byID     map[string]*RequestTimeline
+	order    []string // Ring of the request IDs, oldest at next
+	next     int
+}
+
+// NewTraceStore creates a store keeping the most recent timelines.
+func NewTraceStore(config TracesConfig) *TraceStore {
+	if config.MaxTraces == 0 {
+		config.MaxTraces = DefaultMaxTraces
+	}
+	return &TraceStore{
+		config: config,
+		byID:   make(map[string]*RequestTimeline, config.MaxTraces),
+		order:  make([]string, 0, config.MaxTraces),
+	}
+}
+
+// SetRedactor sets the redaction policies applied to the content kept in timelines.
+func (s *TraceStore) SetRedactor(redactor *redaction.Redactor) {
+	s.redactor = redactor
+}
+
+// Finish ends a trace and keeps its timeline, evicting the oldest one when the store is full. A request ID
+// sent again replaces the timeline of the earlier request. The values changed by the request steps and the
+// upstream bodies are redacted with the policy of tenant, the virtual key of the request, or dropped when the
+// request falls under zero data retention.
+func (s *TraceStore) Finish(trace *RequestTrace, statusCode int, tenant string, zeroDataRetention bool) {
+	timeline := trace.finish(statusCode, s.config.UpstreamBodies, traceContent{redactor: s.redactor, tenant: tenant, zeroDataRetention: zeroDataRetention})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.byID[timeline.RequestID]; ok {
+		s.byID[timeline.RequestID] = timeline
+		return
+	}
+	if len(s.order) < s.config.MaxTraces {
+		s.order = append(s.order, timeline.RequestID)
+	} else {
+		delete(s.byID, s.order[s.next])
+		s.order[s.next] = timeline.RequestID
+		s.next = (s.next + 1) % len(s.order)
+	}
+	s.byID[timeline.RequestID] = timeline
+}
+
+// Get returns the timeline of a request, if it is still kept.
+func (s *TraceStore) Get(requestID string) (*RequestTimeline, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	timeline, ok := s.byID[requestID]
+	return timeline, ok
+}
+
+// initTraces sets up the trace store when tracing is enabled.
+func (s *Config) initTraces(config *TracesConfig) error {
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	s.Traces = NewTraceStore(*config)
+	s.Traces.SetRedactor(s.Redactor)
+	return nil
+}
@@ -0,0 +1,226 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TransformationRulesConfigKey is the config store key holding the transformation rules.
+const TransformationRulesConfigKey = "transformation_rules"
+
+// TransformationOp is an operation applied to the request body by a transformation rule.
+type TransformationOp string
+
+const (
+	TransformationOpSet          TransformationOp = "set"           // Set the field to value
+	TransformationOpDefault      TransformationOp = "default"       // Set the field to value when it is absent
+	TransformationOpRemove       TransformationOp = "remove"        // Remove the field
+	TransformationOpClamp        TransformationOp = "clamp"         // Clamp a numeric field into [min, max]
+	TransformationOpSystemPrompt TransformationOp = "system_prompt" // Prepend a system message with value as content to "messages"
+)
+
+// TransformationRule rewrites the body of inference requests matching all of its match conditions.
+// Rules are evaluated in order, before plugin transport interceptors, and every matching rule is applied.
+type TransformationRule struct {
+	Name       string                    `json:"name"`
+	Disabled   bool                      `json:"disabled,omitempty"`
+	Match      TransformationMatch       `json:"match"`
+	Operations []TransformationOperation `json:"operations"`
+}
+
+// TransformationMatch selects the requests a rule applies to. Empty lists match everything.
+// Patterns ending in * match by prefix.
+type TransformationMatch struct {
+	Paths       []string `json:"paths,omitempty"`        // Request paths, e.g. "/v1/chat/completions" or "/openai/*"
+	VirtualKeys []string `json:"virtual_keys,omitempty"` // Virtual key values sent in the x-bf-vk header
+	Providers   []string `json:"providers,omitempty"`    // Provider prefix of the model, e.g. "openai" in "openai/gpt-4o"
+	Models      []string `json:"models,omitempty"`       // Model without the provider prefix, e.g. "gpt-4o*"
+}
+
+// TransformationOperation is a single rewrite of the request body.
+type TransformationOperation struct {
+	Op    TransformationOp `json:"op"`
+	Field string           `json:"field,omitempty"` // Dot separated path in the request body, e.g. "temperature" or "params.top_p"
+	Value any              `json:"value,omitempty"` // Value for set, default and system_prompt
+	Min   *float64         `json:"min,omitempty"`   // Lower bound for clamp
+	Max   *float64         `json:"max,omitempty"`   // Upper bound for clamp
+}
+
+// ValidateTransformationRules checks that every rule is named uniquely and that its operations are well formed.
+func ValidateTransformationRules(rules []TransformationRule) error {
+	names := make(map[string]struct{}, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d: name is required", i)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return fmt.Errorf("rule %s: duplicate name", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+		if len(rule.Operations) == 0 {
+			return fmt.Errorf("rule %s: at least one operation is required", rule.Name)
+		}
+		for j, op := range rule.Operations {
+			if err := op.validate(); err != nil {
+				return fmt.Errorf("rule %s: operation %d: %w", rule.Name, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (o TransformationOperation) validate() error {
+	switch o.Op {
+	case TransformationOpSet, TransformationOpDefault, TransformationOpRemove:
+		if o.Field == "" {
+			return errors.New("field is required")
+		}
+	case TransformationOpClamp:
+		if o.Field == "" {
+			return errors.New("field is required")
+		}
+		if o.Min == nil && o.Max == nil {
+			return errors.New("min or max is required")
+		}
+		if o.Min != nil && o.Max != nil && *o.Min > *o.Max {
+			return errors.New("min must not exceed max")
+		}
+	case TransformationOpSystemPrompt:
+		if prompt, ok := o.Value.(string); !ok || prompt == "" {
+			return errors.New("value must be a non-empty string")
+		}
+	default:
+		return fmt.Errorf("unknown op %q", o.Op)
+	}
+	return nil
+}
+
+// ApplyTransformationRules applies the enabled rules matching the request to body in place
+// and returns the names of the rules that matched.
+func ApplyTransformationRules(rules []TransformationRule, path string, virtualKey string, body map[string]any) []string {
+	model, _ := body["model"].(string)
+	provider, modelName := "", model
+	if before, after, found := strings.Cut(model, "/"); found {
+		provider, modelName = before, after
+	}
+
+	var applied []string
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		if !matchesAny(rule.Match.Paths, path) || !matchesAny(rule.Match.VirtualKeys, virtualKey) ||
+			!matchesAny(rule.Match.Providers, provider) || !matchesAny(rule.Match.Models, modelName) {
+			continue
+		}
+		for _, op := range rule.Operations {
+			op.apply(body)
+		}
+		applied = append(applied, rule.Name)
+	}
+	return applied
+}
+
+// matchesAny reports whether value matches one of the patterns. An empty pattern list matches everything.
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		} else if pattern == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (o TransformationOperation) apply(body map[string]any) {
+	if o.Op == TransformationOpSystemPrompt {
+		messages, _ := body["messages"].([]any)
+		body["messages"] = append([]any{map[string]any{"role": "system", "content": o.Value}}, messages...)
+		return
+	}
+
+	// Walk to the object holding the last path segment, creating intermediate objects for set and default
+	keys := strings.Split(o.Field, ".")
+	parent := body
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := parent[key].(map[string]any)
+		if !ok {
+			if o.Op != TransformationOpSet && o.Op != TransformationOpDefault {
+				return
+			}
+			child = make(map[string]any)
+			parent[key] = child
+		}
+		parent = child
+	}
+	key := keys[len(keys)-1]
+
+	switch o.Op {
+	case TransformationOpSet:
+		parent[key] = o.Value
+	case TransformationOpDefault:
+		if _, ok := parent[key]; !ok {
+			parent[key] = o.Value
+		}
+	case TransformationOpRemove:
+		delete(parent, key)
+	case TransformationOpClamp:
+		value, ok := parent[key].(float64)
+		if !ok {
+			return
+		}
+		if o.Min != nil && value < *o.Min {
+			parent[key] = *o.Min
+		}
+		if o.Max != nil && value > *o.Max {
+			parent[key] = *o.Max
+		}
+	}
+}
+
+// GetTransformationRules returns the active transformation rules.
+func (s *Config) GetTransformationRules() []TransformationRule {
+	rules := s.transformationRules.Load()
+	if rules == nil {
+		return nil
+	}
+	return *rules
+}
+
+// UpdateTransformationRules validates and activates rules, persisting them in the config store when one is configured.
+func (s *Config) UpdateTransformationRules(ctx context.Context, rules []TransformationRule) error {
+	if err := ValidateTransformationRules(rules); err != nil {
+		return err
+	}
+	if err := s.saveStoredConfig(ctx, TransformationRulesConfigKey, rules); err != nil {
+		return fmt.Errorf("failed to save transformation rules: %w", err)
+	}
+	s.transformationRules.Store(&rules)
+	return nil
+}
+
+// loadTransformationRules activates the rules saved in the config store. Without saved rules,
+// the rules from the config file are used and saved to bootstrap the store.
+func (s *Config) loadTransformationRules(ctx context.Context, fileRules []TransformationRule) error {
+	var rules []TransformationRule
+	found, err := s.loadStoredConfig(ctx, TransformationRulesConfigKey, &rules)
+	if err != nil {
+		return fmt.Errorf("failed to load transformation rules: %w", err)
+	}
+	if found {
+		s.transformationRules.Store(&rules)
+		return nil
+	}
+	if len(fileRules) == 0 {
+		return nil
+	}
+	return s.UpdateTransformationRules(ctx, fileRules)
+}
@@ -0,0 +1,234 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maximhq/bifrost/framework/configstore"
+	"github.com/maximhq/bifrost/framework/serviceaccounts"
+)
+
+// UISessionTTL is how long a dashboard session lasts after its login.
+const UISessionTTL = 24 * time.Hour
+
+// UISessionTokenPrefix starts the internal tokens the UI proxy exchanges session cookies for, telling them apart
+// from the admin secret and service account tokens.
+const UISessionTokenPrefix = "bf-ui-"
+
+// UIProxyContextKey marks requests dispatched by the UI proxy, the only requests whose internal session tokens
+// are accepted.
+const UIProxyContextKey ContextKey = "bifrost-ui-proxy"
+
+// UISession is a dashboard session started by a login. The browser only holds the opaque session ID in its cookie;
+// the UI proxy exchanges it for the internal token of the session, which never leaves the server.
+type UISession struct {
+	ID        string                  `json:"-"`
+	Token     string                  `json:"-"`
+	Tenant    string                  `json:"tenant,omitempty"` // Tenant whose login started the session
+	Scopes    []serviceaccounts.Scope `json:"scopes,omitempty"` // Empty grants everything the admin secret allows
+	CreatedAt time.Time               `json:"created_at"`
+	ExpiresAt time.Time               `json:"expires_at"`
+}
+
+// Allows reports whether the session is granted a scope. The admin scope allows everything.
+func (s *UISession) Allows(scope serviceaccounts.Scope) bool {
+	if len(s.Scopes) == 0 {
+		return true
+	}
+	for _, granted := range s.Scopes {
+		if granted == scope || granted == serviceaccounts.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// uiSessions holds the dashboard sessions in memory, by ID and by token, when there is no config store. Sessions
+// kept in memory do not survive a restart and are only valid on the replica that started them.
+type uiSessions struct {
+	mu      sync.Mutex
+	byID    map[string]*UISession
+	byToken map[string]*UISession
+}
+
+// CreateUISession starts a dashboard session limited to scopes, every scope when empty. The session is stored in the
+// config store when there is one, so that every replica accepts it.
+func (s *Config) CreateUISession(ctx context.Context, tenant string, scopes []serviceaccounts.Scope) (*UISession, error) {
+	for _, scope := range scopes {
+		if !serviceaccounts.ValidScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	id, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	now := time.Now().UTC()
+	session := &UISession{
+		ID:        id,
+		Token:     UISessionTokenPrefix + token,
+		Tenant:    tenant,
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(UISessionTTL),
+	}
+
+	if s.ConfigStore != nil {
+		if err := s.ConfigStore.DeleteExpiredUISessions(ctx, now); err != nil {
+			logger.Warn("failed to delete expired dashboard sessions: %v", err)
+		}
+		if err := s.ConfigStore.CreateUISession(ctx, uiSessionToTable(session)); err != nil {
+			return nil, fmt.Errorf("failed to save session: %w", err)
+		}
+		return session, nil
+	}
+
+	s.uiSessions.mu.Lock()
+	defer s.uiSessions.mu.Unlock()
+	if s.uiSessions.byID == nil {
+		s.uiSessions.byID = make(map[string]*UISession)
+		s.uiSessions.byToken = make(map[string]*UISession)
+	}
+	s.pruneUISessions(now)
+	s.uiSessions.byID[session.ID] = session
+	s.uiSessions.byToken[session.Token] = session
+	return session, nil
+}
+
+// GetUISession returns the session with an ID, nil when it is unknown or expired.
+func (s *Config) GetUISession(ctx context.Context, id string) *UISession {
+	if s.ConfigStore != nil {
+		return s.storedUISession(s.ConfigStore.GetUISession(ctx, id))
+	}
+	s.uiSessions.mu.Lock()
+	defer s.uiSessions.mu.Unlock()
+	return activeUISession(s.uiSessions.byID[id])
+}
+
+// GetUISessionByToken returns the session with an internal token, nil when it is unknown or expired.
+func (s *Config) GetUISessionByToken(ctx context.Context, token string) *UISession {
+	if s.ConfigStore != nil {
+		return s.storedUISession(s.ConfigStore.GetUISessionByToken(ctx, token))
+	}
+	s.uiSessions.mu.Lock()
+	defer s.uiSessions.mu.Unlock()
+	return activeUISession(s.uiSessions.byToken[token])
+}
+
+// DeleteUISession ends a session.
+func (s *Config) DeleteUISession(ctx context.Context, id string) {
+	if s.ConfigStore != nil {
+		if err := s.ConfigStore.DeleteUISession(ctx, id); err != nil {
+			logger.Warn("failed to delete dashboard session: %v", err)
+		}
+		return
+	}
+	s.uiSessions.mu.Lock()
+	defer s.uiSessions.mu.Unlock()
+	if session, ok := s.uiSessions.byID[id]; ok {
+		delete(s.uiSessions.byID, id)
+		delete(s.uiSessions.byToken, session.Token)
+	}
+}
+
+// clearUISessions ends every session.
+func (s *Config) clearUISessions(ctx context.Context) {
+	if s.ConfigStore != nil {
+		if err := s.ConfigStore.DeleteUISessions(ctx); err != nil {
+			logger.Warn("failed to delete dashboard sessions: %v", err)
+		}
+		return
+	}
+	s.uiSessions.mu.Lock()
+	defer s.uiSessions.mu.Unlock()
+	s.uiSessions.byID = nil
+	s.uiSessions.byToken = nil
+}
+
+// pruneUISessions forgets the sessions expired at now. The caller holds the lock.
+func (s *Config) pruneUISessions(now time.Time) {
+	for id, session := range s.uiSessions.byID {
+		if !now.Before(session.ExpiresAt) {
+			delete(s.uiSessions.byID, id)
+			delete(s.uiSessions.byToken, session.Token)
+		}
+	}
+}
+
+// storedUISession returns the active session of a config store lookup, nil when it is unknown, expired or the
+// lookup failed.
+func (s *Config) storedUISession(row *configstore.TableUISession, err error) *UISession {
+	if err != nil {
+		if !errors.Is(err, configstore.ErrNotFound) {
+			logger.Warn("failed to get dashboard session: %v", err)
+		}
+		return nil
+	}
+	return activeUISession(&UISession{
+		ID:        row.ID,
+		Token:     row.Token,
+		Tenant:    row.Tenant,
+		Scopes:    scopesOf(row.Scopes),
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+	})
+}
+
+// uiSessionToTable converts a session to its config store row.
+func uiSessionToTable(session *UISession) *configstore.TableUISession {
+	return &configstore.TableUISession{
+		ID:        session.ID,
+		Token:     session.Token,
+		Tenant:    session.Tenant,
+		Scopes:    scopeStrings(session.Scopes),
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+// scopeStrings converts scopes to the strings stored in the config store.
+func scopeStrings(scopes []serviceaccounts.Scope) []string {
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		result = append(result, string(scope))
+	}
+	return result
+}
+
+// scopesOf converts the strings stored in the config store back to scopes, nil when there are none.
+func scopesOf(scopes []string) []serviceaccounts.Scope {
+	if len(scopes) == 0 {
+		return nil
+	}
+	result := make([]serviceaccounts.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		result = append(result, serviceaccounts.Scope(scope))
+	}
+	return result
+}
+
+// activeUISession returns session unless it is nil or expired.
+func activeUISession(session *UISession) *UISession {
+	if session == nil || !time.Now().Before(session.ExpiresAt) {
+		return nil
+	}
+	return session
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
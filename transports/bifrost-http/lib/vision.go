@@ -0,0 +1,366 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // Registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/maximhq/bifrost/core/schemas"
+)
+
+// Defaults of the vision config.
+const (
+	DefaultVisionMaxImageBytes       = 20 << 20 // 20 MiB, the limit of the OpenAI and Anthropic APIs
+	DefaultVisionFetchTimeoutSeconds = 10
+)
+
+const (
+	visionMaxRedirects = 3
+	visionMaxPixels    = 50_000_000 // Images over 50 megapixels are rejected rather than decoded
+	visionJPEGQuality  = 85
+)
+
+// VisionFetchMode decides which remote image URLs are fetched by Bifrost and sent to providers inline.
+type VisionFetchMode string
+
+const (
+	// VisionFetchNever sends image URLs as they are (default).
+	VisionFetchNever VisionFetchMode = "never"
+	// VisionFetchAuto fetches image URLs for the providers that only accept inline images.
+	VisionFetchAuto VisionFetchMode = "auto"
+	// VisionFetchAlways fetches every image URL, so that providers never see client URLs.
+	VisionFetchAlways VisionFetchMode = "always"
+)
+
+// visionInlineProviders only accept images as base64 data, rejecting or ignoring image URLs.
+var visionInlineProviders = map[schemas.ModelProvider]bool{
+	schemas.Bedrock: true,
+	schemas.Gemini:  true,
+	schemas.Vertex:  true,
+	schemas.Ollama:  true,
+}
+
+// visionBlockedPrefixes are the non-public ranges not covered by the net.IP predicates.
+var visionBlockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which may map to private IPv4 addresses
+}
+
+// VisionConfig represents the handling of image content parts in chat and responses requests.
+type VisionConfig struct {
+	Enabled              bool            `json:"enabled"`
+	MaxImageBytes        int             `json:"max_image_bytes,omitempty"`        // Largest decoded image (default: 20 MiB)
+	MaxImagesPerRequest  int             `json:"max_images_per_request,omitempty"` // 0 for no limit
+	MaxDimension         int             `json:"max_dimension,omitempty"`          // Longest side in pixels JPEG, PNG and GIF images are downscaled to, 0 to keep them as they are
+	FetchRemoteImages    VisionFetchMode `json:"fetch_remote_images,omitempty"`    // never (default), auto or always
+	FetchTimeoutSeconds  int             `json:"fetch_timeout_seconds,omitempty"`  // Timeout of an image download (default: 10)
+	AllowedHosts         []string        `json:"allowed_hosts,omitempty"`          // Hosts images may be fetched from, "*.example.com" matches subdomains; any public host when empty
+	AllowPrivateNetworks bool            `json:"allow_private_networks,omitempty"` // Allow fetching from loopback, private and link-local addresses
+}
+
+// Validate checks the limits and the fetch mode, and applies the defaults.
+func (c *VisionConfig) Validate() error {
+	switch c.FetchRemoteImages {
+	case "":
+		c.FetchRemoteImages = VisionFetchNever
+	case VisionFetchNever, VisionFetchAuto, VisionFetchAlways:
+	default:
+		return fmt.Errorf("unknown vision fetch_remote_images %q, expected never, auto or always", c.FetchRemoteImages)
+	}
+	if c.MaxImageBytes < 0 || c.MaxImagesPerRequest < 0 || c.MaxDimension < 0 || c.FetchTimeoutSeconds < 0 {
+		return fmt.Errorf("vision limits cannot be negative")
+	}
+	if c.MaxImageBytes == 0 {
+		c.MaxImageBytes = DefaultVisionMaxImageBytes
+	}
+	if c.FetchTimeoutSeconds == 0 {
+		c.FetchTimeoutSeconds = DefaultVisionFetchTimeoutSeconds
+	}
+	for _, host := range c.AllowedHosts {
+		pattern := strings.TrimPrefix(host, "*.")
+		if pattern == "" || strings.ContainsAny(pattern, "*/: ") {
+			return fmt.Errorf("vision allowed_hosts: invalid host %q, expected a hostname or *.domain", host)
+		}
+	}
+	return nil
+}
+
+// ImageError is an image content part Bifrost cannot accept, reported to clients as a 400.
+type ImageError struct {
+	Message string
+}
+
+func (e *ImageError) Error() string {
+	return e.Message
+}
+
+// imageErrorf returns an ImageError with a formatted message.
+func imageErrorf(format string, args ...any) error {
+	return &ImageError{Message: fmt.Sprintf(format, args...)}
+}
+
+// VisionProcessor validates, fetches and downscales the images of requests.
+type VisionProcessor struct {
+	config *VisionConfig
+	client *http.Client
+}
+
+// NewVisionProcessor returns the processor of a validated config. Image downloads bypass proxies, so that the
+// address checks apply to the host actually connected to, after DNS resolution and on every redirect.
+func NewVisionProcessor(config *VisionConfig) *VisionProcessor {
+	dialer := &net.Dialer{Timeout: time.Duration(config.FetchTimeoutSeconds) * time.Second}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if addr, err := netip.ParseAddr(host); err != nil || !IsPublicAddr(addr) {
+				return imageErrorf("image URLs may not point to the private address %s", host)
+			}
+			return nil
+		}
+	}
+	client := &http.Client{
+		Timeout: time.Duration(config.FetchTimeoutSeconds) * time.Second,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   time.Duration(config.FetchTimeoutSeconds) * time.Second,
+			ResponseHeaderTimeout: time.Duration(config.FetchTimeoutSeconds) * time.Second,
+			MaxIdleConns:          16,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > visionMaxRedirects {
+				return imageErrorf("image URL redirected more than %d times", visionMaxRedirects)
+			}
+			return config.checkURL(req)
+		},
+	}
+	return &VisionProcessor{config: config, client: client}
+}
+
+// IsPublicAddr reports whether addr is a public unicast address, excluding loopback, private, link-local,
+// multicast, carrier-grade NAT and other reserved ranges.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range visionBlockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkURL rejects image downloads over other schemes than http and https, or from hosts outside the allowlist.
+func (c *VisionConfig) checkURL(req *http.Request) error {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return imageErrorf("image URLs must use http or https")
+	}
+	if !schemas.EgressHostAllowed(c.AllowedHosts, req.URL.Hostname()) {
+		return imageErrorf("images may not be fetched from %s", req.URL.Hostname())
+	}
+	return nil
+}
+
+// MaxImages returns the maximum number of images of a request, 0 for no limit.
+func (p *VisionProcessor) MaxImages() int {
+	return p.config.MaxImagesPerRequest
+}
+
+// ProcessImageURL validates the image URL of a content part sent to provider, and returns the URL to send instead:
+// base64 images are checked and downscaled, and remote images are fetched and inlined according to the fetch
+// mode. Images Bifrost cannot accept are reported with an *ImageError.
+func (p *VisionProcessor) ProcessImageURL(ctx context.Context, rawURL string, provider schemas.ModelProvider) (string, error) {
+	sanitized, err := schemas.SanitizeImageURL(rawURL)
+	if err != nil {
+		return "", imageErrorf("invalid image: %v", err)
+	}
+
+	var data []byte
+	var mediaType string
+	if strings.HasPrefix(sanitized, "data:") {
+		info := schemas.ExtractURLTypeInfo(sanitized)
+		if info.Type != schemas.ImageContentTypeBase64 || info.DataURLWithoutPrefix == nil || info.MediaType == nil {
+			return "", imageErrorf("invalid image: data URLs must be base64 encoded")
+		}
+		if base64.StdEncoding.DecodedLen(len(*info.DataURLWithoutPrefix)) > p.config.MaxImageBytes+2 {
+			return "", imageErrorf("image is larger than %d bytes", p.config.MaxImageBytes)
+		}
+		if data, err = base64.StdEncoding.DecodeString(*info.DataURLWithoutPrefix); err != nil {
+			return "", imageErrorf("invalid image: malformed base64 data")
+		}
+		if len(data) > p.config.MaxImageBytes {
+			return "", imageErrorf("image is larger than %d bytes", p.config.MaxImageBytes)
+		}
+		mediaType = *info.MediaType
+	} else {
+		if !p.shouldFetch(provider) {
+			return sanitized, nil
+		}
+		if data, mediaType, err = p.fetch(ctx, sanitized); err != nil {
+			return "", err
+		}
+	}
+
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", imageErrorf("invalid image: unsupported media type %s", mediaType)
+	}
+	resized, resizedType, err := p.downscale(data, mediaType)
+	if err != nil {
+		return "", err
+	}
+	if resized == nil && strings.HasPrefix(sanitized, "data:") {
+		return sanitized, nil
+	}
+	if resized != nil {
+		data, mediaType = resized, resizedType
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// shouldFetch reports whether image URLs sent to provider are fetched by Bifrost.
+func (p *VisionProcessor) shouldFetch(provider schemas.ModelProvider) bool {
+	switch p.config.FetchRemoteImages {
+	case VisionFetchAlways:
+		return true
+	case VisionFetchAuto:
+		return visionInlineProviders[provider]
+	default:
+		return false
+	}
+}
+
+// fetch downloads a remote image, returning its data and media type.
+func (p *VisionProcessor) fetch(ctx context.Context, imageURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", imageErrorf("invalid image URL: %v", err)
+	}
+	if err := p.config.checkURL(req); err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "image/*")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		var imageErr *ImageError
+		if errors.As(err, &imageErr) {
+			return nil, "", imageErr
+		}
+		return nil, "", imageErrorf("failed to download image from %s: %v", req.URL.Hostname(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", imageErrorf("failed to download image from %s: status %d", req.URL.Hostname(), resp.StatusCode)
+	}
+	if resp.ContentLength > int64(p.config.MaxImageBytes) {
+		return nil, "", imageErrorf("image is larger than %d bytes", p.config.MaxImageBytes)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(p.config.MaxImageBytes)+1))
+	if err != nil {
+		return nil, "", imageErrorf("failed to download image from %s: %v", req.URL.Hostname(), err)
+	}
+	if len(data) > p.config.MaxImageBytes {
+		return nil, "", imageErrorf("image is larger than %d bytes", p.config.MaxImageBytes)
+	}
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	if mediaType = strings.TrimSpace(strings.ToLower(mediaType)); !strings.HasPrefix(mediaType, "image/") {
+		mediaType = http.DetectContentType(data)
+	}
+	return data, mediaType, nil
+}
+
+// downscale shrinks JPEG, PNG and GIF images whose longest side exceeds the max dimension, keeping their aspect
+// ratio. It returns nil when the image is kept as it is. Animated GIFs keep their first frame and become PNGs.
+func (p *VisionProcessor) downscale(data []byte, mediaType string) ([]byte, string, error) {
+	switch mediaType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, "", nil
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", imageErrorf("invalid image: the data is not a valid %s", mediaType)
+	}
+	if config.Width*config.Height > visionMaxPixels {
+		return nil, "", imageErrorf("image of %dx%d pixels is too large", config.Width, config.Height)
+	}
+	longest := max(config.Width, config.Height)
+	if p.config.MaxDimension == 0 || longest <= p.config.MaxDimension {
+		return nil, "", nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", imageErrorf("invalid image: the data is not a valid %s", mediaType)
+	}
+	width := max(1, config.Width*p.config.MaxDimension/longest)
+	height := max(1, config.Height*p.config.MaxDimension/longest)
+	resized := resizeImage(src, width, height)
+
+	var out bytes.Buffer
+	if mediaType == "image/jpeg" {
+		err = jpeg.Encode(&out, resized, &jpeg.Options{Quality: visionJPEGQuality})
+	} else {
+		err = png.Encode(&out, resized)
+		mediaType = "image/png"
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode the downscaled image: %w", err)
+	}
+	return out.Bytes(), mediaType, nil
+}
+
+// resizeImage downscales src to width x height, averaging the source pixels covered by each pixel (box filter).
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	rgba, ok := src.(*image.RGBA)
+	if !ok || bounds.Min != (image.Point{}) {
+		rgba = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	}
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
@@ -34,6 +34,14 @@
 //
 //	To bind to all interfaces for container usage, set BIFROST_HOST=0.0.0.0 or use -host 0.0.0.0
 //
+// Subcommands are given after the flags:
+//
+//	go run main.go -app-dir ./data migrate    (apply config and logs store migrations, then exit)
+//	go run main.go keys list                  (administer a running instance, see package cli)
+//
+// Administration subcommands (keys, config, logs, password) talk to the management API of the
+// instance at -host/-port, or BIFROST_URL, authenticating with BIFROST_ADMIN_PASSWORD.
+//
 // Integration Support:
 // Bifrost supports multiple AI provider integrations through dedicated HTTP endpoints.
 // Each integration exposes API-compatible endpoints that accept the provider's native request format,
@@ -56,11 +64,15 @@ import (
 	"embed"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	bifrost "github.com/maximhq/bifrost/core"
 	schemas "github.com/maximhq/bifrost/core/schemas"
+	"github.com/maximhq/bifrost/transports/bifrost-http/cli"
 	"github.com/maximhq/bifrost/transports/bifrost-http/handlers"
 	"github.com/maximhq/bifrost/transports/bifrost-http/lib"
 )
@@ -79,12 +91,37 @@ var server *handlers.BifrostHTTPServer
 //   - port: Server port (default: 8080)
 //   - app-dir: Application data directory (default: current directory)
 //   - log-level: Logger level (debug, info, warn, error). Default is info.
-//   - log-style: Logger output type (json or pretty). Default is JSON.
+//   - log-style: Logger output type (json, pretty or console). Default is JSON.
 
 func init() {
 	if Version == "" {
 		Version = "v1.0.0"
 	}
+	// Set default host from environment variable or use localhost
+	defaultHost := os.Getenv("BIFROST_HOST")
+	if defaultHost == "" {
+		defaultHost = handlers.DefaultHost
+	}
+	// Initializing server
+	server = handlers.NewBifrostHTTPServer(Version, uiContent)
+	// Updating server properties from flags
+	flag.StringVar(&server.Port, "port", handlers.DefaultPort, "Port to run the server on")
+	flag.StringVar(&server.Host, "host", defaultHost, "Host to bind the server to (default: localhost, override with BIFROST_HOST env var)")
+	flag.StringVar(&server.AppDir, "app-dir", handlers.DefaultAppDir, "Application data directory (contains config.json and logs)")
+	flag.StringVar(&server.LogLevel, "log-level", handlers.DefaultLogLevel, "Logger level (debug, info, warn, error). Default is info. Can be changed at runtime via PUT /api/logging/level.")
+	flag.StringVar(&server.LogOutputStyle, "log-style", handlers.DefaultLogOutputStyle, "Logger output type (json, pretty or console). Default is JSON.")
+	flag.Parse()
+	// Configure logger from flags
+	logger.SetOutputType(schemas.LoggerOutputType(server.LogOutputStyle))
+	logger.SetLevel(schemas.LogLevel(server.LogLevel))
+	// Setting up logger
+	lib.SetLogger(logger)
+	handlers.SetLogger(logger)
+
+}
+
+// printBanner prints the welcome banner with the version.
+func printBanner() {
 	// Printing version
 	versionLine := fmt.Sprintf("║%s%s%s║", strings.Repeat(" ", (61-2-len(Version))/2), Version, strings.Repeat(" ", (61-2-len(Version)+1)/2))
 	// Welcome to bifrost!
@@ -107,32 +144,35 @@ func init() {
 ╚═══════════════════════════════════════════════════════════╝
 
 `, versionLine)
-	// Set default host from environment variable or use localhost
-	defaultHost := os.Getenv("BIFROST_HOST")
-	if defaultHost == "" {
-		defaultHost = handlers.DefaultHost
-	}
-	// Initializing server
-	server = handlers.NewBifrostHTTPServer(Version, uiContent)
-	// Updating server properties from flags
-	flag.StringVar(&server.Port, "port", handlers.DefaultPort, "Port to run the server on")
-	flag.StringVar(&server.Host, "host", defaultHost, "Host to bind the server to (default: localhost, override with BIFROST_HOST env var)")
-	flag.StringVar(&server.AppDir, "app-dir", handlers.DefaultAppDir, "Application data directory (contains config.json and logs)")
-	flag.StringVar(&server.LogLevel, "log-level", handlers.DefaultLogLevel, "Logger level (debug, info, warn, error). Default is info.")
-	flag.StringVar(&server.LogOutputStyle, "log-style", handlers.DefaultLogOutputStyle, "Logger output type (json or pretty). Default is JSON.")
-	flag.Parse()
-	// Configure logger from flags
-	logger.SetOutputType(schemas.LoggerOutputType(server.LogOutputStyle))
-	logger.SetLevel(schemas.LogLevel(server.LogLevel))
-	// Setting up logger
-	lib.SetLogger(logger)
-	handlers.SetLogger(logger)
-
 }
 
 // main is the entry point of the application.
 func main() {
 	ctx := context.Background()
+	switch command := flag.Arg(0); {
+	case command == "":
+		printBanner()
+	case command == "migrate":
+		printBanner()
+		if err := lib.RunMigrations(ctx, server.AppDir); err != nil {
+			logger.Error("failed to run migrations: %v", err)
+			os.Exit(1)
+		}
+		return
+	case cli.IsCommand(command):
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		options := cli.Options{BaseURL: fmt.Sprintf("http://%s", net.JoinHostPort(server.Host, server.Port))}
+		if err := cli.Run(ctx, flag.Args(), options); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			stop()
+			os.Exit(1)
+		}
+		return
+	default:
+		logger.Error("unknown command: %s", command)
+		os.Exit(2)
+	}
 	err := server.Bootstrap(ctx)
 	if err != nil {
 		logger.Error("failed to bootstrap server: %v", err)
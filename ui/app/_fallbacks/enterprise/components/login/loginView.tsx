@@ -1,14 +1,80 @@
 "use client";
 import FullPageLoader from "@/components/fullPageLoader";
-import { useRouter } from "next/navigation";
-import { useEffect } from "react";
+import { Button } from "@/components/ui/button";
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { getErrorMessage, useGetAuthSessionQuery, useLoginMutation } from "@/lib/store";
+import { getBrandLogo, getBrandName, getTenantBranding } from "@/lib/utils/branding";
+import { useQueryState } from "nuqs";
+import { FormEvent, useEffect, useState } from "react";
+
+// Only local paths are followed after signing in, the server applies the same rule
+function localPath(next: string | null | undefined): string {
+	if (!next || !next.startsWith("/") || next.startsWith("//") || next.includes("://") || next.includes("\\")) {
+		return "/";
+	}
+	return next;
+}
 
 export default function LoginView() {
-	const router = useRouter();
+	const [next] = useQueryState("next");
+	const [password, setPassword] = useState("");
+	const [error, setError] = useState<string | null>(null);
+	const { data: session, isLoading } = useGetAuthSessionQuery();
+	const [login, { isLoading: isSigningIn }] = useLoginMutation();
 
+	// Nothing to do here when already signed in or when admin auth is disabled
 	useEffect(() => {
-		router.push("/");
-	}, [router]);
+		if (session?.authenticated) {
+			window.location.href = localPath(next);
+		}
+	}, [session, next]);
+
+	const onSubmit = async (e: FormEvent) => {
+		e.preventDefault();
+		setError(null);
+		try {
+			const result = await login({ password, next: next ?? undefined }).unwrap();
+			// Full navigation so every query is refetched with the new session cookie
+			window.location.href = localPath(result.redirect);
+		} catch (err) {
+			setError(getErrorMessage(err));
+		}
+	};
+
+	if (isLoading || session?.authenticated) {
+		return <FullPageLoader />;
+	}
 
-	return <FullPageLoader />;
+	return (
+		<div className="flex min-h-dvh w-full items-center justify-center p-4">
+			<Card className="w-full max-w-sm">
+				<CardHeader>
+					<img src={getBrandLogo(false)} alt={getBrandName()} className="mb-2 h-6 w-fit dark:hidden" />
+					<img src={getBrandLogo(true)} alt={getBrandName()} className="mb-2 hidden h-6 w-fit dark:block" />
+					<CardTitle>Sign in</CardTitle>
+					{getTenantBranding() ? (
+						<CardDescription>Enter the {getBrandName()} password provided by your administrator.</CardDescription>
+					) : (
+						<CardDescription>
+							Enter the admin password. To obtain it, run <code>operator bifrost password</code> locally.
+						</CardDescription>
+					)}
+				</CardHeader>
+				<CardContent>
+					<form onSubmit={onSubmit} className="flex flex-col gap-4">
+						<div className="flex flex-col gap-2">
+							<Label htmlFor="password">Password</Label>
+							<Input id="password" type="password" autoFocus required value={password} onChange={(e) => setPassword(e.target.value)} />
+						</div>
+						{error && <p className="text-destructive text-sm">{error}</p>}
+						<Button type="submit" disabled={isSigningIn || password === ""}>
+							{isSigningIn ? "Signing in..." : "Sign in"}
+						</Button>
+					</form>
+				</CardContent>
+			</Card>
+		</div>
+	);
 }
@@ -1,6 +1,8 @@
 "use client";
 
+import ConfigVersionsList from "@/app/config/views/configVersionsList";
 import PluginsForm from "@/app/config/views/pluginsForm";
+import TransformationRulesForm from "@/app/config/views/transformationRulesForm";
 import FullPageLoader from "@/components/fullPageLoader";
 import { Alert, AlertDescription } from "@/components/ui/alert";
 import { CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
@@ -395,6 +397,10 @@ export default function ConfigPage() {
 
 					<PluginsForm isVectorStoreEnabled={bifrostConfig?.is_cache_connected ?? false} />
 
+					<TransformationRulesForm />
+
+					<ConfigVersionsList />
+
 					<div>
 						<div className="space-y-2 rounded-lg border p-4">
 							<div className="space-y-0.5">
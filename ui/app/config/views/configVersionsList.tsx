@@ -0,0 +1,100 @@
+"use client";
+
+import {
+	AlertDialog,
+	AlertDialogAction,
+	AlertDialogCancel,
+	AlertDialogContent,
+	AlertDialogDescription,
+	AlertDialogFooter,
+	AlertDialogHeader,
+	AlertDialogTitle,
+	AlertDialogTrigger,
+} from "@/components/ui/alertDialog";
+import { Button } from "@/components/ui/button";
+import { getErrorMessage, useGetConfigVersionsQuery, useRollbackConfigVersionMutation } from "@/lib/store";
+import { ConfigChange } from "@/lib/types/config";
+import { Loader2, RotateCcw } from "lucide-react";
+import { toast } from "sonner";
+
+// formatChange describes a change on one line, e.g. "update provider openai (keys)"
+const formatChange = (change: ConfigChange) =>
+	`${change.action} ${change.resource} ${change.name}${change.fields?.length ? ` (${change.fields.join(", ")})` : ""}`;
+
+export default function ConfigVersionsList() {
+	const { data: versions, isLoading } = useGetConfigVersionsQuery(undefined, { refetchOnMountOrArgChange: true });
+	const [rollbackConfigVersion, { isLoading: isRollingBack }] = useRollbackConfigVersionMutation();
+
+	const handleRollback = async (id: number) => {
+		try {
+			const result = await rollbackConfigVersion({ id }).unwrap();
+			toast.success(
+				result.changes.length > 0 ? `Rolled back to version ${id} with ${result.changes.length} changes.` : `Already at version ${id}.`,
+			);
+		} catch (error) {
+			toast.error(getErrorMessage(error));
+		}
+	};
+
+	return (
+		<div className="space-y-2 rounded-lg border p-4">
+			<div className="space-y-0.5">
+				<label className="text-sm font-medium">Configuration Versions</label>
+				<p className="text-muted-foreground text-sm">
+					Every change to providers, plugins and core settings is recorded as a version. Rolling back restores a version and
+					re-initializes the affected providers and plugins.
+				</p>
+			</div>
+			{isLoading ? (
+				<div className="flex items-center justify-center">
+					<Loader2 className="h-4 w-4 animate-spin" />
+				</div>
+			) : !versions?.length ? (
+				<p className="text-muted-foreground text-sm">No versions recorded yet.</p>
+			) : (
+				<div className="max-h-96 divide-y overflow-y-auto">
+					{versions.map((version, index) => (
+						<div key={version.id} className="flex items-start justify-between gap-4 py-2">
+							<div className="min-w-0 space-y-0.5">
+								<p className="text-sm font-medium">
+									Version {version.id} <span className="text-muted-foreground font-normal">· {version.source}</span>
+								</p>
+								<p className="text-muted-foreground text-xs">{new Date(version.created_at).toLocaleString()}</p>
+								{version.changes.map((change) => (
+									<p key={`${change.resource}-${change.name}`} className="text-muted-foreground truncate font-mono text-xs">
+										{formatChange(change)}
+									</p>
+								))}
+							</div>
+							{index > 0 && (
+								<AlertDialog>
+									<AlertDialogTrigger asChild>
+										<Button variant="outline" size="sm" disabled={isRollingBack}>
+											<RotateCcw className="h-4 w-4" />
+											Rollback
+										</Button>
+									</AlertDialogTrigger>
+									<AlertDialogContent>
+										<AlertDialogHeader>
+											<AlertDialogTitle>Roll back to version {version.id}</AlertDialogTitle>
+											<AlertDialogDescription>
+												Providers, plugins and core settings will be restored as they were in version {version.id}. The rollback is recorded
+												as a new version, so it can be undone.
+											</AlertDialogDescription>
+										</AlertDialogHeader>
+										<AlertDialogFooter>
+											<AlertDialogCancel>Cancel</AlertDialogCancel>
+											<AlertDialogAction onClick={() => handleRollback(version.id)} disabled={isRollingBack}>
+												{isRollingBack ? "Rolling back..." : "Rollback"}
+											</AlertDialogAction>
+										</AlertDialogFooter>
+									</AlertDialogContent>
+								</AlertDialog>
+							)}
+						</div>
+					))}
+				</div>
+			)}
+		</div>
+	);
+}
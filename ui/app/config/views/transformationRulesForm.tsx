@@ -0,0 +1,92 @@
+"use client";
+
+import { Button } from "@/components/ui/button";
+import { Textarea } from "@/components/ui/textarea";
+import { getErrorMessage, useGetTransformationRulesQuery, useUpdateTransformationRulesMutation } from "@/lib/store";
+import { TransformationRule } from "@/lib/types/config";
+import { Loader2 } from "lucide-react";
+import { useEffect, useState } from "react";
+import { toast } from "sonner";
+
+const exampleRules = `[
+  {
+    "name": "clamp-temperature",
+    "match": { "models": ["gpt-4o*"] },
+    "operations": [{ "op": "clamp", "field": "temperature", "max": 1 }]
+  }
+]`;
+
+export default function TransformationRulesForm() {
+	const { data, isLoading } = useGetTransformationRulesQuery();
+	const [updateTransformationRules, { isLoading: isSaving }] = useUpdateTransformationRulesMutation();
+	const [rulesText, setRulesText] = useState("");
+	const [parseError, setParseError] = useState<string | null>(null);
+
+	// Show the saved rules once loaded
+	useEffect(() => {
+		if (data) {
+			setRulesText(data.rules.length > 0 ? JSON.stringify(data.rules, null, 2) : "");
+		}
+	}, [data]);
+
+	const handleRulesChange = (value: string) => {
+		setRulesText(value);
+		if (value.trim() === "") {
+			setParseError(null);
+			return;
+		}
+		try {
+			const parsed = JSON.parse(value);
+			setParseError(Array.isArray(parsed) ? null : "Rules must be a JSON array.");
+		} catch (error) {
+			setParseError((error as Error).message);
+		}
+	};
+
+	const handleSave = async () => {
+		const rules: TransformationRule[] = rulesText.trim() === "" ? [] : JSON.parse(rulesText);
+		try {
+			await updateTransformationRules({ rules }).unwrap();
+			toast.success("Transformation rules updated successfully.");
+		} catch (error) {
+			toast.error(getErrorMessage(error));
+		}
+	};
+
+	return (
+		<div className="space-y-2 rounded-lg border p-4">
+			<div className="space-y-0.5">
+				<label htmlFor="transformation-rules" className="text-sm font-medium">
+					Transformation Rules
+				</label>
+				<p className="text-muted-foreground text-sm">
+					Rewrite inference requests before plugins run. Each rule matches by <b>paths</b>, <b>virtual_keys</b>, <b>providers</b> and{" "}
+					<b>models</b> (a trailing * matches a prefix) and applies <b>set</b>, <b>default</b>, <b>remove</b>, <b>clamp</b> or{" "}
+					<b>system_prompt</b> operations. Applied rules are listed in the <b>x-bf-transformations</b> response header.
+				</p>
+			</div>
+			{isLoading ? (
+				<div className="flex items-center justify-center">
+					<Loader2 className="h-4 w-4 animate-spin" />
+				</div>
+			) : (
+				<>
+					<Textarea
+						id="transformation-rules"
+						className="h-48 font-mono text-xs"
+						placeholder={exampleRules}
+						value={rulesText}
+						onChange={(e) => handleRulesChange(e.target.value)}
+					/>
+					{parseError && <p className="text-destructive text-xs">{parseError}</p>}
+					<div className="flex justify-end">
+						<Button size="sm" onClick={handleSave} disabled={parseError !== null || isSaving}>
+							{isSaving && <Loader2 className="h-4 w-4 animate-spin" />}
+							Save Rules
+						</Button>
+					</div>
+				</>
+			)}
+		</div>
+	);
+}
@@ -0,0 +1,7 @@
+"use client";
+
+import DeviceLoginView from "./views/deviceLogin";
+
+export default function DevicePage() {
+	return <DeviceLoginView />;
+}
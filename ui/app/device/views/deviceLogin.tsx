@@ -0,0 +1,111 @@
+"use client";
+
+import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
+import { CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Input } from "@/components/ui/input";
+import { getErrorMessage, useDecideDeviceAuthorizationMutation, useLazyGetDeviceAuthorizationQuery } from "@/lib/store";
+import { DeviceAuthorization } from "@/lib/types/auth";
+import { useEffect, useState } from "react";
+import { toast } from "sonner";
+
+// DeviceLoginView lets a signed-in admin confirm the code a CLI shows while logging in
+export default function DeviceLoginView() {
+	const [code, setCode] = useState("");
+	const [authorization, setAuthorization] = useState<DeviceAuthorization | null>(null);
+	const [decided, setDecided] = useState<DeviceAuthorization | null>(null);
+	const [lookup, { isFetching }] = useLazyGetDeviceAuthorizationQuery();
+	const [decide, { isLoading: isDeciding }] = useDecideDeviceAuthorizationMutation();
+
+	const find = async (userCode: string) => {
+		setDecided(null);
+		try {
+			setAuthorization(await lookup(userCode.trim()).unwrap());
+		} catch (error) {
+			setAuthorization(null);
+			toast.error(getErrorMessage(error));
+		}
+	};
+
+	// The verification link the CLI prints carries the code
+	useEffect(() => {
+		const initial = new URLSearchParams(window.location.search).get("code");
+		if (initial) {
+			setCode(initial);
+			find(initial);
+		}
+		// eslint-disable-next-line react-hooks/exhaustive-deps
+	}, []);
+
+	const submit = async (approve: boolean) => {
+		if (!authorization) {
+			return;
+		}
+		try {
+			setDecided(await decide({ user_code: authorization.user_code, approve }).unwrap());
+			setAuthorization(null);
+		} catch (error) {
+			toast.error(getErrorMessage(error));
+		}
+	};
+
+	return (
+		<div className="mx-auto max-w-xl space-y-4">
+			<CardHeader className="mb-4 px-0">
+				<CardTitle>Device Login</CardTitle>
+				<CardDescription>
+					Enter the code shown by the CLI to let it call the management API. The CLI receives a token limited to the scopes it
+					requested, renewed until you rotate the admin password.
+				</CardDescription>
+			</CardHeader>
+			<form
+				className="flex gap-2"
+				onSubmit={(e) => {
+					e.preventDefault();
+					find(code);
+				}}
+			>
+				<Input placeholder="XXXX-XXXX" value={code} onChange={(e) => setCode(e.target.value)} className="font-mono uppercase" />
+				<Button type="submit" disabled={!code.trim() || isFetching}>
+					Continue
+				</Button>
+			</form>
+			{authorization && (
+				<div className="space-y-4 rounded-sm border p-4">
+					<div className="text-sm">
+						<span className="font-medium">{authorization.client_name || "A CLI"}</span> is asking to sign in with code{" "}
+						<code>{authorization.user_code}</code>. Only approve it if you started this login.
+					</div>
+					<div className="flex flex-wrap items-center gap-2 text-sm">
+						<span className="text-muted-foreground">Scopes:</span>
+						{authorization.scopes?.length ? (
+							authorization.scopes.map((scope) => (
+								<Badge key={scope} variant="outline">
+									{scope}
+								</Badge>
+							))
+						) : (
+							<Badge variant="outline">everything the admin password allows</Badge>
+						)}
+					</div>
+					<div className="text-muted-foreground text-xs">Expires at {new Date(authorization.expires_at).toLocaleTimeString()}</div>
+					<div className="flex gap-2">
+						<Button disabled={isDeciding} onClick={() => submit(true)}>
+							Approve
+						</Button>
+						<Button variant="outline" disabled={isDeciding} onClick={() => submit(false)}>
+							Deny
+						</Button>
+					</div>
+				</div>
+			)}
+			{decided && (
+				<div className="text-muted-foreground rounded-sm border py-6 text-center text-sm">
+					{decided.status === "approved"
+						? "Device approved. You can return to the CLI."
+						: "Device login denied. The CLI will not receive a token."}
+				</div>
+			)}
+		</div>
+	);
+}
@@ -0,0 +1,7 @@
+"use client";
+
+import FineTuningJobsView from "./views/fineTuningJobs";
+
+export default function FineTuningPage() {
+	return <FineTuningJobsView />;
+}
@@ -0,0 +1,160 @@
+"use client";
+
+import FullPageLoader from "@/components/fullPageLoader";
+import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
+import { CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { getErrorMessage, useGetFineTuningJobsQuery } from "@/lib/store";
+import { FineTuningJobStatus } from "@/lib/types/fineTuning";
+import { RefreshCcw } from "lucide-react";
+import { useEffect, useState } from "react";
+import { toast } from "sonner";
+
+const PAGE_SIZE = 50;
+
+const STATUS_VARIANTS: Record<FineTuningJobStatus, "secondary" | "success" | "destructive" | "outline"> = {
+	validating_files: "outline",
+	queued: "outline",
+	running: "secondary",
+	succeeded: "success",
+	failed: "destructive",
+	cancelled: "outline",
+};
+
+export default function FineTuningJobsView() {
+	const [status, setStatus] = useState<FineTuningJobStatus | "all">("all");
+	const [offset, setOffset] = useState(0);
+
+	const { data, error, isLoading, isFetching, refetch } = useGetFineTuningJobsQuery(
+		{
+			status: status === "all" ? undefined : status,
+			limit: PAGE_SIZE,
+			offset,
+		},
+		{ pollingInterval: 30000 },
+	);
+
+	useEffect(() => {
+		if (error) {
+			toast.error(`Failed to load fine-tuning jobs: ${getErrorMessage(error)}`);
+		}
+	}, [error]);
+
+	if (isLoading) {
+		return <FullPageLoader />;
+	}
+
+	const jobs = data?.jobs || [];
+	const total = data?.total || 0;
+
+	return (
+		<div className="space-y-4">
+			<CardHeader className="mb-4 px-0">
+				<CardTitle className="flex items-center justify-between">
+					<div className="flex items-center gap-2">Fine-Tuning Jobs</div>
+					<div className="flex items-center gap-2">
+						<Select
+							value={status}
+							onValueChange={(value) => {
+								setStatus(value as FineTuningJobStatus | "all");
+								setOffset(0);
+							}}
+						>
+							<SelectTrigger className="w-40">
+								<SelectValue />
+							</SelectTrigger>
+							<SelectContent>
+								<SelectItem value="all">All jobs</SelectItem>
+								<SelectItem value="validating_files">Validating files</SelectItem>
+								<SelectItem value="queued">Queued</SelectItem>
+								<SelectItem value="running">Running</SelectItem>
+								<SelectItem value="succeeded">Succeeded</SelectItem>
+								<SelectItem value="failed">Failed</SelectItem>
+								<SelectItem value="cancelled">Cancelled</SelectItem>
+							</SelectContent>
+						</Select>
+						<Button variant="outline" size="icon" disabled={isFetching} onClick={() => refetch()}>
+							<RefreshCcw className="h-4 w-4" />
+						</Button>
+					</div>
+				</CardTitle>
+				<CardDescription>
+					Fine-tuning jobs created through Bifrost, with the status and latest progress reported by their provider.
+				</CardDescription>
+			</CardHeader>
+			{data && !data.enabled ? (
+				<div className="text-muted-foreground rounded-sm border py-6 text-center text-sm">
+					Fine-tuning is not enabled. Enable it in the <code>fine_tuning</code> section of config.json.
+				</div>
+			) : (
+				<div className="rounded-sm border">
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Job</TableHead>
+								<TableHead>Model</TableHead>
+								<TableHead>Status</TableHead>
+								<TableHead>Progress</TableHead>
+								<TableHead>Virtual Key</TableHead>
+								<TableHead>Created</TableHead>
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{jobs.length === 0 && (
+								<TableRow>
+									<TableCell colSpan={6} className="py-6 text-center">
+										No fine-tuning jobs found.
+									</TableCell>
+								</TableRow>
+							)}
+							{jobs.map((job) => (
+								<TableRow key={job.id}>
+									<TableCell>
+										<div className="font-mono text-xs">{job.id}</div>
+										<div className="text-muted-foreground text-xs">{job.provider}</div>
+									</TableCell>
+									<TableCell>
+										<div>{job.model}</div>
+										{job.fine_tuned_model && <div className="text-muted-foreground font-mono text-xs">{job.fine_tuned_model}</div>}
+									</TableCell>
+									<TableCell>
+										<Badge variant={STATUS_VARIANTS[job.status] || "outline"}>{job.status}</Badge>
+									</TableCell>
+									<TableCell className="max-w-80 text-xs">
+										{job.error ? (
+											<div className="text-destructive">{job.error}</div>
+										) : (
+											<div className="overflow-hidden text-ellipsis whitespace-nowrap">{job.last_event || "-"}</div>
+										)}
+										<div className="text-muted-foreground">
+											{job.trained_tokens ? `${job.trained_tokens.toLocaleString()} tokens trained` : `Training file ${job.training_file}`}
+											{job.estimated_finish && !job.finished_at && `, estimated finish ${new Date(job.estimated_finish).toLocaleString()}`}
+											{job.finished_at && `, finished ${new Date(job.finished_at).toLocaleString()}`}
+										</div>
+									</TableCell>
+									<TableCell>{job.virtual_key || "-"}</TableCell>
+									<TableCell className="whitespace-nowrap">{new Date(job.created_at).toLocaleString()}</TableCell>
+								</TableRow>
+							))}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+			{total > PAGE_SIZE && (
+				<div className="flex items-center justify-end gap-2 text-sm">
+					<span className="text-muted-foreground">
+						{offset + 1}-{Math.min(offset + PAGE_SIZE, total)} of {total}
+					</span>
+					<Button variant="outline" size="sm" disabled={offset === 0} onClick={() => setOffset(Math.max(0, offset - PAGE_SIZE))}>
+						Previous
+					</Button>
+					<Button variant="outline" size="sm" disabled={offset + PAGE_SIZE >= total} onClick={() => setOffset(offset + PAGE_SIZE)}>
+						Next
+					</Button>
+				</div>
+			)}
+		</div>
+	);
+}
@@ -4,11 +4,13 @@ import FullPageLoader from "@/components/fullPageLoader";
 import NotAvailableBanner from "@/components/notAvailableBanner";
 import ProgressProvider from "@/components/progressBar";
 import Sidebar from "@/components/sidebar";
+import SystemModeBanner from "@/components/systemModeBanner";
 import { ThemeProvider } from "@/components/themeProvider";
 import { SidebarProvider } from "@/components/ui/sidebar";
 import { WebSocketProvider } from "@/hooks/useWebSocket";
 import { getErrorMessage, ReduxProvider, useGetCoreConfigQuery } from "@/lib/store";
 import { Geist, Geist_Mono } from "next/font/google";
+import { usePathname } from "next/navigation";
 import { NuqsAdapter } from "nuqs/adapters/next/app";
 import { useEffect } from "react";
 import { toast, Toaster } from "sonner";
@@ -25,6 +27,15 @@ const geistMono = Geist_Mono({
 });
 
 function AppContent({ children }: { children: React.ReactNode }) {
+	const pathname = usePathname();
+	// The login page is shown on its own, before the dashboard can load its configuration
+	if (pathname?.startsWith("/login")) {
+		return <>{children}</>;
+	}
+	return <Dashboard>{children}</Dashboard>;
+}
+
+function Dashboard({ children }: { children: React.ReactNode }) {
 	const { data: bifrostConfig, error } = useGetCoreConfigQuery({});
 
 	useEffect(() => {
@@ -39,6 +50,7 @@ function AppContent({ children }: { children: React.ReactNode }) {
 				<Sidebar />
 				<div className="dark:bg-card custom-scrollbar my-[1rem] h-[calc(100dvh-2rem)] w-full overflow-auto rounded-md border border-gray-200 bg-white dark:border-zinc-800">
 					<main className="custom-scrollbar relative mx-auto flex w-5xl flex-col px-4 py-12 2xl:w-7xl">
+						{bifrostConfig && <SystemModeBanner />}
 						{bifrostConfig?.is_db_connected ? children : bifrostConfig ? <NotAvailableBanner /> : <FullPageLoader />}
 					</main>
 				</div>
@@ -7,9 +7,9 @@ import { LogsDataTable } from "@/app/logs/views/logsTable";
 import FullPageLoader from "@/components/fullPageLoader";
 import { Alert, AlertDescription } from "@/components/ui/alert";
 import { Card, CardContent } from "@/components/ui/card";
-import { useWebSocket } from "@/hooks/useWebSocket";
-import { getErrorMessage, useAppSelector, useLazyGetLogsQuery } from "@/lib/store";
-import type { ChatMessage, ChatMessageContent, ContentBlock, LogEntry, LogFilters, LogStats, Pagination } from "@/lib/types/logs";
+import { useEventStream } from "@/hooks/useEventStream";
+import { getErrorMessage, logFilterParams, useAppSelector, useLazyGetLogsQuery } from "@/lib/store";
+import type { ChatMessage, ChatMessageContent, ContentBlock, LogEntry, LogFilters, LogStats, Pagination, StreamMetrics } from "@/lib/types/logs";
 import { AlertCircle, BarChart, CheckCircle, Clock, DollarSign, Hash } from "lucide-react";
 import { useCallback, useEffect, useMemo, useRef, useState } from "react";
 
@@ -187,17 +187,20 @@ export default function LogsPage() {
 		});
 	}, []);
 
-	const { isConnected: isSocketConnected, subscribe } = useWebSocket();
-
-	// Subscribe to log messages
-	useEffect(() => {
-		const unsubscribe = subscribe("log", (data) => {
-			const { payload, operation } = data;
-			handleLogMessage(payload, operation);
-		});
-
-		return unsubscribe;
-	}, [handleLogMessage, subscribe]);
+	// Live log events and metrics, filtered server-side with the current filters. The first page is fetched
+	// separately, so no recent events are replayed.
+	const streamParams = useMemo(() => logFilterParams(filters), [filters]);
+	const [liveMetrics, setLiveMetrics] = useState<StreamMetrics | null>(null);
+	const { isConnected: isSocketConnected } = useEventStream(
+		"/stream/logs",
+		{ ...streamParams, recent: 0 },
+		{
+			log: (data) => handleLogMessage(data.payload, data.operation),
+		},
+	);
+	useEventStream("/stream/metrics", streamParams, {
+		metrics: (data) => setLiveMetrics(data.payload),
+	});
 
 	// Cleanup timeouts on unmount
 	useEffect(() => {
@@ -368,7 +371,20 @@ export default function LogsPage() {
 							))}
 						</div>
 
-						{/* Error Alert */}
+						{/* Live metrics of the last minute */}
+						{liveMetrics && (
+							<div className="text-muted-foreground flex flex-wrap items-center gap-x-4 gap-y-1 font-mono text-xs">
+								<span>Last {liveMetrics.window_seconds}s:</span>
+								<span>{liveMetrics.requests_per_second.toFixed(2)} req/s</span>
+								<span>{liveMetrics.error_rate.toFixed(2)}% errors</span>
+								<span>avg {liveMetrics.avg_latency.toFixed(0)}ms</span>
+								<span>p95 {liveMetrics.p95_latency.toFixed(0)}ms</span>
+								<span>{liveMetrics.total_tokens.toLocaleString()} tokens</span>
+								<span>${liveMetrics.total_cost.toFixed(4)}</span>
+							</div>
+						)}
+
+												{/* Error Alert */}
 						{error && (
 							<Alert variant="destructive">
 								<AlertCircle className="h-4 w-4" />
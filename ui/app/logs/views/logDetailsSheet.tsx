@@ -12,6 +12,7 @@ import { CodeEditor } from "./codeEditor";
 import LogEntryDetailsView from "./logEntryDetailsView";
 import LogMessageView from "./logMessageView";
 import SpeechView from "./speechView";
+import TraceView from "./traceView";
 import TranscriptionView from "./transcriptionView";
 
 interface LogDetailSheetProps {
@@ -70,6 +71,20 @@ export function LogDetailSheet({ log, open, onOpenChange }: LogDetailSheetProps)
 								label="Latency"
 								value={isNaN(log.latency || 0) ? "NA" : <div>{(log.latency || 0)?.toFixed(2)}ms</div>}
 							/>
+							{log.time_to_first_token !== undefined && (
+								<LogEntryDetailsView
+									className="w-full"
+									label="Time to First Token"
+									value={<div>{log.time_to_first_token.toFixed(2)}ms</div>}
+								/>
+							)}
+							{log.tokens_per_second !== undefined && (
+								<LogEntryDetailsView
+									className="w-full"
+									label="Tokens / Second"
+									value={<div>{log.tokens_per_second.toFixed(1)}</div>}
+								/>
+							)}
 						</div>
 					</div>
 					<DottedSeparator />
@@ -307,6 +322,7 @@ export function LogDetailSheet({ log, open, onOpenChange }: LogDetailSheetProps)
 								</div>
 							</>
 						)}
+						<TraceView requestId={log.id} />
 						{log.error_details?.error.message && (
 							<>
 								<div className="mt-4 w-full text-left text-sm font-medium">Error</div>
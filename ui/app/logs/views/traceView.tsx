@@ -0,0 +1,118 @@
+"use client";
+
+import { Badge } from "@/components/ui/badge";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { useGetRequestTraceQuery } from "@/lib/store";
+import { TraceChange } from "@/lib/types/playground";
+import { RequestTimeline } from "@/lib/types/traces";
+
+// formatLatency formats a latency in nanoseconds, e.g. 1.2ms
+function formatLatency(nanoseconds: number): string {
+	const ms = nanoseconds / 1e6;
+	return ms < 1 ? `${(ms * 1000).toFixed(0)}µs` : `${ms.toFixed(1)}ms`;
+}
+
+// formatChanges lists changed values, e.g. model: "gpt-4o" → "gpt-4o-mini"
+function formatChanges(changes?: TraceChange[]): string {
+	return (changes || [])
+		.map((change) => `${change.path}: ${change.old === undefined ? "∅" : JSON.stringify(change.old)} → ${change.new === undefined ? "∅" : JSON.stringify(change.new)}`)
+		.join("\n");
+}
+
+interface TraceRow {
+	step: string;
+	detail?: string;
+	changes?: string;
+	error?: string;
+	latency: number;
+}
+
+// traceRows flattens the timeline into middlewares, interceptors and pipeline steps in the order they ran
+function traceRows(trace: RequestTimeline): TraceRow[] {
+	const rows: TraceRow[] = trace.middlewares.map((m) => ({
+		step: `${m.name} middleware`,
+		detail: m.stopped ? "answered the request" : undefined,
+		changes: formatChanges(m.changes),
+		latency: m.before + m.after,
+	}));
+	for (const i of trace.interceptors || []) {
+		rows.push({ step: `${i.plugin} interceptor`, changes: formatChanges([...(i.headers || []), ...(i.body || [])]), error: i.error, latency: i.latency });
+	}
+	for (const s of trace.pipeline.steps) {
+		switch (s.stage) {
+			case "pre_hook":
+			case "post_hook":
+				rows.push({
+					step: `${s.plugin} ${s.stage === "pre_hook" ? "pre-hook" : "post-hook"}`,
+					detail: s.decision?.replace("_", " "),
+					changes: formatChanges(s.changes),
+					error: s.error,
+					latency: s.latency,
+				});
+				break;
+			case "fallback":
+				rows.push({ step: `Fallback to ${s.provider}/${s.model}`, latency: 0 });
+				break;
+			default:
+				rows.push({
+					step: `${s.provider}/${s.model} attempt ${s.attempt}`,
+					detail: s.usage ? `${s.usage.total_tokens} tokens` : undefined,
+					error: s.error,
+					latency: s.latency,
+				});
+		}
+	}
+	return rows;
+}
+
+// TraceView shows where the time of a request went and what changed it, when request tracing is on and the trace
+// is still kept
+export default function TraceView({ requestId }: { requestId: string }) {
+	const { data: trace } = useGetRequestTraceQuery(requestId);
+	if (!trace) {
+		return null;
+	}
+	const { summary } = trace;
+	return (
+		<>
+			<div className="mt-4 w-full text-left text-sm font-medium">Trace</div>
+			<div className="text-muted-foreground flex flex-wrap gap-4 text-xs">
+				<span>Total: {formatLatency(trace.duration)}</span>
+				<span>Middlewares: {formatLatency(summary.middleware_latency)}</span>
+				<span>Plugins: {formatLatency(summary.plugin_latency)}</span>
+				<span>Provider: {formatLatency(summary.provider_latency)}</span>
+				<span>Attempts: {summary.attempts}</span>
+				{summary.fallbacks > 0 && <span>Fallbacks: {summary.fallbacks}</span>}
+				{summary.stopped_by && <Badge variant="destructive">Stopped by {summary.stopped_by}</Badge>}
+			</div>
+			<div className="w-full rounded-sm border">
+				<Table>
+					<TableHeader>
+						<TableRow>
+							<TableHead>Step</TableHead>
+							<TableHead>Changes</TableHead>
+							<TableHead className="text-right">Latency</TableHead>
+						</TableRow>
+					</TableHeader>
+					<TableBody>
+						{traceRows(trace).map((row, i) => (
+							<TableRow key={i}>
+								<TableCell className="text-xs">
+									{row.step}
+									{row.detail && (
+										<Badge variant="outline" className="ml-2">
+											{row.detail}
+										</Badge>
+									)}
+									{row.error && <div className="text-destructive">{row.error}</div>}
+								</TableCell>
+								<TableCell className="font-mono text-xs whitespace-pre-wrap">{row.changes}</TableCell>
+								<TableCell className="text-right text-xs">{formatLatency(row.latency)}</TableCell>
+							</TableRow>
+						))}
+					</TableBody>
+				</Table>
+			</div>
+		</>
+	);
+}
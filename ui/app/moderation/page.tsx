@@ -0,0 +1,7 @@
+"use client";
+
+import ReviewQueue from "./views/reviewQueue";
+
+export default function ModerationPage() {
+	return <ReviewQueue />;
+}
@@ -0,0 +1,82 @@
+"use client";
+
+import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
+import { Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle } from "@/components/ui/dialog";
+import { Input } from "@/components/ui/input";
+import { Textarea } from "@/components/ui/textarea";
+import { getErrorMessage, useReviewModerationEventMutation } from "@/lib/store";
+import { ModerationEvent, ReviewModerationEventRequest } from "@/lib/types/moderation";
+import { useState } from "react";
+import { toast } from "sonner";
+
+interface ReviewDialogProps {
+	event: ModerationEvent;
+	onClose: () => void;
+}
+
+export default function ReviewDialog({ event, onClose }: ReviewDialogProps) {
+	const [reviewModerationEvent, { isLoading }] = useReviewModerationEventMutation();
+	const [reviewer, setReviewer] = useState(event.reviewer || "");
+	const [note, setNote] = useState(event.review_note || "");
+
+	const handleReview = async (status?: ReviewModerationEventRequest["status"]) => {
+		try {
+			await reviewModerationEvent({ id: event.id, status, reviewer, note }).unwrap();
+			toast.success(status ? `Event ${status}.` : "Note saved.");
+			onClose();
+		} catch (error) {
+			toast.error(getErrorMessage(error));
+		}
+	};
+
+	const scores = Object.entries(event.scores || {}).sort(([, a], [, b]) => b - a);
+
+	return (
+		<Dialog open onOpenChange={onClose}>
+			<DialogContent className="max-h-[90vh] max-w-2xl overflow-y-auto">
+				<DialogHeader>
+					<DialogTitle>Review moderation event</DialogTitle>
+					<DialogDescription>
+						{event.stage === "input" ? "Prompt" : "Completion"} {event.action === "hold" ? "held" : "flagged"} by policy {event.policy}
+						{event.virtual_key ? ` for virtual key ${event.virtual_key}` : ""}.
+						{event.action === "hold" && " Approving releases the request; denying rejects it."}
+					</DialogDescription>
+				</DialogHeader>
+				<div className="space-y-4">
+					<div className="flex flex-wrap gap-2">
+						{scores.map(([category, score]) => (
+							<Badge key={category} variant={event.flagged?.includes(category) ? "destructive" : "outline"}>
+								{category}: {score.toFixed(2)}
+							</Badge>
+						))}
+					</div>
+					<pre className="bg-muted max-h-64 overflow-auto rounded-sm p-3 text-sm whitespace-pre-wrap">{event.content}</pre>
+					<div className="space-y-2">
+						<label htmlFor="reviewer" className="text-sm font-medium">
+							Reviewer
+						</label>
+						<Input id="reviewer" value={reviewer} onChange={(e) => setReviewer(e.target.value)} placeholder="Your name" />
+					</div>
+					<div className="space-y-2">
+						<label htmlFor="review-note" className="text-sm font-medium">
+							Note
+						</label>
+						<Textarea id="review-note" value={note} onChange={(e) => setNote(e.target.value)} rows={3} />
+					</div>
+				</div>
+				<DialogFooter>
+					<Button variant="outline" disabled={isLoading} onClick={() => handleReview()}>
+						Save note
+					</Button>
+					<Button variant="destructive" disabled={isLoading} onClick={() => handleReview("denied")}>
+						Deny
+					</Button>
+					<Button disabled={isLoading} onClick={() => handleReview("approved")}>
+						Approve
+					</Button>
+				</DialogFooter>
+			</DialogContent>
+		</Dialog>
+	);
+}
@@ -0,0 +1,139 @@
+"use client";
+
+import FullPageLoader from "@/components/fullPageLoader";
+import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
+import { CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { getErrorMessage, useGetModerationEventsQuery } from "@/lib/store";
+import { ModerationEvent, ReviewStatus } from "@/lib/types/moderation";
+import { RefreshCcw } from "lucide-react";
+import { useEffect, useState } from "react";
+import { toast } from "sonner";
+import ReviewDialog from "./reviewDialog";
+
+const PAGE_SIZE = 50;
+
+const REVIEW_STATUS_VARIANTS: Record<ReviewStatus, "secondary" | "success" | "destructive"> = {
+	pending: "secondary",
+	approved: "success",
+	denied: "destructive",
+};
+
+export default function ReviewQueue() {
+	const [reviewStatus, setReviewStatus] = useState<ReviewStatus | "all">("pending");
+	const [offset, setOffset] = useState(0);
+	const [selected, setSelected] = useState<ModerationEvent | null>(null);
+
+	const { data, error, isLoading, isFetching, refetch } = useGetModerationEventsQuery({
+		review_status: reviewStatus === "all" ? undefined : reviewStatus,
+		limit: PAGE_SIZE,
+		offset,
+	});
+
+	useEffect(() => {
+		if (error) {
+			toast.error(`Failed to load moderation events: ${getErrorMessage(error)}`);
+		}
+	}, [error]);
+
+	if (isLoading) {
+		return <FullPageLoader />;
+	}
+
+	const events = data?.events || [];
+	const total = data?.total || 0;
+
+	return (
+		<div className="space-y-4">
+			<CardHeader className="mb-4 px-0">
+				<CardTitle className="flex items-center justify-between">
+					<div className="flex items-center gap-2">Moderation Review Queue</div>
+					<div className="flex items-center gap-2">
+						<Select
+							value={reviewStatus}
+							onValueChange={(value) => {
+								setReviewStatus(value as ReviewStatus | "all");
+								setOffset(0);
+							}}
+						>
+							<SelectTrigger className="w-40">
+								<SelectValue />
+							</SelectTrigger>
+							<SelectContent>
+								<SelectItem value="pending">Pending</SelectItem>
+								<SelectItem value="approved">Approved</SelectItem>
+								<SelectItem value="denied">Denied</SelectItem>
+								<SelectItem value="all">All events</SelectItem>
+							</SelectContent>
+						</Select>
+						<Button variant="outline" size="icon" disabled={isFetching} onClick={() => refetch()}>
+							<RefreshCcw className="h-4 w-4" />
+						</Button>
+					</div>
+				</CardTitle>
+				<CardDescription>Flagged and held requests waiting for review. Approving a held request releases it.</CardDescription>
+			</CardHeader>
+			<div className="rounded-sm border">
+				<Table>
+					<TableHeader>
+						<TableRow>
+							<TableHead>Time</TableHead>
+							<TableHead>Stage</TableHead>
+							<TableHead>Action</TableHead>
+							<TableHead>Categories</TableHead>
+							<TableHead>Virtual Key</TableHead>
+							<TableHead>Content</TableHead>
+							<TableHead>Review</TableHead>
+							<TableHead className="text-right">Actions</TableHead>
+						</TableRow>
+					</TableHeader>
+					<TableBody>
+						{events.length === 0 && (
+							<TableRow>
+								<TableCell colSpan={8} className="py-6 text-center">
+									No moderation events found.
+								</TableCell>
+							</TableRow>
+						)}
+						{events.map((event) => (
+							<TableRow key={event.id}>
+								<TableCell className="whitespace-nowrap">{new Date(event.created_at).toLocaleString()}</TableCell>
+								<TableCell>{event.stage}</TableCell>
+								<TableCell>
+									<Badge variant={event.action === "block" ? "destructive" : "outline"}>{event.action}</Badge>
+								</TableCell>
+								<TableCell>{event.flagged?.join(", ")}</TableCell>
+								<TableCell>{event.virtual_key || "-"}</TableCell>
+								<TableCell className="max-w-72 overflow-hidden text-ellipsis whitespace-nowrap">{event.content}</TableCell>
+								<TableCell>
+									{event.review_status ? <Badge variant={REVIEW_STATUS_VARIANTS[event.review_status]}>{event.review_status}</Badge> : "-"}
+								</TableCell>
+								<TableCell className="text-right">
+									<Button variant="outline" size="sm" onClick={() => setSelected(event)}>
+										Review
+									</Button>
+								</TableCell>
+							</TableRow>
+						))}
+					</TableBody>
+				</Table>
+			</div>
+			{total > PAGE_SIZE && (
+				<div className="flex items-center justify-end gap-2 text-sm">
+					<span className="text-muted-foreground">
+						{offset + 1}-{Math.min(offset + PAGE_SIZE, total)} of {total}
+					</span>
+					<Button variant="outline" size="sm" disabled={offset === 0} onClick={() => setOffset(Math.max(0, offset - PAGE_SIZE))}>
+						Previous
+					</Button>
+					<Button variant="outline" size="sm" disabled={offset + PAGE_SIZE >= total} onClick={() => setOffset(offset + PAGE_SIZE)}>
+						Next
+					</Button>
+				</div>
+			)}
+			{selected && <ReviewDialog event={selected} onClose={() => setSelected(null)} />}
+		</div>
+	);
+}
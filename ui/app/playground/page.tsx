@@ -0,0 +1,7 @@
+"use client";
+
+import PlaygroundView from "./views/playgroundView";
+
+export default function PlaygroundPage() {
+	return <PlaygroundView />;
+}
@@ -0,0 +1,255 @@
+"use client";
+
+import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
+import { CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Collapsible, CollapsibleContent, CollapsibleTrigger } from "@/components/ui/collapsible";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { Textarea } from "@/components/ui/textarea";
+import { getErrorMessage, useGetProvidersQuery, useSendPlaygroundChatMutation } from "@/lib/store";
+import { PipelineTraceStep, PlaygroundChatResponse, PlaygroundMessage, UpstreamExchange } from "@/lib/types/playground";
+import { ChevronRight, Send } from "lucide-react";
+import { useState } from "react";
+import { toast } from "sonner";
+
+const ANY_KEY = "any";
+
+// formatLatency formats a latency in nanoseconds, e.g. 1.2ms
+function formatLatency(nanoseconds: number): string {
+	const ms = nanoseconds / 1e6;
+	return ms < 1 ? `${(ms * 1000).toFixed(0)}µs` : `${ms.toFixed(1)}ms`;
+}
+
+// decodeBody decodes a base64 body, pretty-printing JSON
+function decodeBody(body?: string): string {
+	if (!body) {
+		return "";
+	}
+	let text: string;
+	try {
+		text = new TextDecoder().decode(Uint8Array.from(atob(body), (c) => c.charCodeAt(0)));
+	} catch {
+		return body;
+	}
+	try {
+		return JSON.stringify(JSON.parse(text), null, 2);
+	} catch {
+		return text;
+	}
+}
+
+// describeStep names what a step of the trace did
+function describeStep(step: PipelineTraceStep): string {
+	switch (step.stage) {
+		case "pre_hook":
+			return `${step.plugin} pre-hook`;
+		case "post_hook":
+			return `${step.plugin} post-hook`;
+		case "fallback":
+			return `Fallback to ${step.provider}/${step.model}`;
+		default:
+			return `${step.provider}/${step.model} attempt ${step.attempt}${step.key_id ? ` with key ${step.key_id}` : ""}`;
+	}
+}
+
+function ExchangeView({ exchange, index }: { exchange: UpstreamExchange; index: number }) {
+	return (
+		<Collapsible className="rounded-sm border">
+			<CollapsibleTrigger className="flex w-full items-center gap-2 px-3 py-2 text-left text-sm">
+				<ChevronRight className="h-4 w-4" />
+				<span className="font-medium">#{index + 1}</span>
+				<span className="font-mono text-xs">
+					{exchange.method} {exchange.url}
+				</span>
+				<Badge variant={exchange.error || (exchange.status_code || 0) >= 400 ? "destructive" : "outline"} className="ml-auto">
+					{exchange.error ? "error" : exchange.status_code}
+				</Badge>
+				<span className="text-muted-foreground text-xs">{formatLatency(exchange.latency)}</span>
+			</CollapsibleTrigger>
+			<CollapsibleContent className="grid gap-2 border-t p-3 md:grid-cols-2">
+				<div>
+					<div className="text-muted-foreground mb-1 text-xs">Request</div>
+					<pre className="bg-muted max-h-96 overflow-auto rounded-sm p-2 text-xs">
+						{Object.entries(exchange.request_headers || {})
+							.map(([name, value]) => `${name}: ${value}`)
+							.join("\n")}
+						{"\n\n"}
+						{decodeBody(exchange.request_body)}
+					</pre>
+				</div>
+				<div>
+					<div className="text-muted-foreground mb-1 text-xs">Response</div>
+					<pre className="bg-muted max-h-96 overflow-auto rounded-sm p-2 text-xs">
+						{exchange.error ||
+							`${Object.entries(exchange.response_headers || {})
+								.map(([name, value]) => `${name}: ${value}`)
+								.join("\n")}\n\n${decodeBody(exchange.response_body)}`}
+					</pre>
+				</div>
+			</CollapsibleContent>
+		</Collapsible>
+	);
+}
+
+export default function PlaygroundView() {
+	const { data: providers } = useGetProvidersQuery();
+	const [sendChat, { isLoading }] = useSendPlaygroundChatMutation();
+	const [provider, setProvider] = useState("");
+	const [keyID, setKeyID] = useState(ANY_KEY);
+	const [model, setModel] = useState("");
+	const [systemPrompt, setSystemPrompt] = useState("");
+	const [prompt, setPrompt] = useState("");
+	const [maxTokens, setMaxTokens] = useState("");
+	const [result, setResult] = useState<PlaygroundChatResponse | null>(null);
+
+	const keys = providers?.find((p) => p.name === provider)?.keys || [];
+
+	const send = async () => {
+		const messages: PlaygroundMessage[] = [];
+		if (systemPrompt.trim()) {
+			messages.push({ role: "system", content: systemPrompt });
+		}
+		messages.push({ role: "user", content: prompt });
+		try {
+			const response = await sendChat({
+				provider,
+				model,
+				key_id: keyID === ANY_KEY ? undefined : keyID,
+				messages,
+				params: maxTokens ? { max_completion_tokens: Number(maxTokens) } : undefined,
+			}).unwrap();
+			setResult(response);
+		} catch (error) {
+			toast.error(`Playground request failed: ${getErrorMessage(error)}`);
+		}
+	};
+
+	const answer = result?.response?.choices?.[0]?.message?.content;
+
+	return (
+		<div className="space-y-4">
+			<CardHeader className="mb-4 px-0">
+				<CardTitle>Playground</CardTitle>
+				<CardDescription>
+					Send a test prompt through every plugin and the provider, and inspect what each step decided and the exact exchange with the
+					provider. Requests are capped in completion tokens and cost by the <code>playground</code> section of config.json.
+				</CardDescription>
+			</CardHeader>
+			<div className="grid gap-4 md:grid-cols-3">
+				<div className="space-y-2">
+					<Label>Provider</Label>
+					<Select
+						value={provider}
+						onValueChange={(value) => {
+							setProvider(value);
+							setKeyID(ANY_KEY);
+						}}
+					>
+						<SelectTrigger className="w-full">
+							<SelectValue placeholder="Select a provider" />
+						</SelectTrigger>
+						<SelectContent>
+							{(providers || []).map((p) => (
+								<SelectItem key={p.name} value={p.name}>
+									{p.name}
+								</SelectItem>
+							))}
+						</SelectContent>
+					</Select>
+				</div>
+				<div className="space-y-2">
+					<Label>Key</Label>
+					<Select value={keyID} onValueChange={setKeyID} disabled={!provider}>
+						<SelectTrigger className="w-full">
+							<SelectValue />
+						</SelectTrigger>
+						<SelectContent>
+							<SelectItem value={ANY_KEY}>Selected by weight</SelectItem>
+							{keys.map((key) => (
+								<SelectItem key={key.id} value={key.id}>
+									{key.id}
+								</SelectItem>
+							))}
+						</SelectContent>
+					</Select>
+				</div>
+				<div className="space-y-2">
+					<Label>Model</Label>
+					<Input value={model} onChange={(e) => setModel(e.target.value)} placeholder="gpt-4o-mini" />
+				</div>
+			</div>
+			<div className="space-y-2">
+				<Label>System prompt</Label>
+				<Textarea value={systemPrompt} onChange={(e) => setSystemPrompt(e.target.value)} rows={2} />
+			</div>
+			<div className="space-y-2">
+				<Label>Prompt</Label>
+				<Textarea value={prompt} onChange={(e) => setPrompt(e.target.value)} rows={4} />
+			</div>
+			<div className="flex items-end gap-4">
+				<div className="space-y-2">
+					<Label>Max completion tokens</Label>
+					<Input type="number" min={1} value={maxTokens} onChange={(e) => setMaxTokens(e.target.value)} placeholder="Playground limit" />
+				</div>
+				<Button onClick={send} disabled={isLoading || !provider || !model || !prompt.trim()}>
+					<Send className="h-4 w-4" />
+					{isLoading ? "Sending..." : "Send"}
+				</Button>
+			</div>
+
+			{result && (
+				<div className="space-y-4">
+					<div className="rounded-sm border p-4">
+						<div className="text-muted-foreground mb-2 flex flex-wrap gap-4 text-xs">
+							<span>Max tokens: {result.max_tokens}</span>
+							{result.estimated_cost !== undefined && <span>Worst-case cost: ${result.estimated_cost.toFixed(6)}</span>}
+							{result.cost !== undefined && <span>Cost: ${result.cost.toFixed(6)}</span>}
+							{result.response?.usage && <span>Tokens: {result.response.usage.total_tokens}</span>}
+						</div>
+						{result.error ? (
+							<div className="text-destructive text-sm">{result.error.error?.message || "The request failed"}</div>
+						) : (
+							<div className="text-sm whitespace-pre-wrap">{answer}</div>
+						)}
+					</div>
+
+					<div className="rounded-sm border">
+						<Table>
+							<TableHeader>
+								<TableRow>
+									<TableHead>Step</TableHead>
+									<TableHead>Decision</TableHead>
+									<TableHead>Error</TableHead>
+									<TableHead className="text-right">Latency</TableHead>
+								</TableRow>
+							</TableHeader>
+							<TableBody>
+								{result.trace.steps.map((step, i) => (
+									<TableRow key={i}>
+										<TableCell>{describeStep(step)}</TableCell>
+										<TableCell>{step.decision && <Badge variant="outline">{step.decision.replace("_", " ")}</Badge>}</TableCell>
+										<TableCell className="text-destructive text-xs">{step.error}</TableCell>
+										<TableCell className="text-right text-xs">{formatLatency(step.latency)}</TableCell>
+									</TableRow>
+								))}
+							</TableBody>
+						</Table>
+						{result.trace.truncated && <div className="text-muted-foreground p-2 text-xs">Later steps were left out of the trace.</div>}
+					</div>
+
+					{result.trace.upstream.length > 0 && (
+						<div className="space-y-2">
+							<div className="text-sm font-medium">Provider requests</div>
+							{result.trace.upstream.map((exchange, i) => (
+								<ExchangeView key={i} exchange={exchange} index={i} />
+							))}
+						</div>
+					)}
+				</div>
+			)}
+		</div>
+	);
+}
@@ -2,9 +2,10 @@ import { Button } from "@/components/ui/button";
 import { Dialog, DialogContent, DialogDescription, DialogFooter, DialogHeader, DialogTitle } from "@/components/ui/dialog";
 import { Form, FormControl, FormField, FormItem, FormLabel, FormMessage } from "@/components/ui/form";
 import { Input } from "@/components/ui/input";
+import { Textarea } from "@/components/ui/textarea";
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
 import { getErrorMessage, useCreateProviderMutation } from "@/lib/store";
-import { KnownProvider, ModelProviderName } from "@/lib/types/config";
+import { BaseProviderType, ModelProviderName, ProviderSpec } from "@/lib/types/config";
 import { zodResolver } from "@hookform/resolvers/zod";
 import { useEffect } from "react";
 import { useForm } from "react-hook-form";
@@ -17,18 +18,34 @@ const allowedRequestsSchema = z.object({
 	chat_completion: z.boolean(),
 	chat_completion_stream: z.boolean(),
 	embedding: z.boolean(),
+	rerank: z.boolean(),
 	speech: z.boolean(),
 	speech_stream: z.boolean(),
 	transcription: z.boolean(),
 	transcription_stream: z.boolean(),
 });
 
-const formSchema = z.object({
-	name: z.string().min(1),
-	baseFormat: z.string().min(1),
-	base_url: z.string().min(1, "Base URL is required").url("Must be a valid URL"),
-	allowed_requests: allowedRequestsSchema,
-});
+const parseSpec = (spec: string): ProviderSpec | undefined => {
+	try {
+		const parsed = JSON.parse(spec);
+		return parsed && typeof parsed === "object" && !Array.isArray(parsed) ? parsed : undefined;
+	} catch {
+		return undefined;
+	}
+};
+
+const formSchema = z
+	.object({
+		name: z.string().min(1),
+		baseFormat: z.string().min(1),
+		base_url: z.string().min(1, "Base URL is required").url("Must be a valid URL"),
+		allowed_requests: allowedRequestsSchema,
+		spec: z.string().optional(),
+	})
+	.refine((data) => data.baseFormat !== "declarative" || parseSpec(data.spec ?? "") !== undefined, {
+		message: "Spec must be a JSON object",
+		path: ["spec"],
+	});
 
 type FormData = z.infer<typeof formSchema>;
 
@@ -46,11 +63,13 @@ export default function AddCustomProviderDialog({ show, onClose, onSave }: Props
 			name: "",
 			baseFormat: "",
 			base_url: "",
+			spec: "",
 			allowed_requests: {
 				text_completion: true,
 				chat_completion: true,
 				chat_completion_stream: true,
 				embedding: true,
+				rerank: true,
 				speech: true,
 				speech_stream: true,
 				transcription: true,
@@ -69,8 +88,9 @@ export default function AddCustomProviderDialog({ show, onClose, onSave }: Props
 		addProvider({
 			provider: data.name as ModelProviderName,
 			custom_provider_config: {
-				base_provider_type: data.baseFormat as KnownProvider,
+				base_provider_type: data.baseFormat as BaseProviderType,
 				allowed_requests: data.allowed_requests,
+				spec: data.baseFormat === "declarative" ? parseSpec(data.spec ?? "") : undefined,
 			},
 			network_config: {
 				base_url: data.base_url,
@@ -135,6 +155,7 @@ export default function AddCustomProviderDialog({ show, onClose, onSave }: Props
 													<SelectItem value="gemini">Gemini</SelectItem>
 													<SelectItem value="cohere">Cohere</SelectItem>
 													<SelectItem value="bedrock">AWS Bedrock</SelectItem>
+													<SelectItem value="declarative">Declarative (spec)</SelectItem>
 												</SelectContent>
 											</Select>
 										</FormControl>
@@ -162,6 +183,28 @@ export default function AddCustomProviderDialog({ show, onClose, onSave }: Props
 								</FormItem>
 							)}
 						/>
+						{form.watch("baseFormat") === "declarative" && (
+							<FormField
+								control={form.control}
+								name="spec"
+								render={({ field }) => (
+									<FormItem className="flex flex-col gap-3">
+										<FormLabel>Spec</FormLabel>
+										<div>
+											<FormControl>
+												<Textarea
+													className="min-h-[200px] font-mono text-xs"
+													placeholder={'{"chat_completion": {"path": "/generate", "request": {"inputs": ".messages[-1].content"}, "response": {"content": ".generated_text"}}}'}
+													{...field}
+													value={field.value || ""}
+												/>
+											</FormControl>
+											<FormMessage />
+										</div>
+									</FormItem>
+								)}
+							/>
+						)}
 						{/* Allowed Requests Configuration */}
 						<AllowedRequestsFields control={form.control} />
 						<DialogFooter className="flex flex-row gap-2">
@@ -14,6 +14,7 @@ const REQUEST_TYPES = [
 	{ key: "chat_completion", label: "Chat Completion" },
 	{ key: "chat_completion_stream", label: "Chat Completion Stream" },
 	{ key: "embedding", label: "Embedding" },
+	{ key: "rerank", label: "Rerank" },
 	{ key: "speech", label: "Speech" },
 	{ key: "speech_stream", label: "Speech Stream" },
 	{ key: "transcription", label: "Transcription" },
@@ -21,8 +22,8 @@ const REQUEST_TYPES = [
 ];
 
 export function AllowedRequestsFields({ control, namePrefix = "allowed_requests" }: AllowedRequestsFieldsProps) {
-	const leftColumn = REQUEST_TYPES.slice(0, 4);
-	const rightColumn = REQUEST_TYPES.slice(4);
+	const leftColumn = REQUEST_TYPES.slice(0, 5);
+	const rightColumn = REQUEST_TYPES.slice(5);
 
 	return (
 		<div className="space-y-4">
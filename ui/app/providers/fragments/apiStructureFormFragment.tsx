@@ -7,7 +7,7 @@ import { Tooltip, TooltipContent, TooltipProvider, TooltipTrigger } from "@/comp
 import { AllowedRequestsFields } from "./allowedRequestsFields";
 import { getErrorMessage, setProviderFormDirtyState, useAppDispatch } from "@/lib/store";
 import { useUpdateProviderMutation } from "@/lib/store/apis/providersApi";
-import { KnownProvider, ModelProvider } from "@/lib/types/config";
+import { BaseProviderType, ModelProvider } from "@/lib/types/config";
 import { formCustomProviderConfigSchema } from "@/lib/types/schemas";
 import { zodResolver } from "@hookform/resolvers/zod";
 import { use, useEffect } from "react";
@@ -41,6 +41,7 @@ export function ApiStructureFormFragment({ provider, showRestartAlert }: Props)
 				chat_completion: provider.custom_provider_config?.allowed_requests?.chat_completion ?? true,
 				chat_completion_stream: provider.custom_provider_config?.allowed_requests?.chat_completion_stream ?? true,
 				embedding: provider.custom_provider_config?.allowed_requests?.embedding ?? true,
+				rerank: provider.custom_provider_config?.allowed_requests?.rerank ?? true,
 				speech: provider.custom_provider_config?.allowed_requests?.speech ?? true,
 				speech_stream: provider.custom_provider_config?.allowed_requests?.speech_stream ?? true,
 				transcription: provider.custom_provider_config?.allowed_requests?.transcription ?? true,
@@ -62,8 +63,10 @@ export function ApiStructureFormFragment({ provider, showRestartAlert }: Props)
 		updateProvider({
 			...provider,
 			custom_provider_config: {
-				base_provider_type: data.base_provider_type as unknown as KnownProvider,
+				base_provider_type: data.base_provider_type as BaseProviderType,
 				allowed_requests: data.allowed_requests,
+				// The spec of declarative providers is not edited here
+				spec: provider.custom_provider_config?.spec,
 			},
 		})
 			.unwrap()
@@ -109,6 +112,7 @@ export function ApiStructureFormFragment({ provider, showRestartAlert }: Props)
 										<SelectItem value="bedrock">AWS Bedrock</SelectItem>
 										<SelectItem value="cohere">Cohere</SelectItem>
 										<SelectItem value="gemini">Gemini</SelectItem>
+										<SelectItem value="declarative">Declarative</SelectItem>
 									</SelectContent>
 								</Select>
 								<FormDescription>The underlying provider this custom provider will use</FormDescription>
@@ -12,6 +12,7 @@ import {
 	setSelectedProvider,
 	useAppDispatch,
 	useAppSelector,
+	useGetProviderHealthQuery,
 	useGetProvidersQuery,
 	useLazyGetProviderQuery
 } from "@/lib/store";
@@ -24,6 +25,7 @@ import { toast } from "sonner";
 import AddCustomProviderDialog from "./dialogs/addNewCustomProviderDialog";
 import ConfirmDeleteProviderDialog from "./dialogs/confirmDeleteProviderDialog";
 import ConfirmRedirectionDialog from "./dialogs/confirmRedirection";
+import { ProviderHealthDot, ProviderHealthTooltip } from "./views/providerHealthIndicator";
 
 export default function Providers() {
 	const dispatch = useAppDispatch();
@@ -38,6 +40,8 @@ export default function Providers() {
 
 	const { data: savedProviders, isLoading: isLoadingProviders } = useGetProvidersQuery();
 	const [getProvider, { isLoading: isLoadingProvider }] = useLazyGetProviderQuery();
+	const { data: providerHealth } = useGetProviderHealthQuery(undefined, { pollingInterval: 30000 });
+	const healthOf = (name: string) => providerHealth?.providers.find((health) => health.provider === name);
 
 	const allProviders = ProviderNames.map((p) => savedProviders?.find((provider) => provider.name === p) ?? { name: p, keys: [] }).sort(
 		(a, b) => a.name.localeCompare(b.name),
@@ -167,8 +171,10 @@ export default function Providers() {
 															<div className="text-sm">{ProviderLabels[p.name as keyof typeof ProviderLabels]}</div>
 														</>
 													)}
+													<ProviderHealthDot health={healthOf(p.name)} />
 												</span>
 											</TooltipTrigger>
+											<ProviderHealthTooltip health={healthOf(p.name)} />
 										</Tooltip>
 									);
 								})}
@@ -211,6 +217,7 @@ export default function Providers() {
 															<div className="text-sm">{ProviderLabels[p.name as keyof typeof ProviderLabels]}</div>
 														</>
 													)}
+													<ProviderHealthDot health={healthOf(p.name)} />
 												</div>
 												{selectedProvider?.name === p.name && (
 													<Trash
@@ -224,6 +231,7 @@ export default function Providers() {
 												)}
 											</div>
 										</TooltipTrigger>
+										<ProviderHealthTooltip health={healthOf(p.name)} />
 									</Tooltip>
 								))}
 							</div>
@@ -3,8 +3,9 @@
 import { Button } from "@/components/ui/button";
 import { CardHeader, CardTitle } from "@/components/ui/card";
 import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
-import { ModelProvider, ModelProviderKey } from "@/lib/types/config";
-import { EllipsisIcon, PencilIcon, PlusIcon, TrashIcon } from "lucide-react";
+import { Tooltip, TooltipContent, TooltipProvider, TooltipTrigger } from "@/components/ui/tooltip";
+import { KeyValidation, ModelProvider, ModelProviderKey } from "@/lib/types/config";
+import { CircleCheckIcon, CircleHelpIcon, EllipsisIcon, PencilIcon, PlusIcon, ShieldCheckIcon, TrashIcon, TriangleAlertIcon } from "lucide-react";
 import { useState } from "react";
 import AddNewKeyDialog from "../dialogs/addNewKeyDialog";
 import { DropdownMenu, DropdownMenuContent, DropdownMenuItem, DropdownMenuTrigger } from "@/components/ui/dropdownMenu";
@@ -18,7 +19,7 @@ import {
 	AlertDialogHeader,
 	AlertDialogTitle,
 } from "@/components/ui/alertDialog";
-import { getErrorMessage, useUpdateProviderMutation } from "@/lib/store";
+import { getErrorMessage, useUpdateProviderMutation, useValidateProviderKeysMutation } from "@/lib/store";
 import { toast } from "sonner";
 import { cn } from "@/lib/utils";
 import { KnownProvidersNames } from "@/lib/constants/logs";
@@ -28,8 +29,39 @@ interface Props {
 	provider: ModelProvider;
 }
 
+// Icon showing the result of the last live validation of a key, with its error in a tooltip
+function KeyValidationStatus({ validation }: { validation?: KeyValidation }) {
+	if (!validation) return <span className="text-muted-foreground text-sm">Not validated</span>;
+	const Icon = validation.status === "valid" ? CircleCheckIcon : validation.status === "invalid" ? TriangleAlertIcon : CircleHelpIcon;
+	return (
+		<TooltipProvider>
+			<Tooltip>
+				<TooltipTrigger asChild>
+					<span
+						className={cn(
+							"flex items-center gap-1 text-sm capitalize",
+							validation.status === "valid" && "text-green-600",
+							validation.status === "invalid" && "text-red-600",
+							validation.status === "unknown" && "text-muted-foreground",
+						)}
+					>
+						<Icon className="h-4 w-4" />
+						{validation.status}
+					</span>
+				</TooltipTrigger>
+				<TooltipContent className="max-w-xs text-xs">
+					{validation.model && <div>Validated with {validation.model}</div>}
+					<div>{new Date(validation.validated_at).toLocaleString()}</div>
+					{validation.error && <div className="break-words">{validation.error}</div>}
+				</TooltipContent>
+			</Tooltip>
+		</TooltipProvider>
+	);
+}
+
 export default function ModelProviderKeysTableView({ provider, className }: Props) {
 	const [updateProvider, { isLoading: isUpdatingProvider }] = useUpdateProviderMutation();
+	const [validateProviderKeys] = useValidateProviderKeysMutation();
 	const [showAddNewKeyDialog, setShowAddNewKeyDialog] = useState<{ show: boolean; keyIndex: number } | undefined>(undefined);
 	const [showDeleteKeyDialog, setShowDeleteKeyDialog] = useState<{ show: boolean; keyIndex: number } | undefined>(undefined);
 
@@ -37,6 +69,26 @@ export default function ModelProviderKeysTableView({ provider, className }: Prop
 		setShowAddNewKeyDialog({ show: true, keyIndex: keyIndex });
 	}
 
+	function handleValidateKey(key: ModelProviderKey) {
+		validateProviderKeys({ provider: provider.name, keyId: key.id })
+			.unwrap()
+			.then((results) => {
+				const result = results?.[key.id];
+				if (result?.status === "invalid") {
+					toast.warning("Key validation failed", { description: result.error });
+				} else if (result?.status === "valid") {
+					toast.success("Key is valid");
+				} else {
+					toast.info("Key could not be validated", { description: result?.error });
+				}
+			})
+			.catch((err) => {
+				toast.error("Failed to validate key", {
+					description: getErrorMessage(err),
+				});
+			});
+	}
+
 	function getKey(provider: ModelProvider, key: ModelProviderKey) {
 		switch (provider.name) {
 			case KnownProvidersNames[5]:
@@ -111,13 +163,14 @@ export default function ModelProviderKeysTableView({ provider, className }: Prop
 						<TableRow>
 							<TableHead>API Key</TableHead>
 							<TableHead>Weight</TableHead>
+							<TableHead>Status</TableHead>
 							<TableHead className="text-right"></TableHead>
 						</TableRow>
 					</TableHeader>
 					<TableBody>
 						{provider.keys.length === 0 && (
 							<TableRow>
-								<TableCell colSpan={3} className="py-6 text-center">
+								<TableCell colSpan={4} className="py-6 text-center">
 									No keys found.
 								</TableCell>
 							</TableRow>
@@ -135,6 +188,9 @@ export default function ModelProviderKeysTableView({ provider, className }: Prop
 											<span className="font-mono text-sm">{key.weight}</span>
 										</div>
 									</TableCell>
+									<TableCell>
+										<KeyValidationStatus validation={provider.key_validations?.[key.id]} />
+									</TableCell>
 									<TableCell className="text-right">
 										<div className="flex items-center justify-end space-x-2">
 											<DropdownMenu>
@@ -152,6 +208,10 @@ export default function ModelProviderKeysTableView({ provider, className }: Prop
 														<PencilIcon className="mr-1 h-4 w-4" />
 														Edit
 													</DropdownMenuItem>
+													<DropdownMenuItem onClick={() => handleValidateKey(key)}>
+														<ShieldCheckIcon className="mr-1 h-4 w-4" />
+														Validate
+													</DropdownMenuItem>
 													<DropdownMenuItem
 														onClick={() => {
 															setShowDeleteKeyDialog({ show: true, keyIndex: index });
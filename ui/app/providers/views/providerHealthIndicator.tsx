@@ -0,0 +1,35 @@
+import { TooltipContent } from "@/components/ui/tooltip";
+import { ProviderHealth } from "@/lib/types/config";
+import { cn } from "@/lib/utils";
+
+const statusColors: Record<ProviderHealth["status"], string> = {
+	healthy: "bg-green-500",
+	unhealthy: "bg-red-500",
+	unknown: "bg-zinc-400",
+};
+
+// Dot showing the probed health of a provider, rendered inside the provider list entry
+export function ProviderHealthDot({ health }: { health?: ProviderHealth }) {
+	if (!health) return null;
+	return <span className={cn("ml-auto h-2 w-2 shrink-0 rounded-full", statusColors[health.status])} />;
+}
+
+// Tooltip with availability, latency and the last probe error of a provider
+export function ProviderHealthTooltip({ health }: { health?: ProviderHealth }) {
+	if (!health) return null;
+	return (
+		<TooltipContent side="right" className="max-w-xs text-xs">
+			<div className="font-medium capitalize">{health.status}</div>
+			{health.model && <div>Probe model: {health.model}</div>}
+			{health.history.length > 0 && (
+				<>
+					<div>
+						Availability: {(health.availability * 100).toFixed(1)}% of last {health.history.length} probes
+					</div>
+					<div>Average latency: {health.avg_latency_ms.toFixed(0)}ms</div>
+				</>
+			)}
+			{health.last_error && <div className="break-words">Last error: {health.last_error}</div>}
+		</TooltipContent>
+	);
+}
@@ -49,7 +49,13 @@ export default function ProviderKeyForm({ provider, keyIndex, onCancel, onSave }
 			keys: updatedKeys,
 		})
 			.unwrap()
-			.then(() => {
+			.then((saved) => {
+				const validation = saved.key_validations?.[value.key.id];
+				if (validation?.status === "invalid") {
+					toast.warning("Key saved, but its validation failed", {
+						description: `${validation.error}. Requests routed to this key will fail until it is fixed.`,
+					});
+				}
 				onSave();
 			})
 			.catch((err) => {
@@ -1 +1 @@
-export const keysRequired = (selectedProvider: string) => selectedProvider === "custom" || !["ollama", "sgl"].includes(selectedProvider);
+export const keysRequired = (selectedProvider: string) => selectedProvider === "custom" || !["ollama", "sgl", "mock"].includes(selectedProvider);
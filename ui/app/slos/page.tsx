@@ -0,0 +1,7 @@
+"use client";
+
+import SLOStatusView from "./views/sloStatus";
+
+export default function SLOsPage() {
+	return <SLOStatusView />;
+}
@@ -0,0 +1,147 @@
+"use client";
+
+import FullPageLoader from "@/components/fullPageLoader";
+import { Badge } from "@/components/ui/badge";
+import { Button } from "@/components/ui/button";
+import { CardDescription, CardHeader, CardTitle } from "@/components/ui/card";
+import { Progress } from "@/components/ui/progress";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { getErrorMessage, useGetSLOsQuery } from "@/lib/store";
+import { SLOStatus } from "@/lib/types/slo";
+import { RefreshCcw } from "lucide-react";
+import { useEffect } from "react";
+import { toast } from "sonner";
+
+// describeObjective summarizes the objective, e.g. "99% of streams with a time to first token under 2000ms"
+function describeObjective(status: SLOStatus): string {
+	switch (status.indicator) {
+		case "ttft":
+			return `${status.target}% of streams with a time to first token under ${status.threshold_ms}ms`;
+		case "latency":
+			return `${status.target}% of requests completed under ${status.threshold_ms}ms`;
+		default:
+			return `${status.target}% of requests without server or provider errors`;
+	}
+}
+
+// windowName formats a window in minutes the way the backend names burn rates, e.g. 5m, 1h or 3d
+function windowName(minutes: number): string {
+	if (minutes % (24 * 60) === 0) {
+		return `${minutes / (24 * 60)}d`;
+	}
+	if (minutes % 60 === 0) {
+		return `${minutes / 60}h`;
+	}
+	return `${minutes}m`;
+}
+
+export default function SLOStatusView() {
+	const { data, error, isLoading, isFetching, refetch } = useGetSLOsQuery(undefined, { pollingInterval: 30000 });
+
+	useEffect(() => {
+		if (error) {
+			toast.error(`Failed to load SLOs: ${getErrorMessage(error)}`);
+		}
+	}, [error]);
+
+	if (isLoading) {
+		return <FullPageLoader />;
+	}
+
+	const objectives = data?.objectives || [];
+
+	return (
+		<div className="space-y-4">
+			<CardHeader className="mb-4 px-0">
+				<CardTitle className="flex items-center justify-between">
+					<div className="flex items-center gap-2">Service Level Objectives</div>
+					<Button variant="outline" size="icon" disabled={isFetching} onClick={() => refetch()}>
+						<RefreshCcw className="h-4 w-4" />
+					</Button>
+				</CardTitle>
+				<CardDescription>
+					Compliance and error budget of each objective over its period, with the burn rates driving its alerts. Each replica reports
+					the traffic it served.
+				</CardDescription>
+			</CardHeader>
+			{data && !data.enabled ? (
+				<div className="text-muted-foreground rounded-sm border py-6 text-center text-sm">
+					No SLOs are configured. Define objectives in the <code>slos</code> section of config.json.
+				</div>
+			) : (
+				<div className="rounded-sm border">
+					<Table>
+						<TableHeader>
+							<TableRow>
+								<TableHead>Objective</TableHead>
+								<TableHead>Model</TableHead>
+								<TableHead>Compliance</TableHead>
+								<TableHead className="w-48">Error Budget</TableHead>
+								<TableHead>Burn Rates</TableHead>
+								<TableHead>Alerts</TableHead>
+							</TableRow>
+						</TableHeader>
+						<TableBody>
+							{objectives.length === 0 && (
+								<TableRow>
+									<TableCell colSpan={6} className="py-6 text-center">
+										No requests recorded yet.
+									</TableCell>
+								</TableRow>
+							)}
+							{objectives.map((status) => {
+								const budget = Math.max(0, Math.min(100, status.error_budget_remaining));
+								return (
+									<TableRow key={`${status.name}/${status.model || ""}`}>
+										<TableCell>
+											<div className="font-medium">{status.name}</div>
+											<div className="text-muted-foreground text-xs">{status.description || describeObjective(status)}</div>
+										</TableCell>
+										<TableCell>{status.model || "All models"}</TableCell>
+										<TableCell>
+											<div className={status.compliance < status.target ? "text-destructive font-medium" : "font-medium"}>
+												{status.compliance.toFixed(3)}%
+											</div>
+											<div className="text-muted-foreground text-xs">
+												{status.good.toLocaleString()} / {status.total.toLocaleString()} over {status.period_days}d
+											</div>
+										</TableCell>
+										<TableCell>
+											<Progress value={budget} className={status.error_budget_remaining <= 0 ? "bg-destructive/20" : undefined} />
+											<div className="text-muted-foreground mt-1 text-xs">
+												{status.error_budget_remaining <= 0 ? "Exhausted" : `${status.error_budget_remaining.toFixed(1)}% left`}
+											</div>
+										</TableCell>
+										<TableCell className="text-xs whitespace-nowrap">
+											{status.alerts.map((alert) => (
+												<div key={alert.severity}>
+													{windowName(alert.long_window_minutes)}: {(status.burn_rates[windowName(alert.long_window_minutes)] || 0).toFixed(1)}x,{" "}
+													{windowName(alert.short_window_minutes)}: {(status.burn_rates[windowName(alert.short_window_minutes)] || 0).toFixed(1)}x
+													<span className="text-muted-foreground"> (alert at {alert.burn_rate}x)</span>
+												</div>
+											))}
+										</TableCell>
+										<TableCell>
+											<div className="flex flex-wrap gap-1">
+												{status.alerts.map((alert) => (
+													<Badge
+														key={alert.severity}
+														variant={alert.firing ? "destructive" : "outline"}
+														title={alert.since ? `Firing since ${new Date(alert.since).toLocaleString()}` : undefined}
+													>
+														{alert.severity}
+														{alert.firing ? " firing" : " ok"}
+													</Badge>
+												))}
+											</div>
+										</TableCell>
+									</TableRow>
+								);
+							})}
+						</TableBody>
+					</Table>
+				</div>
+			)}
+		</div>
+	);
+}
@@ -10,6 +10,7 @@ import {
 } from "@/lib/store";
 import { useEffect, useState } from "react";
 import { toast } from "sonner";
+import UsageByLevelTable from "./views/usageByLevelTable";
 import VirtualKeysTable from "./views/virtualKeysTable";
 
 export default function VirtualKeysPage() {
@@ -70,11 +71,14 @@ export default function VirtualKeysPage() {
 	}
 
 	return (
-		<VirtualKeysTable
-			virtualKeys={virtualKeysData?.virtual_keys || []}
-			teams={teamsData?.teams || []}
-			customers={customersData?.customers || []}
-			onRefresh={handleRefresh}
-		/>
+		<div className="space-y-8">
+			<VirtualKeysTable
+				virtualKeys={virtualKeysData?.virtual_keys || []}
+				teams={teamsData?.teams || []}
+				customers={customersData?.customers || []}
+				onRefresh={handleRefresh}
+			/>
+			<UsageByLevelTable />
+		</div>
 	);
 }
@@ -0,0 +1,95 @@
+"use client";
+
+import { Badge } from "@/components/ui/badge";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
+import { Table, TableBody, TableCell, TableHead, TableHeader, TableRow } from "@/components/ui/table";
+import { useGetGovernanceUsageQuery } from "@/lib/store";
+import { GovernanceLevel } from "@/lib/types/governance";
+import { formatCurrency } from "@/lib/utils/governance";
+import { useState } from "react";
+
+const levelLabels: Record<GovernanceLevel, string> = {
+	customer: "Customers",
+	team: "Teams",
+	project: "Projects",
+	virtual_key: "Virtual Keys",
+};
+
+export default function UsageByLevelTable() {
+	const [level, setLevel] = useState<GovernanceLevel>("team");
+	const { data, isFetching } = useGetGovernanceUsageQuery({ level });
+
+	const usage = data?.usage || [];
+
+	return (
+		<div className="space-y-4">
+			<div className="flex items-center justify-between">
+				<p className="text-muted-foreground text-sm">Spend in the current budget period, aggregated over everything below each entity.</p>
+				<Select value={level} onValueChange={(value) => setLevel(value as GovernanceLevel)}>
+					<SelectTrigger className="w-40">
+						<SelectValue />
+					</SelectTrigger>
+					<SelectContent>
+						{(Object.keys(levelLabels) as GovernanceLevel[]).map((key) => (
+							<SelectItem key={key} value={key}>
+								{levelLabels[key]}
+							</SelectItem>
+						))}
+					</SelectContent>
+				</Select>
+			</div>
+
+			<div className="rounded-sm border">
+				<Table>
+					<TableHeader>
+						<TableRow>
+							<TableHead>Name</TableHead>
+							<TableHead>Virtual Keys</TableHead>
+							<TableHead>Spend</TableHead>
+							<TableHead>Budget</TableHead>
+							<TableHead>Rate Limit</TableHead>
+						</TableRow>
+					</TableHeader>
+					<TableBody>
+						{usage.length === 0 ? (
+							<TableRow>
+								<TableCell colSpan={5} className="text-muted-foreground py-8 text-center">
+									{isFetching ? "Loading usage..." : "No usage recorded yet."}
+								</TableCell>
+							</TableRow>
+						) : (
+							usage.map((entry) => (
+								<TableRow key={`${entry.level}/${entry.id}`}>
+									<TableCell className="font-medium">{entry.name}</TableCell>
+									<TableCell>{entry.virtual_keys}</TableCell>
+									<TableCell className="font-mono text-sm">{formatCurrency(entry.spend)}</TableCell>
+									<TableCell>
+										{entry.budget ? (
+											<span className="font-mono text-sm">{formatCurrency(entry.budget.max_limit)}</span>
+										) : (
+											<span className="text-muted-foreground text-sm">-</span>
+										)}
+									</TableCell>
+									<TableCell>
+										{entry.rate_limit ? (
+											<div className="flex flex-wrap gap-1">
+												{entry.rate_limit.token_max_limit != null && (
+													<Badge variant="outline">{entry.rate_limit.token_max_limit.toLocaleString()} tokens</Badge>
+												)}
+												{entry.rate_limit.request_max_limit != null && (
+													<Badge variant="outline">{entry.rate_limit.request_max_limit.toLocaleString()} requests</Badge>
+												)}
+											</div>
+										) : (
+											<span className="text-muted-foreground text-sm">-</span>
+										)}
+									</TableCell>
+								</TableRow>
+							))
+						)}
+					</TableBody>
+				</Table>
+			</div>
+		</div>
+	);
+}
@@ -0,0 +1,55 @@
+"use client";
+
+import { Languages } from "lucide-react";
+
+import { Button } from "@/components/ui/button";
+import { DropdownMenu, DropdownMenuContent, DropdownMenuItem, DropdownMenuTrigger } from "@/components/ui/dropdownMenu";
+import { useGetUILocalesQuery } from "@/lib/store";
+
+// Cookie choosing the locale of the dashboard over the browser languages
+const LOCALE_COOKIE = "bf_locale";
+
+function localeName(locale: string) {
+	try {
+		return new Intl.DisplayNames([locale], { type: "language" }).of(locale) ?? locale;
+	} catch {
+		return locale;
+	}
+}
+
+export function LocaleToggle() {
+	const { data } = useGetUILocalesQuery();
+
+	// Only shown when localized bundles are embedded
+	if (!data || data.locales.length < 2) {
+		return null;
+	}
+
+	const selectLocale = (locale: string) => {
+		document.cookie = `${LOCALE_COOKIE}=${locale}; path=/; max-age=31536000; samesite=lax`;
+		// The localized bundle is served on the next page load
+		window.location.reload();
+	};
+
+	return (
+		<DropdownMenu>
+			<DropdownMenuTrigger asChild>
+				<Button
+					variant="ghost"
+					size="icon"
+					className="hover:text-primary text-muted-foreground h-5 w-5 border-0 ring-offset-0 outline-none select-none focus-visible:ring-0"
+				>
+					<Languages className="h-5.5 w-5.5" strokeWidth={2} />
+					<span className="sr-only">Change language</span>
+				</Button>
+			</DropdownMenuTrigger>
+			<DropdownMenuContent align="end">
+				{data.locales.map((locale) => (
+					<DropdownMenuItem key={locale} onClick={() => selectLocale(locale)} disabled={locale === data.current}>
+						{localeName(locale)}
+					</DropdownMenuItem>
+				))}
+			</DropdownMenuContent>
+		</DropdownMenu>
+	);
+}
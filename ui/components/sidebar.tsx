@@ -7,13 +7,17 @@ import {
 	BugIcon,
 	Building2,
 	Construction,
+	Gauge,
+	GraduationCap,
 	KeyRound,
 	Layers,
 	LogOut,
 	ScrollText,
 	Settings2Icon,
+	ShieldAlert,
 	Shuffle,
 	Telescope,
+	TerminalSquare,
 	Users
 } from "lucide-react";
 
@@ -30,13 +34,15 @@ import {
 import { Tooltip, TooltipContent, TooltipProvider, TooltipTrigger } from "@/components/ui/tooltip";
 import { useWebSocket } from "@/hooks/useWebSocket";
 import { IS_ENTERPRISE } from "@/lib/constants/config";
-import { useGetCoreConfigQuery, useGetLatestReleaseQuery, useGetVersionQuery } from "@/lib/store";
+import { useGetAuthSessionQuery, useGetCoreConfigQuery, useGetLatestReleaseQuery, useGetVersionQuery, useLogoutMutation } from "@/lib/store";
+import { getBrandLogo, getBrandName } from "@/lib/utils/branding";
 import { BooksIcon, DiscordLogoIcon, GithubLogoIcon } from "@phosphor-icons/react";
 import { useTheme } from "next-themes";
 import Image from "next/image";
 import Link from "next/link";
 import { usePathname } from "next/navigation";
 import { useEffect, useMemo, useState } from "react";
+import { LocaleToggle } from "./localeToggle";
 import { ThemeToggle } from "./themeToggle";
 import { PromoCardStack } from "./ui/promoCardStack";
 
@@ -73,6 +79,30 @@ const items = [
 		icon: Binoculars,
 		description: "Observability setup",
 	},
+	{
+		title: "SLOs",
+		url: "/slos",
+		icon: Gauge,
+		description: "Latency & availability objectives",
+	},
+	{
+		title: "Moderation",
+		url: "/moderation",
+		icon: ShieldAlert,
+		description: "Review flagged requests",
+	},
+	{
+		title: "Fine-Tuning",
+		url: "/fine-tuning",
+		icon: GraduationCap,
+		description: "Fine-tuning job progress",
+	},
+	{
+		title: "Playground",
+		url: "/playground",
+		icon: TerminalSquare,
+		description: "Trace test prompts",
+	},
 	{
 		title: "Providers",
 		url: "/providers",
@@ -294,6 +324,11 @@ export default function AppSidebar() {
 	const { data: coreConfig } = useGetCoreConfigQuery({});
 	const isGovernanceEnabled = coreConfig?.client_config.enable_governance || false;
 
+	// Admin password sessions can be ended from the sidebar
+	const { data: authSession } = useGetAuthSessionQuery(undefined, { skip: IS_ENTERPRISE });
+	const [logout] = useLogoutMutation();
+	const canSignOut = !IS_ENTERPRISE && authSession?.method === "cookie";
+
 	useEffect(() => {
 		setMounted(true);
 	}, []);
@@ -305,7 +340,7 @@ export default function AppSidebar() {
 	};
 
 	// Always render the light theme version for SSR to avoid hydration mismatch
-	const logoSrc = mounted && resolvedTheme === "dark" ? "/bifrost-logo-dark.png" : "/bifrost-logo.png";
+	const logoSrc = getBrandLogo(mounted && resolvedTheme === "dark");
 
 	const { isConnected: isWebSocketConnected } = useWebSocket();
 
@@ -342,7 +377,7 @@ export default function AppSidebar() {
 			<SidebarHeader className="mt-1 ml-2 flex h-12 justify-between px-0">
 				<div className="flex h-full items-center justify-between gap-2 px-1.5">
 					<Link href="/" className="group flex items-center gap-2">
-						<Image className="h-10 w-auto" src={logoSrc} alt="Bifrost" width={100} height={100} />
+						<Image className="h-10 w-auto" src={logoSrc} alt={getBrandName()} width={100} height={100} />
 					</Link>
 				</div>
 			</SidebarHeader>
@@ -407,7 +442,21 @@ export default function AppSidebar() {
 									</div>
 								</a>
 							))}
+							<LocaleToggle />
 							<ThemeToggle />
+							{canSignOut && (
+								<button
+									type="button"
+									title="Sign out"
+									className="flex items-center space-x-3"
+									onClick={async () => {
+										await logout().unwrap().catch(() => undefined);
+										window.location.href = "/login";
+									}}
+								>
+									<LogOut className="hover:text-primary text-muted-foreground h-4.5 w-4.5" size={20} strokeWidth={1.5} />
+								</button>
+							)}
 							{IS_ENTERPRISE && (
 								<div>
 									<div
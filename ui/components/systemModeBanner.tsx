@@ -0,0 +1,51 @@
+"use client";
+
+import { Alert, AlertDescription, AlertTitle } from "@/components/ui/alert";
+import { Button } from "@/components/ui/button";
+import { getErrorMessage, useGetSystemModeQuery, useUpdateSystemModeMutation } from "@/lib/store";
+import { Construction, Lock } from "lucide-react";
+import { toast } from "sonner";
+
+// Shown above every page while maintenance or read-only mode is on
+const SystemModeBanner = () => {
+	const { data: mode } = useGetSystemModeQuery(undefined, { pollingInterval: 30000 });
+	const [updateSystemMode, { isLoading }] = useUpdateSystemModeMutation();
+
+	if (!mode || (!mode.maintenance && !mode.read_only)) {
+		return null;
+	}
+
+	const switchOff = async () => {
+		try {
+			await updateSystemMode(mode.maintenance ? { maintenance: false } : { read_only: false }).unwrap();
+		} catch (error) {
+			toast.error(getErrorMessage(error));
+		}
+	};
+
+	const Icon = mode.maintenance ? Construction : Lock;
+	return (
+		<Alert className="mb-6 border-amber-500/50 bg-amber-50 text-amber-900 dark:bg-amber-950/30 dark:text-amber-200 [&>svg]:text-amber-600">
+			<Icon className="h-4 w-4" />
+			<AlertTitle className="flex items-center justify-between gap-2">
+				<span>
+					{mode.maintenance && mode.read_only
+						? "Maintenance and read-only mode are on"
+						: mode.maintenance
+							? "Maintenance mode is on"
+							: "Read-only mode is on"}
+				</span>
+				<Button variant="outline" size="sm" disabled={isLoading} onClick={switchOff}>
+					{mode.maintenance ? "End maintenance" : "Allow changes"}
+				</Button>
+			</AlertTitle>
+			<AlertDescription className="text-xs">
+				{mode.maintenance && <div>Inference requests are rejected with 503 and retried after {mode.retry_after_seconds || 60}s.</div>}
+				{mode.read_only && <div>Configuration changes are disabled.</div>}
+				{mode.message && <div className="mt-1">{mode.message}</div>}
+			</AlertDescription>
+		</Alert>
+	);
+};
+
+export default SystemModeBanner;
@@ -0,0 +1,41 @@
+"use client";
+
+import { getApiBaseUrl } from "@/lib/utils/port";
+import { useEffect, useRef, useState } from "react";
+
+type EventHandlers = Record<string, (data: any) => void>;
+
+// Subscribes to a server-sent event stream of the API (e.g. /stream/logs), calling the handler of each named event
+// with its parsed data. The browser reconnects on its own; the stream is reopened when the path or params change.
+export function useEventStream(path: string, params: Record<string, string | number>, handlers: EventHandlers, enabled = true) {
+	const [isConnected, setIsConnected] = useState(false);
+	const handlersRef = useRef(handlers);
+	handlersRef.current = handlers;
+
+	const query = new URLSearchParams(Object.entries(params).map(([key, value]) => [key, String(value)])).toString();
+	const events = Object.keys(handlers).sort().join(",");
+
+	useEffect(() => {
+		if (!enabled || typeof window === "undefined") {
+			return;
+		}
+		const source = new EventSource(`${getApiBaseUrl()}${path}${query ? `?${query}` : ""}`, { withCredentials: true });
+		source.onopen = () => setIsConnected(true);
+		source.onerror = () => setIsConnected(source.readyState === EventSource.OPEN);
+		for (const event of events.split(",").filter(Boolean)) {
+			source.addEventListener(event, (message) => {
+				try {
+					handlersRef.current[event]?.(JSON.parse((message as MessageEvent).data));
+				} catch (error) {
+					console.error(`Failed to parse ${event} event:`, error);
+				}
+			});
+		}
+		return () => {
+			source.close();
+			setIsConnected(false);
+		};
+	}, [path, query, events, enabled]);
+
+	return { isConnected };
+}
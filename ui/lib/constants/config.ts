@@ -12,12 +12,15 @@ export const ModelPlaceholders = {
 	gemini: "e.g. gemini-1.5-pro, gemini-1.5-flash",
 	groq: "e.g. llama3-70b-8192, mixtral-8x7b-32768",
 	mistral: "e.g. mistral-7b-instruct, mixtral-8x7b",
+	mock: "e.g. mock-chat, mock-embedding. Leave blank for all models.",
 	openrouter: "e.g. openai/gpt-4, anthropic/claude-3-haiku",
 	sgl: "e.g. sgl-2, sgl-vision",
 	parasail: "e.g. parasail-2, parasail-vision",
 	ollama: "e.g. llama3.1, llama2",
 	openai: "e.g. gpt-4, gpt-4o, gpt-4o-mini, gpt-3.5-turbo",
 	vertex: "e.g. gemini-1.5-pro, text-bison, chat-bison",
+	voyage: "e.g. rerank-2.5, voyage-3.5",
+	jina: "e.g. jina-reranker-v2-base-multilingual, jina-embeddings-v3",
 };
 
 export const isKeyRequiredByProvider: Record<ProviderName, boolean> = {
@@ -29,12 +32,15 @@ export const isKeyRequiredByProvider: Record<ProviderName, boolean> = {
 	gemini: true,
 	groq: true,
 	mistral: true,
+	mock: false,
 	openrouter: true,
 	sgl: false,
 	parasail: true,
 	ollama: false,
 	openai: true,
 	vertex: true,
+	voyage: true,
+	jina: true,
 };
 
 export const DefaultNetworkConfig = {
@@ -61,6 +67,7 @@ export const DEFAULT_ALLOWED_REQUESTS = {
 	chat_completion: true,
 	chat_completion_stream: true,
 	embedding: true,
+	rerank: true,
 	speech: true,
 	speech_stream: true,
 	transcription: true,
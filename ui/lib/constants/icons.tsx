@@ -558,6 +558,73 @@ export const ProviderIcons = {
 			</svg>
 		);
 	},
+
+	voyage: ({ size = "md", className = "" }: IconProps) => {
+		const resolvedSize = resolveSize(size);
+
+		return (
+			<svg
+				fill="none"
+				stroke="currentColor"
+				strokeWidth="2"
+				strokeLinecap="round"
+				strokeLinejoin="round"
+				height={resolvedSize}
+				style={{ flex: "none", lineHeight: "1" }}
+				viewBox="0 0 24 24"
+				width={resolvedSize}
+				xmlns="http://www.w3.org/2000/svg"
+				className={className}
+			>
+				<title>Voyage AI</title>
+				<path d="M4 5l8 14 8-14" />
+			</svg>
+		);
+	},
+
+	jina: ({ size = "md", className = "" }: IconProps) => {
+		const resolvedSize = resolveSize(size);
+
+		return (
+			<svg
+				fill="currentColor"
+				height={resolvedSize}
+				style={{ flex: "none", lineHeight: "1" }}
+				viewBox="0 0 24 24"
+				width={resolvedSize}
+				xmlns="http://www.w3.org/2000/svg"
+				className={className}
+			>
+				<title>Jina AI</title>
+				<rect x="3" y="14" width="7" height="7" rx="1" />
+				<path d="M14 3h4v11a7 7 0 0 1-7 7h-1v-4h1a3 3 0 0 0 3-3z" />
+			</svg>
+		);
+	},
+
+	mock: ({ size = "md", className = "" }: IconProps) => {
+		const resolvedSize = resolveSize(size);
+
+		return (
+			<svg
+				fill="none"
+				stroke="currentColor"
+				strokeWidth="2"
+				strokeLinecap="round"
+				strokeLinejoin="round"
+				height={resolvedSize}
+				style={{ flex: "none", lineHeight: "1" }}
+				viewBox="0 0 24 24"
+				width={resolvedSize}
+				xmlns="http://www.w3.org/2000/svg"
+				className={className}
+			>
+				<title>Mock</title>
+				<path d="M9 3h6M10 3v6.5L4.5 19a1.5 1.5 0 0 0 1.3 2h12.4a1.5 1.5 0 0 0 1.3-2L14 9.5V3" />
+				<path d="M7 15h10" />
+			</svg>
+		);
+	},
 } as const;
 
 // Helper component to render provider icons
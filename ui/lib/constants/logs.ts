@@ -8,12 +8,15 @@ export const KnownProvidersNames = [
 	"gemini",
 	"groq",
 	"mistral",
+	"mock",
 	"ollama",
 	"openai",
 	"openrouter",
 	"parasail",
 	"sgl",
 	"vertex",
+	"voyage",
+	"jina",
 ] as const;
 
 // Local Provider type derived from KNOWN_PROVIDERS constant
@@ -30,6 +33,7 @@ export const RequestTypes = [
 	"completion",
 	"embedding",
 	"list",
+	"rerank",
 	"audio.speech",
 	"audio.transcription",
 	"chat.completion.chunk",
@@ -54,6 +58,9 @@ export const ProviderLabels: Record<ProviderName, string> = {
 	cerebras: "Cerebras",
 	gemini: "Gemini",
 	openrouter: "OpenRouter",
+	voyage: "Voyage AI",
+	jina: "Jina AI",
+	mock: "Mock",
 } as const;
 
 // Helper function to get provider label, supporting custom providers
@@ -82,6 +89,7 @@ export const RequestTypeLabels = {
 	"text.completion": "Text",
 	embedding: "Embedding",
 	list: "List",
+	rerank: "Rerank",
 	"audio.speech": "Speech",
 	"audio.transcription": "Transcription",
 	"chat.completion.chunk": "Chat Stream",
@@ -96,6 +104,7 @@ export const RequestTypeColors = {
 	"text.completion": "bg-green-100 text-green-800",
 	embedding: "bg-red-100 text-red-800",
 	list: "bg-red-100 text-red-800",
+	rerank: "bg-amber-100 text-amber-800",
 	"audio.speech": "bg-purple-100 text-purple-800",
 	"audio.transcription": "bg-orange-100 text-orange-800",
 	"chat.completion.chunk": "bg-yellow-100 text-yellow-800",
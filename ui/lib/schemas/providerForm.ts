@@ -57,6 +57,7 @@ const AllowedRequestsSchema = z.object({
 	chat_completion: z.boolean(),
 	chat_completion_stream: z.boolean(),
 	embedding: z.boolean(),
+	rerank: z.boolean(),
 	speech: z.boolean(),
 	speech_stream: z.boolean(),
 	transcription: z.boolean(),
@@ -213,7 +214,7 @@ export const ProviderFormSchema = z
 		}
 
 		// Keys validation
-		const keysRequired = data.selectedProvider === "custom" || !["ollama", "sgl"].includes(data.selectedProvider);
+		const keysRequired = data.selectedProvider === "custom" || !["ollama", "sgl", "mock"].includes(data.selectedProvider);
 		if (keysRequired) {
 			if (data.keys.length < 1) {
 				ctx.addIssue({
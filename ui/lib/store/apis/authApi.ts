@@ -0,0 +1,58 @@
+import { AuthSession, DeviceAuthorization, DeviceDecisionRequest, LoginRequest } from "@/lib/types/auth";
+import { baseApi } from "./baseApi";
+
+export const authApi = baseApi.injectEndpoints({
+	endpoints: (builder) => ({
+		// Get the admin session of the current browser
+		getAuthSession: builder.query<AuthSession, void>({
+			query: () => ({
+				url: "/auth/me",
+			}),
+			providesTags: ["Auth"],
+		}),
+
+		// Sign in with the admin password
+		login: builder.mutation<AuthSession, LoginRequest>({
+			query: (body) => ({
+				url: "/auth/login",
+				method: "POST",
+				body,
+			}),
+			invalidatesTags: ["Auth"],
+		}),
+
+		// Sign out, clearing the session cookie
+		logout: builder.mutation<AuthSession, void>({
+			query: () => ({
+				url: "/auth/logout",
+				method: "POST",
+			}),
+			invalidatesTags: ["Auth"],
+		}),
+
+		// Get the pending CLI device login of a user code
+		getDeviceAuthorization: builder.query<DeviceAuthorization, string>({
+			query: (userCode) => ({
+				url: "/auth/device",
+				params: { user_code: userCode },
+			}),
+		}),
+
+		// Approve or deny a CLI device login
+		decideDeviceAuthorization: builder.mutation<DeviceAuthorization, DeviceDecisionRequest>({
+			query: (body) => ({
+				url: "/auth/device/approve",
+				method: "POST",
+				body,
+			}),
+		}),
+	}),
+});
+
+export const {
+	useGetAuthSessionQuery,
+	useLoginMutation,
+	useLogoutMutation,
+	useLazyGetDeviceAuthorizationQuery,
+	useDecideDeviceAuthorizationMutation,
+} = authApi;
@@ -12,10 +12,28 @@ const baseQuery = fetchBaseQuery({
 	},
 });
 
+// Management API calls go through the server-side proxy, which exchanges the session cookie for the session's
+// internal token. The public endpoints of the login page (sessions and locales) are called directly.
+const proxied = (args: any) => {
+	const url: string = typeof args === "string" ? args : args.url;
+	if (url.startsWith("/auth/") || url.startsWith("/ui/")) {
+		return args;
+	}
+	const proxiedUrl = `/proxy${url.startsWith("/") ? url : `/${url}`}`;
+	return typeof args === "string" ? proxiedUrl : { ...args, url: proxiedUrl };
+};
+
 // Enhanced base query with error handling
 const baseQueryWithErrorHandling = async (args: any, api: any, extraOptions: any) => {
-	const result = await baseQuery(args, api, extraOptions);
+	const result = await baseQuery(proxied(args), api, extraOptions);
 	if (result.error) {
+		// The admin session is missing or expired: go to the login page, coming back here afterwards
+		if (result.error.status === 401 && typeof window !== "undefined" && !window.location.pathname.startsWith("/login")) {
+			const next = window.location.pathname + window.location.search;
+			window.location.href = `/login?next=${encodeURIComponent(next)}`;
+			return result;
+		}
+
 		// Handle specific error types
 		if (result.error.status === "FETCH_ERROR") {
 			// Network error
@@ -69,6 +87,7 @@ export const baseApi = createApi({
 		"CacheConfig",
 		"VirtualKeys",
 		"Teams",
+		"Projects",
 		"Customers",
 		"Budgets",
 		"RateLimits",
@@ -80,6 +99,14 @@ export const baseApi = createApi({
 		"SCIMProviders",
 		"User",
 		"Guardrails",
+		"Transformations",
+		"ModerationEvents",
+		"Auth",
+		"SystemMode",
+		"SLOs",
+		"FineTuningJobs",
+		"ConfigVersions",
+		"UILocales",
 	],
 	endpoints: () => ({}),
 });
@@ -1,4 +1,13 @@
-import { BifrostConfig, CoreConfig, LatestReleaseResponse } from "@/lib/types/config";
+import {
+	BifrostConfig,
+	ConfigRollbackResponse,
+	ConfigVersionSummary,
+	CoreConfig,
+	LatestReleaseResponse,
+	SystemMode,
+	SystemModeRequest,
+	TransformationRulesResponse,
+} from "@/lib/types/config";
 import axios from "axios";
 import { baseApi } from "./baseApi";
 
@@ -75,6 +84,60 @@ export const configApi = baseApi.injectEndpoints({
 			}),
 			invalidatesTags: ["Config"],
 		}),
+
+		// Get request transformation rules
+		getTransformationRules: builder.query<TransformationRulesResponse, void>({
+			query: () => ({
+				url: "/transformations",
+			}),
+			providesTags: ["Transformations"],
+		}),
+
+		// Replace request transformation rules
+		updateTransformationRules: builder.mutation<TransformationRulesResponse, TransformationRulesResponse>({
+			query: (data) => ({
+				url: "/transformations",
+				method: "PUT",
+				body: data,
+			}),
+			invalidatesTags: ["Transformations"],
+		}),
+
+		// Get the maintenance and read-only mode toggles
+		getSystemMode: builder.query<SystemMode, void>({
+			query: () => ({
+				url: "/system/mode",
+			}),
+			providesTags: ["SystemMode"],
+		}),
+
+		// Switch maintenance or read-only mode on or off
+		updateSystemMode: builder.mutation<SystemMode, SystemModeRequest>({
+			query: (data) => ({
+				url: "/system/mode",
+				method: "PUT",
+				body: data,
+			}),
+			invalidatesTags: ["SystemMode"],
+		}),
+
+		// Get the configuration versions with their changes, newest first
+		getConfigVersions: builder.query<ConfigVersionSummary[], void>({
+			query: () => ({
+				url: "/config/versions",
+			}),
+			providesTags: ["ConfigVersions"],
+		}),
+
+		// Roll back to a configuration version
+		rollbackConfigVersion: builder.mutation<ConfigRollbackResponse, { id: number; dryRun?: boolean }>({
+			query: ({ id, dryRun = false }) => ({
+				url: `/config/versions/${id}/rollback`,
+				method: "POST",
+				params: { dry_run: dryRun },
+			}),
+			invalidatesTags: ["ConfigVersions", "Config", "Providers", "Plugins"],
+		}),
 	}),
 });
 
@@ -85,4 +148,10 @@ export const {
 	useLazyGetCoreConfigQuery,
 	useGetLatestReleaseQuery,
 	useLazyGetLatestReleaseQuery,
+	useGetTransformationRulesQuery,
+	useUpdateTransformationRulesMutation,
+	useGetSystemModeQuery,
+	useUpdateSystemModeMutation,
+	useGetConfigVersionsQuery,
+	useRollbackConfigVersionMutation,
 } = configApi;
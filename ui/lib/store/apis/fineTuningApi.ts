@@ -0,0 +1,17 @@
+import { FineTuningJobsParams, FineTuningJobsResponse } from "@/lib/types/fineTuning";
+import { baseApi } from "./baseApi";
+
+export const fineTuningApi = baseApi.injectEndpoints({
+	endpoints: (builder) => ({
+		// Get the fine-tuning jobs created through Bifrost with their latest status
+		getFineTuningJobs: builder.query<FineTuningJobsResponse, FineTuningJobsParams>({
+			query: (params) => ({
+				url: "/fine-tuning/jobs",
+				params,
+			}),
+			providesTags: ["FineTuningJobs"],
+		}),
+	}),
+});
+
+export const { useGetFineTuningJobsQuery } = fineTuningApi;
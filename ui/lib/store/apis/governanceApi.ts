@@ -1,22 +1,28 @@
 import {
 	Budget,
 	CreateCustomerRequest,
+	CreateProjectRequest,
 	CreateTeamRequest,
 	CreateVirtualKeyRequest,
 	Customer,
 	DebugStatsResponse,
 	GetBudgetsResponse,
 	GetCustomersResponse,
+	GetGovernanceUsageResponse,
+	GetProjectsResponse,
 	GetRateLimitsResponse,
 	GetTeamsResponse,
 	GetUsageStatsResponse,
 	GetVirtualKeysResponse,
+	GovernanceLevel,
 	HealthCheckResponse,
+	Project,
 	RateLimit,
 	ResetUsageRequest,
 	Team,
 	UpdateBudgetRequest,
 	UpdateCustomerRequest,
+	UpdateProjectRequest,
 	UpdateRateLimitRequest,
 	UpdateTeamRequest,
 	UpdateVirtualKeyRequest,
@@ -103,6 +109,55 @@ export const governanceApi = baseApi.injectEndpoints({
 			invalidatesTags: ["Teams"],
 		}),
 
+		// Projects
+		getProjects: builder.query<GetProjectsResponse, { teamId?: string }>({
+			query: ({ teamId } = {}) => ({
+				url: "/governance/projects",
+				params: teamId ? { team_id: teamId } : {},
+			}),
+			providesTags: ["Projects"],
+		}),
+
+		getProject: builder.query<{ project: Project }, string>({
+			query: (projectId) => `/governance/projects/${projectId}`,
+			providesTags: (result, error, projectId) => [{ type: "Projects", id: projectId }],
+		}),
+
+		createProject: builder.mutation<{ message: string; project: Project }, CreateProjectRequest>({
+			query: (data) => ({
+				url: "/governance/projects",
+				method: "POST",
+				body: data,
+			}),
+			invalidatesTags: ["Projects", "Teams"],
+		}),
+
+		updateProject: builder.mutation<{ message: string; project: Project }, { projectId: string; data: UpdateProjectRequest }>({
+			query: ({ projectId, data }) => ({
+				url: `/governance/projects/${projectId}`,
+				method: "PUT",
+				body: data,
+			}),
+			invalidatesTags: (result, error, { projectId }) => ["Projects", "Teams", { type: "Projects", id: projectId }],
+		}),
+
+		deleteProject: builder.mutation<{ message: string }, string>({
+			query: (projectId) => ({
+				url: `/governance/projects/${projectId}`,
+				method: "DELETE",
+			}),
+			invalidatesTags: ["Projects", "Teams", "VirtualKeys"],
+		}),
+
+		// Aggregate usage per level of the hierarchy
+		getGovernanceUsage: builder.query<GetGovernanceUsageResponse, { level?: GovernanceLevel }>({
+			query: ({ level } = {}) => ({
+				url: "/governance/usage",
+				params: level ? { level } : {},
+			}),
+			providesTags: ["UsageStats"],
+		}),
+
 		// Customers
 		getCustomers: builder.query<GetCustomersResponse, void>({
 			query: () => "/governance/customers",
@@ -242,6 +297,14 @@ export const {
 	useUpdateTeamMutation,
 	useDeleteTeamMutation,
 
+	// Projects
+	useGetProjectsQuery,
+	useGetProjectQuery,
+	useCreateProjectMutation,
+	useUpdateProjectMutation,
+	useDeleteProjectMutation,
+	useGetGovernanceUsageQuery,
+
 	// Customers
 	useGetCustomersQuery,
 	useGetCustomerQuery,
@@ -274,6 +337,8 @@ export const {
 	useLazyGetVirtualKeyQuery,
 	useLazyGetTeamsQuery,
 	useLazyGetTeamQuery,
+	useLazyGetProjectsQuery,
+	useLazyGetProjectQuery,
 	useLazyGetCustomersQuery,
 	useLazyGetCustomerQuery,
 	useLazyGetBudgetsQuery,
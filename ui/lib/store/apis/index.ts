@@ -2,9 +2,16 @@
 export { baseApi, getErrorMessage } from "./baseApi";
 
 // API slices and hooks
+export * from "./authApi";
 export * from "./configApi";
+export * from "./fineTuningApi";
 export * from "./governanceApi";
 export * from "./logsApi";
 export * from "./mcpApi";
+export * from "./playgroundApi";
+export * from "./moderationApi";
 export * from "./providersApi";
 export * from "./pluginsApi";
+export * from "./sloApi";
+export * from "./tracesApi";
+export * from "./uiApi";
@@ -1,6 +1,42 @@
 import { LogEntry, LogFilters, LogStats, Pagination } from "@/lib/types/logs";
 import { baseApi } from "./baseApi";
 
+// Query parameters of the log filters, shared by the log search and the live streams
+export const logFilterParams = (filters: LogFilters): Record<string, string | number> => {
+	const params: Record<string, string | number> = {};
+	if (filters.providers && filters.providers.length > 0) {
+		params.providers = filters.providers.join(",");
+	}
+	if (filters.models && filters.models.length > 0) {
+		params.models = filters.models.join(",");
+	}
+	if (filters.status && filters.status.length > 0) {
+		params.status = filters.status.join(",");
+	}
+	if (filters.objects && filters.objects.length > 0) {
+		params.objects = filters.objects.join(",");
+	}
+	if (filters.start_time) params.start_time = filters.start_time;
+	if (filters.end_time) params.end_time = filters.end_time;
+	if (filters.min_latency) params.min_latency = filters.min_latency;
+	if (filters.max_latency) params.max_latency = filters.max_latency;
+	if (filters.min_tokens) params.min_tokens = filters.min_tokens;
+	if (filters.max_tokens) params.max_tokens = filters.max_tokens;
+	if (filters.content_search) params.content_search = filters.content_search;
+	if (filters.customers && filters.customers.length > 0) {
+		params.customers = filters.customers.join(",");
+	}
+	if (filters.languages && filters.languages.length > 0) {
+		params.languages = filters.languages.join(",");
+	}
+	if (filters.labels && Object.keys(filters.labels).length > 0) {
+		params.labels = Object.entries(filters.labels)
+			.map(([name, value]) => `${name}=${value}`)
+			.join(",");
+	}
+	return params;
+};
+
 export const logsApi = baseApi.injectEndpoints({
 	endpoints: (builder) => ({
 		// Get logs with filters and pagination
@@ -21,29 +57,9 @@ export const logsApi = baseApi.injectEndpoints({
 					offset: pagination.offset,
 					sort_by: pagination.sort_by,
 					order: pagination.order,
+					...logFilterParams(filters),
 				};
 
-				// Add filters to params if they exist
-				if (filters.providers && filters.providers.length > 0) {
-					params.providers = filters.providers.join(",");
-				}
-				if (filters.models && filters.models.length > 0) {
-					params.models = filters.models.join(",");
-				}
-				if (filters.status && filters.status.length > 0) {
-					params.status = filters.status.join(",");
-				}
-				if (filters.objects && filters.objects.length > 0) {
-					params.objects = filters.objects.join(",");
-				}
-				if (filters.start_time) params.start_time = filters.start_time;
-				if (filters.end_time) params.end_time = filters.end_time;
-				if (filters.min_latency) params.min_latency = filters.min_latency;
-				if (filters.max_latency) params.max_latency = filters.max_latency;
-				if (filters.min_tokens) params.min_tokens = filters.min_tokens;
-				if (filters.max_tokens) params.max_tokens = filters.max_tokens;
-				if (filters.content_search) params.content_search = filters.content_search;
-
 				return {
 					url: "/logs",
 					params,
@@ -0,0 +1,32 @@
+import {
+	ModerationEvent,
+	ModerationEventFilters,
+	ModerationEventsResponse,
+	ReviewModerationEventRequest,
+} from "@/lib/types/moderation";
+import { baseApi } from "./baseApi";
+
+export const moderationApi = baseApi.injectEndpoints({
+	endpoints: (builder) => ({
+		// List moderation events, the review queue when filtered by pending review status
+		getModerationEvents: builder.query<ModerationEventsResponse, ModerationEventFilters>({
+			query: (filters) => ({
+				url: "/moderation/events",
+				params: filters,
+			}),
+			providesTags: ["ModerationEvents"],
+		}),
+
+		// Approve, deny or annotate a moderation event
+		reviewModerationEvent: builder.mutation<ModerationEvent, ReviewModerationEventRequest>({
+			query: ({ id, ...body }) => ({
+				url: `/moderation/events/${id}/review`,
+				method: "POST",
+				body,
+			}),
+			invalidatesTags: ["ModerationEvents"],
+		}),
+	}),
+});
+
+export const { useGetModerationEventsQuery, useReviewModerationEventMutation } = moderationApi;
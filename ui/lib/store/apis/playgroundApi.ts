@@ -0,0 +1,17 @@
+import { PlaygroundChatRequest, PlaygroundChatResponse } from "@/lib/types/playground";
+import { baseApi } from "./baseApi";
+
+export const playgroundApi = baseApi.injectEndpoints({
+	endpoints: (builder) => ({
+		// Send a test chat request through the full pipeline and get its trace
+		sendPlaygroundChat: builder.mutation<PlaygroundChatResponse, PlaygroundChatRequest>({
+			query: (body) => ({
+				url: "/playground/chat",
+				method: "POST",
+				body,
+			}),
+		}),
+	}),
+});
+
+export const { useSendPlaygroundChatMutation } = playgroundApi;
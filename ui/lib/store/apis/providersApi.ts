@@ -1,4 +1,11 @@
-import { AddProviderRequest, ListProvidersResponse, ModelProvider, ModelProviderName } from "@/lib/types/config";
+import {
+	AddProviderRequest,
+	KeyValidation,
+	ListProvidersResponse,
+	ModelProvider,
+	ModelProviderName,
+	ProviderHealthResponse,
+} from "@/lib/types/config";
 import { DBKey } from "@/lib/types/governance";
 import { baseApi } from "./baseApi";
 
@@ -23,6 +30,7 @@ export const providersApi = baseApi.injectEndpoints({
 				url: "/providers",
 				method: "POST",
 				body: data,
+				params: { validate: true },
 			}),
 			invalidatesTags: ["Providers"],
 		}),
@@ -33,6 +41,7 @@ export const providersApi = baseApi.injectEndpoints({
 				url: `/providers/${provider.name}`,
 				method: "PUT",
 				body: provider,
+				params: { validate: true },
 			}),
 			invalidatesTags: (result, error, provider) => ["Providers", { type: "Providers", id: provider.name }],
 		}),
@@ -46,6 +55,21 @@ export const providersApi = baseApi.injectEndpoints({
 			invalidatesTags: ["Providers"],
 		}),
 
+		// Validate the keys of a provider with a live call
+		validateProviderKeys: builder.mutation<Record<string, KeyValidation>, { provider: string; keyId?: string }>({
+			query: ({ provider, keyId }) => ({
+				url: `/providers/${provider}/keys/validate`,
+				method: "POST",
+				params: keyId ? { key_id: keyId } : undefined,
+			}),
+			invalidatesTags: (result, error, { provider }) => ["Providers", { type: "Providers", id: provider }],
+		}),
+
+		// Get provider health probe results
+		getProviderHealth: builder.query<ProviderHealthResponse, void>({
+			query: () => "/providers/health",
+		}),
+
 		// Get all available keys from all providers for governance selection
 		getAllKeys: builder.query<DBKey[], void>({
 			query: () => "/keys",
@@ -60,7 +84,9 @@ export const {
 	useCreateProviderMutation,
 	useUpdateProviderMutation,
 	useDeleteProviderMutation,
+	useValidateProviderKeysMutation,
 	useGetAllKeysQuery,
+	useGetProviderHealthQuery,
 	useLazyGetProvidersQuery,
 	useLazyGetProviderQuery,
 	useLazyGetAllKeysQuery,
@@ -0,0 +1,16 @@
+import { SLOStatusResponse } from "@/lib/types/slo";
+import { baseApi } from "./baseApi";
+
+export const sloApi = baseApi.injectEndpoints({
+	endpoints: (builder) => ({
+		// Get the compliance, error budget, burn rates and alerts of every objective
+		getSLOs: builder.query<SLOStatusResponse, void>({
+			query: () => ({
+				url: "/slos",
+			}),
+			providesTags: ["SLOs"],
+		}),
+	}),
+});
+
+export const { useGetSLOsQuery } = sloApi;
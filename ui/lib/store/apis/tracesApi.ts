@@ -0,0 +1,15 @@
+import { RequestTimeline } from "@/lib/types/traces";
+import { baseApi } from "./baseApi";
+
+export const tracesApi = baseApi.injectEndpoints({
+	endpoints: (builder) => ({
+		// Get the timeline of a recent inference request
+		getRequestTrace: builder.query<RequestTimeline, string>({
+			query: (requestId) => ({
+				url: `/traces/${encodeURIComponent(requestId)}`,
+			}),
+		}),
+	}),
+});
+
+export const { useGetRequestTraceQuery } = tracesApi;
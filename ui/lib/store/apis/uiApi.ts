@@ -0,0 +1,16 @@
+import { UILocales } from "@/lib/types/config";
+import { baseApi } from "./baseApi";
+
+export const uiApi = baseApi.injectEndpoints({
+	endpoints: (builder) => ({
+		// Get the locales of the dashboard and the one served to this browser
+		getUILocales: builder.query<UILocales, void>({
+			query: () => ({
+				url: "/ui/locales",
+			}),
+			providesTags: ["UILocales"],
+		}),
+	}),
+});
+
+export const { useGetUILocalesQuery } = uiApi;
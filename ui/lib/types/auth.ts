@@ -0,0 +1,45 @@
+// Admin session types matching the Go backend (transports/bifrost-http/handlers/auth.go)
+
+export type AuthMethod = "none" | "cookie" | "bearer" | "service_account" | "tenant" | "device";
+
+// Branding of the dashboard served on a tenant domain
+export interface TenantBranding {
+	name: string;
+	logo_url?: string;
+	logo_dark_url?: string;
+}
+
+export interface AuthSession {
+	auth_enabled: boolean;
+	authenticated: boolean;
+	method: AuthMethod;
+	service_account?: string;
+	tenant?: string;
+	scopes?: string[];
+	redirect?: string;
+	branding?: TenantBranding;
+	expires_at?: string;
+}
+
+export interface LoginRequest {
+	password: string;
+	next?: string;
+	scopes?: string[];
+}
+
+
+// Device login of the CLI, confirmed in the dashboard (transports/bifrost-http/handlers/devicelogin.go)
+export interface DeviceAuthorization {
+	user_code: string;
+	client_name?: string;
+	scopes?: string[];
+	status: "pending" | "approved" | "denied";
+	created_at: string;
+	expires_at: string;
+}
+
+export interface DeviceDecisionRequest {
+	user_code: string;
+	approve: boolean;
+	scopes?: string[];
+}
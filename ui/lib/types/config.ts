@@ -98,6 +98,33 @@ export interface NetworkConfig {
 	max_retries: number;
 	retry_backoff_initial: number; // Duration in milliseconds
 	retry_backoff_max: number; // Duration in milliseconds
+	http_client?: HTTPClientConfig;
+	passthrough_headers?: ForwardingPolicy;
+	passthrough_params?: ParamForwardingPolicy;
+}
+
+// ForwardingPolicy matching Go's schemas.ForwardingPolicy
+export interface ForwardingPolicy {
+	allow: string[];
+	sensitive?: string[];
+}
+
+// ParamForwardingPolicy matching Go's schemas.ParamForwardingPolicy
+export interface ParamForwardingPolicy {
+	allow: string[];
+}
+
+// HTTPClientConfig matching Go's schemas.HTTPClientConfig
+export interface HTTPClientConfig {
+	max_conns_per_host?: number;
+	max_idle_conns?: number;
+	idle_conn_timeout_in_seconds?: number;
+	max_conn_lifetime_in_seconds?: number;
+	disable_keep_alives?: boolean;
+	tls_session_cache_size?: number;
+	ca_bundle?: string;
+	client_certificate?: string;
+	client_key?: string;
 }
 
 // ConcurrencyAndBufferSize matching Go's schemas.ConcurrencyAndBufferSize
@@ -123,6 +150,7 @@ export interface AllowedRequests {
 	chat_completion: boolean;
 	chat_completion_stream: boolean;
 	embedding: boolean;
+	rerank: boolean;
 	speech: boolean;
 	speech_stream: boolean;
 	transcription: boolean;
@@ -134,22 +162,94 @@ export const DefaultAllowedRequests: AllowedRequests = {
 	chat_completion: true,
 	chat_completion_stream: true,
 	embedding: true,
+	rerank: true,
 	speech: true,
 	speech_stream: true,
 	transcription: true,
 	transcription_stream: true,
 } as const satisfies Required<AllowedRequests>;
 
+// ProviderSpecResponse matching Go's schemas.ProviderSpecResponse, path expressions such as .choices[0].message.content
+export interface ProviderSpecResponse {
+	id?: string;
+	content?: string;
+	finish_reason?: string;
+	prompt_tokens?: string;
+	completion_tokens?: string;
+	embeddings?: string;
+	error?: string;
+}
+
+// ProviderSpecOperation matching Go's schemas.ProviderSpecOperation
+export interface ProviderSpecOperation {
+	path: string;
+	method?: string;
+	request?: Record<string, unknown>;
+	response: ProviderSpecResponse;
+	stream?: {
+		format: "sse" | "ndjson";
+		request?: Record<string, unknown>;
+		done?: string;
+		chunk: ProviderSpecResponse;
+	};
+}
+
+// ProviderSpec matching Go's schemas.ProviderSpec
+export interface ProviderSpec {
+	auth_header?: string;
+	auth_template?: string;
+	headers?: Record<string, string>;
+	chat_completion?: ProviderSpecOperation;
+	text_completion?: ProviderSpecOperation;
+	embedding?: ProviderSpecOperation;
+}
+
+// BaseProviderType is a standard provider, or declarative for providers defined by a spec
+export type BaseProviderType = KnownProvider | "declarative";
+
 // CustomProviderConfig matching Go's schemas.CustomProviderConfig
 export interface CustomProviderConfig {
-	base_provider_type: KnownProvider;
+	base_provider_type: BaseProviderType;
 	allowed_requests?: AllowedRequests;
+	spec?: ProviderSpec;
 }
 
 export const DefaultCustomProviderConfig: CustomProviderConfig = {
 	base_provider_type: "openai",
 	allowed_requests: DefaultAllowedRequests,
-} as const satisfies Required<CustomProviderConfig>;
+} as const satisfies Required<Omit<CustomProviderConfig, "spec">>;
+
+// MockProviderConfig matching Go's schemas.MockProviderConfig
+export interface MockProviderConfig {
+	response?: string;
+	model_responses?: Record<string, string>;
+	latency_ms?: number;
+	jitter_ms?: number;
+	token_delay_ms?: number;
+	error_rate?: number;
+	error_status_code?: number;
+	error_message?: string;
+	embedding_dimensions?: number;
+}
+
+// RequestShapingRule matching Go's schemas.RequestShapingRule
+export interface RequestShapingRule {
+	defaults?: Record<string, unknown>;
+	overrides?: Record<string, unknown>;
+}
+
+// RequestShapingConfig matching Go's schemas.RequestShapingConfig. Precedence, lowest first: provider defaults,
+// model defaults, the request, provider overrides, model overrides
+export interface RequestShapingConfig extends RequestShapingRule {
+	models?: Record<string, RequestShapingRule>;
+}
+
+// SyntheticStreamingConfig matching Go's schemas.SyntheticStreamingConfig
+export interface SyntheticStreamingConfig {
+	enabled: boolean;
+	chunk_words?: number;
+	interval_ms?: number;
+}
 
 // ProviderConfig matching Go's lib.ProviderConfig
 export interface ModelProviderConfig {
@@ -159,11 +259,23 @@ export interface ModelProviderConfig {
 	proxy_config?: ProxyConfig;
 	send_back_raw_response?: boolean;
 	custom_provider_config?: CustomProviderConfig;
+	mock_config?: MockProviderConfig;
+	request_shaping?: RequestShapingConfig;
+	synthetic_streaming?: SyntheticStreamingConfig;
+}
+
+// KeyValidation matching Go's lib.KeyValidation
+export interface KeyValidation {
+	status: "valid" | "invalid" | "unknown";
+	model?: string;
+	error?: string;
+	validated_at: string;
 }
 
 // ProviderResponse matching Go's ProviderResponse
 export interface ModelProvider extends ModelProviderConfig {
 	name: ModelProviderName;
+	key_validations?: Record<string, KeyValidation>;
 }
 
 // ListProvidersResponse matching Go's ListProvidersResponse
@@ -172,6 +284,95 @@ export interface ListProvidersResponse {
 	total: number;
 }
 
+// ProviderHealth matching Go's lib.ProviderHealth
+export type ProviderHealthStatus = "healthy" | "unhealthy" | "unknown";
+
+export interface ProviderHealthSample {
+	timestamp: string;
+	latency_ms: number;
+	success: boolean;
+	error?: string;
+}
+
+export interface ProviderHealth {
+	provider: ModelProviderName;
+	model?: string;
+	status: ProviderHealthStatus;
+	consecutive_failures: number;
+	last_checked?: string;
+	last_error?: string;
+	availability: number; // 0..1
+	avg_latency_ms: number;
+	history: ProviderHealthSample[];
+}
+
+export interface ProviderHealthResponse {
+	enabled: boolean;
+	providers: ProviderHealth[];
+}
+
+// TransformationRule matching Go's lib.TransformationRule
+export type TransformationOp = "set" | "default" | "remove" | "clamp" | "system_prompt";
+
+export interface TransformationOperation {
+	op: TransformationOp;
+	field?: string;
+	value?: unknown;
+	min?: number;
+	max?: number;
+}
+
+export interface TransformationRule {
+	name: string;
+	disabled?: boolean;
+	match: {
+		paths?: string[];
+		virtual_keys?: string[];
+		providers?: string[];
+		models?: string[];
+	};
+	operations: TransformationOperation[];
+}
+
+export interface TransformationRulesResponse {
+	rules: TransformationRule[];
+}
+
+// ConfigChange matching Go's ApplyChange
+export interface ConfigChange {
+	resource: "client" | "provider" | "key" | "plugin" | "budget";
+	name: string;
+	action: "create" | "update" | "delete";
+	fields?: string[];
+}
+
+// ConfigVersionSummary matching Go's ConfigVersionSummary
+export interface ConfigVersionSummary {
+	id: number;
+	created_at: string;
+	source: string;
+	changes: ConfigChange[];
+}
+
+// ConfigRollbackResponse matching Go's ConfigRollbackResponse
+export interface ConfigRollbackResponse {
+	dry_run: boolean;
+	changes: ConfigChange[];
+	version?: number;
+}
+
+// SystemMode matching Go's lib.SystemMode
+export interface SystemMode {
+	maintenance: boolean;
+	read_only: boolean;
+	message?: string;
+	retry_after_seconds?: number;
+	updated_at?: string;
+}
+
+// SystemModeRequest matching Go's SystemModeRequest, omitted fields are left unchanged
+export type SystemModeRequest = Partial<Omit<SystemMode, "updated_at">>;
+
 // AddProviderRequest matching Go's AddProviderRequest
 export interface AddProviderRequest {
 	provider: ModelProviderName;
@@ -181,6 +382,9 @@ export interface AddProviderRequest {
 	proxy_config?: ProxyConfig;
 	send_back_raw_response?: boolean;
 	custom_provider_config?: CustomProviderConfig;
+	mock_config?: MockProviderConfig;
+	request_shaping?: RequestShapingConfig;
+	synthetic_streaming?: SyntheticStreamingConfig;
 }
 
 // UpdateProviderRequest matching Go's UpdateProviderRequest
@@ -191,6 +395,9 @@ export interface UpdateProviderRequest {
 	proxy_config: ProxyConfig;
 	send_back_raw_response?: boolean;
 	custom_provider_config?: CustomProviderConfig;
+	mock_config?: MockProviderConfig;
+	request_shaping?: RequestShapingConfig;
+	synthetic_streaming?: SyntheticStreamingConfig;
 }
 
 // BifrostErrorResponse matching Go's schemas.BifrostError
@@ -286,3 +493,10 @@ export interface ProviderFormData {
 
 // Status types
 export type ProviderStatus = "active" | "error" | "added" | "updated" | "deleted";
+
+// Locales of the dashboard, from GET /api/ui/locales
+export interface UILocales {
+	default: string;
+	locales: string[];
+	current: string;
+}
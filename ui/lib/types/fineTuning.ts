@@ -0,0 +1,36 @@
+// Fine-tuning types matching the Go backend (framework/finetuning/store.go)
+
+export type FineTuningJobStatus = "validating_files" | "queued" | "running" | "succeeded" | "failed" | "cancelled";
+
+export interface FineTuningJob {
+	id: string;
+	provider: string;
+	key_id?: string;
+	virtual_key?: string;
+	model: string;
+	fine_tuned_model?: string;
+	status: FineTuningJobStatus;
+	training_file: string;
+	validation_file?: string;
+	trained_tokens?: number;
+	last_event?: string;
+	error?: string;
+	estimated_finish?: string;
+	finished_at?: string;
+	created_at: string;
+	updated_at: string;
+}
+
+export interface FineTuningJobsParams {
+	provider?: string;
+	status?: string;
+	virtual_key?: string;
+	limit?: number;
+	offset?: number;
+}
+
+export interface FineTuningJobsResponse {
+	enabled: boolean;
+	jobs: FineTuningJob[];
+	total: number;
+}
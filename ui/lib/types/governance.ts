@@ -4,6 +4,11 @@ export interface Budget {
 	id: string;
 	max_limit: number; // In dollars
 	reset_duration: string; // e.g., "30s", "5m", "1h", "1d", "1w", "1M"
+	reset_schedule?: string; // "rolling" (default), "calendar_month" or a UTC cron expression
+	rollover?: boolean; // Carry unused quota over to the next period
+	rollover_cap?: number; // In dollars, defaults to max_limit
+	carried_over: number; // In dollars, quota rolled over into the current period
+	alert_threshold: number; // Highest consumption percentage alerted in the current period
 	current_usage: number; // In dollars
 	last_reset: string; // ISO timestamp
 }
@@ -27,18 +32,37 @@ export interface Team {
 	name: string;
 	customer_id?: string;
 	budget_id?: string;
+	rate_limit_id?: string;
 	// Populated relationships
 	customer?: Customer;
 	budget?: Budget;
+	rate_limit?: RateLimit;
+	projects?: Project[];
+}
+
+export interface Project {
+	id: string;
+	name: string;
+	team_id?: string;
+	budget_id?: string;
+	rate_limit_id?: string;
+	created_at: string;
+	updated_at: string;
+	// Populated relationships
+	team?: Team;
+	budget?: Budget;
+	rate_limit?: RateLimit;
 }
 
 export interface Customer {
 	id: string;
 	name: string;
 	budget_id?: string;
+	rate_limit_id?: string;
 	// Populated relationships
 	teams?: Team[];
 	budget?: Budget;
+	rate_limit?: RateLimit;
 }
 
 export interface DBKey {
@@ -53,6 +77,8 @@ export interface VirtualKey {
 	value: string; // The actual key value
 	description?: string;
 	provider_configs?: VirtualKeyProviderConfig[];
+	parameter_guardrails?: ParameterGuardrails;
+	project_id?: string;
 	team_id?: string;
 	customer_id?: string;
 	budget_id?: string;
@@ -61,6 +87,7 @@ export interface VirtualKey {
 	created_at: string;
 	updated_at: string;
 	// Populated relationships
+	project?: Project;
 	team?: Team;
 	customer?: Customer;
 	budget?: Budget;
@@ -68,6 +95,15 @@ export interface VirtualKey {
 	keys?: DBKey[]; // Associated database keys
 }
 
+// ParameterGuardrails limit the inference parameters of the requests of a virtual key
+export interface ParameterGuardrails {
+	mode?: "hard" | "soft"; // hard (default) rejects violations with 400, soft clamps them
+	max_temperature?: number;
+	max_tokens?: number;
+	disallow_tools?: boolean;
+	response_format?: "text" | "json_object";
+}
+
 export interface VirtualKeyProviderConfig {
 	id?: number;
 	provider: string;
@@ -90,55 +126,83 @@ export interface CreateVirtualKeyRequest {
 	name: string;
 	description?: string;
 	provider_configs?: VirtualKeyProviderConfig[];
+	project_id?: string; // Mutually exclusive with team_id and customer_id
 	team_id?: string;
 	customer_id?: string;
 	budget?: CreateBudgetRequest;
 	rate_limit?: CreateRateLimitRequest;
 	key_ids?: string[]; // List of DBKey UUIDs to associate
 	is_active?: boolean;
+	parameter_guardrails?: ParameterGuardrails; // An empty object removes the guardrails on update
 }
 
 export interface UpdateVirtualKeyRequest {
 	description?: string;
 	provider_configs?: VirtualKeyProviderConfig[];
+	project_id?: string; // Mutually exclusive with team_id and customer_id
 	team_id?: string;
 	customer_id?: string;
 	budget?: UpdateBudgetRequest;
 	rate_limit?: UpdateRateLimitRequest;
 	key_ids?: string[]; // List of DBKey UUIDs to associate
 	is_active?: boolean;
+	parameter_guardrails?: ParameterGuardrails; // An empty object removes the guardrails on update
 }
 
 export interface CreateTeamRequest {
 	name: string;
 	customer_id?: string;
 	budget?: CreateBudgetRequest;
+	rate_limit?: CreateRateLimitRequest;
 }
 
 export interface UpdateTeamRequest {
 	name?: string;
 	customer_id?: string;
 	budget?: UpdateBudgetRequest;
+	rate_limit?: UpdateRateLimitRequest;
+}
+
+export interface CreateProjectRequest {
+	name: string;
+	team_id?: string;
+	budget?: CreateBudgetRequest;
+	rate_limit?: CreateRateLimitRequest;
+}
+
+export interface UpdateProjectRequest {
+	name?: string;
+	team_id?: string;
+	budget?: UpdateBudgetRequest;
+	rate_limit?: UpdateRateLimitRequest;
 }
 
 export interface CreateCustomerRequest {
 	name: string;
 	budget?: CreateBudgetRequest;
+	rate_limit?: CreateRateLimitRequest;
 }
 
 export interface UpdateCustomerRequest {
 	name?: string;
 	budget?: UpdateBudgetRequest;
+	rate_limit?: UpdateRateLimitRequest;
 }
 
 export interface CreateBudgetRequest {
 	max_limit: number; // In dollars
-	reset_duration: string; // e.g., "30s", "5m", "1h", "1d", "1w", "1M"
+	reset_duration?: string; // e.g., "30s", "5m", "1h", "1d", "1w", "1M"; required for rolling budgets
+	reset_schedule?: string;
+	rollover?: boolean;
+	rollover_cap?: number;
 }
 
 export interface UpdateBudgetRequest {
 	max_limit?: number;
 	reset_duration?: string;
+	reset_schedule?: string;
+	rollover?: boolean;
+	rollover_cap?: number;
 }
 
 export interface CreateRateLimitRequest {
@@ -172,6 +236,11 @@ export interface GetTeamsResponse {
 	count: number;
 }
 
+export interface GetProjectsResponse {
+	projects: Project[];
+	count: number;
+}
+
 export interface GetCustomersResponse {
 	customers: Customer[];
 	count: number;
@@ -218,3 +287,23 @@ export interface HealthCheckResponse {
 		}
 	>;
 }
+
+export type GovernanceLevel = "customer" | "team" | "project" | "virtual_key";
+
+// Usage of one entity of the governance hierarchy, aggregated over everything below it
+export interface LevelUsage {
+	level: GovernanceLevel;
+	id: string;
+	name: string;
+	parent_level?: GovernanceLevel;
+	parent_id?: string;
+	spend: number; // Dollars spent in the current budget period
+	virtual_keys: number; // Virtual keys at or below the entity
+	budget?: Budget;
+	rate_limit?: RateLimit;
+}
+
+export interface GetGovernanceUsageResponse {
+	usage: LevelUsage[];
+	count: number;
+}
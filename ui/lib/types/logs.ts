@@ -175,7 +175,8 @@ export interface ModelParameters {
 
 // Token usage types
 export interface TokenDetails {
-	cached_tokens?: number;
+	cached_tokens?: number; // Prompt tokens read from the provider's prompt cache
+	cache_creation_tokens?: number; // Prompt tokens written to the provider's prompt cache
 	audio_tokens?: number;
 }
 
@@ -245,6 +246,12 @@ export interface LogEntry {
 	timestamp: string; // ISO string format from Go time.Time
 	provider: string;
 	model: string;
+	customer?: string; // End customer the request is billed to
+	retention_class?: string; // "zero_data_retention" when the request content was not stored
+	key_id?: string; // Provider key that served the request
+	cache_savings?: number; // Dollars saved by the provider's prompt cache
+	language?: string; // ISO 639-1 code of the prompt language
+	labels?: Record<string, string>; // Labels attributing the request, e.g. to a feature or environment
 	input_history: ChatMessage[];
 	output_message?: ChatMessage;
 	embedding_output?: BifrostEmbedding[];
@@ -256,6 +263,8 @@ export interface LogEntry {
 	tools?: Tool[];
 	tool_calls?: ToolCall[];
 	latency?: number;
+	time_to_first_token?: number; // Streaming only, in milliseconds
+	tokens_per_second?: number; // Streaming only, completion tokens per second
 	token_usage?: LLMUsage;
 	cache_debug?: CacheDebug;
 	cost?: number; // Cost in dollars (total cost of the request - includes cache lookup cost)
@@ -278,6 +287,9 @@ export interface LogFilters {
 	min_tokens?: number;
 	max_tokens?: number;
 	content_search?: string;
+	customers?: string[];
+	languages?: string[];
+	labels?: Record<string, string>;
 }
 
 export interface Pagination {
@@ -295,6 +307,20 @@ export interface LogStats {
 	total_cost: number;
 }
 
+// Metrics of the last minute of requests, pushed by /api/stream/metrics
+export interface StreamMetrics {
+	timestamp: string;
+	window_seconds: number;
+	requests: number;
+	errors: number;
+	error_rate: number;
+	requests_per_second: number;
+	avg_latency: number;
+	p95_latency: number;
+	total_tokens: number;
+	total_cost: number;
+}
+
 export interface LogsResponse {
 	logs: LogEntry[];
 	pagination: Pagination;
@@ -0,0 +1,46 @@
+// Moderation event types matching the Go backend (framework/moderation)
+
+export type ModerationStage = "input" | "output";
+export type ModerationAction = "block" | "flag" | "log" | "hold";
+export type ReviewStatus = "pending" | "approved" | "denied";
+
+export interface ModerationEvent {
+	id: string;
+	request_id: string;
+	stage: ModerationStage;
+	action: ModerationAction;
+	policy: string;
+	virtual_key?: string;
+	provider?: string;
+	model?: string;
+	content: string;
+	scores: Record<string, number>;
+	flagged: string[];
+	created_at: string;
+	review_status?: ReviewStatus;
+	reviewer?: string;
+	review_note?: string;
+	reviewed_at?: string;
+}
+
+export interface ModerationEventFilters {
+	stage?: ModerationStage;
+	action?: ModerationAction;
+	virtual_key?: string;
+	review_status?: ReviewStatus;
+	limit?: number;
+	offset?: number;
+}
+
+export interface ModerationEventsResponse {
+	events: ModerationEvent[];
+	count: number;
+	total: number;
+}
+
+export interface ReviewModerationEventRequest {
+	id: string;
+	status?: Exclude<ReviewStatus, "pending">;
+	reviewer?: string;
+	note?: string;
+}
@@ -0,0 +1,68 @@
+// Playground types matching the Go backend (transports/bifrost-http/handlers/playground.go)
+
+export type PipelineTraceStage = "pre_hook" | "provider" | "fallback" | "post_hook";
+
+export interface PipelineTraceStep {
+	stage: PipelineTraceStage;
+	plugin?: string;
+	provider?: string;
+	model?: string;
+	key_id?: string;
+	attempt?: number;
+	decision?: "short_circuit" | "rerouted" | "recovered" | "failed";
+	error?: string;
+	changes?: TraceChange[]; // Changes of the pre-hook to the request
+	usage?: { prompt_tokens: number; completion_tokens: number; total_tokens: number };
+	latency: number; // Nanoseconds
+}
+
+// A value changed at a path of the JSON form of the request; old is absent for added values and new for removed ones
+export interface TraceChange {
+	path: string;
+	old?: unknown;
+	new?: unknown;
+}
+
+export interface UpstreamExchange {
+	method: string;
+	url: string;
+	request_headers: Record<string, string>;
+	request_body?: string; // Base64
+	status_code?: number;
+	response_headers?: Record<string, string>;
+	response_body?: string; // Base64
+	latency: number; // Nanoseconds
+	error?: string;
+}
+
+export interface PipelineTrace {
+	steps: PipelineTraceStep[];
+	upstream: UpstreamExchange[];
+	truncated?: boolean;
+}
+
+export interface PlaygroundMessage {
+	role: "system" | "user" | "assistant";
+	content: string;
+}
+
+export interface PlaygroundChatRequest {
+	provider: string;
+	model: string;
+	key_id?: string;
+	messages: PlaygroundMessage[];
+	params?: { max_completion_tokens?: number; temperature?: number };
+	max_cost?: number;
+}
+
+export interface PlaygroundChatResponse {
+	response?: {
+		choices?: { message?: { content?: string | null } }[];
+		usage?: { prompt_tokens: number; completion_tokens: number; total_tokens: number };
+	};
+	error?: { status_code?: number; error?: { message: string } };
+	max_tokens: number;
+	estimated_cost?: number;
+	cost?: number;
+	trace: PipelineTrace;
+}
@@ -224,6 +224,7 @@ export const allowedRequestsSchema = z.object({
 	chat_completion: z.boolean(),
 	chat_completion_stream: z.boolean(),
 	embedding: z.boolean(),
+	rerank: z.boolean(),
 	speech: z.boolean(),
 	speech_stream: z.boolean(),
 	transcription: z.boolean(),
@@ -232,8 +233,9 @@ export const allowedRequestsSchema = z.object({
 
 // Custom provider config schema
 export const customProviderConfigSchema = z.object({
-	base_provider_type: knownProviderSchema,
+	base_provider_type: z.union([knownProviderSchema, z.literal("declarative")]),
 	allowed_requests: allowedRequestsSchema.optional(),
+	spec: z.record(z.string(), z.any()).optional(),
 });
 
 // Form-specific custom provider config schema
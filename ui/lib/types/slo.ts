@@ -0,0 +1,36 @@
+// SLO types matching the Go backend (transports/bifrost-http/lib/slo.go)
+
+export type SLOIndicator = "ttft" | "latency" | "availability";
+
+export interface SLOBurnRateAlert {
+	severity: string;
+	long_window_minutes: number;
+	short_window_minutes: number;
+	burn_rate: number;
+}
+
+export interface SLOAlertState extends SLOBurnRateAlert {
+	firing: boolean;
+	since?: string;
+}
+
+export interface SLOStatus {
+	name: string;
+	description?: string;
+	indicator: SLOIndicator;
+	threshold_ms?: number;
+	target: number;
+	period_days: number;
+	model?: string;
+	total: number;
+	good: number;
+	compliance: number;
+	error_budget_remaining: number;
+	burn_rates: Record<string, number>;
+	alerts: SLOAlertState[];
+}
+
+export interface SLOStatusResponse {
+	enabled: boolean;
+	objectives: SLOStatus[];
+}
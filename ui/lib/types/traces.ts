@@ -0,0 +1,44 @@
+// Request trace types matching the Go backend (transports/bifrost-http/lib/traces.go)
+
+import { PipelineTrace, TraceChange } from "./playground";
+
+export interface MiddlewareTiming {
+	name: string;
+	before: number; // Nanoseconds until it called the next handler
+	after: number; // Nanoseconds once the next handler returned
+	stopped?: boolean; // Answered without calling the next handler
+	changes?: TraceChange[];
+}
+
+export interface InterceptorMutation {
+	plugin: string;
+	headers?: TraceChange[];
+	body?: TraceChange[];
+	error?: string;
+	latency: number; // Nanoseconds
+}
+
+export interface TraceSummary {
+	middleware_latency: number;
+	plugin_latency: number;
+	provider_latency: number;
+	attempts: number;
+	fallbacks: number;
+	stopped_by?: string;
+	provider?: string;
+	model?: string;
+	usage?: { prompt_tokens: number; completion_tokens: number; total_tokens: number };
+}
+
+export interface RequestTimeline {
+	request_id: string;
+	method: string;
+	path: string;
+	started_at: string;
+	duration: number; // Nanoseconds
+	status_code: number;
+	summary: TraceSummary;
+	middlewares: MiddlewareTiming[];
+	interceptors?: InterceptorMutation[];
+	pipeline: PipelineTrace;
+}
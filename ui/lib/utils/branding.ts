@@ -0,0 +1,42 @@
+/**
+ * Branding utility - the dashboard served on a tenant domain shows the tenant's name and logos
+ *
+ * The server injects the branding of tenant domains into every page as window.__BIFROST_BRANDING__
+ * (see transports/bifrost-http/handlers/tenantdomains.go).
+ */
+
+import { TenantBranding } from "@/lib/types/auth";
+
+declare global {
+	interface Window {
+		__BIFROST_BRANDING__?: TenantBranding;
+	}
+}
+
+/**
+ * Get the branding of the tenant domain the dashboard is served on, undefined outside tenant domains
+ */
+export function getTenantBranding(): TenantBranding | undefined {
+	if (typeof window === "undefined") {
+		return undefined;
+	}
+	return window.__BIFROST_BRANDING__;
+}
+
+/**
+ * Get the product name shown in the dashboard
+ */
+export function getBrandName(): string {
+	return getTenantBranding()?.name || "Bifrost";
+}
+
+/**
+ * Get the logo for a theme, falling back to the Bifrost logos
+ */
+export function getBrandLogo(dark: boolean): string {
+	const branding = getTenantBranding();
+	if (dark) {
+		return branding?.logo_dark_url || branding?.logo_url || "/bifrost-logo-dark.png";
+	}
+	return branding?.logo_url || "/bifrost-logo.png";
+}
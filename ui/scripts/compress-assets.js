@@ -0,0 +1,61 @@
+#!/usr/bin/env node
+
+// Writes brotli (.br) and gzip (.gz) variants next to the text assets of the static export, served by the
+// embedded dashboard handler to clients accepting them.
+
+const fs = require("fs");
+const path = require("path");
+const zlib = require("zlib");
+
+const OUT_DIR = path.join(__dirname, "..", "out");
+const EXTENSIONS = new Set([".html", ".js", ".css", ".txt", ".json", ".svg", ".map"]);
+const MIN_SIZE = 1024; // Smaller files gain little from compression
+
+function compressFile(filePath) {
+	const content = fs.readFileSync(filePath);
+	const brotli = zlib.brotliCompressSync(content, {
+		params: {
+			[zlib.constants.BROTLI_PARAM_QUALITY]: zlib.constants.BROTLI_MAX_QUALITY,
+			[zlib.constants.BROTLI_PARAM_SIZE_HINT]: content.length,
+		},
+	});
+	const gzip = zlib.gzipSync(content, { level: zlib.constants.Z_BEST_COMPRESSION });
+	// Variants that are not smaller than the original are left out
+	if (brotli.length < content.length) {
+		fs.writeFileSync(`${filePath}.br`, brotli);
+	}
+	if (gzip.length < content.length) {
+		fs.writeFileSync(`${filePath}.gz`, gzip);
+	}
+	return { original: content.length, brotli: brotli.length };
+}
+
+function walk(dir, files = []) {
+	for (const entry of fs.readdirSync(dir, { withFileTypes: true })) {
+		const entryPath = path.join(dir, entry.name);
+		if (entry.isDirectory()) {
+			walk(entryPath, files);
+		} else if (EXTENSIONS.has(path.extname(entry.name)) && fs.statSync(entryPath).size >= MIN_SIZE) {
+			files.push(entryPath);
+		}
+	}
+	return files;
+}
+
+function main() {
+	if (!fs.existsSync(OUT_DIR)) {
+		console.error(`Output directory not found: ${OUT_DIR}`);
+		process.exit(1);
+	}
+	let original = 0;
+	let compressed = 0;
+	const files = walk(OUT_DIR);
+	for (const file of files) {
+		const sizes = compressFile(file);
+		original += sizes.original;
+		compressed += Math.min(sizes.brotli, sizes.original);
+	}
+	console.log(`Compressed ${files.length} assets: ${(original / 1024).toFixed(0)}KB -> ${(compressed / 1024).toFixed(0)}KB (brotli)`);
+}
+
+main();